@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"os/exec"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 // newCmdPool gets the current status of the AWS Account Operator AccountPool
@@ -18,8 +18,8 @@ func newCmdPool(streams genericclioptions.IOStreams, flags *genericclioptions.Co
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
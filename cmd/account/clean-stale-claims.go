@@ -0,0 +1,314 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/osdctl/pkg/checkpoint"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+)
+
+const (
+	cleanStaleClaimsLong = `Find the AccountClaims stuck in a state other than Ready for longer than --age and delete them.
+
+This command should be run against the hive cluster. The age of a claim is counted from its last condition transition,
+or from its creation when it has no condition. The report gives the reason of every stale claim, from its latest
+condition. Claims already being deleted are left to the aws-account-operator, unless --remove-finalizers is set.
+
+The cleaned claims are saved to a checkpoint, so that re-running an interrupted or partly failed clean only retries
+the remaining claims. Pass --restart to start over.`
+	cleanStaleClaimsExample = `
+  # Report the claims stuck for more than 30 days without changing anything
+  osdctl account clean-stale-claims --age 30d --dry-run
+
+  # Delete the claims in Error for more than a week
+  osdctl account clean-stale-claims --age 7d --state Error`
+
+	claimActionDelete           = "delete"
+	claimActionRemoveFinalizers = "remove-finalizers"
+	claimActionSkip             = "skip"
+)
+
+// newCmdCleanStaleClaims implements the clean-stale-claims command which deletes the AccountClaims stuck in a non-Ready state
+func newCmdCleanStaleClaims(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, client client.Client) *cobra.Command {
+	ops := newCleanStaleClaimsOptions(streams, flags, client)
+	cleanStaleClaimsCmd := &cobra.Command{
+		Use:               "clean-stale-claims",
+		Short:             "Delete the AccountClaims stuck in a non-Ready state",
+		Long:              cleanStaleClaimsLong,
+		Example:           cleanStaleClaimsExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	flagtypes.DurationVar(cleanStaleClaimsCmd.Flags(), &ops.age, "age", 30*24*time.Hour, time.Hour, "Minimum time a claim has been stuck, e.g. 30d or 12h")
+	cleanStaleClaimsCmd.Flags().StringSliceVar(&ops.states, "state", nil, "Only clean the claims in these states (Pending, Error, or empty for the claims never reconciled), all non-Ready states by default")
+	cleanStaleClaimsCmd.Flags().BoolVar(&ops.dryRun, "dry-run", false, "Print the report without deleting anything")
+	cleanStaleClaimsCmd.Flags().BoolVar(&ops.removeFinalizers, "remove-finalizers", false, "Remove the finalizers of the stale claims already being deleted")
+	cleanStaleClaimsCmd.Flags().BoolVarP(&ops.skipPrompt, "yes", "y", false, "Skip the confirmation prompt")
+	ops.checkpoint.AddFlags(cleanStaleClaimsCmd)
+
+	return cleanStaleClaimsCmd
+}
+
+// cleanStaleClaimsOptions defines the struct for running the clean-stale-claims command
+type cleanStaleClaimsOptions struct {
+	age              time.Duration
+	states           []string
+	dryRun           bool
+	removeFinalizers bool
+	skipPrompt       bool
+	checkpoint       checkpoint.Flags
+
+	// now is overridden by tests
+	now func() time.Time
+
+	flags *genericclioptions.ConfigFlags
+	genericclioptions.IOStreams
+	kubeCli client.Client
+}
+
+// staleClaim is an AccountClaim stuck for longer than the age threshold
+type staleClaim struct {
+	claim  *awsv1alpha1.AccountClaim
+	state  string
+	age    time.Duration
+	reason string
+	action string
+}
+
+func newCleanStaleClaimsOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, client client.Client) *cleanStaleClaimsOptions {
+	return &cleanStaleClaimsOptions{
+		now:       time.Now,
+		flags:     flags,
+		IOStreams: streams,
+		kubeCli:   client,
+	}
+}
+
+func (o *cleanStaleClaimsOptions) complete(cmd *cobra.Command, _ []string) error {
+	for _, state := range o.states {
+		switch awsv1alpha1.ClaimStatus(state) {
+		case "", awsv1alpha1.ClaimStatusPending, awsv1alpha1.ClaimStatusError:
+		case awsv1alpha1.ClaimStatusReady:
+			return cmdutil.UsageErrorf(cmd, "Ready claims aren't stale, use another --state")
+		default:
+			return cmdutil.UsageErrorf(cmd, "unsupported account claim state "+state)
+		}
+	}
+
+	return nil
+}
+
+func (o *cleanStaleClaimsOptions) run() error {
+	ctx := context.TODO()
+	var claims awsv1alpha1.AccountClaimList
+	if err := o.kubeCli.List(ctx, &claims, &client.ListOptions{}); err != nil {
+		return err
+	}
+
+	stale := o.findStaleClaims(claims.Items)
+	if err := printStaleClaims(o.Out, stale); err != nil {
+		return err
+	}
+
+	toClean := 0
+	for _, s := range stale {
+		if s.action != claimActionSkip {
+			toClean++
+		}
+	}
+	if toClean == 0 || o.dryRun {
+		fmt.Fprintf(o.ErrOut, "%d stale account claims, %d to clean\n", len(stale), toClean)
+		return nil
+	}
+
+	if err := utils.Confirm(utils.ConfirmOptions{
+		Summary:    &utils.ImpactSummary{Action: fmt.Sprintf("clean %d stale account claims", toClean)},
+		SkipPrompt: o.skipPrompt,
+		In:         o.In,
+		Out:        o.ErrOut,
+	}); err != nil {
+		return err
+	}
+
+	progress, err := o.checkpoint.Open("account clean-stale-claims", flagtypes.FormatDuration(o.age), strings.Join(o.states, ","), strconv.FormatBool(o.removeFinalizers))
+	if err != nil {
+		return err
+	}
+	if progress.Resumed() {
+		fmt.Fprintf(o.ErrOut, "Resuming the clean saved in %s\n", progress.Path())
+	}
+
+	var failed []string
+	for _, s := range stale {
+		key := s.claim.Namespace + "/" + s.claim.Name
+		if s.action == claimActionSkip || progress.Done(key) {
+			continue
+		}
+		err := o.clean(ctx, s)
+		if err != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot clean account claim %s: %v\n", key, err)
+			failed = append(failed, key)
+		}
+		if saveErr := progress.Record(key, err); saveErr != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot save the progress to %s: %q\n", progress.Path(), saveErr)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(o.ErrOut, "Run the same command again to retry the failed claims, progress saved in %s\n", progress.Path())
+		return fmt.Errorf("cannot clean %d account claims: %s", len(failed), strings.Join(failed, ", "))
+	}
+	if err := progress.Complete(); err != nil {
+		fmt.Fprintf(o.ErrOut, "Cannot remove the checkpoint %s: %q\n", progress.Path(), err)
+	}
+	return nil
+}
+
+func (o *cleanStaleClaimsOptions) clean(ctx context.Context, s staleClaim) error {
+	switch s.action {
+	case claimActionDelete:
+		fmt.Fprintf(o.ErrOut, "Deleting account claim %s/%s\n", s.claim.Namespace, s.claim.Name)
+		if err := o.kubeCli.Delete(ctx, s.claim); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	case claimActionRemoveFinalizers:
+		fmt.Fprintf(o.ErrOut, "Removing the finalizers of account claim %s/%s\n", s.claim.Namespace, s.claim.Name)
+		patch := client.MergeFrom(s.claim.DeepCopy())
+		s.claim.Finalizers = nil
+		if err := o.kubeCli.Patch(ctx, s.claim, patch); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// findStaleClaims returns the claims in the selected states stuck for longer than the age threshold, oldest first
+func (o *cleanStaleClaimsOptions) findStaleClaims(claims []awsv1alpha1.AccountClaim) []staleClaim {
+	now := o.now()
+	var stale []staleClaim
+	for i := range claims {
+		claim := &claims[i]
+		state := string(claim.Status.State)
+		deleting := claim.DeletionTimestamp != nil
+		if claim.Status.State == awsv1alpha1.ClaimStatusReady && !deleting {
+			continue
+		}
+		if len(o.states) > 0 && !contains(o.states, state) {
+			continue
+		}
+
+		since := claimStuckSince(claim)
+		if now.Sub(since) < o.age {
+			continue
+		}
+
+		s := staleClaim{claim: claim, state: state, age: now.Sub(since), reason: claimReason(claim), action: claimActionDelete}
+		if deleting {
+			s.action = claimActionSkip
+			if o.removeFinalizers {
+				s.action = claimActionRemoveFinalizers
+			}
+		}
+		stale = append(stale, s)
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].age > stale[j].age
+	})
+	return stale
+}
+
+// claimStuckSince returns when the claim was last changed: its deletion, its last condition transition or its creation
+func claimStuckSince(claim *awsv1alpha1.AccountClaim) time.Time {
+	if claim.DeletionTimestamp != nil {
+		return claim.DeletionTimestamp.Time
+	}
+	since := claim.CreationTimestamp.Time
+	for _, condition := range claim.Status.Conditions {
+		if condition.LastTransitionTime.After(since) {
+			since = condition.LastTransitionTime.Time
+		}
+	}
+	return since
+}
+
+// claimReason explains why the claim is stuck from its deletion or its latest true condition
+func claimReason(claim *awsv1alpha1.AccountClaim) string {
+	if claim.DeletionTimestamp != nil {
+		return fmt.Sprintf("deletion blocked by the finalizers %s", strings.Join(claim.Finalizers, ", "))
+	}
+
+	var latest *awsv1alpha1.AccountClaimCondition
+	for i := range claim.Status.Conditions {
+		condition := &claim.Status.Conditions[i]
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		if latest == nil || condition.LastTransitionTime.After(latest.LastTransitionTime.Time) {
+			latest = condition
+		}
+	}
+	switch {
+	case latest != nil && latest.Message != "":
+		return fmt.Sprintf("%s: %s", latest.Type, latest.Message)
+	case latest != nil && latest.Reason != "":
+		return fmt.Sprintf("%s: %s", latest.Type, latest.Reason)
+	case latest != nil:
+		return string(latest.Type)
+	case claim.Status.State == "":
+		return "never reconciled"
+	}
+	return "no condition set"
+}
+
+func printStaleClaims(out io.Writer, stale []staleClaim) error {
+	p := printer.NewTablePrinter(out, 20, 1, 3, ' ')
+	p.AddRow([]string{"Namespace", "Name", "State", "Stuck For", "Action", "Reason"})
+	for _, s := range stale {
+		state := s.state
+		if state == "" {
+			state = "<none>"
+		}
+		p.AddRow([]string{
+			s.claim.Namespace,
+			s.claim.Name,
+			state,
+			duration.HumanDuration(s.age),
+			s.action,
+			s.reason,
+		})
+	}
+	// Add empty row for readability
+	p.AddRow([]string{})
+	return p.Flush()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
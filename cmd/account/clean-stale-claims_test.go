@@ -0,0 +1,114 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testClaim(name string, state awsv1alpha1.ClaimStatus, created time.Time, conditions ...awsv1alpha1.AccountClaimCondition) *awsv1alpha1.AccountClaim {
+	return &awsv1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "uhc-production-" + name,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Status: awsv1alpha1.AccountClaimStatus{State: state, Conditions: conditions},
+	}
+}
+
+func TestCleanStaleClaims(t *testing.T) {
+	g := NewGomegaWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(awsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := now.Add(-60 * 24 * time.Hour)
+	failed := testClaim("failed", awsv1alpha1.ClaimStatusError, old, awsv1alpha1.AccountClaimCondition{
+		Type:               awsv1alpha1.AccountClaimFailed,
+		Status:             corev1.ConditionTrue,
+		Message:            "no account available",
+		LastTransitionTime: metav1.NewTime(old),
+	})
+	// Recently failed again, it isn't stuck for long enough
+	retried := testClaim("retried", awsv1alpha1.ClaimStatusError, old, awsv1alpha1.AccountClaimCondition{
+		Type:               awsv1alpha1.ClientError,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(now.Add(-time.Hour)),
+	})
+	unreconciled := testClaim("unreconciled", "", old)
+	ready := testClaim("ready", awsv1alpha1.ClaimStatusReady, old)
+	deleting := testClaim("deleting", awsv1alpha1.ClaimStatusPending, old)
+	deleting.DeletionTimestamp = &metav1.Time{Time: old}
+	deleting.Finalizers = []string{"finalizer.aws.managed.openshift.com"}
+
+	kubeCli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(failed, retried, unreconciled, ready, deleting).Build()
+	var out, errOut bytes.Buffer
+	ops := newCleanStaleClaimsOptions(genericclioptions.IOStreams{In: strings.NewReader(""), Out: &out, ErrOut: &errOut}, nil, kubeCli)
+	ops.now = func() time.Time { return now }
+	ops.age = 30 * 24 * time.Hour
+
+	var claims awsv1alpha1.AccountClaimList
+	g.Expect(kubeCli.List(context.TODO(), &claims)).To(Succeed())
+	stale := ops.findStaleClaims(claims.Items)
+	reasons := map[string]string{}
+	actions := map[string]string{}
+	for _, s := range stale {
+		reasons[s.claim.Name] = s.reason
+		actions[s.claim.Name] = s.action
+	}
+	g.Expect(reasons).To(Equal(map[string]string{
+		"failed":       "AccountClaimFailed: no account available",
+		"unreconciled": "never reconciled",
+		"deleting":     "deletion blocked by the finalizers finalizer.aws.managed.openshift.com",
+	}))
+	g.Expect(actions["deleting"]).To(Equal(claimActionSkip))
+
+	ops.states = []string{string(awsv1alpha1.ClaimStatusError)}
+	ops.skipPrompt = true
+	ops.checkpoint.Path = filepath.Join(t.TempDir(), "checkpoint.json")
+	g.Expect(ops.run()).To(Succeed())
+	_, err := os.Stat(ops.checkpoint.Path)
+	g.Expect(os.IsNotExist(err)).To(BeTrue(), "the checkpoint of a complete clean is removed")
+	g.Expect(out.String()).To(ContainSubstring("no account available"))
+
+	err = kubeCli.Get(context.TODO(), client.ObjectKeyFromObject(failed), &awsv1alpha1.AccountClaim{})
+	g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	g.Expect(kubeCli.Get(context.TODO(), client.ObjectKeyFromObject(unreconciled), &awsv1alpha1.AccountClaim{})).To(Succeed())
+	g.Expect(kubeCli.Get(context.TODO(), client.ObjectKeyFromObject(retried), &awsv1alpha1.AccountClaim{})).To(Succeed())
+}
+
+func TestCleanStaleClaimsDryRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(awsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	pending := testClaim("pending", awsv1alpha1.ClaimStatusPending, now.Add(-40*24*time.Hour))
+	kubeCli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pending).Build()
+
+	var out, errOut bytes.Buffer
+	ops := newCleanStaleClaimsOptions(genericclioptions.IOStreams{In: strings.NewReader(""), Out: &out, ErrOut: &errOut}, nil, kubeCli)
+	ops.now = func() time.Time { return now }
+	ops.age = 30 * 24 * time.Hour
+	ops.dryRun = true
+
+	g.Expect(ops.run()).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("pending"))
+	g.Expect(errOut.String()).To(ContainSubstring("1 stale account claims, 1 to clean"))
+	g.Expect(kubeCli.Get(context.TODO(), client.ObjectKeyFromObject(pending), &awsv1alpha1.AccountClaim{})).To(Succeed())
+}
@@ -3,6 +3,8 @@ package account
 import (
 	"fmt"
 
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -21,12 +23,13 @@ func newCmdCleanVeleroSnapshots(streams genericclioptions.IOStreams) *cobra.Comm
 	ops := newCleanVeleroSnapshotsOptions(streams)
 	cleanCmd := &cobra.Command{
 		Use:               "clean-velero-snapshots",
+		Annotations:       map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
 		Short:             "Cleans up S3 buckets whose name start with managed-velero",
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -48,6 +51,9 @@ type cleanVeleroSnapshotsOptions struct {
 	profile         string
 	region          string
 
+	// awsClient is only created from the flags when it isn't injected, e.g. by tests
+	awsClient awsprovider.Client
+
 	genericclioptions.IOStreams
 }
 
@@ -68,23 +74,22 @@ func (o *cleanVeleroSnapshotsOptions) complete(cmd *cobra.Command, _ []string) e
 }
 
 func (o *cleanVeleroSnapshotsOptions) run() error {
-	var (
-		awsClient awsprovider.Client
-		err       error
-	)
-	if o.accessKeyID == "" && o.secretAccessKey == "" {
-		awsClient, err = awsprovider.NewAwsClient(o.profile, o.region, o.configFile)
-	} else {
-		awsClient, err = awsprovider.NewAwsClientWithInput(&awsprovider.AwsClientInput{
-			AccessKeyID:     o.accessKeyID,
-			SecretAccessKey: o.secretAccessKey,
-			Region:          o.region,
-		})
-	}
+	if o.awsClient == nil {
+		var err error
+		if o.accessKeyID == "" && o.secretAccessKey == "" {
+			o.awsClient, err = awsprovider.NewAwsClient(o.profile, o.region, o.configFile)
+		} else {
+			o.awsClient, err = awsprovider.NewAwsClientWithInput(&awsprovider.AwsClientInput{
+				AccessKeyID:     o.accessKeyID,
+				SecretAccessKey: o.secretAccessKey,
+				Region:          o.region,
+			})
+		}
 
-	if err != nil {
-		return err
+		if err != nil {
+			return err
+		}
 	}
 
-	return awsprovider.DeleteS3BucketsWithPrefix(awsClient, "managed-velero")
+	return awsprovider.DeleteS3BucketsWithPrefix(o.awsClient, "managed-velero")
 }
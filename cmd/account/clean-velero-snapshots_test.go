@@ -5,7 +5,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
@@ -65,3 +69,20 @@ func TestCleanVeleroSnapshotsCmdComplete(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanVeleroSnapshotsCmdRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	mockAWSClient.EXPECT().ListBuckets(gomock.Any()).Return(&s3.ListBucketsOutput{Buckets: []*s3.Bucket{
+		{Name: aws.String("managed-velero-backups")},
+		{Name: aws.String("customer-bucket")},
+	}}, nil)
+	mockAWSClient.EXPECT().ListObjects(&s3.ListObjectsInput{Bucket: aws.String("managed-velero-backups")}).Return(&s3.ListObjectsOutput{}, nil)
+	mockAWSClient.EXPECT().DeleteBucket(&s3.DeleteBucketInput{Bucket: aws.String("managed-velero-backups")}).Return(&s3.DeleteBucketOutput{}, nil)
+
+	o := &cleanVeleroSnapshotsOptions{awsClient: mockAWSClient}
+	g.Expect(o.run()).To(Succeed())
+}
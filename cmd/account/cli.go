@@ -6,10 +6,10 @@ import (
 	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/service/sts"
 	osdCloud "github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 // newCmdCli implements the Cli command which generates temporary STS cli credentials for the specified account cr
@@ -21,8 +21,8 @@ func newCmdCli() *cobra.Command {
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -30,7 +30,7 @@ func newCmdCli() *cobra.Command {
 	cliCmd.Flags().StringVarP(&ops.awsAccountID, "accountId", "i", "", "AWS Account ID")
 	cliCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS Profile")
 	cliCmd.Flags().StringVarP(&ops.output, "output", "o", "", "Output type")
-	cliCmd.Flags().StringVarP(&ops.region, "region", "r", "", "Region")
+	cliCmd.Flags().StringVarP(&ops.region, "region", "r", "", "Region, the one of the cluster with -C, us-east-1 otherwise")
 	cliCmd.Flags().StringVarP(&ops.clusterID, "clusterID", "C", "", "Cluster ID")
 
 	return cliCmd
@@ -68,8 +68,9 @@ func (o *cliOptions) complete(cmd *cobra.Command) error {
 		}
 	}
 
-	if o.region == "" {
-		o.region = "us-east-1"
+	o.region, err = defaultRegion(ocmClient, o.clusterID, o.region)
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -93,12 +94,15 @@ func (o *cliOptions) run() error {
 	// Build the base AWS client using the provide credentials (profile or env vars)
 	awsClient, err := aws.NewAwsClient(o.awsProfile, o.region, "")
 	if err != nil {
-		fmt.Printf("Could not build AWS Client: %s\n", err)
-		return err
+		return fmt.Errorf("could not build AWS Client: %w", err)
 	}
 
-	// Get the right partition for the final ARN
-	partition, err := aws.GetAwsPartition(awsClient)
+	// Get the right partition for the final ARN, the one of the region
+	credentialsPartition, err := aws.GetAwsPartition(awsClient)
+	if err != nil {
+		return err
+	}
+	partition, err := osdCloud.CheckCredentialsPartition(credentialsPartition, o.region)
 	if err != nil {
 		return err
 	}
@@ -106,8 +110,7 @@ func (o *cliOptions) run() error {
 	// Generate a session name using the SRE's kerberos ID
 	sessionName, err := osdCloud.GenerateRoleSessionName(awsClient)
 	if err != nil {
-		fmt.Printf("Could not generate Session Name: %s\n", err)
-		return err
+		return fmt.Errorf("could not generate Session Name: %w", err)
 	}
 
 	var assumedRoleCreds *sts.Credentials
@@ -138,8 +141,7 @@ func (o *cliOptions) run() error {
 		// If the cluster is non-CCS, or an AWS Account ID was provided with -i, try and use OrganizationAccountAccessRole
 		assumedRoleCreds, err = osdCloud.GenerateOrganizationAccountAccessCredentials(awsClient, o.awsAccountID, sessionName, partition)
 		if err != nil {
-			fmt.Printf("Could not build AWS Client for OrganizationAccountAccessRole: %s\n", err)
-			return err
+			return fmt.Errorf("could not build AWS Client for OrganizationAccountAccessRole: %w", err)
 		}
 	}
 
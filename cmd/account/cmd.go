@@ -8,9 +8,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift/osdctl/cmd/account/get"
+	"github.com/openshift/osdctl/cmd/account/iam"
 	"github.com/openshift/osdctl/cmd/account/list"
 	"github.com/openshift/osdctl/cmd/account/mgmt"
 	"github.com/openshift/osdctl/cmd/account/servicequotas"
+	awscmd "github.com/openshift/osdctl/cmd/aws"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
 )
 
@@ -27,11 +29,16 @@ func NewCmdAccount(streams genericclioptions.IOStreams, flags *genericclioptions
 	accountCmd.AddCommand(list.NewCmdList(streams, flags, client, globalOpts))
 	accountCmd.AddCommand(servicequotas.NewCmdServiceQuotas(streams, flags))
 	accountCmd.AddCommand(mgmt.NewCmdMgmt(streams, flags, globalOpts))
+	accountCmd.AddCommand(iam.NewCmdIAM(streams))
 	accountCmd.AddCommand(newCmdReset(streams, flags, client))
 	accountCmd.AddCommand(newCmdSet(streams, flags, client))
 	accountCmd.AddCommand(newCmdConsole())
 	accountCmd.AddCommand(newCmdCli())
+	supportCaseCmd := awscmd.NewCmdSupportCase()
+	supportCaseCmd.Deprecated = "use 'osdctl aws support-case' instead"
+	accountCmd.AddCommand(supportCaseCmd)
 	accountCmd.AddCommand(newCmdCleanVeleroSnapshots(streams))
+	accountCmd.AddCommand(newCmdCleanStaleClaims(streams, flags, client))
 	accountCmd.AddCommand(newCmdVerifySecrets(streams, flags, client))
 	accountCmd.AddCommand(newCmdRotateSecret(streams, flags, client))
 	accountCmd.AddCommand(newCmdGenerateSecret(streams, flags, client))
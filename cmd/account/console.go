@@ -3,14 +3,19 @@ package account
 import (
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/pkg/browser"
 	"github.com/spf13/cobra"
-
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+
 	awsSdk "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	sdk "github.com/openshift-online/ocm-sdk-go"
 	osdCloud "github.com/openshift/osdctl/pkg/osdCloud"
 	"github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/openshift/osdctl/pkg/utils"
@@ -25,18 +30,18 @@ func newCmdConsole() *cobra.Command {
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
 	consoleCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
 	consoleCmd.Flags().BoolVar(&ops.launch, "launch", false, "Launch web browser directly")
-	consoleCmd.Flags().Int64VarP(&ops.consoleDuration, "duration", "d", 3600, "The duration of the console session. "+
-		"Default value is 3600 seconds(1 hour)")
+	flagtypes.DurationVarP(consoleCmd.Flags(), &ops.duration, "duration", "d", time.Hour, minConsoleDuration, "The duration of the console session, "+
+		"either as a duration (e.g. 90m, 2h) or as a number of seconds. Must be between 15m and 12h")
 	consoleCmd.Flags().StringVarP(&ops.awsAccountID, "accountId", "i", "", "AWS Account ID")
 	consoleCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS Profile")
-	consoleCmd.Flags().StringVarP(&ops.region, "region", "r", "", "Region")
+	consoleCmd.Flags().StringVarP(&ops.region, "region", "r", "", "Region, the one of the cluster with -C, us-east-1 otherwise")
 	consoleCmd.Flags().StringVarP(&ops.clusterID, "clusterID", "C", "", "Cluster ID")
 
 	return consoleCmd
@@ -52,14 +57,23 @@ type consoleOptions struct {
 	region       string
 	clusterID    string
 
-	consoleDuration int64
+	duration time.Duration
 }
 
+const (
+	// AWS refuses federated console sessions outside of these bounds
+	minConsoleDuration = 15 * time.Minute
+	maxConsoleDuration = 12 * time.Hour
+)
+
 func newConsoleOptions() *consoleOptions {
 	return &consoleOptions{}
 }
 
 func (o *consoleOptions) complete(cmd *cobra.Command) error {
+	if o.duration > maxConsoleDuration {
+		return cmdutil.UsageErrorf(cmd, "--duration must be at most %s, got %s", maxConsoleDuration, flagtypes.FormatDuration(o.duration))
+	}
 
 	var err error
 
@@ -80,8 +94,9 @@ func (o *consoleOptions) complete(cmd *cobra.Command) error {
 		}
 	}
 
-	if o.region == "" {
-		o.region = "us-east-1"
+	o.region, err = defaultRegion(ocmClient, o.clusterID, o.region)
+	if err != nil {
+		return err
 	}
 
 	return nil
@@ -105,12 +120,15 @@ func (o *consoleOptions) run() error {
 	// Build the base AWS client using the provide credentials (profile or env vars)
 	awsClient, err := aws.NewAwsClient(o.awsProfile, o.region, "")
 	if err != nil {
-		fmt.Printf("Could not build AWS Client: %s\n", err)
-		return err
+		return fmt.Errorf("could not build AWS Client: %w", err)
 	}
 
-	// Get the right partition for the final ARN
-	partition, err := aws.GetAwsPartition(awsClient)
+	// Get the right partition for the final ARN, the one of the region
+	credentialsPartition, err := aws.GetAwsPartition(awsClient)
+	if err != nil {
+		return err
+	}
+	partition, err := osdCloud.CheckCredentialsPartition(credentialsPartition, o.region)
 	if err != nil {
 		return err
 	}
@@ -118,12 +136,11 @@ func (o *consoleOptions) run() error {
 	// Generate a session name using the SRE's kerberos ID
 	sessionName, err := osdCloud.GenerateRoleSessionName(awsClient)
 	if err != nil {
-		fmt.Printf("Could not generate Session Name: %s\n", err)
-		return err
+		return fmt.Errorf("could not generate Session Name: %w", err)
 	}
 
 	// By default, the target role arn is OrganizationAccountAccessRole (works for -i and non-CCS clusters)
-	targetRoleArnString := aws.GenerateRoleARN(o.awsAccountID, osdCloud.OrganizationAccountAccessRole)
+	targetRoleArnString := aws.GenerateRoleARNInPartition(partition, o.awsAccountID, osdCloud.OrganizationAccountAccessRole)
 
 	if isCCS {
 		// If a cluster is provided and it's CCS, the target role is the Managed Support role arn
@@ -160,13 +177,12 @@ func (o *consoleOptions) run() error {
 
 	consoleURL, err := aws.RequestSignInToken(
 		awsClient,
-		&o.consoleDuration,
+		awsSdk.Int64(int64(o.duration.Seconds())),
 		awsSdk.String(sessionName),
 		awsSdk.String(targetRoleArn.String()),
 	)
 	if err != nil {
-		fmt.Printf("Generating console failed: %s\n", err)
-		return err
+		return fmt.Errorf("generating console failed: %w", err)
 	}
 
 	consoleURL, err = PrependRegionToURL(consoleURL, o.region)
@@ -182,6 +198,27 @@ func (o *consoleOptions) run() error {
 	return nil
 }
 
+// defaultRegion returns the region of the cluster in OCM when no region is given, so that the URLs and credentials are
+// of its partition, us-east-1 without a cluster
+func defaultRegion(ocmClient *sdk.Connection, clusterID, region string) (string, error) {
+	if region != "" {
+		return region, nil
+	}
+	if clusterID == "" {
+		return "us-east-1", nil
+	}
+	cluster, err := utils.GetClusterAnyStatus(ocmClient, clusterID)
+	if err != nil {
+		return "", err
+	}
+	if cluster.Region().ID() == "" {
+		return "us-east-1", nil
+	}
+	return cluster.Region().ID(), nil
+}
+
+// PrependRegionToURL makes the console open in the region. The commercial console has a host per region, the consoles
+// of the other partitions take the region as a parameter.
 func PrependRegionToURL(consoleURL, region string) (string, error) {
 	// Extract the url data
 	u, err := url.Parse(consoleURL)
@@ -199,8 +236,14 @@ func PrependRegionToURL(consoleURL, region string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("cannot parse rawDestinationUrl '%s' : %w", rawDestinationUrl, err)
 	}
-	// Prepend the region to the url
-	destinationURL.Host = fmt.Sprintf("%s.%s", region, destinationURL.Host)
+	if aws.PartitionForRegion(region) == endpoints.AwsPartitionID {
+		// Prepend the region to the url
+		destinationURL.Host = fmt.Sprintf("%s.%s", region, destinationURL.Host)
+	} else {
+		destinationValues := destinationURL.Query()
+		destinationValues.Set("region", region)
+		destinationURL.RawQuery = destinationValues.Encode()
+	}
 	prependedDestinationURL := destinationURL.String()
 
 	// override the Destination after it was modified
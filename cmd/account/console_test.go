@@ -0,0 +1,113 @@
+package account
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestConsoleDuration(t *testing.T) {
+	testCases := []struct {
+		title       string
+		input       string
+		expected    string
+		errExpected bool
+	}{
+		{
+			title:    "go duration",
+			input:    "1h",
+			expected: "1h0m0s",
+		},
+		{
+			title:    "compound go duration",
+			input:    "1h30m",
+			expected: "1h30m0s",
+		},
+		{
+			title:    "plain seconds for backwards compatibility",
+			input:    "900",
+			expected: "15m0s",
+		},
+		{
+			title:       "below the minimum",
+			input:       "10m",
+			errExpected: true,
+		},
+		{
+			title:       "not a duration",
+			input:       "forever",
+			errExpected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			cmd := newCmdConsole()
+			err := cmd.Flags().Set("duration", tc.input)
+			if tc.errExpected {
+				if err == nil {
+					t.Fatalf("expected an error for input %s, got none", tc.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error for input %s: %v", tc.input, err)
+			}
+			if result := cmd.Flags().Lookup("duration").Value.String(); result != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, result)
+			}
+		})
+	}
+
+	// Above the maximum
+	o := newConsoleOptions()
+	o.duration = 13 * time.Hour
+	if err := o.complete(newCmdConsole()); err == nil {
+		t.Fatal("expected an error for a duration of 13h, got none")
+	}
+}
+
+func TestPrependRegionToURL(t *testing.T) {
+	testCases := []struct {
+		title       string
+		destination string
+		region      string
+		expected    string
+	}{
+		{
+			title:       "commercial console has a host per region",
+			destination: "https://console.aws.amazon.com/",
+			region:      "eu-west-1",
+			expected:    "https://eu-west-1.console.aws.amazon.com/",
+		},
+		{
+			title:       "GovCloud console takes the region as a parameter",
+			destination: "https://console.amazonaws-us-gov.com/",
+			region:      "us-gov-east-1",
+			expected:    "https://console.amazonaws-us-gov.com/?region=us-gov-east-1",
+		},
+		{
+			title:       "China console takes the region as a parameter",
+			destination: "https://console.amazonaws.cn/",
+			region:      "cn-north-1",
+			expected:    "https://console.amazonaws.cn/?region=cn-north-1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			signInURL := "https://signin.example.com/federation?" + url.Values{"Action": {"login"}, "Destination": {tc.destination}}.Encode()
+			result, err := PrependRegionToURL(signInURL, tc.region)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			u, err := url.Parse(result)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if destination := u.Query().Get("Destination"); destination != tc.expected {
+				t.Fatalf("expected destination %s, got %s", tc.expected, destination)
+			}
+		})
+	}
+}
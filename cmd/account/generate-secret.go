@@ -13,6 +13,7 @@ import (
 	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
@@ -32,8 +33,8 @@ func newCmdGenerateSecret(streams genericclioptions.IOStreams, flags *genericcli
 		Long:              "When logged into a hive shard, this generates a new IAM credential secret for a given IAM user",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 		Aliases: []string{"generate-secrets"},
 	}
@@ -185,7 +186,7 @@ func (o *generateSecretOptions) run() error {
 
 	// if the specified user does not exist, create one
 	if !ok {
-		policyArn := aws.String("arn:aws:iam::aws:policy/AdministratorAccess")
+		policyArn := aws.String(awsprovider.GeneratePolicyARNInPartition(awsprovider.PartitionForRegion(o.region), "aws", "AdministratorAccess"))
 		if err := awsprovider.CreateIAMUserAndAttachPolicy(awsClient,
 			username, policyArn); err != nil {
 			return err
@@ -245,6 +246,12 @@ func (o *generateSecretOptions) generateCcsSecret() error {
 	if err != nil {
 		return err
 	}
+	// The roles and the IAM endpoint are the ones of the partition of the caller, e.g. GovCloud
+	callerArn, err := arn.Parse(aws.StringValue(callerIdentityOutput.Arn))
+	if err != nil {
+		return err
+	}
+	region := awsprovider.GlobalRegion(callerArn.Partition)
 
 	accountIDSuffixLabel, ok := account.Labels["iamUserId"]
 	if !ok {
@@ -274,7 +281,7 @@ func (o *generateSecretOptions) generateCcsSecret() error {
 		AccessKeyID:     *srepRoleCredentials.AccessKeyId,
 		SecretAccessKey: *srepRoleCredentials.SecretAccessKey,
 		SessionToken:    *srepRoleCredentials.SessionToken,
-		Region:          "us-east-1",
+		Region:          region,
 	})
 	if err != nil {
 		return err
@@ -295,13 +302,13 @@ func (o *generateSecretOptions) generateCcsSecret() error {
 		AccessKeyID:     *jumpRoleCreds.AccessKeyId,
 		SecretAccessKey: *jumpRoleCreds.SecretAccessKey,
 		SessionToken:    *jumpRoleCreds.SessionToken,
-		Region:          "us-east-1",
+		Region:          region,
 	})
 	if err != nil {
 		return err
 	}
 	// Role chain to assume ManagedOpenShift-Support-{uid}
-	roleArn := aws.String(fmt.Sprintf("arn:aws:iam::%s:role/%s", account.Spec.AwsAccountID, "ManagedOpenShift-Support-"+accountIDSuffixLabel))
+	roleArn := aws.String(awsprovider.GenerateRoleARNInPartition(callerArn.Partition, account.Spec.AwsAccountID, "ManagedOpenShift-Support-"+accountIDSuffixLabel))
 	credentials, err := awsprovider.GetAssumeRoleCredentials(jumpRoleClient, o.awsAccountTimeout,
 		callerIdentityOutput.UserId, roleArn)
 	if err != nil {
@@ -313,7 +320,7 @@ func (o *generateSecretOptions) generateCcsSecret() error {
 		AccessKeyID:     *credentials.AccessKeyId,
 		SecretAccessKey: *credentials.SecretAccessKey,
 		SessionToken:    *credentials.SessionToken,
-		Region:          "us-east-1",
+		Region:          region,
 	})
 	if err != nil {
 		return err
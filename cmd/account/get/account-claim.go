@@ -9,6 +9,7 @@ import (
 
 	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -25,8 +26,8 @@ func newCmdGetAccountClaim(streams genericclioptions.IOStreams, flags *genericcl
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -134,5 +135,9 @@ func (o *getAccountClaimOptions) run() error {
 		return err
 	}
 
-	return resourcePrinter.PrintObj(&accountClaim, o.Out)
+	out, flush := printer.FilterJSON(printer.Tee(o.Out))
+	if err := resourcePrinter.PrintObj(&accountClaim, out); err != nil {
+		return err
+	}
+	return flush()
 }
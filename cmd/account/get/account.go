@@ -7,6 +7,7 @@ import (
 	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -27,8 +28,8 @@ func newCmdGetAccount(streams genericclioptions.IOStreams, flags *genericcliopti
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -100,7 +101,7 @@ func (o *getAccountOptions) run() error {
 		}
 		// there is no related account
 		if accountClaim.Spec.AccountLink == "" {
-			fmt.Fprintf(o.IOStreams.Out, "Account matched for AccountClaim %s not found\n", o.accountClaimName)
+			fmt.Fprintf(o.IOStreams.ErrOut, "Account matched for AccountClaim %s not found\n", o.accountClaimName)
 			return nil
 		}
 
@@ -125,7 +126,7 @@ func (o *getAccountOptions) run() error {
 			}
 		}
 		if accountCRName == "" {
-			fmt.Fprintf(o.IOStreams.Out, "Account matched for AWS Account ID %s not found\n", o.accountID)
+			fmt.Fprintf(o.IOStreams.ErrOut, "Account matched for AWS Account ID %s not found\n", o.accountID)
 			return nil
 		}
 	}
@@ -140,5 +141,9 @@ func (o *getAccountOptions) run() error {
 		return err
 	}
 
-	return resourcePrinter.PrintObj(account, o.Out)
+	out, flush := printer.FilterJSON(printer.Tee(o.Out))
+	if err := resourcePrinter.PrintObj(account, out); err != nil {
+		return err
+	}
+	return flush()
 }
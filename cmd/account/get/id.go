@@ -7,6 +7,7 @@ import (
 	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -25,8 +26,8 @@ func newCmdGetAWSAccount(streams genericclioptions.IOStreams, flags *genericclio
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -95,7 +96,7 @@ func (o *getAWSAccountOptions) run() error {
 		}
 
 		if accountClaim.Spec.AccountLink == "" {
-			fmt.Fprintf(o.IOStreams.Out, "Account matched for AccountClaim %s not found\n", o.accountClaimName)
+			fmt.Fprintf(o.IOStreams.ErrOut, "Account matched for AccountClaim %s not found\n", o.accountClaimName)
 			return nil
 		}
 
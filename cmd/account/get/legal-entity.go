@@ -11,6 +11,7 @@ import (
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -27,8 +28,8 @@ func newCmdGetLegalEntity(streams genericclioptions.IOStreams, flags *genericcli
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -103,14 +104,14 @@ func (o *getLegalEntityOptions) run() error {
 
 			err := outputflag.PrintResponse(o.output, resp)
 			if err != nil {
-				fmt.Println("Error while printing response: ", err.Error())
+				fmt.Fprintln(o.IOStreams.ErrOut, "Error while printing response: ", err.Error())
 				return err
 			}
 		}
 	}
 
 	// matched account not found
-	_, err := fmt.Fprintf(o.IOStreams.Out, "Account matched for AWS Account ID %s not found\n", o.accountID)
+	_, err := fmt.Fprintf(o.IOStreams.ErrOut, "Account matched for AWS Account ID %s not found\n", o.accountID)
 	if err != nil {
 		return err
 	}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -32,8 +33,8 @@ func newCmdGetSecrets(streams genericclioptions.IOStreams, flags *genericcliopti
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -118,7 +119,11 @@ func (o *getSecretsOptions) run() error {
 			return err
 		}
 
-		return resourcePrinter.PrintObj(&secret, o.Out)
+		out, flush := printer.FilterJSON(printer.Tee(o.Out))
+		if err := resourcePrinter.PrintObj(&secret, out); err != nil {
+			return err
+		}
+		return flush()
 	}
 
 	return nil
@@ -0,0 +1,22 @@
+package iam
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// NewCmdIAM implements commands managing temporary SRE IAM users in cluster accounts
+func NewCmdIAM(streams genericclioptions.IOStreams) *cobra.Command {
+	iamCmd := &cobra.Command{
+		Use:               "iam",
+		Short:             "Manage temporary SRE IAM users in AWS accounts",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+	}
+
+	iamCmd.AddCommand(newCmdCreateUser(streams))
+	iamCmd.AddCommand(newCmdRotateKeys(streams))
+	iamCmd.AddCommand(newCmdSweep())
+
+	return iamCmd
+}
@@ -0,0 +1,148 @@
+package iam
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/timefmt"
+)
+
+const (
+	// userPath groups every user managed by osdctl so they can be listed without scanning the whole account
+	userPath = "/osdctl/"
+
+	managedTagKey = "osdctl-managed"
+	ownerTagKey   = "osdctl-owner"
+	expiryTagKey  = "osdctl-expiry"
+
+	// defaultPolicyName is the AWS managed policy attached to created users, in the partition of the account
+	defaultPolicyName = "ReadOnlyAccess"
+	defaultTTL        = 24 * time.Hour
+	maxTTL            = 7 * 24 * time.Hour
+)
+
+// accountClient builds a client for the target account through OrganizationAccountAccessRole,
+// using the given profile. The session name identifies the SRE and is used as the owner of created users,
+// the partition is the one of the payer account.
+func accountClient(profile, accountID string) (client aws.Client, owner, partition string, err error) {
+	payerClient, err := aws.NewAwsClient(profile, common.DefaultRegion, "")
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	sessionName, err := osdCloud.GenerateRoleSessionName(payerClient)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("could not generate session name: %w", err)
+	}
+
+	partition, err = aws.GetAwsPartition(payerClient)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	client, err = assumeAccountRole(payerClient, partition, accountID, sessionName)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return client, sessionName, partition, nil
+}
+
+func assumeAccountRole(payerClient aws.Client, partition, accountID, sessionName string) (aws.Client, error) {
+	creds, err := osdCloud.GenerateOrganizationAccountAccessCredentials(payerClient, accountID, sessionName, partition)
+	if err != nil {
+		return nil, fmt.Errorf("could not assume OrganizationAccountAccessRole in %s: %w", accountID, err)
+	}
+
+	return aws.NewAwsClientWithInput(&aws.AwsClientInput{
+		AccessKeyID:     *creds.AccessKeyId,
+		SecretAccessKey: *creds.SecretAccessKey,
+		SessionToken:    *creds.SessionToken,
+		Region:          aws.GlobalRegion(partition),
+	})
+}
+
+func userTags(owner string, expiry time.Time) []*iam.Tag {
+	return []*iam.Tag{
+		{Key: awsSdk.String(managedTagKey), Value: awsSdk.String("true")},
+		{Key: awsSdk.String(ownerTagKey), Value: awsSdk.String(owner)},
+		{Key: awsSdk.String(expiryTagKey), Value: awsSdk.String(expiry.UTC().Format(time.RFC3339))},
+	}
+}
+
+// managedUserExpiry returns the expiry of a user created by osdctl, or an error if the user isn't managed by osdctl
+func managedUserExpiry(user *iam.User) (time.Time, error) {
+	var managed bool
+	var expiry string
+	for _, tag := range user.Tags {
+		switch awsSdk.StringValue(tag.Key) {
+		case managedTagKey:
+			managed = awsSdk.StringValue(tag.Value) == "true"
+		case expiryTagKey:
+			expiry = awsSdk.StringValue(tag.Value)
+		}
+	}
+	if !managed {
+		return time.Time{}, fmt.Errorf("user %s is not managed by osdctl", awsSdk.StringValue(user.UserName))
+	}
+
+	parsed, err := time.Parse(time.RFC3339, expiry)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("user %s has an invalid %s tag %q: %w", awsSdk.StringValue(user.UserName), expiryTagKey, expiry, err)
+	}
+	return parsed, nil
+}
+
+// getManagedUser fetches the user including its tags and ensures it was created by osdctl
+func getManagedUser(client aws.Client, username string) (*iam.User, time.Time, error) {
+	output, err := client.GetUser(&iam.GetUserInput{UserName: awsSdk.String(username)})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	expiry, err := managedUserExpiry(output.User)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return output.User, expiry, nil
+}
+
+// deleteManagedUser removes the access keys and policies of the user before deleting it
+func deleteManagedUser(client aws.Client, username string) error {
+	keys, err := client.ListAccessKeys(&iam.ListAccessKeysInput{UserName: awsSdk.String(username)})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys.AccessKeyMetadata {
+		_, err = client.DeleteAccessKey(&iam.DeleteAccessKeyInput{UserName: awsSdk.String(username), AccessKeyId: key.AccessKeyId})
+		if err != nil {
+			return err
+		}
+	}
+
+	policies, err := client.ListAttachedUserPolicies(&iam.ListAttachedUserPoliciesInput{UserName: awsSdk.String(username)})
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies.AttachedPolicies {
+		_, err = client.DetachUserPolicy(&iam.DetachUserPolicyInput{UserName: awsSdk.String(username), PolicyArn: policy.PolicyArn})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = client.DeleteUser(&iam.DeleteUserInput{UserName: awsSdk.String(username)})
+	return err
+}
+
+func printAccessKey(out io.Writer, accountID string, key *iam.AccessKey, expiry time.Time) {
+	fmt.Fprintf(out, "Account:         %s\n", accountID)
+	fmt.Fprintf(out, "User:            %s\n", awsSdk.StringValue(key.UserName))
+	fmt.Fprintf(out, "AccessKeyId:     %s\n", awsSdk.StringValue(key.AccessKeyId))
+	fmt.Fprintf(out, "SecretAccessKey: %s\n", awsSdk.StringValue(key.SecretAccessKey))
+	fmt.Fprintf(out, "Expires:         %s\n", timefmt.Format(expiry))
+}
@@ -0,0 +1,127 @@
+package iam
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func TestManagedUserExpiry(t *testing.T) {
+	g := NewGomegaWithT(t)
+	expiry := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		title       string
+		tags        []*iam.Tag
+		errExpected bool
+	}{
+		{
+			title: "managed user",
+			tags:  userTags("jdoe", expiry),
+		},
+		{
+			title:       "user not created by osdctl",
+			tags:        []*iam.Tag{{Key: awsSdk.String(expiryTagKey), Value: awsSdk.String(expiry.Format(time.RFC3339))}},
+			errExpected: true,
+		},
+		{
+			title: "invalid expiry",
+			tags: []*iam.Tag{
+				{Key: awsSdk.String(managedTagKey), Value: awsSdk.String("true")},
+				{Key: awsSdk.String(expiryTagKey), Value: awsSdk.String("tomorrow")},
+			},
+			errExpected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			parsed, err := managedUserExpiry(&iam.User{UserName: awsSdk.String("user"), Tags: tc.tags})
+			if tc.errExpected {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(parsed.Equal(expiry)).To(BeTrue())
+		})
+	}
+}
+
+func TestFindExpiredUsers(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	client := mock.NewMockClient(mockCtrl)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	client.EXPECT().ListUsers(gomock.Any()).Return(&iam.ListUsersOutput{Users: []*iam.User{
+		{UserName: awsSdk.String("expired")},
+		{UserName: awsSdk.String("valid")},
+	}}, nil)
+	client.EXPECT().GetUser(&iam.GetUserInput{UserName: awsSdk.String("expired")}).Return(&iam.GetUserOutput{
+		User: &iam.User{UserName: awsSdk.String("expired"), Tags: userTags("jdoe", now.Add(-time.Hour))},
+	}, nil)
+	client.EXPECT().GetUser(&iam.GetUserInput{UserName: awsSdk.String("valid")}).Return(&iam.GetUserOutput{
+		User: &iam.User{UserName: awsSdk.String("valid"), Tags: userTags("jdoe", now.Add(time.Hour))},
+	}, nil)
+
+	users, err := findExpiredUsers(client, "123456789012", now)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(users).To(HaveLen(1))
+	g.Expect(users[0].username).To(Equal("expired"))
+	g.Expect(users[0].owner).To(Equal("jdoe"))
+}
+
+func TestRotateKeys(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	client := mock.NewMockClient(mockCtrl)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	client.EXPECT().GetUser(gomock.Any()).Return(&iam.GetUserOutput{
+		User: &iam.User{UserName: awsSdk.String("debug"), Tags: userTags("jdoe", now.Add(time.Hour))},
+	}, nil)
+	client.EXPECT().ListAccessKeys(gomock.Any()).Return(&iam.ListAccessKeysOutput{AccessKeyMetadata: []*iam.AccessKeyMetadata{
+		{AccessKeyId: awsSdk.String("newer"), CreateDate: awsSdk.Time(now.Add(-time.Minute))},
+		{AccessKeyId: awsSdk.String("oldest"), CreateDate: awsSdk.Time(now.Add(-time.Hour))},
+	}}, nil)
+	gomock.InOrder(
+		client.EXPECT().DeleteAccessKey(&iam.DeleteAccessKeyInput{UserName: awsSdk.String("debug"), AccessKeyId: awsSdk.String("oldest")}).Return(&iam.DeleteAccessKeyOutput{}, nil),
+		client.EXPECT().CreateAccessKey(gomock.Any()).Return(&iam.CreateAccessKeyOutput{AccessKey: &iam.AccessKey{AccessKeyId: awsSdk.String("new")}}, nil),
+		client.EXPECT().DeleteAccessKey(&iam.DeleteAccessKeyInput{UserName: awsSdk.String("debug"), AccessKeyId: awsSdk.String("newer")}).Return(&iam.DeleteAccessKeyOutput{}, nil),
+	)
+
+	key, _, err := rotateKeys(client, "debug", now)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(awsSdk.StringValue(key.AccessKeyId)).To(Equal("new"))
+
+	// The new key is returned when the old one can't be deleted, it can't be retrieved again
+	client.EXPECT().GetUser(gomock.Any()).Return(&iam.GetUserOutput{
+		User: &iam.User{UserName: awsSdk.String("debug"), Tags: userTags("jdoe", now.Add(time.Hour))},
+	}, nil)
+	client.EXPECT().ListAccessKeys(gomock.Any()).Return(&iam.ListAccessKeysOutput{AccessKeyMetadata: []*iam.AccessKeyMetadata{
+		{AccessKeyId: awsSdk.String("old"), CreateDate: awsSdk.Time(now.Add(-time.Hour))},
+	}}, nil)
+	gomock.InOrder(
+		client.EXPECT().CreateAccessKey(gomock.Any()).Return(&iam.CreateAccessKeyOutput{AccessKey: &iam.AccessKey{AccessKeyId: awsSdk.String("new")}}, nil),
+		client.EXPECT().DeleteAccessKey(&iam.DeleteAccessKeyInput{UserName: awsSdk.String("debug"), AccessKeyId: awsSdk.String("old")}).Return(nil, errors.New("AccessDenied")),
+	)
+
+	key, _, err = rotateKeys(client, "debug", now)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(awsSdk.StringValue(key.AccessKeyId)).To(Equal("new"))
+
+	// Expired users are left for the sweep
+	client.EXPECT().GetUser(gomock.Any()).Return(&iam.GetUserOutput{
+		User: &iam.User{UserName: awsSdk.String("debug"), Tags: userTags("jdoe", now.Add(-time.Hour))},
+	}, nil)
+	_, _, err = rotateKeys(client, "debug", now)
+	g.Expect(err).To(HaveOccurred())
+}
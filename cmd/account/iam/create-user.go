@@ -0,0 +1,114 @@
+package iam
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const createUserExample = `
+  # Create a read-only user for a day
+  osdctl account iam create-user -i 123456789012 -u jdoe-debug
+
+  # Create a user with a different policy for 4 hours
+  osdctl account iam create-user -i 123456789012 -u jdoe-debug --policy-arn arn:aws:iam::aws:policy/AmazonEC2ReadOnlyAccess --ttl 4h
+`
+
+type createUserOptions struct {
+	accountID  string
+	username   string
+	awsProfile string
+	policyArn  string
+	ttl        time.Duration
+
+	genericclioptions.IOStreams
+}
+
+func newCmdCreateUser(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &createUserOptions{IOStreams: streams}
+	createUserCmd := &cobra.Command{
+		Use:               "create-user",
+		Short:             "Create a temporary IAM user with access keys in an AWS account",
+		Example:           createUserExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	createUserCmd.Flags().StringVarP(&ops.accountID, "account-id", "i", "", "AWS account ID")
+	createUserCmd.Flags().StringVarP(&ops.username, "username", "u", "", "Name of the IAM user to create")
+	createUserCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile")
+	createUserCmd.Flags().StringVar(&ops.policyArn, "policy-arn", "", "Managed policy attached to the user, defaults to ReadOnlyAccess in the partition of the account")
+	createUserCmd.Flags().DurationVar(&ops.ttl, "ttl", defaultTTL, "Time after which the user is removed by 'sweep'")
+	_ = createUserCmd.MarkFlagRequired("account-id")
+	_ = createUserCmd.MarkFlagRequired("username")
+
+	return createUserCmd
+}
+
+func (o *createUserOptions) complete(cmd *cobra.Command) error {
+	if o.ttl <= 0 || o.ttl > maxTTL {
+		return cmdutil.UsageErrorf(cmd, "--ttl must be between 0 and %s", maxTTL)
+	}
+	return nil
+}
+
+func (o *createUserOptions) run() error {
+	client, owner, partition, err := accountClient(o.awsProfile, o.accountID)
+	if err != nil {
+		return err
+	}
+	if o.policyArn == "" {
+		o.policyArn = aws.GeneratePolicyARNInPartition(partition, "aws", defaultPolicyName)
+	}
+
+	expiry := time.Now().Add(o.ttl)
+	key, err := createManagedUser(o.ErrOut, client, o.username, owner, o.policyArn, expiry)
+	if err != nil {
+		return err
+	}
+
+	printAccessKey(o.Out, o.accountID, key, expiry)
+	return nil
+}
+
+// createManagedUser creates a tagged user with the given policy and returns its access key.
+// If attaching the policy or creating the key fails, the user is removed again.
+func createManagedUser(errOut io.Writer, client aws.Client, username, owner, policyArn string, expiry time.Time) (*iam.AccessKey, error) {
+	_, err := client.CreateUser(&iam.CreateUserInput{
+		UserName: awsSdk.String(username),
+		Path:     awsSdk.String(userPath),
+		Tags:     userTags(owner, expiry),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create user %s: %w", username, err)
+	}
+
+	_, err = client.AttachUserPolicy(&iam.AttachUserPolicyInput{
+		UserName:  awsSdk.String(username),
+		PolicyArn: awsSdk.String(policyArn),
+	})
+	if err == nil {
+		var output *iam.CreateAccessKeyOutput
+		output, err = client.CreateAccessKey(&iam.CreateAccessKeyInput{UserName: awsSdk.String(username)})
+		if err == nil {
+			return output.AccessKey, nil
+		}
+	}
+
+	if cleanupErr := deleteManagedUser(client, username); cleanupErr != nil {
+		fmt.Fprintf(errOut, "Could not clean up user %s, it will be removed by 'sweep': %v\n", username, cleanupErr)
+	}
+	return nil, fmt.Errorf("could not set up user %s: %w", username, err)
+}
@@ -0,0 +1,103 @@
+package iam
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+type rotateKeysOptions struct {
+	accountID  string
+	username   string
+	awsProfile string
+
+	genericclioptions.IOStreams
+}
+
+func newCmdRotateKeys(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &rotateKeysOptions{IOStreams: streams}
+	rotateKeysCmd := &cobra.Command{
+		Use:               "rotate-keys",
+		Short:             "Replace the access keys of an IAM user created by 'create-user'",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	rotateKeysCmd.Flags().StringVarP(&ops.accountID, "account-id", "i", "", "AWS account ID")
+	rotateKeysCmd.Flags().StringVarP(&ops.username, "username", "u", "", "Name of the IAM user")
+	rotateKeysCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile")
+	_ = rotateKeysCmd.MarkFlagRequired("account-id")
+	_ = rotateKeysCmd.MarkFlagRequired("username")
+
+	return rotateKeysCmd
+}
+
+func (o *rotateKeysOptions) run() error {
+	client, _, _, err := accountClient(o.awsProfile, o.accountID)
+	if err != nil {
+		return err
+	}
+
+	// The new key is printed even when an old key couldn't be deleted, it can't be retrieved again
+	key, expiry, err := rotateKeys(client, o.username, time.Now())
+	if key != nil {
+		printAccessKey(o.Out, o.accountID, key, expiry)
+	}
+	return err
+}
+
+// rotateKeys creates a new access key for a managed user and deletes the previous ones.
+// Expired users are refused, they are due to be removed by 'sweep'. When an old key can't be deleted, the new key is
+// returned along with the error.
+func rotateKeys(client aws.Client, username string, now time.Time) (*iam.AccessKey, time.Time, error) {
+	_, expiry, err := getManagedUser(client, username)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if now.After(expiry) {
+		return nil, time.Time{}, fmt.Errorf("user %s expired at %s, create a new one instead", username, timefmt.Format(expiry))
+	}
+
+	existing, err := client.ListAccessKeys(&iam.ListAccessKeysInput{UserName: awsSdk.String(username)})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	oldKeys := existing.AccessKeyMetadata
+	sort.Slice(oldKeys, func(i, j int) bool {
+		return awsSdk.TimeValue(oldKeys[i].CreateDate).Before(awsSdk.TimeValue(oldKeys[j].CreateDate))
+	})
+
+	// IAM allows two keys per user, make room for the new one
+	if len(oldKeys) >= 2 {
+		_, err = client.DeleteAccessKey(&iam.DeleteAccessKeyInput{UserName: awsSdk.String(username), AccessKeyId: oldKeys[0].AccessKeyId})
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		oldKeys = oldKeys[1:]
+	}
+
+	output, err := client.CreateAccessKey(&iam.CreateAccessKeyInput{UserName: awsSdk.String(username)})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	for _, key := range oldKeys {
+		_, err = client.DeleteAccessKey(&iam.DeleteAccessKeyInput{UserName: awsSdk.String(username), AccessKeyId: key.AccessKeyId})
+		if err != nil {
+			return output.AccessKey, expiry, fmt.Errorf("created new key %s but could not delete old key %s: %w", awsSdk.StringValue(output.AccessKey.AccessKeyId), awsSdk.StringValue(key.AccessKeyId), err)
+		}
+	}
+
+	return output.AccessKey, expiry, nil
+}
@@ -0,0 +1,246 @@
+package iam
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/checkpoint"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/spf13/cobra"
+)
+
+const sweepExample = `
+  # List the expired users in every account of the organization without deleting them
+  osdctl account iam sweep -p osd-staging-1 --dry-run
+
+  # Remove the expired users of specific accounts
+  osdctl account iam sweep -p osd-staging-1 -i 123456789012 -i 210987654321
+
+  # Sweep the organization again from the first account, discarding the progress of an interrupted sweep
+  osdctl account iam sweep -p osd-staging-1 --restart
+`
+
+type sweepOptions struct {
+	accountIDs []string
+	awsProfile string
+	dryRun     bool
+	checkpoint checkpoint.Flags
+}
+
+type expiredUser struct {
+	accountID string
+	username  string
+	owner     string
+	expiry    time.Time
+}
+
+func newCmdSweep() *cobra.Command {
+	ops := &sweepOptions{}
+	sweepCmd := &cobra.Command{
+		Use:   "sweep",
+		Short: "Remove expired IAM users created by 'create-user'",
+		Long: "Remove expired IAM users created by 'create-user'. Without --account-id, every active account of the profile's organization is swept.\n\n" +
+			"The swept accounts are saved to a checkpoint: re-running an interrupted sweep skips them, and retries the accounts that failed.",
+		Example:           sweepExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	sweepCmd.Flags().StringSliceVarP(&ops.accountIDs, "account-id", "i", nil, "AWS account IDs to sweep, defaults to all accounts of the organization")
+	sweepCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile of the organization's payer account")
+	sweepCmd.Flags().BoolVar(&ops.dryRun, "dry-run", false, "Only list the expired users")
+	ops.checkpoint.AddFlags(sweepCmd)
+
+	return sweepCmd
+}
+
+func (o *sweepOptions) run() error {
+	payerClient, err := aws.NewAwsClient(o.awsProfile, common.DefaultRegion, "")
+	if err != nil {
+		return err
+	}
+
+	sessionName, err := osdCloud.GenerateRoleSessionName(payerClient)
+	if err != nil {
+		return fmt.Errorf("could not generate session name: %w", err)
+	}
+
+	partition, err := aws.GetAwsPartition(payerClient)
+	if err != nil {
+		return err
+	}
+
+	accountIDs := o.accountIDs
+	if len(accountIDs) == 0 {
+		accountIDs, err = listActiveAccounts(payerClient)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Dry runs don't change anything, there is no progress to save
+	var progress *checkpoint.Checkpoint
+	if !o.dryRun {
+		if progress, err = o.openCheckpoint(); err != nil {
+			return err
+		}
+		if progress.Resumed() {
+			fmt.Fprintf(os.Stderr, "Resuming the sweep saved in %s\n", progress.Path())
+			accountIDs = progress.Pending(accountIDs)
+		}
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Account", "User", "Owner", "Expired", "Result"})
+	var failed int
+	now := time.Now()
+	for _, accountID := range accountIDs {
+		rows, err := o.sweepAccount(payerClient, partition, accountID, sessionName, now)
+		for _, row := range rows {
+			table.AddRow(row)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+		}
+		if progress != nil {
+			if saveErr := progress.Record(accountID, err); saveErr != nil {
+				fmt.Fprintf(os.Stderr, "Cannot save the progress to %s: %v\n", progress.Path(), saveErr)
+			}
+		}
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		if progress != nil {
+			fmt.Fprintf(os.Stderr, "Run the same command again to retry the failed accounts, progress saved in %s\n", progress.Path())
+		}
+		return fmt.Errorf("sweep finished with errors in %d accounts", failed)
+	}
+	if progress != nil {
+		if err := progress.Complete(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot remove the checkpoint %s: %v\n", progress.Path(), err)
+		}
+	}
+	return nil
+}
+
+// openCheckpoint returns the progress of previous sweeps of the same accounts, or of the whole organization
+func (o *sweepOptions) openCheckpoint() (*checkpoint.Checkpoint, error) {
+	accountIDs := append([]string{}, o.accountIDs...)
+	sort.Strings(accountIDs)
+	return o.checkpoint.Open("account iam sweep", o.awsProfile, strings.Join(accountIDs, ","))
+}
+
+// sweepAccount deletes the expired users of the account and returns their rows of the table, with an error when the
+// account couldn't be swept completely
+func (o *sweepOptions) sweepAccount(payerClient aws.Client, partition, accountID, sessionName string, now time.Time) ([][]string, error) {
+	client, err := assumeAccountRole(payerClient, partition, accountID, sessionName)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := findExpiredUsers(client, accountID, now)
+	if err != nil {
+		return nil, fmt.Errorf("could not list users of %s: %w", accountID, err)
+	}
+
+	var rows [][]string
+	var failed int
+	for _, user := range users {
+		result := "dry-run"
+		if !o.dryRun {
+			result = "deleted"
+			if err := deleteManagedUser(client, user.username); err != nil {
+				result = fmt.Sprintf("failed: %v", err)
+				failed++
+			}
+		}
+		rows = append(rows, []string{user.accountID, user.username, user.owner, timefmt.Format(user.expiry), result})
+	}
+	if failed > 0 {
+		return rows, fmt.Errorf("could not delete %d expired users of %s", failed, accountID)
+	}
+	return rows, nil
+}
+
+func listActiveAccounts(client aws.Client) ([]string, error) {
+	var accountIDs []string
+	input := &organizations.ListAccountsInput{}
+	for {
+		output, err := client.ListAccounts(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range output.Accounts {
+			if awsSdk.StringValue(account.Status) == organizations.AccountStatusActive {
+				accountIDs = append(accountIDs, awsSdk.StringValue(account.Id))
+			}
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	return accountIDs, nil
+}
+
+// findExpiredUsers lists the users under the osdctl path whose expiry tag is in the past.
+// ListUsers doesn't return tags, so every user is fetched individually.
+func findExpiredUsers(client aws.Client, accountID string, now time.Time) ([]expiredUser, error) {
+	var expired []expiredUser
+	input := &iam.ListUsersInput{PathPrefix: awsSdk.String(userPath)}
+	for {
+		output, err := client.ListUsers(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, listed := range output.Users {
+			user, expiry, err := getManagedUser(client, awsSdk.StringValue(listed.UserName))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping user %s in %s: %v\n", awsSdk.StringValue(listed.UserName), accountID, err)
+				continue
+			}
+			if now.After(expiry) {
+				expired = append(expired, expiredUser{
+					accountID: accountID,
+					username:  awsSdk.StringValue(user.UserName),
+					owner:     tagValue(user.Tags, ownerTagKey),
+					expiry:    expiry,
+				})
+			}
+		}
+		if !awsSdk.BoolValue(output.IsTruncated) {
+			break
+		}
+		input.Marker = output.Marker
+	}
+	return expired, nil
+}
+
+func tagValue(tags []*iam.Tag, key string) string {
+	for _, tag := range tags {
+		if awsSdk.StringValue(tag.Key) == key {
+			return awsSdk.StringValue(tag.Value)
+		}
+	}
+	return ""
+}
@@ -6,6 +6,7 @@ import (
 	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,8 +24,8 @@ func newCmdListAccountClaim(streams genericclioptions.IOStreams, flags *genericc
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -104,7 +105,11 @@ func (o *listAccountClaimOptions) run() error {
 				return err
 			}
 
-			return resourcePrinter.PrintObj(&claims.Items[i], o.Out)
+			out, flush := printer.FilterJSON(printer.Tee(o.Out))
+			if err := resourcePrinter.PrintObj(&claims.Items[i], out); err != nil {
+				return err
+			}
+			return flush()
 		}
 	}
 	return nil
@@ -9,6 +9,7 @@ import (
 
 	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -17,8 +18,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// newCmdListAccount implements the list account command to list account crs
-func newCmdListAccount(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, client client.Client, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+// NewCmdListAccount implements the list account command to list account crs, also run as osdctl get accounts
+func NewCmdListAccount(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, client client.Client, globalOpts *globalflags.GlobalOptions) *cobra.Command {
 	ops := newListAccountOptions(streams, flags, client, globalOpts)
 	listAccountCmd := &cobra.Command{
 		Use:               "account",
@@ -26,8 +27,8 @@ func newCmdListAccount(streams genericclioptions.IOStreams, flags *genericcliopt
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -196,7 +197,11 @@ func (o *listAccountOptions) run() error {
 	}
 
 	if o.output != "" {
-		return resourcePrinter.PrintObj(&outputAccounts, o.Out)
+		out, flush := printer.FilterJSON(printer.Tee(o.Out))
+		if err := resourcePrinter.PrintObj(&outputAccounts, out); err != nil {
+			return err
+		}
+		return flush()
 	}
 
 	if matched {
@@ -125,7 +125,7 @@ func TestGetAccountCmdComplete(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.title, func(t *testing.T) {
-			cmd := newCmdListAccount(streams, tc.option.flags, mockk8s.NewMockClient(mockCtrl), &globalFlags)
+			cmd := NewCmdListAccount(streams, tc.option.flags, mockk8s.NewMockClient(mockCtrl), &globalFlags)
 			err := tc.option.complete(cmd, nil)
 			if tc.errExpected {
 				g.Expect(err).Should(HaveOccurred())
@@ -17,7 +17,7 @@ func NewCmdList(streams genericclioptions.IOStreams, flags *genericclioptions.Co
 		DisableAutoGenTag: true,
 	}
 
-	listCmd.AddCommand(newCmdListAccount(streams, flags, client, globalOpts))
+	listCmd.AddCommand(NewCmdListAccount(streams, flags, client, globalOpts))
 	listCmd.AddCommand(newCmdListAccountClaim(streams, flags, client, globalOpts))
 
 	return listCmd
@@ -7,10 +7,13 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	outputflag "github.com/openshift/osdctl/cmd/getoutput"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
 
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/spf13/cobra"
@@ -32,6 +35,8 @@ type accountAssignOptions struct {
 	payerAccount string
 	accountID    string
 	output       string
+	createIAM    bool
+	showSecret   bool
 
 	flags      *genericclioptions.ConfigFlags
 	printFlags *printer.PrintFlags
@@ -40,14 +45,23 @@ type accountAssignOptions struct {
 }
 
 type assignResponse struct {
-	Username string `json:"username" yaml:"username"`
-	Id       string `json:"id" yaml:"id"`
+	Username        string `json:"username" yaml:"username"`
+	Id              string `json:"id" yaml:"id"`
+	AccessKeyID     string `json:"accessKeyId,omitempty" yaml:"accessKeyId,omitempty"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty" yaml:"secretAccessKey,omitempty"`
 }
 
 func (f assignResponse) String() string {
-	return fmt.Sprintf("  Username: %s\n  Account: %s\n", f.Username, f.Id)
+	s := fmt.Sprintf("  Username: %s\n  Account: %s\n", f.Username, f.Id)
+	if f.AccessKeyID != "" {
+		s += fmt.Sprintf("  AccessKeyID: %s\n  SecretAccessKey: %s\n", f.AccessKeyID, f.SecretAccessKey)
+	}
+	return s
 }
 
+// developerAccessPolicy is the AWS managed policy attached to the IAM user created for the developer
+const developerAccessPolicy = "AdministratorAccess"
+
 func newAccountAssignOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *accountAssignOptions {
 	return &accountAssignOptions{
 		flags:         flags,
@@ -65,14 +79,16 @@ func newCmdAccountAssign(streams genericclioptions.IOStreams, flags *genericclio
 		Short:             "Assign account to user",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	ops.printFlags.AddFlags(accountAssignCmd)
 	accountAssignCmd.Flags().StringVarP(&ops.payerAccount, "payer-account", "p", "", "Payer account type")
 	accountAssignCmd.Flags().StringVarP(&ops.username, "username", "u", "", "LDAP username")
 	accountAssignCmd.Flags().StringVarP(&ops.accountID, "account-id", "i", "", "(optional) Specific AWS account ID to assign")
+	accountAssignCmd.Flags().BoolVar(&ops.createIAM, "create-iam-user", false, "(optional) Create an IAM user named after the LDAP username with access keys in the assigned account, requires --show-secret")
+	accountAssignCmd.Flags().BoolVar(&ops.showSecret, "show-secret", false, "Print the secret access key of the IAM user created with --create-iam-user")
 
 	return accountAssignCmd
 }
@@ -84,6 +100,10 @@ func (o *accountAssignOptions) complete(cmd *cobra.Command, _ []string) error {
 	if o.payerAccount == "" {
 		return cmdutil.UsageErrorf(cmd, "Payer account was not provided")
 	}
+	// the secret access key can't be retrieved again once printed, so it is only printed when explicitly asked for
+	if o.createIAM && !o.showSecret {
+		return cmdutil.UsageErrorf(cmd, "--create-iam-user prints the secret access key of the created user, which can't be retrieved later: pass --show-secret to confirm")
+	}
 
 	o.output = o.GlobalOptions.Output
 
@@ -109,7 +129,7 @@ func (o *accountAssignOptions) run() error {
 	}
 
 	//Instantiate aws client
-	awsClient, err := awsprovider.NewAwsClient(o.payerAccount, "us-east-1", "")
+	awsClient, err := awsprovider.NewAwsClient(o.payerAccount, common.DefaultRegion, "")
 	if err != nil {
 		return err
 	}
@@ -168,9 +188,26 @@ func (o *accountAssignOptions) run() error {
 		Id:       accountAssignID,
 	}
 
+	if o.createIAM {
+		partition, err := awsprovider.GetAwsPartition(o.awsClient)
+		if err != nil {
+			return fmt.Errorf("account assigned, but could not determine the AWS partition to create IAM access: %w", err)
+		}
+		assumedRoleAwsClient, err := assumeOrganizationAccessRole(o.awsClient, partition, accountAssignID, "osdctl-account-assignment")
+		if err != nil {
+			return fmt.Errorf("account assigned, but could not assume role to create IAM access: %w", err)
+		}
+		accessKey, err := createIAMUser(assumedRoleAwsClient, o.username, partition)
+		if err != nil {
+			return fmt.Errorf("account assigned, but could not create IAM access: %w", err)
+		}
+		resp.AccessKeyID = *accessKey.AccessKeyId
+		resp.SecretAccessKey = *accessKey.SecretAccessKey
+	}
+
 	err = outputflag.PrintResponse(o.output, resp)
 	if err != nil {
-		fmt.Println("Error while calling PrintResponse(): ", err.Error())
+		fmt.Fprintln(o.ErrOut, "Error while calling PrintResponse(): ", err.Error())
 	}
 
 	return nil
@@ -280,7 +317,7 @@ func (o *accountAssignOptions) tagAccount(accountId string) error {
 
 func (o *accountAssignOptions) buildAccount(seedVal int64) (string, error) {
 
-	fmt.Println("Creating account")
+	fmt.Fprintln(o.ErrOut, "Creating account")
 	var newAccountId string
 
 	orgOutput, orgErr := o.createAccount(seedVal)
@@ -387,3 +424,37 @@ func (o *accountAssignOptions) moveAccount(accountIdInput string, destOuInput st
 	}
 	return nil
 }
+
+// createIAMUser creates an IAM user for the developer in the assigned account of the partition and returns its access
+// key. The user is removed again by 'unassign', which deletes all IAM users of the account.
+func createIAMUser(awsClient awsprovider.Client, username, partition string) (*iam.AccessKey, error) {
+	_, err := awsClient.CreateUser(&iam.CreateUserInput{
+		UserName: aws.String(username),
+		Tags: []*iam.Tag{
+			{
+				Key:   aws.String("owner"),
+				Value: aws.String(username),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = awsClient.AttachUserPolicy(&iam.AttachUserPolicyInput{
+		UserName:  aws.String(username),
+		PolicyArn: aws.String(awsprovider.GeneratePolicyARNInPartition(partition, "aws", developerAccessPolicy)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := awsClient.CreateAccessKey(&iam.CreateAccessKeyInput{
+		UserName: aws.String(username),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.AccessKey, nil
+}
@@ -6,12 +6,15 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/golang/mock/gomock"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/openshift/osdctl/pkg/provider/aws/mock"
 
+	"github.com/openshift/osdctl/internal/utils/globalflags"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 func TestIsOwned(t *testing.T) {
@@ -317,3 +320,51 @@ func TestMoveAccount(t *testing.T) {
 		t.Errorf("failed to move account")
 	}
 }
+
+func TestCreateIAMUser(t *testing.T) {
+
+	mocks := setupDefaultMocks(t, []runtime.Object{})
+
+	mockAWSClient := mock.NewMockClient(mocks.mockCtrl)
+	username := "dev-user"
+
+	gomock.InOrder(
+		mockAWSClient.EXPECT().CreateUser(gomock.Any()).Return(&iam.CreateUserOutput{}, nil),
+		mockAWSClient.EXPECT().AttachUserPolicy(&iam.AttachUserPolicyInput{
+			UserName:  aws.String(username),
+			PolicyArn: aws.String("arn:aws:iam::aws:policy/AdministratorAccess"),
+		}).Return(&iam.AttachUserPolicyOutput{}, nil),
+		mockAWSClient.EXPECT().CreateAccessKey(gomock.Any()).Return(&iam.CreateAccessKeyOutput{
+			AccessKey: &iam.AccessKey{
+				AccessKeyId:     aws.String("AKIAEXAMPLE"),
+				SecretAccessKey: aws.String("secret"),
+			},
+		}, nil),
+	)
+
+	accessKey, err := createIAMUser(mockAWSClient, username, "aws")
+	if err != nil {
+		t.Fatalf("failed to create IAM user: %v", err)
+	}
+	if *accessKey.AccessKeyId != "AKIAEXAMPLE" {
+		t.Errorf("unexpected access key id %s", *accessKey.AccessKeyId)
+	}
+}
+
+func TestCreateIAMUserRequiresShowSecret(t *testing.T) {
+	g := globalflags.GlobalOptions{}
+	cmd := newCmdAccountAssign(genericclioptions.IOStreams{}, &genericclioptions.ConfigFlags{}, &g)
+
+	o := newAccountAssignOptions(genericclioptions.IOStreams{}, &genericclioptions.ConfigFlags{}, &g)
+	o.payerAccount = "osd-staging-2"
+	o.username = "testuser"
+	o.createIAM = true
+	if err := o.complete(cmd, []string{}); err == nil {
+		t.Errorf("expected --create-iam-user to be rejected without --show-secret")
+	}
+
+	o.showSecret = true
+	if err := o.complete(cmd, []string{}); err != nil {
+		t.Errorf("expected --create-iam-user to be accepted with --show-secret, got %v", err)
+	}
+}
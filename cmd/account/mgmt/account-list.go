@@ -12,6 +12,7 @@ import (
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
@@ -57,8 +58,8 @@ func newCmdAccountList(streams genericclioptions.IOStreams, flags *genericcliopt
 		Short:             "List out accounts for username",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	ops.printFlags.AddFlags(accountListCmd)
@@ -138,7 +139,7 @@ func (o *accountListOptions) run() error {
 
 		err := outputflag.PrintResponse(o.output, resp)
 		if err != nil {
-			fmt.Println("Error while printing response: ", err.Error())
+			fmt.Fprintln(o.ErrOut, "Error while printing response: ", err.Error())
 			return err
 		}
 
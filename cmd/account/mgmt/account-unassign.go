@@ -1,7 +1,6 @@
 package mgmt
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"strings"
@@ -10,9 +9,11 @@ import (
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
-	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -25,8 +26,8 @@ func newCmdAccountUnassign(streams genericclioptions.IOStreams, flags *genericcl
 		Short:             "Unassign account to user",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	ops.printFlags.AddFlags(accountUnassignCmd)
@@ -74,7 +75,11 @@ func (o *accountUnassignOptions) run() error {
 		assumedRoleAwsClient awsprovider.Client
 	)
 	// Instantiate Aws client
-	awsClient, err := awsprovider.NewAwsClient(o.payerAccount, "us-east-1", "")
+	payerClient, err := awsprovider.NewAwsClient(o.payerAccount, common.DefaultRegion, "")
+	if err != nil {
+		return err
+	}
+	partition, err := awsprovider.GetAwsPartition(payerClient)
 	if err != nil {
 		return err
 	}
@@ -88,7 +93,7 @@ func (o *accountUnassignOptions) run() error {
 		return fmt.Errorf("invalid payer account provided")
 	}
 
-	o.awsClient = awsClient
+	o.awsClient = payerClient
 	var allUsers []string
 
 	if o.accountID != "" {
@@ -115,9 +120,8 @@ func (o *accountUnassignOptions) run() error {
 		}
 	}
 
-	fmt.Printf("Are you sure you want to unassign account(s) [%v] from %s? [y/n] ", accountIdList, accountUsername)
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
+	fmt.Fprintf(o.ErrOut, "Are you sure you want to unassign account(s) [%v] from %s? [y/n] ", accountIdList, accountUsername)
+	response, err := utils.ReadAnswer(o.In)
 	if err != nil {
 		return err
 	}
@@ -128,6 +132,8 @@ func (o *accountUnassignOptions) run() error {
 
 	// loop through accounts list and untag and move them back into root OU
 	for _, id := range accountIdList {
+		// the client of the previous account was kept to delete its users
+		o.awsClient = payerClient
 
 		// untag account
 		err = o.untagAccount(id)
@@ -141,24 +147,24 @@ func (o *accountUnassignOptions) run() error {
 			return err
 		}
 		// instantiate new client with AssumeRole
-		assumedRoleAwsClient, err = o.assumeRoleForAccount(id)
+		assumedRoleAwsClient, err = assumeOrganizationAccessRole(payerClient, partition, id, "osdctl-account-unassignment")
 		if err != nil {
 			return err
 		}
 		// delete roles
 		err = deleteRoles(assumedRoleAwsClient)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 		// delete account policies
 		err = deleteAccountPolicies(assumedRoleAwsClient)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 		// list iam users created by each account and append to slice
 		users, err := listUsersFromAccount(assumedRoleAwsClient, id)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 
 		allUsers = append(allUsers, users...)
@@ -170,72 +176,43 @@ func (o *accountUnassignOptions) run() error {
 		// Delete login profile
 		err = o.deleteLoginProfile(userName)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 		// Delete access keys
 		err = o.deleteAccessKeys(userName)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 		// Delete signing certificates
 		err = o.deleteSigningCert(userName)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 		// Delete user policies
 		err = o.deleteUserPolicies(userName)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 		// Delete attached policies
 		err = o.deleteAttachedPolicies(userName)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 		// Delete groups
 		err = o.deleteGroups(userName)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 		// Delete user
 		err = o.deleteUser(userName)
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(o.ErrOut, err)
 		}
 	}
 
 	return nil
 }
 
-func (o *accountUnassignOptions) assumeRoleForAccount(account_id string) (awsprovider.Client, error) {
-
-	roleArn := fmt.Sprintf("arn:aws:iam::%s:role/OrganizationAccountAccessRole", account_id)
-
-	input := &sts.AssumeRoleInput{
-		RoleArn:         aws.String(roleArn),
-		RoleSessionName: aws.String("osdctl-account-unassignment"),
-	}
-
-	result, err := o.awsClient.AssumeRole(input)
-	if err != nil {
-		return nil, err
-	}
-
-	newAwsClientInput := &awsprovider.AwsClientInput{
-		AccessKeyID:     *result.Credentials.AccessKeyId,
-		SecretAccessKey: *result.Credentials.SecretAccessKey,
-		SessionToken:    *result.Credentials.SessionToken,
-		Region:          "us-east-1",
-	}
-
-	newAWSClient, err := awsprovider.NewAwsClientWithInput(newAwsClientInput)
-	if err != nil {
-		return nil, err
-	}
-
-	return newAWSClient, nil
-}
-
 func listUsersFromAccount(newAWSClient awsprovider.Client, account_id string) ([]string, error) {
 
 	listInput := &iam.ListUsersInput{}
@@ -36,14 +36,15 @@ func TestAssumeRoleForAccount(t *testing.T) {
 		},
 	}
 
-	mockAWSClient.EXPECT().AssumeRole(gomock.Any()).Return(
+	mockAWSClient.EXPECT().AssumeRole(&sts.AssumeRoleInput{
+		RoleArn:         aws.String("arn:aws-us-gov:iam::111111111111:role/OrganizationAccountAccessRole"),
+		RoleSessionName: aws.String("test"),
+	}).Return(
 		awsAssumeRoleOutput,
 		nil,
 	)
 
-	o := &accountUnassignOptions{}
-	o.awsClient = mockAWSClient
-	returnVal, err := o.assumeRoleForAccount(accountId)
+	returnVal, err := assumeOrganizationAccessRole(mockAWSClient, "aws-us-gov", accountId, "test")
 	if err != nil {
 		t.Errorf("failed to assume role")
 	}
@@ -2,7 +2,12 @@ package mgmt
 
 import (
 	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
@@ -30,3 +35,22 @@ func help(cmd *cobra.Command, _ []string) {
 		return
 	}
 }
+
+// assumeOrganizationAccessRole returns a client of the account through its OrganizationAccountAccessRole, assumed from
+// the payer account of the partition
+func assumeOrganizationAccessRole(payerClient awsprovider.Client, partition, accountID, sessionName string) (awsprovider.Client, error) {
+	result, err := payerClient.AssumeRole(&sts.AssumeRoleInput{
+		RoleArn:         aws.String(awsprovider.GenerateRoleARNInPartition(partition, accountID, osdCloud.OrganizationAccountAccessRole)),
+		RoleSessionName: aws.String(sessionName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return awsprovider.NewAwsClientWithInput(&awsprovider.AwsClientInput{
+		AccessKeyID:     *result.Credentials.AccessKeyId,
+		SecretAccessKey: *result.Credentials.SecretAccessKey,
+		SessionToken:    *result.Credentials.SessionToken,
+		Region:          awsprovider.GlobalRegion(partition),
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/openshift/aws-account-operator/api/v1alpha1"
 	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
@@ -33,8 +34,8 @@ func newCmdReset(streams genericclioptions.IOStreams, flags *genericclioptions.C
 		Short:             "Reset AWS Account CR",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -83,7 +84,7 @@ func (o *resetOptions) complete(cmd *cobra.Command, args []string) error {
 func (o *resetOptions) run() error {
 	if !o.skipCheck {
 		reader := bufio.NewReader(o.In)
-		fmt.Fprintf(o.Out, fmt.Sprintf("Reset account %s? (Y/N) ", o.accountName))
+		fmt.Fprintf(o.ErrOut, "Reset account %s? (Y/N) ", o.accountName)
 		text, _ := reader.ReadSlice('\n')
 
 		input := strings.ToLower(strings.Trim(string(text), "\n"))
@@ -103,7 +104,7 @@ func (o *resetOptions) run() error {
 	}
 	for i, secret := range secrets.Items {
 		if strings.HasPrefix(secret.Name, o.accountName) {
-			fmt.Fprintln(o.Out, "Deleting secret "+secret.Name)
+			fmt.Fprintln(o.ErrOut, "Deleting secret "+secret.Name)
 			if err := o.kubeCli.Delete(ctx, &secrets.Items[i], &client.DeleteOptions{}); err != nil {
 
 				if apierrors.IsNotFound(err) {
@@ -201,13 +202,13 @@ func (o *resetOptions) getAwsClientFromSecret(secretName string, namespace strin
 	accessId := base64.StdEncoding.EncodeToString([]byte(secretAws.Data["aws_access_key_id"]))
 	accessKeyID, err := base64.StdEncoding.DecodeString(accessId)
 	if err != nil {
-		fmt.Println("decode error:", err)
+		fmt.Fprintln(o.ErrOut, "decode error:", err)
 		return nil, err
 	}
 	secretId := base64.StdEncoding.EncodeToString([]byte(secretAws.Data["aws_secret_access_key"]))
 	secretkeyID, err := base64.StdEncoding.DecodeString(secretId)
 	if err != nil {
-		fmt.Println("decode error:", err)
+		fmt.Fprintln(o.ErrOut, "decode error:", err)
 		return nil, err
 	}
 	awsClient, err := awsprovider.NewAwsClientWithInput(&awsprovider.AwsClientInput{
@@ -216,7 +217,7 @@ func (o *resetOptions) getAwsClientFromSecret(secretName string, namespace strin
 		Region:          "us-east-1",
 	})
 	if err != nil {
-		fmt.Printf(" error occurred when calling NewAwsClientWithInput")
+		fmt.Fprintln(o.ErrOut, "error occurred when calling NewAwsClientWithInput")
 		return awsClient, err
 	}
 	return awsClient, nil
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
 	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
@@ -16,6 +17,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -23,6 +25,7 @@ import (
 
 	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/poll"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 )
 
@@ -35,8 +38,8 @@ func newCmdRotateSecret(streams genericclioptions.IOStreams, flags *genericcliop
 		Long:              "When logged into a hive shard, this rotates IAM credential secrets for a given `account` CR.",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -121,6 +124,12 @@ func (o *rotateSecretOptions) run() error {
 	if err != nil {
 		return err
 	}
+	// The roles and the IAM endpoint are the ones of the partition of the caller, e.g. GovCloud
+	callerArn, err := arn.Parse(aws.StringValue(callerIdentityOutput.Arn))
+	if err != nil {
+		return err
+	}
+	region := awsprovider.GlobalRegion(callerArn.Partition)
 
 	var credentials *sts.Credentials
 	// Need to role chain if the cluster is CCS
@@ -148,7 +157,7 @@ func (o *rotateSecretOptions) run() error {
 			AccessKeyID:     *srepRoleCredentials.AccessKeyId,
 			SecretAccessKey: *srepRoleCredentials.SecretAccessKey,
 			SessionToken:    *srepRoleCredentials.SessionToken,
-			Region:          "us-east-1",
+			Region:          region,
 		})
 		if err != nil {
 			return err
@@ -169,13 +178,13 @@ func (o *rotateSecretOptions) run() error {
 			AccessKeyID:     *jumpRoleCreds.AccessKeyId,
 			SecretAccessKey: *jumpRoleCreds.SecretAccessKey,
 			SessionToken:    *jumpRoleCreds.SessionToken,
-			Region:          "us-east-1",
+			Region:          region,
 		})
 		if err != nil {
 			return err
 		}
 		// Role chain to assume ManagedOpenShift-Support-{uid}
-		roleArn := aws.String(fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, "ManagedOpenShift-Support-"+accountIDSuffixLabel))
+		roleArn := aws.String(awsprovider.GenerateRoleARNInPartition(callerArn.Partition, accountID, "ManagedOpenShift-Support-"+accountIDSuffixLabel))
 		credentials, err = awsprovider.GetAssumeRoleCredentials(jumpRoleClient, o.awsAccountTimeout,
 			callerIdentityOutput.UserId, roleArn)
 		if err != nil {
@@ -184,7 +193,7 @@ func (o *rotateSecretOptions) run() error {
 
 	} else {
 		// Assume the OrganizationAdminAccess role
-		roleArn := aws.String(fmt.Sprintf("arn:aws:iam::%s:role/%s", accountID, awsv1alpha1.AccountOperatorIAMRole))
+		roleArn := aws.String(awsprovider.GenerateRoleARNInPartition(callerArn.Partition, accountID, awsv1alpha1.AccountOperatorIAMRole))
 		credentials, err = awsprovider.GetAssumeRoleCredentials(awsSetupClient, o.awsAccountTimeout,
 			callerIdentityOutput.UserId, roleArn)
 		if err != nil {
@@ -197,7 +206,7 @@ func (o *rotateSecretOptions) run() error {
 		AccessKeyID:     *credentials.AccessKeyId,
 		SecretAccessKey: *credentials.SecretAccessKey,
 		SessionToken:    *credentials.SessionToken,
-		Region:          "us-east-1",
+		Region:          region,
 	})
 	if err != nil {
 		return err
@@ -286,7 +295,7 @@ func (o *rotateSecretOptions) run() error {
 		return err
 	}
 
-	fmt.Printf("Watching Cluster Sync Status for deployment...")
+	fmt.Println("Watching Cluster Sync Status for deployment...")
 	hiveinternalv1alpha1.AddToScheme(o.kubeCli.Scheme())
 	searchStatus := &hiveinternalv1alpha1.ClusterSync{
 		ObjectMeta: metav1.ObjectMeta{
@@ -295,33 +304,22 @@ func (o *rotateSecretOptions) run() error {
 		},
 	}
 	foundStatus := &hiveinternalv1alpha1.ClusterSync{}
-	isSSSynced := false
-	for i := 0; i < 6; i++ {
-		err = o.kubeCli.Get(ctx, client.ObjectKeyFromObject(searchStatus), foundStatus)
-		if err != nil {
-			return err
+	opts := poll.Options{Description: "syncset " + syncSetName + " to sync", Timeout: 30 * time.Second, Interval: 5 * time.Second}
+	err = poll.Until(ctx, opts, func(ctx context.Context) (bool, string, error) {
+		if err := o.kubeCli.Get(ctx, client.ObjectKeyFromObject(searchStatus), foundStatus); err != nil {
+			return false, "", err
 		}
-
 		for _, status := range foundStatus.Status.SyncSets {
-			if status.Name == syncSetName {
-				if status.FirstSuccessTime != nil {
-					isSSSynced = true
-					break
-				}
+			if status.Name == syncSetName && status.FirstSuccessTime != nil {
+				return true, "", nil
 			}
 		}
-
-		if isSSSynced {
-			fmt.Printf("\nSync completed...\n")
-			break
-		}
-
-		fmt.Printf(".")
-		time.Sleep(time.Second * 5)
-	}
-	if !isSSSynced {
-		return fmt.Errorf("syncset failed to sync. Please verify")
+		return false, "not synced yet", nil
+	})
+	if err != nil {
+		return fmt.Errorf("syncset failed to sync. Please verify: %w", err)
 	}
+	fmt.Println("Sync completed...")
 
 	// Clean up the SS on hive
 	err = o.kubeCli.Delete(ctx, syncSet)
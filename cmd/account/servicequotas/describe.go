@@ -9,7 +9,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 
 	"github.com/openshift/osdctl/pkg/osdCloud"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
@@ -24,8 +24,8 @@ func newCmdDescribe() *cobra.Command {
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
 		},
 		Aliases: []string{"describe-quotas", "describe-quota"},
 	}
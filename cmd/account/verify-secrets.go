@@ -2,13 +2,17 @@ package account
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"io"
+	"strings"
 
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/sts"
 	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -17,12 +21,37 @@ import (
 	"github.com/openshift/osdctl/cmd/common"
 	"github.com/openshift/osdctl/pkg/k8s"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/tui"
 )
 
 const (
 	verifySecretsUsage = "The verify-secrets command should have only 0 or 1 arguments" //#nosec G101 -- not a secret
+
+	secretStatusValid              = "valid"
+	secretStatusInvalid            = "invalid"
+	secretStatusRevoked            = "revoked"
+	secretStatusDrifted            = "drifted"
+	secretStatusMissingPermissions = "missing-permissions"
 )
 
+// expectedSecretActions are the actions the operator needs from the IAM user to set up an account and its clusters
+var expectedSecretActions = []string{
+	"iam:CreateUser",
+	"iam:CreateAccessKey",
+	"iam:AttachUserPolicy",
+	"iam:CreateRole",
+	"sts:AssumeRole",
+	"ec2:DescribeRegions",
+	"ec2:RunInstances",
+	"servicequotas:RequestServiceQuotaIncrease",
+}
+
+const verifySecretsLong = `Verify the IAM user credentials of Account CRs.
+
+Each secret is checked to authenticate (revoked otherwise), to belong to the AWS account of the Account CR
+(drifted otherwise) and to be allowed the actions the operator needs to provision clusters (missing-permissions
+otherwise). The command fails when any secret isn't valid.`
+
 // newCmdVerifySecrets implements the verify-secrets command
 // which verifies AWS credentials managed by AWS Account Operator
 func newCmdVerifySecrets(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, client client.Client) *cobra.Command {
@@ -30,10 +59,11 @@ func newCmdVerifySecrets(streams genericclioptions.IOStreams, flags *genericclio
 	verifySecretsCmd := &cobra.Command{
 		Use:               "verify-secrets [<account name>]",
 		Short:             "Verify AWS Account CR IAM User credentials",
+		Long:              verifySecretsLong,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 		Aliases: []string{"verify-secret"},
 	}
@@ -42,6 +72,7 @@ func newCmdVerifySecrets(streams genericclioptions.IOStreams, flags *genericclio
 		"The namespace to keep AWS accounts. The default value is aws-account-operator.")
 	verifySecretsCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
 	verifySecretsCmd.Flags().BoolVarP(&ops.all, "all", "A", false, "Verify all Account CRs")
+	verifySecretsCmd.Flags().StringVarP(&ops.accountID, "aws-account-id", "i", "", "Verify the Account CR of this AWS account ID")
 
 	return verifySecretsCmd
 }
@@ -49,6 +80,7 @@ func newCmdVerifySecrets(streams genericclioptions.IOStreams, flags *genericclio
 // verifySecretsOptions defines the struct for running verify command
 type verifySecretsOptions struct {
 	accountName      string
+	accountID        string
 	accountNamespace string
 
 	verbose bool
@@ -76,17 +108,23 @@ func (o *verifySecretsOptions) complete(cmd *cobra.Command, args []string) error
 		o.accountName = args[0]
 	}
 
+	selectors := 0
+	for _, set := range []bool{o.accountName != "", o.accountID != "", o.all} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors > 1 {
+		return cmdutil.UsageErrorf(cmd, "Only one of the Account CR name, -i and --all can be used")
+	}
+
 	return nil
 }
 
 func (o *verifySecretsOptions) run() error {
 	ctx := context.TODO()
-	var (
-		awsClient   awsprovider.Client
-		credentials []*awsSecret
-		err         error
-		allErr      bool
-	)
+	var credentials []*awsSecret
+
 	if o.all {
 		var accounts awsv1alpha1.AccountList
 		if err := o.kubeCli.List(ctx, &accounts, &client.ListOptions{
@@ -95,110 +133,194 @@ func (o *verifySecretsOptions) run() error {
 			return err
 		}
 
-		for _, account := range accounts.Items {
+		for i := range accounts.Items {
+			account := &accounts.Items[i]
 			if account.Spec.IAMUserSecret == "" {
 				continue
 			}
 			if o.verbose {
-				fmt.Fprintln(o.IOStreams.Out, "Getting AWS Credentials for account "+account.Name)
+				fmt.Fprintln(o.IOStreams.ErrOut, "Getting AWS Credentials for account "+account.Name)
 			}
-			creds, err := k8s.GetAWSAccountCredentials(ctx, o.kubeCli, o.accountNamespace, account.Spec.IAMUserSecret)
+			cred, err := o.accountSecret(ctx, account, o.accountNamespace, account.Spec.IAMUserSecret)
 			if err != nil {
 				if apierrors.IsNotFound(err) && account.Status.State != "Creating" {
-					fmt.Fprintf(o.IOStreams.Out, "Account %s doesn't have associate credentials, state %s",
+					fmt.Fprintf(o.IOStreams.ErrOut, "Account %s doesn't have associate credentials, state %s\n",
 						account.Name, account.Status.State)
 				}
 				continue
 			}
-			credentials = append(credentials, &awsSecret{
-				secret: account.Spec.IAMUserSecret,
-				awsCreds: &awsprovider.AwsClientInput{
-					AccessKeyID:     creds.AccessKeyID,
-					SecretAccessKey: creds.SecretAccessKey,
-				},
-			})
+			credentials = append(credentials, cred)
 		}
 	} else {
-		if o.accountName == "" {
-			return fmt.Errorf("Please provide an account CR name")
-		}
-		account, err := k8s.GetAWSAccount(ctx, o.kubeCli, o.accountNamespace, o.accountName)
+		account, err := o.getAccount(ctx)
 		if err != nil {
 			return err
 		}
 		if account.Spec.IAMUserSecret == "" {
-			return fmt.Errorf("account %s doesn't have associate credentials", account.Name)
+			return osdctlErrors.New(osdctlErrors.ErrNotFound, "account %s doesn't have associate credentials", account.Name)
 		}
 		if o.verbose {
-			fmt.Fprintln(o.IOStreams.Out, "Getting AWS Credentials for account "+account.Name)
+			fmt.Fprintln(o.IOStreams.ErrOut, "Getting AWS Credentials for account "+account.Name)
 		}
-		creds, err := k8s.GetAWSAccountCredentials(ctx, o.kubeCli, o.accountNamespace, account.Spec.IAMUserSecret)
+		cred, err := o.accountSecret(ctx, account, o.accountNamespace, account.Spec.IAMUserSecret)
 		if err != nil {
 			return err
 		}
-		credentials = append(credentials, &awsSecret{
-			secret: account.Spec.IAMUserSecret,
-			awsCreds: &awsprovider.AwsClientInput{
-				AccessKeyID:     creds.AccessKeyID,
-				SecretAccessKey: creds.SecretAccessKey,
-			},
-		})
+		credentials = append(credentials, cred)
 
 		// Add osdCcsAdmin credentials to be validated for CCS accounts
 		if account.Spec.BYOC {
-			creds, err := k8s.GetAWSAccountCredentials(ctx, o.kubeCli, account.Spec.ClaimLinkNamespace, "byoc")
+			cred, err := o.accountSecret(ctx, account, account.Spec.ClaimLinkNamespace, "byoc")
 			if err != nil {
 				return err
 			}
-			credentials = append(credentials, &awsSecret{
-				secret: "byoc",
-				awsCreds: &awsprovider.AwsClientInput{
-					AccessKeyID:     creds.AccessKeyID,
-					SecretAccessKey: creds.SecretAccessKey,
-				},
-			})
+			credentials = append(credentials, cred)
 		}
 	}
 
+	results := make([]secretVerification, 0, len(credentials))
 	for _, cred := range credentials {
 		if o.verbose {
-			fmt.Fprintln(o.IOStreams.Out, "Start validating secret "+cred.secret)
+			fmt.Fprintln(o.IOStreams.ErrOut, "Start validating secret "+cred.secret)
 		}
-		awsClient, err = awsprovider.NewAwsClientWithInput(cred.awsCreds)
+		awsClient, err := awsprovider.NewAwsClientWithInput(cred.awsCreds)
 		if err != nil {
-			fmt.Fprintf(o.IOStreams.Out, "Failed to create AWS client with secret %s\n", cred.secret)
-			if o.all {
-				allErr = true
-				continue
-			}
-			return err
+			results = append(results, secretVerification{secret: cred, status: secretStatusInvalid, details: err.Error()})
+			continue
 		}
-		if _, err := awsClient.GetCallerIdentity(
-			&sts.GetCallerIdentityInput{}); err != nil {
-			fmt.Fprintf(o.IOStreams.Out, "Failed to get caller identity with secret %s\n", cred.secret)
-			if o.all {
-				allErr = true
-				continue
-			}
-			return err
+		results = append(results, verifySecret(awsClient, cred))
+	}
+
+	return printSecretVerifications(o.IOStreams.Out, results)
+}
+
+// getAccount returns the Account CR given by name or by AWS account ID
+func (o *verifySecretsOptions) getAccount(ctx context.Context) (*awsv1alpha1.Account, error) {
+	if o.accountID == "" {
+		if o.accountName == "" {
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "Please provide an account CR name or an AWS account ID")
 		}
+		return k8s.GetAWSAccount(ctx, o.kubeCli, o.accountNamespace, o.accountName)
 	}
 
-	if allErr {
-		fmt.Fprintf(o.IOStreams.Out, "Some credentials are invalid\n")
-		return errors.New("AccountCredentialError")
+	var accounts awsv1alpha1.AccountList
+	if err := o.kubeCli.List(ctx, &accounts, &client.ListOptions{
+		Namespace: o.accountNamespace,
+	}); err != nil {
+		return nil, err
+	}
+	for i := range accounts.Items {
+		if accounts.Items[i].Spec.AwsAccountID == o.accountID {
+			return &accounts.Items[i], nil
+		}
 	}
+	return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "no Account CR found for AWS account %s", o.accountID)
+}
 
-	if !o.all {
-		fmt.Fprintf(o.IOStreams.Out, "Credentials valid for %s\n", o.accountName)
-	} else {
-		fmt.Fprintf(o.IOStreams.Out, "Credentials valid for all Account CRs\n")
+func (o *verifySecretsOptions) accountSecret(ctx context.Context, account *awsv1alpha1.Account, namespace, secret string) (*awsSecret, error) {
+	creds, err := k8s.GetAWSAccountCredentials(ctx, o.kubeCli, namespace, secret)
+	if err != nil {
+		return nil, err
 	}
+	return &awsSecret{
+		account:      account.Name,
+		awsAccountID: account.Spec.AwsAccountID,
+		secret:       secret,
+		awsCreds: &awsprovider.AwsClientInput{
+			AccessKeyID:     creds.AccessKeyID,
+			SecretAccessKey: creds.SecretAccessKey,
+		},
+	}, nil
+}
+
+// verifySecret checks the credentials authenticate, belong to the AWS account of the Account CR and are allowed
+// the actions the operator needs to provision clusters
+func verifySecret(awsClient awsprovider.Client, cred *awsSecret) secretVerification {
+	result := secretVerification{secret: cred}
+
+	identity, err := awsClient.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		result.status = secretStatusRevoked
+		result.details = err.Error()
+		return result
+	}
+	if cred.awsAccountID != "" && awsSdk.StringValue(identity.Account) != cred.awsAccountID {
+		result.status = secretStatusDrifted
+		result.details = fmt.Sprintf("credentials belong to AWS account %s (%s)", awsSdk.StringValue(identity.Account), awsSdk.StringValue(identity.Arn))
+		return result
+	}
+
+	denied, err := deniedActions(awsClient, awsSdk.StringValue(identity.Arn))
+	if err != nil {
+		result.status = secretStatusMissingPermissions
+		result.details = fmt.Sprintf("cannot simulate the policies of %s: %v", awsSdk.StringValue(identity.Arn), err)
+		return result
+	}
+	if len(denied) > 0 {
+		result.status = secretStatusMissingPermissions
+		result.details = "denied " + strings.Join(denied, ", ")
+		return result
+	}
+
+	result.status = secretStatusValid
+	result.details = awsSdk.StringValue(identity.Arn)
+	return result
+}
 
+// deniedActions returns the expected actions the policies of the principal don't allow
+func deniedActions(awsClient awsprovider.Client, principalArn string) ([]string, error) {
+	var denied []string
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: awsSdk.String(principalArn),
+		ActionNames:     awsSdk.StringSlice(expectedSecretActions),
+	}
+	for {
+		output, err := awsClient.SimulatePrincipalPolicy(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range output.EvaluationResults {
+			if awsSdk.StringValue(result.EvalDecision) != iam.PolicyEvaluationDecisionTypeAllowed {
+				denied = append(denied, awsSdk.StringValue(result.EvalActionName))
+			}
+		}
+		if !awsSdk.BoolValue(output.IsTruncated) {
+			return denied, nil
+		}
+		input.Marker = output.Marker
+	}
+}
+
+func printSecretVerifications(out io.Writer, results []secretVerification) error {
+	// Errors can be long, the table is truncated to fit the terminal
+	table := tui.NewTable(out)
+	table.AddRow("ACCOUNT", "AWS ACCOUNT ID", "SECRET", "STATUS", "DETAILS")
+	failed := 0
+	for _, result := range results {
+		if result.status != secretStatusValid {
+			failed++
+		}
+		table.AddRow(result.secret.account, result.secret.awsAccountID, result.secret.secret, result.status, result.details)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "%d of %d credentials are invalid", failed, len(results))
+	}
 	return nil
 }
 
 type awsSecret struct {
-	awsCreds *awsprovider.AwsClientInput
-	secret   string
+	awsCreds     *awsprovider.AwsClientInput
+	account      string
+	awsAccountID string
+	secret       string
+}
+
+type secretVerification struct {
+	secret  *awsSecret
+	status  string
+	details string
 }
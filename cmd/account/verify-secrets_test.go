@@ -1,12 +1,17 @@
 package account
 
 import (
+	"errors"
 	"os"
 	"strings"
 	"testing"
 
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
 
 	mockk8s "github.com/openshift/osdctl/cmd/clusterdeployment/mock/k8s"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -47,6 +52,16 @@ func TestCheckSecretsCmdComplete(t *testing.T) {
 			args:        []string{},
 			errExpected: false,
 		},
+		{
+			title: "account name and AWS account ID",
+			option: &verifySecretsOptions{
+				accountID: "123456789012",
+				flags:     kubeFlags,
+			},
+			args:        []string{"foo"},
+			errExpected: true,
+			errContent:  "Only one of",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -64,3 +79,53 @@ func TestCheckSecretsCmdComplete(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifySecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cred := &awsSecret{account: "osd-creds-mgmt-abcde", awsAccountID: "123456789012", secret: "osd-creds-mgmt-abcde-secret"}
+	identity := &sts.GetCallerIdentityOutput{
+		Account: awsSdk.String("123456789012"),
+		Arn:     awsSdk.String("arn:aws:iam::123456789012:user/osdManagedAdmin-abcde"),
+	}
+	evaluation := func(action, decision string) *iam.EvaluationResult {
+		return &iam.EvaluationResult{EvalActionName: awsSdk.String(action), EvalDecision: awsSdk.String(decision)}
+	}
+
+	t.Run("revoked", func(t *testing.T) {
+		mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+		mockAWSClient.EXPECT().GetCallerIdentity(gomock.Any()).Return(nil, errors.New("InvalidClientTokenId"))
+		g.Expect(verifySecret(mockAWSClient, cred).status).To(Equal(secretStatusRevoked))
+	})
+
+	t.Run("drifted", func(t *testing.T) {
+		mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+		mockAWSClient.EXPECT().GetCallerIdentity(gomock.Any()).Return(&sts.GetCallerIdentityOutput{
+			Account: awsSdk.String("210987654321"),
+			Arn:     awsSdk.String("arn:aws:iam::210987654321:user/someone"),
+		}, nil)
+		g.Expect(verifySecret(mockAWSClient, cred).status).To(Equal(secretStatusDrifted))
+	})
+
+	t.Run("missing permissions", func(t *testing.T) {
+		mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+		mockAWSClient.EXPECT().GetCallerIdentity(gomock.Any()).Return(identity, nil)
+		mockAWSClient.EXPECT().SimulatePrincipalPolicy(gomock.Any()).Return(&iam.SimulatePolicyResponse{
+			EvaluationResults: []*iam.EvaluationResult{
+				evaluation("iam:CreateUser", iam.PolicyEvaluationDecisionTypeAllowed),
+				evaluation("ec2:RunInstances", iam.PolicyEvaluationDecisionTypeExplicitDeny),
+			},
+		}, nil)
+		result := verifySecret(mockAWSClient, cred)
+		g.Expect(result.status).To(Equal(secretStatusMissingPermissions))
+		g.Expect(result.details).To(Equal("denied ec2:RunInstances"))
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+		mockAWSClient.EXPECT().GetCallerIdentity(gomock.Any()).Return(identity, nil)
+		mockAWSClient.EXPECT().SimulatePrincipalPolicy(gomock.Any()).Return(&iam.SimulatePolicyResponse{
+			EvaluationResults: []*iam.EvaluationResult{evaluation("iam:CreateUser", iam.PolicyEvaluationDecisionTypeAllowed)},
+		}, nil)
+		g.Expect(verifySecret(mockAWSClient, cred).status).To(Equal(secretStatusValid))
+	})
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/openshift/osdctl/pkg/alias"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const aliasLong = `Aliases are short names for osdctl commands with their flags, defined in the '` + alias.ConfigKey + `' section of the
+config file. 'osdctl <alias> ARGS...' runs the command of the alias with the arguments: the $1 to $9 placeholders of
+the command take the arguments given after the alias, the others are appended. An alias can expand to another alias,
+but never replaces an osdctl command of the same name. The names are lowercase.
+
+  ` + alias.ConfigKey + `:
+    lsdel: cluster support delete --yes
+    ready: cluster list --search "state='ready'"
+    slpost: servicelog post $1 -t https://example.com/template.json`
+
+// newCmdAlias implements the alias command which lists the aliases of the config file
+func newCmdAlias(streams genericclioptions.IOStreams) *cobra.Command {
+	aliasCmd := &cobra.Command{
+		Use:               "alias",
+		Short:             "Provides utilities for interacting with the aliases of the config file",
+		Long:              aliasLong,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	aliasCmd.AddCommand(&cobra.Command{
+		Use:               "list",
+		Short:             "List the aliases of the config file and the commands they expand to",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			aliases := alias.Aliases()
+			if len(aliases) == 0 {
+				fmt.Fprintf(streams.ErrOut, "No alias in the '%s' section of the config file\n", alias.ConfigKey)
+				return nil
+			}
+			table := printer.NewTablePrinter(streams.Out, 20, 1, 3, ' ')
+			table.AddRow([]string{"ALIAS", "COMMAND"})
+			for _, name := range alias.Names(aliases) {
+				table.AddRow([]string{name, "osdctl " + aliases[name]})
+			}
+			return table.Flush()
+		},
+	})
+
+	return aliasCmd
+}
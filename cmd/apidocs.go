@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const apiDocsLong = `Print a catalog of every command with its flags, its positional arguments and whether it changes anything.
+
+The catalog is meant for automation, e.g. chatops bots generating wrappers that only expose the read-only commands:
+use '-o json' or '-o yaml'. Whether a command changes anything comes from its '` + catalog.MutationAnnotation + `'
+annotation when set, and is otherwise inferred from its name and flags, see 'mutation_source'.`
+
+type apiDocsResponse struct {
+	catalog.Catalog `yaml:",inline"`
+}
+
+func (r apiDocsResponse) String() string {
+	var b bytes.Buffer
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"COMMAND", "MUTATION", "DESCRIPTION"})
+	for _, command := range r.Commands {
+		table.AddRow([]string{command.Command, command.Mutation, command.Short})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the catalog: %v", err)
+	}
+	return b.String()
+}
+
+// newCmdAPIDocs implements the api-docs command which prints the catalog of the commands
+func newCmdAPIDocs(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:               "api-docs",
+		Short:             "Print the catalog of the commands, their flags and arguments for automation",
+		Long:              apiDocsLong,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Annotations:       map[string]string{catalog.MutationAnnotation: catalog.MutationReadOnly},
+		Run: func(cmd *cobra.Command, args []string) {
+			resp := apiDocsResponse{catalog.Build(cmd.Root(), utils.Version)}
+			osdctlErrors.CheckErr(outputflag.PrintResponse(globalOpts.Output, resp))
+		},
+	}
+}
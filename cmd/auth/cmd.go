@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewCmdAuth implements the auth command, managing the credentials osdctl hands out to automation
+func NewCmdAuth() *cobra.Command {
+	authCmd := &cobra.Command{
+		Use:               "auth",
+		Short:             "Manages the credentials osdctl hands out to automation",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	authCmd.AddCommand(newCmdMintToken())
+
+	return authCmd
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/guardrails"
+	"github.com/openshift/osdctl/pkg/osdctlConfig"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/scopes"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+var mintTokenLong = `Write a config profile for the automation running osdctl reports, instead of sharing an SRE offline token.
+
+The profile turns read-only mode on, refusing every call that would change something, and only allows the commands
+of the given scopes. Point osdctl at it with ` + osdctlConfig.ConfigEnv + `. The profile holds either:
+
+  * the current OCM access token, which expires within minutes and can't be refreshed, for a single short job
+  * with --client-id, the client credentials of a service account, whose secret is read from ` + utils.OCMClientSecretEnv + `.
+    The OCM permissions of the service account are the ones it was granted in the console.
+
+The scopes are: ` + strings.Join(scopes.Names(), ", ") + `. The profile is only readable by the current user, and the
+minting is recorded in the audit log.`
+
+const mintTokenExample = `
+  # Profile for a CI job listing the clusters and service logs, using the current access token
+  osdctl auth mint-token --scopes read:clusters,read:servicelogs --file ci-profile.yaml
+
+  # Profile using the client credentials of a service account, for a scheduled report
+  OCM_CLIENT_SECRET=... osdctl auth mint-token --scopes read:fleet --client-id 0a1b2c3d-service-account --file report-profile.yaml
+
+  # Run the report with the profile
+  OSDCTL_CONFIG=report-profile.yaml osdctl fleet compliance --policy-file policies.yaml -o csv
+`
+
+type mintTokenOptions struct {
+	scopes   []string
+	file     string
+	clientID string
+}
+
+// profile is the config file written for the automation, see the keys read by utils.NewConnection
+type profile struct {
+	ReadOnly        bool     `json:"read_only"`
+	Scopes          []string `json:"scopes"`
+	OCMAuth         string   `json:"ocm_auth,omitempty"`
+	OCMURL          string   `json:"ocm_url"`
+	OCMToken        string   `json:"ocm_token,omitempty"`
+	OCMClientID     string   `json:"ocm_client_id,omitempty"`
+	OCMClientSecret string   `json:"ocm_client_secret,omitempty"`
+}
+
+func newCmdMintToken() *cobra.Command {
+	ops := &mintTokenOptions{}
+	mintTokenCmd := &cobra.Command{
+		Use:               "mint-token",
+		Short:             "Write a read-only config profile restricted to some commands, for automation",
+		Long:              mintTokenLong,
+		Example:           mintTokenExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run(cmd))
+		},
+	}
+
+	mintTokenCmd.Flags().StringSliceVar(&ops.scopes, "scopes", nil, "Comma separated scopes of the commands the profile allows: "+strings.Join(scopes.Names(), ", "))
+	mintTokenCmd.Flags().StringVarP(&ops.file, "file", "f", "", "Path of the profile to write, it must not exist")
+	mintTokenCmd.Flags().StringVar(&ops.clientID, "client-id", "", "Client ID of the service account the profile authenticates as, with the secret from "+utils.OCMClientSecretEnv)
+	_ = mintTokenCmd.MarkFlagRequired("scopes")
+	_ = mintTokenCmd.MarkFlagRequired("file")
+
+	return mintTokenCmd
+}
+
+func (o *mintTokenOptions) complete(cmd *cobra.Command) error {
+	if err := scopes.Validate(o.scopes); err != nil {
+		return cmdutil.UsageErrorf(cmd, "%v", err)
+	}
+	if o.clientID != "" && os.Getenv(utils.OCMClientSecretEnv) == "" {
+		return cmdutil.UsageErrorf(cmd, "--client-id requires the client secret in %s", utils.OCMClientSecretEnv)
+	}
+	if _, err := os.Stat(o.file); err == nil {
+		return cmdutil.UsageErrorf(cmd, "%s exists already, remove it or choose another --file", o.file)
+	}
+	return nil
+}
+
+func (o *mintTokenOptions) run(cmd *cobra.Command) error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	p := profile{ReadOnly: true, Scopes: o.scopes, OCMURL: connection.URL()}
+	var expiresAt *time.Time
+	if o.clientID != "" {
+		p.OCMAuth = utils.OCMAuthClientCredentials
+		p.OCMClientID = o.clientID
+		p.OCMClientSecret = os.Getenv(utils.OCMClientSecretEnv)
+		if err := checkClientCredentials(p.OCMURL, p.OCMClientID, p.OCMClientSecret); err != nil {
+			return err
+		}
+	} else {
+		token, _, err := connection.Tokens()
+		if err != nil {
+			return fmt.Errorf("cannot get an OCM access token: %w", err)
+		}
+		expiry, err := utils.TokenExpiry(token)
+		if err != nil {
+			return fmt.Errorf("cannot read the expiry of the OCM access token: %w", err)
+		}
+		p.OCMToken = token
+		expiresAt = &expiry
+	}
+
+	if err := writeProfile(o.file, p); err != nil {
+		return err
+	}
+
+	if err := guardrails.WriteAuditRecord(guardrails.AuditRecord{
+		Command:     cmd.CommandPath(),
+		Args:        append([]string{o.file}, o.scopes...),
+		Environment: utils.GetCurrentOCMEnv(connection),
+		ExpiresAt:   expiresAt,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to record the profile in the audit log: %v\n", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Profile scoped to %s written to %s, use it with %s=%s\n", strings.Join(o.scopes, ", "), o.file, osdctlConfig.ConfigEnv, o.file)
+	if expiresAt != nil {
+		fmt.Fprintf(os.Stderr, "The access token expires at %s, use --client-id for jobs running longer\n", timefmt.Format(*expiresAt))
+	}
+	return nil
+}
+
+// checkClientCredentials gets a token with the client credentials, so that a wrong secret fails now rather than in CI
+func checkClientCredentials(url, clientID, clientSecret string) error {
+	connection, err := sdk.NewConnectionBuilder().URL(url).Client(clientID, clientSecret).Build()
+	if err != nil {
+		return fmt.Errorf("cannot create an OCM connection for client %s: %w", clientID, err)
+	}
+	defer connection.Close()
+	if _, _, err := connection.Tokens(); err != nil {
+		return osdctlErrors.Wrap(osdctlErrors.ErrForbidden, fmt.Errorf("cannot authenticate as client %s: %w", clientID, err))
+	}
+	return nil
+}
+
+// writeProfile writes the profile, only readable by the current user, failing if the file exists
+func writeProfile(path string, p profile) error {
+	content, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Written by 'osdctl auth mint-token' on %s, it holds OCM credentials\n", time.Now().UTC().Format(time.RFC3339))
+	b.Write(content)
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600) //#nosec G304 -- path is given by the user
+	if err != nil {
+		return fmt.Errorf("cannot create the profile: %w", err)
+	}
+	if _, err := file.Write(b.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("cannot write the profile: %w", err)
+	}
+	return file.Close()
+}
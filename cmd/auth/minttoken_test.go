@@ -0,0 +1,33 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+func TestWriteProfile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	path := filepath.Join(t.TempDir(), "ci-profile.yaml")
+
+	g.Expect(writeProfile(path, profile{ReadOnly: true, Scopes: []string{"read:clusters"}, OCMURL: "https://api.openshift.com", OCMToken: "token"})).To(Succeed())
+	info, err := os.Stat(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	// The profile is read back as a config file
+	config := viper.New()
+	config.SetConfigFile(path)
+	config.SetConfigType("yaml")
+	g.Expect(config.ReadInConfig()).To(Succeed())
+	g.Expect(config.GetBool("read_only")).To(BeTrue())
+	g.Expect(config.GetStringSlice("scopes")).To(Equal([]string{"read:clusters"}))
+	g.Expect(config.GetString("ocm_token")).To(Equal("token"))
+	g.Expect(config.IsSet("ocm_client_secret")).To(BeFalse())
+
+	// An existing file isn't overwritten
+	g.Expect(writeProfile(path, profile{})).NotTo(Succeed())
+}
@@ -0,0 +1,23 @@
+package aws
+
+import (
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdAws implements the base aws command
+func NewCmdAws(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	awsCmd := &cobra.Command{
+		Use:               "aws",
+		Short:             "AWS organization related utilities",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	awsCmd.AddCommand(newCmdTagAudit(globalOpts))
+	awsCmd.AddCommand(NewCmdSupportCase())
+	return awsCmd
+}
@@ -0,0 +1,317 @@
+package aws
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/support"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	"github.com/openshift/osdctl/cmd/common"
+	osdCloud "github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	supportCaseCreateLongDescription = `
+Opens an AWS support case from the account of a cluster, through the same role chain as 'osdctl account cli'.
+
+With -C, the IDs of the EC2 instances of the cluster and of their EBS volumes are appended to the body of the
+case, so that AWS support doesn't need to ask for them. More resources can be added with --resource.
+`
+	supportCaseCreateExample = `
+  # Open a case about the instances of a cluster
+  osdctl aws support-case create -C 1kfmyclusteristhebesteverp8m --subject "Instance unreachable" --body-file notes.md
+
+  # Open an urgent case about an EBS volume of an account, reading the body from stdin
+  osdctl aws support-case create -i 123456789012 --subject "Volume stuck attaching" --severity urgent \
+    --service amazon-elastic-block-store --resource vol-0123456789abcdef0 --body-file -
+`
+
+	defaultSupportCaseService  = "amazon-elastic-compute-cloud-linux"
+	defaultSupportCaseCategory = "other"
+	defaultSupportCaseSeverity = "normal"
+	supportCaseLanguage        = "en"
+)
+
+// supportCaseSeverities are the severity codes accepted by AWS support, from the lowest to the highest
+var supportCaseSeverities = []string{"low", "normal", "high", "urgent", "critical"}
+
+// NewCmdSupportCase implements the support-case command grouping the AWS support case utilities, it is also
+// registered under 'account' for the scripts using its former location
+func NewCmdSupportCase() *cobra.Command {
+	supportCaseCmd := &cobra.Command{
+		Use:               "support-case",
+		Short:             "AWS support case related utilities",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	supportCaseCmd.AddCommand(newCmdSupportCaseCreate())
+
+	return supportCaseCmd
+}
+
+// supportCaseCreateOptions defines the struct for running the support-case create command
+type supportCaseCreateOptions struct {
+	awsAccountID string
+	awsProfile   string
+	region       string
+	clusterID    string
+
+	subject    string
+	bodyFile   string
+	service    string
+	category   string
+	severity   string
+	resources  []string
+	skipPrompt bool
+
+	body string
+}
+
+func newCmdSupportCaseCreate() *cobra.Command {
+	ops := &supportCaseCreateOptions{}
+	createCmd := &cobra.Command{
+		Use:               "create",
+		Short:             "Open an AWS support case from the account of a cluster",
+		Long:              supportCaseCreateLongDescription,
+		Example:           supportCaseCreateExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, os.Stdin))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	createCmd.Flags().StringVarP(&ops.awsAccountID, "accountId", "i", "", "AWS Account ID")
+	createCmd.Flags().StringVarP(&ops.clusterID, "clusterID", "C", "", "Cluster ID")
+	createCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS Profile")
+	createCmd.Flags().StringVarP(&ops.region, "region", "r", "", "Region of the resources with -i, defaults to us-east-1. The region of the cluster is used with -C")
+	createCmd.Flags().StringVar(&ops.subject, "subject", "", "Subject of the case")
+	createCmd.Flags().StringVar(&ops.bodyFile, "body-file", "", "File containing the body of the case, - to read it from stdin")
+	createCmd.Flags().StringVar(&ops.service, "service", defaultSupportCaseService, "AWS service code of the case, see 'aws support describe-services'")
+	createCmd.Flags().StringVar(&ops.category, "category", defaultSupportCaseCategory, "Category code of the case within the service")
+	createCmd.Flags().StringVar(&ops.severity, "severity", defaultSupportCaseSeverity, "Severity of the case: "+strings.Join(supportCaseSeverities, ", "))
+	createCmd.Flags().StringSliceVar(&ops.resources, "resource", nil, "ID of a resource to mention in the case, can be repeated")
+	createCmd.Flags().BoolVarP(&ops.skipPrompt, "yes", "y", false, "Skip the confirmation prompt")
+	_ = createCmd.MarkFlagRequired("subject")
+	_ = createCmd.MarkFlagRequired("body-file")
+
+	return createCmd
+}
+
+func (o *supportCaseCreateOptions) complete(cmd *cobra.Command, stdin io.Reader) error {
+	if o.awsAccountID == "" && o.clusterID == "" {
+		return cmdutil.UsageErrorf(cmd, "please specify -i or -C")
+	}
+	if o.awsAccountID != "" && o.clusterID != "" {
+		return cmdutil.UsageErrorf(cmd, "-i and -C are mutually exclusive, please only specify one")
+	}
+	if strings.TrimSpace(o.subject) == "" {
+		return cmdutil.UsageErrorf(cmd, "--subject can't be empty")
+	}
+	if !isSupportCaseSeverity(o.severity) {
+		return cmdutil.UsageErrorf(cmd, "invalid severity %q, must be one of %s", o.severity, strings.Join(supportCaseSeverities, ", "))
+	}
+
+	var body []byte
+	var err error
+	if o.bodyFile == "-" {
+		body, err = io.ReadAll(stdin)
+	} else {
+		body, err = os.ReadFile(o.bodyFile) //#nosec G304 -- path is provided by the user
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read the body of the case: %w", err)
+	}
+	o.body = strings.TrimSpace(string(body))
+	if o.body == "" {
+		return cmdutil.UsageErrorf(cmd, "the body of the case can't be empty")
+	}
+
+	return nil
+}
+
+func (o *supportCaseCreateOptions) run() error {
+	var awsClient awsprovider.Client
+	var err error
+	summary := &utils.ImpactSummary{}
+	resources := o.resources
+
+	if o.clusterID != "" {
+		ocmClient := utils.CreateConnection()
+		defer ocmClient.Close()
+
+		cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+		if err != nil {
+			return err
+		}
+		if cluster.CloudProvider().ID() != "aws" {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s is not an AWS cluster", cluster.ID())
+		}
+		o.awsAccountID, err = utils.GetAWSAccountIdForCluster(ocmClient, cluster.ID())
+		if err != nil {
+			return err
+		}
+		summary = utils.NewClusterImpactSummary(ocmClient, cluster, "")
+
+		// The role chain builds the client in the region of the cluster
+		awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+		if err != nil {
+			return err
+		}
+
+		clusterResources, err := clusterResourceIDs(awsClient, cluster.InfraID())
+		if err != nil {
+			return fmt.Errorf("cannot list the resources of cluster %s: %w", cluster.ID(), err)
+		}
+		resources = append(resources, clusterResources...)
+	} else {
+		awsClient, err = o.accountClient()
+		if err != nil {
+			return err
+		}
+	}
+	summary.Action = fmt.Sprintf("open a %s AWS support case in account %s", o.severity, o.awsAccountID)
+
+	input := o.caseInput(resources)
+	fmt.Fprintf(os.Stderr, "Subject:   %s\nService:   %s (%s)\n", o.subject, o.service, o.category)
+	if len(resources) > 0 {
+		fmt.Fprintf(os.Stderr, "Resources: %s\n", strings.Join(uniqueSorted(resources), ", "))
+	}
+	fmt.Fprintln(os.Stderr)
+	if err := utils.Confirm(utils.ConfirmOptions{Summary: summary, SkipPrompt: o.skipPrompt}); err != nil {
+		return err
+	}
+
+	output, err := awsClient.CreateCase(input)
+	if err != nil {
+		return fmt.Errorf("cannot create the support case: %w", err)
+	}
+	fmt.Printf("Created AWS support case %s in account %s\n", awsSdk.StringValue(output.CaseId), o.awsAccountID)
+
+	return nil
+}
+
+// accountClient builds a client for the account given with -i through OrganizationAccountAccessRole
+func (o *supportCaseCreateOptions) accountClient() (awsprovider.Client, error) {
+	region := o.region
+	if region == "" {
+		region = common.DefaultRegion
+	}
+
+	payerClient, err := awsprovider.NewAwsClient(o.awsProfile, region, "")
+	if err != nil {
+		return nil, err
+	}
+
+	partition, err := awsprovider.GetAwsPartition(payerClient)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionName, err := osdCloud.GenerateRoleSessionName(payerClient)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate session name: %w", err)
+	}
+
+	creds, err := osdCloud.GenerateOrganizationAccountAccessCredentials(payerClient, o.awsAccountID, sessionName, partition)
+	if err != nil {
+		return nil, fmt.Errorf("could not assume OrganizationAccountAccessRole in %s: %w", o.awsAccountID, err)
+	}
+
+	return awsprovider.NewAwsClientWithInput(&awsprovider.AwsClientInput{
+		AccessKeyID:     *creds.AccessKeyId,
+		SecretAccessKey: *creds.SecretAccessKey,
+		SessionToken:    *creds.SessionToken,
+		Region:          region,
+	})
+}
+
+// caseInput builds the request creating the case, with the resource IDs listed at the end of the body
+func (o *supportCaseCreateOptions) caseInput(resources []string) *support.CreateCaseInput {
+	body := o.body
+	if len(resources) > 0 {
+		body += "\n\nResources:\n"
+		for _, resource := range uniqueSorted(resources) {
+			body += "- " + resource + "\n"
+		}
+	}
+
+	return &support.CreateCaseInput{
+		Subject:           awsSdk.String(o.subject),
+		CommunicationBody: awsSdk.String(body),
+		ServiceCode:       awsSdk.String(o.service),
+		CategoryCode:      awsSdk.String(o.category),
+		SeverityCode:      awsSdk.String(o.severity),
+		Language:          awsSdk.String(supportCaseLanguage),
+		IssueType:         awsSdk.String("technical"),
+	}
+}
+
+// clusterResourceIDs returns the IDs of the instances owned by the cluster and of their EBS volumes
+func clusterResourceIDs(awsClient awsprovider.Client, infraID string) ([]string, error) {
+	var ids []string
+	input := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{{
+			Name:   awsSdk.String("tag:kubernetes.io/cluster/" + infraID),
+			Values: []*string{awsSdk.String("owned")},
+		}},
+	}
+	for {
+		output, err := awsClient.DescribeInstances(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				ids = append(ids, awsSdk.StringValue(instance.InstanceId))
+				for _, mapping := range instance.BlockDeviceMappings {
+					if mapping.Ebs != nil {
+						ids = append(ids, awsSdk.StringValue(mapping.Ebs.VolumeId))
+					}
+				}
+			}
+		}
+		if output.NextToken == nil {
+			return ids, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+func isSupportCaseSeverity(severity string) bool {
+	for _, s := range supportCaseSeverities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueSorted(values []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	sort.Strings(unique)
+	return unique
+}
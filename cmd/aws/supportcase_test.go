@@ -0,0 +1,120 @@
+package aws
+
+import (
+	"strings"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/spf13/cobra"
+)
+
+func TestSupportCaseCreateComplete(t *testing.T) {
+	g := NewGomegaWithT(t)
+	testCases := []struct {
+		title       string
+		option      *supportCaseCreateOptions
+		stdin       string
+		errExpected bool
+		errContent  string
+	}{
+		{
+			title:       "no account nor cluster",
+			option:      &supportCaseCreateOptions{subject: "subject", bodyFile: "-", severity: "normal"},
+			errExpected: true,
+			errContent:  "please specify -i or -C",
+		},
+		{
+			title:       "both account and cluster",
+			option:      &supportCaseCreateOptions{awsAccountID: "123456789012", clusterID: "cluster", subject: "subject", bodyFile: "-", severity: "normal"},
+			errExpected: true,
+			errContent:  "mutually exclusive",
+		},
+		{
+			title:       "invalid severity",
+			option:      &supportCaseCreateOptions{awsAccountID: "123456789012", subject: "subject", bodyFile: "-", severity: "blocker"},
+			errExpected: true,
+			errContent:  "invalid severity",
+		},
+		{
+			title:       "empty body",
+			option:      &supportCaseCreateOptions{awsAccountID: "123456789012", subject: "subject", bodyFile: "-", severity: "normal"},
+			stdin:       "  \n",
+			errExpected: true,
+			errContent:  "body of the case can't be empty",
+		},
+		{
+			title:  "body from stdin",
+			option: &supportCaseCreateOptions{awsAccountID: "123456789012", subject: "subject", bodyFile: "-", severity: "urgent"},
+			stdin:  "The volume is stuck attaching\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.option.complete(&cobra.Command{}, strings.NewReader(tc.stdin))
+			if tc.errExpected {
+				g.Expect(err).Should(HaveOccurred())
+				g.Expect(err.Error()).Should(ContainSubstring(tc.errContent))
+			} else {
+				g.Expect(err).ShouldNot(HaveOccurred())
+				g.Expect(tc.option.body).Should(Equal(strings.TrimSpace(tc.stdin)))
+			}
+		})
+	}
+}
+
+func TestSupportCaseInput(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := &supportCaseCreateOptions{
+		subject:  "Volume stuck attaching",
+		body:     "The volume is stuck attaching",
+		service:  defaultSupportCaseService,
+		category: defaultSupportCaseCategory,
+		severity: "high",
+	}
+
+	input := o.caseInput([]string{"vol-2", "i-1", "vol-2", ""})
+	g.Expect(awsSdk.StringValue(input.CommunicationBody)).Should(Equal("The volume is stuck attaching\n\nResources:\n- i-1\n- vol-2\n"))
+	g.Expect(awsSdk.StringValue(input.SeverityCode)).Should(Equal("high"))
+	g.Expect(awsSdk.StringValue(input.ServiceCode)).Should(Equal(defaultSupportCaseService))
+	g.Expect(input.Validate()).ShouldNot(HaveOccurred())
+
+	input = o.caseInput(nil)
+	g.Expect(awsSdk.StringValue(input.CommunicationBody)).Should(Equal("The volume is stuck attaching"))
+}
+
+func TestClusterResourceIDs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockClient := awsmock.NewMockClient(gomock.NewController(t))
+
+	instance := func(id string, volumes ...string) *ec2.Instance {
+		i := &ec2.Instance{InstanceId: awsSdk.String(id)}
+		for _, volume := range volumes {
+			i.BlockDeviceMappings = append(i.BlockDeviceMappings, &ec2.InstanceBlockDeviceMapping{
+				Ebs: &ec2.EbsInstanceBlockDevice{VolumeId: awsSdk.String(volume)},
+			})
+		}
+		return i
+	}
+
+	gomock.InOrder(
+		mockClient.EXPECT().DescribeInstances(gomock.Any()).DoAndReturn(func(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+			g.Expect(awsSdk.StringValue(input.Filters[0].Name)).Should(Equal("tag:kubernetes.io/cluster/mycluster-abcde"))
+			return &ec2.DescribeInstancesOutput{
+				Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{instance("i-1", "vol-1", "vol-2")}}},
+				NextToken:    awsSdk.String("next"),
+			}, nil
+		}),
+		mockClient.EXPECT().DescribeInstances(gomock.Any()).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{instance("i-2")}}},
+		}, nil),
+	)
+
+	ids, err := clusterResourceIDs(mockClient, "mycluster-abcde")
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(ids).Should(Equal([]string{"i-1", "vol-1", "vol-2", "i-2"}))
+}
@@ -0,0 +1,467 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/openshift/osdctl/cmd/common"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	// RequiredTagsConfigKey overrides the tags every billable resource must have
+	RequiredTagsConfigKey = "tag_audit_required_tags"
+
+	// clusterIDTag is set by OCM on the resources of ROSA and OSD clusters, it fills the cluster-id tag with --fix
+	clusterIDTag = "api.openshift.com/id"
+	// maxTagResourcesARNs is the maximum number of resources TagResources takes at once
+	maxTagResourcesARNs = 20
+
+	tagAuditLong = `Scans the accounts of an organizational unit, and its child OUs, for billable resources missing one of the
+required tags, and reports the violations per account.
+
+The required tags are owner, cluster-id and expiry, or the list set in the config file:
+
+  tag_audit_required_tags: [owner, cluster-id, expiry, cost-center]
+
+With --fix, the missing tags are applied: cluster-id is taken from the api.openshift.com/id tag OCM sets on the
+resources of a cluster, the other tags from --set. A resource whose missing tag has no value is reported as not
+fixed.
+
+The resources are found with the resource groups tagging API, which only knows the resources which are or were
+tagged. Every account is accessed through OrganizationAccountAccessRole from the profile's payer account.`
+
+	tagAuditExample = `
+  # Report the resources missing a required tag in the accounts of an OU
+  osdctl aws tag-audit --ou ou-abcd-12345678 -p osd-staging-1
+
+  # Only instances and volumes, in two regions
+  osdctl aws tag-audit --ou ou-abcd-12345678 --region us-east-1 --region eu-west-1 --resource-type ec2:instance --resource-type ec2:volume
+
+  # Apply the missing tags
+  osdctl aws tag-audit --ou ou-abcd-12345678 --fix --set owner=sre-team --set expiry=2024-12-31
+`
+)
+
+// billableResourceTypes are the resource types audited by default
+var billableResourceTypes = []string{
+	"ec2:instance",
+	"ec2:volume",
+	"ec2:snapshot",
+	"ec2:natgateway",
+	"ec2:elastic-ip",
+	"elasticloadbalancing:loadbalancer",
+	"rds:db",
+	"s3",
+}
+
+func init() {
+	viper.SetDefault(RequiredTagsConfigKey, []string{"owner", "cluster-id", "expiry"})
+}
+
+type tagAuditOptions struct {
+	ou            string
+	awsProfile    string
+	regions       []string
+	resourceTypes []string
+	fix           bool
+	set           []string
+	skipPrompt    bool
+
+	requiredTags []string
+	values       map[string]string
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// tagViolation is a resource missing required tags
+type tagViolation struct {
+	AccountID   string   `json:"account_id" yaml:"account_id"`
+	Region      string   `json:"region" yaml:"region"`
+	ARN         string   `json:"arn" yaml:"arn"`
+	MissingTags []string `json:"missing_tags" yaml:"missing_tags"`
+	// Result is set with --fix: fixed, not fixed or the error
+	Result string `json:"result,omitempty" yaml:"result,omitempty"`
+
+	tags map[string]string
+}
+
+// accountTagAudit is the audit of an account
+type accountTagAudit struct {
+	AccountID  string         `json:"account_id" yaml:"account_id"`
+	Resources  int            `json:"resources" yaml:"resources"`
+	Violations []tagViolation `json:"violations" yaml:"violations"`
+	Error      string         `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type tagAuditResponse struct {
+	OU           string            `json:"ou" yaml:"ou"`
+	RequiredTags []string          `json:"required_tags" yaml:"required_tags"`
+	Accounts     []accountTagAudit `json:"accounts" yaml:"accounts"`
+}
+
+func (r tagAuditResponse) String() string {
+	var b bytes.Buffer
+	summary := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	summary.AddRow([]string{"ACCOUNT", "RESOURCES", "VIOLATIONS", "ERROR"})
+	for _, account := range r.Accounts {
+		summary.AddRow([]string{account.AccountID, fmt.Sprint(account.Resources), fmt.Sprint(len(account.Violations)), account.Error})
+	}
+	// Add empty row for readability
+	summary.AddRow([]string{})
+	_ = summary.Flush()
+
+	violations := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	violations.AddRow([]string{"ACCOUNT", "REGION", "RESOURCE", "MISSING TAGS", "RESULT"})
+	count := 0
+	for _, account := range r.Accounts {
+		for _, violation := range account.Violations {
+			violations.AddRow([]string{violation.AccountID, violation.Region, violation.ARN, strings.Join(violation.MissingTags, ", "), violation.Result})
+			count++
+		}
+	}
+	if count == 0 {
+		fmt.Fprintf(&b, "Every resource has the required tags: %s\n", strings.Join(r.RequiredTags, ", "))
+		return b.String()
+	}
+	violations.AddRow([]string{})
+	_ = violations.Flush()
+	return b.String()
+}
+
+func newCmdTagAudit(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &tagAuditOptions{GlobalOptions: globalOpts}
+	tagAuditCmd := &cobra.Command{
+		Use:               "tag-audit --ou OU_ID",
+		Short:             "Report the billable resources of an OU's accounts missing required tags, and apply them with --fix",
+		Long:              tagAuditLong,
+		Example:           tagAuditExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	tagAuditCmd.Flags().StringVar(&ops.ou, "ou", "", "ID of the organizational unit to audit, e.g. ou-abcd-12345678")
+	tagAuditCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile of the organization's payer account")
+	tagAuditCmd.Flags().StringArrayVar(&ops.regions, "region", nil, "Region to audit, repeatable, defaults to the global region of the partition of the payer account")
+	tagAuditCmd.Flags().StringArrayVar(&ops.resourceTypes, "resource-type", billableResourceTypes, "Resource type to audit, repeatable, e.g. ec2:instance")
+	tagAuditCmd.Flags().BoolVar(&ops.fix, "fix", false, "Apply the missing tags")
+	tagAuditCmd.Flags().StringArrayVar(&ops.set, "set", nil, "Value of a missing tag applied with --fix, as KEY=VALUE, repeatable")
+	tagAuditCmd.Flags().BoolVarP(&ops.skipPrompt, "yes", "y", false, "Don't ask for confirmation before applying the tags")
+	_ = tagAuditCmd.MarkFlagRequired("ou")
+
+	return tagAuditCmd
+}
+
+func (o *tagAuditOptions) complete(cmd *cobra.Command) error {
+	if !strings.HasPrefix(o.ou, "ou-") {
+		return cmdutil.UsageErrorf(cmd, "--ou must be an organizational unit ID, e.g. ou-abcd-12345678")
+	}
+	if len(o.set) > 0 && !o.fix {
+		return cmdutil.UsageErrorf(cmd, "--set is only used with --fix")
+	}
+
+	o.requiredTags = viper.GetStringSlice(RequiredTagsConfigKey)
+	if len(o.requiredTags) == 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "'%s' is empty in the config file, no tag to audit", RequiredTagsConfigKey)
+	}
+
+	o.values = map[string]string{}
+	for _, value := range o.set {
+		key, v, ok := strings.Cut(value, "=")
+		if !ok || key == "" || v == "" {
+			return cmdutil.UsageErrorf(cmd, "invalid --set '%s', expected KEY=VALUE", value)
+		}
+		o.values[key] = v
+	}
+	return nil
+}
+
+func (o *tagAuditOptions) run() error {
+	payerClient, err := awsprovider.NewAwsClient(o.awsProfile, common.DefaultRegion, "")
+	if err != nil {
+		return err
+	}
+	sessionName, err := osdCloud.GenerateRoleSessionName(payerClient)
+	if err != nil {
+		return fmt.Errorf("could not generate session name: %w", err)
+	}
+	partition, err := awsprovider.GetAwsPartition(payerClient)
+	if err != nil {
+		return err
+	}
+	if len(o.regions) == 0 {
+		o.regions = []string{awsprovider.GlobalRegion(partition)}
+	}
+
+	accountIDs, err := listOUAccounts(payerClient, o.ou)
+	if err != nil {
+		return fmt.Errorf("cannot list the accounts of %s: %w", o.ou, err)
+	}
+
+	response := tagAuditResponse{OU: o.ou, RequiredTags: o.requiredTags, Accounts: []accountTagAudit{}}
+	clients := map[string]map[string]awsprovider.Client{}
+	for i, accountID := range accountIDs {
+		fmt.Fprintf(os.Stderr, "Auditing account %s (%d/%d)\n", accountID, i+1, len(accountIDs))
+		audit := accountTagAudit{AccountID: accountID, Violations: []tagViolation{}}
+		regionClients, err := o.accountClients(payerClient, accountID, sessionName, partition)
+		if err != nil {
+			audit.Error = err.Error()
+			response.Accounts = append(response.Accounts, audit)
+			continue
+		}
+		clients[accountID] = regionClients
+		for _, region := range o.regions {
+			resources, violations, err := auditResources(regionClients[region], accountID, region, o.resourceTypes, o.requiredTags)
+			if err != nil {
+				audit.Error = fmt.Sprintf("%s: %v", region, err)
+				break
+			}
+			audit.Resources += resources
+			audit.Violations = append(audit.Violations, violations...)
+		}
+		response.Accounts = append(response.Accounts, audit)
+	}
+
+	if o.fix {
+		if err := o.fixViolations(response, clients); err != nil {
+			return err
+		}
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// accountClients returns a client for every audited region of the account, through OrganizationAccountAccessRole
+func (o *tagAuditOptions) accountClients(payerClient awsprovider.Client, accountID, sessionName, partition string) (map[string]awsprovider.Client, error) {
+	creds, err := osdCloud.GenerateOrganizationAccountAccessCredentials(payerClient, accountID, sessionName, partition)
+	if err != nil {
+		return nil, fmt.Errorf("could not assume OrganizationAccountAccessRole in %s: %w", accountID, err)
+	}
+	clients := map[string]awsprovider.Client{}
+	for _, region := range o.regions {
+		client, err := awsprovider.NewAwsClientWithInput(&awsprovider.AwsClientInput{
+			AccessKeyID:     *creds.AccessKeyId,
+			SecretAccessKey: *creds.SecretAccessKey,
+			SessionToken:    *creds.SessionToken,
+			Region:          region,
+		})
+		if err != nil {
+			return nil, err
+		}
+		clients[region] = client
+	}
+	return clients, nil
+}
+
+// listOUAccounts returns the active accounts of the OU and of its child OUs
+func listOUAccounts(client awsprovider.Client, ou string) ([]string, error) {
+	var accountIDs []string
+	input := &organizations.ListAccountsForParentInput{ParentId: awsSdk.String(ou)}
+	for {
+		output, err := client.ListAccountsForParent(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range output.Accounts {
+			if awsSdk.StringValue(account.Status) == organizations.AccountStatusActive {
+				accountIDs = append(accountIDs, awsSdk.StringValue(account.Id))
+			}
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	ouInput := &organizations.ListOrganizationalUnitsForParentInput{ParentId: awsSdk.String(ou)}
+	for {
+		output, err := client.ListOrganizationalUnitsForParent(ouInput)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range output.OrganizationalUnits {
+			childAccountIDs, err := listOUAccounts(client, awsSdk.StringValue(child.Id))
+			if err != nil {
+				return nil, err
+			}
+			accountIDs = append(accountIDs, childAccountIDs...)
+		}
+		if output.NextToken == nil {
+			break
+		}
+		ouInput.NextToken = output.NextToken
+	}
+	return accountIDs, nil
+}
+
+// auditResources returns how many resources of the types the region has, and the ones missing required tags
+func auditResources(client awsprovider.Client, accountID, region string, resourceTypes, requiredTags []string) (int, []tagViolation, error) {
+	count := 0
+	var violations []tagViolation
+	input := &resourcegroupstaggingapi.GetResourcesInput{ResourceTypeFilters: awsSdk.StringSlice(resourceTypes)}
+	for {
+		output, err := client.GetResources(input)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, resource := range output.ResourceTagMappingList {
+			count++
+			tags := map[string]string{}
+			for _, tag := range resource.Tags {
+				tags[awsSdk.StringValue(tag.Key)] = awsSdk.StringValue(tag.Value)
+			}
+			var missing []string
+			for _, required := range requiredTags {
+				if strings.TrimSpace(tags[required]) == "" {
+					missing = append(missing, required)
+				}
+			}
+			if len(missing) > 0 {
+				violations = append(violations, tagViolation{
+					AccountID:   accountID,
+					Region:      region,
+					ARN:         awsSdk.StringValue(resource.ResourceARN),
+					MissingTags: missing,
+					tags:        tags,
+				})
+			}
+		}
+		if awsSdk.StringValue(output.PaginationToken) == "" {
+			break
+		}
+		input.PaginationToken = output.PaginationToken
+	}
+	return count, violations, nil
+}
+
+// missingTagValues returns the values of the tags missing on the resource, and the missing tags without a value
+func missingTagValues(violation tagViolation, values map[string]string) (map[string]string, []string) {
+	fix := map[string]string{}
+	var unknown []string
+	for _, tag := range violation.MissingTags {
+		value := values[tag]
+		if tag == "cluster-id" && violation.tags[clusterIDTag] != "" {
+			value = violation.tags[clusterIDTag]
+		}
+		if value == "" {
+			unknown = append(unknown, tag)
+			continue
+		}
+		fix[tag] = value
+	}
+	return fix, unknown
+}
+
+// tagBatch is a set of resources of an account and region getting the same tags
+type tagBatch struct {
+	accountID string
+	region    string
+	tags      map[string]string
+	arns      []string
+	// violations are the violations of the batch, in the order of arns, to record the result
+	violations []*tagViolation
+}
+
+// fixViolations applies the missing tags, grouping the resources getting the same tags
+func (o *tagAuditOptions) fixViolations(response tagAuditResponse, clients map[string]map[string]awsprovider.Client) error {
+	batches := map[string]*tagBatch{}
+	var keys []string
+	for a := range response.Accounts {
+		for v := range response.Accounts[a].Violations {
+			violation := &response.Accounts[a].Violations[v]
+			tags, unknown := missingTagValues(*violation, o.values)
+			if len(unknown) > 0 {
+				violation.Result = fmt.Sprintf("not fixed, no value for %s, use --set", strings.Join(unknown, ", "))
+			}
+			if len(tags) == 0 {
+				continue
+			}
+			key := batchKey(violation.AccountID, violation.Region, tags)
+			batch, ok := batches[key]
+			if !ok {
+				batch = &tagBatch{accountID: violation.AccountID, region: violation.Region, tags: tags}
+				batches[key] = batch
+				keys = append(keys, key)
+			}
+			batch.arns = append(batch.arns, violation.ARN)
+			batch.violations = append(batch.violations, violation)
+		}
+	}
+	if len(batches) == 0 {
+		return nil
+	}
+
+	resources := 0
+	for _, batch := range batches {
+		resources += len(batch.arns)
+	}
+	err := utils.Confirm(utils.ConfirmOptions{
+		Summary: &utils.ImpactSummary{
+			Action: fmt.Sprintf("Tag %d billable resources in the accounts of %s", resources, o.ou),
+		},
+		SkipPrompt: o.skipPrompt,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		batch := batches[key]
+		client := clients[batch.accountID][batch.region]
+		for start := 0; start < len(batch.arns); start += maxTagResourcesARNs {
+			end := start + maxTagResourcesARNs
+			if end > len(batch.arns) {
+				end = len(batch.arns)
+			}
+			failed := map[string]string{}
+			output, err := client.TagResources(&resourcegroupstaggingapi.TagResourcesInput{
+				ResourceARNList: awsSdk.StringSlice(batch.arns[start:end]),
+				Tags:            awsSdk.StringMap(batch.tags),
+			})
+			if err == nil {
+				for arn, info := range output.FailedResourcesMap {
+					failed[arn] = awsSdk.StringValue(info.ErrorMessage)
+				}
+			}
+			for _, violation := range batch.violations[start:end] {
+				result := "fixed"
+				if err != nil {
+					result = fmt.Sprintf("failed: %v", err)
+				} else if message, ok := failed[violation.ARN]; ok {
+					result = "failed: " + message
+				}
+				if violation.Result != "" {
+					result += "; " + violation.Result
+				}
+				violation.Result = result
+			}
+		}
+	}
+	return nil
+}
+
+func batchKey(accountID, region string, tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return accountID + "/" + region + "/" + strings.Join(pairs, ",")
+}
@@ -0,0 +1,125 @@
+package aws
+
+import (
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func taggedResource(arn string, tags map[string]string) *resourcegroupstaggingapi.ResourceTagMapping {
+	resource := &resourcegroupstaggingapi.ResourceTagMapping{ResourceARN: awsSdk.String(arn)}
+	for key, value := range tags {
+		resource.Tags = append(resource.Tags, &resourcegroupstaggingapi.Tag{Key: awsSdk.String(key), Value: awsSdk.String(value)})
+	}
+	return resource
+}
+
+func TestListOUAccounts(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+
+	active := awsSdk.String(organizations.AccountStatusActive)
+	client.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{ParentId: awsSdk.String("ou-root")}).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts:  []*organizations.Account{{Id: awsSdk.String("111"), Status: active}},
+			NextToken: awsSdk.String("next"),
+		}, nil)
+	client.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{ParentId: awsSdk.String("ou-root"), NextToken: awsSdk.String("next")}).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{
+				{Id: awsSdk.String("222"), Status: awsSdk.String(organizations.AccountStatusSuspended)},
+				{Id: awsSdk.String("333"), Status: active},
+			},
+		}, nil)
+	client.EXPECT().ListOrganizationalUnitsForParent(&organizations.ListOrganizationalUnitsForParentInput{ParentId: awsSdk.String("ou-root")}).Return(
+		&organizations.ListOrganizationalUnitsForParentOutput{
+			OrganizationalUnits: []*organizations.OrganizationalUnit{{Id: awsSdk.String("ou-child")}},
+		}, nil)
+	client.EXPECT().ListAccountsForParent(&organizations.ListAccountsForParentInput{ParentId: awsSdk.String("ou-child")}).Return(
+		&organizations.ListAccountsForParentOutput{
+			Accounts: []*organizations.Account{{Id: awsSdk.String("444"), Status: active}},
+		}, nil)
+	client.EXPECT().ListOrganizationalUnitsForParent(&organizations.ListOrganizationalUnitsForParentInput{ParentId: awsSdk.String("ou-child")}).Return(
+		&organizations.ListOrganizationalUnitsForParentOutput{}, nil)
+
+	accountIDs, err := listOUAccounts(client, "ou-root")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(accountIDs).To(Equal([]string{"111", "333", "444"}))
+}
+
+func TestAuditResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+
+	types := []string{"ec2:instance"}
+	client.EXPECT().GetResources(&resourcegroupstaggingapi.GetResourcesInput{ResourceTypeFilters: awsSdk.StringSlice(types)}).Return(
+		&resourcegroupstaggingapi.GetResourcesOutput{
+			ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+				taggedResource("arn:compliant", map[string]string{"owner": "sre", "cluster-id": "abc", "expiry": "2024-12-31"}),
+			},
+			PaginationToken: awsSdk.String("next"),
+		}, nil)
+	client.EXPECT().GetResources(&resourcegroupstaggingapi.GetResourcesInput{ResourceTypeFilters: awsSdk.StringSlice(types), PaginationToken: awsSdk.String("next")}).Return(
+		&resourcegroupstaggingapi.GetResourcesOutput{
+			ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+				taggedResource("arn:violation", map[string]string{"owner": " ", clusterIDTag: "abc"}),
+			},
+			PaginationToken: awsSdk.String(""),
+		}, nil)
+
+	count, violations, err := auditResources(client, "111", "us-east-1", types, []string{"owner", "cluster-id", "expiry"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(count).To(Equal(2))
+	g.Expect(violations).To(HaveLen(1))
+	g.Expect(violations[0].ARN).To(Equal("arn:violation"))
+	g.Expect(violations[0].MissingTags).To(Equal([]string{"owner", "cluster-id", "expiry"}))
+}
+
+func TestMissingTagValues(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	violation := tagViolation{MissingTags: []string{"owner", "cluster-id", "expiry"}, tags: map[string]string{clusterIDTag: "abc"}}
+	tags, unknown := missingTagValues(violation, map[string]string{"owner": "sre"})
+	g.Expect(tags).To(Equal(map[string]string{"owner": "sre", "cluster-id": "abc"}))
+	g.Expect(unknown).To(Equal([]string{"expiry"}))
+}
+
+func TestFixViolations(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+
+	response := tagAuditResponse{Accounts: []accountTagAudit{{
+		AccountID: "111",
+		Violations: []tagViolation{
+			{AccountID: "111", Region: "us-east-1", ARN: "arn:a", MissingTags: []string{"owner"}},
+			{AccountID: "111", Region: "us-east-1", ARN: "arn:b", MissingTags: []string{"owner"}},
+			{AccountID: "111", Region: "us-east-1", ARN: "arn:c", MissingTags: []string{"expiry"}},
+		},
+	}}}
+	client.EXPECT().TagResources(&resourcegroupstaggingapi.TagResourcesInput{
+		ResourceARNList: awsSdk.StringSlice([]string{"arn:a", "arn:b"}),
+		Tags:            awsSdk.StringMap(map[string]string{"owner": "sre"}),
+	}).Return(&resourcegroupstaggingapi.TagResourcesOutput{
+		FailedResourcesMap: map[string]*resourcegroupstaggingapi.FailureInfo{
+			"arn:b": {ErrorMessage: awsSdk.String("access denied")},
+		},
+	}, nil)
+
+	o := &tagAuditOptions{ou: "ou-root", values: map[string]string{"owner": "sre"}, skipPrompt: true}
+	err := o.fixViolations(response, map[string]map[string]awsprovider.Client{"111": {"us-east-1": client}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	violations := response.Accounts[0].Violations
+	g.Expect(violations[0].Result).To(Equal("fixed"))
+	g.Expect(violations[1].Result).To(Equal("failed: access denied"))
+	g.Expect(violations[2].Result).To(Equal("not fixed, no value for expiry, use --set"))
+}
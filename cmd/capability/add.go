@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 type addOptions struct {
@@ -21,8 +21,8 @@ func newAddCmd() *cobra.Command {
 		Short: "adds a specific capability to a specific OCM organization.\nAvailable capabilities: hibernation, autoscaling, ovn, upgradeChannelChange",
 		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run(cmd, args[0]))
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run(cmd, args[0]))
 		},
 		Deprecated: "This command is being deprecated in lieu of using git-backed capabilities. Soon, this command will not work, and you will have to follow the SOP at https://github.com/openshift/ops-sop/v4/howto/capabilities.md.",
 	}
@@ -72,7 +72,7 @@ func (o *addOptions) run(cmd *cobra.Command, capability string) error {
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 
@@ -0,0 +1,437 @@
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const serveLong = `Serve a Slack slash command running a few read-only osdctl commands against a cluster, so that the
+cluster context, limited support reasons and health can be looked up from the incident channel.
+
+The requests are authenticated with the signing secret of the Slack app, passed with --signing-secret, stored with
+'osdctl secrets set ` + secrets.SlackSigningSecretKey + `' or set in the config file. Only the commands below are run, as
+the osdctl binary serving them, with --read-only and --skip-version-check and with its OCM and cloud credentials:
+
+  context CLUSTER            osdctl cluster context CLUSTER
+  limited-support CLUSTER    osdctl cluster support status CLUSTER
+  health CLUSTER             osdctl cluster health --cluster-id CLUSTER
+
+Slack is answered right away and the output of the command is posted to the channel once it's done.`
+
+const serveExample = `
+  # Serve the slash command on port 8080, for the requests of two users
+  osdctl serve-chatops --listen :8080 --allowed-user U012ABCDEF --allowed-user U034GHIJKL
+
+  # In Slack, with the slash command pointing at https://<host>:8080/slack/command
+  /osdctl health 1kfmyclusteristhebesteverp8m`
+
+const (
+	commandPath = "/slack/command"
+	healthzPath = "/healthz"
+
+	// maxSignatureAge is how old a request can be before it's rejected as a replay, as advised by Slack
+	maxSignatureAge = 5 * time.Minute
+	// maxBodySize bounds the slash command payloads, which are a few hundred bytes
+	maxBodySize = 64 * 1024
+	// maxOutputSize keeps the posted output under the size of a Slack message
+	maxOutputSize = 3500
+	// maxRunning is how many commands run at the same time
+	maxRunning = 4
+)
+
+// chatopsCommand is one of the commands that can be run from Slack
+type chatopsCommand struct {
+	name    string
+	summary string
+	// path is the osdctl command run, the cluster is given with clusterFlag or as its last argument
+	path        []string
+	clusterFlag string
+}
+
+// chatopsCommands is the allowlist of the commands run from Slack. They must all be read-only.
+var chatopsCommands = []chatopsCommand{
+	{name: "context", summary: "Context of the cluster", path: []string{"cluster", "context"}},
+	{name: "limited-support", summary: "Limited support reasons of the cluster", path: []string{"cluster", "support", "status"}},
+	{name: "health", summary: "Health of the cluster nodes", path: []string{"cluster", "health"}, clusterFlag: "--cluster-id"},
+}
+
+func (c chatopsCommand) args(cluster string) []string {
+	args := append([]string{}, c.path...)
+	if c.clusterFlag != "" {
+		args = append(args, c.clusterFlag)
+	}
+	return append(args, cluster)
+}
+
+type serveOptions struct {
+	listen          string
+	signingSecret   string
+	allowedUsers    []string
+	allowedChannels []string
+	timeout         time.Duration
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// NewCmdServeChatops implements the serve-chatops command
+func NewCmdServeChatops(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &serveOptions{GlobalOptions: globalOpts}
+	serveCmd := &cobra.Command{
+		Use:               "serve-chatops",
+		Short:             "Serve a Slack slash command running read-only osdctl commands",
+		Long:              serveLong,
+		Example:           serveExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	serveCmd.Flags().StringVar(&ops.listen, "listen", ":8080", "Address to listen on")
+	serveCmd.Flags().StringVar(&ops.signingSecret, "signing-secret", "", fmt.Sprintf("Signing secret of the Slack app. If not passed in, it's read from '%s' in the stored secrets or the config file", secrets.SlackSigningSecretKey))
+	serveCmd.Flags().StringSliceVar(&ops.allowedUsers, "allowed-user", nil, "Slack user ID allowed to run commands, all the users of the workspace when not given")
+	serveCmd.Flags().StringSliceVar(&ops.allowedChannels, "allowed-channel", nil, "Slack channel ID the commands can be run from, all the channels when not given")
+	serveCmd.Flags().DurationVar(&ops.timeout, "timeout", 5*time.Minute, "Time after which a command is stopped")
+
+	return serveCmd
+}
+
+func (o *serveOptions) complete(cmd *cobra.Command) error {
+	if o.timeout <= 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "--timeout must be positive")
+	}
+	if o.signingSecret == "" {
+		secret, err := secrets.Lookup(secrets.SlackSigningSecretKey)
+		if err != nil {
+			return err
+		}
+		if secret == "" {
+			secret = viper.GetString(secrets.SlackSigningSecretKey)
+		}
+		if secret == "" {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "no Slack signing secret, pass --signing-secret or store it with 'osdctl secrets set %s'", secrets.SlackSigningSecretKey)
+		}
+		o.signingSecret = secret
+	}
+	return checkReadOnly(cmd.Root())
+}
+
+// checkReadOnly makes sure the allowlisted commands exist and don't change anything, so that a command renamed or
+// made mutating can't be run from Slack
+func checkReadOnly(root *cobra.Command) error {
+	for _, command := range chatopsCommands {
+		found, _, err := root.Find(command.path)
+		if err != nil || found.Name() != command.path[len(command.path)-1] {
+			return fmt.Errorf("chatops command '%s' runs 'osdctl %s', which doesn't exist", command.name, strings.Join(command.path, " "))
+		}
+		if mutation, _ := catalog.Mutation(found); mutation != catalog.MutationReadOnly {
+			return fmt.Errorf("chatops command '%s' runs 'osdctl %s', which isn't read-only", command.name, strings.Join(command.path, " "))
+		}
+	}
+	return nil
+}
+
+func (o *serveOptions) run() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find the osdctl binary: %w", err)
+	}
+	s := &chatopsServer{
+		signingSecret:   []byte(o.signingSecret),
+		allowedUsers:    o.allowedUsers,
+		allowedChannels: o.allowedChannels,
+		timeout:         o.timeout,
+		running:         make(chan struct{}, maxRunning),
+		now:             time.Now,
+		execute:         executeCommand(executable),
+		respond:         postResponse(&http.Client{Timeout: 30 * time.Second}),
+	}
+
+	server := &http.Server{
+		Addr:              o.listen,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving the Slack slash command on %s%s\n", o.listen, commandPath)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// slackMessage is the response to a slash command, https://api.slack.com/interactivity/slash-commands#responding_to_commands
+type slackMessage struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+type chatopsServer struct {
+	signingSecret   []byte
+	allowedUsers    []string
+	allowedChannels []string
+	timeout         time.Duration
+	running         chan struct{}
+
+	now func() time.Time
+	// execute runs osdctl with the arguments and returns its output
+	execute func(ctx context.Context, args []string) (string, error)
+	// respond posts the message to the response URL of the slash command
+	respond func(responseURL string, message slackMessage) error
+}
+
+func (s *chatopsServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(healthzPath, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc(commandPath, s.handleCommand)
+	return mux
+}
+
+func (s *chatopsServer) handleCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "cannot read the request", http.StatusBadRequest)
+		return
+	}
+	if err := verifySignature(s.signingSecret, r.Header.Get("X-Slack-Request-Timestamp"), r.Header.Get("X-Slack-Signature"), body, s.now()); err != nil {
+		fmt.Fprintf(os.Stderr, "Rejected a request from %s: %v\n", r.RemoteAddr, err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "cannot parse the request", http.StatusBadRequest)
+		return
+	}
+
+	user, channel := form.Get("user_id"), form.Get("channel_id")
+	if !allowed(s.allowedUsers, user) || !allowed(s.allowedChannels, channel) {
+		fmt.Fprintf(os.Stderr, "Refused '%s' from user %s in channel %s\n", form.Get("text"), user, channel)
+		writeMessage(w, slackMessage{ResponseType: "ephemeral", Text: "You aren't allowed to run osdctl commands here."})
+		return
+	}
+	command, cluster, err := parseCommand(form.Get("text"))
+	if err != nil {
+		writeMessage(w, slackMessage{ResponseType: "ephemeral", Text: err.Error() + "\n\n" + usage(form.Get("command"))})
+		return
+	}
+	if command == nil {
+		writeMessage(w, slackMessage{ResponseType: "ephemeral", Text: usage(form.Get("command"))})
+		return
+	}
+	responseURL := form.Get("response_url")
+	if err := validateResponseURL(responseURL); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case s.running <- struct{}{}:
+	default:
+		writeMessage(w, slackMessage{ResponseType: "ephemeral", Text: "Too many commands are running, try again in a minute."})
+		return
+	}
+	args := command.args(cluster)
+	commandLine := "osdctl " + strings.Join(args, " ")
+	fmt.Fprintf(os.Stderr, "Running '%s' for user %s in channel %s\n", commandLine, user, channel)
+	writeMessage(w, slackMessage{ResponseType: "ephemeral", Text: fmt.Sprintf("Running `%s`...", commandLine)})
+
+	go func() {
+		defer func() { <-s.running }()
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		output, err := s.execute(ctx, args)
+		message := slackMessage{ResponseType: "in_channel", Text: formatResult(commandLine, user, output, err)}
+		if err := s.respond(responseURL, message); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot post the output of '%s': %v\n", commandLine, err)
+		}
+	}()
+}
+
+// verifySignature checks the request was signed by Slack with the signing secret of the app, see
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func verifySignature(secret []byte, timestamp, signature string, body []byte, now time.Time) error {
+	if timestamp == "" || signature == "" {
+		return errors.New("the request isn't signed")
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp '%s'", timestamp)
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxSignatureAge || age < -maxSignatureAge {
+		return fmt.Errorf("the request was signed %s ago", age.Round(time.Second))
+	}
+	mac := hmac.New(sha256.New, secret)
+	_, _ = fmt.Fprintf(mac, "v0:%s:", timestamp)
+	_, _ = mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return errors.New("the signature doesn't match")
+	}
+	return nil
+}
+
+// parseCommand returns the allowlisted command and the cluster of the slash command text, e.g. 'health mycluster',
+// or no command when the help is asked for
+func parseCommand(text string) (*chatopsCommand, string, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || fields[0] == "help" {
+		return nil, "", nil
+	}
+	var command *chatopsCommand
+	for i := range chatopsCommands {
+		if chatopsCommands[i].name == fields[0] {
+			command = &chatopsCommands[i]
+		}
+	}
+	if command == nil {
+		return nil, "", fmt.Errorf("unknown command '%s'", fields[0])
+	}
+	if len(fields) != 2 {
+		return nil, "", fmt.Errorf("'%s' expects exactly one cluster", command.name)
+	}
+	// The cluster is given to osdctl as an argument, it mustn't be taken for a flag
+	cluster := fields[1]
+	if strings.HasPrefix(cluster, "-") {
+		return nil, "", fmt.Errorf("invalid cluster '%s'", cluster)
+	}
+	if err := utils.IsValidClusterKey(cluster); err != nil {
+		return nil, "", err
+	}
+	return command, cluster, nil
+}
+
+func usage(slashCommand string) string {
+	if slashCommand == "" {
+		slashCommand = "/osdctl"
+	}
+	var b strings.Builder
+	b.WriteString("Usage:\n")
+	for _, command := range chatopsCommands {
+		fmt.Fprintf(&b, "`%s %s CLUSTER`  %s\n", slashCommand, command.name, command.summary)
+	}
+	return b.String()
+}
+
+func allowed(allowlist []string, id string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	for _, allowedID := range allowlist {
+		if allowedID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// validateResponseURL makes sure the output is only posted back to Slack
+func validateResponseURL(responseURL string) error {
+	parsed, err := url.Parse(responseURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host != "hooks.slack.com" {
+		return fmt.Errorf("invalid response URL '%s'", responseURL)
+	}
+	return nil
+}
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
+
+// formatResult formats the output of the command as a Slack message, keeping its beginning when it's too long
+func formatResult(commandLine, user, output string, err error) string {
+	output = strings.TrimSpace(ansiEscape.ReplaceAllString(output, ""))
+	if len(output) > maxOutputSize {
+		output = output[:maxOutputSize] + fmt.Sprintf("\n... %d more bytes", len(output)-maxOutputSize)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "<@%s> ran `%s`", user, commandLine)
+	if err != nil {
+		fmt.Fprintf(&b, ", which failed: %v", err)
+	}
+	if output != "" {
+		fmt.Fprintf(&b, "\n```\n%s\n```", output)
+	}
+	return b.String()
+}
+
+// executeCommand runs the commands with the osdctl binary of the server. They exit the process when they fail,
+// running them in a child keeps the server up and the outputs of the commands apart. The children always run with
+// --read-only, so that a command changing something is refused even if the allowlist check missed it, and with
+// --skip-version-check, as the upgrade prompt of an outdated binary would read the empty stdin and fail the command.
+func executeCommand(executable string) func(ctx context.Context, args []string) (string, error) {
+	return func(ctx context.Context, args []string) (string, error) {
+		var output bytes.Buffer
+		args = append(append([]string{}, args...), "--"+readonly.Flag, "--skip-version-check")
+		cmd := exec.CommandContext(ctx, executable, args...) //#nosec G204 -- the arguments are allowlisted
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		err := cmd.Run()
+		if ctx.Err() != nil {
+			return output.String(), fmt.Errorf("timed out")
+		}
+		return output.String(), err
+	}
+}
+
+func postResponse(client *http.Client) func(responseURL string, message slackMessage) error {
+	return func(responseURL string, message slackMessage) error {
+		body, err := json.Marshal(message)
+		if err != nil {
+			return err
+		}
+		response, err := client.Post(responseURL, "application/json", bytes.NewReader(body)) //#nosec G107 -- the URL is checked to be Slack's
+		if err != nil {
+			return err
+		}
+		defer response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("Slack answered %s", response.Status)
+		}
+		return nil
+	}
+}
+
+func writeMessage(w http.ResponseWriter, message slackMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(message)
+}
@@ -0,0 +1,225 @@
+package chatops
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/spf13/cobra"
+)
+
+var testNow = time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+func sign(secret, timestamp string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignature(t *testing.T) {
+	timestamp := strconv.FormatInt(testNow.Unix(), 10)
+	body := []byte("command=%2Fosdctl&text=health+mycluster")
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		now       time.Time
+		wantErr   bool
+	}{
+		{name: "valid", timestamp: timestamp, signature: sign("secret", timestamp, string(body)), now: testNow},
+		{name: "another secret", timestamp: timestamp, signature: sign("other", timestamp, string(body)), now: testNow, wantErr: true},
+		{name: "another body", timestamp: timestamp, signature: sign("secret", timestamp, "text=context+mycluster"), now: testNow, wantErr: true},
+		{name: "replayed", timestamp: timestamp, signature: sign("secret", timestamp, string(body)), now: testNow.Add(10 * time.Minute), wantErr: true},
+		{name: "not signed", timestamp: timestamp, now: testNow, wantErr: true},
+		{name: "invalid timestamp", timestamp: "yesterday", signature: sign("secret", "yesterday", string(body)), now: testNow, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature([]byte("secret"), tt.timestamp, tt.signature, body, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantArgs []string
+		wantErr  bool
+	}{
+		{text: ""},
+		{text: "help"},
+		{text: "context mycluster", wantArgs: []string{"cluster", "context", "mycluster"}},
+		{text: " limited-support  mycluster ", wantArgs: []string{"cluster", "support", "status", "mycluster"}},
+		{text: "health 1kfmyclusteristhebesteverp8m", wantArgs: []string{"cluster", "health", "--cluster-id", "1kfmyclusteristhebesteverp8m"}},
+		{text: "delete mycluster", wantErr: true},
+		{text: "health", wantErr: true},
+		{text: "health mycluster --full", wantErr: true},
+		{text: "health --help", wantErr: true},
+		{text: "health my;cluster", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			command, cluster, err := parseCommand(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var args []string
+			if command != nil {
+				args = command.args(cluster)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("parseCommand() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	newRoot := func(mutating bool) *cobra.Command {
+		root := &cobra.Command{Use: "osdctl"}
+		cluster := &cobra.Command{Use: "cluster"}
+		support := &cobra.Command{Use: "support"}
+		health := &cobra.Command{Use: "health", Run: func(*cobra.Command, []string) {}}
+		if mutating {
+			health.Annotations = map[string]string{catalog.MutationAnnotation: catalog.MutationMutating}
+		}
+		support.AddCommand(&cobra.Command{Use: "status", Run: func(*cobra.Command, []string) {}})
+		cluster.AddCommand(support, health, &cobra.Command{Use: "context", Run: func(*cobra.Command, []string) {}})
+		root.AddCommand(cluster)
+		return root
+	}
+	if err := checkReadOnly(newRoot(false)); err != nil {
+		t.Errorf("checkReadOnly() error = %v", err)
+	}
+	if err := checkReadOnly(newRoot(true)); err == nil {
+		t.Error("checkReadOnly() accepted a mutating command")
+	}
+	if err := checkReadOnly(&cobra.Command{Use: "osdctl"}); err == nil {
+		t.Error("checkReadOnly() accepted missing commands")
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	type posted struct {
+		url     string
+		message slackMessage
+	}
+	responses := make(chan posted, 1)
+	var ran []string
+	s := &chatopsServer{
+		signingSecret: []byte("secret"),
+		allowedUsers:  []string{"U1"},
+		timeout:       time.Minute,
+		running:       make(chan struct{}, 1),
+		now:           func() time.Time { return testNow },
+		execute: func(ctx context.Context, args []string) (string, error) {
+			ran = args
+			return "\x1b[32mCluster is fully supported\x1b[0m\n", nil
+		},
+		respond: func(responseURL string, message slackMessage) error {
+			responses <- posted{url: responseURL, message: message}
+			return nil
+		},
+	}
+	handler := s.handler()
+
+	send := func(form url.Values, signature string) (*httptest.ResponseRecorder, slackMessage) {
+		body := form.Encode()
+		timestamp := strconv.FormatInt(testNow.Unix(), 10)
+		if signature == "" {
+			signature = sign("secret", timestamp, body)
+		}
+		request := httptest.NewRequest(http.MethodPost, commandPath, strings.NewReader(body))
+		request.Header.Set("X-Slack-Request-Timestamp", timestamp)
+		request.Header.Set("X-Slack-Signature", signature)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		var message slackMessage
+		_ = json.Unmarshal(recorder.Body.Bytes(), &message)
+		return recorder, message
+	}
+	form := func(user, text, responseURL string) url.Values {
+		return url.Values{"command": {"/osdctl"}, "user_id": {user}, "channel_id": {"C1"}, "text": {text}, "response_url": {responseURL}}
+	}
+	responseURL := "https://hooks.slack.com/commands/T1/1/abc"
+
+	recorder, _ := send(form("U1", "health mycluster", responseURL), "v0=forged")
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("forged request: status = %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	_, message := send(form("U2", "health mycluster", responseURL), "")
+	if !strings.Contains(message.Text, "aren't allowed") {
+		t.Errorf("user not allowed: text = %q", message.Text)
+	}
+
+	_, message = send(form("U1", "reboot mycluster", responseURL), "")
+	if !strings.Contains(message.Text, "unknown command 'reboot'") || !strings.Contains(message.Text, "/osdctl health CLUSTER") {
+		t.Errorf("unknown command: text = %q", message.Text)
+	}
+
+	recorder, _ = send(form("U1", "health mycluster", "https://attacker.example.com/"), "")
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("response URL not Slack's: status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+	if ran != nil {
+		t.Fatalf("a rejected request ran %v", ran)
+	}
+
+	_, message = send(form("U1", "limited-support mycluster", responseURL), "")
+	if message.ResponseType != "ephemeral" || !strings.Contains(message.Text, "osdctl cluster support status mycluster") {
+		t.Errorf("acknowledgement = %+v", message)
+	}
+	select {
+	case response := <-responses:
+		if response.url != responseURL {
+			t.Errorf("posted to %s, want %s", response.url, responseURL)
+		}
+		want := "<@U1> ran `osdctl cluster support status mycluster`\n```\nCluster is fully supported\n```"
+		if response.message.ResponseType != "in_channel" || response.message.Text != want {
+			t.Errorf("posted %+v, want text %q", response.message, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the output wasn't posted")
+	}
+	if !reflect.DeepEqual(ran, []string{"cluster", "support", "status", "mycluster"}) {
+		t.Errorf("ran %v", ran)
+	}
+}
+
+func TestExecuteCommandArgs(t *testing.T) {
+	echo, err := exec.LookPath("echo")
+	if err != nil {
+		t.Skip("echo isn't available")
+	}
+	output, err := executeCommand(echo)(context.Background(), []string{"cluster", "health", "abc"})
+	if err != nil || output != "cluster health abc --read-only --skip-version-check\n" {
+		t.Errorf("expected the child to run with --read-only and --skip-version-check, got %q and %v", output, err)
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	got := formatResult("osdctl cluster health --cluster-id c", "U1", strings.Repeat("a", maxOutputSize+10), errors.New("exit status 1"))
+	if !strings.HasPrefix(got, "<@U1> ran `osdctl cluster health --cluster-id c`, which failed: exit status 1\n```\n") {
+		t.Errorf("formatResult() = %q", got[:100])
+	}
+	if !strings.HasSuffix(got, "\n... 10 more bytes\n```") {
+		t.Errorf("formatResult() wasn't truncated: %q", got[len(got)-50:])
+	}
+}
@@ -12,15 +12,18 @@ import (
 	"time"
 
 	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/deadline"
 	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/poll"
 	osdctlutil "github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	corev1 "k8s.io/api/core/v1"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
@@ -52,17 +55,18 @@ var (
 func NewCmdAccess(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) *cobra.Command {
 	accessCmd := &cobra.Command{
 		Use:               "break-glass <cluster identifier>",
+		Annotations:       map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
 		Short:             "Emergency access to a cluster",
 		Long:              "Obtain emergency credentials to access the given cluster. You must be logged into the cluster's hive shard",
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(accessCmdComplete(cmd, args))
+			osdctlErrors.CheckErr(accessCmdComplete(cmd, args))
 			// Prior to creating k8s client, verify the user has elevated permissions
-			cmdutil.CheckErr(verifyPermissions(streams, flags))
+			osdctlErrors.CheckErr(verifyPermissions(streams, flags))
 			client := k8s.NewClient(flags)
 			clusterAccess := newClusterAccessOptions(client, streams, flags)
-			cmdutil.CheckErr(clusterAccess.Run(cmd, args))
+			osdctlErrors.CheckErr(clusterAccess.Run(cmd, args))
 		},
 	}
 	accessCmd.AddCommand(newCmdCleanup(streams, flags))
@@ -153,7 +157,7 @@ func (c *clusterAccessOptions) Run(cmd *cobra.Command, args []string) error {
 	// Connect to ocm
 	conn := osdctlutil.CreateConnection()
 	defer func() {
-		cmdutil.CheckErr(conn.Close())
+		osdctlErrors.CheckErr(conn.Close())
 	}()
 
 	cluster, err := osdctlutil.GetCluster(conn, clusterIdentifier)
@@ -444,18 +448,19 @@ func (c *clusterAccessOptions) waitForJumpPod(pod corev1.Pod, interval time.Dura
 		Name:      pod.Name,
 		Namespace: pod.Namespace,
 	}
-	return wait.PollImmediate(interval, timeout, func() (done bool, err error) {
-		err = c.Client.Get(context.TODO(), key, &pod)
+	opts := poll.Options{Description: "jump pod " + pod.Name, Timeout: timeout, Interval: interval}
+	return poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
+		err := c.Client.Get(ctx, key, &pod)
 		if kerr.IsNotFound(err) {
-			return false, nil
+			return false, "the pod isn't created yet", nil
 		} else if err != nil {
-			return false, err
+			return false, "", err
 		}
 		for _, container := range pod.Status.ContainerStatuses {
-			if container.Name == jumpContainerName && *container.Started {
-				return true, nil
+			if container.Name == jumpContainerName && container.Started != nil && *container.Started {
+				return true, "", nil
 			}
 		}
-		return false, nil
+		return false, "the container hasn't started yet", nil
 	})
 }
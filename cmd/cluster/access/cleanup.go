@@ -8,13 +8,16 @@ import (
 	"strings"
 
 	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/deadline"
 	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/poll"
 	osdctlutil "github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	kclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,16 +26,17 @@ import (
 func newCmdCleanup(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) *cobra.Command {
 	cleanupCmd := &cobra.Command{
 		Use:               "cleanup <cluster identifier>",
+		Annotations:       map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
 		Short:             "Drop emergency access to a cluster",
 		Long:              "Relinquish emergency access from the given cluster. If the cluster is PrivateLink, it deletes\nall jump pods in the cluster's namespace (because of this, you must be logged into the hive shard\nwhen dropping access for PrivateLink clusters). For non-PrivateLink clusters, the $KUBECONFIG\nenvironment variable is unset, if applicable.",
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(cleanupCmdComplete(cmd, args))
-			cmdutil.CheckErr(verifyPermissions(streams, flags))
+			osdctlErrors.CheckErr(cleanupCmdComplete(cmd, args))
+			osdctlErrors.CheckErr(verifyPermissions(streams, flags))
 			client := k8s.NewClient(flags)
 			cleanupAccess := newCleanupAccessOptions(client, streams, flags)
-			cmdutil.CheckErr(cleanupAccess.Run(cmd, args))
+			osdctlErrors.CheckErr(cleanupAccess.Run(cmd, args))
 		},
 	}
 	return cleanupCmd
@@ -90,7 +94,7 @@ func (c *cleanupAccessOptions) Run(cmd *cobra.Command, args []string) error {
 
 	conn := osdctlutil.CreateConnection()
 	defer func() {
-		cmdutil.CheckErr(conn.Close())
+		osdctlErrors.CheckErr(conn.Close())
 	}()
 
 	cluster, err := osdctlutil.GetCluster(conn, clusteridentifier)
@@ -159,16 +163,19 @@ func (c *cleanupAccessOptions) dropPrivateLinkAccess(cluster *clustersmgmtv1.Clu
 		}
 
 		c.Println(fmt.Sprintf("Waiting for %d pod(s) to terminate", numPods))
-		err = wait.PollImmediate(jumpPodPollInterval, jumpPodPollTimeout, func() (done bool, err error) {
+		opts := poll.Options{Description: "the jump pods to terminate", Timeout: jumpPodPollTimeout, Interval: jumpPodPollInterval}
+		err = poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
 			// For some reason, we have to recreate the podList after deleting the pods, otherwise the listOpts don't filter properly,
 			// and we end up waiting for irrelevant pods. I've tried reproducing this bug in other places, but I haven't been able to
 			// figure it out. If someone does, please fix it.
 			pods := corev1.PodList{}
-			err = c.Client.List(context.TODO(), &pods, &listOpts)
-			if err != nil || len(pods.Items) != 0 {
-				return false, err
+			if err := c.Client.List(ctx, &pods, &listOpts); err != nil {
+				return false, "", err
 			}
-			return true, nil
+			if len(pods.Items) != 0 {
+				return false, fmt.Sprintf("%d pod(s) left", len(pods.Items)), nil
+			}
+			return true, "", nil
 		})
 		if err != nil {
 			c.Errorln("Error while waiting for pods to terminate")
@@ -0,0 +1,238 @@
+package access
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	clustersmgmtv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/artifacts"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/guardrails"
+	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	osdctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	// The service account the short-lived tokens are issued for, bound to cluster-admin
+	kubeconfigServiceAccount          = "osdctl-kubeconfig"
+	kubeconfigServiceAccountNamespace = "openshift-backplane-srep"
+
+	// Bounds of --ttl, the API server doesn't issue tokens valid for less than 10 minutes
+	minKubeconfigTTL = 10 * time.Minute
+	maxKubeconfigTTL = 24 * time.Hour
+)
+
+const kubeconfigLong = `Retrieve a short-lived cluster-admin kubeconfig for the given cluster.
+
+The admin kubeconfig stored on hive is used to issue a token for the '` + kubeconfigServiceAccount + `' service account,
+which is bound to cluster-admin. The token expires after --ttl, the kubeconfig is written to a temporary file only
+readable by the current user and the expiry is recorded in the audit log. You must be logged into the cluster's
+hive shard. Clusters with a private API are only reachable from hive, use 'osdctl cluster break-glass' for them.`
+
+const kubeconfigExample = `
+  # Get a cluster-admin kubeconfig valid for an hour
+  osdctl cluster kubeconfig 1kfmyclusteristhebesteverp8m --ttl 1h --as backplane-cluster-admin
+
+  # Use it right away
+  export KUBECONFIG=$(osdctl cluster kubeconfig my-cluster --as backplane-cluster-admin)
+`
+
+// kubeconfigOptions contains the objects and information required to issue a kubeconfig
+type kubeconfigOptions struct {
+	ttl time.Duration
+
+	flags *genericclioptions.ConfigFlags
+	genericclioptions.IOStreams
+}
+
+// NewCmdKubeconfig implements the 'cluster kubeconfig' subcommand
+func NewCmdKubeconfig(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags) *cobra.Command {
+	ops := &kubeconfigOptions{flags: flags, IOStreams: streams}
+	kubeconfigCmd := &cobra.Command{
+		Use:               "kubeconfig <cluster identifier>",
+		Annotations:       map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
+		Short:             "Retrieve a short-lived cluster-admin kubeconfig",
+		Long:              kubeconfigLong,
+		Example:           kubeconfigExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			// Prior to creating k8s client, verify the user has elevated permissions
+			osdctlErrors.CheckErr(verifyPermissions(streams, flags))
+			osdctlErrors.CheckErr(ops.run(cmd, args[0]))
+		},
+	}
+	kubeconfigCmd.Flags().DurationVar(&ops.ttl, "ttl", time.Hour, "How long the kubeconfig stays valid, from 10m to 24h")
+
+	return kubeconfigCmd
+}
+
+func (o *kubeconfigOptions) complete(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "Exactly one cluster identifier was expected")
+	}
+	if err := validateKubeconfigTTL(o.ttl); err != nil {
+		return cmdutil.UsageErrorf(cmd, err.Error())
+	}
+	return osdctlutil.IsValidClusterKey(args[0])
+}
+
+func validateKubeconfigTTL(ttl time.Duration) error {
+	if ttl < minKubeconfigTTL || ttl > maxKubeconfigTTL {
+		return fmt.Errorf("--ttl must be between %s and %s, got %s", minKubeconfigTTL, maxKubeconfigTTL, ttl)
+	}
+	return nil
+}
+
+func (o *kubeconfigOptions) run(cmd *cobra.Command, clusterIdentifier string) error {
+	conn := osdctlutil.CreateConnection()
+	defer func() {
+		osdctlErrors.CheckErr(conn.Close())
+	}()
+
+	cluster, err := osdctlutil.GetCluster(conn, clusterIdentifier)
+	if err != nil {
+		return err
+	}
+	if cluster.AWS().PrivateLink() || cluster.API().Listening() == clustersmgmtv1.ListeningMethodInternal {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the API of cluster '%s' is private and only reachable from hive, use 'osdctl cluster break-glass' instead", cluster.Name())
+	}
+
+	// Retrieve the admin kubeconfig secret from the cluster's namespace on hive
+	access := newClusterAccessOptions(k8s.NewClient(o.flags), o.IOStreams, o.flags)
+	ns, err := getClusterNamespace(access.Client, cluster.ID())
+	if err != nil {
+		return err
+	}
+	kubeconfigSecret, err := access.getKubeConfigSecret(ns)
+	if err != nil {
+		return err
+	}
+	rawKubeconfig, found := kubeconfigSecret.Data[kubeconfigSecretKey]
+	if !found {
+		return fmt.Errorf("expected key '%s' not found in Secret '%s'", kubeconfigSecretKey, kubeconfigSecret.Name)
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(rawKubeconfig)
+	if err != nil {
+		return fmt.Errorf("cannot parse the admin kubeconfig of cluster '%s': %w", cluster.Name(), err)
+	}
+	restConfig.Wrap(recording.KubeTransportWrapper)
+	restConfig.Wrap(readonly.KubeTransportWrapper)
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	access.Errorln(fmt.Sprintf("Issuing a token valid for %s for cluster '%s'", o.ttl, cluster.Name()))
+	token, err := issueAdminToken(context.TODO(), clientset, o.ttl)
+	if err != nil {
+		return fmt.Errorf("cannot issue a token for cluster '%s': %w", cluster.Name(), err)
+	}
+	expiresAt := token.Status.ExpirationTimestamp.UTC()
+
+	data, err := buildTokenKubeconfig(cluster.Name(), restConfig.Host, restConfig.CAData, token.Status.Token)
+	if err != nil {
+		return err
+	}
+	var path string
+	if artifacts.Enabled() {
+		path, err = artifacts.Write(cluster.ID(), "kubeconfig", data, "cluster-admin kubeconfig valid until "+timefmt.Format(expiresAt))
+	} else {
+		path, err = writeTempKubeconfig(cluster.Name(), data)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := guardrails.WriteAuditRecord(guardrails.AuditRecord{
+		Command:     cmd.CommandPath(),
+		Args:        []string{clusterIdentifier},
+		Environment: osdctlutil.GetCurrentOCMEnv(conn),
+		Cluster:     cluster.ID(),
+		ExpiresAt:   &expiresAt,
+	}); err != nil {
+		access.Errorln(fmt.Sprintf("Warning: unable to record the kubeconfig in the audit log: %v", err))
+	}
+
+	access.Errorln(fmt.Sprintf("Kubeconfig for cluster '%s' written, valid until %s", cluster.Name(), timefmt.Format(expiresAt)))
+	fmt.Fprintln(o.Out, path)
+	return nil
+}
+
+// issueAdminToken makes sure the cluster-admin service account exists and requests a token for it
+func issueAdminToken(ctx context.Context, clientset kubernetes.Interface, ttl time.Duration) (*authenticationv1.TokenRequest, error) {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: kubeconfigServiceAccount, Namespace: kubeconfigServiceAccountNamespace},
+	}
+	_, err := clientset.CoreV1().ServiceAccounts(kubeconfigServiceAccountNamespace).Create(ctx, serviceAccount, metav1.CreateOptions{})
+	if err != nil && !kerr.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: kubeconfigServiceAccount},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      kubeconfigServiceAccount,
+			Namespace: kubeconfigServiceAccountNamespace,
+		}},
+	}
+	_, err = clientset.RbacV1().ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{})
+	if err != nil && !kerr.IsAlreadyExists(err) {
+		return nil, err
+	}
+
+	expirationSeconds := int64(ttl.Seconds())
+	return clientset.CoreV1().ServiceAccounts(kubeconfigServiceAccountNamespace).CreateToken(ctx, kubeconfigServiceAccount, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expirationSeconds},
+	}, metav1.CreateOptions{})
+}
+
+// buildTokenKubeconfig returns a kubeconfig authenticating to the API server with the token
+func buildTokenKubeconfig(clusterName, server string, caData []byte, token string) ([]byte, error) {
+	user := kubeconfigServiceAccount + "/" + clusterName
+	config := clientcmdapi.NewConfig()
+	config.Clusters[clusterName] = &clientcmdapi.Cluster{Server: server, CertificateAuthorityData: caData}
+	config.AuthInfos[user] = &clientcmdapi.AuthInfo{Token: token}
+	config.Contexts[clusterName] = &clientcmdapi.Context{Cluster: clusterName, AuthInfo: user}
+	config.CurrentContext = clusterName
+	return clientcmd.Write(*config)
+}
+
+// writeTempKubeconfig writes the kubeconfig to a new temporary file only readable by the current user
+func writeTempKubeconfig(clusterName string, data []byte) (string, error) {
+	file, err := os.CreateTemp("", "kubeconfig-"+clusterName+"-*")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := file.Chmod(0600); err != nil {
+		return "", err
+	}
+	if _, err := file.Write(data); err != nil {
+		return "", err
+	}
+	return file.Name(), nil
+}
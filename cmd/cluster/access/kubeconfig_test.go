@@ -0,0 +1,100 @@
+package access
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestValidateKubeconfigTTL(t *testing.T) {
+	tests := []struct {
+		ttl       time.Duration
+		expectErr bool
+	}{
+		{ttl: 5 * time.Minute, expectErr: true},
+		{ttl: 10 * time.Minute},
+		{ttl: time.Hour},
+		{ttl: 24 * time.Hour},
+		{ttl: 25 * time.Hour, expectErr: true},
+	}
+
+	for _, test := range tests {
+		err := validateKubeconfigTTL(test.ttl)
+		if (err != nil) != test.expectErr {
+			t.Errorf("validateKubeconfigTTL(%s): expected error %t, got %v", test.ttl, test.expectErr, err)
+		}
+	}
+}
+
+func TestIssueAdminToken(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	var requested int64
+	clientset.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		request := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenRequest)
+		requested = *request.Spec.ExpirationSeconds
+		request.Status = authenticationv1.TokenRequestStatus{Token: "sha256~token", ExpirationTimestamp: metav1.Now()}
+		return true, request, nil
+	})
+
+	// Issuing a second token reuses the service account and its binding
+	for i := 0; i < 2; i++ {
+		token, err := issueAdminToken(context.TODO(), clientset, time.Hour)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if token.Status.Token != "sha256~token" {
+			t.Errorf("expected the issued token, got '%s'", token.Status.Token)
+		}
+	}
+	if requested != 3600 {
+		t.Errorf("expected a token valid for 3600 seconds, got %d", requested)
+	}
+
+	binding, err := clientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), kubeconfigServiceAccount, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the cluster role binding to be created: %v", err)
+	}
+	if binding.RoleRef.Name != "cluster-admin" || binding.Subjects[0].Namespace != kubeconfigServiceAccountNamespace {
+		t.Errorf("unexpected cluster role binding: %+v", binding)
+	}
+}
+
+func TestWriteTempKubeconfig(t *testing.T) {
+	data, err := buildTokenKubeconfig("my-cluster", "https://api.my-cluster.example.com:6443", []byte("ca"), "sha256~token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	path, err := writeTempKubeconfig("my-cluster", data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Remove(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the kubeconfig to only be readable by the user, got %s", info.Mode().Perm())
+	}
+
+	config, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("cannot load the kubeconfig: %v", err)
+	}
+	kubeContext := config.Contexts[config.CurrentContext]
+	if kubeContext == nil || config.Clusters[kubeContext.Cluster].Server != "https://api.my-cluster.example.com:6443" || config.AuthInfos[kubeContext.AuthInfo].Token != "sha256~token" {
+		t.Errorf("unexpected kubeconfig: %+v", config)
+	}
+}
@@ -0,0 +1,293 @@
+package cluster
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const (
+	accessAuditLongDescription = `
+Reports who accessed a cluster, summarized per user, for compliance requests
+
+  Access is collected from:
+
+  * CloudTrail AssumeRole events in the cluster's AWS account (AWS clusters only, CloudTrail keeps 90 days)
+  * Kubernetes audit log files exported from the cluster or Splunk (--audit-log), including backplane
+    sessions which show up as impersonated requests
+`
+	accessAuditExample = `
+  # Summarize AWS access over the last 30 days
+  osdctl cluster access-audit 1kfmyclusteristhebesteverp8m --since 30d
+
+  # Include kube audit entries exported as JSON lines
+  osdctl cluster access-audit 1kfmyclusteristhebesteverp8m --since 7d --audit-log audit.log
+`
+
+	accessSourceAssumeRole = "aws-assume-role"
+	accessSourceBackplane  = "backplane"
+	accessSourceKubeAudit  = "kube-audit"
+
+	cloudTrailRetention = 90 * 24 * time.Hour
+)
+
+type accessAuditOptions struct {
+	clusterID     string
+	awsProfile    string
+	since         time.Duration
+	auditLogFiles []string
+	skipAWS       bool
+
+	genericclioptions.IOStreams
+}
+
+// accessRecord is a single access to the cluster by a user
+type accessRecord struct {
+	user   string
+	source string
+	time   time.Time
+}
+
+// userAccessSummary aggregates the access records of a user for one source
+type userAccessSummary struct {
+	user      string
+	source    string
+	count     int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+func newCmdAccessAudit(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &accessAuditOptions{IOStreams: streams}
+	accessAuditCmd := &cobra.Command{
+		Use:               "access-audit CLUSTER_ID",
+		Short:             "Reports who accessed a cluster, summarized per user",
+		Long:              accessAuditLongDescription,
+		Example:           accessAuditExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	accessAuditCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile name")
+	flagtypes.DurationVar(accessAuditCmd.Flags(), &ops.since, "since", 30*24*time.Hour, time.Minute, "How far back to look, e.g. 30d or 12h")
+	accessAuditCmd.Flags().StringSliceVar(&ops.auditLogFiles, "audit-log", nil, "Kubernetes audit log files (JSON lines) to include")
+	accessAuditCmd.Flags().BoolVar(&ops.skipAWS, "skip-aws", false, "Skip the CloudTrail lookup")
+
+	return accessAuditCmd
+}
+
+func (o *accessAuditOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now().Add(-o.since)
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetClusterAnyStatus(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	var records []accessRecord
+	if !o.skipAWS && cluster.CloudProvider().ID() == "aws" {
+		if o.since > cloudTrailRetention {
+			fmt.Fprintf(o.ErrOut, "Warning: CloudTrail only keeps %d days of events\n", int(cloudTrailRetention.Hours()/24))
+		}
+		events, err := o.lookupAssumeRoleEvents(start)
+		if err != nil {
+			return fmt.Errorf("could not look up CloudTrail events: %w", err)
+		}
+		records = append(records, assumeRoleRecords(events)...)
+	}
+
+	for _, path := range o.auditLogFiles {
+		f, err := os.Open(path) //#nosec G304 -- path is provided by the user
+		if err != nil {
+			return err
+		}
+		auditRecords, err := parseAuditLog(f, start)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("could not parse audit log %s: %w", path, err)
+		}
+		records = append(records, auditRecords...)
+	}
+
+	if len(o.auditLogFiles) == 0 {
+		fmt.Fprintf(o.ErrOut, "Kube audit entries are not included, export them from Splunk and pass them with --audit-log: https://osdsecuritylogs.splunkcloud.com/en-US/app/search/search?q=search%%20index%%3D%%22openshift_managed_audit%%22%%20clusterid%%3D%%22%s%%22\n\n", cluster.InfraID())
+	}
+
+	fmt.Printf("Access to cluster %s (%s) since %s\n\n", cluster.Name(), cluster.ID(), timefmt.Format(start))
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"User", "Source", "Count", "First Seen", "Last Seen"})
+	for _, s := range summarizeAccess(records) {
+		table.AddRow([]string{s.user, s.source, strconv.Itoa(s.count), timefmt.Format(s.firstSeen), timefmt.Format(s.lastSeen)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	return table.Flush()
+}
+
+func (o *accessAuditOptions) lookupAssumeRoleEvents(start time.Time) ([]*cloudtrail.Event, error) {
+	awsClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, o.clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*cloudtrail.Event
+	input := &cloudtrail.LookupEventsInput{
+		StartTime: awsSdk.Time(start),
+		LookupAttributes: []*cloudtrail.LookupAttribute{
+			{
+				AttributeKey:   awsSdk.String(cloudtrail.LookupAttributeKeyEventName),
+				AttributeValue: awsSdk.String("AssumeRole"),
+			},
+		},
+	}
+	for {
+		output, err := awsClient.LookupEvents(input)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, output.Events...)
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	return events, nil
+}
+
+type cloudTrailAssumeRole struct {
+	UserIdentity struct {
+		Arn string `json:"arn"`
+	} `json:"userIdentity"`
+	RequestParameters struct {
+		RoleArn         string `json:"roleArn"`
+		RoleSessionName string `json:"roleSessionName"`
+	} `json:"requestParameters"`
+}
+
+// assumeRoleRecords attributes AssumeRole events to the session name, which osdctl and backplane
+// set to the SRE's username, falling back to the calling identity
+func assumeRoleRecords(events []*cloudtrail.Event) []accessRecord {
+	var records []accessRecord
+	for _, event := range events {
+		var detail cloudTrailAssumeRole
+		if err := json.Unmarshal([]byte(awsSdk.StringValue(event.CloudTrailEvent)), &detail); err != nil {
+			continue
+		}
+		user := detail.RequestParameters.RoleSessionName
+		if user == "" {
+			user = detail.UserIdentity.Arn
+		}
+		if user == "" {
+			user = awsSdk.StringValue(event.Username)
+		}
+		if user == "" {
+			continue
+		}
+		records = append(records, accessRecord{user: user, source: accessSourceAssumeRole, time: awsSdk.TimeValue(event.EventTime)})
+	}
+	return records
+}
+
+type kubeAuditEvent struct {
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ImpersonatedUser *struct {
+		Username string `json:"username"`
+	} `json:"impersonatedUser"`
+	RequestReceivedTimestamp time.Time `json:"requestReceivedTimestamp"`
+}
+
+// parseAuditLog reads kube audit events as JSON lines. Service accounts and system users are skipped,
+// requests impersonated by backplane are attributed to the impersonated user.
+func parseAuditLog(r io.Reader, start time.Time) ([]accessRecord, error) {
+	var records []accessRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var event kubeAuditEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return nil, err
+		}
+		if event.RequestReceivedTimestamp.Before(start) {
+			continue
+		}
+
+		user, source := event.User.Username, accessSourceKubeAudit
+		if event.ImpersonatedUser != nil && event.ImpersonatedUser.Username != "" {
+			if strings.Contains(event.User.Username, "backplane") {
+				source = accessSourceBackplane
+			}
+			user = event.ImpersonatedUser.Username
+		}
+		if user == "" || strings.HasPrefix(user, "system:") {
+			continue
+		}
+		records = append(records, accessRecord{user: user, source: source, time: event.RequestReceivedTimestamp})
+	}
+	return records, scanner.Err()
+}
+
+// summarizeAccess groups the records by user and source, sorted by user
+func summarizeAccess(records []accessRecord) []userAccessSummary {
+	byKey := map[string]*userAccessSummary{}
+	for _, record := range records {
+		key := record.user + "/" + record.source
+		s, ok := byKey[key]
+		if !ok {
+			s = &userAccessSummary{user: record.user, source: record.source, firstSeen: record.time, lastSeen: record.time}
+			byKey[key] = s
+		}
+		s.count++
+		if record.time.Before(s.firstSeen) {
+			s.firstSeen = record.time
+		}
+		if record.time.After(s.lastSeen) {
+			s.lastSeen = record.time
+		}
+	}
+
+	summaries := make([]userAccessSummary, 0, len(byKey))
+	for _, s := range byKey {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].user != summaries[j].user {
+			return summaries[i].user < summaries[j].user
+		}
+		return summaries[i].source < summaries[j].source
+	})
+	return summaries
+}
@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	. "github.com/onsi/gomega"
+)
+
+func TestAssumeRoleRecords(t *testing.T) {
+	g := NewGomegaWithT(t)
+	eventTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	events := []*cloudtrail.Event{
+		{
+			EventTime:       awsSdk.Time(eventTime),
+			CloudTrailEvent: awsSdk.String(`{"userIdentity":{"arn":"arn:aws:iam::123:user/jump"},"requestParameters":{"roleArn":"arn:aws:iam::456:role/ManagedOpenShift-Support","roleSessionName":"jdoe"}}`),
+		},
+		{
+			EventTime:       awsSdk.Time(eventTime),
+			CloudTrailEvent: awsSdk.String(`{"userIdentity":{"arn":"arn:aws:iam::123:user/automation"},"requestParameters":{}}`),
+		},
+		{
+			EventTime:       awsSdk.Time(eventTime),
+			CloudTrailEvent: awsSdk.String(`not json`),
+		},
+	}
+
+	records := assumeRoleRecords(events)
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[0].user).To(Equal("jdoe"))
+	g.Expect(records[1].user).To(Equal("arn:aws:iam::123:user/automation"))
+}
+
+func TestParseAuditLog(t *testing.T) {
+	g := NewGomegaWithT(t)
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	log := strings.Join([]string{
+		`{"user":{"username":"system:serviceaccount:openshift-backplane-srep:abc"},"impersonatedUser":{"username":"jdoe"},"requestReceivedTimestamp":"2023-01-02T00:00:00Z"}`,
+		`{"user":{"username":"customer-admin"},"requestReceivedTimestamp":"2023-01-03T00:00:00Z"}`,
+		`{"user":{"username":"system:apiserver"},"requestReceivedTimestamp":"2023-01-03T00:00:00Z"}`,
+		`{"user":{"username":"too-old"},"requestReceivedTimestamp":"2022-12-01T00:00:00Z"}`,
+		``,
+	}, "\n")
+
+	records, err := parseAuditLog(strings.NewReader(log), start)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[0]).To(Equal(accessRecord{user: "jdoe", source: accessSourceBackplane, time: start.Add(24 * time.Hour)}))
+	g.Expect(records[1].user).To(Equal("customer-admin"))
+	g.Expect(records[1].source).To(Equal(accessSourceKubeAudit))
+
+	_, err = parseAuditLog(strings.NewReader("{"), start)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestSummarizeAccess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	t1 := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	summaries := summarizeAccess([]accessRecord{
+		{user: "jdoe", source: accessSourceBackplane, time: t2},
+		{user: "alice", source: accessSourceAssumeRole, time: t1},
+		{user: "jdoe", source: accessSourceBackplane, time: t1},
+	})
+	g.Expect(summaries).To(Equal([]userAccessSummary{
+		{user: "alice", source: accessSourceAssumeRole, count: 1, firstSeen: t1, lastSeen: t1},
+		{user: "jdoe", source: accessSourceBackplane, count: 2, firstSeen: t1, lastSeen: t2},
+	}))
+}
@@ -0,0 +1,478 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const (
+	addonLong = `Lists, installs and uninstalls the managed add-ons of a cluster through OCM.
+
+  The parameters of an add-on are validated against its definition before installing it: unknown parameters are
+  rejected, the values must match the type, options and validation of the parameter, and the required parameters
+  which weren't given with --param are prompted for.`
+
+	addonExample = `
+  # List the add-ons installed on a cluster
+  osdctl cluster addon list 1kfmyclusteristhebesteverp8m
+
+  # Show the state of an add-on and its parameters, e.g. one stuck installing
+  osdctl cluster addon status 1kfmyclusteristhebesteverp8m rhoams
+
+  # Install an add-on, prompting for its required parameters which aren't given
+  osdctl cluster addon install 1kfmyclusteristhebesteverp8m rhoams --param addon-managed-api-service=1
+
+  # Uninstall an add-on
+  osdctl cluster addon uninstall 1kfmyclusteristhebesteverp8m rhoams
+`
+)
+
+type addonOptions struct {
+	clusterID   string
+	addonID     string
+	params      []string
+	skipPrompts bool
+
+	in            io.Reader
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// addonInstallation is the state of an add-on installed on a cluster
+type addonInstallation struct {
+	ID               string            `json:"id" yaml:"id"`
+	Version          string            `json:"version" yaml:"version"`
+	OperatorVersion  string            `json:"operator_version,omitempty" yaml:"operator_version,omitempty"`
+	State            string            `json:"state" yaml:"state"`
+	StateDescription string            `json:"state_description,omitempty" yaml:"state_description,omitempty"`
+	Created          time.Time         `json:"created" yaml:"created"`
+	Updated          time.Time         `json:"updated" yaml:"updated"`
+	Parameters       map[string]string `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+type addonListResponse struct {
+	Addons []addonInstallation `json:"addons" yaml:"addons"`
+}
+
+func (r addonListResponse) String() string {
+	var b bytes.Buffer
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"ID", "VERSION", "STATE", "UPDATED"})
+	for _, addon := range r.Addons {
+		table.AddRow([]string{addon.ID, addon.Version, addon.State, addonAge(addon.Updated)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the add-ons: %v", err)
+	}
+	return b.String()
+}
+
+func (a addonInstallation) String() string {
+	var b bytes.Buffer
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"ID:", a.ID})
+	table.AddRow([]string{"Version:", a.Version})
+	table.AddRow([]string{"Operator Version:", a.OperatorVersion})
+	table.AddRow([]string{"State:", a.State})
+	table.AddRow([]string{"State Description:", a.StateDescription})
+	table.AddRow([]string{"Created:", timefmt.Format(a.Created)})
+	table.AddRow([]string{"Updated:", fmt.Sprintf("%s (%s ago)", timefmt.Format(a.Updated), addonAge(a.Updated))})
+	names := make([]string, 0, len(a.Parameters))
+	for name := range a.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		table.AddRow([]string{"Parameter " + name + ":", a.Parameters[name]})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the add-on: %v", err)
+	}
+	return b.String()
+}
+
+func addonAge(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return duration.HumanDuration(time.Since(t))
+}
+
+func newCmdAddon(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	addonCmd := &cobra.Command{
+		Use:               "addon",
+		Short:             "Lists, installs and uninstalls the managed add-ons of a cluster",
+		Long:              addonLong,
+		Example:           addonExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	listOpts := &addonOptions{GlobalOptions: globalOpts}
+	addonCmd.AddCommand(&cobra.Command{
+		Use:               "list CLUSTER_ID",
+		Short:             "Lists the add-ons installed on a cluster",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			listOpts.clusterID = args[0]
+			osdctlErrors.CheckErr(listOpts.runList())
+		},
+	})
+
+	statusOpts := &addonOptions{GlobalOptions: globalOpts}
+	addonCmd.AddCommand(&cobra.Command{
+		Use:               "status CLUSTER_ID ADDON_ID",
+		Short:             "Shows the state and parameters of an add-on installed on a cluster",
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			statusOpts.clusterID, statusOpts.addonID = args[0], args[1]
+			osdctlErrors.CheckErr(statusOpts.runStatus())
+		},
+	})
+
+	installOpts := &addonOptions{GlobalOptions: globalOpts, in: os.Stdin}
+	installCmd := &cobra.Command{
+		Use:               "install CLUSTER_ID ADDON_ID",
+		Short:             "Installs an add-on on a cluster, validating its parameters",
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			installOpts.clusterID, installOpts.addonID = args[0], args[1]
+			osdctlErrors.CheckErr(installOpts.runInstall())
+		},
+	}
+	installCmd.Flags().StringArrayVarP(&installOpts.params, "param", "p", nil, "Parameter of the add-on as NAME=VALUE, can be repeated")
+	installCmd.Flags().BoolVarP(&installOpts.skipPrompts, "yes", "y", false, "Skips the confirmation prompt, the required parameters must then be given with --param")
+	addonCmd.AddCommand(installCmd)
+
+	uninstallOpts := &addonOptions{GlobalOptions: globalOpts}
+	uninstallCmd := &cobra.Command{
+		Use:               "uninstall CLUSTER_ID ADDON_ID",
+		Short:             "Uninstalls an add-on from a cluster",
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			uninstallOpts.clusterID, uninstallOpts.addonID = args[0], args[1]
+			osdctlErrors.CheckErr(uninstallOpts.runUninstall())
+		},
+	}
+	uninstallCmd.Flags().BoolVarP(&uninstallOpts.skipPrompts, "yes", "y", false, "Skips the confirmation prompt")
+	addonCmd.AddCommand(uninstallCmd)
+
+	return addonCmd
+}
+
+func (o *addonOptions) cluster(connection *sdk.Connection) (*cmv1.Cluster, error) {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return nil, err
+	}
+	return utils.GetCluster(connection, o.clusterID)
+}
+
+func (o *addonOptions) runList() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := o.cluster(connection)
+	if err != nil {
+		return err
+	}
+
+	response, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).Addons().List().Send()
+	if err != nil {
+		return fmt.Errorf("cannot list the add-ons of cluster %s: %w", cluster.ID(), err)
+	}
+	addons := []addonInstallation{}
+	for _, installation := range response.Items().Slice() {
+		addons = append(addons, toAddonInstallation(installation))
+	}
+	sort.Slice(addons, func(i, j int) bool { return addons[i].ID < addons[j].ID })
+	return outputflag.PrintResponse(o.GlobalOptions.Output, addonListResponse{Addons: addons})
+}
+
+func (o *addonOptions) runStatus() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := o.cluster(connection)
+	if err != nil {
+		return err
+	}
+
+	installation, err := getAddonInstallation(connection, cluster.ID(), o.addonID)
+	if err != nil {
+		return err
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, toAddonInstallation(installation))
+}
+
+func (o *addonOptions) runInstall() error {
+	given, err := parseAddonParams(o.params)
+	if err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := o.cluster(connection)
+	if err != nil {
+		return err
+	}
+
+	addonResponse, err := connection.ClustersMgmt().V1().Addons().Addon(o.addonID).Get().Send()
+	if err != nil {
+		return fmt.Errorf("cannot get add-on %s: %w", o.addonID, err)
+	}
+	addon := addonResponse.Body()
+	if !addon.Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "add-on %s is disabled", o.addonID)
+	}
+
+	prompt := promptAddonParameter(bufio.NewReader(o.in), os.Stderr)
+	if o.skipPrompts {
+		prompt = nil
+	}
+	values, err := resolveAddonParameters(addon.Parameters().Slice(), given, prompt)
+	if err != nil {
+		return err
+	}
+
+	parameters := make([]*cmv1.AddOnInstallationParameterBuilder, 0, len(values))
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parameters = append(parameters, cmv1.NewAddOnInstallationParameter().ID(name).Value(values[name]))
+		fmt.Fprintf(os.Stderr, "  %s=%s\n", name, values[name])
+	}
+	installation, err := cmv1.NewAddOnInstallation().
+		ID(addon.ID()).
+		Addon(cmv1.NewAddOn().ID(addon.ID())).
+		Parameters(cmv1.NewAddOnInstallationParameterList().Items(parameters...)).
+		Build()
+	if err != nil {
+		return fmt.Errorf("cannot build the add-on installation: %w", err)
+	}
+
+	if err := utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("install add-on %s", addon.ID())),
+		SkipPrompt: o.skipPrompts,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).Addons().Add().Body(installation).Send(); err != nil {
+		return fmt.Errorf("cannot install add-on %s: %w", addon.ID(), err)
+	}
+	utils.RecordClusterAction(connection, cluster, fmt.Sprintf("Install add-on %s", addon.ID()))
+	fmt.Printf("Installing add-on %s, follow it with 'osdctl cluster addon status %s %s'\n", addon.ID(), cluster.ID(), addon.ID())
+	return nil
+}
+
+func (o *addonOptions) runUninstall() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := o.cluster(connection)
+	if err != nil {
+		return err
+	}
+
+	installation, err := getAddonInstallation(connection, cluster.ID(), o.addonID)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Add-on %s %s is %s\n", installation.ID(), installation.AddonVersion().ID(), installation.State())
+
+	if err := utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("uninstall add-on %s", o.addonID)),
+		SkipPrompt: o.skipPrompts,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).Addons().Addoninstallation(o.addonID).Delete().Send(); err != nil {
+		return fmt.Errorf("cannot uninstall add-on %s: %w", o.addonID, err)
+	}
+	utils.RecordClusterAction(connection, cluster, fmt.Sprintf("Uninstall add-on %s", o.addonID))
+	fmt.Printf("Uninstalling add-on %s, follow it with 'osdctl cluster addon status %s %s'\n", o.addonID, cluster.ID(), o.addonID)
+	return nil
+}
+
+func getAddonInstallation(connection *sdk.Connection, clusterID, addonID string) (*cmv1.AddOnInstallation, error) {
+	response, err := connection.ClustersMgmt().V1().Clusters().Cluster(clusterID).Addons().Addoninstallation(addonID).Get().Send()
+	if err != nil {
+		if response != nil && response.Status() == 404 {
+			return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "add-on %s isn't installed on cluster %s", addonID, clusterID)
+		}
+		return nil, fmt.Errorf("cannot get add-on %s of cluster %s: %w", addonID, clusterID, err)
+	}
+	return response.Body(), nil
+}
+
+func toAddonInstallation(installation *cmv1.AddOnInstallation) addonInstallation {
+	addon := addonInstallation{
+		ID:               installation.ID(),
+		Version:          installation.AddonVersion().ID(),
+		OperatorVersion:  installation.OperatorVersion(),
+		State:            string(installation.State()),
+		StateDescription: installation.StateDescription(),
+		Created:          installation.CreationTimestamp(),
+		Updated:          installation.UpdatedTimestamp(),
+	}
+	for _, parameter := range installation.Parameters().Slice() {
+		if addon.Parameters == nil {
+			addon.Parameters = map[string]string{}
+		}
+		addon.Parameters[parameter.ID()] = parameter.Value()
+	}
+	return addon
+}
+
+// parseAddonParams parses the NAME=VALUE parameters
+func parseAddonParams(params []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, param := range params {
+		name, value, found := strings.Cut(param, "=")
+		if !found || name == "" {
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "invalid parameter %q, expected NAME=VALUE", param)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// addonParameterPrompt asks for the value of a required parameter
+type addonParameterPrompt func(parameter *cmv1.AddOnParameter) (string, error)
+
+func promptAddonParameter(reader *bufio.Reader, out io.Writer) addonParameterPrompt {
+	return func(parameter *cmv1.AddOnParameter) (string, error) {
+		fmt.Fprintf(out, "%s (%s): %s\n", parameter.Name(), parameter.ID(), parameter.Description())
+		if options := parameter.Options(); len(options) > 0 {
+			values := make([]string, 0, len(options))
+			for _, option := range options {
+				values = append(values, option.Value())
+			}
+			fmt.Fprintf(out, "  one of: %s\n", strings.Join(values, ", "))
+		}
+		fmt.Fprintf(out, "%s: ", parameter.ID())
+		response, err := reader.ReadString('\n')
+		if err != nil && response == "" {
+			return "", fmt.Errorf("cannot read the value of parameter %s: %w", parameter.ID(), err)
+		}
+		return strings.TrimSpace(response), nil
+	}
+}
+
+// resolveAddonParameters validates the given parameters against the parameters of the add-on. The required parameters
+// which weren't given take their default value, or are prompted for when prompt is set.
+func resolveAddonParameters(parameters []*cmv1.AddOnParameter, given map[string]string, prompt addonParameterPrompt) (map[string]string, error) {
+	known := map[string]*cmv1.AddOnParameter{}
+	for _, parameter := range parameters {
+		if parameter.Enabled() {
+			known[parameter.ID()] = parameter
+		}
+	}
+	for name := range given {
+		if _, ok := known[name]; !ok {
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "the add-on has no parameter %s", name)
+		}
+	}
+
+	values := map[string]string{}
+	for _, parameter := range parameters {
+		if !parameter.Enabled() {
+			continue
+		}
+		value, ok := given[parameter.ID()]
+		switch {
+		case ok:
+		case !parameter.Required():
+			continue
+		case parameter.DefaultValue() != "":
+			value = parameter.DefaultValue()
+		case prompt != nil:
+			var err error
+			if value, err = prompt(parameter); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "the required parameter %s wasn't given, use --param %s=VALUE", parameter.ID(), parameter.ID())
+		}
+		if err := validateAddonParameter(parameter, value); err != nil {
+			return nil, err
+		}
+		values[parameter.ID()] = value
+	}
+	return values, nil
+}
+
+func validateAddonParameter(parameter *cmv1.AddOnParameter, value string) error {
+	if value == "" && parameter.Required() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the required parameter %s can't be empty", parameter.ID())
+	}
+
+	switch parameter.ValueType() {
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "parameter %s must be true or false, got %q", parameter.ID(), value)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "parameter %s must be a number, got %q", parameter.ID(), value)
+		}
+	}
+
+	if options := parameter.Options(); len(options) > 0 {
+		values := make([]string, 0, len(options))
+		for _, option := range options {
+			if option.Value() == value {
+				return nil
+			}
+			values = append(values, option.Value())
+		}
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "parameter %s must be one of %s, got %q", parameter.ID(), strings.Join(values, ", "), value)
+	}
+
+	if parameter.Validation() != "" {
+		validation, err := regexp.Compile(parameter.Validation())
+		if err != nil {
+			// The validation is meant for the OCM UI and may not be a Go regular expression, OCM validates it anyway
+			return nil
+		}
+		if !validation.MatchString(value) {
+			message := parameter.ValidationErrMsg()
+			if message == "" {
+				message = "must match " + parameter.Validation()
+			}
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "parameter %s: %s, got %q", parameter.ID(), message, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func testAddonParameters(t *testing.T) []*cmv1.AddOnParameter {
+	builders := []*cmv1.AddOnParameterBuilder{
+		cmv1.NewAddOnParameter().ID("quota").Name("Quota").Enabled(true).Required(true).ValueType("string").
+			Options(cmv1.NewAddOnParameterOption().Value("1"), cmv1.NewAddOnParameterOption().Value("5")),
+		cmv1.NewAddOnParameter().ID("email").Name("Email").Enabled(true).Required(true).ValueType("string").
+			Validation("^[^@]+@[^@]+$").ValidationErrMsg("must be an email address"),
+		cmv1.NewAddOnParameter().ID("trial").Name("Trial").Enabled(true).Required(true).ValueType("boolean").DefaultValue("false"),
+		cmv1.NewAddOnParameter().ID("size").Name("Size").Enabled(true).ValueType("number"),
+		cmv1.NewAddOnParameter().ID("legacy").Name("Legacy").Enabled(false),
+	}
+	parameters := make([]*cmv1.AddOnParameter, 0, len(builders))
+	for _, builder := range builders {
+		parameter, err := builder.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parameters = append(parameters, parameter)
+	}
+	return parameters
+}
+
+func TestParseAddonParams(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	values, err := parseAddonParams([]string{"quota=5", "cidr=10.0.0.0/16=x"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values).To(Equal(map[string]string{"quota": "5", "cidr": "10.0.0.0/16=x"}))
+
+	_, err = parseAddonParams([]string{"quota"})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestResolveAddonParameters(t *testing.T) {
+	parameters := testAddonParameters(t)
+
+	tests := []struct {
+		name     string
+		given    map[string]string
+		prompted string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name:     "given and defaults",
+			given:    map[string]string{"quota": "5", "email": "a@example.com", "size": "3"},
+			expected: map[string]string{"quota": "5", "email": "a@example.com", "trial": "false", "size": "3"},
+		},
+		{
+			name:     "prompted",
+			given:    map[string]string{"email": "a@example.com"},
+			prompted: "1\n",
+			expected: map[string]string{"quota": "1", "email": "a@example.com", "trial": "false"},
+		},
+		{name: "missing", given: map[string]string{"email": "a@example.com"}, err: "the required parameter quota wasn't given"},
+		{name: "unknown", given: map[string]string{"legacy": "x"}, err: "the add-on has no parameter legacy"},
+		{name: "option", given: map[string]string{"quota": "2"}, err: "must be one of 1, 5"},
+		{name: "validation", given: map[string]string{"quota": "1", "email": "nope"}, err: "must be an email address"},
+		{name: "type", given: map[string]string{"quota": "1", "email": "a@example.com", "trial": "maybe"}, err: "must be true or false"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			var prompt addonParameterPrompt
+			if test.prompted != "" {
+				prompt = promptAddonParameter(bufio.NewReader(strings.NewReader(test.prompted)), &bytes.Buffer{})
+			}
+			values, err := resolveAddonParameters(parameters, test.given, prompt)
+			if test.err != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(test.err)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(values).To(Equal(test.expected))
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+const (
+	advisorLongDescription = `
+Runs detectors of the usual reasons to put a cluster in limited support, and suggests the templates which apply
+
+  The detectors are:
+
+  * deleted-infra-components: platform namespaces or infra nodes deleted, cluster operators missing or unavailable
+  * disabled-monitoring: the Prometheus or Alertmanager of the platform monitoring deleted or scaled down
+  * cluster-admin-misuse: cluster-admin granted to customer users or groups while the platform is damaged
+
+  A detector firing doesn't put the cluster in limited support on its own: review the findings, then post the
+  suggested template with 'osdctl cluster support post'. The templates can be mapped to their files or URLs with
+  ` + advisorTemplatesConfigKey + ` in the config file, e.g.
+
+    ` + advisorTemplatesConfigKey + `:
+      disabled-monitoring: https://example.com/limited_support/monitoring_disabled.json
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID').
+`
+	advisorExample = `
+  # Find which limited support templates apply to a cluster
+  osdctl cluster advisor 1kfmyclusteristhebesteverp8m
+`
+
+	// advisorTemplatesConfigKey maps the detectors to the limited support template to post when they fire
+	advisorTemplatesConfigKey = "limited_support_advisor_templates"
+
+	detectorInfra        = "deleted-infra-components"
+	detectorMonitoring   = "disabled-monitoring"
+	detectorClusterAdmin = "cluster-admin-misuse"
+
+	monitoringNamespace = "openshift-monitoring"
+	// minInfraNodes is the number of infra nodes of the classic clusters, running the router, registry and monitoring
+	minInfraNodes = 2
+)
+
+// advisorNamespaces are the platform namespaces of the data plane which the customer can't delete
+var advisorNamespaces = []string{"openshift-dns", "openshift-image-registry", "openshift-ingress", "openshift-ingress-operator",
+	"openshift-machine-api", "openshift-monitoring", "openshift-network-operator"}
+
+// monitoringStatefulSets are the platform monitoring components scaled down or deleted to disable it
+var monitoringStatefulSets = []string{"prometheus-k8s", "alertmanager-main"}
+
+type advisorOptions struct {
+	clusterID string
+
+	runOC utils.OCRunner
+	// clusterAdmins returns the members of the cluster-admins group of OCM
+	clusterAdmins func() ([]string, error)
+	hostedCP      bool
+}
+
+// advisorFinding is a finding of a detector, the template applies when one of the findings of its detector failed
+type advisorFinding struct {
+	checkFinding
+	detector string
+}
+
+func newCmdAdvisor() *cobra.Command {
+	ops := &advisorOptions{runOC: utils.RunOCAsClusterAdmin}
+	advisorCmd := &cobra.Command{
+		Use:               "advisor CLUSTER_ID",
+		Short:             "Suggests which limited support templates apply to a cluster",
+		Long:              advisorLongDescription,
+		Example:           advisorExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	return advisorCmd
+}
+
+func (o *advisorOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+	o.hostedCP = cluster.Hypershift().Enabled()
+	o.clusterAdmins = func() ([]string, error) {
+		response, err := ocmClient.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).Groups().Group(clusterAdminsGroup).Users().List().Send()
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the members of the %s group: %w", clusterAdminsGroup, err)
+		}
+		var users []string
+		for _, user := range response.Items().Slice() {
+			users = append(users, user.ID())
+		}
+		return users, nil
+	}
+
+	findings, err := o.detect()
+	if err != nil {
+		return err
+	}
+	return printFindings("Detector", "limited support", o.suggest(cluster.ID(), findings))
+}
+
+// detect runs the detectors, the cluster-admin one last as it depends on the damage found by the others
+func (o *advisorOptions) detect() ([]advisorFinding, error) {
+	var findings []advisorFinding
+	for _, detector := range []func() ([]advisorFinding, error){o.detectInfra, o.detectMonitoring} {
+		detected, err := detector()
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, detected...)
+	}
+
+	var damage []string
+	for _, f := range findings {
+		if f.status != dnsCheckOK {
+			damage = append(damage, f.check)
+		}
+	}
+	finding, err := o.detectClusterAdmin(damage)
+	if err != nil {
+		return nil, err
+	}
+	return append(findings, finding), nil
+}
+
+// detectInfra looks for the platform namespaces, infra nodes and cluster operators which are gone
+func (o *advisorOptions) detectInfra() ([]advisorFinding, error) {
+	var findings []advisorFinding
+	finding := func(check, status, message string) {
+		findings = append(findings, advisorFinding{checkFinding: checkFinding{check: check, status: status, message: message}, detector: detectorInfra})
+	}
+
+	output, err := o.runOC("get", "namespaces", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var namespaces corev1.NamespaceList
+	if err := json.Unmarshal(output, &namespaces); err != nil {
+		return nil, fmt.Errorf("cannot parse the namespaces: %w", err)
+	}
+	existing := map[string]bool{}
+	for _, namespace := range namespaces.Items {
+		existing[namespace.Name] = namespace.DeletionTimestamp == nil
+	}
+	var missing []string
+	for _, namespace := range advisorNamespaces {
+		if !existing[namespace] {
+			missing = append(missing, namespace)
+		}
+	}
+	if len(missing) > 0 {
+		finding("platform namespaces", dnsCheckFail, fmt.Sprintf("%s deleted or being deleted", strings.Join(missing, ", ")))
+	} else {
+		finding("platform namespaces", dnsCheckOK, fmt.Sprintf("the %d platform namespaces exist", len(advisorNamespaces)))
+	}
+
+	// The infra nodes of the hosted control plane clusters are on the management cluster
+	if !o.hostedCP {
+		output, err = o.runOC("get", "nodes", "-l", "node-role.kubernetes.io/infra", "-o", "json")
+		if err != nil {
+			return nil, err
+		}
+		var nodes corev1.NodeList
+		if err := json.Unmarshal(output, &nodes); err != nil {
+			return nil, fmt.Errorf("cannot parse the infra nodes: %w", err)
+		}
+		if len(nodes.Items) < minInfraNodes {
+			finding("infra nodes", dnsCheckFail, fmt.Sprintf("%d infra nodes, at least %d are expected", len(nodes.Items), minInfraNodes))
+		} else {
+			finding("infra nodes", dnsCheckOK, fmt.Sprintf("%d infra nodes", len(nodes.Items)))
+		}
+	}
+
+	output, err = o.runOC("get", "clusteroperators", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var operators struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				Conditions []struct {
+					Type   string `json:"type"`
+					Status string `json:"status"`
+				} `json:"conditions"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &operators); err != nil {
+		return nil, fmt.Errorf("cannot parse the cluster operators: %w", err)
+	}
+	var unavailable []string
+	for _, operator := range operators.Items {
+		for _, condition := range operator.Status.Conditions {
+			if condition.Type == "Available" && condition.Status != "True" {
+				unavailable = append(unavailable, operator.Metadata.Name)
+			}
+		}
+	}
+	sort.Strings(unavailable)
+	if len(unavailable) > 0 {
+		finding("cluster operators", dnsCheckFail, fmt.Sprintf("%s unavailable", strings.Join(unavailable, ", ")))
+	} else {
+		finding("cluster operators", dnsCheckOK, fmt.Sprintf("the %d cluster operators are available", len(operators.Items)))
+	}
+	return findings, nil
+}
+
+// detectMonitoring looks for the platform Prometheus and Alertmanager scaled down or deleted
+func (o *advisorOptions) detectMonitoring() ([]advisorFinding, error) {
+	output, err := o.runOC("get", "statefulsets", "-n", monitoringNamespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var statefulSets appsv1.StatefulSetList
+	if err := json.Unmarshal(output, &statefulSets); err != nil {
+		return nil, fmt.Errorf("cannot parse the monitoring stateful sets: %w", err)
+	}
+	byName := map[string]appsv1.StatefulSet{}
+	for _, statefulSet := range statefulSets.Items {
+		byName[statefulSet.Name] = statefulSet
+	}
+
+	var findings []advisorFinding
+	for _, name := range monitoringStatefulSets {
+		f := advisorFinding{checkFinding: checkFinding{check: name, status: dnsCheckOK}, detector: detectorMonitoring}
+		statefulSet, ok := byName[name]
+		switch {
+		case !ok:
+			f.status = dnsCheckFail
+			f.message = fmt.Sprintf("deleted from %s", monitoringNamespace)
+		case statefulSet.Spec.Replicas != nil && *statefulSet.Spec.Replicas == 0:
+			f.status = dnsCheckFail
+			f.message = "scaled down to 0 replicas"
+		case statefulSet.Status.ReadyReplicas == 0:
+			f.status = dnsCheckWarn
+			f.message = "no ready replica"
+		default:
+			f.message = fmt.Sprintf("%d ready replicas", statefulSet.Status.ReadyReplicas)
+		}
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// detectClusterAdmin finds who has cluster-admin besides the platform, it fails when the platform is damaged too
+func (o *advisorOptions) detectClusterAdmin(damage []string) (advisorFinding, error) {
+	f := advisorFinding{checkFinding: checkFinding{check: "cluster-admin", status: dnsCheckOK}, detector: detectorClusterAdmin}
+
+	admins, err := o.clusterAdmins()
+	if err != nil {
+		return f, err
+	}
+	for i := range admins {
+		admins[i] = fmt.Sprintf("User %s (%s group)", admins[i], clusterAdminsGroup)
+	}
+
+	output, err := o.runOC("get", "clusterrolebindings", "-o", "json")
+	if err != nil {
+		return f, err
+	}
+	var bindings rbacv1.ClusterRoleBindingList
+	if err := json.Unmarshal(output, &bindings); err != nil {
+		return f, fmt.Errorf("cannot parse the cluster role bindings: %w", err)
+	}
+	for _, binding := range bindings.Items {
+		if binding.RoleRef.Kind != "ClusterRole" || binding.RoleRef.Name != "cluster-admin" {
+			continue
+		}
+		for _, subject := range binding.Subjects {
+			if !isPlatformSubject(subject) {
+				admins = append(admins, fmt.Sprintf("%s %s (binding %s)", subject.Kind, subject.Name, binding.Name))
+			}
+		}
+	}
+	sort.Strings(admins)
+
+	switch {
+	case len(admins) == 0:
+		f.message = "only the platform has cluster-admin"
+	case len(damage) == 0:
+		f.message = fmt.Sprintf("granted to %s, no platform damage found", strings.Join(admins, ", "))
+	default:
+		f.status = dnsCheckFail
+		f.message = fmt.Sprintf("granted to %s, while %s are damaged", strings.Join(admins, ", "), strings.Join(damage, ", "))
+	}
+	return f, nil
+}
+
+// isPlatformSubject is true for the subjects of the cluster, the SRE tooling and the cluster-admins group of OCM
+func isPlatformSubject(subject rbacv1.Subject) bool {
+	if strings.HasPrefix(subject.Name, "system:") || strings.HasPrefix(subject.Name, "backplane-") {
+		return true
+	}
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		for _, prefix := range platformNamespacePrefixes {
+			if strings.HasPrefix(subject.Namespace, prefix) {
+				return true
+			}
+		}
+	case rbacv1.GroupKind:
+		return subject.Name == clusterAdminsGroup
+	}
+	return false
+}
+
+// suggest adds the limited support template of its detector to the first failed finding of every detector
+func (o *advisorOptions) suggest(clusterID string, findings []advisorFinding) []checkFinding {
+	templates := viper.GetStringMapString(advisorTemplatesConfigKey)
+	suggested := map[string]bool{}
+	result := make([]checkFinding, 0, len(findings))
+	for _, f := range findings {
+		if f.status == dnsCheckFail && !suggested[f.detector] {
+			suggested[f.detector] = true
+			if template, ok := templates[f.detector]; ok && template != "" {
+				f.hint = fmt.Sprintf("the %s template applies: osdctl cluster support post %s --template %s", f.detector, clusterID, template)
+			} else {
+				f.hint = fmt.Sprintf("the %s template applies, map it to its file or URL with '%s' in the config file", f.detector, advisorTemplatesConfigKey)
+			}
+		}
+		f.check = f.detector + ": " + f.check
+		result = append(result, f.checkFinding)
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+const testAdvisorNamespaces = `{"items": [
+  {"metadata": {"name": "openshift-dns"}}, {"metadata": {"name": "openshift-image-registry"}},
+  {"metadata": {"name": "openshift-ingress"}}, {"metadata": {"name": "openshift-ingress-operator"}},
+  {"metadata": {"name": "openshift-machine-api"}}, {"metadata": {"name": "openshift-network-operator"}},
+  {"metadata": {"name": "openshift-monitoring", "deletionTimestamp": "2024-03-01T09:30:00Z"}}
+]}`
+
+const testAdvisorOperators = `{"items": [
+  {"metadata": {"name": "ingress"}, "status": {"conditions": [{"type": "Available", "status": "True"}]}},
+  {"metadata": {"name": "monitoring"}, "status": {"conditions": [{"type": "Available", "status": "False"}, {"type": "Degraded", "status": "True"}]}}
+]}`
+
+const testAdvisorBindings = `{"items": [
+  {"metadata": {"name": "cluster-admins"}, "roleRef": {"kind": "ClusterRole", "name": "cluster-admin"},
+   "subjects": [{"kind": "Group", "name": "cluster-admins"}, {"kind": "Group", "name": "system:masters"}]},
+  {"metadata": {"name": "gitops-admin"}, "roleRef": {"kind": "ClusterRole", "name": "cluster-admin"},
+   "subjects": [{"kind": "ServiceAccount", "namespace": "openshift-gitops", "name": "argocd"}, {"kind": "ServiceAccount", "namespace": "ci", "name": "deployer"}]},
+  {"metadata": {"name": "view-all"}, "roleRef": {"kind": "ClusterRole", "name": "view"}, "subjects": [{"kind": "User", "name": "bob"}]}
+]}`
+
+func newTestAdvisor(statefulSets string) *advisorOptions {
+	return &advisorOptions{
+		runOC: func(args ...string) ([]byte, error) {
+			switch strings.Join(args, " ") {
+			case "get namespaces -o json":
+				return []byte(testAdvisorNamespaces), nil
+			case "get nodes -l node-role.kubernetes.io/infra -o json":
+				return []byte(`{"items": [{"metadata": {"name": "infra-1"}}, {"metadata": {"name": "infra-2"}}, {"metadata": {"name": "infra-3"}}]}`), nil
+			case "get clusteroperators -o json":
+				return []byte(testAdvisorOperators), nil
+			case "get statefulsets -n openshift-monitoring -o json":
+				return []byte(statefulSets), nil
+			case "get clusterrolebindings -o json":
+				return []byte(testAdvisorBindings), nil
+			}
+			return nil, errors.New("oc get failed: NotFound")
+		},
+		clusterAdmins: func() ([]string, error) { return []string{"alice"}, nil },
+	}
+}
+
+func TestAdvisorDetect(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := newTestAdvisor(`{"items": [{"metadata": {"name": "prometheus-k8s"}, "spec": {"replicas": 0}, "status": {"readyReplicas": 0}}]}`)
+
+	findings, err := o.detect()
+	g.Expect(err).NotTo(HaveOccurred())
+	byCheck := map[string]advisorFinding{}
+	for _, f := range findings {
+		byCheck[f.detector+"/"+f.check] = f
+	}
+	g.Expect(byCheck).To(HaveLen(6))
+
+	g.Expect(byCheck["deleted-infra-components/platform namespaces"].message).To(Equal("openshift-monitoring deleted or being deleted"))
+	g.Expect(byCheck["deleted-infra-components/infra nodes"].status).To(Equal(dnsCheckOK))
+	g.Expect(byCheck["deleted-infra-components/cluster operators"].message).To(Equal("monitoring unavailable"))
+	g.Expect(byCheck["disabled-monitoring/prometheus-k8s"].message).To(Equal("scaled down to 0 replicas"))
+	g.Expect(byCheck["disabled-monitoring/alertmanager-main"].message).To(Equal("deleted from openshift-monitoring"))
+
+	admin := byCheck["cluster-admin-misuse/cluster-admin"]
+	g.Expect(admin.status).To(Equal(dnsCheckFail))
+	g.Expect(admin.message).To(HavePrefix("granted to ServiceAccount deployer (binding gitops-admin), User alice (cluster-admins group), while platform namespaces"))
+}
+
+func TestAdvisorClusterAdminWithoutDamage(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := newTestAdvisor("")
+
+	finding, err := o.detectClusterAdmin(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(finding.status).To(Equal(dnsCheckOK))
+	g.Expect(finding.message).To(Equal("granted to ServiceAccount deployer (binding gitops-admin), User alice (cluster-admins group), no platform damage found"))
+}
+
+func TestAdvisorSuggest(t *testing.T) {
+	g := NewGomegaWithT(t)
+	viper.Set(advisorTemplatesConfigKey, map[string]string{detectorMonitoring: "monitoring_disabled.json"})
+	defer viper.Set(advisorTemplatesConfigKey, nil)
+
+	findings := []advisorFinding{
+		{checkFinding: checkFinding{check: "prometheus-k8s", status: dnsCheckFail}, detector: detectorMonitoring},
+		{checkFinding: checkFinding{check: "alertmanager-main", status: dnsCheckFail}, detector: detectorMonitoring},
+		{checkFinding: checkFinding{check: "infra nodes", status: dnsCheckFail}, detector: detectorInfra},
+		{checkFinding: checkFinding{check: "cluster-admin", status: dnsCheckOK}, detector: detectorClusterAdmin},
+	}
+	suggested := newTestAdvisor("").suggest("2ab3cd", findings)
+	g.Expect(suggested[0].check).To(Equal("disabled-monitoring: prometheus-k8s"))
+	g.Expect(suggested[0].hint).To(Equal("the disabled-monitoring template applies: osdctl cluster support post 2ab3cd --template monitoring_disabled.json"))
+	g.Expect(suggested[1].hint).To(BeEmpty())
+	g.Expect(suggested[2].hint).To(ContainSubstring("map it to its file or URL with 'limited_support_advisor_templates'"))
+	g.Expect(suggested[3].hint).To(BeEmpty())
+}
@@ -0,0 +1,847 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	applyLongDescription = `
+Applies a declarative spec of the OCM resources of one or more clusters, e.g. kept in git and applied from a
+pipeline. The labels, machine pools, upgrade policy and limited support reasons of the spec are compared with OCM,
+the changes are printed resource by resource as a diff, then applied once confirmed:
+
+  clusters:
+  - cluster: 1kfmyclusteristhebesteverp8m   # ID, external ID or name, or 'search' for an OCM search query
+    labels:
+      subscription:
+        my.feature.opt-in: "true"
+      cluster:
+        team: sre
+    machinePools:
+    - id: infra
+      instanceType: r5.xlarge                # only used to create the machine pool
+      replicas: 3                            # or autoscaling: {minReplicas: 2, maxReplicas: 6}
+      labels:
+        node-role.kubernetes.io/infra: ""
+      taints:
+      - {key: node-role.kubernetes.io/infra, effect: NoSchedule}
+    upgradePolicy:
+      schedule: "0 8 * * 1"                  # automatic upgrades, or version and nextRun for a manual one
+    limitedSupportReasons:
+    - summary: Cluster is in limited support
+      details: The customer was informed and accepted the risk
+
+Only the sections present in the spec are managed. The labels, machine pools and limited support reasons of a
+managed section that the spec doesn't declare are left alone, unless --prune is set.
+`
+	applyExample = `
+  # Print the changes the spec would make, resource by resource
+  osdctl apply -f desired-state.yaml --dry-run
+
+  # Apply them without prompting, e.g. from a pipeline, removing what the spec doesn't declare
+  osdctl apply -f desired-state.yaml --prune --yes
+`
+
+	applyResourceSubscriptionLabel = "subscription label"
+	applyResourceClusterLabel      = "cluster label"
+	applyResourceMachinePool       = "machine pool"
+	applyResourceUpgradePolicy     = "upgrade policy"
+	applyResourceLimitedSupport    = "limited support reason"
+
+	applyActionCreate = "create"
+	applyActionUpdate = "update"
+	applyActionDelete = "delete"
+
+	applyResultPlanned = "planned"
+	applyResultApplied = "applied"
+	applyResultSkipped = "skipped"
+	applyResultFailed  = "failed"
+
+	scheduleTypeAutomatic = "automatic"
+	scheduleTypeManual    = "manual"
+)
+
+// desiredState is the spec given to apply
+type desiredState struct {
+	Clusters []clusterState `json:"clusters"`
+}
+
+// clusterState is the desired state of the clusters matching Cluster or Search, the nil sections aren't managed
+type clusterState struct {
+	Cluster               string                `json:"cluster,omitempty"`
+	Search                string                `json:"search,omitempty"`
+	Labels                *labelsState          `json:"labels,omitempty"`
+	MachinePools          []machinePoolState    `json:"machinePools,omitempty"`
+	UpgradePolicy         *upgradePolicyState   `json:"upgradePolicy,omitempty"`
+	LimitedSupportReasons []limitedSupportState `json:"limitedSupportReasons,omitempty"`
+}
+
+type labelsState struct {
+	Subscription map[string]string `json:"subscription,omitempty"`
+	Cluster      map[string]string `json:"cluster,omitempty"`
+}
+
+// machinePoolState is the desired state of a machine pool, the nil fields keep their current value
+type machinePoolState struct {
+	ID           string             `json:"id"`
+	InstanceType string             `json:"instanceType,omitempty"`
+	Replicas     *int               `json:"replicas,omitempty"`
+	Autoscaling  *autoscalingState  `json:"autoscaling,omitempty"`
+	Labels       map[string]string  `json:"labels,omitempty"`
+	Taints       []machinePoolTaint `json:"taints,omitempty"`
+}
+
+type autoscalingState struct {
+	MinReplicas int `json:"minReplicas"`
+	MaxReplicas int `json:"maxReplicas"`
+}
+
+type machinePoolTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// upgradePolicyState is either an automatic upgrade policy with Schedule, or a manual one with Version and NextRun
+type upgradePolicyState struct {
+	Schedule string     `json:"schedule,omitempty"`
+	Version  string     `json:"version,omitempty"`
+	NextRun  *time.Time `json:"nextRun,omitempty"`
+}
+
+type limitedSupportState struct {
+	Summary string `json:"summary"`
+	Details string `json:"details,omitempty"`
+}
+
+// machinePoolView and upgradePolicyView are the managed fields of a resource, shown in the diffs and compared to
+// find the changes
+type machinePoolView struct {
+	ID           string             `json:"id"`
+	InstanceType string             `json:"instanceType,omitempty"`
+	Replicas     *int               `json:"replicas,omitempty"`
+	Autoscaling  *autoscalingState  `json:"autoscaling,omitempty"`
+	Labels       map[string]string  `json:"labels,omitempty"`
+	Taints       []machinePoolTaint `json:"taints,omitempty"`
+}
+
+type upgradePolicyView struct {
+	ScheduleType string     `json:"scheduleType"`
+	Schedule     string     `json:"schedule,omitempty"`
+	Version      string     `json:"version,omitempty"`
+	NextRun      *time.Time `json:"nextRun,omitempty"`
+}
+
+// applyChange is a change to a resource of a cluster, apply makes it
+type applyChange struct {
+	ClusterID   string `json:"cluster_id" yaml:"cluster_id"`
+	ClusterName string `json:"cluster_name" yaml:"cluster_name"`
+	Resource    string `json:"resource" yaml:"resource"`
+	Name        string `json:"name" yaml:"name"`
+	Action      string `json:"action" yaml:"action"`
+	Result      string `json:"result" yaml:"result"`
+	Error       string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	before interface{}
+	after  interface{}
+	apply  func(*sdk.Connection) error
+}
+
+type applyResponse struct {
+	DryRun  bool          `json:"dry_run" yaml:"dry_run"`
+	Changes []applyChange `json:"changes" yaml:"changes"`
+}
+
+func (r applyResponse) String() string {
+	var b bytes.Buffer
+	if len(r.Changes) == 0 {
+		fmt.Fprintln(&b, "The clusters are in the desired state, nothing to change.")
+		return b.String()
+	}
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Cluster", "Resource", "Name", "Action", "Result"})
+	for _, change := range r.Changes {
+		result := change.Result
+		if change.Error != "" {
+			result += ": " + change.Error
+		}
+		table.AddRow([]string{change.ClusterName, change.Resource, change.Name, change.Action, result})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	return b.String()
+}
+
+type applyOptions struct {
+	file   string
+	prune  bool
+	dryRun bool
+	yes    bool
+
+	// diffOut gets the diffs, stderr when the response is printed as JSON or YAML
+	diffOut io.Writer
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// NewCmdApply implements the apply command, it lives with the cluster commands as it manages the same resources
+func NewCmdApply(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &applyOptions{GlobalOptions: globalOpts}
+	applyCmd := &cobra.Command{
+		Use:               "apply -f FILE",
+		Short:             "Apply a declarative spec of the labels, machine pools, upgrade policy and limited support reasons of clusters",
+		Long:              applyLongDescription,
+		Example:           applyExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	applyCmd.Flags().StringVarP(&ops.file, "filename", "f", "", "The spec to apply, '-' reads it from stdin")
+	applyCmd.Flags().BoolVar(&ops.prune, "prune", false, "Remove the labels, machine pools and limited support reasons of the managed sections that the spec doesn't declare")
+	applyCmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Print the changes without applying them")
+	applyCmd.Flags().BoolVarP(&ops.yes, "yes", "y", false, "Skip the confirmation prompt of every cluster")
+	_ = applyCmd.MarkFlagRequired("filename")
+
+	return applyCmd
+}
+
+func (o *applyOptions) complete(cmd *cobra.Command) error {
+	if o.file == "" {
+		return cmdutil.UsageErrorf(cmd, "the spec to apply is required, use -f")
+	}
+	o.diffOut = os.Stdout
+	if o.GlobalOptions != nil && o.GlobalOptions.Output != "" {
+		o.diffOut = os.Stderr
+	}
+	return nil
+}
+
+func (o *applyOptions) run() error {
+	var data []byte
+	var err error
+	if o.file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(o.file)
+	}
+	if err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cannot read the spec: %v", err)
+	}
+	state, err := parseDesiredState(data)
+	if err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	// Every cluster is planned before anything changes, so that an invalid spec doesn't leave the fleet half applied
+	type clusterPlan struct {
+		cluster *cmv1.Cluster
+		changes []applyChange
+	}
+	var plans []clusterPlan
+	for _, entry := range state.Clusters {
+		clusters, err := resolveStateClusters(connection, entry)
+		if err != nil {
+			return err
+		}
+		for _, cluster := range clusters {
+			changes, err := planCluster(connection, cluster, entry, o.prune)
+			if err != nil {
+				return fmt.Errorf("cannot compute the changes to cluster %s: %w", cluster.ID(), err)
+			}
+			plans = append(plans, clusterPlan{cluster: cluster, changes: changes})
+		}
+	}
+
+	response := applyResponse{DryRun: o.dryRun, Changes: []applyChange{}}
+	failed := 0
+	for _, plan := range plans {
+		changes, err := o.applyCluster(connection, plan.cluster, plan.changes)
+		if err != nil {
+			return err
+		}
+		for _, change := range changes {
+			if change.Result == applyResultFailed {
+				failed++
+			}
+		}
+		response.Changes = append(response.Changes, changes...)
+	}
+
+	if err := outputflag.PrintResponse(o.GlobalOptions.Output, response); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return osdctlErrors.New(osdctlErrors.ErrTransient, "%d change(s) failed", failed)
+	}
+	if o.dryRun && len(response.Changes) > 0 {
+		fmt.Fprintln(o.diffOut, "This is a dry run, nothing changed.")
+	}
+	return nil
+}
+
+// applyCluster prints the diff of the changes to the cluster, then makes them once confirmed. The changes are
+// returned with their result, a failed change doesn't stop the following ones.
+func (o *applyOptions) applyCluster(connection *sdk.Connection, cluster *cmv1.Cluster, changes []applyChange) ([]applyChange, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	fmt.Fprintf(o.diffOut, "Cluster %s (%s): %d change(s)\n", cluster.Name(), cluster.ID(), len(changes))
+	for _, change := range changes {
+		diff := &printer.Diff{
+			Title:  fmt.Sprintf("%s %s '%s':", change.Action, change.Resource, change.Name),
+			Before: change.before,
+			After:  change.after,
+		}
+		if err := diff.Print(o.diffOut); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.dryRun {
+		return setResult(changes, applyResultPlanned), nil
+	}
+	err := utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("Apply %d change(s) from %s", len(changes), o.file)),
+		SkipPrompt: o.yes,
+	})
+	if err != nil {
+		fmt.Fprintf(o.diffOut, "Skipping cluster %s: %v\n", cluster.ID(), err)
+		return setResult(changes, applyResultSkipped), nil
+	}
+
+	applied := 0
+	for i := range changes {
+		if err := changes[i].apply(connection); err != nil {
+			changes[i].Result = applyResultFailed
+			changes[i].Error = err.Error()
+			continue
+		}
+		changes[i].Result = applyResultApplied
+		applied++
+	}
+	if applied > 0 {
+		utils.RecordClusterAction(connection, cluster, fmt.Sprintf("Apply %d change(s) from %s", applied, o.file))
+	}
+	return changes, nil
+}
+
+func setResult(changes []applyChange, result string) []applyChange {
+	for i := range changes {
+		changes[i].Result = result
+	}
+	return changes
+}
+
+// parseDesiredState reads the spec, rejecting the unknown fields so that a typo doesn't go unnoticed
+func parseDesiredState(data []byte) (*desiredState, error) {
+	state := &desiredState{}
+	if err := yaml.UnmarshalStrict(data, state); err != nil {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "invalid spec: %v", err)
+	}
+	if len(state.Clusters) == 0 {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "the spec declares no cluster")
+	}
+	for i, entry := range state.Clusters {
+		if err := entry.validate(); err != nil {
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "invalid clusters[%d]: %v", i, err)
+		}
+	}
+	return state, nil
+}
+
+func (s clusterState) validate() error {
+	if (s.Cluster == "") == (s.Search == "") {
+		return fmt.Errorf("exactly one of 'cluster' and 'search' is required")
+	}
+	if s.Labels != nil {
+		for key, value := range s.Labels.Subscription {
+			if err := validateLabel(labelScopeSubscription, key, value); err != nil {
+				return err
+			}
+		}
+		for key, value := range s.Labels.Cluster {
+			if err := validateLabel(labelScopeCluster, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for _, pool := range s.MachinePools {
+		if pool.ID == "" {
+			return fmt.Errorf("a machine pool has no id")
+		}
+		if seen[pool.ID] {
+			return fmt.Errorf("machine pool '%s' is declared twice", pool.ID)
+		}
+		seen[pool.ID] = true
+		if pool.Replicas != nil && pool.Autoscaling != nil {
+			return fmt.Errorf("machine pool '%s' has both replicas and autoscaling", pool.ID)
+		}
+		if pool.Autoscaling != nil && pool.Autoscaling.MinReplicas > pool.Autoscaling.MaxReplicas {
+			return fmt.Errorf("machine pool '%s' has more minReplicas than maxReplicas", pool.ID)
+		}
+	}
+	if policy := s.UpgradePolicy; policy != nil {
+		if (policy.Schedule == "") == (policy.Version == "") {
+			return fmt.Errorf("the upgrade policy needs either a schedule, or a version and a nextRun")
+		}
+		if policy.Version != "" && policy.NextRun == nil {
+			return fmt.Errorf("the manual upgrade policy to %s has no nextRun", policy.Version)
+		}
+	}
+	for _, reason := range s.LimitedSupportReasons {
+		if reason.Summary == "" {
+			return fmt.Errorf("a limited support reason has no summary")
+		}
+	}
+	return nil
+}
+
+// resolveStateClusters returns the cluster of the entry, or the clusters matching its search
+func resolveStateClusters(connection *sdk.Connection, entry clusterState) ([]*cmv1.Cluster, error) {
+	if entry.Search != "" {
+		clusters, err := searchClusters(connection, entry.Search, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(clusters) == 0 {
+			return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "no cluster matches \"%s\"", entry.Search)
+		}
+		return clusters, nil
+	}
+	cluster, err := utils.GetCluster(connection, entry.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	return []*cmv1.Cluster{cluster}, nil
+}
+
+// planCluster reads the current state of the managed sections of the cluster and returns the changes to make
+func planCluster(connection *sdk.Connection, cluster *cmv1.Cluster, entry clusterState, prune bool) ([]applyChange, error) {
+	resource := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID())
+	var changes []applyChange
+
+	if entry.Labels != nil {
+		labels, err := listLabels(connection, cluster, "")
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, planLabels(cluster, entry.Labels, labels, prune)...)
+	}
+
+	if entry.MachinePools != nil {
+		if cluster.Hypershift().Enabled() {
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s is an HCP cluster, its node pools can't be applied", cluster.ID())
+		}
+		response, err := resource.MachinePools().List().Size(exportPageSize).Send()
+		if err != nil {
+			return nil, fmt.Errorf("can't retrieve the machine pools of cluster %s: %w", cluster.ID(), err)
+		}
+		poolChanges, err := planMachinePools(cluster, entry.MachinePools, response.Items().Slice(), prune)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, poolChanges...)
+	}
+
+	if entry.UpgradePolicy != nil {
+		response, err := resource.UpgradePolicies().List().Size(exportPageSize).Send()
+		if err != nil {
+			return nil, fmt.Errorf("can't retrieve the upgrade policies of cluster %s: %w", cluster.ID(), err)
+		}
+		if change := planUpgradePolicy(cluster, entry.UpgradePolicy, response.Items().Slice()); change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	if entry.LimitedSupportReasons != nil {
+		reasons, err := utils.GetClusterLimitedSupportReasons(connection, cluster.ID())
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, planLimitedSupportReasons(cluster, entry.LimitedSupportReasons, reasons, prune)...)
+	}
+
+	return changes, nil
+}
+
+func newApplyChange(cluster *cmv1.Cluster, resource, name, action string, before, after interface{}, apply func(*sdk.Connection) error) applyChange {
+	return applyChange{
+		ClusterID:   cluster.ID(),
+		ClusterName: cluster.Name(),
+		Resource:    resource,
+		Name:        name,
+		Action:      action,
+		before:      before,
+		after:       after,
+		apply:       apply,
+	}
+}
+
+// planLabels sets the declared labels of each scope, and removes the undeclared ones with prune. The labels with a
+// reserved prefix are managed outside of osdctl and never removed.
+func planLabels(cluster *cmv1.Cluster, desired *labelsState, current []clusterLabel, prune bool) []applyChange {
+	var changes []applyChange
+	plan := func(scope, resource string, declared map[string]string) {
+		if declared == nil {
+			return
+		}
+		existing := scopeLabels(current, scope)
+		for _, key := range sortedStringKeys(declared) {
+			value := declared[key]
+			label := findLabel(existing, key)
+			switch {
+			case label == nil:
+				changes = append(changes, newApplyChange(cluster, resource, key, applyActionCreate, nil, map[string]string{key: value},
+					func(connection *sdk.Connection) error {
+						return setStateLabel(connection, cluster, scope, key, value, nil)
+					}))
+			case label.Value != value:
+				previous := *label
+				changes = append(changes, newApplyChange(cluster, resource, key, applyActionUpdate, map[string]string{key: label.Value}, map[string]string{key: value},
+					func(connection *sdk.Connection) error {
+						return setStateLabel(connection, cluster, scope, key, value, &previous)
+					}))
+			}
+		}
+		if !prune {
+			return
+		}
+		for _, label := range existing {
+			if _, ok := declared[label.Key]; ok || reservedLabelPrefix(label.Key) != "" {
+				continue
+			}
+			removed := label
+			changes = append(changes, newApplyChange(cluster, resource, label.Key, applyActionDelete, map[string]string{label.Key: label.Value}, nil,
+				func(connection *sdk.Connection) error {
+					return deleteLabel(connection, cluster, removed)
+				}))
+		}
+	}
+	plan(labelScopeSubscription, applyResourceSubscriptionLabel, desired.Subscription)
+	plan(labelScopeCluster, applyResourceClusterLabel, desired.Cluster)
+	return changes
+}
+
+// setStateLabel adds the label, or updates the existing one keeping whether it is internal
+func setStateLabel(connection *sdk.Connection, cluster *cmv1.Cluster, scope, key, value string, existing *clusterLabel) error {
+	if scope == labelScopeSubscription {
+		return addSubscriptionLabel(connection, cluster.Subscription().ID(), key, value, existing != nil && existing.Internal, existing != nil)
+	}
+	return addClusterLabel(connection, cluster.ID(), key, value, existing)
+}
+
+// planMachinePools creates the declared machine pools that don't exist, updates the ones that differ, and deletes
+// the undeclared ones with prune
+func planMachinePools(cluster *cmv1.Cluster, desired []machinePoolState, current []*cmv1.MachinePool, prune bool) ([]applyChange, error) {
+	var changes []applyChange
+	currentByID := map[string]*cmv1.MachinePool{}
+	for _, pool := range current {
+		currentByID[pool.ID()] = pool
+	}
+
+	for _, pool := range desired {
+		spec := pool
+		existing, ok := currentByID[pool.ID]
+		if !ok {
+			if pool.InstanceType == "" {
+				return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "machine pool '%s' doesn't exist and has no instanceType to create it", pool.ID)
+			}
+			if pool.Replicas == nil && pool.Autoscaling == nil {
+				return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "machine pool '%s' doesn't exist and has neither replicas nor autoscaling to create it", pool.ID)
+			}
+			changes = append(changes, newApplyChange(cluster, applyResourceMachinePool, pool.ID, applyActionCreate, nil, desiredMachinePoolView(machinePoolView{ID: pool.ID}, pool),
+				func(connection *sdk.Connection) error {
+					body, err := machinePoolBuilder(spec).InstanceType(spec.InstanceType).Build()
+					if err != nil {
+						return fmt.Errorf("cannot build machine pool '%s': %w", spec.ID, err)
+					}
+					_, err = connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).MachinePools().Add().Body(body).Send()
+					return err
+				}))
+			continue
+		}
+
+		before := currentMachinePoolView(existing)
+		if pool.InstanceType != "" && pool.InstanceType != before.InstanceType {
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "the instance type of machine pool '%s' can't be changed from %s to %s", pool.ID, before.InstanceType, pool.InstanceType)
+		}
+		after := desiredMachinePoolView(before, pool)
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+		changes = append(changes, newApplyChange(cluster, applyResourceMachinePool, pool.ID, applyActionUpdate, before, after,
+			func(connection *sdk.Connection) error {
+				body, err := machinePoolBuilder(spec).Build()
+				if err != nil {
+					return fmt.Errorf("cannot build machine pool '%s': %w", spec.ID, err)
+				}
+				_, err = connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).MachinePools().MachinePool(spec.ID).Update().Body(body).Send()
+				return err
+			}))
+	}
+
+	if !prune {
+		return changes, nil
+	}
+	declared := map[string]bool{}
+	for _, pool := range desired {
+		declared[pool.ID] = true
+	}
+	for _, pool := range current {
+		if declared[pool.ID()] {
+			continue
+		}
+		id := pool.ID()
+		changes = append(changes, newApplyChange(cluster, applyResourceMachinePool, id, applyActionDelete, currentMachinePoolView(pool), nil,
+			func(connection *sdk.Connection) error {
+				_, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).MachinePools().MachinePool(id).Delete().Send()
+				return err
+			}))
+	}
+	return changes, nil
+}
+
+// machinePoolBuilder sets the declared fields of the machine pool, the others are left as they are by OCM
+func machinePoolBuilder(pool machinePoolState) *cmv1.MachinePoolBuilder {
+	builder := cmv1.NewMachinePool().ID(pool.ID)
+	if pool.Replicas != nil {
+		builder.Replicas(*pool.Replicas)
+	}
+	if pool.Autoscaling != nil {
+		builder.Autoscaling(cmv1.NewMachinePoolAutoscaling().MinReplicas(pool.Autoscaling.MinReplicas).MaxReplicas(pool.Autoscaling.MaxReplicas))
+	}
+	if pool.Labels != nil {
+		builder.Labels(pool.Labels)
+	}
+	if pool.Taints != nil {
+		taints := []*cmv1.TaintBuilder{}
+		for _, taint := range pool.Taints {
+			taints = append(taints, cmv1.NewTaint().Key(taint.Key).Value(taint.Value).Effect(taint.Effect))
+		}
+		builder.Taints(taints...)
+	}
+	return builder
+}
+
+func currentMachinePoolView(pool *cmv1.MachinePool) machinePoolView {
+	view := machinePoolView{ID: pool.ID(), InstanceType: pool.InstanceType()}
+	if autoscaling, ok := pool.GetAutoscaling(); ok {
+		view.Autoscaling = &autoscalingState{MinReplicas: autoscaling.MinReplicas(), MaxReplicas: autoscaling.MaxReplicas()}
+	} else {
+		replicas := pool.Replicas()
+		view.Replicas = &replicas
+	}
+	if len(pool.Labels()) > 0 {
+		view.Labels = pool.Labels()
+	}
+	for _, taint := range pool.Taints() {
+		view.Taints = append(view.Taints, machinePoolTaint{Key: taint.Key(), Value: taint.Value(), Effect: taint.Effect()})
+	}
+	return view
+}
+
+// desiredMachinePoolView is the view of the machine pool once the declared fields are applied
+func desiredMachinePoolView(view machinePoolView, pool machinePoolState) machinePoolView {
+	if pool.InstanceType != "" {
+		view.InstanceType = pool.InstanceType
+	}
+	if pool.Replicas != nil {
+		view.Replicas = pool.Replicas
+		view.Autoscaling = nil
+	}
+	if pool.Autoscaling != nil {
+		view.Autoscaling = pool.Autoscaling
+		view.Replicas = nil
+	}
+	if pool.Labels != nil {
+		view.Labels = nil
+		if len(pool.Labels) > 0 {
+			view.Labels = pool.Labels
+		}
+	}
+	if pool.Taints != nil {
+		view.Taints = nil
+		if len(pool.Taints) > 0 {
+			view.Taints = pool.Taints
+		}
+	}
+	return view
+}
+
+// planUpgradePolicy replaces the upgrade policies of the cluster with the declared one. An automatic policy whose
+// schedule changed is updated in place, the other changes delete the current policies and create the new one.
+func planUpgradePolicy(cluster *cmv1.Cluster, desired *upgradePolicyState, current []*cmv1.UpgradePolicy) *applyChange {
+	after := upgradePolicyView{ScheduleType: scheduleTypeAutomatic, Schedule: desired.Schedule}
+	if desired.Version != "" {
+		nextRun := desired.NextRun.UTC()
+		after = upgradePolicyView{ScheduleType: scheduleTypeManual, Version: desired.Version, NextRun: &nextRun}
+	}
+
+	// The add-on upgrade policies have their own upgrade type
+	var policies []*cmv1.UpgradePolicy
+	for _, policy := range current {
+		if policy.UpgradeType() == "" || policy.UpgradeType() == "OSD" {
+			policies = append(policies, policy)
+		}
+	}
+
+	create := func(connection *sdk.Connection) error {
+		builder := cmv1.NewUpgradePolicy().ScheduleType(after.ScheduleType).UpgradeType("OSD")
+		if after.ScheduleType == scheduleTypeAutomatic {
+			builder.Schedule(after.Schedule)
+		} else {
+			builder.Version(after.Version).NextRun(*after.NextRun)
+		}
+		body, err := builder.Build()
+		if err != nil {
+			return fmt.Errorf("cannot build the upgrade policy: %w", err)
+		}
+		_, err = connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).UpgradePolicies().Add().Body(body).Send()
+		return err
+	}
+
+	if len(policies) == 0 {
+		change := newApplyChange(cluster, applyResourceUpgradePolicy, after.ScheduleType, applyActionCreate, nil, after, create)
+		return &change
+	}
+	before := currentUpgradePolicyView(policies[0])
+	if len(policies) == 1 && reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	if len(policies) == 1 && before.ScheduleType == scheduleTypeAutomatic && after.ScheduleType == scheduleTypeAutomatic {
+		id := policies[0].ID()
+		change := newApplyChange(cluster, applyResourceUpgradePolicy, after.ScheduleType, applyActionUpdate, before, after,
+			func(connection *sdk.Connection) error {
+				body, err := cmv1.NewUpgradePolicy().Schedule(after.Schedule).Build()
+				if err != nil {
+					return fmt.Errorf("cannot build the upgrade policy: %w", err)
+				}
+				_, err = connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).UpgradePolicies().UpgradePolicy(id).Update().Body(body).Send()
+				return err
+			})
+		return &change
+	}
+
+	ids := []string{}
+	for _, policy := range policies {
+		ids = append(ids, policy.ID())
+	}
+	change := newApplyChange(cluster, applyResourceUpgradePolicy, after.ScheduleType, applyActionUpdate, before, after,
+		func(connection *sdk.Connection) error {
+			for _, id := range ids {
+				if _, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).UpgradePolicies().UpgradePolicy(id).Delete().Send(); err != nil {
+					return fmt.Errorf("cannot delete upgrade policy %s: %w", id, err)
+				}
+			}
+			return create(connection)
+		})
+	return &change
+}
+
+func currentUpgradePolicyView(policy *cmv1.UpgradePolicy) upgradePolicyView {
+	view := upgradePolicyView{ScheduleType: policy.ScheduleType(), Schedule: policy.Schedule(), Version: policy.Version()}
+	if policy.ScheduleType() == scheduleTypeManual {
+		nextRun := policy.NextRun().UTC()
+		view.NextRun = &nextRun
+		view.Schedule = ""
+	} else {
+		// The version of an automatic policy is the one of its next run, it isn't managed
+		view.Version = ""
+	}
+	return view
+}
+
+// planLimitedSupportReasons creates the declared reasons the cluster doesn't have, recreates the ones whose details
+// changed as they can't be updated, and deletes the undeclared ones with prune. The reasons are matched by summary.
+func planLimitedSupportReasons(cluster *cmv1.Cluster, desired []limitedSupportState, current []*utils.LimitedSupportReasonItem, prune bool) []applyChange {
+	var changes []applyChange
+	add := func(connection *sdk.Connection, reason limitedSupportState) error {
+		body, err := cmv1.NewLimitedSupportReason().Summary(reason.Summary).Details(reason.Details).DetectionType(cmv1.DetectionTypeManual).Build()
+		if err != nil {
+			return fmt.Errorf("cannot build limited support reason '%s': %w", reason.Summary, err)
+		}
+		_, err = connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).LimitedSupportReasons().Add().Body(body).Send()
+		return err
+	}
+	remove := func(connection *sdk.Connection, id string) error {
+		_, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).LimitedSupportReasons().LimitedSupportReason(id).Delete().Send()
+		return err
+	}
+
+	declared := map[string]bool{}
+	for _, reason := range desired {
+		spec := reason
+		declared[reason.Summary] = true
+		var existing *utils.LimitedSupportReasonItem
+		for _, item := range current {
+			if item.Summary == reason.Summary {
+				existing = item
+				break
+			}
+		}
+		switch {
+		case existing == nil:
+			changes = append(changes, newApplyChange(cluster, applyResourceLimitedSupport, reason.Summary, applyActionCreate, nil, spec,
+				func(connection *sdk.Connection) error { return add(connection, spec) }))
+		case existing.Details != reason.Details:
+			id := existing.ID
+			changes = append(changes, newApplyChange(cluster, applyResourceLimitedSupport, reason.Summary, applyActionUpdate,
+				limitedSupportState{Summary: existing.Summary, Details: existing.Details}, spec,
+				func(connection *sdk.Connection) error {
+					if err := remove(connection, id); err != nil {
+						return err
+					}
+					return add(connection, spec)
+				}))
+		}
+	}
+
+	if !prune {
+		return changes
+	}
+	for _, item := range current {
+		if declared[item.Summary] {
+			continue
+		}
+		id := item.ID
+		changes = append(changes, newApplyChange(cluster, applyResourceLimitedSupport, item.Summary, applyActionDelete,
+			limitedSupportState{Summary: item.Summary, Details: item.Details}, nil,
+			func(connection *sdk.Connection) error { return remove(connection, id) }))
+	}
+	return changes
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,154 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/utils"
+)
+
+// changeSummaries leaves out the closures applying the changes, which can't be compared
+func changeSummaries(changes []applyChange) []string {
+	summaries := []string{}
+	for _, change := range changes {
+		summaries = append(summaries, change.Action+" "+change.Resource+" "+change.Name)
+	}
+	return summaries
+}
+
+func TestParseDesiredState(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	state, err := parseDesiredState([]byte(`
+clusters:
+- cluster: mycluster
+  labels:
+    subscription:
+      my.feature.opt-in: "true"
+  machinePools:
+  - id: infra
+    replicas: 3
+`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(state.Clusters).To(HaveLen(1))
+	g.Expect(state.Clusters[0].Labels.Subscription).To(HaveKeyWithValue("my.feature.opt-in", "true"))
+	g.Expect(*state.Clusters[0].MachinePools[0].Replicas).To(Equal(3))
+	g.Expect(state.Clusters[0].UpgradePolicy).To(BeNil())
+
+	for _, invalid := range []string{
+		"clusters: []",
+		"clusters:\n- cluster: mycluster\n  lables: {}",
+		"clusters:\n- cluster: mycluster\n  search: state='ready'",
+		"clusters:\n- cluster: mycluster\n  labels:\n    cluster:\n      hive.openshift.io/foo: bar",
+		"clusters:\n- cluster: mycluster\n  machinePools:\n  - id: infra\n    replicas: 2\n    autoscaling: {minReplicas: 1, maxReplicas: 3}",
+		"clusters:\n- cluster: mycluster\n  upgradePolicy:\n    version: 4.14.10",
+	} {
+		_, err := parseDesiredState([]byte(invalid))
+		g.Expect(err).To(HaveOccurred(), invalid)
+	}
+}
+
+func TestPlanLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster, err := cmv1.NewCluster().ID("abc").Name("mycluster").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	current := []clusterLabel{
+		{Scope: labelScopeSubscription, Key: "keep", Value: "same"},
+		{Scope: labelScopeSubscription, Key: "change", Value: "old"},
+		{Scope: labelScopeSubscription, Key: "stale", Value: "x"},
+		{Scope: labelScopeSubscription, Key: "capability.cluster.manage_cluster_admin", Value: "true"},
+		{Scope: labelScopeCluster, Key: "team", Value: "sre", id: "1"},
+	}
+	desired := &labelsState{Subscription: map[string]string{"keep": "same", "change": "new", "added": "yes"}}
+
+	g.Expect(changeSummaries(planLabels(cluster, desired, current, false))).To(Equal([]string{
+		"create subscription label added",
+		"update subscription label change",
+	}))
+	// The cluster labels aren't managed, and the reserved labels are never pruned
+	g.Expect(changeSummaries(planLabels(cluster, desired, current, true))).To(Equal([]string{
+		"create subscription label added",
+		"update subscription label change",
+		"delete subscription label stale",
+	}))
+}
+
+func TestPlanMachinePools(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster, err := cmv1.NewCluster().ID("abc").Name("mycluster").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	worker, err := cmv1.NewMachinePool().ID("worker").InstanceType("m5.xlarge").Replicas(3).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	infra, err := cmv1.NewMachinePool().ID("infra").InstanceType("r5.xlarge").
+		Autoscaling(cmv1.NewMachinePoolAutoscaling().MinReplicas(2).MaxReplicas(4)).Labels(map[string]string{"role": "infra"}).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	current := []*cmv1.MachinePool{worker, infra}
+	three, five := 3, 5
+
+	changes, err := planMachinePools(cluster, []machinePoolState{
+		{ID: "worker", Replicas: &three},
+		{ID: "infra", Autoscaling: &autoscalingState{MinReplicas: 2, MaxReplicas: 6}},
+		{ID: "gpu", InstanceType: "g4dn.xlarge", Replicas: &five},
+	}, current, true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changeSummaries(changes)).To(Equal([]string{"update machine pool infra", "create machine pool gpu"}))
+	g.Expect(changes[0].after).To(Equal(machinePoolView{ID: "infra", InstanceType: "r5.xlarge",
+		Autoscaling: &autoscalingState{MinReplicas: 2, MaxReplicas: 6}, Labels: map[string]string{"role": "infra"}}))
+
+	changes, err = planMachinePools(cluster, []machinePoolState{{ID: "worker", Labels: map[string]string{}}}, current, true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changeSummaries(changes)).To(Equal([]string{"delete machine pool infra"}))
+
+	_, err = planMachinePools(cluster, []machinePoolState{{ID: "gpu", Replicas: &five}}, current, false)
+	g.Expect(err).To(HaveOccurred())
+	_, err = planMachinePools(cluster, []machinePoolState{{ID: "worker", InstanceType: "m5.2xlarge"}}, current, false)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestPlanUpgradePolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster, err := cmv1.NewCluster().ID("abc").Name("mycluster").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	automatic, err := cmv1.NewUpgradePolicy().ID("p1").UpgradeType("OSD").ScheduleType(scheduleTypeAutomatic).Schedule("0 8 * * 1").Version("4.14.10").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	addon, err := cmv1.NewUpgradePolicy().ID("p2").UpgradeType("ADDON").ScheduleType(scheduleTypeManual).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(planUpgradePolicy(cluster, &upgradePolicyState{Schedule: "0 8 * * 1"}, []*cmv1.UpgradePolicy{automatic, addon})).To(BeNil())
+
+	change := planUpgradePolicy(cluster, &upgradePolicyState{Schedule: "0 2 * * 6"}, []*cmv1.UpgradePolicy{automatic})
+	g.Expect(change).NotTo(BeNil())
+	g.Expect(change.Action).To(Equal(applyActionUpdate))
+	g.Expect(change.before).To(Equal(upgradePolicyView{ScheduleType: scheduleTypeAutomatic, Schedule: "0 8 * * 1"}))
+
+	nextRun := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	change = planUpgradePolicy(cluster, &upgradePolicyState{Version: "4.15.2", NextRun: &nextRun}, []*cmv1.UpgradePolicy{addon})
+	g.Expect(change).NotTo(BeNil())
+	g.Expect(change.Action).To(Equal(applyActionCreate))
+	g.Expect(change.after).To(Equal(upgradePolicyView{ScheduleType: scheduleTypeManual, Version: "4.15.2", NextRun: &nextRun}))
+}
+
+func TestPlanLimitedSupportReasons(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster, err := cmv1.NewCluster().ID("abc").Name("mycluster").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	current := []*utils.LimitedSupportReasonItem{
+		{ID: "1", Summary: "Accepted risk", Details: "old details"},
+		{ID: "2", Summary: "Unsupported configuration", Details: "details"},
+	}
+	desired := []limitedSupportState{
+		{Summary: "Accepted risk", Details: "new details"},
+		{Summary: "Customer request", Details: "details"},
+	}
+
+	g.Expect(changeSummaries(planLimitedSupportReasons(cluster, desired, current, false))).To(Equal([]string{
+		"update limited support reason Accepted risk",
+		"create limited support reason Customer request",
+	}))
+	g.Expect(changeSummaries(planLimitedSupportReasons(cluster, desired, current, true))).To(ContainElement(
+		"delete limited support reason Unsupported configuration"))
+	g.Expect(planLimitedSupportReasons(cluster, []limitedSupportState{}, nil, true)).To(BeEmpty())
+}
@@ -0,0 +1,478 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	backupSourceVelero = "velero"
+	backupSourceEtcd   = "etcd"
+
+	veleroBackupPhaseCompleted = "Completed"
+
+	backupsLong = `Lists the backups of a cluster and restores them.
+
+  The backups are the Velero backups of the managed-velero-operator (openshift-velero namespace) or of OADP
+  (openshift-adp namespace), and the etcd backups of the EtcdBackup CRs. Hive doesn't back up clusters.
+
+  Only Velero backups can be restored, by creating a Velero Restore. Restoring etcd follows the etcd disaster
+  recovery procedure of the OpenShift documentation and isn't done by osdctl.
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'), the commands are
+  run as backplane-cluster-admin. The cluster the current kubeconfig points to is checked against CLUSTER_ID.`
+
+	backupsExample = `
+  # List the Velero and etcd backups of a cluster
+  osdctl cluster backups list 1kfmyclusteristhebesteverp8m
+
+  # Print the Velero Restore that would be created
+  osdctl cluster backups restore 1kfmyclusteristhebesteverp8m daily-20240601 --dry-run
+
+  # Restore two namespaces of a backup, the cluster name must be typed to confirm
+  osdctl cluster backups restore 1kfmyclusteristhebesteverp8m daily-20240601 --include-namespaces app,app-db
+`
+)
+
+// veleroNamespaces are the namespaces Velero runs in, for the managed-velero-operator and OADP
+var veleroNamespaces = []string{"openshift-velero", "openshift-adp"}
+
+type backupsOptions struct {
+	clusterID         string
+	backupName        string
+	includeNamespaces []string
+	dryRun            bool
+
+	runOC         utils.OCRunner
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// clusterBackup is a Velero or etcd backup of a cluster
+type clusterBackup struct {
+	Source    string     `json:"source" yaml:"source"`
+	Namespace string     `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name      string     `json:"name" yaml:"name"`
+	Phase     string     `json:"phase" yaml:"phase"`
+	Created   time.Time  `json:"created" yaml:"created"`
+	Expires   *time.Time `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Location  string     `json:"location,omitempty" yaml:"location,omitempty"`
+	Items     int        `json:"items,omitempty" yaml:"items,omitempty"`
+	Errors    int        `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Warnings  int        `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+type backupsResponse struct {
+	Backups []clusterBackup `json:"backups" yaml:"backups"`
+}
+
+func (r backupsResponse) String() string {
+	var b bytes.Buffer
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"SOURCE", "NAMESPACE", "NAME", "PHASE", "CREATED", "EXPIRES", "LOCATION", "ITEMS", "ERRORS"})
+	for _, backup := range r.Backups {
+		expires := ""
+		if backup.Expires != nil {
+			expires = timefmt.Format(*backup.Expires)
+		}
+		table.AddRow([]string{
+			backup.Source,
+			backup.Namespace,
+			backup.Name,
+			backup.Phase,
+			timefmt.Format(backup.Created),
+			expires,
+			backup.Location,
+			fmt.Sprint(backup.Items),
+			fmt.Sprint(backup.Errors),
+		})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the backups: %v", err)
+	}
+	return b.String()
+}
+
+func newCmdBackups(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	backupsCmd := &cobra.Command{
+		Use:               "backups",
+		Short:             "Lists the Velero and etcd backups of a cluster and restores Velero backups",
+		Long:              backupsLong,
+		Example:           backupsExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	listOpts := &backupsOptions{runOC: utils.RunOCAsClusterAdmin, GlobalOptions: globalOpts}
+	backupsCmd.AddCommand(&cobra.Command{
+		Use:               "list CLUSTER_ID",
+		Short:             "Lists the Velero and etcd backups of a cluster, newest first",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			listOpts.clusterID = args[0]
+			osdctlErrors.CheckErr(listOpts.runList())
+		},
+	})
+
+	restoreOpts := &backupsOptions{runOC: utils.RunOCAsClusterAdmin, GlobalOptions: globalOpts}
+	restoreCmd := &cobra.Command{
+		Use:   "restore CLUSTER_ID BACKUP_NAME",
+		Short: "Restores a completed Velero backup, after typing the cluster name to confirm",
+		Long: `Restores a Velero backup by creating a Velero Restore from it.
+
+  The backup must be Completed and not expired, and no other restore may be running. The cluster name must be typed
+  to confirm, there is no way to skip the confirmation. The progress of the restore is followed with
+  'oc get restores.velero.io -n NAMESPACE RESTORE_NAME'.`,
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			restoreOpts.clusterID = args[0]
+			restoreOpts.backupName = args[1]
+			osdctlErrors.CheckErr(restoreOpts.runRestore())
+		},
+	}
+	restoreCmd.Flags().StringSliceVar(&restoreOpts.includeNamespaces, "include-namespaces", nil, "Only restore these namespaces, all the namespaces of the backup by default")
+	restoreCmd.Flags().BoolVarP(&restoreOpts.dryRun, "dry-run", "d", false, "Print the Velero Restore without creating it")
+	backupsCmd.AddCommand(restoreCmd)
+
+	return backupsCmd
+}
+
+func (o *backupsOptions) runList() error {
+	if err := o.connect(); err != nil {
+		return err
+	}
+	backups, err := listClusterBackups(o.runOC)
+	if err != nil {
+		return err
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, backupsResponse{Backups: backups})
+}
+
+func (o *backupsOptions) runRestore() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	backups, err := listClusterBackups(o.runOC)
+	if err != nil {
+		return err
+	}
+	backup, err := restorableBackup(backups, o.backupName, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := checkNoRunningRestore(o.runOC, backup.Namespace); err != nil {
+		return err
+	}
+
+	restore := veleroRestore(backup, o.includeNamespaces, time.Now())
+	manifest, err := json.MarshalIndent(restore, "", "  ")
+	if err != nil {
+		return err
+	}
+	if o.dryRun {
+		fmt.Println(string(manifest))
+		return nil
+	}
+
+	namespaces := "every namespace"
+	if len(o.includeNamespaces) > 0 {
+		namespaces = strings.Join(o.includeNamespaces, ", ")
+	}
+	fmt.Fprintf(os.Stderr, "Backup %s/%s was created %s, restoring %s\n\n", backup.Namespace, backup.Name, timefmt.Format(backup.Created), namespaces)
+	// Restores overwrite what is on the cluster and can't be undone, so require the cluster name to be typed
+	if err := utils.Confirm(utils.ConfirmOptions{
+		Summary:           utils.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("restore the Velero backup %s", backup.Name)),
+		TypedConfirmation: cluster.Name(),
+	}); err != nil {
+		return err
+	}
+
+	if _, err := createFromManifest(o.runOC, manifest); err != nil {
+		return fmt.Errorf("cannot create the restore: %w", err)
+	}
+	utils.RecordClusterAction(connection, cluster, fmt.Sprintf("Restore the Velero backup %s", backup.Name))
+	fmt.Printf("Created restore %s, follow it with 'oc get restores.velero.io -n %s %s'\n", restore.Metadata.Name, backup.Namespace, restore.Metadata.Name)
+	return nil
+}
+
+// connect checks that the current kubeconfig points to the cluster
+func (o *backupsOptions) connect() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	return utils.CheckOCCluster(o.runOC, cluster)
+}
+
+// listClusterBackups returns the Velero and etcd backups of the cluster, newest first.
+// The sources whose CRD isn't installed on the cluster are skipped.
+func listClusterBackups(run utils.OCRunner) ([]clusterBackup, error) {
+	backups := []clusterBackup{}
+	for _, namespace := range veleroNamespaces {
+		output, err := run("get", "backups.velero.io", "-n", namespace, "-o", "json", "--ignore-not-found")
+		if err != nil {
+			if isMissingResource(err) {
+				continue
+			}
+			return nil, fmt.Errorf("cannot list the Velero backups in %s: %w", namespace, err)
+		}
+		found, err := parseVeleroBackups(output)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, found...)
+	}
+
+	output, err := run("get", "etcdbackups.operator.openshift.io", "-o", "json", "--ignore-not-found")
+	if err != nil && !isMissingResource(err) {
+		return nil, fmt.Errorf("cannot list the etcd backups: %w", err)
+	}
+	if err == nil {
+		found, err := parseEtcdBackups(output)
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, found...)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+	return backups, nil
+}
+
+// isMissingResource returns true when oc failed because the CRD of the resource isn't installed
+func isMissingResource(err error) bool {
+	return strings.Contains(err.Error(), "the server doesn't have a resource type")
+}
+
+func parseVeleroBackups(output []byte) ([]clusterBackup, error) {
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, nil
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Namespace         string    `json:"namespace"`
+				Name              string    `json:"name"`
+				CreationTimestamp time.Time `json:"creationTimestamp"`
+			} `json:"metadata"`
+			Spec struct {
+				StorageLocation string `json:"storageLocation"`
+			} `json:"spec"`
+			Status struct {
+				Phase          string     `json:"phase"`
+				StartTimestamp *time.Time `json:"startTimestamp"`
+				Expiration     *time.Time `json:"expiration"`
+				Errors         int        `json:"errors"`
+				Warnings       int        `json:"warnings"`
+				Progress       struct {
+					ItemsBackedUp int `json:"itemsBackedUp"`
+				} `json:"progress"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("cannot parse the Velero backups: %w", err)
+	}
+
+	backups := make([]clusterBackup, 0, len(list.Items))
+	for _, item := range list.Items {
+		backup := clusterBackup{
+			Source:    backupSourceVelero,
+			Namespace: item.Metadata.Namespace,
+			Name:      item.Metadata.Name,
+			Phase:     item.Status.Phase,
+			Created:   item.Metadata.CreationTimestamp,
+			Expires:   item.Status.Expiration,
+			Location:  item.Spec.StorageLocation,
+			Items:     item.Status.Progress.ItemsBackedUp,
+			Errors:    item.Status.Errors,
+			Warnings:  item.Status.Warnings,
+		}
+		if item.Status.StartTimestamp != nil {
+			backup.Created = *item.Status.StartTimestamp
+		}
+		backups = append(backups, backup)
+	}
+	return backups, nil
+}
+
+func parseEtcdBackups(output []byte) ([]clusterBackup, error) {
+	if len(bytes.TrimSpace(output)) == 0 {
+		return nil, nil
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name              string    `json:"name"`
+				CreationTimestamp time.Time `json:"creationTimestamp"`
+			} `json:"metadata"`
+			Spec struct {
+				PVCName string `json:"pvcName"`
+			} `json:"spec"`
+			Status struct {
+				Conditions []struct {
+					Type   string `json:"type"`
+					Status string `json:"status"`
+					Reason string `json:"reason"`
+				} `json:"conditions"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("cannot parse the etcd backups: %w", err)
+	}
+
+	backups := make([]clusterBackup, 0, len(list.Items))
+	for _, item := range list.Items {
+		// The backup is done once a condition is true, its reason tells whether it succeeded
+		phase := "InProgress"
+		for _, condition := range item.Status.Conditions {
+			if condition.Status == "True" {
+				phase = condition.Reason
+				if phase == "" {
+					phase = condition.Type
+				}
+			}
+		}
+		backups = append(backups, clusterBackup{
+			Source:   backupSourceEtcd,
+			Name:     item.Metadata.Name,
+			Phase:    phase,
+			Created:  item.Metadata.CreationTimestamp,
+			Location: "pvc/" + item.Spec.PVCName,
+		})
+	}
+	return backups, nil
+}
+
+// restorableBackup returns the Velero backup to restore, or why it can't be restored
+func restorableBackup(backups []clusterBackup, name string, now time.Time) (clusterBackup, error) {
+	var matches []clusterBackup
+	for _, backup := range backups {
+		if backup.Name == name {
+			matches = append(matches, backup)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return clusterBackup{}, osdctlErrors.New(osdctlErrors.ErrNotFound, "there is no backup named %s, see 'osdctl cluster backups list'", name)
+	case len(matches) > 1:
+		return clusterBackup{}, osdctlErrors.New(osdctlErrors.ErrValidation, "there are %d backups named %s", len(matches), name)
+	}
+
+	backup := matches[0]
+	if backup.Source == backupSourceEtcd {
+		return clusterBackup{}, osdctlErrors.New(osdctlErrors.ErrValidation, "%s is an etcd backup, restoring etcd follows the etcd disaster recovery procedure of the OpenShift documentation", name)
+	}
+	if backup.Phase != veleroBackupPhaseCompleted {
+		return clusterBackup{}, osdctlErrors.New(osdctlErrors.ErrValidation, "backup %s is %s, only Completed backups can be restored", name, backup.Phase)
+	}
+	if backup.Expires != nil && backup.Expires.Before(now) {
+		return clusterBackup{}, osdctlErrors.New(osdctlErrors.ErrValidation, "backup %s expired at %s", name, timefmt.Format(*backup.Expires))
+	}
+	return backup, nil
+}
+
+// checkNoRunningRestore refuses to start a restore while another one is running in the namespace
+func checkNoRunningRestore(run utils.OCRunner, namespace string) error {
+	output, err := run("get", "restores.velero.io", "-n", namespace, "-o", "json")
+	if err != nil {
+		return fmt.Errorf("cannot list the Velero restores in %s: %w", namespace, err)
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				Phase string `json:"phase"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return fmt.Errorf("cannot parse the Velero restores: %w", err)
+	}
+	for _, item := range list.Items {
+		switch item.Status.Phase {
+		case "", "New", "InProgress", "WaitingForPluginOperations", "WaitingForPluginOperationsPartiallyFailed", "Finalizing":
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "restore %s/%s is %s, wait for it to finish", namespace, item.Metadata.Name, item.Status.Phase)
+		}
+	}
+	return nil
+}
+
+type veleroRestoreManifest struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		BackupName         string   `json:"backupName"`
+		IncludedNamespaces []string `json:"includedNamespaces,omitempty"`
+	} `json:"spec"`
+}
+
+func veleroRestore(backup clusterBackup, includeNamespaces []string, now time.Time) veleroRestoreManifest {
+	restore := veleroRestoreManifest{APIVersion: "velero.io/v1", Kind: "Restore"}
+	restore.Metadata.Name = fmt.Sprintf("%s-%s", backup.Name, now.UTC().Format("20060102150405"))
+	restore.Metadata.Namespace = backup.Namespace
+	restore.Metadata.Labels = map[string]string{"app.kubernetes.io/created-by": "osdctl"}
+	restore.Spec.BackupName = backup.Name
+	restore.Spec.IncludedNamespaces = includeNamespaces
+	return restore
+}
+
+// createFromManifest creates the resources of the manifest through a temporary file, oc create doesn't get a stdin,
+// args are added to the oc create arguments, e.g. to print the created resources
+func createFromManifest(run utils.OCRunner, manifest []byte, args ...string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "osdctl-manifest-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "manifest.json")
+	if err := os.WriteFile(path, manifest, 0600); err != nil {
+		return nil, err
+	}
+	return run(append([]string{"create", "-f", path}, args...)...)
+}
@@ -0,0 +1,114 @@
+package cluster
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const testVeleroBackups = `{"items": [
+  {"metadata": {"namespace": "openshift-velero", "name": "hourly-1", "creationTimestamp": "2024-06-01T10:00:00Z"},
+   "spec": {"storageLocation": "default"},
+   "status": {"phase": "Completed", "startTimestamp": "2024-06-01T10:00:05Z", "expiration": "2024-07-01T10:00:00Z",
+              "progress": {"itemsBackedUp": 42}}},
+  {"metadata": {"namespace": "openshift-velero", "name": "hourly-0", "creationTimestamp": "2024-05-01T10:00:00Z"},
+   "status": {"phase": "PartiallyFailed", "errors": 3}}
+]}`
+
+const testEtcdBackups = `{"items": [
+  {"metadata": {"name": "etcd-1", "creationTimestamp": "2024-05-15T10:00:00Z"}, "spec": {"pvcName": "etcd-backup"},
+   "status": {"conditions": [{"type": "BackupCompleted", "status": "True", "reason": "BackupCompleted"}]}}
+]}`
+
+func testBackupsRunner(t *testing.T) func(args ...string) ([]byte, error) {
+	return func(args ...string) ([]byte, error) {
+		resource := strings.Join(args, " ")
+		switch {
+		case strings.Contains(resource, "backups.velero.io -n openshift-velero"):
+			return []byte(testVeleroBackups), nil
+		case strings.Contains(resource, "backups.velero.io -n openshift-adp"):
+			return nil, errors.New(`error: the server doesn't have a resource type "backups"`)
+		case strings.Contains(resource, "etcdbackups"):
+			return []byte(testEtcdBackups), nil
+		}
+		t.Fatalf("unexpected oc %s", resource)
+		return nil, nil
+	}
+}
+
+func TestListClusterBackups(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backups, err := listClusterBackups(testBackupsRunner(t))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(backups).To(HaveLen(3))
+
+	g.Expect(backups[0].Name).To(Equal("hourly-1"))
+	g.Expect(backups[0].Created).To(Equal(time.Date(2024, 6, 1, 10, 0, 5, 0, time.UTC)))
+	g.Expect(backups[0].Items).To(Equal(42))
+	g.Expect(backups[0].Location).To(Equal("default"))
+	g.Expect(backups[1].Source).To(Equal(backupSourceEtcd))
+	g.Expect(backups[1].Phase).To(Equal("BackupCompleted"))
+	g.Expect(backups[1].Location).To(Equal("pvc/etcd-backup"))
+	g.Expect(backups[2].Errors).To(Equal(3))
+
+	g.Expect(backupsResponse{Backups: backups}.String()).To(ContainSubstring("hourly-1"))
+}
+
+func TestRestorableBackup(t *testing.T) {
+	backups, err := listClusterBackups(testBackupsRunner(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		backup   string
+		now      time.Time
+		expected string
+	}{
+		{name: "completed", backup: "hourly-1", now: now},
+		{name: "missing", backup: "hourly-2", now: now, expected: "there is no backup named hourly-2"},
+		{name: "failed", backup: "hourly-0", now: now, expected: "backup hourly-0 is PartiallyFailed"},
+		{name: "etcd", backup: "etcd-1", now: now, expected: "etcd-1 is an etcd backup"},
+		{name: "expired", backup: "hourly-1", now: now.AddDate(0, 2, 0), expected: "backup hourly-1 expired"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			backup, err := restorableBackup(backups, test.backup, test.now)
+			if test.expected == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(backup.Namespace).To(Equal("openshift-velero"))
+				return
+			}
+			g.Expect(err).To(MatchError(ContainSubstring(test.expected)))
+		})
+	}
+}
+
+func TestCheckNoRunningRestore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	restores := `{"items": [{"metadata": {"name": "old"}, "status": {"phase": "Completed"}}]}`
+	run := func(args ...string) ([]byte, error) { return []byte(restores), nil }
+	g.Expect(checkNoRunningRestore(run, "openshift-velero")).To(Succeed())
+
+	restores = `{"items": [{"metadata": {"name": "running"}, "status": {"phase": "InProgress"}}]}`
+	g.Expect(checkNoRunningRestore(run, "openshift-velero")).To(MatchError(ContainSubstring("running is InProgress")))
+}
+
+func TestVeleroRestore(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	backup := clusterBackup{Source: backupSourceVelero, Namespace: "openshift-velero", Name: "hourly-1"}
+	restore := veleroRestore(backup, []string{"app"}, time.Date(2024, 6, 2, 3, 4, 5, 0, time.UTC))
+	g.Expect(restore.Metadata.Name).To(Equal("hourly-1-20240602030405"))
+	g.Expect(restore.Metadata.Namespace).To(Equal("openshift-velero"))
+	g.Expect(restore.Spec.BackupName).To(Equal("hourly-1"))
+	g.Expect(restore.Spec.IncludedNamespaces).To(Equal([]string{"app"}))
+}
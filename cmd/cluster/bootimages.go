@@ -0,0 +1,258 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	bootImagesLongDescription = `
+Compares the boot images of the machine sets of a cluster with the ones of its release
+
+  This command will:
+
+  * Read the RHCOS boot images of the release from the coreos-bootimages config map of the machine config operator
+  * Read the AMI, or the GCP disk image, every machine set boots its machines from
+  * Report the machine sets booting another image than the release for the region and architecture of the cluster
+
+  The machine sets keep the boot image of the install, so some drift is expected on upgraded clusters: new nodes boot
+  it and pivot to the release image on their first boot. A machine set pinned to a much older image than the others,
+  e.g. by the customer, can leave its nodes unable to join the cluster.
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID').
+`
+	bootImagesExample = `
+  # Check the boot images of the machine sets of a cluster
+  osdctl cluster boot-images 1kfmyclusteristhebesteverp8m
+`
+
+	bootImagesNamespace = "openshift-machine-config-operator"
+	bootImagesConfigMap = "coreos-bootimages"
+)
+
+type bootImagesOptions struct {
+	clusterID string
+
+	runOC utils.OCRunner
+}
+
+// coreOSStream is the part of the CoreOS stream metadata of the coreos-bootimages config map the check reads
+type coreOSStream struct {
+	Architectures map[string]struct {
+		Images struct {
+			AWS struct {
+				Regions map[string]struct {
+					Image string `json:"image"`
+				} `json:"regions"`
+			} `json:"aws"`
+			GCP struct {
+				Name string `json:"name"`
+			} `json:"gcp"`
+		} `json:"images"`
+	} `json:"architectures"`
+}
+
+// machineSetBootImage is the boot image of a machine set, empty when it can't be told
+type machineSetBootImage struct {
+	name  string
+	image string
+}
+
+func newCmdBootImages() *cobra.Command {
+	ops := &bootImagesOptions{runOC: utils.RunOCAsClusterAdmin}
+	bootImagesCmd := &cobra.Command{
+		Use:               "boot-images CLUSTER_ID",
+		Short:             "Reports the machine sets booting another image than the release of the cluster",
+		Long:              bootImagesLongDescription,
+		Example:           bootImagesExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	return bootImagesCmd
+}
+
+func (o *bootImagesOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s has a hosted control plane, the boot images of its node pools are managed by HyperShift", cluster.ID())
+	}
+	provider := strings.ToLower(cluster.CloudProvider().ID())
+	if provider != "aws" && provider != "gcp" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the boot images of %s clusters can't be checked", cluster.CloudProvider().ID())
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	expected, err := o.releaseBootImages(provider, cluster.Region().ID())
+	if err != nil {
+		return err
+	}
+	sets, err := o.machineSetBootImages(provider)
+	if err != nil {
+		return err
+	}
+	return printFindings("Machine set", "boot image", checkBootImages(sets, expected, cluster.OpenshiftVersion()))
+}
+
+// releaseBootImages returns the boot image of the release for every architecture, in the region of the cluster
+func (o *bootImagesOptions) releaseBootImages(provider, region string) (map[string]string, error) {
+	output, err := o.runOC("get", "configmap", bootImagesConfigMap, "-n", bootImagesNamespace, "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the boot images of the release, the %s config map is only there from 4.10: %w", bootImagesConfigMap, err)
+	}
+	var configMap struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(output, &configMap); err != nil {
+		return nil, fmt.Errorf("cannot parse the %s config map: %w", bootImagesConfigMap, err)
+	}
+	var stream coreOSStream
+	if err := json.Unmarshal([]byte(configMap.Data["stream"]), &stream); err != nil {
+		return nil, fmt.Errorf("cannot parse the CoreOS stream of the %s config map: %w", bootImagesConfigMap, err)
+	}
+
+	expected := map[string]string{}
+	for arch, images := range stream.Architectures {
+		image := images.Images.GCP.Name
+		if provider == "aws" {
+			image = images.Images.AWS.Regions[region].Image
+		}
+		if image != "" {
+			expected[arch] = image
+		}
+	}
+	if len(expected) == 0 {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "the release has no %s boot image for region %s", provider, region)
+	}
+	return expected, nil
+}
+
+// machineSetBootImages returns the boot image of every machine set, by name
+func (o *bootImagesOptions) machineSetBootImages(provider string) ([]machineSetBootImage, error) {
+	output, err := o.runOC("get", "machinesets.machine.openshift.io", "-n", machineAPINamespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				Template struct {
+					Spec struct {
+						ProviderSpec struct {
+							Value struct {
+								AMI struct {
+									ID string `json:"id"`
+								} `json:"ami"`
+								Disks []struct {
+									Boot  bool   `json:"boot"`
+									Image string `json:"image"`
+								} `json:"disks"`
+							} `json:"value"`
+						} `json:"providerSpec"`
+					} `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("cannot parse the machine sets: %w", err)
+	}
+
+	var sets []machineSetBootImage
+	for _, item := range list.Items {
+		set := machineSetBootImage{name: item.Metadata.Name}
+		value := item.Spec.Template.Spec.ProviderSpec.Value
+		if provider == "aws" {
+			set.image = value.AMI.ID
+		} else {
+			for _, disk := range value.Disks {
+				if disk.Boot {
+					// e.g. projects/rhcos-cloud/global/images/rhcos-412-86-202303211731-0-gcp-x86-64
+					set.image = path.Base(disk.Image)
+				}
+			}
+		}
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].name < sets[j].name })
+	return sets, nil
+}
+
+// checkBootImages reports the machine sets booting another image than the release, warning rather than failing as
+// the drift of upgraded clusters is expected
+func checkBootImages(sets []machineSetBootImage, expected map[string]string, version string) []checkFinding {
+	if len(sets) == 0 {
+		return []checkFinding{{check: "machine sets", status: dnsCheckOK, message: "the cluster has no machine set"}}
+	}
+
+	releaseImages := map[string]string{}
+	for arch, image := range expected {
+		releaseImages[image] = arch
+	}
+	var archs []string
+	for arch := range expected {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	var want []string
+	for _, arch := range archs {
+		want = append(want, fmt.Sprintf("%s (%s)", expected[arch], arch))
+	}
+
+	// byImage counts the machine sets booting every image, an image booted by fewer sets than the others hints at pinning
+	byImage := map[string]int{}
+	for _, set := range sets {
+		byImage[set.image]++
+	}
+
+	var findings []checkFinding
+	for _, set := range sets {
+		finding := checkFinding{check: set.name}
+		switch {
+		case set.image == "":
+			finding.status = dnsCheckWarn
+			finding.message = "the boot image isn't set by ID, e.g. the AMI is selected by filters"
+			finding.hint = "Check which image the filters of the provider spec select"
+		case releaseImages[set.image] != "":
+			finding.status = dnsCheckOK
+			finding.message = fmt.Sprintf("boots %s, the %s image of the release %s", set.image, releaseImages[set.image], version)
+		default:
+			finding.status = dnsCheckWarn
+			finding.message = fmt.Sprintf("boots %s, the release %s boots %s", set.image, version, strings.Join(want, ", "))
+			if byImage[set.image] < len(sets)-byImage[set.image] {
+				finding.message += fmt.Sprintf(", %d other machine sets boot another image", len(sets)-byImage[set.image])
+			}
+			finding.hint = "The nodes pivot to the release image on their first boot, if they fail to join check the " +
+				"ignition errors on their console and whether the customer pinned the image of the machine set"
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
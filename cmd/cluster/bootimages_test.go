@@ -0,0 +1,88 @@
+package cluster
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const testBootImagesConfigMap = `{"data": {"stream": "{\"architectures\": {\"x86_64\": {\"images\": {\"aws\": {\"regions\": {\"us-east-1\": {\"image\": \"ami-0new\"}}}, \"gcp\": {\"project\": \"rhcos-cloud\", \"name\": \"rhcos-412-86-202303211731-0-gcp-x86-64\"}}}, \"aarch64\": {\"images\": {\"aws\": {\"regions\": {\"us-east-1\": {\"image\": \"ami-0arm\"}}}}}}}"}}`
+
+const testAWSMachineSets = `{"items": [
+  {"metadata": {"name": "mycluster-worker-us-east-1a"}, "spec": {"template": {"spec": {"providerSpec": {"value": {"ami": {"id": "ami-0old"}}}}}}},
+  {"metadata": {"name": "mycluster-infra-us-east-1a"}, "spec": {"template": {"spec": {"providerSpec": {"value": {"ami": {"id": "ami-0new"}}}}}}},
+  {"metadata": {"name": "mycluster-worker-us-east-1b"}, "spec": {"template": {"spec": {"providerSpec": {"value": {"ami": {"id": "ami-0new"}}}}}}},
+  {"metadata": {"name": "mycluster-arm-us-east-1a"}, "spec": {"template": {"spec": {"providerSpec": {"value": {"ami": {"id": "ami-0arm"}}}}}}},
+  {"metadata": {"name": "mycluster-custom-us-east-1a"}, "spec": {"template": {"spec": {"providerSpec": {"value": {"ami": {"filters": [{"name": "tag:Name"}]}}}}}}}
+]}`
+
+const testGCPMachineSets = `{"items": [
+  {"metadata": {"name": "mycluster-worker-a"}, "spec": {"template": {"spec": {"providerSpec": {"value": {"disks": [
+    {"boot": true, "image": "projects/rhcos-cloud/global/images/rhcos-412-86-202303211731-0-gcp-x86-64"}]}}}}}}
+]}`
+
+func newTestBootImages(machineSets string) *bootImagesOptions {
+	return &bootImagesOptions{
+		runOC: func(args ...string) ([]byte, error) {
+			switch strings.Join(args, " ") {
+			case "get configmap coreos-bootimages -n openshift-machine-config-operator -o json":
+				return []byte(testBootImagesConfigMap), nil
+			case "get machinesets.machine.openshift.io -n openshift-machine-api -o json":
+				return []byte(machineSets), nil
+			}
+			return nil, errors.New("oc get failed: NotFound")
+		},
+	}
+}
+
+func TestReleaseBootImages(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := newTestBootImages(testAWSMachineSets)
+
+	expected, err := o.releaseBootImages("aws", "us-east-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expected).To(Equal(map[string]string{"x86_64": "ami-0new", "aarch64": "ami-0arm"}))
+
+	expected, err = o.releaseBootImages("gcp", "us-east1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expected).To(Equal(map[string]string{"x86_64": "rhcos-412-86-202303211731-0-gcp-x86-64"}))
+
+	_, err = o.releaseBootImages("aws", "ap-south-2")
+	g.Expect(err).To(MatchError("the release has no aws boot image for region ap-south-2"))
+}
+
+func TestCheckBootImages(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := newTestBootImages(testAWSMachineSets)
+
+	expected, err := o.releaseBootImages("aws", "us-east-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	sets, err := o.machineSetBootImages("aws")
+	g.Expect(err).NotTo(HaveOccurred())
+	findings := checkBootImages(sets, expected, "4.12.8")
+	g.Expect(findings).To(HaveLen(5))
+
+	byName := map[string]checkFinding{}
+	for _, f := range findings {
+		byName[f.check] = f
+	}
+	g.Expect(byName["mycluster-worker-us-east-1b"].status).To(Equal(dnsCheckOK))
+	g.Expect(byName["mycluster-arm-us-east-1a"].message).To(Equal("boots ami-0arm, the aarch64 image of the release 4.12.8"))
+	g.Expect(byName["mycluster-custom-us-east-1a"].status).To(Equal(dnsCheckWarn))
+
+	drift := byName["mycluster-worker-us-east-1a"]
+	g.Expect(drift.status).To(Equal(dnsCheckWarn))
+	g.Expect(drift.message).To(Equal("boots ami-0old, the release 4.12.8 boots ami-0arm (aarch64), ami-0new (x86_64), 4 other machine sets boot another image"))
+	g.Expect(drift.hint).NotTo(BeEmpty())
+}
+
+func TestMachineSetBootImagesGCP(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := newTestBootImages(testGCPMachineSets)
+
+	sets, err := o.machineSetBootImages("gcp")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sets).To(Equal([]machineSetBootImage{{name: "mycluster-worker-a", image: "rhcos-412-86-202303211731-0-gcp-x86-64"}}))
+}
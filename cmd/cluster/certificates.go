@@ -0,0 +1,334 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/scheduler"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	certificatesLong = `Lists when the certificates of a cluster expire, and highlights the ones expiring within --within.
+
+The API server (api.<domain>:6443) and ingress (*.apps.<domain>:443) certificates are read with a TLS handshake,
+which needs network access to the cluster: the endpoints of private clusters are reported as unreachable. With
+--internal, the certificates and CAs the cluster rotates itself are also read from the annotations of their
+secrets, through the cluster the current kubeconfig is logged in to with 'ocm backplane login'.
+
+With --all, the API server and ingress certificates of every cluster matching --search are checked, and only the
+certificates expiring within --within and the endpoints which couldn't be checked are listed.`
+
+	certificatesExample = `
+  # The certificates of a cluster, including the internal ones, once logged in with backplane
+  osdctl cluster certificates 1kfmyclusteristhebesteverp8m --internal
+
+  # The certificates expiring within 2 weeks in the fleet
+  osdctl cluster certificates --all --within 336h
+`
+
+	certificateKindAPI      = "api"
+	certificateKindIngress  = "ingress"
+	certificateKindInternal = "internal"
+
+	// notAfterAnnotation is set by the cluster operators on the secrets of the certificates they rotate, along with
+	// auth.openshift.io/certificate-issuer
+	notAfterAnnotation = "auth.openshift.io/certificate-not-after"
+
+	certificateStatusOK       = "ok"
+	certificateStatusExpiring = "EXPIRING"
+	certificateStatusExpired  = "EXPIRED"
+	certificateStatusError    = "error"
+)
+
+// certificateProber returns the leaf certificate served on the address
+type certificateProber func(address string, timeout time.Duration) (*x509.Certificate, error)
+
+type certificatesOptions struct {
+	clusterID string
+	all       bool
+	search    string
+	within    time.Duration
+	internal  bool
+	parallel  int
+	timeout   time.Duration
+
+	probe         certificateProber
+	runOC         utils.OCRunner
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+type certificateExpiry struct {
+	ClusterID   string    `json:"cluster_id" yaml:"cluster_id"`
+	ClusterName string    `json:"cluster_name" yaml:"cluster_name"`
+	Kind        string    `json:"kind" yaml:"kind"`
+	Name        string    `json:"name" yaml:"name"`
+	Subject     string    `json:"subject,omitempty" yaml:"subject,omitempty"`
+	Issuer      string    `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	NotAfter    time.Time `json:"not_after,omitempty" yaml:"not_after,omitempty"`
+	DaysLeft    int       `json:"days_left" yaml:"days_left"`
+	Status      string    `json:"status" yaml:"status"`
+	Error       string    `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type certificatesResponse struct {
+	Within       string              `json:"within" yaml:"within"`
+	Clusters     int                 `json:"clusters" yaml:"clusters"`
+	Certificates []certificateExpiry `json:"certificates" yaml:"certificates"`
+}
+
+func (r certificatesResponse) String() string {
+	var b bytes.Buffer
+	if len(r.Certificates) == 0 {
+		fmt.Fprintf(&b, "No certificate of the %d cluster(s) expires within %s\n", r.Clusters, r.Within)
+		return b.String()
+	}
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"CLUSTER", "KIND", "NAME", "ISSUER", "NOT AFTER", "DAYS LEFT", "STATUS"})
+	for _, c := range r.Certificates {
+		if c.Status == certificateStatusError {
+			table.AddRow([]string{c.ClusterName, c.Kind, c.Name, "", "", "", "error: " + c.Error})
+			continue
+		}
+		table.AddRow([]string{c.ClusterName, c.Kind, c.Name, c.Issuer, timefmt.Format(c.NotAfter), strconv.Itoa(c.DaysLeft), c.Status})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the certificates: %v", err)
+	}
+	return b.String()
+}
+
+func newCmdCertificates(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &certificatesOptions{GlobalOptions: globalOpts, probe: probeCertificate, runOC: utils.RunOCAsClusterAdmin}
+	certificatesCmd := &cobra.Command{
+		Use:               "certificates [CLUSTER_ID]",
+		Short:             "List when the API server, ingress and internal certificates of a cluster, or of the fleet, expire",
+		Long:              certificatesLong,
+		Example:           certificatesExample,
+		Args:              cobra.MaximumNArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	certificatesCmd.Flags().BoolVar(&ops.all, "all", false, "Check every cluster matching --search instead of a single cluster")
+	certificatesCmd.Flags().StringVar(&ops.search, "search", "state='ready'", "OCM search query of the clusters checked with --all")
+	certificatesCmd.Flags().DurationVar(&ops.within, "within", 30*24*time.Hour, "Highlight the certificates expiring within this duration")
+	certificatesCmd.Flags().BoolVar(&ops.internal, "internal", false, "Also read the internal certificates and CAs through the current backplane login")
+	certificatesCmd.Flags().IntVar(&ops.parallel, "parallel", 10, "How many clusters to check at once with --all")
+	certificatesCmd.Flags().DurationVar(&ops.timeout, "timeout", 10*time.Second, "Timeout of every TLS handshake")
+
+	return certificatesCmd
+}
+
+func (o *certificatesOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.all == (len(args) == 1) {
+		return cmdutil.UsageErrorf(cmd, "Provide either a cluster ID or --all")
+	}
+	if o.all && o.internal {
+		return cmdutil.UsageErrorf(cmd, "--internal needs a backplane login to the cluster, it can't be used with --all")
+	}
+	if o.within <= 0 || o.timeout <= 0 {
+		return cmdutil.UsageErrorf(cmd, "--within and --timeout must be positive")
+	}
+	if o.parallel < 1 {
+		return cmdutil.UsageErrorf(cmd, "--parallel must be at least 1")
+	}
+	if len(args) == 1 {
+		if err := utils.IsValidClusterKey(args[0]); err != nil {
+			return err
+		}
+		o.clusterID = args[0]
+	}
+	return nil
+}
+
+func (o *certificatesOptions) run() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	var clusters []*cmv1.Cluster
+	if o.all {
+		var err error
+		clusters, err = searchClusters(connection, o.search, 0)
+		if err != nil {
+			return err
+		}
+	} else {
+		cluster, err := utils.GetCluster(connection, o.clusterID)
+		if err != nil {
+			return err
+		}
+		clusters = []*cmv1.Cluster{cluster}
+	}
+
+	now := time.Now()
+	certificates := o.probeClusters(clusters, now)
+	if o.internal {
+		internal, err := internalCertificates(o.runOC, clusters[0], now, o.within)
+		if err != nil {
+			return err
+		}
+		certificates = append(certificates, internal...)
+	}
+	if o.all {
+		certificates = needingAttention(certificates)
+	}
+	sortCertificates(certificates)
+
+	return outputflag.PrintResponse(o.GlobalOptions.Output, certificatesResponse{
+		Within:       o.within.String(),
+		Clusters:     len(clusters),
+		Certificates: certificates,
+	})
+}
+
+// probeClusters reads the API server and ingress certificates of the clusters, --parallel clusters at once
+func (o *certificatesOptions) probeClusters(clusters []*cmv1.Cluster, now time.Time) []certificateExpiry {
+	results := make([][]certificateExpiry, len(clusters))
+	tasks := make([]scheduler.Task, len(clusters))
+	for i, cluster := range clusters {
+		i, cluster := i, cluster
+		tasks[i] = scheduler.Task{Cluster: cluster.ID(), Kind: scheduler.Read, Run: func(context.Context) error {
+			clusterDomain := utils.ClusterDomain(cluster)
+			endpoints := []struct{ kind, address string }{
+				{certificateKindAPI, net.JoinHostPort("api."+clusterDomain, "6443")},
+				{certificateKindIngress, net.JoinHostPort(fmt.Sprintf("%s.apps.%s", wildcardProbeLabel, clusterDomain), "443")},
+			}
+			for _, endpoint := range endpoints {
+				entry := certificateExpiry{ClusterID: cluster.ID(), ClusterName: cluster.Name(), Kind: endpoint.kind, Name: endpoint.address}
+				certificate, err := o.probe(endpoint.address, o.timeout)
+				if err != nil {
+					entry.Status = certificateStatusError
+					entry.Error = err.Error()
+				} else {
+					entry.Subject = certificate.Subject.CommonName
+					entry.Issuer = certificate.Issuer.CommonName
+					entry.setExpiry(certificate.NotAfter, now, o.within)
+				}
+				results[i] = append(results[i], entry)
+			}
+			return nil
+		}}
+	}
+	probes := scheduler.New()
+	probes.Reads = o.parallel
+	probes.Run(context.Background(), tasks)
+
+	certificates := []certificateExpiry{}
+	for _, result := range results {
+		certificates = append(certificates, result...)
+	}
+	return certificates
+}
+
+func (c *certificateExpiry) setExpiry(notAfter, now time.Time, within time.Duration) {
+	c.NotAfter = notAfter.UTC()
+	c.DaysLeft = int(notAfter.Sub(now).Hours() / 24)
+	switch {
+	case !now.Before(notAfter):
+		c.Status = certificateStatusExpired
+	case notAfter.Sub(now) < within:
+		c.Status = certificateStatusExpiring
+	default:
+		c.Status = certificateStatusOK
+	}
+}
+
+// probeCertificate returns the leaf certificate served on the address, without verifying it so that expired and
+// untrusted certificates are reported too
+func probeCertificate(address string, timeout time.Duration) (*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{InsecureSkipVerify: true}) //#nosec G402 -- only the expiry of the certificate is read
+	if err != nil {
+		return nil, fmt.Errorf("cannot establish a TLS connection: %v", err)
+	}
+	defer conn.Close()
+	chain := conn.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificate was served")
+	}
+	return chain[0], nil
+}
+
+// internalCertificates reads the expiry of the certificates the cluster operators rotate from the annotations of
+// their secrets, which doesn't read the certificates and keys themselves
+func internalCertificates(runOC utils.OCRunner, cluster *cmv1.Cluster, now time.Time, within time.Duration) ([]certificateExpiry, error) {
+	if err := utils.CheckOCCluster(runOC, cluster); err != nil {
+		return nil, err
+	}
+	output, err := runOC("get", "secrets", "--all-namespaces", "-o",
+		`jsonpath={range .items[*]}{.metadata.namespace}{"\t"}{.metadata.name}{"\t"}{.metadata.annotations.auth\.openshift\.io/certificate-not-after}{"\t"}{.metadata.annotations.auth\.openshift\.io/certificate-issuer}{"\n"}{end}`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the secrets of cluster %s: %w", cluster.ID(), err)
+	}
+	return parseInternalCertificates(string(output), cluster, now, within), nil
+}
+
+// parseInternalCertificates parses the 'namespace name not-after issuer' lines, skipping the secrets without expiry
+func parseInternalCertificates(output string, cluster *cmv1.Cluster, now time.Time, within time.Duration) []certificateExpiry {
+	var certificates []certificateExpiry
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 || fields[2] == "" {
+			continue
+		}
+		entry := certificateExpiry{ClusterID: cluster.ID(), ClusterName: cluster.Name(), Kind: certificateKindInternal, Name: fields[0] + "/" + fields[1]}
+		if len(fields) > 3 {
+			entry.Issuer = fields[3]
+		}
+		notAfter, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			entry.Status = certificateStatusError
+			entry.Error = fmt.Sprintf("invalid %s annotation '%s'", notAfterAnnotation, fields[2])
+		} else {
+			entry.setExpiry(notAfter, now, within)
+		}
+		certificates = append(certificates, entry)
+	}
+	return certificates
+}
+
+// needingAttention keeps the certificates expiring within the window and the endpoints which couldn't be checked
+func needingAttention(certificates []certificateExpiry) []certificateExpiry {
+	kept := []certificateExpiry{}
+	for _, certificate := range certificates {
+		if certificate.Status != certificateStatusOK {
+			kept = append(kept, certificate)
+		}
+	}
+	return kept
+}
+
+// sortCertificates sorts the certificates by expiry, soonest first, and the ones which couldn't be checked last
+func sortCertificates(certificates []certificateExpiry) {
+	sort.SliceStable(certificates, func(i, j int) bool {
+		iError, jError := certificates[i].Status == certificateStatusError, certificates[j].Status == certificateStatusError
+		if iError != jError {
+			return jError
+		}
+		if !certificates[i].NotAfter.Equal(certificates[j].NotAfter) {
+			return certificates[i].NotAfter.Before(certificates[j].NotAfter)
+		}
+		return certificates[i].ClusterName+certificates[i].Name < certificates[j].ClusterName+certificates[j].Name
+	})
+}
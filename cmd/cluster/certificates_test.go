@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func TestProbeClusters(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	cluster, err := cmv1.NewCluster().ID("abc").Name("mycluster").DNS(cmv1.NewDNS().BaseDomain("x1y2.p1.openshiftapps.com")).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ops := &certificatesOptions{parallel: 2, within: 30 * 24 * time.Hour, probe: func(address string, _ time.Duration) (*x509.Certificate, error) {
+		if strings.HasPrefix(address, "api.") {
+			return &x509.Certificate{Subject: pkix.Name{CommonName: "api.mycluster"}, Issuer: pkix.Name{CommonName: "R3"}, NotAfter: now.Add(10 * 24 * time.Hour)}, nil
+		}
+		return nil, errors.New("connection refused")
+	}}
+	certificates := ops.probeClusters([]*cmv1.Cluster{cluster}, now)
+	g.Expect(certificates).To(Equal([]certificateExpiry{
+		{ClusterID: "abc", ClusterName: "mycluster", Kind: certificateKindAPI, Name: "api.mycluster.x1y2.p1.openshiftapps.com:6443",
+			Subject: "api.mycluster", Issuer: "R3", NotAfter: now.Add(10 * 24 * time.Hour), DaysLeft: 10, Status: certificateStatusExpiring},
+		{ClusterID: "abc", ClusterName: "mycluster", Kind: certificateKindIngress, Name: wildcardProbeLabel + ".apps.mycluster.x1y2.p1.openshiftapps.com:443",
+			Status: certificateStatusError, Error: "connection refused"},
+	}))
+}
+
+func TestParseInternalCertificates(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cluster, err := cmv1.NewCluster().ID("abc").Name("mycluster").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	output := "openshift-config\tpull-secret\t\t\n" +
+		"openshift-kube-apiserver-operator\tloadbalancer-serving-signer\t2034-05-30T00:00:00Z\topenshift-kube-apiserver-operator_loadbalancer-serving-signer@1717200000\n" +
+		"openshift-kube-apiserver\taggregator-client\t2024-06-02T12:00:00Z\topenshift-kube-apiserver-operator_aggregator-client-signer@1717200000\n" +
+		"openshift-etcd\tetcd-signer\tnot-a-date\t\n"
+	certificates := parseInternalCertificates(output, cluster, now, 30*24*time.Hour)
+	g.Expect(certificates).To(HaveLen(3))
+	g.Expect(certificates[0].Name).To(Equal("openshift-kube-apiserver-operator/loadbalancer-serving-signer"))
+	g.Expect(certificates[0].Status).To(Equal(certificateStatusOK))
+	g.Expect(certificates[1].Status).To(Equal(certificateStatusExpiring))
+	g.Expect(certificates[1].DaysLeft).To(Equal(1))
+	g.Expect(certificates[2].Status).To(Equal(certificateStatusError))
+
+	sortCertificates(certificates)
+	g.Expect(certificates[0].Name).To(Equal("openshift-kube-apiserver/aggregator-client"))
+	g.Expect(certificates[2].Status).To(Equal(certificateStatusError))
+	g.Expect(needingAttention(certificates)).To(HaveLen(2))
+}
+
+func TestCertificateExpiryStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	expired := certificateExpiry{}
+	expired.setExpiry(now.Add(-time.Hour), now, time.Hour)
+	g.Expect(expired.Status).To(Equal(certificateStatusExpired))
+
+	valid := certificateExpiry{}
+	valid.setExpiry(now.Add(2*time.Hour), now, time.Hour)
+	g.Expect(valid.Status).To(Equal(certificateStatusOK))
+}
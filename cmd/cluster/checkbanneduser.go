@@ -3,34 +3,35 @@ package cluster
 import (
 	"fmt"
 	"github.com/openshift/osdctl/cmd/servicelog"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 const BanCodeExportControlCompliance = "export_control_compliance"
 
-func newCmdCheckBannedUser() *cobra.Command {
+func newCmdCheckBannedUser(streams genericclioptions.IOStreams) *cobra.Command {
 	return &cobra.Command{
 		Use:               "check-banned-user [CLUSTER_ID]",
 		Short:             "Checks if the cluster owner is a banned user.",
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(CheckBannedUser(args[0]))
+			osdctlErrors.CheckErr(CheckBannedUser(args[0], streams))
 		},
 	}
 }
 
-func CheckBannedUser(clusterID string) error {
+func CheckBannedUser(clusterID string, streams genericclioptions.IOStreams) error {
 	ocm := utils.CreateConnection()
 	defer func() {
 		if ocmCloseErr := ocm.Close(); ocmCloseErr != nil {
-			fmt.Printf("Cannot close the ocm (possible memory leak): %q", ocmCloseErr)
+			fmt.Fprintf(streams.ErrOut, "Cannot close the ocm (possible memory leak): %q\n", ocmCloseErr)
 		}
 	}()
 
-	fmt.Print("Finding subscription account: ")
+	fmt.Fprintln(streams.ErrOut, "Finding subscription account")
 	subscription, err := utils.GetSubscription(ocm, clusterID)
 	if err != nil {
 		return err
@@ -40,9 +41,9 @@ func CheckBannedUser(clusterID string) error {
 		return fmt.Errorf("Expecting status 'Active' found %v\n", status)
 	}
 
-	fmt.Printf("Account %v - %v - %v\n", subscription.SupportLevel(), subscription.Creator().HREF(), subscription.Status())
+	fmt.Fprintf(streams.Out, "Account %v - %v - %v\n", subscription.SupportLevel(), subscription.Creator().HREF(), subscription.Status())
 
-	fmt.Print("Finding account owner: ")
+	fmt.Fprintln(streams.ErrOut, "Finding account owner")
 	creator, err := utils.GetAccount(ocm, subscription.Creator().ID())
 	if err != nil {
 		return err
@@ -54,18 +55,18 @@ func CheckBannedUser(clusterID string) error {
 	userBanDescription := creator.BanDescription()
 	lastUpdate := creator.UpdatedAt()
 
-	fmt.Printf("%v\n-------------------\nLast Update : %v\n", userEmail, lastUpdate)
+	fmt.Fprintf(streams.Out, "%v\n-------------------\nLast Update : %v\n", userEmail, lastUpdate)
 
 	if userBanned {
-		fmt.Println("User is banned")
-		fmt.Printf("Ban code = %v\n", userBanCode)
-		fmt.Printf("Ban description = %v\n", userBanDescription)
+		fmt.Fprintln(streams.Out, "User is banned")
+		fmt.Fprintf(streams.Out, "Ban code = %v\n", userBanCode)
+		fmt.Fprintf(streams.Out, "Ban description = %v\n", userBanDescription)
 		if userBanCode == BanCodeExportControlCompliance {
-			fmt.Println("User banned due to export control compliance.\nPlease follow the steps detailed here: https://github.com/openshift/ops-sop/blob/master/v4/alerts/UpgradeConfigSyncFailureOver4HrSRE.md#user-banneddisabled-due-to-export-control-compliance .")
+			fmt.Fprintln(streams.Out, "User banned due to export control compliance.\nPlease follow the steps detailed here: https://github.com/openshift/ops-sop/blob/master/v4/alerts/UpgradeConfigSyncFailureOver4HrSRE.md#user-banneddisabled-due-to-export-control-compliance .")
 			return nil
 		}
 
-		fmt.Println("Sending service log.")
+		fmt.Fprintln(streams.ErrOut, "Sending service log.")
 		postCmd := servicelog.PostCmdOptions{
 			Template:  "https://raw.githubusercontent.com/openshift/managed-notifications/master/ocm/cluster_owner_disabled.json",
 			ClusterId: clusterID,
@@ -76,6 +77,6 @@ func CheckBannedUser(clusterID string) error {
 
 		return nil
 	}
-	fmt.Println("User allowed")
+	fmt.Fprintln(streams.Out, "User allowed")
 	return nil
 }
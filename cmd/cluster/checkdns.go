@@ -0,0 +1,328 @@
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	checkDNSLongDescription = `
+Runs DNS and certificate diagnostics for a cluster
+
+  This command will:
+
+  * Resolve the API and *.apps domains using the system resolver and public resolvers, and flag missing or inconsistent answers
+  * Validate the API and default ingress certificates, including hostname and expiry
+  * For AWS clusters, check that the Route53 hosted zone contains the expected API and *.apps records
+`
+	checkDNSExample = `
+  # Check DNS and certificates for a cluster
+  osdctl cluster check-dns 1kfmyclusteristhebesteverp8m
+
+  # Also check Route53 records using an AWS profile named "rhcontrol"
+  osdctl cluster check-dns 1kfmyclusteristhebesteverp8m --profile rhcontrol
+`
+
+	dnsCheckOK   = "OK"
+	dnsCheckWarn = "WARN"
+	dnsCheckFail = "FAIL"
+
+	// Certificates expiring sooner than this are flagged
+	certExpiryWarningThreshold = 30 * 24 * time.Hour
+	dnsCheckTimeout            = 5 * time.Second
+	// Any name will do to test the *.apps wildcard, as long as no route uses it
+	wildcardProbeLabel = "osdctl-dns-check"
+)
+
+// publicResolvers are queried in addition to the system resolver to catch split-horizon
+// or stale delegation issues on the customer side
+var publicResolvers = []string{"8.8.8.8:53", "1.1.1.1:53"}
+
+type checkDNSOptions struct {
+	clusterID  string
+	awsProfile string
+	skipAWS    bool
+}
+
+type dnsFinding struct {
+	check   string
+	status  string
+	message string
+}
+
+func newCmdCheckDNS() *cobra.Command {
+	ops := &checkDNSOptions{}
+	checkDNSCmd := &cobra.Command{
+		Use:               "check-dns CLUSTER_ID",
+		Short:             "Runs DNS and certificate diagnostics for a cluster",
+		Long:              checkDNSLongDescription,
+		Example:           checkDNSExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	checkDNSCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile name")
+	checkDNSCmd.Flags().BoolVar(&ops.skipAWS, "skip-aws", false, "Skip the Route53 hosted zone checks")
+
+	return checkDNSCmd
+}
+
+func (o *checkDNSOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetClusterAnyStatus(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	clusterDomain := utils.ClusterDomain(cluster)
+	apiHost := "api." + clusterDomain
+	appsHost := fmt.Sprintf("%s.apps.%s", wildcardProbeLabel, clusterDomain)
+
+	var findings []dnsFinding
+	findings = append(findings, checkResolution(apiHost)...)
+	findings = append(findings, checkResolution(appsHost)...)
+	findings = append(findings, checkCertificate(apiHost, "6443"))
+	findings = append(findings, checkCertificate(appsHost, "443"))
+
+	if cluster.CloudProvider().ID() == "aws" && !o.skipAWS {
+		awsClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+		if err != nil {
+			findings = append(findings, dnsFinding{"route53", dnsCheckWarn, fmt.Sprintf("could not get AWS credentials for the cluster, skipping: %v", err)})
+		} else {
+			findings = append(findings, checkHostedZone(awsClient, clusterDomain)...)
+		}
+	}
+
+	return printDNSFindings(findings)
+}
+
+// checkResolution resolves the host with the system resolver and each public resolver
+func checkResolution(host string) []dnsFinding {
+	answers := map[string][]string{}
+
+	resolvers := map[string]*net.Resolver{"system": net.DefaultResolver}
+	for _, address := range publicResolvers {
+		address := address
+		resolvers[address] = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: dnsCheckTimeout}
+				return d.DialContext(ctx, network, address)
+			},
+		}
+	}
+
+	for name, resolver := range resolvers {
+		ctx, cancel := context.WithTimeout(context.Background(), dnsCheckTimeout)
+		addrs, err := resolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			answers[name] = nil
+			continue
+		}
+		answers[name] = addrs
+	}
+
+	return evaluateResolution(host, answers)
+}
+
+// evaluateResolution compares the answers of every resolver for the given host
+func evaluateResolution(host string, answers map[string][]string) []dnsFinding {
+	check := "resolve " + host
+	var failed []string
+	distinct := map[string]bool{}
+
+	for name, addrs := range answers {
+		if len(addrs) == 0 {
+			failed = append(failed, name)
+			continue
+		}
+		sorted := append([]string{}, addrs...)
+		sort.Strings(sorted)
+		distinct[strings.Join(sorted, ",")] = true
+	}
+	sort.Strings(failed)
+
+	if len(failed) == len(answers) {
+		return []dnsFinding{{check, dnsCheckFail, "does not resolve on any resolver, check the hosted zone and its delegation"}}
+	}
+
+	var findings []dnsFinding
+	if len(failed) > 0 {
+		findings = append(findings, dnsFinding{check, dnsCheckWarn, fmt.Sprintf("does not resolve using %s, possible split-horizon DNS or custom resolver issue", strings.Join(failed, ", "))})
+	}
+	if len(distinct) > 1 {
+		findings = append(findings, dnsFinding{check, dnsCheckWarn, "resolvers returned different answers, records may be stale or overridden by the customer"})
+	}
+	if len(findings) == 0 {
+		for answer := range distinct {
+			findings = append(findings, dnsFinding{check, dnsCheckOK, answer})
+		}
+	}
+	return findings
+}
+
+// checkCertificate connects to the host and validates the served certificate chain
+func checkCertificate(host, port string) dnsFinding {
+	check := fmt.Sprintf("certificate %s:%s", host, port)
+
+	dialer := &net.Dialer{Timeout: dnsCheckTimeout}
+	// Verification is done below, so that an invalid chain can be reported rather than just failing the handshake
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, port), &tls.Config{InsecureSkipVerify: true}) //#nosec G402 -- certificates are verified manually
+	if err != nil {
+		return dnsFinding{check, dnsCheckFail, fmt.Sprintf("cannot establish a TLS connection: %v", err)}
+	}
+	defer conn.Close()
+
+	return evaluateCertificate(check, host, conn.ConnectionState().PeerCertificates, time.Now())
+}
+
+func evaluateCertificate(check, host string, chain []*x509.Certificate, now time.Time) dnsFinding {
+	if len(chain) == 0 {
+		return dnsFinding{check, dnsCheckFail, "no certificate was served"}
+	}
+	leaf := chain[0]
+
+	if now.After(leaf.NotAfter) {
+		return dnsFinding{check, dnsCheckFail, fmt.Sprintf("certificate expired on %s", timefmt.Format(leaf.NotAfter))}
+	}
+	if err := leaf.VerifyHostname(host); err != nil {
+		return dnsFinding{check, dnsCheckFail, fmt.Sprintf("certificate is not valid for this host: %v", err)}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Intermediates: intermediates, CurrentTime: now}); err != nil {
+		return dnsFinding{check, dnsCheckWarn, fmt.Sprintf("certificate is not trusted by the system roots (issuer %q): %v", leaf.Issuer.CommonName, err)}
+	}
+
+	if leaf.NotAfter.Sub(now) < certExpiryWarningThreshold {
+		return dnsFinding{check, dnsCheckWarn, fmt.Sprintf("certificate expires soon, on %s", timefmt.Format(leaf.NotAfter))}
+	}
+
+	return dnsFinding{check, dnsCheckOK, fmt.Sprintf("valid until %s", timefmt.Format(leaf.NotAfter))}
+}
+
+// checkHostedZone verifies that the cluster's public hosted zone exists and contains the expected records
+func checkHostedZone(awsClient aws.Client, clusterDomain string) []dnsFinding {
+	zoneName := clusterDomain + "."
+	zones, err := awsClient.ListHostedZonesByName(&route53.ListHostedZonesByNameInput{DNSName: awsSdk.String(zoneName)})
+	if err != nil {
+		return []dnsFinding{{"route53", dnsCheckWarn, fmt.Sprintf("cannot list hosted zones: %v", err)}}
+	}
+
+	var findings []dnsFinding
+	found := false
+	for _, zone := range zones.HostedZones {
+		if *zone.Name != zoneName {
+			continue
+		}
+		found = true
+
+		records, err := listRecordNames(awsClient, *zone.Id)
+		if err != nil {
+			findings = append(findings, dnsFinding{"route53 " + *zone.Id, dnsCheckWarn, fmt.Sprintf("cannot list records: %v", err)})
+			continue
+		}
+
+		zoneType := "public"
+		if zone.Config != nil && zone.Config.PrivateZone != nil && *zone.Config.PrivateZone {
+			zoneType = "private"
+		}
+		findings = append(findings, evaluateHostedZoneRecords(fmt.Sprintf("route53 %s zone %s", zoneType, *zone.Id), clusterDomain, records)...)
+	}
+
+	if !found {
+		findings = append(findings, dnsFinding{"route53", dnsCheckFail, fmt.Sprintf("no hosted zone named %s found in the cluster's account", zoneName)})
+	}
+	return findings
+}
+
+func listRecordNames(awsClient aws.Client, zoneID string) (map[string]bool, error) {
+	names := map[string]bool{}
+	input := &route53.ListResourceRecordSetsInput{HostedZoneId: awsSdk.String(zoneID)}
+	for {
+		output, err := awsClient.ListResourceRecordSets(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range output.ResourceRecordSets {
+			names[*record.Name] = true
+		}
+		if output.IsTruncated == nil || !*output.IsTruncated {
+			return names, nil
+		}
+		input.StartRecordName = output.NextRecordName
+		input.StartRecordType = output.NextRecordType
+	}
+}
+
+func evaluateHostedZoneRecords(check, clusterDomain string, records map[string]bool) []dnsFinding {
+	// Route53 returns the wildcard character escaped as \052
+	expected := []string{
+		fmt.Sprintf("api.%s.", clusterDomain),
+		fmt.Sprintf("\\052.apps.%s.", clusterDomain),
+	}
+
+	var findings []dnsFinding
+	for _, name := range expected {
+		if !records[name] {
+			findings = append(findings, dnsFinding{check, dnsCheckFail, fmt.Sprintf("record %s is missing", strings.Replace(name, "\\052", "*", 1))})
+		}
+	}
+	if len(findings) == 0 {
+		findings = append(findings, dnsFinding{check, dnsCheckOK, "API and *.apps records are present"})
+	}
+	return findings
+}
+
+func printDNSFindings(findings []dnsFinding) error {
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Check", "Status", "Details"})
+	failed := 0
+	for _, f := range findings {
+		table.AddRow([]string{f.check, f.status, f.message})
+		if f.status == dnsCheckFail {
+			failed++
+		}
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d DNS/certificate checks failed", failed)
+	}
+	return nil
+}
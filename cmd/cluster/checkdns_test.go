@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestEvaluateResolution(t *testing.T) {
+	g := NewGomegaWithT(t)
+	testCases := []struct {
+		title          string
+		answers        map[string][]string
+		expectedStatus []string
+	}{
+		{
+			title:          "all resolvers agree",
+			answers:        map[string][]string{"system": {"1.2.3.4"}, "8.8.8.8:53": {"1.2.3.4"}},
+			expectedStatus: []string{dnsCheckOK},
+		},
+		{
+			title:          "no resolver answers",
+			answers:        map[string][]string{"system": nil, "8.8.8.8:53": nil},
+			expectedStatus: []string{dnsCheckFail},
+		},
+		{
+			title:          "only the system resolver answers",
+			answers:        map[string][]string{"system": {"10.0.0.1"}, "8.8.8.8:53": nil},
+			expectedStatus: []string{dnsCheckWarn},
+		},
+		{
+			title:          "resolvers disagree",
+			answers:        map[string][]string{"system": {"10.0.0.1"}, "8.8.8.8:53": {"1.2.3.4"}},
+			expectedStatus: []string{dnsCheckWarn},
+		},
+		{
+			title:          "order of the answers does not matter",
+			answers:        map[string][]string{"system": {"1.2.3.4", "5.6.7.8"}, "8.8.8.8:53": {"5.6.7.8", "1.2.3.4"}},
+			expectedStatus: []string{dnsCheckOK},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			findings := evaluateResolution("api.example.com", tc.answers)
+			var statuses []string
+			for _, f := range findings {
+				statuses = append(statuses, f.status)
+			}
+			g.Expect(statuses).To(Equal(tc.expectedStatus))
+		})
+	}
+}
+
+func TestEvaluateCertificate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expired := &x509.Certificate{DNSNames: []string{"api.example.com"}, NotAfter: now.Add(-time.Hour)}
+	g.Expect(evaluateCertificate("cert", "api.example.com", []*x509.Certificate{expired}, now).status).To(Equal(dnsCheckFail))
+
+	wrongHost := &x509.Certificate{DNSNames: []string{"other.example.com"}, NotAfter: now.Add(365 * 24 * time.Hour)}
+	g.Expect(evaluateCertificate("cert", "api.example.com", []*x509.Certificate{wrongHost}, now).status).To(Equal(dnsCheckFail))
+
+	g.Expect(evaluateCertificate("cert", "api.example.com", nil, now).status).To(Equal(dnsCheckFail))
+}
+
+func TestEvaluateHostedZoneRecords(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	complete := map[string]bool{"api.foo.example.com.": true, "\\052.apps.foo.example.com.": true}
+	findings := evaluateHostedZoneRecords("zone", "foo.example.com", complete)
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].status).To(Equal(dnsCheckOK))
+
+	missingApps := map[string]bool{"api.foo.example.com.": true}
+	findings = evaluateHostedZoneRecords("zone", "foo.example.com", missingApps)
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].status).To(Equal(dnsCheckFail))
+	g.Expect(findings[0].message).To(ContainSubstring("*.apps.foo.example.com."))
+}
@@ -0,0 +1,407 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	checkIngressLongDescription = `
+Runs diagnostics on the default ingress of an AWS cluster
+
+  This command will:
+
+  * Find the load balancer of the default router service
+  * Check that its targets, the nodes running the routers, are healthy
+  * Check that its security groups let HTTP and HTTPS traffic in
+  * Check that the *.apps wildcard DNS record resolves to the load balancer
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID') to read the router
+  service. A remediation hint is printed for every failed check.
+`
+	checkIngressExample = `
+  # Check the default ingress of a cluster
+  osdctl cluster check-ingress 1kfmyclusteristhebesteverp8m
+
+  # Use an AWS profile named "rhcontrol"
+  osdctl cluster check-ingress 1kfmyclusteristhebesteverp8m --profile rhcontrol
+`
+
+	routerNamespace = "openshift-ingress"
+	routerService   = "router-default"
+)
+
+// routerPorts are the ports the load balancer of the default router listens on
+var routerPorts = []int64{80, 443}
+
+type checkIngressOptions struct {
+	clusterID  string
+	awsProfile string
+
+	runOC  utils.OCRunner
+	lookup func(host string) ([]string, error)
+}
+
+type checkFinding struct {
+	check   string
+	status  string
+	message string
+	// hint tells how to remediate a failed or warning check
+	hint string
+}
+
+// routerLoadBalancer is the classic or network load balancer of the default router
+type routerLoadBalancer struct {
+	name           string
+	arn            string
+	dnsName        string
+	scheme         string
+	classic        bool
+	securityGroups []*string
+}
+
+func newCmdCheckIngress() *cobra.Command {
+	ops := &checkIngressOptions{runOC: utils.RunOCAsClusterAdmin, lookup: lookupHost}
+	checkIngressCmd := &cobra.Command{
+		Use:               "check-ingress CLUSTER_ID",
+		Short:             "Runs load balancer, security group and DNS diagnostics for the default ingress",
+		Long:              checkIngressLongDescription,
+		Example:           checkIngressExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	checkIngressCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile name")
+
+	return checkIngressCmd
+}
+
+func lookupHost(host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsCheckTimeout)
+	defer cancel()
+	return net.DefaultResolver.LookupHost(ctx, host)
+}
+
+func (o *checkIngressOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.CloudProvider().ID() != "aws" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "check-ingress is only available for AWS clusters")
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	awsClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+	if err != nil {
+		return err
+	}
+
+	appsHost := fmt.Sprintf("%s.apps.%s", wildcardProbeLabel, utils.ClusterDomain(cluster))
+	findings := o.checkIngress(awsClient, appsHost)
+	return printFindings("Check", "ingress", findings)
+}
+
+// checkIngress runs every check, the checks of the load balancer are skipped when it can't be found
+func (o *checkIngressOptions) checkIngress(awsClient aws.Client, appsHost string) []checkFinding {
+	hostname, err := o.runOC("get", "service", routerService, "-n", routerNamespace, "-o", "jsonpath={.status.loadBalancer.ingress[0].hostname}")
+	if err != nil || strings.TrimSpace(string(hostname)) == "" {
+		message := "the service has no load balancer hostname"
+		if err != nil {
+			message = err.Error()
+		}
+		return []checkFinding{{
+			check:   "router service",
+			status:  dnsCheckFail,
+			message: message,
+			hint:    fmt.Sprintf("Check the ingress operator ('oc get co ingress') and the events of the service ('oc -n %s describe service %s')", routerNamespace, routerService),
+		}}
+	}
+	dnsName := strings.TrimSpace(string(hostname))
+
+	lb, err := findRouterLoadBalancer(awsClient, dnsName)
+	if err != nil {
+		return []checkFinding{{
+			check:   "load balancer",
+			status:  dnsCheckFail,
+			message: err.Error(),
+			hint: fmt.Sprintf("The load balancer may have been deleted from the AWS account, deleting the service ('oc -n %s delete service %s') makes the ingress operator recreate it, "+
+				"the *.apps record must then be updated", routerNamespace, routerService),
+		}}
+	}
+
+	kind := "network"
+	if lb.classic {
+		kind = "classic"
+	}
+	findings := []checkFinding{{
+		check:   "load balancer",
+		status:  dnsCheckOK,
+		message: fmt.Sprintf("%s %s (%s, %s)", kind, lb.name, lb.scheme, lb.dnsName),
+	}}
+	findings = append(findings, checkLoadBalancerTargets(awsClient, lb))
+	findings = append(findings, checkLoadBalancerSecurityGroups(awsClient, lb))
+	findings = append(findings, checkAppsDNS(o.lookup, appsHost, lb.dnsName))
+	return findings
+}
+
+// findRouterLoadBalancer looks for the load balancer with the DNS name among the classic and network load balancers
+func findRouterLoadBalancer(awsClient aws.Client, dnsName string) (*routerLoadBalancer, error) {
+	input := &elb.DescribeLoadBalancersInput{}
+	for {
+		output, err := awsClient.DescribeLoadBalancers(input)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the classic load balancers: %w", err)
+		}
+		for _, lb := range output.LoadBalancerDescriptions {
+			if strings.EqualFold(awsSdk.StringValue(lb.DNSName), dnsName) {
+				return &routerLoadBalancer{
+					name:           awsSdk.StringValue(lb.LoadBalancerName),
+					dnsName:        awsSdk.StringValue(lb.DNSName),
+					scheme:         awsSdk.StringValue(lb.Scheme),
+					classic:        true,
+					securityGroups: lb.SecurityGroups,
+				}, nil
+			}
+		}
+		if output.NextMarker == nil {
+			break
+		}
+		input.Marker = output.NextMarker
+	}
+
+	inputV2 := &elbv2.DescribeLoadBalancersInput{}
+	for {
+		output, err := awsClient.DescribeV2LoadBalancers(inputV2)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the network load balancers: %w", err)
+		}
+		for _, lb := range output.LoadBalancers {
+			if strings.EqualFold(awsSdk.StringValue(lb.DNSName), dnsName) {
+				return &routerLoadBalancer{
+					name:           awsSdk.StringValue(lb.LoadBalancerName),
+					arn:            awsSdk.StringValue(lb.LoadBalancerArn),
+					dnsName:        awsSdk.StringValue(lb.DNSName),
+					scheme:         awsSdk.StringValue(lb.Scheme),
+					securityGroups: lb.SecurityGroups,
+				}, nil
+			}
+		}
+		if output.NextMarker == nil {
+			break
+		}
+		inputV2.Marker = output.NextMarker
+	}
+
+	return nil, fmt.Errorf("no load balancer named %s found in the cluster's account", dnsName)
+}
+
+// checkLoadBalancerTargets counts the healthy targets of the load balancer
+func checkLoadBalancerTargets(awsClient aws.Client, lb *routerLoadBalancer) checkFinding {
+	check := "load balancer targets"
+	var healthy, total int
+	var unhealthy []string
+
+	if lb.classic {
+		output, err := awsClient.DescribeInstanceHealth(&elb.DescribeInstanceHealthInput{LoadBalancerName: awsSdk.String(lb.name)})
+		if err != nil {
+			return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot get the instance health: %v", err)}
+		}
+		for _, state := range output.InstanceStates {
+			total++
+			if awsSdk.StringValue(state.State) == "InService" {
+				healthy++
+				continue
+			}
+			unhealthy = append(unhealthy, fmt.Sprintf("%s (%s)", awsSdk.StringValue(state.InstanceId), awsSdk.StringValue(state.ReasonCode)))
+		}
+	} else {
+		groups, err := awsClient.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{LoadBalancerArn: awsSdk.String(lb.arn)})
+		if err != nil {
+			return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot list the target groups: %v", err)}
+		}
+		for _, group := range groups.TargetGroups {
+			output, err := awsClient.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{TargetGroupArn: group.TargetGroupArn})
+			if err != nil {
+				return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot get the target health: %v", err)}
+			}
+			for _, target := range output.TargetHealthDescriptions {
+				total++
+				if target.TargetHealth != nil && awsSdk.StringValue(target.TargetHealth.State) == elbv2.TargetHealthStateEnumHealthy {
+					healthy++
+					continue
+				}
+				reason := ""
+				if target.TargetHealth != nil {
+					reason = awsSdk.StringValue(target.TargetHealth.Reason)
+				}
+				unhealthy = append(unhealthy, fmt.Sprintf("%s:%d in %s (%s)", awsSdk.StringValue(target.Target.Id),
+					awsSdk.Int64Value(target.Target.Port), awsSdk.StringValue(group.TargetGroupName), reason))
+			}
+		}
+	}
+
+	return evaluateTargetHealth(check, healthy, total, unhealthy)
+}
+
+func evaluateTargetHealth(check string, healthy, total int, unhealthy []string) checkFinding {
+	hint := fmt.Sprintf("Check that the router pods are running ('oc -n %s get pods -o wide') and that the nodes they run on are registered and pass the health check", routerNamespace)
+	switch {
+	case total == 0:
+		return checkFinding{check: check, status: dnsCheckFail, message: "no target is registered", hint: hint}
+	case healthy == 0:
+		return checkFinding{check: check, status: dnsCheckFail, message: fmt.Sprintf("no healthy target, unhealthy: %s", strings.Join(unhealthy, ", ")), hint: hint}
+	case healthy < total:
+		// Nodes without a router pod fail the health check of network load balancers, that's expected
+		return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("%d/%d targets healthy, unhealthy: %s", healthy, total, strings.Join(unhealthy, ", ")), hint: hint}
+	}
+	return checkFinding{check: check, status: dnsCheckOK, message: fmt.Sprintf("%d/%d targets healthy", healthy, total)}
+}
+
+// checkLoadBalancerSecurityGroups checks that the security groups of the load balancer allow the router ports
+func checkLoadBalancerSecurityGroups(awsClient aws.Client, lb *routerLoadBalancer) checkFinding {
+	check := "load balancer security groups"
+	if len(lb.securityGroups) == 0 {
+		return checkFinding{check: check, status: dnsCheckOK, message: "no security group attached, traffic is filtered by the node security groups"}
+	}
+
+	output, err := awsClient.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{GroupIds: lb.securityGroups})
+	if err != nil {
+		return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot describe the security groups: %v", err)}
+	}
+	return evaluateSecurityGroups(check, output.SecurityGroups, routerPorts)
+}
+
+func evaluateSecurityGroups(check string, groups []*ec2.SecurityGroup, ports []int64) checkFinding {
+	var ids []string
+	var blocked []string
+	for _, group := range groups {
+		ids = append(ids, awsSdk.StringValue(group.GroupId))
+	}
+	sort.Strings(ids)
+
+	for _, port := range ports {
+		allowed := false
+		for _, group := range groups {
+			for _, permission := range group.IpPermissions {
+				if permissionAllowsPort(permission, port) {
+					allowed = true
+				}
+			}
+		}
+		if !allowed {
+			blocked = append(blocked, fmt.Sprint(port))
+		}
+	}
+
+	if len(blocked) > 0 {
+		return checkFinding{
+			check:   check,
+			status:  dnsCheckFail,
+			message: fmt.Sprintf("no inbound rule of %s allows TCP port %s", strings.Join(ids, ", "), strings.Join(blocked, ", ")),
+			hint:    "Restore the inbound rules of the load balancer security group, the ingress operator creates them for ports 80 and 443 from the allowed source ranges",
+		}
+	}
+	return checkFinding{check: check, status: dnsCheckOK, message: fmt.Sprintf("%s allow TCP ports 80 and 443", strings.Join(ids, ", "))}
+}
+
+func permissionAllowsPort(permission *ec2.IpPermission, port int64) bool {
+	if len(permission.IpRanges) == 0 && len(permission.Ipv6Ranges) == 0 && len(permission.PrefixListIds) == 0 && len(permission.UserIdGroupPairs) == 0 {
+		return false
+	}
+	switch awsSdk.StringValue(permission.IpProtocol) {
+	case "-1":
+		return true
+	case "tcp", "6":
+		return awsSdk.Int64Value(permission.FromPort) <= port && port <= awsSdk.Int64Value(permission.ToPort)
+	}
+	return false
+}
+
+// checkAppsDNS checks that the *.apps wildcard resolves to addresses of the load balancer
+func checkAppsDNS(lookup func(string) ([]string, error), appsHost, lbDNSName string) checkFinding {
+	check := "resolve " + appsHost
+	hint := fmt.Sprintf("Point the *.apps record of the cluster's hosted zone at the load balancer, as an alias of %s", lbDNSName)
+
+	appsAddrs, err := lookup(appsHost)
+	if err != nil || len(appsAddrs) == 0 {
+		return checkFinding{check: check, status: dnsCheckFail, message: "does not resolve", hint: hint}
+	}
+	lbAddrs, err := lookup(lbDNSName)
+	if err != nil || len(lbAddrs) == 0 {
+		return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("the load balancer %s does not resolve, cannot compare", lbDNSName)}
+	}
+
+	// Load balancers rotate their addresses, any common address means the record is right
+	lbSet := map[string]bool{}
+	for _, addr := range lbAddrs {
+		lbSet[addr] = true
+	}
+	for _, addr := range appsAddrs {
+		if lbSet[addr] {
+			return checkFinding{check: check, status: dnsCheckOK, message: "resolves to the load balancer"}
+		}
+	}
+	sort.Strings(appsAddrs)
+	return checkFinding{check: check, status: dnsCheckFail, message: fmt.Sprintf("resolves to %s, not to the load balancer", strings.Join(appsAddrs, ", ")), hint: hint}
+}
+
+// printFindings prints the findings of a diagnostic command and returns an error when a check failed
+func printFindings(header, kind string, findings []checkFinding) error {
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{header, "Status", "Details"})
+	failed := 0
+	var hints []string
+	for _, f := range findings {
+		table.AddRow([]string{f.check, f.status, f.message})
+		if f.status == dnsCheckFail {
+			failed++
+		}
+		if f.hint != "" {
+			hints = append(hints, fmt.Sprintf("  * %s: %s", f.check, f.hint))
+		}
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if len(hints) > 0 {
+		fmt.Println("Remediation:")
+		fmt.Println(strings.Join(hints, "\n"))
+		fmt.Println()
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d %s checks failed", failed, kind)
+	}
+	return nil
+}
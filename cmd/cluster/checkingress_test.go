@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func TestEvaluateSecurityGroups(t *testing.T) {
+	g := NewGomegaWithT(t)
+	anywhere := []*ec2.IpRange{{CidrIp: awsSdk.String("0.0.0.0/0")}}
+	group := func(permissions ...*ec2.IpPermission) []*ec2.SecurityGroup {
+		return []*ec2.SecurityGroup{{GroupId: awsSdk.String("sg-1"), IpPermissions: permissions}}
+	}
+	tcp := func(from, to int64) *ec2.IpPermission {
+		return &ec2.IpPermission{IpProtocol: awsSdk.String("tcp"), FromPort: awsSdk.Int64(from), ToPort: awsSdk.Int64(to), IpRanges: anywhere}
+	}
+
+	g.Expect(evaluateSecurityGroups("sg", group(tcp(80, 80), tcp(443, 443)), routerPorts).status).To(Equal(dnsCheckOK))
+	g.Expect(evaluateSecurityGroups("sg", group(tcp(0, 65535)), routerPorts).status).To(Equal(dnsCheckOK))
+	g.Expect(evaluateSecurityGroups("sg", group(&ec2.IpPermission{IpProtocol: awsSdk.String("-1"), IpRanges: anywhere}), routerPorts).status).To(Equal(dnsCheckOK))
+
+	finding := evaluateSecurityGroups("sg", group(tcp(443, 443)), routerPorts)
+	g.Expect(finding.status).To(Equal(dnsCheckFail))
+	g.Expect(finding.message).To(ContainSubstring("port 80"))
+	g.Expect(finding.hint).NotTo(BeEmpty())
+
+	// A rule without any source doesn't let anything in
+	g.Expect(evaluateSecurityGroups("sg", group(&ec2.IpPermission{IpProtocol: awsSdk.String("-1")}), routerPorts).status).To(Equal(dnsCheckFail))
+}
+
+func TestEvaluateTargetHealth(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(evaluateTargetHealth("targets", 3, 3, nil).status).To(Equal(dnsCheckOK))
+	g.Expect(evaluateTargetHealth("targets", 2, 3, []string{"i-1"}).status).To(Equal(dnsCheckWarn))
+	g.Expect(evaluateTargetHealth("targets", 0, 3, []string{"i-1", "i-2", "i-3"}).status).To(Equal(dnsCheckFail))
+	g.Expect(evaluateTargetHealth("targets", 0, 0, nil).status).To(Equal(dnsCheckFail))
+}
+
+func TestCheckAppsDNS(t *testing.T) {
+	g := NewGomegaWithT(t)
+	records := map[string][]string{
+		"test.apps.example.com":   {"1.1.1.1", "2.2.2.2"},
+		"lb.elb.amazonaws.com":    {"2.2.2.2", "3.3.3.3"},
+		"other.elb.amazonaws.com": {"4.4.4.4"},
+	}
+	lookup := func(host string) ([]string, error) {
+		if addrs, ok := records[host]; ok {
+			return addrs, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	g.Expect(checkAppsDNS(lookup, "test.apps.example.com", "lb.elb.amazonaws.com").status).To(Equal(dnsCheckOK))
+	g.Expect(checkAppsDNS(lookup, "test.apps.example.com", "other.elb.amazonaws.com").status).To(Equal(dnsCheckFail))
+	g.Expect(checkAppsDNS(lookup, "missing.apps.example.com", "lb.elb.amazonaws.com").status).To(Equal(dnsCheckFail))
+	g.Expect(checkAppsDNS(lookup, "test.apps.example.com", "missing.elb.amazonaws.com").status).To(Equal(dnsCheckWarn))
+}
+
+func TestCheckIngressNetworkLoadBalancer(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+
+	mockAWSClient.EXPECT().DescribeLoadBalancers(gomock.Any()).Return(&elb.DescribeLoadBalancersOutput{}, nil)
+	mockAWSClient.EXPECT().DescribeV2LoadBalancers(gomock.Any()).Return(&elbv2.DescribeLoadBalancersOutput{
+		LoadBalancers: []*elbv2.LoadBalancer{{
+			LoadBalancerName: awsSdk.String("router"),
+			LoadBalancerArn:  awsSdk.String("arn:router"),
+			DNSName:          awsSdk.String("lb.elb.amazonaws.com"),
+			Scheme:           awsSdk.String("internet-facing"),
+		}},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeTargetGroups(gomock.Any()).Return(&elbv2.DescribeTargetGroupsOutput{
+		TargetGroups: []*elbv2.TargetGroup{{TargetGroupArn: awsSdk.String("arn:tg"), TargetGroupName: awsSdk.String("tg")}},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeTargetHealth(gomock.Any()).Return(&elbv2.DescribeTargetHealthOutput{
+		TargetHealthDescriptions: []*elbv2.TargetHealthDescription{
+			{Target: &elbv2.TargetDescription{Id: awsSdk.String("i-1"), Port: awsSdk.Int64(30443)}, TargetHealth: &elbv2.TargetHealth{State: awsSdk.String("healthy")}},
+			{Target: &elbv2.TargetDescription{Id: awsSdk.String("i-2"), Port: awsSdk.Int64(30443)}, TargetHealth: &elbv2.TargetHealth{State: awsSdk.String("healthy")}},
+		},
+	}, nil)
+
+	o := &checkIngressOptions{
+		runOC: func(args ...string) ([]byte, error) { return []byte("LB.elb.amazonaws.com"), nil },
+		lookup: func(host string) ([]string, error) {
+			return []string{"1.1.1.1"}, nil
+		},
+	}
+	findings := o.checkIngress(mockAWSClient, "test.apps.example.com")
+
+	var statuses []string
+	for _, f := range findings {
+		statuses = append(statuses, f.status)
+	}
+	g.Expect(statuses).To(Equal([]string{dnsCheckOK, dnsCheckOK, dnsCheckOK, dnsCheckOK}))
+	g.Expect(printFindings("Check", "ingress", findings)).To(Succeed())
+}
+
+func TestCheckIngressWithoutRouterLoadBalancer(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	o := &checkIngressOptions{runOC: func(args ...string) ([]byte, error) { return nil, nil }}
+	findings := o.checkIngress(nil, "test.apps.example.com")
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].status).To(Equal(dnsCheckFail))
+	g.Expect(findings[0].hint).To(ContainSubstring("oc get co ingress"))
+	g.Expect(printFindings("Check", "ingress", findings)).NotTo(Succeed())
+}
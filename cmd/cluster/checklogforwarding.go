@@ -0,0 +1,603 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	checkLogForwardingLongDescription = `
+Checks the log forwarding of a cluster to its CloudWatch, Splunk and other destinations
+
+  This command will:
+
+  * Check the ClusterLogForwarders (logging.openshift.io and observability.openshift.io) are ready, and that their
+    pipelines only reference defined outputs
+  * Check that every output has a destination and that its secret exists
+  * From a node, through 'oc debug', check that every destination answers, using the cluster-wide proxy if any
+  * From the cluster Prometheus, count the logs the collectors sent and dropped per output over --since
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'). A remediation hint
+  is printed for every failed check. The nodes reach the destinations like the collector pods, except through the
+  network policies of the logging namespace.
+`
+	checkLogForwardingExample = `
+  # Check the log forwarding of a cluster
+  osdctl cluster check-logforwarding 1kfmyclusteristhebesteverp8m
+
+  # Count the dropped logs of the last day, probing from a given node
+  osdctl cluster check-logforwarding 1kfmyclusteristhebesteverp8m --since 24h --node ip-10-0-1-2.ec2.internal
+`
+
+	// logForwardingProbePrefix marks the probe results in the output of oc debug
+	logForwardingProbePrefix = "OSDCTL_LOGFORWARDING"
+
+	// defaultLogOutput is the log store of the cluster, it has no destination to check
+	defaultLogOutput = "default"
+
+	// droppedLogsFailRatio is the share of dropped logs of an output above which the check fails, below it warns
+	droppedLogsFailRatio = 0.01
+)
+
+// logForwarderResources are the ClusterLogForwarders of Red Hat OpenShift Logging 5 and 6
+var logForwarderResources = []string{"clusterlogforwarders.logging.openshift.io", "clusterlogforwarders.observability.openshift.io"}
+
+// vectorComponentID matches the characters the collector replaces in the names of the outputs
+var vectorComponentID = regexp.MustCompile(`[^a-z0-9_]`)
+
+type checkLogForwardingOptions struct {
+	clusterID string
+	node      string
+	since     time.Duration
+
+	runOC utils.OCRunner
+}
+
+// logCondition is a status condition of a forwarder or of one of its outputs
+type logCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// logForwarder is the part of a ClusterLogForwarder of either API which is checked
+type logForwarder struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Outputs   []json.RawMessage `json:"outputs"`
+		Pipelines []struct {
+			Name       string   `json:"name"`
+			OutputRefs []string `json:"outputRefs"`
+		} `json:"pipelines"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []logCondition `json:"conditions"`
+		// Outputs are the conditions of every output with logging.openshift.io, observability.openshift.io has
+		// outputConditions instead, named after the output
+		Outputs          map[string][]logCondition `json:"outputs"`
+		OutputConditions []logCondition            `json:"outputConditions"`
+	} `json:"status"`
+}
+
+// logOutput is a destination of a forwarder
+type logOutput struct {
+	namespace string
+	name      string
+	kind      string
+	// endpoint is the URL the logs are sent to, empty when the output doesn't have one
+	endpoint string
+	secrets  []string
+}
+
+// logForwardingProbe is the result of probing a destination from a node, code is the HTTP code, tcp when a TCP
+// connection was opened, 000 when the destination can't be reached
+type logForwardingProbe struct {
+	code string
+}
+
+// droppedLogs are the logs the collectors of an output sent and dropped
+type droppedLogs struct {
+	sent    float64
+	dropped float64
+}
+
+func newCmdCheckLogForwarding() *cobra.Command {
+	ops := &checkLogForwardingOptions{runOC: utils.RunOCAsClusterAdmin}
+	checkLogForwardingCmd := &cobra.Command{
+		Use:               "check-logforwarding CLUSTER_ID",
+		Short:             "Checks the ClusterLogForwarder configuration, the reachability of its destinations and the dropped logs",
+		Long:              checkLogForwardingLongDescription,
+		Example:           checkLogForwardingExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	checkLogForwardingCmd.Flags().StringVar(&ops.node, "node", "", "Node to run the probes from, a worker node by default")
+	checkLogForwardingCmd.Flags().DurationVar(&ops.since, "since", time.Hour, "Period to count the dropped logs over")
+
+	return checkLogForwardingCmd
+}
+
+func (o *checkLogForwardingOptions) run() error {
+	if o.since < time.Minute {
+		return cmdutil.UsageErrorf(nil, "--since must be at least a minute, got %s", o.since)
+	}
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	findings, err := o.checkLogForwarding()
+	if err != nil {
+		return err
+	}
+	return printFindings("Log forwarding", "log forwarding", findings)
+}
+
+// checkLogForwarding checks the forwarders, probes their destinations from a node and counts the dropped logs
+func (o *checkLogForwardingOptions) checkLogForwarding() ([]checkFinding, error) {
+	forwarders, err := o.logForwarders()
+	if err != nil {
+		return nil, err
+	}
+	if len(forwarders) == 0 {
+		return []checkFinding{{check: "ClusterLogForwarder", status: dnsCheckWarn, message: "none on the cluster, the logs aren't forwarded",
+			hint: "the customer needs to install Red Hat OpenShift Logging and create a ClusterLogForwarder to forward the logs"}}, nil
+	}
+
+	var findings []checkFinding
+	var outputs []logOutput
+	for _, forwarder := range forwarders {
+		forwarderFindings, forwarderOutputs, err := evaluateLogForwarder(forwarder)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, forwarderFindings...)
+		outputs = append(outputs, forwarderOutputs...)
+	}
+
+	for _, output := range outputs {
+		findings = append(findings, o.checkLogOutputSecrets(output)...)
+	}
+
+	probes, err := o.probeLogOutputs(outputs)
+	if err != nil {
+		return nil, err
+	}
+	dropped, droppedErr := o.droppedLogs()
+	for i, output := range outputs {
+		if output.endpoint != "" {
+			findings = append(findings, evaluateLogForwardingProbe(output, probes[i]))
+		}
+		findings = append(findings, evaluateDroppedLogs(output, dropped, droppedErr, o.since))
+	}
+	return findings, nil
+}
+
+// logForwarders returns the ClusterLogForwarders of both APIs, the API which isn't installed is skipped
+func (o *checkLogForwardingOptions) logForwarders() ([]logForwarder, error) {
+	var forwarders []logForwarder
+	for _, resource := range logForwarderResources {
+		output, err := o.runOC("get", resource, "-A", "-o", "json")
+		if err != nil {
+			continue
+		}
+		var list struct {
+			Items []logForwarder `json:"items"`
+		}
+		if err := json.Unmarshal(output, &list); err != nil {
+			return nil, fmt.Errorf("cannot parse the %s: %w", resource, err)
+		}
+		forwarders = append(forwarders, list.Items...)
+	}
+	return forwarders, nil
+}
+
+// evaluateLogForwarder checks the conditions and the pipelines of a forwarder and returns its outputs
+func evaluateLogForwarder(forwarder logForwarder) ([]checkFinding, []logOutput, error) {
+	name := fmt.Sprintf("%s/%s", forwarder.Metadata.Namespace, forwarder.Metadata.Name)
+	var findings []checkFinding
+
+	if failed := failedLogConditions(forwarder.Status.Conditions); len(failed) > 0 {
+		findings = append(findings, checkFinding{check: "forwarder " + name, status: dnsCheckFail, message: strings.Join(failed, "; "),
+			hint: fmt.Sprintf("check the status and the events of the ClusterLogForwarder, 'oc describe clusterlogforwarder -n %s %s'", forwarder.Metadata.Namespace, forwarder.Metadata.Name)})
+	} else {
+		findings = append(findings, checkFinding{check: "forwarder " + name, status: dnsCheckOK, message: "ready"})
+	}
+
+	var outputs []logOutput
+	defined := map[string]bool{defaultLogOutput: true}
+	for _, raw := range forwarder.Spec.Outputs {
+		output, err := parseLogOutput(raw)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot parse the outputs of the ClusterLogForwarder %s: %w", name, err)
+		}
+		output.namespace = forwarder.Metadata.Namespace
+		defined[output.name] = true
+		outputs = append(outputs, output)
+
+		check := fmt.Sprintf("%s (%s) configuration", output.name, output.kind)
+		conditions := append(forwarder.Status.Outputs[output.name], outputConditions(forwarder.Status.OutputConditions, output.name)...)
+		switch failed := failedLogConditions(conditions); {
+		case len(failed) > 0:
+			findings = append(findings, checkFinding{check: check, status: dnsCheckFail, message: strings.Join(failed, "; "),
+				hint: "fix the output in the ClusterLogForwarder, the collector doesn't send to it"})
+		case output.endpoint == "" && output.kind != "lokistack":
+			findings = append(findings, checkFinding{check: check, status: dnsCheckFail, message: "no destination",
+				hint: "the output needs a URL, or a region for CloudWatch"})
+		default:
+			message := "sends to " + output.endpoint
+			if output.endpoint == "" {
+				message = "sends to the LokiStack of the cluster"
+			}
+			findings = append(findings, checkFinding{check: check, status: dnsCheckOK, message: message})
+		}
+	}
+
+	for _, pipeline := range forwarder.Spec.Pipelines {
+		for _, ref := range pipeline.OutputRefs {
+			if !defined[ref] {
+				findings = append(findings, checkFinding{check: fmt.Sprintf("pipeline %s", pipeline.Name), status: dnsCheckFail,
+					message: fmt.Sprintf("references the undefined output %s", ref),
+					hint:    "its logs aren't forwarded there, define the output or remove it from the pipeline"})
+			}
+		}
+	}
+	return findings, outputs, nil
+}
+
+// parseLogOutput returns the destination and the secrets of an output of either API. logging.openshift.io has
+// the url and the secret on the output, observability.openshift.io has them in the settings of its type.
+func parseLogOutput(raw json.RawMessage) (logOutput, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return logOutput{}, err
+	}
+	output := logOutput{name: stringField(fields, "name"), kind: strings.ToLower(stringField(fields, "type"))}
+	settings, _ := fields[stringField(fields, "type")].(map[string]interface{})
+
+	output.endpoint = stringField(fields, "url")
+	if output.endpoint == "" {
+		output.endpoint = stringField(settings, "url")
+	}
+	if output.endpoint == "" && output.kind == "cloudwatch" {
+		if region := stringField(settings, "region"); region != "" {
+			output.endpoint = awsprovider.ServiceEndpointURL("logs", region)
+		}
+	}
+
+	if secret, ok := fields["secret"].(map[string]interface{}); ok && stringField(secret, "name") != "" {
+		output.secrets = append(output.secrets, stringField(secret, "name"))
+	}
+	output.secrets = append(output.secrets, secretNames(settings)...)
+	sort.Strings(output.secrets)
+	return output, nil
+}
+
+func stringField(fields map[string]interface{}, name string) string {
+	value, _ := fields[name].(string)
+	return value
+}
+
+// secretNames returns the secretName fields of the settings of an output, each once
+func secretNames(value interface{}) []string {
+	seen := map[string]bool{}
+	var walk func(interface{})
+	walk = func(value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, field := range v {
+				if name, ok := field.(string); ok && key == "secretName" && name != "" {
+					seen[name] = true
+					continue
+				}
+				walk(field)
+			}
+		case []interface{}:
+			for _, item := range v {
+				walk(item)
+			}
+		}
+	}
+	walk(value)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	return names
+}
+
+// outputConditions returns the conditions of observability.openshift.io of the output, their type ends with its name,
+// e.g. observability.openshift.io/ValidOutput-cloudwatch
+func outputConditions(conditions []logCondition, output string) []logCondition {
+	var named []logCondition
+	for _, condition := range conditions {
+		if strings.HasSuffix(condition.Type, "-"+output) {
+			named = append(named, condition)
+		}
+	}
+	return named
+}
+
+// failedLogConditions returns the readiness, validation and authorization conditions which aren't true
+func failedLogConditions(conditions []logCondition) []string {
+	var failed []string
+	for _, condition := range conditions {
+		kind := condition.Type
+		if i := strings.LastIndex(kind, "/"); i >= 0 {
+			kind = kind[i+1:]
+		}
+		if !strings.HasPrefix(kind, "Ready") && !strings.HasPrefix(kind, "Valid") && !strings.HasPrefix(kind, "Authorized") {
+			continue
+		}
+		if condition.Status == "True" {
+			continue
+		}
+		message := condition.Message
+		if message == "" {
+			message = condition.Reason
+		}
+		failed = append(failed, fmt.Sprintf("%s: %s", condition.Type, message))
+	}
+	return failed
+}
+
+// checkLogOutputSecrets checks that the secrets of an output exist in the namespace of its forwarder
+func (o *checkLogForwardingOptions) checkLogOutputSecrets(output logOutput) []checkFinding {
+	var findings []checkFinding
+	for _, secret := range output.secrets {
+		check := fmt.Sprintf("%s (%s) secret %s", output.name, output.kind, secret)
+		if _, err := o.runOC("get", "secret", secret, "-n", output.namespace, "-o", "name"); err != nil {
+			findings = append(findings, checkFinding{check: check, status: dnsCheckFail, message: "missing",
+				hint: fmt.Sprintf("the customer needs to create the secret %s in %s with the credentials of the destination", secret, output.namespace)})
+			continue
+		}
+		findings = append(findings, checkFinding{check: check, status: dnsCheckOK, message: "present"})
+	}
+	return findings
+}
+
+// probeLogOutputs probes the destinations of the outputs from a node, the results are by index of the outputs
+func (o *checkLogForwardingOptions) probeLogOutputs(outputs []logOutput) (map[int]logForwardingProbe, error) {
+	script := logForwardingProbeScript(outputs, clusterProxyEnv(o.runOC))
+	if script == "" {
+		return nil, nil
+	}
+	node, err := probeNode(o.runOC, o.node)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Probing the log destinations from node %s\n", node)
+	output, err := o.runOC("debug", "node/"+node, "--", "chroot", "/host", "bash", "-c", script)
+	if err != nil {
+		return nil, err
+	}
+	return parseLogForwardingProbes(string(output)), nil
+}
+
+// logForwardingProbeScript returns the shell script probing the destinations from the node: an HTTP request for
+// the HTTP destinations, a TCP connection for the others like syslog and kafka. Empty when there is nothing to probe.
+func logForwardingProbeScript(outputs []logOutput, proxyEnv []string) string {
+	var probes strings.Builder
+	for i, output := range outputs {
+		if output.endpoint == "" {
+			continue
+		}
+		host, port := endpointHostPort(output.endpoint)
+		if host == "" {
+			continue
+		}
+		fmt.Fprintf(&probes, "probe %d %s %s %s\n", i, shellQuote(output.endpoint), shellQuote(host), shellQuote(port))
+	}
+	if probes.Len() == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, env := range proxyEnv {
+		fmt.Fprintf(&b, "export %s\n", shellQuote(env))
+	}
+	b.WriteString(`probe() {
+  case "$2" in
+    http://*|https://*) code=$(curl -s -o /dev/null -w '%{http_code}' --max-time 10 "$2") ;;
+    *) if timeout 10 bash -c "</dev/tcp/$3/$4" 2>/dev/null; then code=tcp; else code=000; fi ;;
+  esac
+  echo "` + logForwardingProbePrefix + ` $1 $code"
+}
+`)
+	b.WriteString(probes.String())
+	return b.String()
+}
+
+// endpointHostPort returns the host and the port of a destination, with the default port of its scheme
+func endpointHostPort(endpoint string) (string, string) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Hostname() == "" {
+		return "", ""
+	}
+	port := u.Port()
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		case "tls":
+			port = "6514"
+		default:
+			port = "514"
+		}
+	}
+	return u.Hostname(), port
+}
+
+// parseLogForwardingProbes returns the probe results by index of the outputs
+func parseLogForwardingProbes(output string) map[int]logForwardingProbe {
+	probes := map[int]logForwardingProbe{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != logForwardingProbePrefix {
+			continue
+		}
+		var index int
+		if _, err := fmt.Sscanf(fields[1], "%d", &index); err != nil {
+			continue
+		}
+		probes[index] = logForwardingProbe{code: fields[2]}
+	}
+	return probes
+}
+
+func evaluateLogForwardingProbe(output logOutput, probe logForwardingProbe) checkFinding {
+	check := fmt.Sprintf("%s (%s) reachability", output.name, output.kind)
+	host, port := endpointHostPort(output.endpoint)
+	switch probe.code {
+	case "":
+		return checkFinding{check: check, status: dnsCheckWarn, message: "not probed, the node gave no result",
+			hint: "check that 'oc debug node' works on the cluster, curl and bash are needed on the node"}
+	case "000":
+		return checkFinding{check: check, status: dnsCheckFail, message: fmt.Sprintf("%s unreachable from the node", net.JoinHostPort(host, port)),
+			hint: fmt.Sprintf("allow the traffic to %s through the firewall and the cluster-wide proxy, see 'osdctl network verify-egress'", net.JoinHostPort(host, port))}
+	case "tcp":
+		return checkFinding{check: check, status: dnsCheckOK, message: fmt.Sprintf("%s accepts connections", net.JoinHostPort(host, port))}
+	}
+	// Any HTTP answer means the destination is reachable, the credentials are checked by the collector
+	return checkFinding{check: check, status: dnsCheckOK, message: fmt.Sprintf("reachable (HTTP %s)", probe.code)}
+}
+
+// droppedLogsQueries are the logs sent and dropped by the outputs of the collectors, per namespace and output
+const (
+	sentLogsQuery    = `sum by (namespace, component_id) (increase(vector_component_sent_events_total{component_kind="sink"}[%s]))`
+	droppedLogsQuery = `sum by (namespace, component_id) (increase({__name__=~"vector_component_discarded_events_total|vector_buffer_discarded_events_total"}[%s]))`
+)
+
+// droppedLogs returns the logs sent and dropped over --since by namespace and collector component, from the
+// cluster Prometheus
+func (o *checkLogForwardingOptions) droppedLogs() (map[string]droppedLogs, error) {
+	window := fmt.Sprintf("%ds", int(o.since.Seconds()))
+	logs := map[string]droppedLogs{}
+	for _, query := range []struct {
+		query   string
+		dropped bool
+	}{{fmt.Sprintf(sentLogsQuery, window), false}, {fmt.Sprintf(droppedLogsQuery, window), true}} {
+		output, err := o.runOC("exec", "-n", "openshift-monitoring", "-c", "prometheus", "prometheus-k8s-0", "--",
+			"curl", "-s", "--data-urlencode", "query="+query.query, "http://localhost:9090/api/v1/query")
+		if err != nil {
+			return nil, err
+		}
+		samples, err := parseCollectorSamples(output)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range samples {
+			counts := logs[key]
+			if query.dropped {
+				counts.dropped += value
+			} else {
+				counts.sent += value
+			}
+			logs[key] = counts
+		}
+	}
+	return logs, nil
+}
+
+// parseCollectorSamples returns the values of a Prometheus response by namespace and component
+func parseCollectorSamples(output []byte) (map[string]float64, error) {
+	var response struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  []interface{}     `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return nil, fmt.Errorf("cannot parse the prometheus response: %w", err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("the prometheus query failed with status '%s'", response.Status)
+	}
+
+	samples := map[string]float64{}
+	for _, result := range response.Data.Result {
+		if len(result.Value) != 2 {
+			continue
+		}
+		if value, ok := result.Value[1].(string); ok {
+			var count float64
+			if _, err := fmt.Sscanf(value, "%g", &count); err == nil {
+				samples[collectorKey(result.Metric["namespace"], result.Metric["component_id"])] = count
+			}
+		}
+	}
+	return samples, nil
+}
+
+func collectorKey(namespace, component string) string {
+	return namespace + "/" + component
+}
+
+// vectorSinkID returns the ID of the collector component sending to the output
+func vectorSinkID(output string) string {
+	return "output_" + vectorComponentID.ReplaceAllString(strings.ToLower(output), "_")
+}
+
+func evaluateDroppedLogs(output logOutput, logs map[string]droppedLogs, err error, since time.Duration) checkFinding {
+	check := fmt.Sprintf("%s (%s) dropped logs", output.name, output.kind)
+	if err != nil {
+		return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot read the collector metrics: %v", err),
+			hint: "check that the prometheus-k8s pods of openshift-monitoring are running"}
+	}
+	counts, ok := logs[collectorKey(output.namespace, vectorSinkID(output.name))]
+	if !ok {
+		return checkFinding{check: check, status: dnsCheckWarn, message: "no collector metrics for the output",
+			hint: fmt.Sprintf("check that the collector pods of %s are running, the fluentd collector has no such metrics", output.namespace)}
+	}
+	// increase() extrapolates, round to whole logs
+	summary := fmt.Sprintf("%.0f dropped, %.0f sent in the last %s", counts.dropped, counts.sent, since)
+	total := counts.sent + counts.dropped
+	if counts.dropped < 1 || total == 0 {
+		return checkFinding{check: check, status: dnsCheckOK, message: summary}
+	}
+	ratio := counts.dropped / total
+	summary += fmt.Sprintf(" (%.2f%% dropped)", ratio*100)
+	hint := "the destination is throttling or rejecting the logs, check its quotas and the collector logs for the errors of the output"
+	if ratio >= droppedLogsFailRatio {
+		return checkFinding{check: check, status: dnsCheckFail, message: summary, hint: hint}
+	}
+	return checkFinding{check: check, status: dnsCheckWarn, message: summary, hint: hint}
+}
@@ -0,0 +1,124 @@
+package cluster
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const testLoggingForwarders = `{"items": [{
+  "metadata": {"name": "instance", "namespace": "openshift-logging"},
+  "spec": {
+    "outputs": [
+      {"name": "cw", "type": "cloudwatch", "cloudwatch": {"region": "us-east-1", "groupBy": "logType"}, "secret": {"name": "cw-secret"}},
+      {"name": "splunk-prod", "type": "splunk", "url": "https://splunk.example.com:8088", "secret": {"name": "splunk-secret"}},
+      {"name": "syslog", "type": "syslog", "url": "tls://syslog.example.com"}
+    ],
+    "pipelines": [{"name": "all", "outputRefs": ["cw", "splunk-prod", "syslog", "default", "missing"]}]
+  },
+  "status": {
+    "conditions": [{"type": "Ready", "status": "True"}],
+    "outputs": {"syslog": [{"type": "Ready", "status": "False", "reason": "Invalid", "message": "invalid URL scheme"}]}
+  }
+}]}`
+
+const testObservabilityForwarders = `{"items": [{
+  "metadata": {"name": "collector", "namespace": "logging"},
+  "spec": {
+    "outputs": [{"name": "splunk", "type": "splunk", "splunk": {"url": "https://hec.example.com", "authentication": {"token": {"secretName": "hec-token", "key": "token"}}}}]
+  },
+  "status": {
+    "conditions": [{"type": "observability.openshift.io/Authorized", "status": "True"}],
+    "outputConditions": [{"type": "observability.openshift.io/ValidOutput-splunk", "status": "True"}]
+  }
+}]}`
+
+func TestCheckLogForwarding(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var script string
+	o := &checkLogForwardingOptions{since: time.Hour, runOC: func(args ...string) ([]byte, error) {
+		switch {
+		case args[0] == "get" && args[1] == "clusterlogforwarders.logging.openshift.io":
+			return []byte(testLoggingForwarders), nil
+		case args[0] == "get" && args[1] == "clusterlogforwarders.observability.openshift.io":
+			return []byte(testObservabilityForwarders), nil
+		case args[0] == "get" && args[1] == "secret":
+			if args[2] == "splunk-secret" {
+				return nil, errors.New("not found")
+			}
+			return []byte("secret/" + args[2]), nil
+		case args[0] == "get" && args[1] == "nodes":
+			return []byte("worker-1"), nil
+		case args[0] == "debug":
+			script = args[len(args)-1]
+			return []byte(`OSDCTL_LOGFORWARDING 0 400
+OSDCTL_LOGFORWARDING 1 000
+OSDCTL_LOGFORWARDING 2 tcp
+OSDCTL_LOGFORWARDING 3 200
+`), nil
+		case args[0] == "exec" && strings.Contains(args[len(args)-2], "sent_events"):
+			return []byte(`{"status": "success", "data": {"result": [
+				{"metric": {"namespace": "openshift-logging", "component_id": "output_cw"}, "value": [1, "10000"]},
+				{"metric": {"namespace": "openshift-logging", "component_id": "output_splunk_prod"}, "value": [1, "900"]},
+				{"metric": {"namespace": "logging", "component_id": "output_splunk"}, "value": [1, "5000"]}]}}`), nil
+		case args[0] == "exec":
+			return []byte(`{"status": "success", "data": {"result": [
+				{"metric": {"namespace": "openshift-logging", "component_id": "output_cw"}, "value": [1, "5.2"]},
+				{"metric": {"namespace": "openshift-logging", "component_id": "output_splunk_prod"}, "value": [1, "100"]}]}}`), nil
+		}
+		return nil, nil
+	}}
+
+	findings, err := o.checkLogForwarding()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(script).To(ContainSubstring("probe 0 'https://logs.us-east-1.amazonaws.com' 'logs.us-east-1.amazonaws.com' '443'"))
+	g.Expect(script).To(ContainSubstring("probe 2 'tls://syslog.example.com' 'syslog.example.com' '6514'"))
+
+	var statuses []string
+	for _, f := range findings {
+		statuses = append(statuses, f.check+" "+f.status)
+	}
+	g.Expect(statuses).To(Equal([]string{
+		"forwarder openshift-logging/instance OK",
+		"cw (cloudwatch) configuration OK",
+		"splunk-prod (splunk) configuration OK",
+		"syslog (syslog) configuration FAIL",
+		"pipeline all FAIL",
+		"forwarder logging/collector OK",
+		"splunk (splunk) configuration OK",
+		"cw (cloudwatch) secret cw-secret OK",
+		"splunk-prod (splunk) secret splunk-secret FAIL",
+		"splunk (splunk) secret hec-token OK",
+		"cw (cloudwatch) reachability OK",
+		"cw (cloudwatch) dropped logs WARN",
+		"splunk-prod (splunk) reachability FAIL",
+		"splunk-prod (splunk) dropped logs FAIL",
+		"syslog (syslog) reachability OK",
+		"syslog (syslog) dropped logs WARN",
+		"splunk (splunk) reachability OK",
+		"splunk (splunk) dropped logs OK",
+	}))
+	g.Expect(findings[4].message).To(Equal("references the undefined output missing"))
+	g.Expect(findings[13].message).To(Equal("100 dropped, 900 sent in the last 1h0m0s (10.00% dropped)"))
+	g.Expect(findings[15].message).To(Equal("no collector metrics for the output"))
+}
+
+func TestCheckLogForwardingWithoutForwarder(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := &checkLogForwardingOptions{since: time.Hour, runOC: func(args ...string) ([]byte, error) {
+		return nil, errors.New("the server doesn't have a resource type")
+	}}
+	findings, err := o.checkLogForwarding()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].status).To(Equal(dnsCheckWarn))
+}
+
+func TestVectorSinkID(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(vectorSinkID("Splunk-Prod.eu")).To(Equal("output_splunk_prod_eu"))
+}
@@ -0,0 +1,328 @@
+package cluster
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	checkRegistryLongDescription = `
+Checks that the nodes of a cluster can pull from the registries they need
+
+  This command will:
+
+  * Collect the required registries, the mirrors of the ImageContentSourcePolicies and ImageDigestMirrorSets, and
+    the registries of the pull secret
+  * From a node, through 'oc debug', check that every registry answers on HTTPS, using the cluster-wide proxy if any
+  * Check that the credentials of the pull secret are accepted by the registries they are for
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'). A remediation hint
+  is printed for every failed check. Image pull failures commonly lead to limited support.
+`
+	checkRegistryExample = `
+  # Check the registries from a worker node
+  osdctl cluster check-registry 1kfmyclusteristhebesteverp8m
+
+  # Check from a given node, including an additional registry
+  osdctl cluster check-registry 1kfmyclusteristhebesteverp8m --node ip-10-0-1-2.ec2.internal --registry registry.example.com
+`
+
+	pullSecretNamespace = "openshift-config"
+	pullSecretName      = "pull-secret"
+
+	// registryProbePrefix marks the probe results in the output of oc debug
+	registryProbePrefix = "OSDCTL_REGISTRY"
+)
+
+// requiredRegistries are the registries the release and operator images are pulled from
+var requiredRegistries = []string{"quay.io", "registry.redhat.io", "registry.access.redhat.com"}
+
+// authenticatedRegistries refuse anonymous pulls
+var authenticatedRegistries = map[string]bool{"registry.redhat.io": true}
+
+// nonRegistryAuths are pull secret entries that aren't registries, e.g. the telemetry token
+var nonRegistryAuths = map[string]bool{"cloud.openshift.com": true}
+
+type checkRegistryOptions struct {
+	clusterID  string
+	node       string
+	registries []string
+
+	runOC utils.OCRunner
+}
+
+// registryTarget is a registry to probe and why it is needed
+type registryTarget struct {
+	host    string
+	source  string
+	hasAuth bool
+}
+
+// registryProbe is the result of probing a registry from a node
+type registryProbe struct {
+	host string
+	// httpCode is 000 when the registry can't be reached
+	httpCode string
+	// login is valid, invalid or none when the pull secret has no credentials for the registry
+	login string
+}
+
+func newCmdCheckRegistry() *cobra.Command {
+	ops := &checkRegistryOptions{runOC: utils.RunOCAsClusterAdmin}
+	checkRegistryCmd := &cobra.Command{
+		Use:               "check-registry CLUSTER_ID",
+		Short:             "Checks connectivity and authentication to the registries and mirrors from a node",
+		Long:              checkRegistryLongDescription,
+		Example:           checkRegistryExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	checkRegistryCmd.Flags().StringVar(&ops.node, "node", "", "Node to run the probes from, a worker node by default")
+	checkRegistryCmd.Flags().StringSliceVar(&ops.registries, "registry", nil, "Additional registry host to check, can be repeated")
+
+	return checkRegistryCmd
+}
+
+func (o *checkRegistryOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	findings, err := o.checkRegistries()
+	if err != nil {
+		return err
+	}
+	return printFindings("Registry", "registry", findings)
+}
+
+// checkRegistries collects the registries, probes them from a node and evaluates the results
+func (o *checkRegistryOptions) checkRegistries() ([]checkFinding, error) {
+	auths, err := o.pullSecretRegistries()
+	if err != nil {
+		return nil, err
+	}
+	mirrors := o.mirrorRegistries()
+	targets := registryTargets(requiredRegistries, mirrors, auths, o.registries)
+
+	node, err := probeNode(o.runOC, o.node)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"debug", "node/" + node, "--", "chroot", "/host", "sh", "-c", registryProbeScript(targets, clusterProxyEnv(o.runOC))}
+	fmt.Fprintf(os.Stderr, "Probing %d registries from node %s\n", len(targets), node)
+	output, err := o.runOC(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	probes := parseRegistryProbes(string(output))
+	var findings []checkFinding
+	for _, target := range targets {
+		findings = append(findings, evaluateRegistryProbe(target, probes[target.host]))
+	}
+	return findings, nil
+}
+
+// pullSecretRegistries returns the registries the pull secret of the cluster has credentials for
+func (o *checkRegistryOptions) pullSecretRegistries() (map[string]bool, error) {
+	output, err := o.runOC("get", "secret", pullSecretName, "-n", pullSecretNamespace, "-o", `jsonpath={.data.\.dockerconfigjson}`)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode the pull secret: %w", err)
+	}
+	var config struct {
+		Auths map[string]json.RawMessage `json:"auths"`
+	}
+	if err := json.Unmarshal(decoded, &config); err != nil {
+		return nil, fmt.Errorf("cannot parse the pull secret: %w", err)
+	}
+
+	registries := map[string]bool{}
+	for registry := range config.Auths {
+		if host := registryHost(registry); !nonRegistryAuths[host] {
+			registries[host] = true
+		}
+	}
+	return registries, nil
+}
+
+// mirrorRegistries returns the registries of the mirrors configured on the cluster. ImageDigestMirrorSets don't
+// exist on older clusters, failures to list them are ignored.
+func (o *checkRegistryOptions) mirrorRegistries() []string {
+	var mirrors []string
+	for _, query := range [][]string{
+		{"imagecontentsourcepolicies", "jsonpath={.items[*].spec.repositoryDigestMirrors[*].mirrors[*]}"},
+		{"imagedigestmirrorsets", "jsonpath={.items[*].spec.imageDigestMirrors[*].mirrors[*]}"},
+	} {
+		output, err := o.runOC("get", query[0], "-o", query[1])
+		if err != nil {
+			continue
+		}
+		for _, mirror := range strings.Fields(string(output)) {
+			mirrors = append(mirrors, registryHost(mirror))
+		}
+	}
+	return mirrors
+}
+
+// probeNode returns the node to run the probes from, the given one or a worker node
+func probeNode(run utils.OCRunner, node string) (string, error) {
+	if node != "" {
+		return node, nil
+	}
+	output, err := run("get", "nodes", "-l", "node-role.kubernetes.io/worker", "-o", "jsonpath={.items[0].metadata.name}")
+	if err != nil {
+		return "", err
+	}
+	node = strings.TrimSpace(string(output))
+	if node == "" {
+		return "", fmt.Errorf("the cluster has no worker node to run the probes from, use --node")
+	}
+	return node, nil
+}
+
+// clusterProxyEnv returns the proxy variables of the cluster-wide proxy, the nodes pull images through it
+func clusterProxyEnv(run utils.OCRunner) []string {
+	var env []string
+	for _, field := range []struct{ name, jsonpath string }{
+		{"https_proxy", "{.status.httpsProxy}"},
+		{"no_proxy", "{.status.noProxy}"},
+	} {
+		output, err := run("get", "proxy", "cluster", "-o", "jsonpath="+field.jsonpath)
+		if err != nil || strings.TrimSpace(string(output)) == "" {
+			continue
+		}
+		env = append(env, fmt.Sprintf("%s=%s", field.name, strings.TrimSpace(string(output))))
+	}
+	return env
+}
+
+// registryHost returns the host of a registry, mirror or pull secret entry, e.g. quay.io for
+// https://quay.io/openshift-release-dev
+func registryHost(registry string) string {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	return strings.SplitN(registry, "/", 2)[0]
+}
+
+// registryTargets returns the registries to probe, sorted, each once with the first reason it is needed
+func registryTargets(required, mirrors []string, auths map[string]bool, additional []string) []registryTarget {
+	seen := map[string]bool{}
+	var targets []registryTarget
+	add := func(hosts []string, source string) {
+		sorted := append([]string{}, hosts...)
+		sort.Strings(sorted)
+		for _, host := range sorted {
+			host = registryHost(host)
+			if host == "" || seen[host] {
+				continue
+			}
+			seen[host] = true
+			targets = append(targets, registryTarget{host: host, source: source, hasAuth: auths[host]})
+		}
+	}
+	add(required, "required")
+	add(mirrors, "mirror")
+	add(additional, "requested")
+	authRegistries := make([]string, 0, len(auths))
+	for host := range auths {
+		authRegistries = append(authRegistries, host)
+	}
+	add(authRegistries, "pull secret")
+	return targets
+}
+
+// registryProbeScript returns the shell script probing the registries from the node. The credentials are checked
+// with 'podman login' on a copy of the kubelet's pull secret, so that the node's file is never written.
+func registryProbeScript(targets []registryTarget, proxyEnv []string) string {
+	var b strings.Builder
+	for _, env := range proxyEnv {
+		fmt.Fprintf(&b, "export %s\n", shellQuote(env))
+	}
+	b.WriteString(`auth=$(mktemp)
+cp /var/lib/kubelet/config.json "$auth"
+probe() {
+  code=$(curl -s -o /dev/null -w '%{http_code}' --max-time 10 "https://$1/v2/")
+  login=none
+  if [ "$2" = true ]; then
+    if podman login --authfile "$auth" "$1" </dev/null >/dev/null 2>&1; then login=valid; else login=invalid; fi
+  fi
+  echo "` + registryProbePrefix + ` $1 $code $login"
+}
+`)
+	for _, target := range targets {
+		fmt.Fprintf(&b, "probe %s %t\n", shellQuote(target.host), target.hasAuth)
+	}
+	b.WriteString(`rm -f "$auth"` + "\n")
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// parseRegistryProbes returns the probe results by registry host
+func parseRegistryProbes(output string) map[string]registryProbe {
+	probes := map[string]registryProbe{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != registryProbePrefix {
+			continue
+		}
+		probes[fields[1]] = registryProbe{host: fields[1], httpCode: fields[2], login: fields[3]}
+	}
+	return probes
+}
+
+func evaluateRegistryProbe(target registryTarget, probe registryProbe) checkFinding {
+	check := fmt.Sprintf("%s (%s)", target.host, target.source)
+	if probe.host == "" {
+		return checkFinding{check: check, status: dnsCheckWarn, message: "not probed, the node gave no result",
+			hint: "check that 'oc debug node' works on the cluster, curl and podman are needed on the node"}
+	}
+	if probe.httpCode == "000" {
+		return checkFinding{check: check, status: dnsCheckFail, message: "unreachable from the node",
+			hint: fmt.Sprintf("allow HTTPS to %s through the firewall and the cluster-wide proxy, see 'osdctl network verify-egress'", target.host)}
+	}
+	// The v2 endpoint answers 401 to anonymous requests on most registries, any HTTP answer means it is reachable
+	reachable := fmt.Sprintf("reachable (HTTP %s)", probe.httpCode)
+	switch probe.login {
+	case "valid":
+		return checkFinding{check: check, status: dnsCheckOK, message: reachable + ", credentials accepted"}
+	case "invalid":
+		return checkFinding{check: check, status: dnsCheckFail, message: reachable + ", credentials rejected",
+			hint: fmt.Sprintf("the customer needs to refresh the %s credentials in %s/%s", target.host, pullSecretNamespace, pullSecretName)}
+	}
+	if authenticatedRegistries[target.host] {
+		return checkFinding{check: check, status: dnsCheckFail, message: reachable + ", no credentials in the pull secret",
+			hint: fmt.Sprintf("%s refuses anonymous pulls, the pull secret needs credentials for it", target.host)}
+	}
+	return checkFinding{check: check, status: dnsCheckOK, message: reachable + ", anonymous"}
+}
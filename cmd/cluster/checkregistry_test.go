@@ -0,0 +1,100 @@
+package cluster
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRegistryTargets(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	targets := registryTargets(requiredRegistries,
+		[]string{"mirror.example.com:5000", "quay.io"},
+		map[string]bool{"quay.io": true, "registry.redhat.io": true, "private.example.com": true},
+		[]string{"https://extra.example.com/team"})
+
+	var hosts []string
+	for _, target := range targets {
+		hosts = append(hosts, target.host+" "+target.source)
+	}
+	g.Expect(hosts).To(Equal([]string{
+		"quay.io required",
+		"registry.access.redhat.com required",
+		"registry.redhat.io required",
+		"mirror.example.com:5000 mirror",
+		"extra.example.com requested",
+		"private.example.com pull secret",
+	}))
+	g.Expect(targets[0].hasAuth).To(BeTrue())
+	g.Expect(targets[1].hasAuth).To(BeFalse())
+}
+
+func TestEvaluateRegistryProbe(t *testing.T) {
+	g := NewGomegaWithT(t)
+	target := func(host string) registryTarget { return registryTarget{host: host, source: "required"} }
+
+	g.Expect(evaluateRegistryProbe(target("quay.io"), registryProbe{host: "quay.io", httpCode: "401", login: "valid"}).status).To(Equal(dnsCheckOK))
+	g.Expect(evaluateRegistryProbe(target("registry.access.redhat.com"), registryProbe{host: "registry.access.redhat.com", httpCode: "200", login: "none"}).status).To(Equal(dnsCheckOK))
+	g.Expect(evaluateRegistryProbe(target("quay.io"), registryProbe{}).status).To(Equal(dnsCheckWarn))
+
+	unreachable := evaluateRegistryProbe(target("quay.io"), registryProbe{host: "quay.io", httpCode: "000", login: "invalid"})
+	g.Expect(unreachable.status).To(Equal(dnsCheckFail))
+	g.Expect(unreachable.message).To(ContainSubstring("unreachable"))
+
+	rejected := evaluateRegistryProbe(target("quay.io"), registryProbe{host: "quay.io", httpCode: "401", login: "invalid"})
+	g.Expect(rejected.status).To(Equal(dnsCheckFail))
+	g.Expect(rejected.hint).To(ContainSubstring("openshift-config/pull-secret"))
+
+	// registry.redhat.io doesn't allow anonymous pulls
+	g.Expect(evaluateRegistryProbe(target("registry.redhat.io"), registryProbe{host: "registry.redhat.io", httpCode: "401", login: "none"}).status).To(Equal(dnsCheckFail))
+}
+
+func TestCheckRegistries(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pullSecret := base64.StdEncoding.EncodeToString([]byte(`{"auths": {"quay.io": {}, "registry.redhat.io": {}, "cloud.openshift.com": {}}}`))
+	var script string
+	o := &checkRegistryOptions{runOC: func(args ...string) ([]byte, error) {
+		switch {
+		case args[0] == "get" && args[1] == "secret":
+			return []byte(pullSecret), nil
+		case args[0] == "get" && args[1] == "imagecontentsourcepolicies":
+			return []byte("mirror.example.com/ocp/release"), nil
+		case args[0] == "get" && args[1] == "proxy" && strings.Contains(args[4], "httpsProxy"):
+			return []byte("http://proxy.example.com:3128"), nil
+		case args[0] == "get" && args[1] == "nodes":
+			return []byte("worker-1"), nil
+		case args[0] == "debug":
+			g.Expect(args[1]).To(Equal("node/worker-1"))
+			script = args[len(args)-1]
+			return []byte(`Starting pod/worker-1-debug ...
+OSDCTL_REGISTRY quay.io 401 valid
+OSDCTL_REGISTRY registry.access.redhat.com 200 none
+OSDCTL_REGISTRY registry.redhat.io 401 invalid
+OSDCTL_REGISTRY mirror.example.com 000 none
+`), nil
+		}
+		return nil, nil
+	}}
+
+	findings, err := o.checkRegistries()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(script).To(ContainSubstring("export 'https_proxy=http://proxy.example.com:3128'"))
+	g.Expect(script).To(ContainSubstring("probe 'registry.redhat.io' true"))
+	g.Expect(script).To(ContainSubstring("probe 'mirror.example.com' false"))
+	g.Expect(script).NotTo(ContainSubstring("cloud.openshift.com"))
+
+	var statuses []string
+	for _, f := range findings {
+		statuses = append(statuses, f.check+" "+f.status)
+	}
+	g.Expect(statuses).To(Equal([]string{
+		"quay.io (required) OK",
+		"registry.access.redhat.com (required) OK",
+		"registry.redhat.io (required) FAIL",
+		"mirror.example.com (mirror) FAIL",
+	}))
+}
@@ -0,0 +1,458 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	checkRegistryStorageLongDescription = `
+Checks the storage of the internal image registry
+
+  This command will:
+
+  * Report the conditions of the image-registry cluster operator and the storage conditions of its config
+  * On AWS, check that the S3 bucket of the registry exists and can be accessed, that its public access is blocked,
+    that it is encrypted as configured, that its policy doesn't deny the registry, and report its size
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'). A remediation hint
+  is printed for every failed check. A bucket deleted by the customer leaves the image-registry operator degraded.
+`
+	checkRegistryStorageExample = `
+  # Check the registry storage of a cluster
+  osdctl cluster check-registry-storage 1kfmyclusteristhebesteverp8m
+
+  # Skip counting the objects of a large bucket
+  osdctl cluster check-registry-storage 1kfmyclusteristhebesteverp8m --max-objects 0
+`
+
+	imageRegistryConfig   = "configs.imageregistry.operator.openshift.io/cluster"
+	imageRegistryOperator = "clusteroperator/image-registry"
+
+	// removeRegistryStorage has the operator configure, and create, new storage for the registry
+	removeRegistryStorage = `oc patch ` + imageRegistryConfig + ` --type json -p '[{"op": "remove", "path": "/spec/storage"}]'`
+)
+
+type checkRegistryStorageOptions struct {
+	clusterID  string
+	awsProfile string
+	maxObjects int
+
+	runOC utils.OCRunner
+}
+
+// registryConfig is the part of the image registry operator config the checks need
+type registryConfig struct {
+	Spec struct {
+		ManagementState string          `json:"managementState"`
+		Storage         registryStorage `json:"storage"`
+	} `json:"spec"`
+	Status struct {
+		StorageManaged bool                `json:"storageManaged"`
+		Storage        registryStorage     `json:"storage"`
+		Conditions     []registryCondition `json:"conditions"`
+	} `json:"status"`
+}
+
+type registryStorage struct {
+	S3  *registryS3Storage `json:"s3,omitempty"`
+	GCS *struct {
+		Bucket string `json:"bucket"`
+	} `json:"gcs,omitempty"`
+	PVC *struct {
+		Claim string `json:"claim"`
+	} `json:"pvc,omitempty"`
+	EmptyDir *struct{} `json:"emptyDir,omitempty"`
+}
+
+type registryS3Storage struct {
+	Bucket  string `json:"bucket"`
+	Region  string `json:"region"`
+	Encrypt bool   `json:"encrypt"`
+	KeyID   string `json:"keyID"`
+}
+
+type registryCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+func newCmdCheckRegistryStorage() *cobra.Command {
+	ops := &checkRegistryStorageOptions{runOC: utils.RunOCAsClusterAdmin}
+	checkRegistryStorageCmd := &cobra.Command{
+		Use:               "check-registry-storage CLUSTER_ID",
+		Short:             "Checks the image registry operator and its S3 bucket",
+		Long:              checkRegistryStorageLongDescription,
+		Example:           checkRegistryStorageExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	checkRegistryStorageCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile name")
+	checkRegistryStorageCmd.Flags().IntVar(&ops.maxObjects, "max-objects", 100000, "Number of objects of the bucket to count at most to report its size, 0 skips it")
+
+	return checkRegistryStorageCmd
+}
+
+func (o *checkRegistryStorageOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	findings := o.checkOperator()
+	config, err := o.registryConfig()
+	if err != nil {
+		return err
+	}
+	findings = append(findings, checkRegistryConfig(config)...)
+
+	if config.Status.Storage.S3 != nil && cluster.CloudProvider().ID() == "aws" {
+		awsClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+		if err != nil {
+			return err
+		}
+		findings = append(findings, checkRegistryBucket(awsClient, config, o.maxObjects)...)
+	}
+	return printFindings("Check", "registry storage", findings)
+}
+
+// checkOperator reports the conditions of the image-registry cluster operator
+func (o *checkRegistryStorageOptions) checkOperator() []checkFinding {
+	output, err := o.runOC("get", imageRegistryOperator, "-o", "jsonpath={.status.conditions}")
+	if err != nil {
+		return []checkFinding{{check: "Operator", status: dnsCheckFail, message: fmt.Sprintf("cannot get the cluster operator: %v", err)}}
+	}
+	var conditions []registryCondition
+	if err := json.Unmarshal([]byte(strings.TrimSpace(string(output))), &conditions); err != nil {
+		return []checkFinding{{check: "Operator", status: dnsCheckFail, message: fmt.Sprintf("cannot parse the conditions of the cluster operator: %v", err)}}
+	}
+	return []checkFinding{evaluateOperatorConditions(conditions)}
+}
+
+func evaluateOperatorConditions(conditions []registryCondition) checkFinding {
+	byType := map[string]registryCondition{}
+	for _, condition := range conditions {
+		byType[condition.Type] = condition
+	}
+	describe := func(condition registryCondition) string {
+		if condition.Message == "" {
+			return condition.Reason
+		}
+		return fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+	}
+	if degraded := byType["Degraded"]; degraded.Status == "True" {
+		return checkFinding{check: "Operator", status: dnsCheckFail, message: "degraded, " + describe(degraded),
+			hint: "see the storage checks below, 'oc logs -n openshift-image-registry deployment/cluster-image-registry-operator' has the details"}
+	}
+	if available := byType["Available"]; available.Status != "True" {
+		return checkFinding{check: "Operator", status: dnsCheckFail, message: "not available, " + describe(available),
+			hint: "check the image-registry pods in openshift-image-registry"}
+	}
+	if progressing := byType["Progressing"]; progressing.Status == "True" {
+		return checkFinding{check: "Operator", status: dnsCheckWarn, message: "progressing, " + describe(progressing)}
+	}
+	return checkFinding{check: "Operator", status: dnsCheckOK, message: "available"}
+}
+
+func (o *checkRegistryStorageOptions) registryConfig() (*registryConfig, error) {
+	output, err := o.runOC("get", imageRegistryConfig, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var config registryConfig
+	if err := json.Unmarshal(output, &config); err != nil {
+		return nil, fmt.Errorf("cannot parse the image registry config: %w", err)
+	}
+	return &config, nil
+}
+
+// checkRegistryConfig reports the management state, the storage type and the storage conditions of the registry
+func checkRegistryConfig(config *registryConfig) []checkFinding {
+	var findings []checkFinding
+	switch state := config.Spec.ManagementState; state {
+	case "Managed", "":
+	case "Removed":
+		return append(findings, checkFinding{check: "Management state", status: dnsCheckWarn, message: "the registry is removed",
+			hint: "builds and image streams referencing the internal registry don't work without it"})
+	default:
+		findings = append(findings, checkFinding{check: "Management state", status: dnsCheckWarn, message: fmt.Sprintf("%s, the operator doesn't reconcile the registry", state)})
+	}
+
+	storage := config.Status.Storage
+	switch {
+	case storage.S3 != nil:
+		managed := "managed by the operator"
+		if !config.Status.StorageManaged {
+			managed = "provided by the customer"
+		}
+		findings = append(findings, checkFinding{check: "Storage", status: dnsCheckOK, message: fmt.Sprintf("S3 bucket %s in %s, %s", storage.S3.Bucket, storage.S3.Region, managed)})
+	case storage.GCS != nil:
+		findings = append(findings, checkFinding{check: "Storage", status: dnsCheckOK, message: fmt.Sprintf("GCS bucket %s", storage.GCS.Bucket)})
+	case storage.PVC != nil:
+		findings = append(findings, checkFinding{check: "Storage", status: dnsCheckOK, message: fmt.Sprintf("persistent volume claim %s", storage.PVC.Claim)})
+	case storage.EmptyDir != nil:
+		findings = append(findings, checkFinding{check: "Storage", status: dnsCheckWarn, message: "emptyDir, the images are lost when the registry pods restart"})
+	default:
+		findings = append(findings, checkFinding{check: "Storage", status: dnsCheckFail, message: "no storage is configured",
+			hint: "the operator configures the storage when it's removed from the config, the customer may have changed it"})
+	}
+
+	for _, condition := range config.Status.Conditions {
+		if !strings.HasPrefix(condition.Type, "Storage") || condition.Status == "True" {
+			continue
+		}
+		finding := checkFinding{check: condition.Type, status: dnsCheckWarn, message: strings.TrimSpace(condition.Reason + " " + condition.Message)}
+		if condition.Type == "StorageExists" {
+			finding.status = dnsCheckFail
+			finding.hint = "the storage was deleted, the operator creates a new one when it's removed from the config: " + removeRegistryStorage
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// checkRegistryBucket checks the S3 bucket of the registry. Only its existence and access matter when it can't be
+// read, the other checks are skipped.
+func checkRegistryBucket(awsClient aws.Client, config *registryConfig, maxObjects int) []checkFinding {
+	storage := config.Status.Storage.S3
+	bucket := awsSdk.String(storage.Bucket)
+	check := "Bucket " + storage.Bucket
+
+	if _, err := awsClient.HeadBucket(&s3.HeadBucketInput{Bucket: bucket}); err != nil {
+		switch s3StatusCode(err) {
+		case http.StatusNotFound:
+			hint := "the bucket was deleted, the operator creates a new one when the storage is removed from the config: " + removeRegistryStorage +
+				". The images that were pushed to the registry are lost"
+			if !config.Status.StorageManaged {
+				hint = "the bucket was provided by the customer, who needs to recreate it or configure another storage"
+			}
+			return []checkFinding{{check: check, status: dnsCheckFail, message: "does not exist", hint: hint}}
+		case http.StatusForbidden:
+			return []checkFinding{{check: check, status: dnsCheckFail, message: "access denied",
+				hint: "check the bucket policy and the service control policies of the account, they mustn't deny the registry or osdctl"}}
+		}
+		return []checkFinding{{check: check, status: dnsCheckFail, message: fmt.Sprintf("cannot be reached: %v", err)}}
+	}
+
+	findings := []checkFinding{{check: check, status: dnsCheckOK, message: "exists and is accessible"}}
+	findings = append(findings, checkBucketPublicAccess(awsClient, bucket))
+	findings = append(findings, checkBucketEncryption(awsClient, bucket, storage.Encrypt, storage.KeyID))
+	findings = append(findings, checkBucketPolicy(awsClient, bucket))
+	if maxObjects > 0 {
+		findings = append(findings, bucketSize(awsClient, bucket, maxObjects))
+	}
+	return findings
+}
+
+// s3StatusCode returns the HTTP status of a failed S3 request, the requests without a body like HeadBucket have no
+// error code
+func s3StatusCode(err error) int {
+	if failure, ok := err.(awserr.RequestFailure); ok {
+		return failure.StatusCode()
+	}
+	return 0
+}
+
+func s3ErrorCode(err error) string {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+	return ""
+}
+
+func checkBucketPublicAccess(awsClient aws.Client, bucket *string) checkFinding {
+	const check = "Public access block"
+	const hint = "the operator blocks all public access to the bucket it creates, a public registry bucket exposes the images"
+	output, err := awsClient.GetPublicAccessBlock(&s3.GetPublicAccessBlockInput{Bucket: bucket})
+	if err != nil {
+		if s3ErrorCode(err) == "NoSuchPublicAccessBlockConfiguration" {
+			return checkFinding{check: check, status: dnsCheckWarn, message: "not configured", hint: hint}
+		}
+		return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot be read: %v", err)}
+	}
+	block := output.PublicAccessBlockConfiguration
+	var missing []string
+	for _, setting := range []struct {
+		name  string
+		value *bool
+	}{
+		{"BlockPublicAcls", block.BlockPublicAcls},
+		{"BlockPublicPolicy", block.BlockPublicPolicy},
+		{"IgnorePublicAcls", block.IgnorePublicAcls},
+		{"RestrictPublicBuckets", block.RestrictPublicBuckets},
+	} {
+		if !awsSdk.BoolValue(setting.value) {
+			missing = append(missing, setting.name)
+		}
+	}
+	if len(missing) > 0 {
+		return checkFinding{check: check, status: dnsCheckWarn, message: "not set: " + strings.Join(missing, ", "), hint: hint}
+	}
+	return checkFinding{check: check, status: dnsCheckOK, message: "all public access is blocked"}
+}
+
+// checkBucketEncryption checks the default encryption of the bucket, and that it uses the KMS key of the config if any
+func checkBucketEncryption(awsClient aws.Client, bucket *string, encrypt bool, keyID string) checkFinding {
+	const check = "Encryption"
+	output, err := awsClient.GetBucketEncryption(&s3.GetBucketEncryptionInput{Bucket: bucket})
+	if err != nil {
+		if s3ErrorCode(err) == "ServerSideEncryptionConfigurationNotFoundError" {
+			status := dnsCheckWarn
+			if encrypt {
+				status = dnsCheckFail
+			}
+			return checkFinding{check: check, status: status, message: "no default encryption",
+				hint: "the registry config asks for an encrypted bucket, the operator sets its encryption when it's reconciled"}
+		}
+		return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot be read: %v", err)}
+	}
+	var algorithms []string
+	for _, rule := range output.ServerSideEncryptionConfiguration.Rules {
+		defaults := rule.ApplyServerSideEncryptionByDefault
+		if defaults == nil {
+			continue
+		}
+		algorithm, bucketKey := awsSdk.StringValue(defaults.SSEAlgorithm), awsSdk.StringValue(defaults.KMSMasterKeyID)
+		if keyID != "" && !sameKMSKey(keyID, bucketKey) {
+			return checkFinding{check: check, status: dnsCheckFail, message: fmt.Sprintf("%s with key %s, the registry config has %s", algorithm, bucketKey, keyID),
+				hint: "the customer changed the encryption key of the bucket, the registry can't read the images without access to it"}
+		}
+		algorithms = append(algorithms, algorithm)
+	}
+	if len(algorithms) == 0 {
+		return checkFinding{check: check, status: dnsCheckWarn, message: "no default encryption rule"}
+	}
+	return checkFinding{check: check, status: dnsCheckOK, message: strings.Join(algorithms, ", ")}
+}
+
+// sameKMSKey compares KMS keys given as IDs or ARNs, e.g. 1234abcd-... and arn:aws:kms:us-east-1:111122223333:key/1234abcd-...
+func sameKMSKey(a, b string) bool {
+	return a == b || strings.HasSuffix(a, "/"+b) || strings.HasSuffix(b, "/"+a)
+}
+
+// bucketPolicyDocument is the part of an S3 bucket policy the check needs. The fields are strings or lists.
+type bucketPolicyDocument struct {
+	Statement []struct {
+		Sid       string          `json:"Sid"`
+		Effect    string          `json:"Effect"`
+		Principal json.RawMessage `json:"Principal"`
+		Action    json.RawMessage `json:"Action"`
+		Condition json.RawMessage `json:"Condition"`
+	} `json:"Statement"`
+}
+
+// checkBucketPolicy flags the statements that deny access whatever the request, which also locks out the registry,
+// and the ones granting access to anyone
+func checkBucketPolicy(awsClient aws.Client, bucket *string) checkFinding {
+	const check = "Bucket policy"
+	output, err := awsClient.GetBucketPolicy(&s3.GetBucketPolicyInput{Bucket: bucket})
+	if err != nil {
+		if s3ErrorCode(err) == "NoSuchBucketPolicy" {
+			return checkFinding{check: check, status: dnsCheckOK, message: "none"}
+		}
+		return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot be read: %v", err)}
+	}
+	return evaluateBucketPolicy(awsSdk.StringValue(output.Policy))
+}
+
+func evaluateBucketPolicy(policy string) checkFinding {
+	const check = "Bucket policy"
+	var document bucketPolicyDocument
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot be parsed: %v", err)}
+	}
+	conditional := func(condition json.RawMessage) bool {
+		trimmed := strings.TrimSpace(string(condition))
+		return trimmed != "" && trimmed != "null" && trimmed != "{}"
+	}
+	anyone := func(principal json.RawMessage) bool {
+		trimmed := strings.ReplaceAll(string(principal), " ", "")
+		return trimmed == `"*"` || strings.Contains(trimmed, `"AWS":"*"`) || strings.Contains(trimmed, `"AWS":["*"]`)
+	}
+	name := func(i int) string {
+		if document.Statement[i].Sid != "" {
+			return document.Statement[i].Sid
+		}
+		return fmt.Sprintf("#%d", i+1)
+	}
+
+	var denies, public []string
+	for i, statement := range document.Statement {
+		if conditional(statement.Condition) {
+			continue
+		}
+		switch statement.Effect {
+		case "Deny":
+			denies = append(denies, name(i))
+		case "Allow":
+			if anyone(statement.Principal) {
+				public = append(public, name(i))
+			}
+		}
+	}
+	if len(denies) > 0 {
+		return checkFinding{check: check, status: dnsCheckFail, message: "unconditional Deny statements: " + strings.Join(denies, ", "),
+			hint: "these statements deny the registry too, the customer needs to scope them or remove them"}
+	}
+	if len(public) > 0 {
+		return checkFinding{check: check, status: dnsCheckWarn, message: "grants access to anyone: " + strings.Join(public, ", "),
+			hint: "the registry bucket needn't be public, the customer should remove these statements"}
+	}
+	return checkFinding{check: check, status: dnsCheckOK, message: fmt.Sprintf("%d statements, none denies the registry", len(document.Statement))}
+}
+
+// bucketSize counts the objects and bytes of the bucket, up to maxObjects objects
+func bucketSize(awsClient aws.Client, bucket *string, maxObjects int) checkFinding {
+	const check = "Size"
+	var objects int
+	var size int64
+	input := &s3.ListObjectsInput{Bucket: bucket}
+	for {
+		output, err := awsClient.ListObjects(input)
+		if err != nil {
+			return checkFinding{check: check, status: dnsCheckWarn, message: fmt.Sprintf("cannot list the objects: %v", err)}
+		}
+		for _, object := range output.Contents {
+			objects++
+			size += awsSdk.Int64Value(object.Size)
+		}
+		if !awsSdk.BoolValue(output.IsTruncated) || len(output.Contents) == 0 {
+			break
+		}
+		if objects >= maxObjects {
+			fmt.Fprintf(os.Stderr, "Stopped counting the objects of %s after %d, see --max-objects\n", awsSdk.StringValue(bucket), objects)
+			return checkFinding{check: check, status: dnsCheckOK, message: fmt.Sprintf("more than %d objects, more than %s", objects, formatBytes(size))}
+		}
+		input.Marker = output.Contents[len(output.Contents)-1].Key
+	}
+	return checkFinding{check: check, status: dnsCheckOK, message: fmt.Sprintf("%d objects, %s", objects, formatBytes(size))}
+}
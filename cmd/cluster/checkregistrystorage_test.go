@@ -0,0 +1,154 @@
+package cluster
+
+import (
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func s3RegistryConfig(storageManaged bool) *registryConfig {
+	config := &registryConfig{}
+	config.Spec.ManagementState = "Managed"
+	config.Status.StorageManaged = storageManaged
+	config.Status.Storage.S3 = &registryS3Storage{Bucket: "mycluster-image-registry", Region: "us-east-1", Encrypt: true}
+	return config
+}
+
+func TestEvaluateOperatorConditions(t *testing.T) {
+	g := NewGomegaWithT(t)
+	condition := func(conditionType, status string) registryCondition {
+		return registryCondition{Type: conditionType, Status: status, Reason: "Reason", Message: "message"}
+	}
+
+	g.Expect(evaluateOperatorConditions([]registryCondition{condition("Available", "True"), condition("Degraded", "False")}).status).To(Equal(dnsCheckOK))
+	g.Expect(evaluateOperatorConditions([]registryCondition{condition("Available", "True"), condition("Progressing", "True")}).status).To(Equal(dnsCheckWarn))
+	g.Expect(evaluateOperatorConditions([]registryCondition{condition("Available", "False")}).status).To(Equal(dnsCheckFail))
+
+	finding := evaluateOperatorConditions([]registryCondition{
+		condition("Available", "True"),
+		{Type: "Degraded", Status: "True", Reason: "StorageNotConfigured", Message: "NoSuchBucket: The specified bucket does not exist"},
+	})
+	g.Expect(finding.status).To(Equal(dnsCheckFail))
+	g.Expect(finding.message).To(ContainSubstring("NoSuchBucket"))
+}
+
+func TestCheckRegistryConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	config := s3RegistryConfig(true)
+	config.Status.Conditions = []registryCondition{
+		{Type: "StorageExists", Status: "False", Reason: "S3BucketDoesNotExist", Message: "The bucket does not exist"},
+		{Type: "StorageEncrypted", Status: "True"},
+		{Type: "Available", Status: "False"},
+	}
+	findings := checkRegistryConfig(config)
+	g.Expect(findings).To(HaveLen(2))
+	g.Expect(findings[0].status).To(Equal(dnsCheckOK))
+	g.Expect(findings[0].message).To(ContainSubstring("mycluster-image-registry"))
+	g.Expect(findings[1].check).To(Equal("StorageExists"))
+	g.Expect(findings[1].status).To(Equal(dnsCheckFail))
+	g.Expect(findings[1].hint).To(ContainSubstring(removeRegistryStorage))
+
+	removed := &registryConfig{}
+	removed.Spec.ManagementState = "Removed"
+	findings = checkRegistryConfig(removed)
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].status).To(Equal(dnsCheckWarn))
+
+	g.Expect(checkRegistryConfig(&registryConfig{})[0].status).To(Equal(dnsCheckFail))
+}
+
+func TestCheckRegistryBucketDeleted(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+	notFound := awserr.NewRequestFailure(awserr.New("NotFound", "Not Found", nil), 404, "request")
+
+	mockAWSClient.EXPECT().HeadBucket(gomock.Any()).Return(nil, notFound).Times(2)
+
+	findings := checkRegistryBucket(mockAWSClient, s3RegistryConfig(true), 1000)
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].status).To(Equal(dnsCheckFail))
+	g.Expect(findings[0].message).To(Equal("does not exist"))
+	g.Expect(findings[0].hint).To(ContainSubstring(removeRegistryStorage))
+
+	// The operator doesn't recreate the buckets it didn't create
+	findings = checkRegistryBucket(mockAWSClient, s3RegistryConfig(false), 1000)
+	g.Expect(findings[0].hint).To(ContainSubstring("provided by the customer"))
+}
+
+func TestCheckRegistryBucket(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+	config := s3RegistryConfig(true)
+	config.Status.Storage.S3.KeyID = "1234abcd"
+
+	mockAWSClient.EXPECT().HeadBucket(&s3.HeadBucketInput{Bucket: awsSdk.String("mycluster-image-registry")}).Return(&s3.HeadBucketOutput{}, nil)
+	mockAWSClient.EXPECT().GetPublicAccessBlock(gomock.Any()).Return(&s3.GetPublicAccessBlockOutput{
+		PublicAccessBlockConfiguration: &s3.PublicAccessBlockConfiguration{
+			BlockPublicAcls:       awsSdk.Bool(true),
+			BlockPublicPolicy:     awsSdk.Bool(true),
+			IgnorePublicAcls:      awsSdk.Bool(true),
+			RestrictPublicBuckets: awsSdk.Bool(false),
+		},
+	}, nil)
+	mockAWSClient.EXPECT().GetBucketEncryption(gomock.Any()).Return(&s3.GetBucketEncryptionOutput{
+		ServerSideEncryptionConfiguration: &s3.ServerSideEncryptionConfiguration{
+			Rules: []*s3.ServerSideEncryptionRule{{ApplyServerSideEncryptionByDefault: &s3.ServerSideEncryptionByDefault{
+				SSEAlgorithm:   awsSdk.String("aws:kms"),
+				KMSMasterKeyID: awsSdk.String("arn:aws:kms:us-east-1:111122223333:key/1234abcd"),
+			}}},
+		},
+	}, nil)
+	mockAWSClient.EXPECT().GetBucketPolicy(gomock.Any()).Return(nil, awserr.New("NoSuchBucketPolicy", "The bucket policy does not exist", nil))
+	gomock.InOrder(
+		mockAWSClient.EXPECT().ListObjects(&s3.ListObjectsInput{Bucket: awsSdk.String("mycluster-image-registry")}).Return(&s3.ListObjectsOutput{
+			Contents:    []*s3.Object{{Key: awsSdk.String("a"), Size: awsSdk.Int64(1024)}, {Key: awsSdk.String("b"), Size: awsSdk.Int64(1024)}},
+			IsTruncated: awsSdk.Bool(true),
+		}, nil),
+		mockAWSClient.EXPECT().ListObjects(&s3.ListObjectsInput{Bucket: awsSdk.String("mycluster-image-registry"), Marker: awsSdk.String("b")}).Return(&s3.ListObjectsOutput{
+			Contents: []*s3.Object{{Key: awsSdk.String("c"), Size: awsSdk.Int64(1024)}},
+		}, nil),
+	)
+
+	findings := checkRegistryBucket(mockAWSClient, config, 1000)
+	g.Expect(findings).To(HaveLen(5))
+	g.Expect(findings[0].status).To(Equal(dnsCheckOK))
+	g.Expect(findings[1].status).To(Equal(dnsCheckWarn))
+	g.Expect(findings[1].message).To(Equal("not set: RestrictPublicBuckets"))
+	g.Expect(findings[2].status).To(Equal(dnsCheckOK))
+	g.Expect(findings[2].message).To(Equal("aws:kms"))
+	g.Expect(findings[3].status).To(Equal(dnsCheckOK))
+	g.Expect(findings[4].message).To(Equal("3 objects, 3.0 KiB"))
+}
+
+func TestEvaluateBucketPolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	secureTransport := `{"Statement": [{"Sid": "TLS", "Effect": "Deny", "Principal": "*", "Action": "s3:*",
+		"Condition": {"Bool": {"aws:SecureTransport": "false"}}}]}`
+	g.Expect(evaluateBucketPolicy(secureTransport).status).To(Equal(dnsCheckOK))
+
+	finding := evaluateBucketPolicy(`{"Statement": [{"Sid": "Lockdown", "Effect": "Deny", "Principal": "*", "Action": "s3:*"}]}`)
+	g.Expect(finding.status).To(Equal(dnsCheckFail))
+	g.Expect(finding.message).To(ContainSubstring("Lockdown"))
+
+	finding = evaluateBucketPolicy(`{"Statement": [{"Effect": "Allow", "Principal": {"AWS": "*"}, "Action": "s3:GetObject"}]}`)
+	g.Expect(finding.status).To(Equal(dnsCheckWarn))
+	g.Expect(finding.message).To(ContainSubstring("#1"))
+
+	g.Expect(evaluateBucketPolicy(`not a policy`).status).To(Equal(dnsCheckWarn))
+}
+
+func TestSameKMSKey(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(sameKMSKey("1234abcd", "1234abcd")).To(BeTrue())
+	g.Expect(sameKMSKey("1234abcd", "arn:aws:kms:us-east-1:111122223333:key/1234abcd")).To(BeTrue())
+	g.Expect(sameKMSKey("arn:aws:kms:us-east-1:111122223333:key/1234abcd", "1234abcd")).To(BeTrue())
+	g.Expect(sameKMSKey("1234abcd", "arn:aws:kms:us-east-1:111122223333:key/5678efgh")).To(BeFalse())
+}
@@ -0,0 +1,287 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	checkWebhooksLongDescription = `
+Checks the validating and mutating admission webhooks of a cluster which point at customer services
+
+  This command will:
+
+  * List the webhook configurations, skipping the webhooks served from the platform namespaces
+  * Check that the service of every webhook has ready endpoints, or that the host of its URL resolves
+  * Tell which requests a broken webhook blocks, from its failure policy, rules and namespace selector
+
+  A webhook with the Fail policy which can't be reached rejects the requests it intercepts: on pods it blocks
+  node drains, and with them upgrades, a frequent reason to put a cluster in limited support.
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID').
+`
+	checkWebhooksExample = `
+  # Check the customer webhooks of a cluster
+  osdctl cluster check-webhooks 1kfmyclusteristhebesteverp8m
+`
+)
+
+// platformNamespacePrefixes are the namespaces of the webhooks shipped with the cluster, they are left to the operators
+var platformNamespacePrefixes = []string{"openshift-", "kube-"}
+
+type checkWebhooksOptions struct {
+	clusterID string
+
+	runOC  utils.OCRunner
+	lookup func(host string) ([]string, error)
+}
+
+// webhook is a validating or mutating webhook, as the checks care about the fields both kinds share
+type webhook struct {
+	kind          string
+	configuration string
+	name          string
+	clientConfig  admissionregistrationv1.WebhookClientConfig
+	rules         []admissionregistrationv1.RuleWithOperations
+	failurePolicy admissionregistrationv1.FailurePolicyType
+	// namespaces is true when the webhook intercepts the requests of every namespace
+	namespaces bool
+	timeout    int32
+}
+
+func newCmdCheckWebhooks() *cobra.Command {
+	ops := &checkWebhooksOptions{runOC: utils.RunOCAsClusterAdmin, lookup: lookupHost}
+	checkWebhooksCmd := &cobra.Command{
+		Use:               "check-webhooks CLUSTER_ID",
+		Short:             "Checks that the admission webhooks pointing at customer services are reachable",
+		Long:              checkWebhooksLongDescription,
+		Example:           checkWebhooksExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	return checkWebhooksCmd
+}
+
+func (o *checkWebhooksOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	webhooks, err := o.listWebhooks()
+	if err != nil {
+		return err
+	}
+	return printFindings("Webhook", "webhook", o.checkWebhooks(webhooks))
+}
+
+// listWebhooks returns the validating then mutating webhooks, with the API defaults of the fields left empty
+func (o *checkWebhooksOptions) listWebhooks() ([]webhook, error) {
+	var webhooks []webhook
+
+	output, err := o.runOC("get", "validatingwebhookconfigurations", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var validating admissionregistrationv1.ValidatingWebhookConfigurationList
+	if err := json.Unmarshal(output, &validating); err != nil {
+		return nil, fmt.Errorf("cannot parse the validating webhook configurations: %w", err)
+	}
+	for _, configuration := range validating.Items {
+		for _, w := range configuration.Webhooks {
+			webhooks = append(webhooks, newWebhook("validating", configuration.Name, w.Name, w.ClientConfig, w.Rules, w.FailurePolicy, w.NamespaceSelector, w.TimeoutSeconds))
+		}
+	}
+
+	output, err = o.runOC("get", "mutatingwebhookconfigurations", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var mutating admissionregistrationv1.MutatingWebhookConfigurationList
+	if err := json.Unmarshal(output, &mutating); err != nil {
+		return nil, fmt.Errorf("cannot parse the mutating webhook configurations: %w", err)
+	}
+	for _, configuration := range mutating.Items {
+		for _, w := range configuration.Webhooks {
+			webhooks = append(webhooks, newWebhook("mutating", configuration.Name, w.Name, w.ClientConfig, w.Rules, w.FailurePolicy, w.NamespaceSelector, w.TimeoutSeconds))
+		}
+	}
+	return webhooks, nil
+}
+
+func newWebhook(kind, configuration, name string, clientConfig admissionregistrationv1.WebhookClientConfig, rules []admissionregistrationv1.RuleWithOperations,
+	failurePolicy *admissionregistrationv1.FailurePolicyType, namespaceSelector *metav1.LabelSelector, timeout *int32) webhook {
+	w := webhook{
+		kind:          kind,
+		configuration: configuration,
+		name:          name,
+		clientConfig:  clientConfig,
+		rules:         rules,
+		failurePolicy: admissionregistrationv1.Fail,
+		timeout:       10,
+	}
+	if failurePolicy != nil {
+		w.failurePolicy = *failurePolicy
+	}
+	if timeout != nil {
+		w.timeout = *timeout
+	}
+	// An empty selector matches every namespace, the platform ones too
+	w.namespaces = namespaceSelector == nil || (len(namespaceSelector.MatchLabels) == 0 && len(namespaceSelector.MatchExpressions) == 0)
+	return w
+}
+
+// isPlatformWebhook is true for the webhooks served from the platform namespaces
+func isPlatformWebhook(w webhook) bool {
+	if w.clientConfig.Service == nil {
+		return false
+	}
+	for _, prefix := range platformNamespacePrefixes {
+		if strings.HasPrefix(w.clientConfig.Service.Namespace, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWebhooks checks every customer webhook, in the order of the configurations
+func (o *checkWebhooksOptions) checkWebhooks(webhooks []webhook) []checkFinding {
+	var findings []checkFinding
+	for _, w := range webhooks {
+		if isPlatformWebhook(w) {
+			continue
+		}
+		findings = append(findings, o.checkWebhook(w))
+	}
+	if len(findings) == 0 {
+		return []checkFinding{{check: "customer webhooks", status: dnsCheckOK, message: "no webhook points at a customer service"}}
+	}
+	return findings
+}
+
+func (o *checkWebhooksOptions) checkWebhook(w webhook) checkFinding {
+	finding := checkFinding{check: fmt.Sprintf("%s %s/%s", w.kind, w.configuration, w.name)}
+
+	var reachable bool
+	if service := w.clientConfig.Service; service != nil {
+		finding.message, reachable = o.checkService(service)
+	} else {
+		finding.message, reachable = o.checkURL(w.clientConfig.URL)
+	}
+	if reachable {
+		finding.status = dnsCheckOK
+		return finding
+	}
+
+	impact := webhookImpact(w)
+	if w.failurePolicy == admissionregistrationv1.Ignore {
+		finding.status = dnsCheckWarn
+		finding.message += fmt.Sprintf(", the Ignore policy lets the requests on %s through after the %ds timeout", impact, w.timeout)
+		finding.hint = "Requests are slowed down by the timeout, ask the customer to fix the webhook service or delete the webhook"
+		return finding
+	}
+	finding.status = dnsCheckFail
+	finding.message += fmt.Sprintf(", the Fail policy rejects the requests on %s", impact)
+	finding.hint = fmt.Sprintf("Ask the customer to fix the webhook service, or to delete the webhook ('oc delete %swebhookconfiguration %s') until it is fixed", w.kind, w.configuration)
+	if interceptsPods(w.rules) {
+		finding.hint += ", node drains and upgrades are blocked meanwhile"
+	}
+	return finding
+}
+
+// checkService checks that the service of the webhook has ready endpoints
+func (o *checkWebhooksOptions) checkService(service *admissionregistrationv1.ServiceReference) (string, bool) {
+	name := fmt.Sprintf("service %s/%s", service.Namespace, service.Name)
+	output, err := o.runOC("get", "endpoints", service.Name, "-n", service.Namespace, "-o", "json")
+	if err != nil {
+		return fmt.Sprintf("%s not found", name), false
+	}
+	var endpoints corev1.Endpoints
+	if err := json.Unmarshal(output, &endpoints); err != nil {
+		return fmt.Sprintf("cannot parse the endpoints of %s: %v", name, err), false
+	}
+	ready := 0
+	for _, subset := range endpoints.Subsets {
+		ready += len(subset.Addresses)
+	}
+	if ready == 0 {
+		return fmt.Sprintf("%s has no ready endpoint", name), false
+	}
+	return fmt.Sprintf("%s has %d ready endpoints", name, ready), true
+}
+
+// checkURL checks that the host of a webhook served outside of the cluster resolves, the API server is the only one
+// which can tell whether it answers
+func (o *checkWebhooksOptions) checkURL(rawURL *string) (string, bool) {
+	if rawURL == nil {
+		return "neither a service nor a URL is set", false
+	}
+	parsed, err := url.Parse(*rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return fmt.Sprintf("invalid URL %s", *rawURL), false
+	}
+	addrs, err := o.lookup(parsed.Hostname())
+	if err != nil || len(addrs) == 0 {
+		return fmt.Sprintf("%s does not resolve", parsed.Hostname()), false
+	}
+	return fmt.Sprintf("%s resolves to %s", parsed.Hostname(), strings.Join(addrs, ", ")), true
+}
+
+// webhookImpact describes the requests intercepted by the webhook, e.g. "CREATE pods in every namespace"
+func webhookImpact(w webhook) string {
+	var rules []string
+	for _, rule := range w.rules {
+		operations := make([]string, 0, len(rule.Operations))
+		for _, operation := range rule.Operations {
+			operations = append(operations, string(operation))
+		}
+		rules = append(rules, strings.Join(operations, "/")+" "+strings.Join(rule.Resources, ", "))
+	}
+	sort.Strings(rules)
+	impact := strings.Join(rules, "; ")
+	if impact == "" {
+		impact = "no resource"
+	}
+	if w.namespaces {
+		impact += " in every namespace"
+	}
+	return impact
+}
+
+// interceptsPods is true when the webhook intercepts the pods or their evictions, which node drains go through
+func interceptsPods(rules []admissionregistrationv1.RuleWithOperations) bool {
+	for _, rule := range rules {
+		for _, resource := range rule.Resources {
+			if resource == "*" || resource == "*/*" || resource == "pods" || strings.HasPrefix(resource, "pods/") {
+				return true
+			}
+		}
+	}
+	return false
+}
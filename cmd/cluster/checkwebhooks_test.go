@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const testValidatingWebhooks = `{"items": [
+  {"metadata": {"name": "gatekeeper"}, "webhooks": [
+    {"name": "validation.gatekeeper.sh", "clientConfig": {"service": {"namespace": "gatekeeper-system", "name": "gatekeeper-webhook"}},
+     "rules": [{"operations": ["CREATE", "UPDATE"], "apiGroups": ["*"], "apiVersions": ["*"], "resources": ["pods"]}]},
+    {"name": "check-ignore.gatekeeper.sh", "failurePolicy": "Ignore", "timeoutSeconds": 3, "namespaceSelector": {"matchLabels": {"team": "a"}},
+     "clientConfig": {"service": {"namespace": "gatekeeper-system", "name": "gatekeeper-webhook"}},
+     "rules": [{"operations": ["CREATE"], "resources": ["configmaps"]}]}
+  ]},
+  {"metadata": {"name": "sre-namespace-validation"}, "webhooks": [
+    {"name": "namespace-validation.managed.openshift.io", "clientConfig": {"service": {"namespace": "openshift-validation-webhook", "name": "validation-webhook"}}}
+  ]}
+]}`
+
+const testMutatingWebhooks = `{"items": [
+  {"metadata": {"name": "vault-injector"}, "webhooks": [
+    {"name": "vault.hashicorp.com", "clientConfig": {"url": "https://vault.example.com/mutate"},
+     "rules": [{"operations": ["CREATE"], "resources": ["pods"]}]},
+    {"name": "secrets.example.com", "clientConfig": {"service": {"namespace": "secrets", "name": "injector"}},
+     "rules": [{"operations": ["CREATE"], "resources": ["secrets"]}]}
+  ]}
+]}`
+
+func newTestCheckWebhooks() *checkWebhooksOptions {
+	return &checkWebhooksOptions{
+		runOC: func(args ...string) ([]byte, error) {
+			switch strings.Join(args, " ") {
+			case "get validatingwebhookconfigurations -o json":
+				return []byte(testValidatingWebhooks), nil
+			case "get mutatingwebhookconfigurations -o json":
+				return []byte(testMutatingWebhooks), nil
+			case "get endpoints gatekeeper-webhook -n gatekeeper-system -o json":
+				return []byte(`{"subsets": [{"notReadyAddresses": [{"ip": "10.128.2.4"}]}]}`), nil
+			case "get endpoints injector -n secrets -o json":
+				return []byte(`{"subsets": [{"addresses": [{"ip": "10.128.2.5"}, {"ip": "10.129.0.8"}]}]}`), nil
+			}
+			return nil, errors.New("oc get failed: NotFound")
+		},
+		lookup: func(host string) ([]string, error) {
+			return nil, errors.New("no such host")
+		},
+	}
+}
+
+func TestCheckWebhooks(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := newTestCheckWebhooks()
+
+	webhooks, err := o.listWebhooks()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(webhooks).To(HaveLen(5))
+
+	// The webhook of the openshift- namespace is skipped
+	findings := o.checkWebhooks(webhooks)
+	g.Expect(findings).To(HaveLen(4))
+
+	g.Expect(findings[0].check).To(Equal("validating gatekeeper/validation.gatekeeper.sh"))
+	g.Expect(findings[0].status).To(Equal(dnsCheckFail))
+	g.Expect(findings[0].message).To(Equal("service gatekeeper-system/gatekeeper-webhook has no ready endpoint, the Fail policy rejects the requests on CREATE/UPDATE pods in every namespace"))
+	g.Expect(findings[0].hint).To(ContainSubstring("node drains and upgrades are blocked"))
+
+	g.Expect(findings[1].status).To(Equal(dnsCheckWarn))
+	g.Expect(findings[1].message).To(ContainSubstring("lets the requests on CREATE configmaps through after the 3s timeout"))
+
+	g.Expect(findings[2].check).To(Equal("mutating vault-injector/vault.hashicorp.com"))
+	g.Expect(findings[2].status).To(Equal(dnsCheckFail))
+	g.Expect(findings[2].message).To(HavePrefix("vault.example.com does not resolve"))
+
+	g.Expect(findings[3].status).To(Equal(dnsCheckOK))
+	g.Expect(findings[3].message).To(Equal("service secrets/injector has 2 ready endpoints"))
+
+	g.Expect(o.checkWebhooks(webhooks[2:3])).To(Equal([]checkFinding{{check: "customer webhooks", status: dnsCheckOK, message: "no webhook points at a customer service"}}))
+}
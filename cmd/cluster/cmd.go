@@ -24,13 +24,56 @@ func NewCmdCluster(streams genericclioptions.IOStreams, flags *genericclioptions
 	clusterCmd.AddCommand(newCmdLoggingCheck(streams, flags, globalOpts))
 	clusterCmd.AddCommand(newCmdOwner(streams, flags, globalOpts))
 	clusterCmd.AddCommand(support.NewCmdSupport(streams, flags, client, globalOpts))
-	clusterCmd.AddCommand(newCmdContext())
+	clusterCmd.AddCommand(newCmdContext(streams))
 	clusterCmd.AddCommand(newCmdTransferOwner(streams, globalOpts))
 	clusterCmd.AddCommand(access.NewCmdAccess(streams, flags))
+	clusterCmd.AddCommand(access.NewCmdKubeconfig(streams, flags))
 	clusterCmd.AddCommand(newCmdResizeControlPlaneNode(streams, flags, globalOpts))
-	clusterCmd.AddCommand(newCmdCpd())
-	clusterCmd.AddCommand(newCmdCheckBannedUser())
-	clusterCmd.AddCommand(newCmdValidatePullSecret(client, flags))
+	clusterCmd.AddCommand(newCmdCpd(streams))
+	clusterCmd.AddCommand(newCmdCheckBannedUser(streams))
+	clusterCmd.AddCommand(newCmdValidatePullSecret(streams, client, flags))
+	clusterCmd.AddCommand(newCmdCheckDNS())
+	clusterCmd.AddCommand(newCmdFixDNSDelegation())
+	clusterCmd.AddCommand(newCmdCheckIngress())
+	clusterCmd.AddCommand(newCmdCheckWebhooks())
+	clusterCmd.AddCommand(newCmdBootImages())
+	clusterCmd.AddCommand(newCmdAdvisor())
+	clusterCmd.AddCommand(newCmdCheckRegistry())
+	clusterCmd.AddCommand(newCmdCheckRegistryStorage())
+	clusterCmd.AddCommand(newCmdCheckLogForwarding())
+	clusterCmd.AddCommand(newCmdRefreshCache(streams))
+	clusterCmd.AddCommand(newCmdAccessAudit(streams))
+	clusterCmd.AddCommand(newCmdPullSecret(streams, client))
+	clusterCmd.AddCommand(newCmdHive(streams))
+	clusterCmd.AddCommand(newCmdLabel(globalOpts))
+	clusterCmd.AddCommand(newCmdValidateIAM())
+	clusterCmd.AddCommand(newCmdEtcd())
+	clusterCmd.AddCommand(newCmdNode())
+	clusterCmd.AddCommand(newCmdMachinePool())
+	clusterCmd.AddCommand(newCmdOrphanedResources(globalOpts))
+	clusterCmd.AddCommand(NewCmdList(globalOpts))
+	clusterCmd.AddCommand(newCmdQuota())
+	clusterCmd.AddCommand(newCmdExport())
+	clusterCmd.AddCommand(newCmdIDP(globalOpts))
+	clusterCmd.AddCommand(NewCmdDescribe(globalOpts))
+	clusterCmd.AddCommand(newCmdClusterHistory(globalOpts))
+	clusterCmd.AddCommand(newCmdClusterWatch(globalOpts))
+	clusterCmd.AddCommand(newCmdEventsK8s(globalOpts))
+	clusterCmd.AddCommand(newCmdBackups(globalOpts))
+	clusterCmd.AddCommand(newCmdAddon(globalOpts))
+	clusterCmd.AddCommand(newCmdNetwork(globalOpts))
+	clusterCmd.AddCommand(newCmdLogs())
+	clusterCmd.AddCommand(newCmdHCPTopology(globalOpts))
+	clusterCmd.AddCommand(newCmdClusterCost(globalOpts))
+	clusterCmd.AddCommand(newCmdFlowLogs(globalOpts))
+	clusterCmd.AddCommand(newCmdCertificates(globalOpts))
+	clusterCmd.AddCommand(newCmdCredentialsMode(globalOpts))
+	clusterCmd.AddCommand(newCmdMUO(globalOpts))
+	clusterCmd.AddCommand(newCmdElevate())
+	clusterCmd.AddCommand(newCmdInstallLogs(globalOpts))
+	clusterCmd.AddCommand(newCmdSubscriptionDrift(globalOpts))
+	clusterCmd.AddCommand(newCmdVolumes(globalOpts))
+	clusterCmd.AddCommand(newCmdIncidentBundle())
 	return clusterCmd
 }
 
@@ -19,10 +19,14 @@ import (
 	sl "github.com/openshift/osdctl/internal/servicelog"
 	"github.com/openshift/osdctl/pkg/osdCloud"
 	"github.com/openshift/osdctl/pkg/osdctlConfig"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/openshift/osdctl/pkg/timefmt"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
@@ -42,27 +46,29 @@ type contextOptions struct {
 	infraID           string
 	awsProfile        string
 	jiratoken         string
+
+	genericclioptions.IOStreams
 }
 
 const (
-	JiraTokenConfigKey            = "jira_token"
-	PagerDutyOauthTokenConfigKey  = "pd_oauth_token"
-	PagerDutyUserTokenConfigKey   = "pd_user_token"
+	JiraTokenConfigKey            = secrets.JiraTokenKey
+	PagerDutyOauthTokenConfigKey  = secrets.PagerDutyOauthTokenKey
+	PagerDutyUserTokenConfigKey   = secrets.PagerDutyUserTokenKey
 	JiraTokenRegistrationUrl      = "https://issues.redhat.com/secure/ViewProfile.jspa?selectedTab=com.atlassian.pats.pats-plugin:jira-user-personal-access-tokens"
 	PagerDutyTokenRegistrationUrl = "https://martindstone.github.io/PDOAuth/"
 )
 
 // newCmdContext implements the context command to show the current context of a cluster
-func newCmdContext() *cobra.Command {
-	ops := newContextOptions()
+func newCmdContext(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := newContextOptions(streams)
 	contextCmd := &cobra.Command{
 		Use:               "context",
 		Short:             "Shows the context of a specified cluster",
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -79,8 +85,8 @@ func newCmdContext() *cobra.Command {
 	return contextCmd
 }
 
-func newContextOptions() *contextOptions {
-	return &contextOptions{}
+func newContextOptions(streams genericclioptions.IOStreams) *contextOptions {
+	return &contextOptions{IOStreams: streams}
 }
 
 func (o *contextOptions) complete(cmd *cobra.Command, args []string) error {
@@ -96,7 +102,7 @@ func (o *contextOptions) complete(cmd *cobra.Command, args []string) error {
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(o.ErrOut, "Cannot close the ocmClient (possible memory leak): %q\n", err)
 		}
 	}()
 
@@ -114,7 +120,7 @@ func (o *contextOptions) complete(cmd *cobra.Command, args []string) error {
 
 	orgID, err := utils.GetOrgfromClusterID(ocmClient, *cluster)
 	if err != nil {
-		fmt.Printf("Failed to get Org ID for cluster ID %s - err: %q", o.clusterID, err)
+		fmt.Fprintf(o.ErrOut, "Failed to get Org ID for cluster ID %s - err: %q\n", o.clusterID, err)
 		o.organizationID = ""
 	} else {
 		o.organizationID = orgID
@@ -130,39 +136,43 @@ func (o *contextOptions) run() error {
 
 	err := printClusterInfo(o.clusterID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't print cluster info: %v\n", err)
+		fmt.Fprintf(o.ErrOut, "Can't print cluster info: %v\n", err)
 		os.Exit(1)
 	}
 
 	limitedSupportReasons, err := utils.GetClusterLimitedSupportReasons(connection, o.clusterID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't retrieve cluster limited support reasons: %v\n", err)
+		fmt.Fprintf(o.ErrOut, "Can't retrieve cluster limited support reasons: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Check support status of cluster
 	err = printSupportStatus(limitedSupportReasons)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't print support status: %v\n", err)
+		fmt.Fprintf(o.ErrOut, "Can't print support status: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Print the Servicelogs for this cluster
 	err = o.printServiceLogs()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't print service logs: %v\n", err)
+		fmt.Fprintf(o.ErrOut, "Can't print service logs: %v\n", err)
 		os.Exit(1)
 	}
 
 	err = o.printJiraCards()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't print jira cards: %v\n", err)
+	if secrets.IsNotConfigured(err) {
+		printSkippedSection("Cluster OHSS Cards", err)
+	} else if err != nil {
+		fmt.Fprintf(o.ErrOut, "Can't print jira cards: %v\n", err)
 	}
 
 	// Print all triggered and acknowledged pd alerts
 	err = o.printPDAlerts()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't print pagerduty alerts: %v\n", err)
+	if secrets.IsNotConfigured(err) {
+		printSkippedSection("Current Pagerduty Alerts for the Cluster", err)
+	} else if err != nil {
+		fmt.Fprintf(o.ErrOut, "Can't print pagerduty alerts: %v\n", err)
 		// Here we don't actually want to error out, this is to ensure that even if we don't have the
 		// pd auth setup, we can still get the rest of the output.
 	}
@@ -170,13 +180,13 @@ func (o *contextOptions) run() error {
 	// Print other helpful links
 	err = o.printOtherLinks()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't print other links: %v\n", err)
+		fmt.Fprintf(o.ErrOut, "Can't print other links: %v\n", err)
 	}
 
 	if o.full {
 		err = o.printCloudTrailLogs()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Can't print cloudtrail: %v\n", err)
+			fmt.Fprintf(o.ErrOut, "Can't print cloudtrail: %v\n", err)
 			os.Exit(1)
 		}
 	} else {
@@ -237,7 +247,7 @@ func printSupportStatus(limitedSupportReasons []*utils.LimitedSupportReasonItem)
 func (o *contextOptions) printServiceLogs() error {
 
 	// Get the SLs for the cluster
-	slResponse, err := servicelog.FetchServiceLogs(o.clusterID)
+	slResponse, err := servicelog.FetchServiceLogs(o.ErrOut, o.clusterID, false, false)
 	if err != nil {
 		return err
 	}
@@ -245,8 +255,7 @@ func (o *contextOptions) printServiceLogs() error {
 	var serviceLogs sl.ServiceLogShortList
 	err = json.Unmarshal(slResponse.Bytes(), &serviceLogs)
 	if err != nil {
-		fmt.Printf("Failed to unmarshal the SL response %q\n", err)
-		return err
+		return fmt.Errorf("failed to unmarshal the SL response: %w", err)
 	}
 
 	// Parsing the relevant servicelogs
@@ -274,7 +283,7 @@ func (o *contextOptions) printServiceLogs() error {
 	} else {
 		// Non verbose only prints the summaries
 		for i, errorServiceLog := range errorServiceLogs {
-			fmt.Printf("%d. %s (%s)\n", i, errorServiceLog.Summary, errorServiceLog.CreatedAt.Format(time.RFC3339))
+			fmt.Printf("%d. %s (%s)\n", i, errorServiceLog.Summary, timefmt.Format(errorServiceLog.CreatedAt))
 		}
 	}
 	fmt.Println()
@@ -282,22 +291,47 @@ func (o *contextOptions) printServiceLogs() error {
 	return nil
 }
 
+// printSkippedSection prints the section of an optional integration which isn't configured, instead of its content
+func printSkippedSection(title string, err error) {
+	fmt.Println()
+	fmt.Println("============================================================")
+	fmt.Println(title)
+	fmt.Println("============================================================")
+	fmt.Printf("Skipped: %v\n", err)
+	fmt.Println()
+}
+
+// lookupToken returns the token passed as a flag, stored with 'osdctl secrets set', or set in the config file. It
+// returns a secrets.NotConfiguredError when the integration has no token.
+func lookupToken(flagValue string, key string, integration string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	token, err := secrets.Lookup(key)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		return token, nil
+	}
+	if viper.GetString(key) == "" {
+		return "", &secrets.NotConfiguredError{Integration: integration, Key: key}
+	}
+	return viper.GetString(key), nil
+}
+
 func getPDUserClient(usertoken string) (*pd.Client, error) {
-	if usertoken == "" {
-		if !viper.IsSet(PagerDutyUserTokenConfigKey) {
-			return nil, fmt.Errorf("key %s is not set in config file", PagerDutyUserTokenConfigKey)
-		}
-		usertoken = viper.GetString(PagerDutyUserTokenConfigKey)
+	usertoken, err := lookupToken(usertoken, PagerDutyUserTokenConfigKey, "PagerDuty")
+	if err != nil {
+		return nil, err
 	}
 	return pd.NewClient(usertoken), nil
 }
 
 func getPDOauthClient(oauthtoken string) (*pd.Client, error) {
-	if oauthtoken == "" {
-		if !viper.IsSet(PagerDutyOauthTokenConfigKey) {
-			return nil, fmt.Errorf("key %s is not set in config file", PagerDutyOauthTokenConfigKey)
-		}
-		oauthtoken = viper.GetString(PagerDutyOauthTokenConfigKey)
+	oauthtoken, err := lookupToken(oauthtoken, PagerDutyOauthTokenConfigKey, "PagerDuty")
+	if err != nil {
+		return nil, err
 	}
 	return pd.NewOAuthClient(oauthtoken), nil
 }
@@ -308,8 +342,12 @@ func GetPagerdutyClient(usertoken string, oauthtoken string) (*pd.Client, error)
 		return client, err
 	}
 	client, err = getPDOauthClient(oauthtoken)
+	// Neither token is set, the user is told to set up the oauth one
+	if secrets.IsNotConfigured(err) {
+		return nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create both user and oauth clients for pd")
+		return nil, fmt.Errorf("failed to create both user and oauth clients for pd: %w", err)
 	}
 	return client, err
 }
@@ -318,8 +356,7 @@ func getPDSeviceID(pdClient *pd.Client, ctx context.Context, baseDomain string)
 	lsResponse, err := pdClient.ListServicesWithContext(ctx, pd.ListServiceOptions{Query: baseDomain})
 
 	if err != nil {
-		fmt.Printf("Failed to ListServicesWithContext %q\n", err)
-		return "", err
+		return "", fmt.Errorf("failed to ListServicesWithContext: %w", err)
 	}
 
 	if len(lsResponse.Services) != 1 {
@@ -339,8 +376,7 @@ func printCurrentPDAlerts(pdClient *pd.Client, ctx context.Context, serviceID st
 		},
 	)
 	if err != nil {
-		fmt.Printf("Failed to ListIncidentsWithContext %q\n", err)
-		return err
+		return fmt.Errorf("failed to ListIncidentsWithContext: %w", err)
 	}
 
 	fmt.Println("============================================================")
@@ -356,8 +392,7 @@ func printCurrentPDAlerts(pdClient *pd.Client, ctx context.Context, serviceID st
 	table.AddRow([]string{})
 	err = table.Flush()
 	if err != nil {
-		fmt.Println("error while flushing table: ", err.Error())
-		return err
+		return fmt.Errorf("error while flushing table: %w", err)
 	}
 	return nil
 }
@@ -419,14 +454,12 @@ func printHistoricalPDAlertSummary(pdClient *pd.Client, ctx context.Context, ser
 			// Compare current incident timestamp vs our previous 'latest occurrence', and save the most recent.
 			currentLastOccurence, err := time.Parse(time.RFC3339, incidentCounter[title].lastOccurrence)
 			if err != nil {
-				fmt.Printf("Failed to parse time %q\n", err)
-				return err
+				return fmt.Errorf("failed to parse time: %w", err)
 			}
 
 			incidentCreatedAt, err := time.Parse(time.RFC3339, incident.CreatedAt)
 			if err != nil {
-				fmt.Printf("Failed to parse time %q\n", err)
-				return err
+				return fmt.Errorf("failed to parse time: %w", err)
 			}
 
 			// We want to see when the latest occurrence was
@@ -463,15 +496,13 @@ func printHistoricalPDAlertSummary(pdClient *pd.Client, ctx context.Context, ser
 	table.AddRow([]string{})
 	err := table.Flush()
 	if err != nil {
-		fmt.Println("error while flushing table: ", err.Error())
-		return err
+		return fmt.Errorf("error while flushing table: %w", err)
 	}
 
 	totalIncidents := len(incidents)
 	oldestIncidentTimestamp, err := time.Parse(time.RFC3339, incidents[totalIncidents-1].CreatedAt)
 	if err != nil {
-		fmt.Printf("Failed to parse time %q\n", err)
-		return err
+		return fmt.Errorf("failed to parse time: %w", err)
 	}
 	oldestIncidentTimeInDays := int(time.Since(oldestIncidentTimestamp).Hours() / 24)
 	fmt.Println("Total number of incidents [", totalIncidents, "] in [", oldestIncidentTimeInDays, "] days")
@@ -481,12 +512,11 @@ func printHistoricalPDAlertSummary(pdClient *pd.Client, ctx context.Context, ser
 
 func (o *contextOptions) printJiraCards() error {
 
-	if !viper.IsSet(JiraTokenConfigKey) {
-		return fmt.Errorf("key %s is not set in config file", JiraTokenConfigKey)
+	jiratoken, err := lookupToken(o.jiratoken, JiraTokenConfigKey, "Jira")
+	if err != nil {
+		return err
 	}
 
-	jiratoken := viper.GetString(JiraTokenConfigKey)
-
 	tp := jira.PATAuthTransport{
 		Token: jiratoken,
 	}
@@ -503,18 +533,9 @@ func (o *contextOptions) printJiraCards() error {
 }
 
 func (o *contextOptions) printJIRAOHSS(jiraClient *jira.Client) error {
-	jql := fmt.Sprintf(
-		`(project = "OpenShift Hosted SRE Support" AND "Cluster ID" ~ "%s") 
-		OR (project = "OpenShift Hosted SRE Support" AND "Cluster ID" ~ "%s") 
-		ORDER BY priority DESC, Status DESC`,
-		o.externalClusterID,
-		o.clusterID,
-	)
-
-	issues, _, err := jiraClient.Issue.Search(jql, nil)
+	issues, _, err := jiraClient.Issue.Search(ohssJQL(o.externalClusterID, o.clusterID), nil)
 	if err != nil {
-		fmt.Printf("Failed to search for jira issues %q\n", err)
-		return err
+		return fmt.Errorf("failed to search for jira issues: %w", err)
 	}
 
 	fmt.Println()
@@ -535,6 +556,17 @@ func (o *contextOptions) printJIRAOHSS(jiraClient *jira.Client) error {
 	return nil
 }
 
+// ohssJQL searches the OHSS cards of the cluster, the most urgent first
+func ohssJQL(externalClusterID, clusterID string) string {
+	return fmt.Sprintf(
+		`(project = "OpenShift Hosted SRE Support" AND "Cluster ID" ~ "%s") 
+		OR (project = "OpenShift Hosted SRE Support" AND "Cluster ID" ~ "%s") 
+		ORDER BY priority DESC, Status DESC`,
+		externalClusterID,
+		clusterID,
+	)
+}
+
 func (o *contextOptions) printJIRASupportExceptions(jiraClient *jira.Client) error {
 	jql := fmt.Sprintf(
 		`project = "Support Exceptions" AND type = Story AND Status = Approved AND
@@ -544,8 +576,7 @@ func (o *contextOptions) printJIRASupportExceptions(jiraClient *jira.Client) err
 
 	issues, _, err := jiraClient.Issue.Search(jql, nil)
 	if err != nil {
-		fmt.Printf("Failed to search for jira issues %q\n", err)
-		return err
+		return fmt.Errorf("failed to search for jira issues: %w", err)
 	}
 
 	fmt.Println()
@@ -569,27 +600,23 @@ func (o *contextOptions) printPDAlerts() error {
 
 	pdClient, err := GetPagerdutyClient(o.usertoken, o.oauthtoken)
 	if err != nil {
-		fmt.Println("error getting pd client: ", err.Error())
 		return err
 	}
 
 	ctx := context.TODO()
 	serviceID, err := getPDSeviceID(pdClient, ctx, o.baseDomain)
 	if err != nil {
-		fmt.Println("error getting pd service id: ", err.Error())
-		return err
+		return fmt.Errorf("error getting pd service id: %w", err)
 	}
 
 	err = printCurrentPDAlerts(pdClient, ctx, serviceID)
 	if err != nil {
-		fmt.Println("error calling printCurrentPDAlerts: ", err.Error())
-		return err
+		return fmt.Errorf("error calling printCurrentPDAlerts: %w", err)
 	}
 
 	err = printHistoricalPDAlertSummary(pdClient, ctx, serviceID)
 	if err != nil {
-		fmt.Println("error calling printHistoricalPDAlertSummary: ", err.Error())
-		return err
+		return fmt.Errorf("error calling printHistoricalPDAlertSummary: %w", err)
 	}
 
 	return nil
@@ -657,8 +684,7 @@ func (o *contextOptions) printCloudTrailLogs() error {
 	table.AddRow([]string{})
 	err = table.Flush()
 	if err != nil {
-		fmt.Println("error while flushing table: ", err.Error())
-		return err
+		return fmt.Errorf("error while flushing table: %w", err)
 	}
 
 	return nil
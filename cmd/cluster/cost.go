@@ -0,0 +1,498 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	clusterCostLong = `Estimates the monthly cloud cost of an AWS cluster from its running instances, their volumes and its load
+balancers, at the current on-demand prices of the AWS Price List API, and compares it with what Cost Explorer
+reports for the cluster's account over the last 30 days.
+
+The estimate is a floor: data transfer, snapshots, S3, NAT gateways and the load balancer capacity units aren't
+part of it, and neither are savings plans, reserved instances or credits, which the actual cost includes. Cost
+Explorer only has the actual cost when the cluster is alone in its account and Cost Explorer is enabled there.`
+
+	clusterCostExample = `
+  # Estimate the monthly cost of a cluster and compare it with the actual cost
+  osdctl cluster cost 1kfmyclusteristhebesteverp8m
+
+  # As JSON
+  osdctl cluster cost 1kfmyclusteristhebesteverp8m -o json
+`
+
+	// hoursPerMonth is the number of hours AWS prices a month of an hourly resource with
+	hoursPerMonth = 730
+	// actualCostDays is the period of the actual cost
+	actualCostDays = 30
+)
+
+type clusterCostOptions struct {
+	clusterID  string
+	awsProfile string
+
+	awsClient     awsprovider.Client
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// costItem is the estimate of the resources of a kind sharing a price
+type costItem struct {
+	Kind     string  `json:"kind" yaml:"kind"`
+	Type     string  `json:"type" yaml:"type"`
+	Count    int     `json:"count" yaml:"count"`
+	Quantity float64 `json:"quantity" yaml:"quantity"`
+	Unit     string  `json:"unit" yaml:"unit"`
+	// UnitPrice is the on-demand price in USD of one unit, 0 when the price list doesn't have it
+	UnitPrice float64 `json:"unit_price" yaml:"unit_price"`
+	Monthly   float64 `json:"monthly" yaml:"monthly"`
+}
+
+type serviceCost struct {
+	Service string  `json:"service" yaml:"service"`
+	Amount  float64 `json:"amount" yaml:"amount"`
+}
+
+type clusterCostResponse struct {
+	ClusterID       string        `json:"cluster_id" yaml:"cluster_id"`
+	Region          string        `json:"region" yaml:"region"`
+	Items           []costItem    `json:"items" yaml:"items"`
+	EstimateMonthly float64       `json:"estimate_monthly" yaml:"estimate_monthly"`
+	ActualLast30d   *float64      `json:"actual_last_30d,omitempty" yaml:"actual_last_30d,omitempty"`
+	ActualServices  []serviceCost `json:"actual_services,omitempty" yaml:"actual_services,omitempty"`
+	// ActualError is why the actual cost couldn't be retrieved
+	ActualError string   `json:"actual_error,omitempty" yaml:"actual_error,omitempty"`
+	Warnings    []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+func (r clusterCostResponse) String() string {
+	var b bytes.Buffer
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"KIND", "TYPE", "COUNT", "QUANTITY", "UNIT PRICE", "MONTHLY"})
+	for _, item := range r.Items {
+		price := "unknown"
+		if item.UnitPrice > 0 {
+			price = fmt.Sprintf("$%.4f/%s", item.UnitPrice, item.Unit)
+		}
+		table.AddRow([]string{item.Kind, item.Type, strconv.Itoa(item.Count), fmt.Sprintf("%g %s", item.Quantity, item.Unit), price, formatUSD(item.Monthly)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	table.AddRow([]string{"Estimated monthly cost:", formatUSD(r.EstimateMonthly)})
+	if r.ActualLast30d != nil {
+		table.AddRow([]string{"Actual cost, last 30 days:", formatUSD(*r.ActualLast30d)})
+		if *r.ActualLast30d > 0 {
+			difference := (r.EstimateMonthly - *r.ActualLast30d) / *r.ActualLast30d * 100
+			table.AddRow([]string{"Estimate vs actual:", fmt.Sprintf("%+.0f%%", difference)})
+		}
+	} else {
+		table.AddRow([]string{"Actual cost, last 30 days:", "unavailable: " + r.ActualError})
+	}
+	table.AddRow([]string{})
+	_ = table.Flush()
+
+	if len(r.ActualServices) > 0 {
+		services := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+		services.AddRow([]string{"SERVICE", "ACTUAL, LAST 30 DAYS"})
+		for _, service := range r.ActualServices {
+			services.AddRow([]string{service.Service, formatUSD(service.Amount)})
+		}
+		services.AddRow([]string{})
+		_ = services.Flush()
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(&b, "Warning: %s\n", warning)
+	}
+	return b.String()
+}
+
+func formatUSD(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+func newCmdClusterCost(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &clusterCostOptions{GlobalOptions: globalOpts}
+	costCmd := &cobra.Command{
+		Use:               "cost CLUSTER_ID",
+		Short:             "Estimate the monthly cloud cost of a cluster and compare it with the last 30 days of actual cost",
+		Long:              clusterCostLong,
+		Example:           clusterCostExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	costCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile used to reach the cluster's account")
+
+	return costCmd
+}
+
+func (o *clusterCostOptions) run() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.CloudProvider().ID() != "aws" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "'osdctl cluster cost' only supports AWS clusters, %s is on %s",
+			cluster.ID(), cluster.CloudProvider().ID())
+	}
+
+	if o.awsClient == nil {
+		o.awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+		if err != nil {
+			return err
+		}
+	}
+
+	response, err := estimateClusterCost(o.awsClient, cluster, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// estimateClusterCost estimates the monthly cost of the resources of the cluster, and retrieves its actual cost
+func estimateClusterCost(client awsprovider.Client, cluster *cmv1.Cluster, now time.Time) (clusterCostResponse, error) {
+	region := cluster.Region().ID()
+	response := clusterCostResponse{ClusterID: cluster.ID(), Region: region, Items: []costItem{}}
+	prices := newPriceList(client, region)
+	ownedTag := "kubernetes.io/cluster/" + cluster.InfraID()
+
+	instanceItems, err := estimateInstances(client, prices, ownedTag)
+	if err != nil {
+		return response, err
+	}
+	volumeItems, err := estimateVolumes(client, prices, ownedTag)
+	if err != nil {
+		return response, err
+	}
+	loadBalancerItems, err := estimateLoadBalancers(client, prices, ownedTag)
+	if err != nil {
+		return response, err
+	}
+	response.Items = append(response.Items, instanceItems...)
+	response.Items = append(response.Items, volumeItems...)
+	response.Items = append(response.Items, loadBalancerItems...)
+	for _, item := range response.Items {
+		response.EstimateMonthly += item.Monthly
+		if item.UnitPrice == 0 {
+			response.Warnings = append(response.Warnings, fmt.Sprintf("no on-demand price for %s %s in %s, it isn't part of the estimate", item.Kind, item.Type, region))
+		}
+	}
+	response.EstimateMonthly = roundCents(response.EstimateMonthly)
+
+	total, services, err := actualCost(client, now)
+	if err != nil {
+		response.ActualError = err.Error()
+	} else {
+		response.ActualLast30d = &total
+		response.ActualServices = services
+	}
+	return response, nil
+}
+
+func estimateInstances(client awsprovider.Client, prices *priceList, ownedTag string) ([]costItem, error) {
+	counts := map[string]int{}
+	input := &ec2.DescribeInstancesInput{Filters: []*ec2.Filter{
+		{Name: awsSdk.String("tag:" + ownedTag), Values: awsSdk.StringSlice([]string{"owned"})},
+		{Name: awsSdk.String("instance-state-name"), Values: awsSdk.StringSlice([]string{"pending", "running"})},
+	}}
+	for {
+		output, err := client.DescribeInstances(input)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the instances of the cluster: %w", err)
+		}
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				counts[awsSdk.StringValue(instance.InstanceType)]++
+			}
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	var items []costItem
+	for _, instanceType := range sortedKeys(counts) {
+		price, err := prices.instanceHourly(instanceType)
+		if err != nil {
+			return nil, err
+		}
+		hours := float64(counts[instanceType] * hoursPerMonth)
+		items = append(items, costItem{Kind: "instance", Type: instanceType, Count: counts[instanceType], Quantity: hours,
+			Unit: "hour", UnitPrice: price, Monthly: roundCents(hours * price)})
+	}
+	return items, nil
+}
+
+func estimateVolumes(client awsprovider.Client, prices *priceList, ownedTag string) ([]costItem, error) {
+	counts := map[string]int{}
+	sizes := map[string]int64{}
+	input := &ec2.DescribeVolumesInput{Filters: []*ec2.Filter{
+		{Name: awsSdk.String("tag:" + ownedTag), Values: awsSdk.StringSlice([]string{"owned"})},
+	}}
+	for {
+		output, err := client.DescribeVolumes(input)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the volumes of the cluster: %w", err)
+		}
+		for _, volume := range output.Volumes {
+			volumeType := awsSdk.StringValue(volume.VolumeType)
+			counts[volumeType]++
+			sizes[volumeType] += awsSdk.Int64Value(volume.Size)
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+
+	var items []costItem
+	for _, volumeType := range sortedKeys(counts) {
+		price, err := prices.volumeMonthlyPerGB(volumeType)
+		if err != nil {
+			return nil, err
+		}
+		size := float64(sizes[volumeType])
+		items = append(items, costItem{Kind: "volume", Type: volumeType, Count: counts[volumeType], Quantity: size,
+			Unit: "GB-month", UnitPrice: price, Monthly: roundCents(size * price)})
+	}
+	return items, nil
+}
+
+func estimateLoadBalancers(client awsprovider.Client, prices *priceList, ownedTag string) ([]costItem, error) {
+	counts := map[string]int{}
+	input := &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: awsSdk.StringSlice([]string{"elasticloadbalancing:loadbalancer"}),
+		TagFilters:          []*resourcegroupstaggingapi.TagFilter{{Key: awsSdk.String(ownedTag)}},
+	}
+	for {
+		output, err := client.GetResources(input)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the load balancers of the cluster: %w", err)
+		}
+		for _, resource := range output.ResourceTagMappingList {
+			counts[loadBalancerKind(awsSdk.StringValue(resource.ResourceARN))]++
+		}
+		if awsSdk.StringValue(output.PaginationToken) == "" {
+			break
+		}
+		input.PaginationToken = output.PaginationToken
+	}
+
+	var items []costItem
+	for _, kind := range sortedKeys(counts) {
+		price, err := prices.loadBalancerHourly(kind)
+		if err != nil {
+			return nil, err
+		}
+		hours := float64(counts[kind] * hoursPerMonth)
+		items = append(items, costItem{Kind: "load balancer", Type: kind, Count: counts[kind], Quantity: hours,
+			Unit: "hour", UnitPrice: price, Monthly: roundCents(hours * price)})
+	}
+	return items, nil
+}
+
+// loadBalancerKind returns the kind of load balancer of the ARN: network and application ARNs have it in their
+// resource, e.g. loadbalancer/net/name/id, the classic ones don't
+func loadBalancerKind(arn string) string {
+	switch {
+	case strings.Contains(arn, ":loadbalancer/net/"):
+		return "network"
+	case strings.Contains(arn, ":loadbalancer/app/"):
+		return "application"
+	}
+	return "classic"
+}
+
+// actualCost returns the cost of the account over the last 30 days, by service, the most expensive first
+func actualCost(client awsprovider.Client, now time.Time) (float64, []serviceCost, error) {
+	input := &costexplorer.GetCostAndUsageInput{
+		TimePeriod: &costexplorer.DateInterval{
+			Start: awsSdk.String(now.AddDate(0, 0, -actualCostDays).Format("2006-01-02")),
+			End:   awsSdk.String(now.Format("2006-01-02")),
+		},
+		Granularity: awsSdk.String(costexplorer.GranularityDaily),
+		Metrics:     awsSdk.StringSlice([]string{costexplorer.MetricUnblendedCost}),
+		GroupBy:     []*costexplorer.GroupDefinition{{Type: awsSdk.String(costexplorer.GroupDefinitionTypeDimension), Key: awsSdk.String("SERVICE")}},
+	}
+	byService := map[string]float64{}
+	for {
+		output, err := client.GetCostAndUsage(input)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, result := range output.ResultsByTime {
+			for _, group := range result.Groups {
+				metric, ok := group.Metrics[costexplorer.MetricUnblendedCost]
+				if !ok || len(group.Keys) == 0 {
+					continue
+				}
+				amount, err := strconv.ParseFloat(awsSdk.StringValue(metric.Amount), 64)
+				if err != nil {
+					return 0, nil, fmt.Errorf("invalid cost amount '%s': %w", awsSdk.StringValue(metric.Amount), err)
+				}
+				byService[awsSdk.StringValue(group.Keys[0])] += amount
+			}
+		}
+		if output.NextPageToken == nil {
+			break
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+
+	total := 0.0
+	services := []serviceCost{}
+	for service, amount := range byService {
+		total += amount
+		if amount >= 0.01 {
+			services = append(services, serviceCost{Service: service, Amount: roundCents(amount)})
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Amount != services[j].Amount {
+			return services[i].Amount > services[j].Amount
+		}
+		return services[i].Service < services[j].Service
+	})
+	return roundCents(total), services, nil
+}
+
+// priceList queries the on-demand prices of a region from the AWS Price List API, once per product
+type priceList struct {
+	client awsprovider.Client
+	region string
+	cache  map[string]float64
+}
+
+func newPriceList(client awsprovider.Client, region string) *priceList {
+	return &priceList{client: client, region: region, cache: map[string]float64{}}
+}
+
+// instanceHourly returns the hourly price of a shared Linux instance, RHCOS being priced as Linux
+func (p *priceList) instanceHourly(instanceType string) (float64, error) {
+	return p.price("AmazonEC2", "Hrs", map[string]string{
+		"instanceType":    instanceType,
+		"operatingSystem": "Linux",
+		"tenancy":         "Shared",
+		"preInstalledSw":  "NA",
+		"capacitystatus":  "Used",
+	})
+}
+
+func (p *priceList) volumeMonthlyPerGB(volumeType string) (float64, error) {
+	return p.price("AmazonEC2", "GB-Mo", map[string]string{
+		"productFamily": "Storage",
+		"volumeApiName": volumeType,
+	})
+}
+
+func (p *priceList) loadBalancerHourly(kind string) (float64, error) {
+	productFamily := map[string]string{
+		"network":     "Load Balancer-Network",
+		"application": "Load Balancer-Application",
+		"classic":     "Load Balancer",
+	}[kind]
+	return p.price("AWSELB", "Hrs", map[string]string{"productFamily": productFamily})
+}
+
+// price returns the USD price per unit of the product matching the attributes, 0 when there is none
+func (p *priceList) price(serviceCode, unit string, attributes map[string]string) (float64, error) {
+	keys := sortedKeys(attributes)
+	filters := []*pricing.Filter{{Type: awsSdk.String(pricing.FilterTypeTermMatch), Field: awsSdk.String("regionCode"), Value: awsSdk.String(p.region)}}
+	cacheKey := serviceCode
+	for _, key := range keys {
+		filters = append(filters, &pricing.Filter{Type: awsSdk.String(pricing.FilterTypeTermMatch), Field: awsSdk.String(key), Value: awsSdk.String(attributes[key])})
+		cacheKey += "/" + key + "=" + attributes[key]
+	}
+	if price, ok := p.cache[cacheKey]; ok {
+		return price, nil
+	}
+
+	input := &pricing.GetProductsInput{ServiceCode: awsSdk.String(serviceCode), Filters: filters, FormatVersion: awsSdk.String("aws_v1")}
+	price := 0.0
+	for {
+		output, err := p.client.GetProducts(input)
+		if err != nil {
+			return 0, fmt.Errorf("cannot get the price of %s: %w", cacheKey, err)
+		}
+		for _, product := range output.PriceList {
+			if onDemand := onDemandPrice(product, unit); onDemand > 0 && (price == 0 || onDemand < price) {
+				price = onDemand
+			}
+		}
+		if awsSdk.StringValue(output.NextToken) == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	p.cache[cacheKey] = price
+	return price, nil
+}
+
+// onDemandPrice returns the positive USD on-demand price per unit of a price list product, 0 when there is none:
+//
+//	{"terms": {"OnDemand": {"<offer>": {"priceDimensions": {"<rate>": {"unit": "Hrs", "pricePerUnit": {"USD": "0.192"}}}}}}}
+func onDemandPrice(product awsSdk.JSONValue, unit string) float64 {
+	terms, _ := product["terms"].(map[string]interface{})
+	offers, _ := terms["OnDemand"].(map[string]interface{})
+	for _, offer := range offers {
+		offerMap, _ := offer.(map[string]interface{})
+		dimensions, _ := offerMap["priceDimensions"].(map[string]interface{})
+		for _, dimension := range dimensions {
+			dimensionMap, _ := dimension.(map[string]interface{})
+			if dimensionMap["unit"] != unit {
+				continue
+			}
+			perUnit, _ := dimensionMap["pricePerUnit"].(map[string]interface{})
+			usd, _ := perUnit["USD"].(string)
+			// Free tiers and the first tiers of tiered prices are 0
+			if price, err := strconv.ParseFloat(usd, 64); err == nil && price > 0 {
+				return price
+			}
+		}
+	}
+	return 0
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
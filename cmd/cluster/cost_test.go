@@ -0,0 +1,126 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func priceListProduct(unit, usd string) awsSdk.JSONValue {
+	return awsSdk.JSONValue{"terms": map[string]interface{}{"OnDemand": map[string]interface{}{
+		"offer": map[string]interface{}{"priceDimensions": map[string]interface{}{
+			"rate": map[string]interface{}{"unit": unit, "pricePerUnit": map[string]interface{}{"USD": usd}},
+		}},
+	}}}
+}
+
+func TestOnDemandPrice(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(onDemandPrice(priceListProduct("Hrs", "0.192"), "Hrs")).To(Equal(0.192))
+	g.Expect(onDemandPrice(priceListProduct("Hrs", "0.0000000000"), "Hrs")).To(Equal(0.0))
+	g.Expect(onDemandPrice(priceListProduct("LCU-Hrs", "0.006"), "Hrs")).To(Equal(0.0))
+	g.Expect(onDemandPrice(awsSdk.JSONValue{}, "Hrs")).To(Equal(0.0))
+}
+
+func TestLoadBalancerKind(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(loadBalancerKind("arn:aws:elasticloadbalancing:us-east-1:111:loadbalancer/net/mycluster-int/abc")).To(Equal("network"))
+	g.Expect(loadBalancerKind("arn:aws:elasticloadbalancing:us-east-1:111:loadbalancer/app/console/abc")).To(Equal("application"))
+	g.Expect(loadBalancerKind("arn:aws:elasticloadbalancing:us-east-1:111:loadbalancer/a1b2c3")).To(Equal("classic"))
+}
+
+func TestEstimateClusterCost(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+
+	cluster, err := cmv1.NewCluster().ID("abc").InfraID("mycluster-abcde").Region(cmv1.NewCloudRegion().ID("us-east-1")).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	client.EXPECT().DescribeInstances(gomock.Any()).Return(&ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{
+		Instances: []*ec2.Instance{
+			{InstanceType: awsSdk.String("m5.xlarge")},
+			{InstanceType: awsSdk.String("m5.xlarge")},
+			{InstanceType: awsSdk.String("r5.xlarge")},
+		},
+	}}}, nil)
+	client.EXPECT().DescribeVolumes(gomock.Any()).Return(&ec2.DescribeVolumesOutput{Volumes: []*ec2.Volume{
+		{VolumeType: awsSdk.String("gp3"), Size: awsSdk.Int64(100)},
+		{VolumeType: awsSdk.String("gp3"), Size: awsSdk.Int64(300)},
+	}}, nil)
+	client.EXPECT().GetResources(gomock.Any()).Return(&resourcegroupstaggingapi.GetResourcesOutput{
+		ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+			{ResourceARN: awsSdk.String("arn:aws:elasticloadbalancing:us-east-1:111:loadbalancer/net/mycluster-int/abc")},
+		},
+	}, nil)
+	prices := map[string]awsSdk.JSONValue{
+		"m5.xlarge":             priceListProduct("Hrs", "0.192"),
+		"gp3":                   priceListProduct("GB-Mo", "0.08"),
+		"Load Balancer-Network": priceListProduct("Hrs", "0.0225"),
+	}
+	client.EXPECT().GetProducts(gomock.Any()).DoAndReturn(func(input *pricing.GetProductsInput) (*pricing.GetProductsOutput, error) {
+		output := &pricing.GetProductsOutput{}
+		for _, filter := range input.Filters {
+			if product, ok := prices[awsSdk.StringValue(filter.Value)]; ok {
+				output.PriceList = append(output.PriceList, product)
+			}
+		}
+		return output, nil
+	}).Times(4)
+	client.EXPECT().GetCostAndUsage(gomock.Any()).Return(nil, errors.New("AccessDeniedException: not enabled"))
+
+	response, err := estimateClusterCost(client, cluster, time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(response.Items).To(Equal([]costItem{
+		{Kind: "instance", Type: "m5.xlarge", Count: 2, Quantity: 1460, Unit: "hour", UnitPrice: 0.192, Monthly: 280.32},
+		{Kind: "instance", Type: "r5.xlarge", Count: 1, Quantity: 730, Unit: "hour"},
+		{Kind: "volume", Type: "gp3", Count: 2, Quantity: 400, Unit: "GB-month", UnitPrice: 0.08, Monthly: 32},
+		{Kind: "load balancer", Type: "network", Count: 1, Quantity: 730, Unit: "hour", UnitPrice: 0.0225, Monthly: 16.43},
+	}))
+	g.Expect(response.EstimateMonthly).To(Equal(328.75))
+	g.Expect(response.Warnings).To(ConsistOf(ContainSubstring("r5.xlarge")))
+	g.Expect(response.ActualLast30d).To(BeNil())
+	g.Expect(response.ActualError).To(ContainSubstring("not enabled"))
+}
+
+func TestActualCost(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+
+	group := func(service, amount string) *costexplorer.Group {
+		return &costexplorer.Group{Keys: awsSdk.StringSlice([]string{service}),
+			Metrics: map[string]*costexplorer.MetricValue{costexplorer.MetricUnblendedCost: {Amount: awsSdk.String(amount)}}}
+	}
+	client.EXPECT().GetCostAndUsage(gomock.Any()).Return(&costexplorer.GetCostAndUsageOutput{
+		ResultsByTime: []*costexplorer.ResultByTime{
+			{Groups: []*costexplorer.Group{group("Amazon Elastic Compute Cloud - Compute", "10.5"), group("Tax", "0.001")}},
+		},
+		NextPageToken: awsSdk.String("next"),
+	}, nil)
+	client.EXPECT().GetCostAndUsage(gomock.Any()).Return(&costexplorer.GetCostAndUsageOutput{
+		ResultsByTime: []*costexplorer.ResultByTime{
+			{Groups: []*costexplorer.Group{group("Amazon Elastic Compute Cloud - Compute", "10"), group("EC2 - Other", "30")}},
+		},
+	}, nil)
+
+	total, services, err := actualCost(client, time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(total).To(Equal(50.5))
+	g.Expect(services).To(Equal([]serviceCost{
+		{Service: "EC2 - Other", Amount: 30},
+		{Service: "Amazon Elastic Compute Cloud - Compute", Amount: 20.5},
+	}))
+}
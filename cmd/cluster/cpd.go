@@ -3,20 +3,52 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
 
 	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	"github.com/openshift/osdctl/cmd/network"
 	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 type cpdOptions struct {
 	clusterID  string
 	awsProfile string
+
+	awsClient aws.Client
+
+	genericclioptions.IOStreams
+}
+
+// cpdFinding is a likely cause of a provisioning delay. Findings with a higher
+// likelihood are printed first.
+type cpdFinding struct {
+	Check      string
+	Cause      string
+	NextStep   string
+	Likelihood int
+}
+
+// installLogPattern maps an error found in the install logs to its likely cause
+type installLogPattern struct {
+	match      []string
+	cause      string
+	nextStep   string
+	likelihood int
 }
 
 const (
@@ -28,30 +60,104 @@ Helps investigate OSD/ROSA cluster provisioning delays (CPD) or failures
 	
   * Check the cluster's dnszone.hive.openshift.io custom resource
   * Check whether a known OCM error code and message has been shared with the customer already
+  * Check the install logs for known errors
+  * Check the results of the network verifier run by OCM
   * Check that the cluster's VPC and/or subnet route table(s) contain a route for 0.0.0.0/0 if it's BYOVPC
+  * Check that the IAM roles of STS clusters exist and have the expected trust policies
+
+  The likely causes are printed ranked from most to least likely.
 `
 	cpdExample = `
   # Investigate a CPD for a cluster using an AWS profile named "rhcontrol"
-  osdctl cluster cpd --cluster-id 1kfmyclusteristhebesteverp8m --profile rhcontrol
+  osdctl cluster cpd 1kfmyclusteristhebesteverp8m --profile rhcontrol
 `
 )
 
-func newCmdCpd() *cobra.Command {
-	ops := cpdOptions{}
+var installLogPatterns = []installLogPattern{
+	{
+		match:      []string{"is not authorized to perform: sts:AssumeRole", "AssumeRoleWithWebIdentity"},
+		cause:      "An IAM role cannot be assumed, its trust policy is likely wrong",
+		nextStep:   "Compare the trust policies of the account and operator roles with the ones created by 'rosa create account-roles/operator-roles'",
+		likelihood: 90,
+	},
+	{
+		match:      []string{"AccessDenied: User:", "api error AccessDenied:", "UnauthorizedOperation: You are not authorized to perform this operation"},
+		cause:      "The installer is missing AWS permissions (IAM policy or SCP)",
+		nextStep:   "Check the SCPs and IAM policies of the account, send the ROSA_AWS_invalid_permissions service log if the customer changed them",
+		likelihood: 85,
+	},
+	{
+		match:      []string{"LimitExceeded", "VcpuLimitExceeded", "QuotaExceeded"},
+		cause:      "An AWS quota was reached",
+		nextStep:   "Check the service quotas of the account in the cluster region",
+		likelihood: 80,
+	},
+	{
+		match:      []string{"InsufficientInstanceCapacity", "Unsupported: Your requested instance type"},
+		cause:      "AWS has no capacity for the instance type in an availability zone",
+		nextStep:   "Retry the install later, or with another instance type or availability zone",
+		likelihood: 75,
+	},
+	{
+		match:      []string{"x509: certificate signed by unknown authority"},
+		cause:      "A TLS certificate isn't trusted, a proxy likely intercepts the traffic",
+		nextStep:   "Check the proxy and its additional trust bundle in the cluster configuration",
+		likelihood: 65,
+	},
+	{
+		match:      []string{"manifest unknown", "unauthorized: access to the requested resource is not authorized"},
+		cause:      "The release images cannot be pulled",
+		nextStep:   "Check the pull secret of the cluster and that quay.io is reachable from the VPC",
+		likelihood: 65,
+	},
+	{
+		match:      []string{"i/o timeout", "context deadline exceeded", "connection refused"},
+		cause:      "The cluster cannot reach a required endpoint, egress is likely blocked",
+		nextStep:   "Run 'osdctl network verify-egress' and check the firewall/proxy configuration",
+		likelihood: 70,
+	},
+	{
+		match:      []string{"no such host"},
+		cause:      "DNS resolution fails inside the VPC",
+		nextStep:   "Check the VPC DHCP options and that DNS hostnames/resolution are enabled",
+		likelihood: 70,
+	},
+	{
+		match:      []string{"error creating Route53 Hosted Zone", "HostedZoneAlreadyExists", "ConflictingDomainExists", "NoSuchHostedZone", "InvalidChangeBatch"},
+		cause:      "The cluster Route53 hosted zone could not be created or updated",
+		nextStep:   "Check the dnszone CR in the cluster namespace on hive",
+		likelihood: 60,
+	},
+	{
+		match:      []string{"Bootstrap failed to complete", "failed to wait for bootstrapping to complete"},
+		cause:      "The bootstrap node didn't bring up the control plane",
+		nextStep:   "Check bootstrap/journals/bootkube.log of the log bundle and the console output of the bootstrap instance",
+		likelihood: 40,
+	},
+}
+
+func newCmdCpd(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := cpdOptions{IOStreams: streams}
 	cpdCmd := &cobra.Command{
-		Use:               "cpd",
+		Use:               "cpd [CLUSTER_ID]",
 		Short:             "Runs diagnostic for a Cluster Provisioning Delay (CPD)",
 		Long:              cpdLongDescription,
 		Example:           cpdExample,
-		Args:              cobra.NoArgs,
+		Args:              cobra.MaximumNArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-
-			cmdutil.CheckErr(ops.run())
+			if len(args) == 1 {
+				ops.clusterID = args[0]
+			}
+			if ops.clusterID == "" {
+				osdctlErrors.CheckErr(cmdutil.UsageErrorf(cmd, "Provide exactly one cluster ID"))
+			}
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	cpdCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "C", ops.clusterID, "The internal/external (OCM) Cluster ID")
 	cpdCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", ops.awsProfile, "AWS profile name")
+	_ = cpdCmd.Flags().MarkDeprecated("cluster-id", "pass the cluster ID as an argument instead")
 
 	return cpdCmd
 }
@@ -66,65 +172,242 @@ func (o *cpdOptions) run() error {
 		return err
 	}
 
-	fmt.Println("Checking if cluster has become ready")
+	fmt.Fprintln(o.ErrOut, "Checking if cluster has become ready")
 	if cluster.Status().State() == "ready" {
-		fmt.Printf("This cluster is in a ready state and already provisioned")
+		fmt.Fprintln(o.Out, "This cluster is in a ready state and already provisioned")
 		return nil
 	}
 
-	fmt.Println("Checking if cluster DNS is ready")
-	// Check if DNS is ready, exit out if not
+	var findings []cpdFinding
+
+	fmt.Fprintln(o.ErrOut, "Checking if cluster DNS is ready")
 	if !cluster.Status().DNSReady() {
-		fmt.Println("DNS not ready. Investigate reasons using the dnszones CR in the cluster namespace:")
-		fmt.Printf("oc get dnszones -n uhc-production-%s -o yaml --as backplane-cluster-admin\n", o.clusterID)
-		return nil
+		findings = append(findings, cpdFinding{
+			Check:      "DNS zone",
+			Cause:      "The cluster DNS zone is not ready",
+			NextStep:   fmt.Sprintf("oc get dnszones -n uhc-production-%s -o yaml --as backplane-cluster-admin", cluster.ID()),
+			Likelihood: 95,
+		})
 	}
 
-	fmt.Println("Checking if OCM error code is already known")
+	fmt.Fprintln(o.ErrOut, "Checking if OCM error code is already known")
 	// Check if the OCM Error code is a known error
 	if len(cluster.Status().ProvisionErrorCode()) > 0 && cluster.Status().ProvisionErrorCode() != unknownProvisionCode {
-		fmt.Printf("Error code '%s' is known, customer already received Service Log\n", cluster.Status().ProvisionErrorCode())
+		fmt.Fprintf(o.ErrOut, "Error code '%s' is known, customer already received Service Log\n", cluster.Status().ProvisionErrorCode())
+	}
+
+	fmt.Fprintln(o.ErrOut, "Checking the install logs")
+	installLogs, err := ocmClient.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).Logs().Install().Get().Send()
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "Cannot retrieve the install logs: %v\n", err)
+	} else {
+		findings = append(findings, installLogFindings(installLogs.Body().Content())...)
 	}
 
-	fmt.Println("Checking if cluster is GCP")
+	fmt.Fprintln(o.ErrOut, "Checking the network verifier results")
+	inflightChecks, err := getInflightChecks(ocmClient, cluster.ID())
+	if err != nil {
+		fmt.Fprintf(o.ErrOut, "Cannot retrieve the network verifier results: %v\n", err)
+	} else {
+		findings = append(findings, inflightCheckFindings(inflightChecks)...)
+	}
+
+	fmt.Fprintln(o.ErrOut, "Checking if cluster is GCP")
 	// If the cluster is GCP, give instructions on how to get console access
 	if cluster.CloudProvider().ID() == "gcp" {
+		o.printFindings(findings)
 		return fmt.Errorf("this command doesn't support GCP yet. Needs manual investigation:\nocm backplane cloud console -b %s", o.clusterID)
 	}
 
-	fmt.Println("Generating AWS credentials for cluster")
-	// Get AWS credentials for the cluster
-	awsClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, o.clusterID)
-	if err != nil {
-		fmt.Println("PLEASE CONFIRM YOUR CREDENTIALS ARE CORRECT. If you're absolutely sure they are, send this Service Log https://github.com/openshift/managed-notifications/blob/master/osd/aws/ROSA_AWS_invalid_permissions.json")
-		fmt.Println(err)
-		return err
+	if o.awsClient == nil {
+		fmt.Fprintln(o.ErrOut, "Generating AWS credentials for cluster")
+		// Get AWS credentials for the cluster
+		o.awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, o.clusterID)
+		if err != nil {
+			o.printFindings(findings)
+			fmt.Fprintln(o.ErrOut, "PLEASE CONFIRM YOUR CREDENTIALS ARE CORRECT. If you're absolutely sure they are, send this Service Log https://github.com/openshift/managed-notifications/blob/master/osd/aws/ROSA_AWS_invalid_permissions.json")
+			return err
+		}
+	}
+
+	if cluster.AWS().STS().RoleARN() != "" {
+		fmt.Fprintln(o.ErrOut, "Checking the IAM role trust policies")
+		findings = append(findings, trustPolicyFindings(o.awsClient, cluster, o.ErrOut)...)
 	}
 
 	// If the cluster is BYOVPC, check the route tables
 	// This check is copied from ocm-cli
-	if cluster.AWS().SubnetIDs() != nil && len(cluster.AWS().SubnetIDs()) > 0 {
-		fmt.Println("Checking BYOVPC to ensure subnets have valid routing")
+	byovpc := cluster.AWS().SubnetIDs() != nil && len(cluster.AWS().SubnetIDs()) > 0
+	if byovpc {
+		fmt.Fprintln(o.ErrOut, "Checking BYOVPC to ensure subnets have valid routing")
 		for _, subnet := range cluster.AWS().SubnetIDs() {
-			isValid, err := isSubnetRouteValid(awsClient, subnet)
+			isValid, err := isSubnetRouteValid(o.awsClient, subnet)
 			if err != nil {
 				return err
 			}
 			if !isValid {
-				return fmt.Errorf("subnet %s does not have a default route to 0.0.0.0/0\n Run the following to send a SerivceLog:\n osdctl servicelog post %s -t https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/aws/InstallFailed_NoRouteToInternet.json", subnet, o.clusterID)
+				findings = append(findings, cpdFinding{
+					Check:      "Subnet routing",
+					Cause:      fmt.Sprintf("subnet %s does not have a default route to 0.0.0.0/0", subnet),
+					NextStep:   fmt.Sprintf("osdctl servicelog post %s -t https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/aws/InstallFailed_NoRouteToInternet.json", o.clusterID),
+					Likelihood: 90,
+				})
 			}
 		}
-		fmt.Printf("Attempting to run: osdctl network verify-egress --cluster-id %s\n", o.clusterID)
+	}
+
+	o.printFindings(findings)
+
+	if byovpc && len(inflightChecks) == 0 {
+		fmt.Fprintf(o.ErrOut, "Attempting to run: osdctl network verify-egress --cluster-id %s\n", o.clusterID)
 		ev := &network.EgressVerification{ClusterId: o.clusterID}
-		ev.Run(context.TODO())
-		return nil
+		return ev.Run(context.TODO())
 	}
 
-	fmt.Println("Next step: check the AWS resources manually, run ocm backplane cloud console")
+	fmt.Fprintln(o.Out, "Next step: check the AWS resources manually, run ocm backplane cloud console")
 
 	return nil
 }
 
+// installLogFindings returns the likely causes matching known errors in the install logs
+func installLogFindings(content string) []cpdFinding {
+	var findings []cpdFinding
+	for _, pattern := range installLogPatterns {
+		for _, match := range pattern.match {
+			if strings.Contains(content, match) {
+				findings = append(findings, cpdFinding{
+					Check:      "Install logs",
+					Cause:      fmt.Sprintf("%s ('%s' found in the install logs)", pattern.cause, match),
+					NextStep:   pattern.nextStep,
+					Likelihood: pattern.likelihood,
+				})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// getInflightChecks returns the network verifier results OCM gathered before the install
+func getInflightChecks(ocmClient *sdk.Connection, clusterID string) ([]*cmv1.InflightCheck, error) {
+	client := cmv1.NewInflightChecksClient(ocmClient, "/api/clusters_mgmt/v1/clusters/"+clusterID+"/inflight_checks")
+	response, err := client.List().Send()
+	if err != nil {
+		return nil, err
+	}
+	return response.Items().Slice(), nil
+}
+
+// inflightCheckFindings returns a finding for every failed network verifier check
+func inflightCheckFindings(checks []*cmv1.InflightCheck) []cpdFinding {
+	var findings []cpdFinding
+	for _, check := range checks {
+		if check.State() != cmv1.InflightCheckStateFailed {
+			continue
+		}
+		cause := fmt.Sprintf("The network verifier check '%s' failed", check.Name())
+		if details, ok := check.GetDetails(); ok {
+			cause = fmt.Sprintf("%s: %v", cause, details)
+		}
+		findings = append(findings, cpdFinding{
+			Check:      "Network verifier",
+			Cause:      cause,
+			NextStep:   "Check the firewall/proxy configuration for the blocked egress endpoints",
+			Likelihood: 85,
+		})
+	}
+	return findings
+}
+
+// trustPolicyFindings checks that the STS roles exist and that the operator roles trust the cluster OIDC provider, the
+// roles which can't be read are reported to errOut
+func trustPolicyFindings(awsClient aws.Client, cluster *cmv1.Cluster, errOut io.Writer) []cpdFinding {
+	var findings []cpdFinding
+	sts := cluster.AWS().STS()
+	oidcProvider := strings.TrimPrefix(sts.OIDCEndpointURL(), "https://")
+
+	roles := map[string]string{
+		"Installer": sts.RoleARN(),
+		"Support":   sts.SupportRoleARN(),
+		"Master":    sts.InstanceIAMRoles().MasterRoleARN(),
+		"Worker":    sts.InstanceIAMRoles().WorkerRoleARN(),
+	}
+	for _, operatorRole := range sts.OperatorIAMRoles() {
+		roles[fmt.Sprintf("Operator %s/%s", operatorRole.Namespace(), operatorRole.Name())] = operatorRole.RoleARN()
+	}
+
+	names := make([]string, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		arn := roles[name]
+		if arn == "" {
+			continue
+		}
+		policy, err := roleTrustPolicy(awsClient, arn)
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+				findings = append(findings, cpdFinding{
+					Check:      "IAM roles",
+					Cause:      fmt.Sprintf("%s role %s does not exist", name, arn),
+					NextStep:   "Ask the customer to re-create the account and operator roles",
+					Likelihood: 90,
+				})
+				continue
+			}
+			fmt.Fprintf(errOut, "Cannot get the %s role %s: %v\n", name, arn, err)
+			continue
+		}
+		if strings.HasPrefix(name, "Operator") && oidcProvider != "" && !strings.Contains(policy, oidcProvider) {
+			findings = append(findings, cpdFinding{
+				Check:      "IAM roles",
+				Cause:      fmt.Sprintf("%s role %s does not trust the cluster OIDC provider %s", name, arn, oidcProvider),
+				NextStep:   "Ask the customer to re-create the operator roles for this cluster",
+				Likelihood: 85,
+			})
+		}
+	}
+	return findings
+}
+
+// roleTrustPolicy returns the decoded trust policy of the role
+func roleTrustPolicy(awsClient aws.Client, arn string) (string, error) {
+	roleName := arn[strings.LastIndex(arn, "/")+1:]
+	output, err := awsClient.GetRole(&iam.GetRoleInput{RoleName: awsSdk.String(roleName)})
+	if err != nil {
+		return "", err
+	}
+	return url.QueryUnescape(awsSdk.StringValue(output.Role.AssumeRolePolicyDocument))
+}
+
+// rankCpdFindings sorts the findings from most to least likely
+func rankCpdFindings(findings []cpdFinding) {
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Likelihood > findings[j].Likelihood })
+}
+
+func (o *cpdOptions) printFindings(findings []cpdFinding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(o.Out, "No likely cause found")
+		return
+	}
+	rankCpdFindings(findings)
+
+	fmt.Fprintln(o.Out, "Likely causes, most likely first:")
+	table := printer.NewTablePrinter(o.Out, 20, 1, 3, ' ')
+	table.AddRow([]string{"#", "Check", "Cause", "Next Step"})
+	for i, finding := range findings {
+		table.AddRow([]string{fmt.Sprint(i + 1), finding.Check, finding.Cause, finding.NextStep})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		fmt.Fprintf(o.ErrOut, "error while flushing table: %v\n", err)
+	}
+}
+
 func isSubnetRouteValid(awsClient aws.Client, subnetID string) (bool, error) {
 	var routeTable string
 
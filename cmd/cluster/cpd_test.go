@@ -0,0 +1,103 @@
+package cluster
+
+import (
+	"io"
+	"net/url"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func TestInstallLogFindings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(installLogFindings("level=info msg=\"Creating infrastructure resources...\"")).To(BeEmpty())
+	// healthy installs log the resources and the permissions they check
+	g.Expect(installLogFindings(`level=debug msg="aws_route53_record.api_internal: Creation complete"
+level=debug msg="Created HostedZone Z0123456789 in route53"
+level=debug msg="Skipping the AccessDenied check of the permissions simulation"`)).To(BeEmpty())
+
+	findings := installLogFindings(`level=error msg="UnauthorizedOperation: You are not authorized to perform this operation"
+level=error msg="Error: VcpuLimitExceeded: You have requested more vCPU capacity"`)
+	g.Expect(findings).To(HaveLen(2))
+	g.Expect(findings[0].Cause).To(ContainSubstring("UnauthorizedOperation"))
+	g.Expect(findings[1].Cause).To(ContainSubstring("AWS quota was reached"))
+
+	findings = installLogFindings(`level=error msg="Error: error creating Route53 Hosted Zone: HostedZoneAlreadyExists: A hosted zone has already been created"`)
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].Cause).To(ContainSubstring("hosted zone could not be created"))
+}
+
+func TestInflightCheckFindings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	passed, err := cmv1.NewInflightCheck().Name("egress").State(cmv1.InflightCheckStatePassed).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	failed, err := cmv1.NewInflightCheck().Name("egress").State(cmv1.InflightCheckStateFailed).
+		Details(map[string]interface{}{"blocked": "quay.io:443"}).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(inflightCheckFindings([]*cmv1.InflightCheck{passed})).To(BeEmpty())
+	findings := inflightCheckFindings([]*cmv1.InflightCheck{passed, failed})
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].Cause).To(ContainSubstring("quay.io:443"))
+}
+
+func TestTrustPolicyFindings(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := awsmock.NewMockClient(mockCtrl)
+
+	cluster, err := cmv1.NewCluster().AWS(cmv1.NewAWS().STS(cmv1.NewSTS().
+		RoleARN("arn:aws:iam::123456789012:role/ManagedOpenShift-Installer-Role").
+		OIDCEndpointURL("https://oidc.example.com/abc").
+		OperatorIAMRoles(
+			cmv1.NewOperatorIAMRole().Namespace("openshift-ingress-operator").Name("cloud-credentials").
+				RoleARN("arn:aws:iam::123456789012:role/foo-openshift-ingress-operator-cloud-credentials"),
+			cmv1.NewOperatorIAMRole().Namespace("openshift-image-registry").Name("installer-cloud-credentials").
+				RoleARN("arn:aws:iam::123456789012:role/foo-openshift-image-registry-installer-cloud-creden"),
+		))).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	trusted := url.QueryEscape(`{"Statement":[{"Principal":{"Federated":"arn:aws:iam::123456789012:oidc-provider/oidc.example.com/abc"}}]}`)
+	untrusted := url.QueryEscape(`{"Statement":[{"Principal":{"Federated":"arn:aws:iam::123456789012:oidc-provider/oidc.example.com/other"}}]}`)
+
+	mockAWSClient.EXPECT().GetRole(&iam.GetRoleInput{RoleName: awsSdk.String("ManagedOpenShift-Installer-Role")}).
+		Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil))
+	mockAWSClient.EXPECT().GetRole(&iam.GetRoleInput{RoleName: awsSdk.String("foo-openshift-ingress-operator-cloud-credentials")}).
+		Return(&iam.GetRoleOutput{Role: &iam.Role{AssumeRolePolicyDocument: awsSdk.String(trusted)}}, nil)
+	mockAWSClient.EXPECT().GetRole(&iam.GetRoleInput{RoleName: awsSdk.String("foo-openshift-image-registry-installer-cloud-creden")}).
+		Return(&iam.GetRoleOutput{Role: &iam.Role{AssumeRolePolicyDocument: awsSdk.String(untrusted)}}, nil)
+
+	findings := trustPolicyFindings(mockAWSClient, cluster, io.Discard)
+	g.Expect(findings).To(HaveLen(2))
+	g.Expect(findings[0].Cause).To(ContainSubstring("Installer role"))
+	g.Expect(findings[0].Cause).To(ContainSubstring("does not exist"))
+	g.Expect(findings[1].Cause).To(ContainSubstring("openshift-image-registry"))
+	g.Expect(findings[1].Cause).To(ContainSubstring("does not trust"))
+}
+
+func TestRankCpdFindings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	findings := []cpdFinding{
+		{Cause: "egress", Likelihood: 70},
+		{Cause: "dns", Likelihood: 95},
+		{Cause: "subnet", Likelihood: 90},
+		{Cause: "proxy", Likelihood: 70},
+	}
+	rankCpdFindings(findings)
+
+	var causes []string
+	for _, finding := range findings {
+		causes = append(causes, finding.Cause)
+	}
+	g.Expect(causes).To(Equal([]string{"dns", "subnet", "egress", "proxy"}))
+}
@@ -0,0 +1,362 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1 "github.com/openshift/api/operator/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	credentialsModeLongDescription = `
+Reports how the cloud-credential-operator provides the cloud credentials of the cluster components
+
+  This command will:
+
+  * Tell whether the cluster uses mint, passthrough, manual or STS (manual with short-lived tokens) credentials
+  * List the CredentialsRequests and whether their credentials are provisioned
+  * Flag the conditions of the cloud-credential operator which block upgrades, Degraded or not Upgradeable
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID').
+`
+	credentialsModeExample = `
+  # The credentials mode and the CredentialsRequests of a cluster
+  osdctl cluster credentials-mode 1kfmyclusteristhebesteverp8m
+
+  # As JSON, e.g. to check the CredentialsRequests of several clusters
+  osdctl cluster credentials-mode 1kfmyclusteristhebesteverp8m -o json
+`
+
+	cloudCredentialOperatorNamespace = "openshift-cloud-credential-operator"
+	cloudCredentialOperator          = "cloud-credential"
+
+	// modeAnnotation is set by the cloud-credential-operator on the root credentials secret, with the mode it
+	// picked when none is configured
+	modeAnnotation = "cloudcredential.openshift.io/mode"
+
+	credentialsModeSTS = "STS"
+
+	credentialsRequestProvisioned    = "provisioned"
+	credentialsRequestNotProvisioned = "not provisioned"
+	credentialsRequestIgnored        = "ignored"
+	// credentialsRequestIgnoredCondition is true on the CredentialsRequests of another cloud provider
+	credentialsRequestIgnoredCondition = "Ignored"
+)
+
+// rootSecrets are the secrets holding the root cloud credentials the operator mints or passes through, by provider
+var rootSecrets = map[string]string{
+	"aws": "aws-creds",
+	"gcp": "gcp-credentials",
+}
+
+type credentialsModeOptions struct {
+	clusterID string
+
+	runOC         utils.OCRunner
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// credentialsRequest is the subset of a CredentialsRequest of the cloud-credential-operator read by the command
+type credentialsRequest struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		SecretRef corev1.ObjectReference `json:"secretRef"`
+	} `json:"spec"`
+	Status struct {
+		Provisioned bool `json:"provisioned"`
+		Conditions  []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+type credentialsRequestStatus struct {
+	Name        string `json:"name"`
+	Secret      string `json:"secret"`
+	Provisioned bool   `json:"provisioned"`
+	Status      string `json:"status"`
+	Message     string `json:"message,omitempty"`
+}
+
+// operatorCondition is a condition of the cloud-credential operator blocking upgrades
+type operatorCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type credentialsModeResponse struct {
+	ClusterID string `json:"cluster_id"`
+	// Mode is the effective mode: Mint, Passthrough, Manual or STS
+	Mode string `json:"mode"`
+	// ConfiguredMode is the mode of the CloudCredential, empty when the operator picks it
+	ConfiguredMode      string                     `json:"configured_mode"`
+	BlocksUpgrades      bool                       `json:"blocks_upgrades"`
+	Conditions          []operatorCondition        `json:"conditions"`
+	CredentialsRequests []credentialsRequestStatus `json:"credentials_requests"`
+}
+
+func (r credentialsModeResponse) String() string {
+	var b bytes.Buffer
+	configured := r.ConfiguredMode
+	if configured == "" {
+		configured = "default, picked by the operator"
+	}
+	fmt.Fprintf(&b, "Credentials mode: %s (configured: %s)\n", r.Mode, configured)
+	if len(r.Conditions) == 0 {
+		fmt.Fprintf(&b, "The %s operator is available, not degraded and upgradeable\n\n", cloudCredentialOperator)
+	} else {
+		fmt.Fprintf(&b, "The %s operator blocks upgrades:\n", cloudCredentialOperator)
+		table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+		table.AddRow([]string{"CONDITION", "STATUS", "REASON", "MESSAGE"})
+		for _, condition := range r.Conditions {
+			table.AddRow([]string{condition.Type, condition.Status, condition.Reason, truncateMessage(condition.Message)})
+		}
+		table.AddRow([]string{})
+		if err := table.Flush(); err != nil {
+			return fmt.Sprintf("cannot print the conditions: %v", err)
+		}
+	}
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"CREDENTIALS REQUEST", "SECRET", "STATUS", "MESSAGE"})
+	for _, request := range r.CredentialsRequests {
+		table.AddRow([]string{request.Name, request.Secret, request.Status, truncateMessage(request.Message)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the credentials requests: %v", err)
+	}
+	return b.String()
+}
+
+func newCmdCredentialsMode(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &credentialsModeOptions{runOC: utils.RunOCAsClusterAdmin, GlobalOptions: globalOpts}
+	credentialsModeCmd := &cobra.Command{
+		Use:               "credentials-mode CLUSTER_ID",
+		Short:             "Reports the cloud credentials mode of a cluster, its CredentialsRequests and the cloud-credential operator conditions blocking upgrades",
+		Long:              credentialsModeLongDescription,
+		Example:           credentialsModeExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	return credentialsModeCmd
+}
+
+func (o *credentialsModeOptions) run() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s has a hosted control plane, its credentials are managed by HyperShift", cluster.ID())
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	response, err := o.inspect(strings.ToLower(cluster.CloudProvider().ID()), cluster.AWS().STS().RoleARN() != "")
+	if err != nil {
+		return err
+	}
+	response.ClusterID = cluster.ID()
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// inspect reads the mode, the CredentialsRequests and the operator conditions, sts is true when OCM knows the
+// cluster as an STS one
+func (o *credentialsModeOptions) inspect(provider string, sts bool) (credentialsModeResponse, error) {
+	response := credentialsModeResponse{Conditions: []operatorCondition{}, CredentialsRequests: []credentialsRequestStatus{}}
+
+	mode, err := o.effectiveMode(provider, sts)
+	if err != nil {
+		return response, err
+	}
+	response.ConfiguredMode, response.Mode = mode.configured, mode.effective
+
+	output, err := o.runOC("get", "credentialsrequests", "-n", cloudCredentialOperatorNamespace, "-o", "json")
+	if err != nil {
+		return response, err
+	}
+	var requests struct {
+		Items []credentialsRequest `json:"items"`
+	}
+	if err := json.Unmarshal(output, &requests); err != nil {
+		return response, fmt.Errorf("cannot parse the credentials requests: %w", err)
+	}
+	for _, request := range requests.Items {
+		response.CredentialsRequests = append(response.CredentialsRequests, credentialsRequestState(request))
+	}
+	sortCredentialsRequests(response.CredentialsRequests)
+
+	output, err = o.runOC("get", "clusteroperator", cloudCredentialOperator, "-o", "json")
+	if err != nil {
+		return response, err
+	}
+	var operator configv1.ClusterOperator
+	if err := json.Unmarshal(output, &operator); err != nil {
+		return response, fmt.Errorf("cannot parse the %s cluster operator: %w", cloudCredentialOperator, err)
+	}
+	response.Conditions = blockingConditions(operator.Status.Conditions)
+	response.BlocksUpgrades = len(response.Conditions) > 0
+	return response, nil
+}
+
+type credentialsMode struct {
+	configured string
+	effective  string
+}
+
+// effectiveMode returns the configured mode and the one in use: the operator annotates the root credentials
+// secret with the mode it picked when none is configured, and manual clusters with a service account issuer use
+// short-lived tokens
+func (o *credentialsModeOptions) effectiveMode(provider string, sts bool) (credentialsMode, error) {
+	output, err := o.runOC("get", "cloudcredential", "cluster", "-o", "json")
+	if err != nil {
+		return credentialsMode{}, err
+	}
+	var cloudCredential operatorv1.CloudCredential
+	if err := json.Unmarshal(output, &cloudCredential); err != nil {
+		return credentialsMode{}, fmt.Errorf("cannot parse the cloud credential configuration: %w", err)
+	}
+	mode := credentialsMode{configured: string(cloudCredential.Spec.CredentialsMode), effective: string(cloudCredential.Spec.CredentialsMode)}
+
+	switch cloudCredential.Spec.CredentialsMode {
+	case operatorv1.CloudCredentialsModeDefault:
+		mode.effective = "unknown"
+		secret, ok := rootSecrets[provider]
+		if !ok {
+			return mode, nil
+		}
+		output, err := o.runOC("get", "secret", secret, "-n", "kube-system", "--ignore-not-found", "-o", "json")
+		if err != nil {
+			return mode, err
+		}
+		if len(bytes.TrimSpace(output)) == 0 {
+			// Without root credentials, the operator can only run in manual mode
+			mode.effective = string(operatorv1.CloudCredentialsModeManual)
+			break
+		}
+		var rootSecret corev1.Secret
+		if err := json.Unmarshal(output, &rootSecret); err != nil {
+			return mode, fmt.Errorf("cannot parse the root credentials secret: %w", err)
+		}
+		if annotation := rootSecret.Annotations[modeAnnotation]; annotation != "" {
+			mode.effective = strings.ToUpper(annotation[:1]) + annotation[1:]
+		}
+	case operatorv1.CloudCredentialsModeManual:
+		if sts {
+			mode.effective = credentialsModeSTS
+			break
+		}
+		output, err := o.runOC("get", "authentication", "cluster", "-o", "json")
+		if err != nil {
+			return mode, err
+		}
+		var authentication configv1.Authentication
+		if err := json.Unmarshal(output, &authentication); err != nil {
+			return mode, fmt.Errorf("cannot parse the authentication configuration: %w", err)
+		}
+		if authentication.Spec.ServiceAccountIssuer != "" {
+			mode.effective = credentialsModeSTS
+		}
+	}
+	return mode, nil
+}
+
+// credentialsRequestState tells whether the credentials of the request are provisioned, with the message of the
+// failing condition when they aren't
+func credentialsRequestState(request credentialsRequest) credentialsRequestStatus {
+	state := credentialsRequestStatus{
+		Name:        request.Metadata.Name,
+		Secret:      request.Spec.SecretRef.Namespace + "/" + request.Spec.SecretRef.Name,
+		Provisioned: request.Status.Provisioned,
+		Status:      credentialsRequestNotProvisioned,
+	}
+	if request.Status.Provisioned {
+		state.Status = credentialsRequestProvisioned
+	}
+	for _, condition := range request.Status.Conditions {
+		if condition.Status != string(corev1.ConditionTrue) {
+			continue
+		}
+		if condition.Type == credentialsRequestIgnoredCondition {
+			state.Status = credentialsRequestIgnored
+			return state
+		}
+		// Every other condition of a CredentialsRequest reports a failure, e.g. InsufficientCloudCreds
+		state.Status = condition.Type
+		state.Message = condition.Message
+	}
+	return state
+}
+
+// sortCredentialsRequests lists the failing requests first, then the not provisioned ones, the ignored ones last
+func sortCredentialsRequests(requests []credentialsRequestStatus) {
+	rank := func(status string) int {
+		switch status {
+		case credentialsRequestProvisioned:
+			return 2
+		case credentialsRequestIgnored:
+			return 3
+		case credentialsRequestNotProvisioned:
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		ri, rj := rank(requests[i].Status), rank(requests[j].Status)
+		if ri != rj {
+			return ri < rj
+		}
+		return requests[i].Name < requests[j].Name
+	})
+}
+
+// blockingConditions returns the conditions of the operator blocking upgrades: Degraded, or not Available nor
+// Upgradeable
+func blockingConditions(conditions []configv1.ClusterOperatorStatusCondition) []operatorCondition {
+	blocking := []operatorCondition{}
+	for _, condition := range conditions {
+		switch {
+		case condition.Type == configv1.OperatorDegraded && condition.Status == configv1.ConditionTrue,
+			condition.Type == configv1.OperatorAvailable && condition.Status != configv1.ConditionTrue,
+			condition.Type == configv1.OperatorUpgradeable && condition.Status == configv1.ConditionFalse:
+			blocking = append(blocking, operatorCondition{
+				Type:    string(condition.Type),
+				Status:  string(condition.Status),
+				Reason:  condition.Reason,
+				Message: condition.Message,
+			})
+		}
+	}
+	return blocking
+}
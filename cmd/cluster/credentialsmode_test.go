@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const testCredentialsRequests = `{"items": [
+  {"metadata": {"name": "openshift-image-registry"}, "spec": {"secretRef": {"namespace": "openshift-image-registry", "name": "installer-cloud-credentials"}},
+   "status": {"provisioned": true}},
+  {"metadata": {"name": "openshift-machine-api-aws"}, "spec": {"secretRef": {"namespace": "openshift-machine-api", "name": "aws-cloud-credentials"}},
+   "status": {"provisioned": false, "conditions": [{"type": "InsufficientCloudCreds", "status": "True", "message": "cannot mint the credentials: AccessDenied"}]}},
+  {"metadata": {"name": "openshift-machine-api-gcp"}, "spec": {"secretRef": {"namespace": "openshift-machine-api", "name": "gcp-cloud-credentials"}},
+   "status": {"provisioned": false, "conditions": [{"type": "Ignored", "status": "True"}]}},
+  {"metadata": {"name": "aws-ebs-csi-driver-operator"}, "spec": {"secretRef": {"namespace": "openshift-cluster-csi-drivers", "name": "ebs-cloud-credentials"}},
+   "status": {"provisioned": false}}
+]}`
+
+const testCloudCredentialOperator = `{"metadata": {"name": "cloud-credential"}, "status": {"conditions": [
+  {"type": "Available", "status": "True"},
+  {"type": "Degraded", "status": "True", "reason": "CredentialsFailing", "message": "1 of 4 credentials requests are failing to sync."},
+  {"type": "Upgradeable", "status": "True"}
+]}}`
+
+func credentialsModeRunner(mode, rootSecret, issuer string) func(args ...string) ([]byte, error) {
+	return func(args ...string) ([]byte, error) {
+		switch strings.Join(args, " ") {
+		case "get cloudcredential cluster -o json":
+			return []byte(fmt.Sprintf(`{"spec": {"credentialsMode": %q}}`, mode)), nil
+		case "get secret aws-creds -n kube-system --ignore-not-found -o json":
+			return []byte(rootSecret), nil
+		case "get authentication cluster -o json":
+			return []byte(fmt.Sprintf(`{"spec": {"serviceAccountIssuer": %q}}`, issuer)), nil
+		case "get credentialsrequests -n openshift-cloud-credential-operator -o json":
+			return []byte(testCredentialsRequests), nil
+		case "get clusteroperator cloud-credential -o json":
+			return []byte(testCloudCredentialOperator), nil
+		}
+		return nil, fmt.Errorf("unexpected oc %s", strings.Join(args, " "))
+	}
+}
+
+func TestCredentialsModeInspect(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := &credentialsModeOptions{runOC: credentialsModeRunner("", `{"metadata": {"annotations": {"cloudcredential.openshift.io/mode": "mint"}}}`, "")}
+
+	response, err := o.inspect("aws", false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(response.ConfiguredMode).To(BeEmpty())
+	g.Expect(response.Mode).To(Equal("Mint"))
+
+	g.Expect(response.BlocksUpgrades).To(BeTrue())
+	g.Expect(response.Conditions).To(HaveLen(1))
+	g.Expect(response.Conditions[0].Type).To(Equal("Degraded"))
+
+	// The failing requests first, the ignored ones last
+	g.Expect(response.CredentialsRequests).To(HaveLen(4))
+	g.Expect(response.CredentialsRequests[0].Name).To(Equal("openshift-machine-api-aws"))
+	g.Expect(response.CredentialsRequests[0].Status).To(Equal("InsufficientCloudCreds"))
+	g.Expect(response.CredentialsRequests[0].Message).To(ContainSubstring("AccessDenied"))
+	g.Expect(response.CredentialsRequests[1].Status).To(Equal(credentialsRequestNotProvisioned))
+	g.Expect(response.CredentialsRequests[2].Status).To(Equal(credentialsRequestProvisioned))
+	g.Expect(response.CredentialsRequests[2].Secret).To(Equal("openshift-image-registry/installer-cloud-credentials"))
+	g.Expect(response.CredentialsRequests[3].Status).To(Equal(credentialsRequestIgnored))
+
+	g.Expect(response.String()).To(ContainSubstring("blocks upgrades"))
+}
+
+func TestCredentialsModeEffectiveMode(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	tests := []struct {
+		name       string
+		mode       string
+		rootSecret string
+		issuer     string
+		sts        bool
+		expected   string
+	}{
+		{name: "configured passthrough", mode: "Passthrough", expected: "Passthrough"},
+		{name: "default without root credentials", mode: "", rootSecret: "", expected: "Manual"},
+		{name: "manual", mode: "Manual", expected: "Manual"},
+		{name: "manual with a service account issuer", mode: "Manual", issuer: "https://oidc.example.com/1234", expected: credentialsModeSTS},
+		{name: "STS in OCM", mode: "Manual", sts: true, expected: credentialsModeSTS},
+	}
+	for _, test := range tests {
+		o := &credentialsModeOptions{runOC: credentialsModeRunner(test.mode, test.rootSecret, test.issuer)}
+		mode, err := o.effectiveMode("aws", test.sts)
+		g.Expect(err).NotTo(HaveOccurred(), test.name)
+		g.Expect(mode.effective).To(Equal(test.expected), test.name)
+		g.Expect(mode.configured).To(Equal(test.mode), test.name)
+	}
+}
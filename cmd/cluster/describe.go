@@ -0,0 +1,221 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	describeLongDescription = `
+Describes a cluster: its identifiers, product, cloud account, owner and lifecycle timestamps.
+
+Clusters which were deleted are gone from the clusters API, but their subscription is kept. With --include-archived,
+a cluster the clusters API doesn't know anymore is described from its subscription instead, to answer "what was
+this cluster?" after its deletion. OCM doesn't record when a cluster was deleted: the last update of a
+deprovisioned or archived subscription is shown instead.
+`
+	describeExample = `
+  # Describe a cluster
+  osdctl cluster describe 1kfmyclusteristhebesteverp8m
+
+  # Describe a cluster that was deleted
+  osdctl cluster describe 1kfmyclusteristhebesteverp8m --include-archived -o json
+`
+
+	// Statuses of the subscriptions of the clusters that were deleted
+	subscriptionStatusDeprovisioned = "Deprovisioned"
+	subscriptionStatusArchived      = "Archived"
+)
+
+type describeOptions struct {
+	clusterID       string
+	includeArchived bool
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// clusterDescription is what is known about a cluster, live or deleted
+type clusterDescription struct {
+	ID             string     `json:"id" yaml:"id"`
+	ExternalID     string     `json:"external_id" yaml:"external_id"`
+	Name           string     `json:"name" yaml:"name"`
+	State          string     `json:"state" yaml:"state"`
+	Archived       bool       `json:"archived" yaml:"archived"`
+	Product        string     `json:"product" yaml:"product"`
+	CloudProvider  string     `json:"cloud_provider" yaml:"cloud_provider"`
+	Region         string     `json:"region" yaml:"region"`
+	CloudAccount   string     `json:"cloud_account,omitempty" yaml:"cloud_account,omitempty"`
+	SubscriptionID string     `json:"subscription_id" yaml:"subscription_id"`
+	OrganizationID string     `json:"organization_id" yaml:"organization_id"`
+	Owner          string     `json:"owner" yaml:"owner"`
+	OwnerEmail     string     `json:"owner_email" yaml:"owner_email"`
+	CreatedAt      time.Time  `json:"created_at" yaml:"created_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty" yaml:"deleted_at,omitempty"`
+}
+
+func (d clusterDescription) String() string {
+	var b bytes.Buffer
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	rows := [][]string{
+		{"ID:", d.ID},
+		{"External ID:", d.ExternalID},
+		{"Name:", d.Name},
+		{"State:", d.State},
+		{"Product:", d.Product},
+		{"Cloud Provider:", d.CloudProvider},
+		{"Region:", d.Region},
+		{"Cloud Account:", d.CloudAccount},
+		{"Subscription ID:", d.SubscriptionID},
+		{"Organization ID:", d.OrganizationID},
+		{"Owner:", fmt.Sprintf("%s (%s)", d.Owner, d.OwnerEmail)},
+		{"Created:", formatDescribeTime(&d.CreatedAt)},
+	}
+	if d.DeletedAt != nil {
+		rows = append(rows, []string{"Deleted (last update):", formatDescribeTime(d.DeletedAt)})
+	}
+	for _, row := range rows {
+		table.AddRow(row)
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the cluster: %v", err)
+	}
+	return b.String()
+}
+
+func formatDescribeTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "unknown"
+	}
+	return timefmt.Format(*t)
+}
+
+// NewCmdDescribe implements the cluster describe command, also run as osdctl describe cluster
+func NewCmdDescribe(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &describeOptions{GlobalOptions: globalOpts}
+	describeCmd := &cobra.Command{
+		Use:               "describe CLUSTER_ID",
+		Short:             "Describe a cluster, including deleted ones with --include-archived",
+		Long:              describeLongDescription,
+		Example:           describeExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	describeCmd.Flags().BoolVar(&ops.includeArchived, "include-archived", false, "Describe the cluster from its subscription when it was deleted")
+
+	return describeCmd
+}
+
+func (o *describeOptions) run() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err == nil {
+		var subscription *amv1.Subscription
+		if cluster.Subscription().ID() != "" {
+			if subscription, err = getSubscriptionWithCreator(connection, cluster.Subscription().ID()); err != nil {
+				return err
+			}
+		}
+		return outputflag.PrintResponse(o.GlobalOptions.Output, describeCluster(cluster, subscription))
+	}
+	if !o.includeArchived {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "%v, use --include-archived to describe a deleted cluster", err)
+	}
+
+	// The subscriptions of the deleted clusters are still found by their cluster ID, external ID or name
+	found, err := utils.GetSubscription(connection, o.clusterID)
+	if err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "%v", err)
+	}
+	subscription, err := getSubscriptionWithCreator(connection, found.ID())
+	if err != nil {
+		return err
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, describeSubscription(subscription))
+}
+
+// getSubscriptionWithCreator returns the subscription with its creator, which the searches don't return
+func getSubscriptionWithCreator(connection *sdk.Connection, subscriptionID string) (*amv1.Subscription, error) {
+	response, err := connection.AccountsMgmt().V1().Subscriptions().Subscription(subscriptionID).Get().
+		Parameter("fetchAccounts", true).Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve subscription '%s': %w", subscriptionID, err)
+	}
+	return response.Body(), nil
+}
+
+// describeCluster describes a cluster the clusters API knows, with the owner from its subscription when there is one
+func describeCluster(cluster *cmv1.Cluster, subscription *amv1.Subscription) clusterDescription {
+	description := clusterDescription{
+		ID:             cluster.ID(),
+		ExternalID:     cluster.ExternalID(),
+		Name:           cluster.Name(),
+		State:          string(cluster.State()),
+		Product:        cluster.Product().ID(),
+		CloudProvider:  cluster.CloudProvider().ID(),
+		Region:         cluster.Region().ID(),
+		SubscriptionID: cluster.Subscription().ID(),
+		CloudAccount:   cluster.AWS().AccountID(),
+		CreatedAt:      cluster.CreationTimestamp(),
+	}
+	if description.CloudAccount == "" {
+		description.CloudAccount = cluster.GCP().ProjectID()
+	}
+	if subscription != nil {
+		description.OrganizationID = subscription.OrganizationID()
+		description.Owner = subscription.Creator().Username()
+		description.OwnerEmail = subscription.Creator().Email()
+		if description.CloudAccount == "" {
+			description.CloudAccount = subscription.CloudAccountID()
+		}
+	}
+	return description
+}
+
+// describeSubscription describes a cluster the clusters API doesn't know anymore from its subscription
+func describeSubscription(subscription *amv1.Subscription) clusterDescription {
+	description := clusterDescription{
+		ID:             subscription.ClusterID(),
+		ExternalID:     subscription.ExternalClusterID(),
+		Name:           subscription.DisplayName(),
+		State:          subscription.Status(),
+		Product:        subscription.Plan().ID(),
+		CloudProvider:  subscription.CloudProviderID(),
+		Region:         subscription.RegionID(),
+		CloudAccount:   subscription.CloudAccountID(),
+		SubscriptionID: subscription.ID(),
+		OrganizationID: subscription.OrganizationID(),
+		Owner:          subscription.Creator().Username(),
+		OwnerEmail:     subscription.Creator().Email(),
+		CreatedAt:      subscription.CreatedAt(),
+	}
+	if status := subscription.Status(); status == subscriptionStatusDeprovisioned || status == subscriptionStatusArchived {
+		description.Archived = true
+		deletedAt := subscription.UpdatedAt()
+		description.DeletedAt = &deletedAt
+	}
+	return description
+}
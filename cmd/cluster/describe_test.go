@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func testSubscription(status string) *amv1.Subscription {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(48 * time.Hour)
+	subscription, err := amv1.NewSubscription().
+		ID("sub-1").
+		ClusterID("cluster-1").
+		ExternalClusterID("external-1").
+		DisplayName("my-cluster").
+		Status(status).
+		Plan(amv1.NewPlan().ID("MOA")).
+		CloudProviderID("aws").
+		RegionID("us-east-1").
+		CloudAccountID("123456789012").
+		OrganizationID("org-1").
+		Creator(amv1.NewAccount().Username("jdoe").Email("jdoe@example.com")).
+		CreatedAt(created).
+		UpdatedAt(updated).
+		Build()
+	if err != nil {
+		panic(err)
+	}
+	return subscription
+}
+
+func TestDescribeSubscription(t *testing.T) {
+	g := NewGomegaWithT(t)
+	useUTC(t)
+
+	description := describeSubscription(testSubscription(subscriptionStatusDeprovisioned))
+	g.Expect(description.ID).To(Equal("cluster-1"))
+	g.Expect(description.Product).To(Equal("MOA"))
+	g.Expect(description.CloudAccount).To(Equal("123456789012"))
+	g.Expect(description.Owner).To(Equal("jdoe"))
+	g.Expect(description.Archived).To(BeTrue())
+	g.Expect(description.DeletedAt).ToNot(BeNil())
+	g.Expect(*description.DeletedAt).To(Equal(time.Date(2024, 1, 4, 3, 4, 5, 0, time.UTC)))
+	g.Expect(description.String()).To(ContainSubstring("2024-01-04 03:04:05 UTC"))
+
+	active := describeSubscription(testSubscription("Active"))
+	g.Expect(active.Archived).To(BeFalse())
+	g.Expect(active.DeletedAt).To(BeNil())
+}
+
+func TestDescribeCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cluster, err := cmv1.NewCluster().
+		ID("cluster-1").
+		Name("my-cluster").
+		State(cmv1.ClusterStateReady).
+		Product(cmv1.NewProduct().ID("rosa")).
+		CloudProvider(cmv1.NewCloudProvider().ID("aws")).
+		Region(cmv1.NewCloudRegion().ID("us-east-1")).
+		Subscription(cmv1.NewSubscription().ID("sub-1")).
+		Build()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	description := describeCluster(cluster, testSubscription("Active"))
+	g.Expect(description.State).To(Equal("ready"))
+	g.Expect(description.Product).To(Equal("rosa"))
+	g.Expect(description.CloudAccount).To(Equal("123456789012"))
+	g.Expect(description.OwnerEmail).To(Equal("jdoe@example.com"))
+	g.Expect(description.Archived).To(BeFalse())
+
+	g.Expect(describeCluster(cluster, nil).Owner).To(BeEmpty())
+}
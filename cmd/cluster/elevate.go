@@ -0,0 +1,303 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/guardrails"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	elevateLong = `Grants cluster-admin to the current backplane user for --duration, and schedules the de-elevation on the cluster.
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'). The cluster the
+  current kubeconfig points to is checked against CLUSTER_ID.
+
+  The elevation is a ClusterRoleBinding owned by a dedicated namespace, where a job deletes the namespace once the
+  elevation expires, the binding being garbage collected with it. The job keeps the expiry across restarts, and
+  the elevation can be ended early by deleting the namespace. A justification is required with --reason, and
+  both the elevation and the scheduled de-elevation are recorded in the audit log.`
+
+	elevateExample = `
+  # cluster-admin for an hour
+  osdctl cluster elevate 1kfmyclusteristhebesteverp8m --duration 1h --reason "console down, OHSS-1234"
+`
+
+	// The bounds of --duration
+	minElevationDuration = 10 * time.Minute
+	maxElevationDuration = 8 * time.Hour
+
+	elevationPrefix           = "osdctl-elevate-"
+	elevationCreatedBy        = "osdctl"
+	elevationReasonAnnotation = "osdctl.openshift.io/reason"
+	elevationExpiryAnnotation = "osdctl.openshift.io/expires-at"
+	elevationUserAnnotation   = "osdctl.openshift.io/user"
+
+	serviceAccountUserPrefix = "system:serviceaccount:"
+)
+
+type elevateOptions struct {
+	clusterID string
+	duration  time.Duration
+	yes       bool
+
+	runOC utils.OCRunner
+	// whoami runs oc as the current user, without impersonation
+	whoami utils.OCRunner
+	now    func() time.Time
+}
+
+func newCmdElevate() *cobra.Command {
+	ops := &elevateOptions{runOC: utils.RunOCAsClusterAdmin, whoami: utils.RunOC, now: time.Now}
+	elevateCmd := &cobra.Command{
+		Use:               "elevate CLUSTER_ID",
+		Short:             "Grants an expiring cluster-admin to the current backplane user",
+		Long:              elevateLong,
+		Example:           elevateExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run(cmd))
+		},
+	}
+	elevateCmd.Flags().DurationVar(&ops.duration, "duration", time.Hour, "How long the elevation lasts, from 10m to 8h")
+	elevateCmd.Flags().BoolVarP(&ops.yes, "yes", "y", false, "Skip the confirmation prompt")
+
+	return elevateCmd
+}
+
+func (o *elevateOptions) complete(cmd *cobra.Command) error {
+	if o.duration < minElevationDuration || o.duration > maxElevationDuration {
+		return cmdutil.UsageErrorf(cmd, "--duration must be between %s and %s, got %s", minElevationDuration, maxElevationDuration, o.duration)
+	}
+	if guardrails.Reason(cmd) == "" {
+		return cmdutil.UsageErrorf(cmd, "elevating requires a justification with --%s", guardrails.ReasonFlag)
+	}
+	return utils.IsValidClusterKey(o.clusterID)
+}
+
+func (o *elevateOptions) run(cmd *cobra.Command) error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	output, err := o.whoami("whoami")
+	if err != nil {
+		return err
+	}
+	user := strings.TrimSpace(string(output))
+	if user == "" {
+		return fmt.Errorf("cannot tell the current user from 'oc whoami'")
+	}
+
+	now := o.now().UTC()
+	elevation := newElevation(user, guardrails.Reason(cmd), now, o.duration)
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("Grant cluster-admin to %s until %s", user, timefmt.Format(elevation.expiresAt))),
+		SkipPrompt: o.yes,
+	})
+	if err != nil {
+		return err
+	}
+
+	image, err := o.cliImage()
+	if err != nil {
+		return err
+	}
+	if err := elevation.create(o.runOC, image); err != nil {
+		return err
+	}
+
+	environment := utils.GetCurrentOCMEnv(connection)
+	for _, action := range []string{"elevate", "de-elevate"} {
+		if err := guardrails.WriteAuditRecord(guardrails.AuditRecord{
+			Command:     cmd.CommandPath(),
+			Args:        []string{o.clusterID, action, user, elevation.name},
+			Environment: environment,
+			Reason:      guardrails.Reason(cmd),
+			Ticket:      flagString(cmd, guardrails.TicketFlag),
+			Cluster:     cluster.ID(),
+			ExpiresAt:   &elevation.expiresAt,
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: unable to record the %s in the audit log: %v\n", action, err)
+		}
+	}
+
+	utils.RecordClusterAction(connection, cluster, fmt.Sprintf("Grant cluster-admin to %s until %s", user, elevation.expiresAt.Format(time.RFC3339)))
+	fmt.Fprintf(os.Stderr, "%s is cluster-admin until %s, end it early with 'oc delete namespace %s --as %s'\n",
+		user, timefmt.Format(elevation.expiresAt), elevation.name, BackplaneClusterAdmin)
+	return nil
+}
+
+// cliImage returns the oc image of the release of the cluster, which the nodes can pull
+func (o *elevateOptions) cliImage() (string, error) {
+	output, err := o.runOC("get", "imagestreamtag", "cli:latest", "-n", "openshift", "-o", "jsonpath={.image.dockerImageReference}")
+	if err != nil {
+		return "", err
+	}
+	image := strings.TrimSpace(string(output))
+	if image == "" {
+		return "", fmt.Errorf("cannot find the oc image of the cluster in the openshift/cli image stream")
+	}
+	return image, nil
+}
+
+// elevation is the cluster-admin binding of a user, and the job revoking it
+type elevation struct {
+	name      string
+	user      string
+	reason    string
+	expiresAt time.Time
+}
+
+func newElevation(user, reason string, now time.Time, duration time.Duration) elevation {
+	return elevation{
+		name:      elevationPrefix + now.Format("20060102t150405"),
+		user:      user,
+		reason:    reason,
+		expiresAt: now.Add(duration).Truncate(time.Second),
+	}
+}
+
+func (e elevation) objectMeta(name, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    map[string]string{"app.kubernetes.io/created-by": elevationCreatedBy},
+		Annotations: map[string]string{
+			elevationReasonAnnotation: e.reason,
+			elevationExpiryAnnotation: e.expiresAt.Format(time.RFC3339),
+			elevationUserAnnotation:   e.user,
+		},
+	}
+}
+
+// subject returns the RBAC subject of the user, backplane users being service accounts or users
+func (e elevation) subject() rbacv1.Subject {
+	if parts := strings.Split(strings.TrimPrefix(e.user, serviceAccountUserPrefix), ":"); strings.HasPrefix(e.user, serviceAccountUserPrefix) && len(parts) == 2 {
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: parts[0], Name: parts[1]}
+	}
+	return rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: e.user}
+}
+
+// create creates the namespace first, which owns everything else so that deleting it ends the elevation
+func (e elevation) create(run utils.OCRunner, image string) error {
+	namespace := &corev1.Namespace{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"}, ObjectMeta: e.objectMeta(e.name, "")}
+	manifest, err := json.Marshal(namespace)
+	if err != nil {
+		return err
+	}
+	uid, err := createFromManifest(run, manifest, "-o", "jsonpath={.metadata.uid}")
+	if err != nil {
+		return fmt.Errorf("cannot create the namespace of the elevation: %w", err)
+	}
+
+	resources, err := e.resources(types.UID(strings.TrimSpace(string(uid))), image)
+	if err == nil {
+		manifest, err = json.Marshal(resources)
+	}
+	if err == nil {
+		_, err = createFromManifest(run, manifest)
+	}
+	if err != nil {
+		if _, cleanupErr := run("delete", "namespace", e.name, "--wait=false"); cleanupErr != nil {
+			return fmt.Errorf("cannot elevate: %w, and the namespace %s couldn't be deleted: %v", err, e.name, cleanupErr)
+		}
+		return fmt.Errorf("cannot elevate: %w", err)
+	}
+	return nil
+}
+
+// resources returns the binding of the user, and the job deleting the namespace of the elevation on expiry with the
+// only permission of deleting it
+func (e elevation) resources(namespaceUID types.UID, image string) (*corev1.List, error) {
+	owner := []metav1.OwnerReference{{APIVersion: "v1", Kind: "Namespace", Name: e.name, UID: namespaceUID}}
+	revoker := e.name + "-revoker"
+
+	binding := &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+		ObjectMeta: e.objectMeta(e.name, ""),
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "cluster-admin"},
+		Subjects:   []rbacv1.Subject{e.subject()},
+	}
+	binding.OwnerReferences = owner
+
+	revokerRole := &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: e.objectMeta(revoker, ""),
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups:     []string{""},
+			Resources:     []string{"namespaces"},
+			ResourceNames: []string{e.name},
+			Verbs:         []string{"get", "delete"},
+		}},
+	}
+	revokerRole.OwnerReferences = owner
+
+	revokerBinding := &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+		ObjectMeta: e.objectMeta(revoker, ""),
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: revoker},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: e.name, Name: revoker}},
+	}
+	revokerBinding.OwnerReferences = owner
+
+	serviceAccount := &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: e.objectMeta(revoker, e.name),
+	}
+
+	// Waiting until the expiry, rather than for the duration, keeps it when the pod is restarted
+	script := fmt.Sprintf("while [ \"$(date +%%s)\" -lt %d ]; do sleep 30; done; oc delete namespace %s --wait=false", e.expiresAt.Unix(), e.name)
+	job := &batchv1.Job{
+		TypeMeta:   metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "Job"},
+		ObjectMeta: e.objectMeta(revoker, e.name),
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32(100),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: revoker,
+					RestartPolicy:      corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{{
+						Name:    "revoke",
+						Image:   image,
+						Command: []string{"/bin/sh", "-c", script},
+					}},
+				},
+			},
+		},
+	}
+
+	list := &corev1.List{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "List"}}
+	for _, object := range []interface{}{binding, revokerRole, revokerBinding, serviceAccount, job} {
+		raw, err := json.Marshal(object)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, runtime.RawExtension{Raw: raw})
+	}
+	return list, nil
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestElevationSubject(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	subject := elevation{user: "system:serviceaccount:openshift-backplane-srep:1a2b3c"}.subject()
+	g.Expect(subject).To(Equal(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "openshift-backplane-srep", Name: "1a2b3c"}))
+
+	subject = elevation{user: "jdoe@example.com"}.subject()
+	g.Expect(subject).To(Equal(rbacv1.Subject{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "jdoe@example.com"}))
+}
+
+func TestElevationCreate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+	e := newElevation("system:serviceaccount:openshift-backplane-srep:1a2b3c", "console down", now, time.Hour)
+	g.Expect(e.name).To(Equal("osdctl-elevate-20261014t093000"))
+
+	var manifests [][]byte
+	run := func(args ...string) ([]byte, error) {
+		if len(args) < 3 || args[0] != "create" || args[1] != "-f" {
+			return nil, fmt.Errorf("unexpected oc %s", strings.Join(args, " "))
+		}
+		manifest, err := os.ReadFile(args[2])
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+		return []byte("0c6a5efa-8a4f-4a8e-9a40-0ee1c3a4e7d2"), nil
+	}
+	g.Expect(e.create(run, "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:abc")).To(Succeed())
+	g.Expect(manifests).To(HaveLen(2))
+
+	// The namespace first, owning everything else
+	var namespace corev1.Namespace
+	g.Expect(json.Unmarshal(manifests[0], &namespace)).To(Succeed())
+	g.Expect(namespace.Name).To(Equal(e.name))
+	g.Expect(namespace.Annotations).To(HaveKeyWithValue(elevationExpiryAnnotation, "2026-10-14T10:30:00Z"))
+
+	var list corev1.List
+	g.Expect(json.Unmarshal(manifests[1], &list)).To(Succeed())
+	g.Expect(list.Items).To(HaveLen(5))
+
+	var binding rbacv1.ClusterRoleBinding
+	g.Expect(json.Unmarshal(list.Items[0].Raw, &binding)).To(Succeed())
+	g.Expect(binding.RoleRef.Name).To(Equal("cluster-admin"))
+	g.Expect(binding.Subjects[0].Name).To(Equal("1a2b3c"))
+	g.Expect(binding.OwnerReferences).To(HaveLen(1))
+	g.Expect(string(binding.OwnerReferences[0].UID)).To(Equal("0c6a5efa-8a4f-4a8e-9a40-0ee1c3a4e7d2"))
+
+	// The revoker can only delete the namespace of the elevation
+	var role rbacv1.ClusterRole
+	g.Expect(json.Unmarshal(list.Items[1].Raw, &role)).To(Succeed())
+	g.Expect(role.Rules).To(HaveLen(1))
+	g.Expect(role.Rules[0].ResourceNames).To(Equal([]string{e.name}))
+
+	// The job waits until the expiry
+	var job batchv1.Job
+	g.Expect(json.Unmarshal(list.Items[4].Raw, &job)).To(Succeed())
+	g.Expect(job.Namespace).To(Equal(e.name))
+	script := job.Spec.Template.Spec.Containers[0].Command[2]
+	g.Expect(script).To(ContainSubstring(fmt.Sprintf("-lt %d ]", now.Add(time.Hour).Unix())))
+	g.Expect(script).To(HaveSuffix("oc delete namespace " + e.name + " --wait=false"))
+}
+
+func TestElevationCreateCleansUp(t *testing.T) {
+	g := NewGomegaWithT(t)
+	e := newElevation("jdoe@example.com", "console down", time.Now(), time.Hour)
+
+	creates := 0
+	var deleted string
+	run := func(args ...string) ([]byte, error) {
+		switch args[0] {
+		case "create":
+			if creates++; creates == 2 {
+				return nil, fmt.Errorf("forbidden")
+			}
+			return []byte("uid"), nil
+		case "delete":
+			deleted = strings.Join(args, " ")
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected oc %s", strings.Join(args, " "))
+	}
+	g.Expect(e.create(run, "image")).To(MatchError(ContainSubstring("forbidden")))
+	g.Expect(deleted).To(Equal("delete namespace " + e.name + " --wait=false"))
+}
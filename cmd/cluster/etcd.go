@@ -0,0 +1,509 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/poll"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	etcdNamespace = "openshift-etcd"
+
+	etcdLong = `Reports the health of the etcd members of a cluster and defragments them.
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'), the commands are
+  run as backplane-cluster-admin. The cluster the current kubeconfig points to is checked against CLUSTER_ID.`
+
+	etcdExample = `
+  # Show member health, DB sizes, raft terms and leader changes of the last hour
+  osdctl cluster etcd status 1kfmyclusteristhebesteverp8m
+
+  # Print the defragmentation order without running it
+  osdctl cluster etcd defrag 1kfmyclusteristhebesteverp8m --dry-run
+
+  # Defragment every member, followers first and the leader last
+  osdctl cluster etcd defrag 1kfmyclusteristhebesteverp8m
+`
+
+	leaderChangesQuery = `increase(etcd_server_leader_changes_seen_total{job=~".*etcd.*"}[1h])`
+)
+
+type etcdOptions struct {
+	clusterID   string
+	dryRun      bool
+	skipPrompts bool
+	timeout     time.Duration
+
+	run utils.OCRunner
+}
+
+// etcdMember is the status of an etcd member, as reported by etcdctl
+type etcdMember struct {
+	Pod           string
+	Endpoint      string
+	MemberID      uint64
+	Leader        bool
+	Healthy       bool
+	HealthError   string
+	DBSize        int64
+	DBSizeInUse   int64
+	RaftTerm      uint64
+	Version       string
+	LeaderChanges string
+}
+
+// etcdClient runs etcdctl in the etcd pods of the cluster
+type etcdClient struct {
+	run utils.OCRunner
+	// pods maps the member IPs to their pod, etcd runs on the host network
+	pods map[string]string
+}
+
+func newCmdEtcd() *cobra.Command {
+	etcdCmd := &cobra.Command{
+		Use:               "etcd",
+		Short:             "Reports etcd health and defragments etcd members",
+		Long:              etcdLong,
+		Example:           etcdExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	statusOpts := &etcdOptions{run: utils.RunOCAsClusterAdmin}
+	etcdCmd.AddCommand(&cobra.Command{
+		Use:               "status CLUSTER_ID",
+		Short:             "Reports etcd member health, DB sizes and leader changes",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			statusOpts.clusterID = args[0]
+			osdctlErrors.CheckErr(statusOpts.runStatus())
+		},
+	})
+
+	defragOpts := &etcdOptions{run: utils.RunOCAsClusterAdmin}
+	defragCmd := &cobra.Command{
+		Use:   "defrag CLUSTER_ID",
+		Short: "Defragments the etcd members one at a time, aborting on unhealthy members or leader changes",
+		Long: `Defragments the etcd members one at a time, followers first and the leader last.
+
+  Every member must be healthy before starting. After each member the command waits for it to be healthy
+  again and aborts if the raft term changed, which means the leader changed during the defragmentation.`,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			defragOpts.clusterID = args[0]
+			osdctlErrors.CheckErr(defragOpts.runDefrag())
+		},
+	}
+	defragCmd.Flags().BoolVarP(&defragOpts.dryRun, "dry-run", "d", false, "Print the members in the order they would be defragmented")
+	defragCmd.Flags().BoolVarP(&defragOpts.skipPrompts, "yes", "y", false, "Skips all prompts.")
+	defragCmd.Flags().DurationVar(&defragOpts.timeout, "timeout", 2*time.Minute, "How long to wait for a defragmented member to be healthy again")
+	etcdCmd.AddCommand(defragCmd)
+
+	return etcdCmd
+}
+
+// connect checks that the current kubeconfig points to the cluster and finds its etcd pods
+func (o *etcdOptions) connect() (*etcdClient, error) {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return nil, err
+	}
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := utils.CheckOCCluster(o.run, cluster); err != nil {
+		return nil, err
+	}
+
+	return newEtcdClient(o.run)
+}
+
+func newEtcdClient(run utils.OCRunner) (*etcdClient, error) {
+	output, err := run("get", "pods", "-n", etcdNamespace, "-l", "app=etcd", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var pods struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Status struct {
+				Phase string `json:"phase"`
+				PodIP string `json:"podIP"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(output, &pods); err != nil {
+		return nil, fmt.Errorf("cannot parse the etcd pods: %w", err)
+	}
+
+	client := &etcdClient{run: run, pods: map[string]string{}}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == "Running" && pod.Status.PodIP != "" {
+			client.pods[pod.Status.PodIP] = pod.Metadata.Name
+		}
+	}
+	if len(client.pods) == 0 {
+		return nil, fmt.Errorf("no running etcd pod found in %s", etcdNamespace)
+	}
+	return client, nil
+}
+
+// anyPod returns a running etcd pod to run cluster-wide etcdctl commands from
+func (c *etcdClient) anyPod() string {
+	pods := make([]string, 0, len(c.pods))
+	for _, pod := range c.pods {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+	return pods[0]
+}
+
+func (c *etcdClient) etcdctl(pod string, args ...string) ([]byte, error) {
+	return c.run(append([]string{"exec", "-n", etcdNamespace, "-c", "etcdctl", pod, "--", "etcdctl"}, args...)...)
+}
+
+// members returns the status and health of every member
+func (c *etcdClient) members() ([]etcdMember, error) {
+	pod := c.anyPod()
+	statusOutput, err := c.etcdctl(pod, "endpoint", "status", "--cluster", "-w", "json")
+	if err != nil {
+		return nil, err
+	}
+	// endpoint health exits non-zero when a member is unhealthy, the output still has every member
+	healthOutput, healthErr := c.etcdctl(pod, "endpoint", "health", "--cluster", "-w", "json")
+	if healthErr != nil && len(healthOutput) == 0 {
+		return nil, healthErr
+	}
+	return parseEtcdMembers(statusOutput, healthOutput, c.pods)
+}
+
+func parseEtcdMembers(statusOutput, healthOutput []byte, pods map[string]string) ([]etcdMember, error) {
+	var statuses []struct {
+		Endpoint string `json:"Endpoint"`
+		Status   struct {
+			Header struct {
+				MemberID uint64 `json:"member_id"`
+			} `json:"header"`
+			Version     string `json:"version"`
+			DBSize      int64  `json:"dbSize"`
+			DBSizeInUse int64  `json:"dbSizeInUse"`
+			Leader      uint64 `json:"leader"`
+			RaftTerm    uint64 `json:"raftTerm"`
+		} `json:"Status"`
+	}
+	if err := json.Unmarshal(statusOutput, &statuses); err != nil {
+		return nil, fmt.Errorf("cannot parse the etcd endpoint status: %w", err)
+	}
+
+	var healths []struct {
+		Endpoint string `json:"endpoint"`
+		Health   bool   `json:"health"`
+		Error    string `json:"error"`
+	}
+	if len(healthOutput) > 0 {
+		if err := json.Unmarshal(healthOutput, &healths); err != nil {
+			return nil, fmt.Errorf("cannot parse the etcd endpoint health: %w", err)
+		}
+	}
+
+	members := make([]etcdMember, 0, len(statuses))
+	for _, status := range statuses {
+		member := etcdMember{
+			Endpoint:    status.Endpoint,
+			MemberID:    status.Status.Header.MemberID,
+			Leader:      status.Status.Header.MemberID == status.Status.Leader,
+			DBSize:      status.Status.DBSize,
+			DBSizeInUse: status.Status.DBSizeInUse,
+			RaftTerm:    status.Status.RaftTerm,
+			Version:     status.Status.Version,
+			HealthError: "no health reported",
+		}
+		if u, err := url.Parse(status.Endpoint); err == nil {
+			member.Pod = pods[u.Hostname()]
+		}
+		for _, health := range healths {
+			if health.Endpoint == status.Endpoint {
+				member.Healthy, member.HealthError = health.Health, health.Error
+			}
+		}
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Pod < members[j].Pod })
+	return members, nil
+}
+
+// leaderChanges returns the leader changes of the last hour per etcd pod, from the cluster Prometheus
+func (c *etcdClient) leaderChanges() (map[string]string, error) {
+	output, err := c.run("exec", "-n", "openshift-monitoring", "-c", "prometheus", "prometheus-k8s-0", "--",
+		"curl", "-s", "--data-urlencode", "query="+leaderChangesQuery, "http://localhost:9090/api/v1/query")
+	if err != nil {
+		return nil, err
+	}
+	return parseLeaderChanges(output)
+}
+
+func parseLeaderChanges(output []byte) (map[string]string, error) {
+	var response struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  []interface{}     `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return nil, fmt.Errorf("cannot parse the prometheus response: %w", err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("the prometheus query failed with status '%s'", response.Status)
+	}
+
+	changes := map[string]string{}
+	for _, result := range response.Data.Result {
+		if len(result.Value) != 2 {
+			continue
+		}
+		if value, ok := result.Value[1].(string); ok {
+			// increase() extrapolates, round to whole leader changes
+			var count float64
+			if _, err := fmt.Sscanf(value, "%g", &count); err == nil {
+				changes[result.Metric["pod"]] = fmt.Sprintf("%.0f", count)
+			}
+		}
+	}
+	return changes, nil
+}
+
+func (o *etcdOptions) runStatus() error {
+	client, err := o.connect()
+	if err != nil {
+		return err
+	}
+	members, err := client.members()
+	if err != nil {
+		return err
+	}
+
+	changes, err := client.leaderChanges()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot get the leader changes from prometheus: %v\n", err)
+	}
+	for i := range members {
+		if value, ok := changes[members[i].Pod]; ok {
+			members[i].LeaderChanges = value
+		}
+	}
+
+	printEtcdMembers(members)
+	if alarms, err := client.etcdctl(client.anyPod(), "alarm", "list"); err == nil && len(strings.TrimSpace(string(alarms))) > 0 {
+		fmt.Printf("Active alarms:\n%s\n", strings.TrimSpace(string(alarms)))
+	}
+	return nil
+}
+
+func printEtcdMembers(members []etcdMember) {
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Pod", "Endpoint", "Leader", "Health", "DB Size", "In Use", "Raft Term", "Leader Changes (1h)", "Version"})
+	for _, member := range members {
+		health := "healthy"
+		if !member.Healthy {
+			health = "unhealthy: " + member.HealthError
+		}
+		leaderChanges := member.LeaderChanges
+		if leaderChanges == "" {
+			leaderChanges = "unknown"
+		}
+		table.AddRow([]string{
+			member.Pod,
+			member.Endpoint,
+			fmt.Sprint(member.Leader),
+			health,
+			formatBytes(member.DBSize),
+			formatBytes(member.DBSizeInUse),
+			fmt.Sprint(member.RaftTerm),
+			leaderChanges,
+			member.Version,
+		})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "error while flushing table: %v\n", err)
+	}
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGT"[exp])
+}
+
+// defragOrder returns the followers first and the leader last, so that a single leader election at most happens
+func defragOrder(members []etcdMember) []etcdMember {
+	ordered := append([]etcdMember{}, members...)
+	sort.SliceStable(ordered, func(i, j int) bool { return !ordered[i].Leader && ordered[j].Leader })
+	return ordered
+}
+
+// checkDefragSafe refuses to defragment unless every member is healthy, mapped to a pod and agrees on the term
+func checkDefragSafe(members []etcdMember) error {
+	if len(members) == 0 {
+		return errors.New("no etcd member found")
+	}
+	leaders := 0
+	for _, member := range members {
+		if !member.Healthy {
+			return fmt.Errorf("member %s is unhealthy (%s), fix it before defragmenting", member.Endpoint, member.HealthError)
+		}
+		if member.Pod == "" {
+			return fmt.Errorf("no running etcd pod found for member %s", member.Endpoint)
+		}
+		if member.RaftTerm != members[0].RaftTerm {
+			return errors.New("the members don't agree on the raft term, a leader election is in progress")
+		}
+		if member.Leader {
+			leaders++
+		}
+	}
+	if leaders != 1 {
+		return fmt.Errorf("expected one leader, found %d", leaders)
+	}
+	return nil
+}
+
+func (o *etcdOptions) runDefrag() error {
+	client, err := o.connect()
+	if err != nil {
+		return err
+	}
+	members, err := client.members()
+	if err != nil {
+		return err
+	}
+	printEtcdMembers(members)
+	if err := checkDefragSafe(members); err != nil {
+		return err
+	}
+
+	ordered := defragOrder(members)
+	fmt.Println("Members will be defragmented in this order:")
+	for i, member := range ordered {
+		role := "follower"
+		if member.Leader {
+			role = "leader"
+		}
+		fmt.Printf("  %d. %s (%s, %s)\n", i+1, member.Pod, role, formatBytes(member.DBSize))
+	}
+	if o.dryRun {
+		return nil
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	action := fmt.Sprintf("Defragment %d etcd members", len(ordered))
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, action),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := defragMembers(client, ordered, o.timeout, 5*time.Second); err != nil {
+		return err
+	}
+	utils.RecordClusterAction(connection, cluster, action)
+	return nil
+}
+
+// defragMembers defragments the members one at a time, aborting when a member doesn't recover or the leader changes
+func defragMembers(client *etcdClient, ordered []etcdMember, timeout, interval time.Duration) error {
+	term := ordered[0].RaftTerm
+	for i, member := range ordered {
+		fmt.Printf("[%d/%d] Defragmenting %s\n", i+1, len(ordered), member.Pod)
+		if _, err := client.etcdctl(member.Pod, "defrag", "--endpoints="+member.Endpoint, "--command-timeout=60s"); err != nil {
+			return fmt.Errorf("defragmentation of %s failed, aborting: %w", member.Pod, err)
+		}
+
+		after, err := waitForHealthy(client, timeout, interval)
+		if err != nil {
+			return fmt.Errorf("aborting after %s: %w", member.Pod, err)
+		}
+		for _, m := range after {
+			if m.RaftTerm != term {
+				return fmt.Errorf("the raft term changed from %d to %d after defragmenting %s, the leader flapped, aborting", term, m.RaftTerm, member.Pod)
+			}
+			if m.Endpoint == member.Endpoint {
+				fmt.Printf("[%d/%d] %s: %s -> %s\n", i+1, len(ordered), member.Pod, formatBytes(member.DBSize), formatBytes(m.DBSize))
+			}
+		}
+	}
+
+	if alarms, err := client.etcdctl(client.anyPod(), "alarm", "list"); err == nil && len(strings.TrimSpace(string(alarms))) > 0 {
+		fmt.Printf("Active alarms, clear them with 'etcdctl alarm disarm' once the DB sizes are down:\n%s\n", strings.TrimSpace(string(alarms)))
+	}
+	fmt.Println("Every member was defragmented")
+	return nil
+}
+
+// waitForHealthy polls the members until they are all healthy
+func waitForHealthy(client *etcdClient, timeout, interval time.Duration) ([]etcdMember, error) {
+	var members []etcdMember
+	opts := poll.Options{Description: "the etcd members to be healthy", Timeout: timeout, Interval: interval}
+	err := poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
+		var err error
+		members, err = client.members()
+		if err != nil {
+			return false, fmt.Sprintf("the members can't be listed: %v", err), nil
+		}
+		var unhealthy []string
+		for _, member := range members {
+			if !member.Healthy {
+				unhealthy = append(unhealthy, member.Pod)
+			}
+		}
+		if len(unhealthy) > 0 {
+			return false, "unhealthy: " + strings.Join(unhealthy, ", "), nil
+		}
+		return true, "", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
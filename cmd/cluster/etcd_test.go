@@ -0,0 +1,133 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	etcdStatusJSON = `[
+  {"Endpoint":"https://10.0.0.1:2379","Status":{"header":{"member_id":11,"raft_term":4},"version":"3.5.9","dbSize":2147483648,"dbSizeInUse":1073741824,"leader":22,"raftTerm":4}},
+  {"Endpoint":"https://10.0.0.2:2379","Status":{"header":{"member_id":22,"raft_term":4},"version":"3.5.9","dbSize":2147483648,"dbSizeInUse":1073741824,"leader":22,"raftTerm":4}},
+  {"Endpoint":"https://10.0.0.3:2379","Status":{"header":{"member_id":33,"raft_term":4},"version":"3.5.9","dbSize":2147483648,"dbSizeInUse":1073741824,"leader":22,"raftTerm":4}}
+]`
+	etcdHealthJSON = `[
+  {"endpoint":"https://10.0.0.1:2379","health":true,"took":"10ms"},
+  {"endpoint":"https://10.0.0.2:2379","health":true,"took":"10ms"},
+  {"endpoint":"https://10.0.0.3:2379","health":false,"took":"10ms","error":"context deadline exceeded"}
+]`
+)
+
+var etcdPods = map[string]string{"10.0.0.1": "etcd-a", "10.0.0.2": "etcd-b", "10.0.0.3": "etcd-c"}
+
+func TestParseEtcdMembers(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	members, err := parseEtcdMembers([]byte(etcdStatusJSON), []byte(etcdHealthJSON), etcdPods)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(members).To(HaveLen(3))
+
+	g.Expect(members[0].Pod).To(Equal("etcd-a"))
+	g.Expect(members[0].Leader).To(BeFalse())
+	g.Expect(members[0].Healthy).To(BeTrue())
+	g.Expect(members[1].Leader).To(BeTrue())
+	g.Expect(members[1].RaftTerm).To(Equal(uint64(4)))
+	g.Expect(members[2].Healthy).To(BeFalse())
+	g.Expect(members[2].HealthError).To(Equal("context deadline exceeded"))
+
+	g.Expect(checkDefragSafe(members)).To(MatchError(ContainSubstring("https://10.0.0.3:2379 is unhealthy")))
+
+	members[2].Healthy = true
+	g.Expect(checkDefragSafe(members)).To(Succeed())
+
+	ordered := defragOrder(members)
+	g.Expect([]string{ordered[0].Pod, ordered[1].Pod, ordered[2].Pod}).To(Equal([]string{"etcd-a", "etcd-c", "etcd-b"}))
+}
+
+func TestParseLeaderChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	changes, err := parseLeaderChanges([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"pod":"etcd-a"},"value":[1700000000,"0"]},
+		{"metric":{"pod":"etcd-b"},"value":[1700000000,"2.0338983050847457"]}]}}`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changes).To(Equal(map[string]string{"etcd-a": "0", "etcd-b": "2"}))
+
+	_, err = parseLeaderChanges([]byte(`{"status":"error"}`))
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestFormatBytes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(formatBytes(512)).To(Equal("512 B"))
+	g.Expect(formatBytes(1536)).To(Equal("1.5 KiB"))
+	g.Expect(formatBytes(2147483648)).To(Equal("2.0 GiB"))
+}
+
+func TestDefragMembers(t *testing.T) {
+	healthy := strings.ReplaceAll(etcdHealthJSON, `"health":false`, `"health":true`)
+
+	tests := []struct {
+		name          string
+		statusAfter   func(defrags int) string
+		expectDefrags int
+		expectErr     string
+	}{
+		{
+			name:          "defragments every member",
+			statusAfter:   func(int) string { return etcdStatusJSON },
+			expectDefrags: 3,
+		},
+		{
+			name: "aborts when the leader flaps",
+			statusAfter: func(defrags int) string {
+				if defrags >= 1 {
+					return strings.ReplaceAll(etcdStatusJSON, `"raftTerm":4`, `"raftTerm":5`)
+				}
+				return etcdStatusJSON
+			},
+			expectDefrags: 1,
+			expectErr:     "the raft term changed from 4 to 5 after defragmenting etcd-a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			defrags := 0
+			run := func(args ...string) ([]byte, error) {
+				command := strings.Join(args, " ")
+				switch {
+				case strings.Contains(command, "endpoint status"):
+					return []byte(tt.statusAfter(defrags)), nil
+				case strings.Contains(command, "endpoint health"):
+					return []byte(healthy), nil
+				case strings.Contains(command, "etcdctl defrag"):
+					defrags++
+					return []byte("Finished defragmenting etcd member"), nil
+				case strings.Contains(command, "alarm list"):
+					return nil, nil
+				}
+				return nil, fmt.Errorf("unexpected command %s", command)
+			}
+
+			client := &etcdClient{run: run, pods: etcdPods}
+			members, err := client.members()
+			g.Expect(err).NotTo(HaveOccurred())
+
+			err = defragMembers(client, defragOrder(members), time.Second, time.Millisecond)
+			if tt.expectErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.expectErr)))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(defrags).To(Equal(tt.expectDefrags))
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/utils/strings/slices"
+)
+
+const (
+	eventsK8sLongDescription = `
+Prints a snapshot of the recent Kubernetes events of the managed namespaces of a cluster, for a quick triage
+
+  This command will:
+
+  * Read the events of the namespaces matching --namespaces, newer than --since
+  * Highlight the containers in a crash loop and the pods which can't be scheduled
+  * Group the events by reason, the warnings first, with how many times they happened
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID').
+`
+	eventsK8sExample = `
+  # The events of the last hour in the openshift-* and kube-* namespaces
+  osdctl cluster events-k8s 1kfmyclusteristhebesteverp8m
+
+  # The events of the last 15 minutes in the monitoring namespaces, as JSON
+  osdctl cluster events-k8s 1kfmyclusteristhebesteverp8m --namespaces 'openshift-monitoring,openshift-user-workload-*' --since 15m -o json
+`
+
+	// eventReasonBackOff is the reason of the events of the kubelet restarting a crashing container
+	eventReasonBackOff = "BackOff"
+	// eventReasonFailedScheduling is the reason of the events of the scheduler finding no node for a pod
+	eventReasonFailedScheduling = "FailedScheduling"
+	// eventMessageMaxLength is the length the messages are cut at in the tables
+	eventMessageMaxLength = 100
+)
+
+type eventsK8sOptions struct {
+	clusterID  string
+	namespaces []string
+	since      time.Duration
+
+	runOC         utils.OCRunner
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// eventObject is an object the events are about, e.g. a crash looping pod
+type eventObject struct {
+	Namespace string    `json:"namespace"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Count     int       `json:"count"`
+	LastSeen  time.Time `json:"last_seen"`
+	Message   string    `json:"message"`
+}
+
+// eventReason is the summary of the events with the same type and reason
+type eventReason struct {
+	Type       string    `json:"type"`
+	Reason     string    `json:"reason"`
+	Count      int       `json:"count"`
+	Objects    int       `json:"objects"`
+	Namespaces []string  `json:"namespaces"`
+	LastSeen   time.Time `json:"last_seen"`
+	// Message is the one of the latest event
+	Message string `json:"message"`
+}
+
+type eventsK8sResponse struct {
+	ClusterID        string        `json:"cluster_id"`
+	Since            string        `json:"since"`
+	Events           int           `json:"events"`
+	CrashLoops       []eventObject `json:"crash_loops"`
+	FailedScheduling []eventObject `json:"failed_scheduling"`
+	Reasons          []eventReason `json:"reasons"`
+}
+
+func (r eventsK8sResponse) String() string {
+	var b bytes.Buffer
+	if r.Events == 0 {
+		fmt.Fprintf(&b, "No event in the last %s\n", r.Since)
+		return b.String()
+	}
+
+	for _, highlight := range []struct {
+		title   string
+		objects []eventObject
+	}{
+		{"Containers in a crash loop", r.CrashLoops},
+		{"Pods failing to be scheduled", r.FailedScheduling},
+	} {
+		if len(highlight.objects) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:\n", highlight.title)
+		table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+		table.AddRow([]string{"NAMESPACE", "POD", "COUNT", "LAST SEEN", "MESSAGE"})
+		for _, object := range highlight.objects {
+			table.AddRow([]string{object.Namespace, object.Name, strconv.Itoa(object.Count), timefmt.Format(object.LastSeen), truncateMessage(object.Message)})
+		}
+		table.AddRow([]string{})
+		if err := table.Flush(); err != nil {
+			return fmt.Sprintf("cannot print the events: %v", err)
+		}
+	}
+
+	fmt.Fprintf(&b, "%d events in the last %s, by reason:\n", r.Events, r.Since)
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"TYPE", "REASON", "COUNT", "OBJECTS", "NAMESPACES", "LAST SEEN", "LATEST MESSAGE"})
+	for _, reason := range r.Reasons {
+		table.AddRow([]string{reason.Type, reason.Reason, strconv.Itoa(reason.Count), strconv.Itoa(reason.Objects),
+			strings.Join(reason.Namespaces, ","), timefmt.Format(reason.LastSeen), truncateMessage(reason.Message)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the events: %v", err)
+	}
+	return b.String()
+}
+
+func newCmdEventsK8s(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &eventsK8sOptions{runOC: utils.RunOCAsClusterAdmin, GlobalOptions: globalOpts}
+	eventsCmd := &cobra.Command{
+		Use:               "events-k8s CLUSTER_ID",
+		Short:             "Summarizes the recent Kubernetes events of the managed namespaces, highlighting crash loops and failed scheduling",
+		Long:              eventsK8sLongDescription,
+		Example:           eventsK8sExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	eventsCmd.Flags().StringSliceVar(&ops.namespaces, "namespaces", []string{"openshift-*", "kube-*"}, "Comma separated namespaces to read the events of, * matches any characters")
+	eventsCmd.Flags().DurationVar(&ops.since, "since", time.Hour, "Only summarize the events newer than this duration")
+
+	return eventsCmd
+}
+
+func (o *eventsK8sOptions) complete(cmd *cobra.Command) error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	if o.since <= 0 {
+		return cmdutil.UsageErrorf(cmd, "--since must be positive")
+	}
+	if len(o.namespaces) == 0 {
+		return cmdutil.UsageErrorf(cmd, "--namespaces can't be empty")
+	}
+	for _, pattern := range o.namespaces {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return cmdutil.UsageErrorf(cmd, "invalid namespace pattern '%s'", pattern)
+		}
+	}
+	return nil
+}
+
+func (o *eventsK8sOptions) run() error {
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	events, err := o.listEvents()
+	if err != nil {
+		return err
+	}
+	response := summarizeEvents(events, o.namespaces, time.Now().Add(-o.since))
+	response.ClusterID = cluster.ID()
+	response.Since = o.since.String()
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// listEvents reads the events of every namespace, the namespace patterns can't be given to oc
+func (o *eventsK8sOptions) listEvents() ([]corev1.Event, error) {
+	output, err := o.runOC("get", "events", "--all-namespaces", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var events corev1.EventList
+	if err := json.Unmarshal(output, &events); err != nil {
+		return nil, fmt.Errorf("cannot parse the events: %w", err)
+	}
+	return events.Items, nil
+}
+
+// summarizeEvents groups the events of the matching namespaces newer than since by reason, and by object for the
+// crash loops and the failed scheduling
+func summarizeEvents(events []corev1.Event, namespaces []string, since time.Time) eventsK8sResponse {
+	response := eventsK8sResponse{CrashLoops: []eventObject{}, FailedScheduling: []eventObject{}, Reasons: []eventReason{}}
+	reasons := map[string]*eventReason{}
+	reasonObjects := map[string]map[string]bool{}
+	crashLoops := map[string]*eventObject{}
+	failedScheduling := map[string]*eventObject{}
+
+	for _, event := range events {
+		lastSeen := eventLastSeen(event).UTC()
+		if !matchesNamespace(event.Namespace, namespaces) || lastSeen.Before(since) {
+			continue
+		}
+		count := eventCount(event)
+		response.Events += count
+
+		key := event.Type + "/" + event.Reason
+		reason, ok := reasons[key]
+		if !ok {
+			reason = &eventReason{Type: event.Type, Reason: event.Reason}
+			reasons[key] = reason
+			reasonObjects[key] = map[string]bool{}
+		}
+		reason.Count += count
+		object := event.InvolvedObject.Kind + "/" + event.Namespace + "/" + event.InvolvedObject.Name
+		reasonObjects[key][object] = true
+		reason.Objects = len(reasonObjects[key])
+		if !slices.Contains(reason.Namespaces, event.Namespace) {
+			reason.Namespaces = append(reason.Namespaces, event.Namespace)
+		}
+		if !lastSeen.Before(reason.LastSeen) {
+			reason.LastSeen = lastSeen
+			reason.Message = event.Message
+		}
+
+		switch {
+		case isCrashLoop(event):
+			addEventObject(crashLoops, event, count, lastSeen)
+		case event.Reason == eventReasonFailedScheduling:
+			addEventObject(failedScheduling, event, count, lastSeen)
+		}
+	}
+
+	for _, reason := range reasons {
+		sort.Strings(reason.Namespaces)
+		response.Reasons = append(response.Reasons, *reason)
+	}
+	// The warnings first, then the most frequent
+	sort.Slice(response.Reasons, func(i, j int) bool {
+		a, b := response.Reasons[i], response.Reasons[j]
+		if (a.Type == corev1.EventTypeWarning) != (b.Type == corev1.EventTypeWarning) {
+			return a.Type == corev1.EventTypeWarning
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Reason < b.Reason
+	})
+	response.CrashLoops = sortedEventObjects(crashLoops)
+	response.FailedScheduling = sortedEventObjects(failedScheduling)
+	return response
+}
+
+// isCrashLoop returns true for the events of the kubelet backing off restarting a container, the ones behind the
+// CrashLoopBackOff status, not the image pull back-offs
+func isCrashLoop(event corev1.Event) bool {
+	return event.Reason == eventReasonBackOff && event.InvolvedObject.Kind == "Pod" &&
+		strings.Contains(event.Message, "restarting failed container")
+}
+
+func addEventObject(objects map[string]*eventObject, event corev1.Event, count int, lastSeen time.Time) {
+	key := event.Namespace + "/" + event.InvolvedObject.Name
+	object, ok := objects[key]
+	if !ok {
+		object = &eventObject{Namespace: event.Namespace, Kind: event.InvolvedObject.Kind, Name: event.InvolvedObject.Name}
+		objects[key] = object
+	}
+	object.Count += count
+	if !lastSeen.Before(object.LastSeen) {
+		object.LastSeen = lastSeen
+		object.Message = event.Message
+	}
+}
+
+// sortedEventObjects returns the objects with the most events first
+func sortedEventObjects(objects map[string]*eventObject) []eventObject {
+	sorted := make([]eventObject, 0, len(objects))
+	for _, object := range objects {
+		sorted = append(sorted, *object)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Namespace+"/"+sorted[i].Name < sorted[j].Namespace+"/"+sorted[j].Name
+	})
+	return sorted
+}
+
+// eventLastSeen returns when the event last happened, the fields set depend on the API version which created it.
+// The kubernetes client parses the timestamps in the local timezone.
+func eventLastSeen(event corev1.Event) time.Time {
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// eventCount returns how many times the event happened
+func eventCount(event corev1.Event) int {
+	if event.Series != nil && event.Series.Count > 0 {
+		return int(event.Series.Count)
+	}
+	if event.Count > 0 {
+		return int(event.Count)
+	}
+	return 1
+}
+
+func matchesNamespace(namespace string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, namespace); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func truncateMessage(message string) string {
+	message = strings.Join(strings.Fields(message), " ")
+	if len(message) > eventMessageMaxLength {
+		return message[:eventMessageMaxLength-3] + "..."
+	}
+	return message
+}
@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const testEvents = `{"items": [
+  {"metadata": {"namespace": "openshift-monitoring", "name": "a"}, "type": "Warning", "reason": "BackOff", "count": 12,
+   "lastTimestamp": "2024-05-01T11:50:00Z", "message": "Back-off restarting failed container prometheus in pod prometheus-k8s-0",
+   "involvedObject": {"kind": "Pod", "namespace": "openshift-monitoring", "name": "prometheus-k8s-0"}},
+  {"metadata": {"namespace": "openshift-monitoring", "name": "b"}, "type": "Warning", "reason": "BackOff", "count": 3,
+   "lastTimestamp": "2024-05-01T11:55:00Z", "message": "Back-off pulling image \"quay.io/example\"",
+   "involvedObject": {"kind": "Pod", "namespace": "openshift-monitoring", "name": "puller"}},
+  {"metadata": {"namespace": "openshift-ingress", "name": "c"}, "type": "Warning", "reason": "FailedScheduling",
+   "eventTime": "2024-05-01T11:58:00.000000Z", "series": {"count": 4, "lastObservedTime": "2024-05-01T11:59:00.000000Z"},
+   "message": "0/6 nodes are available: 3 node(s) didn't match pod anti-affinity rules",
+   "involvedObject": {"kind": "Pod", "namespace": "openshift-ingress", "name": "router-default-1"}},
+  {"metadata": {"namespace": "kube-system", "name": "d"}, "type": "Normal", "reason": "Pulled", "count": 1,
+   "lastTimestamp": "2024-05-01T11:40:00Z", "message": "Container image pulled",
+   "involvedObject": {"kind": "Pod", "namespace": "kube-system", "name": "x"}},
+  {"metadata": {"namespace": "customer-app", "name": "e"}, "type": "Warning", "reason": "BackOff", "count": 50,
+   "lastTimestamp": "2024-05-01T11:50:00Z", "message": "Back-off restarting failed container app in pod app-1",
+   "involvedObject": {"kind": "Pod", "namespace": "customer-app", "name": "app-1"}},
+  {"metadata": {"namespace": "openshift-monitoring", "name": "f"}, "type": "Warning", "reason": "Unhealthy", "count": 2,
+   "lastTimestamp": "2024-05-01T09:00:00Z", "message": "Readiness probe failed",
+   "involvedObject": {"kind": "Pod", "namespace": "openshift-monitoring", "name": "old"}}
+]}`
+
+func TestSummarizeEvents(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := &eventsK8sOptions{runOC: func(args ...string) ([]byte, error) {
+		g.Expect(strings.Join(args, " ")).To(Equal("get events --all-namespaces -o json"))
+		return []byte(testEvents), nil
+	}}
+	events, err := o.listEvents()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	since := time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+	response := summarizeEvents(events, []string{"openshift-*", "kube-*"}, since)
+
+	// The customer namespace and the event older than an hour are left out
+	g.Expect(response.Events).To(Equal(12 + 3 + 4 + 1))
+	g.Expect(response.CrashLoops).To(HaveLen(1))
+	g.Expect(response.CrashLoops[0].Name).To(Equal("prometheus-k8s-0"))
+	g.Expect(response.CrashLoops[0].Count).To(Equal(12))
+	g.Expect(response.FailedScheduling).To(HaveLen(1))
+	g.Expect(response.FailedScheduling[0].Count).To(Equal(4))
+	g.Expect(response.FailedScheduling[0].LastSeen.Equal(time.Date(2024, 5, 1, 11, 59, 0, 0, time.UTC))).To(BeTrue())
+
+	g.Expect(response.Reasons).To(HaveLen(3))
+	g.Expect(response.Reasons[0].Reason).To(Equal("BackOff"))
+	g.Expect(response.Reasons[0].Count).To(Equal(15))
+	g.Expect(response.Reasons[0].Objects).To(Equal(2))
+	// The message of the latest event
+	g.Expect(response.Reasons[0].Message).To(ContainSubstring("pulling image"))
+	g.Expect(response.Reasons[1].Reason).To(Equal("FailedScheduling"))
+	g.Expect(response.Reasons[2].Type).To(Equal("Normal"))
+
+	output := response.String()
+	g.Expect(output).To(ContainSubstring("Containers in a crash loop:"))
+	g.Expect(output).To(ContainSubstring("Pods failing to be scheduled:"))
+	g.Expect(output).To(ContainSubstring("20 events in the last"))
+
+	g.Expect(summarizeEvents(events, []string{"openshift-logging"}, since).String()).To(HavePrefix("No event in the last"))
+}
+
+func TestTruncateMessage(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(truncateMessage("a\n  b")).To(Equal("a b"))
+	g.Expect(truncateMessage(strings.Repeat("x", 150))).To(HaveLen(eventMessageMaxLength))
+}
@@ -0,0 +1,215 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/artifacts"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	exportLongDescription = `
+Exports the OCM definition of a cluster into YAML files, one per kind of resource, that can be versioned,
+diffed over time and used to re-create the cluster after a disaster:
+
+  cluster.yaml              the cluster
+  machine_pools.yaml        the machine pools, or node_pools.yaml for HCP clusters
+  identity_providers.yaml   the identity providers, without their secrets which OCM doesn't return
+  upgrade_policies.yaml     the upgrade policies
+  labels.yaml               the subscription and cluster labels
+
+Fields that change on their own, e.g. the status and the metrics, are left out so that only changes
+to the definition show up in diffs.
+`
+	exportExample = `
+  # Export into ./backup
+  osdctl cluster export 1kfmyclusteristhebesteverp8m --dir ./backup
+
+  # Track the changes over time
+  osdctl cluster export 1kfmyclusteristhebesteverp8m --dir ./backup && git -C ./backup diff
+`
+
+	exportPageSize = 100
+)
+
+// volatileFields are top level fields of the exported resources that change without the definition changing
+var volatileFields = []string{"status", "metrics", "activity_timestamp", "expiration_timestamp", "health_state", "current_replicas"}
+
+type exportOptions struct {
+	clusterID string
+	dir       string
+	// dirSet is true when --dir was given, it takes precedence over --artifacts
+	dirSet bool
+}
+
+func newCmdExport() *cobra.Command {
+	ops := &exportOptions{}
+	exportCmd := &cobra.Command{
+		Use:               "export CLUSTER_ID",
+		Short:             "Export the OCM definition of a cluster into YAML files",
+		Long:              exportLongDescription,
+		Example:           exportExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			ops.dirSet = cmd.Flags().Changed("dir")
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	exportCmd.Flags().StringVar(&ops.dir, "dir", ".", "Directory to write the files to, created if needed")
+
+	return exportCmd
+}
+
+// exportFile is a file of the export, marshal writes the resources as JSON
+type exportFile struct {
+	name    string
+	marshal func(*bytes.Buffer) error
+}
+
+func (o *exportOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	files, err := exportFiles(connection, cluster)
+	if err != nil {
+		return err
+	}
+
+	record := !o.dirSet && artifacts.Enabled()
+	if record {
+		o.dir, err = artifacts.Dir(cluster.ID())
+		if err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(o.dir, 0750); err != nil {
+		return fmt.Errorf("cannot create %s: %w", o.dir, err)
+	}
+	for _, file := range files {
+		var buf bytes.Buffer
+		if err := file.marshal(&buf); err != nil {
+			return fmt.Errorf("cannot marshal %s: %w", file.name, err)
+		}
+		content, err := exportYAML(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("cannot convert %s: %w", file.name, err)
+		}
+		path := filepath.Join(o.dir, file.name)
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			return fmt.Errorf("cannot write %s: %w", path, err)
+		}
+		if record {
+			if err := artifacts.Record(cluster.ID(), path, "OCM definition of the cluster"); err != nil {
+				return err
+			}
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// exportFiles reads the resources of the cluster, HCP clusters have node pools instead of machine pools
+func exportFiles(connection *sdk.Connection, cluster *cmv1.Cluster) ([]exportFile, error) {
+	resource := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID())
+	files := []exportFile{{
+		name:    "cluster.yaml",
+		marshal: func(buf *bytes.Buffer) error { return cmv1.MarshalCluster(cluster, buf) },
+	}}
+
+	if cluster.Hypershift().Enabled() {
+		response, err := resource.NodePools().List().Size(exportPageSize).Send()
+		if err != nil {
+			return nil, fmt.Errorf("can't retrieve the node pools of cluster %s: %w", cluster.ID(), err)
+		}
+		files = append(files, exportFile{
+			name:    "node_pools.yaml",
+			marshal: func(buf *bytes.Buffer) error { return cmv1.MarshalNodePoolList(response.Items().Slice(), buf) },
+		})
+	} else {
+		response, err := resource.MachinePools().List().Size(exportPageSize).Send()
+		if err != nil {
+			return nil, fmt.Errorf("can't retrieve the machine pools of cluster %s: %w", cluster.ID(), err)
+		}
+		files = append(files, exportFile{
+			name:    "machine_pools.yaml",
+			marshal: func(buf *bytes.Buffer) error { return cmv1.MarshalMachinePoolList(response.Items().Slice(), buf) },
+		})
+	}
+
+	idps, err := resource.IdentityProviders().List().Size(exportPageSize).Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve the identity providers of cluster %s: %w", cluster.ID(), err)
+	}
+	files = append(files, exportFile{
+		name:    "identity_providers.yaml",
+		marshal: func(buf *bytes.Buffer) error { return cmv1.MarshalIdentityProviderList(idps.Items().Slice(), buf) },
+	})
+
+	policies, err := resource.UpgradePolicies().List().Size(exportPageSize).Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve the upgrade policies of cluster %s: %w", cluster.ID(), err)
+	}
+	files = append(files, exportFile{
+		name:    "upgrade_policies.yaml",
+		marshal: func(buf *bytes.Buffer) error { return cmv1.MarshalUpgradePolicyList(policies.Items().Slice(), buf) },
+	})
+
+	labels, err := listLabels(connection, cluster, "")
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, exportFile{
+		name:    "labels.yaml",
+		marshal: func(buf *bytes.Buffer) error { return json.NewEncoder(buf).Encode(labels) },
+	})
+
+	return files, nil
+}
+
+// exportYAML converts the JSON of a resource or a list of resources to YAML, without the volatile fields.
+// The keys are sorted so that the files only change when the definition does.
+func exportYAML(data []byte) ([]byte, error) {
+	var content interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+	dropVolatile := func(v interface{}) {
+		if object, ok := v.(map[string]interface{}); ok {
+			for _, field := range volatileFields {
+				delete(object, field)
+			}
+		}
+	}
+	if list, ok := content.([]interface{}); ok {
+		for _, item := range list {
+			dropVolatile(item)
+		}
+	} else {
+		dropVolatile(content)
+	}
+	if content == nil {
+		content = []interface{}{}
+	}
+	return yaml.Marshal(content)
+}
@@ -0,0 +1,47 @@
+package cluster
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func TestExportYAMLDropsVolatileFields(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cluster, err := cmv1.NewCluster().
+		ID("abc123").
+		Name("my-cluster").
+		State(cmv1.ClusterStateReady).
+		Status(cmv1.NewClusterStatus().State(cmv1.ClusterStateReady)).
+		Region(cmv1.NewCloudRegion().ID("us-east-1")).
+		Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var buf bytes.Buffer
+	g.Expect(cmv1.MarshalCluster(cluster, &buf)).To(Succeed())
+	content, err := exportYAML(buf.Bytes())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(ContainSubstring("name: my-cluster"))
+	g.Expect(string(content)).To(ContainSubstring("id: us-east-1"))
+	g.Expect(string(content)).NotTo(ContainSubstring("status:"))
+}
+
+func TestExportYAMLLists(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pool, err := cmv1.NewMachinePool().ID("worker").Replicas(3).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var buf bytes.Buffer
+	g.Expect(cmv1.MarshalMachinePoolList([]*cmv1.MachinePool{pool}, &buf)).To(Succeed())
+	content, err := exportYAML(buf.Bytes())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("- id: worker\n  kind: MachinePool\n  replicas: 3\n"))
+
+	content, err = exportYAML([]byte("null"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("[]\n"))
+}
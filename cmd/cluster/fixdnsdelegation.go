@@ -0,0 +1,428 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	fixDNSDelegationLongDescription = `
+Detects a broken NS delegation between the parent zone of the base domain and the hosted zone of the cluster
+
+  This command will:
+
+  * Read the name servers of the public hosted zone of the cluster, in the cluster's AWS account
+  * Read the NS record delegating the cluster domain in the parent zone, named after the base domain, with the
+    credentials of --parent-profile, e.g. the customer's account for a domain they own, or the cluster's account
+  * Compare both with the name servers the public resolvers answer with
+  * Print the corrective NS record set, as an AWS CLI change batch, and apply it with --apply once confirmed
+
+  Only AWS clusters with a public hosted zone are supported.
+`
+	fixDNSDelegationExample = `
+  # Check the delegation of a cluster whose base domain is hosted in the cluster's account
+  osdctl cluster fix-dns-delegation 1kfmyclusteristhebesteverp8m --profile rhcontrol
+
+  # Repair the delegation in the parent zone of the customer's account
+  osdctl cluster fix-dns-delegation 1kfmyclusteristhebesteverp8m --profile rhcontrol --parent-profile customer --apply
+`
+
+	// defaultDelegationTTL is the TTL of the NS records Route53 creates
+	defaultDelegationTTL = 172800
+)
+
+type fixDNSDelegationOptions struct {
+	clusterID     string
+	awsProfile    string
+	parentProfile string
+	apply         bool
+	skipPrompts   bool
+
+	lookupNS func(domain string) ([]string, error)
+}
+
+// delegation is what is known of the delegation of the cluster domain
+type delegation struct {
+	clusterDomain string
+	// nameServers are the ones of the hosted zone of the cluster, the delegation has to point to
+	nameServers []string
+	// parentZoneID and parentZoneName are empty when the parent zone wasn't found with the given credentials
+	parentZoneID   string
+	parentZoneName string
+	// delegated are the name servers of the NS record of the parent zone, nil when it has none
+	delegated   []string
+	delegateTTL int64
+	// resolved are the name servers the public resolvers answer with, nil when they can't resolve the domain
+	resolved []string
+}
+
+func newCmdFixDNSDelegation() *cobra.Command {
+	ops := &fixDNSDelegationOptions{lookupNS: lookupPublicNS}
+	fixDNSDelegationCmd := &cobra.Command{
+		Use:               "fix-dns-delegation CLUSTER_ID",
+		Short:             "Detects a broken NS delegation to the hosted zone of a cluster and prints or applies the corrective record set",
+		Long:              fixDNSDelegationLongDescription,
+		Example:           fixDNSDelegationExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	fixDNSDelegationCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile name")
+	fixDNSDelegationCmd.Flags().StringVar(&ops.parentProfile, "parent-profile", "", "AWS profile with access to the parent zone, the cluster's account when empty")
+	fixDNSDelegationCmd.Flags().BoolVar(&ops.apply, "apply", false, "Apply the corrective record set to the parent zone, once confirmed")
+	fixDNSDelegationCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+
+	return fixDNSDelegationCmd
+}
+
+func (o *fixDNSDelegationOptions) run() error {
+	err := utils.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.CloudProvider().ID() != "aws" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the DNS delegation of %s clusters can't be checked", cluster.CloudProvider().ID())
+	}
+
+	clusterClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+	if err != nil {
+		return err
+	}
+	parentClient := clusterClient
+	if o.parentProfile != "" {
+		parentClient, err = aws.NewAwsClient(o.parentProfile, common.DefaultRegion, "")
+		if err != nil {
+			return err
+		}
+	}
+
+	clusterDomain := utils.ClusterDomain(cluster)
+	d, err := o.inspectDelegation(clusterClient, parentClient, clusterDomain, cluster.DNS().BaseDomain())
+	if err != nil {
+		return err
+	}
+
+	findings, broken := evaluateDelegation(d)
+	// The failed checks are reported by the findings, the corrective record set follows
+	if err := printDNSFindings(findings); err != nil && !broken {
+		return err
+	}
+	if !broken {
+		fmt.Printf("The delegation of %s is correct.\n", clusterDomain)
+		return nil
+	}
+
+	changeBatch := delegationChangeBatch(d)
+	if err := printChangeBatch(d, changeBatch); err != nil {
+		return err
+	}
+	if !o.apply {
+		fmt.Println("Run again with --apply to apply it, with credentials allowed to change the parent zone.")
+		return nil
+	}
+	if d.parentZoneID == "" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the parent zone of %s wasn't found with the given credentials, use --parent-profile", clusterDomain)
+	}
+
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(ocmClient, cluster, fmt.Sprintf("Upsert the NS record of %s in the hosted zone %s (%s)", clusterDomain, d.parentZoneName, d.parentZoneID)),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+	output, err := parentClient.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: awsSdk.String(d.parentZoneID),
+		ChangeBatch:  changeBatch,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot change the NS record of %s: %w", clusterDomain, err)
+	}
+	utils.RecordClusterAction(ocmClient, cluster, fmt.Sprintf("Upsert the NS record of %s in the hosted zone %s", clusterDomain, d.parentZoneName))
+	fmt.Printf("The delegation of %s has been updated, change %s is %s\n", clusterDomain, awsSdk.StringValue(output.ChangeInfo.Id), awsSdk.StringValue(output.ChangeInfo.Status))
+	return nil
+}
+
+// inspectDelegation reads the name servers of the cluster zone, the delegation of the parent zone and what the public
+// resolvers answer
+func (o *fixDNSDelegationOptions) inspectDelegation(clusterClient, parentClient aws.Client, clusterDomain, baseDomain string) (delegation, error) {
+	d := delegation{clusterDomain: clusterDomain}
+
+	zone, err := findPublicZone(clusterClient, clusterDomain)
+	if err != nil {
+		return d, err
+	}
+	if zone == nil {
+		return d, osdctlErrors.New(osdctlErrors.ErrNotFound, "no public hosted zone named %s found in the cluster's account, the cluster may be private", clusterDomain)
+	}
+	record, err := findNSRecord(clusterClient, awsSdk.StringValue(zone.Id), clusterDomain)
+	if err != nil {
+		return d, err
+	}
+	if record == nil {
+		return d, fmt.Errorf("the hosted zone %s has no NS record", awsSdk.StringValue(zone.Id))
+	}
+	d.nameServers = recordValues(record)
+
+	// The base domain may be a subdomain of the parent zone, e.g. a zone example.com for the base domain osd.example.com
+	parent := strings.TrimSuffix(baseDomain, ".")
+	for strings.Contains(parent, ".") {
+		zone, err := findPublicZone(parentClient, parent)
+		if err != nil {
+			return d, err
+		}
+		if zone != nil {
+			d.parentZoneID, d.parentZoneName = awsSdk.StringValue(zone.Id), parent
+			break
+		}
+		parent = parent[strings.Index(parent, ".")+1:]
+	}
+	if d.parentZoneID != "" {
+		record, err := findNSRecord(parentClient, d.parentZoneID, clusterDomain)
+		if err != nil {
+			return d, err
+		}
+		if record != nil {
+			d.delegated = recordValues(record)
+			d.delegateTTL = awsSdk.Int64Value(record.TTL)
+		}
+	}
+
+	// A failed lookup is a finding, the domain doesn't resolve when the delegation is missing
+	if resolved, err := o.lookupNS(clusterDomain); err == nil {
+		d.resolved = normalizeNameServers(resolved)
+	}
+	return d, nil
+}
+
+// findPublicZone returns the public hosted zone of the domain, nil when there is none
+func findPublicZone(awsClient aws.Client, domain string) (*route53.HostedZone, error) {
+	zoneName := strings.TrimSuffix(domain, ".") + "."
+	zones, err := awsClient.ListHostedZonesByName(&route53.ListHostedZonesByNameInput{DNSName: awsSdk.String(zoneName)})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the hosted zones named %s: %w", zoneName, err)
+	}
+	for _, zone := range zones.HostedZones {
+		if awsSdk.StringValue(zone.Name) != zoneName {
+			continue
+		}
+		if zone.Config != nil && awsSdk.BoolValue(zone.Config.PrivateZone) {
+			continue
+		}
+		return zone, nil
+	}
+	return nil, nil
+}
+
+// findNSRecord returns the NS record set of the name in the zone, nil when there is none
+func findNSRecord(awsClient aws.Client, zoneID, name string) (*route53.ResourceRecordSet, error) {
+	recordName := strings.TrimSuffix(name, ".") + "."
+	output, err := awsClient.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    awsSdk.String(zoneID),
+		StartRecordName: awsSdk.String(recordName),
+		StartRecordType: awsSdk.String(route53.RRTypeNs),
+		MaxItems:        awsSdk.String("1"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the records of the hosted zone %s: %w", zoneID, err)
+	}
+	for _, record := range output.ResourceRecordSets {
+		if strings.EqualFold(awsSdk.StringValue(record.Name), recordName) && awsSdk.StringValue(record.Type) == route53.RRTypeNs {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+func recordValues(record *route53.ResourceRecordSet) []string {
+	values := make([]string, 0, len(record.ResourceRecords))
+	for _, value := range record.ResourceRecords {
+		values = append(values, awsSdk.StringValue(value.Value))
+	}
+	return normalizeNameServers(values)
+}
+
+// normalizeNameServers lowercases the name servers and removes their trailing dot, sorted
+func normalizeNameServers(nameServers []string) []string {
+	normalized := make([]string, 0, len(nameServers))
+	for _, nameServer := range nameServers {
+		normalized = append(normalized, strings.ToLower(strings.TrimSuffix(nameServer, ".")))
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func sameNameServers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateDelegation returns the findings of the delegation, broken is true when the parent zone or the public
+// resolvers don't point to the name servers of the cluster zone
+func evaluateDelegation(d delegation) ([]dnsFinding, bool) {
+	expected := strings.Join(d.nameServers, ", ")
+	findings := []dnsFinding{{"cluster zone", dnsCheckOK, "name servers " + expected}}
+	broken := false
+
+	switch {
+	case d.parentZoneID == "":
+		findings = append(findings, dnsFinding{"parent zone", dnsCheckWarn, "no public hosted zone of the base domain found with the given credentials, use --parent-profile"})
+	case d.delegated == nil:
+		findings = append(findings, dnsFinding{"parent zone " + d.parentZoneName, dnsCheckFail, fmt.Sprintf("no NS record delegates %s", d.clusterDomain)})
+		broken = true
+	case !sameNameServers(d.delegated, d.nameServers):
+		findings = append(findings, dnsFinding{"parent zone " + d.parentZoneName, dnsCheckFail, "delegates to " + strings.Join(d.delegated, ", ")})
+		broken = true
+	default:
+		findings = append(findings, dnsFinding{"parent zone " + d.parentZoneName, dnsCheckOK, "delegates to the cluster zone"})
+	}
+
+	// Once the parent zone delegates to the cluster zone, the resolvers only lag until the TTL of their cache expires
+	resolutionStatus := dnsCheckFail
+	if d.parentZoneID != "" && !broken {
+		resolutionStatus = dnsCheckWarn
+	}
+	switch {
+	case d.resolved == nil:
+		findings = append(findings, dnsFinding{"public resolution", resolutionStatus, fmt.Sprintf("the public resolvers can't resolve the name servers of %s", d.clusterDomain)})
+		broken = broken || resolutionStatus == dnsCheckFail
+	case !sameNameServers(d.resolved, d.nameServers):
+		findings = append(findings, dnsFinding{"public resolution", resolutionStatus, "resolves to " + strings.Join(d.resolved, ", ")})
+		broken = broken || resolutionStatus == dnsCheckFail
+	default:
+		findings = append(findings, dnsFinding{"public resolution", dnsCheckOK, "resolves to the cluster zone"})
+	}
+	return findings, broken
+}
+
+// delegationChangeBatch returns the change upserting the NS record of the cluster domain in the parent zone, keeping
+// the TTL of the existing record
+func delegationChangeBatch(d delegation) *route53.ChangeBatch {
+	ttl := d.delegateTTL
+	if ttl == 0 {
+		ttl = defaultDelegationTTL
+	}
+	records := make([]*route53.ResourceRecord, 0, len(d.nameServers))
+	for _, nameServer := range d.nameServers {
+		records = append(records, &route53.ResourceRecord{Value: awsSdk.String(nameServer + ".")})
+	}
+	return &route53.ChangeBatch{
+		Comment: awsSdk.String(fmt.Sprintf("Delegate %s to its hosted zone", d.clusterDomain)),
+		Changes: []*route53.Change{{
+			Action: awsSdk.String(route53.ChangeActionUpsert),
+			ResourceRecordSet: &route53.ResourceRecordSet{
+				Name:            awsSdk.String(d.clusterDomain + "."),
+				Type:            awsSdk.String(route53.RRTypeNs),
+				TTL:             awsSdk.Int64(ttl),
+				ResourceRecords: records,
+			},
+		}},
+	}
+}
+
+// printChangeBatch prints the corrective change with the AWS CLI command applying it, for the parent zones osdctl
+// has no credentials for
+func printChangeBatch(d delegation, changeBatch *route53.ChangeBatch) error {
+	// The AWS SDK structures have no JSON tags, the CLI expects the API names
+	type record struct {
+		Value string `json:"Value"`
+	}
+	type recordSet struct {
+		Name            string   `json:"Name"`
+		Type            string   `json:"Type"`
+		TTL             int64    `json:"TTL"`
+		ResourceRecords []record `json:"ResourceRecords"`
+	}
+	type change struct {
+		Action            string    `json:"Action"`
+		ResourceRecordSet recordSet `json:"ResourceRecordSet"`
+	}
+	batch := struct {
+		Comment string   `json:"Comment"`
+		Changes []change `json:"Changes"`
+	}{Comment: awsSdk.StringValue(changeBatch.Comment)}
+	for _, c := range changeBatch.Changes {
+		set := recordSet{
+			Name: awsSdk.StringValue(c.ResourceRecordSet.Name),
+			Type: awsSdk.StringValue(c.ResourceRecordSet.Type),
+			TTL:  awsSdk.Int64Value(c.ResourceRecordSet.TTL),
+		}
+		for _, value := range c.ResourceRecordSet.ResourceRecords {
+			set.ResourceRecords = append(set.ResourceRecords, record{Value: awsSdk.StringValue(value.Value)})
+		}
+		batch.Changes = append(batch.Changes, change{Action: awsSdk.StringValue(c.Action), ResourceRecordSet: set})
+	}
+	data, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot marshal the change batch: %w", err)
+	}
+
+	zoneID := d.parentZoneID
+	if zoneID == "" {
+		zoneID = "<ID of the hosted zone of " + d.clusterDomain[strings.Index(d.clusterDomain, ".")+1:] + ">"
+	}
+	fmt.Printf("The following record set repairs the delegation, in the parent zone:\n%s\n\n", data)
+	fmt.Printf("With the AWS CLI, once saved to delegation.json:\n  aws route53 change-resource-record-sets --hosted-zone-id %s --change-batch file://delegation.json\n\n", strings.TrimPrefix(zoneID, "/hostedzone/"))
+	return nil
+}
+
+// lookupPublicNS resolves the name servers of the domain with the first public resolver answering
+func lookupPublicNS(domain string) ([]string, error) {
+	var lastErr error
+	for _, address := range publicResolvers {
+		address := address
+		resolver := &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: dnsCheckTimeout}
+				return d.DialContext(ctx, network, address)
+			},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), dnsCheckTimeout)
+		records, err := resolver.LookupNS(ctx, domain)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		nameServers := make([]string, 0, len(records))
+		for _, record := range records {
+			nameServers = append(nameServers, record.Host)
+		}
+		return nameServers, nil
+	}
+	fmt.Fprintf(os.Stderr, "Cannot resolve the name servers of %s: %v\n", domain, lastErr)
+	return nil, lastErr
+}
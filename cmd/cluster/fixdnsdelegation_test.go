@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func nsRecordSet(name string, ttl int64, nameServers ...string) *route53.ListResourceRecordSetsOutput {
+	record := &route53.ResourceRecordSet{Name: awsSdk.String(name), Type: awsSdk.String(route53.RRTypeNs), TTL: awsSdk.Int64(ttl)}
+	for _, nameServer := range nameServers {
+		record.ResourceRecords = append(record.ResourceRecords, &route53.ResourceRecord{Value: awsSdk.String(nameServer)})
+	}
+	return &route53.ListResourceRecordSetsOutput{ResourceRecordSets: []*route53.ResourceRecordSet{record}}
+}
+
+func TestInspectDelegation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	clusterClient := awsmock.NewMockClient(mockCtrl)
+	parentClient := awsmock.NewMockClient(mockCtrl)
+
+	clusterClient.EXPECT().ListHostedZonesByName(gomock.Any()).Return(&route53.ListHostedZonesByNameOutput{HostedZones: []*route53.HostedZone{
+		{Id: awsSdk.String("/hostedzone/ZPRIVATE"), Name: awsSdk.String("mycluster.osd.example.com."), Config: &route53.HostedZoneConfig{PrivateZone: awsSdk.Bool(true)}},
+		{Id: awsSdk.String("/hostedzone/ZCLUSTER"), Name: awsSdk.String("mycluster.osd.example.com."), Config: &route53.HostedZoneConfig{PrivateZone: awsSdk.Bool(false)}},
+	}}, nil)
+	clusterClient.EXPECT().ListResourceRecordSets(gomock.Any()).Return(nsRecordSet("mycluster.osd.example.com.", 172800, "ns-2.awsdns-02.net.", "NS-1.awsdns-01.org."), nil)
+
+	// The base domain osd.example.com has no zone, its parent example.com has one
+	parentClient.EXPECT().ListHostedZonesByName(&route53.ListHostedZonesByNameInput{DNSName: awsSdk.String("osd.example.com.")}).Return(&route53.ListHostedZonesByNameOutput{HostedZones: []*route53.HostedZone{
+		{Id: awsSdk.String("/hostedzone/ZOTHER"), Name: awsSdk.String("other.example.com.")},
+	}}, nil)
+	parentClient.EXPECT().ListHostedZonesByName(&route53.ListHostedZonesByNameInput{DNSName: awsSdk.String("example.com.")}).Return(&route53.ListHostedZonesByNameOutput{HostedZones: []*route53.HostedZone{
+		{Id: awsSdk.String("/hostedzone/ZPARENT"), Name: awsSdk.String("example.com.")},
+	}}, nil)
+	// The delegation points to the name servers of a previous zone
+	parentClient.EXPECT().ListResourceRecordSets(gomock.Any()).Return(nsRecordSet("mycluster.osd.example.com.", 3600, "ns-9.awsdns-09.net."), nil)
+
+	o := &fixDNSDelegationOptions{lookupNS: func(domain string) ([]string, error) {
+		return []string{"ns-9.awsdns-09.net."}, nil
+	}}
+	d, err := o.inspectDelegation(clusterClient, parentClient, "mycluster.osd.example.com", "osd.example.com")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(d.nameServers).To(Equal([]string{"ns-1.awsdns-01.org", "ns-2.awsdns-02.net"}))
+	g.Expect(d.parentZoneID).To(Equal("/hostedzone/ZPARENT"))
+	g.Expect(d.parentZoneName).To(Equal("example.com"))
+	g.Expect(d.delegated).To(Equal([]string{"ns-9.awsdns-09.net"}))
+
+	findings, broken := evaluateDelegation(d)
+	g.Expect(broken).To(BeTrue())
+	g.Expect(findings).To(HaveLen(3))
+	g.Expect(findings[1].status).To(Equal(dnsCheckFail))
+
+	changeBatch := delegationChangeBatch(d)
+	g.Expect(changeBatch.Changes).To(HaveLen(1))
+	recordSet := changeBatch.Changes[0].ResourceRecordSet
+	g.Expect(*changeBatch.Changes[0].Action).To(Equal(route53.ChangeActionUpsert))
+	g.Expect(*recordSet.Name).To(Equal("mycluster.osd.example.com."))
+	// The TTL of the existing record is kept
+	g.Expect(*recordSet.TTL).To(Equal(int64(3600)))
+	g.Expect(recordSet.ResourceRecords).To(HaveLen(2))
+	g.Expect(*recordSet.ResourceRecords[0].Value).To(Equal("ns-1.awsdns-01.org."))
+}
+
+func TestEvaluateDelegation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	nameServers := []string{"ns-1.awsdns-01.org", "ns-2.awsdns-02.net"}
+
+	// Correct, the resolvers still caching the previous delegation only warn
+	d := delegation{clusterDomain: "mycluster.example.com", nameServers: nameServers, parentZoneID: "/hostedzone/ZPARENT", parentZoneName: "example.com", delegated: nameServers}
+	findings, broken := evaluateDelegation(d)
+	g.Expect(broken).To(BeFalse())
+	g.Expect(findings[2].status).To(Equal(dnsCheckWarn))
+
+	d.resolved = nameServers
+	findings, broken = evaluateDelegation(d)
+	g.Expect(broken).To(BeFalse())
+	g.Expect(findings[2].status).To(Equal(dnsCheckOK))
+
+	// Missing delegation
+	d.delegated = nil
+	_, broken = evaluateDelegation(d)
+	g.Expect(broken).To(BeTrue())
+
+	// Without access to the parent zone, the public resolution tells
+	d = delegation{clusterDomain: "mycluster.example.com", nameServers: nameServers, resolved: []string{"ns-9.awsdns-09.net"}}
+	findings, broken = evaluateDelegation(d)
+	g.Expect(broken).To(BeTrue())
+	g.Expect(findings[1].status).To(Equal(dnsCheckWarn))
+	g.Expect(findings[2].status).To(Equal(dnsCheckFail))
+	g.Expect(*delegationChangeBatch(d).Changes[0].ResourceRecordSet.TTL).To(Equal(int64(defaultDelegationTTL)))
+}
@@ -0,0 +1,711 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sts"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/artifacts"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/utils/strings/slices"
+)
+
+const (
+	flowLogsLong = `Captures the VPC flow logs of an AWS cluster for a limited time window, to diagnose intermittent connectivity
+complaints.
+
+'enable' turns on the flow logs of the cluster VPC, delivered to the osdctl-flowlogs-<account>-<region> S3 bucket of
+the cluster's account, which is created if needed and deletes the logs after --retention-days. AWS delivers the logs
+every 10 minutes or so. The flow log is tagged with the end of its window: 'disable' deletes it, and so does the next
+'enable' once the window is over. 'fetch' downloads the logs of the window and filters them by network interface,
+address and action.`
+
+	flowLogsEnableExample = `
+  # Capture the flow logs of a cluster for the next 2 hours
+  osdctl cluster flowlogs enable 1kfmyclusteristhebesteverp8m --duration 2h
+`
+	flowLogsFetchExample = `
+  # Show the rejected flows of a network interface over the last 30 minutes
+  osdctl cluster flowlogs fetch 1kfmyclusteristhebesteverp8m --eni eni-0123456789abcdef0 --action REJECT --since 30m
+
+  # The flows from or to an address, as JSON
+  osdctl cluster flowlogs fetch 1kfmyclusteristhebesteverp8m --address 10.0.128.12 -o json
+`
+	flowLogsDisableExample = `
+  # Stop capturing the flow logs of a cluster, the captured logs stay in the bucket until they expire
+  osdctl cluster flowlogs disable 1kfmyclusteristhebesteverp8m
+`
+
+	// flowLogsExpiryTag is set on the flow logs osdctl creates, to the end of their window
+	flowLogsExpiryTag   = "osdctl.openshift.io/flowlogs-expiry"
+	flowLogsMaxDuration = 24 * time.Hour
+	// flowLogsBucketRule is the lifecycle rule of the bucket deleting the logs after the retention
+	flowLogsBucketRule = "osdctl-flowlogs-retention"
+	// flowLogsFileDelay is how much older than the time in their name the records of a log file can be
+	flowLogsFileDelay = 15 * time.Minute
+)
+
+// flowLogFileTime is the time in the name of a flow log file, e.g. 123456789012_vpcflowlogs_us-east-1_fl-0abc_20240531T1205Z_1a2b3c4d.log.gz
+var flowLogFileTime = regexp.MustCompile(`_(\d{8}T\d{4}Z)_[^_]+\.log\.gz$`)
+
+type flowLogsOptions struct {
+	clusterID  string
+	awsProfile string
+
+	// enable
+	duration      time.Duration
+	retentionDays int64
+	skipPrompts   bool
+
+	// fetch
+	since     time.Duration
+	enis      []string
+	addresses []string
+	action    string
+	limit     int
+
+	awsClient     awsprovider.Client
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// flowLogsTarget is where the flow logs of a cluster are captured
+type flowLogsTarget struct {
+	AccountID string
+	Region    string
+	VpcID     string
+	Bucket    string
+	// Prefix is the folder of the cluster in the bucket
+	Prefix string
+}
+
+// destination is the ARN of the folder of the cluster in the bucket, in the partition of the cluster region
+func (t flowLogsTarget) destination() string {
+	return awsprovider.GenerateS3ARNInPartition(awsprovider.PartitionForRegion(t.Region), fmt.Sprintf("%s/%s/", t.Bucket, t.Prefix))
+}
+
+// dayPrefix is the folder AWS delivers the logs of a day to
+func (t flowLogsTarget) dayPrefix(day time.Time) string {
+	return fmt.Sprintf("%s/AWSLogs/%s/vpcflowlogs/%s/%s/", t.Prefix, t.AccountID, t.Region, day.UTC().Format("2006/01/02"))
+}
+
+// flowLogRecord is a record of the default flow log format
+type flowLogRecord struct {
+	Start       time.Time `json:"start" yaml:"start"`
+	End         time.Time `json:"end" yaml:"end"`
+	InterfaceID string    `json:"interface_id" yaml:"interface_id"`
+	SrcAddr     string    `json:"src_addr" yaml:"src_addr"`
+	SrcPort     string    `json:"src_port" yaml:"src_port"`
+	DstAddr     string    `json:"dst_addr" yaml:"dst_addr"`
+	DstPort     string    `json:"dst_port" yaml:"dst_port"`
+	Protocol    string    `json:"protocol" yaml:"protocol"`
+	Packets     int64     `json:"packets" yaml:"packets"`
+	Bytes       int64     `json:"bytes" yaml:"bytes"`
+	Action      string    `json:"action" yaml:"action"`
+}
+
+type flowLogsFetchResponse struct {
+	ClusterID string          `json:"cluster_id" yaml:"cluster_id"`
+	VpcID     string          `json:"vpc_id" yaml:"vpc_id"`
+	Bucket    string          `json:"bucket" yaml:"bucket"`
+	Since     time.Time       `json:"since" yaml:"since"`
+	Records   []flowLogRecord `json:"records" yaml:"records"`
+	// Truncated is the number of older matching records left out by --limit
+	Truncated int      `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+	Warnings  []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+func (r flowLogsFetchResponse) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Flow logs of cluster %s, VPC %s, since %s\n", r.ClusterID, r.VpcID, timefmt.Format(r.Since))
+	if len(r.Records) == 0 {
+		fmt.Fprintln(&b, "No flow log record matches")
+	} else {
+		table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+		table.AddRow([]string{"START", "INTERFACE", "SOURCE", "DESTINATION", "PROTOCOL", "PACKETS", "BYTES", "ACTION"})
+		for _, record := range r.Records {
+			table.AddRow([]string{timefmt.Format(record.Start), record.InterfaceID,
+				record.SrcAddr + ":" + record.SrcPort, record.DstAddr + ":" + record.DstPort, record.Protocol,
+				strconv.FormatInt(record.Packets, 10), strconv.FormatInt(record.Bytes, 10), record.Action})
+		}
+		// Add empty row for readability
+		table.AddRow([]string{})
+		_ = table.Flush()
+	}
+	if r.Truncated > 0 {
+		fmt.Fprintf(&b, "%d older record(s) left out, raise --limit or narrow the filters\n", r.Truncated)
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(&b, "Warning: %s\n", warning)
+	}
+	return b.String()
+}
+
+func newCmdFlowLogs(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	flowLogsCmd := &cobra.Command{
+		Use:               "flowlogs",
+		Short:             "Capture and retrieve the VPC flow logs of a cluster for a limited time window",
+		Long:              flowLogsLong,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	enableOpts := &flowLogsOptions{GlobalOptions: globalOpts}
+	enableCmd := &cobra.Command{
+		Use:               "enable CLUSTER_ID",
+		Short:             "Turn on the VPC flow logs of a cluster for a limited time window",
+		Long:              flowLogsLong,
+		Example:           flowLogsEnableExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			enableOpts.clusterID = args[0]
+			osdctlErrors.CheckErr(enableOpts.completeEnable(cmd))
+			osdctlErrors.CheckErr(enableOpts.runEnable())
+		},
+	}
+	enableCmd.Flags().StringVarP(&enableOpts.awsProfile, "profile", "p", "", "AWS profile used to reach the cluster's account")
+	enableCmd.Flags().DurationVar(&enableOpts.duration, "duration", time.Hour, "How long to capture the flow logs, at most 24h")
+	enableCmd.Flags().Int64Var(&enableOpts.retentionDays, "retention-days", 7, "Days the logs are kept in the bucket, when the bucket is created")
+	enableCmd.Flags().BoolVarP(&enableOpts.skipPrompts, "yes", "y", false, "Skip the confirmation prompt")
+
+	fetchOpts := &flowLogsOptions{GlobalOptions: globalOpts}
+	fetchCmd := &cobra.Command{
+		Use:               "fetch CLUSTER_ID",
+		Short:             "Retrieve and filter the captured VPC flow logs of a cluster",
+		Example:           flowLogsFetchExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			fetchOpts.clusterID = args[0]
+			osdctlErrors.CheckErr(fetchOpts.completeFetch(cmd))
+			osdctlErrors.CheckErr(fetchOpts.runFetch())
+		},
+	}
+	fetchCmd.Flags().StringVarP(&fetchOpts.awsProfile, "profile", "p", "", "AWS profile used to reach the cluster's account")
+	fetchCmd.Flags().DurationVar(&fetchOpts.since, "since", time.Hour, "Only the records of the flows which started within this duration")
+	fetchCmd.Flags().StringSliceVar(&fetchOpts.enis, "eni", nil, "Only the records of these network interfaces, can be repeated")
+	fetchCmd.Flags().StringSliceVar(&fetchOpts.addresses, "address", nil, "Only the records from or to these IP addresses, can be repeated")
+	fetchCmd.Flags().StringVar(&fetchOpts.action, "action", "", "Only the records with this action, ACCEPT or REJECT")
+	fetchCmd.Flags().IntVar(&fetchOpts.limit, "limit", 500, "Maximum number of records, the newest are kept")
+
+	disableOpts := &flowLogsOptions{GlobalOptions: globalOpts}
+	disableCmd := &cobra.Command{
+		Use:               "disable CLUSTER_ID",
+		Short:             "Turn off the VPC flow logs osdctl turned on for a cluster",
+		Example:           flowLogsDisableExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			disableOpts.clusterID = args[0]
+			osdctlErrors.CheckErr(disableOpts.runDisable())
+		},
+	}
+	disableCmd.Flags().StringVarP(&disableOpts.awsProfile, "profile", "p", "", "AWS profile used to reach the cluster's account")
+	disableCmd.Flags().BoolVarP(&disableOpts.skipPrompts, "yes", "y", false, "Skip the confirmation prompt")
+
+	flowLogsCmd.AddCommand(enableCmd, fetchCmd, disableCmd)
+	return flowLogsCmd
+}
+
+func (o *flowLogsOptions) completeEnable(cmd *cobra.Command) error {
+	if o.duration <= 0 || o.duration > flowLogsMaxDuration {
+		return cmdutil.UsageErrorf(cmd, "--duration must be between 0 and %s", flowLogsMaxDuration)
+	}
+	if o.retentionDays < 1 {
+		return cmdutil.UsageErrorf(cmd, "--retention-days must be at least 1")
+	}
+	return nil
+}
+
+func (o *flowLogsOptions) completeFetch(cmd *cobra.Command) error {
+	if o.since <= 0 {
+		return cmdutil.UsageErrorf(cmd, "--since must be positive")
+	}
+	if o.limit < 1 {
+		return cmdutil.UsageErrorf(cmd, "--limit must be at least 1")
+	}
+	o.action = strings.ToUpper(o.action)
+	if o.action != "" && o.action != "ACCEPT" && o.action != "REJECT" {
+		return cmdutil.UsageErrorf(cmd, "--action must be ACCEPT or REJECT")
+	}
+	return nil
+}
+
+// setup returns the cluster, whose VPC flow logs are captured, and a client of its account
+func (o *flowLogsOptions) setup(ocmClient *sdk.Connection) (*cmv1.Cluster, flowLogsTarget, error) {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return nil, flowLogsTarget{}, err
+	}
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return nil, flowLogsTarget{}, err
+	}
+	if cluster.CloudProvider().ID() != "aws" {
+		return nil, flowLogsTarget{}, osdctlErrors.New(osdctlErrors.ErrValidation, "'osdctl cluster flowlogs' only supports AWS clusters, %s is on %s",
+			cluster.ID(), cluster.CloudProvider().ID())
+	}
+	if o.awsClient == nil {
+		o.awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+		if err != nil {
+			return nil, flowLogsTarget{}, err
+		}
+	}
+	target, err := flowLogsTargetFor(o.awsClient, cluster)
+	return cluster, target, err
+}
+
+func (o *flowLogsOptions) runEnable() error {
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, target, err := o.setup(ocmClient)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	expiry := now.Add(o.duration).Truncate(time.Second)
+	active, expired, err := osdctlFlowLogs(o.awsClient, target.VpcID, now)
+	if err != nil {
+		return err
+	}
+	if len(active) > 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the flow logs of the VPC %s are already captured until %s by %s, disable them first",
+			target.VpcID, timefmt.Format(flowLogExpiry(active[0])), awsSdk.StringValue(active[0].FlowLogId))
+	}
+
+	action := fmt.Sprintf("Capture the flow logs of the VPC %s to s3://%s/%s/ until %s", target.VpcID, target.Bucket, target.Prefix, timefmt.Format(expiry))
+	if len(expired) > 0 {
+		action += fmt.Sprintf(", delete %d flow log(s) whose window is over", len(expired))
+	}
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(ocmClient, cluster, action),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := ensureFlowLogsBucket(o.awsClient, target, o.retentionDays); err != nil {
+		return err
+	}
+	if err := deleteFlowLogs(o.awsClient, expired); err != nil {
+		return err
+	}
+	flowLogID, err := createFlowLog(o.awsClient, target, expiry)
+	if err != nil {
+		return err
+	}
+	utils.RecordClusterAction(ocmClient, cluster, fmt.Sprintf("Capture the flow logs of the VPC %s until %s", target.VpcID, expiry.Format(time.RFC3339)))
+	fmt.Printf("Flow log %s captures the flows of the VPC %s until %s. AWS delivers the logs every 10 minutes or so, "+
+		"retrieve them with 'osdctl cluster flowlogs fetch %s' and stop the capture with 'osdctl cluster flowlogs disable %s'\n",
+		flowLogID, target.VpcID, timefmt.Format(expiry), cluster.ID(), cluster.ID())
+	return nil
+}
+
+func (o *flowLogsOptions) runFetch() error {
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, target, err := o.setup(ocmClient)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	filter := flowLogFilter{
+		since:     now.Add(-o.since),
+		enis:      o.enis,
+		addresses: o.addresses,
+		action:    o.action,
+	}
+	response, err := fetchFlowLogs(o.awsClient, target, filter, o.limit, now)
+	if err != nil {
+		return err
+	}
+	response.ClusterID = cluster.ID()
+	if artifacts.Enabled() {
+		content, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return err
+		}
+		path, err := artifacts.Write(cluster.ID(), "flowlogs.json", content, fmt.Sprintf("flow log records of the last %s", o.since))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Flow log records saved to %s\n", path)
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+func (o *flowLogsOptions) runDisable() error {
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, target, err := o.setup(ocmClient)
+	if err != nil {
+		return err
+	}
+	active, expired, err := osdctlFlowLogs(o.awsClient, target.VpcID, time.Now())
+	if err != nil {
+		return err
+	}
+	flowLogs := append(active, expired...)
+	if len(flowLogs) == 0 {
+		fmt.Printf("osdctl doesn't capture the flow logs of the VPC %s\n", target.VpcID)
+		return nil
+	}
+
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(ocmClient, cluster, fmt.Sprintf("Delete %d flow log(s) of the VPC %s, the captured logs stay in s3://%s", len(flowLogs), target.VpcID, target.Bucket)),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+	if err := deleteFlowLogs(o.awsClient, flowLogs); err != nil {
+		return err
+	}
+	utils.RecordClusterAction(ocmClient, cluster, fmt.Sprintf("Delete %d flow log(s) of the VPC %s", len(flowLogs), target.VpcID))
+	fmt.Printf("Deleted %d flow log(s) of the VPC %s\n", len(flowLogs), target.VpcID)
+	return nil
+}
+
+// flowLogsTargetFor returns the VPC of the cluster and the bucket of its account receiving the flow logs
+func flowLogsTargetFor(client awsprovider.Client, cluster *cmv1.Cluster) (flowLogsTarget, error) {
+	subnets, err := clusterSubnets(client, cluster)
+	if err != nil {
+		return flowLogsTarget{}, err
+	}
+	identity, err := client.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return flowLogsTarget{}, fmt.Errorf("cannot get the account of the cluster: %w", err)
+	}
+	accountID := awsSdk.StringValue(identity.Account)
+	region := cluster.Region().ID()
+	return flowLogsTarget{
+		AccountID: accountID,
+		Region:    region,
+		VpcID:     awsSdk.StringValue(subnets[0].VpcId),
+		Bucket:    fmt.Sprintf("osdctl-flowlogs-%s-%s", accountID, region),
+		Prefix:    cluster.ID(),
+	}, nil
+}
+
+// osdctlFlowLogs returns the flow logs osdctl created on the VPC, split between the ones whose window is over or not
+func osdctlFlowLogs(client awsprovider.Client, vpcID string, now time.Time) (active, expired []*ec2.FlowLog, err error) {
+	input := &ec2.DescribeFlowLogsInput{Filter: []*ec2.Filter{
+		{Name: awsSdk.String("resource-id"), Values: []*string{awsSdk.String(vpcID)}},
+		{Name: awsSdk.String("tag-key"), Values: []*string{awsSdk.String(flowLogsExpiryTag)}},
+	}}
+	for {
+		output, err := client.DescribeFlowLogs(input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot describe the flow logs of the VPC %s: %w", vpcID, err)
+		}
+		for _, flowLog := range output.FlowLogs {
+			if flowLogExpiry(flowLog).After(now) {
+				active = append(active, flowLog)
+			} else {
+				expired = append(expired, flowLog)
+			}
+		}
+		if output.NextToken == nil {
+			return active, expired, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// flowLogExpiry returns the end of the window of a flow log, the zero time when its tag can't be parsed
+func flowLogExpiry(flowLog *ec2.FlowLog) time.Time {
+	for _, tag := range flowLog.Tags {
+		if awsSdk.StringValue(tag.Key) == flowLogsExpiryTag {
+			expiry, _ := time.Parse(time.RFC3339, awsSdk.StringValue(tag.Value))
+			return expiry
+		}
+	}
+	return time.Time{}
+}
+
+// ensureFlowLogsBucket creates the bucket receiving the flow logs of the account if it doesn't exist yet, with a
+// lifecycle rule deleting the logs after the retention. AWS adds the bucket policy letting the flow logs write to it.
+func ensureFlowLogsBucket(client awsprovider.Client, target flowLogsTarget, retentionDays int64) error {
+	buckets, err := client.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return fmt.Errorf("cannot list the buckets of the account %s: %w", target.AccountID, err)
+	}
+	for _, bucket := range buckets.Buckets {
+		if awsSdk.StringValue(bucket.Name) == target.Bucket {
+			return nil
+		}
+	}
+
+	input := &s3.CreateBucketInput{Bucket: awsSdk.String(target.Bucket)}
+	// us-east-1 is the default location, which can't be given as a constraint
+	if target.Region != "us-east-1" {
+		input.CreateBucketConfiguration = &s3.CreateBucketConfiguration{LocationConstraint: awsSdk.String(target.Region)}
+	}
+	if _, err := client.CreateBucket(input); err != nil {
+		return fmt.Errorf("cannot create the bucket %s: %w", target.Bucket, err)
+	}
+	_, err = client.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket: awsSdk.String(target.Bucket),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: []*s3.LifecycleRule{{
+			ID:         awsSdk.String(flowLogsBucketRule),
+			Status:     awsSdk.String(s3.ExpirationStatusEnabled),
+			Filter:     &s3.LifecycleRuleFilter{Prefix: awsSdk.String("")},
+			Expiration: &s3.LifecycleExpiration{Days: awsSdk.Int64(retentionDays)},
+		}}},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot set the retention of the bucket %s: %w", target.Bucket, err)
+	}
+	return nil
+}
+
+// createFlowLog captures all the traffic of the VPC to the folder of the cluster in the bucket, aggregated by minute
+func createFlowLog(client awsprovider.Client, target flowLogsTarget, expiry time.Time) (string, error) {
+	output, err := client.CreateFlowLogs(&ec2.CreateFlowLogsInput{
+		ResourceIds:            []*string{awsSdk.String(target.VpcID)},
+		ResourceType:           awsSdk.String(ec2.FlowLogsResourceTypeVpc),
+		TrafficType:            awsSdk.String(ec2.TrafficTypeAll),
+		LogDestinationType:     awsSdk.String(ec2.LogDestinationTypeS3),
+		LogDestination:         awsSdk.String(target.destination()),
+		MaxAggregationInterval: awsSdk.Int64(60),
+		TagSpecifications: []*ec2.TagSpecification{{
+			ResourceType: awsSdk.String(ec2.ResourceTypeVpcFlowLog),
+			Tags: []*ec2.Tag{
+				{Key: awsSdk.String("Name"), Value: awsSdk.String("osdctl-" + target.Prefix)},
+				{Key: awsSdk.String(flowLogsExpiryTag), Value: awsSdk.String(expiry.Format(time.RFC3339))},
+			},
+		}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot create the flow log of the VPC %s: %w", target.VpcID, err)
+	}
+	for _, unsuccessful := range output.Unsuccessful {
+		if unsuccessful.Error != nil {
+			return "", fmt.Errorf("cannot create the flow log of the VPC %s: %s", target.VpcID, awsSdk.StringValue(unsuccessful.Error.Message))
+		}
+	}
+	if len(output.FlowLogIds) == 0 {
+		return "", fmt.Errorf("no flow log was created for the VPC %s", target.VpcID)
+	}
+	return awsSdk.StringValue(output.FlowLogIds[0]), nil
+}
+
+func deleteFlowLogs(client awsprovider.Client, flowLogs []*ec2.FlowLog) error {
+	if len(flowLogs) == 0 {
+		return nil
+	}
+	input := &ec2.DeleteFlowLogsInput{}
+	for _, flowLog := range flowLogs {
+		input.FlowLogIds = append(input.FlowLogIds, flowLog.FlowLogId)
+	}
+	output, err := client.DeleteFlowLogs(input)
+	if err != nil {
+		return fmt.Errorf("cannot delete the flow logs: %w", err)
+	}
+	for _, unsuccessful := range output.Unsuccessful {
+		if unsuccessful.Error != nil {
+			return fmt.Errorf("cannot delete the flow log %s: %s", awsSdk.StringValue(unsuccessful.ResourceId), awsSdk.StringValue(unsuccessful.Error.Message))
+		}
+	}
+	return nil
+}
+
+// flowLogFilter selects the records of fetch, the empty fields match every record
+type flowLogFilter struct {
+	since     time.Time
+	enis      []string
+	addresses []string
+	action    string
+}
+
+func (f flowLogFilter) matches(record flowLogRecord) bool {
+	if record.Start.Before(f.since) {
+		return false
+	}
+	if f.action != "" && record.Action != f.action {
+		return false
+	}
+	if len(f.enis) > 0 && !slices.Contains(f.enis, record.InterfaceID) {
+		return false
+	}
+	if len(f.addresses) > 0 && !slices.Contains(f.addresses, record.SrcAddr) && !slices.Contains(f.addresses, record.DstAddr) {
+		return false
+	}
+	return true
+}
+
+// fetchFlowLogs downloads the log files of the cluster delivered since the start of the filter, and returns their
+// matching records, the newest ones when there are more than the limit
+func fetchFlowLogs(client awsprovider.Client, target flowLogsTarget, filter flowLogFilter, limit int, now time.Time) (flowLogsFetchResponse, error) {
+	response := flowLogsFetchResponse{VpcID: target.VpcID, Bucket: target.Bucket, Since: filter.since, Records: []flowLogRecord{}}
+
+	active, expired, err := osdctlFlowLogs(client, target.VpcID, now)
+	if err != nil {
+		return response, err
+	}
+	if len(active) == 0 {
+		response.Warnings = append(response.Warnings, fmt.Sprintf("osdctl isn't capturing the flow logs of the VPC %s, see 'osdctl cluster flowlogs enable'", target.VpcID))
+	}
+	for _, flowLog := range expired {
+		response.Warnings = append(response.Warnings, fmt.Sprintf("the window of the flow log %s ended at %s, stop it with 'osdctl cluster flowlogs disable'",
+			awsSdk.StringValue(flowLog.FlowLogId), timefmt.Format(flowLogExpiry(flowLog))))
+	}
+
+	keys, err := flowLogFiles(client, target, filter.since, now)
+	if err != nil {
+		return response, err
+	}
+	for _, key := range keys {
+		records, err := readFlowLogFile(client, target.Bucket, key)
+		if err != nil {
+			return response, err
+		}
+		for _, record := range records {
+			if filter.matches(record) {
+				response.Records = append(response.Records, record)
+			}
+		}
+	}
+
+	sort.SliceStable(response.Records, func(i, j int) bool {
+		return response.Records[i].Start.Before(response.Records[j].Start)
+	})
+	if len(response.Records) > limit {
+		response.Truncated = len(response.Records) - limit
+		response.Records = response.Records[response.Truncated:]
+	}
+	return response, nil
+}
+
+// flowLogFiles lists the log files of the cluster which can have records since the given time
+func flowLogFiles(client awsprovider.Client, target flowLogsTarget, since, now time.Time) ([]string, error) {
+	var keys []string
+	for day := since.UTC().Truncate(24 * time.Hour); !day.After(now); day = day.Add(24 * time.Hour) {
+		input := &s3.ListObjectsInput{Bucket: awsSdk.String(target.Bucket), Prefix: awsSdk.String(target.dayPrefix(day))}
+		for {
+			output, err := client.ListObjects(input)
+			if err != nil {
+				return nil, fmt.Errorf("cannot list the flow logs in the bucket %s: %w", target.Bucket, err)
+			}
+			for _, object := range output.Contents {
+				key := awsSdk.StringValue(object.Key)
+				match := flowLogFileTime.FindStringSubmatch(key)
+				if match == nil {
+					continue
+				}
+				fileTime, err := time.Parse("20060102T1504Z", match[1])
+				if err != nil || fileTime.Before(since.Add(-flowLogsFileDelay)) {
+					continue
+				}
+				keys = append(keys, key)
+			}
+			if !awsSdk.BoolValue(output.IsTruncated) || len(output.Contents) == 0 {
+				break
+			}
+			input.Marker = output.Contents[len(output.Contents)-1].Key
+		}
+	}
+	return keys, nil
+}
+
+// readFlowLogFile downloads and parses a gzipped log file, skipping its header and the records without data
+func readFlowLogFile(client awsprovider.Client, bucket, key string) ([]flowLogRecord, error) {
+	output, err := client.GetObject(&s3.GetObjectInput{Bucket: awsSdk.String(bucket), Key: awsSdk.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("cannot download the flow log %s: %w", key, err)
+	}
+	defer output.Body.Close()
+	reader, err := gzip.NewReader(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decompress the flow log %s: %w", key, err)
+	}
+	defer reader.Close()
+
+	var records []flowLogRecord
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if record, ok := parseFlowLogRecord(scanner.Text()); ok {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read the flow log %s: %w", key, err)
+	}
+	return records, nil
+}
+
+// parseFlowLogRecord parses a record of the default format:
+// version account-id interface-id srcaddr dstaddr srcport dstport protocol packets bytes start end action log-status
+func parseFlowLogRecord(line string) (flowLogRecord, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 14 || fields[13] != "OK" {
+		return flowLogRecord{}, false
+	}
+	start, err := strconv.ParseInt(fields[10], 10, 64)
+	if err != nil {
+		return flowLogRecord{}, false
+	}
+	end, err := strconv.ParseInt(fields[11], 10, 64)
+	if err != nil {
+		return flowLogRecord{}, false
+	}
+	packets, _ := strconv.ParseInt(fields[8], 10, 64)
+	bytesCount, _ := strconv.ParseInt(fields[9], 10, 64)
+	return flowLogRecord{
+		Start:       time.Unix(start, 0).UTC(),
+		End:         time.Unix(end, 0).UTC(),
+		InterfaceID: fields[2],
+		SrcAddr:     fields[3],
+		DstAddr:     fields[4],
+		SrcPort:     fields[5],
+		DstPort:     fields[6],
+		Protocol:    protocolName(fields[7]),
+		Packets:     packets,
+		Bytes:       bytesCount,
+		Action:      fields[12],
+	}, true
+}
+
+// protocolName returns the name of the common IANA protocol numbers
+func protocolName(number string) string {
+	switch number {
+	case "1":
+		return "icmp"
+	case "6":
+		return "tcp"
+	case "17":
+		return "udp"
+	case "58":
+		return "icmpv6"
+	}
+	return number
+}
@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+var testFlowLogsTarget = flowLogsTarget{
+	AccountID: "123456789012",
+	Region:    "eu-west-1",
+	VpcID:     "vpc-1",
+	Bucket:    "osdctl-flowlogs-123456789012-eu-west-1",
+	Prefix:    "abc",
+}
+
+func gzipped(g *WithT, content string) io.ReadCloser {
+	var b bytes.Buffer
+	writer := gzip.NewWriter(&b)
+	_, err := writer.Write([]byte(content))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(writer.Close()).To(Succeed())
+	return io.NopCloser(&b)
+}
+
+func TestParseFlowLogRecord(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	record, ok := parseFlowLogRecord("2 123456789012 eni-1 10.0.1.5 10.0.2.7 443 49152 6 10 840 1717156800 1717156860 REJECT OK")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(record).To(Equal(flowLogRecord{
+		Start: time.Unix(1717156800, 0).UTC(), End: time.Unix(1717156860, 0).UTC(), InterfaceID: "eni-1",
+		SrcAddr: "10.0.1.5", SrcPort: "443", DstAddr: "10.0.2.7", DstPort: "49152", Protocol: "tcp",
+		Packets: 10, Bytes: 840, Action: "REJECT",
+	}))
+
+	_, ok = parseFlowLogRecord("version account-id interface-id srcaddr dstaddr srcport dstport protocol packets bytes start end action log-status")
+	g.Expect(ok).To(BeFalse())
+	_, ok = parseFlowLogRecord("2 123456789012 eni-1 - - - - - - - 1717156800 1717156860 - NODATA")
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestFetchFlowLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	useUTC(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+	now := time.Date(2024, 6, 1, 0, 10, 0, 0, time.UTC)
+
+	client.EXPECT().DescribeFlowLogs(gomock.Any()).Return(&ec2.DescribeFlowLogsOutput{FlowLogs: []*ec2.FlowLog{{
+		FlowLogId: awsSdk.String("fl-1"),
+		Tags:      []*ec2.Tag{{Key: awsSdk.String(flowLogsExpiryTag), Value: awsSdk.String("2024-05-31T23:00:00Z")}},
+	}}}, nil)
+	// The window starts on May 31st and ends on June 1st
+	client.EXPECT().ListObjects(&s3.ListObjectsInput{Bucket: awsSdk.String(testFlowLogsTarget.Bucket),
+		Prefix: awsSdk.String("abc/AWSLogs/123456789012/vpcflowlogs/eu-west-1/2024/05/31/")}).Return(&s3.ListObjectsOutput{
+		Contents: []*s3.Object{
+			{Key: awsSdk.String("abc/AWSLogs/123456789012/vpcflowlogs/eu-west-1/2024/05/31/123456789012_vpcflowlogs_eu-west-1_fl-1_20240531T2000Z_aaaa.log.gz")},
+			{Key: awsSdk.String("abc/AWSLogs/123456789012/vpcflowlogs/eu-west-1/2024/05/31/123456789012_vpcflowlogs_eu-west-1_fl-1_20240531T2355Z_bbbb.log.gz")},
+		},
+	}, nil)
+	client.EXPECT().ListObjects(&s3.ListObjectsInput{Bucket: awsSdk.String(testFlowLogsTarget.Bucket),
+		Prefix: awsSdk.String("abc/AWSLogs/123456789012/vpcflowlogs/eu-west-1/2024/06/01/")}).Return(&s3.ListObjectsOutput{
+		Contents: []*s3.Object{
+			{Key: awsSdk.String("abc/AWSLogs/123456789012/vpcflowlogs/eu-west-1/2024/06/01/123456789012_vpcflowlogs_eu-west-1_fl-1_20240601T0005Z_cccc.log.gz")},
+		},
+	}, nil)
+	// The file of 20:00 is older than the window and isn't downloaded
+	client.EXPECT().GetObject(gomock.Any()).DoAndReturn(func(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		g.Expect(awsSdk.StringValue(input.Key)).To(HaveSuffix("bbbb.log.gz"))
+		return &s3.GetObjectOutput{Body: gzipped(g, "version account-id interface-id srcaddr dstaddr srcport dstport protocol packets bytes start end action log-status\n"+
+			"2 123456789012 eni-1 10.0.1.5 10.0.2.7 443 49152 6 10 840 1717199700 1717199760 REJECT OK\n"+
+			"2 123456789012 eni-2 10.0.1.6 10.0.2.7 443 49153 6 10 840 1717199700 1717199760 REJECT OK\n")}, nil
+	})
+	client.EXPECT().GetObject(gomock.Any()).DoAndReturn(func(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+		g.Expect(awsSdk.StringValue(input.Key)).To(HaveSuffix("cccc.log.gz"))
+		return &s3.GetObjectOutput{Body: gzipped(g, "2 123456789012 eni-1 10.0.1.5 10.0.2.7 443 49152 6 10 840 1717200000 1717200060 ACCEPT OK\n"+
+			"2 123456789012 eni-1 10.0.1.5 10.0.2.8 443 49152 17 1 60 1717200060 1717200120 REJECT OK\n")}, nil
+	})
+
+	filter := flowLogFilter{since: time.Date(2024, 5, 31, 23, 50, 0, 0, time.UTC), enis: []string{"eni-1"}, action: "REJECT"}
+	response, err := fetchFlowLogs(client, testFlowLogsTarget, filter, 1, now)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(response.Records).To(HaveLen(1))
+	g.Expect(response.Records[0].DstAddr).To(Equal("10.0.2.8"))
+	g.Expect(response.Records[0].Protocol).To(Equal("udp"))
+	g.Expect(response.Truncated).To(Equal(1))
+	g.Expect(response.Warnings).To(ConsistOf(
+		ContainSubstring("isn't capturing the flow logs of the VPC vpc-1"),
+		ContainSubstring("the window of the flow log fl-1 ended at 2024-05-31 23:00:00 UTC"),
+	))
+}
+
+func TestFlowLogFilterAddresses(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	filter := flowLogFilter{addresses: []string{"10.0.2.7"}}
+	g.Expect(filter.matches(flowLogRecord{SrcAddr: "10.0.2.7", DstAddr: "10.0.1.5"})).To(BeTrue())
+	g.Expect(filter.matches(flowLogRecord{SrcAddr: "10.0.1.5", DstAddr: "10.0.2.7"})).To(BeTrue())
+	g.Expect(filter.matches(flowLogRecord{SrcAddr: "10.0.1.5", DstAddr: "10.0.1.6"})).To(BeFalse())
+}
+
+func TestEnableFlowLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+	expiry := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	client.EXPECT().ListBuckets(gomock.Any()).Return(&s3.ListBucketsOutput{Buckets: []*s3.Bucket{{Name: awsSdk.String("other")}}}, nil)
+	client.EXPECT().CreateBucket(&s3.CreateBucketInput{
+		Bucket:                    awsSdk.String(testFlowLogsTarget.Bucket),
+		CreateBucketConfiguration: &s3.CreateBucketConfiguration{LocationConstraint: awsSdk.String("eu-west-1")},
+	}).Return(&s3.CreateBucketOutput{}, nil)
+	client.EXPECT().PutBucketLifecycleConfiguration(gomock.Any()).DoAndReturn(func(input *s3.PutBucketLifecycleConfigurationInput) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+		g.Expect(awsSdk.Int64Value(input.LifecycleConfiguration.Rules[0].Expiration.Days)).To(Equal(int64(3)))
+		return &s3.PutBucketLifecycleConfigurationOutput{}, nil
+	})
+	g.Expect(ensureFlowLogsBucket(client, testFlowLogsTarget, 3)).To(Succeed())
+
+	client.EXPECT().CreateFlowLogs(gomock.Any()).DoAndReturn(func(input *ec2.CreateFlowLogsInput) (*ec2.CreateFlowLogsOutput, error) {
+		g.Expect(awsSdk.StringValue(input.LogDestination)).To(Equal("arn:aws:s3:::osdctl-flowlogs-123456789012-eu-west-1/abc/"))
+		g.Expect(input.TagSpecifications[0].Tags).To(ContainElement(&ec2.Tag{Key: awsSdk.String(flowLogsExpiryTag), Value: awsSdk.String("2024-06-01T02:00:00Z")}))
+		return &ec2.CreateFlowLogsOutput{FlowLogIds: awsSdk.StringSlice([]string{"fl-2"})}, nil
+	})
+	flowLogID, err := createFlowLog(client, testFlowLogsTarget, expiry)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(flowLogID).To(Equal("fl-2"))
+
+	client.EXPECT().DescribeFlowLogs(gomock.Any()).Return(&ec2.DescribeFlowLogsOutput{FlowLogs: []*ec2.FlowLog{
+		{FlowLogId: awsSdk.String("fl-2"), Tags: []*ec2.Tag{{Key: awsSdk.String(flowLogsExpiryTag), Value: awsSdk.String("2024-06-01T02:00:00Z")}}},
+	}}, nil)
+	active, expired, err := osdctlFlowLogs(client, "vpc-1", expiry.Add(-time.Minute))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(active).To(HaveLen(1))
+	g.Expect(expired).To(BeEmpty())
+}
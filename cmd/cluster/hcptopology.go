@@ -0,0 +1,204 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	hcpTopologyLong = `Resolves the layers of a hosted control plane (HCP) cluster: the management cluster running its control plane,
+the service cluster managing that management cluster, and the namespaces of the hosted cluster on the management
+cluster.
+
+The management cluster comes from the hypershift settings of the cluster in OCM, the service cluster from the OSD
+fleet management API. With --login-hints, the backplane commands to log in to every layer are printed as well.`
+
+	hcpTopologyExample = `
+  # Print the management and service clusters of a HCP cluster
+  osdctl cluster hcp-topology 1kfmyclusteristhebesteverp8m
+
+  # With the backplane login commands of every layer
+  osdctl cluster hcp-topology 1kfmyclusteristhebesteverp8m --login-hints
+`
+)
+
+type hcpTopologyOptions struct {
+	clusterID  string
+	loginHints bool
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// hcpLayer is a cluster of the topology of a hosted cluster
+type hcpLayer struct {
+	ID    string `json:"id" yaml:"id"`
+	Name  string `json:"name" yaml:"name"`
+	Login string `json:"login,omitempty" yaml:"login,omitempty"`
+}
+
+type hcpTopology struct {
+	Cluster                     hcpLayer `json:"cluster" yaml:"cluster"`
+	ManagementCluster           hcpLayer `json:"management_cluster" yaml:"management_cluster"`
+	ServiceCluster              hcpLayer `json:"service_cluster" yaml:"service_cluster"`
+	HostedClusterNamespace      string   `json:"hosted_cluster_namespace" yaml:"hosted_cluster_namespace"`
+	HostedControlPlaneNamespace string   `json:"hosted_control_plane_namespace" yaml:"hosted_control_plane_namespace"`
+	// ControlPlaneHint shows the control plane once logged in to the management cluster
+	ControlPlaneHint string `json:"control_plane_hint,omitempty" yaml:"control_plane_hint,omitempty"`
+}
+
+func (t hcpTopology) String() string {
+	var b bytes.Buffer
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"LAYER", "ID", "NAME", "LOGIN"})
+	table.AddRow([]string{"Hosted cluster", t.Cluster.ID, t.Cluster.Name, t.Cluster.Login})
+	table.AddRow([]string{"Management cluster", t.ManagementCluster.ID, t.ManagementCluster.Name, t.ManagementCluster.Login})
+	table.AddRow([]string{"Service cluster", t.ServiceCluster.ID, t.ServiceCluster.Name, t.ServiceCluster.Login})
+	// Add empty row for readability
+	table.AddRow([]string{})
+	table.AddRow([]string{"Hosted cluster namespace:", t.HostedClusterNamespace})
+	table.AddRow([]string{"Hosted control plane namespace:", t.HostedControlPlaneNamespace})
+	if t.ControlPlaneHint != "" {
+		table.AddRow([]string{"Control plane:", t.ControlPlaneHint})
+	}
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the topology: %v", err)
+	}
+	return b.String()
+}
+
+func newCmdHCPTopology(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &hcpTopologyOptions{GlobalOptions: globalOpts}
+	hcpTopologyCmd := &cobra.Command{
+		Use:               "hcp-topology CLUSTER_ID",
+		Short:             "Print the management cluster, service cluster and namespaces of a hosted control plane cluster",
+		Long:              hcpTopologyLong,
+		Example:           hcpTopologyExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	hcpTopologyCmd.Flags().BoolVar(&ops.loginHints, "login-hints", false, "Print the backplane login command of every layer")
+
+	return hcpTopologyCmd
+}
+
+func (o *hcpTopologyOptions) run() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if !cluster.Hypershift().Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s isn't a hosted control plane cluster", cluster.ID())
+	}
+
+	managementCluster, err := getManagementCluster(connection, cluster.ID())
+	if err != nil {
+		return err
+	}
+	serviceCluster, err := getServiceCluster(connection, managementCluster.Name())
+	if err != nil {
+		return err
+	}
+
+	topology := newHCPTopology(cluster, managementCluster, serviceCluster, utils.GetCurrentOCMEnv(connection), o.loginHints)
+	return outputflag.PrintResponse(o.GlobalOptions.Output, topology)
+}
+
+// getManagementCluster returns the management cluster running the control plane of the hosted cluster
+func getManagementCluster(connection *sdk.Connection, clusterID string) (*cmv1.Cluster, error) {
+	response, err := connection.ClustersMgmt().V1().Clusters().Cluster(clusterID).Hypershift().Get().Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve the hypershift settings of cluster %s: %w", clusterID, err)
+	}
+	name := response.Body().ManagementCluster()
+	if name == "" {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "cluster %s has no management cluster yet", clusterID)
+	}
+	return getClusterByName(connection, name, "management")
+}
+
+// getServiceCluster returns the service cluster of the management cluster, the parent of the management cluster
+// in the OSD fleet management API
+func getServiceCluster(connection *sdk.Connection, managementClusterName string) (*cmv1.Cluster, error) {
+	fleet := connection.OSDFleetMgmt().V1()
+	response, err := fleet.ManagementClusters().List().
+		Parameter("search", fmt.Sprintf("name='%s'", managementClusterName)).Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve management cluster %s from fleet management: %w", managementClusterName, err)
+	}
+	if response.Items().Len() == 0 {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "fleet management doesn't know management cluster %s", managementClusterName)
+	}
+	parentID := response.Items().Get(0).Parent().ClusterId()
+	if parentID == "" {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "management cluster %s has no service cluster", managementClusterName)
+	}
+
+	serviceCluster, err := fleet.ServiceClusters().ServiceCluster(parentID).Get().Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve service cluster %s from fleet management: %w", parentID, err)
+	}
+	clusterID := serviceCluster.Body().ClusterManagementReference().ClusterId()
+	if clusterID == "" {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "service cluster %s has no OCM cluster", parentID)
+	}
+	return utils.GetCluster(connection, clusterID)
+}
+
+func getClusterByName(connection *sdk.Connection, name, layer string) (*cmv1.Cluster, error) {
+	response, err := connection.ClustersMgmt().V1().Clusters().List().Search(fmt.Sprintf("name='%s'", name)).Size(1).Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve %s cluster %s: %w", layer, name, err)
+	}
+	if response.Items().Len() == 0 {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "%s cluster %s not found", layer, name)
+	}
+	return response.Items().Get(0), nil
+}
+
+// hcpNamespacePrefix returns the prefix of the namespaces of the hosted clusters of the OCM environment
+func hcpNamespacePrefix(env string) string {
+	// The namespaces are named after the environment as OCM calls it, and not as osdctl does
+	if env == "stage" {
+		env = "staging"
+	}
+	return "ocm-" + env
+}
+
+func newHCPTopology(cluster, managementCluster, serviceCluster *cmv1.Cluster, env string, loginHints bool) hcpTopology {
+	hostedClusterNamespace := fmt.Sprintf("%s-%s", hcpNamespacePrefix(env), cluster.ID())
+	topology := hcpTopology{
+		Cluster:                     hcpLayer{ID: cluster.ID(), Name: cluster.Name()},
+		ManagementCluster:           hcpLayer{ID: managementCluster.ID(), Name: managementCluster.Name()},
+		ServiceCluster:              hcpLayer{ID: serviceCluster.ID(), Name: serviceCluster.Name()},
+		HostedClusterNamespace:      hostedClusterNamespace,
+		HostedControlPlaneNamespace: fmt.Sprintf("%s-%s", hostedClusterNamespace, cluster.Name()),
+	}
+	if loginHints {
+		topology.Cluster.Login = "ocm backplane login " + cluster.ID()
+		topology.ManagementCluster.Login = "ocm backplane login " + managementCluster.ID()
+		topology.ServiceCluster.Login = "ocm backplane login " + serviceCluster.ID()
+		topology.ControlPlaneHint = "oc get hostedcontrolplane,pods -n " + topology.HostedControlPlaneNamespace
+	}
+	return topology
+}
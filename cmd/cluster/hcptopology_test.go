@@ -0,0 +1,36 @@
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func newTopologyTestCluster(g *WithT, id, name string) *cmv1.Cluster {
+	cluster, err := cmv1.NewCluster().ID(id).Name(name).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	return cluster
+}
+
+func TestNewHCPTopology(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := newTopologyTestCluster(g, "abc", "mycluster")
+	managementCluster := newTopologyTestCluster(g, "mc1", "hs-mc-1")
+	serviceCluster := newTopologyTestCluster(g, "sc1", "hs-sc-1")
+
+	topology := newHCPTopology(cluster, managementCluster, serviceCluster, "production", false)
+	g.Expect(topology.ManagementCluster).To(Equal(hcpLayer{ID: "mc1", Name: "hs-mc-1"}))
+	g.Expect(topology.ServiceCluster).To(Equal(hcpLayer{ID: "sc1", Name: "hs-sc-1"}))
+	g.Expect(topology.HostedClusterNamespace).To(Equal("ocm-production-abc"))
+	g.Expect(topology.HostedControlPlaneNamespace).To(Equal("ocm-production-abc-mycluster"))
+	g.Expect(topology.ControlPlaneHint).To(BeEmpty())
+
+	topology = newHCPTopology(cluster, managementCluster, serviceCluster, "stage", true)
+	g.Expect(topology.HostedControlPlaneNamespace).To(Equal("ocm-staging-abc-mycluster"))
+	g.Expect(topology.Cluster.Login).To(Equal("ocm backplane login abc"))
+	g.Expect(topology.ManagementCluster.Login).To(Equal("ocm backplane login mc1"))
+	g.Expect(topology.ServiceCluster.Login).To(Equal("ocm backplane login sc1"))
+	g.Expect(topology.ControlPlaneHint).To(Equal("oc get hostedcontrolplane,pods -n ocm-staging-abc-mycluster"))
+	g.Expect(topology.String()).To(ContainSubstring("hs-mc-1"))
+}
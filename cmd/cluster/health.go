@@ -3,18 +3,19 @@ package cluster
 import (
 	"errors"
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/provider/gcp"
 	"github.com/openshift/osdctl/pkg/utils"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"google.golang.org/api/iterator"
 	"gopkg.in/yaml.v2"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 // healthOptions defines the struct for running health command
@@ -25,6 +26,10 @@ type healthOptions struct {
 	output     string
 	verbose    bool
 	awsProfile string
+
+	// Clients are only created when they aren't injected, e.g. by tests
+	awsClient aws.Client
+	gcpClient gcp.Client
 }
 
 // newCmdHealth implements the health command to describe number of running instances in cluster and the expected number of nodes
@@ -36,8 +41,8 @@ func newCmdHealth() *cobra.Command {
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -98,13 +103,8 @@ func (o *healthOptions) run() error {
 		healthObject.Expected.Worker = int(cluster.Nodes().Compute())
 	}
 
-	runningMasters := 0
-	runningInfra := 0
-	runningWorkers := 0
-	totalStopped := 0
-	totalCluster := 0
-
-	if cluster.CloudProvider().ID() == "gcp" {
+	switch cluster.CloudProvider().ID() {
+	case "gcp":
 		clusterResources, err := ocmClient.ClustersMgmt().V1().Clusters().Cluster(o.clusterID).Resources().Live().Get().Send()
 		if err != nil {
 			return err
@@ -123,118 +123,106 @@ func (o *healthOptions) run() error {
 		if projectId == "" || len(zones) == 0 {
 			return fmt.Errorf("ProjectID or Zones empty - aborting")
 		}
-		gcpClient, err := osdCloud.GenerateGCPComputeInstancesClient()
-		defer gcpClient.Close()
-		if err != nil {
+		if o.gcpClient == nil {
+			o.gcpClient, err = gcp.NewGcpClient()
+			if err != nil {
+				return err
+			}
+			defer o.gcpClient.Close()
+		}
+		if err := countGCPInstances(o.gcpClient, projectId, zones, cluster.InfraID(), healthObject); err != nil {
 			return err
 		}
-		ownedLabel := "kubernetes-io-cluster-" + cluster.InfraID()
-		for _, zone := range zones {
-			instances := osdCloud.ListInstances(gcpClient, projectId, zone)
-			for {
-				instance, err := instances.Next()
-				if err == iterator.Done {
-					break
-				}
-				if err != nil {
-					return err
-				}
-				name := instance.GetName()
-				state := instance.GetStatus()
-				labels := instance.GetLabels()
-				belongsToCluster := false
-				for label := range labels {
-					if label == ownedLabel {
-						belongsToCluster = true
-					}
-				}
-				if !belongsToCluster {
-					log.Printf("Skipping a machine not belonging to the cluster: %s\n", name)
-					continue
-				}
-				totalCluster += 1
-				if state != "RUNNING" {
-					totalStopped += 1
-				} else {
-					if strings.HasPrefix(name, cluster.InfraID()) && strings.Contains(name, "master") {
-						runningMasters += 1
-					} else if strings.HasPrefix(name, cluster.InfraID()) && strings.Contains(name, "infra") {
-						runningInfra += 1
-					} else if strings.HasPrefix(name, cluster.InfraID()) && strings.Contains(name, "worker") {
-						runningWorkers += 1
-					}
-				}
+	case "aws":
+		if o.awsClient == nil {
+			o.awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, o.clusterID)
+			if err != nil {
+				return err
 			}
 		}
-	} else if cluster.CloudProvider().ID() == "aws" {
-		awsClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, o.clusterID)
-		if err != nil {
+		if err := countAWSInstances(o.awsClient, cluster.InfraID(), healthObject); err != nil {
 			return err
 		}
+	default:
+		return errors.New(fmt.Sprintf("Unknown cloud provider found: %s", cluster.CloudProvider().ID()))
+	}
+
+	healthOutput, err := yaml.Marshal(&healthObject)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n \n")
+	fmt.Println(string(healthOutput))
+
+	return nil
+}
 
-		instances, err := awsClient.DescribeInstances(&ec2.DescribeInstancesInput{})
+// countGCPInstances fills in the actual nodes of the health object from the instances labeled as owned by the cluster
+func countGCPInstances(gcpClient gcp.Client, projectID string, zones []string, infraID string, healthObject *ClusterHealthCondensedObject) error {
+	ownedLabel := "kubernetes-io-cluster-" + infraID
+	for _, zone := range zones {
+		instances, err := gcpClient.ListInstances(projectID, zone)
 		if err != nil {
 			return err
 		}
-
-		//Here we count the number of customer's running worker, infra and master instances in the cluster in the given region. To decide if the instance belongs to the cluster we are checking the Name Tag on the instance.
-		for idx := range instances.Reservations {
-			for _, inst := range instances.Reservations[idx].Instances {
-				tags := inst.Tags
-				for _, t := range tags {
-					if *t.Key == "Name" {
-						if strings.HasPrefix(*t.Value, cluster.InfraID()) && strings.Contains(*t.Value, "master") {
-							totalCluster += 1
-							if *inst.State.Name == "running" {
-								runningMasters += 1
-							}
-							if *inst.State.Name == "stopped" {
-								totalStopped += 1
-							}
-
-						} else if strings.HasPrefix(*t.Value, cluster.InfraID()) && strings.Contains(*t.Value, "infra") {
-							totalCluster += 1
-							if *inst.State.Name == "running" {
-								runningInfra += 1
-							}
-							if *inst.State.Name == "stopped" {
-								totalStopped += 1
-							}
-						} else if strings.HasPrefix(*t.Value, cluster.InfraID()) && strings.Contains(*t.Value, "worker") {
-							totalCluster += 1
-							if *inst.State.Name == "running" {
-								runningWorkers += 1
-							}
-							if *inst.State.Name == "stopped" {
-								totalStopped += 1
-							}
-						}
-					}
-				}
+		for _, instance := range instances {
+			name := instance.GetName()
+			if _, belongsToCluster := instance.GetLabels()[ownedLabel]; !belongsToCluster {
+				log.Printf("Skipping a machine not belonging to the cluster: %s\n", name)
+				continue
+			}
+			healthObject.Actual.Total += 1
+			if instance.GetStatus() != "RUNNING" {
+				healthObject.Actual.Stopped += 1
+				continue
+			}
+			if strings.HasPrefix(name, infraID) && strings.Contains(name, "master") {
+				healthObject.Actual.RunningMasters += 1
+			} else if strings.HasPrefix(name, infraID) && strings.Contains(name, "infra") {
+				healthObject.Actual.RunningInfra += 1
+			} else if strings.HasPrefix(name, infraID) && strings.Contains(name, "worker") {
+				healthObject.Actual.RunningWorker += 1
 			}
 		}
-	} else {
-		return errors.New(fmt.Sprintf("Unknown cloud provider found: %s", cluster.CloudProvider().ID()))
 	}
+	return nil
+}
 
-	healthObject.Actual.Stopped = totalStopped
-	healthObject.Actual.RunningMasters = runningMasters
-	healthObject.Actual.RunningInfra = runningInfra
-	healthObject.Actual.RunningWorker = runningWorkers
-	healthObject.Actual.Total = totalCluster
-
+// countAWSInstances fills in the actual nodes of the health object. To decide if the instance belongs to the cluster
+// we are checking the Name Tag on the instance.
+func countAWSInstances(awsClient aws.Client, infraID string, healthObject *ClusterHealthCondensedObject) error {
+	instances, err := awsClient.DescribeInstances(&ec2.DescribeInstancesInput{})
 	if err != nil {
-		log.Fatalf("Error getting instances %v", err)
 		return err
 	}
 
-	healthOutput, err := yaml.Marshal(&healthObject)
-	if err != nil {
-		log.Fatalf("error: %v", err)
+	for idx := range instances.Reservations {
+		for _, inst := range instances.Reservations[idx].Instances {
+			for _, t := range inst.Tags {
+				if *t.Key != "Name" || !strings.HasPrefix(*t.Value, infraID) {
+					continue
+				}
+				var running *int
+				switch {
+				case strings.Contains(*t.Value, "master"):
+					running = &healthObject.Actual.RunningMasters
+				case strings.Contains(*t.Value, "infra"):
+					running = &healthObject.Actual.RunningInfra
+				case strings.Contains(*t.Value, "worker"):
+					running = &healthObject.Actual.RunningWorker
+				default:
+					continue
+				}
+				healthObject.Actual.Total += 1
+				if *inst.State.Name == "running" {
+					*running += 1
+				}
+				if *inst.State.Name == "stopped" {
+					healthObject.Actual.Stopped += 1
+				}
+			}
+		}
 	}
-	fmt.Printf("\n \n")
-	fmt.Println(string(healthOutput))
-
 	return nil
 }
 
@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+	gcpmock "github.com/openshift/osdctl/pkg/provider/gcp/mock"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+)
+
+func awsInstance(name, state string) *ec2.Instance {
+	return &ec2.Instance{
+		Tags:  []*ec2.Tag{{Key: awsSdk.String("Name"), Value: awsSdk.String(name)}},
+		State: &ec2.InstanceState{Name: awsSdk.String(state)},
+	}
+}
+
+func gcpInstance(name, status string, labels map[string]string) *computepb.Instance {
+	return &computepb.Instance{Name: &name, Status: &status, Labels: labels}
+}
+
+func TestCountAWSInstances(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := awsmock.NewMockClient(mockCtrl)
+
+	mockAWSClient.EXPECT().DescribeInstances(gomock.Any()).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{
+			awsInstance("foo-abcde-master-0", "running"),
+			awsInstance("foo-abcde-infra-a-1", "running"),
+			awsInstance("foo-abcde-worker-a-1", "running"),
+			awsInstance("foo-abcde-worker-a-2", "stopped"),
+			awsInstance("bar-fghij-worker-a-1", "running"),
+		}}},
+	}, nil)
+
+	healthObject := &ClusterHealthCondensedObject{}
+	g.Expect(countAWSInstances(mockAWSClient, "foo-abcde", healthObject)).To(Succeed())
+	g.Expect(healthObject.Actual.Total).To(Equal(4))
+	g.Expect(healthObject.Actual.Stopped).To(Equal(1))
+	g.Expect(healthObject.Actual.RunningMasters).To(Equal(1))
+	g.Expect(healthObject.Actual.RunningInfra).To(Equal(1))
+	g.Expect(healthObject.Actual.RunningWorker).To(Equal(1))
+}
+
+func TestCountGCPInstances(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockGCPClient := gcpmock.NewMockClient(mockCtrl)
+	owned := map[string]string{"kubernetes-io-cluster-foo-abcde": "owned"}
+
+	mockGCPClient.EXPECT().ListInstances("project", "us-east1-b").Return([]*computepb.Instance{
+		gcpInstance("foo-abcde-master-0", "RUNNING", owned),
+		gcpInstance("foo-abcde-worker-b-1", "TERMINATED", owned),
+		gcpInstance("unrelated", "RUNNING", nil),
+	}, nil)
+	mockGCPClient.EXPECT().ListInstances("project", "us-east1-c").Return([]*computepb.Instance{
+		gcpInstance("foo-abcde-worker-c-1", "RUNNING", owned),
+	}, nil)
+
+	healthObject := &ClusterHealthCondensedObject{}
+	g.Expect(countGCPInstances(mockGCPClient, "project", []string{"us-east1-b", "us-east1-c"}, "foo-abcde", healthObject)).To(Succeed())
+	g.Expect(healthObject.Actual.Total).To(Equal(3))
+	g.Expect(healthObject.Actual.Stopped).To(Equal(1))
+	g.Expect(healthObject.Actual.RunningMasters).To(Equal(1))
+	g.Expect(healthObject.Actual.RunningWorker).To(Equal(1))
+}
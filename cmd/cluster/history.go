@@ -0,0 +1,259 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	clusterHistoryLongDescription = `
+Lists the history OCM keeps for a cluster, what the console shows in its Cluster history tab: the service logs sent
+to the customer, the internal ones, and the events OCM and its services recorded, with who or what created them.
+
+Unlike 'osdctl history' and the local audit log, which only know what was run from this machine, the history is
+the one recorded by OCM for everyone. The entries are filtered on the server and listed most recent first. The
+history of a deleted cluster is kept, the cluster is found from its subscription.
+`
+	clusterHistoryExample = `
+  # What happened on the cluster over the last two weeks
+  osdctl cluster history 1kfmyclusteristhebesteverp8m --since 14d
+
+  # The entries of a user, as JSON
+  osdctl cluster history 1kfmyclusteristhebesteverp8m --actor jdoe -o json
+
+  # The errors sent by SREs
+  osdctl cluster history 1kfmyclusteristhebesteverp8m --severity Error --service SREManualAction
+`
+
+	clusterHistoryPageSize = 100
+)
+
+var clusterHistorySeverities = []string{"Debug", "Info", "Warning", "Error", "Fatal"}
+
+type clusterHistoryOptions struct {
+	clusterID string
+	since     time.Duration
+	actor     string
+	severity  string
+	service   string
+	search    string
+	limit     int
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// clusterHistoryEntry is a service log of the cluster, as the console shows it in the cluster history
+type clusterHistoryEntry struct {
+	ID            string    `json:"id" yaml:"id"`
+	Timestamp     time.Time `json:"timestamp" yaml:"timestamp"`
+	Severity      string    `json:"severity" yaml:"severity"`
+	ServiceName   string    `json:"service_name" yaml:"service_name"`
+	Actor         string    `json:"actor" yaml:"actor"`
+	InternalOnly  bool      `json:"internal_only" yaml:"internal_only"`
+	Summary       string    `json:"summary" yaml:"summary"`
+	Description   string    `json:"description" yaml:"description"`
+	EventStreamID string    `json:"event_stream_id,omitempty" yaml:"event_stream_id,omitempty"`
+}
+
+type clusterHistoryResponse struct {
+	ClusterID string                `json:"cluster_id" yaml:"cluster_id"`
+	Since     *time.Time            `json:"since,omitempty" yaml:"since,omitempty"`
+	Truncated bool                  `json:"truncated" yaml:"truncated"`
+	Entries   []clusterHistoryEntry `json:"entries" yaml:"entries"`
+}
+
+func (r clusterHistoryResponse) String() string {
+	var b bytes.Buffer
+	if len(r.Entries) == 0 {
+		fmt.Fprintf(&b, "No history entry for cluster %s matches.\n", r.ClusterID)
+		return b.String()
+	}
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Time", "Severity", "Service", "Actor", "Summary"})
+	for _, entry := range r.Entries {
+		severity := entry.Severity
+		if entry.InternalOnly {
+			severity += " (internal)"
+		}
+		table.AddRow([]string{timefmt.Format(entry.Timestamp), severity, entry.ServiceName, entry.Actor, entry.Summary})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the history: %v", err)
+	}
+	if r.Truncated {
+		fmt.Fprintf(&b, "Only the %d most recent entries are listed, see --limit.\n", len(r.Entries))
+	}
+	return b.String()
+}
+
+func newCmdClusterHistory(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &clusterHistoryOptions{GlobalOptions: globalOpts}
+	historyCmd := &cobra.Command{
+		Use:               "history CLUSTER_ID",
+		Short:             "Lists the history OCM recorded for a cluster, with filters",
+		Long:              clusterHistoryLongDescription,
+		Example:           clusterHistoryExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	flagtypes.DurationVar(historyCmd.Flags(), &ops.since, "since", 0, time.Minute, "Only list the entries more recent than this, e.g. 14d or 12h")
+	historyCmd.Flags().StringVar(&ops.actor, "actor", "", "Only list the entries created by a user or service account, matching part of its name")
+	historyCmd.Flags().StringVar(&ops.severity, "severity", "", "Only list the entries of this severity, one of "+strings.Join(clusterHistorySeverities, ", "))
+	historyCmd.Flags().StringVar(&ops.service, "service", "", "Only list the entries of this service, e.g. SREManualAction")
+	historyCmd.Flags().StringVar(&ops.search, "search", "", "Only list the entries whose summary or description contains this text")
+	historyCmd.Flags().IntVar(&ops.limit, "limit", 500, "Number of entries to list at most, 0 lists all of them")
+
+	return historyCmd
+}
+
+func (o *clusterHistoryOptions) run() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	filter, err := o.historyFilter(time.Now())
+	if err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	clusterID, externalID, err := historyClusterIDs(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	filter.clusterID, filter.externalID = clusterID, externalID
+
+	response := clusterHistoryResponse{ClusterID: clusterID, Since: filter.since, Entries: []clusterHistoryEntry{}}
+	request := connection.ServiceLogs().V1().ClusterLogs().List().Search(filter.search()).Order("timestamp desc").Size(clusterHistoryPageSize)
+	for page := 1; ; page++ {
+		result, err := request.Page(page).Send()
+		if err != nil {
+			return fmt.Errorf("cannot list the history of cluster %s: %w", clusterID, err)
+		}
+		for _, entry := range result.Items().Slice() {
+			if o.limit > 0 && len(response.Entries) == o.limit {
+				response.Truncated = true
+				break
+			}
+			response.Entries = append(response.Entries, historyEntry(entry))
+		}
+		if response.Truncated || result.Size() < clusterHistoryPageSize {
+			break
+		}
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// historyClusterIDs returns the IDs the service logs of the cluster have, from its subscription when it was deleted
+func historyClusterIDs(connection *sdk.Connection, key string) (string, string, error) {
+	cluster, err := utils.GetCluster(connection, key)
+	if err == nil {
+		return cluster.ID(), cluster.ExternalID(), nil
+	}
+	subscription, subscriptionErr := utils.GetSubscription(connection, key)
+	if subscriptionErr != nil {
+		return "", "", osdctlErrors.New(osdctlErrors.ErrNotFound, "%v", err)
+	}
+	return subscription.ClusterID(), subscription.ExternalClusterID(), nil
+}
+
+// clusterHistoryFilter is the search of the history entries, run by the service logs API
+type clusterHistoryFilter struct {
+	clusterID  string
+	externalID string
+	since      *time.Time
+	actor      string
+	severity   string
+	service    string
+	text       string
+}
+
+func (o *clusterHistoryOptions) historyFilter(now time.Time) (*clusterHistoryFilter, error) {
+	filter := &clusterHistoryFilter{actor: o.actor, service: o.service, text: o.search}
+	if o.since > 0 {
+		since := now.Add(-o.since).UTC()
+		filter.since = &since
+	}
+	if o.severity != "" {
+		for _, severity := range clusterHistorySeverities {
+			if strings.EqualFold(severity, o.severity) {
+				filter.severity = severity
+			}
+		}
+		if filter.severity == "" {
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "invalid --severity '%s', expected one of %s", o.severity, strings.Join(clusterHistorySeverities, ", "))
+		}
+	}
+	if o.limit < 0 {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "--limit can't be negative")
+	}
+	return filter, nil
+}
+
+// search returns the search of the service logs API. Like 'osdctl servicelog list', it prefers the external ID of
+// the cluster, which the service logs posted before the cluster got its OCM ID have.
+func (f *clusterHistoryFilter) search() string {
+	quote := func(value string) string {
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	like := func(value string) string {
+		return quote("%" + value + "%")
+	}
+
+	var conditions []string
+	if f.externalID != "" {
+		conditions = append(conditions, "cluster_uuid = "+quote(f.externalID))
+	} else {
+		conditions = append(conditions, "cluster_id = "+quote(f.clusterID))
+	}
+	if f.since != nil {
+		conditions = append(conditions, "timestamp >= "+quote(f.since.Format(time.RFC3339)))
+	}
+	if f.actor != "" {
+		conditions = append(conditions, "username like "+like(f.actor))
+	}
+	if f.severity != "" {
+		conditions = append(conditions, "severity = "+quote(f.severity))
+	}
+	if f.service != "" {
+		conditions = append(conditions, "service_name = "+quote(f.service))
+	}
+	if f.text != "" {
+		conditions = append(conditions, fmt.Sprintf("(summary like %s or description like %s)", like(f.text), like(f.text)))
+	}
+	return strings.Join(conditions, " and ")
+}
+
+func historyEntry(entry *slv1.LogEntry) clusterHistoryEntry {
+	return clusterHistoryEntry{
+		ID:            entry.ID(),
+		Timestamp:     entry.Timestamp(),
+		Severity:      string(entry.Severity()),
+		ServiceName:   entry.ServiceName(),
+		Actor:         entry.Username(),
+		InternalOnly:  entry.InternalOnly(),
+		Summary:       entry.Summary(),
+		Description:   entry.Description(),
+		EventStreamID: entry.EventStreamID(),
+	}
+}
@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/spf13/viper"
+)
+
+func TestClusterHistoryFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	filter, err := (&clusterHistoryOptions{}).historyFilter(now)
+	g.Expect(err).NotTo(HaveOccurred())
+	filter.clusterID = "abc123"
+	g.Expect(filter.search()).To(Equal("cluster_id = 'abc123'"))
+
+	filter, err = (&clusterHistoryOptions{since: 14 * 24 * time.Hour, actor: "o'brien", severity: "error", service: "SREManualAction", search: "upgrade"}).historyFilter(now)
+	g.Expect(err).NotTo(HaveOccurred())
+	filter.clusterID, filter.externalID = "abc123", "5a9b7a8e-0f6a-4d3b-9c1e-0123456789ab"
+	g.Expect(filter.search()).To(Equal("cluster_uuid = '5a9b7a8e-0f6a-4d3b-9c1e-0123456789ab'" +
+		" and timestamp >= '2026-09-30T12:00:00Z'" +
+		" and username like '%o''brien%'" +
+		" and severity = 'Error'" +
+		" and service_name = 'SREManualAction'" +
+		" and (summary like '%upgrade%' or description like '%upgrade%')"))
+
+	_, err = (&clusterHistoryOptions{severity: "Critical"}).historyFilter(now)
+	g.Expect(err).To(HaveOccurred())
+	_, err = (&clusterHistoryOptions{limit: -1}).historyFilter(now)
+	g.Expect(err).To(HaveOccurred())
+}
+
+// useUTC prints the timestamps in UTC, whatever the timezone of the machine running the tests
+func useUTC(t *testing.T) {
+	viper.Set(timefmt.ConfigKey, true)
+	t.Cleanup(func() { viper.Set(timefmt.ConfigKey, false) })
+}
+
+func TestClusterHistoryResponseString(t *testing.T) {
+	g := NewGomegaWithT(t)
+	useUTC(t)
+
+	g.Expect(clusterHistoryResponse{ClusterID: "abc123"}.String()).To(Equal("No history entry for cluster abc123 matches.\n"))
+
+	response := clusterHistoryResponse{
+		ClusterID: "abc123",
+		Truncated: true,
+		Entries: []clusterHistoryEntry{
+			{Timestamp: time.Date(2026, 10, 13, 8, 0, 0, 0, time.UTC), Severity: "Info", ServiceName: "SREManualAction", Actor: "jdoe", InternalOnly: true, Summary: "Investigating alert"},
+			{Timestamp: time.Date(2026, 10, 12, 8, 0, 0, 0, time.UTC), Severity: "Warning", ServiceName: "ClusterUpgrade", Actor: "service-account-ocm", Summary: "Upgrade scheduled"},
+		},
+	}
+	output := response.String()
+	g.Expect(output).To(ContainSubstring("2026-10-13 08:00:00 UTC"))
+	g.Expect(output).To(ContainSubstring("Info (internal)"))
+	g.Expect(output).To(ContainSubstring("Upgrade scheduled"))
+	g.Expect(output).To(ContainSubstring("Only the 2 most recent entries are listed"))
+}
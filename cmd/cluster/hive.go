@@ -0,0 +1,172 @@
+package cluster
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const hiveExample = `
+  # Show the hive shard hosting a cluster
+  osdctl cluster hive 1kfmyclusteristhebesteverp8m
+
+  # Print the kubeconfig context name 'oc login' creates for the hive shard
+  osdctl cluster hive 1kfmyclusteristhebesteverp8m --context
+`
+
+type hiveOptions struct {
+	clusterID string
+	context   bool
+	user      string
+	namespace string
+
+	genericclioptions.IOStreams
+}
+
+// hiveShard describes the hive cluster a cluster was provisioned from
+type hiveShard struct {
+	ID         string
+	Status     string
+	Region     string
+	APIURL     string
+	ConsoleURL string
+}
+
+func newCmdHive(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &hiveOptions{IOStreams: streams}
+	hiveCmd := &cobra.Command{
+		Use:               "hive CLUSTER_ID",
+		Short:             "Shows the hive shard a cluster was provisioned from",
+		Example:           hiveExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	hiveCmd.Flags().BoolVar(&ops.context, "context", false, "Only print the kubeconfig context name 'oc login' creates for the hive shard")
+	hiveCmd.Flags().StringVar(&ops.user, "user", "", "User in the kubeconfig context name, defaults to the current OCM account username")
+	hiveCmd.Flags().StringVar(&ops.namespace, "namespace", "default", "Namespace in the kubeconfig context name")
+
+	return hiveCmd
+}
+
+func (o *hiveOptions) run() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot close the connection: %q\n", err)
+		}
+	}()
+
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	shard, err := getHiveShard(connection, cluster.ID())
+	if err != nil {
+		return err
+	}
+
+	user := o.user
+	if user == "" {
+		response, err := connection.AccountsMgmt().V1().CurrentAccount().Get().Send()
+		if err != nil {
+			return fmt.Errorf("can't retrieve the current OCM account: %w", err)
+		}
+		user = response.Body().Username()
+	}
+	contextName, err := hiveContextName(shard.APIURL, o.namespace, user)
+	if err != nil {
+		return err
+	}
+
+	if o.context {
+		fmt.Println(contextName)
+		return nil
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Cluster:", cluster.Name() + " (" + cluster.ID() + ")"})
+	table.AddRow([]string{"Shard ID:", shard.ID})
+	table.AddRow([]string{"Status:", shard.Status})
+	table.AddRow([]string{"Region:", shard.Region})
+	table.AddRow([]string{"API URL:", shard.APIURL})
+	table.AddRow([]string{"Console:", shard.ConsoleURL})
+	table.AddRow([]string{"Context:", contextName})
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Log in with: oc login %s\n", shard.APIURL)
+	return nil
+}
+
+// getHiveShard returns the provision shard of the cluster with the given internal ID, from the cluster cache with
+// --cached
+func getHiveShard(connection *sdk.Connection, clusterID string) (*hiveShard, error) {
+	shard, err := utils.GetProvisionShard(connection, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := shard.HiveConfig().Server()
+	if apiURL == "" {
+		return nil, fmt.Errorf("provision shard %s of cluster %s has no hive API URL", shard.ID(), clusterID)
+	}
+
+	return &hiveShard{
+		ID:         shard.ID(),
+		Status:     shard.Status(),
+		Region:     shard.Region().ID(),
+		APIURL:     apiURL,
+		ConsoleURL: hiveConsoleURL(apiURL),
+	}, nil
+}
+
+// hiveConsoleURL converts a hive API URL in the form of
+// https://api.<hive_cluster>.p1.openshiftapps.com:6443
+// to the console URL in the form of
+// https://console-openshift-console.apps.<hive_cluster>.p1.openshiftapps.com
+func hiveConsoleURL(apiURL string) string {
+	host := strings.TrimPrefix(apiURL, "https://")
+	host = strings.TrimPrefix(host, "api.")
+	host = strings.SplitN(host, ":", 2)[0]
+	host = strings.TrimSuffix(host, "/")
+	return "https://console-openshift-console.apps." + host
+}
+
+// hiveContextName returns the kubeconfig context name 'oc login' creates for the API URL,
+// e.g. default/api-hive-01-abcd-p1-openshiftapps-com:6443/jdoe
+func hiveContextName(apiURL, namespace, user string) (string, error) {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse hive API URL '%s': %w", apiURL, err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("hive API URL '%s' has no host", apiURL)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	server := strings.ReplaceAll(u.Hostname(), ".", "-") + ":" + port
+	return namespace + "/" + server + "/" + user, nil
+}
@@ -0,0 +1,30 @@
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestHiveConsoleURL(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(hiveConsoleURL("https://api.hive-01.abcd.p1.openshiftapps.com:6443")).
+		To(Equal("https://console-openshift-console.apps.hive-01.abcd.p1.openshiftapps.com"))
+	g.Expect(hiveConsoleURL("https://api.hive-01.abcd.p1.openshiftapps.com/")).
+		To(Equal("https://console-openshift-console.apps.hive-01.abcd.p1.openshiftapps.com"))
+}
+
+func TestHiveContextName(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	name, err := hiveContextName("https://api.hive-01.abcd.p1.openshiftapps.com:6443", "default", "jdoe")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(name).To(Equal("default/api-hive-01-abcd-p1-openshiftapps-com:6443/jdoe"))
+
+	name, err = hiveContextName("https://api.hive-01.abcd.p1.openshiftapps.com", "uhc-production", "jdoe")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(name).To(Equal("uhc-production/api-hive-01-abcd-p1-openshiftapps-com:443/jdoe"))
+
+	_, err = hiveContextName("not a url", "default", "jdoe")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,378 @@
+package cluster
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/guardrails"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const idpExample = `
+  # List the identity providers, with the expiry of the break-glass ones
+  osdctl cluster idp list 1kfmyclusteristhebesteverp8m
+
+  # Add a break-glass htpasswd user in the cluster-admins group for 4 hours
+  osdctl cluster idp add-htpasswd 1kfmyclusteristhebesteverp8m --ttl 4h --cluster-admin --reason "console down, OHSS-1234"
+
+  # Remove it once done
+  osdctl cluster idp delete 1kfmyclusteristhebesteverp8m breakglass
+`
+
+const (
+	defaultBreakGlassIDP  = "breakglass"
+	defaultBreakGlassUser = "breakglass"
+	clusterAdminsGroup    = "cluster-admins"
+
+	// OCM requires htpasswd passwords of at least 14 characters with upper and lower case letters, and digits or symbols
+	breakGlassPasswordLength = 24
+	passwordAlphabet         = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+type idpOptions struct {
+	clusterID    string
+	name         string
+	username     string
+	ttl          time.Duration
+	clusterAdmin bool
+	yes          bool
+	output       string
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// identityProvider is an identity provider of a cluster, as printed with -o json
+type identityProvider struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	MappingMethod string     `json:"mappingMethod"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+}
+
+func newCmdIDP(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &idpOptions{GlobalOptions: globalOpts}
+	idpCmd := &cobra.Command{
+		Use:               "idp",
+		Short:             "Manages the identity providers of a cluster",
+		Example:           idpExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run:               help,
+	}
+
+	listCmd := &cobra.Command{
+		Use:               "list CLUSTER_ID",
+		Short:             "Lists the identity providers of a cluster",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			ops.output = ops.GlobalOptions.Output
+			osdctlErrors.CheckErr(ops.list())
+		},
+	}
+
+	addHtpasswdCmd := &cobra.Command{
+		Use:   "add-htpasswd CLUSTER_ID",
+		Short: "Adds a temporary break-glass htpasswd user to a cluster",
+		Long: `Adds an htpasswd identity provider with a single user and a generated password, printed once.
+
+The expiry is recorded in the audit log together with --reason and --ticket, and shown by 'osdctl cluster idp list'.
+OCM doesn't expire the user, delete the identity provider with 'osdctl cluster idp delete' once done.`,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.addHtpasswd(cmd))
+		},
+	}
+	addHtpasswdCmd.Flags().StringVar(&ops.name, "name", defaultBreakGlassIDP, "Name of the identity provider")
+	addHtpasswdCmd.Flags().StringVar(&ops.username, "username", defaultBreakGlassUser, "Name of the user")
+	addHtpasswdCmd.Flags().DurationVar(&ops.ttl, "ttl", 4*time.Hour, "How long the user is needed, recorded in the audit log")
+	addHtpasswdCmd.Flags().BoolVar(&ops.clusterAdmin, "cluster-admin", false, "Add the user to the "+clusterAdminsGroup+" group")
+	addHtpasswdCmd.Flags().BoolVarP(&ops.yes, "yes", "y", false, "Skip the confirmation prompt")
+
+	deleteCmd := &cobra.Command{
+		Use:               "delete CLUSTER_ID IDP_NAME",
+		Short:             "Deletes an identity provider from a cluster",
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			ops.name = args[1]
+			osdctlErrors.CheckErr(ops.delete(cmd))
+		},
+	}
+	deleteCmd.Flags().BoolVarP(&ops.yes, "yes", "y", false, "Skip the confirmation prompt")
+
+	idpCmd.AddCommand(listCmd, addHtpasswdCmd, deleteCmd)
+	return idpCmd
+}
+
+func (o *idpOptions) connect() (*sdk.Connection, *cmv1.Cluster, error) {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return nil, nil, err
+	}
+	connection := utils.CreateConnection()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+	return connection, cluster, nil
+}
+
+func (o *idpOptions) list() error {
+	connection, cluster, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	idps, err := listIdentityProviders(connection, cluster)
+	if err != nil {
+		return err
+	}
+	records, err := guardrails.ReadAuditRecords()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: can't read the break-glass expiry from the audit log: %v\n", err)
+	}
+	setBreakGlassExpiry(idps, records, cluster.ID())
+
+	if o.output == "json" {
+		if idps == nil {
+			idps = []identityProvider{}
+		}
+		data, err := json.MarshalIndent(idps, "", "    ")
+		if err != nil {
+			return err
+		}
+		return printer.PrintJSON(printer.Tee(os.Stdout), data)
+	}
+
+	now := time.Now()
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Name", "Type", "Mapping", "ID", "Expires"})
+	for _, idp := range idps {
+		table.AddRow([]string{idp.Name, idp.Type, idp.MappingMethod, idp.ID, describeBreakGlassExpiry(idp.ExpiresAt, now)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	return table.Flush()
+}
+
+func (o *idpOptions) addHtpasswd(cmd *cobra.Command) error {
+	if o.ttl <= 0 {
+		return cmdutil.UsageErrorf(cmd, "--ttl must be positive")
+	}
+	connection, cluster, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	idps, err := listIdentityProviders(connection, cluster)
+	if err != nil {
+		return err
+	}
+	if findIdentityProvider(idps, o.name) != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s already has an identity provider named '%s'", cluster.ID(), o.name)
+	}
+
+	action := fmt.Sprintf("Add htpasswd identity provider '%s' with user '%s' for %s", o.name, o.username, o.ttl)
+	if o.clusterAdmin {
+		action += ", in the " + clusterAdminsGroup + " group"
+	}
+	if err := utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, action),
+		SkipPrompt: o.yes,
+	}); err != nil {
+		return err
+	}
+
+	password, err := generatePassword(breakGlassPasswordLength)
+	if err != nil {
+		return err
+	}
+	idp, err := cmv1.NewIdentityProvider().
+		Name(o.name).
+		Type(cmv1.IdentityProviderTypeHtpasswd).
+		MappingMethod(cmv1.IdentityProviderMappingMethodClaim).
+		Htpasswd(cmv1.NewHTPasswdIdentityProvider().Users(cmv1.NewHTPasswdUserList().Items(
+			cmv1.NewHTPasswdUser().Username(o.username).Password(password),
+		))).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	resource := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID())
+	if _, err := resource.IdentityProviders().Add().Body(idp).Send(); err != nil {
+		return fmt.Errorf("failed to add the identity provider '%s': %w", o.name, err)
+	}
+	if o.clusterAdmin {
+		user, err := cmv1.NewUser().ID(o.username).Build()
+		if err != nil {
+			return err
+		}
+		if _, err := resource.Groups().Group(clusterAdminsGroup).Users().Add().Body(user).Send(); err != nil {
+			return fmt.Errorf("the identity provider was added, but not the user to the %s group: %w", clusterAdminsGroup, err)
+		}
+	}
+
+	expiresAt := time.Now().UTC().Add(o.ttl)
+	if err := guardrails.WriteAuditRecord(guardrails.AuditRecord{
+		Command:     cmd.CommandPath(),
+		Args:        []string{o.clusterID, o.name, o.username},
+		Environment: utils.GetCurrentOCMEnv(connection),
+		Reason:      flagString(cmd, guardrails.ReasonFlag),
+		Ticket:      flagString(cmd, guardrails.TicketFlag),
+		Cluster:     cluster.ID(),
+		ExpiresAt:   &expiresAt,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to record the break-glass user in the audit log: %v\n", err)
+	}
+	utils.RecordClusterAction(connection, cluster, action)
+
+	fmt.Fprintf(os.Stderr, "Identity provider '%s' added, delete it after %s with 'osdctl cluster idp delete %s %s'\n",
+		o.name, timefmt.Format(expiresAt), cluster.ID(), o.name)
+	fmt.Printf("username: %s\npassword: %s\n", o.username, password)
+	return nil
+}
+
+func (o *idpOptions) delete(cmd *cobra.Command) error {
+	connection, cluster, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	idps, err := listIdentityProviders(connection, cluster)
+	if err != nil {
+		return err
+	}
+	idp := findIdentityProvider(idps, o.name)
+	if idp == nil {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "cluster %s has no identity provider '%s'", cluster.ID(), o.name)
+	}
+
+	if err := utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("Delete %s identity provider '%s', its users can't log in anymore", idp.Type, idp.Name)),
+		SkipPrompt: o.yes,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).IdentityProviders().IdentityProvider(idp.ID).Delete().Send(); err != nil {
+		return fmt.Errorf("failed to delete the identity provider '%s': %w", idp.Name, err)
+	}
+	if err := guardrails.WriteAuditRecord(guardrails.AuditRecord{
+		Command:     cmd.CommandPath(),
+		Args:        []string{o.clusterID, idp.Name},
+		Environment: utils.GetCurrentOCMEnv(connection),
+		Reason:      flagString(cmd, guardrails.ReasonFlag),
+		Ticket:      flagString(cmd, guardrails.TicketFlag),
+		Cluster:     cluster.ID(),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to record the deletion in the audit log: %v\n", err)
+	}
+	utils.RecordClusterAction(connection, cluster, fmt.Sprintf("Delete %s identity provider '%s'", idp.Type, idp.Name))
+
+	fmt.Fprintf(os.Stderr, "Identity provider '%s' deleted\n", idp.Name)
+	return nil
+}
+
+func flagString(cmd *cobra.Command, name string) string {
+	if f := cmd.Flag(name); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
+
+func listIdentityProviders(connection *sdk.Connection, cluster *cmv1.Cluster) ([]identityProvider, error) {
+	response, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).IdentityProviders().List().Size(exportPageSize).Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve the identity providers of cluster %s: %w", cluster.ID(), err)
+	}
+	var idps []identityProvider
+	response.Items().Each(func(idp *cmv1.IdentityProvider) bool {
+		idps = append(idps, identityProvider{
+			ID:            idp.ID(),
+			Name:          idp.Name(),
+			Type:          string(idp.Type()),
+			MappingMethod: string(idp.MappingMethod()),
+		})
+		return true
+	})
+	return idps, nil
+}
+
+// findIdentityProvider finds an identity provider by name or ID
+func findIdentityProvider(idps []identityProvider, nameOrID string) *identityProvider {
+	for i := range idps {
+		if idps[i].Name == nameOrID || idps[i].ID == nameOrID {
+			return &idps[i]
+		}
+	}
+	return nil
+}
+
+// setBreakGlassExpiry sets the expiry of the identity providers added with 'idp add-htpasswd', from the latest
+// audit record for their name on the cluster
+func setBreakGlassExpiry(idps []identityProvider, records []guardrails.AuditRecord, clusterID string) {
+	for _, record := range records {
+		if record.Cluster != clusterID || record.ExpiresAt == nil || !strings.HasSuffix(record.Command, " idp add-htpasswd") || len(record.Args) < 2 {
+			continue
+		}
+		for i := range idps {
+			if idps[i].Name == record.Args[1] {
+				idps[i].ExpiresAt = record.ExpiresAt
+			}
+		}
+	}
+}
+
+func describeBreakGlassExpiry(expiresAt *time.Time, now time.Time) string {
+	if expiresAt == nil {
+		return "-"
+	}
+	if !expiresAt.After(now) {
+		return timefmt.Format(*expiresAt) + " (expired, delete it)"
+	}
+	return timefmt.Format(*expiresAt)
+}
+
+// generatePassword returns a random password with upper and lower case letters and digits, without the characters
+// that are easily confused
+func generatePassword(length int) (string, error) {
+	size := big.NewInt(int64(len(passwordAlphabet)))
+	for {
+		var b strings.Builder
+		for i := 0; i < length; i++ {
+			n, err := rand.Int(rand.Reader, size)
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte(passwordAlphabet[n.Int64()])
+		}
+		password := b.String()
+		if strings.ContainsAny(password, "abcdefghijkmnopqrstuvwxyz") && strings.ContainsAny(password, "ABCDEFGHJKLMNPQRSTUVWXYZ") &&
+			strings.ContainsAny(password, "23456789") {
+			return password, nil
+		}
+	}
+}
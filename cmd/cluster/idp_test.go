@@ -0,0 +1,58 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/guardrails"
+)
+
+func TestSetBreakGlassExpiry(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	first := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+	idps := []identityProvider{{ID: "1", Name: "breakglass"}, {ID: "2", Name: "customer-github"}}
+	setBreakGlassExpiry(idps, []guardrails.AuditRecord{
+		{Command: "osdctl cluster idp add-htpasswd", Args: []string{"my-cluster", "breakglass", "admin"}, Cluster: "abc", ExpiresAt: &first},
+		{Command: "osdctl cluster kubeconfig", Args: []string{"my-cluster"}, Cluster: "abc", ExpiresAt: &first},
+		{Command: "osdctl cluster idp add-htpasswd", Args: []string{"other", "breakglass", "admin"}, Cluster: "def", ExpiresAt: &first},
+		{Command: "osdctl cluster idp add-htpasswd", Args: []string{"my-cluster", "breakglass", "admin"}, Cluster: "abc", ExpiresAt: &second},
+	}, "abc")
+
+	g.Expect(idps[0].ExpiresAt).To(Equal(&second))
+	g.Expect(idps[1].ExpiresAt).To(BeNil())
+}
+
+func TestDescribeBreakGlassExpiry(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	useUTC(t)
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	g.Expect(describeBreakGlassExpiry(nil, now)).To(Equal("-"))
+	g.Expect(describeBreakGlassExpiry(&past, now)).To(HaveSuffix("(expired, delete it)"))
+	g.Expect(describeBreakGlassExpiry(&future, now)).To(Equal("2023-05-01 13:00:00 UTC"))
+}
+
+func TestGeneratePassword(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	password, err := generatePassword(breakGlassPasswordLength)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(password).To(HaveLen(breakGlassPasswordLength))
+	g.Expect(strings.ContainsAny(password, "ABCDEFGHJKLMNPQRSTUVWXYZ")).To(BeTrue())
+	g.Expect(strings.ContainsAny(password, "abcdefghijkmnopqrstuvwxyz")).To(BeTrue())
+	g.Expect(strings.ContainsAny(password, "23456789")).To(BeTrue())
+}
+
+func TestFindIdentityProvider(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	idps := []identityProvider{{ID: "1a2b", Name: "breakglass"}}
+	g.Expect(findIdentityProvider(idps, "breakglass")).To(Equal(&idps[0]))
+	g.Expect(findIdentityProvider(idps, "1a2b")).To(Equal(&idps[0]))
+	g.Expect(findIdentityProvider(idps, "github")).To(BeNil())
+}
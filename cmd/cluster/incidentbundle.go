@@ -0,0 +1,572 @@
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	pd "github.com/PagerDuty/go-pagerduty"
+	jira "github.com/andygrunwald/go-jira"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/cmd/servicelog"
+	sl "github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/pkg/artifacts"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	incidentBundleLongDescription = `
+Collects the context of a cluster into a timestamped bundle to attach to an incident ticket:
+
+  incident-<cluster-id>-<timestamp>.md       the summary of every section, readable in the ticket
+  incident-<cluster-id>-<timestamp>.tar.gz   the summary and the raw data of every section
+
+The sections are the cluster, its limited support reasons, the service logs of the last --days, the open
+PagerDuty incidents and OHSS cards, the firing alerts, the Kubernetes events of the managed namespaces newer than
+--since and, with --verify-egress, the results of the network verifier. A section which can't be collected says
+why in the summary, the others are still collected. The PagerDuty and Jira sections are skipped with a note when
+their token isn't configured.
+
+The alerts and the events require being logged in to the cluster through backplane ('ocm backplane login
+CLUSTER_ID'). --verify-egress runs 'osdctl network verify-egress', which launches instances in the cluster
+account and takes a few minutes.
+`
+	incidentBundleExample = `
+  # Bundle the context of a cluster into the current directory
+  osdctl cluster incident-bundle 1kfmyclusteristhebesteverp8m
+
+  # Also run the network verifier, and look at the events of the last day
+  osdctl cluster incident-bundle 1kfmyclusteristhebesteverp8m --verify-egress --since 24h --dir ./incident
+`
+
+	// firingAlertsQuery are the alerts firing on the cluster, from its Prometheus
+	firingAlertsQuery = `ALERTS{alertstate="firing"}`
+)
+
+// incidentNamespaces are the namespaces the events are collected from
+var incidentNamespaces = []string{"openshift-*", "kube-*"}
+
+type incidentBundleOptions struct {
+	clusterID    string
+	dir          string
+	days         int
+	since        time.Duration
+	verifyEgress bool
+	// dirSet is true when --dir was given, it takes precedence over --artifacts
+	dirSet bool
+
+	runOC utils.OCRunner
+	// pagerDutyIncidents and ohssIssues read the integrations, they are replaced in tests
+	pagerDutyIncidents func(cluster *cmv1.Cluster) ([]pd.Incident, error)
+	ohssIssues         func(cluster *cmv1.Cluster) ([]jira.Issue, error)
+	// verifyEgressRun runs the network verifier against the cluster and returns its output
+	verifyEgressRun func(clusterID string) ([]byte, error)
+	now             func() time.Time
+}
+
+// incidentSection is a section of the bundle, its summary goes into the markdown and its data into the archive
+type incidentSection struct {
+	title string
+	// file is the name of the raw data in the archive, empty when there is no data
+	file    string
+	data    []byte
+	summary string
+	err     error
+}
+
+// incidentAlert is an alert firing on the cluster
+type incidentAlert struct {
+	Name      string `json:"name"`
+	Severity  string `json:"severity"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+func newCmdIncidentBundle() *cobra.Command {
+	ops := &incidentBundleOptions{runOC: utils.RunOCAsClusterAdmin, pagerDutyIncidents: openPagerDutyIncidents, ohssIssues: openOHSSIssues,
+		verifyEgressRun: runVerifyEgress, now: time.Now}
+	incidentBundleCmd := &cobra.Command{
+		Use:               "incident-bundle CLUSTER_ID",
+		Short:             "Collect the context of a cluster into a bundle to attach to an incident ticket",
+		Long:              incidentBundleLongDescription,
+		Example:           incidentBundleExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	incidentBundleCmd.Flags().StringVar(&ops.dir, "dir", ".", "Directory to write the bundle to, created if needed")
+	incidentBundleCmd.Flags().IntVar(&ops.days, "days", 30, "Collect the service logs of the last days")
+	incidentBundleCmd.Flags().DurationVar(&ops.since, "since", 6*time.Hour, "Collect the events newer than this duration")
+	incidentBundleCmd.Flags().BoolVar(&ops.verifyEgress, "verify-egress", false, "Also run the network verifier, which launches instances in the cluster account")
+
+	return incidentBundleCmd
+}
+
+func (o *incidentBundleOptions) complete(cmd *cobra.Command, args []string) error {
+	o.clusterID = args[0]
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	if o.days < 1 {
+		return cmdutil.UsageErrorf(cmd, "--days must be at least 1")
+	}
+	if o.since <= 0 {
+		return cmdutil.UsageErrorf(cmd, "--since must be positive")
+	}
+	o.dirSet = cmd.Flags().Changed("dir")
+	return nil
+}
+
+func (o *incidentBundleOptions) run() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	reasons, reasonsErr := utils.GetClusterLimitedSupportReasons(connection, cluster.ID())
+	serviceLogs, serviceLogsErr := listIncidentServiceLogs(connection, cluster)
+	sections := o.collect(cluster, reasons, reasonsErr, serviceLogs, serviceLogsErr)
+
+	name := fmt.Sprintf("incident-%s-%s", cluster.ID(), o.now().UTC().Format("20060102T150405Z"))
+	markdown := incidentMarkdown(cluster, sections, o.now())
+	archive, err := incidentArchive(name, markdown, sections, o.now())
+	if err != nil {
+		return fmt.Errorf("cannot create the bundle: %w", err)
+	}
+
+	for _, file := range []struct {
+		name        string
+		content     []byte
+		description string
+	}{
+		{name + ".md", markdown, "summary of the incident bundle"},
+		{name + ".tar.gz", archive, "incident bundle"},
+	} {
+		var path string
+		if !o.dirSet && artifacts.Enabled() {
+			path, err = artifacts.Write(cluster.ID(), file.name, file.content, file.description)
+			if err != nil {
+				return err
+			}
+		} else {
+			if err := os.MkdirAll(o.dir, 0750); err != nil {
+				return fmt.Errorf("cannot create %s: %w", o.dir, err)
+			}
+			path = filepath.Join(o.dir, file.name)
+			if err := os.WriteFile(path, file.content, 0600); err != nil {
+				return fmt.Errorf("cannot write %s: %w", path, err)
+			}
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// listIncidentServiceLogs returns every service log of the cluster, the most recent first
+func listIncidentServiceLogs(connection *sdk.Connection, cluster *cmv1.Cluster) ([]sl.GoodReply, error) {
+	request, err := servicelog.CreateListSLRequest(connection, cluster, true, false)
+	if err != nil {
+		return nil, err
+	}
+	response, err := request.Send()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the service logs: %w", err)
+	}
+	var list sl.ClusterListGoodReply
+	if err := json.Unmarshal(response.Bytes(), &list); err != nil {
+		return nil, fmt.Errorf("cannot parse the service logs: %w", err)
+	}
+	return list.Items, nil
+}
+
+// collect builds the sections of the bundle, the ones read from the cluster are unavailable when the current
+// kubeconfig isn't logged in to it
+func (o *incidentBundleOptions) collect(cluster *cmv1.Cluster, reasons []*utils.LimitedSupportReasonItem, reasonsErr error,
+	serviceLogs []sl.GoodReply, serviceLogsErr error) []incidentSection {
+	sections := []incidentSection{
+		contextSection(cluster),
+		limitedSupportSection(reasons, reasonsErr),
+		serviceLogsSection(serviceLogs, serviceLogsErr, o.days, o.now()),
+		o.pagerDutySection(cluster),
+		o.ohssSection(cluster),
+	}
+
+	ocErr := utils.CheckOCCluster(o.runOC, cluster)
+	sections = append(sections, o.alertsSection(ocErr), o.eventsSection(ocErr), o.networkVerifierSection(cluster))
+	return sections
+}
+
+func contextSection(cluster *cmv1.Cluster) incidentSection {
+	section := incidentSection{title: "Cluster", file: "cluster.json"}
+	var data bytes.Buffer
+	if err := cmv1.MarshalCluster(cluster, &data); err != nil {
+		section.err = fmt.Errorf("cannot marshal the cluster: %w", err)
+		return section
+	}
+	section.data = data.Bytes()
+
+	version := cluster.OpenshiftVersion()
+	if version == "" {
+		version = cluster.Version().RawID()
+	}
+	topology := "classic"
+	if cluster.Hypershift().Enabled() {
+		topology = "hosted control plane"
+	}
+	rows := [][]string{
+		{"Name", cluster.Name()},
+		{"ID", cluster.ID()},
+		{"External ID", cluster.ExternalID()},
+		{"State", string(cluster.State())},
+		{"Version", version},
+		{"Product", cluster.Product().ID()},
+		{"Cloud", cluster.CloudProvider().ID() + " " + cluster.Region().ID()},
+		{"Topology", topology},
+		{"Console", cluster.Console().URL()},
+	}
+	if created := cluster.CreationTimestamp(); !created.IsZero() {
+		rows = append(rows, []string{"Created", timefmt.Format(created)})
+	}
+	section.summary = markdownTable([]string{"Field", "Value"}, rows)
+	return section
+}
+
+func limitedSupportSection(reasons []*utils.LimitedSupportReasonItem, err error) incidentSection {
+	section := incidentSection{title: "Limited support", file: "limited_support.json", err: err}
+	if err != nil {
+		return section
+	}
+	section.data, section.err = json.MarshalIndent(reasons, "", "  ")
+	if len(reasons) == 0 {
+		section.summary = "The cluster is fully supported.\n"
+		return section
+	}
+	rows := make([][]string, 0, len(reasons))
+	for _, reason := range reasons {
+		rows = append(rows, []string{reason.ID, timefmt.Format(reason.CreatedAt), reason.Summary, reason.Details})
+	}
+	section.summary = fmt.Sprintf("The cluster is in limited support for %d reasons.\n\n", len(reasons)) +
+		markdownTable([]string{"Reason ID", "Since", "Summary", "Details"}, rows)
+	return section
+}
+
+func serviceLogsSection(serviceLogs []sl.GoodReply, err error, days int, now time.Time) incidentSection {
+	section := incidentSection{title: fmt.Sprintf("Service logs of the last %d days", days), file: "service_logs.json", err: err}
+	if err != nil {
+		return section
+	}
+	var recent []sl.GoodReply
+	for _, serviceLog := range serviceLogs {
+		if now.Sub(serviceLog.Timestamp) <= time.Duration(days)*24*time.Hour {
+			recent = append(recent, serviceLog)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool { return recent[i].Timestamp.After(recent[j].Timestamp) })
+	section.data, section.err = json.MarshalIndent(recent, "", "  ")
+	if len(recent) == 0 {
+		section.summary = "No service log was sent.\n"
+		return section
+	}
+	rows := make([][]string, 0, len(recent))
+	for _, serviceLog := range recent {
+		rows = append(rows, []string{timefmt.Format(serviceLog.Timestamp), serviceLog.Severity, serviceLog.ServiceName, serviceLog.Summary})
+	}
+	section.summary = markdownTable([]string{"Sent", "Severity", "Service", "Summary"}, rows)
+	return section
+}
+
+func (o *incidentBundleOptions) pagerDutySection(cluster *cmv1.Cluster) incidentSection {
+	section := incidentSection{title: "PagerDuty incidents", file: "pagerduty_incidents.json"}
+	incidents, err := o.pagerDutyIncidents(cluster)
+	if skipped, ok := skippedSection(section, err); ok {
+		return skipped
+	}
+	section.data, section.err = json.MarshalIndent(incidents, "", "  ")
+	if len(incidents) == 0 {
+		section.summary = "No incident is open.\n"
+		return section
+	}
+	rows := make([][]string, 0, len(incidents))
+	for _, incident := range incidents {
+		rows = append(rows, []string{incident.CreatedAt, incident.Urgency, incident.Status, incident.Title, incident.HTMLURL})
+	}
+	section.summary = markdownTable([]string{"Created", "Urgency", "Status", "Title", "Link"}, rows)
+	return section
+}
+
+// openPagerDutyIncidents returns the triggered and acknowledged incidents of the PagerDuty service of the cluster
+func openPagerDutyIncidents(cluster *cmv1.Cluster) ([]pd.Incident, error) {
+	client, err := GetPagerdutyClient("", "")
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.TODO()
+	serviceID, err := getPDSeviceID(client, ctx, cluster.DNS().BaseDomain())
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.ListIncidentsWithContext(ctx, pd.ListIncidentsOptions{
+		ServiceIDs: []string{serviceID},
+		Statuses:   []string{"triggered", "acknowledged"},
+		SortBy:     "urgency:DESC",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Incidents, nil
+}
+
+func (o *incidentBundleOptions) ohssSection(cluster *cmv1.Cluster) incidentSection {
+	section := incidentSection{title: "OHSS cards", file: "ohss_cards.json"}
+	issues, err := o.ohssIssues(cluster)
+	if skipped, ok := skippedSection(section, err); ok {
+		return skipped
+	}
+	section.data, section.err = json.MarshalIndent(issues, "", "  ")
+	if len(issues) == 0 {
+		section.summary = "No OHSS card was found.\n"
+		return section
+	}
+	rows := make([][]string, 0, len(issues))
+	for _, issue := range issues {
+		var status, priority string
+		if issue.Fields != nil {
+			if issue.Fields.Status != nil {
+				status = issue.Fields.Status.Name
+			}
+			if issue.Fields.Priority != nil {
+				priority = issue.Fields.Priority.Name
+			}
+			rows = append(rows, []string{"[" + issue.Key + "](https://issues.redhat.com/browse/" + issue.Key + ")", priority, status, issue.Fields.Summary})
+		}
+	}
+	section.summary = markdownTable([]string{"Card", "Priority", "Status", "Summary"}, rows)
+	return section
+}
+
+// openOHSSIssues returns the OHSS cards of the cluster
+func openOHSSIssues(cluster *cmv1.Cluster) ([]jira.Issue, error) {
+	token, err := lookupToken("", JiraTokenConfigKey, "Jira")
+	if err != nil {
+		return nil, err
+	}
+	transport := jira.PATAuthTransport{Token: token}
+	client, err := jira.NewClient(transport.Client(), "https://issues.redhat.com/")
+	if err != nil {
+		return nil, err
+	}
+	issues, _, err := client.Issue.Search(ohssJQL(cluster.ExternalID(), cluster.ID()), nil)
+	return issues, err
+}
+
+// skippedSection returns the section noting that its integration isn't configured, false when the error is
+// something else. The other errors make the section unavailable.
+func skippedSection(section incidentSection, err error) (incidentSection, bool) {
+	if secrets.IsNotConfigured(err) {
+		section.file = ""
+		section.summary = fmt.Sprintf("_Skipped: %s_\n", markdownCell(err.Error()))
+		return section, true
+	}
+	if err != nil {
+		section.err = err
+		return section, true
+	}
+	return section, false
+}
+
+func (o *incidentBundleOptions) alertsSection(ocErr error) incidentSection {
+	section := incidentSection{title: "Firing alerts", file: "alerts.json", err: ocErr}
+	if ocErr != nil {
+		return section
+	}
+	output, err := o.runOC("exec", "-n", "openshift-monitoring", "-c", "prometheus", "prometheus-k8s-0", "--",
+		"curl", "-s", "--data-urlencode", "query="+firingAlertsQuery, "http://localhost:9090/api/v1/query")
+	if err != nil {
+		section.err = err
+		return section
+	}
+	alerts, err := parseFiringAlerts(output)
+	if err != nil {
+		section.err = err
+		return section
+	}
+	section.data, section.err = json.MarshalIndent(alerts, "", "  ")
+	if len(alerts) == 0 {
+		section.summary = "No alert is firing.\n"
+		return section
+	}
+	rows := make([][]string, 0, len(alerts))
+	for _, alert := range alerts {
+		rows = append(rows, []string{alert.Name, alert.Severity, alert.Namespace})
+	}
+	section.summary = markdownTable([]string{"Alert", "Severity", "Namespace"}, rows)
+	return section
+}
+
+// parseFiringAlerts returns the alerts of a Prometheus response, the most severe first
+func parseFiringAlerts(output []byte) ([]incidentAlert, error) {
+	var response struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return nil, fmt.Errorf("cannot parse the prometheus response: %w", err)
+	}
+	if response.Status != "success" {
+		return nil, fmt.Errorf("the prometheus query failed with status '%s'", response.Status)
+	}
+
+	alerts := []incidentAlert{}
+	for _, result := range response.Data.Result {
+		alerts = append(alerts, incidentAlert{Name: result.Metric["alertname"], Severity: result.Metric["severity"], Namespace: result.Metric["namespace"]})
+	}
+	rank := map[string]int{"critical": 0, "warning": 1, "info": 2}
+	severity := func(alert incidentAlert) int {
+		if r, ok := rank[alert.Severity]; ok {
+			return r
+		}
+		return len(rank)
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if severity(alerts[i]) != severity(alerts[j]) {
+			return severity(alerts[i]) < severity(alerts[j])
+		}
+		return alerts[i].Name < alerts[j].Name
+	})
+	return alerts, nil
+}
+
+func (o *incidentBundleOptions) eventsSection(ocErr error) incidentSection {
+	section := incidentSection{title: fmt.Sprintf("Events of the last %s", o.since), file: "events.json", err: ocErr}
+	if ocErr != nil {
+		return section
+	}
+	events, err := (&eventsK8sOptions{runOC: o.runOC}).listEvents()
+	if err != nil {
+		section.err = err
+		return section
+	}
+	response := summarizeEvents(events, incidentNamespaces, o.now().Add(-o.since))
+	response.ClusterID = o.clusterID
+	response.Since = o.since.String()
+	section.data, section.err = json.MarshalIndent(response, "", "  ")
+	section.summary = "```\n" + response.String() + "```\n"
+	return section
+}
+
+func (o *incidentBundleOptions) networkVerifierSection(cluster *cmv1.Cluster) incidentSection {
+	section := incidentSection{title: "Network verifier"}
+	if !o.verifyEgress {
+		section.summary = "Not run, collect the bundle with --verify-egress to include it.\n"
+		return section
+	}
+	section.file = "network_verifier.txt"
+	output, err := o.verifyEgressRun(cluster.ID())
+	section.data = output
+	result := "The egress verification passed."
+	if err != nil {
+		result = fmt.Sprintf("The egress verification failed: %v.", err)
+	}
+	section.summary = result + "\n\n```\n" + strings.TrimRight(string(output), "\n") + "\n```\n"
+	return section
+}
+
+// runVerifyEgress runs 'osdctl network verify-egress', it exits when the verification fails
+func runVerifyEgress(clusterID string) ([]byte, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("cannot find the osdctl binary: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "Running the network verifier, it can take a few minutes")
+	return exec.Command(executable, "network", "verify-egress", "--cluster-id", clusterID).CombinedOutput() //#nosec G204 -- the cluster ID is validated
+}
+
+// incidentMarkdown returns the summary of the bundle
+func incidentMarkdown(cluster *cmv1.Cluster, sections []incidentSection, now time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Incident bundle of %s (%s)\n\n", cluster.Name(), cluster.ID())
+	fmt.Fprintf(&b, "Collected on %s with `osdctl %s`.\n", now.UTC().Format(time.RFC3339), strings.Join(os.Args[1:], " "))
+	for _, section := range sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", section.title)
+		if section.err != nil {
+			fmt.Fprintf(&b, "_Unavailable: %s_\n", markdownCell(section.err.Error()))
+			continue
+		}
+		b.WriteString(section.summary)
+	}
+	return b.Bytes()
+}
+
+// incidentArchive returns the gzipped tar of the summary and the data of the sections, under a directory named
+// after the bundle
+func incidentArchive(name string, markdown []byte, sections []incidentSection, now time.Time) ([]byte, error) {
+	var b bytes.Buffer
+	compressed := gzip.NewWriter(&b)
+	archive := tar.NewWriter(compressed)
+	files := []incidentSection{{file: "incident.md", data: markdown}}
+	for _, section := range sections {
+		if section.file != "" && section.data != nil {
+			files = append(files, section)
+		}
+	}
+	for _, file := range files {
+		header := &tar.Header{Name: name + "/" + file.file, Mode: 0600, Size: int64(len(file.data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := archive.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := archive.Write(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	if err := compressed.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func markdownTable(header []string, rows [][]string) string {
+	var b strings.Builder
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = markdownCell(cell)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+// markdownCell keeps the text on one line of a table, it can't hold newlines nor unescaped pipes
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\n", " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	pd "github.com/PagerDuty/go-pagerduty"
+	jira "github.com/andygrunwald/go-jira"
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	sl "github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/openshift/osdctl/pkg/utils"
+)
+
+const testIncidentEvents = `{"items": [
+  {"metadata": {"namespace": "openshift-ingress"}, "involvedObject": {"kind": "Pod", "name": "router-1"}, "type": "Warning", "reason": "BackOff",
+   "message": "Back-off restarting failed container", "count": 12, "lastTimestamp": "2026-10-14T11:50:00Z"}
+]}`
+
+func TestIncidentBundle(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	cluster, err := cmv1.NewCluster().ID("abc").Name("prod-1").ExternalID("uuid-1").OpenshiftVersion("4.15.3").
+		CloudProvider(cmv1.NewCloudProvider().ID("aws")).Region(cmv1.NewCloudRegion().ID("us-east-1")).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	o := &incidentBundleOptions{clusterID: "abc", days: 30, since: time.Hour, now: func() time.Time { return now }, verifyEgress: true,
+		runOC: func(args ...string) ([]byte, error) {
+			switch args[0] {
+			case "get":
+				if args[1] == "clusterversion" {
+					return []byte("uuid-1"), nil
+				}
+				return []byte(testIncidentEvents), nil
+			case "exec":
+				return []byte(`{"status": "success", "data": {"result": [
+					{"metric": {"alertname": "Watchdog", "severity": "none"}},
+					{"metric": {"alertname": "KubePodCrashLooping", "severity": "warning", "namespace": "openshift-ingress"}},
+					{"metric": {"alertname": "ClusterOperatorDown", "severity": "critical"}}]}}`), nil
+			}
+			return nil, nil
+		},
+		pagerDutyIncidents: func(cluster *cmv1.Cluster) ([]pd.Incident, error) {
+			return []pd.Incident{{Title: "ClusterHasGoneMissing", Urgency: "high", Status: "triggered", CreatedAt: "2026-10-14T11:30:00Z"}}, nil
+		},
+		ohssIssues: func(cluster *cmv1.Cluster) ([]jira.Issue, error) {
+			return nil, &secrets.NotConfiguredError{Integration: "Jira", Key: secrets.JiraTokenKey}
+		},
+		verifyEgressRun: func(clusterID string) ([]byte, error) {
+			return []byte("=== Aggregate result ===\nFAIL\tsubnet-1\n"), errors.New("exit status 1")
+		},
+	}
+	reasons := []*utils.LimitedSupportReasonItem{{ID: "r1", Summary: "Cluster | account", Details: "The account\nis gone", CreatedAt: now.Add(-time.Hour)}}
+	serviceLogs := []sl.GoodReply{
+		{Summary: "Old", Timestamp: now.Add(-40 * 24 * time.Hour)},
+		{Summary: "Upgrade scheduled", Severity: "Info", ServiceName: "SREManualAction", Timestamp: now.Add(-2 * time.Hour)},
+	}
+
+	sections := o.collect(cluster, reasons, nil, serviceLogs, nil)
+	markdown := string(incidentMarkdown(cluster, sections, now))
+	g.Expect(markdown).To(ContainSubstring("# Incident bundle of prod-1 (abc)"))
+	g.Expect(markdown).To(ContainSubstring("| Version | 4.15.3 |"))
+	g.Expect(markdown).To(ContainSubstring("| r1 | 2026-10-14 11:00:00 UTC | Cluster \\| account | The account is gone |"))
+	g.Expect(markdown).To(ContainSubstring("Upgrade scheduled"))
+	g.Expect(markdown).NotTo(ContainSubstring("| Old |"))
+	g.Expect(markdown).To(MatchRegexp(`(?s)ClusterOperatorDown.*KubePodCrashLooping.*Watchdog`))
+	g.Expect(markdown).To(ContainSubstring("openshift-ingress   router-1"))
+	g.Expect(markdown).To(ContainSubstring("| 2026-10-14T11:30:00Z | high | triggered | ClusterHasGoneMissing |  |"))
+	g.Expect(markdown).To(ContainSubstring("## OHSS cards\n\n_Skipped: Jira isn't configured, store its token with 'osdctl secrets set jira_token'_"))
+	g.Expect(markdown).To(ContainSubstring("The egress verification failed: exit status 1."))
+
+	archive, err := incidentArchive("incident-abc", []byte(markdown), sections, now)
+	g.Expect(err).NotTo(HaveOccurred())
+	reader, err := gzip.NewReader(bytes.NewReader(archive))
+	g.Expect(err).NotTo(HaveOccurred())
+	files := tar.NewReader(reader)
+	var names []string
+	for {
+		header, err := files.Next()
+		if err == io.EOF {
+			break
+		}
+		g.Expect(err).NotTo(HaveOccurred())
+		names = append(names, header.Name)
+	}
+	g.Expect(names).To(Equal([]string{"incident-abc/incident.md", "incident-abc/cluster.json", "incident-abc/limited_support.json",
+		"incident-abc/service_logs.json", "incident-abc/pagerduty_incidents.json", "incident-abc/alerts.json", "incident-abc/events.json", "incident-abc/network_verifier.txt"}))
+}
+
+func TestIncidentBundleNotLoggedIn(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster, err := cmv1.NewCluster().ID("abc").Name("prod-1").ExternalID("uuid-1").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	o := &incidentBundleOptions{clusterID: "abc", days: 30, since: time.Hour, now: time.Now, runOC: func(args ...string) ([]byte, error) {
+		return []byte("uuid-2"), nil
+	}, pagerDutyIncidents: func(cluster *cmv1.Cluster) ([]pd.Incident, error) {
+		return nil, errors.New("unexpected number of services matched input. Expected 1 got 0")
+	}, ohssIssues: func(cluster *cmv1.Cluster) ([]jira.Issue, error) {
+		return nil, nil
+	}}
+
+	sections := o.collect(cluster, nil, errors.New("forbidden"), nil, nil)
+	markdown := string(incidentMarkdown(cluster, sections, time.Now()))
+	g.Expect(markdown).To(ContainSubstring("## Limited support\n\n_Unavailable: forbidden_"))
+	g.Expect(markdown).To(ContainSubstring("No service log was sent."))
+	g.Expect(markdown).To(ContainSubstring("## PagerDuty incidents\n\n_Unavailable: unexpected number of services"))
+	g.Expect(markdown).To(ContainSubstring("No OHSS card was found."))
+	g.Expect(markdown).To(ContainSubstring("## Firing alerts\n\n_Unavailable: the current kubeconfig isn't logged in to prod-1"))
+	g.Expect(markdown).To(ContainSubstring("Not run, collect the bundle with --verify-egress"))
+}
@@ -0,0 +1,407 @@
+package cluster
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	installLogsExample = `
+  # Download and scan the install logs of a cluster which failed to install
+  osdctl cluster install-logs 1kfmyclusteristhebesteverp8m
+
+  # Only look at a given bucket of the cluster account, with an AWS profile
+  osdctl cluster install-logs 1kfmyclusteristhebesteverp8m --bucket my-bucket --prefix log-bundle --profile rhcontrol
+`
+
+	// hiveInstallLogName is the file the install log hive gathered is written to
+	hiveInstallLogName = "hive-install.log"
+	// maxInstallLogSize bounds what is written of a single file, decompressed, so that a corrupt archive can't fill up the disk
+	maxInstallLogSize = 512 << 20
+	// maxInstallLogLine is the longest line printed with a finding
+	maxInstallLogLine = 200
+)
+
+type installLogsOptions struct {
+	clusterID  string
+	awsProfile string
+	bucket     string
+	prefix     string
+	dir        string
+
+	awsClient     awsprovider.Client
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// installLogMatch is a known fatal pattern found in the install logs
+type installLogMatch struct {
+	Cause       string `json:"cause"`
+	NextStep    string `json:"next_step"`
+	Pattern     string `json:"pattern"`
+	Occurrences int    `json:"occurrences"`
+	// File and Line locate the first occurrence
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Text       string `json:"text"`
+	likelihood int
+}
+
+type installLogsResponse struct {
+	ClusterID string            `json:"cluster_id"`
+	Dir       string            `json:"dir"`
+	Files     []string          `json:"files"`
+	Findings  []installLogMatch `json:"findings"`
+	Warnings  []string          `json:"warnings,omitempty"`
+}
+
+func (r installLogsResponse) String() string {
+	var b strings.Builder
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(&b, "Warning: %s\n", warning)
+	}
+	fmt.Fprintf(&b, "%d install log file(s) of cluster %s written to %s\n\n", len(r.Files), r.ClusterID, r.Dir)
+	if len(r.Findings) == 0 {
+		b.WriteString("No known fatal error found in the install logs.\n")
+		return b.String()
+	}
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Cause", "Found", "First occurrence", "Next step"})
+	for _, finding := range r.Findings {
+		table.AddRow([]string{finding.Cause, fmt.Sprintf("%dx '%s'", finding.Occurrences, finding.Pattern),
+			fmt.Sprintf("%s:%d", finding.File, finding.Line), finding.NextStep})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	for _, finding := range r.Findings {
+		fmt.Fprintf(&b, "%s:%d: %s\n", finding.File, finding.Line, finding.Text)
+	}
+	return b.String()
+}
+
+func newCmdInstallLogs(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &installLogsOptions{GlobalOptions: globalOpts}
+	installLogsCmd := &cobra.Command{
+		Use:   "install-logs CLUSTER_ID",
+		Short: "Download the installer and bootstrap logs of a cluster and look for known fatal errors",
+		Long: `Download the installer and bootstrap logs of a cluster and look for known fatal errors
+
+  The install log gathered by hive is retrieved from OCM. The objects of the S3 buckets of the cluster account
+  named after the infra ID of the cluster, e.g. the bootstrap bucket, or of --bucket, are downloaded as well, and
+  the .gz and .tar.gz ones, e.g. the log bundle gathered when the bootstrap fails, are decompressed.
+
+  Every file is then searched for the known fatal errors, printed with their likely cause, most likely first.`,
+		Example:           installLogsExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	installLogsCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile name")
+	installLogsCmd.Flags().StringVar(&ops.bucket, "bucket", "", "S3 bucket of the cluster account holding the install logs, instead of the buckets named after the infra ID")
+	installLogsCmd.Flags().StringVar(&ops.prefix, "prefix", "", "Only download the objects of the buckets with this key prefix")
+	installLogsCmd.Flags().StringVar(&ops.dir, "dir", "", "Directory the logs are written to (default install-logs-CLUSTER_ID)")
+
+	return installLogsCmd
+}
+
+func (o *installLogsOptions) complete(cmd *cobra.Command) error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return cmdutil.UsageErrorf(cmd, "%v", err)
+	}
+	if o.dir == "" {
+		o.dir = "install-logs-" + o.clusterID
+	}
+	return nil
+}
+
+func (o *installLogsOptions) run() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetClusterAnyStatus(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(o.dir, 0700); err != nil {
+		return err
+	}
+	response := installLogsResponse{ClusterID: cluster.ID(), Dir: o.dir}
+
+	installLog, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).Logs().Install().Get().Send()
+	if err != nil {
+		response.Warnings = append(response.Warnings, fmt.Sprintf("cannot retrieve the install log gathered by hive: %v", err))
+	} else if installLog.Body().Content() != "" {
+		path := filepath.Join(o.dir, hiveInstallLogName)
+		if err := os.WriteFile(path, []byte(installLog.Body().Content()), 0600); err != nil {
+			return err
+		}
+		response.Files = append(response.Files, path)
+	}
+
+	if cluster.CloudProvider().ID() != "aws" {
+		response.Warnings = append(response.Warnings, "only the S3 buckets of AWS clusters are searched for install logs")
+	} else {
+		if o.awsClient == nil {
+			if o.awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID()); err != nil {
+				return err
+			}
+		}
+		files, warnings, err := o.downloadBucketLogs(cluster.InfraID())
+		if err != nil {
+			return err
+		}
+		response.Files = append(response.Files, files...)
+		response.Warnings = append(response.Warnings, warnings...)
+	}
+
+	if response.Findings, err = scanInstallLogs(response.Files); err != nil {
+		return err
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// downloadBucketLogs downloads the objects of the install log buckets to the directory, a warning is returned when
+// there is no bucket, the installer deletes the bootstrap bucket once the bootstrap completed
+func (o *installLogsOptions) downloadBucketLogs(infraID string) ([]string, []string, error) {
+	buckets := []string{o.bucket}
+	if o.bucket == "" {
+		var err error
+		if buckets, err = installLogBuckets(o.awsClient, infraID); err != nil {
+			return nil, nil, err
+		}
+		if len(buckets) == 0 {
+			return nil, []string{fmt.Sprintf("no S3 bucket of the cluster account is named after the infra ID %s, use --bucket", infraID)}, nil
+		}
+	}
+
+	var files []string
+	for _, bucket := range buckets {
+		bucketFiles, err := downloadInstallLogs(o.awsClient, bucket, o.prefix, filepath.Join(o.dir, bucket))
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, bucketFiles...)
+	}
+	return files, nil, nil
+}
+
+// installLogBuckets returns the buckets of the account named after the infra ID of the cluster
+func installLogBuckets(client awsprovider.Client, infraID string) ([]string, error) {
+	output, err := client.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the S3 buckets of the cluster account: %w", err)
+	}
+	var buckets []string
+	for _, bucket := range output.Buckets {
+		if name := awsSdk.StringValue(bucket.Name); infraID != "" && strings.HasPrefix(name, infraID) {
+			buckets = append(buckets, name)
+		}
+	}
+	return buckets, nil
+}
+
+// downloadInstallLogs writes the objects of the bucket with the prefix to the directory, decompressed
+func downloadInstallLogs(client awsprovider.Client, bucket, prefix, dir string) ([]string, error) {
+	var files []string
+	input := &s3.ListObjectsInput{Bucket: awsSdk.String(bucket), Prefix: awsSdk.String(prefix)}
+	for {
+		output, err := client.ListObjects(input)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the objects of the bucket %s: %w", bucket, err)
+		}
+		for _, object := range output.Contents {
+			key := awsSdk.StringValue(object.Key)
+			if strings.HasSuffix(key, "/") {
+				continue
+			}
+			written, err := downloadInstallLog(client, bucket, key, dir)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, written...)
+		}
+		if !awsSdk.BoolValue(output.IsTruncated) || len(output.Contents) == 0 {
+			break
+		}
+		input.Marker = output.Contents[len(output.Contents)-1].Key
+	}
+	return files, nil
+}
+
+func downloadInstallLog(client awsprovider.Client, bucket, key, dir string) ([]string, error) {
+	output, err := client.GetObject(&s3.GetObjectInput{Bucket: awsSdk.String(bucket), Key: awsSdk.String(key)})
+	if err != nil {
+		return nil, fmt.Errorf("cannot download s3://%s/%s: %w", bucket, key, err)
+	}
+	defer output.Body.Close()
+	files, err := extractInstallLog(key, output.Body, dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot extract s3://%s/%s: %w", bucket, key, err)
+	}
+	return files, nil
+}
+
+// extractInstallLog writes the file to the directory, decompressing .gz files and extracting .tar.gz archives
+func extractInstallLog(name string, body io.Reader, dir string) ([]string, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return extractInstallLogArchive(tar.NewReader(reader), filepath.Join(dir, strings.TrimSuffix(strings.TrimSuffix(name, ".tgz"), ".tar.gz")))
+	case strings.HasSuffix(name, ".gz"):
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		path, err := writeInstallLog(dir, strings.TrimSuffix(name, ".gz"), reader)
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+	path, err := writeInstallLog(dir, name, body)
+	if err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+func extractInstallLogArchive(archive *tar.Reader, dir string) ([]string, error) {
+	var files []string
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		path, err := writeInstallLog(dir, header.Name, archive)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+}
+
+// writeInstallLog writes the file to the directory, refusing names outside of it
+func writeInstallLog(dir, name string, content io.Reader) (string, error) {
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("'%s' is outside of the directory", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) //#nosec G304 -- the path is checked to be in the directory
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, io.LimitReader(content, maxInstallLogSize)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// scanInstallLogs searches the files for the known fatal errors, returned most likely first
+func scanInstallLogs(files []string) ([]installLogMatch, error) {
+	matches := map[string]*installLogMatch{}
+	for _, path := range files {
+		if err := scanInstallLog(path, matches); err != nil {
+			return nil, err
+		}
+	}
+
+	findings := make([]installLogMatch, 0, len(matches))
+	for _, match := range matches {
+		findings = append(findings, *match)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].likelihood != findings[j].likelihood {
+			return findings[i].likelihood > findings[j].likelihood
+		}
+		return findings[i].Occurrences > findings[j].Occurrences
+	})
+	return findings, nil
+}
+
+// scanInstallLog records the known fatal errors found in the file, by cause
+func scanInstallLog(path string, matches map[string]*installLogMatch) error {
+	file, err := os.Open(path) //#nosec G304 -- the files were written by this command
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		for _, pattern := range installLogPatterns {
+			for _, text := range pattern.match {
+				if !strings.Contains(line, text) {
+					continue
+				}
+				if match, ok := matches[pattern.cause]; ok {
+					match.Occurrences++
+				} else {
+					matches[pattern.cause] = &installLogMatch{
+						Cause:       pattern.cause,
+						NextStep:    pattern.nextStep,
+						Pattern:     text,
+						Occurrences: 1,
+						File:        path,
+						Line:        number,
+						Text:        truncateInstallLogLine(strings.TrimSpace(line)),
+						likelihood:  pattern.likelihood,
+					}
+				}
+				break
+			}
+		}
+	}
+	// Binary files may have lines longer than the buffer, what was read until then was still scanned
+	if err := scanner.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+		return fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	return nil
+}
+
+func truncateInstallLogLine(line string) string {
+	if len(line) <= maxInstallLogLine {
+		return line
+	}
+	return line[:maxInstallLogLine] + "..."
+}
@@ -0,0 +1,115 @@
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+// tarGzipped returns an archive of the files, by name
+func tarGzipped(g *WithT, files map[string]string) io.ReadCloser {
+	var b bytes.Buffer
+	gzipWriter := gzip.NewWriter(&b)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, content := range files {
+		g.Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+		_, err := tarWriter.Write([]byte(content))
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	g.Expect(tarWriter.Close()).To(Succeed())
+	g.Expect(gzipWriter.Close()).To(Succeed())
+	return io.NopCloser(&b)
+}
+
+func TestInstallLogBuckets(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+
+	client.EXPECT().ListBuckets(gomock.Any()).Return(&s3.ListBucketsOutput{Buckets: []*s3.Bucket{
+		{Name: awsSdk.String("mycluster-x7k2p-bootstrap")},
+		{Name: awsSdk.String("osdctl-flowlogs-123456789012-eu-west-1")},
+	}}, nil)
+	buckets, err := installLogBuckets(client, "mycluster-x7k2p")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(buckets).To(Equal([]string{"mycluster-x7k2p-bootstrap"}))
+}
+
+func TestDownloadInstallLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+	dir := t.TempDir()
+
+	client.EXPECT().ListObjects(gomock.Any()).Return(&s3.ListObjectsOutput{Contents: []*s3.Object{
+		{Key: awsSdk.String("logs/")},
+		{Key: awsSdk.String("logs/log-bundle-20261014.tar.gz")},
+		{Key: awsSdk.String("logs/openshift-install.log.gz")},
+	}}, nil)
+	client.EXPECT().GetObject(&s3.GetObjectInput{Bucket: awsSdk.String("bucket"), Key: awsSdk.String("logs/log-bundle-20261014.tar.gz")}).
+		Return(&s3.GetObjectOutput{Body: tarGzipped(g, map[string]string{"bootstrap/journals/bootkube.log": "Error: Bootstrap failed to complete\n"})}, nil)
+	client.EXPECT().GetObject(&s3.GetObjectInput{Bucket: awsSdk.String("bucket"), Key: awsSdk.String("logs/openshift-install.log.gz")}).
+		Return(&s3.GetObjectOutput{Body: gzipped(g, "level=info msg=\"Waiting up to 20m0s for the Kubernetes API\"\n")}, nil)
+
+	files, err := downloadInstallLogs(client, "bucket", "logs/", dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(Equal([]string{
+		filepath.Join(dir, "logs", "log-bundle-20261014", "bootstrap", "journals", "bootkube.log"),
+		filepath.Join(dir, "logs", "openshift-install.log"),
+	}))
+	content, err := os.ReadFile(files[1])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(ContainSubstring("Waiting up to 20m0s"))
+}
+
+func TestExtractInstallLogRejectsPathsOutsideTheDirectory(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := t.TempDir()
+
+	_, err := extractInstallLog("bundle.tar.gz", tarGzipped(g, map[string]string{"../../etc/cron.d/evil": "* * * * * root true\n"}), filepath.Join(dir, "logs"))
+	g.Expect(err).To(MatchError(ContainSubstring("outside of the directory")))
+	_, err = os.Stat(filepath.Join(dir, "etc"))
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func TestScanInstallLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := t.TempDir()
+
+	install := filepath.Join(dir, hiveInstallLogName)
+	g.Expect(os.WriteFile(install, []byte(strings.Join([]string{
+		`level=info msg="Creating infrastructure resources..."`,
+		`level=error msg="Error: VcpuLimitExceeded: You have requested more vCPU capacity"`,
+		`level=error msg="Bootstrap failed to complete: timed out waiting for the condition"`,
+		`level=error msg="Error: VcpuLimitExceeded: You have requested more vCPU capacity"`,
+	}, "\n")), 0600)).To(Succeed())
+	bootkube := filepath.Join(dir, "bootkube.log")
+	g.Expect(os.WriteFile(bootkube, []byte("x509: certificate signed by unknown authority\n"), 0600)).To(Succeed())
+
+	findings, err := scanInstallLogs([]string{install, bootkube})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(findings).To(HaveLen(3))
+
+	// The most likely cause first
+	g.Expect(findings[0].Cause).To(ContainSubstring("AWS quota was reached"))
+	g.Expect(findings[0].Occurrences).To(Equal(2))
+	g.Expect(findings[0].File).To(Equal(install))
+	g.Expect(findings[0].Line).To(Equal(2))
+	g.Expect(findings[1].Cause).To(ContainSubstring("TLS certificate"))
+	g.Expect(findings[1].File).To(Equal(bootkube))
+	g.Expect(findings[2].Cause).To(ContainSubstring("bootstrap node"))
+
+	response := installLogsResponse{ClusterID: "abc", Dir: dir, Files: []string{install, bootkube}, Findings: findings}
+	g.Expect(response.String()).To(ContainSubstring(install + ":2: level=error"))
+}
@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/utils"
+)
+
+const (
+	archAMD64 = "amd64"
+	archARM64 = "arm64"
+)
+
+// gravitonInstanceType matches the AWS Graviton families, e.g. m6g, c7gn or r6gd
+var gravitonInstanceType = regexp.MustCompile(`^[a-z]+[0-9]+[a-z]*g[a-z]*\.`)
+
+// gravitonEquivalents maps the AWS Graviton families to the x86 families of the same shape, to suggest an instance type
+// of the right architecture either way
+var gravitonEquivalents = map[string][]string{
+	"a1":   {"m5", "m5a"},
+	"t4g":  {"t3", "t3a"},
+	"m6g":  {"m6i", "m6a", "m5"},
+	"m6gd": {"m6id", "m5d"},
+	"m7g":  {"m7i", "m7a"},
+	"m7gd": {"m6id"},
+	"m8g":  {"m7i", "m7a"},
+	"c6g":  {"c6i", "c6a", "c5"},
+	"c6gd": {"c6id", "c5d"},
+	"c6gn": {"c6in", "c5n"},
+	"c7g":  {"c7i", "c7a"},
+	"c7gd": {"c6id"},
+	"c7gn": {"c6in"},
+	"c8g":  {"c7i", "c7a"},
+	"r6g":  {"r6i", "r6a", "r5"},
+	"r6gd": {"r6id", "r5d"},
+	"r7g":  {"r7i", "r7a"},
+	"r7gd": {"r6id"},
+	"r8g":  {"r7i", "r7a"},
+	"x2gd": {"x2idn"},
+	"g5g":  {"g4dn"},
+}
+
+// gcpARM64Families are the GCP machine families running on Arm CPUs, e.g. t2a-standard-4
+var gcpARM64Families = map[string]bool{"t2a": true, "c4a": true}
+
+// instanceFamily returns the family of the instance type, e.g. m6g for m6g.xlarge or t2a for t2a-standard-4
+func instanceFamily(provider, instanceType string) string {
+	separator := "."
+	if strings.EqualFold(provider, "gcp") {
+		separator = "-"
+	}
+	return strings.ToLower(strings.SplitN(instanceType, separator, 2)[0])
+}
+
+// instanceArchitecture returns the CPU architecture of the instance type, amd64 unless it is a known Arm family
+func instanceArchitecture(provider, instanceType string) string {
+	family := instanceFamily(provider, instanceType)
+	if strings.EqualFold(provider, "gcp") {
+		if gcpARM64Families[family] {
+			return archARM64
+		}
+		return archAMD64
+	}
+	if _, ok := gravitonEquivalents[family]; ok || gravitonInstanceType.MatchString(strings.ToLower(instanceType)) {
+		return archARM64
+	}
+	return archAMD64
+}
+
+// equivalentInstanceTypes returns the instance types of the same size as the given one in the families of the other
+// architecture, e.g. m6i.xlarge, m6a.xlarge and m5.xlarge for m6g.xlarge
+func equivalentInstanceTypes(provider, instanceType string) []string {
+	if strings.EqualFold(provider, "gcp") {
+		return nil
+	}
+	family := instanceFamily(provider, instanceType)
+	size := strings.TrimPrefix(strings.ToLower(instanceType), family)
+
+	var families []string
+	if x86, ok := gravitonEquivalents[family]; ok {
+		families = x86
+	} else {
+		for graviton, x86 := range gravitonEquivalents {
+			for _, f := range x86 {
+				if f == family {
+					families = append(families, graviton)
+				}
+			}
+		}
+		sort.Strings(families)
+	}
+
+	var types []string
+	for _, f := range families {
+		types = append(types, f+size)
+	}
+	return types
+}
+
+// validateInstanceArchitecture rejects an instance type of another architecture than the current nodes, unless the
+// release payload of the cluster is multi-arch and has the images of both
+func validateInstanceArchitecture(provider, current, target string, multiArch bool) error {
+	currentArch := instanceArchitecture(provider, current)
+	targetArch := instanceArchitecture(provider, target)
+	if currentArch == targetArch || multiArch {
+		return nil
+	}
+
+	message := "%s is an %s instance type, the %s nodes of %s can't move to another architecture"
+	if alternatives := equivalentInstanceTypes(provider, target); len(alternatives) > 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, message+", use an %s instance type instead, e.g. %s",
+			target, targetArch, currentArch, current, currentArch, strings.Join(alternatives, ", "))
+	}
+	return osdctlErrors.New(osdctlErrors.ErrValidation, message+", use an %s instance type instead",
+		target, targetArch, currentArch, current, currentArch)
+}
+
+// releasePayloadMultiArch returns true when the cluster runs a multi-arch release payload, which can run nodes of
+// both architectures
+func releasePayloadMultiArch(run utils.OCRunner) (bool, error) {
+	output, err := run("get", "clusterversion", "version", "-o", "jsonpath={.status.desired.architecture}")
+	if err != nil {
+		return false, err
+	}
+	// Only set for the multi-arch payloads
+	return strings.EqualFold(strings.TrimSpace(string(output)), "Multi"), nil
+}
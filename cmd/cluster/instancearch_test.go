@@ -0,0 +1,55 @@
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestInstanceArchitecture(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(instanceArchitecture("aws", "m6g.xlarge")).To(Equal(archARM64))
+	g.Expect(instanceArchitecture("aws", "a1.large")).To(Equal(archARM64))
+	g.Expect(instanceArchitecture("aws", "hpc7g.4xlarge")).To(Equal(archARM64))
+	g.Expect(instanceArchitecture("AWS", "m5.xlarge")).To(Equal(archAMD64))
+	g.Expect(instanceArchitecture("aws", "g4dn.xlarge")).To(Equal(archAMD64))
+	g.Expect(instanceArchitecture("gcp", "t2a-standard-4")).To(Equal(archARM64))
+	g.Expect(instanceArchitecture("gcp", "n2-standard-4")).To(Equal(archAMD64))
+}
+
+func TestEquivalentInstanceTypes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(equivalentInstanceTypes("aws", "m6g.xlarge")).To(Equal([]string{"m6i.xlarge", "m6a.xlarge", "m5.xlarge"}))
+	g.Expect(equivalentInstanceTypes("aws", "m5.2xlarge")).To(Equal([]string{"a1.2xlarge", "m6g.2xlarge"}))
+	g.Expect(equivalentInstanceTypes("aws", "p4d.24xlarge")).To(BeEmpty())
+	g.Expect(equivalentInstanceTypes("gcp", "t2a-standard-4")).To(BeEmpty())
+}
+
+func TestValidateInstanceArchitecture(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validateInstanceArchitecture("aws", "m5.xlarge", "m6i.2xlarge", false)).To(Succeed())
+	g.Expect(validateInstanceArchitecture("aws", "m5.xlarge", "m6g.xlarge", true)).To(Succeed())
+
+	err := validateInstanceArchitecture("aws", "m5.xlarge", "m6g.xlarge", false)
+	g.Expect(err).To(MatchError("m6g.xlarge is an arm64 instance type, the amd64 nodes of m5.xlarge can't move to another architecture, " +
+		"use an amd64 instance type instead, e.g. m6i.xlarge, m6a.xlarge, m5.xlarge"))
+
+	err = validateInstanceArchitecture("gcp", "t2a-standard-4", "n2-standard-4", false)
+	g.Expect(err).To(MatchError("n2-standard-4 is an amd64 instance type, the arm64 nodes of t2a-standard-4 can't move to another architecture, " +
+		"use an arm64 instance type instead"))
+}
+
+func TestReleasePayloadMultiArch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	multi, err := releasePayloadMultiArch(func(args ...string) ([]byte, error) { return []byte("Multi"), nil })
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(multi).To(BeTrue())
+
+	multi, err = releasePayloadMultiArch(func(args ...string) ([]byte, error) { return []byte(""), nil })
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(multi).To(BeFalse())
+}
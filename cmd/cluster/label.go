@@ -0,0 +1,484 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/validation"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	// Subscription labels live in accounts_mgmt, e.g. opt-ins and feature gates
+	labelScopeSubscription = "subscription"
+	// Cluster labels live in the cluster's external configuration in clusters_mgmt and are synced to the cluster
+	labelScopeCluster = "cluster"
+)
+
+const labelExample = `
+  # List the subscription and cluster labels
+  osdctl cluster label list 1kfmyclusteristhebesteverp8m
+
+  # Add or update a subscription label
+  osdctl cluster label add 1kfmyclusteristhebesteverp8m my.feature.opt-in=true
+
+  # Remove a cluster label
+  osdctl cluster label remove 1kfmyclusteristhebesteverp8m my.feature.opt-in --scope cluster
+`
+
+// reservedLabelPrefixes are managed by OCM, hive or the platform, and can't be changed with this command
+var reservedLabelPrefixes = []string{
+	"capability.",
+	"api.openshift.com",
+	"hive.openshift.io",
+	"openshift.io",
+	"kubernetes.io",
+	"k8s.io",
+}
+
+// clusterLabel is a label of either scope, as printed with -o json
+type clusterLabel struct {
+	Scope    string `json:"scope"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Internal bool   `json:"internal,omitempty"`
+
+	// id identifies cluster labels in the API, subscription labels are identified by their key
+	id string
+}
+
+type labelOptions struct {
+	clusterID string
+	key       string
+	value     string
+	scope     string
+	internal  bool
+	yes       bool
+	dryRun    bool
+	output    string
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+func newCmdLabel(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &labelOptions{GlobalOptions: globalOpts}
+	labelCmd := &cobra.Command{
+		Use:               "label",
+		Short:             "Manages the OCM subscription and cluster labels of a cluster",
+		Example:           labelExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run:               help,
+	}
+	labelCmd.PersistentFlags().StringVar(&ops.scope, "scope", "", "Label scope, 'subscription' or 'cluster'. list shows both by default, add and remove default to 'subscription'")
+
+	listCmd := &cobra.Command{
+		Use:               "list CLUSTER_ID",
+		Short:             "Lists the labels of a cluster",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args, false))
+			osdctlErrors.CheckErr(ops.list())
+		},
+	}
+
+	addCmd := &cobra.Command{
+		Use:               "add CLUSTER_ID key=value",
+		Short:             "Adds a label to a cluster, or updates its value",
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args, true))
+			osdctlErrors.CheckErr(ops.add())
+		},
+	}
+	addCmd.Flags().BoolVar(&ops.internal, "internal", false, "Hide the subscription label from the customer")
+	addCmd.Flags().BoolVarP(&ops.yes, "yes", "y", false, "Skip the confirmation prompt")
+	addCmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Print the change to the labels without applying it")
+
+	removeCmd := &cobra.Command{
+		Use:               "remove CLUSTER_ID key",
+		Short:             "Removes a label from a cluster",
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args, true))
+			osdctlErrors.CheckErr(ops.remove())
+		},
+	}
+	removeCmd.Flags().BoolVarP(&ops.yes, "yes", "y", false, "Skip the confirmation prompt")
+	removeCmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Print the change to the labels without applying it")
+
+	labelCmd.AddCommand(listCmd, addCmd, removeCmd)
+	return labelCmd
+}
+
+func (o *labelOptions) complete(cmd *cobra.Command, args []string, modify bool) error {
+	o.clusterID = args[0]
+	o.output = o.GlobalOptions.Output
+
+	switch o.scope {
+	case "":
+		if modify {
+			o.scope = labelScopeSubscription
+		}
+	case labelScopeSubscription, labelScopeCluster:
+	default:
+		return cmdutil.UsageErrorf(cmd, "invalid --scope '%s', expected '%s' or '%s'", o.scope, labelScopeSubscription, labelScopeCluster)
+	}
+
+	if !modify {
+		return nil
+	}
+
+	key, value, hasValue := strings.Cut(args[1], "=")
+	if cmd.Name() == "add" && !hasValue {
+		return cmdutil.UsageErrorf(cmd, "expected key=value, got '%s'", args[1])
+	}
+	if cmd.Name() == "remove" && hasValue {
+		return cmdutil.UsageErrorf(cmd, "expected only the key of the label to remove, got '%s'", args[1])
+	}
+	if err := validateLabel(o.scope, key, value); err != nil {
+		return err
+	}
+	o.key = key
+	o.value = value
+
+	return nil
+}
+
+// validateLabel rejects reserved keys, and cluster labels that aren't valid kubernetes labels
+func validateLabel(scope, key, value string) error {
+	if key == "" || strings.TrimSpace(key) != key {
+		return fmt.Errorf("invalid label key '%s'", key)
+	}
+	if prefix := reservedLabelPrefix(key); prefix != "" {
+		return fmt.Errorf("label key '%s' uses the reserved prefix '%s', which is managed outside of osdctl", key, prefix)
+	}
+
+	if scope != labelScopeCluster {
+		return nil
+	}
+	// Cluster labels end up on kubernetes objects
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid cluster label key '%s': %s", key, strings.Join(errs, "; "))
+	}
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return fmt.Errorf("invalid cluster label value '%s': %s", value, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// reservedLabelPrefix returns the reserved prefix used by the key, if any.
+// Domains also reserve their subdomains, e.g. node-role.kubernetes.io/.
+func reservedLabelPrefix(key string) string {
+	domain := ""
+	if i := strings.Index(key, "/"); i >= 0 {
+		domain = key[:i]
+	}
+	for _, prefix := range reservedLabelPrefixes {
+		if strings.HasSuffix(prefix, ".") {
+			if strings.HasPrefix(key, prefix) {
+				return prefix
+			}
+			continue
+		}
+		if domain == prefix || strings.HasSuffix(domain, "."+prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+func (o *labelOptions) list() error {
+	connection, cluster, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	labels, err := listLabels(connection, cluster, o.scope)
+	if err != nil {
+		return err
+	}
+	return o.printLabels(labels)
+}
+
+func (o *labelOptions) add() error {
+	connection, cluster, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	labels, err := listLabels(connection, cluster, o.scope)
+	if err != nil {
+		return err
+	}
+	existing := findLabel(labels, o.key)
+
+	action := fmt.Sprintf("Add %s label '%s=%s'", o.scope, o.key, o.value)
+	if existing != nil {
+		action = fmt.Sprintf("Update %s label '%s' from '%s' to '%s'", o.scope, o.key, existing.Value, o.value)
+	}
+	after := setLabel(labels, clusterLabel{Scope: o.scope, Key: o.key, Value: o.value, Internal: o.internal && o.scope == labelScopeSubscription})
+	if proceed, err := o.confirm(connection, cluster, action, o.labelDiff(labels, after)); !proceed {
+		return err
+	}
+
+	if o.scope == labelScopeSubscription {
+		err = addSubscriptionLabel(connection, cluster.Subscription().ID(), o.key, o.value, o.internal, existing != nil)
+	} else {
+		err = addClusterLabel(connection, cluster.ID(), o.key, o.value, existing)
+	}
+	if err != nil {
+		return err
+	}
+
+	return o.printResult(connection, cluster)
+}
+
+func (o *labelOptions) remove() error {
+	connection, cluster, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer connection.Close()
+
+	labels, err := listLabels(connection, cluster, o.scope)
+	if err != nil {
+		return err
+	}
+	existing := findLabel(labels, o.key)
+	if existing == nil {
+		return fmt.Errorf("cluster %s has no %s label '%s'", cluster.ID(), o.scope, o.key)
+	}
+
+	after := unsetLabel(labels, o.scope, o.key)
+	if proceed, err := o.confirm(connection, cluster, fmt.Sprintf("Remove %s label '%s=%s'", o.scope, o.key, existing.Value), o.labelDiff(labels, after)); !proceed {
+		return err
+	}
+
+	if err := deleteLabel(connection, cluster, *existing); err != nil {
+		return err
+	}
+
+	return o.printResult(connection, cluster)
+}
+
+func (o *labelOptions) connect() (*sdk.Connection, *cmv1.Cluster, error) {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return nil, nil, err
+	}
+	connection := utils.CreateConnection()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		connection.Close()
+		return nil, nil, err
+	}
+	return connection, cluster, nil
+}
+
+// confirm shows the change and prompts, it returns whether to apply the change: not on dry-run nor when declined
+func (o *labelOptions) confirm(connection *sdk.Connection, cluster *cmv1.Cluster, action string, diff *printer.Diff) (bool, error) {
+	summary := utils.NewClusterImpactSummary(connection, cluster, action)
+	if o.dryRun {
+		if err := summary.Print(os.Stdout); err != nil {
+			return false, err
+		}
+		if err := diff.Print(os.Stdout); err != nil {
+			return false, err
+		}
+		fmt.Println("This is a dry run, nothing changed.")
+		return false, nil
+	}
+
+	err := utils.Confirm(utils.ConfirmOptions{
+		Summary:    summary,
+		Diff:       diff,
+		SkipPrompt: o.yes,
+	})
+	return err == nil, err
+}
+
+// labelDiff is the change to the labels of the scope
+func (o *labelOptions) labelDiff(before, after []clusterLabel) *printer.Diff {
+	return &printer.Diff{
+		Title:  fmt.Sprintf("Changes to the %s labels:", o.scope),
+		Before: scopeLabels(before, o.scope),
+		After:  scopeLabels(after, o.scope),
+	}
+}
+
+// setLabel returns the labels with the label added, or replacing the one with the same scope and key
+func setLabel(labels []clusterLabel, label clusterLabel) []clusterLabel {
+	result := append([]clusterLabel{label}, unsetLabel(labels, label.Scope, label.Key)...)
+	sortLabels(result)
+	return result
+}
+
+// unsetLabel returns the labels without the one with the scope and key
+func unsetLabel(labels []clusterLabel, scope, key string) []clusterLabel {
+	var result []clusterLabel
+	for _, label := range labels {
+		if label.Scope != scope || label.Key != key {
+			result = append(result, label)
+		}
+	}
+	return result
+}
+
+func scopeLabels(labels []clusterLabel, scope string) []clusterLabel {
+	result := []clusterLabel{}
+	for _, label := range labels {
+		if label.Scope == scope {
+			result = append(result, label)
+		}
+	}
+	return result
+}
+
+// printResult prints the resulting label set as JSON, so that scripts can check the outcome
+func (o *labelOptions) printResult(connection *sdk.Connection, cluster *cmv1.Cluster) error {
+	labels, err := listLabels(connection, cluster, o.scope)
+	if err != nil {
+		return err
+	}
+	return printLabelsJSON(labels)
+}
+
+func (o *labelOptions) printLabels(labels []clusterLabel) error {
+	if o.output == "json" {
+		return printLabelsJSON(labels)
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Scope", "Key", "Value", "Internal"})
+	for _, label := range labels {
+		table.AddRow([]string{label.Scope, label.Key, label.Value, fmt.Sprintf("%t", label.Internal)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	return table.Flush()
+}
+
+func printLabelsJSON(labels []clusterLabel) error {
+	if labels == nil {
+		labels = []clusterLabel{}
+	}
+	data, err := json.MarshalIndent(labels, "", "    ")
+	if err != nil {
+		return err
+	}
+	return printer.PrintJSON(printer.Tee(os.Stdout), data)
+}
+
+// listLabels returns the labels of the given scope, or of both scopes if empty, sorted by scope and key
+func listLabels(connection *sdk.Connection, cluster *cmv1.Cluster, scope string) ([]clusterLabel, error) {
+	var labels []clusterLabel
+
+	if scope == "" || scope == labelScopeSubscription {
+		response, err := connection.AccountsMgmt().V1().Subscriptions().Subscription(cluster.Subscription().ID()).Labels().List().Size(100).Send()
+		if err != nil {
+			return nil, fmt.Errorf("can't retrieve the subscription labels of cluster %s: %w", cluster.ID(), err)
+		}
+		response.Items().Each(func(label *amv1.Label) bool {
+			labels = append(labels, clusterLabel{Scope: labelScopeSubscription, Key: label.Key(), Value: label.Value(), Internal: label.Internal()})
+			return true
+		})
+	}
+
+	if scope == "" || scope == labelScopeCluster {
+		response, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).ExternalConfiguration().Labels().List().Size(100).Send()
+		if err != nil {
+			return nil, fmt.Errorf("can't retrieve the cluster labels of cluster %s: %w", cluster.ID(), err)
+		}
+		response.Items().Each(func(label *cmv1.Label) bool {
+			labels = append(labels, clusterLabel{Scope: labelScopeCluster, Key: label.Key(), Value: label.Value(), id: label.ID()})
+			return true
+		})
+	}
+
+	sortLabels(labels)
+	return labels, nil
+}
+
+func sortLabels(labels []clusterLabel) {
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Scope != labels[j].Scope {
+			return labels[i].Scope > labels[j].Scope
+		}
+		return labels[i].Key < labels[j].Key
+	})
+}
+
+func findLabel(labels []clusterLabel, key string) *clusterLabel {
+	for i := range labels {
+		if labels[i].Key == key {
+			return &labels[i]
+		}
+	}
+	return nil
+}
+
+func addSubscriptionLabel(connection *sdk.Connection, subscriptionID, key, value string, internal, exists bool) error {
+	label, err := amv1.NewLabel().Key(key).Value(value).Internal(internal).Build()
+	if err != nil {
+		return fmt.Errorf("cannot build subscription label: %w", err)
+	}
+
+	labels := connection.AccountsMgmt().V1().Subscriptions().Subscription(subscriptionID).Labels()
+	if exists {
+		_, err = labels.Label(key).Update().Body(label).Send()
+	} else {
+		_, err = labels.Add().Body(label).Send()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set subscription label '%s': %w", key, err)
+	}
+	return nil
+}
+
+func addClusterLabel(connection *sdk.Connection, clusterID, key, value string, existing *clusterLabel) error {
+	label, err := cmv1.NewLabel().Key(key).Value(value).Build()
+	if err != nil {
+		return fmt.Errorf("cannot build cluster label: %w", err)
+	}
+
+	labels := connection.ClustersMgmt().V1().Clusters().Cluster(clusterID).ExternalConfiguration().Labels()
+	if existing != nil {
+		_, err = labels.Label(existing.id).Update().Body(label).Send()
+	} else {
+		_, err = labels.Add().Body(label).Send()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set cluster label '%s': %w", key, err)
+	}
+	return nil
+}
+
+// deleteLabel removes the label, subscription labels are identified by their key and cluster labels by their ID
+func deleteLabel(connection *sdk.Connection, cluster *cmv1.Cluster, label clusterLabel) error {
+	var err error
+	if label.Scope == labelScopeSubscription {
+		_, err = connection.AccountsMgmt().V1().Subscriptions().Subscription(cluster.Subscription().ID()).Labels().Label(label.Key).Delete().Send()
+	} else {
+		_, err = connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).ExternalConfiguration().Labels().Label(label.id).Delete().Send()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove %s label '%s': %w", label.Scope, label.Key, err)
+	}
+	return nil
+}
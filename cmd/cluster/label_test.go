@@ -0,0 +1,134 @@
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+)
+
+func TestValidateLabel(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     string
+		key       string
+		value     string
+		expectErr string
+	}{
+		{
+			name:  "subscription label",
+			scope: labelScopeSubscription,
+			key:   "my.feature.opt-in",
+			value: "some value",
+		},
+		{
+			name:      "capability",
+			scope:     labelScopeSubscription,
+			key:       "capability.cluster.autoscale_clusters",
+			value:     "true",
+			expectErr: "reserved prefix 'capability.'",
+		},
+		{
+			name:      "reserved subdomain",
+			scope:     labelScopeCluster,
+			key:       "node-role.kubernetes.io/infra",
+			expectErr: "reserved prefix 'kubernetes.io'",
+		},
+		{
+			name:      "reserved domain",
+			scope:     labelScopeSubscription,
+			key:       "api.openshift.com/id",
+			expectErr: "reserved prefix 'api.openshift.com'",
+		},
+		{
+			name:  "domain merely containing a reserved one",
+			scope: labelScopeCluster,
+			key:   "example.com/kubernetes.io",
+			value: "true",
+		},
+		{
+			name:      "cluster label value isn't a valid kubernetes value",
+			scope:     labelScopeCluster,
+			key:       "example.com/feature",
+			value:     "some value",
+			expectErr: "invalid cluster label value",
+		},
+		{
+			name:      "empty key",
+			scope:     labelScopeSubscription,
+			expectErr: "invalid label key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			err := validateLabel(tt.scope, tt.key, tt.value)
+			if tt.expectErr == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.expectErr)))
+			}
+		})
+	}
+}
+
+func TestLabelComplete(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cmd := newCmdLabel(&globalflags.GlobalOptions{})
+	add, _, err := cmd.Find([]string{"add"})
+	g.Expect(err).NotTo(HaveOccurred())
+	remove, _, err := cmd.Find([]string{"remove"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ops := &labelOptions{GlobalOptions: &globalflags.GlobalOptions{}}
+	g.Expect(ops.complete(add, []string{"abc", "my.key=a=b"}, true)).To(Succeed())
+	g.Expect(ops.scope).To(Equal(labelScopeSubscription))
+	g.Expect(ops.key).To(Equal("my.key"))
+	g.Expect(ops.value).To(Equal("a=b"))
+
+	g.Expect(ops.complete(add, []string{"abc", "my.key"}, true)).To(MatchError(ContainSubstring("expected key=value")))
+	g.Expect(ops.complete(remove, []string{"abc", "my.key=true"}, true)).To(MatchError(ContainSubstring("expected only the key")))
+
+	ops = &labelOptions{GlobalOptions: &globalflags.GlobalOptions{}, scope: "org"}
+	g.Expect(ops.complete(add, []string{"abc", "my.key=true"}, true)).To(MatchError(ContainSubstring("invalid --scope 'org'")))
+}
+
+func TestSortLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+	labels := []clusterLabel{
+		{Scope: labelScopeCluster, Key: "b"},
+		{Scope: labelScopeSubscription, Key: "z"},
+		{Scope: labelScopeCluster, Key: "a"},
+		{Scope: labelScopeSubscription, Key: "c"},
+	}
+	sortLabels(labels)
+	g.Expect(labels).To(Equal([]clusterLabel{
+		{Scope: labelScopeSubscription, Key: "c"},
+		{Scope: labelScopeSubscription, Key: "z"},
+		{Scope: labelScopeCluster, Key: "a"},
+		{Scope: labelScopeCluster, Key: "b"},
+	}))
+	g.Expect(findLabel(labels, "a").Scope).To(Equal(labelScopeCluster))
+	g.Expect(findLabel(labels, "missing")).To(BeNil())
+}
+
+func TestSetAndUnsetLabel(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	labels := []clusterLabel{
+		{Scope: labelScopeSubscription, Key: "a", Value: "1"},
+		{Scope: labelScopeCluster, Key: "a", Value: "2"},
+	}
+
+	g.Expect(setLabel(labels, clusterLabel{Scope: labelScopeSubscription, Key: "a", Value: "3"})).To(Equal([]clusterLabel{
+		{Scope: labelScopeSubscription, Key: "a", Value: "3"},
+		{Scope: labelScopeCluster, Key: "a", Value: "2"},
+	}))
+	// Only the label of the scope is removed
+	g.Expect(unsetLabel(labels, labelScopeCluster, "a")).To(Equal([]clusterLabel{
+		{Scope: labelScopeSubscription, Key: "a", Value: "1"},
+	}))
+	g.Expect(scopeLabels(labels, labelScopeCluster)).To(Equal([]clusterLabel{
+		{Scope: labelScopeCluster, Key: "a", Value: "2"},
+	}))
+}
@@ -0,0 +1,354 @@
+package cluster
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/paging"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	listPageSize = 100
+
+	listExample = `
+  # List the ready clusters in us-east-1
+  osdctl cluster list --search "state='ready' and region.id='us-east-1'"
+
+  # Pick the columns, and export them as CSV
+  osdctl cluster list --search "product.id='rosa' and hypershift.enabled='true'" --columns id,name,version -o csv
+
+  # List the available columns
+  osdctl cluster list --list-columns
+`
+)
+
+// clusterColumns are the columns cluster list can print, from the OCM cluster object
+var clusterColumns = map[string]func(*cmv1.Cluster) string{
+	"id":             func(c *cmv1.Cluster) string { return c.ID() },
+	"external_id":    func(c *cmv1.Cluster) string { return c.ExternalID() },
+	"name":           func(c *cmv1.Cluster) string { return c.Name() },
+	"state":          func(c *cmv1.Cluster) string { return string(c.State()) },
+	"version":        func(c *cmv1.Cluster) string { return c.OpenshiftVersion() },
+	"region":         func(c *cmv1.Cluster) string { return c.Region().ID() },
+	"cloud_provider": func(c *cmv1.Cluster) string { return c.CloudProvider().ID() },
+	"product":        func(c *cmv1.Cluster) string { return c.Product().ID() },
+	"hypershift":     func(c *cmv1.Cluster) string { return fmt.Sprint(c.Hypershift().Enabled()) },
+	"ccs":            func(c *cmv1.Cluster) string { return fmt.Sprint(c.CCS().Enabled()) },
+	"multi_az":       func(c *cmv1.Cluster) string { return fmt.Sprint(c.MultiAZ()) },
+	"api_listening":  func(c *cmv1.Cluster) string { return string(c.API().Listening()) },
+	"api_url":        func(c *cmv1.Cluster) string { return c.API().URL() },
+	"console_url":    func(c *cmv1.Cluster) string { return c.Console().URL() },
+	"created":        func(c *cmv1.Cluster) string { return c.CreationTimestamp().UTC().Format("2006-01-02T15:04:05Z") },
+	"subscription":   func(c *cmv1.Cluster) string { return c.Subscription().ID() },
+}
+
+var defaultListColumns = []string{"id", "name", "state", "version", "region", "product"}
+
+type listOptions struct {
+	search      string
+	columns     []string
+	limit       int
+	listColumns bool
+	output      string
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// NewCmdList implements the cluster list command, also run as osdctl get clusters
+func NewCmdList(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &listOptions{GlobalOptions: globalOpts}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists the OCM clusters matching a search query",
+		Long: `Lists the OCM clusters matching a search query, in the OCM search syntax, fetching every page of results.
+
+  The pages are fetched --ocm-page-concurrency at a time and printed as they arrive, in order, the table is aligned
+  page by page. The OCM requests are rate limited like the other commands (see --ocm-rate-limit). The output format
+  is a table by default, or json, csv, go-template=... and jsonpath=... with -o, the templates and --filter are
+  applied once every page has been fetched.`,
+		Example:           listExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	listCmd.Flags().StringVar(&ops.search, "search", "", "OCM search query, e.g. \"state='ready' and region.id='us-east-1'\"")
+	listCmd.Flags().StringSliceVar(&ops.columns, "columns", defaultListColumns, "Comma separated columns to print")
+	listCmd.Flags().IntVar(&ops.limit, "limit", 0, "Maximum number of clusters to list, 0 for all")
+	listCmd.Flags().BoolVar(&ops.listColumns, "list-columns", false, "Print the available columns and exit")
+
+	return listCmd
+}
+
+func (o *listOptions) complete(cmd *cobra.Command) error {
+	o.output = o.GlobalOptions.Output
+	switch o.output {
+	case "", "json", "csv":
+	default:
+		if !outputflag.IsTemplate(o.output) {
+			return cmdutil.UsageErrorf(cmd, "invalid output format '%s', expected 'json', 'csv', 'go-template=...' or 'jsonpath=...'", o.output)
+		}
+	}
+	if o.limit < 0 {
+		return cmdutil.UsageErrorf(cmd, "--limit must be positive")
+	}
+	for i, column := range o.columns {
+		o.columns[i] = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := clusterColumns[o.columns[i]]; !ok {
+			return cmdutil.UsageErrorf(cmd, "unknown column '%s', the available columns are: %s", column, strings.Join(columnNames(), ", "))
+		}
+	}
+	return nil
+}
+
+func (o *listOptions) run() error {
+	if o.listColumns {
+		fmt.Println(strings.Join(columnNames(), "\n"))
+		return nil
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	writer := newClusterListWriter(printer.Tee(os.Stdout), o.output, o.columns)
+	count := 0
+	err := streamClusters(connection, o.search, o.limit, func(clusters []*cmv1.Cluster) error {
+		count += len(clusters)
+		return writer.write(clusterRows(clusters, o.columns))
+	})
+	if err != nil {
+		return err
+	}
+	if err := writer.close(); err != nil {
+		return err
+	}
+	if o.output == "" {
+		fmt.Fprintf(os.Stderr, "%d clusters\n", count)
+	}
+	return nil
+}
+
+// searchClusters fetches every page of the clusters matching the search, or the first limit ones
+func searchClusters(connection *sdk.Connection, search string, limit int) ([]*cmv1.Cluster, error) {
+	var clusters []*cmv1.Cluster
+	err := streamClusters(connection, search, limit, func(page []*cmv1.Cluster) error {
+		clusters = append(clusters, page...)
+		return nil
+	})
+	return clusters, err
+}
+
+// streamClusters fetches the pages of the clusters matching the search concurrently, see --ocm-page-concurrency, and
+// hands them over to emit in order as they arrive
+func streamClusters(connection *sdk.Connection, search string, limit int, emit func([]*cmv1.Cluster) error) error {
+	fetch := func(ctx context.Context, page int) (paging.Page[*cmv1.Cluster], error) {
+		request := connection.ClustersMgmt().V1().Clusters().List().Size(listPageSize).Order("name asc").Page(page)
+		if search != "" {
+			request.Search(search)
+		}
+		response, err := request.SendContext(ctx)
+		if err != nil {
+			return paging.Page[*cmv1.Cluster]{}, fmt.Errorf("cannot search the clusters: %w", err)
+		}
+		if page == 1 && response.Total() > listPageSize {
+			fmt.Fprintf(os.Stderr, "Fetching %d clusters, %d per page, %d pages at a time\n", response.Total(), listPageSize, paging.Concurrency())
+		}
+		return paging.Page[*cmv1.Cluster]{Items: response.Items().Slice(), Total: response.Total()}, nil
+	}
+	return paging.Fetch(deadline.Context(), paging.Options{PageSize: listPageSize, Limit: limit}, fetch, emit)
+}
+
+func clusterRows(clusters []*cmv1.Cluster, columns []string) [][]string {
+	rows := make([][]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = clusterColumns[column](cluster)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// clusterObjects keys the values of the rows by their column, for the json and template outputs
+func clusterObjects(columns []string, rows [][]string) []map[string]string {
+	objects := make([]map[string]string, 0, len(rows))
+	for _, row := range rows {
+		object := map[string]string{}
+		for i, column := range columns {
+			object[column] = row[i]
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// clusterListWriter prints the rows of cluster list as the pages arrive
+type clusterListWriter interface {
+	write(rows [][]string) error
+	close() error
+}
+
+func newClusterListWriter(w io.Writer, output string, columns []string) clusterListWriter {
+	switch {
+	case output == "csv":
+		return &csvListWriter{writer: csv.NewWriter(w), columns: columns}
+	case output == "json" && !printer.FilterEnabled():
+		return &jsonListWriter{w: w, columns: columns}
+	case output == "":
+		return newTableListWriter(w, columns)
+	}
+	// The templates and --filter apply to the whole list
+	return &bufferedListWriter{w: w, output: output, columns: columns}
+}
+
+// tableListWriter flushes the table at every page, like kubectl get does with chunks, the columns are aligned within
+// a page
+type tableListWriter struct {
+	table interface {
+		AddRow(row []string)
+		Flush() error
+	}
+}
+
+func newTableListWriter(w io.Writer, columns []string) *tableListWriter {
+	table := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+	}
+	table.AddRow(header)
+	return &tableListWriter{table: table}
+}
+
+func (t *tableListWriter) write(rows [][]string) error {
+	for _, row := range rows {
+		t.table.AddRow(row)
+	}
+	return t.table.Flush()
+}
+
+func (t *tableListWriter) close() error {
+	// Add empty row for readability
+	t.table.AddRow([]string{})
+	return t.table.Flush()
+}
+
+type csvListWriter struct {
+	writer     *csv.Writer
+	columns    []string
+	headerDone bool
+}
+
+func (c *csvListWriter) write(rows [][]string) error {
+	if !c.headerDone {
+		if err := c.writer.Write(c.columns); err != nil {
+			return err
+		}
+		c.headerDone = true
+	}
+	if err := c.writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return c.writer.Error()
+}
+
+func (c *csvListWriter) close() error {
+	return c.write(nil)
+}
+
+// jsonListWriter writes the elements of the JSON array as they arrive, indented like json.MarshalIndent
+type jsonListWriter struct {
+	w       io.Writer
+	columns []string
+	started bool
+}
+
+func (j *jsonListWriter) write(rows [][]string) error {
+	for _, object := range clusterObjects(j.columns, rows) {
+		data, err := json.MarshalIndent(object, "    ", "    ")
+		if err != nil {
+			return err
+		}
+		separator := ",\n    "
+		if !j.started {
+			separator = "[\n    "
+			j.started = true
+		}
+		if _, err := fmt.Fprintf(j.w, "%s%s", separator, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *jsonListWriter) close() error {
+	if !j.started {
+		_, err := fmt.Fprintln(j.w, "[]")
+		return err
+	}
+	_, err := fmt.Fprintln(j.w, "\n]")
+	return err
+}
+
+type bufferedListWriter struct {
+	w       io.Writer
+	output  string
+	columns []string
+	rows    [][]string
+}
+
+func (b *bufferedListWriter) write(rows [][]string) error {
+	b.rows = append(b.rows, rows...)
+	return nil
+}
+
+func (b *bufferedListWriter) close() error {
+	if b.output == "json" {
+		return writeClustersJSON(b.w, b.columns, b.rows)
+	}
+	return outputflag.PrintTemplate(b.w, b.output, clusterObjects(b.columns, b.rows))
+}
+
+func writeClustersJSON(w io.Writer, columns []string, rows [][]string) error {
+	data, err := json.MarshalIndent(clusterObjects(columns, rows), "", "    ")
+	if err != nil {
+		return err
+	}
+	return printer.PrintJSON(w, data)
+}
+
+func writeClustersCSV(w io.Writer, columns []string, rows [][]string) error {
+	writer := &csvListWriter{writer: csv.NewWriter(w), columns: columns}
+	if err := writer.write(rows); err != nil {
+		return err
+	}
+	return writer.close()
+}
+
+func columnNames() []string {
+	names := make([]string, 0, len(clusterColumns))
+	for name := range clusterColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
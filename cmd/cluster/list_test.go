@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func TestClusterRowsOutput(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cluster, err := cmv1.NewCluster().
+		ID("abc123").
+		Name("my, cluster").
+		State(cmv1.ClusterStateReady).
+		Region(cmv1.NewCloudRegion().ID("us-east-1")).
+		Hypershift(cmv1.NewHypershift().Enabled(true)).
+		Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	columns := []string{"id", "name", "state", "region", "hypershift"}
+	rows := clusterRows([]*cmv1.Cluster{cluster}, columns)
+	g.Expect(rows).To(Equal([][]string{{"abc123", "my, cluster", "ready", "us-east-1", "true"}}))
+
+	var csvOutput bytes.Buffer
+	g.Expect(writeClustersCSV(&csvOutput, columns, rows)).To(Succeed())
+	g.Expect(csvOutput.String()).To(Equal("id,name,state,region,hypershift\nabc123,\"my, cluster\",ready,us-east-1,true\n"))
+
+	var jsonOutput bytes.Buffer
+	g.Expect(writeClustersJSON(&jsonOutput, columns[:2], [][]string{{"abc123", "my, cluster"}})).To(Succeed())
+	g.Expect(jsonOutput.String()).To(MatchJSON(`[{"id":"abc123","name":"my, cluster"}]`))
+
+	var empty bytes.Buffer
+	g.Expect(writeClustersJSON(&empty, columns, nil)).To(Succeed())
+	g.Expect(empty.String()).To(MatchJSON(`[]`))
+
+	g.Expect(clusterObjects(columns[:2], [][]string{{"abc123", "my, cluster"}})).To(Equal([]map[string]string{{"id": "abc123", "name": "my, cluster"}}))
+}
+
+func TestDefaultListColumnsExist(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, column := range defaultListColumns {
+		g.Expect(clusterColumns).To(HaveKey(column))
+	}
+	g.Expect(columnNames()).To(HaveLen(len(clusterColumns)))
+}
+
+func TestClusterListWritersStream(t *testing.T) {
+	g := NewGomegaWithT(t)
+	columns := []string{"id", "name"}
+	pages := [][][]string{{{"a1", "first"}, {"a2", "second"}}, {{"a3", "third"}}}
+
+	var streamed, buffered bytes.Buffer
+	writer := newClusterListWriter(&streamed, "json", columns)
+	for _, page := range pages {
+		g.Expect(writer.write(page)).To(Succeed())
+	}
+	g.Expect(writer.close()).To(Succeed())
+	g.Expect(writeClustersJSON(&buffered, columns, append(pages[0], pages[1]...))).To(Succeed())
+	g.Expect(streamed.String()).To(Equal(buffered.String()))
+
+	var empty bytes.Buffer
+	writer = newClusterListWriter(&empty, "json", columns)
+	g.Expect(writer.close()).To(Succeed())
+	g.Expect(empty.String()).To(Equal("[]\n"))
+
+	var csvOutput bytes.Buffer
+	writer = newClusterListWriter(&csvOutput, "csv", columns)
+	for _, page := range pages {
+		g.Expect(writer.write(page)).To(Succeed())
+	}
+	g.Expect(writer.close()).To(Succeed())
+	g.Expect(csvOutput.String()).To(Equal("id,name\na1,first\na2,second\na3,third\n"))
+
+	var table bytes.Buffer
+	writer = newClusterListWriter(&table, "", columns)
+	g.Expect(writer.write(pages[0])).To(Succeed())
+	// The first page is printed before the next one arrives
+	g.Expect(table.String()).To(ContainSubstring("a2"))
+	g.Expect(writer.write(pages[1])).To(Succeed())
+	g.Expect(writer.close()).To(Succeed())
+	g.Expect(table.String()).To(HavePrefix("ID"))
+	g.Expect(table.String()).To(ContainSubstring("a3"))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
@@ -35,8 +36,8 @@ func newCmdLoggingCheck(streams genericclioptions.IOStreams, flags *genericcliop
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	loggingCheckCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
@@ -62,7 +63,7 @@ func (o *loggingCheckOptions) complete(cmd *cobra.Command, args []string) error
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(o.ErrOut, "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 
@@ -89,7 +90,7 @@ func (o *loggingCheckOptions) run() error {
 	// Send the request to retrieve the list of external cluster labels:
 	response, err := resource.List().Send()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't retrieve cluster labels: %v\n", err)
+		fmt.Fprintf(o.ErrOut, "Can't retrieve cluster labels: %v\n", err)
 		os.Exit(1)
 	}
 
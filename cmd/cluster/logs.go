@@ -0,0 +1,244 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	logsLong = `Prints the logs of the pods matching a label selector in several clusters at once, e.g. to watch an
+operator across a canary set during a rollout. Every line is prefixed with the name of its cluster, and with the
+pod and container it comes from.
+
+  Every cluster is logged in to through backplane ('ocm backplane login') in a kubeconfig of its own, so the
+  current kubeconfig is left untouched. A cluster which can't be logged in to, or whose logs can't be read, is
+  reported without stopping the others.`
+
+	logsExample = `
+  # Follow the logs of the managed upgrade operator in three clusters
+  osdctl cluster logs cluster-a cluster-b cluster-c -n openshift-managed-upgrade-operator -l name=managed-upgrade-operator --follow
+
+  # The last hour of the logs of a container in one cluster
+  osdctl cluster logs 1kfmyclusteristhebesteverp8m -n openshift-monitoring -l app.kubernetes.io/name=prometheus -c prometheus --since 1h
+`
+)
+
+// logStreamer streams the logs of a cluster with the oc logs arguments until ctx is done or the logs end
+type logStreamer func(ctx context.Context, cluster *cmv1.Cluster, args []string, stdout, stderr io.Writer) error
+
+type logsOptions struct {
+	clusterIDs     []string
+	namespace      string
+	selector       string
+	container      string
+	follow         bool
+	since          time.Duration
+	tail           int
+	maxLogRequests int
+
+	streamLogs logStreamer
+	out        io.Writer
+	errOut     io.Writer
+}
+
+func newCmdLogs() *cobra.Command {
+	ops := &logsOptions{streamLogs: streamBackplaneLogs, out: os.Stdout, errOut: os.Stderr}
+	logsCmd := &cobra.Command{
+		Use:               "logs CLUSTER_ID... -n NAMESPACE -l SELECTOR",
+		Short:             "Print or follow the logs of pods in several clusters at once",
+		Long:              logsLong,
+		Example:           logsExample,
+		Args:              cobra.MinimumNArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	logsCmd.Flags().StringVarP(&ops.namespace, "namespace", "n", "", "Namespace of the pods")
+	logsCmd.Flags().StringVarP(&ops.selector, "selector", "l", "", "Label selector of the pods, e.g. name=managed-upgrade-operator")
+	logsCmd.Flags().StringVarP(&ops.container, "container", "c", "", "Only print the logs of this container, all the containers by default")
+	logsCmd.Flags().BoolVarP(&ops.follow, "follow", "f", false, "Keep streaming the logs until interrupted")
+	logsCmd.Flags().DurationVar(&ops.since, "since", 0, "Only print the logs newer than this duration, e.g. 1h")
+	logsCmd.Flags().IntVar(&ops.tail, "tail", 10, "Lines of the recent logs of every container to print, -1 for all of them")
+	logsCmd.Flags().IntVar(&ops.maxLogRequests, "max-log-requests", 10, "Maximum number of containers followed at once in every cluster")
+	_ = logsCmd.MarkFlagRequired("namespace")
+	_ = logsCmd.MarkFlagRequired("selector")
+
+	return logsCmd
+}
+
+func (o *logsOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.maxLogRequests < 1 {
+		return cmdutil.UsageErrorf(cmd, "--max-log-requests must be at least 1")
+	}
+	if o.since < 0 {
+		return cmdutil.UsageErrorf(cmd, "--since can't be negative")
+	}
+	for _, clusterID := range args {
+		if err := utils.IsValidClusterKey(clusterID); err != nil {
+			return err
+		}
+	}
+	o.clusterIDs = args
+	return nil
+}
+
+// ocLogsArgs returns the arguments of oc logs, --since is given to oc in seconds as it doesn't take every Go duration
+func (o *logsOptions) ocLogsArgs() []string {
+	args := []string{"logs", "-n", o.namespace, "-l", o.selector, "--prefix", "--tail", strconv.Itoa(o.tail),
+		"--max-log-requests", strconv.Itoa(o.maxLogRequests)}
+	if o.container != "" {
+		args = append(args, "-c", o.container)
+	} else {
+		args = append(args, "--all-containers")
+	}
+	if o.follow {
+		args = append(args, "--follow")
+	}
+	if o.since > 0 {
+		args = append(args, fmt.Sprintf("--since=%ds", int(o.since.Seconds())))
+	}
+	return args
+}
+
+func (o *logsOptions) run() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	clusters := make([]*cmv1.Cluster, 0, len(o.clusterIDs))
+	for _, clusterID := range o.clusterIDs {
+		cluster, err := utils.GetCluster(connection, clusterID)
+		if err != nil {
+			return err
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	ctx, stop := signal.NotifyContext(deadline.Context(), os.Interrupt)
+	defer stop()
+	return o.tailLogs(ctx, clusters)
+}
+
+// tailLogs streams the logs of every cluster at once, it returns an error when the logs of a cluster couldn't be read
+func (o *logsOptions) tailLogs(ctx context.Context, clusters []*cmv1.Cluster) error {
+	width := 0
+	for _, cluster := range clusters {
+		if len(cluster.Name()) > width {
+			width = len(cluster.Name())
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failed []string
+	args := o.ocLogsArgs()
+	for _, cluster := range clusters {
+		wg.Add(1)
+		go func(cluster *cmv1.Cluster) {
+			defer wg.Done()
+			prefix := fmt.Sprintf("[%-*s] ", width, cluster.Name())
+			stdout := &prefixWriter{mu: &mu, out: o.out, prefix: prefix}
+			stderr := &prefixWriter{mu: &mu, out: o.errOut, prefix: prefix}
+			err := o.streamLogs(ctx, cluster, args, stdout, stderr)
+			stdout.Flush()
+			stderr.Flush()
+			// Interrupting a followed stream isn't a failure
+			if err != nil && ctx.Err() == nil {
+				mu.Lock()
+				fmt.Fprintf(o.errOut, "%serror: %v\n", prefix, err)
+				failed = append(failed, cluster.Name())
+				mu.Unlock()
+			}
+		}(cluster)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("cannot read the logs of %d of %d clusters: %s", len(failed), len(clusters), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// streamBackplaneLogs logs in to the cluster through backplane in a kubeconfig of its own, and runs oc logs with it
+func streamBackplaneLogs(ctx context.Context, cluster *cmv1.Cluster, args []string, stdout, stderr io.Writer) error {
+	if err := readonly.CheckOC(args); err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "osdctl-logs-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	env := append(os.Environ(), "KUBECONFIG="+filepath.Join(dir, "config"))
+
+	login := exec.CommandContext(ctx, "ocm", "backplane", "login", cluster.ID()) //#nosec G204 -- the cluster ID comes from OCM
+	login.Env = env
+	if output, err := login.CombinedOutput(); err != nil {
+		return fmt.Errorf("ocm backplane login failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	logs := exec.CommandContext(ctx, "oc", args...) //#nosec G204 -- arguments are built by the command
+	logs.Env = env
+	logs.Stdout = stdout
+	logs.Stderr = stderr
+	if err := logs.Run(); err != nil {
+		return fmt.Errorf("oc logs failed: %w", err)
+	}
+	return nil
+}
+
+// prefixWriter writes every line with a prefix, holding the lock shared by all the clusters so that lines don't mix
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		w.mu.Lock()
+		_, err := fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf[:i])
+		w.mu.Unlock()
+		w.buf = w.buf[i+1:]
+		if err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes the last line when it doesn't end with a newline
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	w.mu.Lock()
+	fmt.Fprintf(w.out, "%s%s\n", w.prefix, w.buf)
+	w.mu.Unlock()
+	w.buf = nil
+}
@@ -0,0 +1,85 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func TestOCLogsArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	o := &logsOptions{namespace: "openshift-monitoring", selector: "app=prometheus", tail: 10, maxLogRequests: 5}
+	g.Expect(o.ocLogsArgs()).To(Equal([]string{"logs", "-n", "openshift-monitoring", "-l", "app=prometheus", "--prefix",
+		"--tail", "10", "--max-log-requests", "5", "--all-containers"}))
+
+	o.container, o.follow, o.since = "prometheus", true, time.Hour
+	args := o.ocLogsArgs()
+	g.Expect(args[len(args)-4:]).To(Equal([]string{"-c", "prometheus", "--follow", "--since=3600s"}))
+}
+
+func TestPrefixWriter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var out bytes.Buffer
+	w := &prefixWriter{mu: &sync.Mutex{}, out: &out, prefix: "[a] "}
+
+	_, _ = w.Write([]byte("first\nsec"))
+	g.Expect(out.String()).To(Equal("[a] first\n"))
+	_, _ = w.Write([]byte("ond\nthird"))
+	w.Flush()
+	g.Expect(out.String()).To(Equal("[a] first\n[a] second\n[a] third\n"))
+}
+
+func TestTailLogs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var out, errOut bytes.Buffer
+	o := &logsOptions{
+		namespace: "ns", selector: "app=x", tail: 10, maxLogRequests: 5, out: &out, errOut: &errOut,
+		streamLogs: func(_ context.Context, cluster *cmv1.Cluster, args []string, stdout, _ io.Writer) error {
+			if cluster.Name() == "broken" {
+				return errors.New("ocm backplane login failed")
+			}
+			for i := 1; i <= 3; i++ {
+				fmt.Fprintf(stdout, "[pod/x/c] line %d\n", i)
+			}
+			return nil
+		},
+	}
+	canary, _ := cmv1.NewCluster().ID("1").Name("canary").Build()
+	longerName, _ := cmv1.NewCluster().ID("2").Name("canary-2").Build()
+	broken, _ := cmv1.NewCluster().ID("3").Name("broken").Build()
+
+	err := o.tailLogs(context.Background(), []*cmv1.Cluster{canary, longerName, broken})
+	g.Expect(err).To(MatchError("cannot read the logs of 1 of 3 clusters: broken"))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	g.Expect(lines).To(HaveLen(6))
+	g.Expect(lines).To(ContainElements("[canary  ] [pod/x/c] line 1", "[canary-2] [pod/x/c] line 3"))
+	g.Expect(errOut.String()).To(Equal("[broken  ] error: ocm backplane login failed\n"))
+}
+
+func TestTailLogsInterrupted(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	var errOut bytes.Buffer
+	o := &logsOptions{out: io.Discard, errOut: &errOut,
+		streamLogs: func(ctx context.Context, _ *cmv1.Cluster, _ []string, _, _ io.Writer) error {
+			cancel()
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	cluster, _ := cmv1.NewCluster().ID("1").Name("canary").Build()
+
+	g.Expect(o.tailLogs(ctx, []*cmv1.Cluster{cluster})).To(Succeed())
+	g.Expect(errOut.String()).To(BeEmpty())
+}
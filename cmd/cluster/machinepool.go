@@ -0,0 +1,461 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/checkpoint"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/poll"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	// hiveMachinePoolLabel is set by hive on the machine sets of a machine pool
+	hiveMachinePoolLabel = "hive.openshift.io/machine-pool"
+	machineSetLabel      = "machine.openshift.io/cluster-api-machineset"
+	machineAPINamespace  = "openshift-machine-api"
+
+	machinePoolMigrateLong = `Moves the workloads of a machine pool to a new instance type, e.g. to Graviton or spot instances.
+
+  The migration creates a replacement machine pool with the new instance type and the replicas, autoscaling, labels
+  and taints of the old one, waits for its nodes to be Ready, cordons and drains the nodes of the old pool, then
+  deletes the old pool. Moving to an instance type of another architecture, e.g. Graviton, requires a multi-arch
+  release payload.
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'), the oc commands are
+  run as backplane-cluster-admin. The progress is saved to a checkpoint after every step: when the migration is
+  interrupted or a step fails, running the same command again resumes where it stopped. Only classic clusters are
+  supported, the node pools of hosted control planes are managed differently.`
+
+	machinePoolMigrateExample = `
+  # Move the worker pool to Graviton instances
+  osdctl cluster machinepool migrate 1kfmyclusteristhebesteverp8m --to-instance-type m6g.xlarge
+
+  # Move the infra-like "batch" pool to spot instances, paying at most 0.10 USD an hour
+  osdctl cluster machinepool migrate 1kfmyclusteristhebesteverp8m --pool batch --to-instance-type m5.xlarge --spot --spot-max-price 0.10
+`
+
+	migrateStepCreate   = "create"
+	migrateStepCapacity = "capacity"
+	migrateStepCordon   = "cordon"
+	// migrateStepDrain is recorded once every node of the old pool was drained, each node is recorded as drain/NODE
+	migrateStepDrain  = "drain"
+	migrateStepDelete = "delete"
+)
+
+type machinePoolMigrateOptions struct {
+	clusterID       string
+	pool            string
+	newPool         string
+	instanceType    string
+	spot            bool
+	spotMaxPrice    float64
+	capacityTimeout time.Duration
+	drainTimeout    time.Duration
+	skipPrompts     bool
+	checkpoint      checkpoint.Flags
+
+	run      utils.OCRunner
+	interval time.Duration
+}
+
+func newCmdMachinePool() *cobra.Command {
+	machinePoolCmd := &cobra.Command{
+		Use:               "machinepool",
+		Short:             "Manages the machine pools of a cluster",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	machinePoolCmd.AddCommand(newCmdMachinePoolMigrate())
+	return machinePoolCmd
+}
+
+func newCmdMachinePoolMigrate() *cobra.Command {
+	ops := &machinePoolMigrateOptions{run: utils.RunOCAsClusterAdmin, interval: 15 * time.Second}
+	migrateCmd := &cobra.Command{
+		Use:               "migrate CLUSTER_ID",
+		Short:             "Moves a machine pool to another instance type through a replacement pool",
+		Long:              machinePoolMigrateLong,
+		Example:           machinePoolMigrateExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.runMigrate())
+		},
+	}
+	migrateCmd.Flags().StringVar(&ops.pool, "pool", "worker", "Machine pool to migrate")
+	migrateCmd.Flags().StringVar(&ops.instanceType, "to-instance-type", "", "Instance type of the replacement pool, e.g. m6g.xlarge")
+	migrateCmd.Flags().StringVar(&ops.newPool, "new-pool", "", "Name of the replacement pool (default: the old name and the new instance family, e.g. worker-m6g)")
+	migrateCmd.Flags().BoolVar(&ops.spot, "spot", false, "Use AWS spot instances for the replacement pool")
+	migrateCmd.Flags().Float64Var(&ops.spotMaxPrice, "spot-max-price", 0, "Maximum hourly price of the spot instances, in USD (default: the on-demand price)")
+	migrateCmd.Flags().DurationVar(&ops.capacityTimeout, "capacity-timeout", 30*time.Minute, "How long to wait for the nodes of the replacement pool to be Ready")
+	migrateCmd.Flags().DurationVar(&ops.drainTimeout, "drain-timeout", 10*time.Minute, "How long to wait for a node of the old pool to be drained")
+	migrateCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+	ops.checkpoint.AddFlags(migrateCmd)
+	_ = migrateCmd.MarkFlagRequired("to-instance-type")
+
+	return migrateCmd
+}
+
+func (o *machinePoolMigrateOptions) complete(cmd *cobra.Command, args []string) error {
+	o.clusterID = args[0]
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	if o.spotMaxPrice < 0 {
+		return cmdutil.UsageErrorf(cmd, "--spot-max-price can't be negative")
+	}
+	if o.spotMaxPrice > 0 && !o.spot {
+		return cmdutil.UsageErrorf(cmd, "--spot-max-price requires --spot")
+	}
+	if o.newPool == "" {
+		o.newPool = replacementPoolName(o.pool, o.instanceType)
+	}
+	if o.newPool == o.pool {
+		return cmdutil.UsageErrorf(cmd, "--new-pool must differ from --pool")
+	}
+	return nil
+}
+
+// replacementPoolName returns the old pool name followed by the instance family, e.g. worker-m6g
+func replacementPoolName(pool, instanceType string) string {
+	family := strings.ToLower(strings.SplitN(instanceType, ".", 2)[0])
+	return pool + "-" + family
+}
+
+func (o *machinePoolMigrateOptions) runMigrate() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s has a hosted control plane, its node pools can't be migrated with this command", cluster.ID())
+	}
+	if o.spot && strings.ToUpper(cluster.CloudProvider().ID()) != "AWS" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "spot instances are only available for AWS clusters")
+	}
+	if err := utils.CheckOCCluster(o.run, cluster); err != nil {
+		return err
+	}
+
+	pools := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).MachinePools()
+	progress, err := o.checkpoint.Open("cluster machinepool migrate", cluster.ID(), o.pool, o.newPool, o.instanceType)
+	if err != nil {
+		return err
+	}
+	if progress.Resumed() {
+		fmt.Fprintf(os.Stderr, "Resuming the migration saved in %s\n", progress.Path())
+	}
+
+	if progress.Done(migrateStepDelete) {
+		fmt.Fprintf(os.Stderr, "Machine pool %s was already migrated to %s\n", o.pool, o.newPool)
+		return progress.Complete()
+	}
+	old, err := pools.MachinePool(o.pool).Get().Send()
+	if err != nil {
+		return fmt.Errorf("cannot get machine pool %s: %w", o.pool, err)
+	}
+	if old.Body().InstanceType() == o.instanceType && !o.spot {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "machine pool %s already uses %s", o.pool, o.instanceType)
+	}
+
+	multiArch, err := releasePayloadMultiArch(o.run)
+	if err != nil {
+		return fmt.Errorf("cannot get the architecture of the release payload: %w", err)
+	}
+	if err := validateInstanceArchitecture(cluster.CloudProvider().ID(), old.Body().InstanceType(), o.instanceType, multiArch); err != nil {
+		return err
+	}
+
+	target := o.instanceType
+	if o.spot {
+		target += ", spot"
+	}
+	action := fmt.Sprintf("Replace machine pool %s (%s) with %s (%s), then drain and delete %s",
+		o.pool, old.Body().InstanceType(), o.newPool, target, o.pool)
+	if arch := instanceArchitecture(cluster.CloudProvider().ID(), o.instanceType); arch != instanceArchitecture(cluster.CloudProvider().ID(), old.Body().InstanceType()) {
+		fmt.Fprintf(os.Stderr, "%s is an %s instance type: the workloads moved to it need %s images\n", o.instanceType, arch, arch)
+	}
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, action),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := o.step(progress, migrateStepCreate, func() error { return o.createReplacement(pools, old.Body()) }); err != nil {
+		return err
+	}
+	if err := o.step(progress, migrateStepCapacity, o.waitForCapacity); err != nil {
+		return err
+	}
+	if err := o.drainOldPool(progress); err != nil {
+		return err
+	}
+	if err := o.step(progress, migrateStepDelete, func() error {
+		fmt.Fprintf(os.Stderr, "Deleting machine pool %s\n", o.pool)
+		_, err := pools.MachinePool(o.pool).Delete().Send()
+		return err
+	}); err != nil {
+		return err
+	}
+
+	utils.RecordClusterAction(connection, cluster, fmt.Sprintf("Migrate machine pool %s to %s (%s)", o.pool, o.newPool, target))
+	fmt.Fprintf(os.Stderr, "Machine pool %s was migrated to %s (%s)\n", o.pool, o.newPool, o.instanceType)
+	return progress.Complete()
+}
+
+// step runs a step of the migration unless a previous run completed it, and saves its outcome
+func (o *machinePoolMigrateOptions) step(progress *checkpoint.Checkpoint, name string, run func() error) error {
+	if progress.Done(name) {
+		return nil
+	}
+	err := run()
+	if recordErr := progress.Record(name, err); recordErr != nil {
+		return fmt.Errorf("cannot save the migration progress: %w", recordErr)
+	}
+	if err != nil {
+		return fmt.Errorf("%w, run the same command again to resume the migration (progress saved in %s)", err, progress.Path())
+	}
+	return nil
+}
+
+func (o *machinePoolMigrateOptions) createReplacement(pools *cmv1.MachinePoolsClient, old *cmv1.MachinePool) error {
+	// A previous run may have created the pool without recording it
+	existing, err := pools.MachinePool(o.newPool).Get().Send()
+	switch {
+	case err == nil && existing.Body().InstanceType() != o.instanceType:
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "machine pool %s already exists with instance type %s, pass another --new-pool",
+			o.newPool, existing.Body().InstanceType())
+	case err == nil:
+		fmt.Fprintf(os.Stderr, "Machine pool %s already exists\n", o.newPool)
+		return nil
+	case existing == nil || existing.Status() != http.StatusNotFound:
+		return fmt.Errorf("cannot get machine pool %s: %w", o.newPool, err)
+	}
+
+	pool, err := replacementPool(old, o.newPool, o.instanceType, o.spot, o.spotMaxPrice).Build()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Creating machine pool %s with instance type %s\n", o.newPool, o.instanceType)
+	if _, err := pools.Add().Body(pool).Send(); err != nil {
+		return fmt.Errorf("cannot create machine pool %s: %w", o.newPool, err)
+	}
+	return nil
+}
+
+// replacementPool copies the sizing, labels and taints of the old pool, and its subnet when it is a single-AZ pool
+// of a multi-AZ cluster
+func replacementPool(old *cmv1.MachinePool, name, instanceType string, spot bool, spotMaxPrice float64) *cmv1.MachinePoolBuilder {
+	builder := cmv1.NewMachinePool().ID(name).InstanceType(instanceType)
+	if autoscaling, ok := old.GetAutoscaling(); ok {
+		builder.Autoscaling(cmv1.NewMachinePoolAutoscaling().MinReplicas(autoscaling.MinReplicas()).MaxReplicas(autoscaling.MaxReplicas()))
+	} else {
+		builder.Replicas(old.Replicas())
+	}
+	if len(old.Labels()) > 0 {
+		builder.Labels(old.Labels())
+	}
+	var taints []*cmv1.TaintBuilder
+	for _, taint := range old.Taints() {
+		taints = append(taints, cmv1.NewTaint().Key(taint.Key()).Value(taint.Value()).Effect(taint.Effect()))
+	}
+	if len(taints) > 0 {
+		builder.Taints(taints...)
+	}
+	if len(old.Subnets()) == 1 {
+		builder.Subnets(old.Subnets()...)
+	}
+	if spot {
+		options := cmv1.NewAWSSpotMarketOptions()
+		if spotMaxPrice > 0 {
+			options.MaxPrice(spotMaxPrice)
+		}
+		builder.AWS(cmv1.NewAWSMachinePool().SpotMarketOptions(options))
+	}
+	return builder
+}
+
+// machineSet is the part of a machine API MachineSet the migration reads
+type machineSet struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Replicas *int `json:"replicas"`
+	} `json:"spec"`
+	Status struct {
+		ReadyReplicas int `json:"readyReplicas"`
+	} `json:"status"`
+}
+
+// machine is the part of a machine API Machine the migration reads
+type machine struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Status struct {
+		Phase        string `json:"phase"`
+		ErrorMessage string `json:"errorMessage"`
+		NodeRef      *struct {
+			Name string `json:"name"`
+		} `json:"nodeRef"`
+	} `json:"status"`
+}
+
+func poolMachineSets(run utils.OCRunner, pool string) ([]machineSet, error) {
+	output, err := run("get", "machinesets.machine.openshift.io", "-n", machineAPINamespace, "-l", hiveMachinePoolLabel+"="+pool, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []machineSet `json:"items"`
+	}
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("cannot parse the machine sets of machine pool %s: %w", pool, err)
+	}
+	return list.Items, nil
+}
+
+func machineSetMachines(run utils.OCRunner, sets []machineSet) ([]machine, error) {
+	var machines []machine
+	for _, set := range sets {
+		output, err := run("get", "machines.machine.openshift.io", "-n", machineAPINamespace, "-l", machineSetLabel+"="+set.Metadata.Name, "-o", "json")
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Items []machine `json:"items"`
+		}
+		if err := json.Unmarshal(output, &list); err != nil {
+			return nil, fmt.Errorf("cannot parse the machines of machine set %s: %w", set.Metadata.Name, err)
+		}
+		machines = append(machines, list.Items...)
+	}
+	return machines, nil
+}
+
+// poolCapacity reports whether every machine set of the pool has all its replicas Ready, with the status to print
+// while waiting. The machines failing, e.g. for lack of spot capacity, are listed in the status.
+func poolCapacity(sets []machineSet, machines []machine) (bool, string) {
+	if len(sets) == 0 {
+		return false, "the machine sets of the pool weren't created yet"
+	}
+	desired, ready := 0, 0
+	for _, set := range sets {
+		replicas := 0
+		if set.Spec.Replicas != nil {
+			replicas = *set.Spec.Replicas
+		}
+		desired += replicas
+		ready += set.Status.ReadyReplicas
+	}
+	status := fmt.Sprintf("%d of %d nodes Ready", ready, desired)
+	var failed []string
+	for _, m := range machines {
+		if m.Status.Phase == "Failed" {
+			failed = append(failed, fmt.Sprintf("%s: %s", m.Metadata.Name, m.Status.ErrorMessage))
+		}
+	}
+	if len(failed) > 0 {
+		status += ", failed machines: " + strings.Join(failed, "; ")
+	}
+	return ready >= desired, status
+}
+
+// poolNodes returns the nodes of the machines of the pool
+func poolNodes(machines []machine) []string {
+	var nodes []string
+	for _, m := range machines {
+		if m.Status.NodeRef != nil && m.Status.NodeRef.Name != "" {
+			nodes = append(nodes, m.Status.NodeRef.Name)
+		}
+	}
+	return nodes
+}
+
+func (o *machinePoolMigrateOptions) waitForCapacity() error {
+	fmt.Fprintf(os.Stderr, "Waiting up to %s for the nodes of machine pool %s to be Ready\n", o.capacityTimeout, o.newPool)
+	opts := poll.Options{Description: "the nodes of machine pool " + o.newPool + " to be Ready", Timeout: o.capacityTimeout, Interval: o.interval}
+	return poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
+		sets, err := poolMachineSets(o.run, o.newPool)
+		if err != nil {
+			return false, fmt.Sprintf("the machine sets can't be retrieved: %v", err), nil
+		}
+		machines, err := machineSetMachines(o.run, sets)
+		if err != nil {
+			return false, fmt.Sprintf("the machines can't be retrieved: %v", err), nil
+		}
+		done, status := poolCapacity(sets, machines)
+		return done, status, nil
+	})
+}
+
+// drainOldPool cordons every node of the old pool first, so that the evicted pods don't land on its other nodes,
+// then drains them one at a time
+func (o *machinePoolMigrateOptions) drainOldPool(progress *checkpoint.Checkpoint) error {
+	if progress.Done(migrateStepDrain) {
+		return nil
+	}
+	sets, err := poolMachineSets(o.run, o.pool)
+	if err != nil {
+		return err
+	}
+	machines, err := machineSetMachines(o.run, sets)
+	if err != nil {
+		return err
+	}
+	nodes := poolNodes(machines)
+
+	if err := o.step(progress, migrateStepCordon, func() error {
+		for _, node := range nodes {
+			fmt.Fprintf(os.Stderr, "[%s] Cordoning\n", node)
+			if _, err := o.run("adm", "cordon", node); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, node := range progress.Pending(drainSteps(nodes)) {
+		name := strings.TrimPrefix(node, migrateStepDrain+"/")
+		if err := o.step(progress, node, func() error {
+			fmt.Fprintf(os.Stderr, "[%s] Draining, waiting up to %s\n", name, o.drainTimeout)
+			_, err := o.run("adm", "drain", name, "--ignore-daemonsets", "--delete-emptydir-data", fmt.Sprintf("--timeout=%s", o.drainTimeout))
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	return o.step(progress, migrateStepDrain, func() error { return nil })
+}
+
+// drainSteps returns the checkpoint steps of the nodes to drain
+func drainSteps(nodes []string) []string {
+	steps := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		steps = append(steps, migrateStepDrain+"/"+node)
+	}
+	return steps
+}
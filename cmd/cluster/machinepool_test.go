@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func TestReplacementPool(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(replacementPoolName("worker", "m6g.xlarge")).To(Equal("worker-m6g"))
+	g.Expect(gravitonInstanceType.MatchString("m6g.xlarge")).To(BeTrue())
+	g.Expect(gravitonInstanceType.MatchString("c7gn.2xlarge")).To(BeTrue())
+	g.Expect(gravitonInstanceType.MatchString("m5.xlarge")).To(BeFalse())
+	g.Expect(gravitonInstanceType.MatchString("g4dn.xlarge")).To(BeFalse())
+
+	old, err := cmv1.NewMachinePool().ID("batch").InstanceType("m5.xlarge").
+		Autoscaling(cmv1.NewMachinePoolAutoscaling().MinReplicas(2).MaxReplicas(6)).
+		Labels(map[string]string{"team": "data"}).
+		Taints(cmv1.NewTaint().Key("dedicated").Value("batch").Effect("NoSchedule")).
+		Subnets("subnet-1").
+		Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	pool, err := replacementPool(old, "batch-m6g", "m6g.xlarge", true, 0.1).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pool.ID()).To(Equal("batch-m6g"))
+	g.Expect(pool.InstanceType()).To(Equal("m6g.xlarge"))
+	g.Expect(pool.Autoscaling().MinReplicas()).To(Equal(2))
+	g.Expect(pool.Autoscaling().MaxReplicas()).To(Equal(6))
+	g.Expect(pool.Labels()).To(Equal(map[string]string{"team": "data"}))
+	g.Expect(pool.Taints()).To(HaveLen(1))
+	g.Expect(pool.Subnets()).To(Equal([]string{"subnet-1"}))
+	g.Expect(pool.AWS().SpotMarketOptions().MaxPrice()).To(Equal(0.1))
+
+	old, err = cmv1.NewMachinePool().ID("worker").InstanceType("m5.xlarge").Replicas(3).Subnets("subnet-1", "subnet-2").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	pool, err = replacementPool(old, "worker-m6g", "m6g.xlarge", false, 0).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pool.Replicas()).To(Equal(3))
+	g.Expect(pool.Subnets()).To(BeEmpty())
+	_, ok := pool.GetAWS()
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestPoolCapacity(t *testing.T) {
+	g := NewGomegaWithT(t)
+	set := func(name string, replicas, ready int) machineSet {
+		s := machineSet{}
+		s.Metadata.Name = name
+		s.Spec.Replicas = &replicas
+		s.Status.ReadyReplicas = ready
+		return s
+	}
+
+	done, status := poolCapacity(nil, nil)
+	g.Expect(done).To(BeFalse())
+	g.Expect(status).To(ContainSubstring("weren't created"))
+
+	failed := machine{}
+	failed.Metadata.Name = "worker-m6g-a-1"
+	failed.Status.Phase = "Failed"
+	failed.Status.ErrorMessage = "InsufficientInstanceCapacity"
+	done, status = poolCapacity([]machineSet{set("a", 2, 1), set("b", 1, 1)}, []machine{failed})
+	g.Expect(done).To(BeFalse())
+	g.Expect(status).To(Equal("2 of 3 nodes Ready, failed machines: worker-m6g-a-1: InsufficientInstanceCapacity"))
+
+	done, _ = poolCapacity([]machineSet{set("a", 2, 2), set("b", 1, 1)}, nil)
+	g.Expect(done).To(BeTrue())
+}
+
+// fakeMachineAPI answers the oc commands listing the machine sets and machines of the old pool, and records the
+// cordons and drains
+type fakeMachineAPI struct {
+	calls     []string
+	failDrain string
+}
+
+func (f *fakeMachineAPI) run(args ...string) ([]byte, error) {
+	command := strings.Join(args, " ")
+	switch {
+	case strings.HasPrefix(command, "get machinesets.machine.openshift.io"):
+		return []byte(`{"items": [{"metadata": {"name": "mycluster-worker-us-east-1a"}, "spec": {"replicas": 2}}]}`), nil
+	case strings.HasPrefix(command, "get machines.machine.openshift.io"):
+		return []byte(`{"items": [
+			{"metadata": {"name": "m1"}, "status": {"nodeRef": {"name": "ip-10-0-1-1"}}},
+			{"metadata": {"name": "m2"}, "status": {"nodeRef": {"name": "ip-10-0-1-2"}}},
+			{"metadata": {"name": "m3"}, "status": {"phase": "Provisioning"}}
+		]}`), nil
+	}
+	f.calls = append(f.calls, strings.Join(args[:3], " "))
+	if args[1] == "drain" && args[2] == f.failDrain {
+		return nil, errors.New("drain timed out")
+	}
+	return nil, nil
+}
+
+func TestDrainOldPoolResumes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	api := &fakeMachineAPI{failDrain: "ip-10-0-1-2"}
+	o := &machinePoolMigrateOptions{pool: "worker", run: api.run}
+	o.checkpoint.Path = filepath.Join(t.TempDir(), "migrate.json")
+
+	progress, err := o.checkpoint.Open("cluster machinepool migrate", "abc123", "worker", "worker-m6g", "m6g.xlarge")
+	g.Expect(err).NotTo(HaveOccurred())
+	err = o.drainOldPool(progress)
+	g.Expect(err).To(MatchError(ContainSubstring("run the same command again")))
+	g.Expect(api.calls).To(Equal([]string{"adm cordon ip-10-0-1-1", "adm cordon ip-10-0-1-2", "adm drain ip-10-0-1-1", "adm drain ip-10-0-1-2"}))
+
+	// The next run only drains the node that failed
+	api.calls, api.failDrain = nil, ""
+	progress, err = o.checkpoint.Open("cluster machinepool migrate", "abc123", "worker", "worker-m6g", "m6g.xlarge")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(progress.Resumed()).To(BeTrue())
+	g.Expect(o.drainOldPool(progress)).To(Succeed())
+	g.Expect(api.calls).To(Equal([]string{"adm drain ip-10-0-1-2"}))
+	g.Expect(progress.Done(migrateStepDrain)).To(BeTrue())
+}
@@ -0,0 +1,487 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/guardrails"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	muoLong = `Diagnoses the managed upgrades of a cluster, run by the managed-upgrade-operator (MUO).
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'), the commands are
+  run as backplane-cluster-admin. The cluster the current kubeconfig points to is checked against CLUSTER_ID.`
+
+	muoStatusLong = `Shows the UpgradeConfig of the cluster: the desired version, when the upgrade is scheduled, the phase of the
+  upgrade and its conditions, with the failed pre-checks first.
+
+  A failed pre-check which can be overridden is reported with the 'osdctl cluster muo skip-check' command to run.`
+
+	muoSkipCheckLong = `Applies a supported override of the managed-upgrade-operator, so that a stuck upgrade can go on without a
+  pre-check. The supported checks are:
+
+  * capacity-reservation: the extra worker nodes scaled up before upgrading the workers, set to false in the
+    UpgradeConfig
+  * critical-alerts: the critical alerts failing the health check before the upgrade, the alerts given with --alert
+    are added to the ignored critical alerts of the managed-upgrade-operator configuration
+
+  Requires a justification with --reason, recorded in the audit log, and a confirmation.`
+
+	muoExample = `
+  # The managed upgrade of a cluster and its failed pre-checks
+  osdctl cluster muo status 1kfmyclusteristhebesteverp8m
+
+  # Don't scale up extra worker nodes before upgrading the workers
+  osdctl cluster muo skip-check 1kfmyclusteristhebesteverp8m capacity-reservation --reason "no quota for extra nodes, OHSS-1234"
+
+  # Ignore a critical alert firing because of a known bug during the pre-upgrade health check
+  osdctl cluster muo skip-check 1kfmyclusteristhebesteverp8m critical-alerts --alert KubePersistentVolumeErrors --reason "OHSS-1234"
+`
+
+	muoNamespace = "openshift-managed-upgrade-operator"
+	// muoConfigMap holds the configuration of the managed-upgrade-operator, as YAML under muoConfigKey
+	muoConfigMap = "managed-upgrade-operator-config"
+	muoConfigKey = "config.yaml"
+
+	muoCheckCapacityReservation = "capacity-reservation"
+	muoCheckCriticalAlerts      = "critical-alerts"
+)
+
+// muoCheckConditions are the UpgradeConfig conditions of the pre-checks which can be overridden
+var muoCheckConditions = map[string]string{
+	"UpgradeScaleUpExtraNodes": muoCheckCapacityReservation,
+	"UpgradePreHealthCheck":    muoCheckCriticalAlerts,
+}
+
+type muoOptions struct {
+	clusterID string
+	check     string
+	alerts    []string
+	yes       bool
+
+	runOC         utils.OCRunner
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// upgradeConfig is the subset of an UpgradeConfig of the managed-upgrade-operator read by the commands
+type upgradeConfig struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Type                string `json:"type"`
+		UpgradeAt           string `json:"upgradeAt"`
+		CapacityReservation bool   `json:"capacityReservation"`
+		Desired             struct {
+			Version string `json:"version"`
+			Channel string `json:"channel"`
+		} `json:"desired"`
+	} `json:"spec"`
+	Status struct {
+		History []upgradeHistory `json:"history"`
+	} `json:"status"`
+}
+
+type upgradeHistory struct {
+	Version      string         `json:"version"`
+	Phase        string         `json:"phase"`
+	StartTime    string         `json:"startTime"`
+	CompleteTime string         `json:"completeTime"`
+	Conditions   []upgradeCheck `json:"conditions"`
+}
+
+type upgradeCheck struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Override is the skip-check override of a failed pre-check, when there is one
+	Override string `json:"override,omitempty"`
+}
+
+type muoStatusResponse struct {
+	ClusterID           string         `json:"cluster_id"`
+	UpgradeConfig       string         `json:"upgrade_config"`
+	Type                string         `json:"type"`
+	DesiredVersion      string         `json:"desired_version"`
+	Channel             string         `json:"channel"`
+	UpgradeAt           string         `json:"upgrade_at"`
+	CapacityReservation bool           `json:"capacity_reservation"`
+	Phase               string         `json:"phase"`
+	StartTime           string         `json:"start_time,omitempty"`
+	CompleteTime        string         `json:"complete_time,omitempty"`
+	FailedChecks        []upgradeCheck `json:"failed_checks"`
+	Conditions          []upgradeCheck `json:"conditions"`
+	IgnoredCriticals    []string       `json:"ignored_critical_alerts"`
+}
+
+func (r muoStatusResponse) String() string {
+	var b bytes.Buffer
+	if r.UpgradeConfig == "" {
+		fmt.Fprintf(&b, "No managed upgrade is scheduled for cluster %s\n", r.ClusterID)
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "UpgradeConfig: %s (%s)\n", r.UpgradeConfig, r.Type)
+	fmt.Fprintf(&b, "Desired version: %s (channel %s), scheduled at %s\n", r.DesiredVersion, r.Channel, r.UpgradeAt)
+	phase := r.Phase
+	if r.StartTime != "" {
+		phase += ", started at " + r.StartTime
+	}
+	if r.CompleteTime != "" {
+		phase += ", completed at " + r.CompleteTime
+	}
+	fmt.Fprintf(&b, "Phase: %s\n", phase)
+	fmt.Fprintf(&b, "Capacity reservation: %t\n", r.CapacityReservation)
+	if len(r.IgnoredCriticals) > 0 {
+		fmt.Fprintf(&b, "Ignored critical alerts: %s\n", strings.Join(r.IgnoredCriticals, ", "))
+	}
+	fmt.Fprintln(&b)
+
+	if len(r.FailedChecks) > 0 {
+		fmt.Fprintf(&b, "Failed pre-checks:\n")
+		for _, check := range r.FailedChecks {
+			if check.Override != "" {
+				fmt.Fprintf(&b, "  %s can be skipped with 'osdctl cluster muo skip-check %s %s'\n", check.Type, r.ClusterID, check.Override)
+			}
+		}
+	}
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"CONDITION", "STATUS", "REASON", "MESSAGE"})
+	for _, condition := range r.Conditions {
+		table.AddRow([]string{condition.Type, condition.Status, condition.Reason, truncateMessage(condition.Message)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the conditions: %v", err)
+	}
+	return b.String()
+}
+
+func newCmdMUO(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	muoCmd := &cobra.Command{
+		Use:               "muo",
+		Short:             "Diagnoses and overrides the managed upgrades of a cluster",
+		Long:              muoLong,
+		Example:           muoExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run:               help,
+	}
+
+	ops := &muoOptions{runOC: utils.RunOCAsClusterAdmin, GlobalOptions: globalOpts}
+	statusCmd := &cobra.Command{
+		Use:               "status CLUSTER_ID",
+		Short:             "Shows the managed upgrade of a cluster and its failed pre-checks",
+		Long:              muoStatusLong,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.status())
+		},
+	}
+
+	skipCheckCmd := &cobra.Command{
+		Use:               "skip-check CLUSTER_ID CHECK",
+		Short:             "Overrides a pre-check of the managed upgrade of a cluster",
+		Long:              muoSkipCheckLong,
+		Args:              cobra.ExactArgs(2),
+		ValidArgs:         []string{muoCheckCapacityReservation, muoCheckCriticalAlerts},
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID, ops.check = args[0], args[1]
+			osdctlErrors.CheckErr(ops.completeSkipCheck(cmd))
+			osdctlErrors.CheckErr(ops.skipCheck(cmd))
+		},
+	}
+	skipCheckCmd.Flags().StringSliceVar(&ops.alerts, "alert", nil, "Critical alerts to ignore, for the critical-alerts check")
+	skipCheckCmd.Flags().BoolVarP(&ops.yes, "yes", "y", false, "Skip the confirmation prompt")
+
+	muoCmd.AddCommand(statusCmd, skipCheckCmd)
+	return muoCmd
+}
+
+func (o *muoOptions) completeSkipCheck(cmd *cobra.Command) error {
+	switch o.check {
+	case muoCheckCapacityReservation:
+		if len(o.alerts) > 0 {
+			return cmdutil.UsageErrorf(cmd, "--alert is only allowed with the %s check", muoCheckCriticalAlerts)
+		}
+	case muoCheckCriticalAlerts:
+		if len(o.alerts) == 0 {
+			return cmdutil.UsageErrorf(cmd, "the %s check requires the alerts to ignore with --alert", muoCheckCriticalAlerts)
+		}
+	default:
+		return cmdutil.UsageErrorf(cmd, "unsupported check '%s', expected %s or %s", o.check, muoCheckCapacityReservation, muoCheckCriticalAlerts)
+	}
+	if guardrails.Reason(cmd) == "" {
+		return cmdutil.UsageErrorf(cmd, "overriding a pre-check requires a justification with --%s", guardrails.ReasonFlag)
+	}
+	return utils.IsValidClusterKey(o.clusterID)
+}
+
+func (o *muoOptions) status() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s has a hosted control plane, its upgrades aren't run by the managed-upgrade-operator", cluster.ID())
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	response, err := o.inspect()
+	if err != nil {
+		return err
+	}
+	response.ClusterID = cluster.ID()
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// inspect reads the UpgradeConfig and the ignored critical alerts, the UpgradeConfig is empty when no upgrade is
+// scheduled
+func (o *muoOptions) inspect() (muoStatusResponse, error) {
+	response := muoStatusResponse{FailedChecks: []upgradeCheck{}, Conditions: []upgradeCheck{}, IgnoredCriticals: []string{}}
+
+	config, err := o.upgradeConfig()
+	if err != nil || config == nil {
+		return response, err
+	}
+	response.UpgradeConfig = config.Metadata.Name
+	response.Type = config.Spec.Type
+	response.DesiredVersion = config.Spec.Desired.Version
+	response.Channel = config.Spec.Desired.Channel
+	response.UpgradeAt = config.Spec.UpgradeAt
+	response.CapacityReservation = config.Spec.CapacityReservation
+
+	response.Phase = "Pending"
+	if history := currentUpgrade(config); history != nil {
+		response.Phase, response.StartTime, response.CompleteTime = history.Phase, history.StartTime, history.CompleteTime
+		response.Conditions = sortUpgradeChecks(history.Conditions)
+		for _, condition := range response.Conditions {
+			if condition.Status == string(corev1.ConditionFalse) {
+				response.FailedChecks = append(response.FailedChecks, condition)
+			}
+		}
+	}
+
+	muoConfig, err := o.muoConfig()
+	if err != nil {
+		return response, err
+	}
+	response.IgnoredCriticals = ignoredCriticals(muoConfig)
+	return response, nil
+}
+
+// currentUpgrade returns the history entry of the desired version, nil before the upgrade started
+func currentUpgrade(config *upgradeConfig) *upgradeHistory {
+	for i := range config.Status.History {
+		if config.Status.History[i].Version == config.Spec.Desired.Version {
+			return &config.Status.History[i]
+		}
+	}
+	return nil
+}
+
+// sortUpgradeChecks puts the failed pre-checks first, keeping the order of the operator otherwise, and sets the
+// override of the ones which can be skipped
+func sortUpgradeChecks(conditions []upgradeCheck) []upgradeCheck {
+	checks := make([]upgradeCheck, 0, len(conditions))
+	for _, condition := range conditions {
+		if condition.Status == string(corev1.ConditionFalse) {
+			condition.Override = muoCheckConditions[condition.Type]
+		}
+		checks = append(checks, condition)
+	}
+	sort.SliceStable(checks, func(i, j int) bool {
+		return checks[i].Status == string(corev1.ConditionFalse) && checks[j].Status != string(corev1.ConditionFalse)
+	})
+	return checks
+}
+
+func (o *muoOptions) upgradeConfig() (*upgradeConfig, error) {
+	output, err := o.runOC("get", "upgradeconfigs", "-n", muoNamespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var configs struct {
+		Items []upgradeConfig `json:"items"`
+	}
+	if err := json.Unmarshal(output, &configs); err != nil {
+		return nil, fmt.Errorf("cannot parse the upgrade configs: %w", err)
+	}
+	if len(configs.Items) == 0 {
+		return nil, nil
+	}
+	return &configs.Items[0], nil
+}
+
+// muoConfig returns the configuration of the managed-upgrade-operator, parsed from its config map
+func (o *muoOptions) muoConfig() (map[string]interface{}, error) {
+	output, err := o.runOC("get", "configmap", muoConfigMap, "-n", muoNamespace, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var configMap corev1.ConfigMap
+	if err := json.Unmarshal(output, &configMap); err != nil {
+		return nil, fmt.Errorf("cannot parse the %s config map: %w", muoConfigMap, err)
+	}
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(configMap.Data[muoConfigKey]), &config); err != nil {
+		return nil, fmt.Errorf("cannot parse the configuration of the managed-upgrade-operator: %w", err)
+	}
+	return config, nil
+}
+
+// ignoredCriticals returns the critical alerts the health check of the managed-upgrade-operator ignores
+func ignoredCriticals(config map[string]interface{}) []string {
+	alerts := []string{}
+	healthCheck, _ := config["healthCheck"].(map[string]interface{})
+	ignored, _ := healthCheck["ignoredCriticals"].([]interface{})
+	for _, alert := range ignored {
+		if name, ok := alert.(string); ok {
+			alerts = append(alerts, name)
+		}
+	}
+	return alerts
+}
+
+// withIgnoredCriticals returns a copy of the configuration ignoring the alerts too
+func withIgnoredCriticals(config map[string]interface{}, alerts []string) map[string]interface{} {
+	updated := map[string]interface{}{}
+	for key, value := range config {
+		updated[key] = value
+	}
+	healthCheck := map[string]interface{}{}
+	if current, ok := config["healthCheck"].(map[string]interface{}); ok {
+		for key, value := range current {
+			healthCheck[key] = value
+		}
+	}
+
+	ignored := []interface{}{}
+	for _, alert := range sets.NewString(ignoredCriticals(config)...).Insert(alerts...).List() {
+		ignored = append(ignored, alert)
+	}
+	healthCheck["ignoredCriticals"] = ignored
+	updated["healthCheck"] = healthCheck
+	return updated
+}
+
+func (o *muoOptions) skipCheck(cmd *cobra.Command) error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s has a hosted control plane, its upgrades aren't run by the managed-upgrade-operator", cluster.ID())
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	action, diff, patch, err := o.override()
+	if err != nil {
+		return err
+	}
+	if err := utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, action),
+		Diff:       diff,
+		SkipPrompt: o.yes,
+	}); err != nil {
+		return err
+	}
+	if _, err := o.runOC(patch...); err != nil {
+		return fmt.Errorf("failed to override the %s check: %w", o.check, err)
+	}
+
+	if err := guardrails.WriteAuditRecord(guardrails.AuditRecord{
+		Command:     cmd.CommandPath(),
+		Args:        append([]string{o.clusterID, o.check}, o.alerts...),
+		Environment: utils.GetCurrentOCMEnv(connection),
+		Reason:      guardrails.Reason(cmd),
+		Ticket:      flagString(cmd, guardrails.TicketFlag),
+		Cluster:     cluster.ID(),
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to record the override in the audit log: %v\n", err)
+	}
+	utils.RecordClusterAction(connection, cluster, action)
+
+	fmt.Fprintf(os.Stderr, "The %s check is overridden, follow the upgrade with 'osdctl cluster muo status %s'\n", o.check, cluster.ID())
+	return nil
+}
+
+// override returns the action, the change and the oc arguments applying the override of the check
+func (o *muoOptions) override() (string, *printer.Diff, []string, error) {
+	switch o.check {
+	case muoCheckCapacityReservation:
+		config, err := o.upgradeConfig()
+		if err != nil {
+			return "", nil, nil, err
+		}
+		if config == nil {
+			return "", nil, nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "no managed upgrade is scheduled for cluster %s", o.clusterID)
+		}
+		if !config.Spec.CapacityReservation {
+			return "", nil, nil, osdctlErrors.New(osdctlErrors.ErrValidation, "the capacity reservation is already disabled in UpgradeConfig %s", config.Metadata.Name)
+		}
+		diff := &printer.Diff{
+			Title:  "UpgradeConfig " + config.Metadata.Name,
+			Before: map[string]bool{"capacityReservation": true},
+			After:  map[string]bool{"capacityReservation": false},
+		}
+		patch := []string{"patch", "upgradeconfig", config.Metadata.Name, "-n", muoNamespace, "--type", "merge",
+			"-p", `{"spec":{"capacityReservation":false}}`}
+		return "Upgrade the workers without scaling up extra nodes", diff, patch, nil
+
+	case muoCheckCriticalAlerts:
+		config, err := o.muoConfig()
+		if err != nil {
+			return "", nil, nil, err
+		}
+		updated := withIgnoredCriticals(config, o.alerts)
+		data, err := yaml.Marshal(updated)
+		if err != nil {
+			return "", nil, nil, err
+		}
+		patch, err := json.Marshal(map[string]interface{}{"data": map[string]string{muoConfigKey: string(data)}})
+		if err != nil {
+			return "", nil, nil, err
+		}
+		diff := &printer.Diff{
+			Title:  "ConfigMap " + muoConfigMap,
+			Before: map[string][]string{"ignoredCriticals": ignoredCriticals(config)},
+			After:  map[string][]string{"ignoredCriticals": ignoredCriticals(updated)},
+		}
+		args := []string{"patch", "configmap", muoConfigMap, "-n", muoNamespace, "--type", "merge", "-p", string(patch)}
+		return fmt.Sprintf("Ignore the critical alerts %s in the pre-upgrade health check", strings.Join(o.alerts, ", ")), diff, args, nil
+	}
+	return "", nil, nil, fmt.Errorf("unsupported check '%s'", o.check)
+}
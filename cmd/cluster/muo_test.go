@@ -0,0 +1,86 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+)
+
+const testUpgradeConfigs = `{"items": [{"metadata": {"name": "managed-upgrade-config"},
+  "spec": {"type": "OSD", "upgradeAt": "2026-10-14T09:00:00Z", "capacityReservation": true, "desired": {"version": "4.14.5", "channel": "stable-4.14"}},
+  "status": {"history": [
+    {"version": "4.14.5", "phase": "Upgrading", "startTime": "2026-10-14T09:00:05Z", "conditions": [
+      {"type": "StartedNotificationSent", "status": "True", "reason": "StartedNotificationSent"},
+      {"type": "UpgradeScaleUpExtraNodes", "status": "False", "reason": "ScaleUpFailed", "message": "extra nodes were not ready in time"}
+    ]},
+    {"version": "4.14.1", "phase": "Upgraded"}
+  ]}}]}`
+
+const testMUOConfigMap = `{"metadata": {"name": "managed-upgrade-operator-config"}, "data": {"config.yaml": "healthCheck:\n  ignoredCriticals:\n  - PrometheusRuleFailures\n  ignoredNamespaces:\n  - openshift-logging\nupgradeType: OSD\n"}}`
+
+func muoRunner(configs string) func(args ...string) ([]byte, error) {
+	return func(args ...string) ([]byte, error) {
+		switch strings.Join(args, " ") {
+		case "get upgradeconfigs -n openshift-managed-upgrade-operator -o json":
+			return []byte(configs), nil
+		case "get configmap managed-upgrade-operator-config -n openshift-managed-upgrade-operator -o json":
+			return []byte(testMUOConfigMap), nil
+		}
+		return nil, fmt.Errorf("unexpected oc %s", strings.Join(args, " "))
+	}
+}
+
+func TestMUOInspect(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := &muoOptions{runOC: muoRunner(testUpgradeConfigs)}
+
+	response, err := o.inspect()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(response.UpgradeConfig).To(Equal("managed-upgrade-config"))
+	g.Expect(response.DesiredVersion).To(Equal("4.14.5"))
+	g.Expect(response.Phase).To(Equal("Upgrading"))
+	g.Expect(response.IgnoredCriticals).To(Equal([]string{"PrometheusRuleFailures"}))
+
+	// The failed pre-checks first, with their override
+	g.Expect(response.Conditions).To(HaveLen(2))
+	g.Expect(response.Conditions[0].Type).To(Equal("UpgradeScaleUpExtraNodes"))
+	g.Expect(response.FailedChecks).To(HaveLen(1))
+	g.Expect(response.FailedChecks[0].Override).To(Equal(muoCheckCapacityReservation))
+	response.ClusterID = "1kfmyclusteristhebesteverp8m"
+	g.Expect(response.String()).To(ContainSubstring("osdctl cluster muo skip-check 1kfmyclusteristhebesteverp8m capacity-reservation"))
+
+	o = &muoOptions{runOC: muoRunner(`{"items": []}`)}
+	response, err = o.inspect()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(response.UpgradeConfig).To(BeEmpty())
+}
+
+func TestMUOOverride(t *testing.T) {
+	g := NewGomegaWithT(t)
+	o := &muoOptions{clusterID: "1kfmyclusteristhebesteverp8m", check: muoCheckCapacityReservation, runOC: muoRunner(testUpgradeConfigs)}
+
+	_, _, patch, err := o.override()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(strings.Join(patch, " ")).To(Equal(`patch upgradeconfig managed-upgrade-config -n openshift-managed-upgrade-operator --type merge -p {"spec":{"capacityReservation":false}}`))
+
+	o.check = muoCheckCriticalAlerts
+	o.alerts = []string{"KubePersistentVolumeErrors", "PrometheusRuleFailures"}
+	_, diff, patch, err := o.override()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(diff.After).To(Equal(map[string][]string{"ignoredCriticals": {"KubePersistentVolumeErrors", "PrometheusRuleFailures"}}))
+
+	// The rest of the configuration is kept
+	var configMap struct {
+		Data map[string]string `json:"data"`
+	}
+	g.Expect(json.Unmarshal([]byte(patch[len(patch)-1]), &configMap)).To(Succeed())
+	config := map[string]interface{}{}
+	g.Expect(yaml.Unmarshal([]byte(configMap.Data[muoConfigKey]), &config)).To(Succeed())
+	g.Expect(config).To(HaveKeyWithValue("upgradeType", "OSD"))
+	g.Expect(config["healthCheck"]).To(HaveKeyWithValue("ignoredNamespaces", []interface{}{"openshift-logging"}))
+	g.Expect(ignoredCriticals(config)).To(Equal([]string{"KubePersistentVolumeErrors", "PrometheusRuleFailures"}))
+}
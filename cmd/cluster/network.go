@@ -0,0 +1,622 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/utils/strings/slices"
+)
+
+const (
+	networkDiffLongDescription = `
+Compares the network of an AWS cluster with the one the installer creates for its version and topology, to find the
+customer modifications which justify a misconfiguration limited support reason:
+
+  * Security groups of the cluster: the rules the cluster needs which were removed, the ingress rules from outside the
+    VPC or from other security groups which were added, and the allow-all egress rule
+  * Network ACLs of the cluster subnets: the deny rules which were added, and the allow-all rules which were removed
+  * Route tables of the cluster subnets: the default route, which must exist and be active, and for clusters in a VPC
+    created by the installer the routes which were added or changed
+
+The security groups and route tables of BYOVPC clusters belong to the customer: only the default route of their route
+tables is checked.
+`
+	networkDiffExample = `
+  # Compare the network of a cluster with its baseline
+  osdctl cluster network diff 1kfmyclusteristhebesteverp8m
+
+  # As JSON, using an AWS profile named "rhcontrol"
+  osdctl cluster network diff 1kfmyclusteristhebesteverp8m --profile rhcontrol -o json
+`
+
+	// capiInstallerVersion is the first version installed with the cluster API, which names the security groups
+	// after the node roles
+	capiInstallerVersion = "4.16"
+
+	networkChangeAdded    = "added"
+	networkChangeMissing  = "missing"
+	networkChangeModified = "modified"
+
+	// defaultNetworkACLRule is the rule number of the deny-all rule every network ACL ends with
+	defaultNetworkACLRule = 32767
+	allTrafficProtocol    = "-1"
+	anyIPv4               = "0.0.0.0/0"
+)
+
+type networkDiffOptions struct {
+	clusterID  string
+	awsProfile string
+
+	awsClient     aws.Client
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// networkDifference is a difference between the cluster network and its baseline
+type networkDifference struct {
+	Kind     string `json:"kind" yaml:"kind"`
+	Resource string `json:"resource" yaml:"resource"`
+	Change   string `json:"change" yaml:"change"`
+	Detail   string `json:"detail" yaml:"detail"`
+}
+
+type networkDiffResponse struct {
+	ClusterID   string              `json:"cluster_id" yaml:"cluster_id"`
+	VpcID       string              `json:"vpc_id" yaml:"vpc_id"`
+	Baseline    string              `json:"baseline" yaml:"baseline"`
+	Differences []networkDifference `json:"differences" yaml:"differences"`
+}
+
+func (r networkDiffResponse) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Cluster %s, VPC %s, baseline: %s\n", r.ClusterID, r.VpcID, r.Baseline)
+	if len(r.Differences) == 0 {
+		fmt.Fprintln(&b, "The cluster network matches its baseline")
+		return b.String()
+	}
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"KIND", "RESOURCE", "CHANGE", "DETAIL"})
+	for _, d := range r.Differences {
+		table.AddRow([]string{d.Kind, d.Resource, d.Change, d.Detail})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return fmt.Sprintf("cannot print the differences: %v", err)
+	}
+	fmt.Fprintln(&b, "Customer modifications of the cluster network justify a misconfiguration limited support reason, see 'osdctl cluster support post'")
+	return b.String()
+}
+
+// networkBaseline is the network the installer creates for a version and topology
+type networkBaseline struct {
+	name           string
+	byovpc         bool
+	securityGroups []securityGroupBaseline
+}
+
+// securityGroupBaseline is a security group of the cluster, found by the suffix of its name after the infra ID
+type securityGroupBaseline struct {
+	role   string
+	suffix string
+	// required are the ingress rules the cluster needs, as "protocol/port"
+	required []string
+	// public are the ingress rules allowed from anywhere, e.g. the API behind a public load balancer
+	public []string
+}
+
+func newCmdNetwork(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	networkCmd := &cobra.Command{
+		Use:               "network",
+		Short:             "Inspect the cloud network of a cluster",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	diffOpts := &networkDiffOptions{GlobalOptions: globalOpts}
+	diffCmd := &cobra.Command{
+		Use:               "diff CLUSTER_ID",
+		Short:             "Compare the security groups, network ACLs and route tables of a cluster with its baseline",
+		Long:              networkDiffLongDescription,
+		Example:           networkDiffExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			diffOpts.clusterID = args[0]
+			osdctlErrors.CheckErr(diffOpts.run())
+		},
+	}
+	diffCmd.Flags().StringVarP(&diffOpts.awsProfile, "profile", "p", "", "AWS profile name")
+
+	networkCmd.AddCommand(diffCmd)
+	return networkCmd
+}
+
+func (o *networkDiffOptions) run() error {
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.CloudProvider().ID() != "aws" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "'osdctl cluster network diff' only supports AWS clusters, %s is on %s",
+			cluster.ID(), cluster.CloudProvider().ID())
+	}
+
+	if o.awsClient == nil {
+		o.awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+		if err != nil {
+			return err
+		}
+	}
+
+	response, err := diffClusterNetwork(o.awsClient, cluster)
+	if err != nil {
+		return err
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// baselineFor returns the network the installer creates for the cluster
+func baselineFor(cluster *cmv1.Cluster) networkBaseline {
+	byovpc := len(cluster.AWS().SubnetIDs()) > 0
+	topology := "installer VPC"
+	if byovpc {
+		topology = "BYOVPC"
+	}
+	if cluster.AWS().PrivateLink() {
+		topology += ", PrivateLink"
+	}
+
+	if compareMinorVersions(cluster.Version().RawID(), capiInstallerVersion) >= 0 {
+		return networkBaseline{
+			name:   fmt.Sprintf("OpenShift %s+ (%s)", capiInstallerVersion, topology),
+			byovpc: byovpc,
+			securityGroups: []securityGroupBaseline{
+				{role: "control plane", suffix: "-controlplane", required: []string{"tcp/6443", "tcp/22623"}},
+				{role: "node", suffix: "-node", required: []string{"tcp/10250"}},
+				{role: "API load balancer", suffix: "-apiserver-lb", required: []string{"tcp/6443"}, public: []string{"tcp/6443"}},
+			},
+		}
+	}
+	return networkBaseline{
+		name:   fmt.Sprintf("OpenShift < %s (%s)", capiInstallerVersion, topology),
+		byovpc: byovpc,
+		securityGroups: []securityGroupBaseline{
+			{role: "master", suffix: "-master-sg", required: []string{"tcp/6443", "tcp/22623"}, public: []string{"tcp/6443"}},
+			{role: "worker", suffix: "-worker-sg", required: []string{"tcp/10250"}},
+		},
+	}
+}
+
+func diffClusterNetwork(awsClient aws.Client, cluster *cmv1.Cluster) (networkDiffResponse, error) {
+	baseline := baselineFor(cluster)
+	response := networkDiffResponse{ClusterID: cluster.ID(), Baseline: baseline.name, Differences: []networkDifference{}}
+
+	subnets, err := clusterSubnets(awsClient, cluster)
+	if err != nil {
+		return response, err
+	}
+	response.VpcID = awsSdk.StringValue(subnets[0].VpcId)
+
+	vpcs, err := awsClient.DescribeVpcs(&ec2.DescribeVpcsInput{VpcIds: []*string{subnets[0].VpcId}})
+	if err != nil {
+		return response, fmt.Errorf("cannot describe the VPC %s: %w", response.VpcID, err)
+	}
+	var vpcCIDRs []string
+	for _, vpc := range vpcs.Vpcs {
+		for _, association := range vpc.CidrBlockAssociationSet {
+			vpcCIDRs = append(vpcCIDRs, awsSdk.StringValue(association.CidrBlock))
+		}
+	}
+
+	var securityGroups []*ec2.SecurityGroup
+	input := &ec2.DescribeSecurityGroupsInput{Filters: []*ec2.Filter{
+		{Name: awsSdk.String("tag:kubernetes.io/cluster/" + cluster.InfraID()), Values: []*string{awsSdk.String("owned")}},
+	}}
+	for {
+		output, err := awsClient.DescribeSecurityGroups(input)
+		if err != nil {
+			return response, fmt.Errorf("cannot describe the security groups of the cluster: %w", err)
+		}
+		securityGroups = append(securityGroups, output.SecurityGroups...)
+		if awsSdk.StringValue(output.NextToken) == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	response.Differences = append(response.Differences, diffSecurityGroups(securityGroups, cluster.InfraID(), baseline, vpcCIDRs)...)
+
+	subnetIDs := make([]*string, 0, len(subnets))
+	for _, subnet := range subnets {
+		subnetIDs = append(subnetIDs, subnet.SubnetId)
+	}
+
+	acls, err := awsClient.DescribeNetworkAcls(&ec2.DescribeNetworkAclsInput{Filters: []*ec2.Filter{
+		{Name: awsSdk.String("association.subnet-id"), Values: subnetIDs},
+	}})
+	if err != nil {
+		return response, fmt.Errorf("cannot describe the network ACLs of the cluster subnets: %w", err)
+	}
+	response.Differences = append(response.Differences, diffNetworkACLs(acls.NetworkAcls)...)
+
+	routeTables, err := subnetRouteTables(awsClient, response.VpcID, subnetIDs)
+	if err != nil {
+		return response, err
+	}
+	response.Differences = append(response.Differences, diffRouteTables(routeTables, baseline)...)
+
+	sort.SliceStable(response.Differences, func(i, j int) bool {
+		if response.Differences[i].Kind != response.Differences[j].Kind {
+			return response.Differences[i].Kind < response.Differences[j].Kind
+		}
+		return response.Differences[i].Resource < response.Differences[j].Resource
+	})
+	return response, nil
+}
+
+// clusterSubnets returns the subnets of a BYOVPC cluster, or the ones the installer created for the cluster
+func clusterSubnets(awsClient aws.Client, cluster *cmv1.Cluster) ([]*ec2.Subnet, error) {
+	input := &ec2.DescribeSubnetsInput{}
+	if subnetIDs := cluster.AWS().SubnetIDs(); len(subnetIDs) > 0 {
+		input.SubnetIds = awsSdk.StringSlice(subnetIDs)
+	} else {
+		input.Filters = []*ec2.Filter{
+			{Name: awsSdk.String("tag:kubernetes.io/cluster/" + cluster.InfraID()), Values: []*string{awsSdk.String("owned")}},
+		}
+	}
+	output, err := awsClient.DescribeSubnets(input)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe the subnets of the cluster: %w", err)
+	}
+	if len(output.Subnets) == 0 {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "no subnet found for the cluster %s", cluster.ID())
+	}
+	return output.Subnets, nil
+}
+
+// subnetRouteTables returns the route tables of the subnets: the ones associated to them, and the main route table
+// of the VPC for the subnets without one
+func subnetRouteTables(awsClient aws.Client, vpcID string, subnetIDs []*string) ([]*ec2.RouteTable, error) {
+	output, err := awsClient.DescribeRouteTables(&ec2.DescribeRouteTablesInput{Filters: []*ec2.Filter{
+		{Name: awsSdk.String("vpc-id"), Values: []*string{awsSdk.String(vpcID)}},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe the route tables of the VPC %s: %w", vpcID, err)
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range subnetIDs {
+		wanted[awsSdk.StringValue(id)] = true
+	}
+	associated := map[string]bool{}
+	var tables []*ec2.RouteTable
+	var main *ec2.RouteTable
+	for _, table := range output.RouteTables {
+		used := false
+		for _, association := range table.Associations {
+			if awsSdk.BoolValue(association.Main) {
+				main = table
+			}
+			if subnet := awsSdk.StringValue(association.SubnetId); wanted[subnet] {
+				associated[subnet] = true
+				used = true
+			}
+		}
+		if used {
+			tables = append(tables, table)
+		}
+	}
+	if main != nil && len(associated) < len(wanted) {
+		for _, table := range tables {
+			if table == main {
+				return tables, nil
+			}
+		}
+		tables = append(tables, main)
+	}
+	return tables, nil
+}
+
+// diffSecurityGroups compares the security groups of the cluster with the baseline of their role
+func diffSecurityGroups(groups []*ec2.SecurityGroup, infraID string, baseline networkBaseline, vpcCIDRs []string) []networkDifference {
+	clusterGroups := map[string]bool{}
+	for _, group := range groups {
+		clusterGroups[awsSdk.StringValue(group.GroupId)] = true
+	}
+
+	var differences []networkDifference
+	for _, expected := range baseline.securityGroups {
+		name := infraID + expected.suffix
+		var group *ec2.SecurityGroup
+		for _, g := range groups {
+			if securityGroupName(g) == name {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			differences = append(differences, networkDifference{
+				Kind:     "security group",
+				Resource: name,
+				Change:   networkChangeMissing,
+				Detail:   fmt.Sprintf("the %s security group was deleted", expected.role),
+			})
+			continue
+		}
+
+		resource := fmt.Sprintf("%s (%s)", awsSdk.StringValue(group.GroupId), name)
+		present := map[string]bool{}
+		for _, permission := range group.IpPermissions {
+			rule := permissionName(permission)
+			present[rule] = true
+			for _, source := range unexpectedSources(permission, clusterGroups, vpcCIDRs, slices.Contains(expected.public, rule)) {
+				differences = append(differences, networkDifference{
+					Kind:     "security group",
+					Resource: resource,
+					Change:   networkChangeAdded,
+					Detail:   fmt.Sprintf("ingress %s from %s", rule, source),
+				})
+			}
+		}
+		for _, rule := range expected.required {
+			if !present[rule] && !present[allTrafficProtocol] {
+				differences = append(differences, networkDifference{
+					Kind:     "security group",
+					Resource: resource,
+					Change:   networkChangeMissing,
+					Detail:   fmt.Sprintf("ingress %s needed by the %s", rule, expected.role),
+				})
+			}
+		}
+		if !allowsAllEgress(group.IpPermissionsEgress) {
+			differences = append(differences, networkDifference{
+				Kind:     "security group",
+				Resource: resource,
+				Change:   networkChangeMissing,
+				Detail:   "egress of all traffic to " + anyIPv4,
+			})
+		}
+	}
+	return differences
+}
+
+func securityGroupName(group *ec2.SecurityGroup) string {
+	for _, tag := range group.Tags {
+		if awsSdk.StringValue(tag.Key) == "Name" {
+			return awsSdk.StringValue(tag.Value)
+		}
+	}
+	return awsSdk.StringValue(group.GroupName)
+}
+
+// permissionName describes the protocol and ports of a rule, e.g. "tcp/6443", "udp/4789-4790" or "-1" for all traffic
+func permissionName(permission *ec2.IpPermission) string {
+	protocol := awsSdk.StringValue(permission.IpProtocol)
+	if protocol == allTrafficProtocol {
+		return protocol
+	}
+	from, to := awsSdk.Int64Value(permission.FromPort), awsSdk.Int64Value(permission.ToPort)
+	if from == to {
+		return fmt.Sprintf("%s/%d", protocol, from)
+	}
+	return fmt.Sprintf("%s/%d-%d", protocol, from, to)
+}
+
+// unexpectedSources returns the sources of an ingress rule outside the cluster: CIDRs outside the VPC, unless the
+// rule is public, security groups which aren't the cluster's, and prefix lists
+func unexpectedSources(permission *ec2.IpPermission, clusterGroups map[string]bool, vpcCIDRs []string, public bool) []string {
+	var sources []string
+	for _, ipRange := range permission.IpRanges {
+		cidr := awsSdk.StringValue(ipRange.CidrIp)
+		if !public && !cidrWithin(cidr, vpcCIDRs) {
+			sources = append(sources, cidr)
+		}
+	}
+	for _, ipRange := range permission.Ipv6Ranges {
+		if !public {
+			sources = append(sources, awsSdk.StringValue(ipRange.CidrIpv6))
+		}
+	}
+	for _, pair := range permission.UserIdGroupPairs {
+		if id := awsSdk.StringValue(pair.GroupId); !clusterGroups[id] {
+			sources = append(sources, id)
+		}
+	}
+	for _, prefixList := range permission.PrefixListIds {
+		sources = append(sources, awsSdk.StringValue(prefixList.PrefixListId))
+	}
+	return sources
+}
+
+// cidrWithin returns true when the CIDR is part of one of the networks
+func cidrWithin(cidr string, networks []string) bool {
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	ones, _ := ipNet.Mask.Size()
+	for _, network := range networks {
+		_, n, err := net.ParseCIDR(network)
+		if err != nil {
+			continue
+		}
+		networkOnes, _ := n.Mask.Size()
+		if n.Contains(ip) && ones >= networkOnes {
+			return true
+		}
+	}
+	return false
+}
+
+func allowsAllEgress(permissions []*ec2.IpPermission) bool {
+	for _, permission := range permissions {
+		if awsSdk.StringValue(permission.IpProtocol) != allTrafficProtocol {
+			continue
+		}
+		for _, ipRange := range permission.IpRanges {
+			if awsSdk.StringValue(ipRange.CidrIp) == anyIPv4 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// diffNetworkACLs compares the network ACLs of the subnets with the default one, which allows all traffic
+func diffNetworkACLs(acls []*ec2.NetworkAcl) []networkDifference {
+	var differences []networkDifference
+	for _, acl := range acls {
+		resource := awsSdk.StringValue(acl.NetworkAclId)
+		allowsAll := map[bool]bool{}
+		for _, entry := range acl.Entries {
+			egress := awsSdk.BoolValue(entry.Egress)
+			if awsSdk.Int64Value(entry.RuleNumber) == defaultNetworkACLRule || awsSdk.StringValue(entry.CidrBlock) == "" {
+				continue
+			}
+			allTraffic := awsSdk.StringValue(entry.Protocol) == allTrafficProtocol && awsSdk.StringValue(entry.CidrBlock) == anyIPv4
+			if awsSdk.StringValue(entry.RuleAction) == ec2.RuleActionAllow {
+				if allTraffic {
+					allowsAll[egress] = true
+				}
+				continue
+			}
+			differences = append(differences, networkDifference{
+				Kind:     "network ACL",
+				Resource: resource,
+				Change:   networkChangeAdded,
+				Detail:   fmt.Sprintf("rule %d denies %s", awsSdk.Int64Value(entry.RuleNumber), networkACLEntryName(entry)),
+			})
+		}
+		for _, egress := range []bool{false, true} {
+			if !allowsAll[egress] {
+				differences = append(differences, networkDifference{
+					Kind:     "network ACL",
+					Resource: resource,
+					Change:   networkChangeMissing,
+					Detail:   fmt.Sprintf("%s rule allowing all traffic %s %s", direction(egress), peer(egress), anyIPv4),
+				})
+			}
+		}
+	}
+	return differences
+}
+
+func direction(egress bool) string {
+	if egress {
+		return "egress"
+	}
+	return "ingress"
+}
+
+func peer(egress bool) string {
+	if egress {
+		return "to"
+	}
+	return "from"
+}
+
+// networkACLEntryName describes a network ACL entry, e.g. "egress tcp/443 to 0.0.0.0/0"
+func networkACLEntryName(entry *ec2.NetworkAclEntry) string {
+	traffic := "all traffic"
+	switch protocol := awsSdk.StringValue(entry.Protocol); protocol {
+	case allTrafficProtocol:
+	case "6", "17":
+		name := map[string]string{"6": "tcp", "17": "udp"}[protocol]
+		if entry.PortRange != nil {
+			traffic = permissionName(&ec2.IpPermission{IpProtocol: awsSdk.String(name), FromPort: entry.PortRange.From, ToPort: entry.PortRange.To})
+		} else {
+			traffic = name
+		}
+	default:
+		traffic = "protocol " + protocol
+	}
+	egress := awsSdk.BoolValue(entry.Egress)
+	return fmt.Sprintf("%s %s %s %s", direction(egress), traffic, peer(egress), awsSdk.StringValue(entry.CidrBlock))
+}
+
+// diffRouteTables checks the default route of the route tables. The installer only routes the VPC locally, the default
+// route to a NAT or internet gateway and S3 to its VPC endpoint, the other routes of an installer VPC are customer
+// modifications.
+func diffRouteTables(tables []*ec2.RouteTable, baseline networkBaseline) []networkDifference {
+	var differences []networkDifference
+	for _, table := range tables {
+		resource := awsSdk.StringValue(table.RouteTableId)
+		hasDefault := false
+		for _, route := range table.Routes {
+			target := routeTarget(route)
+			destination := awsSdk.StringValue(route.DestinationCidrBlock)
+			if destination == "" {
+				destination = awsSdk.StringValue(route.DestinationPrefixListId) + awsSdk.StringValue(route.DestinationIpv6CidrBlock)
+			}
+			if target == "local" {
+				continue
+			}
+
+			switch {
+			case destination == anyIPv4:
+				hasDefault = true
+				if awsSdk.StringValue(route.State) == ec2.RouteStateBlackhole {
+					differences = append(differences, networkDifference{
+						Kind:     "route table",
+						Resource: resource,
+						Change:   networkChangeModified,
+						Detail:   fmt.Sprintf("the default route to %s is a blackhole, its target was deleted", target),
+					})
+				} else if !baseline.byovpc && !strings.HasPrefix(target, "nat-") && !strings.HasPrefix(target, "igw-") {
+					differences = append(differences, networkDifference{
+						Kind:     "route table",
+						Resource: resource,
+						Change:   networkChangeModified,
+						Detail:   fmt.Sprintf("the default route goes to %s instead of a NAT or internet gateway", target),
+					})
+				}
+			case !baseline.byovpc && !(route.DestinationPrefixListId != nil && strings.HasPrefix(target, "vpce-")):
+				differences = append(differences, networkDifference{
+					Kind:     "route table",
+					Resource: resource,
+					Change:   networkChangeAdded,
+					Detail:   fmt.Sprintf("route to %s via %s", destination, target),
+				})
+			}
+		}
+		if !hasDefault {
+			differences = append(differences, networkDifference{
+				Kind:     "route table",
+				Resource: resource,
+				Change:   networkChangeMissing,
+				Detail:   "default route to " + anyIPv4,
+			})
+		}
+	}
+	return differences
+}
+
+func routeTarget(route *ec2.Route) string {
+	for _, target := range []*string{route.GatewayId, route.NatGatewayId, route.TransitGatewayId, route.VpcPeeringConnectionId,
+		route.NetworkInterfaceId, route.InstanceId, route.LocalGatewayId, route.CarrierGatewayId} {
+		if value := awsSdk.StringValue(target); value != "" {
+			return value
+		}
+	}
+	return "unknown"
+}
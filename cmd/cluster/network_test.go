@@ -0,0 +1,160 @@
+package cluster
+
+import (
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func newNetworkTestCluster(g *WithT, version string, subnetIDs ...string) *cmv1.Cluster {
+	cluster, err := cmv1.NewCluster().ID("abc").InfraID("mycluster-abcde").
+		Version(cmv1.NewVersion().RawID(version)).
+		AWS(cmv1.NewAWS().SubnetIDs(subnetIDs...)).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	return cluster
+}
+
+func tcpPermission(port int64, cidrs ...string) *ec2.IpPermission {
+	permission := &ec2.IpPermission{IpProtocol: awsSdk.String("tcp"), FromPort: awsSdk.Int64(port), ToPort: awsSdk.Int64(port)}
+	for _, cidr := range cidrs {
+		permission.IpRanges = append(permission.IpRanges, &ec2.IpRange{CidrIp: awsSdk.String(cidr)})
+	}
+	return permission
+}
+
+func securityGroup(id, name string, ingress ...*ec2.IpPermission) *ec2.SecurityGroup {
+	return &ec2.SecurityGroup{
+		GroupId:       awsSdk.String(id),
+		GroupName:     awsSdk.String("terraform-" + id),
+		Tags:          []*ec2.Tag{{Key: awsSdk.String("Name"), Value: awsSdk.String(name)}},
+		IpPermissions: ingress,
+		IpPermissionsEgress: []*ec2.IpPermission{
+			{IpProtocol: awsSdk.String("-1"), IpRanges: []*ec2.IpRange{{CidrIp: awsSdk.String("0.0.0.0/0")}}},
+		},
+	}
+}
+
+func TestBaselineFor(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	baseline := baselineFor(newNetworkTestCluster(g, "4.15.20"))
+	g.Expect(baseline.name).To(Equal("OpenShift < 4.16 (installer VPC)"))
+	g.Expect(baseline.byovpc).To(BeFalse())
+	g.Expect(baseline.securityGroups[0].suffix).To(Equal("-master-sg"))
+
+	baseline = baselineFor(newNetworkTestCluster(g, "4.16.2", "subnet-1"))
+	g.Expect(baseline.name).To(Equal("OpenShift 4.16+ (BYOVPC)"))
+	g.Expect(baseline.byovpc).To(BeTrue())
+	g.Expect(baseline.securityGroups[0].suffix).To(Equal("-controlplane"))
+}
+
+func TestDiffSecurityGroups(t *testing.T) {
+	g := NewGomegaWithT(t)
+	baseline := baselineFor(newNetworkTestCluster(g, "4.15.20"))
+	vpcCIDRs := []string{"10.0.0.0/16"}
+
+	master := securityGroup("sg-master", "mycluster-abcde-master-sg",
+		tcpPermission(6443, "0.0.0.0/0"),
+		tcpPermission(22623, "10.0.0.0/16"),
+		tcpPermission(22, "10.0.0.0/16", "203.0.113.0/24"),
+	)
+	worker := securityGroup("sg-worker", "mycluster-abcde-worker-sg",
+		&ec2.IpPermission{IpProtocol: awsSdk.String("tcp"), FromPort: awsSdk.Int64(30000), ToPort: awsSdk.Int64(32767),
+			UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: awsSdk.String("sg-master")}, {GroupId: awsSdk.String("sg-customer")}}},
+	)
+	worker.IpPermissionsEgress = nil
+
+	differences := diffSecurityGroups([]*ec2.SecurityGroup{master, worker}, "mycluster-abcde", baseline, vpcCIDRs)
+	g.Expect(differences).To(ConsistOf(
+		networkDifference{Kind: "security group", Resource: "sg-master (mycluster-abcde-master-sg)", Change: "added", Detail: "ingress tcp/22 from 203.0.113.0/24"},
+		networkDifference{Kind: "security group", Resource: "sg-worker (mycluster-abcde-worker-sg)", Change: "added", Detail: "ingress tcp/30000-32767 from sg-customer"},
+		networkDifference{Kind: "security group", Resource: "sg-worker (mycluster-abcde-worker-sg)", Change: "missing", Detail: "ingress tcp/10250 needed by the worker"},
+		networkDifference{Kind: "security group", Resource: "sg-worker (mycluster-abcde-worker-sg)", Change: "missing", Detail: "egress of all traffic to 0.0.0.0/0"},
+	))
+
+	differences = diffSecurityGroups([]*ec2.SecurityGroup{master}, "mycluster-abcde", baseline, vpcCIDRs)
+	g.Expect(differences).To(ContainElement(networkDifference{
+		Kind: "security group", Resource: "mycluster-abcde-worker-sg", Change: "missing", Detail: "the worker security group was deleted",
+	}))
+}
+
+func TestDiffNetworkACLs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	entry := func(rule int64, egress bool, action, protocol, cidr string, ports *ec2.PortRange) *ec2.NetworkAclEntry {
+		return &ec2.NetworkAclEntry{RuleNumber: awsSdk.Int64(rule), Egress: awsSdk.Bool(egress), RuleAction: awsSdk.String(action),
+			Protocol: awsSdk.String(protocol), CidrBlock: awsSdk.String(cidr), PortRange: ports}
+	}
+
+	defaultACL := &ec2.NetworkAcl{NetworkAclId: awsSdk.String("acl-default"), Entries: []*ec2.NetworkAclEntry{
+		entry(100, false, "allow", "-1", "0.0.0.0/0", nil),
+		entry(100, true, "allow", "-1", "0.0.0.0/0", nil),
+		entry(32767, false, "deny", "-1", "0.0.0.0/0", nil),
+		entry(32767, true, "deny", "-1", "0.0.0.0/0", nil),
+	}}
+	g.Expect(diffNetworkACLs([]*ec2.NetworkAcl{defaultACL})).To(BeEmpty())
+
+	customACL := &ec2.NetworkAcl{NetworkAclId: awsSdk.String("acl-custom"), Entries: []*ec2.NetworkAclEntry{
+		entry(90, true, "deny", "6", "0.0.0.0/0", &ec2.PortRange{From: awsSdk.Int64(443), To: awsSdk.Int64(443)}),
+		entry(100, false, "allow", "-1", "0.0.0.0/0", nil),
+		entry(100, true, "allow", "6", "10.0.0.0/8", nil),
+		entry(32767, true, "deny", "-1", "0.0.0.0/0", nil),
+	}}
+	g.Expect(diffNetworkACLs([]*ec2.NetworkAcl{customACL})).To(ConsistOf(
+		networkDifference{Kind: "network ACL", Resource: "acl-custom", Change: "added", Detail: "rule 90 denies egress tcp/443 to 0.0.0.0/0"},
+		networkDifference{Kind: "network ACL", Resource: "acl-custom", Change: "missing", Detail: "egress rule allowing all traffic to 0.0.0.0/0"},
+	))
+}
+
+func TestDiffRouteTables(t *testing.T) {
+	g := NewGomegaWithT(t)
+	local := &ec2.Route{DestinationCidrBlock: awsSdk.String("10.0.0.0/16"), GatewayId: awsSdk.String("local")}
+	s3 := &ec2.Route{DestinationPrefixListId: awsSdk.String("pl-63a5400a"), GatewayId: awsSdk.String("vpce-1")}
+	tables := []*ec2.RouteTable{
+		{RouteTableId: awsSdk.String("rtb-ok"), Routes: []*ec2.Route{local, s3,
+			{DestinationCidrBlock: awsSdk.String("0.0.0.0/0"), NatGatewayId: awsSdk.String("nat-1"), State: awsSdk.String("active")}}},
+		{RouteTableId: awsSdk.String("rtb-firewall"), Routes: []*ec2.Route{local,
+			{DestinationCidrBlock: awsSdk.String("0.0.0.0/0"), TransitGatewayId: awsSdk.String("tgw-1"), State: awsSdk.String("active")},
+			{DestinationCidrBlock: awsSdk.String("192.168.0.0/16"), VpcPeeringConnectionId: awsSdk.String("pcx-1"), State: awsSdk.String("active")}}},
+		{RouteTableId: awsSdk.String("rtb-blackhole"), Routes: []*ec2.Route{local,
+			{DestinationCidrBlock: awsSdk.String("0.0.0.0/0"), NatGatewayId: awsSdk.String("nat-deleted"), State: awsSdk.String("blackhole")}}},
+		{RouteTableId: awsSdk.String("rtb-isolated"), Routes: []*ec2.Route{local}},
+	}
+
+	g.Expect(diffRouteTables(tables, networkBaseline{})).To(ConsistOf(
+		networkDifference{Kind: "route table", Resource: "rtb-firewall", Change: "modified", Detail: "the default route goes to tgw-1 instead of a NAT or internet gateway"},
+		networkDifference{Kind: "route table", Resource: "rtb-firewall", Change: "added", Detail: "route to 192.168.0.0/16 via pcx-1"},
+		networkDifference{Kind: "route table", Resource: "rtb-blackhole", Change: "modified", Detail: "the default route to nat-deleted is a blackhole, its target was deleted"},
+		networkDifference{Kind: "route table", Resource: "rtb-isolated", Change: "missing", Detail: "default route to 0.0.0.0/0"},
+	))
+
+	// The routing of a BYOVPC belongs to the customer, only the default route is checked
+	g.Expect(diffRouteTables(tables, networkBaseline{byovpc: true})).To(HaveLen(2))
+}
+
+func TestSubnetRouteTables(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := awsmock.NewMockClient(mockCtrl)
+
+	main := &ec2.RouteTable{RouteTableId: awsSdk.String("rtb-main"), Associations: []*ec2.RouteTableAssociation{{Main: awsSdk.Bool(true)}}}
+	private := &ec2.RouteTable{RouteTableId: awsSdk.String("rtb-private"), Associations: []*ec2.RouteTableAssociation{{SubnetId: awsSdk.String("subnet-1")}}}
+	other := &ec2.RouteTable{RouteTableId: awsSdk.String("rtb-other"), Associations: []*ec2.RouteTableAssociation{{SubnetId: awsSdk.String("subnet-9")}}}
+	mockAWSClient.EXPECT().DescribeRouteTables(gomock.Any()).Return(&ec2.DescribeRouteTablesOutput{
+		RouteTables: []*ec2.RouteTable{main, private, other},
+	}, nil).Times(2)
+
+	tables, err := subnetRouteTables(mockAWSClient, "vpc-1", awsSdk.StringSlice([]string{"subnet-1"}))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tables).To(ConsistOf(private))
+
+	// subnet-2 has no route table of its own, it uses the main one
+	tables, err = subnetRouteTables(mockAWSClient, "vpc-1", awsSdk.StringSlice([]string{"subnet-1", "subnet-2"}))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(tables).To(ConsistOf(private, main))
+}
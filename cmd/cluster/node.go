@@ -0,0 +1,391 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/poll"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	infraNodeRoleLabel = "node-role.kubernetes.io/infra"
+
+	nodeLong = `Drains and reboots the nodes of a cluster, one at a time, and edits the labels and taints of the nodes
+  matching a selector.
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID'), the commands are
+  run as backplane-cluster-admin. The cluster the current kubeconfig points to is checked against CLUSTER_ID.
+
+  Before draining a node, the pod disruption budgets protecting its pods are checked and the node is skipped when
+  one of them doesn't allow any disruption. The command stops at the first node that fails, so that at most one
+  node is left cordoned.
+
+  'label' and 'taint' print the changes of every node first, only printing them with --dry-run, and write a rollback
+  file before changing anything. Passing the file to --rollback undoes the changes.`
+
+	nodeExample = `
+  # Cordon and drain a node, it is left cordoned
+  osdctl cluster node drain 1kfmyclusteristhebesteverp8m ip-10-0-1-1.ec2.internal
+
+  # Reboot two worker nodes, one after the other
+  osdctl cluster node reboot 1kfmyclusteristhebesteverp8m ip-10-0-1-1.ec2.internal ip-10-0-1-2.ec2.internal
+
+  # Reboot the infra nodes two at a time
+  osdctl cluster node reboot 1kfmyclusteristhebesteverp8m ip-10-0-2-1.ec2.internal ip-10-0-2-2.ec2.internal ip-10-0-2-3.ec2.internal --parallel 2
+
+  # Keep new workloads off the workers of a bad availability zone, checking the changes first
+  osdctl cluster nodes taint 1kfmyclusteristhebesteverp8m --selector topology.kubernetes.io/zone=us-east-1a,node-role.kubernetes.io/worker bad-az=true:NoSchedule --dry-run
+
+  # Label the infra nodes, then undo it
+  osdctl cluster nodes label 1kfmyclusteristhebesteverp8m -l node-role.kubernetes.io/infra maintenance=2026-10-14
+  osdctl cluster nodes label 1kfmyclusteristhebesteverp8m --rollback nodes-label-1kfmyclusteristhebesteverp8m-20261014T093000Z.json
+`
+)
+
+type nodeOptions struct {
+	clusterID     string
+	nodes         []string
+	reboot        bool
+	parallel      int
+	drainTimeout  time.Duration
+	rebootTimeout time.Duration
+	skipPrompts   bool
+
+	run utils.OCRunner
+	// rebootInstance reboots the cloud instance of a node, given its provider ID
+	rebootInstance func(providerID string) error
+	interval       time.Duration
+}
+
+func newCmdNode() *cobra.Command {
+	nodeCmd := &cobra.Command{
+		Use:               "node",
+		Aliases:           []string{"nodes"},
+		Short:             "Drains, reboots, labels and taints cluster nodes",
+		Long:              nodeLong,
+		Example:           nodeExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	nodeCmd.AddCommand(newCmdNodeAction(false))
+	nodeCmd.AddCommand(newCmdNodeAction(true))
+	nodeCmd.AddCommand(newCmdNodeEdit(nodeEditLabel))
+	nodeCmd.AddCommand(newCmdNodeEdit(nodeEditTaint))
+	return nodeCmd
+}
+
+func newCmdNodeAction(reboot bool) *cobra.Command {
+	ops := &nodeOptions{reboot: reboot, run: utils.RunOCAsClusterAdmin, interval: 10 * time.Second}
+	actionCmd := &cobra.Command{
+		Use:               "drain CLUSTER_ID NODE...",
+		Short:             "Cordons and drains nodes, leaving them cordoned",
+		Args:              cobra.MinimumNArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.runNodes())
+		},
+	}
+	if reboot {
+		actionCmd.Use = "reboot CLUSTER_ID NODE..."
+		actionCmd.Short = "Cordons, drains, reboots through the cloud provider and uncordons nodes"
+		actionCmd.Flags().DurationVar(&ops.rebootTimeout, "reboot-timeout", 15*time.Minute, "How long to wait for a rebooted node to be Ready again")
+	}
+	actionCmd.Flags().IntVar(&ops.parallel, "parallel", 1, "How many nodes to handle at once, only allowed when every node is an infra node")
+	actionCmd.Flags().DurationVar(&ops.drainTimeout, "drain-timeout", 10*time.Minute, "How long to wait for a node to be drained")
+	actionCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+
+	return actionCmd
+}
+
+func (o *nodeOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.parallel < 1 {
+		return cmdutil.UsageErrorf(cmd, "--parallel must be at least 1")
+	}
+	o.clusterID, o.nodes = args[0], args[1:]
+	return utils.IsValidClusterKey(o.clusterID)
+}
+
+func (o *nodeOptions) action() string {
+	if o.reboot {
+		return "Reboot"
+	}
+	return "Drain"
+}
+
+func (o *nodeOptions) runNodes() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.run, cluster); err != nil {
+		return err
+	}
+
+	nodes := make([]*corev1.Node, 0, len(o.nodes))
+	for _, name := range o.nodes {
+		node, err := getNode(o.run, name)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+	if err := checkNodeParallelism(nodes, o.parallel); err != nil {
+		return err
+	}
+
+	if o.reboot {
+		if strings.ToUpper(cluster.CloudProvider().ID()) != "AWS" {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "rebooting nodes is only available for AWS clusters")
+		}
+		awsClient, err := osdCloud.CreateAWSClient(o.clusterID)
+		if err != nil {
+			return err
+		}
+		o.rebootInstance = func(providerID string) error {
+			instanceID, err := awsInstanceID(providerID)
+			if err != nil {
+				return err
+			}
+			_, err = awsClient.RebootInstances(&ec2.RebootInstancesInput{InstanceIds: []*string{aws.String(instanceID)}})
+			return err
+		}
+	}
+
+	action := fmt.Sprintf("%s nodes %s", o.action(), strings.Join(o.nodes, ", "))
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, action),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := o.processNodes(nodes); err != nil {
+		return err
+	}
+	utils.RecordClusterAction(connection, cluster, action)
+	return nil
+}
+
+// processNodes handles the nodes in batches of --parallel nodes, stopping after the first batch with a failure
+func (o *nodeOptions) processNodes(nodes []*corev1.Node) error {
+	for start := 0; start < len(nodes); start += o.parallel {
+		end := start + o.parallel
+		if end > len(nodes) {
+			end = len(nodes)
+		}
+
+		batch := nodes[start:end]
+		errs := make([]error, len(batch))
+		var wg sync.WaitGroup
+		for i, node := range batch {
+			wg.Add(1)
+			go func(i int, node *corev1.Node) {
+				defer wg.Done()
+				errs[i] = o.processNode(node)
+			}(i, node)
+		}
+		wg.Wait()
+
+		var failed []string
+		for i, err := range errs {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[%s] %v\n", batch[i].Name, err)
+				failed = append(failed, batch[i].Name)
+			}
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("failed on %s, the remaining nodes were left untouched", strings.Join(failed, ", "))
+		}
+	}
+
+	if !o.reboot {
+		fmt.Fprintf(os.Stderr, "Nodes drained and left cordoned, run 'oc adm uncordon %s' once done\n", strings.Join(o.nodes, " "))
+	}
+	return nil
+}
+
+func (o *nodeOptions) processNode(node *corev1.Node) error {
+	logf := func(format string, args ...interface{}) {
+		fmt.Fprintf(os.Stderr, "[%s] %s\n", node.Name, fmt.Sprintf(format, args...))
+	}
+
+	pods, err := nodePods(o.run, node.Name)
+	if err != nil {
+		return err
+	}
+	pdbs, err := podDisruptionBudgets(o.run)
+	if err != nil {
+		return err
+	}
+	if blocking := blockingPDBs(pods, pdbs); len(blocking) > 0 {
+		return fmt.Errorf("not draining, these pod disruption budgets don't allow any disruption: %s", strings.Join(blocking, ", "))
+	}
+
+	logf("Cordoning")
+	if _, err := o.run("adm", "cordon", node.Name); err != nil {
+		return err
+	}
+	logf("Draining %d pods, waiting up to %s", len(pods), o.drainTimeout)
+	if _, err := o.run("adm", "drain", node.Name, "--ignore-daemonsets", "--delete-emptydir-data",
+		fmt.Sprintf("--timeout=%s", o.drainTimeout)); err != nil {
+		return fmt.Errorf("drain failed, the node is left cordoned: %w", err)
+	}
+	if !o.reboot {
+		logf("Drained")
+		return nil
+	}
+
+	logf("Rebooting")
+	if err := o.rebootInstance(node.Spec.ProviderID); err != nil {
+		return fmt.Errorf("reboot failed, the node is left cordoned: %w", err)
+	}
+	if err := waitForNodeReboot(o.run, node, o.rebootTimeout, o.interval); err != nil {
+		return fmt.Errorf("%w, the node is left cordoned", err)
+	}
+
+	logf("Uncordoning")
+	if _, err := o.run("adm", "uncordon", node.Name); err != nil {
+		return err
+	}
+	logf("Rebooted")
+	return nil
+}
+
+func getNode(run utils.OCRunner, name string) (*corev1.Node, error) {
+	output, err := run("get", "node", name, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	node := &corev1.Node{}
+	if err := json.Unmarshal(output, node); err != nil {
+		return nil, fmt.Errorf("cannot parse node %s: %w", name, err)
+	}
+	return node, nil
+}
+
+func nodePods(run utils.OCRunner, name string) ([]corev1.Pod, error) {
+	output, err := run("get", "pods", "-A", "--field-selector", "spec.nodeName="+name, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var pods corev1.PodList
+	if err := json.Unmarshal(output, &pods); err != nil {
+		return nil, fmt.Errorf("cannot parse the pods of node %s: %w", name, err)
+	}
+	return pods.Items, nil
+}
+
+func podDisruptionBudgets(run utils.OCRunner) ([]policyv1.PodDisruptionBudget, error) {
+	output, err := run("get", "pdb", "-A", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var pdbs policyv1.PodDisruptionBudgetList
+	if err := json.Unmarshal(output, &pdbs); err != nil {
+		return nil, fmt.Errorf("cannot parse the pod disruption budgets: %w", err)
+	}
+	return pdbs.Items, nil
+}
+
+// blockingPDBs returns the pod disruption budgets covering the pods that don't allow any disruption,
+// the drain would wait on them until it times out
+func blockingPDBs(pods []corev1.Pod, pdbs []policyv1.PodDisruptionBudget) []string {
+	var blocking []string
+	for _, pdb := range pdbs {
+		if pdb.Status.DisruptionsAllowed > 0 {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		for _, pod := range pods {
+			// Pods that are done don't need to be evicted
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			if pod.Namespace == pdb.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+				blocking = append(blocking, pdb.Namespace+"/"+pdb.Name)
+				break
+			}
+		}
+	}
+	return blocking
+}
+
+// checkNodeParallelism only allows handling several nodes at once when they are all infra nodes,
+// the infra pool tolerates it while workers and control plane nodes might not
+func checkNodeParallelism(nodes []*corev1.Node, parallel int) error {
+	if parallel == 1 {
+		return nil
+	}
+	for _, node := range nodes {
+		if _, ok := node.Labels[infraNodeRoleLabel]; !ok {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "--parallel is only allowed for infra nodes, %s isn't one", node.Name)
+		}
+	}
+	return nil
+}
+
+// awsInstanceID returns the EC2 instance ID of a node provider ID, e.g. aws:///us-east-1a/i-0123456789abcdef0
+func awsInstanceID(providerID string) (string, error) {
+	if !strings.HasPrefix(providerID, "aws://") {
+		return "", fmt.Errorf("unexpected provider ID '%s' for an AWS node", providerID)
+	}
+	instanceID := providerID[strings.LastIndex(providerID, "/")+1:]
+	if !strings.HasPrefix(instanceID, "i-") {
+		return "", fmt.Errorf("no instance ID in provider ID '%s'", providerID)
+	}
+	return instanceID, nil
+}
+
+// waitForNodeReboot polls the node until it booted again and is Ready
+func waitForNodeReboot(run utils.OCRunner, before *corev1.Node, timeout, interval time.Duration) error {
+	opts := poll.Options{Description: "node " + before.Name + " to reboot", Timeout: timeout, Interval: interval}
+	return poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
+		node, err := getNode(run, before.Name)
+		switch {
+		case err != nil:
+			return false, fmt.Sprintf("the node can't be retrieved: %v", err), nil
+		case node.Status.NodeInfo.BootID == before.Status.NodeInfo.BootID:
+			return false, "the node hasn't restarted yet", nil
+		case !nodeReady(node):
+			return false, "the node isn't Ready", nil
+		}
+		return true, "", nil
+	})
+}
+
+func nodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
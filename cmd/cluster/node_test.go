@@ -0,0 +1,152 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testNode(name, bootID string, ready bool, nodeLabels map[string]string) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: nodeLabels},
+		Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"},
+		Status: corev1.NodeStatus{
+			NodeInfo:   corev1.NodeSystemInfo{BootID: bootID},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func TestBlockingPDBs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "web-1", Labels: map[string]string{"app": "web"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "app", Name: "job-1", Labels: map[string]string{"app": "job"}},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+	}
+	pdb := func(namespace, name, app string, allowed int32) policyv1.PodDisruptionBudget {
+		return policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}}},
+			Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+		}
+	}
+
+	g.Expect(blockingPDBs(pods, []policyv1.PodDisruptionBudget{
+		pdb("app", "web", "web", 0),
+		pdb("app", "web-ok", "web", 1),
+		pdb("other", "web", "web", 0),
+		pdb("app", "job", "job", 0),
+	})).To(Equal([]string{"app/web"}))
+}
+
+func TestCheckNodeParallelism(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	infra := testNode("infra-1", "a", true, map[string]string{infraNodeRoleLabel: ""})
+	worker := testNode("worker-1", "a", true, map[string]string{"node-role.kubernetes.io/worker": ""})
+
+	g.Expect(checkNodeParallelism([]*corev1.Node{infra, worker}, 1)).To(Succeed())
+	g.Expect(checkNodeParallelism([]*corev1.Node{infra, infra}, 2)).To(Succeed())
+	g.Expect(checkNodeParallelism([]*corev1.Node{infra, worker}, 2)).NotTo(Succeed())
+}
+
+func TestAWSInstanceID(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	id, err := awsInstanceID("aws:///us-east-1a/i-0123456789abcdef0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(id).To(Equal("i-0123456789abcdef0"))
+
+	_, err = awsInstanceID("gce://project/us-east1-b/node")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestProcessNodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		reboot        bool
+		rebootErr     error
+		expectErr     bool
+		expectedCalls []string
+	}{
+		{
+			name:          "drain leaves the nodes cordoned",
+			expectedCalls: []string{"adm cordon node-1", "adm drain node-1", "adm cordon node-2", "adm drain node-2"},
+		},
+		{
+			name:   "reboot uncordons the nodes once Ready",
+			reboot: true,
+			expectedCalls: []string{"adm cordon node-1", "adm drain node-1", "reboot node-1", "adm uncordon node-1",
+				"adm cordon node-2", "adm drain node-2", "reboot node-2", "adm uncordon node-2"},
+		},
+		{
+			name:          "stops at the first failed node",
+			reboot:        true,
+			rebootErr:     errors.New("instance not found"),
+			expectErr:     true,
+			expectedCalls: []string{"adm cordon node-1", "adm drain node-1", "reboot node-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			var calls []string
+			run := func(args ...string) ([]byte, error) {
+				switch {
+				case args[0] == "get" && args[1] == "node":
+					// The node reports a new boot once it has been rebooted
+					return json.Marshal(testNode(args[2], "rebooted", true, nil))
+				case args[0] == "get":
+					return []byte(`{"items": []}`), nil
+				}
+				calls = append(calls, strings.Join(args[:3], " "))
+				return nil, nil
+			}
+			o := &nodeOptions{
+				reboot:        test.reboot,
+				parallel:      1,
+				rebootTimeout: time.Second,
+				run:           run,
+				interval:      time.Millisecond,
+			}
+			o.rebootInstance = func(string) error {
+				calls = append(calls, "reboot "+strings.Fields(calls[len(calls)-1])[2])
+				return test.rebootErr
+			}
+
+			err := o.processNodes([]*corev1.Node{testNode("node-1", "a", true, nil), testNode("node-2", "b", true, nil)})
+			if test.expectErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+			g.Expect(calls).To(Equal(test.expectedCalls))
+		})
+	}
+}
+
+func TestWaitForNodeRebootTimesOut(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	before := testNode("node-1", "a", true, nil)
+	run := func(args ...string) ([]byte, error) {
+		// Still the same boot, the reboot didn't happen
+		return json.Marshal(before)
+	}
+	g.Expect(waitForNodeReboot(run, before, 10*time.Millisecond, time.Millisecond)).NotTo(Succeed())
+}
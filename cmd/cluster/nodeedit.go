@@ -0,0 +1,400 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/artifacts"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	nodeEditLabel = "label"
+	nodeEditTaint = "taint"
+)
+
+type nodeEditOptions struct {
+	clusterID    string
+	kind         string
+	selector     string
+	args         []string
+	dryRun       bool
+	rollbackFile string
+	rollback     string
+	skipPrompts  bool
+
+	edits []nodeEdit
+	run   utils.OCRunner
+	now   func() time.Time
+}
+
+// nodeEdit is a label or a taint to set or remove, as given to 'oc label' or 'oc adm taint'
+type nodeEdit struct {
+	key    string
+	value  string
+	effect corev1.TaintEffect
+	remove bool
+}
+
+// nodeChange is what an edit changes on a node, with the edits undoing it
+type nodeChange struct {
+	diff string
+	edit string
+	undo []string
+}
+
+// nodeRollback is the content of the rollback file, the edits restoring every node that was changed
+type nodeRollback struct {
+	ClusterID string              `json:"cluster_id"`
+	Kind      string              `json:"kind"`
+	CreatedAt time.Time           `json:"created_at"`
+	Nodes     map[string][]string `json:"nodes"`
+}
+
+func newCmdNodeEdit(kind string) *cobra.Command {
+	ops := &nodeEditOptions{kind: kind, run: utils.RunOCAsClusterAdmin, now: time.Now}
+	editCmd := &cobra.Command{
+		Use:               "label CLUSTER_ID --selector SELECTOR KEY=VALUE|KEY-...",
+		Short:             "Sets or removes labels on the nodes matching a selector, with a rollback file",
+		Args:              cobra.MinimumNArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.runEdit())
+		},
+	}
+	if kind == nodeEditTaint {
+		editCmd.Use = "taint CLUSTER_ID --selector SELECTOR KEY[=VALUE]:EFFECT|KEY[:EFFECT]-..."
+		editCmd.Short = "Adds or removes taints on the nodes matching a selector, with a rollback file"
+	}
+	editCmd.Flags().StringVarP(&ops.selector, "selector", "l", "", "Label selector of the nodes to edit, e.g. topology.kubernetes.io/zone=us-east-1a")
+	editCmd.Flags().BoolVar(&ops.dryRun, "dry-run", false, "Print the changes of every node without applying them")
+	editCmd.Flags().StringVar(&ops.rollbackFile, "rollback-file", "", "Where to write the file undoing the changes, nodes-"+kind+"-CLUSTER_ID-TIMESTAMP.json by default")
+	editCmd.Flags().StringVar(&ops.rollback, "rollback", "", "Undo the changes recorded in this rollback file instead")
+	editCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+
+	return editCmd
+}
+
+func (o *nodeEditOptions) complete(cmd *cobra.Command, args []string) error {
+	o.clusterID, o.args = args[0], args[1:]
+	if o.rollback != "" {
+		if o.selector != "" || len(o.args) > 0 {
+			return cmdutil.UsageErrorf(cmd, "--rollback can't be used with --selector or %ss", o.kind)
+		}
+		return utils.IsValidClusterKey(o.clusterID)
+	}
+	if o.selector == "" {
+		return cmdutil.UsageErrorf(cmd, "--selector is required, to edit every node use --selector node-role.kubernetes.io/worker")
+	}
+	if len(o.args) == 0 {
+		return cmdutil.UsageErrorf(cmd, "at least one %s is required", o.kind)
+	}
+	for _, arg := range o.args {
+		parse := parseLabelEdit
+		if o.kind == nodeEditTaint {
+			parse = parseTaintEdit
+		}
+		edit, err := parse(arg)
+		if err != nil {
+			return cmdutil.UsageErrorf(cmd, "%v", err)
+		}
+		o.edits = append(o.edits, edit)
+	}
+	return utils.IsValidClusterKey(o.clusterID)
+}
+
+// parseLabelEdit parses key=value, setting the label, or key-, removing it
+func parseLabelEdit(arg string) (nodeEdit, error) {
+	if strings.HasSuffix(arg, "-") && !strings.Contains(arg, "=") {
+		key := strings.TrimSuffix(arg, "-")
+		return nodeEdit{key: key, remove: true}, validateLabelKey(key)
+	}
+	key, value, found := strings.Cut(arg, "=")
+	if !found {
+		return nodeEdit{}, fmt.Errorf("invalid label '%s', expected KEY=VALUE or KEY-", arg)
+	}
+	if err := validateLabelKey(key); err != nil {
+		return nodeEdit{}, err
+	}
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return nodeEdit{}, fmt.Errorf("invalid label value '%s': %s", value, strings.Join(errs, ", "))
+	}
+	return nodeEdit{key: key, value: value}, nil
+}
+
+// parseTaintEdit parses key=value:effect or key:effect, adding the taint, or key:effect-, removing it, or key-,
+// removing the taints of every effect
+func parseTaintEdit(arg string) (nodeEdit, error) {
+	remove := strings.HasSuffix(arg, "-")
+	spec := strings.TrimSuffix(arg, "-")
+	edit := nodeEdit{remove: remove}
+	keyValue, effect, hasEffect := strings.Cut(spec, ":")
+	if hasEffect {
+		edit.effect = corev1.TaintEffect(effect)
+		switch edit.effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return nodeEdit{}, fmt.Errorf("invalid taint effect '%s' in '%s', expected NoSchedule, PreferNoSchedule or NoExecute", effect, arg)
+		}
+	} else if !remove {
+		return nodeEdit{}, fmt.Errorf("invalid taint '%s', expected KEY[=VALUE]:EFFECT or KEY[:EFFECT]-", arg)
+	}
+	edit.key, edit.value, _ = strings.Cut(keyValue, "=")
+	if remove && edit.value != "" {
+		return nodeEdit{}, fmt.Errorf("invalid taint '%s', a taint is removed by key and effect, e.g. KEY:EFFECT-", arg)
+	}
+	if err := validateLabelKey(edit.key); err != nil {
+		return nodeEdit{}, err
+	}
+	if errs := validation.IsValidLabelValue(edit.value); len(errs) > 0 {
+		return nodeEdit{}, fmt.Errorf("invalid taint value '%s': %s", edit.value, strings.Join(errs, ", "))
+	}
+	return edit, nil
+}
+
+func validateLabelKey(key string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("invalid key '%s': %s", key, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func formatTaint(key, value string, effect corev1.TaintEffect) string {
+	if value == "" {
+		return fmt.Sprintf("%s:%s", key, effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", key, value, effect)
+}
+
+// labelChanges returns what the edits change on the labels of the node, the edits that don't change anything are left out
+func labelChanges(node *corev1.Node, edits []nodeEdit) []nodeChange {
+	var changes []nodeChange
+	for _, edit := range edits {
+		old, exists := node.Labels[edit.key]
+		switch {
+		case edit.remove && exists:
+			changes = append(changes, nodeChange{diff: fmt.Sprintf("- %s=%s", edit.key, old), edit: edit.key + "-", undo: []string{edit.key + "=" + old}})
+		case edit.remove:
+		case exists && old == edit.value:
+		case exists:
+			changes = append(changes, nodeChange{diff: fmt.Sprintf("~ %s: %s -> %s", edit.key, old, edit.value), edit: edit.key + "=" + edit.value, undo: []string{edit.key + "=" + old}})
+		default:
+			changes = append(changes, nodeChange{diff: fmt.Sprintf("+ %s=%s", edit.key, edit.value), edit: edit.key + "=" + edit.value, undo: []string{edit.key + "-"}})
+		}
+	}
+	return changes
+}
+
+// taintChanges returns what the edits change on the taints of the node, the edits that don't change anything are left out
+func taintChanges(node *corev1.Node, edits []nodeEdit) []nodeChange {
+	var changes []nodeChange
+	for _, edit := range edits {
+		if edit.remove {
+			for _, taint := range node.Spec.Taints {
+				if taint.Key != edit.key || (edit.effect != "" && taint.Effect != edit.effect) {
+					continue
+				}
+				old := formatTaint(taint.Key, taint.Value, taint.Effect)
+				changes = append(changes, nodeChange{diff: "- " + old, edit: fmt.Sprintf("%s:%s-", taint.Key, taint.Effect), undo: []string{old}})
+			}
+			continue
+		}
+
+		taint := formatTaint(edit.key, edit.value, edit.effect)
+		var existing *corev1.Taint
+		for i := range node.Spec.Taints {
+			if node.Spec.Taints[i].Key == edit.key && node.Spec.Taints[i].Effect == edit.effect {
+				existing = &node.Spec.Taints[i]
+			}
+		}
+		switch {
+		case existing == nil:
+			changes = append(changes, nodeChange{diff: "+ " + taint, edit: taint, undo: []string{fmt.Sprintf("%s:%s-", edit.key, edit.effect)}})
+		case existing.Value != edit.value:
+			old := formatTaint(existing.Key, existing.Value, existing.Effect)
+			changes = append(changes, nodeChange{diff: fmt.Sprintf("~ %s -> %s", old, taint), edit: taint, undo: []string{old}})
+		}
+	}
+	return changes
+}
+
+func (o *nodeEditOptions) runEdit() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.run, cluster); err != nil {
+		return err
+	}
+
+	if o.rollback != "" {
+		rollback, err := readNodeRollback(o.rollback, cluster.ID(), o.kind)
+		if err != nil {
+			return err
+		}
+		action := fmt.Sprintf("Undo the %s changes of %d nodes recorded in %s", o.kind, len(rollback.Nodes), o.rollback)
+		err = utils.Confirm(utils.ConfirmOptions{
+			Summary:    utils.NewClusterImpactSummary(connection, cluster, action),
+			SkipPrompt: o.skipPrompts,
+		})
+		if err != nil {
+			return err
+		}
+		if err := o.applyNodeEdits(rollback.Nodes); err != nil {
+			return err
+		}
+		utils.RecordClusterAction(connection, cluster, action)
+		return nil
+	}
+
+	nodes, err := o.selectNodes()
+	if err != nil {
+		return err
+	}
+	edits, rollback := o.plan(nodes)
+	if len(edits) == 0 {
+		fmt.Fprintf(os.Stderr, "The %d nodes matching '%s' already have these %ss, nothing to do\n", len(nodes), o.selector, o.kind)
+		return nil
+	}
+	if o.dryRun {
+		return nil
+	}
+
+	action := fmt.Sprintf("Change the %ss %s of %d nodes matching '%s'", o.kind, strings.Join(o.args, " "), len(edits), o.selector)
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, action),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The rollback file is written before the first change, so that an interrupted run can be undone too
+	path, err := o.writeNodeRollback(rollback)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote the rollback file %s, undo the changes with 'osdctl cluster node %s %s --rollback %s'\n", path, o.kind, o.clusterID, path)
+
+	if err := o.applyNodeEdits(edits); err != nil {
+		return err
+	}
+	utils.RecordClusterAction(connection, cluster, action)
+	return nil
+}
+
+func (o *nodeEditOptions) selectNodes() ([]corev1.Node, error) {
+	output, err := o.run("get", "nodes", "-l", o.selector, "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var nodes corev1.NodeList
+	if err := json.Unmarshal(output, &nodes); err != nil {
+		return nil, fmt.Errorf("cannot parse the nodes: %w", err)
+	}
+	if len(nodes.Items) == 0 {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "no node matches the selector '%s'", o.selector)
+	}
+	sort.Slice(nodes.Items, func(i, j int) bool { return nodes.Items[i].Name < nodes.Items[j].Name })
+	return nodes.Items, nil
+}
+
+// plan prints the diff of every node and returns the edits applying it and the ones undoing it, by node
+func (o *nodeEditOptions) plan(nodes []corev1.Node) (map[string][]string, nodeRollback) {
+	edits := map[string][]string{}
+	rollback := nodeRollback{ClusterID: o.clusterID, Kind: o.kind, CreatedAt: o.now().UTC(), Nodes: map[string][]string{}}
+	for i := range nodes {
+		changes := labelChanges(&nodes[i], o.edits)
+		if o.kind == nodeEditTaint {
+			changes = taintChanges(&nodes[i], o.edits)
+		}
+		if len(changes) == 0 {
+			fmt.Printf("%s: unchanged\n", nodes[i].Name)
+			continue
+		}
+		fmt.Printf("%s:\n", nodes[i].Name)
+		for _, change := range changes {
+			fmt.Printf("  %s\n", change.diff)
+			edits[nodes[i].Name] = append(edits[nodes[i].Name], change.edit)
+			// Undone in the reverse order, for the edits of the same key
+			rollback.Nodes[nodes[i].Name] = append(change.undo, rollback.Nodes[nodes[i].Name]...)
+		}
+	}
+	return edits, rollback
+}
+
+func (o *nodeEditOptions) writeNodeRollback(rollback nodeRollback) (string, error) {
+	content, err := json.MarshalIndent(rollback, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("nodes-%s-%s-%s.json", o.kind, o.clusterID, rollback.CreatedAt.Format("20060102T150405Z"))
+	if o.rollbackFile == "" && artifacts.Enabled() {
+		return artifacts.Write(o.clusterID, name, content, fmt.Sprintf("rollback of the node %ss %s", o.kind, strings.Join(o.args, " ")))
+	}
+	path := o.rollbackFile
+	if path == "" {
+		path = name
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return "", fmt.Errorf("cannot write the rollback file, no node was changed: %w", err)
+	}
+	return path, nil
+}
+
+func readNodeRollback(path, clusterID, kind string) (*nodeRollback, error) {
+	content, err := os.ReadFile(path) //#nosec G304 -- path is given by the user
+	if err != nil {
+		return nil, err
+	}
+	rollback := &nodeRollback{}
+	if err := json.Unmarshal(content, rollback); err != nil {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "cannot parse the rollback file %s: %v", path, err)
+	}
+	if rollback.ClusterID != clusterID {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "the rollback file %s is for cluster %s, not %s", path, rollback.ClusterID, clusterID)
+	}
+	if rollback.Kind != kind {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "the rollback file %s undoes %ss, use 'osdctl cluster node %s'", path, rollback.Kind, rollback.Kind)
+	}
+	return rollback, nil
+}
+
+// applyNodeEdits edits the nodes one after the other, a failure doesn't stop the other nodes
+func (o *nodeEditOptions) applyNodeEdits(edits map[string][]string) error {
+	names := make([]string, 0, len(edits))
+	for name := range edits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failed []string
+	for _, name := range names {
+		args := append([]string{"label", "node", name}, edits[name]...)
+		if o.kind == nodeEditTaint {
+			args = append([]string{"adm", "taint", "node", name}, edits[name]...)
+		}
+		if _, err := o.run(append(args, "--overwrite")...); err != nil {
+			fmt.Fprintf(os.Stderr, "[%s] %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "[%s] %s\n", name, strings.Join(edits[name], " "))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to change the %ss of %s", o.kind, strings.Join(failed, ", "))
+	}
+	return nil
+}
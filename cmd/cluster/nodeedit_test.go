@@ -0,0 +1,120 @@
+package cluster
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseNodeEdits(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(parseLabelEdit("topology.kubernetes.io/zone=us-east-1a")).To(Equal(nodeEdit{key: "topology.kubernetes.io/zone", value: "us-east-1a"}))
+	g.Expect(parseLabelEdit("maintenance-")).To(Equal(nodeEdit{key: "maintenance", remove: true}))
+	_, err := parseLabelEdit("maintenance")
+	g.Expect(err).To(MatchError(ContainSubstring("expected KEY=VALUE or KEY-")))
+	_, err = parseLabelEdit("bad key=value")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid key")))
+
+	g.Expect(parseTaintEdit("bad-az=true:NoSchedule")).To(Equal(nodeEdit{key: "bad-az", value: "true", effect: corev1.TaintEffectNoSchedule}))
+	g.Expect(parseTaintEdit("bad-az:NoExecute")).To(Equal(nodeEdit{key: "bad-az", effect: corev1.TaintEffectNoExecute}))
+	g.Expect(parseTaintEdit("bad-az:NoSchedule-")).To(Equal(nodeEdit{key: "bad-az", effect: corev1.TaintEffectNoSchedule, remove: true}))
+	g.Expect(parseTaintEdit("bad-az-")).To(Equal(nodeEdit{key: "bad-az", remove: true}))
+	_, err = parseTaintEdit("bad-az=true")
+	g.Expect(err).To(MatchError(ContainSubstring("expected KEY[=VALUE]:EFFECT")))
+	_, err = parseTaintEdit("bad-az=true:Sometimes")
+	g.Expect(err).To(MatchError(ContainSubstring("invalid taint effect")))
+}
+
+func TestNodeChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+	node := testNode("worker-1", "a", true, map[string]string{"zone": "us-east-1a", "maintenance": "yes"})
+	node.Spec.Taints = []corev1.Taint{
+		{Key: "bad-az", Value: "old", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "bad-az", Effect: corev1.TaintEffectNoExecute},
+	}
+
+	labels := labelChanges(node, []nodeEdit{
+		{key: "zone", value: "us-east-1a"},
+		{key: "pool", value: "infra"},
+		{key: "maintenance", value: "no"},
+		{key: "missing", remove: true},
+	})
+	g.Expect(labels).To(Equal([]nodeChange{
+		{diff: "+ pool=infra", edit: "pool=infra", undo: []string{"pool-"}},
+		{diff: "~ maintenance: yes -> no", edit: "maintenance=no", undo: []string{"maintenance=yes"}},
+	}))
+
+	taints := taintChanges(node, []nodeEdit{
+		{key: "bad-az", value: "true", effect: corev1.TaintEffectNoSchedule},
+		{key: "cordoned", effect: corev1.TaintEffectPreferNoSchedule},
+		{key: "bad-az", remove: true},
+	})
+	g.Expect(taints).To(Equal([]nodeChange{
+		{diff: "~ bad-az=old:NoSchedule -> bad-az=true:NoSchedule", edit: "bad-az=true:NoSchedule", undo: []string{"bad-az=old:NoSchedule"}},
+		{diff: "+ cordoned:PreferNoSchedule", edit: "cordoned:PreferNoSchedule", undo: []string{"cordoned:PreferNoSchedule-"}},
+		{diff: "- bad-az=old:NoSchedule", edit: "bad-az:NoSchedule-", undo: []string{"bad-az=old:NoSchedule"}},
+		{diff: "- bad-az:NoExecute", edit: "bad-az:NoExecute-", undo: []string{"bad-az:NoExecute"}},
+	}))
+}
+
+func TestNodeEditRollback(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var calls []string
+	o := &nodeEditOptions{
+		clusterID:    "abc",
+		kind:         nodeEditLabel,
+		args:         []string{"zone=us-east-1b"},
+		edits:        []nodeEdit{{key: "zone", value: "us-east-1b"}},
+		rollbackFile: filepath.Join(t.TempDir(), "rollback.json"),
+		now:          func() time.Time { return time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC) },
+		run: func(args ...string) ([]byte, error) {
+			calls = append(calls, strings.Join(args, " "))
+			if args[2] == "worker-3" {
+				return nil, errors.New("nodes \"worker-3\" not found")
+			}
+			return nil, nil
+		},
+	}
+
+	nodes := []corev1.Node{
+		*testNode("worker-1", "a", true, map[string]string{"zone": "us-east-1a"}),
+		*testNode("worker-2", "a", true, map[string]string{"zone": "us-east-1b"}),
+		*testNode("worker-3", "a", true, nil),
+	}
+	edits, rollback := o.plan(nodes)
+	g.Expect(edits).To(Equal(map[string][]string{"worker-1": {"zone=us-east-1b"}, "worker-3": {"zone=us-east-1b"}}))
+
+	path, err := o.writeNodeRollback(rollback)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(path).To(Equal(o.rollbackFile))
+	info, err := os.Stat(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	// A failing node doesn't stop the others
+	g.Expect(o.applyNodeEdits(edits)).To(MatchError(ContainSubstring("failed to change the labels of worker-3")))
+	g.Expect(calls).To(Equal([]string{
+		"label node worker-1 zone=us-east-1b --overwrite",
+		"label node worker-3 zone=us-east-1b --overwrite",
+	}))
+
+	read, err := readNodeRollback(path, "abc", nodeEditLabel)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(read.Nodes).To(Equal(map[string][]string{"worker-1": {"zone=us-east-1a"}, "worker-3": {"zone-"}}))
+	_, err = readNodeRollback(path, "def", nodeEditLabel)
+	g.Expect(err).To(MatchError(ContainSubstring("is for cluster abc")))
+	_, err = readNodeRollback(path, "abc", nodeEditTaint)
+	g.Expect(err).To(MatchError(ContainSubstring("use 'osdctl cluster node label'")))
+
+	calls = nil
+	o.kind = nodeEditTaint
+	g.Expect(o.applyNodeEdits(map[string][]string{"worker-1": {"bad-az:NoSchedule-"}})).To(Succeed())
+	g.Expect(calls).To(Equal([]string{"adm taint node worker-1 bad-az:NoSchedule- --overwrite"}))
+}
@@ -0,0 +1,486 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	orphanedResourcesLong = `Looks for the load balancers and Elastic IPs of AWS clusters which are left behind, i.e. tagged as owned by the
+cluster but no longer used by it.
+
+A load balancer is orphaned when no instance or target is registered with it anymore, typically after its service
+was deleted while the cloud controller couldn't clean up. An Elastic IP is orphaned when it isn't associated with a
+network interface. Both keep being billed to the customer.
+
+With --daemon the clusters are scanned, and the scan printed, every --interval until the command is interrupted.
+The number of orphaned resources per cluster is served as Prometheus metrics on --metrics-address, and the resources
+found since the previous scan are posted to --webhook, which accepts Slack incoming webhooks. The AWS credentials
+are requested again for every scan.`
+
+	orphanedResourcesExample = `
+  # Report the orphaned load balancers and Elastic IPs of a cluster
+  osdctl cluster orphaned-resources 1kfmyclusteristhebesteverp8m
+
+  # Scan two clusters every 6 hours, serve the metrics and post the new findings to a webhook
+  osdctl cluster orphaned-resources 1kfmyclusteristhebesteverp8m 2abcdefghijklmnopqrstuvwxyz --daemon --interval 6h --webhook https://hooks.slack.com/services/T000/B000/XXXX
+`
+
+	orphanedLoadBalancer = "load_balancer"
+	orphanedElasticIP    = "elastic_ip"
+
+	// serviceNameTag is set by the cloud controller on the load balancers of the LoadBalancer services
+	serviceNameTag = "kubernetes.io/service-name"
+	// loadBalancerNotFound is the error code of both the classic and v2 load balancer APIs
+	loadBalancerNotFound = "LoadBalancerNotFound"
+)
+
+type orphanedResourcesOptions struct {
+	clusterIDs     []string
+	awsProfile     string
+	daemon         bool
+	interval       time.Duration
+	metricsAddress string
+	webhook        string
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// orphanedResource is a load balancer or Elastic IP owned by a cluster which doesn't use it
+type orphanedResource struct {
+	ClusterID string `json:"cluster_id" yaml:"cluster_id"`
+	Type      string `json:"type" yaml:"type"`
+	ID        string `json:"id" yaml:"id"`
+	Reason    string `json:"reason" yaml:"reason"`
+	// Service is the service the load balancer was created for
+	Service string `json:"service,omitempty" yaml:"service,omitempty"`
+}
+
+// key identifies the resource across scans
+func (r orphanedResource) key() string {
+	return r.ClusterID + "/" + r.Type + "/" + r.ID
+}
+
+type orphanedScanError struct {
+	ClusterID string `json:"cluster_id" yaml:"cluster_id"`
+	Error     string `json:"error" yaml:"error"`
+}
+
+type orphanedResourcesResponse struct {
+	ScannedAt time.Time           `json:"scanned_at" yaml:"scanned_at"`
+	Clusters  []string            `json:"clusters" yaml:"clusters"`
+	Resources []orphanedResource  `json:"resources" yaml:"resources"`
+	Errors    []orphanedScanError `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+func (r orphanedResourcesResponse) String() string {
+	var b bytes.Buffer
+	if len(r.Resources) == 0 {
+		fmt.Fprintf(&b, "No orphaned load balancer or Elastic IP in %d cluster(s)\n", len(r.Clusters))
+	} else {
+		table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+		table.AddRow([]string{"CLUSTER", "TYPE", "ID", "SERVICE", "REASON"})
+		for _, resource := range r.Resources {
+			table.AddRow([]string{resource.ClusterID, resource.Type, resource.ID, resource.Service, resource.Reason})
+		}
+		// Add empty row for readability
+		table.AddRow([]string{})
+		_ = table.Flush()
+	}
+	for _, scanError := range r.Errors {
+		fmt.Fprintf(&b, "Cannot scan cluster %s: %s\n", scanError.ClusterID, scanError.Error)
+	}
+	return b.String()
+}
+
+func newCmdOrphanedResources(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &orphanedResourcesOptions{GlobalOptions: globalOpts}
+	orphanedResourcesCmd := &cobra.Command{
+		Use:               "orphaned-resources CLUSTER_ID...",
+		Short:             "Find the load balancers and Elastic IPs left behind by AWS clusters",
+		Long:              orphanedResourcesLong,
+		Example:           orphanedResourcesExample,
+		Args:              cobra.MinimumNArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	orphanedResourcesCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile used to reach the accounts of the clusters")
+	orphanedResourcesCmd.Flags().BoolVar(&ops.daemon, "daemon", false, "Scan the clusters every --interval until interrupted, serving the findings as metrics")
+	orphanedResourcesCmd.Flags().DurationVar(&ops.interval, "interval", 6*time.Hour, "Time between two scans with --daemon")
+	orphanedResourcesCmd.Flags().StringVar(&ops.metricsAddress, "metrics-address", ":9108", "Address serving the Prometheus metrics with --daemon")
+	orphanedResourcesCmd.Flags().StringVar(&ops.webhook, "webhook", "", "URL the new findings are posted to as JSON, e.g. a Slack incoming webhook")
+
+	return orphanedResourcesCmd
+}
+
+func (o *orphanedResourcesOptions) complete(cmd *cobra.Command, args []string) error {
+	for _, clusterID := range args {
+		if err := utils.IsValidClusterKey(clusterID); err != nil {
+			return err
+		}
+	}
+	o.clusterIDs = args
+
+	if !o.daemon {
+		for _, flag := range []string{"interval", "metrics-address"} {
+			if cmd.Flags().Changed(flag) {
+				return cmdutil.UsageErrorf(cmd, "--%s requires --daemon", flag)
+			}
+		}
+		return nil
+	}
+	if o.interval < time.Minute {
+		return cmdutil.UsageErrorf(cmd, "--interval must be at least 1m")
+	}
+	return nil
+}
+
+func (o *orphanedResourcesOptions) run() error {
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	if o.daemon {
+		return o.runDaemon(deadline.Context(), ocmClient)
+	}
+
+	response := o.scan(ocmClient)
+	if err := outputflag.PrintResponse(o.GlobalOptions.Output, response); err != nil {
+		return err
+	}
+	if o.webhook != "" && len(response.Resources) > 0 {
+		return postOrphanedResources(http.DefaultClient, o.webhook, response.Resources)
+	}
+	return nil
+}
+
+// runDaemon scans the clusters every interval and serves the findings of the last scan
+func (o *orphanedResourcesOptions) runDaemon(ctx context.Context, ocmClient *sdk.Connection) error {
+	metrics := newOrphanedResourcesMetrics()
+	server := &http.Server{
+		Addr:              o.metricsAddress,
+		Handler:           metrics.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverErrors := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErrors <- err
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(os.Stderr, "Serving the metrics on %s/metrics, scanning every %s\n", o.metricsAddress, o.interval)
+
+	reported := map[string]bool{}
+	for {
+		response := o.scan(ocmClient)
+		metrics.record(response)
+		if err := outputflag.PrintResponse(o.GlobalOptions.Output, response); err != nil {
+			return err
+		}
+
+		var found []orphanedResource
+		found, reported = newOrphanedResources(reported, response)
+		if o.webhook != "" && len(found) > 0 {
+			if err := postOrphanedResources(http.DefaultClient, o.webhook, found); err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot post the findings: %v\n", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-serverErrors:
+			return fmt.Errorf("cannot serve the metrics: %w", err)
+		case <-time.After(o.interval):
+		}
+	}
+}
+
+// scan looks for the orphaned resources of every cluster, a cluster which can't be scanned is reported in the errors
+func (o *orphanedResourcesOptions) scan(ocmClient *sdk.Connection) orphanedResourcesResponse {
+	response := orphanedResourcesResponse{ScannedAt: time.Now().UTC(), Clusters: o.clusterIDs, Resources: []orphanedResource{}}
+	for _, clusterID := range o.clusterIDs {
+		resources, err := o.scanCluster(ocmClient, clusterID)
+		if err != nil {
+			response.Errors = append(response.Errors, orphanedScanError{ClusterID: clusterID, Error: err.Error()})
+			continue
+		}
+		response.Resources = append(response.Resources, resources...)
+	}
+	return response
+}
+
+func (o *orphanedResourcesOptions) scanCluster(ocmClient *sdk.Connection, clusterID string) ([]orphanedResource, error) {
+	cluster, err := utils.GetCluster(ocmClient, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.CloudProvider().ID() != "aws" {
+		return nil, fmt.Errorf("only AWS clusters are supported, the cluster is on %s", cluster.CloudProvider().ID())
+	}
+	if cluster.InfraID() == "" {
+		return nil, errors.New("the cluster has no infrastructure ID yet")
+	}
+	awsClient, err := osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+	if err != nil {
+		return nil, err
+	}
+	return findOrphanedResources(awsClient, cluster.ID(), cluster.InfraID())
+}
+
+// findOrphanedResources returns the load balancers without targets and the unassociated Elastic IPs which are
+// tagged as owned by the cluster
+func findOrphanedResources(awsClient awsprovider.Client, clusterID, infraID string) ([]orphanedResource, error) {
+	ownerTag := "kubernetes.io/cluster/" + infraID
+
+	var resources []orphanedResource
+	addresses, err := awsClient.DescribeAddresses(&ec2.DescribeAddressesInput{
+		Filters: []*ec2.Filter{{Name: awsSdk.String("tag-key"), Values: awsSdk.StringSlice([]string{ownerTag})}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the Elastic IPs: %w", err)
+	}
+	for _, address := range addresses.Addresses {
+		if address.AssociationId == nil {
+			resources = append(resources, orphanedResource{
+				ClusterID: clusterID,
+				Type:      orphanedElasticIP,
+				ID:        fmt.Sprintf("%s (%s)", awsSdk.StringValue(address.AllocationId), awsSdk.StringValue(address.PublicIp)),
+				Reason:    "not associated with a network interface",
+			})
+		}
+	}
+
+	input := &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: awsSdk.StringSlice([]string{"elasticloadbalancing:loadbalancer"}),
+		TagFilters:          []*resourcegroupstaggingapi.TagFilter{{Key: awsSdk.String(ownerTag)}},
+	}
+	for {
+		output, err := awsClient.GetResources(input)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the load balancers: %w", err)
+		}
+		for _, mapping := range output.ResourceTagMappingList {
+			service := ""
+			for _, tag := range mapping.Tags {
+				if awsSdk.StringValue(tag.Key) == serviceNameTag {
+					service = awsSdk.StringValue(tag.Value)
+				}
+			}
+			resource, err := checkLoadBalancerRegistrations(awsClient, awsSdk.StringValue(mapping.ResourceARN))
+			if err != nil {
+				return nil, err
+			}
+			if resource != nil {
+				resource.ClusterID, resource.Service = clusterID, service
+				resources = append(resources, *resource)
+			}
+		}
+		if awsSdk.StringValue(output.PaginationToken) == "" {
+			break
+		}
+		input.PaginationToken = output.PaginationToken
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		if resources[i].Type != resources[j].Type {
+			return resources[i].Type > resources[j].Type
+		}
+		return resources[i].ID < resources[j].ID
+	})
+	return resources, nil
+}
+
+// checkLoadBalancerRegistrations returns the load balancer of the ARN when nothing is registered with it, a load
+// balancer deleted since the tagging API listed it is skipped
+func checkLoadBalancerRegistrations(awsClient awsprovider.Client, lbARN string) (*orphanedResource, error) {
+	parsed, err := arn.Parse(lbARN)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the load balancer ARN %s: %w", lbARN, err)
+	}
+	// Classic load balancers are loadbalancer/NAME, the v2 ones loadbalancer/net/NAME/ID or loadbalancer/app/NAME/ID
+	parts := strings.Split(strings.TrimPrefix(parsed.Resource, "loadbalancer/"), "/")
+
+	if len(parts) == 1 {
+		output, err := awsClient.DescribeLoadBalancers(&elb.DescribeLoadBalancersInput{LoadBalancerNames: awsSdk.StringSlice(parts)})
+		if isLoadBalancerNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot describe the load balancer %s: %w", parts[0], err)
+		}
+		for _, lb := range output.LoadBalancerDescriptions {
+			if len(lb.Instances) > 0 {
+				return nil, nil
+			}
+		}
+		return &orphanedResource{Type: orphanedLoadBalancer, ID: parts[0], Reason: "classic load balancer without registered instances"}, nil
+	}
+
+	name := parts[1]
+	groups, err := awsClient.DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{LoadBalancerArn: awsSdk.String(lbARN)})
+	if isLoadBalancerNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the target groups of the load balancer %s: %w", name, err)
+	}
+	for _, group := range groups.TargetGroups {
+		health, err := awsClient.DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{TargetGroupArn: group.TargetGroupArn})
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the targets of %s: %w", awsSdk.StringValue(group.TargetGroupName), err)
+		}
+		if len(health.TargetHealthDescriptions) > 0 {
+			return nil, nil
+		}
+	}
+	return &orphanedResource{Type: orphanedLoadBalancer, ID: name, Reason: fmt.Sprintf("%s load balancer without registered targets", parts[0])}, nil
+}
+
+func isLoadBalancerNotFound(err error) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == loadBalancerNotFound
+}
+
+// newOrphanedResources returns the resources of the scan absent from the previous one, and the keys of the scan.
+// The resources of the clusters which couldn't be scanned are kept so they aren't reported again.
+func newOrphanedResources(previous map[string]bool, response orphanedResourcesResponse) ([]orphanedResource, map[string]bool) {
+	failed := map[string]bool{}
+	for _, scanError := range response.Errors {
+		failed[scanError.ClusterID] = true
+	}
+	current := map[string]bool{}
+	for key := range previous {
+		if failed[strings.SplitN(key, "/", 2)[0]] {
+			current[key] = true
+		}
+	}
+
+	var found []orphanedResource
+	for _, resource := range response.Resources {
+		if !previous[resource.key()] {
+			found = append(found, resource)
+		}
+		current[resource.key()] = true
+	}
+	return found, current
+}
+
+// postOrphanedResources posts the resources as JSON, the text field makes Slack incoming webhooks show a summary
+func postOrphanedResources(client *http.Client, webhook string, resources []orphanedResource) error {
+	lines := []string{fmt.Sprintf("%d orphaned resource(s) found:", len(resources))}
+	for _, resource := range resources {
+		lines = append(lines, fmt.Sprintf("• %s %s of cluster %s: %s", resource.Type, resource.ID, resource.ClusterID, resource.Reason))
+	}
+	body, err := json.Marshal(map[string]interface{}{"text": strings.Join(lines, "\n"), "resources": resources})
+	if err != nil {
+		return err
+	}
+	response, err := client.Post(webhook, "application/json", bytes.NewReader(body)) //#nosec G107 -- the webhook is the user's
+	if err != nil {
+		return fmt.Errorf("cannot post to the webhook: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("cannot post to the webhook: %s", response.Status)
+	}
+	return nil
+}
+
+// orphanedResourcesMetrics are the metrics of the last scan served by the daemon
+type orphanedResourcesMetrics struct {
+	registry  *prometheus.Registry
+	resources *prometheus.GaugeVec
+	failed    *prometheus.GaugeVec
+	lastScan  prometheus.Gauge
+}
+
+func newOrphanedResourcesMetrics() *orphanedResourcesMetrics {
+	m := &orphanedResourcesMetrics{
+		registry: prometheus.NewRegistry(),
+		resources: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "osdctl_orphaned_resources",
+			Help: "Number of orphaned resources of the cluster found by the last scan",
+		}, []string{"cluster_id", "type"}),
+		failed: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "osdctl_orphaned_resources_scan_failed",
+			Help: "Whether the last scan of the cluster failed",
+		}, []string{"cluster_id"}),
+		lastScan: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "osdctl_orphaned_resources_last_scan_timestamp_seconds",
+			Help: "Time of the last scan",
+		}),
+	}
+	m.registry.MustRegister(m.resources, m.failed, m.lastScan)
+	return m
+}
+
+// record replaces the metrics with the ones of the scan, the count of a cluster which can't be scanned is kept
+func (m *orphanedResourcesMetrics) record(response orphanedResourcesResponse) {
+	failed := map[string]bool{}
+	for _, scanError := range response.Errors {
+		failed[scanError.ClusterID] = true
+	}
+	for _, clusterID := range response.Clusters {
+		if failed[clusterID] {
+			m.failed.WithLabelValues(clusterID).Set(1)
+			continue
+		}
+		m.failed.WithLabelValues(clusterID).Set(0)
+		counts := map[string]int{orphanedLoadBalancer: 0, orphanedElasticIP: 0}
+		for _, resource := range response.Resources {
+			if resource.ClusterID == clusterID {
+				counts[resource.Type]++
+			}
+		}
+		for resourceType, count := range counts {
+			m.resources.WithLabelValues(clusterID, resourceType).Set(float64(count))
+		}
+	}
+	m.lastScan.Set(float64(response.ScannedAt.Unix()))
+}
+
+func (m *orphanedResourcesMetrics) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	return mux
+}
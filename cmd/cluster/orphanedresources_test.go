@@ -0,0 +1,135 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func TestFindOrphanedResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+	ownerTag := "kubernetes.io/cluster/mycluster-x7k2p"
+	lbARN := func(resource string) *string {
+		return awsSdk.String("arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/" + resource)
+	}
+
+	mockAWSClient.EXPECT().DescribeAddresses(&ec2.DescribeAddressesInput{
+		Filters: []*ec2.Filter{{Name: awsSdk.String("tag-key"), Values: awsSdk.StringSlice([]string{ownerTag})}},
+	}).Return(&ec2.DescribeAddressesOutput{Addresses: []*ec2.Address{
+		{AllocationId: awsSdk.String("eipalloc-used"), PublicIp: awsSdk.String("3.3.3.3"), AssociationId: awsSdk.String("eipassoc-1")},
+		{AllocationId: awsSdk.String("eipalloc-leaked"), PublicIp: awsSdk.String("4.4.4.4")},
+	}}, nil)
+	mockAWSClient.EXPECT().GetResources(gomock.Any()).Return(&resourcegroupstaggingapi.GetResourcesOutput{
+		ResourceTagMappingList: []*resourcegroupstaggingapi.ResourceTagMapping{
+			{ResourceARN: lbARN("a1b2c3"), Tags: []*resourcegroupstaggingapi.Tag{{Key: awsSdk.String(serviceNameTag), Value: awsSdk.String("shop/frontend")}}},
+			{ResourceARN: lbARN("router")},
+			{ResourceARN: lbARN("net/d4e5f6/0123456789abcdef")},
+			{ResourceARN: lbARN("net/deleted/fedcba9876543210")},
+		},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeLoadBalancers(&elb.DescribeLoadBalancersInput{LoadBalancerNames: awsSdk.StringSlice([]string{"a1b2c3"})}).
+		Return(&elb.DescribeLoadBalancersOutput{LoadBalancerDescriptions: []*elb.LoadBalancerDescription{{LoadBalancerName: awsSdk.String("a1b2c3")}}}, nil)
+	mockAWSClient.EXPECT().DescribeLoadBalancers(&elb.DescribeLoadBalancersInput{LoadBalancerNames: awsSdk.StringSlice([]string{"router"})}).
+		Return(&elb.DescribeLoadBalancersOutput{LoadBalancerDescriptions: []*elb.LoadBalancerDescription{
+			{LoadBalancerName: awsSdk.String("router"), Instances: []*elb.Instance{{InstanceId: awsSdk.String("i-1")}}},
+		}}, nil)
+	mockAWSClient.EXPECT().DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{LoadBalancerArn: lbARN("net/d4e5f6/0123456789abcdef")}).
+		Return(&elbv2.DescribeTargetGroupsOutput{TargetGroups: []*elbv2.TargetGroup{{TargetGroupArn: awsSdk.String("tg-1")}}}, nil)
+	mockAWSClient.EXPECT().DescribeTargetHealth(&elbv2.DescribeTargetHealthInput{TargetGroupArn: awsSdk.String("tg-1")}).
+		Return(&elbv2.DescribeTargetHealthOutput{}, nil)
+	mockAWSClient.EXPECT().DescribeTargetGroups(&elbv2.DescribeTargetGroupsInput{LoadBalancerArn: lbARN("net/deleted/fedcba9876543210")}).
+		Return(nil, awserr.New(loadBalancerNotFound, "not found", nil))
+
+	resources, err := findOrphanedResources(mockAWSClient, "abc123", "mycluster-x7k2p")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resources).To(Equal([]orphanedResource{
+		{ClusterID: "abc123", Type: orphanedLoadBalancer, ID: "a1b2c3", Service: "shop/frontend", Reason: "classic load balancer without registered instances"},
+		{ClusterID: "abc123", Type: orphanedLoadBalancer, ID: "d4e5f6", Reason: "net load balancer without registered targets"},
+		{ClusterID: "abc123", Type: orphanedElasticIP, ID: "eipalloc-leaked (4.4.4.4)", Reason: "not associated with a network interface"},
+	}))
+
+	mockAWSClient.EXPECT().DescribeAddresses(gomock.Any()).Return(nil, errors.New("UnauthorizedOperation"))
+	_, err = findOrphanedResources(mockAWSClient, "abc123", "mycluster-x7k2p")
+	g.Expect(err).To(MatchError(ContainSubstring("cannot list the Elastic IPs")))
+}
+
+func TestNewOrphanedResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+	lb := orphanedResource{ClusterID: "abc123", Type: orphanedLoadBalancer, ID: "a1b2c3"}
+	eip := orphanedResource{ClusterID: "def456", Type: orphanedElasticIP, ID: "eipalloc-1"}
+
+	found, reported := newOrphanedResources(map[string]bool{}, orphanedResourcesResponse{Resources: []orphanedResource{lb, eip}})
+	g.Expect(found).To(Equal([]orphanedResource{lb, eip}))
+
+	// The resources of a cluster which can't be scanned aren't reported again once it can
+	found, reported = newOrphanedResources(reported, orphanedResourcesResponse{
+		Resources: []orphanedResource{lb},
+		Errors:    []orphanedScanError{{ClusterID: "def456", Error: "throttled"}},
+	})
+	g.Expect(found).To(BeEmpty())
+	found, _ = newOrphanedResources(reported, orphanedResourcesResponse{Resources: []orphanedResource{lb, eip}})
+	g.Expect(found).To(BeEmpty())
+
+	// A resource cleaned up and leaked again is reported again
+	found, reported = newOrphanedResources(reported, orphanedResourcesResponse{Resources: []orphanedResource{eip}})
+	g.Expect(found).To(BeEmpty())
+	found, _ = newOrphanedResources(reported, orphanedResourcesResponse{Resources: []orphanedResource{lb, eip}})
+	g.Expect(found).To(Equal([]orphanedResource{lb}))
+}
+
+func TestOrphanedResourcesMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+	metrics := newOrphanedResourcesMetrics()
+	metrics.record(orphanedResourcesResponse{
+		ScannedAt: time.Unix(1760443200, 0),
+		Clusters:  []string{"abc123", "def456"},
+		Resources: []orphanedResource{
+			{ClusterID: "abc123", Type: orphanedLoadBalancer, ID: "a1b2c3"},
+			{ClusterID: "abc123", Type: orphanedLoadBalancer, ID: "d4e5f6"},
+		},
+		Errors: []orphanedScanError{{ClusterID: "def456", Error: "throttled"}},
+	})
+
+	server := httptest.NewServer(metrics.handler())
+	defer server.Close()
+	response, err := http.Get(server.URL + "/metrics")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(string(body)).To(ContainSubstring(`osdctl_orphaned_resources{cluster_id="abc123",type="load_balancer"} 2`))
+	g.Expect(string(body)).To(ContainSubstring(`osdctl_orphaned_resources{cluster_id="abc123",type="elastic_ip"} 0`))
+	g.Expect(string(body)).To(ContainSubstring(`osdctl_orphaned_resources_scan_failed{cluster_id="def456"} 1`))
+	g.Expect(string(body)).To(ContainSubstring(`osdctl_orphaned_resources_last_scan_timestamp_seconds 1.7604432e+09`))
+	g.Expect(string(body)).NotTo(ContainSubstring(`cluster_id="def456",type`))
+}
+
+func TestPostOrphanedResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var posted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Expect(json.NewDecoder(r.Body).Decode(&posted)).To(Succeed())
+	}))
+	defer server.Close()
+
+	resources := []orphanedResource{{ClusterID: "abc123", Type: orphanedElasticIP, ID: "eipalloc-1", Reason: "not associated with a network interface"}}
+	g.Expect(postOrphanedResources(server.Client(), server.URL, resources)).To(Succeed())
+	g.Expect(posted["text"]).To(Equal("1 orphaned resource(s) found:\n• elastic_ip eipalloc-1 of cluster abc123: not associated with a network interface"))
+	g.Expect(posted["resources"]).To(HaveLen(1))
+}
@@ -4,23 +4,56 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
-	"os"
 
 	v1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+const (
+	ownerLong = `Without a cluster ID, lists the clusters owned by the user, the current one or the one of --user-id.
+
+With a cluster ID, prints the SRE team owning the cluster, its escalation path, PagerDuty service and Slack channel,
+so misrouted pages can be redirected. The team is found by matching the subscription labels of the cluster with the
+rules of the team routing file, the first matching rule wins:
+
+  rules:
+    - labels: {"sre.openshift.io/team": "payments"}
+      team: payments-sre
+      escalation: payments-sre primary, then the SRE platform manager
+      pagerduty_service: P1234AB
+      slack_channel: "#payments-sre"
+    - labels: {"sre.openshift.io/product": "*"}
+      team: product-sre
+  default:
+    team: sre-platform
+    slack_channel: "#sd-sre-platform"
+
+A "*" value matches any value of the label. The file is read from --routing-file, team_routing_file of the config
+file, or ~/.config/osdctl-team-routing.yaml.`
+
+	ownerExample = `
+  # List the clusters of the current user
+  osdctl cluster owner
+
+  # Print the team owning a cluster
+  osdctl cluster owner 1kfmyclusteristhebesteverp8m
+`
 )
 
 // ownerOptions defines the struct for the current command
 // This command requires the ocm API Token https://cloud.redhat.com/openshift/token be available in the OCM_TOKEN env variable.
 type ownerOptions struct {
-	output   string
-	verbose  bool
-	userName string
+	output      string
+	verbose     bool
+	userName    string
+	clusterID   string
+	routingFile string
 
 	genericclioptions.IOStreams
 	GlobalOptions *globalflags.GlobalOptions
@@ -30,16 +63,23 @@ type ownerOptions struct {
 func newCmdOwner(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
 	ops := newOwnerOptions(streams, flags, globalOpts)
 	ownerCmd := &cobra.Command{
-		Use:               "owner",
-		Short:             "List the clusters owned by the user (can be specified to any user, not only yourself)",
-		Args:              cobra.NoArgs,
+		Use:               "owner [CLUSTER_ID]",
+		Short:             "List the clusters owned by a user (yourself by default), or print the team owning a cluster",
+		Long:              ownerLong,
+		Example:           ownerExample,
+		Args:              cobra.MaximumNArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			if ops.clusterID != "" {
+				osdctlErrors.CheckErr(ops.runRouting())
+				return
+			}
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	ownerCmd.Flags().StringVarP(&ops.userName, "user-id", "u", ops.userName, "user to check the cluster owner on")
+	ownerCmd.Flags().StringVar(&ops.routingFile, "routing-file", "", "File mapping the subscription labels to the owning teams, defaults to "+TeamRoutingConfigKey+" of the config file or ~/.config/"+defaultTeamRoutingName)
 
 	return ownerCmd
 }
@@ -51,10 +91,24 @@ func newOwnerOptions(streams genericclioptions.IOStreams, flags *genericclioptio
 	}
 }
 
-func (o *ownerOptions) complete(cmd *cobra.Command, _ []string) error {
+func (o *ownerOptions) complete(cmd *cobra.Command, args []string) error {
 
 	o.output = o.GlobalOptions.Output
 
+	if len(args) == 0 {
+		if cmd.Flags().Changed("routing-file") {
+			return cmdutil.UsageErrorf(cmd, "--routing-file requires a cluster ID")
+		}
+		return nil
+	}
+	if cmd.Flags().Changed("user-id") {
+		return cmdutil.UsageErrorf(cmd, "--user-id lists the clusters of a user and can't be used with a cluster ID")
+	}
+	if err := utils.IsValidClusterKey(args[0]); err != nil {
+		return err
+	}
+	o.clusterID = args[0]
+
 	return nil
 }
 
@@ -67,7 +121,7 @@ func (o *ownerOptions) run() error {
 	)
 
 	if accountName == "" {
-		fmt.Println("using the current user")
+		fmt.Fprintln(o.ErrOut, "using the current user")
 		response, err := connection.AccountsMgmt().V1().CurrentAccount().Get().
 			Send()
 		if err != nil {
@@ -89,7 +143,7 @@ func (o *ownerOptions) run() error {
 		}
 		searchString := filledUpUsernameQuery.String()
 		if o.verbose {
-			fmt.Printf("the search query is '%s'\n", searchString)
+			fmt.Fprintf(o.ErrOut, "the search query is '%s'\n", searchString)
 		}
 
 		response, err := connection.AccountsMgmt().V1().Accounts().List().Parameter("search", searchString).
@@ -100,14 +154,14 @@ func (o *ownerOptions) run() error {
 		}
 
 		if response.Total() != 1 {
-			fmt.Println("Found users:")
-			err := v1.MarshalAccountList(response.Items().Slice(), os.Stdout)
+			fmt.Fprintln(o.ErrOut, "Found users:")
+			err := v1.MarshalAccountList(response.Items().Slice(), o.ErrOut)
 			if err != nil {
-				fmt.Println("error while marshalling account list: ", err.Error())
+				fmt.Fprintln(o.ErrOut, "error while marshalling account list: ", err.Error())
 				return err
 			}
 			// newline is required as MarshalAccountList doesn't enter a newline once the object is written down
-			fmt.Println()
+			fmt.Fprintln(o.ErrOut)
 			return fmt.Errorf("given username '%s' is not unique, found '%d' matches", accountName, response.Total())
 		}
 		accountID = response.Items().Get(0).ID()
@@ -118,7 +172,7 @@ func (o *ownerOptions) run() error {
 		return fmt.Errorf("could not extract the accountID")
 	}
 
-	fmt.Printf("the user is '%s' with ID '%s'\n", accountName, accountID)
+	fmt.Fprintf(o.ErrOut, "the user is '%s' with ID '%s'\n", accountName, accountID)
 
 	const subscriptionQuery = "creator.id = '%s' and status != 'Deprovisioned' and status != 'Archived'"
 	searchString := fmt.Sprintf(subscriptionQuery, accountID)
@@ -126,7 +180,7 @@ func (o *ownerOptions) run() error {
 		Send()
 
 	if o.verbose {
-		fmt.Printf("the search query is '%s'\n", searchString)
+		fmt.Fprintf(o.ErrOut, "the search query is '%s'\n", searchString)
 	}
 
 	if err != nil {
@@ -137,7 +191,7 @@ func (o *ownerOptions) run() error {
 		return nil
 	}
 
-	fmt.Printf("'User %s owns the following clusters (total %d):\n", accountName, response.Total())
+	fmt.Fprintf(o.ErrOut, "'User %s owns the following clusters (total %d):\n", accountName, response.Total())
 
 	for _, i := range response.Items().Slice() {
 		fmt.Println(i.ExternalClusterID())
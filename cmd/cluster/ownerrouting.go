@@ -0,0 +1,186 @@
+package cluster
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/viper"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// TeamRoutingConfigKey overrides the location of the file mapping the subscription labels to the owning teams
+	TeamRoutingConfigKey = "team_routing_file"
+
+	defaultTeamRoutingName = "osdctl-team-routing.yaml"
+)
+
+// teamRouting maps the subscription labels of the clusters to the team owning them, the first matching rule wins
+type teamRouting struct {
+	Rules   []teamRoutingRule `json:"rules"`
+	Default *teamRoute        `json:"default,omitempty"`
+}
+
+type teamRoutingRule struct {
+	// Labels all have to be set on the subscription, a "*" value matches any value of the label
+	Labels map[string]string `json:"labels"`
+	teamRoute
+}
+
+// teamRoute is where the pages of a cluster are routed
+type teamRoute struct {
+	Team             string `json:"team" yaml:"team"`
+	Escalation       string `json:"escalation,omitempty" yaml:"escalation,omitempty"`
+	PagerDutyService string `json:"pagerduty_service,omitempty" yaml:"pagerduty_service,omitempty"`
+	SlackChannel     string `json:"slack_channel,omitempty" yaml:"slack_channel,omitempty"`
+}
+
+type clusterOwnerResponse struct {
+	ClusterID   string `json:"cluster_id" yaml:"cluster_id"`
+	ClusterName string `json:"cluster_name" yaml:"cluster_name"`
+	teamRoute   `json:",inline" yaml:",inline"`
+	// MatchedLabels are the labels of the rule which matched, empty when the default route is used
+	MatchedLabels map[string]string `json:"matched_labels,omitempty" yaml:"matched_labels,omitempty"`
+}
+
+func (r clusterOwnerResponse) String() string {
+	var b bytes.Buffer
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Cluster:", fmt.Sprintf("%s (%s)", r.ClusterName, r.ClusterID)})
+	table.AddRow([]string{"Team:", r.Team})
+	table.AddRow([]string{"Escalation:", r.Escalation})
+	table.AddRow([]string{"PagerDuty service:", r.PagerDutyService})
+	table.AddRow([]string{"Slack channel:", r.SlackChannel})
+	if len(r.MatchedLabels) == 0 {
+		table.AddRow([]string{"Matched:", "no rule, default route"})
+	} else {
+		table.AddRow([]string{"Matched:", formatLabels(r.MatchedLabels)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	return b.String()
+}
+
+func formatLabels(labels map[string]string) string {
+	var pairs []string
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// teamRoutingPath returns the location of the routing file
+func teamRoutingPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	if path := viper.GetString(TeamRoutingConfigKey); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", defaultTeamRoutingName), nil
+}
+
+func loadTeamRouting(path string) (*teamRouting, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "no team routing file at %s, pass --routing-file or set %s in the config file", path, TeamRoutingConfigKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	routing := &teamRouting{}
+	if err := yaml.UnmarshalStrict(content, routing); err != nil {
+		return nil, fmt.Errorf("cannot parse the team routing file %s: %w", path, err)
+	}
+	if len(routing.Rules) == 0 && routing.Default == nil {
+		return nil, fmt.Errorf("the team routing file %s has neither rules nor a default route", path)
+	}
+	for i, rule := range routing.Rules {
+		if len(rule.Labels) == 0 {
+			return nil, fmt.Errorf("rule %d of the team routing file has no labels", i+1)
+		}
+		if rule.Team == "" {
+			return nil, fmt.Errorf("rule %d of the team routing file has no team", i+1)
+		}
+	}
+	if routing.Default != nil && routing.Default.Team == "" {
+		return nil, fmt.Errorf("the default route of the team routing file has no team")
+	}
+	return routing, nil
+}
+
+// route returns the route of the first rule matching the labels, or the default route
+func (r *teamRouting) route(labels map[string]string) (teamRoute, map[string]string, bool) {
+	for _, rule := range r.Rules {
+		if labelsMatch(rule.Labels, labels) {
+			return rule.teamRoute, rule.Labels, true
+		}
+	}
+	if r.Default != nil {
+		return *r.Default, nil, true
+	}
+	return teamRoute{}, nil, false
+}
+
+func labelsMatch(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		actual, ok := labels[key]
+		if !ok || (value != "*" && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// runRouting prints the team owning the cluster according to its subscription labels
+func (o *ownerOptions) runRouting() error {
+	path, err := teamRoutingPath(o.routingFile)
+	if err != nil {
+		return err
+	}
+	routing, err := loadTeamRouting(path)
+	if err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	subscriptionLabels, err := listLabels(connection, cluster, labelScopeSubscription)
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{}
+	for _, label := range subscriptionLabels {
+		labels[label.Key] = label.Value
+	}
+
+	route, matched, ok := routing.route(labels)
+	if !ok {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "no rule of %s matches the subscription labels of the cluster (%s) and there is no default route", path, formatLabels(labels))
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, clusterOwnerResponse{
+		ClusterID:     cluster.ID(),
+		ClusterName:   cluster.Name(),
+		teamRoute:     route,
+		MatchedLabels: matched,
+	})
+}
@@ -0,0 +1,99 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"gopkg.in/yaml.v2"
+)
+
+const testTeamRouting = `
+rules:
+  - labels: {"sre.openshift.io/team": "payments"}
+    team: payments-sre
+    escalation: payments-sre primary, then the SRE platform manager
+    pagerduty_service: P1234AB
+    slack_channel: "#payments-sre"
+  - labels: {"sre.openshift.io/product": "*", "sre.openshift.io/tier": "critical"}
+    team: product-sre
+default:
+  team: sre-platform
+  slack_channel: "#sd-sre-platform"
+`
+
+func writeTeamRouting(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "routing.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTeamRouting(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	routing, err := loadTeamRouting(writeTeamRouting(t, testTeamRouting))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	route, matched, ok := routing.route(map[string]string{"sre.openshift.io/team": "payments", "sre.openshift.io/tier": "critical"})
+	g.Expect(ok).To(BeTrue())
+	g.Expect(route).To(Equal(teamRoute{Team: "payments-sre", Escalation: "payments-sre primary, then the SRE platform manager", PagerDutyService: "P1234AB", SlackChannel: "#payments-sre"}))
+	g.Expect(matched).To(Equal(map[string]string{"sre.openshift.io/team": "payments"}))
+
+	route, _, _ = routing.route(map[string]string{"sre.openshift.io/product": "osd", "sre.openshift.io/tier": "critical"})
+	g.Expect(route.Team).To(Equal("product-sre"))
+
+	// Every label of a rule has to match
+	route, matched, ok = routing.route(map[string]string{"sre.openshift.io/product": "osd"})
+	g.Expect(ok).To(BeTrue())
+	g.Expect(route.Team).To(Equal("sre-platform"))
+	g.Expect(matched).To(BeNil())
+
+	routing.Default = nil
+	_, _, ok = routing.route(map[string]string{})
+	g.Expect(ok).To(BeFalse())
+}
+
+func TestLoadTeamRoutingErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := loadTeamRouting(filepath.Join(t.TempDir(), "missing.yaml"))
+	g.Expect(errors.Is(err, osdctlErrors.ErrValidation)).To(BeTrue())
+
+	for _, invalid := range []string{
+		"rules: []",
+		"rules:\n  - team: a\n",
+		"rules:\n  - labels: {a: b}\n",
+		"default:\n  slack_channel: '#a'\n",
+		"default:\n  team: a\n  pager: P1\n",
+	} {
+		_, err := loadTeamRouting(writeTeamRouting(t, invalid))
+		g.Expect(err).To(HaveOccurred(), invalid)
+	}
+}
+
+func TestClusterOwnerResponse(t *testing.T) {
+	g := NewGomegaWithT(t)
+	response := clusterOwnerResponse{
+		ClusterID:     "abc123",
+		ClusterName:   "payments-prod",
+		teamRoute:     teamRoute{Team: "payments-sre", SlackChannel: "#payments-sre"},
+		MatchedLabels: map[string]string{"sre.openshift.io/team": "payments"},
+	}
+
+	g.Expect(response.String()).To(ContainSubstring("sre.openshift.io/team=payments"))
+	g.Expect(response.String()).To(MatchRegexp(`Slack channel:\s+#payments-sre`))
+
+	// The route is inlined in the JSON and YAML outputs
+	content, err := json.Marshal(response)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(ContainSubstring(`"team":"payments-sre","slack_channel":"#payments-sre"`))
+	content, err = yaml.Marshal(response)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(ContainSubstring("\nteam: payments-sre\n"))
+}
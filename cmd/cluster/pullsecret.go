@@ -0,0 +1,328 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	hiveClusterIDLabel = "api.openshift.com/id"
+	dockerConfigJSON   = ".dockerconfigjson"
+
+	pullSecretInSync   = "in sync"
+	pullSecretOutdated = "outdated"
+	pullSecretMissing  = "missing"
+	pullSecretExtra    = "not in OCM"
+)
+
+const pullSecretLong = `Inspect and update the pull secret of a cluster.
+
+The pull secret is read from the ClusterDeployment on the Hive shard and compared to the access token
+OCM currently issues for the cluster owner. The caller must be logged in to the Hive shard of the cluster.`
+
+const pullSecretUpdateExample = `
+  # Show what would change without touching the cluster
+  osdctl cluster pull-secret update 1kfmyclusteristhebesteverp8m --dry-run
+
+  # Replace the outdated registry credentials with the owner's current access token
+  osdctl cluster pull-secret update 1kfmyclusteristhebesteverp8m
+`
+
+// pullSecretOptions defines the struct for running the pull-secret commands
+type pullSecretOptions struct {
+	clusterID   string
+	dryRun      bool
+	skipPrompts bool
+
+	kubeCli client.Client
+	genericclioptions.IOStreams
+}
+
+// pullSecretEntry is the state of a single registry in the pull secret
+type pullSecretEntry struct {
+	Registry string `json:"registry"`
+	Email    string `json:"email,omitempty"`
+	Status   string `json:"status"`
+}
+
+func newCmdPullSecret(streams genericclioptions.IOStreams, kubeCli client.Client) *cobra.Command {
+	pullSecretCmd := &cobra.Command{
+		Use:               "pull-secret",
+		Short:             "Inspect and update the pull secret of a cluster",
+		Long:              pullSecretLong,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+	}
+	pullSecretCmd.AddCommand(newCmdPullSecretGet(streams, kubeCli))
+	pullSecretCmd.AddCommand(newCmdPullSecretUpdate(streams, kubeCli))
+
+	return pullSecretCmd
+}
+
+func newCmdPullSecretGet(streams genericclioptions.IOStreams, kubeCli client.Client) *cobra.Command {
+	ops := &pullSecretOptions{kubeCli: kubeCli, IOStreams: streams}
+	return &cobra.Command{
+		Use:               "get CLUSTER_ID",
+		Short:             "Compare the cluster pull secret with the owner's current OCM access token",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.get())
+		},
+	}
+}
+
+func newCmdPullSecretUpdate(streams genericclioptions.IOStreams, kubeCli client.Client) *cobra.Command {
+	ops := &pullSecretOptions{kubeCli: kubeCli, IOStreams: streams}
+	updateCmd := &cobra.Command{
+		Use:               "update CLUSTER_ID",
+		Short:             "Update the cluster pull secret with the owner's current OCM access token",
+		Long:              "Update the pull secret referenced by the ClusterDeployment with the owner's current OCM access token. Hive then syncs it to the cluster. Registries that are not part of the OCM access token are kept.",
+		Example:           pullSecretUpdateExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.update())
+		},
+	}
+	updateCmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Dry-run - show the changes but do not apply them")
+	updateCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+
+	return updateCmd
+}
+
+func (o *pullSecretOptions) get() error {
+	ocm := utils.CreateConnection()
+	defer func() {
+		if ocmCloseErr := ocm.Close(); ocmCloseErr != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot close the ocm (possible memory leak): %q", ocmCloseErr)
+		}
+	}()
+
+	_, secret, token, err := o.load(ocm)
+	if err != nil {
+		return err
+	}
+	current, err := parsePullSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	entries := diffPullSecret(current, token)
+	o.printPullSecretEntries(entries)
+	if !pullSecretInSyncWith(entries) {
+		fmt.Println("The pull secret differs from the owner's access token, run 'osdctl cluster pull-secret update' to update it")
+	}
+	return nil
+}
+
+func (o *pullSecretOptions) update() error {
+	ocm := utils.CreateConnection()
+	defer func() {
+		if ocmCloseErr := ocm.Close(); ocmCloseErr != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot close the ocm (possible memory leak): %q", ocmCloseErr)
+		}
+	}()
+
+	cluster, secret, token, err := o.load(ocm)
+	if err != nil {
+		return err
+	}
+	current, err := parsePullSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	entries := diffPullSecret(current, token)
+	o.printPullSecretEntries(entries)
+	if pullSecretInSyncWith(entries) {
+		fmt.Println("The pull secret is already in sync with the owner's access token")
+		return nil
+	}
+
+	if o.dryRun {
+		fmt.Println("This is a dry run, nothing changed.")
+		return nil
+	}
+
+	action := fmt.Sprintf("Update pull secret %s/%s", secret.Namespace, secret.Name)
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(ocm, cluster, action),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"auths": mergePullSecret(current, token)})
+	if err != nil {
+		return fmt.Errorf("cannot create the updated pull secret: %w", err)
+	}
+	secret.Data[dockerConfigJSON] = data
+	if err := o.kubeCli.Update(context.TODO(), secret); err != nil {
+		return fmt.Errorf("cannot update pull secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+
+	utils.RecordClusterAction(ocm, cluster, action)
+	fmt.Printf("Updated pull secret %s/%s, Hive will sync it to the cluster\n", secret.Namespace, secret.Name)
+	return nil
+}
+
+// load retrieves the cluster, the pull secret referenced by its ClusterDeployment and the owner's access token
+func (o *pullSecretOptions) load(ocm *sdk.Connection) (*cmv1.Cluster, *corev1.Secret, map[string]map[string]interface{}, error) {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return nil, nil, nil, err
+	}
+	c, err := utils.GetCluster(ocm, o.clusterID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	secret, err := getHivePullSecret(o.kubeCli, c.ID())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	subscription, err := utils.GetSubscription(ocm, c.ID())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not get subscription: %w", err)
+	}
+	owner, err := utils.GetAccount(ocm, subscription.Creator().ID())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("could not get cluster owner account: %w", err)
+	}
+
+	response, err := ocm.AccountsMgmt().V1().AccessToken().Post().Impersonate(owner.Username()).Send()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot retrieve the access token of '%s': %w", owner.Username(), err)
+	}
+	token := map[string]map[string]interface{}{}
+	for registry, auth := range response.Body().Auths() {
+		token[registry] = map[string]interface{}{"auth": auth.Auth(), "email": auth.Email()}
+	}
+
+	return c, secret, token, nil
+}
+
+// getHivePullSecret returns the pull secret referenced by the ClusterDeployment of the cluster
+func getHivePullSecret(kubeCli client.Client, clusterID string) (*corev1.Secret, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{MatchLabels: map[string]string{hiveClusterIDLabel: clusterID}})
+	if err != nil {
+		return nil, err
+	}
+	cds := &hivev1.ClusterDeploymentList{}
+	if err := kubeCli.List(context.TODO(), cds, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, fmt.Errorf("cannot list ClusterDeployments, are you logged in to the Hive shard of the cluster? %w", err)
+	}
+	if len(cds.Items) != 1 {
+		return nil, fmt.Errorf("expected exactly 1 ClusterDeployment for cluster %s, got %d", clusterID, len(cds.Items))
+	}
+	cd := cds.Items[0]
+	if cd.Spec.PullSecretRef == nil || cd.Spec.PullSecretRef.Name == "" {
+		return nil, fmt.Errorf("ClusterDeployment %s/%s has no pull secret", cd.Namespace, cd.Name)
+	}
+
+	secret := &corev1.Secret{}
+	err = kubeCli.Get(context.TODO(), types.NamespacedName{Namespace: cd.Namespace, Name: cd.Spec.PullSecretRef.Name}, secret)
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// parsePullSecret returns the registry auths in a dockerconfigjson secret
+func parsePullSecret(secret *corev1.Secret) (map[string]map[string]interface{}, error) {
+	data, found := secret.Data[dockerConfigJSON]
+	if !found {
+		return nil, fmt.Errorf("secret %s/%s does not contain expected key '%s'", secret.Namespace, secret.Name, dockerConfigJSON)
+	}
+	config := struct {
+		Auths map[string]map[string]interface{} `json:"auths"`
+	}{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("cannot parse pull secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+	if config.Auths == nil {
+		config.Auths = map[string]map[string]interface{}{}
+	}
+	return config.Auths, nil
+}
+
+// diffPullSecret compares the registries of the cluster pull secret with the ones of the access token
+func diffPullSecret(current, token map[string]map[string]interface{}) []pullSecretEntry {
+	var entries []pullSecretEntry
+	for registry, auth := range token {
+		email, _ := auth["email"].(string)
+		entry := pullSecretEntry{Registry: registry, Email: email}
+		existing, found := current[registry]
+		switch {
+		case !found:
+			entry.Status = pullSecretMissing
+		case existing["auth"] != auth["auth"] || existing["email"] != auth["email"]:
+			entry.Status = pullSecretOutdated
+		default:
+			entry.Status = pullSecretInSync
+		}
+		entries = append(entries, entry)
+	}
+	for registry, auth := range current {
+		if _, found := token[registry]; !found {
+			email, _ := auth["email"].(string)
+			entries = append(entries, pullSecretEntry{Registry: registry, Email: email, Status: pullSecretExtra})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Registry < entries[j].Registry })
+	return entries
+}
+
+// pullSecretInSyncWith reports whether every registry of the access token is up to date in the pull secret
+func pullSecretInSyncWith(entries []pullSecretEntry) bool {
+	for _, entry := range entries {
+		if entry.Status == pullSecretMissing || entry.Status == pullSecretOutdated {
+			return false
+		}
+	}
+	return true
+}
+
+// mergePullSecret overrides the registries of the access token in the pull secret, keeping any other registry
+func mergePullSecret(current, token map[string]map[string]interface{}) map[string]map[string]interface{} {
+	merged := map[string]map[string]interface{}{}
+	for registry, auth := range current {
+		merged[registry] = auth
+	}
+	for registry, auth := range token {
+		merged[registry] = auth
+	}
+	return merged
+}
+
+func (o *pullSecretOptions) printPullSecretEntries(entries []pullSecretEntry) {
+	table := printer.NewTablePrinter(o.Out, 20, 1, 3, ' ')
+	table.AddRow([]string{"Registry", "Email", "Status"})
+	for _, entry := range entries {
+		table.AddRow([]string{entry.Registry, entry.Email, entry.Status})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		fmt.Fprintf(o.ErrOut, "error while flushing table: %v\n", err)
+	}
+}
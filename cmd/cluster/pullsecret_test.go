@@ -0,0 +1,93 @@
+package cluster
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGetHivePullSecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(hivev1.AddToScheme(scheme)).To(Succeed())
+
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "uhc-production-abc",
+			Labels:    map[string]string{hiveClusterIDLabel: "abc"},
+		},
+		Spec: hivev1.ClusterDeploymentSpec{
+			PullSecretRef: &corev1.LocalObjectReference{Name: "pull"},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pull", Namespace: "uhc-production-abc"},
+		Data:       map[string][]byte{dockerConfigJSON: []byte(`{"auths":{}}`)},
+	}
+	kubeCli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(cd, secret).Build()
+
+	found, err := getHivePullSecret(kubeCli, "abc")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(found.Namespace).To(Equal("uhc-production-abc"))
+	g.Expect(found.Name).To(Equal("pull"))
+
+	_, err = getHivePullSecret(kubeCli, "other")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestParsePullSecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	auths, err := parsePullSecret(&corev1.Secret{Data: map[string][]byte{
+		dockerConfigJSON: []byte(`{"auths":{"quay.io":{"auth":"abc","email":"owner@example.com"}}}`),
+	}})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(auths).To(HaveKey("quay.io"))
+	g.Expect(auths["quay.io"]["auth"]).To(Equal("abc"))
+
+	_, err = parsePullSecret(&corev1.Secret{Data: map[string][]byte{}})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestDiffPullSecret(t *testing.T) {
+	g := NewGomegaWithT(t)
+	current := map[string]map[string]interface{}{
+		"quay.io":             {"auth": "old", "email": "owner@example.com"},
+		"cloud.openshift.com": {"auth": "same", "email": "owner@example.com"},
+		"registry.example":    {"auth": "custom"},
+	}
+	token := map[string]map[string]interface{}{
+		"quay.io":                 {"auth": "new", "email": "owner@example.com"},
+		"cloud.openshift.com":     {"auth": "same", "email": "owner@example.com"},
+		"registry.redhat.io":      {"auth": "rh", "email": "owner@example.com"},
+		"registry.connect.redhat": {"auth": "same", "email": "owner@example.com"},
+	}
+	current["registry.connect.redhat"] = map[string]interface{}{"auth": "same", "email": "previous@example.com"}
+
+	entries := diffPullSecret(current, token)
+	statuses := map[string]string{}
+	for _, entry := range entries {
+		statuses[entry.Registry] = entry.Status
+	}
+	g.Expect(statuses).To(Equal(map[string]string{
+		"quay.io":                 pullSecretOutdated,
+		"cloud.openshift.com":     pullSecretInSync,
+		"registry.example":        pullSecretExtra,
+		"registry.redhat.io":      pullSecretMissing,
+		"registry.connect.redhat": pullSecretOutdated,
+	}))
+	g.Expect(entries[0].Registry).To(Equal("cloud.openshift.com"))
+	g.Expect(pullSecretInSyncWith(entries)).To(BeFalse())
+
+	merged := mergePullSecret(current, token)
+	g.Expect(diffPullSecret(merged, token)).To(HaveLen(5))
+	g.Expect(pullSecretInSyncWith(diffPullSecret(merged, token))).To(BeTrue())
+	g.Expect(merged["registry.example"]["auth"]).To(Equal("custom"))
+}
@@ -0,0 +1,389 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	quotaStatusOK       = "OK"
+	quotaStatusWarning  = "WARNING"
+	quotaStatusBlocking = "BLOCKING"
+
+	quotaExample = `
+  # Check the AWS quotas of the cluster account and region
+  osdctl cluster quota 1kfmyclusteristhebesteverp8m --profile rhcontrol
+
+  # Warn from 90% of a quota instead of 80%
+  osdctl cluster quota 1kfmyclusteristhebesteverp8m --threshold 90
+`
+)
+
+type quotaOptions struct {
+	clusterID  string
+	awsProfile string
+	threshold  float64
+
+	awsClient aws.Client
+}
+
+// quotaUsage is the usage of a quota, and how much of it the next node or resource of the cluster takes
+type quotaUsage struct {
+	Used float64
+	Step float64
+}
+
+// quotaCheck is an AWS service quota that commonly blocks scale-ups or upgrades, e.g. surge nodes
+type quotaCheck struct {
+	Name        string
+	ServiceCode string
+	QuotaCode   string
+	Unit        string
+	usage       func(client aws.Client, infraID string) (quotaUsage, error)
+}
+
+// quotaResult is the outcome of a quota check
+type quotaResult struct {
+	Check  quotaCheck
+	Usage  quotaUsage
+	Limit  float64
+	Status string
+	Error  string
+}
+
+var quotaChecks = []quotaCheck{
+	{Name: "Running On-Demand Standard instances", ServiceCode: "ec2", QuotaCode: "L-1216C47A", Unit: "vCPUs", usage: standardVCPUUsage},
+	{Name: "EC2-VPC Elastic IPs", ServiceCode: "ec2", QuotaCode: "L-0263D0A3", Unit: "addresses", usage: elasticIPUsage},
+	{Name: "NAT gateways per Availability Zone", ServiceCode: "vpc", QuotaCode: "L-FE5A380F", Unit: "gateways", usage: natGatewayUsage},
+	{Name: "VPCs per Region", ServiceCode: "vpc", QuotaCode: "L-F678F1CE", Unit: "VPCs", usage: vpcUsage},
+	{Name: "Storage for gp3 volumes", ServiceCode: "ebs", QuotaCode: "L-7A658B76", Unit: "TiB", usage: volumeUsage("gp3")},
+	{Name: "Storage for gp2 volumes", ServiceCode: "ebs", QuotaCode: "L-D18FCD1D", Unit: "TiB", usage: volumeUsage("gp2")},
+}
+
+func newCmdQuota() *cobra.Command {
+	ops := &quotaOptions{}
+	quotaCmd := &cobra.Command{
+		Use:   "quota CLUSTER_ID",
+		Short: "Checks the AWS service quotas of the cluster account against their usage",
+		Long: `Checks the AWS service quotas of the cluster account and region against their current usage: vCPUs,
+  Elastic IPs, NAT gateways, VPCs and EBS storage.
+
+  A quota is flagged as a warning from --threshold percent of usage, and as blocking when it can't fit one more
+  node of the cluster, e.g. the vCPUs of its largest instance or the root volume of a node, which scale-ups and
+  upgrades (surge nodes) need.`,
+		Example:           quotaExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.validate(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	quotaCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", "", "AWS profile name")
+	quotaCmd.Flags().Float64Var(&ops.threshold, "threshold", 80, "Usage percentage from which a quota is flagged as a warning")
+
+	return quotaCmd
+}
+
+func (o *quotaOptions) validate(cmd *cobra.Command) error {
+	if o.threshold <= 0 || o.threshold > 100 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "--threshold must be between 0 and 100, got %g", o.threshold)
+	}
+	return utils.IsValidClusterKey(o.clusterID)
+}
+
+func (o *quotaOptions) run() error {
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.CloudProvider().ID() != "aws" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s is not an AWS cluster", cluster.ID())
+	}
+
+	if o.awsClient == nil {
+		o.awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Service quotas of the account of cluster %s in %s\n\n", cluster.Name(), cluster.Region().ID())
+	results := checkQuotas(o.awsClient, cluster.InfraID(), o.threshold)
+	printQuotaResults(results)
+	return nil
+}
+
+func checkQuotas(client aws.Client, infraID string, threshold float64) []quotaResult {
+	results := make([]quotaResult, 0, len(quotaChecks))
+	for _, check := range quotaChecks {
+		result := quotaResult{Check: check}
+		limit, err := getQuotaValue(client, check.ServiceCode, check.QuotaCode)
+		if err == nil {
+			result.Limit = limit
+			result.Usage, err = check.usage(client, infraID)
+		}
+		if err != nil {
+			result.Status = "UNKNOWN"
+			result.Error = err.Error()
+		} else {
+			result.Status = quotaStatus(result.Usage, result.Limit, threshold)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// quotaStatus is blocking when the quota can't fit the next node or resource, a warning from the threshold
+func quotaStatus(usage quotaUsage, limit, threshold float64) string {
+	step := usage.Step
+	if step <= 0 {
+		step = 1
+	}
+	if usage.Used+step > limit {
+		return quotaStatusBlocking
+	}
+	if limit > 0 && usage.Used/limit*100 >= threshold {
+		return quotaStatusWarning
+	}
+	return quotaStatusOK
+}
+
+// getQuotaValue returns the applied quota, or the AWS default when the account never changed it
+func getQuotaValue(client aws.Client, serviceCode, quotaCode string) (float64, error) {
+	output, err := client.GetServiceQuota(&servicequotas.GetServiceQuotaInput{
+		ServiceCode: awsSdk.String(serviceCode),
+		QuotaCode:   awsSdk.String(quotaCode),
+	})
+	if err == nil {
+		return awsSdk.Float64Value(output.Quota.Value), nil
+	}
+	if awsErr, ok := err.(awserr.Error); !ok || awsErr.Code() != servicequotas.ErrCodeNoSuchResourceException {
+		return 0, fmt.Errorf("cannot get quota %s: %w", quotaCode, err)
+	}
+
+	defaultOutput, err := client.GetAWSDefaultServiceQuota(&servicequotas.GetAWSDefaultServiceQuotaInput{
+		ServiceCode: awsSdk.String(serviceCode),
+		QuotaCode:   awsSdk.String(quotaCode),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("cannot get the default of quota %s: %w", quotaCode, err)
+	}
+	return awsSdk.Float64Value(defaultOutput.Quota.Value), nil
+}
+
+// isStandardInstanceType reports whether the instance type counts against the On-Demand Standard
+// (A, C, D, H, I, M, R, T, Z) instances quota, e.g. m5.xlarge but not inf1.xlarge nor p3.2xlarge
+func isStandardInstanceType(instanceType string) bool {
+	family := strings.ToLower(strings.SplitN(instanceType, ".", 2)[0])
+	if family == "" || strings.HasPrefix(family, "inf") || strings.HasPrefix(family, "hpc") {
+		return false
+	}
+	return strings.ContainsRune("acdhimrtz", rune(family[0]))
+}
+
+func instanceVCPUs(instance *ec2.Instance) float64 {
+	if instance.CpuOptions == nil {
+		return 0
+	}
+	return float64(awsSdk.Int64Value(instance.CpuOptions.CoreCount) * awsSdk.Int64Value(instance.CpuOptions.ThreadsPerCore))
+}
+
+func isClusterResource(tags []*ec2.Tag, infraID string) bool {
+	for _, tag := range tags {
+		if awsSdk.StringValue(tag.Key) == "kubernetes.io/cluster/"+infraID {
+			return true
+		}
+	}
+	return false
+}
+
+// standardVCPUUsage sums the vCPUs of the running standard instances, the step is the largest cluster instance
+func standardVCPUUsage(client aws.Client, infraID string) (quotaUsage, error) {
+	var usage quotaUsage
+	var largest float64
+	input := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{{Name: awsSdk.String("instance-state-name"), Values: awsSdk.StringSlice([]string{"pending", "running"})}},
+	}
+	for {
+		output, err := client.DescribeInstances(input)
+		if err != nil {
+			return usage, fmt.Errorf("cannot list the instances: %w", err)
+		}
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				if !isStandardInstanceType(awsSdk.StringValue(instance.InstanceType)) {
+					continue
+				}
+				vcpus := instanceVCPUs(instance)
+				usage.Used += vcpus
+				largest = maxFloat(largest, vcpus)
+				if isClusterResource(instance.Tags, infraID) {
+					usage.Step = maxFloat(usage.Step, vcpus)
+				}
+			}
+		}
+		if awsSdk.StringValue(output.NextToken) == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	if usage.Step == 0 {
+		usage.Step = largest
+	}
+	return usage, nil
+}
+
+func elasticIPUsage(client aws.Client, _ string) (quotaUsage, error) {
+	output, err := client.DescribeAddresses(&ec2.DescribeAddressesInput{
+		Filters: []*ec2.Filter{{Name: awsSdk.String("domain"), Values: awsSdk.StringSlice([]string{"vpc"})}},
+	})
+	if err != nil {
+		return quotaUsage{}, fmt.Errorf("cannot list the Elastic IPs: %w", err)
+	}
+	return quotaUsage{Used: float64(len(output.Addresses)), Step: 1}, nil
+}
+
+// natGatewayUsage returns the gateways of the busiest availability zone, the quota is per zone
+func natGatewayUsage(client aws.Client, _ string) (quotaUsage, error) {
+	subnetIDs := map[string]int{}
+	input := &ec2.DescribeNatGatewaysInput{
+		Filter: []*ec2.Filter{{Name: awsSdk.String("state"), Values: awsSdk.StringSlice([]string{"pending", "available"})}},
+	}
+	for {
+		output, err := client.DescribeNatGateways(input)
+		if err != nil {
+			return quotaUsage{}, fmt.Errorf("cannot list the NAT gateways: %w", err)
+		}
+		for _, gateway := range output.NatGateways {
+			subnetIDs[awsSdk.StringValue(gateway.SubnetId)]++
+		}
+		if awsSdk.StringValue(output.NextToken) == "" {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	if len(subnetIDs) == 0 {
+		return quotaUsage{Step: 1}, nil
+	}
+
+	ids := make([]string, 0, len(subnetIDs))
+	for id := range subnetIDs {
+		ids = append(ids, id)
+	}
+	subnets, err := client.DescribeSubnets(&ec2.DescribeSubnetsInput{SubnetIds: awsSdk.StringSlice(ids)})
+	if err != nil {
+		return quotaUsage{}, fmt.Errorf("cannot list the subnets of the NAT gateways: %w", err)
+	}
+	perZone := map[string]float64{}
+	for _, subnet := range subnets.Subnets {
+		perZone[awsSdk.StringValue(subnet.AvailabilityZone)] += float64(subnetIDs[awsSdk.StringValue(subnet.SubnetId)])
+	}
+	usage := quotaUsage{Step: 1}
+	for _, count := range perZone {
+		usage.Used = maxFloat(usage.Used, count)
+	}
+	return usage, nil
+}
+
+func vpcUsage(client aws.Client, _ string) (quotaUsage, error) {
+	output, err := client.DescribeVpcs(&ec2.DescribeVpcsInput{})
+	if err != nil {
+		return quotaUsage{}, fmt.Errorf("cannot list the VPCs: %w", err)
+	}
+	return quotaUsage{Used: float64(len(output.Vpcs)), Step: 1}, nil
+}
+
+// volumeUsage sums the storage of the volumes of the type in TiB, the step is the largest cluster volume
+func volumeUsage(volumeType string) func(aws.Client, string) (quotaUsage, error) {
+	return func(client aws.Client, infraID string) (quotaUsage, error) {
+		var usage quotaUsage
+		input := &ec2.DescribeVolumesInput{
+			Filters: []*ec2.Filter{{Name: awsSdk.String("volume-type"), Values: awsSdk.StringSlice([]string{volumeType})}},
+		}
+		for {
+			output, err := client.DescribeVolumes(input)
+			if err != nil {
+				return usage, fmt.Errorf("cannot list the %s volumes: %w", volumeType, err)
+			}
+			for _, volume := range output.Volumes {
+				size := float64(awsSdk.Int64Value(volume.Size)) / 1024
+				usage.Used += size
+				if isClusterResource(volume.Tags, infraID) {
+					usage.Step = maxFloat(usage.Step, size)
+				}
+			}
+			if awsSdk.StringValue(output.NextToken) == "" {
+				break
+			}
+			input.NextToken = output.NextToken
+		}
+		return usage, nil
+	}
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func formatQuantity(value float64) string {
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d", int64(value))
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+func printQuotaResults(results []quotaResult) {
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Quota", "Code", "Used", "Limit", "Used %", "Next Node Needs", "Status"})
+	blocking := 0
+	for _, result := range results {
+		if result.Error != "" {
+			table.AddRow([]string{result.Check.Name, result.Check.QuotaCode, "-", "-", "-", "-", result.Status + ": " + result.Error})
+			continue
+		}
+		percentage := "-"
+		if result.Limit > 0 {
+			percentage = fmt.Sprintf("%.0f%%", result.Usage.Used/result.Limit*100)
+		}
+		table.AddRow([]string{
+			result.Check.Name,
+			result.Check.QuotaCode,
+			formatQuantity(result.Usage.Used) + " " + result.Check.Unit,
+			formatQuantity(result.Limit),
+			percentage,
+			formatQuantity(result.Usage.Step),
+			result.Status,
+		})
+		if result.Status == quotaStatusBlocking {
+			blocking++
+		}
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "error while flushing table: %v\n", err)
+	}
+	if blocking > 0 {
+		fmt.Printf("%d quota(s) are exhausted or can't fit another node, scale-ups and upgrades will likely fail until they are raised\n", blocking)
+	}
+}
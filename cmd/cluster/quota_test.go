@@ -0,0 +1,110 @@
+package cluster
+
+import (
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/servicequotas"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func TestIsStandardInstanceType(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, instanceType := range []string{"m5.xlarge", "r5.2xlarge", "c6i.large", "t3.medium", "i3en.large", "z1d.large"} {
+		g.Expect(isStandardInstanceType(instanceType)).To(BeTrue(), instanceType)
+	}
+	for _, instanceType := range []string{"p3.2xlarge", "g4dn.xlarge", "inf1.xlarge", "hpc6a.48xlarge", "x1e.xlarge", ""} {
+		g.Expect(isStandardInstanceType(instanceType)).To(BeFalse(), instanceType)
+	}
+}
+
+func TestQuotaStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(quotaStatus(quotaUsage{Used: 10, Step: 4}, 100, 80)).To(Equal(quotaStatusOK))
+	g.Expect(quotaStatus(quotaUsage{Used: 85, Step: 4}, 100, 80)).To(Equal(quotaStatusWarning))
+	g.Expect(quotaStatus(quotaUsage{Used: 98, Step: 4}, 100, 80)).To(Equal(quotaStatusBlocking))
+	g.Expect(quotaStatus(quotaUsage{Used: 5, Step: 0}, 5, 80)).To(Equal(quotaStatusBlocking))
+}
+
+func TestGetQuotaValueFallsBackToDefault(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+
+	mockAWSClient.EXPECT().GetServiceQuota(gomock.Any()).Return(nil, awserr.New(servicequotas.ErrCodeNoSuchResourceException, "not applied", nil))
+	mockAWSClient.EXPECT().GetAWSDefaultServiceQuota(gomock.Any()).Return(&servicequotas.GetAWSDefaultServiceQuotaOutput{
+		Quota: &servicequotas.ServiceQuota{Value: awsSdk.Float64(5)},
+	}, nil)
+
+	value, err := getQuotaValue(mockAWSClient, "ec2", "L-0263D0A3")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(value).To(Equal(5.0))
+}
+
+func TestStandardVCPUUsage(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+
+	instance := func(instanceType string, cores int64, tags ...*ec2.Tag) *ec2.Instance {
+		return &ec2.Instance{
+			InstanceType: awsSdk.String(instanceType),
+			CpuOptions:   &ec2.CpuOptions{CoreCount: awsSdk.Int64(cores), ThreadsPerCore: awsSdk.Int64(2)},
+			Tags:         tags,
+		}
+	}
+	clusterTag := &ec2.Tag{Key: awsSdk.String("kubernetes.io/cluster/mycluster-abcde"), Value: awsSdk.String("owned")}
+
+	gomock.InOrder(
+		mockAWSClient.EXPECT().DescribeInstances(gomock.Any()).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{
+				instance("m5.xlarge", 2, clusterTag),
+				instance("m5.2xlarge", 4, clusterTag),
+			}}},
+			NextToken: awsSdk.String("next"),
+		}, nil),
+		mockAWSClient.EXPECT().DescribeInstances(&ec2.DescribeInstancesInput{
+			Filters:   []*ec2.Filter{{Name: awsSdk.String("instance-state-name"), Values: awsSdk.StringSlice([]string{"pending", "running"})}},
+			NextToken: awsSdk.String("next"),
+		}).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{
+				// Another cluster's larger instance doesn't change the step
+				instance("r5.8xlarge", 16),
+				// GPU instances have another quota
+				instance("p3.2xlarge", 4),
+			}}},
+		}, nil),
+	)
+
+	usage, err := standardVCPUUsage(mockAWSClient, "mycluster-abcde")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(usage).To(Equal(quotaUsage{Used: 4 + 8 + 32, Step: 8}))
+}
+
+func TestNatGatewayUsageIsPerZone(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+
+	mockAWSClient.EXPECT().DescribeNatGateways(gomock.Any()).Return(&ec2.DescribeNatGatewaysOutput{
+		NatGateways: []*ec2.NatGateway{
+			{SubnetId: awsSdk.String("subnet-a1")},
+			{SubnetId: awsSdk.String("subnet-a2")},
+			{SubnetId: awsSdk.String("subnet-b")},
+		},
+	}, nil)
+	mockAWSClient.EXPECT().DescribeSubnets(gomock.Any()).Return(&ec2.DescribeSubnetsOutput{
+		Subnets: []*ec2.Subnet{
+			{SubnetId: awsSdk.String("subnet-a1"), AvailabilityZone: awsSdk.String("us-east-1a")},
+			{SubnetId: awsSdk.String("subnet-a2"), AvailabilityZone: awsSdk.String("us-east-1a")},
+			{SubnetId: awsSdk.String("subnet-b"), AvailabilityZone: awsSdk.String("us-east-1b")},
+		},
+	}, nil)
+
+	usage, err := natGatewayUsage(mockAWSClient, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(usage).To(Equal(quotaUsage{Used: 2, Step: 1}))
+}
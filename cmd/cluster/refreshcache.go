@@ -0,0 +1,118 @@
+package cluster
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const refreshCacheExample = `
+  # Refresh every cluster in the local cache for the current OCM environment
+  osdctl cluster refresh-cache
+
+  # Add or refresh specific clusters
+  osdctl cluster refresh-cache 1kfmyclusteristhebesteverp8m my-other-cluster
+
+  # Drop the cache entirely
+  osdctl cluster refresh-cache --clear
+`
+
+type refreshCacheOptions struct {
+	clusterIDs []string
+	clear      bool
+
+	genericclioptions.IOStreams
+}
+
+func newCmdRefreshCache(streams genericclioptions.IOStreams) *cobra.Command {
+	ops := &refreshCacheOptions{IOStreams: streams}
+	refreshCacheCmd := &cobra.Command{
+		Use:               "refresh-cache [CLUSTER_ID...]",
+		Short:             "Refreshes the local cluster metadata cache used by --cached",
+		Example:           refreshCacheExample,
+		Args:              cobra.ArbitraryArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterIDs = args
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	refreshCacheCmd.Flags().BoolVar(&ops.clear, "clear", false, "Remove all entries from the cache")
+
+	return refreshCacheCmd
+}
+
+func (o *refreshCacheOptions) run() error {
+	cache, err := utils.LoadClusterCache()
+	if err != nil {
+		return err
+	}
+
+	if o.clear {
+		cache.Clear()
+		if err := cache.Save(); err != nil {
+			return err
+		}
+		fmt.Println("Cluster cache cleared")
+		return nil
+	}
+
+	for _, id := range o.clusterIDs {
+		if err := utils.IsValidClusterKey(id); err != nil {
+			return err
+		}
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer func() {
+		if err := ocmClient.Close(); err != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot close the ocmClient (possible memory leak): %q", err)
+		}
+	}()
+
+	keys := o.clusterIDs
+	if len(keys) == 0 {
+		for id, metadata := range cache.Clusters {
+			if metadata.OCMURL == ocmClient.URL() {
+				keys = append(keys, id)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		fmt.Println("No clusters cached for this OCM environment, pass cluster IDs to add them")
+		return nil
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"ID", "Name", "Cloud Provider", "Region", "Shard"})
+	var failed int
+	for _, key := range keys {
+		metadata, err := utils.FetchClusterMetadata(ocmClient, key)
+		if err != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot refresh cluster %s: %v\n", key, err)
+			failed++
+			continue
+		}
+		cache.Store(metadata)
+		table.AddRow([]string{metadata.ID, metadata.Name, metadata.CloudProvider, metadata.Region, metadata.Shard})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+
+	if err := cache.Save(); err != nil {
+		return err
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to refresh %d clusters", failed)
+	}
+	return nil
+}
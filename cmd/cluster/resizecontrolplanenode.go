@@ -1,29 +1,45 @@
 package cluster
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/flagtypes"
 	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/poll"
 	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/readonly"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
+// maxPendingPodsShown bounds the pods named while waiting for the workloads of the resized node
+const maxPendingPodsShown = 5
+
 // resizeControlPlaneNodeOptions defines the struct for running resizeControlPlaneNode command
 type resizeControlPlaneNodeOptions struct {
 	clusterID      string
 	node           string
 	newMachineType string
+	wait           bool
+	waitTimeout    time.Duration
+	skipPrompts    bool
+
+	runOC    utils.OCRunner
+	interval time.Duration
 
 	genericclioptions.IOStreams
 	GlobalOptions *globalflags.GlobalOptions
@@ -38,13 +54,16 @@ func newCmdResizeControlPlaneNode(streams genericclioptions.IOStreams, flags *ge
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	resizeControlPlaneNodeCmd.Flags().StringVar(&ops.node, "node", "", "The control plane node to resize (e.g. ip-127.0.0.1.eu-west-2.compute.internal)")
 	resizeControlPlaneNodeCmd.Flags().StringVar(&ops.newMachineType, "machine-type", "", "The target AWS machine type to resize to (e.g. m5.2xlarge)")
 	resizeControlPlaneNodeCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "c", "", "The internal ID of the cluster to perform actions on")
+	resizeControlPlaneNodeCmd.Flags().BoolVar(&ops.wait, "wait", false, "Wait for the node to be back, Ready and running its pods instead of asking to check it by hand")
+	flagtypes.DurationVar(resizeControlPlaneNodeCmd.Flags(), &ops.waitTimeout, "wait-timeout", 20*time.Minute, time.Minute, "How long to wait for the resized node with --wait before giving up")
+	resizeControlPlaneNodeCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
 	resizeControlPlaneNodeCmd.MarkFlagRequired("cluster-id")
 	resizeControlPlaneNodeCmd.MarkFlagRequired("node")
 	resizeControlPlaneNodeCmd.MarkFlagRequired("machine-type")
@@ -56,6 +75,8 @@ func newResizeControlPlaneNodeOptions(streams genericclioptions.IOStreams, flags
 	return &resizeControlPlaneNodeOptions{
 		IOStreams:     streams,
 		GlobalOptions: globalOpts,
+		runOC:         utils.RunOCAsClusterAdmin,
+		interval:      15 * time.Second,
 	}
 }
 
@@ -76,6 +97,13 @@ func (o *resizeControlPlaneNodeOptions) complete(cmd *cobra.Command, _ []string)
 	if strings.ToUpper(cluster.CloudProvider().ID()) != "AWS" {
 		return fmt.Errorf("This command is only available for AWS clusters")
 	}
+
+	// --wait follows the node through the cluster the current kubeconfig points to
+	if o.wait {
+		if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+			return err
+		}
+	}
 	/*
 		Ideally we would want additional validation here for:
 		- the machine type exists
@@ -98,17 +126,15 @@ const (
 	Cancel                          = 4
 )
 
-func retryCancelDialog(procedure string) (optionsDialogResponse, error) {
-	fmt.Printf("Do you want to retry %s or cancel this command? (retry/cancel):\n", procedure)
+func retryCancelDialog(errOut io.Writer, procedure string) (optionsDialogResponse, error) {
+	fmt.Fprintf(errOut, "Do you want to retry %s or cancel this command? (retry/cancel):\n", procedure)
 
-	reader := bufio.NewReader(os.Stdin)
-
-	responseBytes, _, err := reader.ReadLine()
+	answer, err := utils.ReadAnswer(os.Stdin)
 	if err != nil {
-		return Undefined, fmt.Errorf("reader.ReadLine() resulted in an error: %s", err)
+		return Undefined, fmt.Errorf("cannot read the answer: %w", err)
 	}
 
-	response := strings.ToUpper(string(responseBytes))
+	response := strings.ToUpper(strings.TrimSpace(answer))
 
 	switch response {
 	case "RETRY":
@@ -116,25 +142,25 @@ func retryCancelDialog(procedure string) (optionsDialogResponse, error) {
 	case "CANCEL":
 		return Cancel, nil
 	default:
-		fmt.Println("Invalid response, expected 'retry' or 'cancel' (case-insensitive).")
-		return retryCancelDialog(procedure)
+		fmt.Fprintln(errOut, "Invalid response, expected 'retry' or 'cancel' (case-insensitive).")
+		return retryCancelDialog(errOut, procedure)
 	}
 
 }
 
-func withRetryCancelOption(fn func() error, procedure string) (err error) {
+func withRetryCancelOption(errOut io.Writer, fn func() error, procedure string) (err error) {
 	err = fn()
 	if err == nil {
 		return nil
 	}
-	dialogResponse, err := retryCancelDialog(procedure)
+	dialogResponse, err := retryCancelDialog(errOut, procedure)
 	if err != nil {
 		return err
 	}
 
 	switch dialogResponse {
 	case Retry:
-		return withRetryCancelOption(fn, procedure)
+		return withRetryCancelOption(errOut, fn, procedure)
 	case Cancel:
 		return fmt.Errorf("Exiting...")
 	default:
@@ -143,17 +169,15 @@ func withRetryCancelOption(fn func() error, procedure string) (err error) {
 	}
 }
 
-func retrySkipCancelDialog(procedure string) (optionsDialogResponse, error) {
-	fmt.Printf("Do you want to retry %[1]s, skip %[1]s or cancel this command? (retry/skip/cancel):\n", procedure)
-
-	reader := bufio.NewReader(os.Stdin)
+func retrySkipCancelDialog(errOut io.Writer, procedure string) (optionsDialogResponse, error) {
+	fmt.Fprintf(errOut, "Do you want to retry %[1]s, skip %[1]s or cancel this command? (retry/skip/cancel):\n", procedure)
 
-	responseBytes, _, err := reader.ReadLine()
+	answer, err := utils.ReadAnswer(os.Stdin)
 	if err != nil {
-		return Undefined, fmt.Errorf("reader.ReadLine() resulted in an error: %s", err)
+		return Undefined, fmt.Errorf("cannot read the answer: %w", err)
 	}
 
-	response := strings.ToUpper(string(responseBytes))
+	response := strings.ToUpper(strings.TrimSpace(answer))
 
 	switch response {
 	case "RETRY":
@@ -163,27 +187,27 @@ func retrySkipCancelDialog(procedure string) (optionsDialogResponse, error) {
 	case "CANCEL":
 		return Cancel, nil
 	default:
-		fmt.Println("Invalid response, expected 'retry', 'skip' or 'cancel' (case-insensitive).")
-		return retrySkipCancelDialog(procedure)
+		fmt.Fprintln(errOut, "Invalid response, expected 'retry', 'skip' or 'cancel' (case-insensitive).")
+		return retrySkipCancelDialog(errOut, procedure)
 	}
 
 }
 
-func withRetrySkipCancelOption(fn func() error, procedure string) (err error) {
+func withRetrySkipCancelOption(errOut io.Writer, fn func() error, procedure string) (err error) {
 	err = fn()
 	if err == nil {
 		return nil
 	}
-	dialogResponse, err := retrySkipCancelDialog(procedure)
+	dialogResponse, err := retrySkipCancelDialog(errOut, procedure)
 	if err != nil {
 		return err
 	}
 
 	switch dialogResponse {
 	case Retry:
-		return withRetrySkipCancelOption(fn, procedure)
+		return withRetrySkipCancelOption(errOut, fn, procedure)
 	case Skip:
-		fmt.Printf("Skipping %s...\n", procedure)
+		fmt.Fprintf(errOut, "Skipping %s...\n", procedure)
 	case Cancel:
 		return fmt.Errorf("Exiting...")
 	default:
@@ -193,17 +217,15 @@ func withRetrySkipCancelOption(fn func() error, procedure string) (err error) {
 	return nil
 }
 
-func retrySkipForceCancelDialog(procedure string) (optionsDialogResponse, error) {
-	fmt.Printf("Do you want to retry %s, skip %s, force %s or cancel this command? (retry/skip/force/cancel):\n", procedure, procedure, procedure)
+func retrySkipForceCancelDialog(errOut io.Writer, procedure string) (optionsDialogResponse, error) {
+	fmt.Fprintf(errOut, "Do you want to retry %s, skip %s, force %s or cancel this command? (retry/skip/force/cancel):\n", procedure, procedure, procedure)
 
-	reader := bufio.NewReader(os.Stdin)
-
-	responseBytes, _, err := reader.ReadLine()
+	answer, err := utils.ReadAnswer(os.Stdin)
 	if err != nil {
-		return Undefined, fmt.Errorf("reader.ReadLine() resulted in an error: %s", err)
+		return Undefined, fmt.Errorf("cannot read the answer: %w", err)
 	}
 
-	response := strings.ToUpper(string(responseBytes))
+	response := strings.ToUpper(strings.TrimSpace(answer))
 
 	switch response {
 	case "RETRY":
@@ -215,8 +237,8 @@ func retrySkipForceCancelDialog(procedure string) (optionsDialogResponse, error)
 	case "CANCEL":
 		return Cancel, nil
 	default:
-		fmt.Println("Invalid response, expected 'retry', 'skip', 'force' or 'cancel' (case-insensitive).")
-		return retrySkipForceCancelDialog(procedure)
+		fmt.Fprintln(errOut, "Invalid response, expected 'retry', 'skip', 'force' or 'cancel' (case-insensitive).")
+		return retrySkipForceCancelDialog(errOut, procedure)
 	}
 }
 
@@ -230,7 +252,7 @@ func forceDrainNode(nodeID string) error {
 	return nil
 }
 
-func drainNode(nodeID string) error {
+func drainNode(errOut io.Writer, nodeID string) error {
 	printer.PrintlnGreen("Draining node", nodeID)
 
 	// TODO: replace subprocess call with API call
@@ -238,21 +260,21 @@ func drainNode(nodeID string) error {
 	output, err := exec.Command("bash", "-c", cmd).CombinedOutput()
 
 	if err != nil {
-		fmt.Println("Failed to drain node:")
-		fmt.Println(strings.TrimSpace(string(output)))
+		fmt.Fprintln(errOut, "Failed to drain node:")
+		fmt.Fprintln(errOut, strings.TrimSpace(string(output)))
 
-		dialogResponse, err := retrySkipForceCancelDialog("draining node")
+		dialogResponse, err := retrySkipForceCancelDialog(errOut, "draining node")
 		if err != nil {
 			return err
 		}
 
 		switch dialogResponse {
 		case Retry:
-			return drainNode(nodeID)
+			return drainNode(errOut, nodeID)
 		case Skip:
-			fmt.Println("Skipping node drain")
+			fmt.Fprintln(errOut, "Skipping node drain")
 		case Force:
-			err = withRetrySkipCancelOption(func() error { return forceDrainNode(nodeID) }, "force draining")
+			err = withRetrySkipCancelOption(errOut, func() error { return forceDrainNode(nodeID) }, "force draining")
 			if err != nil {
 				return err
 			}
@@ -316,14 +338,14 @@ func startNode(awsClient *awsprovider.Client, nodeID string) error {
 	return nil
 }
 
-func uncordonNode(nodeID string) error {
+func uncordonNode(errOut io.Writer, nodeID string) error {
 	printer.PrintlnGreen("Uncordoning node", nodeID)
 	// TODO: replace subprocess call with API call
 	cmd := fmt.Sprintf("oc adm uncordon %s", nodeID)
 	output, err := exec.Command("bash", "-c", cmd).CombinedOutput()
 
 	if err != nil {
-		fmt.Printf("Failed to uncordon node: %s", strings.TrimSpace(string(output)))
+		fmt.Fprintf(errOut, "Failed to uncordon node: %s\n", strings.TrimSpace(string(output)))
 		return err
 	}
 	return nil
@@ -331,7 +353,7 @@ func uncordonNode(nodeID string) error {
 
 // Start and stop calls require the internal AWS instance ID
 // Machinetype patch requires the tag "Name"
-func getNodeAwsInstanceData(node string, awsClient *awsprovider.Client) (string, string, error) {
+func getNodeAwsInstanceData(node string, awsClient *awsprovider.Client) (string, string, string, error) {
 	params := &ec2.DescribeInstancesInput{
 		Filters: []*ec2.Filter{
 			{
@@ -342,10 +364,11 @@ func getNodeAwsInstanceData(node string, awsClient *awsprovider.Client) (string,
 	}
 	ret, err := (*awsClient).DescribeInstances(params)
 	if err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 
 	awsInstanceID := *(ret.Reservations[0].Instances[0].InstanceId)
+	instanceType := aws.StringValue(ret.Reservations[0].Instances[0].InstanceType)
 
 	var machineName string = ""
 	tags := ret.Reservations[0].Instances[0].Tags
@@ -356,12 +379,12 @@ func getNodeAwsInstanceData(node string, awsClient *awsprovider.Client) (string,
 	}
 
 	if machineName == "" {
-		return "", "", fmt.Errorf("Could not retrieve node machine name.")
+		return "", "", "", fmt.Errorf("Could not retrieve node machine name.")
 	}
 
-	fmt.Println("Node", node, "found as AWS internal InstanceId", awsInstanceID, "with machine name", machineName)
+	fmt.Println("Node", node, "found as AWS internal InstanceId", awsInstanceID, "of type", instanceType, "with machine name", machineName)
 
-	return machineName, awsInstanceID, nil
+	return machineName, awsInstanceID, instanceType, nil
 }
 
 func patchMachineType(machine string, machineType string) error {
@@ -375,63 +398,103 @@ func patchMachineType(machine string, machineType string) error {
 }
 
 func (o *resizeControlPlaneNodeOptions) run() error {
+	// The node is drained and patched with oc run through bash, which read-only mode can't intercept
+	if err := readonly.Check(fmt.Sprintf("resize control plane node %s to %s", o.node, o.newMachineType)); err != nil {
+		return err
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
 
 	awsClient, err := osdCloud.CreateAWSClient(o.clusterID)
 	if err != nil {
 		return err
 	}
 
-	machineName, nodeAwsID, err := getNodeAwsInstanceData(o.node, &awsClient)
+	machineName, nodeAwsID, originalMachineType, err := getNodeAwsInstanceData(o.node, &awsClient)
 	if err != nil {
 		return err
 	}
+	// The control plane keeps the architecture it was installed with, even with a multi-arch release payload
+	if err := validateInstanceArchitecture("aws", originalMachineType, o.newMachineType, false); err != nil {
+		return err
+	}
 	fmt.Println() // Add an empty line for better output formatting
 
+	// The boot ID tells when the node restarted on the new instance type
+	var before *corev1.Node
+	if o.wait {
+		before, err = getNode(o.runOC, o.node)
+		if err != nil {
+			return err
+		}
+	}
+
 	// drain node with oc adm drain <node> --ignore-daemonsets --delete-emptydir-data
 	// drainNode has its own retry dialog.
-	err = drainNode(o.node)
+	err = drainNode(o.ErrOut, o.node)
 	if err != nil {
 		return err
 	}
 	fmt.Println() // Add an empty line for better output formatting
 
 	// Stop the node instance
-	err = withRetryCancelOption(func() error { return stopNode(&awsClient, nodeAwsID) }, "stopping node")
+	err = withRetryCancelOption(o.ErrOut, func() error { return stopNode(&awsClient, nodeAwsID) }, "stopping node")
 	if err != nil {
 		return err
 	}
 	fmt.Println() // Add an empty line for better output formatting
 
 	// Once stopped, change the instance type
-	err = withRetryCancelOption(func() error { return modifyInstanceAttribute(&awsClient, nodeAwsID, o.newMachineType) }, "modify instance attribute")
+	err = withRetryCancelOption(o.ErrOut, func() error { return modifyInstanceAttribute(&awsClient, nodeAwsID, o.newMachineType) }, "modify instance attribute")
 	if err != nil {
 		return err
 	}
 	fmt.Println() // Add an empty line for better output formatting
 
 	// Start the node instance
-	err = withRetryCancelOption(func() error { return startNode(&awsClient, nodeAwsID) }, "starting node")
+	err = withRetryCancelOption(o.ErrOut, func() error { return startNode(&awsClient, nodeAwsID) }, "starting node")
 	if err != nil {
 		return err
 	}
 	fmt.Println() // Add an empty line for better output formatting
 
 	// uncordon node with oc adm uncordon <node>
-	err = withRetrySkipCancelOption(func() error { return uncordonNode(o.node) }, "uncordoning node")
+	err = withRetrySkipCancelOption(o.ErrOut, func() error { return uncordonNode(o.ErrOut, o.node) }, "uncordoning node")
 	if err != nil {
 		return err
 	}
 	fmt.Println() // Add an empty line for better output formatting
 
-	fmt.Println("To continue, please confirm that the node is up and running and that the cluster is in the desired state to proceed.")
-	err = utils.ConfirmSend()
-	if err != nil {
-		return err
+	if o.wait {
+		printer.PrintfGreen("Waiting up to %s for the node to be back and running its pods...\n", o.waitTimeout)
+		err = waitForResizedNode(o.runOC, before, machineName, o.waitTimeout, o.interval)
+		if err != nil {
+			return fmt.Errorf("%w\nTo roll back, run 'osdctl cluster resize-control-plane-node -c %s --node %s --machine-type %s'",
+				err, o.clusterID, o.node, originalMachineType)
+		}
+		fmt.Println("The node is back, Ready and running its pods.")
+	} else {
+		fmt.Println("To continue, please confirm that the node is up and running and that the cluster is in the desired state to proceed.")
+		err = utils.Confirm(utils.ConfirmOptions{
+			Summary:    utils.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("Go on with the resize of control plane node %s to %s", o.node, o.newMachineType)),
+			SkipPrompt: o.skipPrompts,
+		})
+		if err != nil {
+			return err
+		}
 	}
 	fmt.Println() // Add an empty line for better output formatting
 
 	fmt.Println("To finish the node resize, it is suggested to update the machine spec. This requires ***elevated privileges***. Do you want to proceed?")
-	err = utils.ConfirmSend()
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("Patch machine %s of node %s to %s as backplane-cluster-admin", machineName, o.node, o.newMachineType)),
+		SkipPrompt: o.skipPrompts,
+	})
 	if err != nil {
 		fmt.Println("Node resized, machine type not patched. Exiting...")
 		return err
@@ -439,13 +502,82 @@ func (o *resizeControlPlaneNodeOptions) run() error {
 	fmt.Println() // Add an empty line for better output formatting
 
 	// Patch node machine to update .spec
-	err = withRetryCancelOption(func() error { return patchMachineType(machineName, o.newMachineType) }, "patch machine type")
+	err = withRetryCancelOption(o.ErrOut, func() error { return patchMachineType(machineName, o.newMachineType) }, "patch machine type")
 	if err != nil {
-		fmt.Println("Control plane node resized but could not patch machine .spec.")
+		fmt.Fprintln(o.ErrOut, "Control plane node resized but could not patch machine .spec.")
 		return err
 	}
 
 	fmt.Println("Control plane node successfully resized.")
+	utils.InvalidateClusterMetadata(o.clusterID)
 
 	return nil
 }
+
+// waitForResizedNode polls the node and its machine until the node restarted, is Ready and schedulable and its pods
+// are running again, printing what it is still waiting on whenever that changes
+func waitForResizedNode(run utils.OCRunner, before *corev1.Node, machineName string, timeout, interval time.Duration) error {
+	opts := poll.Options{Description: "the resized node", Timeout: timeout, Interval: interval}
+	return poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
+		status := resizedNodeStatus(run, before, machineName)
+		return status == "", status, nil
+	})
+}
+
+// resizedNodeStatus returns what the resized node is still waiting on, or an empty string once it is back
+func resizedNodeStatus(run utils.OCRunner, before *corev1.Node, machineName string) string {
+	node, err := getNode(run, before.Name)
+	if err != nil {
+		return fmt.Sprintf("the node can't be retrieved: %v", err)
+	}
+	if node.Status.NodeInfo.BootID == before.Status.NodeInfo.BootID {
+		return "the node hasn't restarted yet"
+	}
+	if !nodeReady(node) {
+		return "the node isn't Ready"
+	}
+	if node.Spec.Unschedulable {
+		return "the node is still cordoned"
+	}
+
+	phase, err := run("-n", "openshift-machine-api", "get", "machine", machineName, "-o", "jsonpath={.status.phase}")
+	if err != nil {
+		return fmt.Sprintf("machine %s can't be retrieved: %v", machineName, err)
+	}
+	if p := strings.TrimSpace(string(phase)); p != "Running" {
+		return fmt.Sprintf("machine %s is %s", machineName, p)
+	}
+
+	pods, err := nodePods(run, node.Name)
+	if err != nil {
+		return fmt.Sprintf("the pods of the node can't be retrieved: %v", err)
+	}
+	if pending := pendingPods(pods); len(pending) > 0 {
+		shown := pending
+		if len(shown) > maxPendingPodsShown {
+			shown = shown[:maxPendingPodsShown]
+		}
+		return fmt.Sprintf("%d pods aren't running yet: %s", len(pending), strings.Join(shown, ", "))
+	}
+	return ""
+}
+
+// pendingPods returns the pods that aren't done and not running with all their containers ready
+func pendingPods(pods []corev1.Pod) []string {
+	var pending []string
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		ready := pod.Status.Phase == corev1.PodRunning
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status != corev1.ConditionTrue {
+				ready = false
+			}
+		}
+		if !ready {
+			pending = append(pending, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	return pending
+}
@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPod(name string, phase corev1.PodPhase, ready bool) corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "openshift-etcd", Name: name},
+		Status: corev1.PodStatus{
+			Phase:      phase,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+		},
+	}
+}
+
+func TestPendingPods(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	pods := []corev1.Pod{
+		testPod("etcd", corev1.PodRunning, true),
+		testPod("installer", corev1.PodSucceeded, false),
+		testPod("kube-apiserver", corev1.PodRunning, false),
+		testPod("guard", corev1.PodPending, false),
+	}
+	g.Expect(pendingPods(pods)).To(Equal([]string{"openshift-etcd/kube-apiserver", "openshift-etcd/guard"}))
+}
+
+func TestResizedNodeStatus(t *testing.T) {
+	before := testNode("master-0", "a", true, nil)
+
+	tests := []struct {
+		name     string
+		node     *corev1.Node
+		phase    string
+		pods     []corev1.Pod
+		expected string
+	}{
+		{
+			name:     "not restarted",
+			node:     testNode("master-0", "a", true, nil),
+			expected: "the node hasn't restarted yet",
+		},
+		{
+			name:     "not ready",
+			node:     testNode("master-0", "b", false, nil),
+			expected: "the node isn't Ready",
+		},
+		{
+			name:     "machine provisioning",
+			node:     testNode("master-0", "b", true, nil),
+			phase:    "Provisioned",
+			expected: "machine master-0-machine is Provisioned",
+		},
+		{
+			name:     "pods not running",
+			node:     testNode("master-0", "b", true, nil),
+			phase:    "Running",
+			pods:     []corev1.Pod{testPod("etcd", corev1.PodPending, false)},
+			expected: "1 pods aren't running yet: openshift-etcd/etcd",
+		},
+		{
+			name:  "back",
+			node:  testNode("master-0", "b", true, nil),
+			phase: "Running",
+			pods:  []corev1.Pod{testPod("etcd", corev1.PodRunning, true)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			run := func(args ...string) ([]byte, error) {
+				switch {
+				case args[0] == "get" && args[1] == "node":
+					return json.Marshal(test.node)
+				case args[0] == "get" && args[1] == "pods":
+					return json.Marshal(corev1.PodList{Items: test.pods})
+				}
+				return []byte(test.phase), nil
+			}
+			g.Expect(resizedNodeStatus(run, before, "master-0-machine")).To(Equal(test.expected))
+		})
+	}
+}
+
+func TestWaitForResizedNodeStalls(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	before := testNode("master-0", "a", true, nil)
+	run := func(args ...string) ([]byte, error) {
+		return json.Marshal(before)
+	}
+	err := waitForResizedNode(run, before, "master-0-machine", 10*time.Millisecond, time.Millisecond)
+	g.Expect(err).To(MatchError(ContainSubstring("the node hasn't restarted yet")))
+}
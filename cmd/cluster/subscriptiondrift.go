@@ -0,0 +1,262 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	subscriptionDriftLongDescription = `
+Compares the metrics the subscription of a cluster reports, which billing is based on, with the live cluster
+
+  This command will:
+
+  * Read the subscription of the cluster and the metrics OCM last received from its telemetry
+  * Count the control plane, infra and compute nodes of the cluster, and the vCPUs and memory of the compute nodes
+  * Flag the values differing by more than --tolerance, and the metrics that weren't updated for --stale-after
+
+  Compute nodes are the nodes that are neither control plane nor infra nodes, as billed. Sockets aren't visible
+  through the Kubernetes API: the sockets of the subscription are compared to the ones the cluster reports through
+  telemetry. Use -o json for the billing team.
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID').
+`
+	subscriptionDriftExample = `
+  # Compare the subscription of a cluster with the cluster
+  osdctl cluster subscription-drift 1kfmyclusteristhebesteverp8m
+
+  # Only flag differences above 10%, as JSON
+  osdctl cluster subscription-drift 1kfmyclusteristhebesteverp8m --tolerance 0.1 -o json
+`
+
+	masterNodeRoleLabel       = "node-role.kubernetes.io/master"
+	controlPlaneNodeRoleLabel = "node-role.kubernetes.io/control-plane"
+)
+
+type subscriptionDriftOptions struct {
+	clusterID  string
+	tolerance  float64
+	staleAfter time.Duration
+
+	runOC         utils.OCRunner
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// subscriptionMetricDrift is a metric of the subscription compared to the cluster
+type subscriptionMetricDrift struct {
+	Metric       string  `json:"metric"`
+	Subscription float64 `json:"subscription"`
+	Live         float64 `json:"live"`
+	Difference   float64 `json:"difference"`
+	Drift        bool    `json:"drift"`
+	// Source tells where the live value comes from, the nodes of the cluster or its telemetry
+	Source string `json:"source"`
+}
+
+type subscriptionDriftResponse struct {
+	ClusterID      string                    `json:"cluster_id"`
+	SubscriptionID string                    `json:"subscription_id"`
+	Usage          string                    `json:"usage"`
+	SupportLevel   string                    `json:"support_level"`
+	SystemUnits    string                    `json:"system_units"`
+	MetricsUpdated *time.Time                `json:"metrics_updated,omitempty"`
+	StaleMetrics   bool                      `json:"stale_metrics"`
+	Drift          bool                      `json:"drift"`
+	Metrics        []subscriptionMetricDrift `json:"metrics"`
+}
+
+func (r subscriptionDriftResponse) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Subscription %s of cluster %s: %s usage, %s support, billed by %s\n", r.SubscriptionID, r.ClusterID,
+		valueOrUnknown(r.Usage), valueOrUnknown(r.SupportLevel), valueOrUnknown(r.SystemUnits))
+	switch {
+	case r.MetricsUpdated == nil:
+		b.WriteString("Warning: the subscription has no metrics, the cluster may not send telemetry\n")
+	case r.StaleMetrics:
+		fmt.Fprintf(&b, "Warning: the metrics of the subscription were last updated %s, the cluster may not send telemetry anymore\n", timefmt.Format(*r.MetricsUpdated))
+	default:
+		fmt.Fprintf(&b, "Metrics last updated %s\n", timefmt.Format(*r.MetricsUpdated))
+	}
+	b.WriteString("\n")
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Metric", "Subscription", "Live", "Difference", "Drift", "Live source"})
+	for _, metric := range r.Metrics {
+		drift := ""
+		if metric.Drift {
+			drift = "yes"
+		}
+		table.AddRow([]string{metric.Metric, formatMetric(metric.Subscription), formatMetric(metric.Live),
+			formatDifference(metric.Difference), drift, metric.Source})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+
+	if r.Drift {
+		b.WriteString("The subscription doesn't match the cluster, billing is based on the subscription.\n")
+	} else {
+		b.WriteString("The subscription matches the cluster.\n")
+	}
+	return b.String()
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+func formatMetric(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatDifference(value float64) string {
+	if value > 0 {
+		return "+" + formatMetric(value)
+	}
+	return formatMetric(value)
+}
+
+func newCmdSubscriptionDrift(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &subscriptionDriftOptions{runOC: utils.RunOC, GlobalOptions: globalOpts}
+	driftCmd := &cobra.Command{
+		Use:               "subscription-drift CLUSTER_ID",
+		Short:             "Compares the metrics of the subscription of a cluster with the live cluster",
+		Long:              subscriptionDriftLongDescription,
+		Example:           subscriptionDriftExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	driftCmd.Flags().Float64Var(&ops.tolerance, "tolerance", 0, "Fraction of the subscription value a live value can differ by without being flagged, e.g. 0.1 for autoscaled clusters")
+	driftCmd.Flags().DurationVar(&ops.staleAfter, "stale-after", 24*time.Hour, "Flag the metrics of the subscription as stale when they weren't updated for this long")
+
+	return driftCmd
+}
+
+func (o *subscriptionDriftOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.tolerance < 0 || o.tolerance >= 1 {
+		return cmdutil.UsageErrorf(cmd, "--tolerance must be between 0 and 1")
+	}
+	o.clusterID = args[0]
+	return utils.IsValidClusterKey(o.clusterID)
+}
+
+func (o *subscriptionDriftOptions) run() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	subscriptionID := cluster.Subscription().ID()
+	if subscriptionID == "" {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "cluster %s has no subscription", cluster.ID())
+	}
+	subscription, err := connection.AccountsMgmt().V1().Subscriptions().Subscription(subscriptionID).Get().Parameter("fetchMetrics", true).Send()
+	if err != nil {
+		return fmt.Errorf("cannot get the subscription %s: %w", subscriptionID, err)
+	}
+	if err := utils.CheckOCCluster(o.runOC, cluster); err != nil {
+		return err
+	}
+
+	output, err := o.runOC("get", "nodes", "-o", "json")
+	if err != nil {
+		return err
+	}
+	var nodes corev1.NodeList
+	if err := json.Unmarshal(output, &nodes); err != nil {
+		return fmt.Errorf("cannot parse the nodes: %w", err)
+	}
+
+	response := compareSubscription(cluster.ID(), subscription.Body(), nodes.Items, o.tolerance, o.staleAfter, time.Now())
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// liveNodeMetrics counts the nodes by role, and the vCPUs and memory in GiB of the compute nodes
+type liveNodeMetrics struct {
+	controlPlane  float64
+	infra         float64
+	compute       float64
+	computeCPU    float64
+	computeMemory float64
+}
+
+func countNodes(nodes []corev1.Node) liveNodeMetrics {
+	var live liveNodeMetrics
+	for _, node := range nodes {
+		_, master := node.Labels[masterNodeRoleLabel]
+		_, controlPlane := node.Labels[controlPlaneNodeRoleLabel]
+		_, infra := node.Labels[infraNodeRoleLabel]
+		switch {
+		case master || controlPlane:
+			live.controlPlane++
+		case infra:
+			live.infra++
+		default:
+			live.compute++
+			live.computeCPU += float64(node.Status.Capacity.Cpu().MilliValue()) / 1000
+			live.computeMemory += float64(node.Status.Capacity.Memory().Value()) / (1 << 30)
+		}
+	}
+	return live
+}
+
+// compareSubscription compares the subscription and the metrics OCM received from the telemetry of the cluster
+// with the nodes of the cluster
+func compareSubscription(clusterID string, subscription *amv1.Subscription, nodes []corev1.Node, tolerance float64, staleAfter time.Duration, now time.Time) subscriptionDriftResponse {
+	response := subscriptionDriftResponse{
+		ClusterID:      clusterID,
+		SubscriptionID: subscription.ID(),
+		Usage:          subscription.Usage(),
+		SupportLevel:   subscription.SupportLevel(),
+		SystemUnits:    subscription.SystemUnits(),
+	}
+	// The getters of the SDK return zero values on nil, when the cluster never sent telemetry
+	var metrics *amv1.SubscriptionMetrics
+	if len(subscription.Metrics()) > 0 {
+		metrics = subscription.Metrics()[0]
+	}
+	if updated := metrics.ComputeNodesCpu().UpdatedTimestamp(); !updated.IsZero() {
+		response.MetricsUpdated = &updated
+		response.StaleMetrics = now.Sub(updated) > staleAfter
+	}
+
+	live := countNodes(nodes)
+	add := func(metric string, reported, actual float64, source string) {
+		drift := subscriptionMetricDrift{Metric: metric, Subscription: reported, Live: actual, Source: source}
+		drift.Difference = math.Round((actual-reported)*100) / 100
+		drift.Drift = math.Abs(actual-reported) > tolerance*reported && drift.Difference != 0
+		response.Drift = response.Drift || drift.Drift
+		response.Metrics = append(response.Metrics, drift)
+	}
+	add("Control plane nodes", metrics.Nodes().Master(), live.controlPlane, "nodes")
+	add("Infra nodes", metrics.Nodes().Infra(), live.infra, "nodes")
+	add("Compute nodes", metrics.Nodes().Compute(), live.compute, "nodes")
+	add("Compute vCPUs", metrics.ComputeNodesCpu().Total().Value(), live.computeCPU, "nodes")
+	add("Compute memory (GiB)", math.Round(metrics.ComputeNodesMemory().Total().Value()/(1<<30)*100)/100, math.Round(live.computeMemory*100)/100, "nodes")
+	add("Sockets", float64(subscription.SocketTotal()), metrics.Sockets().Total().Value(), "telemetry")
+	add("Subscribed vCPUs", float64(subscription.CpuTotal()), metrics.SubscriptionCpuTotal(), "telemetry")
+	return response
+}
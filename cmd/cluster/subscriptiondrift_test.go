@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func driftNode(name, role, cpu, memory string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{role: ""}},
+		Status: corev1.NodeStatus{Capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}},
+	}
+}
+
+func TestCompareSubscription(t *testing.T) {
+	g := NewGomegaWithT(t)
+	updated := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	subscription, err := amv1.NewSubscription().ID("sub").Usage("Production").SupportLevel("Premium").SystemUnits("Cores/vCPU").
+		SocketTotal(4).CpuTotal(8).
+		Metrics(amv1.NewSubscriptionMetrics().
+			Nodes(amv1.NewClusterMetricsNodes().Master(3).Infra(2).Compute(2)).
+			ComputeNodesCpu(amv1.NewClusterResource().Total(amv1.NewValueUnit().Value(8).Unit("")).UpdatedTimestamp(updated)).
+			ComputeNodesMemory(amv1.NewClusterResource().Total(amv1.NewValueUnit().Value(32 << 30).Unit("B"))).
+			Sockets(amv1.NewClusterResource().Total(amv1.NewValueUnit().Value(4))).
+			SubscriptionCpuTotal(8)).
+		Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	nodes := []corev1.Node{
+		driftNode("master-0", masterNodeRoleLabel, "4", "16Gi"),
+		driftNode("master-1", controlPlaneNodeRoleLabel, "4", "16Gi"),
+		driftNode("master-2", masterNodeRoleLabel, "4", "16Gi"),
+		driftNode("infra-0", infraNodeRoleLabel, "4", "16Gi"),
+		driftNode("infra-1", infraNodeRoleLabel, "4", "16Gi"),
+		driftNode("worker-0", "node-role.kubernetes.io/worker", "4", "16Gi"),
+		driftNode("worker-1", "node-role.kubernetes.io/worker", "4", "16Gi"),
+		// Scaled up since the last telemetry
+		driftNode("worker-2", "node-role.kubernetes.io/worker", "3500m", "16Gi"),
+	}
+
+	response := compareSubscription("abc", subscription, nodes, 0, 24*time.Hour, updated.Add(time.Hour))
+	g.Expect(response.Drift).To(BeTrue())
+	g.Expect(response.StaleMetrics).To(BeFalse())
+	g.Expect(*response.MetricsUpdated).To(Equal(updated))
+
+	drifting := map[string]float64{}
+	for _, metric := range response.Metrics {
+		if metric.Drift {
+			drifting[metric.Metric] = metric.Difference
+		}
+	}
+	g.Expect(drifting).To(Equal(map[string]float64{"Compute nodes": 1, "Compute vCPUs": 3.5, "Compute memory (GiB)": 16}))
+
+	// Within the tolerance of autoscaling
+	response = compareSubscription("abc", subscription, nodes, 0.5, 24*time.Hour, updated.Add(48*time.Hour))
+	g.Expect(response.Drift).To(BeFalse())
+	g.Expect(response.StaleMetrics).To(BeTrue())
+	g.Expect(response.String()).To(ContainSubstring("The subscription matches the cluster."))
+
+	data, err := json.Marshal(response)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(ContainSubstring(`{"metric":"Compute nodes","subscription":2,"live":3,"difference":1,"drift":false,"source":"nodes"}`))
+}
+
+func TestCompareSubscriptionWithoutMetrics(t *testing.T) {
+	g := NewGomegaWithT(t)
+	subscription, err := amv1.NewSubscription().ID("sub").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	response := compareSubscription("abc", subscription, []corev1.Node{driftNode("worker-0", "node-role.kubernetes.io/worker", "4", "16Gi")}, 0, time.Hour, time.Now())
+	g.Expect(response.MetricsUpdated).To(BeNil())
+	g.Expect(response.Drift).To(BeTrue())
+	g.Expect(response.String()).To(ContainSubstring("the subscription has no metrics"))
+}
@@ -0,0 +1,133 @@
+package support
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift-online/ocm-cli/pkg/arguments"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/internal/servicelog"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+)
+
+// maxAttachedAlerts bounds the alerts listed in the service log, keeping the most severe and oldest ones
+const maxAttachedAlerts = 50
+
+// severityOrder sorts the alerts from the most to the least severe, unknown severities last
+var severityOrder = map[string]int{"critical": 0, "warning": 1, "info": 2}
+
+// alert is a firing alertmanager alert, as listed by amtool
+type alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	Status      struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+// firingAlerts returns the alerts of the cluster that are firing and not silenced nor inhibited
+func firingAlerts(run ctlutil.OCRunner) ([]alert, error) {
+	output, err := amtool(run, "alert", "query", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var alerts []alert
+	if err := json.Unmarshal(output, &alerts); err != nil {
+		return nil, fmt.Errorf("cannot parse the alerts: %w", err)
+	}
+
+	var firing []alert
+	for _, a := range alerts {
+		if a.Status.State == "active" {
+			firing = append(firing, a)
+		}
+	}
+	return firing, nil
+}
+
+// summarizeAlerts returns one line per alert, the most severe and oldest first
+func summarizeAlerts(alerts []alert) string {
+	if len(alerts) == 0 {
+		return "No alert was firing.\n"
+	}
+	sorted := append([]alert(nil), alerts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, sj := severityRank(sorted[i]), severityRank(sorted[j])
+		if si != sj {
+			return si < sj
+		}
+		return sorted[i].StartsAt.Before(sorted[j].StartsAt)
+	})
+
+	var b strings.Builder
+	for i, a := range sorted {
+		if i == maxAttachedAlerts {
+			fmt.Fprintf(&b, "... and %d more\n", len(sorted)-maxAttachedAlerts)
+			break
+		}
+		fmt.Fprintf(&b, "- %s (%s", a.Labels["alertname"], a.Labels["severity"])
+		if namespace := a.Labels["namespace"]; namespace != "" {
+			fmt.Fprintf(&b, ", %s", namespace)
+		}
+		fmt.Fprintf(&b, ") since %s", a.StartsAt.UTC().Format(time.RFC3339))
+		if summary := a.Annotations["summary"]; summary != "" {
+			fmt.Fprintf(&b, ": %s", summary)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func severityRank(a alert) int {
+	if rank, ok := severityOrder[a.Labels["severity"]]; ok {
+		return rank
+	}
+	return len(severityOrder)
+}
+
+// createAlertsServiceLogRequest builds the internal service log keeping the alerts that were firing when the
+// limited support reason was posted, limited support reasons have no internal field and their details are
+// shown to the customer
+func createAlertsServiceLogRequest(ocmClient SDKConnection, cluster *v1.Cluster, reasonID string, alerts string) (*sdk.Request, error) {
+	request := ocmClient.Post()
+	err := arguments.ApplyPathArg(request, serviceLogAPIPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse API path '%s': %v", serviceLogAPIPath, err)
+	}
+
+	message := servicelog.Message{
+		Severity:     "Info",
+		ServiceName:  "SREManualAction",
+		ClusterUUID:  cluster.ExternalID(),
+		ClusterID:    cluster.ID(),
+		Summary:      fmt.Sprintf("Alerts firing when limited support reason %s was posted", reasonID),
+		Description:  alerts,
+		InternalOnly: true,
+	}
+	if subscription := cluster.Subscription(); subscription != nil {
+		message.SubscriptionID = subscription.ID()
+	}
+
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal service log to json: %v", err)
+	}
+
+	request.Bytes(messageBytes)
+	return request, nil
+}
+
+func checkAlertsServiceLog(response *sdk.Response) error {
+	if response.Status() == http.StatusCreated {
+		fmt.Printf("Firing alerts have been attached in an internal service log\n")
+		return nil
+	}
+
+	return badResponse(response.Status(), response.Bytes())
+}
@@ -0,0 +1,63 @@
+package support
+
+import (
+	"strings"
+	"testing"
+
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func TestFiringAlerts(t *testing.T) {
+	run := func(args ...string) ([]byte, error) {
+		if !strings.HasSuffix(strings.Join(args, " "), "alert query -o json") {
+			t.Fatalf("unexpected oc call: %v", args)
+		}
+		return []byte(`[
+			{"labels": {"alertname": "KubePodCrashLooping", "severity": "warning", "namespace": "openshift-ingress"}, "startsAt": "2026-10-01T10:00:00Z", "status": {"state": "active"}},
+			{"labels": {"alertname": "Watchdog", "severity": "none"}, "startsAt": "2026-09-01T10:00:00Z", "status": {"state": "active"}},
+			{"labels": {"alertname": "ClusterOperatorDown", "severity": "critical"}, "annotations": {"summary": "Cluster operator ingress has been unavailable for 10 minutes."}, "startsAt": "2026-10-02T10:00:00Z", "status": {"state": "active"}},
+			{"labels": {"alertname": "KubeNodeNotReady", "severity": "warning"}, "startsAt": "2026-09-30T10:00:00Z", "status": {"state": "suppressed"}}
+		]`), nil
+	}
+
+	alerts, err := firingAlerts(run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "- ClusterOperatorDown (critical) since 2026-10-02T10:00:00Z: Cluster operator ingress has been unavailable for 10 minutes.\n" +
+		"- KubePodCrashLooping (warning, openshift-ingress) since 2026-10-01T10:00:00Z\n" +
+		"- Watchdog (none) since 2026-09-01T10:00:00Z\n"
+	if summary := summarizeAlerts(alerts); summary != expected {
+		t.Errorf("unexpected summary:\n%s", summary)
+	}
+}
+
+func TestSummarizeAlertsBounded(t *testing.T) {
+	if summary := summarizeAlerts(nil); summary != "No alert was firing.\n" {
+		t.Errorf("unexpected summary for no alerts: %q", summary)
+	}
+
+	alerts := make([]alert, maxAttachedAlerts+3)
+	summary := summarizeAlerts(alerts)
+	if lines := strings.Count(summary, "\n"); lines != maxAttachedAlerts+1 {
+		t.Errorf("expected %d lines, got %d", maxAttachedAlerts+1, lines)
+	}
+	if !strings.HasSuffix(summary, "... and 3 more\n") {
+		t.Errorf("expected the remaining alerts to be counted, got %q", summary)
+	}
+}
+
+func TestCreateAlertsServiceLogRequest(t *testing.T) {
+	cluster, err := v1.NewCluster().ID("abc123").ExternalID("uuid").Build()
+	if err != nil {
+		t.Fatalf("cannot build cluster: %v", err)
+	}
+
+	request, err := createAlertsServiceLogRequest(&MockClient{}, cluster, "1uyTmQSpNgDkmDThBhmyxHsKQby", "No alert was firing.\n")
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %s", err)
+	}
+	if request.GetPath() != serviceLogAPIPath {
+		t.Fatalf("Unexpected path %s", request.GetPath())
+	}
+}
@@ -12,6 +12,10 @@ import (
 // osdctl cluster support status
 // osdctl cluster support create --summary="" --reason=""
 // osdctl cluster support delete --reason=""
+// osdctl cluster support edit --limited-support-reason-id="" --summary=""
+// osdctl cluster support stats --since=90d
+// osdctl cluster support pending-review --older-than=30d
+// osdctl cluster support sweep --template="" --resolved-check=""
 func NewCmdSupport(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, client client.Client, globalOpts *globalflags.GlobalOptions) *cobra.Command {
 	supportCmd := &cobra.Command{
 		Use:               "support",
@@ -21,9 +25,13 @@ func NewCmdSupport(streams genericclioptions.IOStreams, flags *genericclioptions
 		Run:               help,
 	}
 
-	supportCmd.AddCommand(newCmdstatus(streams, flags, globalOpts))
+	supportCmd.AddCommand(NewCmdStatus(streams, flags, globalOpts))
 	supportCmd.AddCommand(newCmdpost(streams, flags, globalOpts))
 	supportCmd.AddCommand(newCmddelete(streams, flags, globalOpts))
+	supportCmd.AddCommand(newCmdedit(streams, flags, globalOpts))
+	supportCmd.AddCommand(newCmdstats(streams, flags, globalOpts))
+	supportCmd.AddCommand(newCmdpendingReview(streams, flags, globalOpts))
+	supportCmd.AddCommand(newCmdsweep(streams, flags, globalOpts))
 
 	return supportCmd
 }
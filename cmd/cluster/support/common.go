@@ -2,15 +2,53 @@ package support
 
 import (
 	"fmt"
+	"os"
 
 	sdk "github.com/openshift-online/ocm-sdk-go"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
 )
 
+// SDKConnection is satisfied by the sdk.Connection and by the mock connection of the tests
+type SDKConnection interface {
+	Post() *sdk.Request
+	Delete() *sdk.Request
+}
+
 func sendRequest(request *sdk.Request) (*sdk.Response, error) {
 
 	response, err := request.Send()
 	if err != nil {
-		return nil, fmt.Errorf("cannot send request: %q", err)
+		return nil, fmt.Errorf("cannot send request: %w", err)
 	}
 	return response, nil
 }
+
+// getCluster finds the cluster targeted by a support command. Clusters in an odd state (e.g. archived
+// after deprovisioning) aren't found by the regular lookup, so fall back to the subscription, which
+// still references the cluster by its external ID.
+func getCluster(connection *sdk.Connection, key string) (*v1.Cluster, error) {
+	cluster, err := ctlutil.GetCluster(connection, key)
+	if err == nil {
+		return cluster, nil
+	}
+
+	cluster, subErr := ctlutil.GetClusterFromSubscription(connection, key)
+	if subErr != nil {
+		return nil, fmt.Errorf("%w, and the subscription lookup failed too: %v", err, subErr)
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: cluster '%s' was found through its subscription only, it may be archived or deprovisioned\n", key)
+	return cluster, nil
+}
+
+// badResponse returns the error of a failed API call, of the class of its HTTP status, so that scripts can tell a
+// missing reason (exit code 3) from a denied call (4) and a server failure worth retrying (5)
+func badResponse(status int, body []byte) error {
+	badReply, err := validateBadResponse(body)
+	if err != nil {
+		return osdctlErrors.WrapStatus(status, fmt.Errorf("failed to validate bad response (status %d): %v", status, err))
+	}
+	return osdctlErrors.WrapStatus(status, fmt.Errorf("bad response reason is: %s", badReply.Reason))
+}
@@ -1,29 +1,54 @@
 package support
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
+	"regexp"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
-	"github.com/openshift/osdctl/internal/support"
+	"github.com/openshift/osdctl/internal/servicelog"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
-	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
 	ctlutil "github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
+const deleteExample = `
+  # Delete a single limited support reason
+  osdctl cluster support delete 1kfmyclusteristhebesteverp8m -i 1uyTmQSpNgDkmDThBhmyxHsKQby
+
+  # Delete the second limited support reason listed by 'osdctl cluster support status'
+  osdctl cluster support delete 1kfmyclusteristhebesteverp8m --index 2
+
+  # Delete every limited support reason mentioning etcd, after listing them
+  osdctl cluster support delete 1kfmyclusteristhebesteverp8m --matching etcd
+
+  # Also expire the alertmanager silences whose comment mentions the reason ID, once logged in with backplane
+  osdctl cluster support delete 1kfmyclusteristhebesteverp8m -i 1uyTmQSpNgDkmDThBhmyxHsKQby --cascade
+`
+
 type deleteOptions struct {
 	output                 string
 	verbose                bool
+	skipPrompts            bool
 	clusterID              string
 	limitedSupportReasonID string
+	index                  int
+	matching               string
+	dryRun                 bool
+
+	postResolutionServiceLog bool
+	resolutionTemplate       string
+	cascade                  bool
+
+	runOC ctlutil.OCRunner
 
 	genericclioptions.IOStreams
 	GlobalOptions *globalflags.GlobalOptions
@@ -35,23 +60,28 @@ func newCmddelete(streams genericclioptions.IOStreams, flags *genericclioptions.
 	deleteCmd := &cobra.Command{
 		Use:               "delete CLUSTER_ID",
 		Short:             "Delete specified limited support reason for a given cluster",
+		Example:           deleteExample,
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
 	// Defined required flags
 	deleteCmd.Flags().StringVarP(&ops.limitedSupportReasonID, "limited-support-reason-id", "i", "", "Limited support reason ID")
-	deleteCmd.Flags().BoolVarP(&isDryRun, "dry-run", "d", false, "Dry-run - print the limited support reason about to be sent but don't send it.")
+	deleteCmd.Flags().IntVar(&ops.index, "index", 0, "Index of the limited support reason in 'osdctl cluster support status', as an alternative to its ID")
+	deleteCmd.Flags().StringVar(&ops.matching, "matching", "", "Delete every limited support reason whose summary matches this regular expression (case insensitive)")
+	deleteCmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Dry-run - print the limited support reason about to be sent but don't send it.")
 	deleteCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
+	deleteCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+	deleteCmd.Flags().BoolVar(&ops.postResolutionServiceLog, "post-resolution-servicelog", false, "Post a service log informing the customer once the limited support reason is removed")
+	deleteCmd.Flags().StringVar(&ops.resolutionTemplate, "resolution-template", "", "Service log template file or URL used with --post-resolution-servicelog (config key: "+ResolutionTemplateConfigKey+"), "+resolutionSummaryPlaceholder+" is replaced by the removed reason's summary")
 
-	// Mark limited-support-reason-id (-i) flag required
-	if err := deleteCmd.MarkFlagRequired("limited-support-reason-id"); err != nil {
-		log.Fatalln("limited-support-reason-id", err)
-	}
+	deleteCmd.Flags().BoolVar(&ops.cascade, "cascade", false, "Also expire the alertmanager silences whose comment mentions the deleted reason IDs, requires being logged in to the cluster with backplane")
+
+	deleteCmd.MarkFlagsMutuallyExclusive("limited-support-reason-id", "index", "matching")
 
 	return deleteCmd
 }
@@ -61,6 +91,7 @@ func newDeleteOptions(streams genericclioptions.IOStreams, flags *genericcliopti
 	return &deleteOptions{
 		IOStreams:     streams,
 		GlobalOptions: globalOpts,
+		runOC:         ctlutil.RunOCAsClusterAdmin,
 	}
 }
 
@@ -70,6 +101,13 @@ func (o *deleteOptions) complete(cmd *cobra.Command, args []string) error {
 		return cmdutil.UsageErrorf(cmd, "Provide exactly one internal cluster ID")
 	}
 
+	if o.limitedSupportReasonID == "" && o.index == 0 && o.matching == "" {
+		return cmdutil.UsageErrorf(cmd, "Provide either a limited support reason ID (-i), its index (--index) or a pattern to match (--matching)")
+	}
+	if o.index < 0 {
+		return cmdutil.UsageErrorf(cmd, "--index starts at 1")
+	}
+
 	o.clusterID = args[0]
 	o.output = o.GlobalOptions.Output
 
@@ -89,41 +127,214 @@ func (o *deleteOptions) run() error {
 	connection := ctlutil.CreateConnection()
 	defer func() {
 		if err := connection.Close(); err != nil {
-			fmt.Printf("Cannot close the connection: %q\n", err)
+			fmt.Fprintf(o.ErrOut, "Cannot close the connection: %q\n", err)
 			os.Exit(1)
 		}
 	}()
 
-	// Stop here if dry-run
-	if isDryRun {
+	// Load the template before changing anything, so that a broken template doesn't leave the customer uninformed
+	var resolutionMessage servicelog.Message
+	if o.postResolutionServiceLog {
+		resolutionMessage, err = loadResolutionTemplate(o.resolutionTemplate)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Stop here if dry-run, unless the reasons to delete, the reason of the index or their silences still need to be listed
+	if o.dryRun && o.matching == "" && o.index == 0 && !o.cascade {
 		return nil
 	}
 
-	// confirmSend prompt to confirm
-	err = utils.ConfirmSend()
+	//getting the cluster
+	cluster, err := getCluster(connection, o.clusterID)
+	if err != nil {
+		return fmt.Errorf("can't retrieve cluster: %w", err)
+	}
+
+	reasons, err := o.reasonsToDelete(connection, cluster)
 	if err != nil {
 		return err
 	}
 
-	//getting the cluster
-	cluster, err := ctlutil.GetCluster(connection, o.clusterID)
+	var action string
+	if o.matching != "" {
+		fmt.Printf("The following limited support reasons match '%s':\n", o.matching)
+		if err := printReasons(reasons); err != nil {
+			return err
+		}
+		action = fmt.Sprintf("Delete %d limited support reason(s)", len(reasons))
+	} else {
+		action = fmt.Sprintf("Delete limited support reason '%s'", o.limitedSupportReasonID)
+	}
+
+	var silences map[string][]silence
+	if o.cascade {
+		silences, err = o.silencesToExpire(cluster, reasons)
+		if err != nil {
+			return err
+		}
+		count := 0
+		for _, linked := range silences {
+			count += len(linked)
+		}
+		fmt.Printf("%d silence(s) are linked to the limited support reasons:\n", count)
+		if err := printSilences(os.Stdout, reasons, silences); err != nil {
+			return err
+		}
+		action += fmt.Sprintf(", expire %d linked silence(s)", count)
+	}
+	if o.dryRun {
+		return nil
+	}
+	if o.postResolutionServiceLog {
+		action += " and post a resolution service log"
+	}
+
+	// Confirm prompt showing what is about to be changed
+	err = ctlutil.Confirm(ctlutil.ConfirmOptions{
+		Summary:    ctlutil.NewClusterImpactSummary(connection, cluster, action),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, reason := range reasons {
+		if err := o.deleteReason(connection, cluster, reason, resolutionMessage); err != nil {
+			return err
+		}
+		if err := expireSilences(o.runOC, silences[reason.ID]); err != nil {
+			return fmt.Errorf("limited support reason deleted, but its silences could not be expired: %w", err)
+		}
+	}
+	return nil
+}
+
+// silencesToExpire returns the active silences linked to each reason
+func (o *deleteOptions) silencesToExpire(cluster *v1.Cluster, reasons []*ctlutil.LimitedSupportReasonItem) (map[string][]silence, error) {
+	if err := ctlutil.CheckOCCluster(o.runOC, cluster); err != nil {
+		return nil, err
+	}
+	active, err := activeSilences(o.runOC)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't retrieve cluster: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("cannot list the silences of cluster %s: %w", cluster.ID(), err)
 	}
+	silences := map[string][]silence{}
+	for _, reason := range reasons {
+		silences[reason.ID] = linkedSilences(active, reason.ID)
+	}
+	return silences, nil
+}
 
-	deleteRequest, err := createDeleteRequest(connection, cluster, o.limitedSupportReasonID)
+// reasonsToDelete returns the reason given with -i or --index, or every reason whose summary matches --matching.
+// The summary is needed in the resolution service log and gone once the reason is deleted.
+func (o *deleteOptions) reasonsToDelete(connection *sdk.Connection, cluster *v1.Cluster) ([]*ctlutil.LimitedSupportReasonItem, error) {
+	if o.index > 0 {
+		reasons, err := ctlutil.GetClusterLimitedSupportReasons(connection, cluster.ID())
+		if err != nil {
+			return nil, err
+		}
+		reason, err := reasonByIndex(reasons, o.index)
+		if err != nil {
+			return nil, err
+		}
+		// Echo what the index resolved to, the confirmation then shows the ID
+		fmt.Printf("Limited support reason #%d is '%s': %s\n", o.index, reason.ID, reason.Summary)
+		o.limitedSupportReasonID = reason.ID
+		return []*ctlutil.LimitedSupportReasonItem{reason}, nil
+	}
+	if o.matching == "" {
+		reason := &ctlutil.LimitedSupportReasonItem{ID: o.limitedSupportReasonID}
+		if o.postResolutionServiceLog {
+			reasonResponse, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).LimitedSupportReasons().LimitedSupportReason(o.limitedSupportReasonID).Get().Send()
+			if err != nil {
+				return nil, fmt.Errorf("can't retrieve limited support reason '%s': %w", o.limitedSupportReasonID, err)
+			}
+			reason.Summary = reasonResponse.Body().Summary()
+		}
+		return []*ctlutil.LimitedSupportReasonItem{reason}, nil
+	}
+
+	pattern, err := regexp.Compile("(?i)" + o.matching)
 	if err != nil {
-		fmt.Printf("failed post call %q\n", err)
+		return nil, fmt.Errorf("invalid --matching pattern '%s': %w", o.matching, err)
+	}
+	reasons, err := ctlutil.GetClusterLimitedSupportReasons(connection, cluster.ID())
+	if err != nil {
+		return nil, err
+	}
+	matches := matchReasons(reasons, pattern)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no limited support reason of cluster %s matches '%s'", cluster.ID(), o.matching)
+	}
+	return matches, nil
+}
+
+// reasonByIndex returns the reason of the 1-based index, in the order of ctlutil.GetClusterLimitedSupportReasons
+func reasonByIndex(reasons []*ctlutil.LimitedSupportReasonItem, index int) (*ctlutil.LimitedSupportReasonItem, error) {
+	if index < 1 || index > len(reasons) {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "no limited support reason #%d, the cluster has %d", index, len(reasons))
+	}
+	return reasons[index-1], nil
+}
+
+// matchReasons returns the reasons whose summary matches the pattern
+func matchReasons(reasons []*ctlutil.LimitedSupportReasonItem, pattern *regexp.Regexp) []*ctlutil.LimitedSupportReasonItem {
+	var matches []*ctlutil.LimitedSupportReasonItem
+	for _, reason := range reasons {
+		if pattern.MatchString(reason.Summary) {
+			matches = append(matches, reason)
+		}
+	}
+	return matches
+}
+
+func printReasons(reasons []*ctlutil.LimitedSupportReasonItem) error {
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Reason ID", "Summary"})
+	for _, reason := range reasons {
+		table.AddRow([]string{reason.ID, reason.Summary})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	return table.Flush()
+}
+
+// deleteReason deletes a single limited support reason and posts the resolution service log if requested
+func (o *deleteOptions) deleteReason(connection *sdk.Connection, cluster *v1.Cluster, reason *ctlutil.LimitedSupportReasonItem, resolutionMessage servicelog.Message) error {
+	deleteRequest, err := createDeleteRequest(connection, cluster, reason.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create delete request: %w", err)
 	}
 	deleteResponse, err := sendRequest(deleteRequest)
 	if err != nil {
-		fmt.Printf("Failed to get delete call response: %q\n", err)
+		return fmt.Errorf("failed to get delete call response: %w", err)
 	}
 
 	err = checkDelete(deleteResponse)
+	if errors.Is(err, osdctlErrors.ErrNotFound) {
+		return fmt.Errorf("limited support reason '%s' not found, it may have been deleted already: %w", reason.ID, err)
+	}
+	if err != nil {
+		return fmt.Errorf("check for delete call failed: %w", err)
+	}
+	ctlutil.InvalidateClusterMetadata(cluster.ID())
+
+	if !o.postResolutionServiceLog {
+		return nil
+	}
+
+	serviceLogRequest, err := createResolutionServiceLogRequest(connection, cluster, resolutionMessage, reason.Summary)
+	if err != nil {
+		return fmt.Errorf("limited support reason deleted, but the resolution service log could not be created: %w", err)
+	}
+	serviceLogResponse, err := sendRequest(serviceLogRequest)
 	if err != nil {
-		fmt.Printf("check for delete call failed: %q", err)
+		return fmt.Errorf("limited support reason deleted, but the resolution service log could not be sent: %w", err)
+	}
+	if err := checkResolutionServiceLog(serviceLogResponse); err != nil {
+		return fmt.Errorf("limited support reason deleted, but the resolution service log failed: %w", err)
 	}
 
 	return nil
@@ -145,22 +356,11 @@ func createDeleteRequest(ocmClient SDKConnection, cluster *v1.Cluster, reasonID
 }
 
 // checkDelete checks the response from delete API call
-// 204 if success, otherwise error
+// 204 if success, otherwise error. A 404 means there is no such reason, it may have been deleted already.
 func checkDelete(response *sdk.Response) error {
-
-	var badReply *support.BadReply
-	body := response.Bytes()
 	if response.Status() == http.StatusNoContent {
 		fmt.Printf("Limited support reason deleted successfully\n")
 		return nil
 	}
-
-	if ok := json.Valid(body); !ok {
-		return fmt.Errorf("server returned invalid JSON")
-	}
-
-	if err := json.Unmarshal(body, &badReply); err != nil {
-		return fmt.Errorf("cannot parse the error JSON meessage: %q", err)
-	}
-	return nil
+	return badResponse(response.Status(), response.Bytes())
 }
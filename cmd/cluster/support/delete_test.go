@@ -0,0 +1,105 @@
+package support
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+)
+
+func TestMatchReasons(t *testing.T) {
+	reasons := []*ctlutil.LimitedSupportReasonItem{
+		{ID: "1", Summary: "Cluster is unreachable"},
+		{ID: "2", Summary: "ETCD quorum lost"},
+		{ID: "3", Summary: "Unsupported etcd encryption change"},
+	}
+
+	tests := []struct {
+		name     string
+		pattern  string
+		expected []string
+	}{
+		{
+			name:     "matches case-insensitively",
+			pattern:  "etcd",
+			expected: []string{"2", "3"},
+		},
+		{
+			name:     "supports regular expressions",
+			pattern:  "^cluster .*reachable$",
+			expected: []string{"1"},
+		},
+		{
+			name:    "no match",
+			pattern: "cloud provider",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, reason := range matchReasons(reasons, regexp.MustCompile("(?i)"+tt.pattern)) {
+				got = append(got, reason.ID)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("matchReasons() = %v, expected %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("matchReasons() = %v, expected %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestReasonByIndex(t *testing.T) {
+	now := time.Now()
+	reasons := []*ctlutil.LimitedSupportReasonItem{
+		{ID: "newest", CreatedAt: now},
+		{ID: "b", CreatedAt: now.Add(-time.Hour)},
+		{ID: "a", CreatedAt: now.Add(-time.Hour)},
+		{ID: "oldest", CreatedAt: now.Add(-2 * time.Hour)},
+	}
+	// The indices follow the order of the listings: oldest first, then by ID
+	ctlutil.SortLimitedSupportReasons(reasons)
+	for index, expected := range map[int]string{1: "oldest", 2: "a", 3: "b", 4: "newest"} {
+		reason, err := reasonByIndex(reasons, index)
+		if err != nil {
+			t.Fatalf("unexpected error for #%d: %v", index, err)
+		}
+		if reason.ID != expected {
+			t.Errorf("expected #%d to be %s, got %s", index, expected, reason.ID)
+		}
+	}
+	for _, index := range []int{0, 5} {
+		if _, err := reasonByIndex(reasons, index); !errors.Is(err, osdctlErrors.ErrNotFound) {
+			t.Errorf("expected #%d not to be found, got %v", index, err)
+		}
+	}
+}
+
+func TestBadResponse(t *testing.T) {
+	tests := []struct {
+		status   int
+		body     string
+		class    error
+		exitCode int
+		message  string
+	}{
+		{404, `{"kind":"Error","reason":"Limited support reason '1uy' not found"}`, osdctlErrors.ErrNotFound, osdctlErrors.ExitNotFound, "bad response reason is: Limited support reason '1uy' not found"},
+		{403, `{"kind":"Error","reason":"Forbidden"}`, osdctlErrors.ErrForbidden, osdctlErrors.ExitForbidden, "bad response reason is: Forbidden"},
+		{503, `upstream connect error`, osdctlErrors.ErrTransient, osdctlErrors.ExitTransient, "failed to validate bad response (status 503): Server returned invalid JSON"},
+	}
+	for _, tt := range tests {
+		err := badResponse(tt.status, []byte(tt.body))
+		if !errors.Is(err, tt.class) || osdctlErrors.ExitCode(err) != tt.exitCode {
+			t.Errorf("status %d: expected exit code %d, got %v (%d)", tt.status, tt.exitCode, err, osdctlErrors.ExitCode(err))
+		}
+		if err.Error() != tt.message {
+			t.Errorf("status %d: expected %q, got %q", tt.status, tt.message, err.Error())
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package support
+
+import (
+	"fmt"
+	"os"
+
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/internal/support"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+type editOptions struct {
+	output                 string
+	verbose                bool
+	skipPrompts            bool
+	clusterID              string
+	limitedSupportReasonID string
+	summary                string
+	details                string
+	dryRun                 bool
+
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+func newCmdedit(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+
+	ops := newEditOptions(streams, flags, globalOpts)
+	editCmd := &cobra.Command{
+		Use:   "edit CLUSTER_ID",
+		Short: "Edit the summary or details of an existing limited support reason for a given cluster",
+		Long: `Edit the summary or details of an existing limited support reason for a given cluster.
+
+OCM has no update of the limited support reasons, so the edited reason is posted as a new one, with the detection
+type of the current one, before the current one is deleted. The cluster never leaves limited support, but the
+reason gets a new ID and creation timestamp: update the tickets referencing the old ID.`,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	// Defined required flags
+	editCmd.Flags().StringVarP(&ops.limitedSupportReasonID, "limited-support-reason-id", "i", "", "Limited support reason ID")
+	editCmd.Flags().StringVar(&ops.summary, "summary", "", "New summary of the limited support reason")
+	editCmd.Flags().StringVar(&ops.details, "details", "", "New details of the limited support reason")
+	editCmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Dry-run - print the changes about to be sent but don't send them.")
+	editCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
+	editCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+
+	// Mark limited-support-reason-id (-i) flag required
+	_ = editCmd.MarkFlagRequired("limited-support-reason-id")
+
+	return editCmd
+}
+
+func newEditOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *editOptions {
+
+	return &editOptions{
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+func (o *editOptions) complete(cmd *cobra.Command, args []string) error {
+
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "Provide exactly one internal cluster ID")
+	}
+
+	if o.summary == "" && o.details == "" {
+		return cmdutil.UsageErrorf(cmd, "Provide at least one of --summary or --details")
+	}
+
+	o.clusterID = args[0]
+	o.output = o.GlobalOptions.Output
+
+	return nil
+}
+
+func (o *editOptions) run() error {
+
+	// Check that the cluster key (name, identifier or external identifier) given by the user
+	// is reasonably safe so that there is no risk of SQL injection
+	err := ctlutil.IsValidClusterKey(o.clusterID)
+	if err != nil {
+		return err
+	}
+
+	// Create an OCM client to talk to the cluster API
+	connection := ctlutil.CreateConnection()
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot close the connection: %q\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	//getting the cluster
+	cluster, err := getCluster(connection, o.clusterID)
+	if err != nil {
+		return fmt.Errorf("can't retrieve cluster: %w", err)
+	}
+
+	reasonResponse, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).LimitedSupportReasons().LimitedSupportReason(o.limitedSupportReasonID).Get().Send()
+	if err != nil {
+		return fmt.Errorf("can't retrieve limited support reason '%s': %w", o.limitedSupportReasonID, err)
+	}
+	diff := editDiff(reasonResponse.Body(), o.summary, o.details)
+
+	// Stop here if dry-run
+	if o.dryRun {
+		return diff.Print(os.Stdout)
+	}
+
+	// Confirm prompt showing what is about to be changed
+	err = ctlutil.Confirm(ctlutil.ConfirmOptions{
+		Summary:    ctlutil.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("Edit limited support reason '%s'", o.limitedSupportReasonID)),
+		Diff:       diff,
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	replacementID, err := replaceReason(connection, cluster, reasonResponse.Body(), o.summary, o.details)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Limited support reason %s has been replaced by %s\n", o.limitedSupportReasonID, replacementID)
+	ctlutil.InvalidateClusterMetadata(cluster.ID())
+	return nil
+}
+
+// editDiff is the change to the limited support reason, empty summary or details keep their current value
+func editDiff(reason *v1.LimitedSupportReason, summary, details string) *printer.Diff {
+	before := map[string]string{"summary": reason.Summary(), "details": reason.Details()}
+	after := map[string]string{"summary": reason.Summary(), "details": reason.Details()}
+	if summary != "" {
+		after["summary"] = summary
+	}
+	if details != "" {
+		after["details"] = details
+	}
+	return &printer.Diff{
+		Title:  fmt.Sprintf("Limited support reason %s (created %s):", reason.ID(), timefmt.Format(reason.CreationTimestamp())),
+		Before: before,
+		After:  after,
+	}
+}
+
+// editedReason is the limited support reason replacing the current one, empty summary or details keep their
+// current value. It isn't linked to the template of the current reason, as OCM would fill it from the template.
+func editedReason(reason *v1.LimitedSupportReason, summary, details string) support.LimitedSupport {
+	edited := support.LimitedSupport{
+		Summary:       reason.Summary(),
+		Details:       reason.Details(),
+		DetectionType: string(reason.DetectionType()),
+	}
+	if edited.DetectionType == "" {
+		edited.DetectionType = string(v1.DetectionTypeManual)
+	}
+	if summary != "" {
+		edited.Summary = summary
+	}
+	if details != "" {
+		edited.Details = details
+	}
+	return edited
+}
+
+// replaceReason posts the edited reason then deletes the current one, so that the cluster stays in limited support
+// throughout, and returns the ID of the new reason
+func replaceReason(connection SDKConnection, cluster *v1.Cluster, reason *v1.LimitedSupportReason, summary, details string) (string, error) {
+	edited := editedReason(reason, summary, details)
+	postRequest, err := createPostRequest(connection, cluster, edited)
+	if err != nil {
+		return "", fmt.Errorf("failed to create post request: %w", err)
+	}
+	postResponse, err := sendRequest(postRequest)
+	if err != nil {
+		return "", fmt.Errorf("failed to get post call response: %w", err)
+	}
+	created, err := check(postResponse, edited)
+	if err != nil {
+		return "", err
+	}
+
+	deleteRequest, err := createDeleteRequest(connection, cluster, reason.ID())
+	if err != nil {
+		return "", fmt.Errorf("failed to create delete request: %w", err)
+	}
+	deleteResponse, err := sendRequest(deleteRequest)
+	if err == nil {
+		err = checkDelete(deleteResponse)
+	}
+	if err != nil {
+		return "", fmt.Errorf("the edited reason was posted as %s, but the reason %s couldn't be deleted, delete it with 'osdctl cluster support delete %s -i %s': %w",
+			created.ID, reason.ID(), cluster.ID(), reason.ID(), err)
+	}
+	return created.ID, nil
+}
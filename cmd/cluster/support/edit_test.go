@@ -0,0 +1,113 @@
+package support
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+// newTestConnection returns an OCM connection to the test server
+func newTestConnection(t *testing.T, url string) *sdk.Connection {
+	t.Helper()
+	encode := base64.RawURLEncoding.EncodeToString
+	token := encode([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + encode([]byte(`{"typ":"Bearer","exp":4102444800}`)) + ".signature"
+	connection, err := sdk.NewConnectionBuilder().URL(url).Tokens(token).Build()
+	if err != nil {
+		t.Fatalf("cannot build connection: %v", err)
+	}
+	t.Cleanup(func() { _ = connection.Close() })
+	return connection
+}
+
+func TestReplaceReason(t *testing.T) {
+	cluster, err := v1.NewCluster().ID("abc123").Build()
+	if err != nil {
+		t.Fatalf("cannot build cluster: %v", err)
+	}
+	reason, err := v1.NewLimitedSupportReason().ID("reason-1").Summary("Old summary").Details("Old details").
+		DetectionType(v1.DetectionTypeAuto).Build()
+	if err != nil {
+		t.Fatalf("cannot build reason: %v", err)
+	}
+
+	testCases := []struct {
+		title        string
+		summary      string
+		details      string
+		deleteStatus int
+		expected     map[string]string
+		expectErr    bool
+	}{
+		{
+			title:        "Only the summary is changed",
+			summary:      "New summary",
+			deleteStatus: http.StatusNoContent,
+			expected:     map[string]string{"summary": "New summary", "details": "Old details", "detection_type": "auto"},
+		},
+		{
+			title:        "Summary and details are changed",
+			summary:      "New summary",
+			details:      "New details",
+			deleteStatus: http.StatusNoContent,
+			expected:     map[string]string{"summary": "New summary", "details": "New details", "detection_type": "auto"},
+		},
+		{
+			title:        "The current reason can't be deleted",
+			summary:      "New summary",
+			deleteStatus: http.StatusForbidden,
+			expected:     map[string]string{"summary": "New summary", "details": "Old details", "detection_type": "auto"},
+			expectErr:    true,
+		},
+	}
+	for _, tc := range testCases {
+		var calls []string
+		var posted map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, r.Method+" "+r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			switch r.Method {
+			case http.MethodPost:
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &posted); err != nil {
+					t.Errorf("Test %s failed. Cannot parse body: %s", tc.title, err)
+				}
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.WriteString(w, `{"kind": "LimitedSupportReason", "id": "reason-2"}`)
+			case http.MethodDelete:
+				w.WriteHeader(tc.deleteStatus)
+				if tc.deleteStatus != http.StatusNoContent {
+					_, _ = io.WriteString(w, `{"kind": "Error", "reason": "forbidden"}`)
+				}
+			}
+		}))
+
+		id, err := replaceReason(newTestConnection(t, server.URL), cluster, reason, tc.summary, tc.details)
+		server.Close()
+
+		// The new reason is posted before the current one is deleted, so the cluster stays in limited support
+		expectedCalls := []string{"POST /api/clusters_mgmt/v1/clusters/abc123/limited_support_reasons",
+			"DELETE /api/clusters_mgmt/v1/clusters/abc123/limited_support_reasons/reason-1"}
+		if len(calls) != 2 || calls[0] != expectedCalls[0] || calls[1] != expectedCalls[1] {
+			t.Errorf("Test %s failed. Expected calls %v, but got %v", tc.title, expectedCalls, calls)
+		}
+		if len(posted) != len(tc.expected) || posted["summary"] != tc.expected["summary"] || posted["details"] != tc.expected["details"] ||
+			posted["detection_type"] != tc.expected["detection_type"] {
+			t.Errorf("Test %s failed. Expected body %v, but got %v", tc.title, tc.expected, posted)
+		}
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("Test %s failed. Expected an error", tc.title)
+			}
+			continue
+		}
+		if err != nil || id != "reason-2" {
+			t.Errorf("Test %s failed. Expected reason-2, but got %s and %v", tc.title, id, err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package support
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/openshift-online/ocm-cli/pkg/arguments"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/internal/servicelog"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/viper"
+)
+
+const (
+	// EvidenceBucketConfigKey is the S3 bucket the evidence files are uploaded to, as bucket or bucket/prefix
+	EvidenceBucketConfigKey = "limited_support_evidence_bucket"
+	// EvidenceRegionConfigKey is the region of the evidence bucket
+	EvidenceRegionConfigKey = "limited_support_evidence_region"
+	// EvidenceProfileConfigKey is the AWS profile the evidence files are uploaded with, the default credentials when empty
+	EvidenceProfileConfigKey = "limited_support_evidence_aws_profile"
+
+	// maxEvidenceFileSize bounds the size of an evidence file, it is meant for log snippets and screenshots
+	maxEvidenceFileSize = 20 * 1024 * 1024
+)
+
+func init() {
+	viper.SetDefault(EvidenceRegionConfigKey, common.DefaultRegion)
+}
+
+// evidenceFile is an evidence file uploaded to the evidence bucket
+type evidenceFile struct {
+	Path string
+	Link string
+}
+
+// evidenceBucket returns the bucket and the key prefix of the configured evidence bucket
+func evidenceBucket() (string, string, error) {
+	configured := strings.TrimPrefix(viper.GetString(EvidenceBucketConfigKey), "s3://")
+	bucket, prefix, _ := strings.Cut(strings.Trim(configured, "/"), "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("'--evidence-file' requires the evidence bucket '%s' to be set in the config file", EvidenceBucketConfigKey)
+	}
+	return bucket, prefix, nil
+}
+
+// checkEvidenceFiles fails early when an evidence file can't be read or is too large
+func checkEvidenceFiles(paths []string) error {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("cannot read the evidence file: %w", err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("the evidence file %s is a directory", path)
+		}
+		if info.Size() > maxEvidenceFileSize {
+			return fmt.Errorf("the evidence file %s is larger than %d MiB", path, maxEvidenceFileSize/1024/1024)
+		}
+	}
+	return nil
+}
+
+// newEvidenceClient returns the AWS client the evidence files are uploaded with
+func newEvidenceClient() (awsprovider.Client, error) {
+	return awsprovider.NewAwsClient(viper.GetString(EvidenceProfileConfigKey), viper.GetString(EvidenceRegionConfigKey), "")
+}
+
+// uploadEvidence uploads the evidence files under <prefix>/<cluster ID>/<timestamp>/, so that the evidence of the
+// reasons of a cluster are listed together, and returns their links
+func uploadEvidence(client awsprovider.Client, bucket, prefix string, cluster *v1.Cluster, summary string, paths []string, now time.Time) ([]evidenceFile, error) {
+	folder := fmt.Sprintf("%s/%s", cluster.ID(), now.UTC().Format("20060102T150405Z"))
+	if prefix != "" {
+		folder = prefix + "/" + folder
+	}
+
+	evidence := make([]evidenceFile, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path) //#nosec G304 -- the evidence files cannot be constant
+		if err != nil {
+			return evidence, fmt.Errorf("cannot read the evidence file: %w", err)
+		}
+		key := folder + "/" + filepath.Base(path)
+		_, err = client.PutObject(&s3.PutObjectInput{
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(data),
+			ContentType: aws.String(http.DetectContentType(data)),
+			Metadata: map[string]*string{
+				"cluster-id":  aws.String(cluster.ID()),
+				"external-id": aws.String(cluster.ExternalID()),
+				"summary":     aws.String(summary),
+			},
+		})
+		if err != nil {
+			return evidence, fmt.Errorf("cannot upload the evidence file %s to bucket %s: %w", path, bucket, err)
+		}
+		evidence = append(evidence, evidenceFile{Path: path, Link: fmt.Sprintf("s3://%s/%s", bucket, key)})
+	}
+	return evidence, nil
+}
+
+// describeEvidence returns one line per evidence file, with its link
+func describeEvidence(evidence []evidenceFile) string {
+	var b strings.Builder
+	for _, file := range evidence {
+		fmt.Fprintf(&b, "- %s: %s\n", filepath.Base(file.Path), file.Link)
+	}
+	return b.String()
+}
+
+// createEvidenceServiceLogRequest keeps the links of the evidence files in an internal service log referencing the
+// limited support reason, the details of the reason being read by the customer
+func createEvidenceServiceLogRequest(ocmClient SDKConnection, cluster *v1.Cluster, reasonID string, evidence []evidenceFile) (*sdk.Request, error) {
+	request := ocmClient.Post()
+	err := arguments.ApplyPathArg(request, serviceLogAPIPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse API path '%s': %v", serviceLogAPIPath, err)
+	}
+
+	message := servicelog.Message{
+		Severity:     "Info",
+		ServiceName:  "SREManualAction",
+		ClusterUUID:  cluster.ExternalID(),
+		ClusterID:    cluster.ID(),
+		Summary:      fmt.Sprintf("Evidence of limited support reason %s", reasonID),
+		Description:  describeEvidence(evidence),
+		InternalOnly: true,
+	}
+	if subscription := cluster.Subscription(); subscription != nil {
+		message.SubscriptionID = subscription.ID()
+	}
+
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal service log to json: %v", err)
+	}
+
+	request.Bytes(messageBytes)
+	return request, nil
+}
+
+func checkEvidenceServiceLog(response *sdk.Response) error {
+	if response.Status() == http.StatusCreated {
+		fmt.Printf("Evidence links have been attached in an internal service log\n")
+		return nil
+	}
+
+	return badResponse(response.Status(), response.Bytes())
+}
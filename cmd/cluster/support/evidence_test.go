@@ -0,0 +1,92 @@
+package support
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/spf13/viper"
+)
+
+func TestEvidenceBucket(t *testing.T) {
+	defer viper.Set(EvidenceBucketConfigKey, "")
+
+	viper.Set(EvidenceBucketConfigKey, "")
+	if _, _, err := evidenceBucket(); err == nil {
+		t.Fatalf("Expected an error without an evidence bucket")
+	}
+
+	viper.Set(EvidenceBucketConfigKey, "s3://sre-evidence/limited-support/")
+	bucket, prefix, err := evidenceBucket()
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %s", err)
+	}
+	if bucket != "sre-evidence" || prefix != "limited-support" {
+		t.Fatalf("Unexpected bucket %s and prefix %s", bucket, prefix)
+	}
+}
+
+func TestCheckEvidenceFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "must-gather.log")
+	if err := os.WriteFile(file, []byte("etcd leader changed\n"), 0600); err != nil {
+		t.Fatalf("cannot write the evidence file: %v", err)
+	}
+
+	if err := checkEvidenceFiles([]string{file}); err != nil {
+		t.Fatalf("Expected no errors, but got %s", err)
+	}
+	if err := checkEvidenceFiles([]string{dir}); err == nil {
+		t.Fatalf("Expected an error for a directory")
+	}
+	if err := checkEvidenceFiles([]string{filepath.Join(dir, "missing.png")}); err == nil {
+		t.Fatalf("Expected an error for a missing file")
+	}
+}
+
+func TestUploadEvidence(t *testing.T) {
+	cluster, err := v1.NewCluster().ID("abc123").ExternalID("uuid").Build()
+	if err != nil {
+		t.Fatalf("cannot build cluster: %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "must-gather.log")
+	if err := os.WriteFile(file, []byte("etcd leader changed\n"), 0600); err != nil {
+		t.Fatalf("cannot write the evidence file: %v", err)
+	}
+
+	mockCtrl := gomock.NewController(t)
+	client := awsmock.NewMockClient(mockCtrl)
+	client.EXPECT().PutObject(gomock.Any()).DoAndReturn(func(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+		if *input.Bucket != "sre-evidence" || *input.Key != "limited-support/abc123/20261014T093000Z/must-gather.log" {
+			t.Fatalf("Unexpected object s3://%s/%s", *input.Bucket, *input.Key)
+		}
+		if *input.Metadata["cluster-id"] != "abc123" {
+			t.Fatalf("Unexpected metadata %v", input.Metadata)
+		}
+		return &s3.PutObjectOutput{}, nil
+	})
+
+	now := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+	evidence, err := uploadEvidence(client, "sre-evidence", "limited-support", cluster, "Cluster is overloaded", []string{file}, now)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %s", err)
+	}
+	description := describeEvidence(evidence)
+	if !strings.Contains(description, "must-gather.log: s3://sre-evidence/limited-support/abc123/20261014T093000Z/must-gather.log") {
+		t.Fatalf("Unexpected description %q", description)
+	}
+
+	request, err := createEvidenceServiceLogRequest(&MockClient{}, cluster, "1uyTmQSpNgDkmDThBhmyxHsKQby", evidence)
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %s", err)
+	}
+	if request.GetPath() != serviceLogAPIPath {
+		t.Fatalf("Unexpected path %s", request.GetPath())
+	}
+}
@@ -0,0 +1,300 @@
+package support
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	// TeamLabelConfigKey is the subscription label (key=value) marking the clusters of your team
+	TeamLabelConfigKey = "support_team_label"
+
+	pendingReviewLong = `Lists the limited support reasons that have been in place for longer than --older-than on the clusters
+of your team, so that stale reasons get revisited.
+
+The clusters of your team are the ones whose subscription has the --team-label label, which defaults to
+'` + TeamLabelConfigKey + `' in the config file. With --slack, a digest is also posted to the Slack incoming
+webhook stored with 'osdctl secrets set ` + secrets.SlackWebhookKey + `' or set in the config file.`
+
+	pendingReviewExample = `
+  # Reasons in place for more than 30 days on the clusters of the team
+  osdctl cluster support pending-review --team-label team=my-team --older-than 30d
+
+  # Post the digest to Slack, with the team label from the config file
+  osdctl cluster support pending-review --slack`
+
+	// subscriptionSearchBatch bounds the number of subscription IDs per cluster search
+	subscriptionSearchBatch = 50
+)
+
+type pendingReviewOptions struct {
+	olderThan  time.Duration
+	teamLabel  string
+	labelKey   string
+	labelValue string
+	search     string
+	slack      bool
+	output     string
+
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// newCmdpendingReview implements the pending-review command to list the reasons due for a review
+func newCmdpendingReview(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &pendingReviewOptions{IOStreams: streams, GlobalOptions: globalOpts}
+	pendingReviewCmd := &cobra.Command{
+		Use:               "pending-review",
+		Short:             "List the limited support reasons in place for too long on the clusters of your team",
+		Long:              pendingReviewLong,
+		Example:           pendingReviewExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	flagtypes.DurationVar(pendingReviewCmd.Flags(), &ops.olderThan, "older-than", 30*24*time.Hour, 24*time.Hour, "Minimum age of the reasons to list, e.g. 30d or 72h")
+	pendingReviewCmd.Flags().StringVar(&ops.teamLabel, "team-label", "", "Subscription label of the clusters of your team, as key=value (defaults to '"+TeamLabelConfigKey+"' in the config file)")
+	pendingReviewCmd.Flags().StringVar(&ops.search, "search", "state != 'uninstalling'", "OCM search query further selecting the clusters")
+	pendingReviewCmd.Flags().BoolVar(&ops.slack, "slack", false, "Also post a digest to the Slack webhook")
+
+	return pendingReviewCmd
+}
+
+func (o *pendingReviewOptions) complete(cmd *cobra.Command) error {
+	if o.teamLabel == "" {
+		o.teamLabel = viper.GetString(TeamLabelConfigKey)
+	}
+	if o.teamLabel == "" {
+		return cmdutil.UsageErrorf(cmd, "--team-label is required when '%s' isn't set in the config file", TeamLabelConfigKey)
+	}
+	key, value, found := strings.Cut(o.teamLabel, "=")
+	if !found || key == "" || value == "" {
+		return cmdutil.UsageErrorf(cmd, "invalid team label '%s', expected key=value", o.teamLabel)
+	}
+	o.labelKey, o.labelValue = key, value
+
+	if o.GlobalOptions != nil {
+		o.output = o.GlobalOptions.Output
+	}
+	return nil
+}
+
+type pendingReason struct {
+	ClusterID   string `json:"clusterId" yaml:"clusterId"`
+	ClusterName string `json:"clusterName" yaml:"clusterName"`
+	ReasonID    string `json:"reasonId" yaml:"reasonId"`
+	Summary     string `json:"summary" yaml:"summary"`
+	Template    string `json:"template,omitempty" yaml:"template,omitempty"`
+	CreatedAt   string `json:"createdAt" yaml:"createdAt"`
+	AgeDays     int    `json:"ageDays" yaml:"ageDays"`
+}
+
+type pendingReviewResponse struct {
+	TeamLabel string          `json:"teamLabel" yaml:"teamLabel"`
+	OlderThan string          `json:"olderThan" yaml:"olderThan"`
+	Clusters  int             `json:"clusters" yaml:"clusters"`
+	Reasons   []pendingReason `json:"reasons" yaml:"reasons"`
+}
+
+// String renders the reasons due for a review as a table
+func (r pendingReviewResponse) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d limited support reasons older than %s on %d clusters labeled %s\n\n", len(r.Reasons), r.OlderThan, r.Clusters, r.TeamLabel)
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Cluster", "Name", "Reason ID", "Summary", "Age"})
+	for _, reason := range r.Reasons {
+		age := duration.HumanDuration(time.Duration(reason.AgeDays) * 24 * time.Hour)
+		table.AddRow([]string{reason.ClusterID, reason.ClusterName, reason.ReasonID, reason.Summary, age})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// slackDigest renders the reasons due for a review as markdown that Slack understands, which doesn't include tables
+func (r pendingReviewResponse) slackDigest() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Limited support reasons older than %s on the clusters labeled %s*\n", r.OlderThan, r.TeamLabel)
+	if len(r.Reasons) == 0 {
+		b.WriteString("Nothing to review.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d reasons to revisit:\n", len(r.Reasons))
+	for _, reason := range r.Reasons {
+		fmt.Fprintf(&b, "- `%s` (%s): %s, for %d days\n", reason.ClusterID, reason.ClusterName, reason.Summary, reason.AgeDays)
+	}
+	return b.String()
+}
+
+func (o *pendingReviewOptions) run() error {
+	connection := ctlutil.CreateConnection()
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot close the connection: %q\n", err)
+		}
+	}()
+
+	subscriptions, err := getLabeledSubscriptions(connection, o.labelKey, o.labelValue)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "no subscription is labeled %s", o.teamLabel)
+	}
+
+	now := time.Now().UTC()
+	var reasons []activeReason
+	names := map[string]string{}
+	clusters := 0
+	for _, batch := range subscriptionSearches(subscriptions) {
+		batchClusters, err := ctlutil.ApplyFilters(connection, []string{o.search, batch})
+		if err != nil {
+			return fmt.Errorf("cannot list the clusters: %w", err)
+		}
+		clusters += len(batchClusters)
+		for _, cluster := range batchClusters {
+			// The count is part of the cluster list, don't ask the clusters that have none
+			if count, ok := cluster.Status().GetLimitedSupportReasonCount(); ok && count == 0 {
+				continue
+			}
+			clusterReasons, err := getActiveReasons(connection, cluster)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Cannot get the limited support reasons of cluster %s: %v\n", cluster.ID(), err)
+				continue
+			}
+			names[cluster.ID()] = cluster.Name()
+			reasons = append(reasons, clusterReasons...)
+		}
+	}
+
+	resp := pendingReviewResponse{
+		TeamLabel: o.teamLabel,
+		OlderThan: flagtypes.FormatDuration(o.olderThan),
+		Clusters:  clusters,
+		Reasons:   pendingReasons(reasons, names, o.olderThan, now),
+	}
+
+	if o.slack {
+		if err := postSlackDigest(resp.slackDigest()); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Posted the digest to Slack")
+	}
+	return outputflag.PrintResponse(o.output, resp)
+}
+
+// getLabeledSubscriptions returns the IDs of the subscriptions with the label
+func getLabeledSubscriptions(connection *sdk.Connection, key, value string) ([]string, error) {
+	search := fmt.Sprintf("type = 'Subscription' and key = '%s' and value = '%s'", strings.ReplaceAll(key, "'", "''"), strings.ReplaceAll(value, "'", "''"))
+	var subscriptions []string
+	request := connection.AccountsMgmt().V1().Labels().List().Search(search).Size(statsPageSize)
+	for page := 1; ; page++ {
+		response, err := request.Page(page).Send()
+		if err != nil {
+			return nil, fmt.Errorf("cannot list the subscriptions labeled %s=%s: %w", key, value, err)
+		}
+		for _, label := range response.Items().Slice() {
+			if id := label.SubscriptionID(); id != "" {
+				subscriptions = append(subscriptions, id)
+			}
+		}
+		if response.Size() < statsPageSize {
+			break
+		}
+	}
+	return subscriptions, nil
+}
+
+// subscriptionSearches returns the cluster searches selecting the subscriptions, in batches keeping the queries short
+func subscriptionSearches(subscriptions []string) []string {
+	var searches []string
+	for start := 0; start < len(subscriptions); start += subscriptionSearchBatch {
+		end := start + subscriptionSearchBatch
+		if end > len(subscriptions) {
+			end = len(subscriptions)
+		}
+		searches = append(searches, fmt.Sprintf("subscription.id in ('%s')", strings.Join(subscriptions[start:end], "','")))
+	}
+	return searches
+}
+
+// pendingReasons returns the reasons in place for at least the given age, the oldest first
+func pendingReasons(reasons []activeReason, names map[string]string, olderThan time.Duration, now time.Time) []pendingReason {
+	pending := []pendingReason{}
+	cutoff := now.Add(-olderThan)
+	for _, reason := range reasons {
+		if reason.CreatedAt.After(cutoff) {
+			continue
+		}
+		pending = append(pending, pendingReason{
+			ClusterID:   reason.ClusterID,
+			ClusterName: names[reason.ClusterID],
+			ReasonID:    reason.ID,
+			Summary:     reason.Summary,
+			Template:    reason.Template,
+			CreatedAt:   timefmt.Format(reason.CreatedAt),
+			AgeDays:     int(now.Sub(reason.CreatedAt).Hours() / 24),
+		})
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].CreatedAt < pending[j].CreatedAt
+	})
+	return pending
+}
+
+// postSlackDigest posts the digest to the Slack incoming webhook
+func postSlackDigest(digest string) error {
+	webhook, err := secrets.Lookup(secrets.SlackWebhookKey)
+	if err != nil {
+		return err
+	}
+	if webhook == "" {
+		webhook = viper.GetString(secrets.SlackWebhookKey)
+	}
+	if webhook == "" {
+		return fmt.Errorf("no Slack webhook, store it with 'osdctl secrets set %s'", secrets.SlackWebhookKey)
+	}
+	return sendSlackMessage(http.DefaultClient, webhook, digest)
+}
+
+func sendSlackMessage(client *http.Client, webhook, text string) error {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	response, err := client.Post(webhook, "application/json", bytes.NewReader(body)) //#nosec G107 -- the webhook is the user's
+	if err != nil {
+		return fmt.Errorf("cannot post to Slack: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("cannot post to Slack: %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package support
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPendingReasons(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	got := pendingReasons([]activeReason{
+		{ID: "r1", ClusterID: "a", Summary: "Cluster is not reachable", CreatedAt: now.AddDate(0, 0, -40)},
+		{ID: "r2", ClusterID: "b", Summary: "Unsupported configuration", CreatedAt: now.AddDate(0, 0, -10)},
+		{ID: "r3", ClusterID: "b", Summary: "Cloud provider access removed", CreatedAt: now.AddDate(0, 0, -90)},
+		{ID: "r4", ClusterID: "c", Summary: "Exactly due", CreatedAt: now.AddDate(0, 0, -30)},
+	}, map[string]string{"a": "alpha", "b": "beta"}, 30*24*time.Hour, now)
+
+	var ids []string
+	for _, reason := range got {
+		ids = append(ids, reason.ReasonID)
+	}
+	if strings.Join(ids, ",") != "r3,r1,r4" {
+		t.Fatalf("expected the reasons due for a review, oldest first, got %v", ids)
+	}
+	if got[0].ClusterName != "beta" || got[0].AgeDays != 90 {
+		t.Errorf("unexpected reason %+v", got[0])
+	}
+}
+
+func TestSubscriptionSearches(t *testing.T) {
+	subscriptions := make([]string, subscriptionSearchBatch+1)
+	for i := range subscriptions {
+		subscriptions[i] = fmt.Sprintf("sub%d", i)
+	}
+
+	searches := subscriptionSearches(subscriptions)
+	if len(searches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(searches))
+	}
+	if searches[1] != fmt.Sprintf("subscription.id in ('sub%d')", subscriptionSearchBatch) {
+		t.Errorf("unexpected last batch %q", searches[1])
+	}
+}
+
+func TestSlackDigest(t *testing.T) {
+	empty := pendingReviewResponse{TeamLabel: "team=sre", OlderThan: "30d"}
+	if !strings.Contains(empty.slackDigest(), "Nothing to review") {
+		t.Errorf("expected an empty digest, got %q", empty.slackDigest())
+	}
+
+	resp := pendingReviewResponse{TeamLabel: "team=sre", OlderThan: "30d", Reasons: []pendingReason{
+		{ClusterID: "a", ClusterName: "alpha", Summary: "Cluster is not reachable", AgeDays: 40},
+	}}
+	if !strings.Contains(resp.slackDigest(), "- `a` (alpha): Cluster is not reachable, for 40 days") {
+		t.Errorf("unexpected digest %q", resp.slackDigest())
+	}
+}
+
+func TestSendSlackMessage(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	if err := sendSlackMessage(server.Client(), server.URL, "digest"); err != nil {
+		t.Fatal(err)
+	}
+	if received["text"] != "digest" {
+		t.Errorf("expected the digest as text, got %v", received)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if err := sendSlackMessage(failing.Client(), failing.URL, "digest"); err == nil {
+		t.Error("expected an error when the webhook refuses the message")
+	}
+}
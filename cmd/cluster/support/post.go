@@ -1,13 +1,15 @@
 package support
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	"github.com/openshift-online/ocm-cli/pkg/dump"
@@ -16,27 +18,37 @@ import (
 	"github.com/openshift/osdctl/internal/support"
 	"github.com/openshift/osdctl/internal/utils"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	ctlutil "github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
-var (
-	LimitedSupport                                          support.LimitedSupport
-	template                                                string
-	isDryRun                                                bool
-	templateParams, userParameterNames, userParameterValues []string
-)
-
 const (
 	defaultTemplate = ""
 )
 
 type postOptions struct {
-	output    string
-	verbose   bool
-	clusterID string
+	output         string
+	verbose        bool
+	skipPrompts    bool
+	clusterID      string
+	template       string
+	reasonFile     string
+	dryRun         bool
+	preview        bool
+	templateParams []string
+	attachAlerts   bool
+	evidenceFiles  []string
+
+	runOC             ctlutil.OCRunner
+	newEvidenceClient func() (awsprovider.Client, error)
+
+	limitedSupport                          support.LimitedSupport
+	userParameterNames, userParameterValues []string
 
 	genericclioptions.IOStreams
 	GlobalOptions *globalflags.GlobalOptions
@@ -51,16 +63,21 @@ func newCmdpost(streams genericclioptions.IOStreams, flags *genericclioptions.Co
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
 	// Define required flags
-	postCmd.Flags().StringVarP(&template, "template", "t", defaultTemplate, "Message template file or URL")
-	postCmd.Flags().BoolVarP(&isDryRun, "dry-run", "d", false, "Dry-run - print the limited support reason about to be sent but don't send it.")
-	postCmd.Flags().StringArrayVarP(&templateParams, "param", "p", templateParams, "Specify a key-value pair (eg. -p FOO=BAR) to set/override a parameter value in the template.")
+	postCmd.Flags().StringVarP(&ops.template, "template", "t", defaultTemplate, "Message template file or URL")
+	postCmd.Flags().StringVarP(&ops.reasonFile, "file", "f", "", "Fully formed limited support reason JSON file, or - to read it from stdin. Validated against the limited support reason schema.")
+	postCmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Dry-run - print the limited support reason about to be sent but don't send it.")
+	postCmd.Flags().BoolVar(&ops.preview, "preview", false, "Render the limited support reason as the customer will read it in the OCM console, with the parameters substituted.")
+	postCmd.Flags().StringArrayVarP(&ops.templateParams, "param", "p", ops.templateParams, "Specify a key-value pair (eg. -p FOO=BAR) to set/override a parameter value in the template.")
 	postCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
+	postCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+	postCmd.Flags().BoolVar(&ops.attachAlerts, "attach-alerts", false, "Keep the alerts firing on the cluster in an internal service log referencing the reason, requires being logged in to the cluster with backplane")
+	postCmd.Flags().StringArrayVar(&ops.evidenceFiles, "evidence-file", nil, "Evidence file (log snippet, screenshot) to upload to the evidence bucket (config key: "+EvidenceBucketConfigKey+"), linked in an internal service log referencing the reason. Can be repeated.")
 
 	return postCmd
 }
@@ -68,8 +85,10 @@ func newCmdpost(streams genericclioptions.IOStreams, flags *genericclioptions.Co
 func newPostOptions(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *postOptions {
 
 	return &postOptions{
-		IOStreams:     streams,
-		GlobalOptions: globalOpts,
+		IOStreams:         streams,
+		GlobalOptions:     globalOpts,
+		runOC:             ctlutil.RunOCAsClusterAdmin,
+		newEvidenceClient: newEvidenceClient,
 	}
 }
 
@@ -82,17 +101,44 @@ func (o *postOptions) complete(cmd *cobra.Command, args []string) error {
 	o.clusterID = args[0]
 	o.output = o.GlobalOptions.Output
 
+	if o.template != defaultTemplate && o.reasonFile != "" {
+		return cmdutil.UsageErrorf(cmd, "Use either '-t' or '-f', not both")
+	}
+	if o.template == defaultTemplate && o.reasonFile == "" {
+		return cmdutil.UsageErrorf(cmd, "Provide the limited support reason with '-t' or '-f'")
+	}
+	// The confirmation prompt can't read from stdin once the reason was read from it
+	if o.reasonFile == "-" && !o.skipPrompts && !o.dryRun {
+		return cmdutil.UsageErrorf(cmd, "Reading the reason from stdin requires '--yes' or '--dry-run'")
+	}
+	if len(o.evidenceFiles) > 0 {
+		if _, _, err := evidenceBucket(); err != nil {
+			return cmdutil.UsageErrorf(cmd, "%v", err)
+		}
+		if err := checkEvidenceFiles(o.evidenceFiles); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 func (o *postOptions) run() error {
 
-	// Parse the given JSON template provided via '-t' flag
-	// and load it into the LimitedSupport variable
-	readTemplate()
+	// Parse the given JSON template provided via '-t' flag, or the reason provided via '-f',
+	// and load it into the limitedSupport field
+	if o.reasonFile != "" {
+		if err := o.readReasonFile(); err != nil {
+			return err
+		}
+	} else if err := o.readTemplate(); err != nil {
+		return err
+	}
 
 	// Parse all the '-p' user flags
-	parseUserParameters()
+	if err := o.parseUserParameters(); err != nil {
+		return err
+	}
 
 	// Check that the cluster key (name, identifier or external identifier) given by the user
 	// is reasonably safe so that there is no risk of SQL injection
@@ -102,8 +148,15 @@ func (o *postOptions) run() error {
 	}
 
 	// For every '-p' flag, replace it's related placeholder in the template
-	for k := range userParameterNames {
-		replaceWithFlags(userParameterNames[k], userParameterValues[k])
+	for k := range o.userParameterNames {
+		if err := o.replaceWithFlags(o.userParameterNames[k], o.userParameterValues[k]); err != nil {
+			return err
+		}
+	}
+	if o.reasonFile != "" {
+		if err := validateLimitedSupport(&o.limitedSupport); err != nil {
+			return err
+		}
 	}
 
 	//if the cluster key is on the right format
@@ -111,59 +164,158 @@ func (o *postOptions) run() error {
 	connection := ctlutil.CreateConnection()
 	defer func() {
 		if err := connection.Close(); err != nil {
-			fmt.Printf("Cannot close the connection: %q\n", err)
+			fmt.Fprintf(o.ErrOut, "Cannot close the connection: %q\n", err)
 			os.Exit(1)
 		}
 	}()
 
 	// Print limited support template to be sent
 	fmt.Printf("The following limited support reason will be sent to %s:\n", o.clusterID)
-	if err := printTemplate(); err != nil {
-		fmt.Printf("Cannot read generated template: %q\n", err)
-		os.Exit(1)
+	if err := o.printTemplate(); err != nil {
+		return fmt.Errorf("cannot read generated template: %w", err)
 	}
+	if o.preview {
+		if err := o.printPreview(os.Stdout); err != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot render the preview: %v\n", err)
+		}
+	}
+	sops, err := loadSOPMappings()
+	if err != nil {
+		return err
+	}
+	printSOPLinks(o.ErrOut, sopLinks(sops, o.template, o.limitedSupport.Summary))
 
 	// Stop here if dry-run
-	if isDryRun {
+	if o.dryRun {
 		return nil
 	}
 
-	// ConfirmSend prompt to confirm
-	err = ctlutil.ConfirmSend()
+	//getting the cluster
+	cluster, err := getCluster(connection, o.clusterID)
 	if err != nil {
-		return err
+		return fmt.Errorf("can't retrieve cluster: %w", err)
 	}
 
-	//getting the cluster
-	cluster, err := ctlutil.GetCluster(connection, o.clusterID)
+	// The alerts are collected before posting, so that a cluster which isn't logged in fails early
+	var alerts string
+	if o.attachAlerts {
+		alerts, err = o.collectAlerts(cluster)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(o.ErrOut, "The following firing alerts will be attached in an internal service log:\n%s\n", alerts)
+	}
+
+	if len(o.evidenceFiles) > 0 {
+		bucket, _, _ := evidenceBucket()
+		fmt.Fprintf(o.ErrOut, "The following evidence files will be uploaded to bucket %s and linked in an internal service log:\n%s\n", bucket, strings.Join(o.evidenceFiles, "\n"))
+	}
+
+	// Confirm prompt showing what is about to be changed
+	err = ctlutil.Confirm(ctlutil.ConfirmOptions{
+		Summary:    ctlutil.NewClusterImpactSummary(connection, cluster, fmt.Sprintf("Post limited support reason '%s'", o.limitedSupport.Summary)),
+		SkipPrompt: o.skipPrompts,
+	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't retrieve cluster: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+
+	// The evidence is uploaded before the reason is posted, so that a reason is never posted without its evidence
+	var evidence []evidenceFile
+	if len(o.evidenceFiles) > 0 {
+		evidence, err = o.uploadEvidence(cluster)
+		if err != nil {
+			return err
+		}
 	}
 
 	// postRequest calls createPostRequest and take in client and clustersmgmt/v1.cluster object
-	postRequest, err := createPostRequest(connection, cluster)
+	postRequest, err := createPostRequest(connection, cluster, o.limitedSupport)
 	if err != nil {
-		fmt.Printf("failed to create post request %q\n", err)
+		return fmt.Errorf("failed to create post request: %w", err)
 	}
 	postResponse, err := sendRequest(postRequest)
 	if err != nil {
-		fmt.Printf("Failed to get post call response: %q\n", err)
+		return fmt.Errorf("failed to get post call response: %w", err)
+	}
+
+	// check if response matches limitedSupport
+	reply, err := check(postResponse, o.limitedSupport)
+	if err != nil {
+		return fmt.Errorf("check for post call failed: %w", err)
 	}
+	ctlutil.InvalidateClusterMetadata(cluster.ID())
 
-	// check if response matches LimitedSupport
-	err = check(postResponse, LimitedSupport)
+	if len(evidence) > 0 {
+		if err := o.attachEvidence(connection, cluster, reply.ID, evidence); err != nil {
+			return err
+		}
+	}
+
+	if !o.attachAlerts {
+		return nil
+	}
+
+	serviceLogRequest, err := createAlertsServiceLogRequest(connection, cluster, reply.ID, alerts)
 	if err != nil {
-		fmt.Printf("Failed to check postResponse %q\n", err)
+		return fmt.Errorf("limited support reason posted, but the alerts service log could not be created: %w", err)
+	}
+	serviceLogResponse, err := sendRequest(serviceLogRequest)
+	if err != nil {
+		return fmt.Errorf("limited support reason posted, but the alerts service log could not be sent: %w", err)
+	}
+	if err := checkAlertsServiceLog(serviceLogResponse); err != nil {
+		return fmt.Errorf("limited support reason posted, but the alerts service log failed: %w", err)
 	}
 	return nil
 }
 
+// uploadEvidence uploads the evidence files to the configured evidence bucket
+func (o *postOptions) uploadEvidence(cluster *v1.Cluster) ([]evidenceFile, error) {
+	bucket, prefix, err := evidenceBucket()
+	if err != nil {
+		return nil, err
+	}
+	client, err := o.newEvidenceClient()
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the AWS client of the evidence bucket: %w", err)
+	}
+	return uploadEvidence(client, bucket, prefix, cluster, o.limitedSupport.Summary, o.evidenceFiles, time.Now())
+}
+
+// attachEvidence links the uploaded evidence files in an internal service log referencing the reason
+func (o *postOptions) attachEvidence(connection SDKConnection, cluster *v1.Cluster, reasonID string, evidence []evidenceFile) error {
+	serviceLogRequest, err := createEvidenceServiceLogRequest(connection, cluster, reasonID, evidence)
+	if err != nil {
+		return fmt.Errorf("limited support reason posted, but the evidence service log could not be created: %w\nThe evidence was uploaded to:\n%s", err, describeEvidence(evidence))
+	}
+	serviceLogResponse, err := sendRequest(serviceLogRequest)
+	if err != nil {
+		return fmt.Errorf("limited support reason posted, but the evidence service log could not be sent: %w\nThe evidence was uploaded to:\n%s", err, describeEvidence(evidence))
+	}
+	if err := checkEvidenceServiceLog(serviceLogResponse); err != nil {
+		return fmt.Errorf("limited support reason posted, but the evidence service log failed: %w\nThe evidence was uploaded to:\n%s", err, describeEvidence(evidence))
+	}
+	return nil
+}
+
+// collectAlerts returns the summary of the alerts firing on the cluster, through backplane
+func (o *postOptions) collectAlerts(cluster *v1.Cluster) (string, error) {
+	if err := ctlutil.CheckOCCluster(o.runOC, cluster); err != nil {
+		return "", err
+	}
+	alerts, err := firingAlerts(o.runOC)
+	if err != nil {
+		return "", fmt.Errorf("cannot list the alerts of cluster %s: %w", cluster.ID(), err)
+	}
+	return summarizeAlerts(alerts), nil
+}
+
 // createPostRequest create and populates the limited support post call
 // swagger code gen: https://api.openshift.com/?urls.primaryName=Clusters%20management%20service#/default/post_api_clusters_mgmt_v1_clusters__cluster_id__limited_support_reasons
 // SDKConnection is an interface that is satisfied by the sdk.Connection and by our mock connection
 // this facilitates unit test and allow us to mock Post() and Delete() api calls
-func createPostRequest(ocmClient SDKConnection, cluster *v1.Cluster) (request *sdk.Request, err error) {
+func createPostRequest(ocmClient SDKConnection, cluster *v1.Cluster, limitedSupport support.LimitedSupport) (request *sdk.Request, err error) {
 
 	targetAPIPath := "/api/clusters_mgmt/v1/clusters/" + cluster.ID() + "/limited_support_reasons"
 
@@ -173,7 +325,7 @@ func createPostRequest(ocmClient SDKConnection, cluster *v1.Cluster) (request *s
 		return nil, fmt.Errorf("cannot parse API path '%s': %v", targetAPIPath, err)
 	}
 
-	messageBytes, err := json.Marshal(LimitedSupport)
+	messageBytes, err := json.Marshal(limitedSupport)
 	if err != nil {
 		return nil, fmt.Errorf("cannot marshal template to json: %v", err)
 	}
@@ -182,22 +334,91 @@ func createPostRequest(ocmClient SDKConnection, cluster *v1.Cluster) (request *s
 	return request, nil
 }
 
-// readTemplate loads the template into the LimitedSupport variable
-func readTemplate() {
+// readTemplate loads the template into the limitedSupport field
+func (o *postOptions) readTemplate() error {
 
-	if template == defaultTemplate {
-		log.Fatalf("Template file is not provided. Use '-t' to fix this.")
+	if o.template == defaultTemplate {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "Template file is not provided. Use '-t' to fix this.")
 	}
 
 	// check if this URL or file and if we can access it
-	file, err := accessFile(template)
+	file, err := accessFile(o.template)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	if err := support.Validate(support.KindLimitedSupport, file); err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "The template %s doesn't match the limited support reason schema, check it with 'osdctl template validate':\n%v", o.template, err)
 	}
 
-	if err = parseTemplate(file); err != nil {
-		log.Fatalf("Cannot not parse the JSON template.\nError: %q\n", err)
+	if err = parseTemplate(file, &o.limitedSupport); err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "Cannot not parse the JSON template.\nError: %q", err)
 	}
+	return nil
+}
+
+// readReasonFile loads the reason given with '-f' into the limitedSupport field, from stdin for '-'
+func (o *postOptions) readReasonFile() error {
+	var data []byte
+	var err error
+	if o.reasonFile == "-" {
+		data, err = io.ReadAll(o.In)
+	} else {
+		data, err = os.ReadFile(o.reasonFile) //#nosec G304 -- reasonFile cannot be constant
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read the limited support reason: %w", err)
+	}
+
+	limitedSupport, err := parseReason(data)
+	if err != nil {
+		return err
+	}
+	o.limitedSupport = *limitedSupport
+	return nil
+}
+
+// parseReason strictly decodes a limited support reason, unknown fields are rejected
+func parseReason(data []byte) (*support.LimitedSupport, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	limitedSupport := &support.LimitedSupport{}
+	if err := decoder.Decode(limitedSupport); err != nil {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "invalid limited support reason: %v", err)
+	}
+	if decoder.More() {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "invalid limited support reason: expected a single JSON object")
+	}
+	return limitedSupport, nil
+}
+
+// validateLimitedSupport checks the reason against the limited support reason schema of OCM
+func validateLimitedSupport(limitedSupport *support.LimitedSupport) error {
+	var problems []string
+	if limitedSupport.ID != "" {
+		problems = append(problems, "'id' is assigned by OCM and can't be set")
+	}
+	if strings.TrimSpace(limitedSupport.Summary) == "" && limitedSupport.TemplateID == "" {
+		problems = append(problems, "'summary' is required")
+	}
+	if strings.TrimSpace(limitedSupport.Details) == "" && limitedSupport.TemplateID == "" {
+		problems = append(problems, "'details' is required")
+	}
+	switch limitedSupport.DetectionType {
+	case "":
+		limitedSupport.DetectionType = "manual"
+	case "manual", "auto":
+	default:
+		problems = append(problems, fmt.Sprintf("'detection_type' must be 'manual' or 'auto', got '%s'", limitedSupport.DetectionType))
+	}
+	if leftovers, found := limitedSupport.FindLeftovers(); found {
+		problems = append(problems, fmt.Sprintf("unresolved parameters %v, set them with '-p'", leftovers))
+	}
+
+	if len(problems) > 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "invalid limited support reason: %s", strings.Join(problems, "; "))
+	}
+	return nil
 }
 
 // accessTemplate returns the contents of a local file or url, and any errors encountered
@@ -227,19 +448,32 @@ func accessFile(filePath string) ([]byte, error) {
 }
 
 // parseTemplate reads the template file into a JSON struct
-func parseTemplate(jsonFile []byte) error {
-	return json.Unmarshal(jsonFile, &LimitedSupport)
+func parseTemplate(jsonFile []byte, limitedSupport *support.LimitedSupport) error {
+	return json.Unmarshal(jsonFile, limitedSupport)
 }
 
-func printTemplate() error {
+func (o *postOptions) printTemplate() error {
 
-	limitedSupportMessage, err := json.Marshal(LimitedSupport)
+	limitedSupportMessage, err := json.Marshal(o.limitedSupport)
 	if err != nil {
 		return err
 	}
 	return dump.Pretty(os.Stdout, limitedSupportMessage)
 }
 
+// printPreview renders the limited support reason as the customer reads it
+func (o *postOptions) printPreview(w io.Writer) error {
+	return printer.PrintPreview(w, printer.Preview{
+		Heading: "Customer view (OCM console cluster overview):",
+		Fields: [][2]string{
+			{"Cluster", o.clusterID},
+			{"Support", "Limited"},
+		},
+		Title: o.limitedSupport.Summary,
+		Body:  o.limitedSupport.Details,
+	})
+}
+
 func validateGoodResponse(body []byte, limitedSupport support.LimitedSupport) (goodReply *support.GoodReply, err error) {
 
 	if !json.Valid(body) {
@@ -263,55 +497,53 @@ func validateBadResponse(body []byte) (badReply *support.BadReply, err error) {
 	return badReply, nil
 }
 
-func check(response *sdk.Response, limitedSupport support.LimitedSupport) error {
+func check(response *sdk.Response, limitedSupport support.LimitedSupport) (*support.GoodReply, error) {
 
 	body := response.Bytes()
 	if response.Status() == http.StatusCreated {
-		_, err := validateGoodResponse(body, limitedSupport)
+		goodReply, err := validateGoodResponse(body, limitedSupport)
 		if err != nil {
-			return fmt.Errorf("failed to validate good response: %q", err)
+			return nil, fmt.Errorf("failed to validate good response: %q", err)
 		}
 		fmt.Printf("Limited support reason has been sent successfully\n")
-		return nil
+		return goodReply, nil
 	}
 
-	badReply, err := validateBadResponse(body)
-	if err != nil {
-		return fmt.Errorf("failed to validate bad response: %v", err)
-	}
-	return fmt.Errorf("bad response reason is: %s", badReply.Reason)
+	return nil, badResponse(response.Status(), body)
 }
 
 // parseUserParameters parse all the '-p FOO=BAR' parameters and checks for syntax errors
-func parseUserParameters() {
-	for _, v := range templateParams {
+func (o *postOptions) parseUserParameters() error {
+	for _, v := range o.templateParams {
 		if !strings.Contains(v, "=") {
-			log.Fatalf("Wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "Wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
 		}
 
 		param := strings.SplitN(v, "=", 2)
 		if param[0] == "" || param[1] == "" {
-			log.Fatalf("Wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "Wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
 		}
 
-		userParameterNames = append(userParameterNames, fmt.Sprintf("${%v}", param[0]))
-		userParameterValues = append(userParameterValues, param[1])
+		o.userParameterNames = append(o.userParameterNames, fmt.Sprintf("${%v}", param[0]))
+		o.userParameterValues = append(o.userParameterValues, param[1])
 	}
+	return nil
 }
 
-func replaceWithFlags(flagName string, flagValue string) {
+func (o *postOptions) replaceWithFlags(flagName string, flagValue string) error {
 	if flagValue == "" {
-		log.Fatalf("The selected template is using '%[1]s' parameter, but '%[1]s' flag was not set. Use '-p %[1]s=\"FOOBAR\"' to fix this.", flagName)
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "The selected template is using '%[1]s' parameter, but '%[1]s' flag was not set. Use '-p %[1]s=\"FOOBAR\"' to fix this.", flagName)
 	}
 
 	found := false
 
-	if LimitedSupport.SearchFlag(flagName) {
+	if o.limitedSupport.SearchFlag(flagName) {
 		found = true
-		LimitedSupport.ReplaceWithFlag(flagName, flagValue)
+		o.limitedSupport.ReplaceWithFlag(flagName, flagValue)
 	}
 
 	if !found {
-		log.Fatalf("The selected template is not using '%s' parameter, but '--param' flag was set. Do not use '-p %s=%s' to fix this.", flagName, flagName, flagValue)
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "The selected template is not using '%s' parameter, but '--param' flag was set. Do not use '-p %s=%s' to fix this.", flagName, flagName, flagValue)
 	}
+	return nil
 }
@@ -1,9 +1,13 @@
 package support
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/openshift/osdctl/internal/support"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 func TestValidateBadResponse(t *testing.T) {
@@ -91,3 +95,66 @@ func TestValidateGoodResponse(t *testing.T) {
 		}
 	}
 }
+
+func TestReadReasonFile(t *testing.T) {
+	testCases := []struct {
+		title       string
+		input       string
+		errExpected string
+		expected    support.LimitedSupport
+	}{
+		{
+			title:    "Fully formed reason",
+			input:    `{"summary":"Cluster is not supported","details":"Details","detection_type":"auto"}`,
+			expected: support.LimitedSupport{Summary: "Cluster is not supported", Details: "Details", DetectionType: "auto"},
+		},
+		{
+			title:    "Detection type defaults to manual",
+			input:    `{"summary":"Cluster is not supported","details":"Details"}`,
+			expected: support.LimitedSupport{Summary: "Cluster is not supported", Details: "Details", DetectionType: "manual"},
+		},
+		{
+			title:       "Unknown field",
+			input:       `{"summary":"Cluster is not supported","details":"Details","severity":"Major"}`,
+			errExpected: `unknown field "severity"`,
+		},
+		{
+			title:       "Several objects",
+			input:       `{"summary":"a","details":"b"} {"summary":"c","details":"d"}`,
+			errExpected: "expected a single JSON object",
+		},
+		{
+			title:       "Missing fields and bad detection type",
+			input:       `{"id":"123","detection_type":"automatic"}`,
+			errExpected: "'id' is assigned by OCM and can't be set; 'summary' is required; 'details' is required; 'detection_type' must be 'manual' or 'auto', got 'automatic'",
+		},
+		{
+			title:       "Unresolved parameters",
+			input:       `{"summary":"Cluster is not supported","details":"See ${LINK}"}`,
+			errExpected: "unresolved parameters [${LINK}]",
+		},
+	}
+
+	for _, tc := range testCases {
+		o := &postOptions{reasonFile: "-", IOStreams: genericclioptions.IOStreams{In: strings.NewReader(tc.input)}}
+		err := o.readReasonFile()
+		if err == nil {
+			err = validateLimitedSupport(&o.limitedSupport)
+		}
+		if tc.errExpected != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.errExpected) {
+				t.Fatalf("Test %s failed. Expected error containing %q, but got %v", tc.title, tc.errExpected, err)
+			}
+			if !errors.Is(err, osdctlErrors.ErrValidation) {
+				t.Fatalf("Test %s failed. Expected a validation error, but got %v", tc.title, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %s failed. Expected no errors, but got %s", tc.title, err.Error())
+		}
+		if o.limitedSupport != tc.expected {
+			t.Fatalf("Test %s failed. Expected %+v, but got %+v", tc.title, tc.expected, o.limitedSupport)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package support
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/openshift-online/ocm-cli/pkg/arguments"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ResolutionTemplateConfigKey is the service log template (file or URL) posted when a limited support reason is removed
+	ResolutionTemplateConfigKey = "limited_support_resolution_template"
+
+	serviceLogAPIPath = "/api/service_logs/v1/cluster_logs"
+
+	// resolutionSummaryPlaceholder is replaced by the summary of the removed limited support reason
+	resolutionSummaryPlaceholder = "${LIMITED_SUPPORT_SUMMARY}"
+)
+
+// defaultResolutionTemplate is used when no template is configured
+var defaultResolutionTemplate = servicelog.Message{
+	Severity:    "Info",
+	ServiceName: "SREManualAction",
+	Summary:     "Cluster is no longer in limited support",
+	Description: "The following limited support reason for your cluster has been resolved and removed: '" + resolutionSummaryPlaceholder + "'. If no other limited support reasons remain, your cluster is fully supported again.",
+}
+
+// loadResolutionTemplate reads the template from the flag, the config file, or falls back to the default one
+func loadResolutionTemplate(templatePath string) (servicelog.Message, error) {
+	if templatePath == "" {
+		templatePath = viper.GetString(ResolutionTemplateConfigKey)
+	}
+	if templatePath == "" {
+		return defaultResolutionTemplate, nil
+	}
+
+	file, err := accessFile(templatePath)
+	if err != nil {
+		return servicelog.Message{}, err
+	}
+
+	var message servicelog.Message
+	if err := json.Unmarshal(file, &message); err != nil {
+		return servicelog.Message{}, fmt.Errorf("cannot parse the resolution service log template %q: %w", templatePath, err)
+	}
+	return message, nil
+}
+
+// createResolutionServiceLogRequest builds the service log post for the cluster, filling in the removed reason's summary
+func createResolutionServiceLogRequest(ocmClient SDKConnection, cluster *v1.Cluster, message servicelog.Message, reasonSummary string) (*sdk.Request, error) {
+	request := ocmClient.Post()
+	err := arguments.ApplyPathArg(request, serviceLogAPIPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse API path '%s': %v", serviceLogAPIPath, err)
+	}
+
+	message.ReplaceWithFlag(resolutionSummaryPlaceholder, reasonSummary)
+	if leftovers, found := message.FindLeftovers(); found {
+		return nil, fmt.Errorf("the resolution service log template has unresolved parameters: %v", leftovers)
+	}
+	message.ClusterUUID = cluster.ExternalID()
+	message.ClusterID = cluster.ID()
+	if subscription := cluster.Subscription(); subscription != nil {
+		message.SubscriptionID = subscription.ID()
+	}
+
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal service log to json: %v", err)
+	}
+
+	request.Bytes(messageBytes)
+	return request, nil
+}
+
+func checkResolutionServiceLog(response *sdk.Response) error {
+	if response.Status() == http.StatusCreated {
+		fmt.Printf("Resolution service log has been sent successfully\n")
+		return nil
+	}
+
+	return badResponse(response.Status(), response.Bytes())
+}
@@ -0,0 +1,57 @@
+package support
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/spf13/viper"
+)
+
+func TestLoadResolutionTemplate(t *testing.T) {
+
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "resolution.json")
+	if err := os.WriteFile(templatePath, []byte(`{"severity":"Info","service_name":"SREManualAction","summary":"Custom","description":"Removed: ${LIMITED_SUPPORT_SUMMARY}"}`), 0600); err != nil {
+		t.Fatalf("cannot write template: %v", err)
+	}
+
+	message, err := loadResolutionTemplate("")
+	if err != nil || message.Summary != defaultResolutionTemplate.Summary {
+		t.Fatalf("Expected the default template, got %v (err %v)", message, err)
+	}
+
+	viper.Set(ResolutionTemplateConfigKey, templatePath)
+	defer viper.Set(ResolutionTemplateConfigKey, "")
+	message, err = loadResolutionTemplate("")
+	if err != nil || message.Summary != "Custom" {
+		t.Fatalf("Expected the configured template, got %v (err %v)", message, err)
+	}
+
+	if _, err = loadResolutionTemplate(filepath.Join(dir, "missing.json")); err == nil {
+		t.Fatalf("Expected an error for a missing template")
+	}
+}
+
+func TestCreateResolutionServiceLogRequest(t *testing.T) {
+
+	cluster, err := v1.NewCluster().ID("abc123").ExternalID("uuid").Build()
+	if err != nil {
+		t.Fatalf("cannot build cluster: %v", err)
+	}
+
+	request, err := createResolutionServiceLogRequest(&MockClient{}, cluster, defaultResolutionTemplate, "Cloud credentials removed")
+	if err != nil {
+		t.Fatalf("Expected no errors, but got %s", err)
+	}
+	if request.GetPath() != serviceLogAPIPath {
+		t.Fatalf("Unexpected path %s", request.GetPath())
+	}
+
+	message := defaultResolutionTemplate
+	message.Description = "Left over ${OTHER}"
+	if _, err = createResolutionServiceLogRequest(&MockClient{}, cluster, message, "summary"); err == nil {
+		t.Fatalf("Expected an error for unresolved parameters")
+	}
+}
@@ -2,15 +2,6 @@ package support
 
 import sdk "github.com/openshift-online/ocm-sdk-go"
 
-type SDKConnection interface {
-	Post() *sdk.Request
-	Delete() *sdk.Request
-}
-
-var (
-	Client SDKConnection
-)
-
 // sdk client structure
 type MockClient struct {
 	//empty structure to satisfy interface
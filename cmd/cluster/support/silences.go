@@ -0,0 +1,91 @@
+package support
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/printer"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+)
+
+const (
+	alertmanagerNamespace = "openshift-monitoring"
+	alertmanagerPod       = "alertmanager-main-0"
+	alertmanagerURL       = "http://localhost:9093"
+)
+
+// silence is an alertmanager silence, as listed by amtool
+type silence struct {
+	ID        string `json:"id"`
+	Comment   string `json:"comment"`
+	CreatedBy string `json:"createdBy"`
+	EndsAt    string `json:"endsAt"`
+	Status    struct {
+		State string `json:"state"`
+	} `json:"status"`
+}
+
+// amtool runs amtool in the alertmanager pod of the cluster
+func amtool(run ctlutil.OCRunner, args ...string) ([]byte, error) {
+	ocArgs := append([]string{"exec", "-n", alertmanagerNamespace, alertmanagerPod, "-c", "alertmanager", "--",
+		"amtool", "--alertmanager.url", alertmanagerURL}, args...)
+	return run(ocArgs...)
+}
+
+// activeSilences returns the silences of the cluster that are not expired yet
+func activeSilences(run ctlutil.OCRunner) ([]silence, error) {
+	output, err := amtool(run, "silence", "query", "-o", "json")
+	if err != nil {
+		return nil, err
+	}
+	var silences []silence
+	if err := json.Unmarshal(output, &silences); err != nil {
+		return nil, fmt.Errorf("cannot parse the silences: %w", err)
+	}
+
+	var active []silence
+	for _, s := range silences {
+		if s.Status.State != "expired" {
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+// linkedSilences returns the silences whose comment mentions the limited support reason ID
+func linkedSilences(silences []silence, reasonID string) []silence {
+	var linked []silence
+	for _, s := range silences {
+		if strings.Contains(s.Comment, reasonID) {
+			linked = append(linked, s)
+		}
+	}
+	return linked
+}
+
+func expireSilences(run ctlutil.OCRunner, silences []silence) error {
+	if len(silences) == 0 {
+		return nil
+	}
+	args := []string{"silence", "expire"}
+	for _, s := range silences {
+		args = append(args, s.ID)
+	}
+	_, err := amtool(run, args...)
+	return err
+}
+
+func printSilences(w io.Writer, reasons []*ctlutil.LimitedSupportReasonItem, silences map[string][]silence) error {
+	table := printer.NewTablePrinter(w, 20, 1, 3, ' ')
+	table.AddRow([]string{"Reason ID", "Silence ID", "Created By", "Ends At", "Comment"})
+	for _, reason := range reasons {
+		for _, s := range silences[reason.ID] {
+			table.AddRow([]string{reason.ID, s.ID, s.CreatedBy, s.EndsAt, s.Comment})
+		}
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	return table.Flush()
+}
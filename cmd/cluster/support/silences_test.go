@@ -0,0 +1,42 @@
+package support
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLinkedSilences(t *testing.T) {
+	var calls []string
+	run := func(args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		return []byte(`[
+			{"id": "a", "comment": "Limited support 1uyTmQSpNgDkmDThBhmyxHsKQby, etcd is being restored", "status": {"state": "active"}},
+			{"id": "b", "comment": "1uyTmQSpNgDkmDThBhmyxHsKQby", "status": {"state": "expired"}},
+			{"id": "c", "comment": "Maintenance", "status": {"state": "pending"}}
+		]`), nil
+	}
+
+	active, err := activeSilences(run)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []string
+	for _, s := range linkedSilences(active, "1uyTmQSpNgDkmDThBhmyxHsKQby") {
+		ids = append(ids, s.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a"}) {
+		t.Errorf("expected only the active linked silence, got %v", ids)
+	}
+
+	if err := expireSilences(run, linkedSilences(active, "1uyTmQSpNgDkmDThBhmyxHsKQby")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Nothing to expire doesn't run amtool
+	if err := expireSilences(run, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 2 || !strings.HasSuffix(calls[1], "amtool --alertmanager.url http://localhost:9093 silence expire a") {
+		t.Errorf("unexpected oc calls: %v", calls)
+	}
+}
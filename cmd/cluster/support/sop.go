@@ -0,0 +1,56 @@
+package support
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// SOPConfigKey maps limited support templates and summaries to the SOP to follow
+const SOPConfigKey = "limited_support_sops"
+
+// sopMapping links a limited support template, or the summary of the reasons it creates, to a runbook
+type sopMapping struct {
+	// Template is matched against the name (or full path/URL) of the template passed to 'post'
+	Template string `mapstructure:"template"`
+	// Summary is matched case-insensitively against the summary of existing reasons
+	Summary string `mapstructure:"summary"`
+	SOP     string `mapstructure:"sop"`
+}
+
+// loadSOPMappings reads the SOP mappings from the config file
+func loadSOPMappings() ([]sopMapping, error) {
+	var mappings []sopMapping
+	if err := viper.UnmarshalKey(SOPConfigKey, &mappings); err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' from the config file: %w", SOPConfigKey, err)
+	}
+	return mappings, nil
+}
+
+// sopLinks returns the SOPs matching either the template or the summary of a limited support reason
+func sopLinks(mappings []sopMapping, template, summary string) []string {
+	var links []string
+	seen := map[string]bool{}
+	for _, m := range mappings {
+		if m.SOP == "" || seen[m.SOP] {
+			continue
+		}
+		templateMatch := m.Template != "" && template != "" && (m.Template == template || m.Template == path.Base(template))
+		summaryMatch := m.Summary != "" && strings.EqualFold(strings.TrimSpace(m.Summary), strings.TrimSpace(summary))
+		if templateMatch || summaryMatch {
+			links = append(links, m.SOP)
+			seen[m.SOP] = true
+		}
+	}
+	return links
+}
+
+// printSOPLinks prints the runbooks to follow, if any
+func printSOPLinks(w io.Writer, links []string) {
+	for _, link := range links {
+		fmt.Fprintf(w, "SOP: %s\n", link)
+	}
+}
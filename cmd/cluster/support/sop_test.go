@@ -0,0 +1,70 @@
+package support
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSOPLinks(t *testing.T) {
+	mappings := []sopMapping{
+		{Template: "cloud_provider_access_removed.json", Summary: "Cloud provider access removed", SOP: "https://sop.example/access-removed"},
+		{Summary: "Cluster is unreachable", SOP: "https://sop.example/unreachable"},
+		{Template: "cloud_provider_access_removed.json", SOP: "https://sop.example/access-removed"},
+		{Template: "no_sop.json"},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		summary  string
+		expected []string
+	}{
+		{
+			name:     "template URL matches by file name",
+			template: "https://example.com/osd/limited_support/cloud_provider_access_removed.json",
+			expected: []string{"https://sop.example/access-removed"},
+		},
+		{
+			name:     "summary matches case-insensitively",
+			summary:  "cluster is unreachable ",
+			expected: []string{"https://sop.example/unreachable"},
+		},
+		{
+			name:     "no match",
+			template: "other.json",
+			summary:  "Something else",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sopLinks(mappings, tt.template, tt.summary)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("sopLinks() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadSOPMappings(t *testing.T) {
+	viper.Set(SOPConfigKey, []map[string]interface{}{
+		{"template": "cluster_unreachable.json", "sop": "https://sop.example/unreachable"},
+	})
+	defer viper.Set(SOPConfigKey, nil)
+
+	mappings, err := loadSOPMappings()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mappings) != 1 || mappings[0].Template != "cluster_unreachable.json" || mappings[0].SOP != "https://sop.example/unreachable" {
+		t.Fatalf("unexpected mappings: %+v", mappings)
+	}
+
+	var out bytes.Buffer
+	printSOPLinks(&out, sopLinks(mappings, "cluster_unreachable.json", ""))
+	if out.String() != "SOP: https://sop.example/unreachable\n" {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+}
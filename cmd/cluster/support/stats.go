@@ -0,0 +1,326 @@
+package support
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const (
+	statsLong = `Aggregates the limited support reasons of all the clusters you can access, by summary, for ops reviews.
+
+OCM only keeps the reasons still in place, so:
+  - Posted counts the reasons in place that were posted during --since
+  - Active counts all the reasons in place, and the mean time in limited support is theirs so far
+  - Removed counts the resolution service logs posted during --since by 'osdctl cluster support delete
+    --post-resolution-servicelog', reasons removed without one aren't counted`
+
+	statsExample = `
+  # Statistics of the last 90 days
+  osdctl cluster support stats --since 90d
+
+  # Only the ROSA clusters, as JSON
+  osdctl cluster support stats --since 30d --search "product.id = 'rosa'" -o json
+
+  # The quarter as CSV, for a reliability review
+  osdctl cluster support stats --since 90d -o csv --columns summary,posted,removed,mean_time_in_limited_support_hours > ls-q3.csv`
+
+	statsPageSize = 100
+)
+
+// statsCSVColumns are the columns of the CSV output, a row per reason summary
+var statsCSVColumns = []string{"since", "summary", "template", "posted", "active", "removed", "mean_time_in_limited_support_hours"}
+
+type statsOptions struct {
+	since   time.Duration
+	search  string
+	output  string
+	columns []string
+
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// newCmdstats implements the stats command to aggregate the limited support reasons of the fleet
+func newCmdstats(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &statsOptions{IOStreams: streams, GlobalOptions: globalOpts}
+	statsCmd := &cobra.Command{
+		Use:               "stats",
+		Short:             "Aggregate the limited support reasons posted and removed across the fleet",
+		Long:              statsLong,
+		Example:           statsExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	flagtypes.DurationVar(statsCmd.Flags(), &ops.since, "since", 90*24*time.Hour, 24*time.Hour, "Period to aggregate, e.g. 90d or 72h")
+	statsCmd.Flags().StringVar(&ops.search, "search", "state != 'uninstalling'", "OCM search query selecting the clusters")
+	printer.AddColumnsFlag(statsCmd, &ops.columns, statsCSVColumns)
+
+	return statsCmd
+}
+
+func (o *statsOptions) complete(cmd *cobra.Command) error {
+	if o.GlobalOptions != nil {
+		o.output = o.GlobalOptions.Output
+	}
+	var err error
+	o.columns, err = printer.CSVColumns(cmd, statsCSVColumns, o.columns)
+	return err
+}
+
+// activeReason is a limited support reason in place on a cluster
+type activeReason struct {
+	ID        string
+	ClusterID string
+	Summary   string
+	Template  string
+	CreatedAt time.Time
+}
+
+type reasonStats struct {
+	Summary  string `json:"summary" yaml:"summary"`
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+	Posted   int    `json:"posted" yaml:"posted"`
+	Active   int    `json:"active" yaml:"active"`
+	Removed  int    `json:"removed" yaml:"removed"`
+	// MeanTimeInLimitedSupport is the mean time the active reasons have been in place, in hours
+	MeanTimeInLimitedSupport float64 `json:"meanTimeInLimitedSupportHours" yaml:"meanTimeInLimitedSupportHours"`
+}
+
+type statsResponse struct {
+	Since                    string        `json:"since" yaml:"since"`
+	Clusters                 int           `json:"clusters" yaml:"clusters"`
+	ClustersInLimitedSupport int           `json:"clustersInLimitedSupport" yaml:"clustersInLimitedSupport"`
+	UnattributedRemoved      int           `json:"unattributedRemovedReasons" yaml:"unattributedRemovedReasons"`
+	Reasons                  []reasonStats `json:"reasons" yaml:"reasons"`
+}
+
+// String renders the statistics as a table
+func (r statsResponse) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d clusters in limited support, reasons posted and removed since %s\n\n", r.ClustersInLimitedSupport, r.Clusters, r.Since)
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Summary", "Template", "Posted", "Active", "Removed", "Mean time in LS"})
+	for _, s := range r.Reasons {
+		mean := "-"
+		if s.Active > 0 {
+			mean = duration.HumanDuration(time.Duration(s.MeanTimeInLimitedSupport * float64(time.Hour)))
+		}
+		table.AddRow([]string{s.Summary, s.Template, strconv.Itoa(s.Posted), strconv.Itoa(s.Active), strconv.Itoa(s.Removed), mean})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+
+	if r.UnattributedRemoved > 0 {
+		fmt.Fprintf(&b, "%d resolution service logs didn't name the removed reason\n", r.UnattributedRemoved)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (o *statsOptions) run() error {
+	connection := ctlutil.CreateConnection()
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot close the connection: %q\n", err)
+		}
+	}()
+
+	now := time.Now().UTC()
+	since := now.Add(-o.since)
+
+	clusters, err := ctlutil.ApplyFilters(connection, []string{o.search})
+	if err != nil {
+		return fmt.Errorf("cannot list the clusters: %w", err)
+	}
+
+	var reasons []activeReason
+	inLimitedSupport := 0
+	for _, cluster := range clusters {
+		// The count is part of the cluster list, don't ask the clusters that have none
+		if count, ok := cluster.Status().GetLimitedSupportReasonCount(); ok && count == 0 {
+			continue
+		}
+		clusterReasons, err := getActiveReasons(connection, cluster)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot get the limited support reasons of cluster %s: %v\n", cluster.ID(), err)
+			continue
+		}
+		if len(clusterReasons) > 0 {
+			inLimitedSupport++
+		}
+		reasons = append(reasons, clusterReasons...)
+	}
+
+	template, err := loadResolutionTemplate("")
+	if err != nil {
+		return err
+	}
+	removed, unattributed, err := getRemovedReasons(connection, template, since)
+	if err != nil {
+		return err
+	}
+
+	resp := statsResponse{
+		Since:                    timefmt.Format(since),
+		Clusters:                 len(clusters),
+		ClustersInLimitedSupport: inLimitedSupport,
+		UnattributedRemoved:      unattributed,
+		Reasons:                  aggregateReasons(reasons, removed, since, now),
+	}
+	if o.output == "csv" {
+		return writeStatsCSV(printer.Tee(os.Stdout), resp, o.columns)
+	}
+	return outputflag.PrintResponse(o.output, resp)
+}
+
+// writeStatsCSV writes a row per reason summary
+func writeStatsCSV(w io.Writer, resp statsResponse, columns []string) error {
+	rows := make([][]string, 0, len(resp.Reasons))
+	for _, s := range resp.Reasons {
+		rows = append(rows, []string{resp.Since, s.Summary, s.Template, strconv.Itoa(s.Posted), strconv.Itoa(s.Active), strconv.Itoa(s.Removed),
+			strconv.FormatFloat(s.MeanTimeInLimitedSupport, 'f', 1, 64)})
+	}
+	return printer.WriteCSV(w, statsCSVColumns, rows, columns)
+}
+
+func getActiveReasons(connection *sdk.Connection, cluster *v1.Cluster) ([]activeReason, error) {
+	response, err := connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).LimitedSupportReasons().List().Send()
+	if err != nil {
+		return nil, err
+	}
+	var reasons []activeReason
+	for _, reason := range response.Items().Slice() {
+		reasons = append(reasons, activeReason{
+			ID:        reason.ID(),
+			ClusterID: cluster.ID(),
+			Summary:   reason.Summary(),
+			Template:  reason.Template().ID(),
+			CreatedAt: reason.CreationTimestamp(),
+		})
+	}
+	return reasons, nil
+}
+
+// getRemovedReasons returns the summaries of the reasons removed since the given time, from the resolution service
+// logs posted with the template, and the number of resolution service logs that don't name the reason
+func getRemovedReasons(connection *sdk.Connection, template servicelog.Message, since time.Time) ([]string, int, error) {
+	// The summary may hold the reason, only its start is fixed
+	prefix := strings.SplitN(template.Summary, resolutionSummaryPlaceholder, 2)[0]
+	search := fmt.Sprintf("summary like '%s%%' and timestamp >= '%s'", strings.ReplaceAll(prefix, "'", "''"), since.Format(time.RFC3339))
+
+	var removed []string
+	unattributed := 0
+	request := connection.ServiceLogs().V1().ClusterLogs().List().Search(search).Size(statsPageSize)
+	for page := 1; ; page++ {
+		response, err := request.Page(page).Send()
+		if err != nil {
+			return nil, 0, fmt.Errorf("cannot list the resolution service logs: %w", err)
+		}
+		for _, entry := range response.Items().Slice() {
+			summary, ok := removedReasonSummary(template, entry.Summary(), entry.Description())
+			if !ok {
+				continue
+			}
+			if summary == "" {
+				unattributed++
+				continue
+			}
+			removed = append(removed, summary)
+		}
+		if response.Size() < statsPageSize {
+			break
+		}
+	}
+	return removed, unattributed, nil
+}
+
+// removedReasonSummary returns the limited support summary a service log posted from the resolution template was
+// posted for. It returns false when the service log doesn't come from the template, and an empty summary when the
+// template doesn't include the summary.
+func removedReasonSummary(template servicelog.Message, summary, description string) (string, bool) {
+	var removed string
+	for _, field := range []struct{ template, value string }{
+		{template.Summary, summary},
+		{template.Description, description},
+	} {
+		pattern := regexp.QuoteMeta(field.template)
+		pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta(resolutionSummaryPlaceholder), "(.+)")
+		match := regexp.MustCompile("^" + pattern + "$").FindStringSubmatch(field.value)
+		if match == nil {
+			return "", false
+		}
+		if len(match) > 1 && removed == "" {
+			removed = match[1]
+		}
+	}
+	return removed, true
+}
+
+// aggregateReasons groups the reasons by summary, the ones with the most activity first
+func aggregateReasons(active []activeReason, removed []string, since, now time.Time) []reasonStats {
+	bySummary := map[string]*reasonStats{}
+	totalHours := map[string]float64{}
+	get := func(summary string) *reasonStats {
+		if bySummary[summary] == nil {
+			bySummary[summary] = &reasonStats{Summary: summary}
+		}
+		return bySummary[summary]
+	}
+
+	for _, reason := range active {
+		s := get(reason.Summary)
+		if s.Template == "" {
+			s.Template = reason.Template
+		}
+		s.Active++
+		if !reason.CreatedAt.Before(since) {
+			s.Posted++
+		}
+		totalHours[reason.Summary] += now.Sub(reason.CreatedAt).Hours()
+	}
+	for _, summary := range removed {
+		get(summary).Removed++
+	}
+
+	stats := []reasonStats{}
+	for summary, s := range bySummary {
+		if s.Active > 0 {
+			s.MeanTimeInLimitedSupport = math.Round(totalHours[summary]/float64(s.Active)*10) / 10
+		}
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		activityI, activityJ := stats[i].Posted+stats[i].Removed+stats[i].Active, stats[j].Posted+stats[j].Removed+stats[j].Active
+		if activityI != activityJ {
+			return activityI > activityJ
+		}
+		return stats[i].Summary < stats[j].Summary
+	})
+	return stats
+}
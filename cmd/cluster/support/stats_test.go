@@ -0,0 +1,65 @@
+package support
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/osdctl/internal/servicelog"
+)
+
+func TestRemovedReasonSummary(t *testing.T) {
+	description := strings.ReplaceAll(defaultResolutionTemplate.Description, resolutionSummaryPlaceholder, "Cluster is not reachable")
+
+	summary, ok := removedReasonSummary(defaultResolutionTemplate, defaultResolutionTemplate.Summary, description)
+	if !ok || summary != "Cluster is not reachable" {
+		t.Errorf("expected the removed summary, got %q (%v)", summary, ok)
+	}
+
+	if _, ok := removedReasonSummary(defaultResolutionTemplate, defaultResolutionTemplate.Summary, "Written by hand"); ok {
+		t.Error("expected a service log not posted from the template not to match")
+	}
+
+	// Reasons can't be attributed when the template doesn't include the summary
+	withoutSummary := servicelog.Message{Summary: "Cluster is back in support", Description: "All good"}
+	summary, ok = removedReasonSummary(withoutSummary, "Cluster is back in support", "All good")
+	if !ok || summary != "" {
+		t.Errorf("expected an unattributed match, got %q (%v)", summary, ok)
+	}
+}
+
+func TestAggregateReasons(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	since := now.AddDate(0, 0, -30)
+
+	got := aggregateReasons([]activeReason{
+		{ClusterID: "a", Summary: "Cluster is not reachable", Template: "not_reachable", CreatedAt: now.AddDate(0, 0, -2)},
+		{ClusterID: "b", Summary: "Cluster is not reachable", CreatedAt: now.AddDate(0, 0, -60)},
+		{ClusterID: "c", Summary: "Unsupported configuration", CreatedAt: now.Add(-12 * time.Hour)},
+	}, []string{"Cluster is not reachable", "Unsupported configuration", "Old reason", "Old reason"}, since, now)
+
+	expected := []reasonStats{
+		{Summary: "Cluster is not reachable", Template: "not_reachable", Posted: 1, Active: 2, Removed: 1, MeanTimeInLimitedSupport: 744},
+		{Summary: "Unsupported configuration", Posted: 1, Active: 1, Removed: 1, MeanTimeInLimitedSupport: 12},
+		{Summary: "Old reason", Removed: 2},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestWriteStatsCSV(t *testing.T) {
+	resp := statsResponse{Since: "90d", Reasons: []reasonStats{
+		{Summary: "Cluster is in Limited Support due to missing IAM roles", Template: "iam", Posted: 4, Active: 2, Removed: 2, MeanTimeInLimitedSupport: 12.5},
+	}}
+	var b bytes.Buffer
+	if err := writeStatsCSV(&b, resp, []string{"summary", "active", "mean_time_in_limited_support_hours"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := "summary,active,mean_time_in_limited_support_hours\nCluster is in Limited Support due to missing IAM roles,2,12.5\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, got %q", expected, b.String())
+	}
+}
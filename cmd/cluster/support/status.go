@@ -2,7 +2,11 @@ package support
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/openshift/osdctl/internal/utils/globalflags"
 	"github.com/openshift/osdctl/pkg/printer"
@@ -10,20 +14,25 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
+// statusCSVColumns are the columns of the CSV output, a row per limited support reason
+var statusCSVColumns = []string{"cluster_id", "cluster_name", "index", "reason_id", "summary", "details", "created"}
+
 type statusOptions struct {
 	output    string
 	verbose   bool
 	clusterID string
+	columns   []string
 
 	genericclioptions.IOStreams
 	GlobalOptions *globalflags.GlobalOptions
 }
 
-// newCmdsupportCheck implements the supportCheck command to show the support status of a cluster
-func newCmdstatus(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+// NewCmdStatus implements the status command to show the support status of a cluster, also run as osdctl get reasons
+func NewCmdStatus(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
 	ops := newStatusOptions(streams, flags, globalOpts)
 	statusCmd := &cobra.Command{
 		Use:               "status",
@@ -31,11 +40,12 @@ func newCmdstatus(streams genericclioptions.IOStreams, flags *genericclioptions.
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	statusCmd.Flags().BoolVarP(&ops.verbose, "verbose", "", false, "Verbose output")
+	printer.AddColumnsFlag(statusCmd, &ops.columns, statusCSVColumns)
 
 	return statusCmd
 }
@@ -55,7 +65,9 @@ func (o *statusOptions) complete(cmd *cobra.Command, args []string) error {
 	o.clusterID = args[0]
 	o.output = o.GlobalOptions.Output
 
-	return nil
+	var err error
+	o.columns, err = printer.CSVColumns(cmd, statusCSVColumns, o.columns)
+	return err
 }
 
 func (o *statusOptions) run() error {
@@ -71,43 +83,69 @@ func (o *statusOptions) run() error {
 	connection := ctlutil.CreateConnection()
 	defer func() {
 		if err := connection.Close(); err != nil {
-			fmt.Printf("Cannot close the connection: %q\n", err)
+			fmt.Fprintf(o.ErrOut, "Cannot close the connection: %q\n", err)
 			os.Exit(1)
 		}
 	}()
 
 	//getting the cluster
-	cluster, err := ctlutil.GetCluster(connection, o.clusterID)
+	cluster, err := getCluster(connection, o.clusterID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't retrieve cluster: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("can't retrieve cluster: %w", err)
 	}
 
 	//getting the limited support reasons for the cluster
 	clusterLimitedSupportReasons, err := ctlutil.GetClusterLimitedSupportReasons(connection, cluster.ID())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Can't retrieve cluster limited support reasons: %v\n", err)
+		fmt.Fprintf(o.ErrOut, "Can't retrieve cluster limited support reasons: %v\n", err)
 		os.Exit(1)
 	}
 
+	if o.output == "csv" {
+		return writeStatusCSV(printer.Tee(os.Stdout), cluster.ID(), cluster.Name(), clusterLimitedSupportReasons, o.columns)
+	}
+
 	// No reasons found, cluster is fully supported
 	if len(clusterLimitedSupportReasons) == 0 {
 		fmt.Printf("Cluster is fully supported\n")
 		return nil
 	}
 
+	sops, err := loadSOPMappings()
+	if err != nil {
+		return err
+	}
+
 	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
-	table.AddRow([]string{"Reason ID", "Summary", "Details"})
-	for _, clusterLimitedSupportReason := range clusterLimitedSupportReasons {
-		table.AddRow([]string{clusterLimitedSupportReason.ID, clusterLimitedSupportReason.Summary, clusterLimitedSupportReason.Details})
+	header := []string{"#", "Reason ID", "Summary", "Details"}
+	if len(sops) > 0 {
+		header = append(header, "SOP")
+	}
+	table.AddRow(header)
+	for i, clusterLimitedSupportReason := range clusterLimitedSupportReasons {
+		// The index can be given to 'osdctl cluster support delete --index'
+		row := []string{strconv.Itoa(i + 1), clusterLimitedSupportReason.ID, clusterLimitedSupportReason.Summary, clusterLimitedSupportReason.Details}
+		if len(sops) > 0 {
+			row = append(row, strings.Join(sopLinks(sops, "", clusterLimitedSupportReason.Summary), " "))
+		}
+		table.AddRow(row)
 	}
 	// Add empty row for readability
 	table.AddRow([]string{})
 	err = table.Flush()
 	if err != nil {
-		fmt.Println("error while flushing table: ", err.Error())
+		fmt.Fprintln(o.ErrOut, "error while flushing table: ", err.Error())
 		return err
 	}
 
 	return nil
 }
+
+// writeStatusCSV writes a row per limited support reason, numbered like the table for 'osdctl cluster support delete --index'
+func writeStatusCSV(w io.Writer, clusterID, clusterName string, reasons []*ctlutil.LimitedSupportReasonItem, columns []string) error {
+	rows := make([][]string, 0, len(reasons))
+	for i, reason := range reasons {
+		rows = append(rows, []string{clusterID, clusterName, strconv.Itoa(i + 1), reason.ID, reason.Summary, reason.Details, reason.CreatedAt.UTC().Format(time.RFC3339)})
+	}
+	return printer.WriteCSV(w, statusCSVColumns, rows, columns)
+}
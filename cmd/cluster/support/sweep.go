@@ -0,0 +1,383 @@
+package support
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/rollout"
+	"github.com/openshift/osdctl/pkg/scheduler"
+	ctlutil "github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	sweepLong = `Removes the limited support reasons posted from a template once their cause is resolved, e.g. after an incident
+put many clusters in limited support.
+
+Every cluster with a reason posted from --template is checked with the --resolved-check executable, called with the
+cluster ID as argument and these environment variables:
+  CLUSTER_ID, CLUSTER_NAME, CLUSTER_EXTERNAL_ID, LIMITED_SUPPORT_REASON_ID, LIMITED_SUPPORT_SUMMARY
+
+An exit code of 0 means the cause is resolved and the reason is removed, any other exit code keeps the reason. A
+check that can't be run, or runs longer than --check-timeout, keeps the reason too. The reasons are only removed
+after a confirmation listing them, and every decision is printed, and appended as JSON lines to --decision-log.
+
+With --canary, that many reasons are removed first, and the sweep asks before removing the others, in batches of
+--batch-size with a --pause-between them. The sweep stops when more than --max-error-rate of the removals so far
+failed, checked after the canary and every batch.`
+
+	sweepExample = `
+  # List the decisions without removing anything
+  osdctl cluster support sweep --template etcd-quorum --resolved-check ./etcd-healthy.sh --dry-run
+
+  # Remove the resolved reasons, inform the customers and keep a log of the decisions
+  osdctl cluster support sweep --template etcd-quorum --resolved-check ./etcd-healthy.sh --post-resolution-servicelog --decision-log sweep.jsonl
+
+  # Remove 3 reasons first, then the others 20 at a time
+  osdctl cluster support sweep --template etcd-quorum --resolved-check ./etcd-healthy.sh --canary 3 --batch-size 20`
+
+	sweepDecisionRemove = "remove"
+	sweepDecisionKeep   = "keep"
+	sweepDecisionError  = "error"
+)
+
+// resolutionCheck runs the check of a reason, it returns whether the cause is resolved and the output of the check
+type resolutionCheck func(ctx context.Context, cluster *v1.Cluster, reason activeReason) (bool, string, error)
+
+type sweepOptions struct {
+	template      string
+	resolvedCheck string
+	checkTimeout  time.Duration
+	search        string
+	dryRun        bool
+	skipPrompts   bool
+	decisionLog   string
+	output        string
+
+	postResolutionServiceLog bool
+	resolutionTemplate       string
+
+	check   resolutionCheck
+	rollout rollout.Flags
+
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// sweepDecision is what the sweep decided for a limited support reason
+type sweepDecision struct {
+	Time        time.Time `json:"time" yaml:"time"`
+	ClusterID   string    `json:"clusterId" yaml:"clusterId"`
+	ClusterName string    `json:"clusterName" yaml:"clusterName"`
+	ReasonID    string    `json:"reasonId" yaml:"reasonId"`
+	Summary     string    `json:"summary" yaml:"summary"`
+	Decision    string    `json:"decision" yaml:"decision"`
+	// Detail is the last line of the output of the check, or why it couldn't be run
+	Detail  string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	Removed bool   `json:"removed" yaml:"removed"`
+	Error   string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type sweepResponse struct {
+	Template  string          `json:"template" yaml:"template"`
+	DryRun    bool            `json:"dryRun" yaml:"dryRun"`
+	Decisions []sweepDecision `json:"decisions" yaml:"decisions"`
+}
+
+func (r sweepResponse) String() string {
+	var b bytes.Buffer
+	removed := 0
+	for _, d := range r.Decisions {
+		if d.Removed {
+			removed++
+		}
+	}
+	fmt.Fprintf(&b, "%d limited support reasons posted from '%s', %d removed\n\n", len(r.Decisions), r.Template, removed)
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Cluster", "Reason ID", "Decision", "Removed", "Detail"})
+	for _, d := range r.Decisions {
+		detail := d.Detail
+		if d.Error != "" {
+			detail = d.Error
+		}
+		table.AddRow([]string{d.ClusterName, d.ReasonID, d.Decision, fmt.Sprint(d.Removed), detail})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// newCmdsweep implements the sweep command to remove the reasons of a template once resolved
+func newCmdsweep(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &sweepOptions{IOStreams: streams, GlobalOptions: globalOpts}
+	sweepCmd := &cobra.Command{
+		Use:               "sweep --template TEMPLATE --resolved-check EXECUTABLE",
+		Short:             "Remove the limited support reasons of a template on the clusters where a check says they are resolved",
+		Long:              sweepLong,
+		Example:           sweepExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	sweepCmd.Flags().StringVar(&ops.template, "template", "", "ID of the limited support template of the reasons to sweep, e.g. etcd-quorum")
+	sweepCmd.Flags().StringVar(&ops.resolvedCheck, "resolved-check", "", "Executable checking whether the cause of a reason is resolved, exiting with 0 when it is")
+	sweepCmd.Flags().DurationVar(&ops.checkTimeout, "check-timeout", 5*time.Minute, "Maximum duration of the check of a cluster")
+	sweepCmd.Flags().StringVar(&ops.search, "search", "state != 'uninstalling'", "OCM search query selecting the clusters")
+	sweepCmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Run the checks and print the decisions without removing any reason")
+	sweepCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+	sweepCmd.Flags().StringVar(&ops.decisionLog, "decision-log", "", "File the decisions are appended to, as JSON lines")
+	sweepCmd.Flags().BoolVar(&ops.postResolutionServiceLog, "post-resolution-servicelog", false, "Post a service log informing the customer once a limited support reason is removed")
+	sweepCmd.Flags().StringVar(&ops.resolutionTemplate, "resolution-template", "", "Service log template file or URL used with --post-resolution-servicelog (config key: "+ResolutionTemplateConfigKey+")")
+	ops.rollout.AddFlags(sweepCmd)
+	_ = sweepCmd.MarkFlagRequired("template")
+	_ = sweepCmd.MarkFlagRequired("resolved-check")
+
+	return sweepCmd
+}
+
+func (o *sweepOptions) complete(cmd *cobra.Command) error {
+	if o.checkTimeout <= 0 {
+		return cmdutil.UsageErrorf(cmd, "--check-timeout must be positive")
+	}
+	if err := o.rollout.Validate(cmd); err != nil {
+		return err
+	}
+	path, err := exec.LookPath(o.resolvedCheck)
+	if err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cannot run the resolution check '%s': %v", o.resolvedCheck, err)
+	}
+	o.check = executableCheck(path, o.checkTimeout)
+	if o.GlobalOptions != nil {
+		o.output = o.GlobalOptions.Output
+	}
+	return nil
+}
+
+func (o *sweepOptions) run() error {
+	connection := ctlutil.CreateConnection()
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot close the connection: %q\n", err)
+		}
+	}()
+
+	// Load the template before changing anything, so that a broken template doesn't leave the customers uninformed
+	var resolutionMessage servicelog.Message
+	var err error
+	if o.postResolutionServiceLog {
+		if resolutionMessage, err = loadResolutionTemplate(o.resolutionTemplate); err != nil {
+			return err
+		}
+	}
+
+	clusters, err := ctlutil.ApplyFilters(connection, []string{o.search})
+	if err != nil {
+		return fmt.Errorf("cannot list the clusters: %w", err)
+	}
+	var candidates []sweepCandidate
+	for _, cluster := range clusters {
+		// The count is part of the cluster list, don't ask the clusters that have none
+		if count, ok := cluster.Status().GetLimitedSupportReasonCount(); ok && count == 0 {
+			continue
+		}
+		reasons, err := getActiveReasons(connection, cluster)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot get the limited support reasons of cluster %s: %v\n", cluster.ID(), err)
+			continue
+		}
+		candidates = append(candidates, templateCandidates(cluster, reasons, o.template)...)
+	}
+	fmt.Fprintf(os.Stderr, "%d limited support reasons were posted from '%s'\n", len(candidates), o.template)
+
+	decisions := o.decide(context.Background(), candidates)
+	// The decisions are logged and printed when the removal stopped, e.g. when the canary failed
+	removeErr := o.removeResolved(connection, candidates, decisions, resolutionMessage)
+	if err := appendDecisionLog(o.decisionLog, decisions); err != nil {
+		return err
+	}
+	if err := outputflag.PrintResponse(o.output, sweepResponse{Template: o.template, DryRun: o.dryRun, Decisions: decisions}); err != nil {
+		return err
+	}
+	return removeErr
+}
+
+// sweepCandidate is a limited support reason of the template to check
+type sweepCandidate struct {
+	cluster *v1.Cluster
+	reason  activeReason
+}
+
+func templateCandidates(cluster *v1.Cluster, reasons []activeReason, template string) []sweepCandidate {
+	var candidates []sweepCandidate
+	for _, reason := range reasons {
+		if reason.Template == template {
+			candidates = append(candidates, sweepCandidate{cluster: cluster, reason: reason})
+		}
+	}
+	return candidates
+}
+
+// decide runs the check of every candidate, one cluster after the other, printing every decision
+func (o *sweepOptions) decide(ctx context.Context, candidates []sweepCandidate) []sweepDecision {
+	decisions := []sweepDecision{}
+	for _, candidate := range candidates {
+		decision := sweepDecision{
+			ClusterID:   candidate.cluster.ID(),
+			ClusterName: candidate.cluster.Name(),
+			ReasonID:    candidate.reason.ID,
+			Summary:     candidate.reason.Summary,
+		}
+		resolved, output, err := o.check(ctx, candidate.cluster, candidate.reason)
+		decision.Time = time.Now().UTC()
+		decision.Detail = lastLine(output)
+		switch {
+		case err != nil:
+			decision.Decision = sweepDecisionError
+			decision.Error = err.Error()
+		case resolved:
+			decision.Decision = sweepDecisionRemove
+		default:
+			decision.Decision = sweepDecisionKeep
+		}
+		fmt.Fprintf(os.Stderr, "%s %s: %s %s\n", decision.ClusterName, decision.ReasonID, decision.Decision, decision.Detail)
+		decisions = append(decisions, decision)
+	}
+	return decisions
+}
+
+// removeResolved removes the reasons the checks found resolved, after a confirmation
+func (o *sweepOptions) removeResolved(connection *sdk.Connection, candidates []sweepCandidate, decisions []sweepDecision, resolutionMessage servicelog.Message) error {
+	var toRemove []int
+	for i, decision := range decisions {
+		if decision.Decision == sweepDecisionRemove {
+			toRemove = append(toRemove, i)
+		}
+	}
+	if len(toRemove) == 0 || o.dryRun {
+		return nil
+	}
+
+	action := fmt.Sprintf("Remove %d limited support reason(s) posted from '%s'", len(toRemove), o.template)
+	if o.postResolutionServiceLog {
+		action += " and post resolution service logs"
+	}
+	err := ctlutil.Confirm(ctlutil.ConfirmOptions{
+		Summary:    &ctlutil.ImpactSummary{Action: action, Environment: ctlutil.GetCurrentOCMEnv(connection)},
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	deleter := &deleteOptions{postResolutionServiceLog: o.postResolutionServiceLog}
+	items := make([]string, 0, len(toRemove))
+	indexes := map[string]int{}
+	for _, i := range toRemove {
+		item := candidates[i].cluster.Name() + " " + candidates[i].reason.ID
+		items = append(items, item)
+		indexes[item] = i
+	}
+	opts := rollout.Options{
+		Label:      "limited support reasons",
+		Success:    "removed",
+		SkipPrompt: o.skipPrompts,
+		// The reasons of a cluster are removed one at a time
+		Scheduler: scheduler.New(),
+		Cluster:   func(item string) string { return candidates[indexes[item]].cluster.ID() },
+	}
+	_, err = o.rollout.Run(deadline.Context(), items, opts,
+		func(_ context.Context, item string) error {
+			i := indexes[item]
+			if err := ctlutil.CheckTenantScope(connection, candidates[i].cluster); err != nil {
+				decisions[i].Error = err.Error()
+				return err
+			}
+			reason := &ctlutil.LimitedSupportReasonItem{ID: candidates[i].reason.ID, Summary: candidates[i].reason.Summary}
+			// A failure on a cluster doesn't stop the sweep, it is part of the decisions
+			if err := deleter.deleteReason(connection, candidates[i].cluster, reason, resolutionMessage); err != nil {
+				decisions[i].Error = err.Error()
+				// The reason is gone when only the resolution service log failed
+				decisions[i].Removed = strings.HasPrefix(err.Error(), "limited support reason deleted")
+				return err
+			}
+			decisions[i].Removed = true
+			return nil
+		})
+	return err
+}
+
+// executableCheck runs the executable with the cluster ID as argument and the reason in the environment, exit code 0
+// meaning resolved
+func executableCheck(path string, timeout time.Duration) resolutionCheck {
+	return func(ctx context.Context, cluster *v1.Cluster, reason activeReason) (bool, string, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		check := exec.CommandContext(ctx, path, cluster.ID()) //#nosec G204 -- the check is chosen by the user
+		check.Env = append(os.Environ(),
+			"CLUSTER_ID="+cluster.ID(),
+			"CLUSTER_NAME="+cluster.Name(),
+			"CLUSTER_EXTERNAL_ID="+cluster.ExternalID(),
+			"LIMITED_SUPPORT_REASON_ID="+reason.ID,
+			"LIMITED_SUPPORT_SUMMARY="+reason.Summary,
+		)
+		output, err := check.CombinedOutput()
+		if ctx.Err() == context.DeadlineExceeded {
+			return false, string(output), fmt.Errorf("check timed out after %s", timeout)
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, string(output), nil
+		}
+		if err != nil {
+			return false, string(output), fmt.Errorf("cannot run the check: %w", err)
+		}
+		return true, string(output), nil
+	}
+}
+
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// appendDecisionLog appends the decisions to the file as JSON lines
+func appendDecisionLog(path string, decisions []sweepDecision) error {
+	if path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) //#nosec G304 -- the file is chosen by the user
+	if err != nil {
+		return fmt.Errorf("cannot open the decision log: %w", err)
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+	for _, decision := range decisions {
+		if err := encoder.Encode(decision); err != nil {
+			return fmt.Errorf("cannot write the decision log: %w", err)
+		}
+	}
+	return nil
+}
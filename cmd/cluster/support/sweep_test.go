@@ -0,0 +1,109 @@
+package support
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func newSweepTestCluster(t *testing.T, id string) *v1.Cluster {
+	cluster, err := v1.NewCluster().ID(id).Name("name-" + id).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cluster
+}
+
+func TestTemplateCandidates(t *testing.T) {
+	cluster := newSweepTestCluster(t, "a")
+	reasons := []activeReason{
+		{ID: "1", Template: "etcd-quorum"},
+		{ID: "2", Template: "cloud-credentials"},
+		{ID: "3"},
+	}
+	candidates := templateCandidates(cluster, reasons, "etcd-quorum")
+	if len(candidates) != 1 || candidates[0].reason.ID != "1" {
+		t.Errorf("expected reason 1 only, got %+v", candidates)
+	}
+}
+
+func TestSweepDecide(t *testing.T) {
+	candidates := []sweepCandidate{
+		{cluster: newSweepTestCluster(t, "a"), reason: activeReason{ID: "1", Summary: "ETCD quorum lost"}},
+		{cluster: newSweepTestCluster(t, "b"), reason: activeReason{ID: "2", Summary: "ETCD quorum lost"}},
+		{cluster: newSweepTestCluster(t, "c"), reason: activeReason{ID: "3", Summary: "ETCD quorum lost"}},
+	}
+	o := &sweepOptions{check: func(ctx context.Context, cluster *v1.Cluster, reason activeReason) (bool, string, error) {
+		switch cluster.ID() {
+		case "a":
+			return true, "checking\netcd healthy\n", nil
+		case "b":
+			return false, "1 member down", nil
+		}
+		return false, "", errors.New("check timed out after 5m0s")
+	}}
+
+	decisions := o.decide(context.Background(), candidates)
+	expected := []struct{ decision, detail, err string }{
+		{sweepDecisionRemove, "etcd healthy", ""},
+		{sweepDecisionKeep, "1 member down", ""},
+		{sweepDecisionError, "", "check timed out after 5m0s"},
+	}
+	for i, e := range expected {
+		d := decisions[i]
+		if d.Decision != e.decision || d.Detail != e.detail || d.Error != e.err || d.Removed {
+			t.Errorf("decision %d: expected %+v, got %+v", i, e, d)
+		}
+	}
+}
+
+func TestExecutableCheck(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "check.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$1 $LIMITED_SUPPORT_REASON_ID\"\n[ \"$CLUSTER_NAME\" = name-resolved ]\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	check := executableCheck(script, time.Minute)
+	reason := activeReason{ID: "r1"}
+
+	resolved, output, err := check(context.Background(), newSweepTestCluster(t, "resolved"), reason)
+	if err != nil || !resolved || strings.TrimSpace(output) != "resolved r1" {
+		t.Errorf("expected a resolved check, got %v %q %v", resolved, output, err)
+	}
+	resolved, _, err = check(context.Background(), newSweepTestCluster(t, "broken"), reason)
+	if err != nil || resolved {
+		t.Errorf("expected an unresolved check, got %v %v", resolved, err)
+	}
+
+	_, _, err = executableCheck(filepath.Join(t.TempDir(), "missing"), time.Minute)(context.Background(), newSweepTestCluster(t, "a"), reason)
+	if err == nil {
+		t.Error("expected an error running a missing check")
+	}
+}
+
+func TestAppendDecisionLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sweep.jsonl")
+	for i := 0; i < 2; i++ {
+		if err := appendDecisionLog(path, []sweepDecision{{ClusterID: "a", ReasonID: "1", Decision: sweepDecisionKeep}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var decision sweepDecision
+	if err := json.Unmarshal([]byte(lines[1]), &decision); err != nil || decision.Decision != sweepDecisionKeep {
+		t.Errorf("unexpected decision %q: %v", lines[1], err)
+	}
+}
@@ -3,15 +3,16 @@ package cluster
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 // transferOwnerOptions defines the struct for running transferOwner command
@@ -20,6 +21,7 @@ type transferOwnerOptions struct {
 	clusterID    string
 	newOwnerName string
 	dryrun       bool
+	skipPrompts  bool
 
 	genericclioptions.IOStreams
 	GlobalOptions *globalflags.GlobalOptions
@@ -33,13 +35,14 @@ func newCmdTransferOwner(streams genericclioptions.IOStreams, globalOpts *global
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	// can we get cluster-id from some context maybe?
 	transferOwnerCmd.Flags().StringVarP(&ops.clusterID, "cluster-id", "C", "", "The Internal Cluster ID/External Cluster ID/ Cluster Name")
 	transferOwnerCmd.Flags().StringVar(&ops.newOwnerName, "new-owner", ops.newOwnerName, "The new owners username to transfer the cluster to")
 	transferOwnerCmd.Flags().BoolVarP(&ops.dryrun, "dry-run", "d", false, "Dry-run - show all changes but do not apply them")
+	transferOwnerCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
 
 	_ = transferOwnerCmd.MarkFlagRequired("cluster-id")
 	_ = transferOwnerCmd.MarkFlagRequired("new-owner")
@@ -63,7 +66,7 @@ func (o *transferOwnerOptions) run() error {
 	ocm := utils.CreateConnection()
 	defer func() {
 		if ocmCloseErr := ocm.Close(); ocmCloseErr != nil {
-			fmt.Printf("Cannot close the ocm (possible memory leak): %q", ocmCloseErr)
+			fmt.Fprintf(o.ErrOut, "Cannot close the ocm (possible memory leak): %q", ocmCloseErr)
 		}
 	}()
 
@@ -136,15 +139,20 @@ func (o *transferOwnerOptions) run() error {
 
 	fmt.Printf("Transfer cluster: \t\t'%v' (%v)\n", externalClusterID, cluster.Name())
 	fmt.Printf("from user \t\t\t'%v' to '%v'\n", oldOwnerAccount.ID(), accountID)
-	err = utils.ConfirmSend()
+	// Ownership transfers are hard to undo, so require the cluster name to be typed
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:           utils.NewClusterImpactSummary(ocm, cluster, fmt.Sprintf("Transfer ownership to '%s'", o.newOwnerName)),
+		TypedConfirmation: cluster.Name(),
+		SkipPrompt:        o.skipPrompts,
+	})
 	if err != nil {
 		return err
 	}
 
 	ok = validateOldOwner(oldOrganizationId, subscription, oldOwnerAccount)
 	if !ok {
-		fmt.Print("can't validate this is old owners cluster, this could be because of a previously failed run\n")
-		err = utils.ConfirmSend()
+		fmt.Fprint(o.ErrOut, "can't validate this is old owners cluster, this could be because of a previously failed run\n")
+		err = utils.Confirm(utils.ConfirmOptions{SkipPrompt: o.skipPrompts})
 		if err != nil {
 			return err
 		}
@@ -207,7 +215,7 @@ func (o *transferOwnerOptions) run() error {
 	fmt.Printf("Patched creator on subscription\n")
 
 	// delete old rolebinding but do not exit on fail could be a rerun
-	err = deleteOldRoleBinding(ocm, subscriptionID)
+	err = deleteOldRoleBinding(o.ErrOut, ocm, subscriptionID)
 
 	if err != nil {
 		fmt.Printf("can' delete old rolebinding %v \n", err)
@@ -248,6 +256,8 @@ func (o *transferOwnerOptions) run() error {
 		return fmt.Errorf("error while validating transfer %w", err)
 	}
 	fmt.Print("Transfer complete\n")
+	utils.RecordClusterAction(ocm, cluster, fmt.Sprintf("Transfer ownership to '%s'", o.newOwnerName))
+	utils.InvalidateClusterMetadata(cluster.ID())
 	return nil
 }
 
@@ -275,7 +285,7 @@ func getRoleBinding(ocm *sdk.Connection, subscriptionID string) (*amv1.RoleBindi
 }
 
 // deletes old rolebinding by subscription id
-func deleteOldRoleBinding(ocm *sdk.Connection, subscriptionID string) error {
+func deleteOldRoleBinding(errOut io.Writer, ocm *sdk.Connection, subscriptionID string) error {
 	oldRoleBinding, err := getRoleBinding(ocm, subscriptionID)
 
 	if err != nil {
@@ -301,7 +311,7 @@ func deleteOldRoleBinding(ocm *sdk.Connection, subscriptionID string) error {
 		fmt.Printf("can't find old rolebinding: %v\n", oldRoleBindingID)
 		return nil
 	}
-	fmt.Printf("request failed with status: %d\n", response.Status())
+	fmt.Fprintf(errOut, "request failed with status: %d\n", response.Status())
 	return nil
 }
 
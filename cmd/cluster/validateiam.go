@@ -0,0 +1,461 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	validateIAMLong = `Checks the operator roles and the OIDC provider of an STS cluster against the policies OCM expects
+for the cluster version.
+
+  For every operator required by the cluster version, it checks that:
+
+  * The cluster has an operator role for it and the role exists in the AWS account
+  * The role trusts the cluster OIDC provider
+  * The attached and inline policies of the role allow every action of the expected policy
+  * The role policies weren't created for an older minor version than the cluster runs
+
+  It also checks that the cluster OIDC provider exists in the AWS account.
+  Missing or drifted permissions commonly make upgrades fail or hang.`
+
+	validateIAMExample = `
+  # Validate the IAM setup of a cluster using an AWS profile named "rhcontrol"
+  osdctl cluster validate-iam 1kfmyclusteristhebesteverp8m --profile rhcontrol
+`
+
+	// operatorRoleVersionTag is set by rosa on the operator roles to the minor version their policies are for
+	operatorRoleVersionTag = "rosa_openshift_version"
+)
+
+type validateIAMOptions struct {
+	clusterID  string
+	awsProfile string
+
+	awsClient aws.Client
+}
+
+// iamFinding is a problem found with the IAM setup of the cluster
+type iamFinding struct {
+	Operator string
+	Role     string
+	Problem  string
+}
+
+// expectedOperatorRole is an operator role the cluster version requires, with the actions its policy allows
+type expectedOperatorRole struct {
+	Namespace string
+	Name      string
+	Actions   []string
+}
+
+func newCmdValidateIAM() *cobra.Command {
+	ops := validateIAMOptions{}
+	validateIAMCmd := &cobra.Command{
+		Use:               "validate-iam CLUSTER_ID",
+		Short:             "Checks the operator roles and OIDC provider of an STS cluster for drifted or missing permissions",
+		Long:              validateIAMLong,
+		Example:           validateIAMExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	validateIAMCmd.Flags().StringVarP(&ops.awsProfile, "profile", "p", ops.awsProfile, "AWS profile name")
+
+	return validateIAMCmd
+}
+
+func (o *validateIAMOptions) run() error {
+	ocmClient := utils.CreateConnection()
+	defer ocmClient.Close()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.CloudProvider().ID() != "aws" || cluster.AWS().STS().RoleARN() == "" {
+		return fmt.Errorf("cluster %s is not an AWS STS cluster", cluster.ID())
+	}
+
+	expected, err := getExpectedOperatorRoles(ocmClient, cluster.Version().RawID())
+	if err != nil {
+		return err
+	}
+
+	if o.awsClient == nil {
+		o.awsClient, err = osdCloud.GenerateAWSClientForCluster(o.awsProfile, cluster.ID())
+		if err != nil {
+			return err
+		}
+	}
+
+	findings := operatorRoleFindings(o.awsClient, cluster, expected)
+	findings = append(findings, oidcProviderFindings(o.awsClient, cluster)...)
+	printIAMFindings(findings)
+	return nil
+}
+
+// getExpectedOperatorRoles returns the operator roles required by the cluster version, with the actions OCM expects
+// their policies to allow
+func getExpectedOperatorRoles(ocmClient *sdk.Connection, version string) ([]expectedOperatorRole, error) {
+	inquiries := ocmClient.ClustersMgmt().V1().AWSInquiries()
+
+	policiesResponse, err := inquiries.STSPolicies().List().Search("policy_type = 'OperatorRole'").Size(100).Send()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the operator role policies from OCM: %w", err)
+	}
+	policies := map[string]string{}
+	policiesResponse.Items().Each(func(policy *cmv1.AWSSTSPolicy) bool {
+		policies[policy.ID()] = policy.Details()
+		return true
+	})
+
+	requestsResponse, err := inquiries.STSCredentialRequests().List().Size(100).Send()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the STS credential requests from OCM: %w", err)
+	}
+
+	var expected []expectedOperatorRole
+	var missingPolicies []string
+	requestsResponse.Items().Each(func(request *cmv1.STSCredentialRequest) bool {
+		operator := request.Operator()
+		if !versionInRange(version, operator.MinVersion(), operator.MaxVersion()) {
+			return true
+		}
+		// Policies are named after the credential request, e.g. openshift_ingress_operator_cloud_credentials_policy
+		policyID := fmt.Sprintf("openshift_%s_policy", request.Name())
+		document, ok := policies[policyID]
+		if !ok {
+			missingPolicies = append(missingPolicies, policyID)
+			return true
+		}
+		actions, err := policyAllowedActions(document)
+		if err != nil {
+			missingPolicies = append(missingPolicies, policyID)
+			return true
+		}
+		expected = append(expected, expectedOperatorRole{
+			Namespace: operator.Namespace(),
+			Name:      operator.Name(),
+			Actions:   actions,
+		})
+		return true
+	})
+	if len(missingPolicies) > 0 {
+		fmt.Fprintf(os.Stderr, "OCM has no usable policy for %s, skipping them\n", strings.Join(missingPolicies, ", "))
+	}
+
+	sort.Slice(expected, func(i, j int) bool {
+		return expected[i].Namespace+"/"+expected[i].Name < expected[j].Namespace+"/"+expected[j].Name
+	})
+	return expected, nil
+}
+
+// operatorRoleFindings checks the operator roles of the cluster against the expected ones
+func operatorRoleFindings(awsClient aws.Client, cluster *cmv1.Cluster, expected []expectedOperatorRole) []iamFinding {
+	sts := cluster.AWS().STS()
+	oidcProvider := strings.TrimPrefix(sts.OIDCEndpointURL(), "https://")
+	clusterMinor := minorVersion(cluster.Version().RawID())
+
+	roles := map[string]string{}
+	for _, role := range sts.OperatorIAMRoles() {
+		roles[role.Namespace()+"/"+role.Name()] = role.RoleARN()
+	}
+
+	var findings []iamFinding
+	for _, operatorRole := range expected {
+		operator := operatorRole.Namespace + "/" + operatorRole.Name
+		arn, ok := roles[operator]
+		if !ok || arn == "" {
+			findings = append(findings, iamFinding{
+				Operator: operator,
+				Problem:  "The cluster has no operator role for this operator, create it with 'rosa create operator-roles'",
+			})
+			continue
+		}
+
+		role, err := getRole(awsClient, arn)
+		if err != nil {
+			if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+				findings = append(findings, iamFinding{Operator: operator, Role: arn, Problem: "The role does not exist"})
+				continue
+			}
+			findings = append(findings, iamFinding{Operator: operator, Role: arn, Problem: fmt.Sprintf("Cannot get the role: %v", err)})
+			continue
+		}
+
+		trustPolicy, err := url.QueryUnescape(awsSdk.StringValue(role.AssumeRolePolicyDocument))
+		if err == nil && oidcProvider != "" && !strings.Contains(trustPolicy, oidcProvider) {
+			findings = append(findings, iamFinding{
+				Operator: operator,
+				Role:     arn,
+				Problem:  fmt.Sprintf("The role does not trust the cluster OIDC provider %s", oidcProvider),
+			})
+		}
+
+		for _, tag := range role.Tags {
+			if awsSdk.StringValue(tag.Key) == operatorRoleVersionTag && clusterMinor != "" &&
+				compareMinorVersions(awsSdk.StringValue(tag.Value), clusterMinor) < 0 {
+				findings = append(findings, iamFinding{
+					Operator: operator,
+					Role:     arn,
+					Problem: fmt.Sprintf("The role policies are for %s but the cluster runs %s, upgrade them with 'rosa upgrade operator-roles'",
+						awsSdk.StringValue(tag.Value), clusterMinor),
+				})
+			}
+		}
+
+		granted, err := roleAllowedActions(awsClient, awsSdk.StringValue(role.RoleName))
+		if err != nil {
+			findings = append(findings, iamFinding{Operator: operator, Role: arn, Problem: fmt.Sprintf("Cannot read the role policies: %v", err)})
+			continue
+		}
+		if missing := missingActions(operatorRole.Actions, granted); len(missing) > 0 {
+			findings = append(findings, iamFinding{
+				Operator: operator,
+				Role:     arn,
+				Problem:  fmt.Sprintf("The role policies don't allow %s", strings.Join(missing, ", ")),
+			})
+		}
+	}
+	return findings
+}
+
+// oidcProviderFindings checks that the cluster OIDC provider exists in the AWS account
+func oidcProviderFindings(awsClient aws.Client, cluster *cmv1.Cluster) []iamFinding {
+	oidcProvider := strings.TrimPrefix(cluster.AWS().STS().OIDCEndpointURL(), "https://")
+	if oidcProvider == "" {
+		return []iamFinding{{Operator: "OIDC provider", Problem: "The cluster has no OIDC endpoint URL"}}
+	}
+
+	output, err := awsClient.ListOpenIDConnectProviders(&iam.ListOpenIDConnectProvidersInput{})
+	if err != nil {
+		return []iamFinding{{Operator: "OIDC provider", Role: oidcProvider, Problem: fmt.Sprintf("Cannot list the OIDC providers: %v", err)}}
+	}
+	for _, provider := range output.OpenIDConnectProviderList {
+		if strings.HasSuffix(awsSdk.StringValue(provider.Arn), ":oidc-provider/"+oidcProvider) {
+			return nil
+		}
+	}
+	return []iamFinding{{
+		Operator: "OIDC provider",
+		Role:     oidcProvider,
+		Problem:  "The OIDC provider does not exist, create it with 'rosa create oidc-provider'",
+	}}
+}
+
+func getRole(awsClient aws.Client, arn string) (*iam.Role, error) {
+	roleName := arn[strings.LastIndex(arn, "/")+1:]
+	output, err := awsClient.GetRole(&iam.GetRoleInput{RoleName: awsSdk.String(roleName)})
+	if err != nil {
+		return nil, err
+	}
+	return output.Role, nil
+}
+
+// roleAllowedActions returns the actions allowed by the attached and inline policies of the role
+func roleAllowedActions(awsClient aws.Client, roleName string) ([]string, error) {
+	var documents []string
+
+	attached, err := awsClient.ListAttachedRolePolicies(&iam.ListAttachedRolePoliciesInput{RoleName: awsSdk.String(roleName)})
+	if err != nil {
+		return nil, err
+	}
+	for _, attachedPolicy := range attached.AttachedPolicies {
+		policy, err := awsClient.GetPolicy(&iam.GetPolicyInput{PolicyArn: attachedPolicy.PolicyArn})
+		if err != nil {
+			return nil, err
+		}
+		version, err := awsClient.GetPolicyVersion(&iam.GetPolicyVersionInput{
+			PolicyArn: attachedPolicy.PolicyArn,
+			VersionId: policy.Policy.DefaultVersionId,
+		})
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, awsSdk.StringValue(version.PolicyVersion.Document))
+	}
+
+	inline, err := awsClient.ListRolePolicies(&iam.ListRolePoliciesInput{RoleName: awsSdk.String(roleName)})
+	if err != nil {
+		return nil, err
+	}
+	for _, policyName := range inline.PolicyNames {
+		policy, err := awsClient.GetRolePolicy(&iam.GetRolePolicyInput{RoleName: awsSdk.String(roleName), PolicyName: policyName})
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, awsSdk.StringValue(policy.PolicyDocument))
+	}
+
+	var actions []string
+	for _, document := range documents {
+		// IAM returns the documents URL-encoded
+		decoded, err := url.QueryUnescape(document)
+		if err != nil {
+			return nil, err
+		}
+		allowed, err := policyAllowedActions(decoded)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, allowed...)
+	}
+	return actions, nil
+}
+
+// stringOrSlice is an IAM policy element that is either a single string or a list of them
+type stringOrSlice []string
+
+func (s *stringOrSlice) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = []string{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+type policyStatement struct {
+	Effect string        `json:"Effect"`
+	Action stringOrSlice `json:"Action"`
+}
+
+// policyAllowedActions returns the actions of the Allow statements of a policy document
+func policyAllowedActions(document string) ([]string, error) {
+	var policy struct {
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy document: %w", err)
+	}
+
+	var statements []policyStatement
+	if err := json.Unmarshal(policy.Statement, &statements); err != nil {
+		var statement policyStatement
+		if err := json.Unmarshal(policy.Statement, &statement); err != nil {
+			return nil, fmt.Errorf("invalid policy statement: %w", err)
+		}
+		statements = []policyStatement{statement}
+	}
+
+	var actions []string
+	for _, statement := range statements {
+		if statement.Effect == "Allow" {
+			actions = append(actions, statement.Action...)
+		}
+	}
+	return actions, nil
+}
+
+// missingActions returns the expected actions that none of the granted ones allow, granted actions may use wildcards
+func missingActions(expected, granted []string) []string {
+	var missing []string
+	for _, action := range expected {
+		allowed := false
+		for _, pattern := range granted {
+			// IAM actions are case-insensitive and never contain a '/', so path.Match handles the wildcards
+			if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(action)); ok {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			missing = append(missing, action)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// minorVersion returns the major.minor part of a version, e.g. 4.12 for 4.12.3
+func minorVersion(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// compareMinorVersions compares the major.minor part of two versions, returning -1, 0 or 1
+func compareMinorVersions(a, b string) int {
+	parse := func(version string) (int, int) {
+		parts := strings.SplitN(minorVersion(version), ".", 2)
+		if len(parts) != 2 {
+			return 0, 0
+		}
+		major, _ := strconv.Atoi(parts[0])
+		minor, _ := strconv.Atoi(parts[1])
+		return major, minor
+	}
+	aMajor, aMinor := parse(a)
+	bMajor, bMinor := parse(b)
+	switch {
+	case aMajor != bMajor:
+		if aMajor < bMajor {
+			return -1
+		}
+		return 1
+	case aMinor < bMinor:
+		return -1
+	case aMinor > bMinor:
+		return 1
+	}
+	return 0
+}
+
+// versionInRange reports whether the version is within the optional minimum and maximum minor versions
+func versionInRange(version, minVersion, maxVersion string) bool {
+	if minVersion != "" && compareMinorVersions(version, minVersion) < 0 {
+		return false
+	}
+	if maxVersion != "" && compareMinorVersions(version, maxVersion) > 0 {
+		return false
+	}
+	return true
+}
+
+func printIAMFindings(findings []iamFinding) {
+	if len(findings) == 0 {
+		fmt.Println("The operator roles and OIDC provider match the expected policies")
+		return
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Operator", "Role", "Problem"})
+	for _, finding := range findings {
+		table.AddRow([]string{finding.Operator, finding.Role, finding.Problem})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "error while flushing table: %v\n", err)
+	}
+	fmt.Printf("Found %d problem(s)\n", len(findings))
+}
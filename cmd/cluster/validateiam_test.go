@@ -0,0 +1,131 @@
+package cluster
+
+import (
+	"net/url"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func TestPolicyAllowedActions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	actions, err := policyAllowedActions(`{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["ec2:DescribeInstances","elasticloadbalancing:*"],"Resource":"*"},
+		{"Effect":"Deny","Action":"iam:*","Resource":"*"},
+		{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(actions).To(Equal([]string{"ec2:DescribeInstances", "elasticloadbalancing:*", "s3:GetObject"}))
+
+	actions, err = policyAllowedActions(`{"Statement":{"Effect":"Allow","Action":"route53:ChangeResourceRecordSets"}}`)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(actions).To(Equal([]string{"route53:ChangeResourceRecordSets"}))
+
+	_, err = policyAllowedActions(`not json`)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestMissingActions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	granted := []string{"ec2:Describe*", "elasticloadbalancing:*", "S3:GetObject"}
+	g.Expect(missingActions([]string{"ec2:DescribeInstances", "elasticloadbalancing:CreateListener", "s3:getobject"}, granted)).To(BeEmpty())
+	g.Expect(missingActions([]string{"ec2:RunInstances", "ec2:DescribeSubnets", "iam:GetRole"}, granted)).
+		To(Equal([]string{"ec2:RunInstances", "iam:GetRole"}))
+	g.Expect(missingActions([]string{"iam:GetRole"}, []string{"*"})).To(BeEmpty())
+}
+
+func TestVersionInRange(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(versionInRange("4.12.3", "", "")).To(BeTrue())
+	g.Expect(versionInRange("4.12.3", "4.10", "")).To(BeTrue())
+	g.Expect(versionInRange("4.12.3", "4.13", "")).To(BeFalse())
+	g.Expect(versionInRange("4.12.3", "", "4.11")).To(BeFalse())
+	g.Expect(versionInRange("4.12.3", "4.12", "4.12")).To(BeTrue())
+	g.Expect(compareMinorVersions("4.9", "4.12")).To(Equal(-1))
+}
+
+func TestOperatorRoleFindings(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := awsmock.NewMockClient(mockCtrl)
+
+	cluster, err := cmv1.NewCluster().
+		Version(cmv1.NewVersion().RawID("4.12.3")).
+		AWS(cmv1.NewAWS().STS(cmv1.NewSTS().
+			OIDCEndpointURL("https://oidc.example.com/abc").
+			OperatorIAMRoles(
+				cmv1.NewOperatorIAMRole().Namespace("openshift-ingress-operator").Name("cloud-credentials").
+					RoleARN("arn:aws:iam::123456789012:role/foo-openshift-ingress-operator-cloud-credentials"),
+				cmv1.NewOperatorIAMRole().Namespace("openshift-image-registry").Name("installer-cloud-credentials").
+					RoleARN("arn:aws:iam::123456789012:role/foo-openshift-image-registry-installer-cloud-creden"),
+			))).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expected := []expectedOperatorRole{
+		{Namespace: "openshift-cloud-credential-operator", Name: "cloud-credential-operator-iam-ro-creds", Actions: []string{"iam:GetUser"}},
+		{Namespace: "openshift-image-registry", Name: "installer-cloud-credentials", Actions: []string{"s3:CreateBucket"}},
+		{Namespace: "openshift-ingress-operator", Name: "cloud-credentials", Actions: []string{"route53:ListHostedZones", "tag:GetResources"}},
+	}
+
+	trusted := url.QueryEscape(`{"Statement":[{"Principal":{"Federated":"arn:aws:iam::123456789012:oidc-provider/oidc.example.com/abc"}}]}`)
+	mockAWSClient.EXPECT().GetRole(&iam.GetRoleInput{RoleName: awsSdk.String("foo-openshift-image-registry-installer-cloud-creden")}).
+		Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil))
+	mockAWSClient.EXPECT().GetRole(&iam.GetRoleInput{RoleName: awsSdk.String("foo-openshift-ingress-operator-cloud-credentials")}).
+		Return(&iam.GetRoleOutput{Role: &iam.Role{
+			RoleName:                 awsSdk.String("foo-openshift-ingress-operator-cloud-credentials"),
+			AssumeRolePolicyDocument: awsSdk.String(trusted),
+			Tags:                     []*iam.Tag{{Key: awsSdk.String(operatorRoleVersionTag), Value: awsSdk.String("4.11")}},
+		}}, nil)
+
+	policyArn := awsSdk.String("arn:aws:iam::123456789012:policy/foo-openshift-ingress-operator-cloud-credentials")
+	mockAWSClient.EXPECT().ListAttachedRolePolicies(gomock.Any()).Return(&iam.ListAttachedRolePoliciesOutput{
+		AttachedPolicies: []*iam.AttachedPolicy{{PolicyArn: policyArn}},
+	}, nil)
+	mockAWSClient.EXPECT().GetPolicy(&iam.GetPolicyInput{PolicyArn: policyArn}).
+		Return(&iam.GetPolicyOutput{Policy: &iam.Policy{DefaultVersionId: awsSdk.String("v2")}}, nil)
+	mockAWSClient.EXPECT().GetPolicyVersion(&iam.GetPolicyVersionInput{PolicyArn: policyArn, VersionId: awsSdk.String("v2")}).
+		Return(&iam.GetPolicyVersionOutput{PolicyVersion: &iam.PolicyVersion{
+			Document: awsSdk.String(url.QueryEscape(`{"Statement":[{"Effect":"Allow","Action":["route53:List*"]}]}`)),
+		}}, nil)
+	mockAWSClient.EXPECT().ListRolePolicies(gomock.Any()).Return(&iam.ListRolePoliciesOutput{}, nil)
+
+	findings := operatorRoleFindings(mockAWSClient, cluster, expected)
+	g.Expect(findings).To(HaveLen(4))
+	g.Expect(findings[0].Operator).To(Equal("openshift-cloud-credential-operator/cloud-credential-operator-iam-ro-creds"))
+	g.Expect(findings[0].Problem).To(ContainSubstring("no operator role"))
+	g.Expect(findings[1].Problem).To(Equal("The role does not exist"))
+	g.Expect(findings[2].Problem).To(ContainSubstring("are for 4.11 but the cluster runs 4.12"))
+	g.Expect(findings[3].Problem).To(Equal("The role policies don't allow tag:GetResources"))
+}
+
+func TestOIDCProviderFindings(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := awsmock.NewMockClient(mockCtrl)
+
+	cluster, err := cmv1.NewCluster().AWS(cmv1.NewAWS().STS(cmv1.NewSTS().
+		OIDCEndpointURL("https://oidc.example.com/abc"))).Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	mockAWSClient.EXPECT().ListOpenIDConnectProviders(gomock.Any()).Return(&iam.ListOpenIDConnectProvidersOutput{
+		OpenIDConnectProviderList: []*iam.OpenIDConnectProviderListEntry{
+			{Arn: awsSdk.String("arn:aws:iam::123456789012:oidc-provider/oidc.example.com/abc")},
+		},
+	}, nil)
+	g.Expect(oidcProviderFindings(mockAWSClient, cluster)).To(BeEmpty())
+
+	mockAWSClient.EXPECT().ListOpenIDConnectProviders(gomock.Any()).Return(&iam.ListOpenIDConnectProvidersOutput{}, nil)
+	findings := oidcProviderFindings(mockAWSClient, cluster)
+	g.Expect(findings).To(HaveLen(1))
+	g.Expect(findings[0].Problem).To(ContainSubstring("does not exist"))
+}
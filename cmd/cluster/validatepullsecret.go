@@ -5,18 +5,18 @@ import (
 	"fmt"
 	v1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
 	"github.com/openshift/osdctl/cmd/servicelog"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var BackplaneClusterAdmin = "backplane-cluster-admin"
 
-func newCmdValidatePullSecret(kubeCli client.Client, flags *genericclioptions.ConfigFlags) *cobra.Command {
+func newCmdValidatePullSecret(streams genericclioptions.IOStreams, kubeCli client.Client, flags *genericclioptions.ConfigFlags) *cobra.Command {
 	return &cobra.Command{
 		Use:   "validate-pull-secret [CLUSTER_ID]",
 		Short: "Checks if the pull secret email matches the owner email",
@@ -26,20 +26,20 @@ The owner's email to check will be determined by the cluster identifier passed t
 		Args:              cobra.ExactArgs(1),
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ValidatePullSecret(args[0], kubeCli, flags))
+			osdctlErrors.CheckErr(ValidatePullSecret(args[0], kubeCli, flags, streams))
 		},
 	}
 }
 
-func ValidatePullSecret(clusterID string, kubeCli client.Client, flags *genericclioptions.ConfigFlags) error {
+func ValidatePullSecret(clusterID string, kubeCli client.Client, flags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) error {
 	ocm := utils.CreateConnection()
 	defer func() {
 		if ocmCloseErr := ocm.Close(); ocmCloseErr != nil {
-			fmt.Printf("Cannot close the ocm (possible memory leak): %q", ocmCloseErr)
+			fmt.Fprintf(streams.ErrOut, "Cannot close the ocm (possible memory leak): %q\n", ocmCloseErr)
 		}
 	}()
 
-	fmt.Println("Checking if pull secret email matches user email")
+	fmt.Fprintln(streams.ErrOut, "Checking if pull secret email matches user email")
 
 	// This is the flagset for the kubeCli object provided from the root command. Set here to retroactively impersonate backplane-cluster-admin
 	flags.Impersonate = &BackplaneClusterAdmin
@@ -49,7 +49,7 @@ func ValidatePullSecret(clusterID string, kubeCli client.Client, flags *genericc
 		return err
 	}
 
-	clusterPullSecretEmail, err, done := getPullSecretEmail(clusterID, secret, true)
+	clusterPullSecretEmail, err, done := getPullSecretEmail(clusterID, secret, true, streams)
 	if done {
 		return err
 	}
@@ -65,7 +65,8 @@ func ValidatePullSecret(clusterID string, kubeCli client.Client, flags *genericc
 	}
 
 	if account.Email() != clusterPullSecretEmail {
-		fmt.Println("Pull secret email doesn't match OCM user email. Sending service log.")
+		fmt.Fprintln(streams.Out, "Pull secret email doesn't match OCM user email.")
+		fmt.Fprintln(streams.ErrOut, "Sending service log.")
 		postCmd := servicelog.PostCmdOptions{
 			Template:  "https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/pull_secret_user_mismatch.json",
 			ClusterId: clusterID,
@@ -76,17 +77,19 @@ func ValidatePullSecret(clusterID string, kubeCli client.Client, flags *genericc
 		return nil
 	}
 
-	fmt.Println("Email addresses match.")
+	fmt.Fprintln(streams.Out, "Email addresses match.")
 	return nil
 }
 
-func getPullSecretEmail(clusterID string, secret *corev1.Secret, sendServiceLog bool) (string, error, bool) {
+// getPullSecretEmail returns the cloud.openshift.com email of the pull secret, the problems found are printed to the
+// output and the progress of the service logs to the error output
+func getPullSecretEmail(clusterID string, secret *corev1.Secret, sendServiceLog bool, streams genericclioptions.IOStreams) (string, error, bool) {
 	dockerConfigJsonBytes, found := secret.Data[".dockerconfigjson"]
 	if !found {
 		// Indicates issue w/ pull-secret, so we can stop evaluating and specify a more direct course of action
-		fmt.Println("Secret does not contain expected key '.dockerconfigjson'.")
+		fmt.Fprintln(streams.Out, "Secret does not contain expected key '.dockerconfigjson'.")
 		if sendServiceLog {
-			fmt.Println("Sending service log.")
+			fmt.Fprintln(streams.ErrOut, "Sending service log.")
 			postCmd := servicelog.PostCmdOptions{
 				Template:  "https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/pull_secret_change_breaking_upgradesync.json",
 				ClusterId: clusterID,
@@ -106,9 +109,9 @@ func getPullSecretEmail(clusterID string, secret *corev1.Secret, sendServiceLog
 
 	cloudOpenshiftAuth, found := dockerConfigJson.Auths()["cloud.openshift.com"]
 	if !found {
-		fmt.Println("Secret does not contain entry for cloud.openshift.com")
+		fmt.Fprintln(streams.Out, "Secret does not contain entry for cloud.openshift.com")
 		if sendServiceLog {
-			fmt.Println("Sending service log")
+			fmt.Fprintln(streams.ErrOut, "Sending service log.")
 			postCmd := servicelog.PostCmdOptions{
 				Template:  "https://raw.githubusercontent.com/openshift/managed-notifications/master/osd/pull_secret_change_breaking_upgradesync.json",
 				ClusterId: clusterID,
@@ -122,7 +125,7 @@ func getPullSecretEmail(clusterID string, secret *corev1.Secret, sendServiceLog
 
 	clusterPullSecretEmail := cloudOpenshiftAuth.Email()
 	if clusterPullSecretEmail == "" {
-		fmt.Printf("%v\n%v\n%v\n",
+		fmt.Fprintf(streams.Out, "%v\n%v\n%v\n",
 			"Couldn't extract email address from pull secret for cloud.openshift.com",
 			"This can mean the pull secret is misconfigured. Please verify the pull secret manually:",
 			"  oc get secret -n openshift-config pull-secret -o json | jq -r '.data[\".dockerconfigjson\"]' | base64 -d")
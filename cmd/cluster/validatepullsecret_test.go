@@ -2,6 +2,7 @@ package cluster
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"reflect"
 	"testing"
 )
@@ -37,7 +38,7 @@ func Test_getPullSecretEmail(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			email, err, done := getPullSecretEmail("abc123", tt.secret, false)
+			email, err, done := getPullSecretEmail("abc123", tt.secret, false, genericclioptions.NewTestIOStreamsDiscard())
 			if email != tt.expectedEmail {
 				t.Errorf("getPullSecretEmail() email = %v, expectedEmail %v", email, tt.expectedEmail)
 			}
@@ -0,0 +1,423 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	volumesTuneLong = `Changes the size and IOPS of the root volume of the nodes of a machine pool.
+
+  This command will:
+
+  * Check the new size and IOPS against the limits of the cloud provider: 128 to 16384 GiB and, for the gp3 volumes
+    of AWS, 3000 to 16000 IOPS; 128 to 65536 GiB on GCP, whose IOPS follow the size
+  * Update the root volume of the machine pool in OCM, used for the nodes created from then on
+  * List the nodes whose root volume differs, which have to be replaced for the change to apply to them, e.g. by
+    deleting their machines one at a time
+
+  Requires being logged in to the cluster through backplane ('ocm backplane login CLUSTER_ID') to read the volumes of
+  the nodes. Only classic clusters are supported, the node pools of hosted control planes are managed differently.`
+
+	volumesTuneExample = `
+  # Grow the root volume of the worker nodes to 300 GiB with 6000 IOPS, showing the change first
+  osdctl cluster volumes tune 1kfmyclusteristhebesteverp8m --worker-root-size 300 --iops 6000 --dry-run
+  osdctl cluster volumes tune 1kfmyclusteristhebesteverp8m --worker-root-size 300 --iops 6000
+
+  # Only raise the IOPS of the nodes of the "batch" pool
+  osdctl cluster volumes tune 1kfmyclusteristhebesteverp8m --pool batch --iops 9000
+`
+
+	minRootVolumeSize   = 128
+	maxAWSVolumeSize    = 16384
+	maxGCPVolumeSize    = 65536
+	minAWSIOPS          = 3000
+	maxAWSIOPS          = 16000
+	gp3BaselineIOPS     = 3000
+	machinePoolsAPIPath = "/api/clusters_mgmt/v1/clusters/%s/machine_pools/%s"
+)
+
+type volumesTuneOptions struct {
+	clusterID   string
+	pool        string
+	size        int
+	iops        int
+	dryRun      bool
+	skipPrompts bool
+
+	run           utils.OCRunner
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// rootVolume is the root volume of the nodes of a machine pool, zero values are the defaults of the provider
+type rootVolume struct {
+	Size int `json:"size,omitempty"`
+	IOPS int `json:"iops,omitempty"`
+}
+
+// machinePoolRootVolume is the part of an OCM machine pool the version of the SDK doesn't model yet
+type machinePoolRootVolume struct {
+	RootVolume struct {
+		AWS *rootVolume `json:"aws,omitempty"`
+		GCP *rootVolume `json:"gcp,omitempty"`
+	} `json:"root_volume"`
+}
+
+// nodeVolume is the root volume of a node of the pool, read from the provider spec of its machine
+type nodeVolume struct {
+	Machine         string `json:"machine"`
+	Node            string `json:"node,omitempty"`
+	Size            int    `json:"size"`
+	IOPS            int    `json:"iops,omitempty"`
+	NeedReplacement bool   `json:"need_replacement"`
+}
+
+type volumesTuneResponse struct {
+	ClusterID string       `json:"cluster_id"`
+	Pool      string       `json:"pool"`
+	Provider  string       `json:"provider"`
+	Current   rootVolume   `json:"current"`
+	Target    rootVolume   `json:"target"`
+	DryRun    bool         `json:"dry_run"`
+	Nodes     []nodeVolume `json:"nodes"`
+}
+
+func (r volumesTuneResponse) String() string {
+	var b strings.Builder
+	verb := "Updated"
+	if r.DryRun {
+		verb = "Would update"
+	}
+	fmt.Fprintf(&b, "%s the root volume of machine pool %s from %s to %s\n\n", verb, r.Pool, formatVolume(r.Current), formatVolume(r.Target))
+
+	replace := 0
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Node", "Machine", "Size (GiB)", "IOPS", "Replacement"})
+	for _, node := range r.Nodes {
+		replacement := ""
+		if node.NeedReplacement {
+			replacement = "needed"
+			replace++
+		}
+		table.AddRow([]string{node.Node, node.Machine, strconv.Itoa(node.Size), formatIOPS(node.IOPS), replacement})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+
+	if replace == 0 {
+		b.WriteString("Every node of the pool already has this root volume.\n")
+	} else {
+		fmt.Fprintf(&b, "%d of the %d nodes keep their root volume until they are replaced, e.g. by deleting their machine one at a time.\n", replace, len(r.Nodes))
+	}
+	return b.String()
+}
+
+func formatVolume(volume rootVolume) string {
+	size := "the default size"
+	if volume.Size > 0 {
+		size = fmt.Sprintf("%d GiB", volume.Size)
+	}
+	if volume.IOPS > 0 {
+		return fmt.Sprintf("%s with %d IOPS", size, volume.IOPS)
+	}
+	return size
+}
+
+func formatIOPS(iops int) string {
+	if iops == 0 {
+		return "default"
+	}
+	return strconv.Itoa(iops)
+}
+
+func newCmdVolumes(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	volumesCmd := &cobra.Command{
+		Use:               "volumes",
+		Short:             "Manages the root volumes of the nodes of a cluster",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	volumesCmd.AddCommand(newCmdVolumesTune(globalOpts))
+	return volumesCmd
+}
+
+func newCmdVolumesTune(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &volumesTuneOptions{run: utils.RunOC, GlobalOptions: globalOpts}
+	tuneCmd := &cobra.Command{
+		Use:               "tune CLUSTER_ID",
+		Short:             "Changes the size and IOPS of the root volume of the nodes of a machine pool",
+		Long:              volumesTuneLong,
+		Example:           volumesTuneExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.runTune())
+		},
+	}
+	tuneCmd.Flags().StringVar(&ops.pool, "pool", "worker", "Machine pool whose root volume to change")
+	flagtypes.SizeVar(tuneCmd.Flags(), &ops.size, "worker-root-size", 0, flagtypes.GiB, "Size of the root volume, in GiB unless a unit is given, e.g. 300 or 1Ti")
+	tuneCmd.Flags().IntVar(&ops.iops, "iops", 0, "IOPS of the root volume, only on AWS")
+	tuneCmd.Flags().BoolVar(&ops.dryRun, "dry-run", false, "Show the change and the nodes to replace without changing the machine pool")
+	tuneCmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+
+	return tuneCmd
+}
+
+func (o *volumesTuneOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.size == 0 && o.iops == 0 {
+		return cmdutil.UsageErrorf(cmd, "at least one of --worker-root-size and --iops is required")
+	}
+	if o.iops < 0 {
+		return cmdutil.UsageErrorf(cmd, "--iops must be positive")
+	}
+	o.clusterID = args[0]
+	return utils.IsValidClusterKey(o.clusterID)
+}
+
+// validateVolumeLimits checks the root volume against the limits of the cloud provider
+func validateVolumeLimits(provider string, volume rootVolume) error {
+	maxSize := 0
+	switch strings.ToLower(provider) {
+	case "aws":
+		maxSize = maxAWSVolumeSize
+	case "gcp":
+		maxSize = maxGCPVolumeSize
+		if volume.IOPS > 0 {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "the IOPS of the persistent disks of GCP follow their size, --iops is only supported on AWS")
+		}
+	default:
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the root volumes can only be tuned on AWS and GCP clusters, not %s", provider)
+	}
+	if volume.Size != 0 && (volume.Size < minRootVolumeSize || volume.Size > maxSize) {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the root volume must be %d to %d GiB on %s, got %d", minRootVolumeSize, maxSize, strings.ToUpper(provider), volume.Size)
+	}
+	// gp3 volumes allow 500 IOPS per GiB, which the minimum size already allows for the maximum IOPS
+	if volume.IOPS != 0 && (volume.IOPS < minAWSIOPS || volume.IOPS > maxAWSIOPS) {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "gp3 volumes have %d to %d IOPS, got %d", minAWSIOPS, maxAWSIOPS, volume.IOPS)
+	}
+	return nil
+}
+
+// targetVolume applies the flags to the current root volume of the pool
+func (o *volumesTuneOptions) targetVolume(current rootVolume) rootVolume {
+	target := current
+	if o.size > 0 {
+		target.Size = o.size
+	}
+	if o.iops > 0 {
+		target.IOPS = o.iops
+	}
+	return target
+}
+
+func (o *volumesTuneOptions) runTune() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.Hypershift().Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s has a hosted control plane, the root volumes of its node pools can't be tuned with this command", cluster.ID())
+	}
+	provider := strings.ToLower(cluster.CloudProvider().ID())
+	if err := validateVolumeLimits(provider, rootVolume{Size: o.size, IOPS: o.iops}); err != nil {
+		return err
+	}
+	if err := utils.CheckOCCluster(o.run, cluster); err != nil {
+		return err
+	}
+
+	poolPath := fmt.Sprintf(machinePoolsAPIPath, cluster.ID(), o.pool)
+	current, err := getRootVolume(connection, poolPath, provider)
+	if err != nil {
+		return err
+	}
+	target := o.targetVolume(current)
+
+	machines, err := poolVolumeMachines(o.run, o.pool)
+	if err != nil {
+		return fmt.Errorf("cannot read the root volumes of the nodes of machine pool %s: %w", o.pool, err)
+	}
+	response := volumesTuneResponse{
+		ClusterID: cluster.ID(),
+		Pool:      o.pool,
+		Provider:  provider,
+		Current:   current,
+		Target:    target,
+		DryRun:    o.dryRun,
+		Nodes:     nodesToReplace(machines, target),
+	}
+	if o.dryRun || target == current {
+		if target == current {
+			fmt.Fprintf(os.Stderr, "Machine pool %s already has a root volume of %s\n", o.pool, formatVolume(target))
+		}
+		return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+	}
+
+	action := fmt.Sprintf("Change the root volume of machine pool %s from %s to %s", o.pool, formatVolume(current), formatVolume(target))
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(connection, cluster, action),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+	if err := patchRootVolume(connection, poolPath, provider, rootVolume{Size: o.size, IOPS: o.iops}); err != nil {
+		return err
+	}
+	utils.RecordClusterAction(connection, cluster, action)
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// getRootVolume returns the root volume of the machine pool, empty when it uses the defaults
+func getRootVolume(connection *sdk.Connection, poolPath, provider string) (rootVolume, error) {
+	response, err := connection.Get().Path(poolPath).Send()
+	if err != nil {
+		return rootVolume{}, fmt.Errorf("cannot get the machine pool: %w", err)
+	}
+	if response.Status() != http.StatusOK {
+		return rootVolume{}, osdctlErrors.WrapStatus(response.Status(), fmt.Errorf("cannot get the machine pool %s: %s", path.Base(poolPath), response.String()))
+	}
+	var pool machinePoolRootVolume
+	if err := json.Unmarshal(response.Bytes(), &pool); err != nil {
+		return rootVolume{}, fmt.Errorf("cannot parse the machine pool: %w", err)
+	}
+	volume := pool.RootVolume.AWS
+	if provider == "gcp" {
+		volume = pool.RootVolume.GCP
+	}
+	if volume == nil {
+		return rootVolume{}, nil
+	}
+	return *volume, nil
+}
+
+// rootVolumePatch returns the body of the request changing only the given fields of the root volume
+func rootVolumePatch(provider string, volume rootVolume) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{"root_volume": map[string]rootVolume{provider: volume}})
+}
+
+func patchRootVolume(connection *sdk.Connection, poolPath, provider string, volume rootVolume) error {
+	body, err := rootVolumePatch(provider, volume)
+	if err != nil {
+		return err
+	}
+	response, err := connection.Patch().Path(poolPath).Bytes(body).Send()
+	if err != nil {
+		return fmt.Errorf("cannot update the root volume of the machine pool: %w", err)
+	}
+	if response.Status() != http.StatusOK {
+		return osdctlErrors.WrapStatus(response.Status(), fmt.Errorf("cannot update the root volume of machine pool %s: %s", path.Base(poolPath), response.String()))
+	}
+	return nil
+}
+
+// volumeMachine is the part of a machine API Machine holding its root volume, the block devices on AWS and the
+// disks on GCP
+type volumeMachine struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		ProviderSpec struct {
+			Value struct {
+				BlockDevices []struct {
+					EBS struct {
+						VolumeSize int    `json:"volumeSize"`
+						Iops       int    `json:"iops"`
+						VolumeType string `json:"volumeType"`
+					} `json:"ebs"`
+				} `json:"blockDevices"`
+				Disks []struct {
+					Boot   bool `json:"boot"`
+					SizeGb int  `json:"sizeGb"`
+				} `json:"disks"`
+			} `json:"value"`
+		} `json:"providerSpec"`
+	} `json:"spec"`
+	Status struct {
+		NodeRef *struct {
+			Name string `json:"name"`
+		} `json:"nodeRef"`
+	} `json:"status"`
+}
+
+// rootVolume returns the root volume of the machine, the first block device on AWS or the boot disk on GCP
+func (m volumeMachine) rootVolume() rootVolume {
+	value := m.Spec.ProviderSpec.Value
+	if len(value.BlockDevices) > 0 {
+		ebs := value.BlockDevices[0].EBS
+		iops := ebs.Iops
+		// gp3 volumes created without IOPS have the baseline
+		if iops == 0 && ebs.VolumeType == "gp3" {
+			iops = gp3BaselineIOPS
+		}
+		return rootVolume{Size: ebs.VolumeSize, IOPS: iops}
+	}
+	for _, disk := range value.Disks {
+		if disk.Boot {
+			return rootVolume{Size: disk.SizeGb}
+		}
+	}
+	return rootVolume{}
+}
+
+func poolVolumeMachines(run utils.OCRunner, pool string) ([]volumeMachine, error) {
+	sets, err := poolMachineSets(run, pool)
+	if err != nil {
+		return nil, err
+	}
+	var machines []volumeMachine
+	for _, set := range sets {
+		output, err := run("get", "machines.machine.openshift.io", "-n", machineAPINamespace, "-l", machineSetLabel+"="+set.Metadata.Name, "-o", "json")
+		if err != nil {
+			return nil, err
+		}
+		var list struct {
+			Items []volumeMachine `json:"items"`
+		}
+		if err := json.Unmarshal(output, &list); err != nil {
+			return nil, fmt.Errorf("cannot parse the machines of machine set %s: %w", set.Metadata.Name, err)
+		}
+		machines = append(machines, list.Items...)
+	}
+	return machines, nil
+}
+
+// nodesToReplace flags the machines whose root volume differs from the target, the volumes of the existing machines
+// don't change with the machine pool
+func nodesToReplace(machines []volumeMachine, target rootVolume) []nodeVolume {
+	nodes := []nodeVolume{}
+	for _, m := range machines {
+		volume := m.rootVolume()
+		node := nodeVolume{Machine: m.Metadata.Name, Size: volume.Size, IOPS: volume.IOPS}
+		if m.Status.NodeRef != nil {
+			node.Node = m.Status.NodeRef.Name
+		}
+		node.NeedReplacement = (target.Size > 0 && volume.Size != target.Size) || (target.IOPS > 0 && volume.IOPS != target.IOPS)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateVolumeLimits(t *testing.T) {
+	for _, tc := range []struct {
+		title    string
+		provider string
+		volume   rootVolume
+		valid    bool
+	}{
+		{"aws size and iops", "aws", rootVolume{Size: 300, IOPS: 6000}, true},
+		{"aws iops only", "AWS", rootVolume{IOPS: 16000}, true},
+		{"aws too small", "aws", rootVolume{Size: 100}, false},
+		{"aws too large", "aws", rootVolume{Size: 20000}, false},
+		{"aws iops below gp3 baseline", "aws", rootVolume{Size: 300, IOPS: 1000}, false},
+		{"aws iops above gp3 maximum", "aws", rootVolume{Size: 300, IOPS: 20000}, false},
+		{"aws maximum iops on the minimum size", "aws", rootVolume{Size: 128, IOPS: 16000}, true},
+		{"gcp large disk", "gcp", rootVolume{Size: 30000}, true},
+		{"gcp iops", "gcp", rootVolume{Size: 300, IOPS: 6000}, false},
+		{"other provider", "azure", rootVolume{Size: 300}, false},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			err := validateVolumeLimits(tc.provider, tc.volume)
+			if tc.valid {
+				g.Expect(err).NotTo(HaveOccurred())
+			} else {
+				g.Expect(err).To(HaveOccurred())
+			}
+		})
+	}
+
+	g := NewGomegaWithT(t)
+	g.Expect(validateVolumeLimits("aws", rootVolume{Size: 6, IOPS: 3000}).Error()).To(ContainSubstring("128 to 16384 GiB"))
+}
+
+func TestNodesToReplace(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var list struct {
+		Items []volumeMachine `json:"items"`
+	}
+	err := json.Unmarshal([]byte(`{"items": [
+		{"metadata": {"name": "worker-a-1"}, "status": {"nodeRef": {"name": "ip-10-0-1-1"}},
+		 "spec": {"providerSpec": {"value": {"blockDevices": [{"ebs": {"volumeSize": 300, "volumeType": "gp3", "iops": 6000}}]}}}},
+		{"metadata": {"name": "worker-a-2"}, "status": {"nodeRef": {"name": "ip-10-0-1-2"}},
+		 "spec": {"providerSpec": {"value": {"blockDevices": [{"ebs": {"volumeSize": 300, "volumeType": "gp3"}}]}}}},
+		{"metadata": {"name": "worker-b-1"},
+		 "spec": {"providerSpec": {"value": {"disks": [{"boot": false, "sizeGb": 500}, {"boot": true, "sizeGb": 128}]}}}}
+	]}`), &list)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	nodes := nodesToReplace(list.Items, rootVolume{Size: 300, IOPS: 6000})
+	g.Expect(nodes).To(Equal([]nodeVolume{
+		{Machine: "worker-a-1", Node: "ip-10-0-1-1", Size: 300, IOPS: 6000},
+		// Created with the gp3 baseline
+		{Machine: "worker-a-2", Node: "ip-10-0-1-2", Size: 300, IOPS: 3000, NeedReplacement: true},
+		{Machine: "worker-b-1", Size: 128, NeedReplacement: true},
+	}))
+
+	response := volumesTuneResponse{Pool: "worker", Current: rootVolume{Size: 300}, Target: rootVolume{Size: 300, IOPS: 6000}, DryRun: true, Nodes: nodes}
+	g.Expect(response.String()).To(ContainSubstring("Would update the root volume of machine pool worker from 300 GiB to 300 GiB with 6000 IOPS"))
+	g.Expect(response.String()).To(ContainSubstring("2 of the 3 nodes keep their root volume"))
+}
+
+func TestRootVolumePatch(t *testing.T) {
+	g := NewGomegaWithT(t)
+	body, err := rootVolumePatch("aws", rootVolume{IOPS: 6000})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(body)).To(Equal(`{"root_volume":{"aws":{"iops":6000}}}`))
+
+	options := &volumesTuneOptions{size: 500}
+	g.Expect(options.targetVolume(rootVolume{Size: 300, IOPS: 6000})).To(Equal(rootVolume{Size: 500, IOPS: 6000}))
+}
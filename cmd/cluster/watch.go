@@ -0,0 +1,260 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/poll"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	clusterWatchLongDescription = `
+Prints the changes of a cluster as they happen, until interrupted with Ctrl-C, to follow it during a remediation
+
+  The events are:
+
+  * state: the OCM state of the cluster changed, e.g. from installing to ready, or its provisioning error
+  * version: the OpenShift version of the cluster changed, e.g. once an upgrade completed
+  * limited support: a limited support reason was posted or removed
+  * service log: a service log was posted, customer facing or internal
+
+  OCM has no event stream, so the cluster, its limited support reasons and its service logs are polled at every
+  --interval. A failed poll is reported and retried at the next interval. With -o json, every event is printed as a
+  JSON object on a line of its own, for scripts.
+`
+	clusterWatchExample = `
+  # Follow a cluster while remediating it
+  osdctl cluster watch 1kfmyclusteristhebesteverp8m
+
+  # Poll every 10 seconds, printing the events as JSON lines
+  osdctl cluster watch 1kfmyclusteristhebesteverp8m --interval 10s -o json
+`
+
+	watchEventState          = "state"
+	watchEventVersion        = "version"
+	watchEventLimitedSupport = "limited support"
+	watchEventServiceLog     = "service log"
+)
+
+type clusterWatchOptions struct {
+	clusterID string
+	interval  time.Duration
+
+	out io.Writer
+	// snapshot reads the cluster, it is swapped in tests
+	snapshot      func(ctx context.Context) (*watchSnapshot, error)
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// watchSnapshot is what the watch compares between two polls
+type watchSnapshot struct {
+	state   string
+	message string
+	version string
+	// limitedSupport are the summaries of the limited support reasons, by ID
+	limitedSupport map[string]string
+	// serviceLogs are the service logs posted since the watch started, by ID
+	serviceLogs map[string]clusterHistoryEntry
+}
+
+// watchEvent is a change of the cluster between two polls
+type watchEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+}
+
+func newCmdClusterWatch(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &clusterWatchOptions{out: os.Stdout, GlobalOptions: globalOpts}
+	watchCmd := &cobra.Command{
+		Use:               "watch CLUSTER_ID",
+		Short:             "Prints the state, version, limited support and service log changes of a cluster live",
+		Long:              clusterWatchLongDescription,
+		Example:           clusterWatchExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.clusterID = args[0]
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	watchCmd.Flags().DurationVar(&ops.interval, "interval", 30*time.Second, "Time between two polls of OCM")
+
+	return watchCmd
+}
+
+func (o *clusterWatchOptions) complete(cmd *cobra.Command) error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+	if o.interval < time.Second {
+		return cmdutil.UsageErrorf(cmd, "--interval must be at least 1s")
+	}
+	if output := o.GlobalOptions.Output; output != "" && output != "json" {
+		return cmdutil.UsageErrorf(cmd, "the events can only be printed as text or json, not '%s'", output)
+	}
+	return nil
+}
+
+func (o *clusterWatchOptions) run() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	cluster, err := utils.GetCluster(connection, o.clusterID)
+	if err != nil {
+		return err
+	}
+	start := time.Now().UTC()
+	o.snapshot = func(ctx context.Context) (*watchSnapshot, error) {
+		return readWatchSnapshot(ctx, connection, cluster.ID(), cluster.ExternalID(), start)
+	}
+	fmt.Fprintf(os.Stderr, "Watching cluster %s (%s) every %s, interrupt with Ctrl-C\n", cluster.Name(), cluster.ID(), o.interval)
+	return o.watch(deadline.Context())
+}
+
+// watch polls the cluster and prints its changes until interrupted, a failed poll doesn't stop it
+func (o *clusterWatchOptions) watch(ctx context.Context) error {
+	var last *watchSnapshot
+	err := poll.Until(ctx, poll.Options{Description: "the changes of the cluster", Interval: o.interval, Progress: io.Discard},
+		func(ctx context.Context) (bool, string, error) {
+			current, err := o.snapshot(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return false, "", err
+				}
+				fmt.Fprintf(os.Stderr, "Warning: cannot poll the cluster, retrying in %s: %v\n", o.interval, err)
+				return false, "", nil
+			}
+			events := watchEvents(last, current, time.Now().UTC())
+			last = current
+			return false, "", o.print(events)
+		})
+	// Interrupting the watch is the way to stop it
+	if errors.Is(err, poll.ErrInterrupted) {
+		return nil
+	}
+	return err
+}
+
+func (o *clusterWatchOptions) print(events []watchEvent) error {
+	encoder := json.NewEncoder(o.out)
+	// Keep the arrows of the changes readable
+	encoder.SetEscapeHTML(false)
+	for _, event := range events {
+		if o.GlobalOptions.Output == "json" {
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(o.out, "%s  %-16s %s\n", timefmt.Format(event.Timestamp), event.Kind, event.Message)
+	}
+	return nil
+}
+
+// watchEvents returns the changes from the previous snapshot, or the current state for the first one
+func watchEvents(previous, current *watchSnapshot, now time.Time) []watchEvent {
+	var events []watchEvent
+	event := func(kind, format string, args ...interface{}) {
+		events = append(events, watchEvent{Timestamp: now, Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if previous == nil {
+		state := current.state
+		if current.message != "" {
+			state += ": " + current.message
+		}
+		event(watchEventState, "%s", state)
+		event(watchEventVersion, "%s", current.version)
+		for _, id := range sortedKeys(current.limitedSupport) {
+			event(watchEventLimitedSupport, "in place: %s (%s)", current.limitedSupport[id], id)
+		}
+		previous = &watchSnapshot{state: current.state, message: current.message, version: current.version, limitedSupport: current.limitedSupport}
+	}
+
+	if current.state != previous.state {
+		event(watchEventState, "%s -> %s", previous.state, current.state)
+	}
+	if current.message != previous.message && current.message != "" {
+		event(watchEventState, "%s", current.message)
+	}
+	if current.version != previous.version {
+		event(watchEventVersion, "%s -> %s", previous.version, current.version)
+	}
+	for _, id := range sortedKeys(current.limitedSupport) {
+		if _, ok := previous.limitedSupport[id]; !ok {
+			event(watchEventLimitedSupport, "posted: %s (%s)", current.limitedSupport[id], id)
+		}
+	}
+	for _, id := range sortedKeys(previous.limitedSupport) {
+		if _, ok := current.limitedSupport[id]; !ok {
+			event(watchEventLimitedSupport, "removed: %s (%s)", previous.limitedSupport[id], id)
+		}
+	}
+
+	var logs []clusterHistoryEntry
+	for id, entry := range current.serviceLogs {
+		if _, ok := previous.serviceLogs[id]; !ok {
+			logs = append(logs, entry)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Timestamp.Before(logs[j].Timestamp) })
+	for _, entry := range logs {
+		severity := entry.Severity
+		if entry.InternalOnly {
+			severity += ", internal"
+		}
+		event(watchEventServiceLog, "%s (%s, by %s)", entry.Summary, severity, entry.Actor)
+	}
+	return events
+}
+
+// readWatchSnapshot reads the cluster, its limited support reasons and the service logs posted since the watch started
+func readWatchSnapshot(ctx context.Context, connection *sdk.Connection, clusterID, externalID string, since time.Time) (*watchSnapshot, error) {
+	clusterClient := connection.ClustersMgmt().V1().Clusters().Cluster(clusterID)
+	response, err := clusterClient.Get().SendContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get cluster %s: %w", clusterID, err)
+	}
+	cluster := response.Body()
+	snapshot := &watchSnapshot{
+		state:          string(cluster.State()),
+		message:        cluster.Status().ProvisionErrorMessage(),
+		version:        cluster.OpenshiftVersion(),
+		limitedSupport: map[string]string{},
+		serviceLogs:    map[string]clusterHistoryEntry{},
+	}
+
+	reasons, err := clusterClient.LimitedSupportReasons().List().SendContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the limited support reasons of cluster %s: %w", clusterID, err)
+	}
+	for _, reason := range reasons.Items().Slice() {
+		snapshot.limitedSupport[reason.ID()] = reason.Summary()
+	}
+
+	filter := clusterHistoryFilter{clusterID: clusterID, externalID: externalID, since: &since}
+	logs, err := connection.ServiceLogs().V1().ClusterLogs().List().Search(filter.search()).Size(clusterHistoryPageSize).SendContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the service logs of cluster %s: %w", clusterID, err)
+	}
+	for _, entry := range logs.Items().Slice() {
+		snapshot.serviceLogs[entry.ID()] = historyEntry(entry)
+	}
+	return snapshot, nil
+}
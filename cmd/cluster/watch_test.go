@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+)
+
+func TestWatchEvents(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	first := &watchSnapshot{state: "ready", version: "4.14.5", limitedSupport: map[string]string{"ls1": "Cluster admin misuse"}}
+	events := watchEvents(nil, first, now)
+	g.Expect(events).To(Equal([]watchEvent{
+		{Timestamp: now, Kind: watchEventState, Message: "ready"},
+		{Timestamp: now, Kind: watchEventVersion, Message: "4.14.5"},
+		{Timestamp: now, Kind: watchEventLimitedSupport, Message: "in place: Cluster admin misuse (ls1)"},
+	}))
+	g.Expect(watchEvents(first, first, now)).To(BeEmpty())
+
+	second := &watchSnapshot{
+		state:          "updating",
+		version:        "4.14.6",
+		limitedSupport: map[string]string{"ls2": "Monitoring disabled"},
+		serviceLogs: map[string]clusterHistoryEntry{
+			"b": {Timestamp: now.Add(-time.Minute), Severity: "Info", Actor: "jdoe", Summary: "Removed from limited support", InternalOnly: true},
+			"a": {Timestamp: now.Add(-2 * time.Minute), Severity: "Warning", Actor: "jdoe", Summary: "Monitoring disabled"},
+		},
+	}
+	var messages []string
+	for _, event := range watchEvents(first, second, now) {
+		messages = append(messages, event.Kind+": "+event.Message)
+	}
+	g.Expect(messages).To(Equal([]string{
+		"state: ready -> updating",
+		"version: 4.14.5 -> 4.14.6",
+		"limited support: posted: Monitoring disabled (ls2)",
+		"limited support: removed: Cluster admin misuse (ls1)",
+		"service log: Monitoring disabled (Warning, by jdoe)",
+		"service log: Removed from limited support (Info, internal, by jdoe)",
+	}))
+}
+
+func TestWatchPrintsUntilInterrupted(t *testing.T) {
+	g := NewGomegaWithT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	polls := 0
+	var out bytes.Buffer
+	o := &clusterWatchOptions{
+		interval:      time.Millisecond,
+		out:           &out,
+		GlobalOptions: &globalflags.GlobalOptions{Output: "json"},
+		snapshot: func(ctx context.Context) (*watchSnapshot, error) {
+			polls++
+			switch polls {
+			case 1:
+				return &watchSnapshot{state: "installing", version: "4.15.0"}, nil
+			case 2:
+				return nil, errors.New("503 Service Unavailable")
+			default:
+				cancel()
+				return &watchSnapshot{state: "ready", version: "4.15.0"}, nil
+			}
+		},
+	}
+
+	g.Expect(o.watch(ctx)).To(Succeed())
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	g.Expect(lines).To(HaveLen(3))
+	g.Expect(lines[0]).To(ContainSubstring(`"kind":"state","message":"installing"`))
+	g.Expect(lines[2]).To(ContainSubstring(`"kind":"state","message":"installing -> ready"`))
+}
@@ -9,6 +9,7 @@ import (
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -26,8 +27,8 @@ func newCmdListResources(streams genericclioptions.IOStreams, flags *genericclio
 		Short:             "List all resources on a hive cluster related to a given cluster",
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(l.complete(cmd, args))
-			cmdutil.CheckErr(l.RunListResources())
+			osdctlErrors.CheckErr(l.complete(cmd, args))
+			osdctlErrors.CheckErr(l.RunListResources())
 		},
 	}
 	lrCmd.Flags().StringVarP(&l.ClusterId, "cluster-id", "C", "", "Cluster ID")
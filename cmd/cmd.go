@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"strings"
@@ -17,20 +18,47 @@ import (
 
 	"github.com/openshift/osdctl/cmd/aao"
 	"github.com/openshift/osdctl/cmd/account"
+	"github.com/openshift/osdctl/cmd/auth"
+	"github.com/openshift/osdctl/cmd/aws"
 	"github.com/openshift/osdctl/cmd/capability"
+	"github.com/openshift/osdctl/cmd/chatops"
 	"github.com/openshift/osdctl/cmd/cluster"
 	"github.com/openshift/osdctl/cmd/clusterdeployment"
 	"github.com/openshift/osdctl/cmd/cost"
+	"github.com/openshift/osdctl/cmd/dashboard"
+	"github.com/openshift/osdctl/cmd/doctor"
 	"github.com/openshift/osdctl/cmd/env"
 	"github.com/openshift/osdctl/cmd/federatedrole"
+	"github.com/openshift/osdctl/cmd/fleet"
+	historycmd "github.com/openshift/osdctl/cmd/history"
 	"github.com/openshift/osdctl/cmd/jumphost"
 	"github.com/openshift/osdctl/cmd/network"
+	notescmd "github.com/openshift/osdctl/cmd/notes"
+	"github.com/openshift/osdctl/cmd/ocm"
 	"github.com/openshift/osdctl/cmd/org"
+	"github.com/openshift/osdctl/cmd/promote"
+	"github.com/openshift/osdctl/cmd/secrets"
 	"github.com/openshift/osdctl/cmd/servicelog"
 	"github.com/openshift/osdctl/cmd/sts"
+	"github.com/openshift/osdctl/cmd/template"
+	"github.com/openshift/osdctl/cmd/whoami"
+	workflowcmd "github.com/openshift/osdctl/cmd/workflow"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/fips"
+	"github.com/openshift/osdctl/pkg/guardrails"
+	"github.com/openshift/osdctl/pkg/history"
 	"github.com/openshift/osdctl/pkg/k8s"
+	"github.com/openshift/osdctl/pkg/logging"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/scopes"
+	"github.com/openshift/osdctl/pkg/telemetry"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/trace"
 	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/openshift/osdctl/pkg/visibility"
 )
 
 func init() {
@@ -48,7 +76,26 @@ func NewCmdRoot(streams genericclioptions.IOStreams) *cobra.Command {
 		Short:             "OSD CLI",
 		Long:              `CLI tool to provide OSD related utilities`,
 		DisableAutoGenTag: true,
+		// main prints the errors, as JSON with --output json
+		SilenceErrors: true,
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			// The shell completion doesn't go through the help, hide the commands the OCM roles don't allow here
+			visibility.HideForCompletion(cmd, args)
+			// --filter implies -o json, which the errors are then printed as
+			if err := printer.SetupFilter(cmd); err != nil {
+				osdctlErrors.CheckErr(err)
+			}
+			osdctlErrors.SetOutputFormat(globalOpts.Output)
+			timefmt.SetOutputFormat(globalOpts.Output)
+			osdctlErrors.CheckErr(logging.Setup(cmd))
+			osdctlErrors.CheckErr(printer.OpenOutputFile(cmd))
+			osdctlErrors.CheckErr(trace.Open())
+			osdctlErrors.CheckErr(recording.Open())
+			// 'osdctl version --crypto' is how to find out why
+			if cmd != versionCmd {
+				osdctlErrors.CheckErr(fips.Require())
+			}
+
 			skipVersionCheck, err := cmd.Flags().GetBool("skip-version-check")
 			if err != nil {
 				fmt.Println("flag --skip-version-check/-S undefined")
@@ -57,8 +104,20 @@ func NewCmdRoot(streams genericclioptions.IOStreams) *cobra.Command {
 
 			// Checks the skipVersionCheck flag and the command being run to determine if the version check should run
 			if shouldRunVersionCheck(skipVersionCheck, cmd.Use) {
-				versionCheck()
+				versionCheck(streams)
 			}
+
+			// --timeout and Ctrl-C cancel the OCM, AWS and Kubernetes requests in flight
+			deadline.Start()
+
+			// Only records anything if the user opted in via the config file
+			telemetry.Start(cmd)
+			// Recorded locally unless disabled in the config file, so that the command can be replayed
+			history.Start(cmd, args, os.Args[1:])
+
+			osdctlErrors.CheckErr(guardrails.Check(cmd, args))
+			// The profiles written by 'osdctl auth mint-token' only allow the commands of their scopes
+			osdctlErrors.CheckErr(scopes.Check(cmd))
 		},
 	}
 
@@ -70,15 +129,28 @@ func NewCmdRoot(streams genericclioptions.IOStreams) *cobra.Command {
 	// add sub commands
 	rootCmd.AddCommand(aao.NewCmdAao(streams, kubeFlags))
 	rootCmd.AddCommand(account.NewCmdAccount(streams, kubeFlags, kubeClient, globalOpts))
+	rootCmd.AddCommand(auth.NewCmdAuth())
+	rootCmd.AddCommand(aws.NewCmdAws(globalOpts))
 	rootCmd.AddCommand(cluster.NewCmdCluster(streams, kubeFlags, kubeClient, globalOpts))
+	rootCmd.AddCommand(cluster.NewCmdApply(globalOpts))
 	rootCmd.AddCommand(clusterdeployment.NewCmdClusterDeployment(streams, kubeFlags, kubeClient))
+	rootCmd.AddCommand(dashboard.NewCmdDashboard())
 	rootCmd.AddCommand(env.NewCmdEnv(streams, kubeFlags))
+	rootCmd.AddCommand(fleet.NewCmdFleet(globalOpts))
 	rootCmd.AddCommand(federatedrole.NewCmdFederatedRole(streams, kubeFlags, kubeClient))
 	rootCmd.AddCommand(jumphost.NewCmdJumphost())
 	rootCmd.AddCommand(network.NewCmdNetwork(streams, kubeFlags, kubeClient))
-	rootCmd.AddCommand(servicelog.NewCmdServiceLog())
+	rootCmd.AddCommand(servicelog.NewCmdServiceLog(globalOpts))
 	rootCmd.AddCommand(org.NewCmdOrg())
+	rootCmd.AddCommand(ocm.NewCmdOcm(streams, globalOpts))
+	rootCmd.AddCommand(promote.NewCmdPromote())
+	rootCmd.AddCommand(secrets.NewCmdSecrets())
 	rootCmd.AddCommand(sts.NewCmdSts(streams, kubeFlags, kubeClient))
+	rootCmd.AddCommand(template.NewCmdTemplate())
+
+	// add the get and describe verbs, running the commands of the resources kubectl-style
+	rootCmd.AddCommand(newCmdGet(streams, kubeFlags, kubeClient, globalOpts))
+	rootCmd.AddCommand(newCmdDescribe(globalOpts))
 
 	// add docs command
 	rootCmd.AddCommand(newCmdDocs(streams))
@@ -89,6 +161,15 @@ func NewCmdRoot(streams genericclioptions.IOStreams) *cobra.Command {
 	// add options command to list global flags
 	rootCmd.AddCommand(newCmdOptions(streams))
 
+	// add api-docs command to print the catalog of the commands for automation
+	rootCmd.AddCommand(newCmdAPIDocs(globalOpts))
+
+	// add plugin command to list the out-of-tree subcommands
+	rootCmd.AddCommand(newCmdPlugin(streams))
+
+	// add alias command to list the aliases of the config file
+	rootCmd.AddCommand(newCmdAlias(streams))
+
 	// Add cost command to use AWS Cost Manager
 	rootCmd.AddCommand(cost.NewCmdCost(streams, globalOpts))
 
@@ -100,6 +181,15 @@ func NewCmdRoot(streams genericclioptions.IOStreams) *cobra.Command {
 	rootCmd.AddCommand(upgradeCmd)
 
 	rootCmd.AddCommand(capability.NewCmdCapability())
+	rootCmd.AddCommand(whoami.NewCmdWhoami(globalOpts))
+	rootCmd.AddCommand(doctor.NewCmdDoctor(globalOpts))
+	rootCmd.AddCommand(historycmd.NewCmdHistory(globalOpts))
+	rootCmd.AddCommand(notescmd.NewCmdNotes(globalOpts))
+	rootCmd.AddCommand(workflowcmd.NewCmdWorkflow(globalOpts))
+	rootCmd.AddCommand(chatops.NewCmdServeChatops(globalOpts))
+
+	// Hide the commands the OCM roles of the user don't allow from the help
+	visibility.Install(rootCmd)
 
 	return rootCmd
 }
@@ -125,30 +215,35 @@ func canCommandSkipVersionCheck(commandName string) bool {
 
 // Returns allowlist of commands that can skip version check
 func getSkipVersionCommands() []string {
-	return []string{"upgrade", "version"}
+	return []string{"upgrade", "version", "doctor", "api-docs"}
 }
 
-func versionCheck() {
+func versionCheck(streams genericclioptions.IOStreams) {
 	latestVersion, err := utils.GetLatestVersion()
 	if err != nil {
-		fmt.Println("Warning: Unable to verify that osdctl is running under the latest released version. Error trying to reach GitHub:")
-		fmt.Println(err)
-		fmt.Println("Please be aware that you are possibly running an outdated or unreleased version.")
+		fmt.Fprintln(streams.ErrOut, "Warning: Unable to verify that osdctl is running under the latest released version. Error trying to reach GitHub:")
+		fmt.Fprintln(streams.ErrOut, err)
+		fmt.Fprintln(streams.ErrOut, "Please be aware that you are possibly running an outdated or unreleased version.")
 	}
 
 	if utils.Version != strings.TrimPrefix(latestVersion, "v") {
-		fmt.Printf("The current version (%s) is different than the latest released version (%s).", utils.Version, latestVersion)
-		fmt.Println("It is recommended that you update to the latest released version to ensure that no known bugs or issues are hit.")
-		fmt.Println("Please confirm that you would like to continue with [y|n]")
+		fmt.Fprintf(streams.ErrOut, "The current version (%s) is different than the latest released version (%s).", utils.Version, latestVersion)
+		fmt.Fprintln(streams.ErrOut, "It is recommended that you update to the latest released version to ensure that no known bugs or issues are hit.")
+		fmt.Fprintln(streams.ErrOut, "Please confirm that you would like to continue with [y|n]")
 
-		var input string
+		reader := bufio.NewReader(streams.In)
 		for {
-			fmt.Scanln(&input)
+			answer, err := utils.ReadAnswer(reader)
+			// A forgotten prompt, or the end of the input, exits instead of waiting forever
+			if err != nil && answer == "" {
+				osdctlErrors.CheckErr(err)
+			}
+			input := strings.TrimSpace(answer)
 			if strings.ToLower(input) == "y" {
 				break
 			}
 			if strings.ToLower(input) == "n" {
-				fmt.Println("Exiting")
+				fmt.Fprintln(streams.ErrOut, "Exiting")
 				os.Exit(0)
 			}
 		}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/templates"
@@ -50,7 +51,7 @@ func newCmdCompletion(streams genericclioptions.IOStreams) *cobra.Command {
 		DisableAutoGenTag:     true,
 		DisableFlagsInUseLine: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.run(cmd, args))
+			osdctlErrors.CheckErr(ops.run(cmd, args))
 		},
 	}
 
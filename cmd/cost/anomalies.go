@@ -0,0 +1,208 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const costDateFormat = "2006-01-02"
+
+// anomaliesCmd represents the anomalies command
+func newCmdAnomalies(streams genericclioptions.IOStreams, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := newAnomaliesOptions(streams, globalOpts)
+	anomaliesCmd := &cobra.Command{
+		Use:   "anomalies",
+		Short: "Flag the accounts under an OU whose daily spend suddenly spiked",
+		Long: `Compares the daily spend of every account under the OU with its average spend of the previous
+--baseline-days days, and flags the days where the spend reached --threshold times that baseline and grew by at
+least --min-increase. The report is markdown that can be pasted in Slack, or JSON with '-o json'.`,
+		Example: `
+  # Spikes of the last 30 days
+  osdctl cost anomalies --ou ou-0000-00000000 --lookback 30d
+
+  # Only large spikes, as JSON
+  osdctl cost anomalies --ou ou-0000-00000000 --lookback 14d --threshold 3 --min-increase 500 -o json`,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.checkArgs(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	anomaliesCmd.Flags().StringVar(&ops.ou, "ou", "", "set OU ID")
+	flagtypes.DurationVar(anomaliesCmd.Flags(), &ops.lookback, "lookback", 30*24*time.Hour, 24*time.Hour, "How far back to look for spikes, e.g. 30d or 72h")
+	anomaliesCmd.Flags().IntVar(&ops.baselineDays, "baseline-days", 7, "Number of previous days the daily spend is compared against")
+	anomaliesCmd.Flags().Float64Var(&ops.threshold, "threshold", 2, "Flag days whose spend is at least this many times the baseline")
+	anomaliesCmd.Flags().Float64Var(&ops.minIncrease, "min-increase", 50, "Ignore spikes adding less than this amount to the baseline")
+
+	return anomaliesCmd
+}
+
+// Store flag options for anomalies command
+type anomaliesOptions struct {
+	ou           string
+	lookback     time.Duration
+	lookbackDays int
+	baselineDays int
+	threshold    float64
+	minIncrease  float64
+	output       string
+
+	genericclioptions.IOStreams
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+func newAnomaliesOptions(streams genericclioptions.IOStreams, globalOpts *globalflags.GlobalOptions) *anomaliesOptions {
+	return &anomaliesOptions{
+		IOStreams:     streams,
+		GlobalOptions: globalOpts,
+	}
+}
+
+func (o *anomaliesOptions) checkArgs(cmd *cobra.Command, _ []string) error {
+	if o.ou == "" {
+		return cmdutil.UsageErrorf(cmd, "Please provide OU")
+	}
+	o.lookbackDays = int(o.lookback.Hours() / 24)
+	if o.baselineDays < 1 {
+		return cmdutil.UsageErrorf(cmd, "--baseline-days must be at least 1")
+	}
+	if o.threshold <= 1 {
+		return cmdutil.UsageErrorf(cmd, "--threshold must be greater than 1")
+	}
+
+	if o.GlobalOptions != nil {
+		o.output = o.GlobalOptions.Output
+	}
+	return nil
+}
+
+type costAnomaly struct {
+	AccountID string          `json:"accountId" yaml:"accountId"`
+	Date      string          `json:"date" yaml:"date"`
+	Cost      decimal.Decimal `json:"cost" yaml:"cost"`
+	Baseline  decimal.Decimal `json:"baseline" yaml:"baseline"`
+	Increase  decimal.Decimal `json:"increase" yaml:"increase"`
+	Unit      string          `json:"unit" yaml:"unit"`
+}
+
+type costAnomaliesResponse struct {
+	OuId      string        `json:"ouid" yaml:"ouid"`
+	OuName    string        `json:"ouname" yaml:"ouname"`
+	Start     string        `json:"start" yaml:"start"`
+	End       string        `json:"end" yaml:"end"`
+	Anomalies []costAnomaly `json:"anomalies" yaml:"anomalies"`
+}
+
+// String renders the anomalies as markdown that Slack understands, which doesn't include tables
+func (f costAnomaliesResponse) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Cost anomalies in %s (%s) from %s to %s*\n", f.OuName, f.OuId, f.Start, f.End)
+	if len(f.Anomalies) == 0 {
+		b.WriteString("No account spiked.\n")
+		return b.String()
+	}
+
+	accounts := map[string]bool{}
+	for _, a := range f.Anomalies {
+		accounts[a.AccountID] = true
+	}
+	fmt.Fprintf(&b, "%d accounts spiked:\n", len(accounts))
+	for _, a := range f.Anomalies {
+		ratio := "new spend"
+		if a.Baseline.IsPositive() {
+			ratio = a.Cost.Div(a.Baseline).StringFixed(1) + "x"
+		}
+		fmt.Fprintf(&b, "- `%s` on %s: %s %s vs %s %s baseline (+%s %s, %s)\n", a.AccountID, a.Date,
+			a.Cost.StringFixed(2), a.Unit, a.Baseline.StringFixed(2), a.Unit, a.Increase.StringFixed(2), a.Unit, ratio)
+	}
+	return b.String()
+}
+
+func (o *anomaliesOptions) run() error {
+	awsClient, err := opsCost.initAWSClients()
+	if err != nil {
+		return err
+	}
+
+	OU, err := getOU(awsClient, o.ou)
+	if err != nil {
+		return err
+	}
+	accounts, err := getAccountsRecursive(OU, awsClient)
+	if err != nil {
+		return err
+	}
+
+	// The baseline of the first day of the lookback needs the days before it
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	start := end.AddDate(0, 0, -(o.lookbackDays + o.baselineDays))
+	resp := costAnomaliesResponse{
+		OuId:   *OU.Id,
+		OuName: *OU.Name,
+		Start:  end.AddDate(0, 0, -o.lookbackDays).Format(costDateFormat),
+		End:    end.Format(costDateFormat),
+	}
+
+	if len(accounts) > 0 {
+		costs, unit, err := opsCost.costExplorer(awsClient).dailyCosts(accounts, start, end)
+		if err != nil {
+			return err
+		}
+		resp.Anomalies = detectCostAnomalies(costs, unit, start, o.lookbackDays+o.baselineDays, o.baselineDays,
+			decimal.NewFromFloat(o.threshold), decimal.NewFromFloat(o.minIncrease))
+	}
+
+	return outputflag.PrintResponse(o.output, resp)
+}
+
+// detectCostAnomalies flags the days after the first baselineDays days whose cost reached threshold times the average
+// of the previous baselineDays days and grew by at least minIncrease. Days without cost count as zero.
+func detectCostAnomalies(costs map[string]map[string]decimal.Decimal, unit string, start time.Time, days, baselineDays int, threshold, minIncrease decimal.Decimal) []costAnomaly {
+	anomalies := []costAnomaly{}
+	for account, daily := range costs {
+		series := make([]decimal.Decimal, days)
+		for i := range series {
+			series[i] = daily[start.AddDate(0, 0, i).Format(costDateFormat)]
+		}
+
+		for i := baselineDays; i < days; i++ {
+			sum := decimal.Zero
+			for _, cost := range series[i-baselineDays : i] {
+				sum = sum.Add(cost)
+			}
+			baseline := sum.Div(decimal.NewFromInt(int64(baselineDays)))
+			increase := series[i].Sub(baseline)
+			if series[i].LessThan(baseline.Mul(threshold)) || increase.LessThan(minIncrease) {
+				continue
+			}
+			anomalies = append(anomalies, costAnomaly{
+				AccountID: account,
+				Date:      start.AddDate(0, 0, i).Format(costDateFormat),
+				Cost:      series[i],
+				Baseline:  baseline.Round(2),
+				Increase:  increase.Round(2),
+				Unit:      unit,
+			})
+		}
+	}
+
+	// Largest spikes first
+	sort.Slice(anomalies, func(i, j int) bool {
+		if !anomalies[i].Increase.Equal(anomalies[j].Increase) {
+			return anomalies[j].Increase.LessThan(anomalies[i].Increase)
+		}
+		return anomalies[i].AccountID+anomalies[i].Date < anomalies[j].AccountID+anomalies[j].Date
+	})
+	return anomalies
+}
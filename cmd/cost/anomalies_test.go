@@ -0,0 +1,102 @@
+package cost
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/shopspring/decimal"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestLookbackFlag(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	flags := newCmdAnomalies(genericclioptions.IOStreams{}, nil).Flags()
+	g.Expect(flags.Lookup("lookback").Value.String()).To(gomega.Equal("30d"))
+
+	g.Expect(flags.Set("lookback", "72h")).To(gomega.Succeed())
+	g.Expect(flags.Lookup("lookback").Value.String()).To(gomega.Equal("3d"))
+
+	for _, invalid := range []string{"0d", "1h", "month", "xd"} {
+		g.Expect(flags.Set("lookback", invalid)).NotTo(gomega.Succeed(), invalid)
+	}
+}
+
+func TestDetectCostAnomalies(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	daily := func(amounts ...int64) map[string]decimal.Decimal {
+		costs := map[string]decimal.Decimal{}
+		for i, amount := range amounts {
+			costs[start.AddDate(0, 0, i).Format(costDateFormat)] = decimal.NewFromInt(amount)
+		}
+		return costs
+	}
+	costs := map[string]map[string]decimal.Decimal{
+		// Spikes on the 4th day
+		"111111111111": daily(100, 100, 100, 400, 100),
+		// Doubles, but by less than the minimum increase
+		"222222222222": daily(10, 10, 10, 30, 10),
+		// Grows steadily
+		"333333333333": daily(100, 110, 120, 130, 140),
+	}
+
+	anomalies := detectCostAnomalies(costs, "USD", start, 5, 3, decimal.NewFromInt(2), decimal.NewFromInt(50))
+	g.Expect(anomalies).To(gomega.HaveLen(1))
+	g.Expect(anomalies[0].AccountID).To(gomega.Equal("111111111111"))
+	g.Expect(anomalies[0].Date).To(gomega.Equal("2026-10-04"))
+	g.Expect(anomalies[0].Baseline.String()).To(gomega.Equal("100"))
+	g.Expect(anomalies[0].Increase.String()).To(gomega.Equal("300"))
+
+	resp := costAnomaliesResponse{OuId: "ou-0000-00000000", OuName: "Test OU", Anomalies: anomalies}
+	g.Expect(resp.String()).To(gomega.ContainSubstring("- `111111111111` on 2026-10-04: 400.00 USD vs 100.00 USD baseline (+300.00 USD, 4.0x)"))
+}
+
+func TestAnomaliesRunWithInjectedClient(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+	opsCost = newCostOptions(streams)
+	opsCost.awsClient = mockAWSClient
+	defer func() { opsCost = nil }()
+	useTestCostCache(t)
+
+	mockAWSClient.EXPECT().DescribeOrganizationalUnit(gomock.Any()).Return(&organizations.DescribeOrganizationalUnitOutput{
+		OrganizationalUnit: &organizations.OrganizationalUnit{Id: aws.String("ou-0000-00000000"), Name: aws.String("Test OU")},
+	}, nil)
+	mockAWSClient.EXPECT().ListOrganizationalUnitsForParent(gomock.Any()).Return(&organizations.ListOrganizationalUnitsForParentOutput{}, nil)
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{{Id: aws.String("111111111111")}},
+	}, nil)
+	// Results are paginated
+	mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).Return(&costexplorer.GetCostAndUsageOutput{
+		NextPageToken: aws.String("next"),
+		ResultsByTime: []*costexplorer.ResultByTime{{
+			TimePeriod: &costexplorer.DateInterval{Start: aws.String("2026-10-01")},
+			Groups: []*costexplorer.Group{{
+				Keys:    aws.StringSlice([]string{"111111111111"}),
+				Metrics: map[string]*costexplorer.MetricValue{"NetUnblendedCost": {Amount: aws.String("12.5"), Unit: aws.String("USD")}},
+			}},
+		}},
+	}, nil)
+	mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).Return(&costexplorer.GetCostAndUsageOutput{}, nil)
+
+	o := newAnomaliesOptions(streams, nil)
+	o.ou = "ou-0000-00000000"
+	o.lookbackDays = 30
+	o.baselineDays = 7
+	o.threshold = 2
+	o.output = "json"
+	g.Expect(o.run()).To(gomega.Succeed())
+}
@@ -1,7 +1,7 @@
 package cost
 
 import (
-	"log"
+	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/organizations"
@@ -28,12 +28,14 @@ platforms may be added in the future)`,
 	costCmd.PersistentFlags().StringVarP(&opsCost.profile, "aws-profile", "p", "", "specify AWS profile")
 	costCmd.PersistentFlags().StringVarP(&opsCost.configFile, "aws-config", "c", "", "specify AWS config file path")
 	costCmd.PersistentFlags().StringVarP(&opsCost.region, "aws-region", "g", common.DefaultRegion, "specify AWS region")
+	costCmd.PersistentFlags().BoolVar(&opsCost.noCache, "no-cache", false, "Query all the costs from Cost Explorer instead of reading the settled daily costs cached locally")
 
 	//Add commands
 	costCmd.AddCommand(newCmdGet(streams, globalOpts))
 	costCmd.AddCommand(newCmdReconcile(streams))
 	costCmd.AddCommand(newCmdCreate(streams))
 	costCmd.AddCommand(newCmdList(streams, globalOpts))
+	costCmd.AddCommand(newCmdAnomalies(streams, globalOpts))
 
 	return costCmd
 }
@@ -49,6 +51,11 @@ type costOptions struct {
 	profile         string
 	region          string
 
+	// awsClient is only created from the flags when it isn't injected, e.g. by tests
+	awsClient awsprovider.Client
+	noCache   bool
+	explorer  *costExplorer
+
 	genericclioptions.IOStreams
 }
 
@@ -60,6 +67,10 @@ func newCostOptions(streams genericclioptions.IOStreams) *costOptions {
 
 // Initiate AWS clients for Organizations and Cost Explorer services using, if given, credentials in flags, else, credentials in the environment
 func (opsCost *costOptions) initAWSClients() (awsprovider.Client, error) {
+	if opsCost.awsClient != nil {
+		return opsCost.awsClient, nil
+	}
+
 	//Initialize AWS clients
 	var (
 		awsClient awsprovider.Client
@@ -79,17 +90,27 @@ func (opsCost *costOptions) initAWSClients() (awsprovider.Client, error) {
 		return nil, err
 	}
 
+	opsCost.awsClient = awsClient
 	return awsClient, err
 }
 
+// costExplorer returns the Cost Explorer client shared by the cost lookups of the command, so that their requests
+// are throttled together and the costs queried once
+func (opsCost *costOptions) costExplorer(awsClient awsprovider.Client) *costExplorer {
+	if opsCost.explorer == nil || opsCost.explorer.client != awsClient {
+		opsCost.explorer = newCostExplorer(awsClient, !opsCost.noCache)
+	}
+	return opsCost.explorer
+}
+
 // Gets information regarding Organizational Unit
-func getOU(org awsprovider.Client, OUid string) *organizations.OrganizationalUnit {
+func getOU(org awsprovider.Client, OUid string) (*organizations.OrganizationalUnit, error) {
 	result, err := org.DescribeOrganizationalUnit(&organizations.DescribeOrganizationalUnitInput{
 		OrganizationalUnitId: aws.String(OUid),
 	})
 	if err != nil {
-		log.Fatalln("Cannot get Organizational Unit:", err)
+		return nil, fmt.Errorf("cannot get Organizational Unit: %w", err)
 	}
 
-	return result.OrganizationalUnit
+	return result.OrganizationalUnit, nil
 }
@@ -2,14 +2,13 @@ package cost
 
 import (
 	"fmt"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/costexplorer"
 	"github.com/aws/aws-sdk-go/service/organizations"
-	"log"
 
 	"github.com/spf13/cobra"
 )
@@ -22,26 +21,23 @@ func newCmdCreate(streams genericclioptions.IOStreams) *cobra.Command {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			awsClient, err := opsCost.initAWSClients()
-			cmdutil.CheckErr(err)
+			osdctlErrors.CheckErr(err)
 
 			//OU Flag
 			OUid, err := cmd.Flags().GetString("ou")
-			if err != nil {
-				log.Fatalln("OU flag:", err)
-			}
+			osdctlErrors.CheckErr(err)
 
 			//Get information regarding Organizational Unit
-			OU := getOU(awsClient, OUid)
+			OU, err := getOU(awsClient, OUid)
+			osdctlErrors.CheckErr(err)
 
 			if err := createCostCategory(&OUid, OU, awsClient); err != nil {
-				log.Fatalf("Error creating cost category for %s: %v", OUid, err)
+				osdctlErrors.CheckErr(fmt.Errorf("error creating cost category for %s: %w", OUid, err))
 			}
 		},
 	}
 	createCmd.Flags().String("ou", "", "get OU ID")
-	if err := createCmd.MarkFlagRequired("ou"); err != nil {
-		log.Fatalln("OU flag:", err)
-	}
+	_ = createCmd.MarkFlagRequired("ou")
 
 	return createCmd
 }
@@ -0,0 +1,358 @@
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	netUnblendedCost = "NetUnblendedCost"
+
+	// Cost Explorer only allows a few requests per second per payer account, the rate is halved on every throttling
+	// error down to costExplorerMinRate, and grows back on every success
+	costExplorerRate    = 4.0
+	costExplorerMinRate = 0.1
+	costExplorerRetries = 8
+	costExplorerBackoff = time.Second
+	// costExplorerMaxBackoff bounds the wait between two retries
+	costExplorerMaxBackoff = time.Minute
+
+	// costBatchSize is the number of accounts queried by a GetCostAndUsage call, grouped by account
+	costBatchSize = 100
+	// costSettleDays is how long the cost of a day keeps changing, the days before it are cached
+	costSettleDays = 3
+)
+
+var (
+	// Swapped in tests
+	costCacheDir = defaultCostCacheDir
+	costSleep    = time.Sleep
+	costNow      = time.Now
+)
+
+// costExplorer batches the GetCostAndUsage calls of the accounts, waits between them and backs off when throttled,
+// and caches the settled daily costs of every account locally
+type costExplorer struct {
+	client  awsprovider.Client
+	limiter *rate.Limiter
+	// useCache reads and writes the daily costs of the accounts in costCacheDir
+	useCache bool
+	// costs are the daily costs already known, by account and date, for the accounts queried more than once in a run
+	costs map[string]*accountCosts
+}
+
+// accountCosts are the daily costs of an account, as cached
+type accountCosts struct {
+	Unit string                     `json:"unit"`
+	Days map[string]decimal.Decimal `json:"days"`
+}
+
+func newCostExplorer(client awsprovider.Client, useCache bool) *costExplorer {
+	return &costExplorer{
+		client:   client,
+		limiter:  rate.NewLimiter(rate.Limit(costExplorerRate), 1),
+		useCache: useCache,
+		costs:    map[string]*accountCosts{},
+	}
+}
+
+func defaultCostCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "osdctl", "cost"), nil
+}
+
+// accountCosts returns the cost of every account over the period, end excluded, summed up
+func (e *costExplorer) accountCosts(accounts []*string, start, end time.Time) (map[string]decimal.Decimal, string, error) {
+	daily, unit, err := e.dailyCosts(accounts, start, end)
+	if err != nil {
+		return nil, "", err
+	}
+	totals := map[string]decimal.Decimal{}
+	for account, days := range daily {
+		total := decimal.Zero
+		for _, cost := range days {
+			total = total.Add(cost)
+		}
+		totals[account] = total
+	}
+	return totals, unit, nil
+}
+
+// dailyCosts returns the daily cost of every account over the period, end excluded, keyed by account ID and date.
+// Only the days missing from the cache are queried, for up to costBatchSize accounts at once.
+func (e *costExplorer) dailyCosts(accounts []*string, start, end time.Time) (map[string]map[string]decimal.Decimal, string, error) {
+	dates := costDates(start, end)
+	costs := map[string]map[string]decimal.Decimal{}
+	var unit string
+
+	// The accounts missing days, by the first day missing
+	missing := map[string][]string{}
+	for _, account := range aws.StringValueSlice(accounts) {
+		known := e.known(account)
+		for _, date := range dates {
+			if _, ok := known.Days[date]; !ok {
+				missing[date] = append(missing[date], account)
+				break
+			}
+		}
+		if unit == "" {
+			unit = known.Unit
+		}
+	}
+
+	// Accounts missing the same days are queried together
+	var firstMissing []string
+	for date := range missing {
+		firstMissing = append(firstMissing, date)
+	}
+	sort.Strings(firstMissing)
+	for _, date := range firstMissing {
+		from, err := time.Parse(costDateFormat, date)
+		if err != nil {
+			return nil, "", err
+		}
+		pending := missing[date]
+		for len(pending) > 0 {
+			batch := pending
+			if len(batch) > costBatchSize {
+				batch = pending[:costBatchSize]
+			}
+			pending = pending[len(batch):]
+			if err := e.query(batch, from, end); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	for _, account := range aws.StringValueSlice(accounts) {
+		known := e.known(account)
+		costs[account] = map[string]decimal.Decimal{}
+		for _, date := range dates {
+			if cost, ok := known.Days[date]; ok && !cost.IsZero() {
+				costs[account][date] = cost
+			}
+		}
+		if unit == "" {
+			unit = known.Unit
+		}
+	}
+	return costs, unit, nil
+}
+
+// query gets the daily costs of the accounts over the period, end excluded, and records them. Days without costs,
+// which Cost Explorer leaves out, are recorded as zero.
+func (e *costExplorer) query(accounts []string, start, end time.Time) error {
+	log.Debugf("Querying the daily costs of %d accounts from %s to %s", len(accounts), start.Format(costDateFormat), end.Format(costDateFormat))
+	dates := costDates(start, end)
+	for _, account := range accounts {
+		known := e.known(account)
+		for _, date := range dates {
+			known.Days[date] = decimal.Zero
+		}
+	}
+
+	input := &costexplorer.GetCostAndUsageInput{
+		Filter: &costexplorer.Expression{
+			Dimensions: &costexplorer.DimensionValues{
+				Key:    aws.String("LINKED_ACCOUNT"),
+				Values: aws.StringSlice(accounts),
+			},
+		},
+		GroupBy: []*costexplorer.GroupDefinition{{
+			Type: aws.String("DIMENSION"),
+			Key:  aws.String("LINKED_ACCOUNT"),
+		}},
+		TimePeriod: &costexplorer.DateInterval{
+			Start: aws.String(start.Format(costDateFormat)),
+			End:   aws.String(end.Format(costDateFormat)),
+		},
+		Granularity: aws.String("DAILY"),
+		Metrics:     aws.StringSlice([]string{netUnblendedCost}),
+	}
+	for {
+		output, err := e.getCostAndUsage(input)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range output.ResultsByTime {
+			if result.TimePeriod == nil {
+				continue
+			}
+			date := aws.StringValue(result.TimePeriod.Start)
+			for _, group := range result.Groups {
+				metric, ok := group.Metrics[netUnblendedCost]
+				if !ok || len(group.Keys) == 0 {
+					continue
+				}
+				cost, err := decimal.NewFromString(aws.StringValue(metric.Amount))
+				if err != nil {
+					return err
+				}
+				known := e.known(aws.StringValue(group.Keys[0]))
+				known.Days[date] = cost
+				if known.Unit == "" {
+					known.Unit = aws.StringValue(metric.Unit)
+				}
+			}
+		}
+
+		if output.NextPageToken == nil {
+			break
+		}
+		input.NextPageToken = output.NextPageToken
+	}
+
+	for _, account := range accounts {
+		e.save(account)
+	}
+	return nil
+}
+
+// getCostAndUsage sends the request once the limiter allows it, and retries it with an exponential backoff when
+// Cost Explorer throttles it, slowing down the next requests
+func (e *costExplorer) getCostAndUsage(input *costexplorer.GetCostAndUsageInput) (*costexplorer.GetCostAndUsageOutput, error) {
+	backoff := costExplorerBackoff
+	for attempt := 0; ; attempt++ {
+		if err := e.limiter.Wait(context.Background()); err != nil {
+			return nil, err
+		}
+		output, err := e.client.GetCostAndUsage(input)
+		if err == nil {
+			e.speedUp()
+			return output, nil
+		}
+		if !errors.Is(osdctlErrors.Classify(err), osdctlErrors.ErrTransient) || attempt == costExplorerRetries {
+			return nil, err
+		}
+
+		e.slowDown()
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff))) //#nosec G404 -- the jitter doesn't need to be secure
+		log.Debugf("Cost Explorer throttled the request, retrying in %s: %v", wait, err)
+		costSleep(wait)
+		if backoff *= 2; backoff > costExplorerMaxBackoff {
+			backoff = costExplorerMaxBackoff
+		}
+	}
+}
+
+func (e *costExplorer) slowDown() {
+	limit := e.limiter.Limit() / 2
+	if limit < costExplorerMinRate {
+		limit = costExplorerMinRate
+	}
+	e.limiter.SetLimit(limit)
+}
+
+func (e *costExplorer) speedUp() {
+	limit := e.limiter.Limit() * 1.25
+	if limit > costExplorerRate {
+		limit = costExplorerRate
+	}
+	e.limiter.SetLimit(limit)
+}
+
+// known returns the daily costs known for the account, loading them from the cache the first time
+func (e *costExplorer) known(account string) *accountCosts {
+	if known, ok := e.costs[account]; ok {
+		return known
+	}
+	known := &accountCosts{Days: map[string]decimal.Decimal{}}
+	if e.useCache {
+		if err := known.load(account); err != nil {
+			log.Debugf("Ignoring the cached costs of account %s: %v", account, err)
+			known = &accountCosts{Days: map[string]decimal.Decimal{}}
+		}
+	}
+	e.costs[account] = known
+	return known
+}
+
+// save writes the settled daily costs of the account to the cache
+func (e *costExplorer) save(account string) {
+	if !e.useCache {
+		return
+	}
+	known := e.costs[account]
+	settled := &accountCosts{Unit: known.Unit, Days: map[string]decimal.Decimal{}}
+	cutoff := costNow().UTC().AddDate(0, 0, -costSettleDays).Format(costDateFormat)
+	for date, cost := range known.Days {
+		if date < cutoff {
+			settled.Days[date] = cost
+		}
+	}
+	if err := settled.write(account); err != nil {
+		log.Warnf("Unable to cache the costs of account %s: %v", account, err)
+	}
+}
+
+func costCachePath(account string) (string, error) {
+	dir, err := costCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, account+".json"), nil
+}
+
+func (c *accountCosts) load(account string) error {
+	path, err := costCachePath(account)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path) //#nosec G304 -- the path is built from the cache dir and an account ID
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	if c.Days == nil {
+		c.Days = map[string]decimal.Decimal{}
+	}
+	return nil
+}
+
+func (c *accountCosts) write(account string) error {
+	path, err := costCachePath(account)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// costDates returns the dates from start to end, end excluded
+func costDates(start, end time.Time) []string {
+	var dates []string
+	for day := start.UTC().Truncate(24 * time.Hour); day.Before(end); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day.Format(costDateFormat))
+	}
+	return dates
+}
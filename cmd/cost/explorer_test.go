@@ -0,0 +1,130 @@
+package cost
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/golang/mock/gomock"
+	"github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"golang.org/x/time/rate"
+)
+
+// useTestCostCache caches the costs in a temporary directory and doesn't wait between retries
+func useTestCostCache(t *testing.T) {
+	dir := t.TempDir()
+	costCacheDir = func() (string, error) { return dir, nil }
+	costSleep = func(time.Duration) {}
+	costNow = func() time.Time { return time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() {
+		costCacheDir = defaultCostCacheDir
+		costSleep = time.Sleep
+		costNow = time.Now
+	})
+}
+
+// dailyCostOutput returns one day of cost for every account of the request
+func dailyCostOutput(input *costexplorer.GetCostAndUsageInput, amount string) *costexplorer.GetCostAndUsageOutput {
+	result := &costexplorer.ResultByTime{TimePeriod: &costexplorer.DateInterval{Start: input.TimePeriod.Start}}
+	for _, account := range input.Filter.Dimensions.Values {
+		result.Groups = append(result.Groups, &costexplorer.Group{
+			Keys:    []*string{account},
+			Metrics: map[string]*costexplorer.MetricValue{"NetUnblendedCost": {Amount: aws.String(amount), Unit: aws.String("USD")}},
+		})
+	}
+	return &costexplorer.GetCostAndUsageOutput{ResultsByTime: []*costexplorer.ResultByTime{result}}
+}
+
+func TestCostExplorerBatchesAndCaches(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	useTestCostCache(t)
+	mockCtrl := gomock.NewController(t)
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	var accounts []*string
+	for i := 0; i < costBatchSize+20; i++ {
+		accounts = append(accounts, aws.String(fmt.Sprintf("%012d", i)))
+	}
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 9, 3, 0, 0, 0, 0, time.UTC)
+
+	// Two batches, the second with the remaining accounts
+	var batches []int
+	mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).Times(2).DoAndReturn(func(input *costexplorer.GetCostAndUsageInput) (*costexplorer.GetCostAndUsageOutput, error) {
+		batches = append(batches, len(input.Filter.Dimensions.Values))
+		return dailyCostOutput(input, "10"), nil
+	})
+	costs, unit, err := newCostExplorer(mockAWSClient, true).accountCosts(accounts, start, end)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(batches).To(gomega.Equal([]int{costBatchSize, 20}))
+	g.Expect(unit).To(gomega.Equal("USD"))
+	g.Expect(costs).To(gomega.HaveLen(costBatchSize + 20))
+	g.Expect(costs["000000000000"].String()).To(gomega.Equal("10"))
+
+	// The settled days are read from the cache by the next run, without querying Cost Explorer
+	costs, _, err = newCostExplorer(mockAWSClient, true).accountCosts(accounts[:1], start, end)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(costs["000000000000"].String()).To(gomega.Equal("10"))
+}
+
+func TestCostExplorerQueriesUnsettledDays(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	useTestCostCache(t)
+	mockCtrl := gomock.NewController(t)
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	accounts := aws.StringSlice([]string{"111111111111"})
+	start := time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+
+	mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).DoAndReturn(func(input *costexplorer.GetCostAndUsageInput) (*costexplorer.GetCostAndUsageOutput, error) {
+		g.Expect(*input.TimePeriod.Start).To(gomega.Equal("2026-10-09"))
+		return dailyCostOutput(input, "5"), nil
+	})
+	_, _, err := newCostExplorer(mockAWSClient, true).dailyCosts(accounts, start, end)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// The days of the last costSettleDays days are queried again
+	mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).DoAndReturn(func(input *costexplorer.GetCostAndUsageInput) (*costexplorer.GetCostAndUsageOutput, error) {
+		g.Expect(*input.TimePeriod.Start).To(gomega.Equal("2026-10-11"))
+		return dailyCostOutput(input, "7"), nil
+	})
+	costs, _, err := newCostExplorer(mockAWSClient, true).dailyCosts(accounts, start, end)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(costs["111111111111"]["2026-10-09"].String()).To(gomega.Equal("5"))
+	g.Expect(costs["111111111111"]["2026-10-11"].String()).To(gomega.Equal("7"))
+}
+
+func TestCostExplorerBacksOffWhenThrottled(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	useTestCostCache(t)
+	mockCtrl := gomock.NewController(t)
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	var waits []time.Duration
+	costSleep = func(wait time.Duration) { waits = append(waits, wait) }
+
+	throttled := awserr.New("ThrottlingException", "Rate exceeded", nil)
+	gomock.InOrder(
+		mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).Return(nil, throttled),
+		mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).Return(nil, throttled),
+		mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).Return(&costexplorer.GetCostAndUsageOutput{}, nil),
+	)
+	explorer := newCostExplorer(mockAWSClient, false)
+	_, err := explorer.getCostAndUsage(&costexplorer.GetCostAndUsageInput{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(waits).To(gomega.HaveLen(2))
+	g.Expect(waits[1]).To(gomega.BeNumerically(">=", 2*costExplorerBackoff))
+	// Slowed down twice, then sped up once
+	g.Expect(explorer.limiter.Limit()).To(gomega.Equal(rate.Limit(costExplorerRate / 4 * 1.25)))
+
+	// Other errors aren't retried
+	mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).Return(nil, awserr.New("AccessDeniedException", "denied", nil))
+	_, err = explorer.getCostAndUsage(&costexplorer.GetCostAndUsageInput{})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(waits).To(gomega.HaveLen(2))
+}
@@ -2,21 +2,23 @@ package cost
 
 import (
 	"fmt"
-	"log"
+	"os"
 	"strconv"
 	"time"
 
 	outputflag "github.com/openshift/osdctl/cmd/getoutput"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/service/costexplorer"
 	"github.com/aws/aws-sdk-go/service/organizations"
 )
 
@@ -27,13 +29,13 @@ func newCmdGet(streams genericclioptions.IOStreams, globalOpts *globalflags.Glob
 		Use:   "get",
 		Short: "Get total cost of a given OU",
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.checkArgs(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.checkArgs(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 	getCmd.Flags().StringVar(&ops.ou, "ou", "", "set OU ID")
 	getCmd.Flags().BoolVarP(&ops.recursive, "recursive", "r", false, "recurse through OUs")
-	getCmd.Flags().StringVarP(&ops.time, "time", "t", "", "set time. One of 'LM', 'MTD', 'YTD', '3M', '6M', '1Y'")
+	flagtypes.EnumVarP(getCmd, &ops.time, "time", "t", "", timePeriods, "set time")
 	getCmd.Flags().StringVar(&ops.start, "start", "", "set start date range")
 	getCmd.Flags().StringVar(&ops.end, "end", "", "set end date range")
 	getCmd.Flags().BoolVar(&ops.csv, "csv", false, "output result as csv")
@@ -110,18 +112,21 @@ func (o *getOptions) run() error {
 	}
 
 	//Get information regarding Organizational Unit
-	OU := getOU(awsClient, o.ou)
+	OU, err := getOU(awsClient, o.ou)
+	if err != nil {
+		return err
+	}
 
 	var cost decimal.Decimal
 	var unit string
 
 	if o.recursive { //Get cost of given OU by aggregating costs of all (including immediate) accounts under OU
 		if err := o.getOUCostRecursive(&cost, &unit, OU, awsClient); err != nil {
-			log.Fatalln("Error getting cost of OU recursively:", err)
+			return fmt.Errorf("error getting cost of OU recursively: %w", err)
 		}
 	} else { //Get cost of given OU by aggregating costs of only immediate accounts under given OU
 		if err := o.getOUCost(&cost, &unit, OU, awsClient); err != nil {
-			log.Fatalln("Error getting cost of OU:", err)
+			return fmt.Errorf("error getting cost of OU: %w", err)
 		}
 	}
 
@@ -235,59 +240,34 @@ func getOUsRecursive(OU *organizations.OrganizationalUnit, awsClient awsprovider
 	return OUs, nil
 }
 
-// Get cost of given account
-func (o *getOptions) getAccountCost(accountID *string, unit *string, awsClient awsprovider.Client, cost *decimal.Decimal) error {
-
-	var start, end, granularity string
+// Get the period of the cost, end excluded
+func (o *getOptions) getPeriod() (time.Time, time.Time, error) {
+	start, end := o.start, o.end
 	if o.time != "" {
 		start, end = getTimePeriod(&o.time)
-		granularity = "MONTHLY"
 	}
 
-	if o.start != "" && o.end != "" {
-		start = o.start
-		end = o.end
-		granularity = "DAILY"
-	}
-
-	metrics := []string{
-		"NetUnblendedCost",
+	startDate, err := time.Parse(costDateFormat, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start date '%s', expected YYYY-MM-DD", start)
 	}
-
-	//Get cost information for chosen account
-	costs, err := awsClient.GetCostAndUsage(&costexplorer.GetCostAndUsageInput{
-		Filter: &costexplorer.Expression{
-			Dimensions: &costexplorer.DimensionValues{
-				Key: aws.String("LINKED_ACCOUNT"),
-				Values: []*string{
-					accountID,
-				},
-			},
-		},
-		TimePeriod: &costexplorer.DateInterval{
-			Start: aws.String(start),
-			End:   aws.String(end),
-		},
-		Granularity: aws.String(granularity),
-		Metrics:     aws.StringSlice(metrics),
-	})
+	endDate, err := time.Parse(costDateFormat, end)
 	if err != nil {
-		return err
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end date '%s', expected YYYY-MM-DD", end)
 	}
-
-	//Loop through month-by-month cost and increment to get total cost
-	for month := 0; month < len(costs.ResultsByTime); month++ {
-		monthCost, err := decimal.NewFromString(*costs.ResultsByTime[month].Total["NetUnblendedCost"].Amount)
-		if err != nil {
-			return err
-		}
-		*cost = cost.Add(monthCost)
+	if endDate.Before(startDate) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the start date %s must not be after the end date %s", start, end)
 	}
+	return startDate, endDate, nil
+}
 
-	//Save unit
-	*unit = *costs.ResultsByTime[0].Total["NetUnblendedCost"].Unit
-
-	return nil
+// Get cost of given accounts, batched in as few Cost Explorer calls as possible
+func (o *getOptions) getAccountsCost(accounts []*string, awsClient awsprovider.Client) (map[string]decimal.Decimal, string, error) {
+	start, end, err := o.getPeriod()
+	if err != nil {
+		return nil, "", err
+	}
+	return opsCost.costExplorer(awsClient).accountCosts(accounts, start, end)
 }
 
 // Get cost of given OU by aggregating costs of only immediate accounts under given OU
@@ -297,12 +277,20 @@ func (o *getOptions) getOUCost(cost *decimal.Decimal, unit *string, OU *organiza
 	if err != nil {
 		return err
 	}
+	if len(accounts) == 0 {
+		return nil
+	}
 
 	//Increment costs of accounts
-	for _, account := range accounts {
-		if err := o.getAccountCost(account, unit, awsClient, cost); err != nil {
-			return err
-		}
+	costs, accountsUnit, err := o.getAccountsCost(accounts, awsClient)
+	if err != nil {
+		return err
+	}
+	for _, accountCost := range costs {
+		*cost = cost.Add(accountCost)
+	}
+	if accountsUnit != "" {
+		*unit = accountsUnit
 	}
 
 	return nil
@@ -331,6 +319,10 @@ func (o *getOptions) getOUCostRecursive(cost *decimal.Decimal, unit *string, OU
 	return nil
 }
 
+// timePeriods are the predefined periods of --time: last month, month to date, year to date and the last 3, 6 and 12
+// months
+var timePeriods = []string{"LM", "MTD", "YTD", "3M", "6M", "1Y"}
+
 // Get time period based on time flag
 func getTimePeriod(timePtr *string) (string, string) {
 
@@ -376,7 +368,7 @@ func (o *getOptions) printCostGet(cost decimal.Decimal, unit string, ops *getOpt
 	}
 
 	if ops.csv { //If csv option specified, print result in csv
-		fmt.Printf("\n%s,%s,%s\n\n", *OU.Name, cost.StringFixed(2), unit)
+		fmt.Fprintf(printer.Tee(os.Stdout), "\n%s,%s,%s\n\n", *OU.Name, cost.StringFixed(2), unit)
 		return nil
 	}
 	if ops.recursive {
@@ -385,7 +377,7 @@ func (o *getOptions) printCostGet(cost decimal.Decimal, unit string, ops *getOpt
 
 	err := outputflag.PrintResponse(o.output, resp)
 	if err != nil {
-		fmt.Println("Error calling PrintResponse(): ", err.Error())
+		fmt.Fprintln(o.ErrOut, "Error calling PrintResponse(): ", err.Error())
 		return err
 	}
 
@@ -0,0 +1,50 @@
+package cost
+
+import (
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/golang/mock/gomock"
+	"github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestGetRunWithInjectedClient(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+	opsCost = newCostOptions(streams)
+	opsCost.awsClient = mockAWSClient
+	defer func() { opsCost = nil }()
+	useTestCostCache(t)
+
+	mockAWSClient.EXPECT().DescribeOrganizationalUnit(gomock.Any()).Return(&organizations.DescribeOrganizationalUnitOutput{
+		OrganizationalUnit: &organizations.OrganizationalUnit{Id: aws.String("ou-0000-00000000"), Name: aws.String("Test OU")},
+	}, nil)
+	mockAWSClient.EXPECT().ListAccountsForParent(gomock.Any()).Return(&organizations.ListAccountsForParentOutput{
+		Accounts: []*organizations.Account{{Id: aws.String("111111111111")}},
+	}, nil)
+	mockAWSClient.EXPECT().GetCostAndUsage(gomock.Any()).Return(&costexplorer.GetCostAndUsageOutput{
+		ResultsByTime: []*costexplorer.ResultByTime{{
+			TimePeriod: &costexplorer.DateInterval{Start: aws.String("2026-10-01")},
+			Groups: []*costexplorer.Group{{
+				Keys:    aws.StringSlice([]string{"111111111111"}),
+				Metrics: map[string]*costexplorer.MetricValue{"NetUnblendedCost": {Amount: aws.String("12.5"), Unit: aws.String("USD")}},
+			}},
+		}},
+	}, nil)
+
+	o := newGetOptions(streams, nil)
+	o.ou = "ou-0000-00000000"
+	o.start = "2026-10-01"
+	o.end = "2026-10-14"
+	o.csv = true
+	g.Expect(o.run()).To(gomega.Succeed())
+}
@@ -2,11 +2,14 @@ package cost
 
 import (
 	"fmt"
-	"log"
+	"os"
 	"sort"
 
 	outputflag "github.com/openshift/osdctl/cmd/getoutput"
 	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/shopspring/decimal"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -23,22 +26,20 @@ func newCmdList(streams genericclioptions.IOStreams, globalOpts *globalflags.Glo
 		Use:   "list",
 		Short: "List the cost of each Account/OU under given OU",
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.checkArgs(cmd, args))
-			cmdutil.CheckErr(ops.runList())
+			osdctlErrors.CheckErr(ops.checkArgs(cmd, args))
+			osdctlErrors.CheckErr(ops.runList())
 		},
 	}
 	listCmd.Flags().StringArrayVar(&ops.ou, "ou", []string{}, "get OU ID")
 	// list supported time args
-	listCmd.Flags().StringVarP(&ops.time, "time", "t", "", "set time. One of 'LM', 'MTD', 'YTD', '3M', '6M', '1Y'")
+	flagtypes.EnumVarP(listCmd, &ops.time, "time", "t", "", timePeriods, "set time")
 	listCmd.Flags().StringVar(&ops.start, "start", "", "set start date range")
 	listCmd.Flags().StringVar(&ops.end, "end", "", "set end date range")
 	listCmd.Flags().BoolVar(&ops.csv, "csv", false, "output result as csv")
-	listCmd.Flags().StringVar(&ops.level, "level", "ou", "Cost cummulation level: possible options: ou, account")
+	flagtypes.EnumVarP(listCmd, &ops.level, "level", "", "ou", []string{"ou", "account"}, "Cost cummulation level")
 	listCmd.Flags().BoolVar(&ops.sum, "sum", true, "Hide sum rows")
 
-	if err := listCmd.MarkFlagRequired("ou"); err != nil {
-		log.Fatalln("OU flag:", err)
-	}
+	_ = listCmd.MarkFlagRequired("ou")
 
 	return listCmd
 }
@@ -114,19 +115,24 @@ func newListOptions(streams genericclioptions.IOStreams, globalOpts *globalflags
 
 func (ops *listOptions) runList() error {
 	awsClient, err := opsCost.initAWSClients()
-	cmdutil.CheckErr(err)
+	if err != nil {
+		return err
+	}
 
 	printHeader(ops)
 
 	for _, ou := range ops.ou {
-		OU := getOU(awsClient, ou)
+		OU, err := getOU(awsClient, ou)
+		if err != nil {
+			return err
+		}
 
 		var cost decimal.Decimal
 		var unit string
 
 		if ops.level == "ou" {
 			if err := listCostsUnderOU(OU, awsClient, ops); err != nil {
-				log.Fatalln("Error listing costs under OU:", err)
+				return fmt.Errorf("error listing costs under OU: %w", err)
 			}
 			printCostList(cost, unit, OU, ops, true) // TODO: Update bool here
 			return nil
@@ -137,7 +143,10 @@ func (ops *listOptions) runList() error {
 				OU:      OU,
 				options: ops,
 			}
-			ouCost.printCostPerAccount(awsClient) // Get cost per account, print per account
+			// Get cost per account, print per account
+			if err := ouCost.printCostPerAccount(awsClient); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -149,11 +158,11 @@ func printHeader(ops *listOptions) {
 
 	case "account":
 		if ops.csv {
-			fmt.Println("OU, AccountID,Cost,Unit")
+			fmt.Fprintln(printer.Tee(os.Stdout), "OU, AccountID,Cost,Unit")
 		}
 	case "ou":
 		if ops.csv {
-			fmt.Printf("OU,Name,Cost,Unit\n")
+			fmt.Fprintf(printer.Tee(os.Stdout), "OU,Name,Cost,Unit\n")
 			break
 		}
 	}
@@ -223,17 +232,19 @@ func (o *OUCost) getCost(awsClient awsprovider.Client) error {
 		ou:    *o.OU.Id,
 	}
 
+	if len(accounts) == 0 {
+		return nil
+	}
+	costs, unit, err := ops.getAccountsCost(accounts, awsClient)
+	if err != nil {
+		return err
+	}
 	for _, account := range accounts {
-		accCost := AccountCost{
+		o.Costs = append(o.Costs, AccountCost{
 			AccountID: *account,
-			Unit:      "",
-			Cost:      decimal.Zero,
-		}
-		err = ops.getAccountCost(account, &accCost.Unit, awsClient, &accCost.Cost)
-		if err != nil {
-			return err
-		}
-		o.Costs = append(o.Costs, accCost)
+			Unit:      unit,
+			Cost:      costs[*account],
+		})
 	}
 
 	sort.Slice(o.Costs, func(i, j int) bool {
@@ -259,11 +270,10 @@ func (o OUCost) getSum() (sum decimal.Decimal, unit string, err error) {
 	return
 }
 
-func (o OUCost) printCostPerAccount(awsClient awsprovider.Client) {
+func (o OUCost) printCostPerAccount(awsClient awsprovider.Client) error {
 	err := o.getCost(awsClient)
 	if err != nil {
-		fmt.Println("Error while calling getCost(): ", err.Error())
-		return
+		return fmt.Errorf("error getting the cost of the accounts: %w", err)
 	}
 
 	for _, accountCost := range o.Costs {
@@ -274,26 +284,26 @@ func (o OUCost) printCostPerAccount(awsClient awsprovider.Client) {
 			Unit:      accountCost.Unit,
 		}
 		if o.options.csv {
-			fmt.Printf("%s,%s,%s,%s\n", *o.OU.Id, accountCost.AccountID, accountCost.Cost.StringFixed(2), accountCost.Unit)
+			fmt.Fprintf(printer.Tee(os.Stdout), "%s,%s,%s,%s\n", *o.OU.Id, accountCost.AccountID, accountCost.Cost.StringFixed(2), accountCost.Unit)
 			continue
 		}
 		err := outputflag.PrintResponse(o.options.output, resp)
 		if err != nil {
-			fmt.Println("Error while printing response: ", err.Error())
-			return
+			return fmt.Errorf("error while printing response: %w", err)
 		}
 	}
 	sum, unit, err := o.getSum() // Sum up account costs
 	if err != nil {
-		log.Fatalln("Error summing up cost of OU:", err)
+		return fmt.Errorf("error summing up cost of OU: %w", err)
 	}
 	if o.options.csv {
 		if o.options.sum {
-			fmt.Printf("%s,%s,%s,%s\n", *o.OU.Id, "SUM", sum.StringFixed(2), unit)
+			fmt.Fprintf(printer.Tee(os.Stdout), "%s,%s,%s,%s\n", *o.OU.Id, "SUM", sum.StringFixed(2), unit)
 		}
-		return
+		return nil
 	}
 	printCostList(sum, unit, o.OU, o.options, true)
+	return nil
 }
 
 func printCostList(cost decimal.Decimal, unit string, OU *organizations.OrganizationalUnit, ops *listOptions, isChildNode bool) {
@@ -309,13 +319,13 @@ func printCostList(cost decimal.Decimal, unit string, OU *organizations.Organiza
 	}
 
 	if ops.csv {
-		fmt.Printf("%v,%v,%s,%s\n", *OU.Id, *OU.Name, cost.StringFixed(2), unit)
+		fmt.Fprintf(printer.Tee(os.Stdout), "%v,%v,%s,%s\n", *OU.Id, *OU.Name, cost.StringFixed(2), unit)
 		return
 	}
 
 	err := outputflag.PrintResponse(ops.output, resp)
 	if err != nil {
-		fmt.Println("Error while printing response: ", err.Error())
+		fmt.Fprintln(ops.ErrOut, "Error while printing response: ", err.Error())
 		return
 	}
 }
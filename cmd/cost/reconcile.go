@@ -5,41 +5,39 @@ import (
 	"github.com/aws/aws-sdk-go/service/costexplorer"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/deckarep/golang-set"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
-	"log"
 )
 
 // reconcileCmd represents the reconcile command
 func newCmdReconcile(streams genericclioptions.IOStreams) *cobra.Command {
 	reconcileCmd := &cobra.Command{
-		Use:   "reconcile",
-		Short: "Checks if there's a cost category for every OU. If an OU is missing a cost category, creates the cost category",
+		Use:         "reconcile",
+		Short:       "Checks if there's a cost category for every OU. If an OU is missing a cost category, creates the cost category",
+		Annotations: map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
 		Run: func(cmd *cobra.Command, args []string) {
 
 			awsClient, err := opsCost.initAWSClients()
-			cmdutil.CheckErr(err)
+			osdctlErrors.CheckErr(err)
 
 			//Get flags
 			OUid, err := cmd.Flags().GetString("ou")
-			if err != nil {
-				log.Fatalln("OU flag:", err)
-			}
+			osdctlErrors.CheckErr(err)
 
 			//Get information regarding Organizational Unit
-			OU := getOU(awsClient, OUid)
+			OU, err := getOU(awsClient, OUid)
+			osdctlErrors.CheckErr(err)
 
 			if err := reconcileCostCategories(OU, awsClient); err != nil {
-				log.Fatalln("Error reconciling cost categories:", err)
+				osdctlErrors.CheckErr(fmt.Errorf("error reconciling cost categories: %w", err))
 			}
 		},
 	}
 	reconcileCmd.Flags().String("ou", "", "get OU ID")
-	if err := reconcileCmd.MarkFlagRequired("ou"); err != nil {
-		log.Fatalln("OU flag:", err)
-	}
+	_ = reconcileCmd.MarkFlagRequired("ou")
 
 	return reconcileCmd
 }
@@ -0,0 +1,334 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	pd "github.com/PagerDuty/go-pagerduty"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+	"github.com/openshift/osdctl/cmd/cluster"
+	"github.com/openshift/osdctl/pkg/osdctlConfig"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+)
+
+// ClustersConfigKey lists the clusters shown by the dashboard, e.g. the clusters a TAM or an SRE is assigned to
+const ClustersConfigKey = "dashboard_clusters"
+
+const (
+	dashboardLong = `Show a terminal dashboard of the clusters you are assigned to: their state, the ones in limited support
+and why, the PagerDuty incidents assigned to you which are still open, and the service logs recently sent to the
+clusters. Every row opens a detail view.
+
+The clusters are the ones given with --cluster, or else the ones listed in the config file:
+
+  dashboard_clusters:
+    - 1kfmyclusteristhebesteverp8m
+    - my-other-cluster
+
+The data comes from the same OCM and PagerDuty APIs as 'osdctl cluster describe', 'osdctl cluster support status',
+'osdctl servicelog list' and 'osdctl cluster context'. A source which can't be read, e.g. without a PagerDuty token,
+is reported in its panel, and the panel of an integration which isn't configured says how to set it up. Press r to
+refresh.`
+
+	dashboardExample = `
+  # Dashboard of the clusters listed in the config file
+  osdctl dashboard
+
+  # Dashboard of two clusters, with the service logs of the last 30 days
+  osdctl dashboard --cluster 1kfmyclusteristhebesteverp8m --cluster my-other-cluster --days 30
+`
+
+	serviceLogsPageSize = 100
+	timeFormat          = "2006-01-02 15:04"
+)
+
+type dashboardOptions struct {
+	clusters   []string
+	days       int
+	usertoken  string
+	oauthtoken string
+}
+
+// NewCmdDashboard implements the dashboard command
+func NewCmdDashboard() *cobra.Command {
+	ops := &dashboardOptions{}
+	dashboardCmd := &cobra.Command{
+		Use:               "dashboard",
+		Short:             "Terminal dashboard of your clusters, limited support, PagerDuty incidents and service logs",
+		Long:              dashboardLong,
+		Example:           dashboardExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete())
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	dashboardCmd.Flags().StringArrayVarP(&ops.clusters, "cluster", "C", nil, fmt.Sprintf("Cluster to show, repeatable. Defaults to %s from the config file", ClustersConfigKey))
+	dashboardCmd.Flags().IntVarP(&ops.days, "days", "d", 7, "Show the service logs sent in the last X days")
+	dashboardCmd.Flags().StringVar(&ops.oauthtoken, "oauthtoken", "", fmt.Sprintf("PD oauthtoken, by default read from `pd_oauth_token` in ~/.config/%s", osdctlConfig.ConfigFileName))
+	dashboardCmd.Flags().StringVar(&ops.usertoken, "usertoken", "", fmt.Sprintf("PD usertoken, by default read from `pd_user_token` in ~/.config/%s", osdctlConfig.ConfigFileName))
+
+	return dashboardCmd
+}
+
+func (o *dashboardOptions) complete() error {
+	if len(o.clusters) == 0 {
+		o.clusters = viper.GetStringSlice(ClustersConfigKey)
+	}
+	if len(o.clusters) == 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "no cluster to show, pass --cluster or list the clusters under '%s' in the config file", ClustersConfigKey)
+	}
+	if o.days < 1 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "--days must be at least 1")
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the dashboard needs a terminal, use 'osdctl cluster context' in scripts")
+	}
+	return nil
+}
+
+func (o *dashboardOptions) run() error {
+	connection := utils.CreateConnection()
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot close the connection: %q\n", err)
+		}
+	}()
+
+	return runTUI(os.Stdin, os.Stdout, func() []panel {
+		return o.loadPanels(connection)
+	})
+}
+
+// clusterReason is an active limited support reason of a cluster
+type clusterReason struct {
+	cluster *cmv1.Cluster
+	reason  *cmv1.LimitedSupportReason
+}
+
+func (o *dashboardOptions) loadPanels(connection *sdk.Connection) []panel {
+	var clusters []*cmv1.Cluster
+	var notFound []string
+	for _, key := range o.clusters {
+		c, err := utils.GetCluster(connection, key)
+		if err != nil {
+			notFound = append(notFound, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		clusters = append(clusters, c)
+	}
+	clustersTab := clustersPanel(clusters)
+	if len(notFound) > 0 {
+		clustersTab.message = "Not found: " + strings.Join(notFound, "; ")
+	}
+
+	var reasons []clusterReason
+	var failures []string
+	for _, c := range clusters {
+		// The count is part of the cluster, don't ask the clusters that have none
+		if count, ok := c.Status().GetLimitedSupportReasonCount(); ok && count == 0 {
+			continue
+		}
+		response, err := connection.ClustersMgmt().V1().Clusters().Cluster(c.ID()).LimitedSupportReasons().List().Send()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", c.Name(), err))
+			continue
+		}
+		for _, reason := range response.Items().Slice() {
+			reasons = append(reasons, clusterReason{cluster: c, reason: reason})
+		}
+	}
+	limitedSupportTab := limitedSupportPanel(reasons)
+	if len(failures) > 0 {
+		limitedSupportTab.message = "Cannot get the limited support reasons of " + strings.Join(failures, "; ")
+	}
+
+	incidentsTab := panel{title: "PagerDuty incidents"}
+	if incidents, err := o.myIncidents(); secrets.IsNotConfigured(err) {
+		incidentsTab.message = fmt.Sprintf("Skipped: %v", err)
+	} else if err != nil {
+		incidentsTab.message = fmt.Sprintf("Cannot get the PagerDuty incidents: %v", err)
+	} else {
+		incidentsTab = incidentsPanel(incidents)
+	}
+
+	serviceLogsTab := panel{title: "Service logs"}
+	if logs, err := recentServiceLogs(connection, clusters, time.Now().AddDate(0, 0, -o.days)); err != nil {
+		serviceLogsTab.message = fmt.Sprintf("Cannot get the service logs: %v", err)
+	} else {
+		serviceLogsTab = serviceLogsPanel(logs, clusters)
+	}
+
+	return []panel{clustersTab, limitedSupportTab, incidentsTab, serviceLogsTab}
+}
+
+// myIncidents returns the open PagerDuty incidents assigned to the owner of the token
+func (o *dashboardOptions) myIncidents() ([]pd.Incident, error) {
+	pdClient, err := cluster.GetPagerdutyClient(o.usertoken, o.oauthtoken)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.TODO()
+	me, err := pdClient.GetCurrentUserWithContext(ctx, pd.GetCurrentUserOptions{})
+	if err != nil {
+		return nil, err
+	}
+	response, err := pdClient.ListIncidentsWithContext(ctx, pd.ListIncidentsOptions{
+		UserIDs:  []string{me.ID},
+		Statuses: []string{"triggered", "acknowledged"},
+		SortBy:   "created_at:DESC",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Incidents, nil
+}
+
+// recentServiceLogs returns the service logs sent to the clusters since the given time, newest first
+func recentServiceLogs(connection *sdk.Connection, clusters []*cmv1.Cluster, since time.Time) ([]*slv1.LogEntry, error) {
+	if len(clusters) == 0 {
+		return nil, nil
+	}
+	var ids []string
+	for _, c := range clusters {
+		ids = append(ids, fmt.Sprintf("'%s'", c.ExternalID()))
+	}
+	search := fmt.Sprintf("cluster_uuid in (%s) and timestamp >= '%s'", strings.Join(ids, ", "), since.UTC().Format(time.RFC3339))
+	response, err := connection.ServiceLogs().V1().ClusterLogs().List().Search(search).Order("timestamp desc").Size(serviceLogsPageSize).Send()
+	if err != nil {
+		return nil, err
+	}
+	return response.Items().Slice(), nil
+}
+
+func clustersPanel(clusters []*cmv1.Cluster) panel {
+	p := panel{title: "Clusters", header: []string{"NAME", "ID", "STATE", "VERSION", "PROVIDER", "LIMITED SUPPORT"}}
+	for _, c := range clusters {
+		limitedSupport := "no"
+		if count := c.Status().LimitedSupportReasonCount(); count > 0 {
+			limitedSupport = fmt.Sprintf("%d reason(s)", count)
+		}
+		p.rows = append(p.rows, panelRow{
+			cells: []string{c.Name(), c.ID(), string(c.State()), c.OpenshiftVersion(), c.CloudProvider().ID() + "/" + c.Region().ID(), limitedSupport},
+			detail: []string{
+				"ID:             " + c.ID(),
+				"External ID:    " + c.ExternalID(),
+				"Name:           " + c.Name(),
+				"State:          " + string(c.State()),
+				"Version:        " + c.OpenshiftVersion(),
+				"Product:        " + c.Product().ID(),
+				"Cloud Provider: " + c.CloudProvider().ID(),
+				"Region:         " + c.Region().ID(),
+				"Created:        " + c.CreationTimestamp().UTC().Format(timeFormat),
+				"Console:        " + c.Console().URL(),
+				"",
+				"osdctl cluster context " + c.ID(),
+			},
+		})
+	}
+	if len(clusters) == 0 {
+		p.message = "No cluster found"
+	}
+	return p
+}
+
+func limitedSupportPanel(reasons []clusterReason) panel {
+	p := panel{title: "Limited support", header: []string{"CLUSTER", "SUMMARY", "DETECTION", "SINCE"}}
+	sort.SliceStable(reasons, func(i, j int) bool {
+		return reasons[i].reason.CreationTimestamp().After(reasons[j].reason.CreationTimestamp())
+	})
+	for _, r := range reasons {
+		p.rows = append(p.rows, panelRow{
+			cells: []string{r.cluster.Name(), r.reason.Summary(), string(r.reason.DetectionType()), r.reason.CreationTimestamp().UTC().Format(timeFormat)},
+			detail: append([]string{
+				"Cluster:   " + r.cluster.Name() + " (" + r.cluster.ID() + ")",
+				"Reason ID: " + r.reason.ID(),
+				"Summary:   " + r.reason.Summary(),
+				"Detection: " + string(r.reason.DetectionType()),
+				"",
+			}, append(strings.Split(r.reason.Details(), "\n"), "", "osdctl cluster support status "+r.cluster.ID())...),
+		})
+	}
+	if len(reasons) == 0 {
+		p.message = "No cluster in limited support"
+	}
+	return p
+}
+
+func incidentsPanel(incidents []pd.Incident) panel {
+	p := panel{title: "PagerDuty incidents", header: []string{"URGENCY", "STATUS", "SERVICE", "TITLE", "CREATED"}}
+	for _, incident := range incidents {
+		created := incident.CreatedAt
+		if t, err := time.Parse(time.RFC3339, incident.CreatedAt); err == nil {
+			created = t.UTC().Format(timeFormat)
+		}
+		detail := []string{
+			fmt.Sprintf("#%d %s", incident.IncidentNumber, incident.Title),
+			"Service: " + incident.Service.Summary,
+			"Status:  " + incident.Status,
+			"Urgency: " + incident.Urgency,
+			"Created: " + created,
+			"Link:    " + incident.HTMLURL,
+		}
+		if incident.Description != "" && incident.Description != incident.Title {
+			detail = append(append(detail, ""), strings.Split(incident.Description, "\n")...)
+		}
+		p.rows = append(p.rows, panelRow{
+			cells:  []string{incident.Urgency, incident.Status, incident.Service.Summary, incident.Title, created},
+			detail: detail,
+		})
+	}
+	if len(incidents) == 0 {
+		p.message = "No open incident assigned to you"
+	}
+	return p
+}
+
+func serviceLogsPanel(logs []*slv1.LogEntry, clusters []*cmv1.Cluster) panel {
+	names := map[string]string{}
+	for _, c := range clusters {
+		names[c.ExternalID()] = c.Name()
+	}
+
+	p := panel{title: "Service logs", header: []string{"TIME", "CLUSTER", "SEVERITY", "SUMMARY"}}
+	for _, log := range logs {
+		name := names[log.ClusterUUID()]
+		if name == "" {
+			name = log.ClusterUUID()
+		}
+		visibility := "customer"
+		if log.InternalOnly() {
+			visibility = "internal"
+		}
+		p.rows = append(p.rows, panelRow{
+			cells: []string{log.Timestamp().UTC().Format(timeFormat), name, string(log.Severity()), log.Summary()},
+			detail: append([]string{
+				"Cluster:    " + name,
+				"Summary:    " + log.Summary(),
+				"Severity:   " + string(log.Severity()),
+				"Service:    " + log.ServiceName(),
+				"Visibility: " + visibility,
+				"Posted by:  " + log.Username(),
+				"Time:       " + timefmt.Format(log.Timestamp()),
+				"",
+			}, strings.Split(log.Description(), "\n")...),
+		})
+	}
+	if len(logs) == 0 {
+		p.message = "No recent service log"
+	}
+	return p
+}
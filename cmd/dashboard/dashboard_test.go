@@ -0,0 +1,151 @@
+package dashboard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pd "github.com/PagerDuty/go-pagerduty"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	slv1 "github.com/openshift-online/ocm-sdk-go/servicelogs/v1"
+)
+
+func testPanels() []panel {
+	return []panel{
+		{title: "Clusters", header: []string{"NAME", "ID"}, rows: []panelRow{
+			{cells: []string{"one", "1"}, detail: []string{"first cluster"}},
+			{cells: []string{"two", "2"}, detail: []string{"second cluster"}},
+		}},
+		{title: "Limited support", message: "No cluster in limited support"},
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("j\x1b[A\x1b[B\t\x1b[Z\r\x1bq\x03"))
+	expected := []string{"j", keyUp, keyDown, keyTab, keyShiftTab, keyEnter, keyEscape, "q", keyQuit}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+}
+
+func TestDashboardNavigation(t *testing.T) {
+	d := newDashboard(testPanels())
+
+	d.handle(keyUp)
+	if d.cursors[0] != 0 {
+		t.Errorf("expected the cursor to stay on the first row, got %d", d.cursors[0])
+	}
+	d.handle("j")
+	d.handle(keyDown)
+	if d.cursors[0] != 1 {
+		t.Errorf("expected the cursor to stop on the last row, got %d", d.cursors[0])
+	}
+
+	d.handle(keyEnter)
+	if !d.detail {
+		t.Fatal("expected enter to open the detail view")
+	}
+	if out := d.render(80, 10); !strings.Contains(out, "second cluster") {
+		t.Errorf("expected the detail of the second row, got %q", out)
+	}
+	if quit, _ := d.handle("q"); quit || d.detail {
+		t.Error("expected q to close the detail view without quitting")
+	}
+
+	d.handle(keyTab)
+	if d.active != 1 {
+		t.Errorf("expected tab to switch to the second panel, got %d", d.active)
+	}
+	d.handle(keyEnter)
+	if d.detail {
+		t.Error("expected enter to do nothing on an empty panel")
+	}
+	if out := d.render(80, 10); !strings.Contains(out, "No cluster in limited support") {
+		t.Errorf("expected the message of the empty panel, got %q", out)
+	}
+	d.handle(keyTab)
+	if d.active != 0 {
+		t.Errorf("expected tab to wrap around to the first panel, got %d", d.active)
+	}
+	d.handle("2")
+	if d.active != 1 {
+		t.Errorf("expected 2 to jump to the second panel, got %d", d.active)
+	}
+
+	if _, refresh := d.handle("r"); !refresh {
+		t.Error("expected r to refresh")
+	}
+	d.reload(testPanels()[:1])
+	if d.active != 0 || d.cursors[0] != 1 {
+		t.Errorf("expected the reload to keep the cursor of the remaining panel, got panel %d cursor %v", d.active, d.cursors)
+	}
+
+	if quit, _ := d.handle(keyQuit); !quit {
+		t.Error("expected ctrl-c to quit")
+	}
+}
+
+func TestDashboardRenderFitsTheTerminal(t *testing.T) {
+	p := panel{title: "Service logs", header: []string{"TIME", "SUMMARY"}}
+	for i := 0; i < 50; i++ {
+		p.rows = append(p.rows, panelRow{cells: []string{"2023-05-01 12:00", strings.Repeat("x", 200)}})
+	}
+	d := newDashboard([]panel{p})
+	for i := 0; i < 30; i++ {
+		d.handle(keyDown)
+	}
+
+	out := d.render(60, 20)
+	lines := strings.Split(out, "\r\n")
+	if len(lines) != 20 {
+		t.Errorf("expected 20 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		plain := strings.NewReplacer(reverseVideo, "", bold, "", resetStyle, "").Replace(line)
+		if len([]rune(plain)) > 60 {
+			t.Errorf("expected the lines to fit in 60 columns, got %d: %q", len([]rune(plain)), plain)
+		}
+	}
+	if !strings.Contains(out, reverseVideo+"2023-05-01 12:00") {
+		t.Error("expected the selected row to stay visible after scrolling")
+	}
+}
+
+func TestPanels(t *testing.T) {
+	c, err := cmv1.NewCluster().ID("abc").ExternalID("uuid-1").Name("my-cluster").State(cmv1.ClusterStateReady).
+		Status(cmv1.NewClusterStatus().LimitedSupportReasonCount(1)).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusters := clustersPanel([]*cmv1.Cluster{c})
+	if !reflect.DeepEqual(clusters.rows[0].cells[:3], []string{"my-cluster", "abc", "ready"}) || clusters.rows[0].cells[5] != "1 reason(s)" {
+		t.Errorf("unexpected cluster row %v", clusters.rows[0].cells)
+	}
+
+	older, _ := cmv1.NewLimitedSupportReason().ID("r1").Summary("Old").CreationTimestamp(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)).Build()
+	newer, _ := cmv1.NewLimitedSupportReason().ID("r2").Summary("New").Details("line 1\nline 2").CreationTimestamp(time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)).Build()
+	limitedSupport := limitedSupportPanel([]clusterReason{{cluster: c, reason: older}, {cluster: c, reason: newer}})
+	if limitedSupport.rows[0].cells[1] != "New" || !strings.Contains(strings.Join(limitedSupport.rows[0].detail, "\n"), "line 1\nline 2") {
+		t.Errorf("expected the newest reason first with its details, got %v", limitedSupport.rows[0])
+	}
+	if empty := limitedSupportPanel(nil); empty.message == "" {
+		t.Error("expected a message when no cluster is in limited support")
+	}
+
+	incidents := incidentsPanel([]pd.Incident{{
+		IncidentNumber: 42, Title: "ClusterOperatorDown", Urgency: "high", Status: "triggered", CreatedAt: "2023-05-01T12:00:00Z",
+		Service: pd.APIObject{Summary: "osd-my-cluster"},
+	}})
+	if !reflect.DeepEqual(incidents.rows[0].cells, []string{"high", "triggered", "osd-my-cluster", "ClusterOperatorDown", "2023-05-01 12:00"}) {
+		t.Errorf("unexpected incident row %v", incidents.rows[0].cells)
+	}
+
+	log, _ := slv1.NewLogEntry().ClusterUUID("uuid-1").Summary("Action required").Severity(slv1.SeverityWarning).
+		InternalOnly(true).Timestamp(time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)).Build()
+	serviceLogs := serviceLogsPanel([]*slv1.LogEntry{log}, []*cmv1.Cluster{c})
+	if serviceLogs.rows[0].cells[1] != "my-cluster" || !strings.Contains(strings.Join(serviceLogs.rows[0].detail, "\n"), "Visibility: internal") {
+		t.Errorf("unexpected service log row %v", serviceLogs.rows[0])
+	}
+}
@@ -0,0 +1,321 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	// ANSI escape sequences of the terminal UI
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+	reverseVideo   = "\x1b[7m"
+	bold           = "\x1b[1m"
+	resetStyle     = "\x1b[0m"
+
+	keyUp       = "up"
+	keyDown     = "down"
+	keyLeft     = "left"
+	keyRight    = "right"
+	keyTab      = "tab"
+	keyShiftTab = "shift-tab"
+	keyEnter    = "enter"
+	keyEscape   = "escape"
+	keyQuit     = "ctrl-c"
+
+	// Lines taken by the tabs, the table header and the help line
+	chromeLines = 4
+)
+
+// panel is a tab of the dashboard: a table whose rows open a detail view
+type panel struct {
+	title  string
+	header []string
+	rows   []panelRow
+	// message replaces the rows when the panel couldn't be loaded, or is empty
+	message string
+}
+
+type panelRow struct {
+	cells  []string
+	detail []string
+}
+
+// dashboard is the state of the terminal UI, independent of the terminal so it can be tested
+type dashboard struct {
+	panels  []panel
+	active  int
+	cursors []int
+	detail  bool
+	status  string
+}
+
+func newDashboard(panels []panel) *dashboard {
+	d := &dashboard{}
+	d.reload(panels)
+	return d
+}
+
+// reload replaces the panels, keeping the active panel and the cursors where they still fit
+func (d *dashboard) reload(panels []panel) {
+	cursors := make([]int, len(panels))
+	for i := range panels {
+		if i < len(d.cursors) && d.cursors[i] < len(panels[i].rows) {
+			cursors[i] = d.cursors[i]
+		}
+	}
+	d.panels = panels
+	d.cursors = cursors
+	if d.active >= len(panels) {
+		d.active = 0
+	}
+	d.detail = false
+	d.status = ""
+}
+
+// handle applies a key press, it returns whether to quit and whether to reload the panels
+func (d *dashboard) handle(key string) (quit bool, refresh bool) {
+	if len(d.panels) == 0 {
+		return key == "q" || key == keyQuit, key == "r"
+	}
+	p := &d.panels[d.active]
+
+	if d.detail {
+		switch key {
+		case keyEscape, keyLeft, "h", "q", "\x7f":
+			d.detail = false
+		case keyQuit:
+			return true, false
+		}
+		return false, false
+	}
+
+	switch key {
+	case "q", keyQuit:
+		return true, false
+	case "r":
+		return false, true
+	case keyUp, "k":
+		if d.cursors[d.active] > 0 {
+			d.cursors[d.active]--
+		}
+	case keyDown, "j":
+		if d.cursors[d.active] < len(p.rows)-1 {
+			d.cursors[d.active]++
+		}
+	case keyTab, keyRight, "l":
+		d.active = (d.active + 1) % len(d.panels)
+	case keyShiftTab, keyLeft, "h":
+		d.active = (d.active + len(d.panels) - 1) % len(d.panels)
+	case keyEnter:
+		if len(p.rows) > 0 {
+			d.detail = true
+		}
+	default:
+		// "1" to "9" jump to a panel
+		if len(key) == 1 && key[0] >= '1' && key[0] <= '9' && int(key[0]-'1') < len(d.panels) {
+			d.active = int(key[0] - '1')
+		}
+	}
+	return false, false
+}
+
+// render draws the dashboard for a terminal of the given size
+func (d *dashboard) render(width, height int) string {
+	var lines []string
+
+	var tabs []string
+	for i, p := range d.panels {
+		tab := fmt.Sprintf(" %d %s (%d) ", i+1, p.title, len(p.rows))
+		if i == d.active {
+			tab = reverseVideo + tab + resetStyle
+		}
+		tabs = append(tabs, tab)
+	}
+	lines = append(lines, strings.Join(tabs, " "))
+
+	if len(d.panels) > 0 {
+		p := d.panels[d.active]
+		if d.detail {
+			lines = append(lines, bold+truncate(strings.Join(p.rows[d.cursors[d.active]].cells, "  "), width)+resetStyle, "")
+			for _, line := range p.rows[d.cursors[d.active]].detail {
+				lines = append(lines, truncate(line, width))
+			}
+		} else {
+			lines = append(lines, d.renderTable(p, width, height)...)
+		}
+	}
+
+	if height > 0 && len(lines) > height-1 {
+		lines = lines[:height-1]
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+
+	help := "tab/←→ panels  ↑↓ move  enter details  r refresh  q quit"
+	if d.detail {
+		help = "esc back  ctrl-c quit"
+	}
+	if d.status != "" {
+		help = d.status
+	}
+	lines = append(lines, truncate(help, width))
+	return strings.Join(lines, "\r\n")
+}
+
+func (d *dashboard) renderTable(p panel, width, height int) []string {
+	if len(p.rows) == 0 {
+		message := p.message
+		if message == "" {
+			message = "Nothing to show"
+		}
+		return []string{"", truncate(message, width)}
+	}
+
+	widths := make([]int, len(p.header))
+	for i, column := range p.header {
+		widths[i] = len([]rune(column))
+	}
+	for _, row := range p.rows {
+		for i, cell := range row.cells {
+			if i < len(widths) && len([]rune(cell)) > widths[i] {
+				widths[i] = len([]rune(cell))
+			}
+		}
+	}
+	format := func(cells []string) string {
+		var b strings.Builder
+		for i, cell := range cells {
+			if i < len(cells)-1 && i < len(widths) {
+				b.WriteString(pad(cell, widths[i]) + "  ")
+			} else {
+				b.WriteString(cell)
+			}
+		}
+		return truncate(b.String(), width)
+	}
+
+	lines := []string{bold + format(p.header) + resetStyle}
+	visible := len(p.rows)
+	if height > chromeLines && visible > height-chromeLines {
+		visible = height - chromeLines
+	}
+	// Scroll so that the cursor stays visible
+	first := 0
+	cursor := d.cursors[d.active]
+	if cursor >= visible {
+		first = cursor - visible + 1
+	}
+	for i := first; i < first+visible && i < len(p.rows); i++ {
+		line := format(p.rows[i].cells)
+		if i == cursor {
+			line = reverseVideo + line + resetStyle
+		}
+		lines = append(lines, line)
+	}
+	if p.message != "" {
+		lines = append(lines, truncate(p.message, width))
+	}
+	return lines
+}
+
+func pad(s string, width int) string {
+	if n := len([]rune(s)); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// parseKeys splits the bytes read from a raw terminal into key presses
+func parseKeys(input []byte) []string {
+	var keys []string
+	for len(input) > 0 {
+		switch {
+		case bytes.HasPrefix(input, []byte("\x1b[A")):
+			keys, input = append(keys, keyUp), input[3:]
+		case bytes.HasPrefix(input, []byte("\x1b[B")):
+			keys, input = append(keys, keyDown), input[3:]
+		case bytes.HasPrefix(input, []byte("\x1b[C")):
+			keys, input = append(keys, keyRight), input[3:]
+		case bytes.HasPrefix(input, []byte("\x1b[D")):
+			keys, input = append(keys, keyLeft), input[3:]
+		case bytes.HasPrefix(input, []byte("\x1b[Z")):
+			keys, input = append(keys, keyShiftTab), input[3:]
+		case input[0] == '\x1b':
+			keys, input = append(keys, keyEscape), input[1:]
+		case input[0] == '\t':
+			keys, input = append(keys, keyTab), input[1:]
+		case input[0] == '\r' || input[0] == '\n':
+			keys, input = append(keys, keyEnter), input[1:]
+		case input[0] == 3:
+			keys, input = append(keys, keyQuit), input[1:]
+		default:
+			keys, input = append(keys, string(input[:1])), input[1:]
+		}
+	}
+	return keys
+}
+
+// runTUI shows the dashboard until it is quit, load is called again to refresh the panels
+func runTUI(in *os.File, out *os.File, load func() []panel) error {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return fmt.Errorf("cannot set up the terminal: %w", err)
+	}
+	defer func() {
+		_, _ = io.WriteString(out, exitAltScreen)
+		_ = term.Restore(int(in.Fd()), state)
+	}()
+	_, _ = io.WriteString(out, enterAltScreen)
+
+	draw := func(d *dashboard) {
+		width, height, err := term.GetSize(int(out.Fd()))
+		if err != nil {
+			width, height = 120, 40
+		}
+		_, _ = io.WriteString(out, clearScreen+d.render(width, height))
+	}
+
+	d := newDashboard(nil)
+	d.status = "Loading..."
+	draw(d)
+	d.reload(load())
+
+	buf := make([]byte, 64)
+	for {
+		draw(d)
+		n, err := in.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			quit, refresh := d.handle(key)
+			if quit {
+				return nil
+			}
+			if refresh {
+				d.status = "Refreshing..."
+				draw(d)
+				d.reload(load())
+			}
+		}
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -19,9 +20,9 @@ func newCmdDocs(streams genericclioptions.IOStreams) *cobra.Command {
 		DisableFlagsInUseLine: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) != 1 {
-				cmdutil.CheckErr(cmdutil.UsageErrorf(cmd, "directory name is needed"))
+				osdctlErrors.CheckErr(cmdutil.UsageErrorf(cmd, "directory name is needed"))
 			}
-			cmdutil.CheckErr(doc.GenMarkdownTree(cmd.Root(), args[0]))
+			osdctlErrors.CheckErr(doc.GenMarkdownTree(cmd.Root(), args[0]))
 			fmt.Fprintln(streams.Out, "Documents generated successfully on", args[0])
 		},
 	}
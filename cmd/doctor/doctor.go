@@ -0,0 +1,422 @@
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/PagerDuty/go-pagerduty"
+	jira "github.com/andygrunwald/go-jira"
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/openshift/osdctl/cmd/cluster"
+	"github.com/openshift/osdctl/cmd/common"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	doctorLong = `Checks the local setup osdctl depends on and prints a checklist with a hint to fix each failed check:
+
+  * osdctl is the latest release
+  * logged in to OCM
+  * the backplane API and the proxies are reachable
+  * the jump role can be assumed with the AWS profile
+  * the PagerDuty and Jira tokens are set and accepted
+
+The command fails when a check fails. Warnings are for what's only needed by some commands.`
+
+	doctorExample = `
+  # Check the setup
+  osdctl doctor
+
+  # Using another AWS profile, as JSON
+  osdctl doctor --aws-profile osd-staging -o json`
+
+	statusPass = "PASS"
+	statusWarn = "WARN"
+	statusFail = "FAIL"
+	statusSkip = "SKIP"
+
+	checkTimeout = 10 * time.Second
+
+	jiraURL = "https://issues.redhat.com/"
+)
+
+type doctorOptions struct {
+	profile string
+	output  string
+}
+
+// NewCmdDoctor implements the doctor command which validates the local setup
+func NewCmdDoctor(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &doctorOptions{}
+	doctorCmd := &cobra.Command{
+		Use:               "doctor",
+		Short:             "Validate the local setup: OCM login, backplane, AWS, tokens, proxies and version",
+		Long:              doctorLong,
+		Example:           doctorExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			ops.output = globalOpts.Output
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	doctorCmd.Flags().StringVarP(&ops.profile, "aws-profile", "p", "", "AWS profile to check, the default profile when empty")
+
+	return doctorCmd
+}
+
+// checkResult is the outcome of a check, Hint tells how to fix it
+type checkResult struct {
+	Check   string `json:"check" yaml:"check"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+	Hint    string `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+type doctorResponse struct {
+	Checks []checkResult `json:"checks" yaml:"checks"`
+}
+
+// String renders the checklist as a table, followed by the hints
+func (r doctorResponse) String() string {
+	var b strings.Builder
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Check", "Status", "Details"})
+	var hints []string
+	for _, c := range r.Checks {
+		table.AddRow([]string{c.Check, c.Status, c.Message})
+		if c.Hint != "" {
+			hints = append(hints, fmt.Sprintf("  * %s: %s", c.Check, c.Hint))
+		}
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+
+	if len(hints) > 0 {
+		b.WriteString("To fix:\n")
+		b.WriteString(strings.Join(hints, "\n"))
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (r doctorResponse) failed() int {
+	failed := 0
+	for _, c := range r.Checks {
+		if c.Status == statusFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (o *doctorOptions) run() error {
+	var resp doctorResponse
+
+	latest, err := utils.GetLatestVersion()
+	resp.Checks = append(resp.Checks, checkVersion(utils.Version, latest, err))
+
+	ocmCheck, loggedIn := checkOCM()
+	resp.Checks = append(resp.Checks, ocmCheck)
+
+	backplaneConfig, err := readBackplaneConfig(backplaneConfigPath())
+	if err != nil {
+		resp.Checks = append(resp.Checks, checkResult{Check: "Backplane", Status: statusFail, Message: err.Error(),
+			Hint: "fix or remove the backplane config file, see 'ocm backplane config'"})
+	} else {
+		resp.Checks = append(resp.Checks, checkBackplane(backplaneConfig))
+	}
+	resp.Checks = append(resp.Checks, checkProxies(proxySettings(backplaneConfig), dialProxy)...)
+
+	if loggedIn {
+		resp.Checks = append(resp.Checks, checkJumpRole(o.profile))
+	} else {
+		resp.Checks = append(resp.Checks, checkResult{Check: "AWS jump role", Status: statusSkip, Message: "needs to be logged in to OCM"})
+	}
+
+	resp.Checks = append(resp.Checks, checkPagerDuty(), checkJira())
+
+	if err := outputflag.PrintResponse(o.output, resp); err != nil {
+		return err
+	}
+	if failed := resp.failed(); failed > 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "%d checks failed", failed)
+	}
+	return nil
+}
+
+func checkVersion(current, latest string, err error) checkResult {
+	c := checkResult{Check: "osdctl version"}
+	if err != nil {
+		c.Status, c.Message = statusWarn, fmt.Sprintf("%s, can't get the latest release: %v", current, err)
+		return c
+	}
+	latest = strings.TrimPrefix(latest, "v")
+	if current != latest {
+		c.Status, c.Message, c.Hint = statusWarn, fmt.Sprintf("%s, the latest release is %s", current, latest), "run 'osdctl upgrade'"
+		return c
+	}
+	c.Status, c.Message = statusPass, current+" is the latest release"
+	return c
+}
+
+// checkOCM checks that the OCM token is accepted, and returns whether osdctl is logged in
+func checkOCM() (checkResult, bool) {
+	c := checkResult{Check: "OCM login"}
+	if _, err := utils.GetOCMURL(); err != nil {
+		c.Status, c.Message, c.Hint = statusFail, err.Error(), "run 'ocm login --use-auth-code --url production'"
+		return c, false
+	}
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	response, err := connection.AccountsMgmt().V1().CurrentAccount().Get().Send()
+	if err != nil {
+		c.Status, c.Message, c.Hint = statusFail, fmt.Sprintf("the token isn't accepted by %s: %v", connection.URL(), err), "log in to OCM again with 'ocm login'"
+		return c, false
+	}
+	c.Status = statusPass
+	c.Message = fmt.Sprintf("%s on %s (%s)", response.Body().Username(), connection.URL(), utils.GetCurrentOCMEnv(connection))
+	return c, true
+}
+
+// backplaneConfig holds the fields of the backplane-cli config file osdctl depends on
+type backplaneConfig struct {
+	URL      string `json:"url"`
+	ProxyURL string `json:"proxy-url"`
+}
+
+// backplaneConfigPath returns the path of the backplane-cli config file, BACKPLANE_CONFIG overrides it
+func backplaneConfigPath() string {
+	if path := os.Getenv("BACKPLANE_CONFIG"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "backplane", "config.json")
+}
+
+// readBackplaneConfig returns an empty config when the file doesn't exist
+func readBackplaneConfig(path string) (backplaneConfig, error) {
+	var config backplaneConfig
+	if path == "" {
+		return config, nil
+	}
+	content, err := os.ReadFile(path) //#nosec G304 -- the path is the user's backplane config
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// checkBackplane checks that the backplane API answers, any HTTP answer means it's reachable
+func checkBackplane(config backplaneConfig) checkResult {
+	c := checkResult{Check: "Backplane"}
+	if config.URL == "" {
+		c.Status, c.Message = statusWarn, "no backplane URL in the backplane config, not checked"
+		return c
+	}
+	client := &http.Client{Timeout: checkTimeout}
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err == nil {
+			client.Transport = &http.Transport{Proxy: http.ProxyURL(proxy)}
+		}
+	}
+	response, err := client.Get(config.URL)
+	if err != nil {
+		c.Status, c.Message, c.Hint = statusFail, fmt.Sprintf("%s is unreachable: %v", config.URL, err), "connect to the VPN and check the proxy-url of the backplane config"
+		return c
+	}
+	response.Body.Close()
+	c.Status, c.Message = statusPass, fmt.Sprintf("%s answered HTTP %d", config.URL, response.StatusCode)
+	return c
+}
+
+// proxySetting is a proxy osdctl or backplane goes through
+type proxySetting struct {
+	source string
+	url    string
+}
+
+// proxySettings returns the proxies of the environment and of the backplane config
+func proxySettings(config backplaneConfig) []proxySetting {
+	var proxies []proxySetting
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if value := os.Getenv(name); value != "" {
+			proxies = append(proxies, proxySetting{source: name, url: value})
+		}
+	}
+	if config.ProxyURL != "" {
+		proxies = append(proxies, proxySetting{source: "backplane proxy-url", url: config.ProxyURL})
+	}
+	return proxies
+}
+
+func dialProxy(address string) error {
+	connection, err := net.DialTimeout("tcp", address, checkTimeout)
+	if err != nil {
+		return err
+	}
+	return connection.Close()
+}
+
+// checkProxies checks that every proxy is a valid URL whose host accepts connections
+func checkProxies(proxies []proxySetting, dial func(address string) error) []checkResult {
+	if len(proxies) == 0 {
+		return []checkResult{{Check: "Proxy", Status: statusPass, Message: "no proxy configured, connecting directly"}}
+	}
+	var checks []checkResult
+	for _, proxy := range proxies {
+		c := checkResult{Check: "Proxy (" + proxy.source + ")"}
+		u, err := url.Parse(proxy.url)
+		if err != nil || u.Host == "" {
+			c.Status, c.Message, c.Hint = statusFail, fmt.Sprintf("'%s' isn't a valid URL", proxy.url), "set it to e.g. http://proxy.example.com:3128"
+			checks = append(checks, c)
+			continue
+		}
+		address := u.Host
+		if u.Port() == "" {
+			port := "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+			address = net.JoinHostPort(u.Hostname(), port)
+		}
+		if err := dial(address); err != nil {
+			c.Status, c.Message, c.Hint = statusFail, fmt.Sprintf("can't connect to %s: %v", address, err), "connect to the VPN, or unset the proxy"
+		} else {
+			c.Status, c.Message = statusPass, address+" accepts connections"
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkJumpRole assumes the role chain up to the jump role, see osdCloud.GenerateJumpRoleCredentials
+func checkJumpRole(profile string) checkResult {
+	c := checkResult{Check: "AWS jump role"}
+	client, err := awsprovider.NewAwsClient(profile, common.DefaultRegion, "")
+	if err != nil {
+		c.Status, c.Message, c.Hint = statusFail, err.Error(), "refresh the credentials of the AWS profile"
+		return c
+	}
+	sessionName, err := osdCloud.GenerateRoleSessionName(client)
+	if err != nil {
+		c.Status, c.Message, c.Hint = statusFail, fmt.Sprintf("can't generate the role session name: %v", err), "use the profile of your own IAM user"
+		return c
+	}
+	credentials, err := osdCloud.GenerateJumpRoleCredentials(client, "", common.DefaultRegion, sessionName)
+	if err != nil {
+		c.Status, c.Message = statusFail, fmt.Sprintf("can't assume the jump role: %v", err)
+		c.Hint = fmt.Sprintf("check '%s' and '%s' in the config file, and that your IAM user may assume %s",
+			osdCloud.ProdJumproleConfigKey, osdCloud.StageJumproleConfigKey, osdCloud.RhSreCcsAccessRolename)
+		return c
+	}
+	c.Status = statusPass
+	c.Message = fmt.Sprintf("assumed as %s, valid until %s", sessionName, timefmt.Format(awsSdk.TimeValue(credentials.Expiration)))
+	return c
+}
+
+// lookupSecret returns the token stored with 'osdctl secrets set' or set in the config file
+func lookupSecret(key string) (string, error) {
+	token, err := secrets.Lookup(key)
+	if err != nil || token != "" {
+		return token, err
+	}
+	return viper.GetString(key), nil
+}
+
+func checkPagerDuty() checkResult {
+	c := checkResult{Check: "PagerDuty token"}
+	var client *pagerduty.Client
+	for _, token := range []struct {
+		key    string
+		client func(string) *pagerduty.Client
+	}{
+		{secrets.PagerDutyOauthTokenKey, func(t string) *pagerduty.Client { return pagerduty.NewOAuthClient(t) }},
+		{secrets.PagerDutyUserTokenKey, func(t string) *pagerduty.Client { return pagerduty.NewClient(t) }},
+	} {
+		value, err := lookupSecret(token.key)
+		if err != nil {
+			c.Status, c.Message, c.Hint = statusFail, err.Error(), "check the secrets backend with 'osdctl secrets status'"
+			return c
+		}
+		if value != "" {
+			client = token.client(value)
+			c.Message = token.key
+			break
+		}
+	}
+	if client == nil {
+		c.Status, c.Message = statusWarn, "not set, 'osdctl cluster context' can't list the alerts"
+		c.Hint = fmt.Sprintf("generate one at %s and store it with 'osdctl secrets set %s'", cluster.PagerDutyTokenRegistrationUrl, secrets.PagerDutyOauthTokenKey)
+		return c
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	if _, err := client.ListAbilitiesWithContext(ctx); err != nil {
+		c.Status, c.Message, c.Hint = statusFail, fmt.Sprintf("%s isn't accepted: %v", c.Message, err), "generate a new token at "+cluster.PagerDutyTokenRegistrationUrl
+		return c
+	}
+	c.Status, c.Message = statusPass, c.Message+" is accepted"
+	return c
+}
+
+func checkJira() checkResult {
+	c := checkResult{Check: "Jira token"}
+	token, err := lookupSecret(secrets.JiraTokenKey)
+	if err != nil {
+		c.Status, c.Message, c.Hint = statusFail, err.Error(), "check the secrets backend with 'osdctl secrets status'"
+		return c
+	}
+	if token == "" {
+		c.Status, c.Message = statusWarn, "not set, 'osdctl cluster context' can't list the issues"
+		c.Hint = fmt.Sprintf("register one at %s and store it with 'osdctl secrets set %s'", cluster.JiraTokenRegistrationUrl, secrets.JiraTokenKey)
+		return c
+	}
+
+	transport := jira.PATAuthTransport{Token: token}
+	httpClient := transport.Client()
+	httpClient.Timeout = checkTimeout
+	client, err := jira.NewClient(httpClient, jiraURL)
+	if err != nil {
+		c.Status, c.Message = statusFail, err.Error()
+		return c
+	}
+	user, _, err := client.User.GetSelf()
+	if err != nil {
+		c.Status, c.Message, c.Hint = statusFail, fmt.Sprintf("isn't accepted: %v", err), "register a new token at "+cluster.JiraTokenRegistrationUrl
+		return c
+	}
+	c.Status, c.Message = statusPass, "accepted for "+user.Name
+	return c
+}
@@ -0,0 +1,88 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	if c := checkVersion("0.30.0", "v0.30.0", nil); c.Status != statusPass {
+		t.Errorf("expected the latest release to pass, got %+v", c)
+	}
+	if c := checkVersion("0.29.0", "v0.30.0", nil); c.Status != statusWarn || c.Hint == "" {
+		t.Errorf("expected an outdated release to warn with a hint, got %+v", c)
+	}
+	if c := checkVersion("0.30.0", "", errors.New("offline")); c.Status != statusWarn {
+		t.Errorf("expected a warning when the latest release is unknown, got %+v", c)
+	}
+}
+
+func TestReadBackplaneConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	config, err := readBackplaneConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || config != (backplaneConfig{}) {
+		t.Errorf("expected an empty config for a missing file, got %+v (err %v)", config, err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"url": "https://backplane.example.com", "proxy-url": "http://proxy.example.com:3128"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err = readBackplaneConfig(path)
+	if err != nil || config.URL != "https://backplane.example.com" || config.ProxyURL != "http://proxy.example.com:3128" {
+		t.Errorf("unexpected config %+v (err %v)", config, err)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBackplaneConfig(path); err == nil {
+		t.Error("expected an error for an invalid config")
+	}
+}
+
+func TestCheckProxies(t *testing.T) {
+	if checks := checkProxies(nil, nil); len(checks) != 1 || checks[0].Status != statusPass {
+		t.Errorf("expected a single passing check without proxy, got %+v", checks)
+	}
+
+	var dialed []string
+	dial := func(address string) error {
+		dialed = append(dialed, address)
+		if strings.HasPrefix(address, "down") {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+	checks := checkProxies([]proxySetting{
+		{source: "HTTPS_PROXY", url: "http://up.example.com:3128"},
+		{source: "backplane proxy-url", url: "https://down.example.com"},
+		{source: "http_proxy", url: "not a url"},
+	}, dial)
+
+	statuses := []string{checks[0].Status, checks[1].Status, checks[2].Status}
+	if strings.Join(statuses, ",") != "PASS,FAIL,FAIL" {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+	if strings.Join(dialed, ",") != "up.example.com:3128,down.example.com:443" {
+		t.Errorf("unexpected dialed addresses %v", dialed)
+	}
+}
+
+func TestDoctorResponseString(t *testing.T) {
+	resp := doctorResponse{Checks: []checkResult{
+		{Check: "OCM login", Status: statusPass, Message: "me on https://api.openshift.com (production)"},
+		{Check: "Jira token", Status: statusWarn, Message: "not set", Hint: "store it with 'osdctl secrets set jira_token'"},
+	}}
+	out := resp.String()
+	if !strings.Contains(out, "OCM login") || !strings.Contains(out, "* Jira token: store it") {
+		t.Errorf("unexpected output %q", out)
+	}
+	if resp.failed() != 0 {
+		t.Error("expected warnings not to fail")
+	}
+}
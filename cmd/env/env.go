@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,8 +13,11 @@ import (
 
 	ocmconfig "github.com/openshift-online/ocm-cli/pkg/config"
 	config "github.com/openshift/osdctl/pkg/envConfig"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 type Options struct {
@@ -42,6 +44,8 @@ type OcEnv struct {
 	Exists  bool
 	Options *Options
 	Config  config.Config
+
+	genericclioptions.IOStreams
 }
 
 var Config_Filepath = "/.osdctl.yaml"
@@ -51,15 +55,18 @@ func NewCmdEnv(streams genericclioptions.IOStreams, flags *genericclioptions.Con
 	config := config.LoadYaml(Config_Filepath)
 
 	env := OcEnv{
-		Options: &options,
-		Config:  config,
+		Options:   &options,
+		Config:    config,
+		IOStreams: streams,
 	}
 	envCmd := &cobra.Command{
 		Use:               "env [flags] [env-alias]",
 		Short:             "Create an environment to interact with a cluster",
 		Args:              cobra.MaximumNArgs(1),
 		DisableAutoGenTag: true,
-		Run:               env.RunCommand,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(env.RunCommand(cmd, args))
+		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			validEnvs := []string{}
 			files, err := os.ReadDir(os.Getenv("HOME") + "/ocenv/")
@@ -91,17 +98,12 @@ func NewCmdEnv(streams genericclioptions.IOStreams, flags *genericclioptions.Con
 	return envCmd
 }
 
-func (e *OcEnv) RunCommand(cmd *cobra.Command, args []string) {
+func (e *OcEnv) RunCommand(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		e.Options.Alias = args[0]
 	}
 	if e.Options.ClusterId == "" && e.Options.Alias == "" {
-		err := cmd.Help()
-		if err != nil {
-			fmt.Println("could not print help")
-			return
-		}
-		log.Fatal("ClusterId or Alias required")
+		return cmdutil.UsageErrorf(cmd, "ClusterId or Alias required")
 	}
 
 	if e.Options.Alias == "" {
@@ -110,40 +112,54 @@ func (e *OcEnv) RunCommand(cmd *cobra.Command, args []string) {
 	}
 
 	e.Path = os.Getenv("HOME") + "/ocenv/" + e.Options.Alias
-	e.Setup()
+	if err := e.Setup(); err != nil {
+		return err
+	}
 
 	if e.Options.DeleteEnv {
 		e.Delete()
-		return
+		return nil
 	}
 	if e.Options.ExportKubeConfig {
 		e.PrintKubeConfigExport()
-		return
+		return nil
+	}
+	if err := e.Start(); err != nil {
+		return err
 	}
-	e.Start()
 	if e.Options.TempEnv {
 		e.Delete()
 	}
+	return nil
 }
 
-func (e *OcEnv) Setup() {
+func (e *OcEnv) Setup() error {
 	if e.Options.ResetEnv {
 		e.Delete()
 	}
-	e.ensureEnvDir()
+	if err := e.ensureEnvDir(); err != nil {
+		return err
+	}
 	if !e.Exists || e.Options.ResetEnv {
 		fmt.Println("Setting up environment...")
-		e.createBins()
-		e.ensureEnvVariables()
-		e.createKubeconfig()
+		if err := e.createBins(); err != nil {
+			return err
+		}
+		if err := e.ensureEnvVariables(); err != nil {
+			return err
+		}
+		if err := e.createKubeconfig(); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func (e *OcEnv) PrintKubeConfigExport() {
 	fmt.Printf("export KUBECONFIG=%s\n", e.Path+"/kubeconfig.json")
 }
 
-func (e *OcEnv) Start() {
+func (e *OcEnv) Start() error {
 	shell := os.Getenv("SHELL")
 
 	fmt.Print("Switching to OpenShift environment " + e.Options.Alias + "\n")
@@ -154,11 +170,11 @@ func (e *OcEnv) Start() {
 	path := filepath.Clean(e.Path + "/.ocenv")
 	file, err := os.Open(path)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Println("Error closing file: ", path)
+			fmt.Fprintln(e.ErrOut, "Error closing file: ", path)
 			return
 		}
 	}()
@@ -174,27 +190,29 @@ func (e *OcEnv) Start() {
 	cmd.Dir = e.Path
 	_ = cmd.Run() // add error checking
 
-	e.killChildren()
+	if err := e.killChildren(); err != nil {
+		return err
+	}
 
 	fmt.Printf("Exited OpenShift environment\n")
-
+	return nil
 }
 
-func (e *OcEnv) killChildren() {
+func (e *OcEnv) killChildren() error {
 	path := filepath.Join(e.Path, "/.killpds")
 	file, err := os.Open(path) //#nosec G304 -- Potential file inclusion via variable
 
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			fmt.Println("Nothing to kill")
-			return
+			return nil
 		}
-		log.Fatalf("Failed to read file .killpids: %v", err)
+		return fmt.Errorf("failed to read file .killpids: %v", err)
 	}
 	defer func(file *os.File) {
 		err := file.Close()
 		if err != nil {
-			fmt.Println("Error while closing file: ", path)
+			fmt.Fprintln(e.ErrOut, "Error while closing file: ", path)
 			return
 		}
 	}(file)
@@ -223,32 +241,28 @@ func (e *OcEnv) killChildren() {
 	err = os.Remove(path)
 	if err != nil {
 		log.Printf("failed to delete .killpids, you may need to clean it up manually: %v\n", err)
-		return
 	}
-
+	return nil
 }
 func (e *OcEnv) Delete() {
 	fmt.Printf("Cleaning up OpenShift environment %s\n", e.Options.Alias)
 	err := os.RemoveAll(e.Path)
 	if err != nil {
-		fmt.Println("Error while calling os.RemoveAll", err.Error())
+		fmt.Fprintln(e.ErrOut, "Error while calling os.RemoveAll", err.Error())
 		return
 	}
 	return
 }
 
-func (e *OcEnv) ensureEnvDir() {
+func (e *OcEnv) ensureEnvDir() error {
 	if _, err := os.Stat(e.Path); errors.Is(err, os.ErrNotExist) {
-		err := os.MkdirAll(e.Path, os.ModePerm)
-		if err != nil {
-			log.Fatal(err)
-		}
-		return
+		return os.MkdirAll(e.Path, os.ModePerm)
 	}
 	e.Exists = true
+	return nil
 }
 
-func (e *OcEnv) ensureEnvVariables() {
+func (e *OcEnv) ensureEnvVariables() error {
 	envContent := `
 KUBECONFIG=` + e.Path + `/kubeconfig.json
 OCM_CONFIG=` + e.Path + `/ocm.json
@@ -257,46 +271,62 @@ PATH=` + e.Path + `/bin:` + os.Getenv("PATH") + `
 	if e.Options.ClusterId != "" {
 		envContent = envContent + "CLUSTERID=" + e.Options.ClusterId + "\n"
 	}
-	direnvfile := e.ensureFile(e.Path + "/.ocenv")
-	_, err := direnvfile.WriteString(envContent)
+	direnvfile, err := e.ensureFile(e.Path + "/.ocenv")
+	if err != nil {
+		return err
+	}
+	_, err = direnvfile.WriteString(envContent)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer func(direnvfile *os.File) {
 		err := direnvfile.Close()
 		if err != nil {
-			fmt.Println("Error while calling direnvFile.Close(): ", err.Error())
+			fmt.Fprintln(e.ErrOut, "Error while calling direnvFile.Close(): ", err.Error())
 			return
 		}
 	}(direnvfile)
 
-	zshenvfile := e.ensureFile(e.Path + "/.zshenv")
+	zshenvfile, err := e.ensureFile(e.Path + "/.zshenv")
+	if err != nil {
+		return err
+	}
 	_, err = zshenvfile.WriteString("source .ocenv")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer func(direnvfile *os.File) {
 		err := direnvfile.Close()
 		if err != nil {
-			fmt.Println("Error while calling direnvFile.Close(): ", err.Error())
+			fmt.Fprintln(e.ErrOut, "Error while calling direnvFile.Close(): ", err.Error())
 			return
 		}
 	}(direnvfile)
-	return
+	return nil
 }
 
-func (e *OcEnv) createBins() {
+func (e *OcEnv) createBins() error {
 	if _, err := os.Stat(e.binPath()); errors.Is(err, os.ErrNotExist) {
 		err := os.Mkdir(e.binPath(), os.ModePerm)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 	}
-	e.createBin("oct", "ocm tunnel "+e.Options.ClusterId)
+	if err := e.createBin("oct", "ocm tunnel "+e.Options.ClusterId); err != nil {
+		return err
+	}
 	if e.Options.Kubeconfig == "" {
-		e.createBin("ocl", e.generateLoginCommand())
+		loginCommand, err := e.generateLoginCommand()
+		if err != nil {
+			return err
+		}
+		if err := e.createBin("ocl", loginCommand); err != nil {
+			return err
+		}
+	}
+	if err := e.createBin("ocd", "ocm describe cluster "+e.Options.ClusterId); err != nil {
+		return err
 	}
-	e.createBin("ocd", "ocm describe cluster "+e.Options.ClusterId)
 	loginScript := e.getLoginScript()
 	ocb := `
 #!/bin/bash
@@ -320,26 +350,26 @@ echo $! >> .killpids
 sleep 5s
 ocm backplane login ` + e.Options.ClusterId + `
 `
-	e.createBin("ocb", ocb)
+	return e.createBin("ocb", ocb)
 }
 
-func (e *OcEnv) generateLoginCommand() string {
+func (e *OcEnv) generateLoginCommand() (string, error) {
 	if e.Options.Username != "" {
 		return e.generateLoginCommandIndividualCluster()
 	}
-	return "ocm cluster login --token " + e.Options.ClusterId
+	return "ocm cluster login --token " + e.Options.ClusterId, nil
 }
 
-func (e *OcEnv) generateLoginCommandIndividualCluster() string {
+func (e *OcEnv) generateLoginCommandIndividualCluster() (string, error) {
 	if e.Options.Url == "" {
-		panic("Username set but no API Url. Use --api to specify it.")
+		return "", osdctlErrors.New(osdctlErrors.ErrValidation, "Username set but no API Url. Use --api to specify it.")
 	}
 	cmd := "oc login -u " + e.Options.Username
 	if e.Options.Password != "" {
 		cmd += " -p " + e.Options.Password
 	}
 	cmd += " " + e.Options.Url
-	return cmd
+	return cmd, nil
 }
 
 func (e *OcEnv) getLoginScript() string {
@@ -349,7 +379,7 @@ func (e *OcEnv) getLoginScript() string {
 	}
 	cfg, err := ocmconfig.Load()
 	if err != nil || cfg == nil {
-		fmt.Println("Can't read ocm config. Ignoring.")
+		fmt.Fprintln(e.ErrOut, "Can't read ocm config. Ignoring.")
 		return ""
 	}
 	if val, ok := e.Config.LoginScripts[cfg.URL]; ok {
@@ -359,52 +389,56 @@ func (e *OcEnv) getLoginScript() string {
 	return ""
 }
 
-func (e *OcEnv) createBin(cmd, content string) {
+func (e *OcEnv) createBin(cmd, content string) error {
 	path := filepath.Join(e.binPath(), cmd)
-	scriptfile := e.ensureFile(path)
+	scriptfile, err := e.ensureFile(path)
+	if err != nil {
+		return err
+	}
 	defer func(scriptfile *os.File) {
 		err := scriptfile.Close()
 		if err != nil {
-			fmt.Println("Error closing file: ", path)
+			fmt.Fprintln(e.ErrOut, "Error closing file: ", path)
 			return
 		}
 	}(scriptfile)
-	_, err := scriptfile.WriteString(content)
+	_, err = scriptfile.WriteString(content)
 	if err != nil {
-		panic(fmt.Errorf("error writing to file %s: %v", path, err))
+		return fmt.Errorf("error writing to file %s: %v", path, err)
 	}
 	err = os.Chmod(path, 0700) //#nosec G302 -- Expect file permissions to be 0600 or less, not applicable here, because it's an executable
 	if err != nil {
-		log.Fatalf("Can't update permissions on file %s: %v", path, err)
-		return
+		return fmt.Errorf("can't update permissions on file %s: %v", path, err)
 	}
+	return nil
 }
 
-func (e *OcEnv) createKubeconfig() {
+func (e *OcEnv) createKubeconfig() error {
 	if e.Options.Kubeconfig != "" {
 		input, err := os.ReadFile(e.Options.Kubeconfig)
 		if err != nil {
 			fmt.Println(err)
-			return
+			return nil
 		}
 
 		path := filepath.Join(e.Path, "/kubeconfig.json")
 		err = os.WriteFile(path, input, 0600)
 		if err != nil {
-			panic(fmt.Errorf("error creating %s: %v", path, err))
+			return fmt.Errorf("error creating %s: %v", path, err)
 		}
 	}
+	return nil
 }
 
-func (e *OcEnv) ensureFile(filename string) (file *os.File) {
+func (e *OcEnv) ensureFile(filename string) (file *os.File, err error) {
 	filename = filepath.Clean(filename)
 	if _, err := os.Stat(filename); errors.Is(err, os.ErrNotExist) {
 		file, err = os.Create(filename) //#nosec G304 -- Potential file inclusion via variable
 		if err != nil {
-			log.Fatalf("Can't create file %s: %v", filename, err)
+			return nil, fmt.Errorf("can't create file %s: %v", filename, err)
 		}
 	}
-	return
+	return file, nil
 }
 
 func (e *OcEnv) binPath() string {
@@ -0,0 +1,640 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/coreos/go-semver/semver"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/flagtypes"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/scheduler"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	complianceLong = `Evaluates the checks of a policy file against every cluster you can access and reports a compliance score.
+
+The policy file lists the checks, each with a weight (1 by default) counting in the score of the clusters:
+
+  search: "state = 'ready' and product.id = 'rosa'"
+  checks:
+    - name: supported-version
+      type: version_floor
+      minVersion: "4.14"
+      weight: 3
+    - name: ownership
+      type: required_labels
+      labels: [team, environment=production]
+    - name: no-stale-limited-support
+      type: limited_support_age
+      maxAge: 7d
+    - name: recent-backups
+      type: backup_recency
+      maxAge: 2d
+
+  version_floor        the OpenShift version of the cluster is at least minVersion
+  required_labels      the cluster has the subscription or cluster labels, KEY or KEY=VALUE
+  limited_support_age  the cluster had no limited support reason for longer than maxAge
+  backup_recency       the last managed Velero backup in the cluster's S3 bucket is more recent than maxAge,
+                       it requires backplane access to the AWS accounts and is skipped for hosted control planes
+
+A check passes, fails, is skipped when it doesn't apply to the cluster, or errors when its data can't be read. The
+score of a cluster is the weight of the checks it passes over the weight of the checks applying to it, errors
+counting as failures. The score of the fleet is the average score of its clusters.`
+
+	complianceExample = `
+  # Human summary of the fleet
+  osdctl fleet compliance --policy-file policies.yaml
+
+  # Every result, one row per cluster and check
+  osdctl fleet compliance --policy-file policies.yaml -o csv > compliance.csv
+
+  # The report of the clusters of an organization, as JSON
+  osdctl fleet compliance --policy-file policies.yaml --search "organization.id = '1a2b3c'" -o json
+`
+
+	defaultComplianceSearch = "state = 'ready'"
+	compliancePageSize      = 100
+
+	checkVersionFloor       = "version_floor"
+	checkRequiredLabels     = "required_labels"
+	checkLimitedSupportAge  = "limited_support_age"
+	checkBackupRecency      = "backup_recency"
+	managedVeleroBucketName = "managed-velero-backups-"
+
+	checkPass  = "pass"
+	checkFail  = "fail"
+	checkSkip  = "skip"
+	checkError = "error"
+)
+
+// veleroBackupTimestamp is the suffix velero adds to the backups of a schedule, e.g. daily-full-backup-20261014020012
+var veleroBackupTimestamp = regexp.MustCompile(`-([0-9]{14})$`)
+
+// complianceCSVColumns are the columns of the CSV output, a row per cluster and check
+var complianceCSVColumns = []string{"cluster_id", "cluster_name", "cluster_score", "check", "type", "status", "detail"}
+
+// compliancePolicy is the policy file
+type compliancePolicy struct {
+	Search string            `json:"search,omitempty"`
+	Checks []complianceCheck `json:"checks"`
+}
+
+type complianceCheck struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Weight     int      `json:"weight,omitempty"`
+	MinVersion string   `json:"minVersion,omitempty"`
+	Labels     []string `json:"labels,omitempty"`
+	MaxAge     string   `json:"maxAge,omitempty"`
+
+	minVersion *semver.Version
+	maxAge     time.Duration
+}
+
+type complianceOptions struct {
+	policyFile string
+	search     string
+	parallel   int
+	columns    []string
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// checkResult is the outcome of a check for a cluster
+type checkResult struct {
+	Check  string `json:"check" yaml:"check"`
+	Type   string `json:"type" yaml:"type"`
+	Status string `json:"status" yaml:"status"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+type clusterCompliance struct {
+	ClusterID   string        `json:"cluster_id" yaml:"cluster_id"`
+	ClusterName string        `json:"cluster_name" yaml:"cluster_name"`
+	Score       float64       `json:"score" yaml:"score"`
+	Results     []checkResult `json:"results" yaml:"results"`
+}
+
+// checkSummary counts the outcomes of a check over the fleet
+type checkSummary struct {
+	Check   string `json:"check" yaml:"check"`
+	Type    string `json:"type" yaml:"type"`
+	Weight  int    `json:"weight" yaml:"weight"`
+	Passed  int    `json:"passed" yaml:"passed"`
+	Failed  int    `json:"failed" yaml:"failed"`
+	Skipped int    `json:"skipped" yaml:"skipped"`
+	Errors  int    `json:"errors" yaml:"errors"`
+}
+
+type complianceReport struct {
+	Policy      string              `json:"policy" yaml:"policy"`
+	Search      string              `json:"search" yaml:"search"`
+	GeneratedAt time.Time           `json:"generated_at" yaml:"generated_at"`
+	Score       float64             `json:"score" yaml:"score"`
+	Checks      []checkSummary      `json:"checks" yaml:"checks"`
+	Clusters    []clusterCompliance `json:"clusters" yaml:"clusters"`
+}
+
+func (r complianceReport) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Fleet compliance: %.1f%% over %d clusters (%s)\n\n", r.Score, len(r.Clusters), r.Policy)
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Check", "Type", "Weight", "Passed", "Failed", "Skipped", "Errors"})
+	for _, check := range r.Checks {
+		table.AddRow([]string{check.Check, check.Type, strconv.Itoa(check.Weight), strconv.Itoa(check.Passed), strconv.Itoa(check.Failed),
+			strconv.Itoa(check.Skipped), strconv.Itoa(check.Errors)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+
+	var noncompliant []clusterCompliance
+	for _, cluster := range r.Clusters {
+		if cluster.Score < 100 {
+			noncompliant = append(noncompliant, cluster)
+		}
+	}
+	if len(noncompliant) == 0 {
+		b.WriteString("Every cluster is compliant.\n")
+		return b.String()
+	}
+	sort.SliceStable(noncompliant, func(i, j int) bool { return noncompliant[i].Score < noncompliant[j].Score })
+
+	fmt.Fprintf(&b, "%d clusters aren't compliant:\n", len(noncompliant))
+	table = printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Cluster", "ID", "Score", "Failing checks"})
+	for _, cluster := range noncompliant {
+		var failing []string
+		for _, result := range cluster.Results {
+			if result.Status == checkFail || result.Status == checkError {
+				failing = append(failing, fmt.Sprintf("%s (%s)", result.Check, result.Detail))
+			}
+		}
+		table.AddRow([]string{cluster.ClusterName, cluster.ClusterID, fmt.Sprintf("%.1f%%", cluster.Score), strings.Join(failing, ", ")})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	return b.String()
+}
+
+func newCmdCompliance(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &complianceOptions{GlobalOptions: globalOpts}
+	complianceCmd := &cobra.Command{
+		Use:               "compliance",
+		Short:             "Scores the clusters against the checks of a policy file",
+		Long:              complianceLong,
+		Example:           complianceExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	complianceCmd.Flags().StringVar(&ops.policyFile, "policy-file", "", "YAML file listing the checks")
+	complianceCmd.Flags().StringVar(&ops.search, "search", "", fmt.Sprintf("OCM search of the clusters to evaluate, overriding the one of the policy file (default \"%s\")", defaultComplianceSearch))
+	complianceCmd.Flags().IntVar(&ops.parallel, "parallel", 5, "Number of clusters evaluated at once")
+	printer.AddColumnsFlag(complianceCmd, &ops.columns, complianceCSVColumns)
+	_ = complianceCmd.MarkFlagRequired("policy-file")
+
+	return complianceCmd
+}
+
+func (o *complianceOptions) complete(cmd *cobra.Command) error {
+	if o.parallel < 1 {
+		return cmdutil.UsageErrorf(cmd, "--parallel must be at least 1")
+	}
+	var err error
+	o.columns, err = printer.CSVColumns(cmd, complianceCSVColumns, o.columns)
+	return err
+}
+
+func (o *complianceOptions) run() error {
+	policy, err := loadCompliancePolicy(o.policyFile)
+	if err != nil {
+		return err
+	}
+	search := o.search
+	if search == "" {
+		search = policy.Search
+	}
+	if search == "" {
+		search = defaultComplianceSearch
+	}
+
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	clusters, err := listComplianceClusters(connection, search)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Evaluating %d checks on %d clusters\n", len(policy.Checks), len(clusters))
+
+	source := &ocmFactSource{connection: connection}
+	report := evaluateFleet(policy, clusters, source, o.parallel, time.Now())
+	report.Policy, report.Search = o.policyFile, search
+
+	if o.GlobalOptions.Output == "csv" {
+		return writeComplianceCSV(printer.Tee(os.Stdout), report, o.columns)
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, report)
+}
+
+func loadCompliancePolicy(path string) (*compliancePolicy, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- the policy file is given by the user
+	if err != nil {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "cannot read the policy file: %v", err)
+	}
+	policy := &compliancePolicy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "cannot parse the policy file %s: %v", path, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "invalid policy file %s: %v", path, err)
+	}
+	return policy, nil
+}
+
+// validate checks the fields of every check and parses their version and age
+func (p *compliancePolicy) validate() error {
+	if len(p.Checks) == 0 {
+		return fmt.Errorf("no check")
+	}
+	names := map[string]bool{}
+	for i := range p.Checks {
+		check := &p.Checks[i]
+		if check.Name == "" {
+			return fmt.Errorf("check #%d has no name", i+1)
+		}
+		if names[check.Name] {
+			return fmt.Errorf("two checks are named %s", check.Name)
+		}
+		names[check.Name] = true
+		if check.Weight < 0 {
+			return fmt.Errorf("check %s: the weight can't be negative", check.Name)
+		}
+		if check.Weight == 0 {
+			check.Weight = 1
+		}
+
+		var err error
+		switch check.Type {
+		case checkVersionFloor:
+			if check.minVersion, err = parseVersion(check.MinVersion); err != nil {
+				return fmt.Errorf("check %s: invalid minVersion '%s'", check.Name, check.MinVersion)
+			}
+		case checkRequiredLabels:
+			if len(check.Labels) == 0 {
+				return fmt.Errorf("check %s: no labels", check.Name)
+			}
+		case checkLimitedSupportAge, checkBackupRecency:
+			if check.maxAge, err = flagtypes.ParseDuration(check.MaxAge); err != nil {
+				return fmt.Errorf("check %s: invalid maxAge: %v", check.Name, err)
+			}
+			if check.maxAge <= 0 {
+				return fmt.Errorf("check %s: maxAge must be positive, got '%s'", check.Name, check.MaxAge)
+			}
+		default:
+			return fmt.Errorf("check %s: unknown type '%s', expected one of %s", check.Name, check.Type,
+				strings.Join([]string{checkVersionFloor, checkRequiredLabels, checkLimitedSupportAge, checkBackupRecency}, ", "))
+		}
+	}
+	return nil
+}
+
+// parseVersion accepts the partial versions, e.g. 4.14 for 4.14.0
+func parseVersion(raw string) (*semver.Version, error) {
+	raw = strings.TrimPrefix(raw, "v")
+	if parts := strings.SplitN(strings.SplitN(raw, "-", 2)[0], ".", 3); len(parts) == 2 {
+		raw = strings.Replace(raw, parts[0]+"."+parts[1], parts[0]+"."+parts[1]+".0", 1)
+	}
+	return semver.NewVersion(raw)
+}
+
+func listComplianceClusters(connection *sdk.Connection, search string) ([]*cmv1.Cluster, error) {
+	request := connection.ClustersMgmt().V1().Clusters().List().Search(search).Size(compliancePageSize).Order("name asc")
+	var clusters []*cmv1.Cluster
+	for page := 1; ; page++ {
+		response, err := request.Page(page).Send()
+		if err != nil {
+			return nil, fmt.Errorf("cannot search the clusters: %w", err)
+		}
+		clusters = append(clusters, response.Items().Slice()...)
+		if response.Size() < compliancePageSize {
+			return clusters, nil
+		}
+	}
+}
+
+// limitedSupportReason is the part of a limited support reason the checks read
+type limitedSupportReason struct {
+	Summary string
+	Created time.Time
+}
+
+// factSource reads what the checks need beyond the cluster itself
+type factSource interface {
+	labels(cluster *cmv1.Cluster) (map[string]string, error)
+	limitedSupportReasons(cluster *cmv1.Cluster) ([]limitedSupportReason, error)
+	lastBackup(cluster *cmv1.Cluster) (time.Time, error)
+}
+
+// evaluateFleet runs the checks on every cluster, parallel clusters at a time, the clusters keep their order
+func evaluateFleet(policy *compliancePolicy, clusters []*cmv1.Cluster, source factSource, parallel int, now time.Time) complianceReport {
+	report := complianceReport{GeneratedAt: now.UTC(), Clusters: make([]clusterCompliance, len(clusters))}
+
+	tasks := make([]scheduler.Task, len(clusters))
+	for i, cluster := range clusters {
+		i := i
+		tasks[i] = scheduler.Task{Cluster: cluster.ID(), Kind: scheduler.Read, Run: func(context.Context) error {
+			report.Clusters[i] = evaluateCluster(policy, clusters[i], source, now)
+			return nil
+		}}
+	}
+	evaluations := scheduler.New()
+	evaluations.Reads = parallel
+	evaluations.Run(context.Background(), tasks)
+
+	for i, check := range policy.Checks {
+		summary := checkSummary{Check: check.Name, Type: check.Type, Weight: check.Weight}
+		for _, cluster := range report.Clusters {
+			switch cluster.Results[i].Status {
+			case checkPass:
+				summary.Passed++
+			case checkFail:
+				summary.Failed++
+			case checkSkip:
+				summary.Skipped++
+			case checkError:
+				summary.Errors++
+			}
+		}
+		report.Checks = append(report.Checks, summary)
+	}
+	report.Score = 100
+	if len(report.Clusters) > 0 {
+		total := 0.0
+		for _, cluster := range report.Clusters {
+			total += cluster.Score
+		}
+		report.Score = total / float64(len(report.Clusters))
+	}
+	return report
+}
+
+func evaluateCluster(policy *compliancePolicy, cluster *cmv1.Cluster, source factSource, now time.Time) clusterCompliance {
+	compliance := clusterCompliance{ClusterID: cluster.ID(), ClusterName: cluster.Name(), Score: 100}
+	// Each source is read once, for all the checks needing it
+	var (
+		labels      map[string]string
+		labelsErr   error
+		labelsRead  bool
+		reasons     []limitedSupportReason
+		reasonsErr  error
+		reasonsRead bool
+	)
+
+	passed, applicable := 0, 0
+	for _, check := range policy.Checks {
+		var result checkResult
+		switch check.Type {
+		case checkRequiredLabels:
+			if !labelsRead {
+				labels, labelsErr = source.labels(cluster)
+				labelsRead = true
+			}
+			result = checkLabels(check, labels, labelsErr)
+		case checkLimitedSupportAge:
+			if !reasonsRead {
+				reasons, reasonsErr = clusterLimitedSupport(cluster, source)
+				reasonsRead = true
+			}
+			result = checkLimitedSupport(check, reasons, reasonsErr, now)
+		case checkBackupRecency:
+			result = checkBackup(check, cluster, source, now)
+		default:
+			result = checkVersion(check, cluster)
+		}
+		result.Check, result.Type = check.Name, check.Type
+		compliance.Results = append(compliance.Results, result)
+
+		if result.Status == checkSkip {
+			continue
+		}
+		applicable += check.Weight
+		if result.Status == checkPass {
+			passed += check.Weight
+		}
+	}
+	if applicable > 0 {
+		compliance.Score = 100 * float64(passed) / float64(applicable)
+	}
+	return compliance
+}
+
+func checkVersion(check complianceCheck, cluster *cmv1.Cluster) checkResult {
+	raw := cluster.OpenshiftVersion()
+	if raw == "" {
+		raw = cluster.Version().RawID()
+	}
+	version, err := parseVersion(raw)
+	if err != nil {
+		return checkResult{Status: checkError, Detail: fmt.Sprintf("unknown version '%s'", raw)}
+	}
+	if version.LessThan(*check.minVersion) {
+		return checkResult{Status: checkFail, Detail: fmt.Sprintf("%s is older than %s", raw, check.MinVersion)}
+	}
+	return checkResult{Status: checkPass, Detail: raw}
+}
+
+func checkLabels(check complianceCheck, labels map[string]string, err error) checkResult {
+	if err != nil {
+		return checkResult{Status: checkError, Detail: err.Error()}
+	}
+	var missing []string
+	for _, required := range check.Labels {
+		key, value, hasValue := strings.Cut(required, "=")
+		actual, ok := labels[key]
+		if !ok || (hasValue && actual != value) {
+			missing = append(missing, required)
+		}
+	}
+	if len(missing) > 0 {
+		return checkResult{Status: checkFail, Detail: "missing " + strings.Join(missing, ", ")}
+	}
+	return checkResult{Status: checkPass}
+}
+
+// clusterLimitedSupport doesn't ask for the reasons of the clusters the list says have none
+func clusterLimitedSupport(cluster *cmv1.Cluster, source factSource) ([]limitedSupportReason, error) {
+	if count, ok := cluster.Status().GetLimitedSupportReasonCount(); ok && count == 0 {
+		return nil, nil
+	}
+	return source.limitedSupportReasons(cluster)
+}
+
+func checkLimitedSupport(check complianceCheck, reasons []limitedSupportReason, err error, now time.Time) checkResult {
+	if err != nil {
+		return checkResult{Status: checkError, Detail: err.Error()}
+	}
+	var stale []string
+	for _, reason := range reasons {
+		if age := now.Sub(reason.Created); age > check.maxAge {
+			stale = append(stale, fmt.Sprintf("'%s' for %dd", reason.Summary, int(age.Hours()/24)))
+		}
+	}
+	switch {
+	case len(stale) > 0:
+		return checkResult{Status: checkFail, Detail: "in limited support: " + strings.Join(stale, ", ")}
+	case len(reasons) > 0:
+		return checkResult{Status: checkPass, Detail: fmt.Sprintf("%d recent limited support reasons", len(reasons))}
+	}
+	return checkResult{Status: checkPass}
+}
+
+func checkBackup(check complianceCheck, cluster *cmv1.Cluster, source factSource, now time.Time) checkResult {
+	if cluster.Hypershift().Enabled() {
+		return checkResult{Status: checkSkip, Detail: "the hosted control planes are backed up by the service"}
+	}
+	if strings.ToUpper(cluster.CloudProvider().ID()) != "AWS" {
+		return checkResult{Status: checkSkip, Detail: "only AWS clusters are checked"}
+	}
+	last, err := source.lastBackup(cluster)
+	if err != nil {
+		return checkResult{Status: checkError, Detail: err.Error()}
+	}
+	if age := now.Sub(last); age > check.maxAge {
+		return checkResult{Status: checkFail, Detail: fmt.Sprintf("last backup %s ago", age.Truncate(time.Minute))}
+	}
+	return checkResult{Status: checkPass, Detail: "last backup " + timefmt.Format(last)}
+}
+
+// writeComplianceCSV writes a row per cluster and check
+func writeComplianceCSV(w io.Writer, report complianceReport, columns []string) error {
+	var rows [][]string
+	for _, cluster := range report.Clusters {
+		for _, result := range cluster.Results {
+			rows = append(rows, []string{cluster.ClusterID, cluster.ClusterName, strconv.FormatFloat(cluster.Score, 'f', 1, 64), result.Check, result.Type, result.Status, result.Detail})
+		}
+	}
+	return printer.WriteCSV(w, complianceCSVColumns, rows, columns)
+}
+
+// ocmFactSource reads the labels and limited support reasons from OCM, and the backups from the S3 bucket of the
+// managed Velero in the cluster's AWS account
+type ocmFactSource struct {
+	connection *sdk.Connection
+}
+
+func (s *ocmFactSource) labels(cluster *cmv1.Cluster) (map[string]string, error) {
+	labels := map[string]string{}
+	subscriptionLabels, err := s.connection.AccountsMgmt().V1().Subscriptions().Subscription(cluster.Subscription().ID()).Labels().List().Size(100).Send()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the subscription labels: %w", err)
+	}
+	subscriptionLabels.Items().Each(func(label *amv1.Label) bool {
+		labels[label.Key()] = label.Value()
+		return true
+	})
+	clusterLabels, err := s.connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).ExternalConfiguration().Labels().List().Size(100).Send()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the cluster labels: %w", err)
+	}
+	clusterLabels.Items().Each(func(label *cmv1.Label) bool {
+		labels[label.Key()] = label.Value()
+		return true
+	})
+	return labels, nil
+}
+
+func (s *ocmFactSource) limitedSupportReasons(cluster *cmv1.Cluster) ([]limitedSupportReason, error) {
+	response, err := s.connection.ClustersMgmt().V1().Clusters().Cluster(cluster.ID()).LimitedSupportReasons().List().Send()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the limited support reasons: %w", err)
+	}
+	var reasons []limitedSupportReason
+	response.Items().Each(func(reason *cmv1.LimitedSupportReason) bool {
+		reasons = append(reasons, limitedSupportReason{Summary: reason.Summary(), Created: reason.CreationTimestamp()})
+		return true
+	})
+	return reasons, nil
+}
+
+func (s *ocmFactSource) lastBackup(cluster *cmv1.Cluster) (time.Time, error) {
+	awsClient, err := osdCloud.CreateAWSClient(cluster.ID())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lastManagedBackup(awsClient)
+}
+
+// lastManagedBackup returns the time of the most recent backup in the managed Velero bucket, from the names of the
+// backups under backups/
+func lastManagedBackup(awsClient awsprovider.Client) (time.Time, error) {
+	buckets, err := awsClient.ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot list the S3 buckets: %w", err)
+	}
+	bucket := ""
+	for _, b := range buckets.Buckets {
+		if strings.HasPrefix(aws.StringValue(b.Name), managedVeleroBucketName) {
+			bucket = aws.StringValue(b.Name)
+		}
+	}
+	if bucket == "" {
+		return time.Time{}, fmt.Errorf("no managed Velero bucket")
+	}
+
+	var last time.Time
+	input := &s3.ListObjectsInput{Bucket: aws.String(bucket), Prefix: aws.String("backups/"), Delimiter: aws.String("/")}
+	for {
+		output, err := awsClient.ListObjects(input)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot list the backups in bucket %s: %w", bucket, err)
+		}
+		for _, prefix := range output.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(aws.StringValue(prefix.Prefix), "backups/"), "/")
+			match := veleroBackupTimestamp.FindStringSubmatch(name)
+			if match == nil {
+				continue
+			}
+			if created, err := time.Parse("20060102150405", match[1]); err == nil && created.After(last) {
+				last = created
+			}
+		}
+		if !aws.BoolValue(output.IsTruncated) || aws.StringValue(output.NextMarker) == "" {
+			break
+		}
+		input.Marker = output.NextMarker
+	}
+	if last.IsZero() {
+		return time.Time{}, fmt.Errorf("no backup in bucket %s", bucket)
+	}
+	return last, nil
+}
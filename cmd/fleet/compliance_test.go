@@ -0,0 +1,194 @@
+package fleet
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+const testPolicy = `
+checks:
+  - name: supported-version
+    type: version_floor
+    minVersion: "4.14"
+    weight: 3
+  - name: ownership
+    type: required_labels
+    labels: [team, environment=production]
+  - name: no-stale-limited-support
+    type: limited_support_age
+    maxAge: 7d
+  - name: recent-backups
+    type: backup_recency
+    maxAge: 2d
+`
+
+// fakeFacts answers the facts of the clusters by ID
+type fakeFacts struct {
+	clusterLabels  map[string]map[string]string
+	clusterReasons map[string][]limitedSupportReason
+	backups        map[string]time.Time
+}
+
+func (f *fakeFacts) labels(cluster *cmv1.Cluster) (map[string]string, error) {
+	return f.clusterLabels[cluster.ID()], nil
+}
+
+func (f *fakeFacts) limitedSupportReasons(cluster *cmv1.Cluster) ([]limitedSupportReason, error) {
+	return f.clusterReasons[cluster.ID()], nil
+}
+
+func (f *fakeFacts) lastBackup(cluster *cmv1.Cluster) (time.Time, error) {
+	last, ok := f.backups[cluster.ID()]
+	if !ok {
+		return time.Time{}, errors.New("no managed Velero bucket")
+	}
+	return last, nil
+}
+
+func writePolicy(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testCluster(g *WithT, id, version string, hypershift bool, limitedSupport int) *cmv1.Cluster {
+	cluster, err := cmv1.NewCluster().ID(id).Name(id + "-name").OpenshiftVersion(version).
+		CloudProvider(cmv1.NewCloudProvider().ID("aws")).
+		Hypershift(cmv1.NewHypershift().Enabled(hypershift)).
+		Status(cmv1.NewClusterStatus().LimitedSupportReasonCount(limitedSupport)).
+		Build()
+	g.Expect(err).NotTo(HaveOccurred())
+	return cluster
+}
+
+func TestLoadCompliancePolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	policy, err := loadCompliancePolicy(writePolicy(t, testPolicy))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(policy.Checks).To(HaveLen(4))
+	g.Expect(policy.Checks[0].minVersion.String()).To(Equal("4.14.0"))
+	g.Expect(policy.Checks[1].Weight).To(Equal(1))
+	g.Expect(policy.Checks[2].maxAge).To(Equal(7 * 24 * time.Hour))
+
+	for _, invalid := range []string{
+		"checks: []",
+		"checks:\n  - name: a\n    type: cpu_limit\n",
+		"checks:\n  - name: a\n    type: version_floor\n    minVersion: latest\n",
+		"checks:\n  - name: a\n    type: required_labels\n",
+		"checks:\n  - name: a\n    type: backup_recency\n    maxAge: a week\n",
+		"checks:\n  - name: a\n    type: backup_recency\n    maxAge: 1d\n  - name: a\n    type: backup_recency\n    maxAge: 2d\n",
+		"checks:\n  - name: a\n    type: backup_recency\n    maxAge: 1d\n    unknown: true\n",
+	} {
+		_, err := loadCompliancePolicy(writePolicy(t, invalid))
+		g.Expect(err).To(HaveOccurred(), invalid)
+	}
+}
+
+func TestEvaluateFleet(t *testing.T) {
+	g := NewGomegaWithT(t)
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	policy, err := loadCompliancePolicy(writePolicy(t, testPolicy))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	clusters := []*cmv1.Cluster{
+		testCluster(g, "compliant", "4.15.3", false, 0),
+		testCluster(g, "old", "4.13.20", false, 1),
+		testCluster(g, "hosted", "4.14.0", true, 0),
+	}
+	facts := &fakeFacts{
+		clusterLabels: map[string]map[string]string{
+			"compliant": {"team": "payments", "environment": "production"},
+			"old":       {"team": "data", "environment": "staging"},
+			"hosted":    {"team": "web", "environment": "production"},
+		},
+		clusterReasons: map[string][]limitedSupportReason{
+			"old": {{Summary: "Cluster is out of support", Created: now.Add(-10 * 24 * time.Hour)}},
+		},
+		backups: map[string]time.Time{"compliant": now.Add(-12 * time.Hour)},
+	}
+
+	report := evaluateFleet(policy, clusters, facts, 2, now)
+	g.Expect(report.Clusters).To(HaveLen(3))
+	g.Expect(report.Clusters[0].ClusterID).To(Equal("compliant"))
+	g.Expect(report.Clusters[0].Score).To(Equal(100.0))
+
+	old := report.Clusters[1]
+	g.Expect(old.Results[0].Status).To(Equal(checkFail))
+	g.Expect(old.Results[1].Detail).To(Equal("missing environment=production"))
+	g.Expect(old.Results[2].Detail).To(ContainSubstring("for 10d"))
+	g.Expect(old.Results[3].Status).To(Equal(checkError))
+	g.Expect(old.Score).To(Equal(0.0))
+
+	// The backups of hosted control planes don't count
+	hosted := report.Clusters[2]
+	g.Expect(hosted.Results[3].Status).To(Equal(checkSkip))
+	g.Expect(hosted.Score).To(Equal(100.0))
+
+	g.Expect(report.Score).To(BeNumerically("~", 200.0/3, 0.01))
+	g.Expect(report.Checks[0]).To(Equal(checkSummary{Check: "supported-version", Type: checkVersionFloor, Weight: 3, Passed: 2, Failed: 1}))
+	g.Expect(report.Checks[3]).To(Equal(checkSummary{Check: "recent-backups", Type: checkBackupRecency, Weight: 1, Passed: 1, Skipped: 1, Errors: 1}))
+
+	output := report.String()
+	g.Expect(output).To(ContainSubstring("Fleet compliance: 66.7% over 3 clusters"))
+	g.Expect(output).To(ContainSubstring("1 clusters aren't compliant"))
+	g.Expect(output).To(ContainSubstring("supported-version (4.13.20 is older than 4.14)"))
+
+	var csv bytes.Buffer
+	g.Expect(writeComplianceCSV(&csv, report, complianceCSVColumns)).To(Succeed())
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	g.Expect(lines).To(HaveLen(13))
+	g.Expect(lines[0]).To(Equal("cluster_id,cluster_name,cluster_score,check,type,status,detail"))
+	g.Expect(lines[5]).To(Equal("old,old-name,0.0,supported-version,version_floor,fail,4.13.20 is older than 4.14"))
+
+	csv.Reset()
+	g.Expect(writeComplianceCSV(&csv, report, []string{"status", "cluster_name"})).To(Succeed())
+	g.Expect(strings.Split(csv.String(), "\n")[:2]).To(Equal([]string{"status,cluster_name", "pass,compliant-name"}))
+}
+
+func TestLastManagedBackup(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockAWSClient := awsmock.NewMockClient(gomock.NewController(t))
+
+	mockAWSClient.EXPECT().ListBuckets(gomock.Any()).Return(&s3.ListBucketsOutput{Buckets: []*s3.Bucket{
+		{Name: awsSdk.String("mycluster-image-registry")},
+		{Name: awsSdk.String("managed-velero-backups-4b3e5f")},
+	}}, nil)
+	gomock.InOrder(
+		mockAWSClient.EXPECT().ListObjects(&s3.ListObjectsInput{
+			Bucket: awsSdk.String("managed-velero-backups-4b3e5f"), Prefix: awsSdk.String("backups/"), Delimiter: awsSdk.String("/"),
+		}).Return(&s3.ListObjectsOutput{
+			CommonPrefixes: []*s3.CommonPrefix{{Prefix: awsSdk.String("backups/daily-full-backup-20261013020012/")}},
+			IsTruncated:    awsSdk.Bool(true),
+			NextMarker:     awsSdk.String("backups/daily-full-backup-20261013020012/"),
+		}, nil),
+		mockAWSClient.EXPECT().ListObjects(gomock.Any()).Return(&s3.ListObjectsOutput{
+			CommonPrefixes: []*s3.CommonPrefix{
+				{Prefix: awsSdk.String("backups/hourly-object-backup-20261014110039/")},
+				{Prefix: awsSdk.String("backups/manual/")},
+			},
+		}, nil),
+	)
+
+	last, err := lastManagedBackup(mockAWSClient)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(last).To(Equal(time.Date(2026, 10, 14, 11, 0, 39, 0, time.UTC)))
+
+	mockAWSClient.EXPECT().ListBuckets(gomock.Any()).Return(&s3.ListBucketsOutput{}, nil)
+	_, err = lastManagedBackup(mockAWSClient)
+	g.Expect(err).To(MatchError("no managed Velero bucket"))
+}
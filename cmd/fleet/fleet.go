@@ -0,0 +1,23 @@
+package fleet
+
+import (
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdFleet implements the commands looking at every cluster the user can access at once
+func NewCmdFleet(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	fleetCmd := &cobra.Command{
+		Use:               "fleet",
+		Short:             "Commands evaluating the whole fleet of clusters",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	fleetCmd.AddCommand(newCmdCompliance(globalOpts))
+	fleetCmd.AddCommand(newCmdSample(globalOpts))
+	return fleetCmd
+}
@@ -0,0 +1,489 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/scheduler"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	sampleLong = `Selects a representative random subset of the clusters and runs a read-only check against each of them.
+
+The clusters are grouped by the values of --strata, e.g. version and region, and every group is sampled in
+proportion to its share of the fleet. When the sample is at least as large as the number of groups, every group is
+represented by one cluster at least. The seed is printed, --seed draws the same sample again.
+
+The check is an osdctl command given after --, it runs once per sampled cluster with ${CLUSTER_ID} replaced by the
+ID of the cluster, or the ID appended when it doesn't have ${CLUSTER_ID}. It must be read-only. A cluster fails when
+the check exits with an error. The share of the failing clusters of the fleet is estimated from the groups, with
+its 95% margin of error, for quick risk assessments before fleet-wide changes. Without a check, the sample is listed.
+
+Strata: ` + "version, region, provider, product, topology"
+
+	sampleExample = `
+  # 25 clusters spread over the versions and regions, checking their health
+  osdctl fleet sample --size 25 --strata version,region -- cluster health
+
+  # Draw the same sample again for the ROSA clusters, checking their webhooks
+  osdctl fleet sample --size 10 --strata version --search "product.id = 'rosa'" --seed 1697040000 -- cluster check-webhooks '${CLUSTER_ID}'
+
+  # Only list the sample
+  osdctl fleet sample --size 5 --strata provider,topology
+`
+
+	// clusterIDPlaceholder is replaced by the ID of every sampled cluster in the check
+	clusterIDPlaceholder = "${CLUSTER_ID}"
+
+	samplePassed  = "passed"
+	sampleFailed  = "failed"
+	sampleError   = "error"
+	sampleSampled = "sampled"
+
+	// sampleZ is the z-score of the 95% margin of error
+	sampleZ = 1.96
+)
+
+// sampleStrata are the properties the clusters can be grouped by
+var sampleStrata = map[string]func(*cmv1.Cluster) string{
+	"version": func(cluster *cmv1.Cluster) string {
+		raw := cluster.OpenshiftVersion()
+		if raw == "" {
+			raw = cluster.Version().RawID()
+		}
+		version, err := parseVersion(raw)
+		if err != nil {
+			return "unknown"
+		}
+		return fmt.Sprintf("%d.%d", version.Major, version.Minor)
+	},
+	"region":   func(cluster *cmv1.Cluster) string { return cluster.Region().ID() },
+	"provider": func(cluster *cmv1.Cluster) string { return cluster.CloudProvider().ID() },
+	"product":  func(cluster *cmv1.Cluster) string { return cluster.Product().ID() },
+	"topology": func(cluster *cmv1.Cluster) string {
+		if cluster.Hypershift().Enabled() {
+			return "hcp"
+		}
+		return "classic"
+	},
+}
+
+type sampleOptions struct {
+	search   string
+	size     int
+	strata   []string
+	seed     int64
+	parallel int
+	check    []string
+
+	// execute runs osdctl with the arguments and returns its output, it is replaced in tests
+	execute func(ctx context.Context, args []string) (string, error)
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+type sampleStratum struct {
+	Stratum    string `json:"stratum" yaml:"stratum"`
+	Population int    `json:"population" yaml:"population"`
+	Sampled    int    `json:"sampled" yaml:"sampled"`
+	Passed     int    `json:"passed" yaml:"passed"`
+	Failed     int    `json:"failed" yaml:"failed"`
+	Errors     int    `json:"errors" yaml:"errors"`
+}
+
+type sampledCluster struct {
+	ClusterID   string `json:"cluster_id" yaml:"cluster_id"`
+	ClusterName string `json:"cluster_name" yaml:"cluster_name"`
+	Stratum     string `json:"stratum" yaml:"stratum"`
+	Status      string `json:"status" yaml:"status"`
+	Detail      string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+type sampleResponse struct {
+	Search     string           `json:"search" yaml:"search"`
+	StrataBy   []string         `json:"strata_by" yaml:"strata_by"`
+	Seed       int64            `json:"seed" yaml:"seed"`
+	Check      string           `json:"check,omitempty" yaml:"check,omitempty"`
+	Population int              `json:"population" yaml:"population"`
+	Strata     []sampleStratum  `json:"strata" yaml:"strata"`
+	Clusters   []sampledCluster `json:"clusters" yaml:"clusters"`
+	// EstimatedFailureRate is the estimated share of the failing clusters of the fleet, with its 95% margin of
+	// error, set when a check ran
+	EstimatedFailureRate *float64 `json:"estimated_failure_rate,omitempty" yaml:"estimated_failure_rate,omitempty"`
+	MarginOfError        *float64 `json:"margin_of_error,omitempty" yaml:"margin_of_error,omitempty"`
+	// Uncovered is the number of clusters of the strata the estimate doesn't cover, none of their sampled clusters
+	// was checked
+	Uncovered int `json:"uncovered,omitempty" yaml:"uncovered,omitempty"`
+}
+
+func (r sampleResponse) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Sampled %d of %d clusters in %d strata by %s (seed %d)\n\n", len(r.Clusters), r.Population, len(r.Strata),
+		strings.Join(r.StrataBy, ", "), r.Seed)
+
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Stratum", "Clusters", "Sampled", "Passed", "Failed", "Errors"})
+	for _, stratum := range r.Strata {
+		table.AddRow([]string{stratum.Stratum, strconv.Itoa(stratum.Population), strconv.Itoa(stratum.Sampled),
+			strconv.Itoa(stratum.Passed), strconv.Itoa(stratum.Failed), strconv.Itoa(stratum.Errors)})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+
+	table = printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Cluster", "ID", "Stratum", "Status", "Details"})
+	for _, cluster := range r.Clusters {
+		table.AddRow([]string{cluster.ClusterName, cluster.ClusterID, cluster.Stratum, cluster.Status, cluster.Detail})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+
+	if r.EstimatedFailureRate != nil {
+		fmt.Fprintf(&b, "Estimated failing clusters: %.1f%% ± %.1f%% of the fleet (95%%), about %.0f clusters", *r.EstimatedFailureRate*100,
+			*r.MarginOfError*100, *r.EstimatedFailureRate*float64(r.Population-r.Uncovered))
+		if r.Uncovered > 0 {
+			fmt.Fprintf(&b, ", the %d clusters of the strata without a checked cluster aren't covered", r.Uncovered)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func newCmdSample(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &sampleOptions{GlobalOptions: globalOpts}
+	sampleCmd := &cobra.Command{
+		Use:               "sample [flags] [-- CHECK...]",
+		Short:             "Runs a read-only check against a representative random sample of the clusters",
+		Long:              sampleLong,
+		Example:           sampleExample,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	sampleCmd.Flags().IntVar(&ops.size, "size", 25, "Number of clusters to sample")
+	sampleCmd.Flags().StringSliceVar(&ops.strata, "strata", []string{"version", "region"}, "Properties the clusters are grouped by, the groups are sampled in proportion to their size")
+	sampleCmd.Flags().StringVar(&ops.search, "search", defaultComplianceSearch, "OCM search of the clusters to sample from")
+	sampleCmd.Flags().Int64Var(&ops.seed, "seed", 0, "Seed of the random sample, to draw the same one again, a new one by default")
+	sampleCmd.Flags().IntVar(&ops.parallel, "parallel", 5, "Number of clusters checked at once")
+
+	return sampleCmd
+}
+
+func (o *sampleOptions) complete(cmd *cobra.Command, args []string) error {
+	if o.size < 1 {
+		return cmdutil.UsageErrorf(cmd, "--size must be at least 1")
+	}
+	if o.parallel < 1 {
+		return cmdutil.UsageErrorf(cmd, "--parallel must be at least 1")
+	}
+	for _, stratum := range o.strata {
+		if _, ok := sampleStrata[stratum]; !ok {
+			return cmdutil.UsageErrorf(cmd, "unknown stratum '%s', expected one of %s", stratum, strings.Join(sampleStrataNames(), ", "))
+		}
+	}
+	if len(args) > 0 && cmd.ArgsLenAtDash() != 0 {
+		return cmdutil.UsageErrorf(cmd, "the check goes after --, e.g. 'osdctl fleet sample -- cluster health'")
+	}
+	if o.seed == 0 {
+		o.seed = time.Now().UnixNano()
+	}
+
+	o.check = args
+	if len(o.check) > 0 && o.check[0] == "osdctl" {
+		o.check = o.check[1:]
+	}
+	if len(o.check) > 0 {
+		if err := validateSampleCheck(cmd.Root(), o.check); err != nil {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "%v", err)
+		}
+	}
+
+	if o.execute == nil {
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("cannot find the osdctl binary: %w", err)
+		}
+		o.execute = executeSampleCheck(executable)
+	}
+	return nil
+}
+
+func sampleStrataNames() []string {
+	names := make([]string, 0, len(sampleStrata))
+	for name := range sampleStrata {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateSampleCheck checks that the check is a read-only osdctl command, it runs against clusters found by search
+func validateSampleCheck(root *cobra.Command, check []string) error {
+	args := make([]string, len(check))
+	for i, arg := range check {
+		args[i] = strings.ReplaceAll(arg, clusterIDPlaceholder, "CLUSTER_ID")
+	}
+	command, _, err := root.Find(args)
+	if err != nil || command == root || !command.Runnable() || command.HasSubCommands() {
+		return fmt.Errorf("'osdctl %s' isn't an osdctl command", strings.Join(check, " "))
+	}
+	if command.Name() == "sample" && command.Parent() != nil && command.Parent().Name() == "fleet" {
+		return fmt.Errorf("the check can't sample the fleet again")
+	}
+	if mutation, _ := catalog.Mutation(command); mutation != catalog.MutationReadOnly {
+		return fmt.Errorf("the check runs 'osdctl %s', which isn't read-only", strings.Join(check, " "))
+	}
+	return nil
+}
+
+func (o *sampleOptions) run() error {
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	clusters, err := listComplianceClusters(connection, o.search)
+	if err != nil {
+		return err
+	}
+	if len(clusters) == 0 {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "no cluster matches the search \"%s\"", o.search)
+	}
+
+	response := sampleFleet(clusters, o.strata, o.size, o.seed)
+	response.Search = o.search
+	if len(o.check) > 0 {
+		response.Check = strings.Join(o.check, " ")
+		fmt.Fprintf(os.Stderr, "Running 'osdctl %s' on %d clusters\n", response.Check, len(response.Clusters))
+		o.runChecks(deadline.Context(), &response)
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// sampleFleet groups the clusters by the strata and samples every group in proportion to its size
+func sampleFleet(clusters []*cmv1.Cluster, strata []string, size int, seed int64) sampleResponse {
+	groups := map[string][]*cmv1.Cluster{}
+	for _, cluster := range clusters {
+		values := make([]string, 0, len(strata))
+		for _, stratum := range strata {
+			value := sampleStrata[stratum](cluster)
+			if value == "" {
+				value = "none"
+			}
+			values = append(values, value)
+		}
+		key := strings.Join(values, "/")
+		groups[key] = append(groups[key], cluster)
+	}
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	populations := make([]int, len(keys))
+	for i, key := range keys {
+		populations[i] = len(groups[key])
+	}
+	sizes := allocateSample(populations, size)
+
+	response := sampleResponse{StrataBy: strata, Seed: seed, Population: len(clusters)}
+	random := rand.New(rand.NewSource(seed)) //#nosec G404 -- sampling clusters isn't about security
+	for i, key := range keys {
+		response.Strata = append(response.Strata, sampleStratum{Stratum: key, Population: populations[i], Sampled: sizes[i]})
+		group := groups[key]
+		var sampled []*cmv1.Cluster
+		for _, j := range random.Perm(len(group))[:sizes[i]] {
+			sampled = append(sampled, group[j])
+		}
+		sort.Slice(sampled, func(a, b int) bool { return sampled[a].Name() < sampled[b].Name() })
+		for _, cluster := range sampled {
+			response.Clusters = append(response.Clusters, sampledCluster{ClusterID: cluster.ID(), ClusterName: cluster.Name(), Stratum: key, Status: sampleSampled})
+		}
+	}
+	return response
+}
+
+// allocateSample returns the number of clusters to sample from every stratum, in proportion to their population with
+// the largest remainders getting the clusters left. Every stratum gets one cluster first when the sample is large
+// enough, so that the small ones are represented.
+func allocateSample(populations []int, size int) []int {
+	sizes := make([]int, len(populations))
+	total := 0
+	for _, population := range populations {
+		total += population
+	}
+	if size >= total {
+		copy(sizes, populations)
+		return sizes
+	}
+
+	remaining := size
+	if size >= len(populations) {
+		for i := range sizes {
+			sizes[i] = 1
+		}
+		remaining -= len(populations)
+	}
+	capacity := total
+	for _, n := range sizes {
+		capacity -= n
+	}
+
+	type share struct {
+		stratum  int
+		fraction float64
+	}
+	shares := make([]share, 0, len(populations))
+	allocated := 0
+	for i, population := range populations {
+		quota := float64(remaining) * float64(population-sizes[i]) / float64(capacity)
+		whole := int(quota)
+		sizes[i] += whole
+		allocated += whole
+		shares = append(shares, share{stratum: i, fraction: quota - float64(whole)})
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].fraction > shares[j].fraction })
+	for i := 0; i < remaining-allocated; i++ {
+		sizes[shares[i].stratum]++
+	}
+	return sizes
+}
+
+// runChecks runs the check on every sampled cluster and estimates the share of the failing clusters of the fleet
+func (o *sampleOptions) runChecks(ctx context.Context, response *sampleResponse) {
+	tasks := make([]scheduler.Task, len(response.Clusters))
+	for i := range response.Clusters {
+		cluster := &response.Clusters[i]
+		tasks[i] = scheduler.Task{Cluster: cluster.ClusterID, Kind: scheduler.Read, Run: func(ctx context.Context) error {
+			output, err := o.execute(ctx, sampleCheckArgs(o.check, cluster.ClusterID))
+			switch {
+			case err == nil:
+				cluster.Status = samplePassed
+			case isExitError(err):
+				cluster.Status, cluster.Detail = sampleFailed, lastOutputLine(output, err.Error())
+			default:
+				cluster.Status, cluster.Detail = sampleError, err.Error()
+			}
+			return nil
+		}}
+	}
+	checks := scheduler.New()
+	checks.Reads = o.parallel
+	for i, err := range checks.Run(ctx, tasks) {
+		// The checks not started before an interruption
+		if err != nil {
+			response.Clusters[i].Status, response.Clusters[i].Detail = sampleError, err.Error()
+		}
+	}
+
+	index := map[string]int{}
+	for i, stratum := range response.Strata {
+		index[stratum.Stratum] = i
+	}
+	for _, cluster := range response.Clusters {
+		stratum := &response.Strata[index[cluster.Stratum]]
+		switch cluster.Status {
+		case samplePassed:
+			stratum.Passed++
+		case sampleFailed:
+			stratum.Failed++
+		default:
+			stratum.Errors++
+		}
+	}
+	estimateFailureRate(response)
+}
+
+// estimateFailureRate sets the stratified estimate of the share of the failing clusters and its margin of error, with
+// the finite population correction. The strata without a checked cluster are left out of the estimate.
+func estimateFailureRate(response *sampleResponse) {
+	covered := 0
+	for _, stratum := range response.Strata {
+		if stratum.Passed+stratum.Failed > 0 {
+			covered += stratum.Population
+		}
+	}
+	response.Uncovered = response.Population - covered
+	if covered == 0 {
+		return
+	}
+
+	rate, variance := 0.0, 0.0
+	for _, stratum := range response.Strata {
+		checked := stratum.Passed + stratum.Failed
+		if checked == 0 {
+			continue
+		}
+		weight := float64(stratum.Population) / float64(covered)
+		p := float64(stratum.Failed) / float64(checked)
+		rate += weight * p
+		if checked > 1 {
+			correction := 1 - float64(checked)/float64(stratum.Population)
+			variance += weight * weight * correction * p * (1 - p) / float64(checked-1)
+		}
+	}
+	margin := sampleZ * math.Sqrt(variance)
+	response.EstimatedFailureRate, response.MarginOfError = &rate, &margin
+}
+
+// sampleCheckArgs returns the arguments of the check for the cluster
+func sampleCheckArgs(check []string, clusterID string) []string {
+	args := make([]string, 0, len(check)+1)
+	replaced := false
+	for _, arg := range check {
+		if strings.Contains(arg, clusterIDPlaceholder) {
+			arg = strings.ReplaceAll(arg, clusterIDPlaceholder, clusterID)
+			replaced = true
+		}
+		args = append(args, arg)
+	}
+	if !replaced {
+		args = append(args, clusterID)
+	}
+	return args
+}
+
+// executeSampleCheck runs osdctl again for every cluster, so that the checks record themselves in the history
+func executeSampleCheck(executable string) func(ctx context.Context, args []string) (string, error) {
+	return func(ctx context.Context, args []string) (string, error) {
+		cmd := exec.CommandContext(ctx, executable, args...) //#nosec G204 -- the check is validated as an osdctl command
+		output, err := cmd.CombinedOutput()
+		return string(output), err
+	}
+}
+
+func isExitError(err error) bool {
+	_, ok := err.(*exec.ExitError)
+	return ok
+}
+
+// lastOutputLine returns the last non-empty line of the output, the error of the check is usually there
+func lastOutputLine(output, fallback string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if line := strings.TrimSpace(lines[len(lines)-1]); line != "" {
+		return line
+	}
+	return fallback
+}
@@ -0,0 +1,119 @@
+package fleet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func TestAllocateSample(t *testing.T) {
+	g := NewGomegaWithT(t)
+	for _, tc := range []struct {
+		title       string
+		populations []int
+		size        int
+		expected    []int
+	}{
+		{"proportional", []int{50, 30, 20}, 10, []int{5, 3, 2}},
+		{"every stratum represented", []int{97, 2, 1}, 10, []int{8, 1, 1}},
+		{"largest remainders", []int{5, 5, 5}, 4, []int{2, 1, 1}},
+		{"fewer than the strata", []int{10, 60, 30}, 2, []int{0, 1, 1}},
+		{"whole fleet", []int{3, 2}, 10, []int{3, 2}},
+	} {
+		sizes := allocateSample(tc.populations, tc.size)
+		g.Expect(sizes).To(Equal(tc.expected), tc.title)
+	}
+}
+
+func sampleTestFleet(g *WithT) []*cmv1.Cluster {
+	var clusters []*cmv1.Cluster
+	for i := 0; i < 40; i++ {
+		version, region := "4.14.3", "us-east-1"
+		if i%4 == 0 {
+			version = "4.15.1"
+		}
+		if i%10 == 0 {
+			region = "eu-west-1"
+		}
+		cluster, err := cmv1.NewCluster().ID(fmt.Sprintf("id-%02d", i)).Name(fmt.Sprintf("cluster-%02d", i)).OpenshiftVersion(version).
+			Region(cmv1.NewCloudRegion().ID(region)).Build()
+		g.Expect(err).NotTo(HaveOccurred())
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+func TestSampleFleet(t *testing.T) {
+	g := NewGomegaWithT(t)
+	clusters := sampleTestFleet(g)
+
+	response := sampleFleet(clusters, []string{"version", "region"}, 8, 42)
+	g.Expect(response.Population).To(Equal(40))
+	var strata []string
+	for _, stratum := range response.Strata {
+		strata = append(strata, fmt.Sprintf("%s %d/%d", stratum.Stratum, stratum.Sampled, stratum.Population))
+	}
+	g.Expect(strata).To(Equal([]string{"4.14/eu-west-1 1/2", "4.14/us-east-1 4/28", "4.15/eu-west-1 1/2", "4.15/us-east-1 2/8"}))
+	g.Expect(response.Clusters).To(HaveLen(8))
+
+	// The seed draws the same sample again
+	g.Expect(sampleFleet(clusters, []string{"version", "region"}, 8, 42).Clusters).To(Equal(response.Clusters))
+}
+
+func TestSampleRunChecks(t *testing.T) {
+	g := NewGomegaWithT(t)
+	clusters := sampleTestFleet(g)
+
+	var checked []string
+	o := &sampleOptions{parallel: 1, check: []string{"cluster", "health", "-C", "${CLUSTER_ID}"}, execute: func(ctx context.Context, args []string) (string, error) {
+		checked = append(checked, args[len(args)-1])
+		switch args[3] {
+		case "id-04", "id-08":
+			return "checking\nError: 2 degraded operators\n", &exec.ExitError{}
+		case "id-12":
+			return "", errors.New("cannot start osdctl")
+		}
+		return "healthy\n", nil
+	}}
+	response := sampleFleet(clusters, []string{"version"}, 40, 1)
+	o.runChecks(context.Background(), &response)
+
+	g.Expect(checked).To(HaveLen(40))
+	g.Expect(response.Strata[1].Stratum).To(Equal("4.15"))
+	g.Expect(response.Strata[1].Failed).To(Equal(2))
+	g.Expect(response.Strata[1].Errors).To(Equal(1))
+	for _, cluster := range response.Clusters {
+		if cluster.ClusterID == "id-04" {
+			g.Expect(cluster.Status).To(Equal(sampleFailed))
+			g.Expect(cluster.Detail).To(Equal("Error: 2 degraded operators"))
+		}
+	}
+	// The cluster which couldn't be checked is left out of its stratum
+	g.Expect(*response.EstimatedFailureRate).To(BeNumerically("~", 0.25*2/9, 1e-9))
+	g.Expect(*response.MarginOfError).To(BeNumerically("~", 0.0228, 1e-3))
+	g.Expect(response.String()).To(ContainSubstring("Estimated failing clusters: 5.6% ± 2.3% of the fleet (95%), about 2 clusters"))
+}
+
+func TestEstimateFailureRate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	response := sampleResponse{Population: 1100, Strata: []sampleStratum{
+		{Stratum: "4.14", Population: 800, Sampled: 8, Passed: 6, Failed: 2},
+		{Stratum: "4.15", Population: 200, Sampled: 2, Passed: 2},
+		{Stratum: "4.16", Population: 100, Sampled: 1, Errors: 1},
+	}}
+	estimateFailureRate(&response)
+	g.Expect(response.Uncovered).To(Equal(100))
+	g.Expect(*response.EstimatedFailureRate).To(BeNumerically("~", 0.8*0.25, 1e-9))
+	g.Expect(*response.MarginOfError).To(BeNumerically("~", 0.2553, 1e-3))
+}
+
+func TestSampleCheckArgs(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(sampleCheckArgs([]string{"cluster", "health"}, "abc")).To(Equal([]string{"cluster", "health", "abc"}))
+	g.Expect(sampleCheckArgs([]string{"cluster", "check-webhooks", "--cluster-id=${CLUSTER_ID}"}, "abc")).To(Equal([]string{"cluster", "check-webhooks", "--cluster-id=abc"}))
+}
@@ -3,7 +3,9 @@ package getoutput
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
+	"github.com/openshift/osdctl/pkg/printer"
 	"gopkg.in/yaml.v2"
 )
 
@@ -19,7 +21,7 @@ func PrintResponse(output string, resp CmdResponse) error {
 			return err
 		}
 
-		fmt.Println(string(accountsToJson))
+		return printer.PrintJSON(printer.Tee(os.Stdout), accountsToJson)
 
 	} else if output == "yaml" {
 
@@ -28,7 +30,11 @@ func PrintResponse(output string, resp CmdResponse) error {
 			return err
 		}
 
-		fmt.Println(string(accountIdToYaml))
+		fmt.Fprintln(printer.Tee(os.Stdout), string(accountIdToYaml))
+
+	} else if IsTemplate(output) {
+
+		return PrintTemplate(printer.Tee(os.Stdout), output, resp)
 
 	} else {
 		fmt.Println(resp)
@@ -0,0 +1,83 @@
+package getoutput
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// The template output formats, like kubectl's: --output go-template='{{.id}}' or --output jsonpath-file=fields.txt
+const (
+	GoTemplateFormat     = "go-template"
+	GoTemplateFileFormat = "go-template-file"
+	JSONPathFormat       = "jsonpath"
+	JSONPathFileFormat   = "jsonpath-file"
+)
+
+// IsTemplate returns whether the output format is a go-template or jsonpath one
+func IsTemplate(output string) bool {
+	format, _, found := strings.Cut(output, "=")
+	if !found {
+		return false
+	}
+	switch format {
+	case GoTemplateFormat, GoTemplateFileFormat, JSONPathFormat, JSONPathFileFormat:
+		return true
+	}
+	return false
+}
+
+// PrintTemplate prints the data with the go-template or jsonpath of the output format. The templates see the data
+// as its JSON, so that the fields are named as with --output json.
+func PrintTemplate(w io.Writer, output string, data interface{}) error {
+	format, text, _ := strings.Cut(output, "=")
+	if format == GoTemplateFileFormat || format == JSONPathFileFormat {
+		content, err := os.ReadFile(text) //#nosec G304 -- the template file is chosen by the user
+		if err != nil {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "cannot read the template file: %v", err)
+		}
+		text = string(content)
+	}
+	if strings.TrimSpace(text) == "" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "empty template in --output %s", format)
+	}
+
+	// Go through JSON so that the templates use the JSON field names, and see struct fields and maps alike
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	var object interface{}
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return err
+	}
+
+	if format == GoTemplateFormat || format == GoTemplateFileFormat {
+		tmpl, err := template.New("output").Parse(text)
+		if err != nil {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "invalid go-template: %v", err)
+		}
+		if err := tmpl.Execute(w, object); err != nil {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "cannot execute the go-template: %v", err)
+		}
+		return nil
+	}
+
+	// Like kubectl, '.items[*].id' is taken as '{.items[*].id}'
+	if !strings.Contains(text, "{") {
+		text = "{" + text + "}"
+	}
+	path := jsonpath.New("output")
+	if err := path.Parse(text); err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "invalid jsonpath: %v", err)
+	}
+	if err := path.Execute(w, object); err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "cannot execute the jsonpath: %v", err)
+	}
+	return nil
+}
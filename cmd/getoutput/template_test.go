@@ -0,0 +1,71 @@
+package getoutput
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+type templateResponse struct {
+	ClusterID string   `json:"cluster_id"`
+	Nodes     []string `json:"nodes"`
+}
+
+func (templateResponse) String() string { return "" }
+
+func TestIsTemplate(t *testing.T) {
+	for output, expected := range map[string]bool{
+		"go-template={{.id}}":    true,
+		"go-template-file=a.tpl": true,
+		"jsonpath={.id}":         true,
+		"jsonpath-file=a.txt":    true,
+		"json":                   false,
+		"jsonpath":               false,
+		"":                       false,
+	} {
+		if IsTemplate(output) != expected {
+			t.Errorf("IsTemplate(%q) should be %t", output, expected)
+		}
+	}
+}
+
+func TestPrintTemplate(t *testing.T) {
+	resp := templateResponse{ClusterID: "abc", Nodes: []string{"a", "b"}}
+	file := filepath.Join(t.TempDir(), "template")
+	if err := os.WriteFile(file, []byte("{{range .nodes}}{{.}} {{end}}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for output, expected := range map[string]string{
+		"go-template={{.cluster_id}}":              "abc",
+		"go-template-file=" + file:                 "a b ",
+		"jsonpath={.nodes[*]}":                     "a b",
+		"jsonpath=.cluster_id":                     "abc",
+		`jsonpath={range .nodes[*]}{@}{"\n"}{end}`: "a\nb\n",
+	} {
+		var b bytes.Buffer
+		if err := PrintTemplate(&b, output, resp); err != nil {
+			t.Errorf("%s: unexpected error: %v", output, err)
+		} else if b.String() != expected {
+			t.Errorf("%s: expected %q, got %q", output, expected, b.String())
+		}
+	}
+}
+
+func TestPrintTemplateErrors(t *testing.T) {
+	for _, output := range []string{
+		"go-template={{.cluster_id",
+		"go-template=",
+		"go-template-file=/nonexistent",
+		"jsonpath={.missing}",
+		"jsonpath={.nodes[",
+	} {
+		err := PrintTemplate(&bytes.Buffer{}, output, templateResponse{})
+		if !errors.Is(err, osdctlErrors.ErrValidation) {
+			t.Errorf("%s: expected a validation error, got %v", output, err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/history"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const historyLong = `List the osdctl commands that ran, with their target cluster and result, and run one of them again.
+
+The commands are recorded in ` + "`~/.config/osdctl-history.jsonl`" + `, or the '` + history.PathConfigKey + `' of the config file, which
+keeps the last '` + history.SizeConfigKey + `' commands (1000 by default). Set '` + history.DisabledConfigKey + `' to stop recording them.
+The values of the flags holding tokens, passwords or secrets are never recorded.`
+
+const historyExample = `
+  # The last 20 commands
+  osdctl history list
+
+  # The commands run against a cluster
+  osdctl history list --cluster 1kfmyclusteristhebesteverp8m --limit 0
+
+  # Run command 42 again, against another cluster
+  osdctl history replay 42 --cluster 2abotherclusteridp8m`
+
+type historyOptions struct {
+	limit   int
+	cluster string
+	print   bool
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// NewCmdHistory implements the history command
+func NewCmdHistory(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &historyOptions{GlobalOptions: globalOpts}
+	historyCmd := &cobra.Command{
+		Use:               "history",
+		Short:             "List the osdctl commands that ran and run them again",
+		Long:              historyLong,
+		Example:           historyExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:               "list",
+		Short:             "List the recorded commands, most recent last",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.list())
+		},
+	}
+	listCmd.Flags().IntVar(&ops.limit, "limit", 20, "Number of commands to list, 0 lists all of them")
+	listCmd.Flags().StringVarP(&ops.cluster, "cluster", "C", "", "Only list the commands run against this cluster")
+
+	replayCmd := &cobra.Command{
+		Use:               "replay NUMBER",
+		Short:             "Run a recorded command again, optionally against another cluster",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		// The replayed command can change anything, it goes through its own confirmation and guardrails
+		Annotations: map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.replay(cmd, args))
+		},
+	}
+	replayCmd.Flags().StringVarP(&ops.cluster, "cluster", "C", "", "Cluster to run the command against instead of the recorded one")
+	replayCmd.Flags().BoolVar(&ops.print, "print", false, "Print the command instead of running it")
+
+	historyCmd.AddCommand(listCmd, replayCmd)
+	return historyCmd
+}
+
+type historyResponse struct {
+	Entries []history.Entry `json:"entries" yaml:"entries"`
+}
+
+func (r historyResponse) String() string {
+	var b strings.Builder
+	if len(r.Entries) == 0 {
+		b.WriteString("No command recorded.\n")
+		return b.String()
+	}
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"#", "Time", "Cluster", "Result", "Command"})
+	for _, entry := range r.Entries {
+		result := entry.Result
+		if entry.ExitCode != 0 {
+			result = fmt.Sprintf("%s (%d)", entry.Result, entry.ExitCode)
+		}
+		table.AddRow([]string{
+			strconv.Itoa(entry.Number),
+			timefmt.Format(entry.Timestamp),
+			entry.Cluster,
+			result,
+			"osdctl " + strings.Join(entry.Args, " "),
+		})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	return b.String()
+}
+
+func (o *historyOptions) list() error {
+	entries, err := history.Read()
+	if err != nil {
+		return err
+	}
+	response := historyResponse{Entries: filterEntries(entries, o.cluster, o.limit)}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, response)
+}
+
+// filterEntries returns the last limit entries run against the cluster, all of them when limit is 0
+func filterEntries(entries []history.Entry, cluster string, limit int) []history.Entry {
+	filtered := []history.Entry{}
+	for _, entry := range entries {
+		if cluster == "" || entry.Cluster == cluster {
+			filtered = append(filtered, entry)
+		}
+	}
+	if limit > 0 && len(filtered) > limit {
+		filtered = filtered[len(filtered)-limit:]
+	}
+	return filtered
+}
+
+func (o *historyOptions) replay(cmd *cobra.Command, args []string) error {
+	number, err := strconv.Atoi(args[0])
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "expected the number of a command in the history, got '%s'", args[0])
+	}
+	entries, err := history.Read()
+	if err != nil {
+		return err
+	}
+	entry, err := history.Find(entries, number)
+	if err != nil {
+		return err
+	}
+	replayArgs, err := history.ReplayArgs(*entry, o.cluster)
+	if err != nil {
+		return err
+	}
+
+	commandLine := "osdctl " + strings.Join(replayArgs, " ")
+	if o.print {
+		fmt.Println(commandLine)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Running: %s\n", commandLine)
+	return execute(replayArgs)
+}
+
+// execute replaces osdctl with the replayed command, so that it gets the terminal, records itself in the history
+// and its exit code is osdctl's
+func execute(args []string) error {
+	path, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find the osdctl binary: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		cmd := exec.Command(path, args...) //#nosec G204 -- the command is the user's own
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				os.Exit(exitErr.ExitCode())
+			}
+			return err
+		}
+		os.Exit(0)
+	}
+	return syscall.Exec(path, append([]string{path}, args...), os.Environ()) //#nosec G204 -- the command is the user's own
+}
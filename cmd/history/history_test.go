@@ -0,0 +1,37 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/osdctl/pkg/history"
+)
+
+func TestFilterEntries(t *testing.T) {
+	entries := []history.Entry{
+		{Number: 1, Cluster: "abc"},
+		{Number: 2, Cluster: "def"},
+		{Number: 3, Cluster: "abc"},
+		{Number: 4},
+	}
+
+	testCases := []struct {
+		cluster  string
+		limit    int
+		expected []int
+	}{
+		{"", 0, []int{1, 2, 3, 4}},
+		{"", 2, []int{3, 4}},
+		{"abc", 1, []int{3}},
+		{"ghi", 0, []int{}},
+	}
+	for _, tc := range testCases {
+		numbers := []int{}
+		for _, entry := range filterEntries(entries, tc.cluster, tc.limit) {
+			numbers = append(numbers, entry.Number)
+		}
+		if !reflect.DeepEqual(numbers, tc.expected) {
+			t.Errorf("filterEntries(%q, %d) = %v, expected %v", tc.cluster, tc.limit, numbers, tc.expected)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/trace"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -86,7 +88,7 @@ func initJumphostConfig(ctx context.Context, clusterId, subnetId string) (*jumph
 	//}
 
 	// TODO: When --cluster-id is supported, only do this as a fallback
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, append(trace.AWSV2Options(), recording.AWSV2Options()...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -5,12 +5,12 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"os/exec"
 	"strconv"
 	"time"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -19,10 +19,12 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
 
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/poll"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -35,6 +37,9 @@ const (
 	nodeLabelValue           = ""
 	packetCaptureDurationSec = 60
 	singlePod                = false
+
+	packetCapturePollInterval = 10 * time.Second
+	packetCapturePollTimeout  = 10 * time.Minute
 )
 
 // newCmdPacketCapture implements the packet-capture command to run a packet capture
@@ -42,13 +47,14 @@ func newCmdPacketCapture(streams genericclioptions.IOStreams, flags *genericclio
 	ops := newPacketCaptureOptions(streams, flags, client)
 	packetCaptureCmd := &cobra.Command{
 		Use:               "packet-capture",
+		Annotations:       map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
 		Aliases:           []string{"pcap"},
 		Short:             "Start packet capture",
 		Args:              cobra.NoArgs,
 		DisableAutoGenTag: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(ops.complete(cmd, args))
-			cmdutil.CheckErr(ops.run())
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
 		},
 	}
 
@@ -102,33 +108,28 @@ func (o *packetCaptureOptions) runDaemonSet() error {
 	log.Println("Confirming the interface for capturing")
 	err := setCaptureInterface(o)
 	if err != nil {
-		log.Fatalf("Error setting the interface for capture")
-		return err
+		return fmt.Errorf("error setting the interface for capture: %w", err)
 	}
 
 	log.Println("Ensuring Packet Capture Daemonset")
 	ds, err := ensurePacketCaptureDaemonSet(o)
 	if err != nil {
-		log.Fatalf("Error ensuring packet capture daemonset %v", err)
-		return err
+		return fmt.Errorf("error ensuring packet capture daemonset: %w", err)
 	}
 	log.Println("Waiting For Packet Capture Daemonset")
 	err = waitForPacketCaptureDaemonset(o, ds)
 	if err != nil {
-		log.Fatalf("Error Waiting for daemonset %v", err)
-		return err
+		return fmt.Errorf("error waiting for daemonset: %w", err)
 	}
 	log.Println("Copying Files From Packet Capture Pods")
 	err = copyFilesFromPacketCapturePods(o)
 	if err != nil {
-		log.Fatalf("Error copying files %v", err)
-		return err
+		return fmt.Errorf("error copying files: %w", err)
 	}
 	log.Println("Deleting Packet Capture Daemonset")
 	err = deletePacketCaptureDaemonSet(o, ds)
 	if err != nil {
-		log.Fatalf("Error deleting packet capture daemonset %v", err)
-		return err
+		return fmt.Errorf("error deleting packet capture daemonset: %w", err)
 	}
 	return nil
 }
@@ -137,33 +138,28 @@ func (o *packetCaptureOptions) runPod() error {
 	log.Println("Confirming the interface for capturing")
 	err := setCaptureInterface(o)
 	if err != nil {
-		log.Fatalf("Error setting the interface for capture")
-		return err
+		return fmt.Errorf("error setting the interface for capture: %w", err)
 	}
 
 	log.Println("Ensuring Packet Capture Daemonset")
 	capturePod, err := ensurePacketCapturePod(o)
 	if err != nil {
-		log.Fatalf("Error ensuring packet capture Pod %v", err)
-		return err
+		return fmt.Errorf("error ensuring packet capture Pod: %w", err)
 	}
 	log.Println("Waiting For Packet Capture Pod")
 	err = waitForPacketCapturePod(o, capturePod)
 	if err != nil {
-		log.Fatalf("Error Waiting for daemonset %v", err)
-		return err
+		return fmt.Errorf("error waiting for daemonset: %w", err)
 	}
 	log.Println("Copying Files From Packet Capture Pods")
 	err = copyFilesFromPacketCapturePods(o)
 	if err != nil {
-		log.Fatalf("Error copying files %v", err)
-		return err
+		return fmt.Errorf("error copying files: %w", err)
 	}
 	log.Println("Deleting Packet Capture Pod")
 	err = deletePacketCapturePod(o, capturePod)
 	if err != nil {
-		log.Fatalf("Error deleting packet capture daemonset %v", err)
-		return err
+		return fmt.Errorf("error deleting packet capture daemonset: %w", err)
 	}
 	return nil
 }
@@ -174,8 +170,7 @@ func ensurePacketCaptureDaemonSet(o *packetCaptureOptions) (*appsv1.DaemonSet, e
 	desired := desiredPacketCaptureDaemonSet(o, key)
 	haveDs, err := hasPacketCaptureDaemonSet(o, key)
 	if err != nil {
-		log.Fatalf("Error getting current daemonset %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error getting current daemonset: %w", err)
 	}
 
 	if haveDs {
@@ -185,8 +180,7 @@ func ensurePacketCaptureDaemonSet(o *packetCaptureOptions) (*appsv1.DaemonSet, e
 
 	err = createPacketCaptureDaemonSet(o, desired)
 	if err != nil {
-		log.Fatalf("Error creating packet capture daemonset %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error creating packet capture daemonset: %w", err)
 	}
 
 	log.Println("Successfully ensured packet capture daemonset")
@@ -314,37 +308,34 @@ func copyFilesFromPod(o *packetCaptureOptions, pod *corev1.Pod) error {
 }
 
 func waitForPacketCaptureDaemonset(o *packetCaptureOptions, ds *appsv1.DaemonSet) error {
-	pollErr := wait.PollImmediate(10*time.Second, time.Duration(600)*time.Second, func() (bool, error) {
-		var err error
+	opts := poll.Options{Description: "daemonset " + ds.Name, Timeout: packetCapturePollTimeout, Interval: packetCapturePollInterval}
+	return poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
 		tmp := &appsv1.DaemonSet{}
 		key := types.NamespacedName{Name: ds.Name, Namespace: ds.Namespace}
-		if err = o.kubeCli.Get(context.TODO(), key, tmp); err == nil {
-			ready := tmp.Status.NumberReady > 0 &&
-				tmp.Status.NumberAvailable == tmp.Status.NumberReady &&
-				tmp.Status.NumberReady == tmp.Status.DesiredNumberScheduled
-			return ready, nil
+		if err := o.kubeCli.Get(ctx, key, tmp); err != nil {
+			return false, "", err
 		}
-		return false, err
+		ready := tmp.Status.NumberReady > 0 &&
+			tmp.Status.NumberAvailable == tmp.Status.NumberReady &&
+			tmp.Status.NumberReady == tmp.Status.DesiredNumberScheduled
+		return ready, fmt.Sprintf("%d of %d pods ready", tmp.Status.NumberReady, tmp.Status.DesiredNumberScheduled), nil
 	})
-	return pollErr
 }
 
 func waitForPacketCaptureContainerRunning(o *packetCaptureOptions, pod *corev1.Pod) error {
-	pollErr := wait.PollImmediate(10*time.Second, time.Duration(600)*time.Second, func() (bool, error) {
-		var err error
+	opts := poll.Options{Description: "the container of pod " + pod.Name, Timeout: packetCapturePollTimeout, Interval: packetCapturePollInterval}
+	return poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
 		tmp := &corev1.Pod{}
 		key := types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}
-		if err = o.kubeCli.Get(context.TODO(), key, tmp); err == nil {
-			if len(tmp.Status.ContainerStatuses) == 0 {
-				return false, nil
-			}
-			state := tmp.Status.ContainerStatuses[0].State
-			running := state.Running != nil
-			return running, nil
+		if err := o.kubeCli.Get(ctx, key, tmp); err != nil {
+			return false, "", err
 		}
-		return false, err
+		if len(tmp.Status.ContainerStatuses) == 0 {
+			return false, "the container isn't created yet", nil
+		}
+		running := tmp.Status.ContainerStatuses[0].State.Running != nil
+		return running, "the container isn't running yet", nil
 	})
-	return pollErr
 }
 
 func copyFilesFromPacketCapturePods(o *packetCaptureOptions) error {
@@ -362,14 +353,12 @@ func copyFilesFromPacketCapturePods(o *packetCaptureOptions) error {
 		}
 		err := waitForPacketCaptureContainerRunning(o, &pods.Items[i])
 		if err != nil {
-			log.Fatalf("Error waiting for pods %v", err)
-			return err
+			return fmt.Errorf("error waiting for pods: %w", err)
 		}
 		log.Printf("Copying files from %s\n", pod.Name)
 		err = copyFilesFromPod(o, &pods.Items[i])
 		if err != nil {
-			log.Fatalf("error copying files %v", err)
-			return err
+			return fmt.Errorf("error copying files: %w", err)
 		}
 	}
 
@@ -469,8 +458,7 @@ func ensurePacketCapturePod(o *packetCaptureOptions) (*corev1.Pod, error) {
 	desired := desiredPacketCapturePod(o, key)
 	havePod, err := hasPacketCapturePod(o, key)
 	if err != nil {
-		log.Fatalf("Error getting current Pod %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error getting current Pod: %w", err)
 	}
 
 	if havePod {
@@ -480,8 +468,7 @@ func ensurePacketCapturePod(o *packetCaptureOptions) (*corev1.Pod, error) {
 
 	err = createPacketCapturePod(o, desired)
 	if err != nil {
-		log.Fatalf("Error creating packet capture Pod %v", err)
-		return nil, err
+		return nil, fmt.Errorf("error creating packet capture Pod: %w", err)
 	}
 
 	log.Println("Successfully ensured packet capture Pod")
@@ -498,17 +485,15 @@ func createPacketCapturePod(o *packetCaptureOptions, capturePod *corev1.Pod) err
 
 // waitForPacketCapturePod creates the given Pod resource
 func waitForPacketCapturePod(o *packetCaptureOptions, capturePod *corev1.Pod) error {
-	pollErr := wait.PollImmediate(10*time.Second, time.Duration(600)*time.Second, func() (bool, error) {
-		var err error
+	opts := poll.Options{Description: "pod " + capturePod.Name, Timeout: packetCapturePollTimeout, Interval: packetCapturePollInterval}
+	return poll.Until(deadline.Context(), opts, func(ctx context.Context) (bool, string, error) {
 		tmp := &corev1.Pod{}
 		key := types.NamespacedName{Name: capturePod.Name, Namespace: capturePod.Namespace}
-		if err = o.kubeCli.Get(context.TODO(), key, tmp); err == nil {
-			ready := tmp.Status.Phase == corev1.PodRunning
-			return ready, nil
+		if err := o.kubeCli.Get(ctx, key, tmp); err != nil {
+			return false, "", err
 		}
-		return false, err
+		return tmp.Status.Phase == corev1.PodRunning, "the pod is " + string(tmp.Status.Phase), nil
 	})
-	return pollErr
 }
 
 func setCaptureInterface(o *packetCaptureOptions) error {
@@ -4,8 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"log"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -14,15 +15,26 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	"github.com/openshift-online/ocm-sdk-go/logging"
+	"github.com/openshift/osd-network-verifier/pkg/output"
 	"github.com/openshift/osd-network-verifier/pkg/proxy"
 	onv "github.com/openshift/osd-network-verifier/pkg/verifier"
 	onvAwsClient "github.com/openshift/osd-network-verifier/pkg/verifier/aws"
+	"github.com/openshift/osdctl/pkg/catalog"
 	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/trace"
+	"github.com/openshift/osdctl/pkg/tui"
 	"github.com/openshift/osdctl/pkg/utils"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
-const nonByovpcPrivateSubnetTagKey = "kubernetes.io/role/internal-elb"
+const (
+	nonByovpcPrivateSubnetTagKey = "kubernetes.io/role/internal-elb"
+
+	defaultEgressTimeout = 2 * time.Second
+)
 
 type EgressVerification struct {
 	awsClient egressVerificationAWSClient
@@ -34,9 +46,14 @@ type EgressVerification struct {
 	ClusterId string
 	// AWS Region is an optional override if not specified via AWS credentials.
 	Region string
-	// SubnetId is an optional override for specifying an AWS subnet ID.
-	// Must be a private subnet to provide accurate results.
-	SubnetId string
+	// SubnetIds is an optional override for specifying the AWS subnet IDs, every one is verified concurrently.
+	// Must be private subnets to provide accurate results.
+	SubnetIds []string
+	// SubnetTags are the tags, as key or key=value, the private subnets of the cluster are discovered by when no
+	// SubnetIds are given
+	SubnetTags []string
+	// EgressTimeout is the timeout of every egress request of the verifier
+	EgressTimeout time.Duration
 	// SecurityGroupId is an optional override for specifying an AWS security group ID.
 	SecurityGroupId string
 	// Debug optionally enables debug-level logging for underlying calls to osd-network-verifier.
@@ -63,6 +80,10 @@ func NewCmdValidateEgress() *cobra.Command {
   verify whether a ROSA cluster's VPC allows for all required external URLs are reachable. The exact cause can vary and
   typically requires a customer to remediate the issue themselves.
 
+  Every private subnet of the cluster is verified concurrently, each with its own section in the output, followed by
+  an aggregate result: the command fails when one of the subnets fails. The private subnets are discovered by their
+  --subnet-tag tags, among the subnets of the cluster.
+
   Docs: https://docs.openshift.com/rosa/rosa_install_access_delete_clusters/rosa_getting_started_iam/rosa-aws-prereqs.html#osd-aws-privatelink-firewall-prerequisites_prerequisites`,
 		Example: `
   # Run against a cluster registered in OCM
@@ -74,20 +95,27 @@ func NewCmdValidateEgress() *cobra.Command {
   touch cacert.txt
   osdctl network verify-egress --cluster-id my-rosa-cluster --cacert cacert.txt
 
-  # Override automatic selection of a subnet or security group id
+  # Override automatic selection of the subnets or security group id
+  ocm-backplane tunnel -D
+  osdctl network verify-egress --cluster-id my-rosa-cluster --subnet-id subnet-abcd,subnet-efgh --security-group sg-abcd
+
+  # Discover the private subnets of a BYOVPC cluster by a tag of the customer, with a longer timeout
   ocm-backplane tunnel -D
-  osdctl network verify-egress --cluster-id my-rosa-cluster --subnet-id subnet-abcd --security-group sg-abcd
+  osdctl network verify-egress --cluster-id my-rosa-cluster --subnet-tag network=private --egress-timeout 5s
 
   # (Not recommended) Run against a specific VPC, without specifying cluster-id
   <export environment variables like AWS_ACCESS_KEY_ID or use aws configure>
   osdctl network verify-egress --subnet-id subnet-abcdefg123 --security-group sg-abcdefgh123 --region us-east-1`,
+		Annotations: map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
 		Run: func(cmd *cobra.Command, args []string) {
-			e.Run(context.TODO())
+			osdctlErrors.CheckErr(e.Run(context.TODO()))
 		},
 	}
 
 	validateEgressCmd.Flags().StringVar(&e.ClusterId, "cluster-id", "", "(optional) OCM internal/external cluster id to run osd-network-verifier against.")
-	validateEgressCmd.Flags().StringVar(&e.SubnetId, "subnet-id", "", "(optional) private subnet ID override, required if not specifying --cluster-id")
+	validateEgressCmd.Flags().StringSliceVar(&e.SubnetIds, "subnet-id", nil, "(optional) comma separated private subnet IDs override, required if not specifying --cluster-id")
+	validateEgressCmd.Flags().StringSliceVar(&e.SubnetTags, "subnet-tag", []string{nonByovpcPrivateSubnetTagKey}, "(optional) comma separated tags, as key or key=value, the private subnets of the cluster are discovered by")
+	validateEgressCmd.Flags().DurationVar(&e.EgressTimeout, "egress-timeout", defaultEgressTimeout, "(optional) timeout of every egress request of the verifier")
 	validateEgressCmd.Flags().StringVar(&e.SecurityGroupId, "security-group", "", "(optional) security group ID override for osd-network-verifier, required if not specifying --cluster-id")
 	validateEgressCmd.Flags().StringVar(&e.CaCert, "cacert", "", "(optional) path to a file containing the additional CA trust bundle. Typically set so that the verifier can use a configured cluster-wide proxy.")
 	validateEgressCmd.Flags().BoolVar(&e.NoTls, "no-tls", false, "(optional) if provided, ignore all ssl certificate validations on client-side.")
@@ -100,6 +128,17 @@ func NewCmdValidateEgress() *cobra.Command {
 	return validateEgressCmd
 }
 
+// egressValidator runs the egress verification from a subnet, it is implemented by the osd-network-verifier clients
+type egressValidator interface {
+	ValidateEgress(vei onv.ValidateEgressInput) *output.Output
+}
+
+// subnetResult is the result of the egress verification from a subnet
+type subnetResult struct {
+	subnetId string
+	out      *output.Output
+}
+
 type egressVerificationAWSClient interface {
 	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
 	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(options *ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
@@ -108,28 +147,79 @@ type egressVerificationAWSClient interface {
 // Run parses the EgressVerification input, typically sets values automatically using the ClusterId, and runs
 // osd-network-verifier's egress check to validate AWS firewall prerequisites for ROSA.
 // Docs: https://docs.openshift.com/rosa/rosa_install_access_delete_clusters/rosa_getting_started_iam/rosa-aws-prereqs.html#osd-aws-privatelink-firewall-prerequisites_prerequisites
-func (e *EgressVerification) Run(ctx context.Context) {
+func (e *EgressVerification) Run(ctx context.Context) error {
 	cfg, err := e.setup(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	c, err := onvAwsClient.NewAwsVerifierFromConfig(*cfg, e.log)
 	if err != nil {
-		log.Fatal(fmt.Errorf("failed to assemble osd-network-verifier client: %s", err))
+		return fmt.Errorf("failed to assemble osd-network-verifier client: %s", err)
 	}
 
 	input, err := e.generateAWSValidateEgressInput(ctx, cfg.Region)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	e.log.Info(ctx, "running with config: %+v", input)
 
-	out := onv.ValidateEgress(c, *input)
-	out.Summary(e.Debug)
-	if out.IsSuccessful() {
-		log.Println("All tests pass")
+	subnetIds, err := e.getSubnetIds(ctx)
+	if err != nil {
+		return err
 	}
+
+	spinner := tui.NewSpinner(os.Stderr, fmt.Sprintf("Running the egress verification from %d subnet(s)", len(subnetIds)))
+	spinner.Start()
+	results := validateSubnets(c, *input, subnetIds)
+	spinner.Stop("")
+
+	if failed := printSubnetResults(results, e.Debug); failed > 0 {
+		return fmt.Errorf("%d of %d subnet(s) failed the egress verification", failed, len(results))
+	}
+	log.Println("All tests pass")
+	return nil
+}
+
+// validateSubnets runs the egress verification from every subnet concurrently, the results are in the order of the
+// subnets
+func validateSubnets(validator egressValidator, input onv.ValidateEgressInput, subnetIds []string) []subnetResult {
+	results := make([]subnetResult, len(subnetIds))
+	var wg sync.WaitGroup
+	for i, subnetId := range subnetIds {
+		wg.Add(1)
+		go func(i int, subnetId string) {
+			defer wg.Done()
+			subnetInput := input
+			subnetInput.SubnetID = subnetId
+			results[i] = subnetResult{subnetId: subnetId, out: validator.ValidateEgress(subnetInput)}
+		}(i, subnetId)
+	}
+	wg.Wait()
+	return results
+}
+
+// printSubnetResults prints a section per subnet and the aggregate result, and returns how many subnets failed
+func printSubnetResults(results []subnetResult, debug bool) int {
+	failed := 0
+	for _, result := range results {
+		fmt.Printf("=== Subnet %s ===\n", result.subnetId)
+		result.out.Summary(debug)
+		fmt.Println()
+		if !result.out.IsSuccessful() {
+			failed++
+		}
+	}
+
+	fmt.Println("=== Aggregate result ===")
+	for _, result := range results {
+		status := "PASS"
+		if !result.out.IsSuccessful() {
+			status = "FAIL"
+		}
+		fmt.Printf("%s\t%s\n", status, result.subnetId)
+	}
+	return failed
 }
 
 // setup configures an EgressVerification's awsClient and cluster depending on whether the ClusterId or profile
@@ -170,13 +260,13 @@ func (e *EgressVerification) setup(ctx context.Context) (*aws.Config, error) {
 	}
 
 	// If no ClusterId is supplied, then --subnet-id and --security-group are required
-	if e.SubnetId == "" || e.SecurityGroupId == "" {
+	if len(e.SubnetIds) == 0 || e.SecurityGroupId == "" {
 		return nil, fmt.Errorf("--subnet-id and --security-group are required when --cluster-id is not specified")
 	}
 
 	e.log.Info(ctx, "[WARNING] no cluster-id specified, there is reduced validation around the security group, subnet, and proxy, causing inaccurate results")
 	e.log.Info(ctx, "using whatever default AWS credentials are locally available")
-	cfg, err := config.LoadDefaultConfig(ctx)
+	cfg, err := config.LoadDefaultConfig(ctx, append(trace.AWSV2Options(), recording.AWSV2Options()...)...)
 	if err != nil {
 		return nil, fmt.Errorf("network verification failed to find valid creds locally: %s", err)
 	}
@@ -193,8 +283,8 @@ func (e *EgressVerification) setup(ctx context.Context) (*aws.Config, error) {
 
 // generateAWSValidateEgressInput is an opinionated interface in front of osd-network-verifier.
 // Its input is an OCM internal/external ClusterId and it returns the corresponding input to osd-network-verifier with
-// default AWS tags, one of the cluster's private subnet IDs, and the cluster's master security group.
-// Can override SecurityGroupId and SubnetId.
+// default AWS tags and the cluster's master security group, the subnet ID is set for every subnet by validateSubnets.
+// Can override SecurityGroupId.
 func (e *EgressVerification) generateAWSValidateEgressInput(ctx context.Context, region string) (*onv.ValidateEgressInput, error) {
 	// We can auto-detect information from OCM
 	if e.cluster != nil {
@@ -214,6 +304,9 @@ func (e *EgressVerification) generateAWSValidateEgressInput(ctx context.Context,
 	if err != nil {
 		return nil, fmt.Errorf("failed to assemble validate egress input: %s", err)
 	}
+	if e.EgressTimeout > 0 {
+		input.Timeout = e.EgressTimeout
+	}
 
 	// Setup proxy configuration that is not automatically determined
 	input.Proxy.NoTls = e.NoTls
@@ -238,13 +331,6 @@ func (e *EgressVerification) generateAWSValidateEgressInput(ctx context.Context,
 		}
 	}
 
-	// Fill in subnetID
-	subnetId, err := e.getSubnetId(context.TODO())
-	if err != nil {
-		return nil, err
-	}
-	input.SubnetID = subnetId
-
 	// Fill in securityGroupID
 	sgId, err := e.getSecurityGroupId(context.TODO())
 	if err != nil {
@@ -255,55 +341,104 @@ func (e *EgressVerification) generateAWSValidateEgressInput(ctx context.Context,
 	return input, nil
 }
 
-// getSubnetId attempts to return a private subnet ID.
-// e.SubnetId acts as an override, otherwise e.awsClient will be used to attempt to determine the correct subnets
-func (e *EgressVerification) getSubnetId(ctx context.Context) (string, error) {
-	// A SubnetId was manually specified, just use that
-	if e.SubnetId != "" {
-		e.log.Info(ctx, "using manually specified subnet-id: %s", e.SubnetId)
-		return e.SubnetId, nil
+// getSubnetIds attempts to return the private subnet IDs of a cluster.
+// e.SubnetIds acts as an override, otherwise e.awsClient will be used to attempt to determine the correct subnets
+func (e *EgressVerification) getSubnetIds(ctx context.Context) ([]string, error) {
+	// SubnetIds were manually specified, just use those
+	if len(e.SubnetIds) > 0 {
+		e.log.Info(ctx, "using manually specified subnet-id(s): %s", strings.Join(e.SubnetIds, ","))
+		return e.SubnetIds, nil
+	}
+
+	tagFilters, err := subnetTagFilters(e.SubnetTags)
+	if err != nil {
+		return nil, err
 	}
 
 	// If this is a non-BYOVPC cluster, we can find the private subnets based on the cluster and internal-elb tag
 	if len(e.cluster.AWS().SubnetIDs()) == 0 {
-		e.log.Info(ctx, "searching for subnets by tags: kubernetes.io/cluster/%s=owned and %s=", e.cluster.InfraID(), nonByovpcPrivateSubnetTagKey)
-		resp, err := e.awsClient.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{
-			Filters: []types.Filter{
-				{
-					Name:   aws.String(fmt.Sprintf("tag:kubernetes.io/cluster/%s", e.cluster.InfraID())),
-					Values: []string{"owned"},
-				},
-				{
-					Name:   aws.String("tag-key"),
-					Values: []string{nonByovpcPrivateSubnetTagKey},
-				},
+		e.log.Info(ctx, "searching for subnets by tags: kubernetes.io/cluster/%s=owned and %s", e.cluster.InfraID(), strings.Join(e.SubnetTags, ","))
+		filters := append([]types.Filter{
+			{
+				Name:   aws.String(fmt.Sprintf("tag:kubernetes.io/cluster/%s", e.cluster.InfraID())),
+				Values: []string{"owned"},
 			},
-		})
+		}, tagFilters...)
+		subnetIds, err := e.describeSubnetIds(ctx, filters)
 		if err != nil {
-			return "", fmt.Errorf("failed to find private subnets for %s: %w", e.cluster.InfraID(), err)
+			return nil, err
 		}
 
-		if len(resp.Subnets) == 0 {
-			return "", fmt.Errorf("found 0 subnets with kubernetes.io/cluster/%s=owned and %s, consider the --subnet-id flag", e.cluster.InfraID(), e.cluster.InfraID())
+		if len(subnetIds) == 0 {
+			return nil, fmt.Errorf("found 0 subnets with kubernetes.io/cluster/%s=owned and %s, consider the --subnet-id flag", e.cluster.InfraID(), strings.Join(e.SubnetTags, ","))
 		}
 
-		e.log.Info(ctx, "using subnet-id: %s", *resp.Subnets[0].SubnetId)
-		return *resp.Subnets[0].SubnetId, nil
+		e.log.Info(ctx, "using subnet-id(s): %s", strings.Join(subnetIds, ","))
+		return subnetIds, nil
 	}
 
 	// For PrivateLink clusters, any provided subnet is considered a private subnet
 	if e.cluster.AWS().PrivateLink() {
-		if len(e.cluster.AWS().SubnetIDs()) == 0 {
-			return "", fmt.Errorf("unexpected error: %s is a PrivateLink cluster, but no subnets in OCM", e.cluster.InfraID())
-		}
-
-		e.log.Info(ctx, "detected BYOVPC PrivateLink cluster, using first subnet from OCM: %s", e.cluster.AWS().SubnetIDs()[0])
-		return e.cluster.AWS().SubnetIDs()[0], nil
+		e.log.Info(ctx, "detected BYOVPC PrivateLink cluster, using the subnets from OCM: %s", strings.Join(e.cluster.AWS().SubnetIDs(), ","))
+		return e.cluster.AWS().SubnetIDs(), nil
 	}
 
-	// For non-PrivateLink BYOVPC clusters, provided subnets are 50/50 public/private subnets, so make the user decide for now
+	// For non-PrivateLink BYOVPC clusters, provided subnets are 50/50 public/private subnets, the private ones are
+	// those with the subnet tags
 	// TODO: Figure out via IGW/NAT GW/Route Tables
-	return "", fmt.Errorf("unable to determine which non-PrivateLink BYOVPC subnets are private yet, please check manually and provide the --subnet-id flag")
+	e.log.Info(ctx, "detected non-PrivateLink BYOVPC cluster, searching the subnets from OCM by tags: %s", strings.Join(e.SubnetTags, ","))
+	filters := append([]types.Filter{
+		{
+			Name:   aws.String("subnet-id"),
+			Values: e.cluster.AWS().SubnetIDs(),
+		},
+	}, tagFilters...)
+	subnetIds, err := e.describeSubnetIds(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subnetIds) == 0 {
+		return nil, fmt.Errorf("unable to determine which non-PrivateLink BYOVPC subnets are private, none is tagged %s, please check manually and provide the --subnet-id or --subnet-tag flag", strings.Join(e.SubnetTags, ","))
+	}
+
+	e.log.Info(ctx, "using subnet-id(s): %s", strings.Join(subnetIds, ","))
+	return subnetIds, nil
+}
+
+// describeSubnetIds returns the IDs of the subnets matching all the filters
+func (e *EgressVerification) describeSubnetIds(ctx context.Context, filters []types.Filter) ([]string, error) {
+	resp, err := e.awsClient.DescribeSubnets(ctx, &ec2.DescribeSubnetsInput{Filters: filters})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find private subnets for %s: %w", e.cluster.InfraID(), err)
+	}
+
+	subnetIds := make([]string, 0, len(resp.Subnets))
+	for _, subnet := range resp.Subnets {
+		subnetIds = append(subnetIds, *subnet.SubnetId)
+	}
+	return subnetIds, nil
+}
+
+// subnetTagFilters converts the subnet tags, as key or key=value, into DescribeSubnets filters
+func subnetTagFilters(tags []string) ([]types.Filter, error) {
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("at least one --subnet-tag is required to discover the private subnets")
+	}
+
+	filters := make([]types.Filter, 0, len(tags))
+	for _, tag := range tags {
+		key, value, hasValue := strings.Cut(tag, "=")
+		if key == "" {
+			return nil, fmt.Errorf("invalid subnet tag %q, expected key or key=value", tag)
+		}
+		if hasValue {
+			filters = append(filters, types.Filter{Name: aws.String("tag:" + key), Values: []string{value}})
+			continue
+		}
+		filters = append(filters, types.Filter{Name: aws.String("tag-key"), Values: []string{key}})
+	}
+	return filters, nil
 }
 
 // getSecurityGroupId attempts to return a cluster's master node security group Id
@@ -354,7 +489,7 @@ func defaultValidateEgressInput(ctx context.Context, region string) (*onv.Valida
 	}
 
 	return &onv.ValidateEgressInput{
-		Timeout:      2 * time.Second,
+		Timeout:      defaultEgressTimeout,
 		Ctx:          ctx,
 		SubnetID:     "",
 		CloudImageID: onvAwsClient.GetAMIForRegion(region),
@@ -2,6 +2,7 @@ package network
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -9,6 +10,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	"github.com/openshift-online/ocm-sdk-go/logging"
+	"github.com/openshift/osd-network-verifier/pkg/output"
 	"github.com/openshift/osd-network-verifier/pkg/proxy"
 	onv "github.com/openshift/osd-network-verifier/pkg/verifier"
 )
@@ -64,7 +66,7 @@ func Test_egressVerificationSetup(t *testing.T) {
 			name: "ClusterId optional",
 			e: &EgressVerification{
 				ClusterId:       "",
-				SubnetId:        "subnet-a",
+				SubnetIds:       []string{"subnet-a"},
 				SecurityGroupId: "sg-b",
 			},
 			expectErr: false,
@@ -128,13 +130,6 @@ func Test_egressVerificationGenerateAWSValidateEgressInput(t *testing.T) {
 							},
 						},
 					},
-					describeSubnetsResp: &ec2.DescribeSubnetsOutput{
-						Subnets: []types.Subnet{
-							{
-								SubnetId: aws.String("subnet-abcd"),
-							},
-						},
-					},
 				},
 				cluster: newTestCluster(t, cmv1.NewCluster().
 					CloudProvider(cmv1.NewCloudProvider().ID("aws")).
@@ -145,7 +140,6 @@ func Test_egressVerificationGenerateAWSValidateEgressInput(t *testing.T) {
 			},
 			region: "us-east-2",
 			expected: &onv.ValidateEgressInput{
-				SubnetID: "subnet-abcd",
 				Proxy: proxy.ProxyConfig{
 					HttpProxy:  "http://my.proxy:80",
 					HttpsProxy: "https://my.proxy:443",
@@ -252,37 +246,63 @@ func Test_egressVerificationGetSecurityGroupId(t *testing.T) {
 	}
 }
 
-func Test_egressVerificationGetSubnetId(t *testing.T) {
+func Test_egressVerificationGetSubnetIds(t *testing.T) {
 	tests := []struct {
 		name      string
 		e         *EgressVerification
-		expected  string
+		expected  []string
 		expectErr bool
 	}{
 		{
 			name: "manual override",
 			e: &EgressVerification{
-				log:      newTestLogger(t),
-				SubnetId: "override",
+				log:       newTestLogger(t),
+				SubnetIds: []string{"override-a", "override-b"},
 			},
-			expected:  "override",
+			expected:  []string{"override-a", "override-b"},
 			expectErr: false,
 		},
 		{
-			name: "non-PrivateLink + BYOVPC unsupported",
+			name: "non-PrivateLink + BYOVPC errors if no subnets are tagged",
 			e: &EgressVerification{
-				cluster: newTestCluster(t, cmv1.NewCluster().AWS(cmv1.NewAWS().PrivateLink(false).SubnetIDs("subnet-abcd"))),
-				log:     newTestLogger(t),
+				awsClient: mockEgressVerificationAWSClient{
+					describeSubnetsResp: &ec2.DescribeSubnetsOutput{
+						Subnets: []types.Subnet{},
+					},
+				},
+				cluster:    newTestCluster(t, cmv1.NewCluster().AWS(cmv1.NewAWS().PrivateLink(false).SubnetIDs("subnet-abcd", "subnet-efgh"))),
+				log:        newTestLogger(t),
+				SubnetTags: []string{nonByovpcPrivateSubnetTagKey},
 			},
 			expectErr: true,
 		},
 		{
-			name: "PrivateLink + BYOVPC picks the first subnet",
+			name: "non-PrivateLink + BYOVPC gets the tagged subnets from AWS",
 			e: &EgressVerification{
-				cluster: newTestCluster(t, cmv1.NewCluster().AWS(cmv1.NewAWS().PrivateLink(true).SubnetIDs("subnet-abcd"))),
-				log:     newTestLogger(t),
+				awsClient: mockEgressVerificationAWSClient{
+					describeSubnetsResp: &ec2.DescribeSubnetsOutput{
+						Subnets: []types.Subnet{
+							{
+								SubnetId: aws.String("subnet-efgh"),
+							},
+						},
+					},
+				},
+				cluster:    newTestCluster(t, cmv1.NewCluster().AWS(cmv1.NewAWS().PrivateLink(false).SubnetIDs("subnet-abcd", "subnet-efgh"))),
+				log:        newTestLogger(t),
+				SubnetTags: []string{"network=private"},
 			},
-			expected:  "subnet-abcd",
+			expected:  []string{"subnet-efgh"},
+			expectErr: false,
+		},
+		{
+			name: "PrivateLink + BYOVPC picks all the subnets",
+			e: &EgressVerification{
+				cluster:    newTestCluster(t, cmv1.NewCluster().AWS(cmv1.NewAWS().PrivateLink(true).SubnetIDs("subnet-abcd", "subnet-efgh"))),
+				log:        newTestLogger(t),
+				SubnetTags: []string{nonByovpcPrivateSubnetTagKey},
+			},
+			expected:  []string{"subnet-abcd", "subnet-efgh"},
 			expectErr: false,
 		},
 		{
@@ -294,13 +314,17 @@ func Test_egressVerificationGetSubnetId(t *testing.T) {
 							{
 								SubnetId: aws.String("subnet-abcd"),
 							},
+							{
+								SubnetId: aws.String("subnet-efgh"),
+							},
 						},
 					},
 				},
-				cluster: newTestCluster(t, cmv1.NewCluster()),
-				log:     newTestLogger(t),
+				cluster:    newTestCluster(t, cmv1.NewCluster()),
+				log:        newTestLogger(t),
+				SubnetTags: []string{nonByovpcPrivateSubnetTagKey},
 			},
-			expected:  "subnet-abcd",
+			expected:  []string{"subnet-abcd", "subnet-efgh"},
 			expectErr: false,
 		},
 		{
@@ -311,6 +335,15 @@ func Test_egressVerificationGetSubnetId(t *testing.T) {
 						Subnets: []types.Subnet{},
 					},
 				},
+				cluster:    newTestCluster(t, cmv1.NewCluster()),
+				log:        newTestLogger(t),
+				SubnetTags: []string{nonByovpcPrivateSubnetTagKey},
+			},
+			expectErr: true,
+		},
+		{
+			name: "subnet tags are required without an override",
+			e: &EgressVerification{
 				cluster: newTestCluster(t, cmv1.NewCluster()),
 				log:     newTestLogger(t),
 			},
@@ -320,7 +353,7 @@ func Test_egressVerificationGetSubnetId(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			actual, err := test.e.getSubnetId(context.TODO())
+			actual, err := test.e.getSubnetIds(context.TODO())
 			if err != nil {
 				if !test.expectErr {
 					t.Errorf("expected no err, got %s", err)
@@ -329,14 +362,66 @@ func Test_egressVerificationGetSubnetId(t *testing.T) {
 				if test.expectErr {
 					t.Errorf("expected err, got none")
 				}
-				if actual != test.expected {
-					t.Errorf("expected subnet-id %s, got %s", test.expected, actual)
+				if strings.Join(actual, ",") != strings.Join(test.expected, ",") {
+					t.Errorf("expected subnet-ids %v, got %v", test.expected, actual)
 				}
 			}
 		})
 	}
 }
 
+func TestSubnetTagFilters(t *testing.T) {
+	filters, err := subnetTagFilters([]string{nonByovpcPrivateSubnetTagKey, "network=private"})
+	if err != nil {
+		t.Fatalf("expected no err, got %s", err)
+	}
+	if len(filters) != 2 {
+		t.Fatalf("expected 2 filters, got %d", len(filters))
+	}
+	if *filters[0].Name != "tag-key" || filters[0].Values[0] != nonByovpcPrivateSubnetTagKey {
+		t.Errorf("unexpected filter %s=%v", *filters[0].Name, filters[0].Values)
+	}
+	if *filters[1].Name != "tag:network" || filters[1].Values[0] != "private" {
+		t.Errorf("unexpected filter %s=%v", *filters[1].Name, filters[1].Values)
+	}
+
+	if _, err := subnetTagFilters([]string{"=private"}); err == nil {
+		t.Errorf("expected err for a tag without a key, got none")
+	}
+}
+
+type mockEgressValidator struct {
+	failingSubnets map[string]bool
+}
+
+func (m mockEgressValidator) ValidateEgress(vei onv.ValidateEgressInput) *output.Output {
+	out := &output.Output{}
+	if m.failingSubnets[vei.SubnetID] {
+		out.SetEgressFailures([]string{"registry.redhat.io:443"})
+	}
+	return out
+}
+
+func TestValidateSubnets(t *testing.T) {
+	validator := mockEgressValidator{failingSubnets: map[string]bool{"subnet-b": true}}
+	results := validateSubnets(validator, onv.ValidateEgressInput{}, []string{"subnet-a", "subnet-b", "subnet-c"})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for i, subnetId := range []string{"subnet-a", "subnet-b", "subnet-c"} {
+		if results[i].subnetId != subnetId {
+			t.Errorf("expected result %d for %s, got %s", i, subnetId, results[i].subnetId)
+		}
+		if results[i].out.IsSuccessful() == (subnetId == "subnet-b") {
+			t.Errorf("unexpected result for %s", subnetId)
+		}
+	}
+
+	if failed := printSubnetResults(results, false); failed != 1 {
+		t.Errorf("expected 1 failed subnet, got %d", failed)
+	}
+}
+
 func TestDefaultValidateEgressInput(t *testing.T) {
 	tests := []struct {
 		region    string
@@ -0,0 +1,193 @@
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/notes"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const notesLong = `Keeps notes about a cluster, e.g. the observations made during an investigation, for the next on-call.
+
+The notes are encrypted with the '` + secrets.NotesKeyKey + `' secret, created the first time, and kept in
+` + "`~/.config/osdctl-notes/`" + `, or the '` + notes.DirConfigKey + `' of the config file. With '` + notes.BucketConfigKey + `'
+set to s3://BUCKET[/PREFIX], the notes are also pushed to the bucket, and the notes of the team pulled from it, using
+the '` + notes.BucketProfileConfigKey + `' AWS profile and the '` + notes.BucketRegionConfigKey + `' region. The team shares the key with 'osdctl secrets set ` + secrets.NotesKeyKey + `'.
+
+The text of a note is read from stdin rather than the arguments, so that it isn't recorded in the command history.`
+
+const notesExample = `
+  # Add a note, typed in and ended with Ctrl-D
+  osdctl notes add 1kfmyclusteristhebesteverp8m
+
+  # Add a note from a command
+  echo "etcd defragmented, the alert cleared after 10m" | osdctl notes add 1kfmyclusteristhebesteverp8m
+
+  # Read the notes about a cluster
+  osdctl notes list 1kfmyclusteristhebesteverp8m`
+
+type notesOptions struct {
+	author string
+
+	in            *os.File
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// NewCmdNotes implements the notes command
+func NewCmdNotes(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &notesOptions{in: os.Stdin, GlobalOptions: globalOpts}
+	notesCmd := &cobra.Command{
+		Use:               "notes",
+		Short:             "Keeps encrypted notes about clusters for the next on-call",
+		Long:              notesLong,
+		Example:           notesExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	addCmd := &cobra.Command{
+		Use:               "add CLUSTER_ID",
+		Short:             "Adds a note about a cluster, read from stdin",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.add(args[0]))
+		},
+	}
+	addCmd.Flags().StringVar(&ops.author, "author", "", "Author of the note, the current user name by default")
+
+	listCmd := &cobra.Command{
+		Use:               "list CLUSTER_ID",
+		Short:             "Lists the notes about a cluster, oldest first",
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.list(args[0]))
+		},
+	}
+
+	notesCmd.AddCommand(addCmd, listCmd)
+	return notesCmd
+}
+
+type notesResponse struct {
+	ClusterID string       `json:"cluster_id" yaml:"cluster_id"`
+	Notes     []notes.Note `json:"notes" yaml:"notes"`
+}
+
+func (r notesResponse) String() string {
+	var b strings.Builder
+	if len(r.Notes) == 0 {
+		fmt.Fprintf(&b, "No note about cluster %s.\n", r.ClusterID)
+		return b.String()
+	}
+	for _, note := range r.Notes {
+		fmt.Fprintf(&b, "%s by %s (%s)\n", timefmt.Format(note.Timestamp), note.Author, note.ID)
+		for _, line := range strings.Split(strings.TrimRight(note.Text, "\n"), "\n") {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// resolveCluster returns the internal ID of the cluster, so that the notes are found by name, ID or external ID
+func resolveCluster(key string) (string, error) {
+	if err := utils.IsValidClusterKey(key); err != nil {
+		return "", err
+	}
+	connection := utils.CreateConnection()
+	defer connection.Close()
+	cluster, err := utils.GetCluster(connection, key)
+	if err != nil {
+		return "", err
+	}
+	return cluster.ID(), nil
+}
+
+func openStore() (*notes.Store, error) {
+	remote, err := notes.ConfiguredRemote()
+	if err != nil {
+		return nil, err
+	}
+	return notes.Open(remote)
+}
+
+func (o *notesOptions) add(key string) error {
+	clusterID, err := resolveCluster(key)
+	if err != nil {
+		return err
+	}
+	text, err := readNote(o.in)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "the note is empty")
+	}
+	author := o.author
+	if author == "" {
+		author = currentUser()
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	note, err := store.Add(clusterID, author, text)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Added note %s about cluster %s\n", note.ID, clusterID)
+	return nil
+}
+
+func (o *notesOptions) list(key string) error {
+	clusterID, err := resolveCluster(key)
+	if err != nil {
+		return err
+	}
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	list, err := store.List(clusterID)
+	if err != nil {
+		return err
+	}
+	return outputflag.PrintResponse(o.GlobalOptions.Output, notesResponse{ClusterID: clusterID, Notes: list})
+}
+
+// readNote reads the note until the end of stdin, prompting for it on a terminal
+func readNote(in *os.File) (string, error) {
+	if term.IsTerminal(int(in.Fd())) { //#nosec G115 -- file descriptors fit in an int
+		fmt.Fprintln(os.Stderr, "Type the note, then Ctrl-D on a new line:")
+	}
+	text, err := io.ReadAll(io.LimitReader(in, 64<<10))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return string(text), nil
+}
+
+func currentUser() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return current.Username
+	}
+	return "unknown"
+}
@@ -0,0 +1,23 @@
+package ocm
+
+import (
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// NewCmdOcm implements the ocm utility
+func NewCmdOcm(streams genericclioptions.IOStreams, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ocmCmd := &cobra.Command{
+		Use:               "ocm",
+		Short:             "Low level access to the OCM API",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	ocmCmd.AddCommand(newCmdRequest(streams, globalOpts))
+	return ocmCmd
+}
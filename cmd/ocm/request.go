@@ -0,0 +1,263 @@
+package ocm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/openshift-online/ocm-cli/pkg/arguments"
+	"github.com/openshift-online/ocm-cli/pkg/dump"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/yaml"
+)
+
+const requestLong = `Sends a raw request to the OCM API using osdctl's connection.
+
+The request shares the OCM rate limiter and the retries of every other osdctl command. Requests
+other than GET ask for confirmation, use --dry-run to only print what would be sent.
+
+--dry-run=server sends the request with OCM's dry-run parameter instead, so that OCM validates it
+without applying it, e.g. to check a cluster can be deleted. Only the endpoints taking a dry-run
+parameter support it: deleting a cluster and updating an add-on.`
+
+const requestExample = `
+  # Get a cluster
+  osdctl ocm request GET /api/clusters_mgmt/v1/clusters/1kfmyclusteristhebesteverp8m
+
+  # Search subscriptions, as YAML
+  osdctl ocm request GET /api/accounts_mgmt/v1/subscriptions -p search="status='Active'" -o yaml
+
+  # Patch a cluster with the body read from a file, without sending it
+  osdctl ocm request PATCH /api/clusters_mgmt/v1/clusters/1kfmyclusteristhebesteverp8m --body patch.json --dry-run
+
+  # Check with OCM that a cluster can be deleted, without deleting it
+  osdctl ocm request DELETE /api/clusters_mgmt/v1/clusters/1kfmyclusteristhebesteverp8m --dry-run=server
+`
+
+var requestMethods = []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete}
+
+type requestOptions struct {
+	method     string
+	path       string
+	body       string
+	parameters []string
+	headers    []string
+	dryRun     utils.DryRun
+	yes        bool
+	output     string
+	// dryRunParameter is the query parameter of the endpoint for --dry-run=server
+	dryRunParameter string
+
+	GlobalOptions *globalflags.GlobalOptions
+	genericclioptions.IOStreams
+}
+
+func newCmdRequest(streams genericclioptions.IOStreams, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &requestOptions{GlobalOptions: globalOpts, IOStreams: streams}
+	requestCmd := &cobra.Command{
+		Use:               "request METHOD PATH",
+		Short:             "Sends a request to the OCM API",
+		Long:              requestLong,
+		Example:           requestExample,
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		ValidArgs:         requestMethods,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	requestCmd.Flags().StringVar(&ops.body, "body", "", "File containing the request body, required for POST and PATCH")
+	arguments.AddParameterFlag(requestCmd.Flags(), &ops.parameters)
+	arguments.AddHeaderFlag(requestCmd.Flags(), &ops.headers)
+	utils.AddDryRunFlag(requestCmd.Flags(), &ops.dryRun, "Print the request without sending it, or with --dry-run=server have OCM validate it without applying it")
+	requestCmd.Flags().BoolVarP(&ops.yes, "yes", "y", false, "Skip the confirmation prompt of requests other than GET")
+
+	return requestCmd
+}
+
+func (o *requestOptions) complete(cmd *cobra.Command, args []string) error {
+	method, err := parseMethod(args[0])
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, err.Error())
+	}
+	o.method = method
+
+	if !strings.HasPrefix(args[1], "/api/") {
+		return cmdutil.UsageErrorf(cmd, "PATH must start with /api/, e.g. /api/clusters_mgmt/v1/clusters")
+	}
+	o.path = args[1]
+
+	switch o.method {
+	case http.MethodPost, http.MethodPatch:
+		if o.body == "" {
+			return cmdutil.UsageErrorf(cmd, "--body is required for %s requests", o.method)
+		}
+	default:
+		if o.body != "" {
+			return cmdutil.UsageErrorf(cmd, "--body can't be used with %s requests", o.method)
+		}
+	}
+
+	if o.dryRun == utils.DryRunServer {
+		parameter, err := utils.ServerDryRunParameter(o.method, o.path)
+		if err != nil {
+			return err
+		}
+		o.dryRunParameter = parameter
+	}
+
+	o.output = o.GlobalOptions.Output
+	if o.output != "" && o.output != "json" && o.output != "yaml" {
+		return cmdutil.UsageErrorf(cmd, "unsupported output format '%s', expected 'json' or 'yaml'", o.output)
+	}
+
+	return nil
+}
+
+// parseMethod validates the HTTP method case-insensitively
+func parseMethod(method string) (string, error) {
+	method = strings.ToUpper(method)
+	for _, m := range requestMethods {
+		if method == m {
+			return method, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported method '%s', expected one of %s", method, strings.Join(requestMethods, ", "))
+}
+
+func (o *requestOptions) run() error {
+	var body []byte
+	if o.body != "" {
+		var err error
+		body, err = os.ReadFile(o.body)
+		if err != nil {
+			return fmt.Errorf("cannot read request body: %w", err)
+		}
+		if !json.Valid(body) {
+			return fmt.Errorf("request body in '%s' is not valid JSON", o.body)
+		}
+	}
+
+	connection := utils.CreateConnection()
+	defer func() {
+		if err := connection.Close(); err != nil {
+			fmt.Fprintf(o.ErrOut, "Cannot close the connection: %q\n", err)
+		}
+	}()
+
+	request, err := o.newRequest(connection, body)
+	if err != nil {
+		return err
+	}
+
+	if o.dryRun == utils.DryRunClient {
+		return printRequest(o.Out, o.method, connection.URL(), o.path, o.parameters, body)
+	}
+
+	if feature, ok := utils.OCMFeatureForPath(request.GetPath()); ok {
+		if err := utils.RequireOCMFeature(connection, feature); err != nil {
+			return err
+		}
+	}
+
+	if o.dryRun == utils.DryRunServer {
+		request.Parameter(o.dryRunParameter, true)
+		fmt.Fprintf(o.ErrOut, "Server-side dry-run: OCM validates the request without applying it\n")
+	} else if o.method != http.MethodGet {
+		err := utils.Confirm(utils.ConfirmOptions{
+			Summary: &utils.ImpactSummary{
+				Action:      o.method + " " + request.GetPath(),
+				Environment: utils.GetCurrentOCMEnv(connection),
+			},
+			SkipPrompt: o.yes,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	response, err := request.Send()
+	if err != nil {
+		return fmt.Errorf("cannot send request: %w", err)
+	}
+
+	if response.Status() >= http.StatusBadRequest {
+		_ = dump.Pretty(o.ErrOut, response.Bytes())
+		return fmt.Errorf("%s %s failed with status %d", o.method, request.GetPath(), response.Status())
+	}
+
+	if o.dryRun == utils.DryRunServer {
+		fmt.Fprintf(o.ErrOut, "OCM accepted %s %s, nothing was changed\n", o.method, request.GetPath())
+	}
+	return printResponseBody(printer.Tee(o.Out), response.Bytes(), o.output)
+}
+
+func (o *requestOptions) newRequest(connection *sdk.Connection, body []byte) (*sdk.Request, error) {
+	var request *sdk.Request
+	switch o.method {
+	case http.MethodGet:
+		request = connection.Get()
+	case http.MethodPost:
+		request = connection.Post()
+	case http.MethodPatch:
+		request = connection.Patch()
+	case http.MethodDelete:
+		request = connection.Delete()
+	}
+
+	if err := arguments.ApplyPathArg(request, o.path); err != nil {
+		return nil, fmt.Errorf("cannot parse API path '%s': %w", o.path, err)
+	}
+	arguments.ApplyParameterFlag(request, o.parameters)
+	arguments.ApplyHeaderFlag(request, o.headers)
+	if body != nil {
+		request.Bytes(body)
+	}
+	return request, nil
+}
+
+// printRequest describes the request that would be sent, for --dry-run
+func printRequest(w io.Writer, method, baseURL, path string, parameters []string, body []byte) error {
+	fmt.Fprintf(w, "%s %s%s\n", method, baseURL, path)
+	for _, parameter := range parameters {
+		name, value := arguments.ParseNameValuePair(parameter)
+		fmt.Fprintf(w, "  %s=%s\n", name, value)
+	}
+
+	if body != nil {
+		return dump.Pretty(w, body)
+	}
+	return nil
+}
+
+// printResponseBody prints the JSON body of a response in the requested output format
+func printResponseBody(w io.Writer, body []byte, output string) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if output == "yaml" {
+		data, err := yaml.JSONToYAML(body)
+		if err != nil {
+			return fmt.Errorf("cannot convert the response to YAML: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	if printer.FilterEnabled() {
+		return printer.PrintJSON(w, body)
+	}
+	return dump.Pretty(w, body)
+}
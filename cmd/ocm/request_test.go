@@ -0,0 +1,48 @@
+package ocm
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestParseMethod(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	method, err := parseMethod("patch")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(method).To(Equal("PATCH"))
+
+	_, err = parseMethod("PUT")
+	g.Expect(err).To(MatchError(ContainSubstring("unsupported method 'PUT'")))
+}
+
+func TestPrintRequest(t *testing.T) {
+	g := NewGomegaWithT(t)
+	var out bytes.Buffer
+
+	err := printRequest(&out, "PATCH", "https://api.openshift.com", "/api/clusters_mgmt/v1/clusters/abc",
+		[]string{"search=name = 'foo'"}, []byte(`{"display_name":"foo"}`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out.String()).To(ContainSubstring("PATCH https://api.openshift.com/api/clusters_mgmt/v1/clusters/abc\n"))
+	g.Expect(out.String()).To(ContainSubstring("  search=name = 'foo'\n"))
+	g.Expect(out.String()).To(ContainSubstring(`"display_name": "foo"`))
+}
+
+func TestPrintResponseBody(t *testing.T) {
+	g := NewGomegaWithT(t)
+	body := []byte(`{"kind":"Cluster","id":"abc"}`)
+
+	var out bytes.Buffer
+	g.Expect(printResponseBody(&out, body, "yaml")).To(Succeed())
+	g.Expect(out.String()).To(Equal("id: abc\nkind: Cluster\n"))
+
+	out.Reset()
+	g.Expect(printResponseBody(&out, body, "")).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring(`"kind": "Cluster"`))
+
+	out.Reset()
+	g.Expect(printResponseBody(&out, nil, "")).To(Succeed())
+	g.Expect(out.String()).To(BeEmpty())
+}
@@ -3,17 +3,17 @@ package org
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 const (
@@ -27,7 +27,7 @@ var (
 		Args:          cobra.ArbitraryArgs,
 		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(SearchClusters(cmd, args))
+			osdctlErrors.CheckErr(SearchClusters(cmd, args))
 		},
 	}
 	onlyActive   bool   = false
@@ -113,17 +113,15 @@ func searchClustersByAWSProfile(cmd *cobra.Command) error {
 		})
 
 	if err != nil {
-		log.Fatalln("cannot get Organizational Unit:", err)
+		return fmt.Errorf("cannot get Organizational Unit: %q", err)
 	}
 
-	searchclustersByOrg(cmd, *result.OrganizationalUnit.Id)
-
-	return nil
+	return searchclustersByOrg(cmd, *result.OrganizationalUnit.Id)
 }
 
 func searchclustersByOrg(cmd *cobra.Command, orgID string) error {
 
-	response, err := getClusters(orgID)
+	response, err := getClusters(cmd.ErrOrStderr(), orgID)
 	if err != nil {
 		return fmt.Errorf("invalid input: %q", err)
 	}
@@ -139,20 +137,24 @@ func searchclustersByOrg(cmd *cobra.Command, orgID string) error {
 	return nil
 }
 
-func getClusters(orgID string) (*sdk.Response, error) {
+func getClusters(errOut io.Writer, orgID string) (*sdk.Response, error) {
 	// Create OCM client to talk
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(errOut, "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 
 	// Now get the matching orgs
-	return sendRequest(createGetClustersRequest(ocmClient, orgID))
+	request, err := createGetClustersRequest(ocmClient, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return sendRequest(request)
 }
 
-func createGetClustersRequest(ocmClient *sdk.Connection, orgID string) *sdk.Request {
+func createGetClustersRequest(ocmClient *sdk.Connection, orgID string) (*sdk.Request, error) {
 	// Create and populate the request:
 	request := ocmClient.Get()
 	subscriptionApiPath := "/api/accounts_mgmt/v1/subscriptions"
@@ -160,8 +162,7 @@ func createGetClustersRequest(ocmClient *sdk.Connection, orgID string) *sdk.Requ
 	err := arguments.ApplyPathArg(request, subscriptionApiPath)
 
 	if err != nil {
-		log.Fatalf("Can't parse API path '%s': %v\n", subscriptionApiPath, err)
-
+		return nil, fmt.Errorf("can't parse API path '%s': %v", subscriptionApiPath, err)
 	}
 
 	formatMessage := fmt.Sprintf(
@@ -170,7 +171,7 @@ func createGetClustersRequest(ocmClient *sdk.Connection, orgID string) *sdk.Requ
 	)
 	arguments.ApplyParameterFlag(request, []string{formatMessage})
 
-	return request
+	return request, nil
 }
 
 func printClusters(items []Subscription) {
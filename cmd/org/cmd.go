@@ -29,6 +29,9 @@ func NewCmdOrg() *cobra.Command {
 	orgCmd.AddCommand(clustersCmd)
 	orgCmd.AddCommand(customersCmd)
 	orgCmd.AddCommand(awsAccountsCmd)
+	orgCmd.AddCommand(limitedSupportCmd)
+	orgCmd.AddCommand(quotaCmd)
+	orgCmd.AddCommand(contactsCmd)
 
 	return orgCmd
 }
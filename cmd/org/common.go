@@ -8,6 +8,7 @@ import (
 	"github.com/openshift-online/ocm-cli/pkg/dump"
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	"github.com/openshift/osdctl/cmd/common"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
 	"github.com/spf13/cobra"
@@ -92,5 +93,9 @@ func IsJsonOutput() bool {
 
 func PrintJson(data interface{}) {
 	marshalledStruct, _ := json.MarshalIndent(data, "", "  ")
-	dump.Pretty(os.Stdout, marshalledStruct)
+	if printer.FilterEnabled() {
+		osdctlErrors.CheckErr(printer.PrintJSON(printer.Tee(os.Stdout), marshalledStruct))
+		return
+	}
+	dump.Pretty(printer.Tee(os.Stdout), marshalledStruct)
 }
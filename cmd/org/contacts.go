@@ -0,0 +1,222 @@
+package org
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	acc_util "github.com/openshift-online/ocm-cli/pkg/account"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const subscriptionsAPIPath = "/api/accounts_mgmt/v1/subscriptions"
+
+var (
+	contactsClusterID string
+
+	contactsCmd = &cobra.Command{
+		Use:   "contacts ORG_ID",
+		Short: "List who receives the service logs of the clusters of an organization",
+		Long: `List the cluster owners and notification contacts of the active clusters of an organization, with their
+email and OCM roles. Customer facing service logs are emailed to the owner and notification contacts of the
+cluster, check them before sending one.`,
+		Example: `  # Everyone receiving the service logs of the clusters of an organization
+  osdctl org contacts 1a2B3c4DefghIjkLMNOpQrSTUV5
+
+  # Only the owner and notification contacts of one cluster
+  osdctl org contacts 1a2B3c4DefghIjkLMNOpQrSTUV5 --cluster-id 1kfmyclusteristhebesteverp8m`,
+		Args:          checkOrgId,
+		SilenceErrors: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(listContacts(args[0]))
+		},
+	}
+)
+
+type ContactItems struct {
+	Contacts []*Contact `json:"items"`
+}
+
+type Contact struct {
+	Email     string `json:"email"`
+	UserName  string `json:"user_name"`
+	Name      string `json:"name"`
+	AccountID string `json:"account_id"`
+	// Roles are the OCM roles of the account, e.g. OrganizationAdmin
+	Roles []string `json:"roles"`
+	// OwnerOf and NotificationContactFor are the clusters the account created and is a notification contact of
+	OwnerOf                []string `json:"owner_of"`
+	NotificationContactFor []string `json:"notification_contact_for"`
+}
+
+func init() {
+	contactsCmd.Flags().StringVarP(&contactsClusterID, "cluster-id", "C", "", "Only list the contacts of this cluster, by internal or external ID")
+	AddOutputFlag(contactsCmd.Flags())
+}
+
+func listContacts(orgID string) error {
+	search := fmt.Sprintf("organization_id='%s' and status='%s'", orgID, statusActive)
+	if contactsClusterID != "" {
+		if err := utils.IsValidClusterKey(contactsClusterID); err != nil {
+			return err
+		}
+		search += fmt.Sprintf(" and (cluster_id='%[1]s' or external_cluster_id='%[1]s')", contactsClusterID)
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer func() {
+		if err := ocmClient.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot close the ocmClient (possible memory leak): %q", err)
+		}
+	}()
+
+	var subscriptions []*amv1.Subscription
+	for page := 1; ; page++ {
+		// The creator is only expanded, with its email, with fetchAccounts
+		response, err := ocmClient.AccountsMgmt().V1().Subscriptions().List().Search(search).
+			Parameter("fetchAccounts", true).Size(subscriptionsPageSize).Page(page).Send()
+		if err != nil {
+			return fmt.Errorf("cannot get subscriptions of organization %s: %w", orgID, err)
+		}
+		subscriptions = append(subscriptions, response.Items().Slice()...)
+		if response.Size() < subscriptionsPageSize {
+			break
+		}
+	}
+	if contactsClusterID != "" && len(subscriptions) == 0 {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "organization %s has no active cluster '%s'", orgID, contactsClusterID)
+	}
+
+	notificationContacts := map[string][]*amv1.Account{}
+	for _, subscription := range subscriptions {
+		accounts, err := getNotificationContacts(ocmClient, subscription.ID())
+		if err != nil {
+			return err
+		}
+		notificationContacts[subscription.ID()] = accounts
+	}
+
+	contacts, accounts := toContacts(subscriptions, notificationContacts)
+	if len(accounts) > 0 {
+		roles, err := acc_util.GetRolesFromUsers(accounts, ocmClient)
+		if err != nil {
+			return fmt.Errorf("cannot get the roles of the contacts: %w", err)
+		}
+		byID := map[string]*Contact{}
+		for _, contact := range contacts {
+			byID[contact.AccountID] = contact
+		}
+		for account, accountRoles := range roles {
+			if contact, ok := byID[account.ID()]; ok {
+				contact.Roles = accountRoles
+			}
+		}
+	}
+
+	printContacts(contacts)
+	return nil
+}
+
+// getNotificationContacts returns the accounts notified of the service logs of the subscription, besides its creator
+func getNotificationContacts(ocmClient *sdk.Connection, subscriptionID string) ([]*amv1.Account, error) {
+	response, err := sendRequest(ocmClient.Get().Path(subscriptionsAPIPath + "/" + subscriptionID + "/notification_contacts"))
+	if err != nil {
+		return nil, err
+	}
+	if response.Status() != http.StatusOK {
+		return nil, osdctlErrors.WrapStatus(response.Status(), fmt.Errorf("cannot get the notification contacts of subscription %s: %s", subscriptionID, response.String()))
+	}
+	var list struct {
+		Items json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(response.Bytes(), &list); err != nil {
+		return nil, fmt.Errorf("cannot parse the notification contacts of subscription %s: %w", subscriptionID, err)
+	}
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+	return amv1.UnmarshalAccountList(list.Items)
+}
+
+// toContacts merges the creators and notification contacts of the subscriptions by account, sorted by email. The
+// accounts are returned too, to look up their roles.
+func toContacts(subscriptions []*amv1.Subscription, notificationContacts map[string][]*amv1.Account) ([]*Contact, []*amv1.Account) {
+	byID := map[string]*Contact{}
+	var accounts []*amv1.Account
+	contact := func(account *amv1.Account) *Contact {
+		if c, ok := byID[account.ID()]; ok {
+			return c
+		}
+		c := &Contact{
+			Email:                  account.Email(),
+			UserName:               account.Username(),
+			Name:                   strings.TrimSpace(account.FirstName() + " " + account.LastName()),
+			AccountID:              account.ID(),
+			Roles:                  []string{},
+			OwnerOf:                []string{},
+			NotificationContactFor: []string{},
+		}
+		byID[account.ID()] = c
+		accounts = append(accounts, account)
+		return c
+	}
+
+	for _, subscription := range subscriptions {
+		cluster := subscription.DisplayName()
+		if cluster == "" {
+			cluster = subscription.ClusterID()
+		}
+		if creator, ok := subscription.GetCreator(); ok && creator.ID() != "" {
+			c := contact(creator)
+			c.OwnerOf = append(c.OwnerOf, cluster)
+		}
+		for _, account := range notificationContacts[subscription.ID()] {
+			c := contact(account)
+			c.NotificationContactFor = append(c.NotificationContactFor, cluster)
+		}
+	}
+
+	contacts := make([]*Contact, 0, len(byID))
+	for _, c := range byID {
+		sort.Strings(c.OwnerOf)
+		sort.Strings(c.NotificationContactFor)
+		contacts = append(contacts, c)
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		if contacts[i].Email != contacts[j].Email {
+			return contacts[i].Email < contacts[j].Email
+		}
+		return contacts[i].AccountID < contacts[j].AccountID
+	})
+	return contacts, accounts
+}
+
+func printContacts(contacts []*Contact) {
+	if IsJsonOutput() {
+		PrintJson(ContactItems{Contacts: contacts})
+		return
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"EMAIL", "USER", "ROLES", "OWNER OF", "NOTIFICATION CONTACT FOR"})
+	for _, contact := range contacts {
+		table.AddRow([]string{
+			contact.Email,
+			contact.UserName,
+			strings.Join(contact.Roles, ", "),
+			strings.Join(contact.OwnerOf, ", "),
+			strings.Join(contact.NotificationContactFor, ", "),
+		})
+	}
+
+	table.AddRow([]string{})
+	table.Flush()
+}
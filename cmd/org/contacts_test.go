@@ -0,0 +1,34 @@
+package org
+
+import (
+	"testing"
+
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+)
+
+func TestToContacts(t *testing.T) {
+	owner := amv1.NewAccount().ID("a1").Email("owner@example.com").Username("owner").FirstName("Jane").LastName("Doe")
+	oncall := amv1.NewAccount().ID("a2").Email("oncall@example.com").Username("oncall")
+	prod, _ := amv1.NewSubscription().ID("s1").DisplayName("prod").ClusterID("c1").Creator(owner).Build()
+	stage, _ := amv1.NewSubscription().ID("s2").ClusterID("c2").Creator(owner).Build()
+	ownerAccount, _ := owner.Build()
+	oncallAccount, _ := oncall.Build()
+
+	contacts, accounts := toContacts([]*amv1.Subscription{prod, stage}, map[string][]*amv1.Account{
+		"s1": {oncallAccount, ownerAccount},
+	})
+	if len(contacts) != 2 || len(accounts) != 2 {
+		t.Fatalf("Expected the 2 accounts merged, got %+v", contacts)
+	}
+
+	// Sorted by email
+	if contacts[0].Email != "oncall@example.com" || len(contacts[0].OwnerOf) != 0 || len(contacts[0].NotificationContactFor) != 1 {
+		t.Fatalf("Unexpected notification contact %+v", contacts[0])
+	}
+	if contacts[1].Name != "Jane Doe" || len(contacts[1].OwnerOf) != 2 || contacts[1].OwnerOf[0] != "c2" || contacts[1].OwnerOf[1] != "prod" {
+		t.Fatalf("Expected the owner of prod and of c2, without a display name, got %+v", contacts[1])
+	}
+	if len(contacts[1].NotificationContactFor) != 1 || contacts[1].NotificationContactFor[0] != "prod" {
+		t.Fatalf("Expected the owner to be a notification contact of prod too, got %+v", contacts[1])
+	}
+}
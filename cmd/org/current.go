@@ -3,13 +3,13 @@ package org
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 var (
@@ -19,7 +19,7 @@ var (
 		Args:          cobra.ArbitraryArgs,
 		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(run(cmd))
+			osdctlErrors.CheckErr(run(cmd))
 		},
 	}
 )
@@ -35,7 +35,7 @@ func init() {
 }
 
 func run(cmd *cobra.Command) error {
-	response, err := getCurrentOrg()
+	response, err := getCurrentOrg(cmd.ErrOrStderr())
 	if err != nil {
 		return fmt.Errorf("invalid input: %q", err)
 	}
@@ -47,26 +47,30 @@ func run(cmd *cobra.Command) error {
 	return nil
 }
 
-func getCurrentOrg() (*sdk.Response, error) {
+func getCurrentOrg(errOut io.Writer) (*sdk.Response, error) {
 	// Create OCM client to talk
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(errOut, "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 
 	// Now get the current org
-	return sendRequest(createGetCurrentOrgRequest(ocmClient))
+	request, err := createGetCurrentOrgRequest(ocmClient)
+	if err != nil {
+		return nil, err
+	}
+	return sendRequest(request)
 }
 
-func createGetCurrentOrgRequest(ocmClient *sdk.Connection) *sdk.Request {
+func createGetCurrentOrgRequest(ocmClient *sdk.Connection) (*sdk.Request, error) {
 	// Create and populate the request:
 	request := ocmClient.Get()
 	err := arguments.ApplyPathArg(request, currentAccountApiPath)
 	if err != nil {
-		log.Fatalf("Can't parse API path '%s': %v\n", currentAccountApiPath, err)
+		return nil, fmt.Errorf("can't parse API path '%s': %v", currentAccountApiPath, err)
 	}
 
-	return request
+	return request, nil
 }
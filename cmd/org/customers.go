@@ -2,14 +2,13 @@ package org
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 var (
@@ -19,7 +18,7 @@ var (
 		Args:          cobra.ArbitraryArgs,
 		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(getCustomers(cmd))
+			osdctlErrors.CheckErr(getCustomers(cmd))
 		},
 	}
 	paying   bool   = true
@@ -59,7 +58,7 @@ func getCustomers(cmd *cobra.Command) error {
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 
@@ -78,7 +77,7 @@ func getCustomers(cmd *cobra.Command) error {
 			Parameter("search", searchQuery).
 			Send()
 		if err != nil {
-			log.Fatalf("Can't retrieve accounts: %v", err)
+			return fmt.Errorf("can't retrieve accounts: %v", err)
 		}
 
 		response.Items().Each(func(resourseQuota *amv1.ResourceQuota) bool {
@@ -3,13 +3,13 @@ package org
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 var (
@@ -19,8 +19,8 @@ var (
 		Args:          cobra.ArbitraryArgs,
 		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(checkOrgId(cmd, args))
-			cmdutil.CheckErr(describeOrg(cmd, args[0]))
+			osdctlErrors.CheckErr(checkOrgId(cmd, args))
+			osdctlErrors.CheckErr(describeOrg(cmd, args[0]))
 		},
 	}
 )
@@ -33,7 +33,7 @@ func init() {
 
 func describeOrg(cmd *cobra.Command, orgID string) error {
 
-	response, err := sendDescribeOrgRequest(orgID)
+	response, err := sendDescribeOrgRequest(cmd.ErrOrStderr(), orgID)
 	if err != nil {
 		return fmt.Errorf("invalid input: %q", err)
 	}
@@ -46,20 +46,24 @@ func describeOrg(cmd *cobra.Command, orgID string) error {
 	return nil
 }
 
-func sendDescribeOrgRequest(orgID string) (*sdk.Response, error) {
+func sendDescribeOrgRequest(errOut io.Writer, orgID string) (*sdk.Response, error) {
 	// Create OCM client to talk
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(errOut, "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 
 	// Now get the matching orgs
-	return sendRequest(createDescribeRequest(ocmClient, orgID))
+	request, err := createDescribeRequest(ocmClient, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return sendRequest(request)
 }
 
-func createDescribeRequest(ocmClient *sdk.Connection, orgID string) *sdk.Request {
+func createDescribeRequest(ocmClient *sdk.Connection, orgID string) (*sdk.Request, error) {
 	// Create and populate the request:
 	request := ocmClient.Get()
 	apiPath := organizationsAPIPath + "/" + orgID
@@ -67,8 +71,8 @@ func createDescribeRequest(ocmClient *sdk.Connection, orgID string) *sdk.Request
 	err := arguments.ApplyPathArg(request, apiPath)
 
 	if err != nil {
-		log.Fatalf("Can't parse API path '%s': %v\n", apiPath, err)
+		return nil, fmt.Errorf("can't parse API path '%s': %v", apiPath, err)
 	}
 
-	return request
+	return request, nil
 }
@@ -3,15 +3,15 @@ package org
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 const (
@@ -26,7 +26,7 @@ var (
 		Args:          cobra.ArbitraryArgs,
 		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(searchOrgs(cmd))
+			osdctlErrors.CheckErr(searchOrgs(cmd))
 		},
 	}
 	searchEBSaccountID string
@@ -84,7 +84,7 @@ func searchOrgs(cmd *cobra.Command) error {
 		return fmt.Errorf("invalid search params")
 	}
 
-	response, err := getOrgs()
+	response, err := getOrgs(cmd.ErrOrStderr())
 
 	if err != nil {
 		return fmt.Errorf("invalid input: %q", err)
@@ -111,12 +111,12 @@ func searchOrgs(cmd *cobra.Command) error {
 	return nil
 }
 
-func getOrgs() (*sdk.Response, error) {
+func getOrgs(errOut io.Writer) (*sdk.Response, error) {
 	// Create OCM client to talk
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(errOut, "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 	request := ocmClient.Get()
@@ -131,8 +131,7 @@ func getOrgs() (*sdk.Response, error) {
 	}
 	err := arguments.ApplyPathArg(request, apiPath)
 	if err != nil {
-		log.Fatalf("Can't parse API path '%s': %v\n", apiPath, err)
-
+		return nil, fmt.Errorf("can't parse API path '%s': %v", apiPath, err)
 	}
 	arguments.ApplyParameterFlag(request, []string{getSearchQuery()})
 	return sendRequest(request)
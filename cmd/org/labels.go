@@ -3,15 +3,15 @@ package org
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 var (
@@ -21,8 +21,8 @@ var (
 		Args:          cobra.ArbitraryArgs,
 		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(checkOrgId(cmd, args))
-			cmdutil.CheckErr(searchLabelsByOrg(cmd, args[0]))
+			osdctlErrors.CheckErr(checkOrgId(cmd, args))
+			osdctlErrors.CheckErr(searchLabelsByOrg(cmd, args[0]))
 		},
 	}
 )
@@ -45,7 +45,7 @@ func init() {
 
 func searchLabelsByOrg(cmd *cobra.Command, orgID string) error {
 
-	response, err := getLabels(orgID)
+	response, err := getLabels(cmd.ErrOrStderr(), orgID)
 	if err != nil {
 		return fmt.Errorf("invalid input: %q", err)
 	}
@@ -58,20 +58,24 @@ func searchLabelsByOrg(cmd *cobra.Command, orgID string) error {
 	return nil
 }
 
-func getLabels(orgID string) (*sdk.Response, error) {
+func getLabels(errOut io.Writer, orgID string) (*sdk.Response, error) {
 	// Create OCM client to talk
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(errOut, "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 
 	// Now get the matching orgs
-	return sendRequest(createGetLabelsRequest(ocmClient, orgID))
+	request, err := createGetLabelsRequest(ocmClient, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return sendRequest(request)
 }
 
-func createGetLabelsRequest(ocmClient *sdk.Connection, orgID string) *sdk.Request {
+func createGetLabelsRequest(ocmClient *sdk.Connection, orgID string) (*sdk.Request, error) {
 	// Create and populate the request:
 	request := ocmClient.Get()
 	labelsApiPath := organizationsAPIPath + "/" + orgID + "/labels"
@@ -79,11 +83,10 @@ func createGetLabelsRequest(ocmClient *sdk.Connection, orgID string) *sdk.Reques
 	err := arguments.ApplyPathArg(request, labelsApiPath)
 
 	if err != nil {
-		log.Fatalf("Can't parse API path '%s': %v\n", labelsApiPath, err)
-
+		return nil, fmt.Errorf("can't parse API path '%s': %v", labelsApiPath, err)
 	}
 
-	return request
+	return request, nil
 }
 
 func printLabels(items []Label) {
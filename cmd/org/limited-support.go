@@ -0,0 +1,144 @@
+package org
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const subscriptionsPageSize = 100
+
+var (
+	limitedSupportCmd = &cobra.Command{
+		Use:           "limited-support ORG_ID",
+		Short:         "List the clusters of an organization currently in limited support",
+		Args:          checkOrgId,
+		SilenceErrors: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(listLimitedSupportClusters(cmd.ErrOrStderr(), args[0]))
+		},
+	}
+)
+
+type LimitedSupportItems struct {
+	Clusters []LimitedSupportCluster `json:"items"`
+}
+
+type LimitedSupportCluster struct {
+	DisplayName string                 `json:"display_name"`
+	ClusterID   string                 `json:"cluster_id"`
+	Reasons     []LimitedSupportReason `json:"reasons"`
+}
+
+type LimitedSupportReason struct {
+	ID        string    `json:"id"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func init() {
+	AddOutputFlag(limitedSupportCmd.Flags())
+}
+
+func listLimitedSupportClusters(errOut io.Writer, orgID string) error {
+	ocmClient := utils.CreateConnection()
+	defer func() {
+		if err := ocmClient.Close(); err != nil {
+			fmt.Fprintf(errOut, "Cannot close the ocmClient (possible memory leak): %q", err)
+		}
+	}()
+
+	subscriptions, err := getActiveSubscriptions(ocmClient, orgID)
+	if err != nil {
+		return err
+	}
+
+	var clusters []LimitedSupportCluster
+	for _, subscription := range subscriptions {
+		if subscription.ClusterID() == "" {
+			continue
+		}
+		response, err := ocmClient.ClustersMgmt().V1().Clusters().Cluster(subscription.ClusterID()).LimitedSupportReasons().List().Send()
+		if err != nil {
+			fmt.Fprintf(errOut, "Cannot get limited support reasons of cluster %s: %v\n", subscription.ClusterID(), err)
+			continue
+		}
+		if cluster, found := toLimitedSupportCluster(subscription.DisplayName(), subscription.ClusterID(), response.Items().Slice()); found {
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	printLimitedSupportClusters(clusters, time.Now())
+	return nil
+}
+
+func getActiveSubscriptions(ocmClient *sdk.Connection, orgID string) ([]*amv1.Subscription, error) {
+	var subscriptions []*amv1.Subscription
+	search := fmt.Sprintf("organization_id='%s' and status='%s'", orgID, statusActive)
+	for page := 1; ; page++ {
+		response, err := ocmClient.AccountsMgmt().V1().Subscriptions().List().Search(search).Size(subscriptionsPageSize).Page(page).Send()
+		if err != nil {
+			return nil, fmt.Errorf("cannot get subscriptions of organization %s: %w", orgID, err)
+		}
+		subscriptions = append(subscriptions, response.Items().Slice()...)
+		if response.Size() < subscriptionsPageSize {
+			break
+		}
+	}
+	return subscriptions, nil
+}
+
+// toLimitedSupportCluster returns false when the cluster has no limited support reasons. Reasons are sorted oldest first.
+func toLimitedSupportCluster(displayName, clusterID string, reasons []*cmv1.LimitedSupportReason) (LimitedSupportCluster, bool) {
+	if len(reasons) == 0 {
+		return LimitedSupportCluster{}, false
+	}
+
+	cluster := LimitedSupportCluster{DisplayName: displayName, ClusterID: clusterID}
+	for _, reason := range reasons {
+		cluster.Reasons = append(cluster.Reasons, LimitedSupportReason{
+			ID:        reason.ID(),
+			Summary:   reason.Summary(),
+			CreatedAt: reason.CreationTimestamp(),
+		})
+	}
+	sort.Slice(cluster.Reasons, func(i, j int) bool {
+		return cluster.Reasons[i].CreatedAt.Before(cluster.Reasons[j].CreatedAt)
+	})
+	return cluster, true
+}
+
+func printLimitedSupportClusters(clusters []LimitedSupportCluster, now time.Time) {
+	if IsJsonOutput() {
+		PrintJson(LimitedSupportItems{Clusters: clusters})
+		return
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"DISPLAY NAME", "CLUSTER ID", "AGE", "SUMMARY"})
+	for _, cluster := range clusters {
+		for _, reason := range cluster.Reasons {
+			table.AddRow([]string{
+				cluster.DisplayName,
+				cluster.ClusterID,
+				duration.HumanDuration(now.Sub(reason.CreatedAt)),
+				reason.Summary,
+			})
+		}
+	}
+
+	table.AddRow([]string{})
+	table.Flush()
+	fmt.Printf("%d clusters in limited support\n", len(clusters))
+}
@@ -0,0 +1,31 @@
+package org
+
+import (
+	"testing"
+	"time"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+)
+
+func TestToLimitedSupportCluster(t *testing.T) {
+	older := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(48 * time.Hour)
+
+	newerReason, _ := cmv1.NewLimitedSupportReason().ID("newer").Summary("Cluster upgrade blocked").CreationTimestamp(newer).Build()
+	olderReason, _ := cmv1.NewLimitedSupportReason().ID("older").Summary("Cloud credentials removed").CreationTimestamp(older).Build()
+
+	if _, found := toLimitedSupportCluster("cluster", "abc", nil); found {
+		t.Fatalf("Expected clusters without reasons to be skipped")
+	}
+
+	cluster, found := toLimitedSupportCluster("cluster", "abc", []*cmv1.LimitedSupportReason{newerReason, olderReason})
+	if !found {
+		t.Fatalf("Expected the cluster to be in limited support")
+	}
+	if len(cluster.Reasons) != 2 || cluster.Reasons[0].ID != "older" || cluster.Reasons[1].ID != "newer" {
+		t.Fatalf("Expected the reasons sorted oldest first, got %v", cluster.Reasons)
+	}
+	if cluster.Reasons[0].Summary != "Cloud credentials removed" {
+		t.Fatalf("Unexpected summary %q", cluster.Reasons[0].Summary)
+	}
+}
@@ -0,0 +1,177 @@
+package org
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+const quotaCostPageSize = 100
+
+var (
+	onlyExhausted bool
+
+	quotaCmd = &cobra.Command{
+		Use:   "quota ORG_ID",
+		Short: "Show the quota of an organization, allowed vs consumed per SKU",
+		Long: `Show the quota cost of an organization from OCM accounts management: for every quota, e.g. a cluster or
+add-on SKU, how much is allowed and consumed, and which resources it pays for.
+
+A cluster or add-on can't be created once the quota paying for it is exhausted, which is reported as
+"quota exceeded" by cluster creation.`,
+		Args:          checkOrgId,
+		SilenceErrors: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(showQuota(args[0]))
+		},
+	}
+)
+
+type QuotaItems struct {
+	Quotas []Quota `json:"items"`
+}
+
+type Quota struct {
+	QuotaID   string   `json:"quota_id"`
+	Allowed   int      `json:"allowed"`
+	Consumed  int      `json:"consumed"`
+	Remaining int      `json:"remaining"`
+	Exhausted bool     `json:"exhausted"`
+	Resources []string `json:"resources"`
+}
+
+func init() {
+	quotaCmd.Flags().BoolVar(&onlyExhausted, "exhausted", false, "Only show the quotas which are fully consumed")
+	AddOutputFlag(quotaCmd.Flags())
+}
+
+func showQuota(orgID string) error {
+	ocmClient := utils.CreateConnection()
+	defer func() {
+		if err := ocmClient.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot close the ocmClient (possible memory leak): %q", err)
+		}
+	}()
+
+	costs, err := getQuotaCost(ocmClient, orgID)
+	if err != nil {
+		return err
+	}
+
+	quotas := toQuotas(costs)
+	if onlyExhausted {
+		var exhausted []Quota
+		for _, quota := range quotas {
+			if quota.Exhausted {
+				exhausted = append(exhausted, quota)
+			}
+		}
+		quotas = exhausted
+	}
+	printQuotas(quotas)
+	return nil
+}
+
+func getQuotaCost(ocmClient *sdk.Connection, orgID string) ([]*amv1.QuotaCost, error) {
+	var costs []*amv1.QuotaCost
+	for page := 1; ; page++ {
+		response, err := ocmClient.AccountsMgmt().V1().Organizations().Organization(orgID).QuotaCost().List().
+			Parameter("fetchRelatedResources", true).Size(quotaCostPageSize).Page(page).Send()
+		if err != nil {
+			return nil, fmt.Errorf("cannot get the quota cost of organization %s: %w", orgID, err)
+		}
+		costs = append(costs, response.Items().Slice()...)
+		if response.Size() < quotaCostPageSize {
+			break
+		}
+	}
+	return costs, nil
+}
+
+// toQuotas summarizes the quota cost, exhausted quotas first then by quota ID
+func toQuotas(costs []*amv1.QuotaCost) []Quota {
+	quotas := make([]Quota, 0, len(costs))
+	for _, cost := range costs {
+		quota := Quota{
+			QuotaID:   cost.QuotaID(),
+			Allowed:   cost.Allowed(),
+			Consumed:  cost.Consumed(),
+			Remaining: cost.Allowed() - cost.Consumed(),
+			Resources: []string{},
+		}
+		quota.Exhausted = quota.Remaining <= 0
+		if quota.Remaining < 0 {
+			quota.Remaining = 0
+		}
+
+		seen := map[string]bool{}
+		for _, resource := range cost.RelatedResources() {
+			description := relatedResourceDescription(resource)
+			if !seen[description] {
+				seen[description] = true
+				quota.Resources = append(quota.Resources, description)
+			}
+		}
+		sort.Strings(quota.Resources)
+		quotas = append(quotas, quota)
+	}
+	sort.SliceStable(quotas, func(i, j int) bool {
+		if quotas[i].Exhausted != quotas[j].Exhausted {
+			return quotas[i].Exhausted
+		}
+		return quotas[i].QuotaID < quotas[j].QuotaID
+	})
+	return quotas
+}
+
+// relatedResourceDescription describes a resource paid for by a quota, e.g. "cluster ocp-AWS (standard, byoc)"
+func relatedResourceDescription(resource *amv1.RelatedResource) string {
+	var details []string
+	for _, detail := range []string{resource.BillingModel(), resource.BYOC(), resource.AvailabilityZoneType()} {
+		if detail != "" && detail != "any" {
+			details = append(details, detail)
+		}
+	}
+	description := strings.TrimSpace(fmt.Sprintf("%s %s", resource.ResourceType(), resource.ResourceName()))
+	if resource.Product() != "" && resource.Product() != "any" {
+		description += " " + resource.Product()
+	}
+	if len(details) > 0 {
+		description += " (" + strings.Join(details, ", ") + ")"
+	}
+	return description
+}
+
+func printQuotas(quotas []Quota) {
+	if IsJsonOutput() {
+		PrintJson(QuotaItems{Quotas: quotas})
+		return
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"QUOTA ID", "ALLOWED", "CONSUMED", "REMAINING", "RESOURCES"})
+	for _, quota := range quotas {
+		remaining := fmt.Sprint(quota.Remaining)
+		if quota.Exhausted {
+			remaining += " (exhausted)"
+		}
+		table.AddRow([]string{
+			quota.QuotaID,
+			fmt.Sprint(quota.Allowed),
+			fmt.Sprint(quota.Consumed),
+			remaining,
+			strings.Join(quota.Resources, ", "),
+		})
+	}
+
+	table.AddRow([]string{})
+	table.Flush()
+}
@@ -0,0 +1,36 @@
+package org
+
+import (
+	"testing"
+
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+)
+
+func TestToQuotas(t *testing.T) {
+	clusters, _ := amv1.NewQuotaCost().QuotaID("cluster|byoc|moa").Allowed(10).Consumed(3).RelatedResources(
+		amv1.NewRelatedResource().ResourceType("cluster").ResourceName("any").Product("ROSA").BillingModel("standard").BYOC("byoc"),
+		amv1.NewRelatedResource().ResourceType("cluster").ResourceName("any").Product("ROSA").BillingModel("standard").BYOC("byoc"),
+	).Build()
+	addon, _ := amv1.NewQuotaCost().QuotaID("addon-rhoam").Allowed(1).Consumed(2).RelatedResources(
+		amv1.NewRelatedResource().ResourceType("add-on").ResourceName("addon-rhoam").Product("any"),
+	).Build()
+
+	quotas := toQuotas([]*amv1.QuotaCost{clusters, addon})
+	if len(quotas) != 2 {
+		t.Fatalf("Expected 2 quotas, got %v", quotas)
+	}
+
+	if quotas[0].QuotaID != "addon-rhoam" || !quotas[0].Exhausted || quotas[0].Remaining != 0 {
+		t.Fatalf("Expected the exhausted add-on quota first, got %+v", quotas[0])
+	}
+	if len(quotas[0].Resources) != 1 || quotas[0].Resources[0] != "add-on addon-rhoam" {
+		t.Fatalf("Unexpected resources %v", quotas[0].Resources)
+	}
+
+	if quotas[1].Exhausted || quotas[1].Remaining != 7 {
+		t.Fatalf("Expected 7 clusters remaining, got %+v", quotas[1])
+	}
+	if len(quotas[1].Resources) != 1 || quotas[1].Resources[0] != "cluster any ROSA (standard, byoc)" {
+		t.Fatalf("Expected the duplicate resources merged, got %v", quotas[1].Resources)
+	}
+}
@@ -2,15 +2,14 @@ package org
 
 import (
 	"fmt"
-	"log"
 	"os"
 
 	acc_util "github.com/openshift-online/ocm-cli/pkg/account"
 	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
 var (
@@ -20,8 +19,8 @@ var (
 		Args:          cobra.ArbitraryArgs,
 		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(checkOrgId(cmd, args))
-			cmdutil.CheckErr(getUsers(args[0]))
+			osdctlErrors.CheckErr(checkOrgId(cmd, args))
+			osdctlErrors.CheckErr(getUsers(args[0]))
 		},
 	}
 )
@@ -85,7 +84,7 @@ func getUsers(orgID string) error {
 			Parameter("search", searchQuery).
 			Send()
 		if err != nil {
-			log.Fatalf("Can't retrieve accounts: %v", err)
+			return fmt.Errorf("can't retrieve accounts: %v", err)
 		}
 
 		accountList := []*amv1.Account{}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/plugin"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+const pluginLong = `Plugins are executables named osdctl-<command> on the PATH, 'osdctl foo bar' runs osdctl-foo-bar or
+osdctl-foo when osdctl has no such command, dashes in the command names become underscores.
+
+Plugins get osdctl's environment and these variables:
+  ` + plugin.EnvAPIVersion + `    version of this contract, currently ` + plugin.APIVersion + `
+  ` + plugin.EnvVersion + `               version of osdctl
+  ` + plugin.EnvConfig + `                path of the osdctl config file
+  ` + plugin.EnvReadOnly + `             true when read-only mode is on in the config file
+  ` + plugin.EnvOCMURL + `               OCM API URL, when logged in to OCM
+  ` + plugin.EnvOCMEnvironment + `       production, stage or integration, when logged in to OCM
+  ` + plugin.EnvOCMToken + `             OCM access token, when logged in to OCM`
+
+// newCmdPlugin implements the plugin command which lists the plugins found on the PATH
+func newCmdPlugin(streams genericclioptions.IOStreams) *cobra.Command {
+	pluginCmd := &cobra.Command{
+		Use:               "plugin",
+		Short:             "Provides utilities for interacting with plugins",
+		Long:              pluginLong,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	pluginCmd.AddCommand(&cobra.Command{
+		Use:               "list",
+		Short:             "List the plugins found on the PATH",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins := plugin.List(os.Getenv("PATH"))
+			if len(plugins) == 0 {
+				fmt.Fprintln(streams.ErrOut, "No plugin found on the PATH")
+				return nil
+			}
+			for _, p := range plugins {
+				command := strings.ReplaceAll(strings.TrimPrefix(filepath.Base(p), plugin.Prefix+"-"), "-", " ")
+				fmt.Fprintf(streams.Out, "%s\t(osdctl %s)\n", p, command)
+			}
+			return nil
+		},
+	})
+
+	return pluginCmd
+}
@@ -0,0 +1,328 @@
+package promote
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	// AppInterfaceDirConfigKey is the local app-interface checkout the promotions are made in
+	AppInterfaceDirConfigKey = "app_interface_dir"
+	// AppInterfaceRepoConfigKey is cloned to the app-interface dir when it doesn't exist yet
+	AppInterfaceRepoConfigKey = "app_interface_repo"
+
+	defaultPackageParameter = "PACKAGE_TAG"
+)
+
+const promoteLong = `Promotes a new version of a service by bumping the git hash its SaaS file targets are pinned to
+in a local app-interface checkout, then committing, pushing the branch to your fork and opening the merge request.
+
+  The checkout is read from --appInterfaceDir or the '` + AppInterfaceDirConfigKey + `' config key. When it doesn't
+  exist and '` + AppInterfaceRepoConfigKey + `' is set, that repository is cloned there first. The promotion branch
+  starts from the --upstreamRemote target branch and is pushed to --forkRemote, with GitLab push options that
+  open the merge request.
+
+  Targets tracking a branch (e.g. master for integration and staging) are left alone, and --target limits the
+  promotion to the targets whose name or namespace matches.`
+
+const promoteExample = `
+  # List the targets of a SaaS file and the versions they run
+  osdctl promote saas --serviceName managed-cluster-config --list
+
+  # Show what the promotion would change
+  osdctl promote saas --serviceName managed-cluster-config --gitHash 1a2b3c4 --dry-run
+
+  # Promote to the production targets only, open the merge request
+  osdctl promote saas --serviceName managed-cluster-config --gitHash 1a2b3c4 --target production
+
+  # Promote the package of an operator deployed with Package Operator
+  osdctl promote package --serviceName route-monitor-operator --gitHash 1a2b3c4
+`
+
+type promoteOptions struct {
+	// field is the promoted target field, "ref" or the name of a target parameter
+	field string
+	kind  string
+
+	serviceName     string
+	gitHash         string
+	appInterfaceDir string
+	upstreamRemote  string
+	forkRemote      string
+	targetBranch    string
+	target          string
+	list            bool
+	dryRun          bool
+	skipPrompts     bool
+}
+
+// NewCmdPromote implements the promote utility
+func NewCmdPromote() *cobra.Command {
+	promoteCmd := &cobra.Command{
+		Use:               "promote",
+		Short:             "Promotes new versions of SaaS services and operator packages in app-interface",
+		Long:              promoteLong,
+		Example:           promoteExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	promoteCmd.AddCommand(newCmdPromoteTarget("saas", "Promotes a git hash to the targets of a SaaS file", &promoteOptions{field: "ref"}))
+	packageOpts := &promoteOptions{}
+	packageCmd := newCmdPromoteTarget("package", "Promotes a package image tag to the targets of a SaaS file", packageOpts)
+	packageCmd.Flags().StringVar(&packageOpts.field, "parameter", defaultPackageParameter, "Target parameter holding the package tag")
+	promoteCmd.AddCommand(packageCmd)
+
+	return promoteCmd
+}
+
+func newCmdPromoteTarget(kind, short string, ops *promoteOptions) *cobra.Command {
+	ops.kind = kind
+	cmd := &cobra.Command{
+		Use:               kind,
+		Short:             short,
+		Long:              promoteLong,
+		Example:           promoteExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+
+	cmd.Flags().StringVar(&ops.serviceName, "serviceName", "", "Name of the service, the SaaS file is saas-<serviceName>.yaml or <serviceName>.yaml")
+	cmd.Flags().StringVar(&ops.gitHash, "gitHash", "", "Git hash to promote")
+	cmd.Flags().StringVar(&ops.appInterfaceDir, "appInterfaceDir", "", "Local app-interface checkout (default: the '"+AppInterfaceDirConfigKey+"' config key)")
+	cmd.Flags().StringVar(&ops.upstreamRemote, "upstreamRemote", "upstream", "Git remote of the canonical app-interface repository")
+	cmd.Flags().StringVar(&ops.forkRemote, "forkRemote", "origin", "Git remote of your app-interface fork, the branch is pushed to it")
+	cmd.Flags().StringVar(&ops.targetBranch, "targetBranch", "master", "Branch the merge request targets")
+	cmd.Flags().StringVar(&ops.target, "target", "", "Only promote the targets whose name or namespace matches this regular expression")
+	cmd.Flags().BoolVar(&ops.list, "list", false, "List the targets and the versions they run, without promoting")
+	cmd.Flags().BoolVarP(&ops.dryRun, "dry-run", "d", false, "Print the changes without editing, committing or pushing")
+	cmd.Flags().BoolVarP(&ops.skipPrompts, "yes", "y", false, "Skips all prompts.")
+	_ = cmd.MarkFlagRequired("serviceName")
+
+	return cmd
+}
+
+func (o *promoteOptions) complete(cmd *cobra.Command) error {
+	if o.appInterfaceDir == "" {
+		o.appInterfaceDir = viper.GetString(AppInterfaceDirConfigKey)
+	}
+	if o.appInterfaceDir == "" {
+		return cmdutil.UsageErrorf(cmd, "Pass --appInterfaceDir or set '%s' in the config file", AppInterfaceDirConfigKey)
+	}
+	if strings.HasPrefix(o.appInterfaceDir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		o.appInterfaceDir = filepath.Join(home, o.appInterfaceDir[2:])
+	}
+	if o.list {
+		return nil
+	}
+
+	o.gitHash = strings.ToLower(o.gitHash)
+	if !gitHashPattern.MatchString(o.gitHash) {
+		return cmdutil.UsageErrorf(cmd, "--gitHash must be a git hash of 7 to 40 hexadecimal characters")
+	}
+	if o.target != "" {
+		if _, err := regexp.Compile(o.target); err != nil {
+			return cmdutil.UsageErrorf(cmd, "Invalid --target regular expression: %v", err)
+		}
+	}
+	return nil
+}
+
+func (o *promoteOptions) run() error {
+	if err := o.ensureCheckout(); err != nil {
+		return err
+	}
+	git := gitRunner{dir: o.appInterfaceDir}
+
+	branch := fmt.Sprintf("promote-%s-%s", o.serviceName, o.gitHash)
+	promoting := !o.list && !o.dryRun
+	if promoting {
+		if status, err := git.run("status", "--porcelain"); err != nil {
+			return err
+		} else if status != "" {
+			return fmt.Errorf("the app-interface checkout %s has uncommitted changes, commit or stash them first", o.appInterfaceDir)
+		}
+		if _, err := git.run("fetch", o.upstreamRemote, o.targetBranch); err != nil {
+			return err
+		}
+		if _, err := git.run("checkout", "-b", branch, o.upstreamRemote+"/"+o.targetBranch); err != nil {
+			return err
+		}
+	}
+
+	path, err := findSaasFile(o.appInterfaceDir, o.serviceName)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path) //#nosec G304 -- the SaaS file is found in the user's checkout
+	if err != nil {
+		return err
+	}
+	targets, err := saasTargets(content, o.field)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	if o.list {
+		return printTargets(targets, o.field)
+	}
+
+	var filter *regexp.Regexp
+	if o.target != "" {
+		filter = regexp.MustCompile(o.target)
+	}
+	selected := selectTargets(targets, filter)
+	if len(selected) == 0 {
+		return fmt.Errorf("no target of %s is pinned to a git hash in '%s'%s", path, o.field, filterNote(o.target))
+	}
+
+	promoted, changes, err := promote(content, selected, o.gitHash)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Printf("Every selected target already runs %s\n", o.gitHash)
+		return nil
+	}
+
+	fmt.Printf("Promoting %s to %s in %s:\n", o.serviceName, o.gitHash, path)
+	if err := printChanges(changes); err != nil {
+		return err
+	}
+	if o.dryRun {
+		return nil
+	}
+
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary: &utils.ImpactSummary{
+			Action: fmt.Sprintf("Push %s to %s and open a merge request promoting %d target(s)", branch, o.forkRemote, len(changes)),
+		},
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeSaasFile(path, promoted); err != nil {
+		return err
+	}
+	title := fmt.Sprintf("Promote %s to %s", o.serviceName, o.gitHash)
+	if _, err := git.run("commit", "-a", "-m", title, "-m", commitBody(o.kind, changes)); err != nil {
+		return err
+	}
+	// GitLab opens the merge request itself when given these push options
+	output, err := git.run("push", "--set-upstream", o.forkRemote, branch,
+		"-o", "merge_request.create",
+		"-o", "merge_request.target="+o.targetBranch,
+		"-o", "merge_request.title="+title,
+		"-o", "merge_request.remove_source_branch")
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// ensureCheckout clones the configured app-interface repository when the checkout doesn't exist yet
+func (o *promoteOptions) ensureCheckout() error {
+	if _, err := os.Stat(filepath.Join(o.appInterfaceDir, ".git")); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	repo := viper.GetString(AppInterfaceRepoConfigKey)
+	if repo == "" {
+		return fmt.Errorf("%s is not a git checkout, clone app-interface there or set '%s' in the config file", o.appInterfaceDir, AppInterfaceRepoConfigKey)
+	}
+	fmt.Printf("Cloning %s to %s\n", repo, o.appInterfaceDir)
+	_, err := gitRunner{}.run("clone", "--origin", o.upstreamRemote, repo, o.appInterfaceDir)
+	return err
+}
+
+func filterNote(target string) string {
+	if target == "" {
+		return ""
+	}
+	return fmt.Sprintf(" matching '%s'", target)
+}
+
+// commitBody lists the promoted targets, for the merge request reviewers
+func commitBody(kind string, changes []change) string {
+	lines := []string{fmt.Sprintf("Promoted with 'osdctl promote %s':", kind)}
+	for _, c := range changes {
+		lines = append(lines, fmt.Sprintf("- %s: %s -> %s", targetName(c.Target), c.From, c.To))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func targetName(target saasTarget) string {
+	if target.Name != "" {
+		return target.Name
+	}
+	return target.Namespace
+}
+
+func printTargets(targets []saasTarget, field string) error {
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Resource Template", "Target", strings.ToUpper(field), "Promotable"})
+	for _, target := range targets {
+		promotable := "no, tracks a branch"
+		if gitHashPattern.MatchString(target.Value) {
+			promotable = "yes"
+		}
+		table.AddRow([]string{target.ResourceTemplate, targetName(target), target.Value, promotable})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	return table.Flush()
+}
+
+func printChanges(changes []change) error {
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"Resource Template", "Target", "From", "To"})
+	for _, c := range changes {
+		table.AddRow([]string{c.Target.ResourceTemplate, targetName(c.Target), c.From, c.To})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	return table.Flush()
+}
+
+// gitRunner runs git in the app-interface checkout
+type gitRunner struct {
+	dir string
+}
+
+func (g gitRunner) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...) //#nosec G204 -- arguments are built from the flags
+	cmd.Dir = g.dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %v\n%s", args[0], err, output)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,189 @@
+package promote
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// gitHashPattern matches the abbreviated or full git hashes targets are pinned to, as opposed to branches
+var gitHashPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// saasTarget is a deployment target of a SaaS file resource template
+type saasTarget struct {
+	ResourceTemplate string
+	Name             string
+	Namespace        string
+	Value            string
+
+	// node is the scalar holding the promoted value, its position is used to edit the file in place
+	node *yaml.Node
+}
+
+// change is a promoted value of a target
+type change struct {
+	Target saasTarget
+	From   string
+	To     string
+}
+
+// findSaasFile returns the SaaS file of the service in an app-interface checkout, named after the
+// service with or without the saas- prefix, in a saas directory under data/services
+func findSaasFile(appInterfaceDir, serviceName string) (string, error) {
+	root := filepath.Join(appInterfaceDir, "data", "services")
+	names := map[string]bool{
+		serviceName + ".yaml":           true,
+		serviceName + ".yml":            true,
+		"saas-" + serviceName + ".yaml": true,
+		"saas-" + serviceName + ".yml":  true,
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && names[entry.Name()] && strings.Contains(filepath.ToSlash(path), "/saas") {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot search the SaaS files in %s: %w", root, err)
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no SaaS file found for service '%s' under %s", serviceName, root)
+	case 1:
+		return matches[0], nil
+	}
+	return "", fmt.Errorf("several SaaS files found for service '%s': %s", serviceName, strings.Join(matches, ", "))
+}
+
+// saasTargets returns the targets of the SaaS file, with the value of the given field: "ref" or a
+// parameter name. Targets without the field are skipped.
+func saasTargets(content []byte, field string) ([]saasTarget, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("cannot parse the SaaS file: %w", err)
+	}
+	if len(document.Content) == 0 {
+		return nil, fmt.Errorf("the SaaS file is empty")
+	}
+
+	var targets []saasTarget
+	templates := mappingValue(document.Content[0], "resourceTemplates")
+	if templates == nil {
+		return nil, fmt.Errorf("the SaaS file has no resourceTemplates")
+	}
+	for _, template := range templates.Content {
+		templateName := scalarValue(mappingValue(template, "name"))
+		targetList := mappingValue(template, "targets")
+		if targetList == nil {
+			continue
+		}
+		for _, target := range targetList.Content {
+			var node *yaml.Node
+			if field == "ref" {
+				node = mappingValue(target, "ref")
+			} else {
+				node = mappingValue(mappingValue(target, "parameters"), field)
+			}
+			if node == nil || node.Kind != yaml.ScalarNode {
+				continue
+			}
+
+			namespace := mappingValue(target, "namespace")
+			namespaceRef := scalarValue(mappingValue(namespace, "$ref"))
+			if namespaceRef == "" {
+				namespaceRef = scalarValue(namespace)
+			}
+			targets = append(targets, saasTarget{
+				ResourceTemplate: templateName,
+				Name:             scalarValue(mappingValue(target, "name")),
+				Namespace:        namespaceRef,
+				Value:            node.Value,
+				node:             node,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// selectTargets returns the targets pinned to a git hash, the ones tracking a branch aren't promoted,
+// and whose name or namespace matches the filter when one is given
+func selectTargets(targets []saasTarget, filter *regexp.Regexp) []saasTarget {
+	var selected []saasTarget
+	for _, target := range targets {
+		if !gitHashPattern.MatchString(target.Value) {
+			continue
+		}
+		if filter != nil && !filter.MatchString(target.Name) && !filter.MatchString(target.Namespace) {
+			continue
+		}
+		selected = append(selected, target)
+	}
+	return selected
+}
+
+// promote sets the value of the targets, editing the lines in place to keep the file formatting and comments
+func promote(content []byte, targets []saasTarget, value string) ([]byte, []change, error) {
+	lines := bytes.Split(content, []byte("\n"))
+	var changes []change
+	for _, target := range targets {
+		if target.Value == value {
+			continue
+		}
+		line, column := target.node.Line-1, target.node.Column-1
+		if line < 0 || line >= len(lines) || column < 0 || column > len(lines[line]) {
+			return nil, nil, fmt.Errorf("cannot locate the value of target %s", target.Namespace)
+		}
+		rest := lines[line][column:]
+		index := bytes.Index(rest, []byte(target.Value))
+		if index < 0 {
+			return nil, nil, fmt.Errorf("cannot locate the value of target %s on line %d", target.Namespace, target.node.Line)
+		}
+
+		edited := append([]byte{}, lines[line][:column+index]...)
+		edited = append(edited, value...)
+		edited = append(edited, rest[index+len(target.Value):]...)
+		lines[line] = edited
+		changes = append(changes, change{Target: target, From: target.Value, To: value})
+	}
+	return bytes.Join(lines, []byte("\n")), changes, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarValue(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// writeSaasFile replaces the SaaS file, keeping its permissions
+func writeSaasFile(path string, content []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, info.Mode().Perm())
+}
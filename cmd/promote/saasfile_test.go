@@ -0,0 +1,93 @@
+package promote
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const testSaasFile = `---
+$schema: /app-sre/saas-file-2.yml
+name: saas-example-operator
+resourceTemplates:
+- name: example-operator
+  url: https://github.com/openshift/example-operator
+  targets:
+  # integration follows master
+  - namespace:
+      $ref: /services/osd-operators/namespaces/hivei01ue1/example-operator.yml
+    ref: master
+  - name: production-hivep01ue1
+    namespace:
+      $ref: /services/osd-operators/namespaces/hivep01ue1/example-operator.yml
+    ref: 0123456789abcdef0123456789abcdef01234567 # pinned
+    parameters:
+      PACKAGE_TAG: "0123456"
+  - name: production-hivep02ue1
+    namespace:
+      $ref: /services/osd-operators/namespaces/hivep02ue1/example-operator.yml
+    ref: 0123456789abcdef0123456789abcdef01234567
+`
+
+func TestSaasTargets(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	targets, err := saasTargets([]byte(testSaasFile), "ref")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(targets).To(HaveLen(3))
+	g.Expect(targets[0].Namespace).To(Equal("/services/osd-operators/namespaces/hivei01ue1/example-operator.yml"))
+	g.Expect(targets[0].Value).To(Equal("master"))
+	g.Expect(targets[1].Name).To(Equal("production-hivep01ue1"))
+	g.Expect(targets[1].ResourceTemplate).To(Equal("example-operator"))
+
+	targets, err = saasTargets([]byte(testSaasFile), "PACKAGE_TAG")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(targets).To(HaveLen(1))
+	g.Expect(targets[0].Value).To(Equal("0123456"))
+
+	_, err = saasTargets([]byte("name: foo\n"), "ref")
+	g.Expect(err).To(MatchError(ContainSubstring("no resourceTemplates")))
+}
+
+func TestPromote(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	targets, err := saasTargets([]byte(testSaasFile), "ref")
+	g.Expect(err).NotTo(HaveOccurred())
+	selected := selectTargets(targets, regexp.MustCompile("hivep01"))
+	g.Expect(selected).To(HaveLen(1))
+
+	promoted, changes, err := promote([]byte(testSaasFile), selectTargets(targets, nil), "fedcba9")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changes).To(HaveLen(2))
+	g.Expect(changes[0].From).To(Equal("0123456789abcdef0123456789abcdef01234567"))
+	// Only the pinned refs change, comments and formatting are kept
+	g.Expect(string(promoted)).To(ContainSubstring("    ref: fedcba9 # pinned\n"))
+	g.Expect(string(promoted)).To(ContainSubstring("    ref: master\n"))
+	g.Expect(string(promoted)).To(ContainSubstring("  # integration follows master\n"))
+	g.Expect(string(promoted)).To(HaveLen(len(testSaasFile) - 2*(40-7)))
+
+	packages, err := saasTargets([]byte(testSaasFile), "PACKAGE_TAG")
+	g.Expect(err).NotTo(HaveOccurred())
+	promoted, _, err = promote([]byte(testSaasFile), selectTargets(packages, nil), "fedcba9")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(promoted)).To(ContainSubstring(`      PACKAGE_TAG: "fedcba9"`))
+}
+
+func TestFindSaasFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := t.TempDir()
+	saasDir := filepath.Join(dir, "data", "services", "osd-operators", "cicd", "saas")
+	g.Expect(os.MkdirAll(saasDir, 0755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(saasDir, "saas-example-operator.yaml"), []byte(testSaasFile), 0600)).To(Succeed())
+
+	path, err := findSaasFile(dir, "example-operator")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(path).To(Equal(filepath.Join(saasDir, "saas-example-operator.yaml")))
+
+	_, err = findSaasFile(dir, "other-operator")
+	g.Expect(err).To(MatchError(ContainSubstring("no SaaS file found")))
+}
@@ -0,0 +1,174 @@
+package secrets
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const secretsLong = `Stores long-lived tokens in the OS keyring (keychain, wincred or secret-service) instead of
+environment variables or the plain text config file. When no keyring is available the tokens are
+kept in a file encrypted with a passphrase, read from ` + secrets.PassphraseEnv + ` or prompted for.
+
+Stored tokens are used when the matching flag isn't passed, before the config file is read.`
+
+const secretsExample = `
+  # Store the PagerDuty OAuth token, prompting for it
+  osdctl secrets set pd_oauth_token
+
+  # Store the OCM refresh token from 'ocm login'
+  ocm token --refresh | osdctl secrets set ocm_refresh_token
+
+  # Show which tokens are stored and where
+  osdctl secrets status
+`
+
+// NewCmdSecrets implements the secrets utility
+func NewCmdSecrets() *cobra.Command {
+	secretsCmd := &cobra.Command{
+		Use:               "secrets",
+		Short:             "Manages the tokens stored in the OS keyring",
+		Long:              secretsLong,
+		Example:           secretsExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	keys := "one of " + strings.Join(secrets.Keys, ", ")
+	secretsCmd.AddCommand(&cobra.Command{
+		Use:               "set KEY",
+		Short:             "Stores a token, read from stdin or prompted for",
+		Long:              "Stores a token, read from stdin or prompted for. KEY is " + keys + ".",
+		Args:              cobra.ExactArgs(1),
+		ValidArgs:         secrets.Keys,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(runSet(args[0], os.Stdin))
+		},
+	})
+	secretsCmd.AddCommand(&cobra.Command{
+		Use:               "delete KEY",
+		Short:             "Deletes a stored token",
+		Long:              "Deletes a stored token. KEY is " + keys + ".",
+		Args:              cobra.ExactArgs(1),
+		ValidArgs:         secrets.Keys,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(runDelete(args[0]))
+		},
+	})
+	secretsCmd.AddCommand(&cobra.Command{
+		Use:               "status",
+		Short:             "Shows the storage backend and which tokens are stored",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(runStatus(os.Stdout))
+		},
+	})
+
+	return secretsCmd
+}
+
+func checkKey(key string) error {
+	if !secrets.IsKnownKey(key) {
+		return fmt.Errorf("unknown key '%s', expected one of %s", key, strings.Join(secrets.Keys, ", "))
+	}
+	return nil
+}
+
+func runSet(key string, in *os.File) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	value, err := readValue(key, in)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return errors.New("the token can't be empty")
+	}
+
+	store, err := secrets.New()
+	if err != nil {
+		return err
+	}
+	if err := store.Set(key, value); err != nil {
+		return fmt.Errorf("cannot store '%s' in the %s: %w", key, store.Name(), err)
+	}
+	fmt.Printf("Stored '%s' in the %s\n", key, store.Name())
+	return nil
+}
+
+// readValue prompts for the token without echoing it, or reads the first line of stdin when it's piped
+func readValue(key string, in *os.File) (string, error) {
+	fd := int(in.Fd()) //#nosec G115 -- file descriptors fit in an int
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Value of %s: ", key)
+		value, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return strings.TrimSpace(string(value)), err
+	}
+	return readLine(in)
+}
+
+func readLine(in io.Reader) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func runDelete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	store, err := secrets.New()
+	if err != nil {
+		return err
+	}
+	err = store.Delete(key)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return fmt.Errorf("'%s' isn't stored in the %s", key, store.Name())
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete '%s' from the %s: %w", key, store.Name(), err)
+	}
+	fmt.Printf("Deleted '%s' from the %s\n", key, store.Name())
+	return nil
+}
+
+func runStatus(out io.Writer) error {
+	store, err := secrets.New()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Backend: %s\n", store.Name())
+	for _, key := range secrets.Keys {
+		_, err := store.Get(key)
+		switch {
+		case err == nil:
+			fmt.Fprintf(out, "  %s: stored\n", key)
+		case errors.Is(err, secrets.ErrNotFound):
+			fmt.Fprintf(out, "  %s: not stored\n", key)
+		default:
+			return fmt.Errorf("cannot read '%s' from the %s: %w", key, store.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package servicelog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/checkpoint"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/rollout"
+	"github.com/openshift/osdctl/pkg/scheduler"
+	ocmutils "github.com/openshift/osdctl/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
+)
+
+const (
+	campaignLong = `Posts a templated service log to many clusters, at most --rate service logs per second, minute or hour.
+
+The progress is saved to a checkpoint after every cluster. When the campaign is interrupted or some
+clusters failed, running the same command again skips the clusters the service log was already sent to
+and retries the others. The checkpoint is removed once every cluster was sent the service log.
+
+With --canary, the service log is first posted to that many clusters, and the campaign asks before going on with the
+others, which can be posted to in batches of --batch-size with a --pause-between them. The campaign stops when more
+than --max-error-rate of the clusters posted to so far failed, checked after the canary and every batch.`
+
+	campaignExample = `
+  # Post a template to every cluster listed in list.txt, 10 per minute
+  osdctl servicelog campaign --clusters-file list.txt --template https://example.com/template.json --rate 10/min
+
+  # Post to 5 clusters first, then to the others 50 at a time, 10 minutes apart
+  osdctl servicelog campaign --clusters-file list.txt --template template.json --canary 5 --batch-size 50 --pause-between 10m
+
+  # Check what the campaign would do, and how many clusters are left
+  osdctl servicelog campaign --clusters-file list.txt --template template.json -p FOO=bar --dry-run
+`
+)
+
+type campaignOptions struct {
+	post         PostCmdOptions
+	clustersFile string
+	rate         string
+	dryRun       bool
+	skipPrompts  bool
+
+	checkpoint checkpoint.Flags
+	rollout    rollout.Flags
+}
+
+func newCampaignCmd() *cobra.Command {
+	opts := campaignOptions{}
+	campaignCmd := &cobra.Command{
+		Use:               "campaign",
+		Short:             "Post a service log to many clusters with rate limiting and resumable progress",
+		Long:              campaignLong,
+		Example:           campaignExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(opts.rollout.Validate(cmd))
+			osdctlErrors.CheckErr(opts.run())
+		},
+	}
+
+	campaignCmd.Flags().StringVarP(&opts.clustersFile, "clusters-file", "c", "", `File listing the clusters, one per line ('#' starts a comment), or in the {"clusters":["$CLUSTERID"]} format`)
+	campaignCmd.Flags().StringVarP(&opts.post.Template, "template", "t", "", "Message template file or URL")
+	campaignCmd.Flags().StringArrayVarP(&opts.post.TemplateParams, "param", "p", nil, "Specify a key-value pair (eg. -p FOO=BAR) to set/override a parameter value in the template.")
+	campaignCmd.Flags().StringVar(&opts.rate, "rate", "10/min", "Maximum number of service logs to post, per s, min or h")
+	campaignCmd.Flags().BoolVarP(&opts.dryRun, "dry-run", "d", false, "Print the template and the clusters left to post to, without posting")
+	campaignCmd.Flags().BoolVarP(&opts.skipPrompts, "yes", "y", false, "Skips all prompts.")
+	opts.checkpoint.AddFlags(campaignCmd)
+	opts.rollout.AddFlags(campaignCmd)
+	_ = campaignCmd.MarkFlagRequired("clusters-file")
+	_ = campaignCmd.MarkFlagRequired("template")
+
+	return campaignCmd
+}
+
+func (o *campaignOptions) run() error {
+	limit, err := parseRate(o.rate)
+	if err != nil {
+		return err
+	}
+
+	contents, err := os.ReadFile(filepath.Clean(o.clustersFile))
+	if err != nil {
+		return fmt.Errorf("cannot read the clusters file: %w", err)
+	}
+	clusterIDs, err := parseClusterList(contents)
+	if err != nil {
+		return fmt.Errorf("cannot parse the clusters file %s: %w", o.clustersFile, err)
+	}
+	if len(clusterIDs) == 0 {
+		return fmt.Errorf("the clusters file %s lists no cluster", o.clustersFile)
+	}
+
+	if err := o.post.parseUserParameters(); err != nil {
+		return err
+	}
+	if err := o.post.readTemplate(); err != nil {
+		return err
+	}
+	for k := range o.post.userParameterNames {
+		if err := o.post.replaceFlags(o.post.userParameterNames[k], o.post.userParameterValues[k]); err != nil {
+			return err
+		}
+	}
+	if err := o.post.checkLeftovers([]string{"${CLUSTER_UUID}"}); err != nil {
+		return err
+	}
+
+	progress, err := o.openCheckpoint()
+	if err != nil {
+		return err
+	}
+	if progress.Resumed() {
+		log.Infof("Resuming the campaign saved in %s", progress.Path())
+	}
+	pending := progress.Pending(clusterIDs)
+
+	log.Infoln("The following template will be sent:")
+	if err := o.post.printTemplate(); err != nil {
+		return fmt.Errorf("cannot read generated template: %w", err)
+	}
+	log.Infof("%d clusters, %d already sent, %d left to post to at %s", len(clusterIDs), len(clusterIDs)-len(pending), len(pending), o.rate)
+	if len(pending) == 0 {
+		return report(progress, clusterIDs)
+	}
+	if o.dryRun {
+		return nil
+	}
+
+	ocmClient := ocmutils.CreateConnection()
+	defer func() {
+		if err := ocmClient.Close(); err != nil {
+			log.Errorf("Cannot close the ocmClient (possible memory leak): %q", err)
+		}
+	}()
+
+	err = ocmutils.Confirm(ocmutils.ConfirmOptions{
+		Summary: &ocmutils.ImpactSummary{
+			Action:      fmt.Sprintf("Post service log '%s' to %d clusters", o.post.Message.Summary, len(pending)),
+			Environment: ocmutils.GetCurrentOCMEnv(ocmClient),
+		},
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Stop between two clusters on Ctrl-C, the state is already saved
+	ctx, stop := signal.NotifyContext(deadline.Context(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limiter := rate.NewLimiter(limit, 1)
+	var saveMu sync.Mutex
+	var saveErr error
+	result, err := o.rollout.Run(ctx, pending, rollout.Options{Label: "clusters sent the service log", Success: "sent", SkipPrompt: o.skipPrompts, Scheduler: scheduler.New()},
+		func(ctx context.Context, clusterID string) error {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+			err := o.postToCluster(ocmClient, clusterID)
+			if recordErr := progress.Record(clusterID, err); recordErr != nil {
+				saveMu.Lock()
+				saveErr = recordErr
+				saveMu.Unlock()
+				cancel()
+			}
+			return err
+		})
+	switch {
+	case saveErr != nil:
+		return fmt.Errorf("cannot save the campaign progress, stopping: %w", saveErr)
+	case err != nil && ctx.Err() != nil:
+		log.Warnf("Interrupted after %d of %d clusters, run the same command again to resume", result.Done, len(pending))
+	case err != nil:
+		// The canary was declined or failed too often
+		log.Warnf("Stopped after %d of %d clusters: %v", result.Done, len(pending), err)
+	}
+
+	return report(progress, clusterIDs)
+}
+
+// openCheckpoint returns the progress of previous runs of the same campaign: same clusters file and message
+func (o *campaignOptions) openCheckpoint() (*checkpoint.Checkpoint, error) {
+	clustersFile, err := filepath.Abs(o.clustersFile)
+	if err != nil {
+		return nil, err
+	}
+	message, err := json.Marshal(o.post.Message)
+	if err != nil {
+		return nil, err
+	}
+	return o.checkpoint.Open("servicelog campaign", clustersFile, o.post.Template, string(message))
+}
+
+func (o *campaignOptions) postToCluster(ocmClient *sdk.Connection, clusterID string) error {
+	cluster, err := ocmutils.GetCluster(ocmClient, clusterID)
+	if err != nil {
+		return err
+	}
+	request, err := o.post.createPostRequest(ocmClient, cluster)
+	if err != nil {
+		return err
+	}
+	response, err := sendRequest(request)
+	if err != nil {
+		return err
+	}
+	return responseError(response, o.post.Message)
+}
+
+// parseRate parses a rate like 10/min into the matching limit
+func parseRate(value string) (rate.Limit, error) {
+	count, unit, found := strings.Cut(value, "/")
+	if !found {
+		return 0, fmt.Errorf("invalid rate '%s', expected e.g. 10/min", value)
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate '%s', the count must be a positive number", value)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s", "sec", "second":
+		period = time.Second
+	case "m", "min", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	default:
+		return 0, fmt.Errorf("invalid rate '%s', the unit must be s, min or h", value)
+	}
+	return rate.Limit(n / period.Seconds()), nil
+}
+
+// parseClusterList reads either one cluster per line or the clusters file format of 'servicelog post'
+func parseClusterList(contents []byte) ([]string, error) {
+	trimmed := bytes.TrimSpace(contents)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var post PostCmdOptions
+		if err := post.parseClustersFile(trimmed); err != nil {
+			return nil, err
+		}
+		return dedupe(post.ClustersFile.Clusters), nil
+	}
+
+	var clusters []string
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			clusters = append(clusters, line)
+		}
+	}
+	return dedupe(clusters), scanner.Err()
+}
+
+func dedupe(values []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// report prints the final success/failure counts and the failed clusters
+func report(progress *checkpoint.Checkpoint, clusterIDs []string) error {
+	notSent := progress.Pending(clusterIDs)
+	sort.Strings(notSent)
+
+	log.Infof("Sent: %d, Failed or not sent: %d", len(clusterIDs)-len(notSent), len(notSent))
+	if len(notSent) == 0 {
+		return progress.Complete()
+	}
+
+	table := printer.NewTablePrinter(os.Stdout, 20, 1, 3, ' ')
+	table.AddRow([]string{"ID", "Status", "Error"})
+	for _, clusterID := range notSent {
+		status, message := "pending", ""
+		if item, ok := progress.Get(clusterID); ok {
+			status, message = item.Status, item.Error
+		}
+		table.AddRow([]string{clusterID, status, message})
+	}
+	// New row for better readability
+	table.AddRow([]string{})
+	if err := table.Flush(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%d of %d clusters weren't sent the service log, run the same command again to retry them (progress saved in %s)",
+		len(notSent), len(clusterIDs), progress.Path())
+}
@@ -0,0 +1,58 @@
+package servicelog
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/checkpoint"
+	"golang.org/x/time/rate"
+)
+
+func TestParseRate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	limit, err := parseRate("10/min")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(limit).To(BeNumerically("~", rate.Limit(10.0/60), 1e-9))
+
+	limit, err = parseRate("2/s")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(limit).To(Equal(rate.Limit(2)))
+
+	for _, invalid := range []string{"10", "0/min", "-1/s", "ten/min", "10/day"} {
+		_, err := parseRate(invalid)
+		g.Expect(err).To(HaveOccurred(), invalid)
+	}
+}
+
+func TestParseClusterList(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	clusters, err := parseClusterList([]byte("# batch 1\nabc\n\n  def  # the big one\nabc\n"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(Equal([]string{"abc", "def"}))
+
+	clusters, err = parseClusterList([]byte(`{"clusters":["abc","def"]}`))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(Equal([]string{"abc", "def"}))
+}
+
+func TestCampaignReport(t *testing.T) {
+	g := NewGomegaWithT(t)
+	flags := checkpoint.Flags{Path: filepath.Join(t.TempDir(), "campaign.json")}
+	clusters := []string{"abc", "def", "ghi"}
+
+	progress, err := flags.Open("servicelog campaign", "list.txt", "template.json")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(progress.Record("abc", nil)).To(Succeed())
+	g.Expect(progress.Record("def", errors.New("cluster not found"))).To(Succeed())
+	g.Expect(report(progress, clusters)).To(MatchError(ContainSubstring("2 of 3 clusters")))
+	g.Expect(flags.Path).To(BeAnExistingFile())
+
+	g.Expect(progress.Record("def", nil)).To(Succeed())
+	g.Expect(progress.Record("ghi", nil)).To(Succeed())
+	g.Expect(report(progress, clusters)).To(Succeed())
+	g.Expect(flags.Path).NotTo(BeAnExistingFile())
+}
@@ -2,10 +2,12 @@ package servicelog
 
 import (
 	"fmt"
+
+	"github.com/openshift/osdctl/internal/utils/globalflags"
 	"github.com/spf13/cobra"
 )
 
-func NewCmdServiceLog() *cobra.Command {
+func NewCmdServiceLog(globalOpts *globalflags.GlobalOptions) *cobra.Command {
 	var servicelogCmd = &cobra.Command{
 		Use:   "servicelog",
 		Short: "OCM/Hive Service log",
@@ -19,8 +21,10 @@ func NewCmdServiceLog() *cobra.Command {
 	}
 
 	// Add subcommands
-	servicelogCmd.AddCommand(listCmd)      // servicelog list
-	servicelogCmd.AddCommand(newPostCmd()) // servicelog post
+	servicelogCmd.AddCommand(newListCmd(globalOpts)) // servicelog list
+	servicelogCmd.AddCommand(newPostCmd())           // servicelog post
+	servicelogCmd.AddCommand(newCampaignCmd())       // servicelog campaign
+	servicelogCmd.AddCommand(newDeleteCmd())         // servicelog delete
 
 	return servicelogCmd
 }
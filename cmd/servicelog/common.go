@@ -2,15 +2,14 @@ package servicelog
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 
 	sdk "github.com/openshift-online/ocm-sdk-go"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	"github.com/openshift/osdctl/internal/servicelog"
-)
-
-var (
-	userParameterNames, userParameterValues, filterParams []string
-	HTMLBody                                              []byte
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 )
 
 const (
@@ -27,6 +26,21 @@ func sendRequest(request *sdk.Request) (*sdk.Response, error) {
 	return response, nil
 }
 
+// responseError returns why posting the service log failed, or nil when it was created as sent
+func responseError(response *sdk.Response, clusterMessage servicelog.Message) error {
+	body := response.Bytes()
+	if response.Status() < 400 {
+		_, err := validateGoodResponse(body, clusterMessage)
+		return err
+	}
+
+	badReply, err := validateBadResponse(body)
+	if err != nil {
+		return err
+	}
+	return errors.New(badReply.Reason)
+}
+
 func validateGoodResponse(body []byte, clusterMessage servicelog.Message) (goodReply *servicelog.GoodReply, err error) {
 	if !json.Valid(body) {
 		return nil, fmt.Errorf("server returned invalid JSON")
@@ -65,3 +79,34 @@ func validateBadResponse(body []byte) (badReply *servicelog.BadReply, err error)
 
 	return badReply, nil
 }
+
+// getServiceLog returns the service log of the cluster with the given ID
+func getServiceLog(ocmClient *sdk.Connection, cluster *v1.Cluster, logID string) (*servicelog.GoodReply, error) {
+	response, err := sendRequest(ocmClient.Get().Path(targetAPIPath + "/" + logID))
+	if err != nil {
+		return nil, err
+	}
+	if response.Status() == http.StatusNotFound {
+		return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "service log '%s' not found", logID)
+	}
+	if response.Status() >= 400 {
+		return nil, osdctlErrors.WrapStatus(response.Status(), fmt.Errorf("cannot get service log '%s': %s", logID, response.String()))
+	}
+	var reply servicelog.GoodReply
+	if err := json.Unmarshal(response.Bytes(), &reply); err != nil {
+		return nil, fmt.Errorf("cannot parse service log '%s': %w", logID, err)
+	}
+	if err := checkServiceLogCluster(&reply, cluster); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// checkServiceLogCluster makes sure that the service log was sent to the cluster, so a mistyped ID doesn't act on
+// the service log of another customer
+func checkServiceLogCluster(reply *servicelog.GoodReply, cluster *v1.Cluster) error {
+	if (reply.ClusterUUID != "" && reply.ClusterUUID == cluster.ExternalID()) || (reply.ClusterID != "" && reply.ClusterID == cluster.ID()) {
+		return nil
+	}
+	return osdctlErrors.New(osdctlErrors.ErrValidation, "service log '%s' was sent to cluster %s, not to cluster %s", reply.ID, reply.ClusterUUID, cluster.ID())
+}
@@ -0,0 +1,91 @@
+package servicelog
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const deleteLong = `Delete a service log sent to a cluster by mistake.
+
+Deleting service logs is restricted by OCM to a few roles, SREs usually can't. The customer may also have read the
+service log already, in the OCM console or in its email, so prefer posting a correction with
+'osdctl servicelog post --supersede LOG_ID', which tells the customer to disregard the erroneous one.`
+
+const deleteExample = `  # Delete a service log, its ID is listed by 'osdctl servicelog list CLUSTER_ID -A -o json'
+  osdctl servicelog delete 1kfmyclusteristhebesteverp8m 2PpZOdWgKDfRzBjpPtmnYHgiCNx`
+
+type deleteOptions struct {
+	clusterID   string
+	logID       string
+	skipPrompts bool
+}
+
+func newDeleteCmd() *cobra.Command {
+	opts := &deleteOptions{}
+	deleteCmd := &cobra.Command{
+		Use:               "delete CLUSTER_ID LOG_ID",
+		Short:             "Delete a service log sent to a cluster by mistake, where permitted",
+		Long:              deleteLong,
+		Example:           deleteExample,
+		Args:              cobra.ExactArgs(2),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.clusterID = args[0]
+			opts.logID = args[1]
+			osdctlErrors.CheckErr(opts.run())
+		},
+	}
+	deleteCmd.Flags().BoolVarP(&opts.skipPrompts, "yes", "y", false, "Skips all prompts.")
+
+	return deleteCmd
+}
+
+func (o *deleteOptions) run() error {
+	if err := utils.IsValidClusterKey(o.clusterID); err != nil {
+		return err
+	}
+
+	ocmClient := utils.CreateConnection()
+	defer func() {
+		if err := ocmClient.Close(); err != nil {
+			log.Errorf("Cannot close the ocmClient (possible memory leak): %q", err)
+		}
+	}()
+
+	cluster, err := utils.GetCluster(ocmClient, o.clusterID)
+	if err != nil {
+		return err
+	}
+	serviceLog, err := getServiceLog(ocmClient, cluster, o.logID)
+	if err != nil {
+		return err
+	}
+
+	err = utils.Confirm(utils.ConfirmOptions{
+		Summary:    utils.NewClusterImpactSummary(ocmClient, cluster, fmt.Sprintf("Delete service log '%s' sent on %s", serviceLog.Summary, timefmt.Format(serviceLog.Timestamp))),
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
+	}
+
+	response, err := sendRequest(ocmClient.Delete().Path(targetAPIPath + "/" + o.logID))
+	if err != nil {
+		return err
+	}
+	switch {
+	case response.Status() == http.StatusForbidden:
+		return osdctlErrors.New(osdctlErrors.ErrForbidden, "you aren't allowed to delete service logs, post a correction with 'osdctl servicelog post %s --supersede %s' instead", cluster.ID(), o.logID)
+	case response.Status() >= 400:
+		return osdctlErrors.WrapStatus(response.Status(), fmt.Errorf("cannot delete service log '%s': %s", o.logID, response.String()))
+	}
+
+	fmt.Printf("Service log '%s' deleted from cluster %s\n", o.logID, cluster.ID())
+	return nil
+}
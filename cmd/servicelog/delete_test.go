@@ -0,0 +1,34 @@
+package servicelog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+func TestCheckServiceLogCluster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster, err := v1.NewCluster().ID("2ab3cd").ExternalID("c0ffee-uuid").Build()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(checkServiceLogCluster(&servicelog.GoodReply{ID: "log-1", ClusterUUID: "c0ffee-uuid"}, cluster)).To(Succeed())
+	g.Expect(checkServiceLogCluster(&servicelog.GoodReply{ID: "log-2", ClusterID: "2ab3cd"}, cluster)).To(Succeed())
+
+	err = checkServiceLogCluster(&servicelog.GoodReply{ID: "log-3", ClusterUUID: "other-uuid"}, cluster)
+	g.Expect(errors.Is(err, osdctlErrors.ErrValidation)).To(BeTrue())
+	g.Expect(err).To(MatchError("service log 'log-3' was sent to cluster other-uuid, not to cluster 2ab3cd"))
+}
+
+func TestSupersededDescription(t *testing.T) {
+	g := NewGomegaWithT(t)
+	earlier := &servicelog.GoodReply{Summary: "Cluster upgrade scheduled", Timestamp: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}
+
+	g.Expect(supersededDescription(earlier, "Your cluster will be upgraded on March 8.")).To(Equal(
+		"This notification supersedes the notification \"Cluster upgrade scheduled\" sent on 2024-03-01 09:30 UTC, which was sent in error. " +
+			"Please disregard it.\n\nYour cluster will be upgraded on March 8."))
+}
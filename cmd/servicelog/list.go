@@ -1,29 +1,59 @@
 package servicelog
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
 	"github.com/openshift-online/ocm-cli/pkg/dump"
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
 	"github.com/openshift/osdctl/pkg/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
-// listCmd represents the list command
-var listCmd = &cobra.Command{
-	Use:           "list [flags] [options] cluster-identifier",
-	Short:         "gets all servicelog messages for a given cluster",
-	Args:          cobra.ArbitraryArgs,
-	SilenceErrors: true,
-	Run: func(cmd *cobra.Command, args []string) {
-		cmdutil.CheckErr(complete(cmd, args))
-		cmdutil.CheckErr(run(cmd, args[0]))
-	},
+// listCSVColumns are the columns of the CSV output, a row per service log
+var listCSVColumns = []string{"id", "timestamp", "cluster_uuid", "severity", "service_name", "summary", "description", "event_stream_id"}
+
+type listOptions struct {
+	allMessages  bool
+	internalOnly bool
+	columns      []string
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// newListCmd represents the list command
+func newListCmd(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	opts := &listOptions{GlobalOptions: globalOpts}
+	listCmd := &cobra.Command{
+		Use:           "list [flags] [options] cluster-identifier",
+		Short:         "gets all servicelog messages for a given cluster",
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(complete(cmd, args))
+			var err error
+			opts.columns, err = printer.CSVColumns(cmd, listCSVColumns, opts.columns)
+			osdctlErrors.CheckErr(err)
+			osdctlErrors.CheckErr(opts.run(cmd, args[0]))
+		},
+	}
+
+	// define required flags
+	listCmd.Flags().BoolVarP(&opts.allMessages, "all-messages", "A", false, "Toggle if we should see all of the messages or only SRE-P specific ones")
+	listCmd.Flags().BoolVarP(&opts.internalOnly, "internal", "i", false, "Toggle if we should see internal messages")
+	printer.AddColumnsFlag(listCmd, &opts.columns, listCSVColumns)
+
+	return listCmd
 }
 
 func complete(cmd *cobra.Command, args []string) error {
@@ -43,8 +73,8 @@ func complete(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func run(cmd *cobra.Command, clusterID string) error {
-	response, err := FetchServiceLogs(clusterID)
+func (o *listOptions) run(cmd *cobra.Command, clusterID string) error {
+	response, err := FetchServiceLogs(cmd.ErrOrStderr(), clusterID, o.allMessages, o.internalOnly)
 	if err != nil {
 		// If the response has errored, likely the input was bad, so show usage
 		err := cmd.Help()
@@ -54,7 +84,13 @@ func run(cmd *cobra.Command, clusterID string) error {
 		return err
 	}
 
-	err = dump.Pretty(os.Stdout, response.Bytes())
+	if o.GlobalOptions.Output == "csv" {
+		return writeServiceLogsCSV(printer.Tee(os.Stdout), response.Bytes(), o.columns)
+	}
+	if printer.FilterEnabled() {
+		return printer.PrintJSON(printer.Tee(os.Stdout), response.Bytes())
+	}
+	err = dump.Pretty(printer.Tee(os.Stdout), response.Bytes())
 	if err != nil {
 		// If outputing the data errored, there's likely an internal error, so just return the error
 		return err
@@ -62,15 +98,12 @@ func run(cmd *cobra.Command, clusterID string) error {
 	return nil
 }
 
-var serviceLogListAllMessagesFlag = false
-var serviceLogListInternalOnlyFlag = false
-
-func FetchServiceLogs(clusterID string) (*sdk.Response, error) {
+func FetchServiceLogs(errOut io.Writer, clusterID string, allMessages bool, internalOnly bool) (*sdk.Response, error) {
 	// Create OCM client to talk to cluster API
 	ocmClient := utils.CreateConnection()
 	defer func() {
 		if err := ocmClient.Close(); err != nil {
-			fmt.Printf("Cannot close the ocmClient (possible memory leak): %q", err)
+			fmt.Fprintf(errOut, "Cannot close the ocmClient (possible memory leak): %q", err)
 		}
 	}()
 
@@ -82,21 +115,19 @@ func FetchServiceLogs(clusterID string) (*sdk.Response, error) {
 	cluster := clusters[0]
 
 	// Now get the SLs for the cluster
-	return sendRequest(CreateListSLRequest(ocmClient, cluster, serviceLogListAllMessagesFlag, serviceLogListInternalOnlyFlag))
-}
-
-func init() {
-	// define required flags
-	listCmd.Flags().BoolVarP(&serviceLogListAllMessagesFlag, "all-messages", "A", serviceLogListAllMessagesFlag, "Toggle if we should see all of the messages or only SRE-P specific ones")
-	listCmd.Flags().BoolVarP(&serviceLogListInternalOnlyFlag, "internal", "i", serviceLogListInternalOnlyFlag, "Toggle if we should see internal messages")
+	request, err := CreateListSLRequest(ocmClient, cluster, allMessages, internalOnly)
+	if err != nil {
+		return nil, err
+	}
+	return sendRequest(request)
 }
 
-func CreateListSLRequest(ocmClient *sdk.Connection, cluster *cmv1.Cluster, allMessages bool, internalMessages bool) *sdk.Request {
+func CreateListSLRequest(ocmClient *sdk.Connection, cluster *cmv1.Cluster, allMessages bool, internalMessages bool) (*sdk.Request, error) {
 	// Create and populate the request:
 	request := ocmClient.Get()
 	err := arguments.ApplyPathArg(request, targetAPIPath)
 	if err != nil {
-		log.Fatalf("Can't parse API path '%s': %v\n", targetAPIPath, err)
+		return nil, fmt.Errorf("can't parse API path '%s': %v", targetAPIPath, err)
 	}
 	var empty []string
 
@@ -116,5 +147,19 @@ func CreateListSLRequest(ocmClient *sdk.Connection, cluster *cmv1.Cluster, allMe
 	}
 	arguments.ApplyParameterFlag(request, []string{formatMessage})
 	arguments.ApplyHeaderFlag(request, empty)
-	return request
+	return request, nil
+}
+
+// writeServiceLogsCSV writes a row per service log of the list response
+func writeServiceLogsCSV(w io.Writer, body []byte, columns []string) error {
+	var list servicelog.ClusterListGoodReply
+	if err := json.Unmarshal(body, &list); err != nil {
+		return fmt.Errorf("cannot parse the service logs: %w", err)
+	}
+	rows := make([][]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		rows = append(rows, []string{item.ID, item.Timestamp.UTC().Format(time.RFC3339), item.ClusterUUID, item.Severity, item.ServiceName,
+			item.Summary, item.Description, item.EventStreamID})
+	}
+	return printer.WriteCSV(w, listCSVColumns, rows, columns)
 }
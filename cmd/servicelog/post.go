@@ -2,14 +2,16 @@ package servicelog
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/utils/strings/slices"
 	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/openshift-online/ocm-cli/pkg/arguments"
@@ -17,7 +19,9 @@ import (
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
 	"github.com/openshift/osdctl/internal/servicelog"
+	"github.com/openshift/osdctl/internal/support"
 	"github.com/openshift/osdctl/internal/utils"
+	"github.com/openshift/osdctl/pkg/checkpoint"
 	"github.com/openshift/osdctl/pkg/printer"
 	ctlutil "github.com/openshift/osdctl/pkg/utils"
 	ocmutils "github.com/openshift/osdctl/pkg/utils"
@@ -34,10 +38,18 @@ type PostCmdOptions struct {
 	filterFiles     []string // Path to filter file
 	filtersFromFile string   // Contents of filterFiles
 	isDryRun        bool
+	preview         bool
 	skipPrompts     bool
 	clustersFile    string
 	internalOnly    bool
 	ClusterId       string
+	filterParams    []string
+	// supersede is the ID of an earlier service log of the cluster, sent in error, which the message corrects
+	supersede string
+
+	userParameterNames, userParameterValues []string
+
+	checkpoint checkpoint.Flags
 
 	// Messaged clusters
 	successfulClusters map[string]string
@@ -57,7 +69,7 @@ func newPostCmd() *cobra.Command {
 			if len(args) > 0 {
 				opts.ClusterId = args[0]
 			}
-			cmdutil.CheckErr(opts.Run())
+			osdctlErrors.CheckErr(opts.Run())
 		},
 	}
 
@@ -65,11 +77,14 @@ func newPostCmd() *cobra.Command {
 	postCmd.Flags().StringVarP(&opts.Template, "template", "t", "", "Message template file or URL")
 	postCmd.Flags().StringArrayVarP(&opts.TemplateParams, "param", "p", opts.TemplateParams, "Specify a key-value pair (eg. -p FOO=BAR) to set/override a parameter value in the template.")
 	postCmd.Flags().BoolVarP(&opts.isDryRun, "dry-run", "d", false, "Dry-run - print the service log about to be sent but don't send it.")
-	postCmd.Flags().StringArrayVarP(&filterParams, "query", "q", filterParams, "Specify a search query (eg. -q \"name like foo\") for a bulk-post to matching clusters.")
+	postCmd.Flags().BoolVar(&opts.preview, "preview", false, "Render the service log as the customer will read it in the OCM console and emails, with the parameters substituted.")
+	postCmd.Flags().StringArrayVarP(&opts.filterParams, "query", "q", opts.filterParams, "Specify a search query (eg. -q \"name like foo\") for a bulk-post to matching clusters.")
 	postCmd.Flags().BoolVarP(&opts.skipPrompts, "yes", "y", false, "Skips all prompts.")
 	postCmd.Flags().StringArrayVarP(&opts.filterFiles, "query-file", "f", []string{}, "File containing search queries to apply. All lines in the file will be concatenated into a single query. If this flag is called multiple times, every file's search query will be combined with logical AND.")
 	postCmd.Flags().StringVarP(&opts.clustersFile, "clusters-file", "c", "", `Read a list of clusters to post the servicelog to. the format of the file is: {"clusters":["$CLUSTERID"]}`)
 	postCmd.Flags().BoolVarP(&opts.internalOnly, "internal", "i", false, "Internal only service log. Use MESSAGE for template parameter (eg. -p MESSAGE='My super secret message').")
+	postCmd.Flags().StringVar(&opts.supersede, "supersede", "", "ID of an earlier service log of the cluster sent in error, the message tells the customer it replaces it")
+	opts.checkpoint.AddFlags(postCmd)
 
 	return postCmd
 }
@@ -79,7 +94,7 @@ func (o *PostCmdOptions) Init() error {
 }
 
 func (o *PostCmdOptions) Validate() error {
-	if o.ClusterId == "" && len(filterParams) == 0 && o.clustersFile == "" {
+	if o.ClusterId == "" && len(o.filterParams) == 0 && o.clustersFile == "" {
 		return fmt.Errorf("no cluster identifier has been found")
 	}
 	return nil
@@ -93,28 +108,41 @@ func (o *PostCmdOptions) Run() error {
 		return err
 	}
 
-	o.parseUserParameters() // parse all the '-p' user flags
-	o.readFilterFile()      // parse the ocm filters in file provided via '-f' flag
-	o.readTemplate()        // parse the given JSON template provided via '-t' flag
+	// parse all the '-p' user flags
+	if err := o.parseUserParameters(); err != nil {
+		return err
+	}
+	// parse the ocm filters in file provided via '-f' flag
+	if err := o.readFilterFile(); err != nil {
+		return err
+	}
+	// parse the given JSON template provided via '-t' flag
+	if err := o.readTemplate(); err != nil {
+		return err
+	}
 
 	var queries []string
 	queries = append(queries, ocmutils.GenerateQuery(o.ClusterId))
 
 	if len(queries) > 0 {
-		if len(filterParams) > 0 {
+		if len(o.filterParams) > 0 {
 			log.Warnf("A cluster identifier was passed with the '-q' flag. This will apply logical AND between the search query and the cluster given, potentially resulting in no matches")
 		}
-		filterParams = append(filterParams, strings.Join(queries, " or "))
+		o.filterParams = append(o.filterParams, strings.Join(queries, " or "))
 	}
 
 	// For every '-p' flag, replace its related placeholder in the template & filterFiles
-	for k := range userParameterNames {
-		o.replaceFlags(userParameterNames[k], userParameterValues[k])
+	for k := range o.userParameterNames {
+		if err := o.replaceFlags(o.userParameterNames[k], o.userParameterValues[k]); err != nil {
+			return err
+		}
 	}
 
 	// Check if there are any remaining placeholders in the template that are not replaced by a parameter,
 	// excluding '${CLUSTER_UUID}' which will be replaced for each cluster later
-	o.checkLeftovers([]string{"${CLUSTER_UUID}"})
+	if err := o.checkLeftovers([]string{"${CLUSTER_UUID}"}); err != nil {
+		return err
+	}
 
 	// Create an OCM client to talk to the cluster API
 	// the user has to be logged in (e.g. 'ocm login')
@@ -127,41 +155,47 @@ func (o *PostCmdOptions) Run() error {
 
 	// Retrieve matching clusters
 	if o.filtersFromFile != "" {
-		if len(filterParams) != 0 {
+		if len(o.filterParams) != 0 {
 			log.Warnf("Search queries were passed using both the '-q' and '-f' flags. This will apply logical AND between the queries, potentially resulting in no matches")
 		}
 		filters := strings.Join(strings.Split(strings.TrimSpace(o.filtersFromFile), "\n"), " ")
-		filterParams = append(filterParams, filters)
+		o.filterParams = append(o.filterParams, filters)
 	}
 
 	if o.clustersFile != "" {
 		contents, err := o.accessFile(o.clustersFile)
 		if err != nil {
-			log.Fatalf("Cannot read file %s: %q", o.clustersFile, err)
+			return fmt.Errorf("cannot read file %s: %q", o.clustersFile, err)
 		}
 		err = o.parseClustersFile(contents)
 		if err != nil {
-			log.Fatalf("Cannot parse file %s: %q", o.clustersFile, err)
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "cannot parse file %s: %q", o.clustersFile, err)
 		}
 		query := []string{}
 		for i := range o.ClustersFile.Clusters {
 			cluster := o.ClustersFile.Clusters[i]
 			query = append(query, ocmutils.GenerateQuery(cluster))
 		}
-		filterParams = append(filterParams, strings.Join(query, " or "))
+		o.filterParams = append(o.filterParams, strings.Join(query, " or "))
 	}
 
-	clusters, err := ocmutils.ApplyFilters(ocmClient, filterParams)
+	clusters, err := ocmutils.ApplyFilters(ocmClient, o.filterParams)
 
 	if err != nil {
-		log.Fatalf("Cannot retrieve clusters: %q", err)
+		return fmt.Errorf("cannot retrieve clusters: %w", err)
 	} else if len(clusters) < 1 {
-		log.Fatalf("No clusters match the given parameters.")
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "no clusters match the given parameters")
 	}
 
 	log.Infoln("The following clusters match the given parameters:")
 	if err := o.printClusters(clusters); err != nil {
-		log.Fatalf("Could not print matching clusters: %q", err)
+		return fmt.Errorf("could not print matching clusters: %q", err)
+	}
+
+	if o.supersede != "" {
+		if err := o.supersedeServiceLog(ocmClient, clusters); err != nil {
+			return err
+		}
 	}
 
 	log.Infoln("The following template will be sent:")
@@ -169,16 +203,32 @@ func (o *PostCmdOptions) Run() error {
 		log.Errorf("Cannot read generated template: %q", err)
 	}
 
+	if o.preview {
+		if err := o.printPreview(clusters); err != nil {
+			log.Errorf("Cannot render the preview: %q", err)
+		}
+	}
+
 	// If this is a dry-run, don't proceed further.
 	if o.isDryRun {
 		return nil
 	}
 
-	if !o.skipPrompts {
-		err = ctlutil.ConfirmSend()
-		if err != nil {
-			log.Fatal(err)
-		}
+	action := fmt.Sprintf("Post service log '%s'", o.Message.Summary)
+	summary := &ctlutil.ImpactSummary{
+		Action:      fmt.Sprintf("%s to %d clusters", action, len(clusters)),
+		Environment: ctlutil.GetCurrentOCMEnv(ocmClient),
+	}
+	if len(clusters) == 1 {
+		summary = ctlutil.NewClusterImpactSummary(ocmClient, clusters[0], action)
+	}
+
+	err = ctlutil.Confirm(ctlutil.ConfirmOptions{
+		Summary:    summary,
+		SkipPrompt: o.skipPrompts,
+	})
+	if err != nil {
+		return err
 	}
 
 	// Handler if the program terminates abruptly
@@ -189,64 +239,115 @@ func (o *PostCmdOptions) Run() error {
 
 		// perform final cleanup actions
 		log.Error("program abruptly terminated, performing clean-up...")
-		o.cleanUp(clusters)
-		log.Fatal("servicelog post command terminated")
+		if err := o.cleanUp(clusters); err != nil {
+			log.Error(err)
+		}
+		osdctlErrors.CheckErr(errors.New("servicelog post command terminated"))
 	}()
 
+	// Bulk posts save their progress, so that re-running an interrupted one doesn't post twice
+	var progress *checkpoint.Checkpoint
+	if len(clusters) > 1 {
+		if progress, err = o.openCheckpoint(); err != nil {
+			return err
+		}
+		if progress.Resumed() {
+			log.Infof("Resuming the bulk post saved in %s", progress.Path())
+		}
+	}
+
 	for _, cluster := range clusters {
+		if progress != nil && progress.Done(cluster.ID()) {
+			o.successfulClusters[cluster.ExternalID()] = "Message was already sent by a previous run"
+			continue
+		}
+
+		// The clusters of a bulk post are checked one by one, the confirmation only checked the single one
+		if len(clusters) > 1 {
+			if err := ctlutil.CheckTenantScope(ocmClient, cluster); err != nil {
+				o.failedClusters[cluster.ExternalID()] = err.Error()
+				o.recordProgress(progress, cluster, err)
+				continue
+			}
+		}
+
 		request, err := o.createPostRequest(ocmClient, cluster)
 		if err != nil {
 			o.failedClusters[cluster.ExternalID()] = err.Error()
+			o.recordProgress(progress, cluster, err)
 			continue
 		}
 
 		response, err := sendRequest(request)
 		if err != nil {
 			o.failedClusters[cluster.ExternalID()] = err.Error()
+			o.recordProgress(progress, cluster, err)
 			continue
 		}
 
 		o.check(response, o.Message)
+		if reason, failed := o.failedClusters[cluster.ExternalID()]; failed {
+			o.recordProgress(progress, cluster, errors.New(reason))
+		} else {
+			o.recordProgress(progress, cluster, nil)
+		}
 	}
 
-	o.printPostOutput()
-	return nil
+	outputErr := o.printPostOutput()
+	if progress != nil {
+		if len(o.failedClusters) > 0 {
+			log.Infof("Run the same command again to retry the failed clusters, progress saved in %s", progress.Path())
+		} else if err := progress.Complete(); err != nil {
+			log.Warnf("Cannot remove the checkpoint %s: %q", progress.Path(), err)
+		}
+	}
+	return outputErr
+}
+
+// openCheckpoint returns the progress of previous runs posting the same message to the same query
+func (o *PostCmdOptions) openCheckpoint() (*checkpoint.Checkpoint, error) {
+	message, err := json.Marshal(o.Message)
+	if err != nil {
+		return nil, err
+	}
+	filters := append([]string{}, o.filterParams...)
+	sort.Strings(filters)
+	return o.checkpoint.Open("servicelog post", string(message), strings.Join(filters, " and "))
+}
+
+func (o *PostCmdOptions) recordProgress(progress *checkpoint.Checkpoint, cluster *v1.Cluster, err error) {
+	if progress == nil {
+		return
+	}
+	if saveErr := progress.Record(cluster.ID(), err); saveErr != nil {
+		log.Warnf("Cannot save the progress to %s: %q", progress.Path(), saveErr)
+	}
 }
 
 func (o *PostCmdOptions) check(response *sdk.Response, clusterMessage servicelog.Message) {
-	body := response.Bytes()
-	if response.Status() < 400 {
-		_, err := validateGoodResponse(body, clusterMessage)
-		if err != nil {
-			o.failedClusters[clusterMessage.ClusterUUID] = err.Error()
-		} else {
-			o.successfulClusters[clusterMessage.ClusterUUID] = fmt.Sprintf("Message has been successfully sent to %s", clusterMessage.ClusterUUID)
-		}
+	if err := responseError(response, clusterMessage); err != nil {
+		o.failedClusters[clusterMessage.ClusterUUID] = err.Error()
 	} else {
-		badReply, err := validateBadResponse(body)
-		if err != nil {
-			o.failedClusters[clusterMessage.ClusterUUID] = err.Error()
-		} else {
-			o.failedClusters[clusterMessage.ClusterUUID] = badReply.Reason
-		}
+		o.successfulClusters[clusterMessage.ClusterUUID] = fmt.Sprintf("Message has been successfully sent to %s", clusterMessage.ClusterUUID)
 	}
 }
 
 // parseUserParameters parse all the '-p FOO=BAR' parameters and checks for syntax errors
-func (o *PostCmdOptions) parseUserParameters() {
+func (o *PostCmdOptions) parseUserParameters() error {
 	for _, v := range o.TemplateParams {
 		if !strings.Contains(v, "=") {
-			log.Fatalf("Wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "Wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
 		}
 
 		param := strings.SplitN(v, "=", 2)
 		if param[0] == "" || param[1] == "" {
-			log.Fatalf("Wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "Wrong syntax of '-p' flag. Please use it like this: '-p FOO=BAR'")
 		}
 
-		userParameterNames = append(userParameterNames, fmt.Sprintf("${%v}", param[0]))
-		userParameterValues = append(userParameterValues, param[1])
+		o.userParameterNames = append(o.userParameterNames, fmt.Sprintf("${%v}", param[0]))
+		o.userParameterValues = append(o.userParameterValues, param[1])
 	}
+	return nil
 }
 
 // accessFile returns the contents of a local file or url, and any errors encountered
@@ -286,7 +387,7 @@ func (o *PostCmdOptions) parseTemplate(jsonFile []byte) error {
 }
 
 // readTemplate loads the template into the Message variable
-func (o *PostCmdOptions) readTemplate() {
+func (o *PostCmdOptions) readTemplate() error {
 	if o.internalOnly {
 		// fixed template for internal service logs
 		messageTemplate := []byte(`
@@ -299,35 +400,39 @@ func (o *PostCmdOptions) readTemplate() {
 		}
 		`)
 		if err := o.parseTemplate(messageTemplate); err != nil {
-			log.Fatalf("Cannot not parse the JSON internal message template.\nError: %q\n", err)
+			return fmt.Errorf("Cannot not parse the JSON internal message template.\nError: %q", err)
 		}
-		return
+		return nil
 	}
 
 	if o.Template == "" {
-		log.Fatalf("Template file is not provided. Use '-t' to fix this.")
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "Template file is not provided. Use '-t' to fix this.")
 	}
 
 	file, err := o.accessFile(o.Template)
 	if err != nil { // check if this URL or file and if we can access it
-		log.Fatal(err)
+		return err
+	}
+	if err := support.Validate(support.KindServiceLog, file); err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "The template %s doesn't match the service log schema, check it with 'osdctl template validate':\n%v", o.Template, err)
 	}
 
 	if err = o.parseTemplate(file); err != nil {
-		log.Fatalf("Cannot not parse the JSON template.\nError: %q\n", err)
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "Cannot not parse the JSON template.\nError: %q", err)
 	}
+	return nil
 }
 
-func (o *PostCmdOptions) readFilterFile() {
+func (o *PostCmdOptions) readFilterFile() error {
 	if len(o.filterFiles) < 1 {
 		// No filterFiles specified in args
-		return
+		return nil
 	}
 
 	for _, filterFile := range o.filterFiles {
 		fileContents, err := o.accessFile(filterFile)
 		if err != nil {
-			log.Fatal(err)
+			return err
 		}
 
 		if o.filtersFromFile == "" {
@@ -336,6 +441,7 @@ func (o *PostCmdOptions) readFilterFile() {
 			o.filtersFromFile = o.filtersFromFile + " and (" + strings.TrimSpace(string(fileContents)) + ")"
 		}
 	}
+	return nil
 }
 
 func (o *PostCmdOptions) FindLeftovers(s string) (matches []string) {
@@ -344,7 +450,7 @@ func (o *PostCmdOptions) FindLeftovers(s string) (matches []string) {
 	return matches
 }
 
-func (o *PostCmdOptions) checkLeftovers(excludes []string) {
+func (o *PostCmdOptions) checkLeftovers(excludes []string) error {
 	unusedParameters, _ := o.Message.FindLeftovers()
 	unusedParameters = append(unusedParameters, o.FindLeftovers(o.filtersFromFile)...)
 
@@ -359,15 +465,16 @@ func (o *PostCmdOptions) checkLeftovers(excludes []string) {
 		}
 	}
 	if numberOfMissingParameters == 1 {
-		log.Fatal("Please define this missing parameter properly.")
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "Please define this missing parameter properly.")
 	} else if numberOfMissingParameters > 1 {
-		log.Fatalf("Please define all %v missing parameters properly.", numberOfMissingParameters)
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "Please define all %v missing parameters properly.", numberOfMissingParameters)
 	}
+	return nil
 }
 
-func (o *PostCmdOptions) replaceFlags(flagName string, flagValue string) {
+func (o *PostCmdOptions) replaceFlags(flagName string, flagValue string) error {
 	if flagValue == "" {
-		log.Fatalf("The selected template is using '%[1]s' parameter, but '%[1]s' flag was not set. Use '-p %[1]s=\"FOOBAR\"' to fix this.", flagName)
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "The selected template is using '%[1]s' parameter, but '%[1]s' flag was not set. Use '-p %[1]s=\"FOOBAR\"' to fix this.", flagName)
 	}
 
 	found := false
@@ -381,8 +488,9 @@ func (o *PostCmdOptions) replaceFlags(flagName string, flagValue string) {
 	}
 
 	if !found {
-		log.Fatalf("The selected template is not using '%s' parameter, but '--param' flag was set. Do not use '-p %s=%s' to fix this.", flagName, flagName, flagValue)
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "The selected template is not using '%s' parameter, but '--param' flag was set. Do not use '-p %s=%s' to fix this.", flagName, flagName, flagValue)
 	}
+	return nil
 }
 
 func (o *PostCmdOptions) printClusters(clusters []*v1.Cluster) (err error) {
@@ -405,6 +513,54 @@ func (o *PostCmdOptions) printTemplate() (err error) {
 	return dump.Pretty(os.Stdout, exampleMessage)
 }
 
+// printPreview renders the service log as the first cluster's customer reads it
+func (o *PostCmdOptions) printPreview(clusters []*v1.Cluster) error {
+	cluster := clusters[0]
+	message := o.Message
+	// ${CLUSTER_UUID} is substituted when the service log is sent to each cluster
+	message.ReplaceWithFlag("${CLUSTER_UUID}", cluster.ExternalID())
+
+	heading := "Customer view (OCM console cluster history and notification email):"
+	if o.internalOnly {
+		heading = "Internal only, the customer won't see this service log:"
+	} else if len(clusters) > 1 {
+		heading = fmt.Sprintf("Customer view of %s, the %d other clusters get the same text with their own ID:", cluster.Name(), len(clusters)-1)
+	}
+
+	return printer.PrintPreview(os.Stdout, printer.Preview{
+		Heading: heading,
+		Fields: [][2]string{
+			{"Cluster", cluster.Name()},
+			{"Severity", message.Severity},
+			{"Service", message.ServiceName},
+		},
+		Title: message.Summary,
+		Body:  message.Description,
+	})
+}
+
+// supersedeServiceLog tells the customer, at the top of the message, that it replaces the earlier service log
+func (o *PostCmdOptions) supersedeServiceLog(ocmClient *sdk.Connection, clusters []*v1.Cluster) error {
+	if len(clusters) != 1 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "--supersede corrects a service log of a single cluster, %d clusters match the given parameters", len(clusters))
+	}
+	if o.internalOnly {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "--supersede corrects a service log read by the customer, it can't be used with --internal")
+	}
+	earlier, err := getServiceLog(ocmClient, clusters[0], o.supersede)
+	if err != nil {
+		return err
+	}
+	o.Message.Description = supersededDescription(earlier, o.Message.Description)
+	return nil
+}
+
+// supersededDescription prefixes the description with the earlier service log it replaces
+func supersededDescription(earlier *servicelog.GoodReply, description string) string {
+	return fmt.Sprintf("This notification supersedes the notification \"%s\" sent on %s, which was sent in error. Please disregard it.\n\n%s",
+		earlier.Summary, earlier.Timestamp.UTC().Format("2006-01-02 15:04 MST"), description)
+}
+
 func (o *PostCmdOptions) createPostRequest(ocmClient *sdk.Connection, cluster *v1.Cluster) (request *sdk.Request, err error) {
 	// Create and populate the request:
 	request = ocmClient.Post()
@@ -445,7 +601,7 @@ func (o *PostCmdOptions) listMessagedClusters(clusters map[string]string) error
 }
 
 // printPostOutput prints the main servicelog post output.
-func (o *PostCmdOptions) printPostOutput() {
+func (o *PostCmdOptions) printPostOutput() error {
 	output := fmt.Sprintf("Success: %d, Failed: %d\n", len(o.successfulClusters), len(o.failedClusters))
 	log.Infoln(output + "\n")
 
@@ -453,7 +609,7 @@ func (o *PostCmdOptions) printPostOutput() {
 	if len(o.successfulClusters) > 0 {
 		log.Infoln("Successful clusters:")
 		if err := o.listMessagedClusters(o.successfulClusters); err != nil {
-			log.Fatalf("Cannot list successful clusters: %q", err)
+			return fmt.Errorf("cannot list successful clusters: %q", err)
 		}
 	}
 
@@ -461,18 +617,19 @@ func (o *PostCmdOptions) printPostOutput() {
 	if len(o.failedClusters) > 0 {
 		log.Infoln("Failed clusters:")
 		if err := o.listMessagedClusters(o.failedClusters); err != nil {
-			log.Fatalf("Cannot list failed clusters: %q", err)
+			return fmt.Errorf("cannot list failed clusters: %q", err)
 		}
 	}
+	return nil
 }
 
 // cleanUp performs final actions in case of program termination.
-func (o *PostCmdOptions) cleanUp(clusters []*v1.Cluster) {
+func (o *PostCmdOptions) cleanUp(clusters []*v1.Cluster) error {
 	for _, cluster := range clusters {
 		if _, ok := o.successfulClusters[cluster.ExternalID()]; !ok {
 			o.failedClusters[cluster.ExternalID()] = "cannot send message due to program interruption"
 		}
 	}
 
-	o.printPostOutput()
+	return o.printPostOutput()
 }
@@ -0,0 +1,131 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/openshift/osdctl/internal/support"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const validateLong = `Checks limited support reason and service log templates against the schemas osdctl ships, before posting them.
+
+Every problem is reported with its line and column: syntax errors, unknown or misspelled fields, missing fields,
+values of the wrong type and severities or detection types which don't exist. The enum and length checks are skipped
+for the values holding ${...} parameters. The kind of every template is told from its fields unless --kind is given.
+
+'osdctl servicelog post -t' and 'osdctl cluster support post -t' run the same checks.`
+
+const validateExample = `
+  # Check the templates of a pull request
+  osdctl template validate osd/upgrade_paused.json osd/limited_support/monitoring_disabled.json
+
+  # Check a template read from stdin as a service log
+  generate-template | osdctl template validate --kind service-log -
+`
+
+type validateOptions struct {
+	kind string
+
+	in  io.Reader
+	out io.Writer
+}
+
+// NewCmdTemplate implements the template utility
+func NewCmdTemplate() *cobra.Command {
+	templateCmd := &cobra.Command{
+		Use:               "template",
+		Short:             "Works with the limited support reason and service log templates",
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	templateCmd.AddCommand(newCmdValidate())
+	return templateCmd
+}
+
+func newCmdValidate() *cobra.Command {
+	ops := &validateOptions{in: os.Stdin, out: os.Stdout}
+	validateCmd := &cobra.Command{
+		Use:               "validate FILE...",
+		Short:             "Checks templates against the limited support reason and service log schemas",
+		Long:              validateLong,
+		Example:           validateExample,
+		Args:              cobra.MinimumNArgs(1),
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd))
+			osdctlErrors.CheckErr(ops.run(args))
+		},
+	}
+	validateCmd.Flags().StringVar(&ops.kind, "kind", "", "Kind of the templates, one of "+strings.Join(support.Kinds(), ", ")+" (default: told from the fields of every template)")
+
+	return validateCmd
+}
+
+func (o *validateOptions) complete(cmd *cobra.Command) error {
+	if o.kind == "" {
+		return nil
+	}
+	if _, err := support.LoadSchema(o.kind); err != nil {
+		return cmdutil.UsageErrorf(cmd, "invalid --kind: %v", err)
+	}
+	return nil
+}
+
+// run checks every file, reporting all the problems before failing
+func (o *validateOptions) run(files []string) error {
+	var invalid []string
+	for _, file := range files {
+		data, err := o.read(file)
+		if err != nil {
+			return err
+		}
+		kind := o.kind
+		if kind == "" {
+			kind = support.DetectKind(data)
+		}
+
+		err = support.Validate(kind, data)
+		var problems support.ValidationErrors
+		switch {
+		case err == nil:
+			fmt.Fprintf(o.out, "%s: valid %s template\n", file, kind)
+		case errors.As(err, &problems):
+			invalid = append(invalid, file)
+			for _, problem := range problems {
+				fmt.Fprintf(o.out, "%s: %v\n", file, problem)
+			}
+		default:
+			return err
+		}
+	}
+
+	if len(invalid) > 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "%d of %d templates are invalid: %s", len(invalid), len(files), strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// read returns the contents of the file, stdin for '-'
+func (o *validateOptions) read(file string) ([]byte, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(o.in)
+	} else {
+		data, err = os.ReadFile(file) //#nosec G304 -- file is given by the user
+	}
+	if err != nil {
+		return nil, osdctlErrors.Wrap(osdctlErrors.ErrNotFound, fmt.Errorf("cannot read the template: %w", err))
+	}
+	return data, nil
+}
@@ -0,0 +1,37 @@
+package template
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+func TestValidateTemplates(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "upgrade_paused.json")
+	g.Expect(os.WriteFile(valid, []byte(`{"severity": "Info", "service_name": "SREManualAction", "summary": "Upgrade paused", "description": "${REASON}"}`), 0600)).To(Succeed())
+
+	var out bytes.Buffer
+	o := &validateOptions{in: strings.NewReader(`{"summary": "Monitoring disabled", "detection_type": "automatic"}`), out: &out}
+	err := o.run([]string{valid, "-"})
+	g.Expect(errors.Is(err, osdctlErrors.ErrValidation)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring("1 of 2 templates are invalid: -")))
+	g.Expect(out.String()).To(Equal(valid + ": valid service-log template\n" +
+		"-: line 1, column 1: expected a 'template_id' or a 'summary' and 'details'\n" +
+		"-: line 1, column 54: detection_type: 'automatic' isn't one of: manual, auto\n"))
+}
+
+func TestValidateMissingTemplate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	o := &validateOptions{out: &bytes.Buffer{}}
+	err := o.run([]string{filepath.Join(t.TempDir(), "missing.json")})
+	g.Expect(errors.Is(err, osdctlErrors.ErrNotFound)).To(BeTrue())
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -18,19 +21,34 @@ import (
 )
 
 var upgradeCmd = &cobra.Command{
-	Use:           "upgrade",
-	Short:         "Upgrade osdctl",
-	Long:          "Fetch latest osdctl from GitHub and replace the running binary",
+	Use:   "upgrade",
+	Short: "Upgrade osdctl",
+	Long: `Fetch latest osdctl from GitHub and replace the running binary. The archive is checked against the
+sha256 checksums published with the release before anything is replaced.
+
+The stable channel follows the latest release, the candidate channel includes the pre-releases as well.`,
+	Example: `
+  # Upgrade to the latest stable release
+  osdctl upgrade
+
+  # Upgrade to the latest release candidate
+  osdctl upgrade --channel candidate`,
 	RunE:          upgrade,
 	SilenceErrors: true,
 }
 
+var upgradeChannel string
+
+func init() {
+	upgradeCmd.Flags().StringVar(&upgradeChannel, "channel", utils.StableChannel, fmt.Sprintf("Release channel to upgrade from: %s or %s", utils.StableChannel, utils.CandidateChannel))
+}
+
 func upgrade(cmd *cobra.Command, args []string) error {
 	// rootName ensures that the upgrade will fail if we ever decide to rename osdctl
 	// between releases :-)
 	rootName := cmd.Root().Name()
 
-	latest, err := utils.GetLatestVersion()
+	latest, err := utils.GetLatestVersionForChannel(upgradeChannel)
 	if err != nil {
 		return err
 	}
@@ -52,17 +70,25 @@ func upgrade(cmd *cobra.Command, args []string) error {
 		parseGOOS(runtime.GOOS),
 		parseGOARCH(runtime.GOARCH))
 
-	req, err := http.NewRequest(http.MethodGet, addr, nil)
+	archive, err := download(client, addr)
 	if err != nil {
 		return err
 	}
 
-	res, err := client.Do(req)
+	checksums, err := download(client, fmt.Sprintf(utils.ChecksumAddressTemplate, latestWithoutPrefix))
+	if err != nil {
+		return fmt.Errorf("cannot download the release checksums: %w", err)
+	}
+	expected, err := utils.ChecksumForFile(checksums, filepath.Base(addr))
 	if err != nil {
 		return err
 	}
+	sum := sha256.Sum256(archive)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", filepath.Base(addr), expected, actual)
+	}
 
-	gzf, err := gzip.NewReader(res.Body)
+	gzf, err := gzip.NewReader(bytes.NewReader(archive))
 	if err != nil {
 		return err
 	}
@@ -97,7 +123,7 @@ func upgrade(cmd *cobra.Command, args []string) error {
 		defer func(path string) {
 			err := os.RemoveAll(path)
 			if err != nil {
-				fmt.Println("Error removing directory ", path)
+				fmt.Fprintln(cmd.ErrOrStderr(), "Error removing directory ", path)
 			}
 		}(dir)
 
@@ -123,10 +149,25 @@ func upgrade(cmd *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		fmt.Printf("Upgraded osdctl from %s to %s\n", utils.Version, latestWithoutPrefix)
 	}
 	return nil
 }
 
+// download returns the body of a GET of addr, GitHub returns errors as html pages that aren't worth printing
+func download(client http.Client, addr string) ([]byte, error) {
+	res, err := client.Get(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cannot download %s: %s", addr, res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
 func parseGOOS(goos string) string {
 	switch goos {
 	case "linux":
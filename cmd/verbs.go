@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/openshift/osdctl/cmd/account/list"
+	"github.com/openshift/osdctl/cmd/cluster"
+	"github.com/openshift/osdctl/cmd/cluster/support"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	getLong = `Lists resources of every kind with the same verb, like kubectl: 'osdctl get clusters|accounts|reasons'.
+
+Every noun runs the osdctl command of its resource, with the same flags, which keeps working as before.`
+
+	describeLong = `Describes a resource with the same verb, like kubectl: 'osdctl describe cluster CLUSTER_ID'.
+
+Every noun runs the osdctl command of its resource, with the same flags, which keeps working as before.`
+)
+
+// newCmdGet implements the get verb, listing the resources of the nouns
+func newCmdGet(streams genericclioptions.IOStreams, flags *genericclioptions.ConfigFlags, client client.Client, globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	getCmd := &cobra.Command{
+		Use:               "get",
+		Short:             "Lists clusters, accounts or limited support reasons",
+		Long:              getLong,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run:               help,
+	}
+
+	getCmd.AddCommand(asNoun(cluster.NewCmdList(globalOpts), "clusters", "osdctl cluster list", "cluster"))
+	getCmd.AddCommand(asNoun(list.NewCmdListAccount(streams, flags, client, globalOpts), "accounts", "osdctl account list account", "account"))
+	getCmd.AddCommand(asNoun(support.NewCmdStatus(streams, flags, globalOpts), "reasons CLUSTER_ID", "osdctl cluster support status", "reason", "limited-support-reasons"))
+
+	return getCmd
+}
+
+// newCmdDescribe implements the describe verb, describing a resource of the nouns
+func newCmdDescribe(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	describeCmd := &cobra.Command{
+		Use:               "describe",
+		Short:             "Describes a cluster",
+		Long:              describeLong,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run:               help,
+	}
+
+	describeCmd.AddCommand(asNoun(cluster.NewCmdDescribe(globalOpts), "cluster CLUSTER_ID", "osdctl cluster describe", "clusters"))
+
+	return describeCmd
+}
+
+// asNoun turns a new instance of the command of a resource into a noun of a verb, use being the noun with the
+// arguments of the command
+func asNoun(cmd *cobra.Command, use string, original string, aliases ...string) *cobra.Command {
+	cmd.Use = use
+	cmd.Aliases = aliases
+	long := cmd.Long
+	if long == "" {
+		long = cmd.Short
+	}
+	cmd.Long = fmt.Sprintf("%s\n\nSame as '%s'.", long, original)
+	return cmd
+}
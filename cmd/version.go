@@ -6,6 +6,7 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"github.com/openshift/osdctl/pkg/fips"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -16,6 +17,8 @@ type versionResponse struct {
 	Commit  string `json:"commit"`
 	Version string `json:"version"`
 	Latest  string `json:"latest"`
+	// Crypto is only set with --crypto
+	Crypto *fips.Crypto `json:"crypto,omitempty"`
 }
 
 // versionCmd is the subcommand "osdctl version" for cobra.
@@ -26,6 +29,10 @@ var versionCmd = &cobra.Command{
 	RunE:  version,
 }
 
+func init() {
+	versionCmd.Flags().Bool("crypto", false, "Also display the cryptographic module osdctl was built with, to confirm a FIPS build")
+}
+
 // version returns the osdctl version marshalled in JSON
 func version(cmd *cobra.Command, args []string) error {
 	gitCommit := "unknown"
@@ -40,11 +47,16 @@ func version(cmd *cobra.Command, args []string) error {
 	}
 
 	latest, _ := utils.GetLatestVersion() // let's ignore this error, just in case we have no internet access
-	ver, err := json.MarshalIndent(&versionResponse{
+	response := &versionResponse{
 		Commit:  gitCommit,
 		Version: utils.Version,
 		Latest:  strings.TrimPrefix(latest, "v"),
-	}, "", "  ")
+	}
+	if showCrypto, _ := cmd.Flags().GetBool("crypto"); showCrypto {
+		crypto := fips.Info()
+		response.Crypto = &crypto
+	}
+	ver, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,301 @@
+package whoami
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/sts"
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	"github.com/openshift/osdctl/cmd/common"
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/printer"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const whoamiLong = `Print who osdctl acts as: the OCM account and organization of the current token, its roles and
+capabilities, when the tokens expire, and the AWS identity of the profile together with the role chain osdctl
+assumes from it to reach the cluster accounts. A part that can't be read is reported instead of aborting, which is
+what is needed when debugging a permission error.`
+
+const whoamiExample = `
+  # Table of the OCM and AWS identities
+  osdctl whoami
+
+  # Using another AWS profile, as JSON
+  osdctl whoami --aws-profile osd-staging -o json`
+
+type whoamiOptions struct {
+	profile string
+	output  string
+
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// NewCmdWhoami implements the whoami command
+func NewCmdWhoami(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &whoamiOptions{GlobalOptions: globalOpts}
+	whoamiCmd := &cobra.Command{
+		Use:               "whoami",
+		Short:             "Print the OCM account, roles, token expiry and AWS identity osdctl uses",
+		Long:              whoamiLong,
+		Example:           whoamiExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete())
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	whoamiCmd.Flags().StringVarP(&ops.profile, "aws-profile", "p", "", "AWS profile to identify, the default profile when empty")
+
+	return whoamiCmd
+}
+
+func (o *whoamiOptions) complete() error {
+	if o.GlobalOptions != nil {
+		o.output = o.GlobalOptions.Output
+	}
+	return nil
+}
+
+type ocmOrganization struct {
+	ID         string `json:"id" yaml:"id"`
+	Name       string `json:"name" yaml:"name"`
+	ExternalID string `json:"externalId" yaml:"externalId"`
+}
+
+type ocmIdentity struct {
+	URL                string          `json:"url" yaml:"url"`
+	Environment        string          `json:"environment" yaml:"environment"`
+	AccountID          string          `json:"accountId,omitempty" yaml:"accountId,omitempty"`
+	Username           string          `json:"username,omitempty" yaml:"username,omitempty"`
+	Email              string          `json:"email,omitempty" yaml:"email,omitempty"`
+	Organization       ocmOrganization `json:"organization" yaml:"organization"`
+	Roles              []string        `json:"roles" yaml:"roles"`
+	Capabilities       []string        `json:"capabilities" yaml:"capabilities"`
+	AccessTokenExpiry  string          `json:"accessTokenExpiry,omitempty" yaml:"accessTokenExpiry,omitempty"`
+	RefreshTokenExpiry string          `json:"refreshTokenExpiry,omitempty" yaml:"refreshTokenExpiry,omitempty"`
+	Errors             []string        `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+type awsIdentity struct {
+	Profile     string   `json:"profile" yaml:"profile"`
+	AccountID   string   `json:"accountId,omitempty" yaml:"accountId,omitempty"`
+	ARN         string   `json:"arn,omitempty" yaml:"arn,omitempty"`
+	UserID      string   `json:"userId,omitempty" yaml:"userId,omitempty"`
+	SessionName string   `json:"sessionName,omitempty" yaml:"sessionName,omitempty"`
+	RoleChain   []string `json:"roleChain" yaml:"roleChain"`
+	Errors      []string `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+type whoamiResponse struct {
+	OCM ocmIdentity `json:"ocm" yaml:"ocm"`
+	AWS awsIdentity `json:"aws" yaml:"aws"`
+}
+
+// String renders the identities as a table
+func (r whoamiResponse) String() string {
+	var b strings.Builder
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	orNone := func(values []string) string {
+		if len(values) == 0 {
+			return "-"
+		}
+		return strings.Join(values, ", ")
+	}
+
+	table.AddRow([]string{"OCM URL", r.OCM.URL})
+	table.AddRow([]string{"OCM environment", r.OCM.Environment})
+	table.AddRow([]string{"Account", fmt.Sprintf("%s (%s)", r.OCM.Username, r.OCM.AccountID)})
+	table.AddRow([]string{"Email", r.OCM.Email})
+	table.AddRow([]string{"Organization", fmt.Sprintf("%s (%s, external ID %s)", r.OCM.Organization.Name, r.OCM.Organization.ID, r.OCM.Organization.ExternalID)})
+	table.AddRow([]string{"Roles", orNone(r.OCM.Roles)})
+	table.AddRow([]string{"Capabilities", orNone(r.OCM.Capabilities)})
+	table.AddRow([]string{"Access token expiry", r.OCM.AccessTokenExpiry})
+	table.AddRow([]string{"Refresh token expiry", r.OCM.RefreshTokenExpiry})
+	for _, err := range r.OCM.Errors {
+		table.AddRow([]string{"OCM error", err})
+	}
+
+	// Add empty row for readability
+	table.AddRow([]string{})
+
+	profile := r.AWS.Profile
+	if profile == "" {
+		profile = "default"
+	}
+	table.AddRow([]string{"AWS profile", profile})
+	table.AddRow([]string{"AWS account", r.AWS.AccountID})
+	table.AddRow([]string{"AWS ARN", r.AWS.ARN})
+	table.AddRow([]string{"Session name", r.AWS.SessionName})
+	for i, role := range r.AWS.RoleChain {
+		table.AddRow([]string{fmt.Sprintf("Assumed role %d", i+1), role})
+	}
+	for _, err := range r.AWS.Errors {
+		table.AddRow([]string{"AWS error", err})
+	}
+
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (o *whoamiOptions) run() error {
+	conn := utils.CreateConnection()
+	defer conn.Close()
+
+	env := utils.GetCurrentOCMEnv(conn)
+	resp := whoamiResponse{
+		OCM: getOCMIdentity(conn, env),
+	}
+
+	awsClient, err := awsprovider.NewAwsClient(o.profile, common.DefaultRegion, "")
+	if err != nil {
+		resp.AWS = awsIdentity{Profile: o.profile, RoleChain: []string{}, Errors: []string{err.Error()}}
+	} else {
+		resp.AWS = getAWSIdentity(awsClient, o.profile, env)
+	}
+
+	return outputflag.PrintResponse(o.output, resp)
+}
+
+// getOCMIdentity reads the current account, its role bindings and the expiry of the tokens, recording the
+// failures instead of returning them
+func getOCMIdentity(conn *sdk.Connection, env string) ocmIdentity {
+	identity := ocmIdentity{
+		URL:          conn.URL(),
+		Environment:  env,
+		Roles:        []string{},
+		Capabilities: []string{},
+	}
+
+	access, refresh, err := conn.Tokens()
+	if err != nil {
+		identity.Errors = append(identity.Errors, fmt.Sprintf("can't get the tokens: %v", err))
+	} else {
+		identity.AccessTokenExpiry = describeTokenExpiry(access, time.Now())
+		identity.RefreshTokenExpiry = describeTokenExpiry(refresh, time.Now())
+	}
+
+	response, err := conn.AccountsMgmt().V1().CurrentAccount().Get().Parameter("fetchCapabilities", true).Send()
+	if err != nil {
+		identity.Errors = append(identity.Errors, fmt.Sprintf("can't get the current account: %v", err))
+		return identity
+	}
+	account := response.Body()
+	identity.AccountID = account.ID()
+	identity.Username = account.Username()
+	identity.Email = account.Email()
+	identity.Organization = ocmOrganization{
+		ID:         account.Organization().ID(),
+		Name:       account.Organization().Name(),
+		ExternalID: account.Organization().ExternalID(),
+	}
+	for _, capability := range account.Capabilities() {
+		identity.Capabilities = append(identity.Capabilities, fmt.Sprintf("%s=%s", capability.Name(), capability.Value()))
+	}
+	sort.Strings(identity.Capabilities)
+
+	bindings, err := conn.AccountsMgmt().V1().RoleBindings().List().
+		Parameter("search", fmt.Sprintf("account_id = '%s'", account.ID())).Size(100).Send()
+	if err != nil {
+		identity.Errors = append(identity.Errors, fmt.Sprintf("can't list the role bindings: %v", err))
+		return identity
+	}
+	identity.Roles = roleNames(bindings.Items().Slice())
+	return identity
+}
+
+// roleNames returns the sorted role IDs of the bindings, suffixed with the scope of the bindings that aren't
+// on the organization
+func roleNames(bindings []*amv1.RoleBinding) []string {
+	seen := map[string]bool{}
+	roles := []string{}
+	for _, binding := range bindings {
+		role := binding.RoleID()
+		if role == "" {
+			role = binding.Role().ID()
+		}
+		if binding.Type() != "" && binding.Type() != "Organization" {
+			role = fmt.Sprintf("%s (%s)", role, strings.ToLower(binding.Type()))
+		}
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// describeTokenExpiry returns when the token expires and how long is left
+func describeTokenExpiry(token string, now time.Time) string {
+	if token == "" {
+		return "no token"
+	}
+	expiry, err := utils.TokenExpiry(token)
+	if err != nil {
+		return err.Error()
+	}
+	if expiry.IsZero() {
+		return "never"
+	}
+	left := expiry.Sub(now).Round(time.Second)
+	if left <= 0 {
+		return fmt.Sprintf("%s (expired)", timefmt.Format(expiry))
+	}
+	return fmt.Sprintf("%s (in %s)", timefmt.Format(expiry), left)
+}
+
+// getAWSIdentity returns the identity of the AWS client and the roles osdctl would assume from it to reach
+// a cluster account, see osdCloud.GenerateJumpRoleCredentials. Nothing is assumed.
+func getAWSIdentity(client awsprovider.Client, profile, env string) awsIdentity {
+	identity := awsIdentity{Profile: profile, RoleChain: []string{}}
+
+	output, err := client.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		identity.Errors = append(identity.Errors, fmt.Sprintf("can't get the caller identity: %v", err))
+		return identity
+	}
+	identity.AccountID = aws.StringValue(output.Account)
+	identity.ARN = aws.StringValue(output.Arn)
+	identity.UserID = aws.StringValue(output.UserId)
+
+	callerArn, err := arn.Parse(identity.ARN)
+	if err != nil {
+		identity.Errors = append(identity.Errors, fmt.Sprintf("can't parse the caller ARN: %v", err))
+		return identity
+	}
+	sessionName, err := osdCloud.GenerateRoleSessionName(client)
+	if err != nil {
+		identity.Errors = append(identity.Errors, fmt.Sprintf("can't generate the role session name: %v", err))
+	}
+	identity.SessionName = sessionName
+
+	identity.RoleChain = append(identity.RoleChain, awsprovider.GenerateRoleARNInPartition(callerArn.Partition, callerArn.AccountID, osdCloud.RhSreCcsAccessRolename))
+	jumpRoleKey := osdCloud.ProdJumproleConfigKey
+	if env == "stage" || env == "integration" {
+		jumpRoleKey = osdCloud.StageJumproleConfigKey
+	}
+	if !viper.IsSet(jumpRoleKey) {
+		identity.Errors = append(identity.Errors, fmt.Sprintf("key %s is not set in config file, the jump role can't be assumed", jumpRoleKey))
+		return identity
+	}
+	identity.RoleChain = append(identity.RoleChain,
+		awsprovider.GenerateRoleARNInPartition(callerArn.Partition, viper.GetString(jumpRoleKey), osdCloud.RhTechnicalSupportAccess),
+		"the support role of the cluster account")
+	return identity
+}
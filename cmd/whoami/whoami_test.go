@@ -0,0 +1,157 @@
+package whoami
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/golang/mock/gomock"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdCloud"
+	awsmock "github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/spf13/viper"
+)
+
+func testJWT(claims string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none"}`)) + "." + encode([]byte(claims)) + ".signature"
+}
+
+func TestDescribeTokenExpiry(t *testing.T) {
+	viper.Set(timefmt.ConfigKey, true)
+	defer viper.Set(timefmt.ConfigKey, false)
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{
+			name:     "valid token",
+			token:    testJWT(fmt.Sprintf(`{"exp":%d}`, now.Add(90*time.Minute).Unix())),
+			expected: "2023-01-01 13:30:00 UTC (in 1h30m0s)",
+		},
+		{
+			name:     "expired token",
+			token:    testJWT(fmt.Sprintf(`{"exp":%d}`, now.Add(-time.Minute).Unix())),
+			expected: "2023-01-01 11:59:00 UTC (expired)",
+		},
+		{
+			name:     "offline token",
+			token:    testJWT(`{"typ":"Offline"}`),
+			expected: "never",
+		},
+		{
+			name:     "no token",
+			expected: "no token",
+		},
+		{
+			name:     "not a JWT",
+			token:    "opaque",
+			expected: "not a JWT",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := describeTokenExpiry(test.token, now); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRoleNames(t *testing.T) {
+	binding := func(role, bindingType string) *amv1.RoleBinding {
+		b, err := amv1.NewRoleBinding().RoleID(role).Type(bindingType).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	got := roleNames([]*amv1.RoleBinding{
+		binding("UHCSupport", "Organization"),
+		binding("ClusterOwner", "Subscription"),
+		binding("UHCSupport", "Organization"),
+		binding("OrganizationAdmin", ""),
+	})
+	expected := []string{"ClusterOwner (subscription)", "OrganizationAdmin", "UHCSupport"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestGetAWSIdentity(t *testing.T) {
+	caller := &sts.GetCallerIdentityOutput{
+		Account: aws.String("111111111111"),
+		Arn:     aws.String("arn:aws:iam::111111111111:user/jdoe"),
+		UserId:  aws.String("AIDAEXAMPLE"),
+	}
+
+	t.Run("full role chain", func(t *testing.T) {
+		viper.Set(osdCloud.StageJumproleConfigKey, "222222222222")
+		defer viper.Set(osdCloud.StageJumproleConfigKey, nil)
+
+		client := awsmock.NewMockClient(gomock.NewController(t))
+		client.EXPECT().GetCallerIdentity(gomock.Any()).Return(caller, nil).AnyTimes()
+
+		identity := getAWSIdentity(client, "osd-staging", "stage")
+		if identity.SessionName != "RH-SRE-jdoe" {
+			t.Errorf("unexpected session name %q", identity.SessionName)
+		}
+		expected := []string{
+			"arn:aws:iam::111111111111:role/RH-SRE-CCS-Access",
+			"arn:aws:iam::222222222222:role/RH-Technical-Support-Access",
+			"the support role of the cluster account",
+		}
+		if !reflect.DeepEqual(identity.RoleChain, expected) {
+			t.Errorf("expected %v, got %v", expected, identity.RoleChain)
+		}
+		if len(identity.Errors) != 0 {
+			t.Errorf("unexpected errors %v", identity.Errors)
+		}
+	})
+
+	t.Run("jump role account not configured", func(t *testing.T) {
+		client := awsmock.NewMockClient(gomock.NewController(t))
+		client.EXPECT().GetCallerIdentity(gomock.Any()).Return(caller, nil).AnyTimes()
+
+		identity := getAWSIdentity(client, "", "production")
+		if len(identity.RoleChain) != 1 || len(identity.Errors) != 1 || !strings.Contains(identity.Errors[0], osdCloud.ProdJumproleConfigKey) {
+			t.Errorf("expected only the CCS access role and a config error, got %v and %v", identity.RoleChain, identity.Errors)
+		}
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		client := awsmock.NewMockClient(gomock.NewController(t))
+		client.EXPECT().GetCallerIdentity(gomock.Any()).Return(nil, errors.New("ExpiredToken"))
+
+		identity := getAWSIdentity(client, "", "production")
+		if identity.ARN != "" || len(identity.Errors) != 1 || !strings.Contains(identity.Errors[0], "ExpiredToken") {
+			t.Errorf("expected the caller identity error, got %+v", identity)
+		}
+	})
+}
+
+func TestWhoamiResponseString(t *testing.T) {
+	resp := whoamiResponse{
+		OCM: ocmIdentity{URL: "https://api.openshift.com", Environment: "production", Username: "jdoe", AccountID: "abc",
+			Roles: []string{}, Errors: []string{"can't list the role bindings: forbidden"}},
+		AWS: awsIdentity{RoleChain: []string{"arn:aws:iam::111111111111:role/RH-SRE-CCS-Access"}},
+	}
+	out := resp.String()
+	for _, expected := range []string{"jdoe (abc)", "Roles", "default", "Assumed role 1", "OCM error", "forbidden"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in:\n%s", expected, out)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package workflow
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	outputflag "github.com/openshift/osdctl/cmd/getoutput"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/openshift/osdctl/pkg/catalog"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/poll"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/openshift/osdctl/pkg/workflow"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const workflowLong = `Run a workflow: a sequence of osdctl commands encoding an SOP, e.g. verify the egress, then remove the limited
+support, then post a service log.
+
+A step runs once the steps it needs succeeded, and once its check, a read-only command run until it succeeds or its
+output contains the expected text, passed. The steps whose needs failed are skipped. Before a step changing something,
+the workflow asks for a confirmation, unless the step sets confirm to never, or --yes is given; confirm: always asks
+before a read-only step too. The commands of the steps still go through their own confirmations and guardrails.
+
+  name: lift-egress-limited-support
+  params:
+  - name: CLUSTER_ID
+    required: true
+  steps:
+  - name: verify-egress
+    run: network verify-egress --cluster-id ${CLUSTER_ID}
+  - name: remove-limited-support
+    needs: [verify-egress]
+    run: cluster support delete ${CLUSTER_ID} --matching egress
+  - name: post-service-log
+    needs: [remove-limited-support]
+    run: servicelog post ${CLUSTER_ID} -t https://example.com/egress-restored.json
+    confirm: always
+    check:
+      run: cluster health -C ${CLUSTER_ID}
+      timeout: 15m
+      interval: 1m`
+
+const workflowExample = `
+  # Show the steps of a workflow, in the order they run, without running them
+  osdctl workflow run lift-egress-limited-support.yaml -p CLUSTER_ID=1kfmyclusteristhebesteverp8m --dry-run
+
+  # Run a workflow
+  osdctl workflow run lift-egress-limited-support.yaml -p CLUSTER_ID=1kfmyclusteristhebesteverp8m`
+
+const (
+	statusSucceeded = "succeeded"
+	statusFailed    = "failed"
+	statusSkipped   = "skipped"
+	statusDeclined  = "declined"
+	statusPlanned   = "planned"
+)
+
+type runOptions struct {
+	params []string
+	yes    bool
+	dryRun bool
+
+	workflow *workflow.Workflow
+	steps    []plannedStep
+
+	// execute runs osdctl with the arguments, writing its output to out. Swapped in tests.
+	execute       func(ctx context.Context, args []string, out io.Writer) error
+	in            io.Reader
+	out           io.Writer
+	GlobalOptions *globalflags.GlobalOptions
+}
+
+// plannedStep is a step with its commands expanded and looked up
+type plannedStep struct {
+	workflow.Step
+	args      []string
+	checkArgs []string
+	mutating  bool
+}
+
+// NewCmdWorkflow implements the workflow command
+func NewCmdWorkflow(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	workflowCmd := &cobra.Command{
+		Use:               "workflow",
+		Short:             "Run sequences of osdctl commands encoding an SOP",
+		Long:              workflowLong,
+		Example:           workflowExample,
+		Args:              cobra.NoArgs,
+		DisableAutoGenTag: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+	workflowCmd.AddCommand(newCmdRun(globalOpts))
+	return workflowCmd
+}
+
+func newCmdRun(globalOpts *globalflags.GlobalOptions) *cobra.Command {
+	ops := &runOptions{in: os.Stdin, out: os.Stderr, GlobalOptions: globalOpts}
+	runCmd := &cobra.Command{
+		Use:               "run WORKFLOW_FILE",
+		Short:             "Run the steps of a workflow file, in the order of their dependencies",
+		Long:              workflowLong,
+		Example:           workflowExample,
+		Args:              cobra.ExactArgs(1),
+		DisableAutoGenTag: true,
+		// The steps can change anything, they go through their own confirmation and guardrails
+		Annotations: map[string]string{catalog.MutationAnnotation: catalog.MutationMutating},
+		Run: func(cmd *cobra.Command, args []string) {
+			osdctlErrors.CheckErr(ops.complete(cmd, args))
+			osdctlErrors.CheckErr(ops.run())
+		},
+	}
+	runCmd.Flags().StringArrayVarP(&ops.params, "param", "p", nil, "Set a parameter of the workflow, as NAME=VALUE; can be repeated")
+	runCmd.Flags().BoolVar(&ops.yes, "yes", false, "Don't ask before the steps, the commands of the steps may still ask")
+	runCmd.Flags().BoolVar(&ops.dryRun, "dry-run", false, "Show the steps in the order they would run, without running them")
+
+	return runCmd
+}
+
+func (o *runOptions) complete(cmd *cobra.Command, args []string) error {
+	given := map[string]string{}
+	for _, param := range o.params {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok || name == "" {
+			return cmdutil.UsageErrorf(cmd, "invalid --param '%s', expected NAME=VALUE", param)
+		}
+		given[name] = value
+	}
+
+	w, err := workflow.Load(args[0])
+	if err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "%v", err)
+	}
+	values, err := w.Values(given)
+	if err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "%v", err)
+	}
+	steps, err := plan(cmd.Root(), w, values)
+	if err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "%v", err)
+	}
+	o.workflow, o.steps = w, steps
+
+	if o.execute == nil {
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("cannot find the osdctl binary: %w", err)
+		}
+		o.execute = executeCommand(executable)
+	}
+	return nil
+}
+
+// plan orders the steps and looks their commands up before anything runs, so that a typo in the last step doesn't
+// leave the cluster half way through the SOP
+func plan(root *cobra.Command, w *workflow.Workflow, values map[string]string) ([]plannedStep, error) {
+	ordered, err := w.Order()
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]plannedStep, 0, len(ordered))
+	for _, step := range ordered {
+		planned := plannedStep{Step: step}
+		if step.Run != "" {
+			if planned.args, err = workflow.Expand(step.Run, values); err != nil {
+				return nil, fmt.Errorf("step '%s': %w", step.Name, err)
+			}
+			command, err := lookup(root, planned.args)
+			if err != nil {
+				return nil, fmt.Errorf("step '%s': %w", step.Name, err)
+			}
+			mutation, _ := catalog.Mutation(command)
+			planned.mutating = mutation == catalog.MutationMutating
+		}
+		if step.Check != nil {
+			if planned.checkArgs, err = workflow.Expand(step.Check.Run, values); err != nil {
+				return nil, fmt.Errorf("step '%s': check: %w", step.Name, err)
+			}
+			command, err := lookup(root, planned.checkArgs)
+			if err != nil {
+				return nil, fmt.Errorf("step '%s': check: %w", step.Name, err)
+			}
+			// The check runs again and again, it mustn't change anything
+			if mutation, _ := catalog.Mutation(command); mutation != catalog.MutationReadOnly {
+				return nil, fmt.Errorf("step '%s': the check runs 'osdctl %s', which isn't read-only", step.Name, strings.Join(planned.checkArgs, " "))
+			}
+		}
+		steps = append(steps, planned)
+	}
+	return steps, nil
+}
+
+// lookup returns the osdctl command the arguments run
+func lookup(root *cobra.Command, args []string) (*cobra.Command, error) {
+	command, _, err := root.Find(args)
+	if err != nil || command == root || !command.Runnable() || command.HasSubCommands() {
+		return nil, fmt.Errorf("'osdctl %s' isn't an osdctl command", strings.Join(args, " "))
+	}
+	for parent := command; parent != nil; parent = parent.Parent() {
+		if parent.Name() == "workflow" && parent.Parent() == root {
+			return nil, fmt.Errorf("a workflow can't run another workflow")
+		}
+	}
+	return command, nil
+}
+
+type stepResult struct {
+	Step     string `json:"step"`
+	Command  string `json:"command,omitempty"`
+	Check    string `json:"check,omitempty"`
+	Status   string `json:"status"`
+	Duration string `json:"duration,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+type runResponse struct {
+	Workflow string       `json:"workflow"`
+	DryRun   bool         `json:"dry_run"`
+	Steps    []stepResult `json:"steps"`
+}
+
+func (r runResponse) String() string {
+	var b strings.Builder
+	table := printer.NewTablePrinter(&b, 20, 1, 3, ' ')
+	table.AddRow([]string{"Step", "Status", "Duration", "Command", "Detail"})
+	for _, step := range r.Steps {
+		command := step.Command
+		if step.Check != "" {
+			command = strings.TrimSpace(command + " (check: " + step.Check + ")")
+		}
+		table.AddRow([]string{step.Step, step.Status, step.Duration, command, step.Detail})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	_ = table.Flush()
+	return b.String()
+}
+
+func (o *runOptions) run() error {
+	// Confirm reuses a bufio.Reader, the answers to the next prompts aren't lost when piped
+	o.in = bufio.NewReader(o.in)
+	response := runResponse{Workflow: o.workflow.Name, DryRun: o.dryRun}
+	status := map[string]string{}
+	failed := 0
+	for _, step := range o.steps {
+		result := stepResult{Step: step.Name, Command: strings.Join(step.args, " "), Check: strings.Join(step.checkArgs, " ")}
+		if o.dryRun {
+			result.Status = statusPlanned
+			if o.confirms(step) {
+				result.Detail = "asks for a confirmation"
+			}
+			response.Steps = append(response.Steps, result)
+			continue
+		}
+
+		start := time.Now()
+		result.Status, result.Detail = o.runStep(step, status)
+		if result.Status != statusSkipped && result.Status != statusDeclined {
+			result.Duration = time.Since(start).Round(time.Second).String()
+		}
+		if result.Status == statusFailed {
+			failed++
+		}
+		status[step.Name] = result.Status
+		response.Steps = append(response.Steps, result)
+	}
+
+	if err := outputflag.PrintResponse(o.GlobalOptions.Output, response); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of the %d steps of workflow '%s' failed", failed, len(o.steps), o.workflow.Name)
+	}
+	return nil
+}
+
+// runStep runs the step once the steps it needs succeeded, returning its status and why it didn't succeed
+func (o *runOptions) runStep(step plannedStep, status map[string]string) (string, string) {
+	for _, need := range step.Needs {
+		if status[need] != statusSucceeded {
+			return statusSkipped, fmt.Sprintf("step '%s' %s", need, status[need])
+		}
+	}
+
+	if step.Check != nil {
+		fmt.Fprintf(o.out, "Step %s: checking 'osdctl %s'\n", step.Name, strings.Join(step.checkArgs, " "))
+		if err := o.check(step); err != nil {
+			return statusFailed, err.Error()
+		}
+	}
+	if step.Run == "" {
+		return statusSucceeded, ""
+	}
+
+	if o.confirms(step) {
+		fmt.Fprintf(o.out, "Step %s runs 'osdctl %s'\n", step.Name, strings.Join(step.args, " "))
+		if err := utils.Confirm(utils.ConfirmOptions{In: o.in, Out: o.out}); err != nil {
+			return statusDeclined, "not confirmed"
+		}
+	} else {
+		fmt.Fprintf(o.out, "Step %s: running 'osdctl %s'\n", step.Name, strings.Join(step.args, " "))
+	}
+	if err := o.execute(context.Background(), step.args, os.Stdout); err != nil {
+		return statusFailed, err.Error()
+	}
+	return statusSucceeded, ""
+}
+
+// confirms returns whether the workflow asks before running the step
+func (o *runOptions) confirms(step plannedStep) bool {
+	if o.yes || step.Run == "" {
+		return false
+	}
+	switch step.Confirm {
+	case workflow.ConfirmAlways:
+		return true
+	case workflow.ConfirmNever:
+		return false
+	default:
+		return step.mutating
+	}
+}
+
+// check runs the check of the step until it passes
+func (o *runOptions) check(step plannedStep) error {
+	timeout, interval := step.Check.Durations()
+	return poll.Until(context.Background(), poll.Options{
+		Description: fmt.Sprintf("the check of step %s", step.Name),
+		Timeout:     timeout,
+		Interval:    interval,
+		Progress:    o.out,
+	}, func(ctx context.Context) (bool, string, error) {
+		var output bytes.Buffer
+		if err := o.execute(ctx, step.checkArgs, &output); err != nil {
+			if ctx.Err() != nil {
+				return false, "", ctx.Err()
+			}
+			return false, lastLine(output.String(), err.Error()), nil
+		}
+		if step.Check.Contains != "" && !strings.Contains(output.String(), step.Check.Contains) {
+			return false, fmt.Sprintf("the output doesn't contain '%s' yet", step.Check.Contains), nil
+		}
+		return true, "", nil
+	})
+}
+
+// executeCommand runs osdctl again, so that every step records itself in the history and goes through the
+// confirmation and guardrails of its command
+func executeCommand(executable string) func(ctx context.Context, args []string, out io.Writer) error {
+	return func(ctx context.Context, args []string, out io.Writer) error {
+		cmd := exec.CommandContext(ctx, executable, args...) //#nosec G204 -- the workflow is the user's own
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = out
+		cmd.Stderr = os.Stderr
+		if out != os.Stdout {
+			cmd.Stdin = nil
+			cmd.Stderr = out
+		}
+		return cmd.Run()
+	}
+}
+
+// lastLine returns the last non-empty line of the output, the error of the command is usually there
+func lastLine(output, fallback string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if line := strings.TrimSpace(lines[len(lines)-1]); line != "" {
+		return line
+	}
+	return fallback
+}
@@ -0,0 +1,174 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/internal/utils/globalflags"
+	"github.com/spf13/cobra"
+)
+
+func newRoot() *cobra.Command {
+	noop := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "osdctl"}
+	cluster := &cobra.Command{Use: "cluster", Run: noop}
+	support := &cobra.Command{Use: "support", Run: noop}
+	support.AddCommand(&cobra.Command{Use: "delete", Run: noop})
+	cluster.AddCommand(support, &cobra.Command{Use: "health", Run: noop}, &cobra.Command{Use: "describe", Run: noop})
+	root.AddCommand(cluster, NewCmdWorkflow(&globalflags.GlobalOptions{}))
+	return root
+}
+
+func writeWorkflow(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "workflow.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testWorkflow = `
+name: test
+params:
+- name: CLUSTER_ID
+  required: true
+steps:
+- name: remove
+  needs: [describe]
+  run: cluster support delete ${CLUSTER_ID}
+  check:
+    run: cluster health ${CLUSTER_ID}
+    contains: healthy
+    interval: 1ms
+- name: describe
+  run: cluster describe ${CLUSTER_ID}
+- name: describe-again
+  needs: [remove]
+  run: cluster describe ${CLUSTER_ID}
+  confirm: always
+`
+
+// fakeOSDCTL records the commands run, the health check passes the second time
+type fakeOSDCTL struct {
+	calls  []string
+	checks int
+	fail   string
+}
+
+func (f *fakeOSDCTL) execute(_ context.Context, args []string, out io.Writer) error {
+	command := strings.Join(args, " ")
+	f.calls = append(f.calls, command)
+	if command == f.fail {
+		return errors.New("exit status 1")
+	}
+	if args[1] == "health" {
+		f.checks++
+		if f.checks == 1 {
+			_, _ = io.WriteString(out, "degraded\n")
+			return nil
+		}
+		_, _ = io.WriteString(out, "healthy\n")
+	}
+	return nil
+}
+
+func newTestOptions(t *testing.T, fake *fakeOSDCTL, input string, flags ...string) (*runOptions, error) {
+	root := newRoot()
+	runCmd, _, err := root.Find([]string{"workflow", "run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := &runOptions{execute: fake.execute, in: strings.NewReader(input), out: &bytes.Buffer{}, GlobalOptions: &globalflags.GlobalOptions{}}
+	o.params = flags
+	return o, o.complete(runCmd, []string{writeWorkflow(t, testWorkflow)})
+}
+
+func TestRunWorkflow(t *testing.T) {
+	g := NewGomegaWithT(t)
+	fake := &fakeOSDCTL{}
+	// The delete is mutating and asks, the last step always asks
+	o, err := newTestOptions(t, fake, "y\ny\n", "CLUSTER_ID=abc")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(o.run()).To(Succeed())
+	g.Expect(fake.calls).To(Equal([]string{
+		"cluster describe abc",
+		"cluster health abc",
+		"cluster health abc",
+		"cluster support delete abc",
+		"cluster describe abc",
+	}))
+	g.Expect(o.out.(*bytes.Buffer).String()).To(ContainSubstring("the output doesn't contain 'healthy' yet"))
+}
+
+func TestRunWorkflowSkipsAfterFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+	fake := &fakeOSDCTL{fail: "cluster describe abc"}
+	o, err := newTestOptions(t, fake, "", "CLUSTER_ID=abc")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	err = o.run()
+	g.Expect(err).To(MatchError(ContainSubstring("1 of the 3 steps")))
+	g.Expect(fake.calls).To(Equal([]string{"cluster describe abc"}))
+}
+
+func TestRunWorkflowDeclined(t *testing.T) {
+	g := NewGomegaWithT(t)
+	fake := &fakeOSDCTL{}
+	o, err := newTestOptions(t, fake, "n\n", "CLUSTER_ID=abc")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(o.run()).To(Succeed())
+	g.Expect(fake.calls).NotTo(ContainElement("cluster support delete abc"))
+	g.Expect(fake.calls).To(HaveLen(3))
+}
+
+func TestRunWorkflowYesAndDryRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+	fake := &fakeOSDCTL{}
+	o, err := newTestOptions(t, fake, "", "CLUSTER_ID=abc")
+	g.Expect(err).NotTo(HaveOccurred())
+	o.yes = true
+	g.Expect(o.run()).To(Succeed())
+	g.Expect(fake.calls).To(HaveLen(5))
+
+	fake = &fakeOSDCTL{}
+	o, err = newTestOptions(t, fake, "", "CLUSTER_ID=abc")
+	g.Expect(err).NotTo(HaveOccurred())
+	o.dryRun = true
+	g.Expect(o.run()).To(Succeed())
+	g.Expect(fake.calls).To(BeEmpty())
+}
+
+func TestPlanRejectsInvalidSteps(t *testing.T) {
+	root := newRoot()
+	for _, tc := range []struct {
+		title    string
+		workflow string
+		expected string
+	}{
+		{"unknown command", "steps:\n- name: a\n  run: cluster frobnicate", "isn't an osdctl command"},
+		{"group command", "steps:\n- name: a\n  run: cluster support", "isn't an osdctl command"},
+		{"nested workflow", "steps:\n- name: a\n  run: workflow run other.yaml", "can't run another workflow"},
+		{"mutating check", "steps:\n- name: a\n  check:\n    run: cluster support delete abc", "isn't read-only"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			runCmd, _, _ := root.Find([]string{"workflow", "run"})
+			o := &runOptions{execute: (&fakeOSDCTL{}).execute, GlobalOptions: &globalflags.GlobalOptions{}}
+			err := o.complete(runCmd, []string{writeWorkflow(t, tc.workflow)})
+			g.Expect(err).To(MatchError(ContainSubstring(tc.expected)))
+		})
+	}
+
+	g := NewGomegaWithT(t)
+	_, err := newTestOptions(t, &fakeOSDCTL{}, "")
+	g.Expect(err).To(MatchError(ContainSubstring("CLUSTER_ID")))
+}
@@ -0,0 +1,357 @@
+package support
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The kinds of templates which have a schema
+const (
+	KindLimitedSupport = "limited-support"
+	KindServiceLog     = "service-log"
+)
+
+//go:embed schemas/limited_support_reason.json
+var limitedSupportSchema []byte
+
+//go:embed schemas/service_log.json
+var serviceLogSchema []byte
+
+var schemas = map[string][]byte{
+	KindLimitedSupport: limitedSupportSchema,
+	KindServiceLog:     serviceLogSchema,
+}
+
+// Schema is the subset of JSON schema the templates are described with
+type Schema struct {
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	// AnyOf is satisfied when one of the alternatives is, they are named by their description in the errors
+	AnyOf     []*Schema `json:"anyOf,omitempty"`
+	Items     *Schema   `json:"items,omitempty"`
+	Enum      []string  `json:"enum,omitempty"`
+	MinLength int       `json:"minLength,omitempty"`
+}
+
+// ValidationError is a problem of a template, at the line and column it was found at
+type ValidationError struct {
+	Line   int
+	Column int
+	// Path is the field the problem is about, e.g. doc_references[1], empty for the template itself
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// ValidationErrors are all the problems of a template, in the order of the file
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Kinds returns the kinds of templates which have a schema, sorted
+func Kinds() []string {
+	kinds := make([]string, 0, len(schemas))
+	for kind := range schemas {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// LoadSchema returns the schema of a kind of template
+func LoadSchema(kind string) (*Schema, error) {
+	data, ok := schemas[kind]
+	if !ok {
+		return nil, fmt.Errorf("no schema for '%s' templates, expected one of: %s", kind, strings.Join(Kinds(), ", "))
+	}
+	schema := &Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("invalid %s schema: %w", kind, err)
+	}
+	return schema, nil
+}
+
+// DetectKind tells a limited support reason, which has details, from a service log, which has a description
+func DetectKind(data []byte) string {
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(data, &fields)
+	for _, field := range []string{"details", "detection_type", "template_id"} {
+		if _, ok := fields[field]; ok {
+			return KindLimitedSupport
+		}
+	}
+	return KindServiceLog
+}
+
+// Validate checks the template against the schema of its kind. The problems are returned as ValidationErrors.
+// The enum and length checks are skipped for the values holding ${...} parameters, which are only resolved when
+// the template is posted.
+func Validate(kind string, data []byte) error {
+	schema, err := LoadSchema(kind)
+	if err != nil {
+		return err
+	}
+	root, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+
+	v := &validator{data: data}
+	v.validate(schema, root, "")
+	if len(v.errors) == 0 {
+		return nil
+	}
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		a, b := v.errors[i], v.errors[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return v.errors
+}
+
+// jsonNode is a JSON value with the offset it starts at
+type jsonNode struct {
+	offset int
+	kind   string
+	value  interface{}
+	fields []jsonField
+	items  []*jsonNode
+}
+
+type jsonField struct {
+	key    string
+	offset int
+	node   *jsonNode
+}
+
+type jsonParser struct {
+	data    []byte
+	decoder *json.Decoder
+}
+
+// parseJSON parses a single JSON value, keeping the offsets the JSON decoder loses
+func parseJSON(data []byte) (*jsonNode, error) {
+	p := &jsonParser{data: data, decoder: json.NewDecoder(bytes.NewReader(data))}
+	p.decoder.UseNumber()
+	token, offset, err := p.next()
+	if err != nil {
+		return nil, p.syntaxError(err)
+	}
+	root, err := p.parse(token, offset)
+	if err != nil {
+		return nil, p.syntaxError(err)
+	}
+	if _, offset, err := p.next(); err != io.EOF {
+		line, column := lineColumn(data, offset)
+		return nil, ValidationErrors{{Line: line, Column: column, Message: "expected a single JSON value"}}
+	}
+	return root, nil
+}
+
+// next returns the next token and the offset it starts at, the decoder only tells where the previous one ended
+func (p *jsonParser) next() (json.Token, int, error) {
+	offset := int(p.decoder.InputOffset())
+	for offset < len(p.data) && strings.IndexByte(" \t\r\n,:", p.data[offset]) >= 0 {
+		offset++
+	}
+	token, err := p.decoder.Token()
+	return token, offset, err
+}
+
+func (p *jsonParser) parse(token json.Token, offset int) (*jsonNode, error) {
+	node := &jsonNode{offset: offset, value: token}
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			node.kind = "object"
+			for p.decoder.More() {
+				key, keyOffset, err := p.next()
+				if err != nil {
+					return nil, err
+				}
+				token, valueOffset, err := p.next()
+				if err != nil {
+					return nil, err
+				}
+				value, err := p.parse(token, valueOffset)
+				if err != nil {
+					return nil, err
+				}
+				node.fields = append(node.fields, jsonField{key: key.(string), offset: keyOffset, node: value})
+			}
+		} else {
+			node.kind = "array"
+			for p.decoder.More() {
+				token, itemOffset, err := p.next()
+				if err != nil {
+					return nil, err
+				}
+				item, err := p.parse(token, itemOffset)
+				if err != nil {
+					return nil, err
+				}
+				node.items = append(node.items, item)
+			}
+		}
+		// The closing delimiter
+		if _, err := p.decoder.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.kind = "string"
+	case json.Number:
+		node.kind = "number"
+	case bool:
+		node.kind = "boolean"
+	case nil:
+		node.kind = "null"
+	}
+	return node, nil
+}
+
+// syntaxError locates the error of the JSON decoder, at the end of the file when it ended too early
+func (p *jsonParser) syntaxError(err error) error {
+	offset := len(p.data)
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = int(syntaxErr.Offset)
+		// The offending character is the last one read, unless the file ended
+		if offset > 0 && !strings.HasPrefix(syntaxErr.Error(), "unexpected end") {
+			offset--
+		}
+	}
+	if err == io.EOF {
+		err = errors.New("the file is empty")
+	}
+	line, column := lineColumn(p.data, offset)
+	return ValidationErrors{{Line: line, Column: column, Message: "invalid JSON: " + err.Error()}}
+}
+
+// lineColumn returns the line and column of the offset, both starting at 1
+func lineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+type validator struct {
+	data   []byte
+	errors ValidationErrors
+}
+
+func (v *validator) fail(offset int, path, format string, args ...interface{}) {
+	line, column := lineColumn(v.data, offset)
+	v.errors = append(v.errors, ValidationError{Line: line, Column: column, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(schema *Schema, node *jsonNode, path string) {
+	if schema.Type != "" && schema.Type != node.kind {
+		v.fail(node.offset, path, "expected %s, got %s", withArticle(schema.Type), withArticle(node.kind))
+		return
+	}
+
+	switch node.kind {
+	case "object":
+		present := map[string]bool{}
+		for _, field := range node.fields {
+			present[field.key] = true
+			fieldPath := field.key
+			if path != "" {
+				fieldPath = path + "." + field.key
+			}
+			property, ok := schema.Properties[field.key]
+			switch {
+			case ok:
+				v.validate(property, field.node, fieldPath)
+			case schema.AdditionalProperties != nil && !*schema.AdditionalProperties:
+				v.fail(field.offset, fieldPath, "unknown field, expected one of: %s", strings.Join(schema.propertyNames(), ", "))
+			}
+		}
+		for _, required := range schema.Required {
+			if !present[required] {
+				v.fail(node.offset, path, "missing required field '%s'", required)
+			}
+		}
+	case "array":
+		if schema.Items != nil {
+			for i, item := range node.items {
+				v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case "string":
+		value := node.value.(string)
+		if strings.Contains(value, "${") {
+			break
+		}
+		if schema.MinLength > 0 && len(strings.TrimSpace(value)) < schema.MinLength {
+			v.fail(node.offset, path, "can't be empty")
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, value) {
+			v.fail(node.offset, path, "'%s' isn't one of: %s", value, strings.Join(schema.Enum, ", "))
+		}
+	}
+
+	if len(schema.AnyOf) > 0 {
+		var alternatives []string
+		for _, alternative := range schema.AnyOf {
+			check := &validator{data: v.data}
+			check.validate(alternative, node, path)
+			if len(check.errors) == 0 {
+				return
+			}
+			alternatives = append(alternatives, alternative.Description)
+		}
+		v.fail(node.offset, path, "expected %s", strings.Join(alternatives, " or "))
+	}
+}
+
+func (s *Schema) propertyNames() []string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withArticle returns the JSON type with its indefinite article, e.g. an object
+func withArticle(kind string) string {
+	if strings.IndexByte("aeiou", kind[0]) >= 0 {
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package support
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateServiceLog(t *testing.T) {
+	template := `{
+  "severity": "info",
+  "service_name": "SREManualAction",
+  "sumary": "Cluster upgrade paused",
+  "description": "The upgrade to ${VERSION} was paused",
+  "internal_only": "false",
+  "doc_references": ["https://docs.openshift.com", 3]
+}`
+	err := Validate(KindServiceLog, []byte(template))
+	var problems ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("expected validation errors, got %v", err)
+	}
+	expected := []string{
+		"line 1, column 1: missing required field 'summary'",
+		"line 2, column 15: severity: 'info' isn't one of: Debug, Info, Warning, Error, Fatal",
+		"line 4, column 3: sumary: unknown field, expected one of: _tags, cluster_id, cluster_uuid, description, doc_references, event_stream_id, internal_only, log_type, service_name, severity, subscription_id, summary",
+		"line 6, column 20: internal_only: expected a boolean, got a string",
+		"line 7, column 52: doc_references[1]: expected a string, got a number",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %d:\n%v", len(expected), len(problems), problems)
+	}
+	for i, problem := range problems {
+		if problem.Error() != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], problem.Error())
+		}
+	}
+}
+
+func TestValidateLimitedSupport(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		template string
+		problem  string
+	}{
+		{name: "valid", template: `{"summary": "Cluster admin misuse", "details": "Restore ${RESOURCE}", "detection_type": "manual"}`},
+		{name: "template id", template: `{"template_id": "lsrt-1234"}`},
+		{name: "no details", template: `{"summary": "Cluster admin misuse", "details": " "}`,
+			problem: "line 1, column 48: details: can't be empty"},
+		{name: "no summary nor template", template: `{"details": "Restore the monitoring"}`,
+			problem: "line 1, column 1: expected a 'template_id' or a 'summary' and 'details'"},
+		{name: "not an object", template: `["summary"]`, problem: "line 1, column 1: expected an object, got an array"},
+		{name: "syntax error", template: "{\n  \"summary\": \"a\"\n  \"details\": \"b\"\n}",
+			problem: "line 3, column 3: invalid JSON: invalid character '\"' after object key:value pair"},
+		{name: "truncated", template: `{"summary": "a"`, problem: "line 1, column 16: invalid JSON: unexpected end of JSON input"},
+		{name: "trailing value", template: `{"template_id": "lsrt-1234"} {}`, problem: "line 1, column 30: expected a single JSON value"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(KindLimitedSupport, []byte(tc.template))
+			if tc.problem == "" {
+				if err != nil {
+					t.Fatalf("expected no problem, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.problem) {
+				t.Fatalf("expected %q, got %v", tc.problem, err)
+			}
+		})
+	}
+}
+
+func TestDetectKind(t *testing.T) {
+	if kind := DetectKind([]byte(`{"summary": "a", "details": "b"}`)); kind != KindLimitedSupport {
+		t.Errorf("expected %s, got %s", KindLimitedSupport, kind)
+	}
+	if kind := DetectKind([]byte(`{"summary": "a", "description": "b"}`)); kind != KindServiceLog {
+		t.Errorf("expected %s, got %s", KindServiceLog, kind)
+	}
+	if _, err := LoadSchema("cluster"); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
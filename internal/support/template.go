@@ -1,6 +1,7 @@
 package support
 
 import (
+	"regexp"
 	"strings"
 	"time"
 )
@@ -59,3 +60,10 @@ func (l *LimitedSupport) SearchFlag(placeholder string) (found bool) {
 	}
 	return false
 }
+
+// FindLeftovers returns the ${...} placeholders left in the summary and details
+func (l *LimitedSupport) FindLeftovers() (matches []string, found bool) {
+	r := regexp.MustCompile(`\${[^{}]*}`)
+	matches = r.FindAllString(l.Summary+l.Details, -1)
+	return matches, len(matches) > 0
+}
@@ -58,7 +58,7 @@ func CreateFile(filepath string) error {
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Println("Error closing file", filepath)
+			fmt.Fprintln(os.Stderr, "Error closing file", filepath)
 		}
 	}()
 
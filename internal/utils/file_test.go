@@ -2,12 +2,13 @@ package utils
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+
+	log "github.com/sirupsen/logrus"
 )
 
 var (
@@ -3,6 +3,20 @@ package globalflags
 import (
 	"flag"
 
+	"github.com/openshift/osdctl/pkg/artifacts"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/guardrails"
+	"github.com/openshift/osdctl/pkg/logging"
+	"github.com/openshift/osdctl/pkg/paging"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/ratelimit"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/responsecache"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/openshift/osdctl/pkg/trace"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/openshift/osdctl/pkg/visibility"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/utils/pointer"
@@ -16,9 +30,32 @@ type GlobalOptions struct {
 
 // AddGlobalFlags adds the Global Flags to the root command
 func AddGlobalFlags(cmd *cobra.Command, opts *GlobalOptions) {
-	cmd.PersistentFlags().AddGoFlagSet(flag.CommandLine)
-	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", "", "Valid formats are ['', 'json', 'yaml', 'env']")
+	// -v is provided by the logging package, which also passes the verbosity on to klog
+	goFlags := flag.NewFlagSet("osdctl", flag.ContinueOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		if f.Name != logging.VerbosityFlag {
+			goFlags.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	cmd.PersistentFlags().AddGoFlagSet(goFlags)
+	logging.AddFlags(cmd)
+	cmd.PersistentFlags().StringVarP(&opts.Output, "output", "o", "", "Valid formats are ['', 'json', 'yaml', 'env', 'go-template=...', 'go-template-file=...', 'jsonpath=...', 'jsonpath-file=...']")
 	cmd.PersistentFlags().BoolVarP(&opts.SkipVersionCheck, "skip-version-check", "S", false, "skip checking to see if this is the most recent release")
+	printer.AddOutputFileFlag(cmd)
+	printer.AddFilterFlag(cmd)
+	artifacts.AddFlags(cmd)
+	deadline.AddFlags(cmd)
+	guardrails.AddFlags(cmd)
+	paging.AddFlags(cmd)
+	ratelimit.AddFlags(cmd)
+	readonly.AddFlags(cmd)
+	recording.AddFlags(cmd)
+	responsecache.AddFlags(cmd)
+	timefmt.AddFlags(cmd)
+	trace.AddFlags(cmd)
+	utils.AddClusterCacheFlags(cmd)
+	utils.AddConfirmFlags(cmd)
+	visibility.AddFlags(cmd)
 }
 
 // GetFlags adds the kubeFlags we care about and adds the flags from the provided command
@@ -5,7 +5,16 @@ import (
 	"os"
 
 	"github.com/openshift/osdctl/cmd"
+	"github.com/openshift/osdctl/pkg/alias"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/history"
 	"github.com/openshift/osdctl/pkg/osdctlConfig"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/plugin"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/telemetry"
+	"github.com/openshift/osdctl/pkg/trace"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
@@ -20,11 +29,26 @@ func main() {
 
 	command := cmd.NewCmdRoot(genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr})
 
-	if err := command.Execute(); err != nil {
-		_, err := fmt.Fprintf(os.Stderr, "%v\n", err)
-		if err != nil {
-			fmt.Println("Error while printing to stderr: ", err.Error())
-		}
-		os.Exit(1)
+	// The aliases are expanded in os.Args, so that the history and the artifacts record the command which ran
+	args, err := alias.Expand(command, os.Args[1:], alias.Aliases())
+	osdctlErrors.CheckErr(err)
+	os.Args = append(os.Args[:1], args...)
+
+	// Only returns when no plugin matches the arguments
+	osdctlErrors.CheckErr(plugin.HandlePluginCommand(command, os.Args[1:]))
+
+	err = command.Execute()
+	telemetry.Finish(err)
+	history.Finish(err)
+	if closeErr := printer.CloseOutputFile(); closeErr != nil {
+		fmt.Fprintf(os.Stderr, "Cannot close the output file: %v\n", closeErr)
+	}
+	if closeErr := recording.Close(); closeErr != nil {
+		fmt.Fprintf(os.Stderr, "Cannot save the recorded session: %v\n", closeErr)
+	}
+	if closeErr := trace.Close(); closeErr != nil {
+		fmt.Fprintf(os.Stderr, "Cannot close the trace file: %v\n", closeErr)
 	}
+	deadline.Stop()
+	osdctlErrors.CheckErr(err)
 }
@@ -0,0 +1,162 @@
+// Package alias expands the aliases of the config file before the command line is parsed, so that a team can encode
+// its conventions, e.g. 'osdctl lsdel' for 'osdctl cluster support delete --yes'.
+package alias
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ConfigKey maps the alias names to the commands they expand to, without the leading osdctl
+const ConfigKey = "aliases"
+
+// maxDepth bounds the aliases expanding to other aliases
+const maxDepth = 10
+
+// placeholder is a positional argument of a macro, $1 is the first argument given after the alias
+var placeholder = regexp.MustCompile(`\$([1-9])`)
+
+// Aliases returns the aliases of the config file, by name
+func Aliases() map[string]string {
+	return viper.GetStringMapString(ConfigKey)
+}
+
+// Names returns the names of the aliases, sorted
+func Names(aliases map[string]string) []string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Expand replaces the alias the arguments start with by its command. The $1 to $9 placeholders of the command take
+// the arguments given after the alias, the other arguments are appended. An alias can expand to another alias, but
+// never shadows an osdctl command: the command wins and a warning is printed.
+func Expand(root *cobra.Command, args []string, aliases map[string]string) ([]string, error) {
+	seen := map[string]bool{}
+	for depth := 0; len(args) > 0; depth++ {
+		// viper lowercases the keys of the config file
+		name := strings.ToLower(args[0])
+		expansion, ok := aliases[name]
+		if !ok {
+			return args, nil
+		}
+		if isCommand(root, name) {
+			fmt.Fprintf(os.Stderr, "Warning: alias '%s' shadows the osdctl command of the same name, it is ignored\n", name)
+			return args, nil
+		}
+		if seen[name] || depth >= maxDepth {
+			return nil, fmt.Errorf("alias '%s' expands to itself", name)
+		}
+		seen[name] = true
+
+		expanded, err := expandOne(name, expansion, args[1:])
+		if err != nil {
+			return nil, err
+		}
+		args = expanded
+	}
+	return args, nil
+}
+
+func expandOne(name, expansion string, given []string) ([]string, error) {
+	words, err := Split(expansion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alias '%s': %w", name, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("alias '%s' is empty", name)
+	}
+
+	used := 0
+	var expanded []string
+	for _, word := range words {
+		var missing int
+		word = placeholder.ReplaceAllStringFunc(word, func(match string) string {
+			index, _ := strconv.Atoi(match[1:])
+			if index > used {
+				used = index
+			}
+			if index > len(given) {
+				missing = index
+				return match
+			}
+			return given[index-1]
+		})
+		if missing > 0 {
+			return nil, fmt.Errorf("alias '%s' expands to '%s' and needs at least %d arguments, got %d", name, expansion, missing, len(given))
+		}
+		expanded = append(expanded, word)
+	}
+	return append(expanded, given[used:]...), nil
+}
+
+// isCommand returns true when name is a subcommand of the root command, or one cobra adds when executed
+func isCommand(root *cobra.Command, name string) bool {
+	if name == "help" || name == cobra.ShellCompRequestCmd || name == cobra.ShellCompNoDescRequestCmd {
+		return true
+	}
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == name || cmd.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Split splits the command of an alias into words like a shell does, the words can be quoted with ' or " and the
+// characters escaped with \, e.g. servicelog post -p 'SUMMARY=Node replaced' splits into 4 words
+func Split(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in '%s'", quote, command)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing \\ in '%s'", command)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,138 @@
+package alias
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newRoot() *cobra.Command {
+	root := &cobra.Command{Use: "osdctl"}
+	root.AddCommand(&cobra.Command{Use: "cluster", Aliases: []string{"c"}, Run: func(*cobra.Command, []string) {}})
+	return root
+}
+
+func TestExpand(t *testing.T) {
+	aliases := map[string]string{
+		"lsdel":  "cluster support delete --yes",
+		"ready":  `cluster list --search "state='ready'"`,
+		"slpost": "servicelog post $1 -t https://example.com/$2.json",
+		"del":    "lsdel --reason test",
+		"c":      "cluster list",
+	}
+
+	for _, tc := range []struct {
+		title    string
+		args     []string
+		expected []string
+	}{
+		{
+			title:    "no alias",
+			args:     []string{"cluster", "list"},
+			expected: []string{"cluster", "list"},
+		},
+		{
+			title:    "the arguments are appended",
+			args:     []string{"lsdel", "1abc", "--dry-run"},
+			expected: []string{"cluster", "support", "delete", "--yes", "1abc", "--dry-run"},
+		},
+		{
+			title:    "quoted words",
+			args:     []string{"ready"},
+			expected: []string{"cluster", "list", "--search", "state='ready'"},
+		},
+		{
+			title:    "placeholders take the first arguments",
+			args:     []string{"slpost", "1abc", "node-replaced", "--dry-run"},
+			expected: []string{"servicelog", "post", "1abc", "-t", "https://example.com/node-replaced.json", "--dry-run"},
+		},
+		{
+			title:    "alias of an alias",
+			args:     []string{"del", "1abc"},
+			expected: []string{"cluster", "support", "delete", "--yes", "--reason", "test", "1abc"},
+		},
+		{
+			title:    "the names are case insensitive",
+			args:     []string{"LsDel"},
+			expected: []string{"cluster", "support", "delete", "--yes"},
+		},
+		{
+			title:    "an alias never shadows a command",
+			args:     []string{"c", "describe"},
+			expected: []string{"c", "describe"},
+		},
+		{
+			title:    "flags before the command aren't aliases",
+			args:     []string{"--help", "lsdel"},
+			expected: []string{"--help", "lsdel"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			got, err := Expand(newRoot(), tc.args, aliases)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestExpandErrors(t *testing.T) {
+	for _, tc := range []struct {
+		title    string
+		aliases  map[string]string
+		args     []string
+		expected string
+	}{
+		{
+			title:    "loop",
+			aliases:  map[string]string{"a": "b --x", "b": "a --y"},
+			args:     []string{"a"},
+			expected: "alias 'a' expands to itself",
+		},
+		{
+			title:    "missing argument",
+			aliases:  map[string]string{"slpost": "servicelog post $1 -t $2"},
+			args:     []string{"slpost", "1abc"},
+			expected: "needs at least 2 arguments, got 1",
+		},
+		{
+			title:    "unterminated quote",
+			aliases:  map[string]string{"ready": `cluster list --search "state='ready'`},
+			args:     []string{"ready"},
+			expected: "invalid alias 'ready': unterminated \" quote",
+		},
+		{
+			title:    "empty",
+			aliases:  map[string]string{"nothing": "  "},
+			args:     []string{"nothing"},
+			expected: "alias 'nothing' is empty",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := Expand(newRoot(), tc.args, tc.aliases)
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected an error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	got, err := Split(`servicelog post -p 'SUMMARY=Node replaced' -p "DESCRIPTION=it's \"done\"" a\ b`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"servicelog", "post", "-p", "SUMMARY=Node replaced", "-p", `DESCRIPTION=it's "done"`, "a b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if got, _ := Split(`--search ''`); !reflect.DeepEqual(got, []string{"--search", ""}) {
+		t.Errorf("expected the empty quoted word to be kept, got %q", got)
+	}
+}
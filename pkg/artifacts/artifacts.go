@@ -0,0 +1,143 @@
+// Package artifacts keeps the files osdctl writes for a cluster, e.g. exports, flow logs and kubeconfigs, under
+// <artifacts_dir>/<cluster-id>/<timestamp>/ with an index, so that the evidence of an incident stays together.
+package artifacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigKey makes the commands writing files for a cluster write them to its artifacts directory when true
+	ConfigKey = "artifacts"
+	Flag      = "artifacts"
+	// DirConfigKey is the directory of the artifacts of every cluster, ~/osdctl by default
+	DirConfigKey = "artifacts_dir"
+
+	// IndexFile lists the artifacts of a cluster, one JSON entry per line, oldest first
+	IndexFile = "index.jsonl"
+
+	timestampFormat = "20060102T150405Z"
+)
+
+// Entry is an artifact of the index
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command"`
+	// Path is relative to the directory of the cluster, e.g. 20240102T150405Z/cluster.yaml
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
+}
+
+// Swapped in tests
+var nowFunc = time.Now
+
+var (
+	mu sync.Mutex
+	// runDirs are the directories of the current command, by cluster, so that all its files land in the same one
+	runDirs = map[string]string{}
+)
+
+// AddFlags adds the --artifacts flag to the given command and binds it to the config key
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(Flag, false, "Write the files of the commands producing them, e.g. exports or kubeconfigs, to <"+DirConfigKey+">/<cluster-id>/<timestamp>/ (config key: "+ConfigKey+")")
+	_ = viper.BindPFlag(ConfigKey, cmd.PersistentFlags().Lookup(Flag))
+}
+
+// Enabled returns true when the artifacts are organized by cluster
+func Enabled() bool {
+	return viper.GetBool(ConfigKey)
+}
+
+// BaseDir returns the directory of the artifacts of every cluster
+func BaseDir() (string, error) {
+	if dir := viper.GetString(DirConfigKey); dir != "" {
+		if strings.HasPrefix(dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, dir[2:])
+		}
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "osdctl"), nil
+}
+
+// Dir returns the directory of the current command for the cluster, creating it, only readable by the current user
+// as the artifacts can hold credentials
+func Dir(clusterID string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if dir, ok := runDirs[clusterID]; ok {
+		return dir, nil
+	}
+
+	base, err := BaseDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, clusterID, nowFunc().UTC().Format(timestampFormat))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("cannot create the artifacts directory %s: %w", dir, err)
+	}
+	runDirs[clusterID] = dir
+	return dir, nil
+}
+
+// Write writes the artifact to the directory of the current command for the cluster and adds it to the index,
+// returning its path
+func Write(clusterID, name string, content []byte, description string) (string, error) {
+	dir, err := Dir(clusterID)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return "", fmt.Errorf("cannot write %s: %w", path, err)
+	}
+	return path, Record(clusterID, path, description)
+}
+
+// Record adds a file written to the directory of the cluster to its index
+func Record(clusterID, path, description string) error {
+	base, err := BaseDir()
+	if err != nil {
+		return err
+	}
+	clusterDir := filepath.Join(base, clusterID)
+	relative, err := filepath.Rel(clusterDir, path)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Entry{
+		Timestamp:   nowFunc().UTC(),
+		Command:     strings.Join(append([]string{"osdctl"}, os.Args[1:]...), " "),
+		Path:        relative,
+		Description: description,
+	})
+	if err != nil {
+		return err
+	}
+	indexPath := filepath.Join(clusterDir, IndexFile)
+	file, err := os.OpenFile(indexPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) //#nosec G304 -- path is configured by the user
+	if err != nil {
+		return fmt.Errorf("cannot open the artifacts index '%s': %w", indexPath, err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
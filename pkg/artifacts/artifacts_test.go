@@ -0,0 +1,59 @@
+package artifacts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+func TestWrite(t *testing.T) {
+	g := NewGomegaWithT(t)
+	base := t.TempDir()
+	viper.Set(DirConfigKey, base)
+	nowFunc = func() time.Time { return time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC) }
+	defer func() {
+		viper.Set(DirConfigKey, "")
+		nowFunc = time.Now
+		runDirs = map[string]string{}
+	}()
+
+	path, err := Write("2ab3cd", "kubeconfig", []byte("apiVersion: v1"), "cluster-admin kubeconfig")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(path).To(Equal(filepath.Join(base, "2ab3cd", "20240102T150405Z", "kubeconfig")))
+	info, err := os.Stat(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	// The files of the same command land in the same directory, even once the clock moved on
+	nowFunc = func() time.Time { return time.Date(2024, 1, 2, 15, 5, 0, 0, time.UTC) }
+	path, err = Write("2ab3cd", "flowlogs.json", []byte("{}"), "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(filepath.Dir(path)).To(Equal(filepath.Join(base, "2ab3cd", "20240102T150405Z")))
+
+	data, err := os.ReadFile(filepath.Join(base, "2ab3cd", IndexFile))
+	g.Expect(err).NotTo(HaveOccurred())
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	g.Expect(lines).To(HaveLen(2))
+	var entry Entry
+	g.Expect(json.Unmarshal([]byte(lines[0]), &entry)).To(Succeed())
+	g.Expect(entry.Path).To(Equal("20240102T150405Z/kubeconfig"))
+	g.Expect(entry.Description).To(Equal("cluster-admin kubeconfig"))
+	g.Expect(entry.Command).To(HavePrefix("osdctl"))
+}
+
+func TestBaseDir(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer viper.Set(DirConfigKey, "")
+	home, err := os.UserHomeDir()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(BaseDir()).To(Equal(filepath.Join(home, "osdctl")))
+	viper.Set(DirConfigKey, "~/incidents")
+	g.Expect(BaseDir()).To(Equal(filepath.Join(home, "incidents")))
+}
@@ -0,0 +1,198 @@
+// Package catalog describes the osdctl commands, their flags and arguments and whether they change anything,
+// so that automation can wrap osdctl without parsing its help
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// MutationAnnotation is set on the commands whose mutation can't be inferred, to MutationReadOnly or MutationMutating
+	MutationAnnotation = "osdctl.openshift.io/mutation"
+
+	MutationReadOnly = "read-only"
+	MutationMutating = "mutating"
+
+	// SourceAnnotation and SourceInferred tell where the mutation of a command comes from
+	SourceAnnotation = "annotation"
+	SourceInferred   = "inferred"
+)
+
+// mutatingFlags are the flags only commands changing something have, to skip their confirmation or preview the change
+var mutatingFlags = []string{"yes", "dry-run", "skip-prompts"}
+
+// mutatingVerbs are the command names, or their first word, of the commands changing something
+var mutatingVerbs = map[string]bool{
+	"add": true, "apply": true, "assign": true, "create": true, "delete": true, "drain": true, "edit": true,
+	"expire": true, "generate": true, "patch": true, "post": true, "promote": true, "reboot": true, "remove": true,
+	"reset": true, "resize": true, "restore": true, "revoke": true, "rotate": true, "set": true, "silence": true,
+	"transfer": true, "unassign": true, "upgrade": true,
+}
+
+// Catalog lists the commands of osdctl and the flags every command inherits
+type Catalog struct {
+	Version     string    `json:"version" yaml:"version"`
+	GlobalFlags []Flag    `json:"global_flags" yaml:"global_flags"`
+	Commands    []Command `json:"commands" yaml:"commands"`
+}
+
+// Command is a runnable command, the command groups are left out
+type Command struct {
+	Command        string     `json:"command" yaml:"command"`
+	Short          string     `json:"short" yaml:"short"`
+	Usage          string     `json:"usage" yaml:"usage"`
+	Aliases        []string   `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	Arguments      []Argument `json:"arguments" yaml:"arguments"`
+	Flags          []Flag     `json:"flags" yaml:"flags"`
+	Mutation       string     `json:"mutation" yaml:"mutation"`
+	MutationSource string     `json:"mutation_source" yaml:"mutation_source"`
+}
+
+// Argument is a positional argument, as named in the usage of the command
+type Argument struct {
+	Name     string `json:"name" yaml:"name"`
+	Required bool   `json:"required" yaml:"required"`
+	Repeated bool   `json:"repeated" yaml:"repeated"`
+}
+
+// Flag is a flag of a command
+type Flag struct {
+	Name      string `json:"name" yaml:"name"`
+	Shorthand string `json:"shorthand,omitempty" yaml:"shorthand,omitempty"`
+	Type      string `json:"type" yaml:"type"`
+	Default   string `json:"default,omitempty" yaml:"default,omitempty"`
+	Usage     string `json:"usage" yaml:"usage"`
+	Required  bool   `json:"required" yaml:"required"`
+}
+
+// Build walks the commands below root
+func Build(root *cobra.Command, version string) Catalog {
+	catalog := Catalog{
+		Version:     version,
+		GlobalFlags: flags(root.PersistentFlags()),
+		Commands:    []Command{},
+	}
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, child := range cmd.Commands() {
+			if !child.IsAvailableCommand() {
+				continue
+			}
+			if child.Runnable() && !isGroup(child) {
+				catalog.Commands = append(catalog.Commands, describe(child))
+			}
+			walk(child)
+		}
+	}
+	walk(root)
+	sort.Slice(catalog.Commands, func(i, j int) bool {
+		return catalog.Commands[i].Command < catalog.Commands[j].Command
+	})
+	return catalog
+}
+
+// isGroup returns true for the commands which only print their help, i.e. have subcommands and take no argument
+func isGroup(cmd *cobra.Command) bool {
+	return cmd.HasAvailableSubCommands() && len(arguments(cmd.Use)) == 0
+}
+
+func describe(cmd *cobra.Command) Command {
+	mutation, source := Mutation(cmd)
+	return Command{
+		Command:        cmd.CommandPath(),
+		Short:          cmd.Short,
+		Usage:          cmd.UseLine(),
+		Aliases:        cmd.Aliases,
+		Arguments:      arguments(cmd.Use),
+		Flags:          flags(cmd.NonInheritedFlags()),
+		Mutation:       mutation,
+		MutationSource: source,
+	}
+}
+
+// Mutation returns whether the command changes anything, from its annotation or inferred from its name and flags
+func Mutation(cmd *cobra.Command) (mutation string, source string) {
+	if value, ok := cmd.Annotations[MutationAnnotation]; ok {
+		return value, SourceAnnotation
+	}
+	for _, name := range mutatingFlags {
+		if cmd.NonInheritedFlags().Lookup(name) != nil {
+			return MutationMutating, SourceInferred
+		}
+	}
+	verb := strings.SplitN(cmd.Name(), "-", 2)[0]
+	if mutatingVerbs[cmd.Name()] || mutatingVerbs[verb] {
+		return MutationMutating, SourceInferred
+	}
+	return MutationReadOnly, SourceInferred
+}
+
+// arguments parses the positional arguments from the usage, e.g. 'post CLUSTER_ID' or 'reboot <cluster identifier> <node> [<node>...]'
+func arguments(use string) []Argument {
+	args := []Argument{}
+	fields := usageFields(use)
+	if len(fields) < 2 {
+		return args
+	}
+	for _, field := range fields[1:] {
+		argument := Argument{
+			Required: !strings.HasPrefix(field, "["),
+			Repeated: strings.HasSuffix(field, "...") || strings.HasSuffix(field, "...]"),
+		}
+		argument.Name = strings.Trim(field, "[]<>.")
+		if argument.Name == "" || argument.Name == "flags" || strings.HasPrefix(argument.Name, "-") {
+			continue
+		}
+		args = append(args, argument)
+	}
+	return args
+}
+
+// usageFields splits the usage on spaces, except within <...> and [...]
+func usageFields(use string) []string {
+	var fields []string
+	var field strings.Builder
+	depth := 0
+	for _, r := range use {
+		switch {
+		case r == '<' || r == '[':
+			depth++
+		case (r == '>' || r == ']') && depth > 0:
+			depth--
+		case r == ' ' && depth == 0:
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			continue
+		}
+		field.WriteRune(r)
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+func flags(set *pflag.FlagSet) []Flag {
+	result := []Flag{}
+	set.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Deprecated != "" {
+			return
+		}
+		_, required := f.Annotations[cobra.BashCompOneRequiredFlag]
+		result = append(result, Flag{
+			Name:      f.Name,
+			Shorthand: f.Shorthand,
+			Type:      f.Value.Type(),
+			Default:   f.DefValue,
+			Usage:     f.Usage,
+			Required:  required,
+		})
+	})
+	return result
+}
@@ -0,0 +1,72 @@
+package catalog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func noop(*cobra.Command, []string) {}
+
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "osdctl"}
+	root.PersistentFlags().StringP("output", "o", "", "Output format")
+
+	cluster := &cobra.Command{Use: "cluster"}
+	root.AddCommand(cluster)
+
+	post := &cobra.Command{Use: "post CLUSTER_ID", Short: "Post a reason", Run: noop}
+	post.Flags().BoolP("yes", "y", false, "Skip the prompt")
+	post.Flags().String("template", "", "Template")
+	_ = post.MarkFlagRequired("template")
+
+	reboot := &cobra.Command{Use: "reboot <cluster identifier> <node> [<node>...]", Run: noop}
+	egress := &cobra.Command{Use: "verify-egress", Run: noop, Annotations: map[string]string{MutationAnnotation: MutationMutating}}
+	health := &cobra.Command{Use: "health [flags]", Run: noop}
+	hidden := &cobra.Command{Use: "docs", Hidden: true, Run: noop}
+	cluster.AddCommand(post, reboot, egress, health)
+	root.AddCommand(hidden)
+
+	return root
+}
+
+func TestBuild(t *testing.T) {
+	catalog := Build(testRoot(), "1.2.3")
+
+	var names []string
+	for _, command := range catalog.Commands {
+		names = append(names, command.Command)
+	}
+	expected := []string{"osdctl cluster health", "osdctl cluster post", "osdctl cluster reboot", "osdctl cluster verify-egress"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected the runnable visible commands %v, got %v", expected, names)
+	}
+	if len(catalog.GlobalFlags) != 1 || catalog.GlobalFlags[0].Name != "output" || catalog.GlobalFlags[0].Shorthand != "o" {
+		t.Errorf("unexpected global flags %+v", catalog.GlobalFlags)
+	}
+
+	post := catalog.Commands[1]
+	if post.Mutation != MutationMutating || post.MutationSource != SourceInferred {
+		t.Errorf("expected post to be inferred as mutating, got %s (%s)", post.Mutation, post.MutationSource)
+	}
+	if !reflect.DeepEqual(post.Arguments, []Argument{{Name: "CLUSTER_ID", Required: true}}) {
+		t.Errorf("unexpected arguments %+v", post.Arguments)
+	}
+	if len(post.Flags) != 2 || post.Flags[0].Name != "template" || !post.Flags[0].Required || post.Flags[1].Required {
+		t.Errorf("unexpected flags %+v", post.Flags)
+	}
+
+	reboot := catalog.Commands[2]
+	expectedArgs := []Argument{{Name: "cluster identifier", Required: true}, {Name: "node", Required: true}, {Name: "node", Repeated: true}}
+	if !reflect.DeepEqual(reboot.Arguments, expectedArgs) {
+		t.Errorf("unexpected arguments %+v", reboot.Arguments)
+	}
+
+	if egress := catalog.Commands[3]; egress.Mutation != MutationMutating || egress.MutationSource != SourceAnnotation {
+		t.Errorf("expected the annotation to be used, got %s (%s)", egress.Mutation, egress.MutationSource)
+	}
+	if health := catalog.Commands[0]; health.Mutation != MutationReadOnly || len(health.Arguments) != 0 {
+		t.Errorf("expected health to be read-only without arguments, got %+v", health)
+	}
+}
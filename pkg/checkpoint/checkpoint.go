@@ -0,0 +1,214 @@
+// Package checkpoint saves the progress of batch commands, so that an interrupted run resumes where it
+// left off instead of processing, or posting to, the same items again.
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// TTLConfigKey is how long a checkpoint of an unfinished run is resumed
+	TTLConfigKey = "checkpoint_ttl"
+
+	StatusDone   = "done"
+	StatusFailed = "failed"
+
+	defaultTTL = 7 * 24 * time.Hour
+)
+
+func init() {
+	viper.SetDefault(TTLConfigKey, defaultTTL.String())
+}
+
+// Item is the outcome of processing one item of the batch
+type Item struct {
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// Checkpoint is the saved progress of a batch run, indexed by item, e.g. cluster ID
+type Checkpoint struct {
+	Command   string           `json:"command"`
+	Inputs    []string         `json:"inputs"`
+	StartedAt time.Time        `json:"started_at"`
+	Items     map[string]*Item `json:"items"`
+
+	path    string
+	resumed bool
+	mu      sync.Mutex
+}
+
+// Flags are the checkpoint flags shared by the batch commands
+type Flags struct {
+	Path    string
+	Restart bool
+}
+
+// AddFlags adds --checkpoint and --restart to a batch command
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.Path, "checkpoint", "", "File the progress is saved to (default: derived from the command inputs, in the user cache dir)")
+	cmd.Flags().BoolVar(&f.Restart, "restart", false, "Discard the progress saved by an interrupted run and start over")
+}
+
+// Open loads the checkpoint of the command for the given inputs, or starts a new one. Runs with the same
+// inputs, e.g. the same template and parameters, share a checkpoint.
+func (f *Flags) Open(command string, inputs ...string) (*Checkpoint, error) {
+	path := f.Path
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(command, inputs...); err != nil {
+			return nil, err
+		}
+	}
+	if f.Restart {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("cannot discard the checkpoint '%s': %w", path, err)
+		}
+	}
+	return load(path, command, inputs, ttl())
+}
+
+// DefaultPath returns the checkpoint location for the command and inputs, in the user cache dir
+func DefaultPath(command string, inputs ...string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(command + "\x00" + strings.Join(inputs, "\x00")))
+	name := fmt.Sprintf("%s-%s.json", strings.ReplaceAll(command, " ", "-"), hex.EncodeToString(sum[:])[:16])
+	return filepath.Join(cacheDir, "osdctl", "checkpoints", name), nil
+}
+
+func ttl() time.Duration {
+	ttl, err := time.ParseDuration(viper.GetString(TTLConfigKey))
+	if err != nil {
+		return defaultTTL
+	}
+	return ttl
+}
+
+func load(path, command string, inputs []string, ttl time.Duration) (*Checkpoint, error) {
+	fresh := &Checkpoint{
+		Command:   command,
+		Inputs:    inputs,
+		StartedAt: time.Now().UTC(),
+		Items:     map[string]*Item{},
+		path:      path,
+	}
+
+	data, err := os.ReadFile(path) //#nosec G304 -- path is derived from the user cache dir or given by the user
+	if errors.Is(err, os.ErrNotExist) {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the checkpoint '%s': %w", path, err)
+	}
+
+	saved := &Checkpoint{}
+	if err := json.Unmarshal(data, saved); err != nil {
+		return nil, fmt.Errorf("cannot parse the checkpoint '%s', remove it or pass --restart: %w", path, err)
+	}
+	if saved.Command != command || strings.Join(saved.Inputs, "\x00") != strings.Join(inputs, "\x00") {
+		return nil, fmt.Errorf("the checkpoint '%s' belongs to another run of '%s', remove it or pass --restart", path, saved.Command)
+	}
+	if time.Since(saved.StartedAt) > ttl {
+		return fresh, nil
+	}
+
+	if saved.Items == nil {
+		saved.Items = map[string]*Item{}
+	}
+	saved.path = path
+	saved.resumed = len(saved.Items) > 0
+	return saved, nil
+}
+
+// Path returns where the checkpoint is saved
+func (c *Checkpoint) Path() string {
+	return c.path
+}
+
+// Resumed reports whether a previous run had already processed some items
+func (c *Checkpoint) Resumed() bool {
+	return c.resumed
+}
+
+// Done reports whether the item was processed successfully by this or a previous run
+func (c *Checkpoint) Done(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.Items[id]
+	return ok && item.Status == StatusDone
+}
+
+// Get returns the outcome of the item, if it was processed
+func (c *Checkpoint) Get(id string) (*Item, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.Items[id]
+	return item, ok
+}
+
+// Pending returns the ids that weren't processed successfully yet, keeping their order
+func (c *Checkpoint) Pending(ids []string) []string {
+	var pending []string
+	for _, id := range ids {
+		if !c.Done(id) {
+			pending = append(pending, id)
+		}
+	}
+	return pending
+}
+
+// Record saves the outcome of processing the item, failed items are retried by the next run
+func (c *Checkpoint) Record(id string, err error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item := &Item{Status: StatusDone, Time: time.Now().UTC()}
+	if err != nil {
+		item.Status = StatusFailed
+		item.Error = err.Error()
+	}
+	c.Items[id] = item
+	return c.save()
+}
+
+// Complete removes the checkpoint once every item was processed, so that the next run starts over
+func (c *Checkpoint) Complete() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// save writes to a temporary file first, so that an interruption never leaves the checkpoint truncated
+func (c *Checkpoint) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,78 @@
+package checkpoint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	g := NewGomegaWithT(t)
+	flags := Flags{Path: filepath.Join(t.TempDir(), "checkpoints", "run.json")}
+	ids := []string{"abc", "def", "ghi"}
+
+	progress, err := flags.Open("servicelog post", "template", "query")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(progress.Resumed()).To(BeFalse())
+	g.Expect(progress.Pending(ids)).To(Equal(ids))
+
+	g.Expect(progress.Record("abc", nil)).To(Succeed())
+	g.Expect(progress.Record("def", errors.New("cluster not found"))).To(Succeed())
+	info, err := os.Stat(flags.Path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	// An interrupted run is resumed, failed items are retried
+	resumed, err := flags.Open("servicelog post", "template", "query")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(resumed.Resumed()).To(BeTrue())
+	g.Expect(resumed.Pending(ids)).To(Equal([]string{"def", "ghi"}))
+	item, ok := resumed.Get("def")
+	g.Expect(ok).To(BeTrue())
+	g.Expect(item.Status).To(Equal(StatusFailed))
+	g.Expect(item.Error).To(Equal("cluster not found"))
+
+	// Other inputs must not reuse the checkpoint
+	_, err = flags.Open("servicelog post", "other template", "query")
+	g.Expect(err).To(MatchError(ContainSubstring("belongs to another run")))
+
+	restart := Flags{Path: flags.Path, Restart: true}
+	restarted, err := restart.Open("servicelog post", "other template", "query")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(restarted.Pending(ids)).To(Equal(ids))
+
+	g.Expect(resumed.Complete()).To(Succeed())
+	g.Expect(flags.Path).NotTo(BeAnExistingFile())
+}
+
+func TestCheckpointExpires(t *testing.T) {
+	g := NewGomegaWithT(t)
+	path := filepath.Join(t.TempDir(), "run.json")
+
+	progress, err := load(path, "servicelog campaign", []string{"list.txt"}, time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	progress.StartedAt = time.Now().Add(-2 * time.Hour)
+	g.Expect(progress.Record("abc", nil)).To(Succeed())
+
+	expired, err := load(path, "servicelog campaign", []string{"list.txt"}, time.Hour)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(expired.Resumed()).To(BeFalse())
+	g.Expect(expired.Done("abc")).To(BeFalse())
+}
+
+func TestDefaultPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+	t.Setenv("XDG_CACHE_HOME", "/tmp/cache")
+	t.Setenv("HOME", "/tmp/home")
+
+	path, err := DefaultPath("servicelog campaign", "list.txt", "template")
+	g.Expect(err).NotTo(HaveOccurred())
+	other, err := DefaultPath("servicelog campaign", "list.txt", "other")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(path).NotTo(Equal(other))
+	g.Expect(filepath.Base(path)).To(MatchRegexp(`^servicelog-campaign-[0-9a-f]{16}\.json$`))
+}
@@ -0,0 +1,183 @@
+// Package deadline holds the context shared by the OCM, AWS and Kubernetes requests of a command, so that
+// --timeout bounds the whole command and Ctrl-C cancels the requests in flight instead of leaving them hanging.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigKey is the time after which the requests of a command are canceled. 0 disables the timeout.
+	ConfigKey = "command_timeout"
+	Flag      = "timeout"
+)
+
+// Output is where the interruption is reported, it is a variable for tests
+var Output io.Writer = os.Stderr
+
+var (
+	mu          sync.Mutex
+	ctx         = context.Background()
+	cancel      context.CancelFunc
+	timeout     time.Duration
+	interrupted bool
+	signals     chan os.Signal
+)
+
+// AddFlags adds the --timeout flag to the given command and binds it to the config key, so that the flag
+// takes precedence over the config file. The commands with their own --timeout flag keep it, the config
+// key still applies to them.
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Duration(Flag, 0, "Cancel the OCM, AWS and Kubernetes requests of the command after this long, e.g. 2m, 0 to disable (config key: "+ConfigKey+")")
+	_ = viper.BindPFlag(ConfigKey, cmd.PersistentFlags().Lookup(Flag))
+}
+
+// Start creates the shared context with the configured timeout and cancels it on Ctrl-C or SIGTERM. A second
+// signal isn't caught anymore, it stops osdctl right away.
+func Start() {
+	mu.Lock()
+	defer mu.Unlock()
+	if cancel != nil {
+		return
+	}
+
+	timeout = viper.GetDuration(ConfigKey)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	signals = make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func(done <-chan struct{}, signals chan os.Signal) {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			fmt.Fprintln(Output, "Interrupted, canceling the requests in flight. Press Ctrl-C again to quit right away.")
+			mu.Lock()
+			interrupted = true
+			cancel()
+			mu.Unlock()
+		case <-done:
+			signal.Stop(signals)
+		}
+	}(ctx.Done(), signals)
+
+	osdctlErrors.AddTranslator(Explain)
+}
+
+// Stop cancels the shared context and stops catching the signals
+func Stop() {
+	mu.Lock()
+	defer mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	signal.Stop(signals)
+	cancel()
+}
+
+// Context returns the context shared by the requests of the command, it is never canceled before Start
+func Context() context.Context {
+	mu.Lock()
+	defer mu.Unlock()
+	return ctx
+}
+
+// Explain returns the error of a command failing because its context was canceled with a message telling
+// why, the other errors are returned as is
+func Explain(err error) error {
+	if err == nil {
+		return nil
+	}
+	mu.Lock()
+	ctxErr, wasInterrupted, limit := ctx.Err(), interrupted, timeout
+	mu.Unlock()
+
+	switch {
+	case wasInterrupted:
+		return fmt.Errorf("interrupted: %w", err)
+	case errors.Is(ctxErr, context.DeadlineExceeded):
+		return osdctlErrors.New(osdctlErrors.ErrTransient, "timed out after %s (--%s): %w", limit, Flag, err)
+	}
+	return err
+}
+
+// merged has the values of a request's own context and the deadline and cancellation of the shared one
+type merged struct {
+	context.Context
+	values context.Context
+}
+
+func (m merged) Value(key interface{}) interface{} {
+	return m.values.Value(key)
+}
+
+// withShared returns the context a request is sent with, and false when it is the request's own. The contexts
+// the callers can't cancel, like the context.Background() the OCM SDK's Send and the AWS SDK's methods without
+// WithContext use, get the shared deadline and cancellation, the others are kept.
+func withShared(requestCtx context.Context) (context.Context, bool) {
+	shared := Context()
+	if shared.Done() == nil {
+		return requestCtx, false
+	}
+	if requestCtx == nil {
+		return shared, true
+	}
+	if requestCtx.Done() != nil {
+		return requestCtx, false
+	}
+	return merged{Context: shared, values: requestCtx}, true
+}
+
+type transport struct {
+	wrapped http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if requestCtx, replaced := withShared(req.Context()); replaced {
+		req = req.WithContext(requestCtx)
+	}
+	return t.wrapped.RoundTrip(req)
+}
+
+// OCMTransportWrapper returns a wrapper suitable for sdk.ConnectionBuilder.TransportWrapper
+// that sends the OCM requests with the shared context
+func OCMTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	return &transport{wrapped: wrapped}
+}
+
+// KubeTransportWrapper returns a wrapper suitable for rest.Config.WrapTransport that sends the Kubernetes
+// requests with the shared context
+func KubeTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	return &transport{wrapped: wrapped}
+}
+
+// AttachToAWSSession makes every request sent through the session use the shared context, including the
+// waits between its retries
+func AttachToAWSSession(sess *session.Session) {
+	sess.Handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "osdctl.deadline",
+		Fn: func(r *request.Request) {
+			if requestCtx, replaced := withShared(r.Context()); replaced {
+				r.SetContext(requestCtx)
+			}
+		},
+	})
+}
@@ -0,0 +1,138 @@
+package deadline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/viper"
+)
+
+// start starts the shared context with the timeout and resets it when the test ends
+func start(t *testing.T, limit time.Duration) *bytes.Buffer {
+	out := &bytes.Buffer{}
+	previous := Output
+	Output = out
+	viper.Set(ConfigKey, limit)
+	Start()
+	t.Cleanup(func() {
+		Stop()
+		mu.Lock()
+		ctx, cancel, timeout, interrupted = context.Background(), nil, 0, false
+		mu.Unlock()
+		viper.Set(ConfigKey, 0)
+		Output = previous
+	})
+	return out
+}
+
+// hangingServer answers once the test ends, like an API endpoint that doesn't answer
+func hangingServer(t *testing.T) *httptest.Server {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return server
+}
+
+func TestTransportTimesOut(t *testing.T) {
+	start(t, 100*time.Millisecond)
+	server := hangingServer(t)
+	client := &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}
+
+	_, err := client.Get(server.URL + "/api/clusters_mgmt/v1/clusters")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to time out, got %v", err)
+	}
+	err = Explain(err)
+	if !errors.Is(err, osdctlErrors.ErrTransient) || !strings.Contains(err.Error(), "timed out after 100ms (--timeout)") {
+		t.Errorf("expected a transient timeout error, got %v", err)
+	}
+}
+
+func TestTransportKeepsCallerContext(t *testing.T) {
+	start(t, 100*time.Millisecond)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}
+
+	// The callers passing their own context decide when it is canceled
+	callerCtx, cancelCaller := context.WithCancel(context.Background())
+	defer cancelCaller()
+	req, _ := http.NewRequestWithContext(callerCtx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected the request to be sent: %v", err)
+	}
+	resp.Body.Close()
+
+	// The values of the contexts the callers can't cancel are kept
+	type key struct{}
+	requestCtx, replaced := withShared(context.WithValue(context.Background(), key{}, "value"))
+	if !replaced || requestCtx.Value(key{}) != "value" || requestCtx.Done() == nil {
+		t.Errorf("expected the shared context with the request's values, got %v", requestCtx)
+	}
+}
+
+func TestAWSRequestTimesOut(t *testing.T) {
+	start(t, 100*time.Millisecond)
+	server := hangingServer(t)
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	AttachToAWSSession(sess)
+
+	_, err := ec2.New(sess).DescribeInstances(&ec2.DescribeInstancesInput{})
+	if err == nil {
+		t.Fatal("expected the request to time out")
+	}
+	if err = Explain(err); !errors.Is(err, osdctlErrors.ErrTransient) {
+		t.Errorf("expected a transient timeout error, got %v", err)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	out := start(t, 0)
+	if err := Explain(errors.New("boom")); err.Error() != "boom" {
+		t.Errorf("expected the error to be kept before the interruption, got %v", err)
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-Context().Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Ctrl-C to cancel the shared context")
+	}
+
+	if err := Explain(context.Canceled); !strings.HasPrefix(err.Error(), "interrupted: ") {
+		t.Errorf("expected the error to tell the command was interrupted, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Interrupted") {
+		t.Errorf("expected the interruption to be reported, got %q", out.String())
+	}
+}
@@ -0,0 +1,170 @@
+// Package deprecation surfaces the deprecation and sunset headers of the OCM responses, so that the users learn about
+// the breaking API changes before they land. Every deprecated endpoint is only reported once per command.
+package deprecation
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DeprecationHeader is true, the date the endpoint was deprecated on, or @ followed by a unix timestamp
+	DeprecationHeader = "Deprecation"
+	// SunsetHeader is the date the endpoint stops responding
+	SunsetHeader = "Sunset"
+	// WarningHeader carries the deprecation warnings of the API servers, with the 299 code
+	WarningHeader = "Warning"
+)
+
+// Output is where the warnings are printed, it is a variable for tests
+var Output io.Writer = os.Stderr
+
+var (
+	mu     sync.Mutex
+	warned = map[string]bool{}
+)
+
+// idSegment matches the path segments holding an ID, so that the endpoint of every cluster is reported once
+var idSegment = regexp.MustCompile(`^[0-9a-zA-Z]{20,}$`)
+
+// linkRelation matches the links to the documentation of a deprecation or a sunset
+var linkRelation = regexp.MustCompile(`<([^>]+)>\s*;[^,]*rel="?(deprecation|sunset)"?`)
+
+// Notice is the deprecation of an endpoint, as told by the headers of a response
+type Notice struct {
+	Endpoint string
+	// Deprecated is the date the endpoint was deprecated on, zero when the response doesn't tell
+	Deprecated time.Time
+	// Sunset is the date the endpoint stops responding, zero when the response doesn't tell
+	Sunset  time.Time
+	Link    string
+	Warning string
+}
+
+func (n Notice) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Warning: the OCM endpoint %s is deprecated", n.Endpoint)
+	if !n.Deprecated.IsZero() {
+		fmt.Fprintf(&b, " since %s", n.Deprecated.UTC().Format("2006-01-02"))
+	}
+	if !n.Sunset.IsZero() {
+		fmt.Fprintf(&b, " and will be removed on %s", n.Sunset.UTC().Format("2006-01-02"))
+	}
+	if n.Warning != "" {
+		fmt.Fprintf(&b, ": %s", n.Warning)
+	}
+	if n.Link != "" {
+		fmt.Fprintf(&b, " (see %s)", n.Link)
+	}
+	b.WriteString(", please update osdctl or report it if the latest version is affected\n")
+	return b.String()
+}
+
+// Parse returns the deprecation notice of the response, false when the endpoint isn't deprecated
+func Parse(resp *http.Response) (Notice, bool) {
+	notice := Notice{}
+	deprecation := strings.TrimSpace(resp.Header.Get(DeprecationHeader))
+	sunset := strings.TrimSpace(resp.Header.Get(SunsetHeader))
+	notice.Warning = deprecationWarning(resp.Header.Values(WarningHeader))
+	if (deprecation == "" || strings.EqualFold(deprecation, "false")) && sunset == "" && notice.Warning == "" {
+		return notice, false
+	}
+
+	notice.Endpoint = endpoint(resp.Request)
+	notice.Deprecated = parseDate(deprecation)
+	notice.Sunset = parseDate(sunset)
+	for _, link := range resp.Header.Values("Link") {
+		if match := linkRelation.FindStringSubmatch(link); match != nil {
+			notice.Link = match[1]
+			break
+		}
+	}
+	return notice, true
+}
+
+// parseDate parses the HTTP date of the Sunset and of the former Deprecation headers, and the @ followed by a unix
+// timestamp of the current Deprecation header
+func parseDate(value string) time.Time {
+	if strings.HasPrefix(value, "@") {
+		if seconds, err := strconv.ParseInt(value[1:], 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC()
+		}
+		return time.Time{}
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return date
+	}
+	return time.Time{}
+}
+
+// deprecationWarning returns the text of the first 299 warning, e.g. 299 - "v1 is deprecated, use v2"
+func deprecationWarning(warnings []string) string {
+	for _, warning := range warnings {
+		code, text, ok := strings.Cut(strings.TrimSpace(warning), " ")
+		if !ok || code != "299" {
+			continue
+		}
+		// The agent, usually -, precedes the quoted text
+		if _, quoted, ok := strings.Cut(text, " "); ok {
+			text = quoted
+		}
+		if unquoted, err := strconv.Unquote(strings.TrimSpace(text)); err == nil {
+			return unquoted
+		}
+		return strings.Trim(strings.TrimSpace(text), `"`)
+	}
+	return ""
+}
+
+// endpoint returns the method and the path of the request, with the IDs replaced by {id}
+func endpoint(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return req.Method + " " + strings.Join(segments, "/")
+}
+
+// Report prints the notice, unless the endpoint was already reported
+func Report(notice Notice) {
+	mu.Lock()
+	defer mu.Unlock()
+	if warned[notice.Endpoint] {
+		return
+	}
+	warned[notice.Endpoint] = true
+	fmt.Fprint(Output, notice.String())
+}
+
+type transport struct {
+	wrapped http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.wrapped.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if notice, ok := Parse(resp); ok {
+		Report(notice)
+	}
+	return resp, nil
+}
+
+// OCMTransportWrapper returns a wrapper suitable for sdk.ConnectionBuilder.TransportWrapper
+// that reports the deprecated OCM endpoints
+func OCMTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	return &transport{wrapped: wrapped}
+}
@@ -0,0 +1,92 @@
+package deprecation
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func captureOutput(t *testing.T) *bytes.Buffer {
+	previous := Output
+	var b bytes.Buffer
+	Output = &b
+	mu.Lock()
+	warned = map[string]bool{}
+	mu.Unlock()
+	t.Cleanup(func() { Output = previous })
+	return &b
+}
+
+func TestTransportWarnsOncePerEndpoint(t *testing.T) {
+	output := captureOutput(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/clusters_mgmt/v1/clusters/") {
+			w.Header().Set(DeprecationHeader, "@1717200000")
+			w.Header().Set(SunsetHeader, "Wed, 01 Jan 2025 00:00:00 GMT")
+			w.Header().Add("Link", `<https://api.openshift.com/docs/deprecations>; rel="deprecation"`)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}
+
+	for _, path := range []string{
+		"/api/clusters_mgmt/v1/clusters/1kfmyclusteristhebesteverp8m",
+		"/api/clusters_mgmt/v1/clusters/2kfmyclusteristhebesteverp8m",
+		"/api/clusters_mgmt/v1/versions",
+	} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("expected the request to be sent: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	got := output.String()
+	if strings.Count(got, "Warning:") != 1 {
+		t.Fatalf("expected a single warning for the clusters endpoint, got %q", got)
+	}
+	for _, expected := range []string{"GET /api/clusters_mgmt/v1/clusters/{id}", "since 2024-06-01", "removed on 2025-01-01", "https://api.openshift.com/docs/deprecations"} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("expected %q in the warning, got %q", expected, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "https://api.openshift.com/api/accounts_mgmt/v1/current_account", nil)
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		deprecated bool
+		sunset     time.Time
+		warning    string
+	}{
+		{name: "no header"},
+		{name: "not deprecated", headers: map[string]string{DeprecationHeader: "false"}},
+		{name: "deprecated without a date", headers: map[string]string{DeprecationHeader: "true"}, deprecated: true},
+		{name: "sunset only", headers: map[string]string{SunsetHeader: "Sat, 01 Mar 2025 00:00:00 GMT"}, deprecated: true, sunset: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "deprecation warning", headers: map[string]string{WarningHeader: `299 - "current_account is deprecated, use the account of the token"`}, deprecated: true, warning: "current_account is deprecated, use the account of the token"},
+		{name: "other warning", headers: map[string]string{WarningHeader: `199 - "miscellaneous"`}},
+	}
+	for _, test := range tests {
+		resp := &http.Response{Header: http.Header{}, Request: request}
+		for key, value := range test.headers {
+			resp.Header.Set(key, value)
+		}
+		notice, deprecated := Parse(resp)
+		if deprecated != test.deprecated {
+			t.Errorf("%s: expected deprecated to be %t", test.name, test.deprecated)
+			continue
+		}
+		if !notice.Sunset.Equal(test.sunset) || notice.Warning != test.warning {
+			t.Errorf("%s: unexpected notice %+v", test.name, notice)
+		}
+		if deprecated && notice.Endpoint != "GET /api/accounts_mgmt/v1/current_account" {
+			t.Errorf("%s: unexpected endpoint %s", test.name, notice.Endpoint)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build boringcrypto
+
+package fips
+
+import (
+	"crypto/boring"
+	// Restricts TLS to the FIPS-approved versions, cipher suites and curves
+	_ "crypto/tls/fipsonly"
+)
+
+const (
+	module      = "boringcrypto"
+	tlsFIPSOnly = true
+)
+
+// Enabled returns true when the cryptography is done by the FIPS-validated BoringCrypto module
+func Enabled() bool {
+	return boring.Enabled()
+}
@@ -0,0 +1,40 @@
+// Package fips reports whether osdctl was built with FIPS-validated cryptography, see 'make build-fips'
+package fips
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/viper"
+)
+
+// RequireConfigKey makes osdctl refuse to run when it wasn't built with FIPS-validated cryptography
+const RequireConfigKey = "require_fips"
+
+// Crypto describes the cryptography osdctl was built with, as printed by 'osdctl version --crypto'
+type Crypto struct {
+	// Module is the cryptographic module: boringcrypto in FIPS builds, go otherwise
+	Module string `json:"module"`
+	FIPS   bool   `json:"fips"`
+	// TLSFIPSOnly is set when TLS is restricted to the FIPS-approved versions, cipher suites and curves
+	TLSFIPSOnly bool   `json:"tls_fips_only"`
+	GoVersion   string `json:"go_version"`
+}
+
+// Info returns the cryptography osdctl was built with
+func Info() Crypto {
+	return Crypto{
+		Module:      module,
+		FIPS:        Enabled(),
+		TLSFIPSOnly: tlsFIPSOnly,
+		GoVersion:   runtime.Version(),
+	}
+}
+
+// Require returns an error when the config requires FIPS-validated cryptography and osdctl wasn't built with it
+func Require() error {
+	if viper.GetBool(RequireConfigKey) && !Enabled() {
+		return fmt.Errorf("'%s' is set but this osdctl build doesn't use FIPS-validated cryptography, build it with 'make build-fips'", RequireConfigKey)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package fips
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestInfo(t *testing.T) {
+	info := Info()
+	if info.FIPS != Enabled() {
+		t.Errorf("Info().FIPS = %v, want %v", info.FIPS, Enabled())
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("Info().GoVersion = %q, want %q", info.GoVersion, runtime.Version())
+	}
+	if info.FIPS && info.Module != "boringcrypto" {
+		t.Errorf("Info().Module = %q, want boringcrypto in a FIPS build", info.Module)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	defer viper.Set(RequireConfigKey, false)
+
+	viper.Set(RequireConfigKey, false)
+	if err := Require(); err != nil {
+		t.Errorf("Require() = %v, want no error when FIPS isn't required", err)
+	}
+
+	viper.Set(RequireConfigKey, true)
+	err := Require()
+	if Enabled() && err != nil {
+		t.Errorf("Require() = %v, want no error in a FIPS build", err)
+	}
+	if !Enabled() && (err == nil || !strings.Contains(err.Error(), "make build-fips")) {
+		t.Errorf("Require() = %v, want an error pointing at 'make build-fips'", err)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !boringcrypto
+
+package fips
+
+const (
+	module      = "go"
+	tlsFIPSOnly = false
+)
+
+// Enabled returns true when the cryptography is done by the FIPS-validated BoringCrypto module
+func Enabled() bool {
+	return false
+}
@@ -0,0 +1,230 @@
+// Package flagtypes provides flag values checked when the flags are parsed: durations taking days, byte sizes with
+// units and enumerations with their shell completion. An invalid value is rejected with the expected format before
+// the command runs, instead of being sent to an API and coming back as an error.
+package flagtypes
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const day = 24 * time.Hour
+
+// Binary size units, the bare numbers of a size flag are in the unit of the flag
+const (
+	Byte int64 = 1
+	KiB        = 1024 * Byte
+	MiB        = 1024 * KiB
+	GiB        = 1024 * MiB
+	TiB        = 1024 * GiB
+)
+
+var sizeUnits = map[string]int64{"": 0, "b": Byte, "ki": KiB, "kib": KiB, "mi": MiB, "mib": MiB, "gi": GiB, "gib": GiB, "ti": TiB, "tib": TiB}
+
+var sizePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+// Duration is a duration flag also taking days or a number of seconds, e.g. 30d, 1d12h, 72h or 900
+type Duration struct {
+	value *time.Duration
+	min   time.Duration
+}
+
+// DurationVar adds a duration flag whose value must be at least min
+func DurationVar(flags *pflag.FlagSet, p *time.Duration, name string, value, min time.Duration, usage string) {
+	DurationVarP(flags, p, name, "", value, min, usage)
+}
+
+// DurationVarP is DurationVar with a shorthand
+func DurationVarP(flags *pflag.FlagSet, p *time.Duration, name, shorthand string, value, min time.Duration, usage string) {
+	*p = value
+	flags.VarP(&Duration{value: p, min: min}, name, shorthand, usage)
+}
+
+// ParseDuration parses a Go duration which may start with a number of days, e.g. 30d or 1d12h. A bare number is a
+// number of seconds, the format of the AWS session durations.
+func ParseDuration(value string) (time.Duration, error) {
+	days, rest := 0, strings.TrimSpace(value)
+	if seconds, err := strconv.ParseInt(rest, 10, 64); err == nil && seconds >= 0 {
+		if seconds > int64(math.MaxInt64/int64(time.Second)) {
+			return 0, fmt.Errorf("duration '%s' is too long", value)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if before, after, found := strings.Cut(rest, "d"); found {
+		d, err := strconv.Atoi(before)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid duration '%s', expected e.g. 30d, 1d12h or 72h", value)
+		}
+		days, rest = d, after
+	}
+	var duration time.Duration
+	if rest != "" {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s', expected e.g. 30d, 1d12h or 72h", value)
+		}
+		duration = d
+	}
+	if days > int(math.MaxInt64/int64(day)) {
+		return 0, fmt.Errorf("duration '%s' is too long", value)
+	}
+	return time.Duration(days)*day + duration, nil
+}
+
+// FormatDuration formats the duration the way it is given to a duration flag, in days when it is a number of days
+func FormatDuration(d time.Duration) string {
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+	return d.String()
+}
+
+func (d *Duration) String() string {
+	if d.value == nil {
+		return "0s"
+	}
+	return FormatDuration(*d.value)
+}
+
+func (d *Duration) Set(value string) error {
+	duration, err := ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if duration < d.min {
+		return fmt.Errorf("must be at least %s, got %s", FormatDuration(d.min), value)
+	}
+	*d.value = duration
+	return nil
+}
+
+func (d *Duration) Type() string {
+	return "duration"
+}
+
+// Size is a size flag taking a number with a binary unit, e.g. 300Gi or 1TiB. The numbers without a unit are in the
+// unit of the flag, and the value is stored as a whole number of that unit.
+type Size struct {
+	value *int
+	unit  int64
+}
+
+// SizeVar adds a size flag stored in the given unit, e.g. GiB
+func SizeVar(flags *pflag.FlagSet, p *int, name string, value int, unit int64, usage string) {
+	*p = value
+	flags.Var(&Size{value: p, unit: unit}, name, usage)
+}
+
+// ParseSize returns the size in the given unit, the numbers without a unit are already in it
+func ParseSize(value string, unit int64) (int, error) {
+	match := sizePattern.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size '%s', expected e.g. 300 or 300Gi", value)
+	}
+	multiplier, ok := sizeUnits[strings.ToLower(match[2])]
+	if !ok {
+		return 0, fmt.Errorf("invalid unit '%s' in size '%s', expected one of B, Ki, Mi, Gi or Ti", match[2], value)
+	}
+	if multiplier == 0 {
+		multiplier = unit
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size '%s', expected e.g. 300 or 300Gi", value)
+	}
+	bytes := number * float64(multiplier)
+	if bytes > float64(math.MaxInt32)*float64(unit) {
+		return 0, fmt.Errorf("size '%s' is too large", value)
+	}
+	size := bytes / float64(unit)
+	if size != math.Trunc(size) {
+		return 0, fmt.Errorf("size '%s' isn't a whole number of %s", value, unitName(unit))
+	}
+	return int(size), nil
+}
+
+func unitName(unit int64) string {
+	for _, name := range []string{"TiB", "GiB", "MiB", "KiB"} {
+		if sizeUnits[strings.ToLower(name)] == unit {
+			return name
+		}
+	}
+	return "bytes"
+}
+
+func (s *Size) String() string {
+	if s.value == nil {
+		return "0"
+	}
+	return strconv.Itoa(*s.value)
+}
+
+func (s *Size) Set(value string) error {
+	size, err := ParseSize(value, s.unit)
+	if err != nil {
+		return err
+	}
+	*s.value = size
+	return nil
+}
+
+func (s *Size) Type() string {
+	return "size"
+}
+
+// Enum is a flag taking one of a list of values, matched regardless of the case
+type Enum struct {
+	value   *string
+	allowed []string
+}
+
+// EnumVarP adds a flag taking one of the allowed values to the command, completed by the shell completion. The
+// allowed values are listed at the end of the usage.
+func EnumVarP(cmd *cobra.Command, p *string, name, shorthand, value string, allowed []string, usage string) {
+	*p = value
+	cmd.Flags().VarP(&Enum{value: p, allowed: allowed}, name, shorthand, fmt.Sprintf("%s, one of: %s", usage, strings.Join(allowed, ", ")))
+	_ = cmd.RegisterFlagCompletionFunc(name, func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return allowed, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func (e *Enum) String() string {
+	if e.value == nil {
+		return ""
+	}
+	return *e.value
+}
+
+// Set stores the allowed value as it is spelled in the list, e.g. mtd is stored as MTD
+func (e *Enum) Set(value string) error {
+	for _, allowed := range e.allowed {
+		if strings.EqualFold(value, allowed) {
+			*e.value = allowed
+			return nil
+		}
+	}
+	return fmt.Errorf("expected one of %s", quoteList(e.allowed))
+}
+
+func (e *Enum) Type() string {
+	return "string"
+}
+
+// quoteList returns 'a', 'b' or 'c'
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + value + "'"
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
@@ -0,0 +1,76 @@
+package flagtypes
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func TestDuration(t *testing.T) {
+	var value time.Duration
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	DurationVar(flags, &value, "lookback", 30*day, day, "")
+	if got := flags.Lookup("lookback").DefValue; got != "30d" {
+		t.Errorf("expected the default to be shown in days, got %s", got)
+	}
+
+	for input, expected := range map[string]time.Duration{"72h": 72 * time.Hour, "1d12h": 36 * time.Hour, "2d": 2 * day, "86400": day} {
+		if err := flags.Set("lookback", input); err != nil || value != expected {
+			t.Errorf("expected %s to be %s, got %s and %v", input, expected, value, err)
+		}
+	}
+	for _, invalid := range []string{"1h", "0d", "month", "xd", "1.5d", "-2d", "3600", "-86400"} {
+		if err := flags.Set("lookback", invalid); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	var value int
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	SizeVar(flags, &value, "size", 0, GiB, "")
+
+	for input, expected := range map[string]int{"300": 300, "300Gi": 300, "300GiB": 300, "1Ti": 1024, "1.5Ti": 1536, "2048Mi": 2} {
+		if err := flags.Set("size", input); err != nil || value != expected {
+			t.Errorf("expected %s to be %d GiB, got %d and %v", input, expected, value, err)
+		}
+	}
+	for _, invalid := range []string{"300G", "1.5", "100Mi", "-1", "large", "99999999999Ti"} {
+		if err := flags.Set("size", invalid); err == nil {
+			t.Errorf("expected %s to be rejected", invalid)
+		}
+	}
+}
+
+func TestEnum(t *testing.T) {
+	var value string
+	cmd := &cobra.Command{Use: "test"}
+	EnumVarP(cmd, &value, "level", "l", "ou", []string{"ou", "account"}, "Level")
+
+	if usage := cmd.Flags().Lookup("level").Usage; usage != "Level, one of: ou, account" {
+		t.Errorf("expected the allowed values in the usage, got %s", usage)
+	}
+	if err := cmd.Flags().Set("level", "Account"); err != nil || value != "account" {
+		t.Errorf("expected Account to be stored as account, got %s and %v", value, err)
+	}
+	err := cmd.Flags().Set("level", "org")
+	if err == nil || !strings.Contains(err.Error(), "'ou' or 'account'") {
+		t.Errorf("expected the allowed values in the error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	cmd.Run = func(*cobra.Command, []string) {}
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{cobra.ShellCompRequestCmd, "--level", ""})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out.String(), "ou\naccount\n:4\n") {
+		t.Errorf("expected the allowed values to be completed, got %s", out.String())
+	}
+}
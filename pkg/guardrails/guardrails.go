@@ -0,0 +1,290 @@
+package guardrails
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/justification"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigKey lists the production-sensitive commands and what they require
+	ConfigKey = "production_guardrails"
+	// AuditLogConfigKey overrides where the justifications of production-sensitive commands are recorded
+	AuditLogConfigKey = "audit_log_path"
+
+	ReasonFlag = "reason"
+	TicketFlag = "ticket"
+
+	// RequireConfirmation asks the user to type 'production' before the command runs
+	RequireConfirmation = "confirmation"
+	// RequireReason requires a justification with --reason
+	RequireReason = "reason"
+	// RequireTicket requires a ticket ID with --ticket
+	RequireTicket = "ticket"
+
+	productionConfirmation = "production"
+	defaultAuditLogName    = "osdctl-audit.log"
+)
+
+var (
+	ticketRegex = regexp.MustCompile(`^[A-Z][A-Z0-9]+-[0-9]+$`)
+
+	// Swapped in tests
+	isProduction           = isProductionOCM
+	confirmIn    io.Reader = os.Stdin
+	confirmOut   io.Writer = os.Stderr
+	nowFunc                = time.Now
+)
+
+// Rule marks a command, and all its subcommands, as production-sensitive
+type Rule struct {
+	// Command is the full command path, e.g. 'osdctl cluster transfer-owner'
+	Command string   `mapstructure:"command"`
+	Require []string `mapstructure:"require"`
+}
+
+// AuditRecord is written to the audit log every time a production-sensitive command runs
+type AuditRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	User        string    `json:"user"`
+	Command     string    `json:"command"`
+	Args        []string  `json:"args,omitempty"`
+	Environment string    `json:"environment"`
+	Reason      string    `json:"reason,omitempty"`
+	Ticket      string    `json:"ticket,omitempty"`
+	// Cluster and ExpiresAt are set for credentials handed out to a cluster, e.g. by 'osdctl cluster kubeconfig'
+	Cluster   string     `json:"cluster,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Request is set for the OCM requests changing something sent by a command run with a --reason, e.g. 'POST https://...'
+	Request string `json:"request,omitempty"`
+}
+
+// AddFlags adds the --reason and --ticket flags to the command and all its children
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(ReasonFlag, "", "Why the command is run, recorded in the audit log with every OCM request changing something")
+	cmd.PersistentFlags().String(TicketFlag, "", "Ticket ID (e.g. OHSS-1234) linked to a production-sensitive command, recorded in the audit log")
+}
+
+// Check enforces the guardrails configured for the command when it runs against production OCM,
+// then records the justification in the audit log
+func Check(cmd *cobra.Command, args []string) error {
+	var rules []Rule
+	if err := viper.UnmarshalKey(ConfigKey, &rules); err != nil {
+		return fmt.Errorf("cannot parse '%s' from the config file: %w", ConfigKey, err)
+	}
+
+	reason := Reason(cmd)
+	ticket := flagValue(cmd, TicketFlag)
+	recordJustifiedRequests(cmd, args, reason, ticket)
+
+	rule := ruleFor(rules, cmd.CommandPath())
+	if rule == nil || !isProduction() {
+		return nil
+	}
+
+	for _, requirement := range rule.Require {
+		switch requirement {
+		case RequireReason:
+			if reason == "" {
+				return osdctlErrors.New(osdctlErrors.ErrValidation, "'%s' is production-sensitive, provide a justification with --%s", cmd.CommandPath(), ReasonFlag)
+			}
+		case RequireTicket:
+			if !ticketRegex.MatchString(ticket) {
+				return osdctlErrors.New(osdctlErrors.ErrValidation, "'%s' is production-sensitive, link a ticket with --%s (e.g. OHSS-1234)", cmd.CommandPath(), TicketFlag)
+			}
+		case RequireConfirmation:
+			// Prompted for last, once everything else has been validated
+		default:
+			return fmt.Errorf("unknown requirement '%s' for '%s' in '%s', expected '%s', '%s' or '%s'",
+				requirement, rule.Command, ConfigKey, RequireConfirmation, RequireReason, RequireTicket)
+		}
+	}
+
+	for _, requirement := range rule.Require {
+		if requirement != RequireConfirmation {
+			continue
+		}
+		err := utils.Confirm(utils.ConfirmOptions{
+			Summary: &utils.ImpactSummary{
+				Action:      cmd.CommandPath(),
+				Environment: productionConfirmation,
+			},
+			TypedConfirmation: productionConfirmation,
+			In:                confirmIn,
+			Out:               confirmOut,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return WriteAuditRecord(AuditRecord{
+		Command:     cmd.CommandPath(),
+		Args:        args,
+		Environment: productionConfirmation,
+		Reason:      reason,
+		Ticket:      ticket,
+	})
+}
+
+// Reason returns the justification given with --reason, or an empty string when none was given
+func Reason(cmd *cobra.Command) string {
+	return strings.TrimSpace(flagValue(cmd, ReasonFlag))
+}
+
+// recordJustifiedRequests records every OCM request changing something of a command run with a --reason in the audit
+// log. OCM has no field for it, so the reason is only recorded locally.
+func recordJustifiedRequests(cmd *cobra.Command, args []string, reason, ticket string) {
+	if reason == "" {
+		justification.SetRecorder(nil)
+		return
+	}
+	command := cmd.CommandPath()
+	justification.SetRecorder(func(request string) {
+		err := WriteAuditRecord(AuditRecord{
+			Command:     command,
+			Args:        args,
+			Environment: environmentOf(request),
+			Reason:      reason,
+			Ticket:      ticket,
+			Request:     request,
+		})
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: unable to record the justification in the audit log: %v\n", err)
+		}
+	})
+}
+
+// environmentOf returns the OCM environment of the request, the same way as utils.GetCurrentOCMEnv
+func environmentOf(request string) string {
+	switch {
+	case strings.Contains(request, "integration"):
+		return "integration"
+	case strings.Contains(request, "stage"):
+		return "stage"
+	}
+	return productionConfirmation
+}
+
+// ruleFor returns the most specific rule matching the command path
+func ruleFor(rules []Rule, commandPath string) *Rule {
+	var match *Rule
+	for i := range rules {
+		rules[i].Command = strings.Join(strings.Fields(rules[i].Command), " ")
+		command := rules[i].Command
+		if command != commandPath && !strings.HasPrefix(commandPath, command+" ") {
+			continue
+		}
+		if match == nil || len(command) > len(match.Command) {
+			match = &rules[i]
+		}
+	}
+	return match
+}
+
+func flagValue(cmd *cobra.Command, name string) string {
+	flag := cmd.Flag(name)
+	if flag == nil {
+		return ""
+	}
+	return flag.Value.String()
+}
+
+// isProductionOCM fails closed: if the environment cannot be determined it is assumed to be production
+func isProductionOCM() bool {
+	url, err := utils.GetOCMURL()
+	if err != nil {
+		return true
+	}
+	return utils.IsProductionOCMURL(url)
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// AuditLogPath returns the location of the audit log
+func AuditLogPath() (string, error) {
+	if path := viper.GetString(AuditLogConfigKey); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", defaultAuditLogName), nil
+}
+
+// WriteAuditRecord appends the record to the audit log, filling in the timestamp and the user when they are not set
+func WriteAuditRecord(record AuditRecord) error {
+	if record.Timestamp.IsZero() {
+		record.Timestamp = nowFunc().UTC()
+	}
+	if record.User == "" {
+		record.User = currentUser()
+	}
+
+	path, err := AuditLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) //#nosec G304 -- path is configured by the user
+	if err != nil {
+		return fmt.Errorf("cannot open audit log '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// ReadAuditRecords returns the records of the audit log, oldest first. A missing audit log has no records.
+func ReadAuditRecords() ([]AuditRecord, error) {
+	path, err := AuditLogPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path) //#nosec G304 -- path is configured by the user
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open audit log '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	var records []AuditRecord
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var record AuditRecord
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("cannot parse audit log '%s': %w", path, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
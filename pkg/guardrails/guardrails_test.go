@@ -0,0 +1,232 @@
+package guardrails
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/justification"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newTestCommand builds 'osdctl cluster transfer-owner' with the guardrail flags set to the given values
+func newTestCommand(t *testing.T, reason, ticket string) *cobra.Command {
+	root := &cobra.Command{Use: "osdctl"}
+	AddFlags(root)
+	cluster := &cobra.Command{Use: "cluster"}
+	transferOwner := &cobra.Command{Use: "transfer-owner"}
+	root.AddCommand(cluster)
+	cluster.AddCommand(transferOwner)
+
+	if reason != "" {
+		if err := root.PersistentFlags().Set(ReasonFlag, reason); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ticket != "" {
+		if err := root.PersistentFlags().Set(TicketFlag, ticket); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return transferOwner
+}
+
+func setupGuardrails(t *testing.T, production bool, rules []map[string]interface{}, input string) string {
+	auditLog := filepath.Join(t.TempDir(), "audit.log")
+	viper.Set(ConfigKey, rules)
+	viper.Set(AuditLogConfigKey, auditLog)
+	isProduction = func() bool { return production }
+	confirmIn = strings.NewReader(input)
+	confirmOut = io.Discard
+	nowFunc = func() time.Time { return time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	t.Cleanup(func() {
+		viper.Set(ConfigKey, nil)
+		viper.Set(AuditLogConfigKey, "")
+		isProduction = isProductionOCM
+		confirmIn = os.Stdin
+		confirmOut = os.Stderr
+		nowFunc = time.Now
+	})
+	return auditLog
+}
+
+func TestRuleFor(t *testing.T) {
+	g := NewGomegaWithT(t)
+	rules := []Rule{
+		{Command: "osdctl cluster", Require: []string{RequireReason}},
+		{Command: "osdctl  cluster support delete", Require: []string{RequireTicket}},
+	}
+
+	g.Expect(ruleFor(rules, "osdctl org get")).To(BeNil())
+	g.Expect(ruleFor(rules, "osdctl clusterdeployment list")).To(BeNil())
+	g.Expect(ruleFor(rules, "osdctl cluster transfer-owner").Require).To(Equal([]string{RequireReason}))
+	g.Expect(ruleFor(rules, "osdctl cluster support delete").Require).To(Equal([]string{RequireTicket}))
+}
+
+func TestCheck(t *testing.T) {
+	rules := []map[string]interface{}{
+		{"command": "osdctl cluster transfer-owner", "require": []string{RequireConfirmation, RequireReason, RequireTicket}},
+	}
+
+	tests := []struct {
+		name        string
+		production  bool
+		reason      string
+		ticket      string
+		input       string
+		expectErr   string
+		validation  bool
+		expectAudit bool
+	}{
+		{
+			name: "not production",
+		},
+		{
+			name:       "missing reason",
+			production: true,
+			ticket:     "OHSS-1234",
+			input:      "production\n",
+			expectErr:  "--reason",
+			validation: true,
+		},
+		{
+			name:       "invalid ticket",
+			production: true,
+			reason:     "customer request",
+			ticket:     "not a ticket",
+			input:      "production\n",
+			expectErr:  "--ticket",
+			validation: true,
+		},
+		{
+			name:       "confirmation declined",
+			production: true,
+			reason:     "customer request",
+			ticket:     "OHSS-1234",
+			input:      "prod\n",
+			expectErr:  "confirmation did not match",
+		},
+		{
+			name:        "all requirements met",
+			production:  true,
+			reason:      "customer request",
+			ticket:      "OHSS-1234",
+			input:       "production\n",
+			expectAudit: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			auditLog := setupGuardrails(t, tt.production, rules, tt.input)
+
+			err := Check(newTestCommand(t, tt.reason, tt.ticket), []string{"abc"})
+			if tt.expectErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.expectErr)))
+				g.Expect(errors.Is(err, osdctlErrors.ErrValidation)).To(Equal(tt.validation))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			data, err := os.ReadFile(auditLog)
+			if !tt.expectAudit {
+				g.Expect(os.IsNotExist(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			var record AuditRecord
+			g.Expect(json.Unmarshal(bytes.TrimSpace(data), &record)).To(Succeed())
+			g.Expect(record.Command).To(Equal("osdctl cluster transfer-owner"))
+			g.Expect(record.Args).To(Equal([]string{"abc"}))
+			g.Expect(record.Reason).To(Equal("customer request"))
+			g.Expect(record.Ticket).To(Equal("OHSS-1234"))
+			g.Expect(record.Environment).To(Equal("production"))
+		})
+	}
+}
+
+func TestCheckUnknownRequirement(t *testing.T) {
+	g := NewGomegaWithT(t)
+	setupGuardrails(t, true, []map[string]interface{}{
+		{"command": "osdctl cluster", "require": []string{"approval"}},
+	}, "")
+
+	err := Check(newTestCommand(t, "", ""), nil)
+	g.Expect(err).To(MatchError(ContainSubstring("unknown requirement 'approval'")))
+}
+
+func TestCheckJustification(t *testing.T) {
+	g := NewGomegaWithT(t)
+	auditLog := setupGuardrails(t, true, []map[string]interface{}{
+		{"command": "osdctl cluster transfer-owner", "require": []string{RequireReason}},
+	}, "")
+	cmd := newTestCommand(t, " moving to the new owner ", "OHSS-1234")
+	t.Cleanup(func() { justification.SetRecorder(nil) })
+
+	g.Expect(Check(cmd, []string{"abc"})).To(Succeed())
+	records, err := ReadAuditRecords()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(HaveLen(1))
+	g.Expect(records[0].Reason).To(Equal("moving to the new owner"))
+
+	// The OCM requests changing something are recorded with the justification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: justification.OCMTransportWrapper(http.DefaultTransport)}
+	resp, err := client.Post(server.URL+"/api/accounts_mgmt/v1/subscriptions/abc", "application/json", strings.NewReader(`{}`))
+	g.Expect(err).NotTo(HaveOccurred())
+	resp.Body.Close()
+
+	records, err = ReadAuditRecords()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[1].Command).To(Equal("osdctl cluster transfer-owner"))
+	g.Expect(records[1].Args).To(Equal([]string{"abc"}))
+	g.Expect(records[1].Reason).To(Equal("moving to the new owner"))
+	g.Expect(records[1].Ticket).To(Equal("OHSS-1234"))
+	g.Expect(records[1].Request).To(Equal("POST " + server.URL + "/api/accounts_mgmt/v1/subscriptions/abc"))
+	g.Expect(auditLog).To(BeAnExistingFile())
+
+	// Without a reason nothing more is recorded
+	g.Expect(Check(newTestCommand(t, "", ""), nil)).To(MatchError(ContainSubstring("provide a justification with --reason")))
+	resp, err = client.Post(server.URL+"/api/accounts_mgmt/v1/subscriptions/abc", "application/json", strings.NewReader(`{}`))
+	g.Expect(err).NotTo(HaveOccurred())
+	resp.Body.Close()
+	records, err = ReadAuditRecords()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(HaveLen(2))
+}
+
+func TestReadAuditRecords(t *testing.T) {
+	g := NewGomegaWithT(t)
+	setupGuardrails(t, false, nil, "")
+
+	records, err := ReadAuditRecords()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(BeEmpty())
+
+	expiresAt := time.Date(2023, 5, 1, 16, 0, 0, 0, time.UTC)
+	g.Expect(WriteAuditRecord(AuditRecord{Command: "osdctl cluster kubeconfig", Cluster: "abc", ExpiresAt: &expiresAt})).To(Succeed())
+	g.Expect(WriteAuditRecord(AuditRecord{Command: "osdctl cluster transfer-owner", Reason: "moving"})).To(Succeed())
+
+	records, err = ReadAuditRecords()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[0].Cluster).To(Equal("abc"))
+	g.Expect(records[0].ExpiresAt.Equal(expiresAt)).To(BeTrue())
+	g.Expect(records[1].Reason).To(Equal("moving"))
+}
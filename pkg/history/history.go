@@ -0,0 +1,286 @@
+// Package history records the osdctl commands that ran, with their arguments, target cluster and result, so that
+// a command run during an incident can be found and re-run later, e.g. against another cluster
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// DisabledConfigKey stops recording the commands when true
+	DisabledConfigKey = "history_disabled"
+	// PathConfigKey overrides where the history is stored
+	PathConfigKey = "history_path"
+	// SizeConfigKey is how many commands the history keeps, the oldest ones are dropped first
+	SizeConfigKey = "history_size"
+
+	// Redacted replaces the values of the flags holding credentials
+	Redacted = "REDACTED"
+
+	ResultSuccess = "success"
+
+	defaultHistoryName = "osdctl-history.jsonl"
+	defaultSize        = 1000
+)
+
+func init() {
+	viper.SetDefault(SizeConfigKey, defaultSize)
+}
+
+var (
+	// sensitiveFlag matches the flags whose value is never recorded
+	sensitiveFlag = regexp.MustCompile(`(?i)(token|password|secret)`)
+
+	// The commands that aren't worth replaying, e.g. the history itself and the shell completion
+	skippedCommands = map[string]bool{
+		"history": true, "help": true, "completion": true, "version": true,
+		cobra.ShellCompRequestCmd: true, cobra.ShellCompNoDescRequestCmd: true,
+	}
+
+	current *invocation
+
+	// Swapped in tests
+	nowFunc = time.Now
+)
+
+// Entry is a command that ran. Number identifies it for replay and keeps growing when the oldest entries are
+// dropped.
+type Entry struct {
+	Number    int       `json:"number"`
+	Timestamp time.Time `json:"timestamp"`
+	Command   string    `json:"command"`
+	// Args are the arguments osdctl was run with, without the binary, to run the command again
+	Args     []string `json:"args"`
+	Cluster  string   `json:"cluster,omitempty"`
+	Result   string   `json:"result"`
+	ExitCode int      `json:"exit_code"`
+	Duration string   `json:"duration"`
+}
+
+type invocation struct {
+	entry Entry
+	start time.Time
+}
+
+// Enabled reports whether the commands are recorded
+func Enabled() bool {
+	return !viper.GetBool(DisabledConfigKey)
+}
+
+// Start begins recording the command, args are its positional arguments and argv the arguments osdctl was run
+// with. The commands failing exit through osdctlErrors.CheckErr without returning to main, Finish is hooked there.
+func Start(cmd *cobra.Command, args []string, argv []string) {
+	if !Enabled() || !cmd.Runnable() || skipped(cmd) {
+		return
+	}
+	now := nowFunc()
+	current = &invocation{
+		entry: Entry{
+			Timestamp: now.UTC(),
+			Command:   cmd.CommandPath(),
+			Args:      Redact(argv),
+			Cluster:   targetCluster(cmd, args),
+		},
+		start: now,
+	}
+	osdctlErrors.OnExit(Finish)
+}
+
+func skipped(cmd *cobra.Command) bool {
+	for c := cmd; c != nil && c.HasParent(); c = c.Parent() {
+		if skippedCommands[c.Name()] {
+			return true
+		}
+	}
+	return false
+}
+
+// targetCluster returns the cluster given with --cluster-id or --cluster, or as the first argument of the commands
+// taking a cluster, e.g. 'cluster describe CLUSTER_ID'
+func targetCluster(cmd *cobra.Command, args []string) string {
+	for _, name := range []string{"cluster-id", "cluster"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return flag.Value.String()
+		}
+	}
+	usage := strings.Fields(cmd.Use)
+	if len(usage) > 1 && len(args) > 0 && strings.Contains(strings.ToUpper(usage[1]), "CLUSTER") {
+		return args[0]
+	}
+	return ""
+}
+
+// Redact returns the arguments with the values of the flags holding credentials replaced
+func Redact(argv []string) []string {
+	redacted := make([]string, 0, len(argv))
+	redactNext := false
+	for _, arg := range argv {
+		if redactNext {
+			redacted = append(redacted, Redacted)
+			redactNext = false
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && sensitiveFlag.MatchString(arg) {
+			if name, _, hasValue := strings.Cut(arg, "="); hasValue {
+				arg = name + "=" + Redacted
+			} else {
+				redactNext = true
+			}
+		}
+		redacted = append(redacted, arg)
+	}
+	return redacted
+}
+
+// Finish records the outcome of the command started with Start. A failure to record is reported but never fails
+// the command.
+func Finish(err error) {
+	if current == nil {
+		return
+	}
+	entry := current.entry
+	entry.Duration = nowFunc().Sub(current.start).Round(time.Millisecond).String()
+	entry.Result = ResultSuccess
+	if err != nil {
+		entry.Result = osdctlErrors.Name(err)
+		entry.ExitCode = osdctlErrors.ExitCode(err)
+	}
+	current = nil
+
+	if err := Append(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to record the command in the history: %v\n", err)
+	}
+}
+
+// Path returns the location of the history
+func Path() (string, error) {
+	if path := viper.GetString(PathConfigKey); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", defaultHistoryName), nil
+}
+
+// Append numbers the entry after the last one and adds it to the history, dropping the oldest entries beyond the
+// configured size
+func Append(entry Entry) error {
+	entries, err := Read()
+	if err != nil {
+		return err
+	}
+	entry.Number = 1
+	if len(entries) > 0 {
+		entry.Number = entries[len(entries)-1].Number + 1
+	}
+	entries = append(entries, entry)
+
+	if size := viper.GetInt(SizeConfigKey); size > 0 && len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+	return write(entries)
+}
+
+func write(entries []Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	// Written aside then renamed, so that two commands finishing together don't leave a truncated history
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("cannot write history '%s': %w", tmp, err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Read returns the entries of the history, oldest first. A missing history has no entries.
+func Read() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path) //#nosec G304 -- path is configured by the user
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open history '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		var entry Entry
+		if err := decoder.Decode(&entry); err != nil {
+			return nil, fmt.Errorf("cannot parse history '%s': %w", path, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Find returns the entry with the number
+func Find(entries []Entry, number int) (*Entry, error) {
+	for i := range entries {
+		if entries[i].Number == number {
+			return &entries[i], nil
+		}
+	}
+	return nil, osdctlErrors.New(osdctlErrors.ErrNotFound, "no command #%d in the history", number)
+}
+
+// ReplayArgs returns the arguments to run the entry again, against cluster instead of the recorded one when set. The
+// recorded cluster is replaced wherever it appears, as the argument of a flag or on its own.
+func ReplayArgs(entry Entry, cluster string) ([]string, error) {
+	for _, arg := range entry.Args {
+		if strings.HasSuffix(arg, Redacted) {
+			return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "command #%d has credentials that weren't recorded, run it again by hand: osdctl %s", entry.Number, strings.Join(entry.Args, " "))
+		}
+	}
+	args := append([]string{}, entry.Args...)
+	if cluster == "" {
+		return args, nil
+	}
+	if entry.Cluster == "" {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "command #%d has no recorded cluster to replace", entry.Number)
+	}
+	replaced := false
+	for i, arg := range args {
+		if arg == entry.Cluster {
+			args[i] = cluster
+			replaced = true
+		} else if name, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(name, "-") && value == entry.Cluster {
+			args[i] = name + "=" + cluster
+			replaced = true
+		}
+	}
+	if !replaced {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, "cluster %s isn't in the arguments of command #%d", entry.Cluster, entry.Number)
+	}
+	return args, nil
+}
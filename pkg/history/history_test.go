@@ -0,0 +1,112 @@
+package history
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func newTestCommands() (*cobra.Command, *cobra.Command) {
+	root := &cobra.Command{Use: "osdctl"}
+	cluster := &cobra.Command{Use: "cluster"}
+	describe := &cobra.Command{Use: "describe CLUSTER_ID", Run: func(*cobra.Command, []string) {}}
+	history := &cobra.Command{Use: "history"}
+	list := &cobra.Command{Use: "list", Run: func(*cobra.Command, []string) {}}
+	root.AddCommand(cluster, history)
+	cluster.AddCommand(describe)
+	history.AddCommand(list)
+	return describe, list
+}
+
+func TestRedact(t *testing.T) {
+	got := Redact([]string{"jira", "--jira-token", "abc", "--api-password=hunter2", "--reason", "incident"})
+	expected := []string{"jira", "--jira-token", Redacted, "--api-password=" + Redacted, "--reason", "incident"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Redact() = %v, expected %v", got, expected)
+	}
+}
+
+func TestStartAndFinish(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(PathConfigKey, filepath.Join(t.TempDir(), "history.jsonl"))
+	viper.Set(SizeConfigKey, 2)
+	savedNow := nowFunc
+	defer func() { nowFunc = savedNow }()
+	now := time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC)
+	nowFunc = func() time.Time { return now }
+
+	describe, list := newTestCommands()
+	for i := 0; i < 3; i++ {
+		Start(describe, []string{"mycluster"}, []string{"cluster", "describe", "mycluster"})
+		var err error
+		if i == 2 {
+			err = osdctlErrors.New(osdctlErrors.ErrNotFound, "no cluster")
+		}
+		Finish(err)
+	}
+	// The history itself isn't recorded
+	Start(list, nil, []string{"history", "list"})
+	Finish(nil)
+
+	entries, err := Read()
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected the 2 last entries, got %v", entries)
+	}
+	last := entries[1]
+	if last.Number != 3 || last.Cluster != "mycluster" || last.Command != "osdctl cluster describe" || last.Result != "not_found" || last.ExitCode != osdctlErrors.ExitCode(osdctlErrors.ErrNotFound) {
+		t.Errorf("unexpected last entry %+v", last)
+	}
+	if entries[0].Number != 2 || entries[0].Result != ResultSuccess {
+		t.Errorf("unexpected first entry %+v", entries[0])
+	}
+}
+
+func TestStartIsNoopWhenDisabled(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(DisabledConfigKey, true)
+
+	describe, _ := newTestCommands()
+	Start(describe, []string{"mycluster"}, []string{"cluster", "describe", "mycluster"})
+	if current != nil {
+		t.Errorf("expected no recording when the history is disabled")
+	}
+}
+
+func TestReplayArgs(t *testing.T) {
+	entry := Entry{Number: 4, Cluster: "abc", Args: []string{"cluster", "health", "abc", "--output=json"}}
+	args, err := ReplayArgs(entry, "")
+	if err != nil || !reflect.DeepEqual(args, entry.Args) {
+		t.Errorf("ReplayArgs() = %v, %v, expected the recorded args", args, err)
+	}
+
+	args, err = ReplayArgs(entry, "def")
+	if err != nil || !reflect.DeepEqual(args, []string{"cluster", "health", "def", "--output=json"}) {
+		t.Errorf("ReplayArgs() = %v, %v, expected the cluster replaced", args, err)
+	}
+
+	flagEntry := Entry{Number: 5, Cluster: "abc", Args: []string{"servicelog", "post", "--cluster-id=abc"}}
+	args, err = ReplayArgs(flagEntry, "def")
+	if err != nil || !reflect.DeepEqual(args, []string{"servicelog", "post", "--cluster-id=def"}) {
+		t.Errorf("ReplayArgs() = %v, %v, expected the flag value replaced", args, err)
+	}
+
+	for _, invalid := range []Entry{
+		{Number: 6, Args: []string{"jira", "--jira-token", Redacted}},
+		{Number: 7, Args: []string{"env"}},
+	} {
+		if _, err := ReplayArgs(invalid, "def"); !errors.Is(err, osdctlErrors.ErrValidation) {
+			t.Errorf("ReplayArgs(%v) = %v, expected a validation error", invalid, err)
+		}
+	}
+}
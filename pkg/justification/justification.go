@@ -0,0 +1,52 @@
+// Package justification records the OCM requests changing something in the audit log, with the --reason they were
+// made for, so that every change can be traced back to why it was made. OCM has no field for a justification, the
+// reason is only kept in the local audit log and isn't sent with the requests.
+package justification
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var (
+	recorderMu sync.Mutex
+	recorder   func(request string)
+)
+
+// SetRecorder registers the function called with the method and URL, without its query, of every OCM request changing
+// something, e.g. to record it in the audit log. Nothing is recorded when it is nil.
+func SetRecorder(record func(request string)) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	recorder = record
+}
+
+type transport struct {
+	wrapped http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorderMu.Lock()
+	record := recorder
+	recorderMu.Unlock()
+	if record != nil && mutating(req) {
+		record(req.Method + " " + req.URL.Scheme + "://" + req.URL.Host + req.URL.Path)
+	}
+	return t.wrapped.RoundTrip(req)
+}
+
+// mutating returns true for the requests changing something, getting a token doesn't
+func mutating(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return !strings.HasSuffix(req.URL.Path, "/token") && !strings.HasSuffix(req.URL.Path, "/access_token")
+}
+
+// OCMTransportWrapper returns a wrapper suitable for sdk.ConnectionBuilder.TransportWrapper
+// that records the OCM requests changing something
+func OCMTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	return &transport{wrapped: wrapped}
+}
@@ -0,0 +1,67 @@
+package justification
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func setRecorder(t *testing.T) *[]string {
+	var recorded []string
+	SetRecorder(func(request string) { recorded = append(recorded, request) })
+	t.Cleanup(func() { SetRecorder(nil) })
+	return &recorded
+}
+
+func TestTransportRecordsMutatingRequests(t *testing.T) {
+	recorded := setRecorder(t)
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}
+
+	for _, send := range []func() (*http.Response, error){
+		func() (*http.Response, error) { return client.Get(server.URL + "/api/clusters_mgmt/v1/clusters") },
+		func() (*http.Response, error) {
+			return client.Post(server.URL+"/auth/realms/redhat-external/protocol/openid-connect/token", "application/x-www-form-urlencoded", nil)
+		},
+		func() (*http.Response, error) {
+			return client.Post(server.URL+"/api/service_logs/v1/cluster_logs?dry_run=true", "application/json", strings.NewReader(`{}`))
+		},
+	} {
+		resp, err := send()
+		if err != nil {
+			t.Fatalf("expected the request to be sent: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(*recorded) != 1 || (*recorded)[0] != "POST "+server.URL+"/api/service_logs/v1/cluster_logs" {
+		t.Errorf("expected only the POST to be recorded, without its query, got %v", *recorded)
+	}
+	// OCM doesn't take a justification, none is sent
+	for _, header := range headers {
+		if value := header.Get("X-Justification"); value != "" {
+			t.Errorf("expected no justification header, got %q", value)
+		}
+	}
+}
+
+func TestTransportWithoutRecorder(t *testing.T) {
+	SetRecorder(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}
+
+	resp, err := client.Post(server.URL+"/api/clusters_mgmt/v1/clusters", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("expected the request to be sent: %v", err)
+	}
+	resp.Body.Close()
+}
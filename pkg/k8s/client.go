@@ -7,7 +7,12 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/recording"
 )
 
 type LazyClient struct {
@@ -73,12 +78,19 @@ func (s *LazyClient) getClient() client.Client {
 func (s *LazyClient) initialize() {
 	configLoader := s.flags.ToRawKubeConfigLoader()
 	cfg, err := configLoader.ClientConfig()
+	// The replayed sessions don't need a kubeconfig
+	if err != nil && recording.Replaying() {
+		cfg, err = &rest.Config{Host: recording.ReplayHost}, nil
+	}
 	if err != nil {
 		//The stub is to allow commands that don't need a connection to a Kubernetes cluster.
 		//We'll produce a warning and the stub itself will error when a command is trying to use it.
 		panic(s.err())
 	}
 
+	cfg.Wrap(recording.KubeTransportWrapper)
+	cfg.Wrap(readonly.KubeTransportWrapper)
+	cfg.Wrap(deadline.KubeTransportWrapper)
 	s.client, err = client.New(cfg, client.Options{})
 	if err != nil {
 		panic(s.err())
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// LogFormatConfigKey selects the log format, either 'text' or 'json'
+	LogFormatConfigKey = "log_format"
+
+	VerbosityFlag = "v"
+	LogFormatFlag = "log-format"
+
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+var verbosity int
+
+func init() {
+	viper.SetDefault(LogFormatConfigKey, FormatText)
+}
+
+// AddFlags adds the -v/-vv verbosity and --log-format flags to the given command
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().CountVarP(&verbosity, VerbosityFlag, VerbosityFlag, "Log verbosity, repeat for more detail (-v debug, -vv trace)")
+	cmd.PersistentFlags().String(LogFormatFlag, FormatText, "Log format, either 'text' or 'json' (config key: "+LogFormatConfigKey+")")
+	_ = viper.BindPFlag(LogFormatConfigKey, cmd.PersistentFlags().Lookup(LogFormatFlag))
+}
+
+// Level returns the log level for the given -v count. A command's --verbose flag counts as -v.
+func Level(verbosity int, verbose bool) log.Level {
+	if verbose && verbosity < 1 {
+		verbosity = 1
+	}
+	switch {
+	case verbosity >= 2:
+		return log.TraceLevel
+	case verbosity == 1:
+		return log.DebugLevel
+	default:
+		return log.InfoLevel
+	}
+}
+
+// Formatter returns the logrus formatter for the given format
+func Formatter(format string) (log.Formatter, error) {
+	switch format {
+	case FormatText, "":
+		return &log.TextFormatter{}, nil
+	case FormatJSON:
+		return &log.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q, valid formats are '%s' and '%s'", format, FormatText, FormatJSON)
+	}
+}
+
+// Setup configures the global logger for the command about to run
+func Setup(cmd *cobra.Command) error {
+	formatter, err := Formatter(viper.GetString(LogFormatConfigKey))
+	if err != nil {
+		return err
+	}
+
+	var verbose bool
+	if f := cmd.Flags().Lookup("verbose"); f != nil {
+		verbose, _ = strconv.ParseBool(f.Value.String())
+	}
+
+	log.SetOutput(os.Stderr)
+	log.SetFormatter(formatter)
+	log.SetLevel(Level(verbosity, verbose))
+
+	// Libraries logging through klog follow the same verbosity
+	klogFlags := flag.NewFlagSet("klog", flag.ContinueOnError)
+	klog.InitFlags(klogFlags)
+	return klogFlags.Set("v", strconv.Itoa(verbosity))
+}
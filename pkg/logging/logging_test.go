@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func TestLevel(t *testing.T) {
+	testCases := []struct {
+		verbosity int
+		verbose   bool
+		expected  log.Level
+	}{
+		{verbosity: 0, expected: log.InfoLevel},
+		{verbosity: 0, verbose: true, expected: log.DebugLevel},
+		{verbosity: 1, expected: log.DebugLevel},
+		{verbosity: 2, expected: log.TraceLevel},
+		{verbosity: 2, verbose: true, expected: log.TraceLevel},
+		{verbosity: 5, expected: log.TraceLevel},
+	}
+	for _, tc := range testCases {
+		if level := Level(tc.verbosity, tc.verbose); level != tc.expected {
+			t.Errorf("Level(%d, %t) = %s, expected %s", tc.verbosity, tc.verbose, level, tc.expected)
+		}
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	if f, err := Formatter(FormatJSON); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, ok := f.(*log.JSONFormatter); !ok {
+		t.Errorf("expected a JSON formatter, got %T", f)
+	}
+	if f, err := Formatter(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, ok := f.(*log.TextFormatter); !ok {
+		t.Errorf("expected a text formatter, got %T", f)
+	}
+	if _, err := Formatter("xml"); err == nil {
+		t.Errorf("expected an error for an invalid format")
+	}
+}
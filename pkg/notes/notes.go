@@ -0,0 +1,304 @@
+// Package notes keeps the notes operators take about a cluster during an investigation, encrypted, in a file per
+// cluster. The files can be synced to a team S3 bucket, so that the next on-call finds the notes from the same tool.
+package notes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/secrets"
+	"github.com/spf13/viper"
+)
+
+const (
+	// DirConfigKey overrides the directory of the notes
+	DirConfigKey = "notes_dir"
+	// BucketConfigKey syncs the notes to an S3 bucket, e.g. s3://team-bucket/osdctl-notes
+	BucketConfigKey = "notes_bucket"
+	// BucketProfileConfigKey is the AWS profile the bucket is accessed with, the default one when unset
+	BucketProfileConfigKey = "notes_aws_profile"
+	// BucketRegionConfigKey is the region of the bucket, us-east-1 when unset
+	BucketRegionConfigKey = "notes_bucket_region"
+
+	defaultDirName = "osdctl-notes"
+	fileExtension  = ".notes"
+	keyLength      = 32
+)
+
+// Note is a note about a cluster
+type Note struct {
+	ID        string    `json:"id"`
+	ClusterID string    `json:"cluster_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+}
+
+// record is a line of a notes file, the note is encrypted with the notes key. The ID is kept in clear to merge the
+// notes of the team.
+type record struct {
+	ID    string `json:"id"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// Remote is where the notes files of the team are synced to
+type Remote interface {
+	// Get returns the content of the file, nil when there is none yet
+	Get(name string) ([]byte, error)
+	Put(name string, content []byte) error
+	// Name describes the remote, e.g. s3://team-bucket/osdctl-notes
+	Name() string
+}
+
+// Store reads and writes the notes
+type Store struct {
+	dir    string
+	gcm    cipher.AEAD
+	remote Remote
+
+	// Swapped in tests
+	now func() time.Time
+}
+
+// Dir returns the directory of the notes files
+func Dir() (string, error) {
+	if dir := viper.GetString(DirConfigKey); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", defaultDirName), nil
+}
+
+// Open returns the store of the configured directory, with the notes key of the secret store. The key is created
+// the first time: share it with the team through 'osdctl secrets set notes_key' to read each other's notes.
+func Open(remote Remote) (*Store, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	key, err := notesKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(dir, key, remote)
+}
+
+// NewStore returns the store of the directory, encrypting the notes with the key
+func NewStore(dir string, key []byte, remote Remote) (*Store, error) {
+	if len(key) != keyLength {
+		return nil, fmt.Errorf("the notes key must be %d bytes, got %d", keyLength, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir, gcm: gcm, remote: remote, now: time.Now}, nil
+}
+
+func notesKey() ([]byte, error) {
+	store, err := secrets.New()
+	if err != nil {
+		return nil, err
+	}
+	value, err := store.Get(secrets.NotesKeyKey)
+	if errors.Is(err, secrets.ErrNotFound) {
+		key := make([]byte, keyLength)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := store.Set(secrets.NotesKeyKey, base64.StdEncoding.EncodeToString(key)); err != nil {
+			return nil, fmt.Errorf("cannot store the new notes key in the %s: %w", store.Name(), err)
+		}
+		fmt.Fprintf(os.Stderr, "Created a notes key in the %s, share it with 'osdctl secrets set %s' for the team to read the notes\n", store.Name(), secrets.NotesKeyKey)
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read '%s' from the %s: %w", secrets.NotesKeyKey, store.Name(), err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("'%s' isn't base64: %w", secrets.NotesKeyKey, err)
+	}
+	return key, nil
+}
+
+func (s *Store) path(clusterID string) string {
+	return filepath.Join(s.dir, clusterID+fileExtension)
+}
+
+// Add adds a note about the cluster, and pushes the notes of the cluster to the remote when there is one
+func (s *Store) Add(clusterID, author, text string) (Note, error) {
+	id := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return Note{}, err
+	}
+	note := Note{ID: hex.EncodeToString(id), ClusterID: clusterID, Timestamp: s.now().UTC(), Author: author, Text: text}
+	plaintext, err := json.Marshal(note)
+	if err != nil {
+		return Note{}, err
+	}
+	nonce := make([]byte, s.gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return Note{}, err
+	}
+	// The cluster and the ID are authenticated, a note can't be moved to another cluster
+	added := record{ID: note.ID, Nonce: nonce, Data: s.gcm.Seal(nil, nonce, plaintext, []byte(clusterID+"/"+note.ID))}
+
+	records, err := s.sync(clusterID)
+	if err != nil {
+		return Note{}, err
+	}
+	records = append(records, added)
+	content, err := encodeRecords(records)
+	if err != nil {
+		return Note{}, err
+	}
+	if err := s.write(clusterID, content); err != nil {
+		return Note{}, err
+	}
+	if s.remote != nil {
+		if err := s.remote.Put(filepath.Base(s.path(clusterID)), content); err != nil {
+			return note, fmt.Errorf("the note was saved locally, but not pushed to %s: %w", s.remote.Name(), err)
+		}
+	}
+	return note, nil
+}
+
+// List returns the notes about the cluster, oldest first, with the ones of the remote when there is one
+func (s *Store) List(clusterID string) ([]Note, error) {
+	records, err := s.sync(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	notes := []Note{}
+	for _, r := range records {
+		plaintext, err := s.gcm.Open(nil, r.Nonce, r.Data, []byte(clusterID+"/"+r.ID))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decrypt note %s of cluster %s, was it written with another '%s'?", r.ID, clusterID, secrets.NotesKeyKey)
+		}
+		var note Note
+		if err := json.Unmarshal(plaintext, &note); err != nil {
+			return nil, fmt.Errorf("cannot parse note %s of cluster %s: %w", r.ID, clusterID, err)
+		}
+		notes = append(notes, note)
+	}
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Timestamp.Before(notes[j].Timestamp) })
+	return notes, nil
+}
+
+// sync returns the records of the local file merged with the ones of the remote, and saves them locally
+func (s *Store) sync(clusterID string) ([]record, error) {
+	content, err := os.ReadFile(s.path(clusterID))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	records, err := decodeRecords(content)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", s.path(clusterID), err)
+	}
+	if s.remote == nil {
+		return records, nil
+	}
+
+	remoteContent, err := s.remote.Get(filepath.Base(s.path(clusterID)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the notes of cluster %s from %s: %w", clusterID, s.remote.Name(), err)
+	}
+	remoteRecords, err := decodeRecords(remoteContent)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the notes of cluster %s from %s: %w", clusterID, s.remote.Name(), err)
+	}
+	merged := mergeRecords(records, remoteRecords)
+	if len(merged) == len(records) {
+		return merged, nil
+	}
+	content, err = encodeRecords(merged)
+	if err != nil {
+		return nil, err
+	}
+	return merged, s.write(clusterID, content)
+}
+
+// mergeRecords appends the remote records missing from the local ones
+func mergeRecords(local, remote []record) []record {
+	known := map[string]bool{}
+	for _, r := range local {
+		known[r.ID] = true
+	}
+	for _, r := range remote {
+		if !known[r.ID] {
+			local = append(local, r)
+			known[r.ID] = true
+		}
+	}
+	return local
+}
+
+// write replaces the notes file of the cluster, only readable by the current user
+func (s *Store) write(clusterID string, content []byte) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, "."+clusterID+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(clusterID))
+}
+
+func decodeRecords(content []byte) ([]record, error) {
+	var records []record
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func encodeRecords(records []record) ([]byte, error) {
+	var b bytes.Buffer
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package notes
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryRemote struct {
+	files map[string][]byte
+	puts  int
+}
+
+func (r *memoryRemote) Get(name string) ([]byte, error) { return r.files[name], nil }
+
+func (r *memoryRemote) Put(name string, content []byte) error {
+	r.files[name] = content
+	r.puts++
+	return nil
+}
+
+func (r *memoryRemote) Name() string { return "memory" }
+
+func testStore(t *testing.T, dir string, key byte, remote Remote) *Store {
+	t.Helper()
+	store, err := NewStore(dir, bytes.Repeat([]byte{key}, keyLength), remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestAddAndList(t *testing.T) {
+	dir := t.TempDir()
+	store := testStore(t, dir, 1, nil)
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	if _, err := store.Add("abc", "alice", "etcd defragmented"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(-time.Hour)
+	if _, err := store.Add("abc", "bob", "disk pressure on worker-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := store.List("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || notes[0].Author != "bob" || notes[1].Text != "etcd defragmented" {
+		t.Errorf("unexpected notes, oldest first expected: %+v", notes)
+	}
+	if other, err := store.List("def"); err != nil || len(other) != 0 {
+		t.Errorf("expected no note about another cluster, got %+v, %v", other, err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "abc.notes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "etcd") {
+		t.Errorf("the notes file isn't encrypted: %s", content)
+	}
+}
+
+func TestListWithAnotherKey(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := testStore(t, dir, 1, nil).Add("abc", "alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testStore(t, dir, 2, nil).List("abc"); err == nil || !strings.Contains(err.Error(), "notes_key") {
+		t.Errorf("expected an error hinting at the key, got %v", err)
+	}
+}
+
+func TestNoteMovedToAnotherCluster(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := testStore(t, dir, 1, nil).Add("abc", "alice", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "abc.notes"), filepath.Join(dir, "def.notes")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testStore(t, dir, 1, nil).List("def"); err == nil {
+		t.Error("expected the note moved to another cluster not to decrypt")
+	}
+}
+
+func TestSyncWithRemote(t *testing.T) {
+	remote := &memoryRemote{files: map[string][]byte{}}
+	alice := testStore(t, t.TempDir(), 1, remote)
+	bob := testStore(t, t.TempDir(), 1, remote)
+
+	if _, err := alice.Add("abc", "alice", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bob.Add("abc", "bob", "second"); err != nil {
+		t.Fatal(err)
+	}
+	if remote.puts != 2 {
+		t.Errorf("expected 2 pushes, got %d", remote.puts)
+	}
+
+	notes, err := alice.List("abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 {
+		t.Fatalf("expected the notes of bob to be pulled, got %+v", notes)
+	}
+
+	// The pulled notes are kept locally
+	offline := testStore(t, alice.dir, 1, nil)
+	if notes, err := offline.List("abc"); err != nil || len(notes) != 2 {
+		t.Errorf("expected 2 local notes, got %+v, %v", notes, err)
+	}
+}
+
+func TestNewS3Remote(t *testing.T) {
+	remote, err := NewS3Remote(nil, "s3://team-bucket/osdctl/notes/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remote.bucket != "team-bucket" || remote.key("abc.notes") != "osdctl/notes/abc.notes" || remote.Name() != "s3://team-bucket/osdctl/notes" {
+		t.Errorf("unexpected remote %+v", remote)
+	}
+	if remote, _ := NewS3Remote(nil, "s3://team-bucket"); remote.key("abc.notes") != "abc.notes" {
+		t.Errorf("unexpected key %s", remote.key("abc.notes"))
+	}
+	for _, url := range []string{"team-bucket", "s3://", "https://team-bucket"} {
+		if _, err := NewS3Remote(nil, url); err == nil {
+			t.Errorf("expected %s to be invalid", url)
+		}
+	}
+}
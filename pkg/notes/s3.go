@@ -0,0 +1,88 @@
+package notes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/openshift/osdctl/cmd/common"
+	awsprovider "github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/spf13/viper"
+)
+
+// maxRemoteSize bounds what is read of a remote notes file
+const maxRemoteSize = 16 << 20
+
+// S3Remote syncs the notes files to a prefix of an S3 bucket
+type S3Remote struct {
+	client awsprovider.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Remote returns the remote of a URL like s3://team-bucket/osdctl-notes
+func NewS3Remote(client awsprovider.Client, url string) (*S3Remote, error) {
+	location := strings.TrimPrefix(url, "s3://")
+	if !strings.HasPrefix(url, "s3://") || location == "" {
+		return nil, fmt.Errorf("invalid '%s' '%s', expected s3://BUCKET[/PREFIX]", BucketConfigKey, url)
+	}
+	bucket, prefix, _ := strings.Cut(location, "/")
+	return &S3Remote{client: client, bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// ConfiguredRemote returns the remote of the config file, nil when the notes aren't synced
+func ConfiguredRemote() (Remote, error) {
+	url := viper.GetString(BucketConfigKey)
+	if url == "" {
+		return nil, nil
+	}
+	region := viper.GetString(BucketRegionConfigKey)
+	if region == "" {
+		region = common.DefaultRegion
+	}
+	client, err := awsprovider.NewAwsClient(viper.GetString(BucketProfileConfigKey), region, "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create the AWS client of the notes bucket: %w", err)
+	}
+	return NewS3Remote(client, url)
+}
+
+func (r *S3Remote) key(name string) string {
+	return path.Join(r.prefix, name)
+}
+
+// Name returns the URL of the remote
+func (r *S3Remote) Name() string {
+	return "s3://" + path.Join(r.bucket, r.prefix)
+}
+
+// Get returns the content of the object, nil when it doesn't exist
+func (r *S3Remote) Get(name string) ([]byte, error) {
+	output, err := r.client.GetObject(&s3.GetObjectInput{Bucket: aws.String(r.bucket), Key: aws.String(r.key(name))})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+	return io.ReadAll(io.LimitReader(output.Body, maxRemoteSize))
+}
+
+// Put replaces the object, encrypted at rest by S3 too
+func (r *S3Remote) Put(name string, content []byte) error {
+	_, err := r.client.PutObject(&s3.PutObjectInput{
+		Bucket:               aws.String(r.bucket),
+		Key:                  aws.String(r.key(name)),
+		Body:                 bytes.NewReader(content),
+		ServerSideEncryption: aws.String(s3.ServerSideEncryptionAes256),
+	})
+	return err
+}
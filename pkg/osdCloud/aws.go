@@ -17,6 +17,8 @@ import (
 
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	"github.com/openshift/osdctl/pkg/provider/aws"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/trace"
 	"github.com/openshift/osdctl/pkg/utils"
 	"github.com/spf13/viper"
 )
@@ -42,22 +44,29 @@ func GenerateOrganizationAccountAccessCredentials(client aws.Client, accountId,
 
 	targetRoleArn.Partition = partition
 
-	assumeRoleOutput, err := client.AssumeRole(
-		&sts.AssumeRoleInput{
-			RoleArn:         awsSdk.String(targetRoleArn.String()),
-			RoleSessionName: awsSdk.String(sessionName),
-		},
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return assumeRoleOutput.Credentials, nil
-
+	return assumeRoleCached(targetRoleArn.String(), sessionName, func() (*sts.Credentials, error) {
+		assumeRoleOutput, err := client.AssumeRole(
+			&sts.AssumeRoleInput{
+				RoleArn:         awsSdk.String(targetRoleArn.String()),
+				RoleSessionName: awsSdk.String(sessionName),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		return assumeRoleOutput.Credentials, nil
+	})
 }
 
 // Uses the provided IAM Client to perform the Assume Role chain needed to get to a cluster's Support Role
+// The chain is skipped while the cached credentials of the Support Role are valid
 func GenerateSupportRoleCredentials(client aws.Client, awsAccountID, region, sessionName, targetRole string) (*sts.Credentials, error) {
+	return assumeRoleCached(targetRole, sessionName, func() (*sts.Credentials, error) {
+		return generateSupportRoleCredentials(client, awsAccountID, region, sessionName, targetRole)
+	})
+}
+
+func generateSupportRoleCredentials(client aws.Client, awsAccountID, region, sessionName, targetRole string) (*sts.Credentials, error) {
 
 	// Perform the Assume Role chain to get the jump
 	jumpRoleCreds, err := GenerateJumpRoleCredentials(client, awsAccountID, region, sessionName)
@@ -107,13 +116,20 @@ func GenerateJumpRoleCredentials(client aws.Client, awsAccountID, region, sessio
 	}
 
 	// Assume RH-SRE-CCS-Access role
-	sreCcsAccessRoleArn := aws.GenerateRoleARN(sreUserArn.AccountID, RhSreCcsAccessRolename)
-	sreCcsAccessAssumeRoleOutput, err := client.AssumeRole(
-		&sts.AssumeRoleInput{
-			RoleArn:         awsSdk.String(sreCcsAccessRoleArn),
-			RoleSessionName: awsSdk.String(sessionName),
-		},
-	)
+	// The roles of the chain are in the partition of the SRE user, e.g. aws-us-gov for GovCloud
+	sreCcsAccessRoleArn := aws.GenerateRoleARNInPartition(sreUserArn.Partition, sreUserArn.AccountID, RhSreCcsAccessRolename)
+	sreCcsAccessCreds, err := assumeRoleCached(sreCcsAccessRoleArn, sessionName, func() (*sts.Credentials, error) {
+		sreCcsAccessAssumeRoleOutput, err := client.AssumeRole(
+			&sts.AssumeRoleInput{
+				RoleArn:         awsSdk.String(sreCcsAccessRoleArn),
+				RoleSessionName: awsSdk.String(sessionName),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		return sreCcsAccessAssumeRoleOutput.Credentials, nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -121,9 +137,9 @@ func GenerateJumpRoleCredentials(client aws.Client, awsAccountID, region, sessio
 	// Build client for RH-SRE-CCS-Access role
 	sreCcsAccessRoleClient, err := aws.NewAwsClientWithInput(
 		&aws.AwsClientInput{
-			AccessKeyID:     *sreCcsAccessAssumeRoleOutput.Credentials.AccessKeyId,
-			SecretAccessKey: *sreCcsAccessAssumeRoleOutput.Credentials.SecretAccessKey,
-			SessionToken:    *sreCcsAccessAssumeRoleOutput.Credentials.SessionToken,
+			AccessKeyID:     *sreCcsAccessCreds.AccessKeyId,
+			SecretAccessKey: *sreCcsAccessCreds.SecretAccessKey,
+			SessionToken:    *sreCcsAccessCreds.SessionToken,
 			Region:          *awsSdk.String(region),
 		},
 	)
@@ -144,19 +160,19 @@ func GenerateJumpRoleCredentials(client aws.Client, awsAccountID, region, sessio
 	// This will be different between stage and prod. There's probably a better way to do this that isn't hardcoding
 	jumproleAccountID := viper.GetString(jumpRoleKey)
 
-	jumpRoleArn := aws.GenerateRoleARN(jumproleAccountID, RhTechnicalSupportAccess)
-	jumpAssumeRoleOutput, err := sreCcsAccessRoleClient.AssumeRole(
-		&sts.AssumeRoleInput{
-			RoleArn:         awsSdk.String(jumpRoleArn),
-			RoleSessionName: awsSdk.String(sessionName),
-		},
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return jumpAssumeRoleOutput.Credentials, nil
-
+	jumpRoleArn := aws.GenerateRoleARNInPartition(sreUserArn.Partition, jumproleAccountID, RhTechnicalSupportAccess)
+	return assumeRoleCached(jumpRoleArn, sessionName, func() (*sts.Credentials, error) {
+		jumpAssumeRoleOutput, err := sreCcsAccessRoleClient.AssumeRole(
+			&sts.AssumeRoleInput{
+				RoleArn:         awsSdk.String(jumpRoleArn),
+				RoleSessionName: awsSdk.String(sessionName),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		return jumpAssumeRoleOutput.Credentials, nil
+	})
 }
 
 // Uses the current IAM ARN to generate a role name. This should end up being RH-SRE-$kerberosID
@@ -219,7 +235,7 @@ func CreateAWSV2Config(ctx context.Context, clusterID string) (awsv2.Config, err
 		return awsv2.Config{}, fmt.Errorf("failed to retrieve AWS credentials from backplane, ensure there is an active backplane tunnel: %s", err)
 	}
 
-	return config.LoadDefaultConfig(ctx,
+	options := []func(*config.LoadOptions) error{
 		config.WithCredentialsProvider(
 			awsv2.NewCredentialsCache(
 				credentials.NewStaticCredentialsProvider(
@@ -230,7 +246,8 @@ func CreateAWSV2Config(ctx context.Context, clusterID string) (awsv2.Config, err
 			),
 		),
 		config.WithRegion(input.Region),
-	)
+	}
+	return config.LoadDefaultConfig(ctx, append(append(options, trace.AWSV2Options()...), recording.AWSV2Options()...)...)
 }
 
 // GetAWSClientInputFromBackplane sets up AWS credentials via backplane-api given a cluster id
@@ -327,8 +344,7 @@ func GenerateNonCCSClusterAWSClient(ocmClient *sdk.Connection, awsClient aws.Cli
 	// If the cluster is non-CCS, or an AWS Account ID was provided with -i, try and use OrganizationAccountAccessRole
 	assumedRoleCreds, err := GenerateOrganizationAccountAccessCredentials(awsClient, accountID, sessionName, partition)
 	if err != nil {
-		fmt.Printf("Could not build AWS Client for OrganizationAccountAccessRole: %s\n", err)
-		return nil, err
+		return nil, fmt.Errorf("could not build AWS Client for OrganizationAccountAccessRole: %w", err)
 	}
 
 	awsClientNonCCS, err := aws.NewAwsClientWithInput(&aws.AwsClientInput{
@@ -352,7 +368,6 @@ func GenerateAWSClientForCluster(awsProfile string, clusterID string) (aws.Clien
 
 	cluster, err := utils.GetClusterAnyStatus(ocmClient, clusterID)
 	if err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 	clusterRegion := cluster.Region().ID()
@@ -361,12 +376,15 @@ func GenerateAWSClientForCluster(awsProfile string, clusterID string) (aws.Clien
 	// Builds the base client using the provided creds (via profile or env vars)
 	awsClient, err := aws.NewAwsClient(awsProfile, clusterRegion, "")
 	if err != nil {
-		fmt.Printf("Could not build AWS Client: %s\n", err)
-		return nil, err
+		return nil, fmt.Errorf("could not build AWS Client: %w", err)
 	}
 
-	// Get the right partition for the final ARN
-	partition, err := aws.GetAwsPartition(awsClient)
+	// Get the right partition for the final ARN, the credentials have to be of the partition of the cluster region
+	credentialsPartition, err := aws.GetAwsPartition(awsClient)
+	if err != nil {
+		return nil, err
+	}
+	partition, err := CheckCredentialsPartition(credentialsPartition, clusterRegion)
 	if err != nil {
 		return nil, err
 	}
@@ -374,8 +392,7 @@ func GenerateAWSClientForCluster(awsProfile string, clusterID string) (aws.Clien
 	// Generate a session name using the SRE's kerberos ID
 	sessionName, err := GenerateRoleSessionName(awsClient)
 	if err != nil {
-		fmt.Printf("Could not generate Session Name: %s\n", err)
-		return nil, err
+		return nil, fmt.Errorf("could not generate Session Name: %w", err)
 	}
 
 	if cluster.CCS().Enabled() {
@@ -386,3 +403,14 @@ func GenerateAWSClientForCluster(awsProfile string, clusterID string) (aws.Clien
 
 	return awsClient, err
 }
+
+// CheckCredentialsPartition returns the partition of the region, e.g. of a cluster, failing when the credentials are
+// of another one as the roles of a partition can't be assumed from another
+func CheckCredentialsPartition(credentialsPartition, region string) (string, error) {
+	partition := aws.PartitionForRegion(region)
+	if credentialsPartition != partition {
+		return "", fmt.Errorf("region %s is in the %s partition, but the AWS credentials are for the %s partition, "+
+			"use a profile with credentials of the %s partition", region, partition, credentialsPartition, partition)
+	}
+	return partition, nil
+}
@@ -0,0 +1,60 @@
+package osdCloud
+
+import (
+	"errors"
+	"testing"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+)
+
+func TestGenerateRoleSessionName(t *testing.T) {
+	g := NewGomegaWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	mockAWSClient.EXPECT().GetCallerIdentity(gomock.Any()).Return(&sts.GetCallerIdentityOutput{
+		Arn: awsSdk.String("arn:aws:iam::123456789012:user/jdoe"),
+	}, nil)
+
+	sessionName, err := GenerateRoleSessionName(mockAWSClient)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(sessionName).To(Equal("RH-SRE-jdoe"))
+}
+
+func TestGenerateOrganizationAccountAccessCredentials(t *testing.T) {
+	g := NewGomegaWithT(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	expected := &sts.Credentials{AccessKeyId: awsSdk.String("AKIAEXAMPLE")}
+	mockAWSClient.EXPECT().AssumeRole(&sts.AssumeRoleInput{
+		RoleArn:         awsSdk.String("arn:aws-us-gov:iam::123456789012:role/OrganizationAccountAccessRole"),
+		RoleSessionName: awsSdk.String("RH-SRE-jdoe"),
+	}).Return(&sts.AssumeRoleOutput{Credentials: expected}, nil)
+
+	creds, err := GenerateOrganizationAccountAccessCredentials(mockAWSClient, "123456789012", "RH-SRE-jdoe", "aws-us-gov")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(creds).To(Equal(expected))
+
+	mockAWSClient.EXPECT().AssumeRole(gomock.Any()).Return(nil, errors.New("AccessDenied"))
+	_, err = GenerateOrganizationAccountAccessCredentials(mockAWSClient, "123456789012", "RH-SRE-jdoe", "aws")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestCheckCredentialsPartition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	partition, err := CheckCredentialsPartition("aws-us-gov", "us-gov-west-1")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(partition).To(Equal("aws-us-gov"))
+
+	_, err = CheckCredentialsPartition("aws", "cn-north-1")
+	g.Expect(err).To(MatchError(ContainSubstring("region cn-north-1 is in the aws-cn partition, but the AWS credentials are for the aws partition")))
+}
@@ -0,0 +1,124 @@
+package osdCloud
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/spf13/viper"
+)
+
+const (
+	// CredentialCacheConfigKey enables caching the assumed role credentials between commands
+	CredentialCacheConfigKey = "aws_credential_cache"
+
+	credentialCacheDirName = "aws-credentials"
+	// credentialExpiryMargin keeps cached credentials from expiring in the middle of a command
+	credentialExpiryMargin = 5 * time.Minute
+)
+
+func init() {
+	viper.SetDefault(CredentialCacheConfigKey, true)
+}
+
+// cachedCredentials is a cache entry, one file per role and session name
+type cachedCredentials struct {
+	RoleArn         string    `json:"role_arn"`
+	SessionName     string    `json:"session_name"`
+	AccessKeyID     string    `json:"access_key_id"`
+	SecretAccessKey string    `json:"secret_access_key"`
+	SessionToken    string    `json:"session_token"`
+	Expiration      time.Time `json:"expiration"`
+}
+
+// CredentialCacheDir returns the directory of the assumed role credentials cache
+func CredentialCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "osdctl", credentialCacheDirName), nil
+}
+
+func credentialCachePath(roleArn, sessionName string) (string, error) {
+	dir, err := CredentialCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(roleArn + "\n" + sessionName))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+// assumeRoleCached returns the credentials of the role from the cache while they are valid, and calls assume
+// to get and cache new ones otherwise. The cache is best effort: failing to read or write it only skips it.
+func assumeRoleCached(roleArn, sessionName string, assume func() (*sts.Credentials, error)) (*sts.Credentials, error) {
+	if !viper.GetBool(CredentialCacheConfigKey) {
+		return assume()
+	}
+
+	path, err := credentialCachePath(roleArn, sessionName)
+	if err != nil {
+		return assume()
+	}
+	if creds := loadCachedCredentials(path, time.Now()); creds != nil {
+		return creds, nil
+	}
+
+	creds, err := assume()
+	if err != nil {
+		return nil, err
+	}
+	if err := saveCachedCredentials(path, roleArn, sessionName, creds); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to cache the credentials of %s: %v\n", roleArn, err)
+	}
+	return creds, nil
+}
+
+// loadCachedCredentials returns the cached credentials unless they are missing, corrupt or about to expire
+func loadCachedCredentials(path string, now time.Time) *sts.Credentials {
+	data, err := os.ReadFile(path) //#nosec G304 -- path is derived from the user cache dir
+	if err != nil {
+		return nil
+	}
+	var entry cachedCredentials
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	if entry.AccessKeyID == "" || now.Add(credentialExpiryMargin).After(entry.Expiration) {
+		return nil
+	}
+	return &sts.Credentials{
+		AccessKeyId:     awsSdk.String(entry.AccessKeyID),
+		SecretAccessKey: awsSdk.String(entry.SecretAccessKey),
+		SessionToken:    awsSdk.String(entry.SessionToken),
+		Expiration:      awsSdk.Time(entry.Expiration),
+	}
+}
+
+func saveCachedCredentials(path, roleArn, sessionName string, creds *sts.Credentials) error {
+	// Credentials without an expiry can't be told apart from expired ones, they aren't cached
+	if creds == nil || creds.Expiration == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cachedCredentials{
+		RoleArn:         roleArn,
+		SessionName:     sessionName,
+		AccessKeyID:     awsSdk.StringValue(creds.AccessKeyId),
+		SecretAccessKey: awsSdk.StringValue(creds.SecretAccessKey),
+		SessionToken:    awsSdk.StringValue(creds.SessionToken),
+		Expiration:      awsSdk.TimeValue(creds.Expiration),
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,82 @@
+package osdCloud
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	awsSdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/provider/aws/mock"
+	"github.com/spf13/viper"
+)
+
+func TestGenerateOrganizationAccountAccessCredentialsCached(t *testing.T) {
+	g := NewGomegaWithT(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockAWSClient := mock.NewMockClient(mockCtrl)
+
+	expected := &sts.Credentials{
+		AccessKeyId:     awsSdk.String("AKIAEXAMPLE"),
+		SecretAccessKey: awsSdk.String("secret"),
+		SessionToken:    awsSdk.String("token"),
+		Expiration:      awsSdk.Time(time.Now().Add(time.Hour).UTC().Truncate(time.Second)),
+	}
+	// The role is only assumed once, the second call is served from the cache
+	mockAWSClient.EXPECT().AssumeRole(gomock.Any()).Return(&sts.AssumeRoleOutput{Credentials: expected}, nil).Times(1)
+
+	for i := 0; i < 2; i++ {
+		creds, err := GenerateOrganizationAccountAccessCredentials(mockAWSClient, "123456789012", "RH-SRE-jdoe", "aws")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(creds).To(Equal(expected))
+	}
+
+	// Another role or session name isn't served the cached credentials
+	mockAWSClient.EXPECT().AssumeRole(gomock.Any()).Return(&sts.AssumeRoleOutput{Credentials: expected}, nil).Times(1)
+	_, err := GenerateOrganizationAccountAccessCredentials(mockAWSClient, "123456789012", "RH-SRE-other", "aws")
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestAssumeRoleCached(t *testing.T) {
+	g := NewGomegaWithT(t)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	calls := 0
+	assume := func(expiration time.Time) func() (*sts.Credentials, error) {
+		return func() (*sts.Credentials, error) {
+			calls++
+			return &sts.Credentials{AccessKeyId: awsSdk.String("AKIAEXAMPLE"), Expiration: awsSdk.Time(expiration)}, nil
+		}
+	}
+
+	// Credentials about to expire are assumed again
+	_, err := assumeRoleCached("arn:aws:iam::123456789012:role/a", "s", assume(time.Now().Add(time.Minute)))
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = assumeRoleCached("arn:aws:iam::123456789012:role/a", "s", assume(time.Now().Add(time.Hour)))
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = assumeRoleCached("arn:aws:iam::123456789012:role/a", "s", assume(time.Now().Add(time.Hour)))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(calls).To(Equal(2))
+
+	path, err := credentialCachePath("arn:aws:iam::123456789012:role/a", "s")
+	g.Expect(err).NotTo(HaveOccurred())
+	info, err := os.Stat(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	// A corrupt entry is ignored
+	g.Expect(os.WriteFile(path, []byte("{"), 0600)).To(Succeed())
+	_, err = assumeRoleCached("arn:aws:iam::123456789012:role/a", "s", assume(time.Now().Add(time.Hour)))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(calls).To(Equal(3))
+
+	viper.Set(CredentialCacheConfigKey, false)
+	defer viper.Set(CredentialCacheConfigKey, true)
+	_, err = assumeRoleCached("arn:aws:iam::123456789012:role/a", "s", assume(time.Now().Add(time.Hour)))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(calls).To(Equal(4))
+}
@@ -1,11 +1,7 @@
 package osdCloud
 
 import (
-	"context"
 	"encoding/json"
-
-	compute "cloud.google.com/go/compute/apiv1"
-	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
 )
 
 type GcpProjectClaimSpec struct {
@@ -23,18 +19,3 @@ func ParseGcpProjectClaim(raw string) (*GcpProjectClaim, error) {
 	}
 	return &projectClaim, nil
 }
-
-func GenerateGCPComputeInstancesClient() (*compute.InstancesClient, error) {
-	ctx := context.Background()
-	client, err := compute.NewInstancesRESTClient(ctx)
-	return client, err
-}
-
-func ListInstances(client *compute.InstancesClient, projectID, zone string) *compute.InstanceIterator {
-	ctx := context.Background()
-	request := &computepb.ListInstancesRequest{
-		Project: projectID,
-		Zone:    zone,
-	}
-	return client.List(ctx, request)
-}
@@ -9,9 +9,17 @@ import (
 
 const (
 	ConfigFileName = "osdctl"
+	// ConfigEnv points at another config file, e.g. a profile written by 'osdctl auth mint-token' for automation
+	ConfigEnv = "OSDCTL_CONFIG"
 )
 
 func EnsureConfigFile() error {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		viper.SetConfigFile(path)
+		viper.SetConfigType("yaml")
+		return viper.ReadInConfig()
+	}
+
 	configHomePath, err := os.UserHomeDir()
 	if err != nil {
 		return err
@@ -0,0 +1,278 @@
+// Package osdctlErrors classifies the errors of osdctl commands, so that scripts wrapping osdctl can branch on
+// the exit code or on the JSON error printed with --output json, instead of matching error messages.
+package osdctlErrors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// The error classes. Wrap errors with New or Wrap, and test them with errors.Is.
+var (
+	// ErrNotFound is returned when a cluster, account or other resource doesn't exist
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned when the user isn't logged in or isn't allowed to do the operation
+	ErrForbidden = errors.New("forbidden")
+	// ErrValidation is returned for invalid arguments, flags, files or templates
+	ErrValidation = errors.New("validation failed")
+	// ErrTransient is returned for failures that may go away when retried: timeouts, throttling, 5xx
+	ErrTransient = errors.New("transient failure")
+)
+
+// The process exit codes of the error classes. 1 is kept for unclassified errors.
+const (
+	ExitGeneric    = 1
+	ExitValidation = 2
+	ExitNotFound   = 3
+	ExitForbidden  = 4
+	ExitTransient  = 5
+)
+
+var classes = []struct {
+	err      error
+	name     string
+	exitCode int
+}{
+	{ErrValidation, "validation", ExitValidation},
+	{ErrNotFound, "not_found", ExitNotFound},
+	{ErrForbidden, "forbidden", ExitForbidden},
+	{ErrTransient, "transient", ExitTransient},
+}
+
+// usageMessages are parts of the untyped errors returned for invalid arguments and flags
+var usageMessages = []string{
+	"for help and examples",
+	"unknown flag: ",
+	"unknown shorthand flag: ",
+	"unknown command ",
+	"required flag(s) ",
+	"invalid argument ",
+	"flag needs an argument: ",
+}
+
+var (
+	outputFormat   string
+	outputFormatMu sync.Mutex
+	exit           = os.Exit
+	exitHooks      []func(error)
+	translators    []func(error) error
+)
+
+// classified is an error of a class, errors.Is matches both the class and the wrapped error
+type classified struct {
+	class error
+	err   error
+}
+
+func (e *classified) Error() string {
+	return e.err.Error()
+}
+
+func (e *classified) Unwrap() error {
+	return e.err
+}
+
+func (e *classified) Is(target error) bool {
+	return target == e.class
+}
+
+// New returns an error of the class with the formatted message, %w wraps like fmt.Errorf
+func New(class error, format string, args ...interface{}) error {
+	return &classified{class: class, err: fmt.Errorf(format, args...)}
+}
+
+// Wrap sets the class of err, it returns nil when err is nil
+func Wrap(class error, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &classified{class: class, err: err}
+}
+
+// WrapStatus sets the class of err from the HTTP status of the failed API call, for the responses read without an
+// SDK error type. err is returned as is when the status has no class.
+func WrapStatus(status int, err error) error {
+	if class := classifyStatus(status); class != nil {
+		return Wrap(class, err)
+	}
+	return err
+}
+
+// Classify returns the class of the error: ErrNotFound, ErrForbidden, ErrValidation, ErrTransient, or nil
+// when it can't be classified. Errors wrapped with New or Wrap come first, then the OCM, Kubernetes and
+// AWS API errors by status code, then network timeouts and usage errors.
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, class := range classes {
+		if errors.Is(err, class.err) {
+			return class.err
+		}
+	}
+
+	var ocmErr *ocmerrors.Error
+	if errors.As(err, &ocmErr) {
+		return classifyStatus(ocmErr.Status())
+	}
+	var statusErr apierrors.APIStatus
+	if errors.As(err, &statusErr) {
+		return classifyStatus(int(statusErr.Status().Code))
+	}
+	var requestErr awserr.RequestFailure
+	if errors.As(err, &requestErr) {
+		if class := classifyStatus(requestErr.StatusCode()); class != nil {
+			return class
+		}
+	}
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		switch awsErr.Code() {
+		case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation", "ExpiredToken", "InvalidClientTokenId":
+			return ErrForbidden
+		case "NoSuchEntity", "NotFoundException", "ResourceNotFoundException":
+			return ErrNotFound
+		case "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequestsException", "RequestTimeout":
+			return ErrTransient
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTransient
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTransient
+	}
+
+	// Usage errors of cobra and kubectl's UsageErrorf aren't typed
+	message := err.Error()
+	for _, usage := range usageMessages {
+		if strings.Contains(message, usage) {
+			return ErrValidation
+		}
+	}
+	return nil
+}
+
+func classifyStatus(status int) error {
+	switch {
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity || status == http.StatusConflict:
+		return ErrValidation
+	case status == http.StatusTooManyRequests || status == http.StatusRequestTimeout || status >= 500:
+		return ErrTransient
+	}
+	return nil
+}
+
+// Name returns the machine-readable name of the error class, "error" when it can't be classified
+func Name(err error) string {
+	class := Classify(err)
+	for _, c := range classes {
+		if c.err == class {
+			return c.name
+		}
+	}
+	return "error"
+}
+
+// ExitCode returns the process exit code of the error, 0 when err is nil
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	class := Classify(err)
+	for _, c := range classes {
+		if c.err == class {
+			return c.exitCode
+		}
+	}
+	return ExitGeneric
+}
+
+// SetOutputFormat sets the --output format, errors are printed as JSON when it is json
+func SetOutputFormat(format string) {
+	outputFormatMu.Lock()
+	defer outputFormatMu.Unlock()
+	outputFormat = format
+}
+
+// jsonError is how errors are printed with --output json
+type jsonError struct {
+	Error struct {
+		Class    string `json:"class"`
+		Message  string `json:"message"`
+		ExitCode int    `json:"exit_code"`
+	} `json:"error"`
+}
+
+// Print writes the error to w, as JSON with --output json
+func Print(w io.Writer, err error) {
+	outputFormatMu.Lock()
+	format := outputFormat
+	outputFormatMu.Unlock()
+
+	message := strings.TrimSpace(err.Error())
+	if format != "json" {
+		if !strings.HasPrefix(message, "error: ") {
+			message = "error: " + message
+		}
+		fmt.Fprintln(w, message)
+		return
+	}
+
+	var output jsonError
+	output.Error.Class = Name(err)
+	output.Error.Message = message
+	output.Error.ExitCode = ExitCode(err)
+	data, marshalErr := json.Marshal(output)
+	if marshalErr != nil {
+		fmt.Fprintln(w, message)
+		return
+	}
+	fmt.Fprintln(w, string(data))
+}
+
+// CheckErr prints the error to stderr and exits with the exit code of its class, it does nothing when err
+// is nil. Commands use it in place of kubectl's CheckErr, which always exits with 1.
+func CheckErr(err error) {
+	if err == nil {
+		return
+	}
+	for _, translate := range translators {
+		err = translate(err)
+	}
+	Print(os.Stderr, err)
+	for _, hook := range exitHooks {
+		hook(err)
+	}
+	exit(ExitCode(err))
+}
+
+// OnExit registers a function CheckErr calls with the error before exiting, as the commands failing exit there
+// without returning to main
+func OnExit(hook func(error)) {
+	exitHooks = append(exitHooks, hook)
+}
+
+// AddTranslator registers a function CheckErr passes the error through before printing it, to explain the
+// errors whose cause is only known to osdctl, like a request canceled by --timeout
+func AddTranslator(translate func(error) error) {
+	translators = append(translators, translate)
+}
@@ -0,0 +1,142 @@
+package osdctlErrors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	. "github.com/onsi/gomega"
+	ocmerrors "github.com/openshift-online/ocm-sdk-go/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassify(t *testing.T) {
+	ocmNotFound, err := ocmerrors.NewError().Status(404).Reason("Cluster 'abc' not found").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ocmThrottled, err := ocmerrors.NewError().Status(429).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		class     error
+		exitCode  int
+		className string
+	}{
+		{"nil", nil, nil, 0, "error"},
+		{"unclassified", errors.New("boom"), nil, ExitGeneric, "error"},
+		{"new", New(ErrNotFound, "no cluster %s", "abc"), ErrNotFound, ExitNotFound, "not_found"},
+		{"wrapped by fmt", fmt.Errorf("cannot post: %w", Wrap(ErrValidation, errors.New("bad template"))), ErrValidation, ExitValidation, "validation"},
+		{"ocm not found", fmt.Errorf("can't retrieve cluster: %w", ocmNotFound), ErrNotFound, ExitNotFound, "not_found"},
+		{"ocm throttled", ocmThrottled, ErrTransient, ExitTransient, "transient"},
+		{"kubernetes forbidden", apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "etcd", errors.New("no")), ErrForbidden, ExitForbidden, "forbidden"},
+		{"kubernetes not found", apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "etcd"), ErrNotFound, ExitNotFound, "not_found"},
+		{"aws request failure", awserr.NewRequestFailure(awserr.New("InternalError", "oops", nil), 503, "id"), ErrTransient, ExitTransient, "transient"},
+		{"aws access denied", awserr.New("AccessDenied", "not allowed", nil), ErrForbidden, ExitForbidden, "forbidden"},
+		{"deadline", fmt.Errorf("waiting: %w", context.DeadlineExceeded), ErrTransient, ExitTransient, "transient"},
+		{"usage", errors.New("missing cluster\nSee 'osdctl cluster list -h' for help and examples"), ErrValidation, ExitValidation, "validation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+
+			if tt.class == nil {
+				g.Expect(Classify(tt.err)).To(BeNil())
+			} else {
+				g.Expect(Classify(tt.err)).To(Equal(tt.class))
+			}
+			g.Expect(ExitCode(tt.err)).To(Equal(tt.exitCode))
+			g.Expect(Name(tt.err)).To(Equal(tt.className))
+		})
+	}
+}
+
+func TestWrapKeepsCause(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cause := errors.New("bad template")
+	err := Wrap(ErrValidation, cause)
+	g.Expect(err.Error()).To(Equal("bad template"))
+	g.Expect(errors.Is(err, cause)).To(BeTrue())
+	g.Expect(errors.Is(err, ErrValidation)).To(BeTrue())
+	g.Expect(errors.Is(err, ErrNotFound)).To(BeFalse())
+	g.Expect(Wrap(ErrValidation, nil)).To(BeNil())
+}
+
+func TestWrapStatus(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cause := errors.New("bad response")
+	g.Expect(errors.Is(WrapStatus(404, cause), ErrNotFound)).To(BeTrue())
+	g.Expect(errors.Is(WrapStatus(403, cause), ErrForbidden)).To(BeTrue())
+	g.Expect(errors.Is(WrapStatus(502, cause), ErrTransient)).To(BeTrue())
+	g.Expect(WrapStatus(418, cause)).To(Equal(cause))
+	g.Expect(WrapStatus(404, nil)).To(BeNil())
+}
+
+func TestPrint(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer SetOutputFormat("")
+
+	var text bytes.Buffer
+	SetOutputFormat("")
+	Print(&text, New(ErrNotFound, "no cluster abc"))
+	g.Expect(text.String()).To(Equal("error: no cluster abc\n"))
+
+	var output bytes.Buffer
+	SetOutputFormat("json")
+	Print(&output, New(ErrNotFound, "no cluster abc"))
+	g.Expect(output.String()).To(MatchJSON(`{"error":{"class":"not_found","message":"no cluster abc","exit_code":3}}`))
+}
+
+func TestCheckErr(t *testing.T) {
+	g := NewGomegaWithT(t)
+	saved := exit
+	defer func() { exit = saved }()
+
+	code := -1
+	exit = func(c int) { code = c }
+
+	CheckErr(nil)
+	g.Expect(code).To(Equal(-1))
+
+	CheckErr(New(ErrTransient, "throttled"))
+	g.Expect(code).To(Equal(ExitTransient))
+}
+
+func TestCheckErrRunsExitHooks(t *testing.T) {
+	g := NewGomegaWithT(t)
+	savedExit, savedHooks := exit, exitHooks
+	defer func() { exit, exitHooks = savedExit, savedHooks }()
+	exit = func(int) {}
+
+	var seen error
+	OnExit(func(err error) { seen = err })
+	CheckErr(nil)
+	g.Expect(seen).To(BeNil())
+
+	err := New(ErrNotFound, "no cluster")
+	CheckErr(err)
+	g.Expect(seen).To(Equal(err))
+}
+
+func TestCheckErrRunsTranslators(t *testing.T) {
+	g := NewGomegaWithT(t)
+	savedExit, savedTranslators := exit, translators
+	defer func() { exit, translators = savedExit, savedTranslators }()
+	var code int
+	exit = func(c int) { code = c }
+
+	AddTranslator(func(err error) error { return New(ErrTransient, "timed out: %w", err) })
+	CheckErr(errors.New("context deadline exceeded"))
+	g.Expect(code).To(Equal(ExitTransient))
+}
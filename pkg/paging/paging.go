@@ -0,0 +1,148 @@
+// Package paging fetches the pages of an OCM list concurrently, at most a configured number at a time, and hands their
+// items over in the order of the pages as soon as they arrive, so that the commands listing a large fleet can print
+// the first rows while the next pages are still being fetched.
+package paging
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConcurrencyConfigKey is the number of pages of an OCM list fetched at the same time, 1 fetches them one by one
+	ConcurrencyConfigKey = "ocm_page_concurrency"
+	ConcurrencyFlag      = "ocm-page-concurrency"
+
+	defaultConcurrency = 4
+)
+
+func init() {
+	viper.SetDefault(ConcurrencyConfigKey, defaultConcurrency)
+}
+
+// AddFlags adds the --ocm-page-concurrency flag to the given command and binds it to the config key
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Int(ConcurrencyFlag, defaultConcurrency, "Maximum OCM list pages fetched at the same time, 1 to fetch them one by one (config key: "+ConcurrencyConfigKey+")")
+	_ = viper.BindPFlag(ConcurrencyConfigKey, cmd.PersistentFlags().Lookup(ConcurrencyFlag))
+}
+
+// Concurrency returns the configured number of pages fetched at the same time, at least 1
+func Concurrency() int {
+	if concurrency := viper.GetInt(ConcurrencyConfigKey); concurrency > 1 {
+		return concurrency
+	}
+	return 1
+}
+
+// Page is a page of a list, with the total number of items of the list as OCM reports it
+type Page[T any] struct {
+	Items []T
+	Total int
+}
+
+// Fetcher fetches a page of a list, the pages are numbered from 1. It is called from several goroutines, so it has to
+// build a new request every time, the OCM request builders aren't safe for concurrent use.
+type Fetcher[T any] func(ctx context.Context, page int) (Page[T], error)
+
+// Options of Fetch
+type Options struct {
+	// PageSize is the size of the pages the fetcher requests
+	PageSize int
+	// Limit is the maximum number of items, 0 for all
+	Limit int
+	// Concurrency is the maximum number of pages fetched at the same time, the configured one when 0
+	Concurrency int
+}
+
+type result[T any] struct {
+	page Page[T]
+	err  error
+}
+
+// Fetch fetches the first page, then the others concurrently, and calls emit with the items of every page in the order
+// of the pages, from the calling goroutine. At most Concurrency pages are fetched or waiting to be emitted at the same
+// time, so the memory doesn't grow with the size of the list. The first error, of a fetch or of emit, stops the fetch.
+func Fetch[T any](ctx context.Context, opts Options, fetch Fetcher[T], emit func(items []T) error) error {
+	if opts.PageSize < 1 {
+		return fmt.Errorf("invalid page size %d", opts.PageSize)
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = Concurrency()
+	}
+
+	first, err := fetch(ctx, 1)
+	if err != nil {
+		return err
+	}
+	emitted := 0
+	done, err := emitPage(first.Items, opts, &emitted, emit)
+	if err != nil || done {
+		return err
+	}
+	total := first.Total
+	if opts.Limit > 0 && opts.Limit < total {
+		total = opts.Limit
+	}
+	if emitted >= total {
+		return nil
+	}
+	lastPage := (total + opts.PageSize - 1) / opts.PageSize
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// A slot is taken when a page is requested and released once it has been emitted
+	slots := make(chan struct{}, concurrency)
+	results := make([]chan result[T], lastPage+1)
+	for page := 2; page <= lastPage; page++ {
+		results[page] = make(chan result[T], 1)
+	}
+	go func() {
+		for page := 2; page <= lastPage; page++ {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(page int) {
+				items, err := fetch(ctx, page)
+				results[page] <- result[T]{page: items, err: err}
+			}(page)
+		}
+	}()
+
+	for page := 2; page <= lastPage; page++ {
+		var r result[T]
+		select {
+		case r = <-results[page]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		<-slots
+		if r.err != nil {
+			return r.err
+		}
+		done, err := emitPage(r.page.Items, opts, &emitted, emit)
+		if err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitPage emits the items up to the limit, done is true once the limit is reached or the page is the last one
+func emitPage[T any](items []T, opts Options, emitted *int, emit func(items []T) error) (bool, error) {
+	done := len(items) < opts.PageSize
+	if opts.Limit > 0 && *emitted+len(items) >= opts.Limit {
+		items = items[:opts.Limit-*emitted]
+		done = true
+	}
+	*emitted += len(items)
+	if len(items) == 0 {
+		return done, nil
+	}
+	return done, emit(items)
+}
@@ -0,0 +1,151 @@
+package paging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// fakeList serves the pages of a list of numbers, recording how many pages were fetched at the same time
+type fakeList struct {
+	total    int
+	pageSize int
+	// delay makes the earlier pages slower, so that they arrive after the later ones
+	delay  func(page int) time.Duration
+	failAt int
+
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+	fetched  []int
+}
+
+func (l *fakeList) fetch(ctx context.Context, page int) (Page[int], error) {
+	l.mu.Lock()
+	l.inFlight++
+	if l.inFlight > l.maxSeen {
+		l.maxSeen = l.inFlight
+	}
+	l.fetched = append(l.fetched, page)
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		l.inFlight--
+		l.mu.Unlock()
+	}()
+
+	if l.delay != nil {
+		select {
+		case <-time.After(l.delay(page)):
+		case <-ctx.Done():
+			return Page[int]{}, ctx.Err()
+		}
+	}
+	if page == l.failAt {
+		return Page[int]{}, errors.New("page failed")
+	}
+	var items []int
+	for i := (page - 1) * l.pageSize; i < page*l.pageSize && i < l.total; i++ {
+		items = append(items, i)
+	}
+	return Page[int]{Items: items, Total: l.total}, nil
+}
+
+func collect(t *testing.T, list *fakeList, opts Options) ([]int, int, error) {
+	t.Helper()
+	var items []int
+	emits := 0
+	err := Fetch(context.Background(), opts, list.fetch, func(page []int) error {
+		emits++
+		items = append(items, page...)
+		return nil
+	})
+	return items, emits, err
+}
+
+func TestFetchKeepsThePageOrder(t *testing.T) {
+	list := &fakeList{total: 95, pageSize: 10, delay: func(page int) time.Duration {
+		return time.Duration(12-page) * 2 * time.Millisecond
+	}}
+	items, emits, err := collect(t, list, Options{PageSize: 10, Concurrency: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 95 || emits != 10 {
+		t.Fatalf("expected 95 items in 10 pages, got %d in %d", len(items), emits)
+	}
+	for i, item := range items {
+		if item != i {
+			t.Fatalf("expected item %d at index %d, got %d", i, i, item)
+		}
+	}
+	if list.maxSeen > 3 {
+		t.Errorf("expected at most 3 pages fetched at the same time, got %d", list.maxSeen)
+	}
+	if list.maxSeen < 2 {
+		t.Errorf("expected the pages to be fetched concurrently, got %d at most", list.maxSeen)
+	}
+}
+
+func TestFetchStopsAtTheLimit(t *testing.T) {
+	list := &fakeList{total: 1000, pageSize: 10}
+	items, _, err := collect(t, list, Options{PageSize: 10, Limit: 25, Concurrency: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 25 || items[24] != 24 {
+		t.Fatalf("expected the first 25 items, got %v", items)
+	}
+	if len(list.fetched) != 3 {
+		t.Errorf("expected only the 3 pages holding the first 25 items to be fetched, got %v", list.fetched)
+	}
+}
+
+func TestFetchSinglePage(t *testing.T) {
+	list := &fakeList{total: 4, pageSize: 10}
+	items, emits, err := collect(t, list, Options{PageSize: 10})
+	if err != nil || len(items) != 4 || emits != 1 {
+		t.Fatalf("expected the 4 items in a single page, got %v in %d pages, error %v", items, emits, err)
+	}
+
+	empty := &fakeList{total: 0, pageSize: 10}
+	items, emits, err = collect(t, empty, Options{PageSize: 10})
+	if err != nil || len(items) != 0 || emits != 0 {
+		t.Fatalf("expected nothing for an empty list, got %v in %d pages, error %v", items, emits, err)
+	}
+}
+
+func TestFetchStopsAtTheFirstError(t *testing.T) {
+	list := &fakeList{total: 100, pageSize: 10, failAt: 4}
+	items, _, err := collect(t, list, Options{PageSize: 10, Concurrency: 2})
+	if err == nil || err.Error() != "page failed" {
+		t.Fatalf("expected the error of page 4, got %v", err)
+	}
+	if len(items) != 30 {
+		t.Errorf("expected the 3 pages before the failed one to be emitted, got %d items", len(items))
+	}
+
+	emitErr := errors.New("cannot print")
+	list = &fakeList{total: 100, pageSize: 10}
+	err = Fetch(context.Background(), Options{PageSize: 10, Concurrency: 2}, list.fetch, func([]int) error { return emitErr })
+	if !errors.Is(err, emitErr) {
+		t.Fatalf("expected the error of emit, got %v", err)
+	}
+}
+
+func TestConcurrencyFromConfig(t *testing.T) {
+	defer viper.Set(ConcurrencyConfigKey, defaultConcurrency)
+
+	viper.Set(ConcurrencyConfigKey, 8)
+	if concurrency := Concurrency(); concurrency != 8 {
+		t.Errorf("expected the configured concurrency of 8, got %d", concurrency)
+	}
+	viper.Set(ConcurrencyConfigKey, 0)
+	if concurrency := Concurrency(); concurrency != 1 {
+		t.Errorf("expected the concurrency to be raised to 1, got %d", concurrency)
+	}
+}
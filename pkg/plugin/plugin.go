@@ -0,0 +1,142 @@
+// Package plugin runs the out-of-tree subcommands: like kubectl plugins, 'osdctl foo bar' runs the osdctl-foo-bar
+// or osdctl-foo executable found on the PATH when osdctl has no such command.
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Prefix is the prefix of the plugin executables
+	Prefix = "osdctl"
+
+	// APIVersion is the version of the environment contract, it changes when a variable is removed or changes meaning
+	APIVersion = "1"
+)
+
+// The environment variables set for the plugins, in addition to the environment of osdctl
+const (
+	EnvAPIVersion     = "OSDCTL_PLUGIN_API_VERSION"
+	EnvVersion        = "OSDCTL_VERSION"
+	EnvConfig         = "OSDCTL_CONFIG"
+	EnvReadOnly       = "OSDCTL_READ_ONLY"
+	EnvOCMURL         = "OSDCTL_OCM_URL"
+	EnvOCMEnvironment = "OSDCTL_OCM_ENVIRONMENT"
+	EnvOCMToken       = "OSDCTL_OCM_TOKEN"
+)
+
+// HandlePluginCommand runs the plugin matching the arguments when the root command has no such subcommand, the
+// longest matching executable name wins. It only returns when no plugin matches, or when the plugin couldn't be run.
+func HandlePluginCommand(root *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if _, _, err := root.Find(args); err == nil {
+		return nil
+	}
+
+	var names []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		names = append(names, strings.ReplaceAll(arg, "-", "_"))
+	}
+	// Cobra only adds these commands when the root command is executed
+	if len(names) == 0 || names[0] == "help" || names[0] == cobra.ShellCompRequestCmd || names[0] == cobra.ShellCompNoDescRequestCmd {
+		return nil
+	}
+
+	for ; len(names) > 0; names = names[:len(names)-1] {
+		path, err := exec.LookPath(Prefix + "-" + strings.Join(names, "-"))
+		if err != nil {
+			continue
+		}
+		return execute(path, args[len(names):], append(os.Environ(), Environment()...))
+	}
+	return nil
+}
+
+// execute replaces osdctl with the plugin, so that the plugin gets the terminal and its exit code is osdctl's
+func execute(path string, args, environment []string) error {
+	if runtime.GOOS == "windows" {
+		cmd := exec.Command(path, args...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		cmd.Env = environment
+		if err := cmd.Run(); err != nil {
+			return err
+		}
+		os.Exit(0)
+	}
+	return syscall.Exec(path, append([]string{path}, args...), environment) //#nosec G204 -- the plugin is the user's
+}
+
+// Environment returns the variables of the environment contract. The OCM variables are only set when
+// osdctl is logged in to OCM, plugins not needing OCM still work otherwise.
+func Environment() []string {
+	env := []string{
+		EnvAPIVersion + "=" + APIVersion,
+		EnvVersion + "=" + utils.Version,
+		EnvConfig + "=" + viper.ConfigFileUsed(),
+		EnvReadOnly + "=" + strconv.FormatBool(readonly.Enabled()),
+	}
+
+	if _, err := utils.GetOCMURL(); err != nil {
+		return env
+	}
+	connection := utils.CreateConnection()
+	defer connection.Close()
+
+	env = append(env,
+		EnvOCMURL+"="+connection.URL(),
+		EnvOCMEnvironment+"="+utils.GetCurrentOCMEnv(connection),
+	)
+	if token, _, err := connection.Tokens(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: can't get an OCM token for the plugin: %v\n", err)
+	} else {
+		env = append(env, EnvOCMToken+"="+token)
+	}
+	return env
+}
+
+// List returns the path of the plugin executables in the directories of path, the ones found first
+// shadow the others with the same name like exec.LookPath does
+func List(path string) []string {
+	seen := map[string]bool{}
+	var plugins []string
+	for _, dir := range filepath.SplitList(path) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasPrefix(name, Prefix+"-") || seen[name] {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.Mode()&0111 == 0 {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, filepath.Join(dir, name))
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return filepath.Base(plugins[i]) < filepath.Base(plugins[j])
+	})
+	return plugins
+}
@@ -0,0 +1,52 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func writeExecutable(t *testing.T, dir, name string, mode os.FileMode) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestList(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	foo := writeExecutable(t, first, "osdctl-foo", 0755)
+	writeExecutable(t, first, "osdctl-not-executable", 0644)
+	writeExecutable(t, first, "kubectl-foo", 0755)
+	writeExecutable(t, second, "osdctl-foo", 0755)
+	bar := writeExecutable(t, second, "osdctl-bar-baz", 0755)
+
+	got := List(strings.Join([]string{first, filepath.Join(first, "missing"), second}, string(os.PathListSeparator)))
+	expected := []string{bar, foo}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestHandlePluginCommandWithoutPlugin(t *testing.T) {
+	root := &cobra.Command{Use: "osdctl"}
+	root.AddCommand(&cobra.Command{Use: "cluster", Run: func(*cobra.Command, []string) {}})
+	t.Setenv("PATH", t.TempDir())
+
+	for _, args := range [][]string{
+		{},
+		{"cluster"},
+		{"help", "cluster"},
+		{"--help"},
+		{"unknown", "command"},
+	} {
+		if err := HandlePluginCommand(root, args); err != nil {
+			t.Errorf("expected no error for %v, got %v", args, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package poll waits for a condition the same way in every command: at an interval that can back off, until a
+// timeout, and until interrupted with Ctrl-C, reporting what it is still waiting on whenever that changes.
+package poll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+)
+
+var (
+	// ErrTimeout is returned when the condition wasn't met within the timeout
+	ErrTimeout = errors.New("timed out")
+	// ErrInterrupted is returned when the wait was interrupted with Ctrl-C or its context was canceled
+	ErrInterrupted = errors.New("interrupted")
+)
+
+// ConditionFunc returns whether the wait is over, and while it isn't, a status telling what it is still waiting on.
+// An error stops the wait. The context is canceled on timeout or Ctrl-C.
+type ConditionFunc func(ctx context.Context) (done bool, status string, err error)
+
+// Options configures a wait
+type Options struct {
+	// Description is what is waited for, e.g. "the node to be Ready", used in the progress lines and the errors
+	Description string
+	// Timeout bounds the wait, there is no timeout when zero
+	Timeout time.Duration
+	// Interval is the time between the first checks, 5s when zero
+	Interval time.Duration
+	// MaxInterval is the interval the time between the checks doubles up to, it doesn't grow when zero
+	MaxInterval time.Duration
+	// Progress receives a line whenever the status changes, os.Stderr when nil; use io.Discard to silence it
+	Progress io.Writer
+}
+
+// Until checks the condition right away, then at every interval until it's met, the condition fails, the timeout
+// expires or the wait is interrupted with Ctrl-C. The error of a timeout or an interruption includes the last status.
+func Until(ctx context.Context, opts Options, condition ConditionFunc) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = os.Stderr
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	var last string
+	for {
+		done, status, err := condition(ctx)
+		if err != nil {
+			// The condition fails when its context is canceled, that's the timeout or the interruption
+			if ctx.Err() != nil {
+				return opts.stopped(ctx, start, last)
+			}
+			return err
+		}
+		if done {
+			return nil
+		}
+		if status != "" && status != last {
+			fmt.Fprintf(progress, "Waiting for %s: %s\n", opts.description(), status)
+			last = status
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return opts.stopped(ctx, start, last)
+		case <-timer.C:
+		}
+		if opts.MaxInterval > interval {
+			interval *= 2
+			if interval > opts.MaxInterval {
+				interval = opts.MaxInterval
+			}
+		}
+	}
+}
+
+func (o Options) description() string {
+	if o.Description == "" {
+		return "the condition"
+	}
+	return o.Description
+}
+
+// stopped returns the error of a wait whose context is done
+func (o Options) stopped(ctx context.Context, start time.Time, status string) error {
+	class, after := ErrInterrupted, time.Since(start).Round(time.Second)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		class, after = ErrTimeout, o.Timeout
+	}
+	err := fmt.Errorf("%w after %s waiting for %s", class, after, o.description())
+	if status != "" {
+		err = fmt.Errorf("%w, %s", err, status)
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package poll
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUntilDone(t *testing.T) {
+	var progress bytes.Buffer
+	calls := 0
+	err := Until(context.Background(), Options{Description: "the node", Interval: time.Millisecond, Progress: &progress},
+		func(ctx context.Context) (bool, string, error) {
+			calls++
+			switch calls {
+			case 1, 2:
+				return false, "not restarted", nil
+			case 3:
+				return false, "not Ready", nil
+			}
+			return true, "", nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 checks, got %d", calls)
+	}
+	// The progress is only reported when the status changes
+	expected := "Waiting for the node: not restarted\nWaiting for the node: not Ready\n"
+	if progress.String() != expected {
+		t.Errorf("expected progress %q, got %q", expected, progress.String())
+	}
+}
+
+func TestUntilTimeout(t *testing.T) {
+	err := Until(context.Background(), Options{Description: "the node", Timeout: 10 * time.Millisecond, Interval: time.Millisecond, Progress: &bytes.Buffer{}},
+		func(ctx context.Context) (bool, string, error) {
+			return false, "not restarted", nil
+		})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "waiting for the node, not restarted") {
+		t.Errorf("expected the last status in the error, got %v", err)
+	}
+}
+
+func TestUntilConditionError(t *testing.T) {
+	failure := errors.New("boom")
+	err := Until(context.Background(), Options{Interval: time.Millisecond},
+		func(ctx context.Context) (bool, string, error) {
+			return false, "", failure
+		})
+	if !errors.Is(err, failure) {
+		t.Errorf("expected the error of the condition, got %v", err)
+	}
+}
+
+func TestUntilCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	err := Until(ctx, Options{Interval: time.Millisecond, Progress: &bytes.Buffer{}},
+		func(ctx context.Context) (bool, string, error) {
+			cancel()
+			return false, "still waiting", nil
+		})
+	if !errors.Is(err, ErrInterrupted) {
+		t.Errorf("expected an interruption, got %v", err)
+	}
+}
+
+func TestUntilBackoff(t *testing.T) {
+	var checks []time.Time
+	err := Until(context.Background(), Options{Interval: 2 * time.Millisecond, MaxInterval: 8 * time.Millisecond},
+		func(ctx context.Context) (bool, string, error) {
+			checks = append(checks, time.Now())
+			return len(checks) == 5, "", nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 2ms, 4ms, 8ms then capped at 8ms
+	if total := checks[4].Sub(checks[0]); total < 22*time.Millisecond {
+		t.Errorf("expected the interval to back off, the checks took %s", total)
+	}
+}
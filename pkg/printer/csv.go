@@ -0,0 +1,66 @@
+package printer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// AddColumnsFlag adds --columns to a command with a CSV output
+func AddColumnsFlag(cmd *cobra.Command, columns *[]string, available []string) {
+	cmd.Flags().StringSliceVar(columns, "columns", nil, fmt.Sprintf("Comma separated columns of the CSV output, in order, all by default: %s", strings.Join(available, ",")))
+}
+
+// CSVColumns checks the columns selected with --columns, they default to all the available ones
+func CSVColumns(cmd *cobra.Command, available, selected []string) ([]string, error) {
+	if len(selected) == 0 {
+		return available, nil
+	}
+	columns := make([]string, len(selected))
+	for i, column := range selected {
+		columns[i] = strings.ToLower(strings.TrimSpace(column))
+		if columnIndex(available, columns[i]) < 0 {
+			return nil, cmdutil.UsageErrorf(cmd, "unknown column '%s', the available columns are: %s", column, strings.Join(available, ", "))
+		}
+	}
+	return columns, nil
+}
+
+// WriteCSV writes the header and the rows, which have the available columns, keeping the given columns only
+func WriteCSV(w io.Writer, available []string, rows [][]string, columns []string) error {
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		if indexes[i] = columnIndex(available, column); indexes[i] < 0 {
+			return fmt.Errorf("unknown column '%s'", column)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, index := range indexes {
+			record[i] = row[index]
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func columnIndex(columns []string, column string) int {
+	for i, c := range columns {
+		if c == column {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,38 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+var testCSVColumns = []string{"id", "name", "state"}
+
+func TestCSVColumns(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cmd := &cobra.Command{Use: "test"}
+
+	columns, err := CSVColumns(cmd, testCSVColumns, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(columns).To(Equal(testCSVColumns))
+
+	columns, err = CSVColumns(cmd, testCSVColumns, []string{"State", " id"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(columns).To(Equal([]string{"state", "id"}))
+
+	_, err = CSVColumns(cmd, testCSVColumns, []string{"id", "owner"})
+	g.Expect(err).To(MatchError(ContainSubstring("unknown column 'owner'")))
+}
+
+func TestWriteCSV(t *testing.T) {
+	g := NewGomegaWithT(t)
+	rows := [][]string{{"1", "prod, eu", "ready"}, {"2", "stage", "error"}}
+
+	var b bytes.Buffer
+	g.Expect(WriteCSV(&b, testCSVColumns, rows, []string{"state", "name"})).To(Succeed())
+	g.Expect(b.String()).To(Equal("state,name\nready,\"prod, eu\"\nerror,stage\n"))
+
+	g.Expect(WriteCSV(&b, testCSVColumns, rows, []string{"owner"})).NotTo(Succeed())
+}
@@ -0,0 +1,157 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// diffContext is the number of unchanged lines printed around the changes
+const diffContext = 3
+
+var (
+	diffRemoved = color.New(color.FgRed).SprintFunc()
+	diffAdded   = color.New(color.FgGreen).SprintFunc()
+)
+
+// Diff is the change a mutating command is about to make to a resource. Before and After are rendered as
+// indented JSON, so they are plain structs or maps, nil for a resource that doesn't exist (yet).
+type Diff struct {
+	Title  string
+	Before interface{}
+	After  interface{}
+}
+
+// Print writes the changed lines of the resource, removed lines in red and added lines in green
+// when writing to a terminal
+func (d *Diff) Print(w io.Writer) error {
+	before, err := diffRender(d.Before)
+	if err != nil {
+		return err
+	}
+	after, err := diffRender(d.After)
+	if err != nil {
+		return err
+	}
+
+	if d.Title != "" {
+		fmt.Fprintln(w, d.Title)
+	}
+	lines := DiffLines(before, after)
+	if !hasChanges(lines) {
+		_, err := fmt.Fprintln(w, "  (no changes)")
+		return err
+	}
+
+	for _, line := range trimContext(lines, diffContext) {
+		switch {
+		case strings.HasPrefix(line, "- "):
+			line = diffRemoved(line)
+		case strings.HasPrefix(line, "+ "):
+			line = diffAdded(line)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	// Add empty line for readability
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+func diffRender(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("cannot render the resource: %w", err)
+	}
+	return strings.Split(string(data), "\n"), nil
+}
+
+// DiffLines returns the lines of before and after, prefixed with "  " when unchanged, "- " when removed
+// and "+ " when added, using their longest common subsequence
+func DiffLines(before, after []string) []string {
+	// lcs[i][j] is the length of the longest common subsequence of before[i:] and after[j:]
+	lcs := make([][]int, len(before)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(before) && j < len(after) {
+		switch {
+		case before[i] == after[j]:
+			lines = append(lines, "  "+before[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "- "+before[i])
+			i++
+		default:
+			lines = append(lines, "+ "+after[j])
+			j++
+		}
+	}
+	for ; i < len(before); i++ {
+		lines = append(lines, "- "+before[i])
+	}
+	for ; j < len(after); j++ {
+		lines = append(lines, "+ "+after[j])
+	}
+	return lines
+}
+
+func hasChanges(lines []string) bool {
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "  ") {
+			return true
+		}
+	}
+	return false
+}
+
+// trimContext keeps the changed lines and the given number of unchanged lines around them, replacing the
+// others with "..."
+func trimContext(lines []string, context int) []string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		for k := i - context; k <= i+context; k++ {
+			if k >= 0 && k < len(lines) {
+				keep[k] = true
+			}
+		}
+	}
+
+	var trimmed []string
+	skipped := false
+	for i, line := range lines {
+		if keep[i] {
+			trimmed = append(trimmed, line)
+			skipped = false
+		} else if !skipped {
+			trimmed = append(trimmed, "  ...")
+			skipped = true
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,44 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestDiffLines(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(DiffLines([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"})).To(Equal([]string{
+		"  a", "- b", "+ x", "  c", "+ d",
+	}))
+	g.Expect(DiffLines(nil, []string{"a"})).To(Equal([]string{"+ a"}))
+	g.Expect(DiffLines([]string{"a"}, nil)).To(Equal([]string{"- a"}))
+}
+
+func TestTrimContext(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	lines := []string{"  1", "  2", "  3", "- 4", "+ 5", "  6", "  7", "  8"}
+	g.Expect(trimContext(lines, 1)).To(Equal([]string{"  ...", "  3", "- 4", "+ 5", "  6", "  ..."}))
+	g.Expect(trimContext(lines, 3)).To(Equal(lines))
+}
+
+func TestDiffPrint(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	out := &bytes.Buffer{}
+	diff := &Diff{
+		Title:  "Labels:",
+		Before: map[string]string{"env": "staging"},
+		After:  map[string]string{"env": "production"},
+	}
+	g.Expect(diff.Print(out)).To(Succeed())
+	g.Expect(out.String()).To(Equal("Labels:\n  {\n-   \"env\": \"staging\"\n+   \"env\": \"production\"\n  }\n\n"))
+
+	out.Reset()
+	diff.After = diff.Before
+	g.Expect(diff.Print(out)).To(Succeed())
+	g.Expect(out.String()).To(Equal("Labels:\n  (no changes)\n"))
+}
@@ -0,0 +1,129 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/itchyny/gojq"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/cobra"
+)
+
+// FilterFlag is the global flag slicing the JSON output of a command with a jq expression, for the hosts without jq.
+// The expressions are evaluated by gojq, so they behave as with jq.
+const FilterFlag = "filter"
+
+var (
+	filter   *gojq.Code
+	filterMu sync.Mutex
+)
+
+// AddFilterFlag adds the --filter flag to the command and all its children
+func AddFilterFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(FilterFlag, "", "jq expression applied to the JSON output, e.g. '.[] | select(.state == \"ready\") | .id'. Implies -o json")
+}
+
+// SetupFilter compiles the expression given with --filter, if any. The filter applies to the JSON output, so it
+// sets -o json when no output format is given and rejects the other ones.
+func SetupFilter(cmd *cobra.Command) error {
+	flag := cmd.Flag(FilterFlag)
+	if flag == nil || flag.Value.String() == "" {
+		return nil
+	}
+	query, err := gojq.Parse(flag.Value.String())
+	if err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "invalid --filter: %v", err)
+	}
+	parsed, err := gojq.Compile(query)
+	if err != nil {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "invalid --filter: %v", err)
+	}
+
+	if output := cmd.Flag("output"); output != nil {
+		switch output.Value.String() {
+		case "":
+			if err := output.Value.Set("json"); err != nil {
+				return err
+			}
+		case "json":
+		default:
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "--filter applies to the JSON output, it can't be used with -o %s", output.Value.String())
+		}
+	}
+
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	filter = parsed
+	return nil
+}
+
+// FilterEnabled reports whether --filter was given
+func FilterEnabled() bool {
+	filterMu.Lock()
+	defer filterMu.Unlock()
+	return filter != nil
+}
+
+// PrintJSON writes the JSON document, or the results of the --filter expression applied to each JSON value of data
+func PrintJSON(w io.Writer, data []byte) error {
+	filterMu.Lock()
+	current := filter
+	filterMu.Unlock()
+	if current == nil {
+		_, err := fmt.Fprintln(w, string(bytes.TrimRight(data, "\n")))
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("--filter applies to JSON output, cannot parse the output: %w", err)
+		}
+		if err := printFiltered(w, current, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printFiltered writes every result of the expression. Like with jq -r, the strings are written without quotes so
+// that they can be used in scripts as they are.
+func printFiltered(w io.Writer, current *gojq.Code, value interface{}) error {
+	results := current.Run(value)
+	for {
+		result, ok := results.Next()
+		if !ok {
+			return nil
+		}
+		if err, isErr := result.(error); isErr {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "--filter failed: %v", err)
+		}
+		if s, isString := result.(string); isString {
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := json.MarshalIndent(result, "", "    ")
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, string(data)); err != nil {
+			return err
+		}
+	}
+}
+
+// FilterJSON returns a writer for the outputs printed by other libraries, e.g. the kubernetes printers. When
+// --filter is given, what is written is kept until flush applies the filter and writes the results to w.
+func FilterJSON(w io.Writer) (io.Writer, func() error) {
+	if !FilterEnabled() {
+		return w, func() error { return nil }
+	}
+	var buf bytes.Buffer
+	return &buf, func() error { return PrintJSON(w, buf.Bytes()) }
+}
@@ -0,0 +1,69 @@
+package printer
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer func() { filter = nil }()
+
+	var output string
+	cmd := &cobra.Command{}
+	AddFilterFlag(cmd)
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", "", "")
+
+	// Without --filter the JSON is written as it is
+	g.Expect(SetupFilter(cmd)).To(Succeed())
+	g.Expect(FilterEnabled()).To(BeFalse())
+	var buf bytes.Buffer
+	g.Expect(PrintJSON(&buf, []byte(`[{"id": "abc"}]`))).To(Succeed())
+	g.Expect(buf.String()).To(Equal("[{\"id\": \"abc\"}]\n"))
+
+	g.Expect(cmd.PersistentFlags().Set(FilterFlag, `.[] | select(.state == "ready") | .id`)).To(Succeed())
+	g.Expect(SetupFilter(cmd)).To(Succeed())
+	g.Expect(FilterEnabled()).To(BeTrue())
+	g.Expect(output).To(Equal("json"))
+
+	buf.Reset()
+	g.Expect(PrintJSON(&buf, []byte(`[{"id": "abc", "state": "ready"}, {"id": "def", "state": "installing"}, {"id": "ghi", "state": "ready"}]`))).To(Succeed())
+	g.Expect(buf.String()).To(Equal("abc\nghi\n"))
+
+	// The kubernetes printers write their output before it is filtered
+	buf.Reset()
+	w, flush := FilterJSON(&buf)
+	_, _ = w.Write([]byte(`{"id": "jkl", "state": "ready"}` + "\n" + `{"id": "mno", "state": "ready"}`))
+	g.Expect(buf.Len()).To(BeZero())
+	g.Expect(cmd.PersistentFlags().Set(FilterFlag, `{id}`)).To(Succeed())
+	g.Expect(SetupFilter(cmd)).To(Succeed())
+	g.Expect(flush()).To(Succeed())
+	g.Expect(buf.String()).To(Equal("{\n    \"id\": \"jkl\"\n}\n{\n    \"id\": \"mno\"\n}\n"))
+
+	// The expressions are the ones of jq
+	for expression, expected := range map[string]string{
+		`[.[] | .nodes] | add`:                            "15\n",
+		`sort_by(.nodes) | reverse | .[0].id`:             "def\n",
+		`.[] | select(.name | test("^stage")) | "\(.id)"`: "def\n",
+		`.[0].labels.team // "none"`:                      "none\n",
+	} {
+		g.Expect(cmd.PersistentFlags().Set(FilterFlag, expression)).To(Succeed())
+		g.Expect(SetupFilter(cmd)).To(Succeed())
+		buf.Reset()
+		g.Expect(PrintJSON(&buf, []byte(`[{"id": "abc", "name": "prod-1", "nodes": 6, "labels": {}}, {"id": "def", "name": "stage-1", "nodes": 9}]`))).To(Succeed(), expression)
+		g.Expect(buf.String()).To(Equal(expected), expression)
+	}
+
+	g.Expect(PrintJSON(&buf, []byte(`not json`))).NotTo(Succeed())
+	// Like with jq, a string can't be indexed
+	g.Expect(PrintJSON(&buf, []byte(`"a string"`))).NotTo(Succeed())
+
+	g.Expect(cmd.PersistentFlags().Set(FilterFlag, `.[`)).To(Succeed())
+	g.Expect(SetupFilter(cmd)).NotTo(Succeed())
+	g.Expect(cmd.PersistentFlags().Set(FilterFlag, `.id`)).To(Succeed())
+	g.Expect(cmd.PersistentFlags().Set("output", "yaml")).To(Succeed())
+	g.Expect(SetupFilter(cmd)).NotTo(Succeed())
+}
@@ -0,0 +1,65 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+// OutputFileFlag is the global flag used to save the structured output of a command to disk
+const OutputFileFlag = "output-file"
+
+var (
+	outputFile   *os.File
+	outputFileMu sync.Mutex
+)
+
+// AddOutputFileFlag adds the --output-file flag to the command and all its children
+func AddOutputFileFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(OutputFileFlag, "", "Also write the structured output (JSON, YAML, CSV) of the command to this file")
+}
+
+// OpenOutputFile opens the file given with --output-file, if any, truncating it
+func OpenOutputFile(cmd *cobra.Command) error {
+	flag := cmd.Flag(OutputFileFlag)
+	if flag == nil || flag.Value.String() == "" {
+		return nil
+	}
+	path := flag.Value.String()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) //#nosec G304 -- path is provided by the user
+	if err != nil {
+		return fmt.Errorf("cannot open output file '%s': %w", path, err)
+	}
+
+	outputFileMu.Lock()
+	defer outputFileMu.Unlock()
+	outputFile = file
+	return nil
+}
+
+// CloseOutputFile closes the file opened by OpenOutputFile
+func CloseOutputFile() error {
+	outputFileMu.Lock()
+	defer outputFileMu.Unlock()
+	if outputFile == nil {
+		return nil
+	}
+	err := outputFile.Close()
+	outputFile = nil
+	return err
+}
+
+// Tee returns a writer duplicating everything written to w into the output file.
+// It returns w untouched when --output-file isn't set.
+func Tee(w io.Writer) io.Writer {
+	outputFileMu.Lock()
+	defer outputFileMu.Unlock()
+	if outputFile == nil {
+		return w
+	}
+	return io.MultiWriter(w, outputFile)
+}
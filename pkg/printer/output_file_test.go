@@ -0,0 +1,43 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+)
+
+func TestTee(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cmd := &cobra.Command{}
+	AddOutputFileFlag(cmd)
+
+	// Without --output-file the writer is returned untouched
+	g.Expect(OpenOutputFile(cmd)).To(Succeed())
+	stdout := &bytes.Buffer{}
+	g.Expect(Tee(stdout)).To(BeIdenticalTo(stdout))
+
+	path := filepath.Join(t.TempDir(), "output.json")
+	g.Expect(cmd.PersistentFlags().Set(OutputFileFlag, path)).To(Succeed())
+	g.Expect(OpenOutputFile(cmd)).To(Succeed())
+
+	fmt.Fprintln(Tee(stdout), `{"id": "abc"}`)
+	g.Expect(CloseOutputFile()).To(Succeed())
+	g.Expect(CloseOutputFile()).To(Succeed())
+
+	g.Expect(stdout.String()).To(Equal("{\"id\": \"abc\"}\n"))
+	content, err := os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("{\"id\": \"abc\"}\n"))
+
+	// Once closed, nothing is written to the file anymore
+	fmt.Fprintln(Tee(stdout), "more")
+	content, err = os.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(content)).To(Equal("{\"id\": \"abc\"}\n"))
+}
@@ -0,0 +1,76 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// previewWidth is the width the preview body is wrapped at, close to what the OCM console and emails show
+const previewWidth = 80
+
+var leftoverPlaceholder = regexp.MustCompile(`\${[^}]*}`)
+
+// Preview is a customer-visible message, rendered as plain text instead of the escaped JSON sent to OCM
+type Preview struct {
+	// Heading says where the customer sees the message
+	Heading string
+	// Fields are shown above the message, e.g. the cluster name and severity
+	Fields [][2]string
+	Title  string
+	Body   string
+}
+
+// PrintPreview renders the message the way the customer reads it, and warns about placeholders left in it
+func PrintPreview(w io.Writer, preview Preview) error {
+	rule := strings.Repeat("─", previewWidth)
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n%s\n", preview.Heading, rule)
+	for _, field := range preview.Fields {
+		fmt.Fprintf(&b, "%-10s %s\n", field[0]+":", field[1])
+	}
+	if len(preview.Fields) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "%s\n\n", preview.Title)
+	for _, paragraph := range strings.Split(preview.Body, "\n") {
+		fmt.Fprintln(&b, wrap(paragraph, previewWidth))
+	}
+	fmt.Fprintln(&b, rule)
+
+	leftovers := leftoverPlaceholder.FindAllString(preview.Title+" "+preview.Body, -1)
+	if len(leftovers) > 0 {
+		fmt.Fprintf(&b, "WARNING: the message still contains %s\n", strings.Join(leftovers, ", "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// wrap breaks a line at word boundaries so that it fits in width columns, longer words are kept whole
+func wrap(line string, width int) string {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	length := 0
+	for i, word := range words {
+		wordLength := len([]rune(word))
+		if i > 0 {
+			if length+1+wordLength > width {
+				b.WriteString("\n")
+				length = 0
+			} else {
+				b.WriteString(" ")
+				length++
+			}
+		}
+		b.WriteString(word)
+		length += wordLength
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package printer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestPrintPreview(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	buf := &bytes.Buffer{}
+	err := PrintPreview(buf, Preview{
+		Heading: "Cluster history",
+		Fields:  [][2]string{{"Cluster", "my-cluster"}, {"Severity", "Warning"}},
+		Title:   "Action required: review the cluster",
+		Body:    strings.Repeat("word ", 20) + "\nSecond paragraph for ${CLUSTER_UUID}",
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	output := buf.String()
+	g.Expect(output).To(ContainSubstring("Cluster:   my-cluster\nSeverity:  Warning\n\nAction required: review the cluster\n\n"))
+	// 20 words of 4 letters wrap after the 16th
+	g.Expect(output).To(ContainSubstring(strings.TrimSpace(strings.Repeat("word ", 16)) + "\nword word word word\nSecond paragraph"))
+	g.Expect(output).To(ContainSubstring("WARNING: the message still contains ${CLUSTER_UUID}"))
+}
+
+func TestWrap(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(wrap("", 10)).To(Equal(""))
+	g.Expect(wrap("a b c", 10)).To(Equal("a b c"))
+	g.Expect(wrap("aaaa bbbb cccc", 9)).To(Equal("aaaa bbbb\ncccc"))
+	g.Expect(wrap("https://docs.openshift.com/a/very/long/link", 10)).To(Equal("https://docs.openshift.com/a/very/long/link"))
+}
@@ -17,18 +17,42 @@ import (
 	"github.com/aws/aws-sdk-go/service/costexplorer/costexploreriface"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/aws/aws-sdk-go/service/organizations"
 	"github.com/aws/aws-sdk-go/service/organizations/organizationsiface"
+	"github.com/aws/aws-sdk-go/service/pricing"
+	"github.com/aws/aws-sdk-go/service/pricing/pricingiface"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi/resourcegroupstaggingapiiface"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/route53/route53iface"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/aws/aws-sdk-go/service/servicequotas"
 	"github.com/aws/aws-sdk-go/service/servicequotas/servicequotasiface"
 	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/aws/aws-sdk-go/service/support"
+	"github.com/aws/aws-sdk-go/service/support/supportiface"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/ratelimit"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/trace"
+)
+
+const (
+	// supportRegion is the region of the AWS Support API endpoint of the commercial partition, whatever the region of
+	// the other clients
+	supportRegion = "us-east-1"
+	// pricingRegion is a region of the AWS Price List API endpoint of the commercial partition, which has the prices of
+	// all its regions
+	pricingRegion = "us-east-1"
 )
 
 // AwsClientInput input for new aws client
@@ -51,6 +75,14 @@ type Client interface {
 	DeleteBucket(*s3.DeleteBucketInput) (*s3.DeleteBucketOutput, error)
 	ListObjects(*s3.ListObjectsInput) (*s3.ListObjectsOutput, error)
 	DeleteObjects(*s3.DeleteObjectsInput) (*s3.DeleteObjectsOutput, error)
+	CreateBucket(*s3.CreateBucketInput) (*s3.CreateBucketOutput, error)
+	PutBucketLifecycleConfiguration(*s3.PutBucketLifecycleConfigurationInput) (*s3.PutBucketLifecycleConfigurationOutput, error)
+	GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	PutObject(*s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	HeadBucket(*s3.HeadBucketInput) (*s3.HeadBucketOutput, error)
+	GetBucketPolicy(*s3.GetBucketPolicyInput) (*s3.GetBucketPolicyOutput, error)
+	GetBucketEncryption(*s3.GetBucketEncryptionInput) (*s3.GetBucketEncryptionOutput, error)
+	GetPublicAccessBlock(*s3.GetPublicAccessBlockInput) (*s3.GetPublicAccessBlockOutput, error)
 
 	//iam
 	CreateAccessKey(*iam.CreateAccessKeyInput) (*iam.CreateAccessKeyOutput, error)
@@ -70,12 +102,19 @@ type Client interface {
 	DeleteSigningCertificate(*iam.DeleteSigningCertificateInput) (*iam.DeleteSigningCertificateOutput, error)
 	ListUserPolicies(*iam.ListUserPoliciesInput) (*iam.ListUserPoliciesOutput, error)
 	ListPolicies(*iam.ListPoliciesInput) (*iam.ListPoliciesOutput, error)
+	SimulatePrincipalPolicy(*iam.SimulatePrincipalPolicyInput) (*iam.SimulatePolicyResponse, error)
 	DeleteUserPolicy(*iam.DeleteUserPolicyInput) (*iam.DeleteUserPolicyOutput, error)
 	ListAttachedUserPolicies(*iam.ListAttachedUserPoliciesInput) (*iam.ListAttachedUserPoliciesOutput, error)
 	DetachUserPolicy(*iam.DetachUserPolicyInput) (*iam.DetachUserPolicyOutput, error)
 	ListGroupsForUser(*iam.ListGroupsForUserInput) (*iam.ListGroupsForUserOutput, error)
 	RemoveUserFromGroup(*iam.RemoveUserFromGroupInput) (*iam.RemoveUserFromGroupOutput, error)
 	ListRoles(*iam.ListRolesInput) (*iam.ListRolesOutput, error)
+	GetRole(*iam.GetRoleInput) (*iam.GetRoleOutput, error)
+	ListRolePolicies(*iam.ListRolePoliciesInput) (*iam.ListRolePoliciesOutput, error)
+	GetRolePolicy(*iam.GetRolePolicyInput) (*iam.GetRolePolicyOutput, error)
+	GetPolicy(*iam.GetPolicyInput) (*iam.GetPolicyOutput, error)
+	GetPolicyVersion(*iam.GetPolicyVersionInput) (*iam.GetPolicyVersionOutput, error)
+	ListOpenIDConnectProviders(*iam.ListOpenIDConnectProvidersInput) (*iam.ListOpenIDConnectProvidersOutput, error)
 	DeleteRole(*iam.DeleteRoleInput) (*iam.DeleteRoleOutput, error)
 	DeleteUser(*iam.DeleteUserInput) (*iam.DeleteUserOutput, error)
 
@@ -84,14 +123,25 @@ type Client interface {
 	DescribeRouteTables(*ec2.DescribeRouteTablesInput) (*ec2.DescribeRouteTablesOutput, error)
 	DescribeSubnets(*ec2.DescribeSubnetsInput) (*ec2.DescribeSubnetsOutput, error)
 	DescribeVpcs(*ec2.DescribeVpcsInput) (*ec2.DescribeVpcsOutput, error)
+	DescribeAddresses(*ec2.DescribeAddressesInput) (*ec2.DescribeAddressesOutput, error)
+	DescribeNatGateways(*ec2.DescribeNatGatewaysInput) (*ec2.DescribeNatGatewaysOutput, error)
+	DescribeVolumes(*ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error)
+	DescribeSecurityGroups(*ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error)
+	DescribeNetworkAcls(*ec2.DescribeNetworkAclsInput) (*ec2.DescribeNetworkAclsOutput, error)
 	StopInstances(*ec2.StopInstancesInput) (*ec2.StopInstancesOutput, error)
 	ModifyInstanceAttribute(*ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error)
 	StartInstances(*ec2.StartInstancesInput) (*ec2.StartInstancesOutput, error)
+	RebootInstances(*ec2.RebootInstancesInput) (*ec2.RebootInstancesOutput, error)
 	WaitUntilInstanceStopped(*ec2.DescribeInstancesInput) error
 	WaitUntilInstanceRunning(*ec2.DescribeInstancesInput) error
+	CreateFlowLogs(*ec2.CreateFlowLogsInput) (*ec2.CreateFlowLogsOutput, error)
+	DescribeFlowLogs(*ec2.DescribeFlowLogsInput) (*ec2.DescribeFlowLogsOutput, error)
+	DeleteFlowLogs(*ec2.DeleteFlowLogsInput) (*ec2.DeleteFlowLogsOutput, error)
 
 	// Service Quotas
 	ListServiceQuotas(*servicequotas.ListServiceQuotasInput) (*servicequotas.ListServiceQuotasOutput, error)
+	GetServiceQuota(*servicequotas.GetServiceQuotaInput) (*servicequotas.GetServiceQuotaOutput, error)
+	GetAWSDefaultServiceQuota(*servicequotas.GetAWSDefaultServiceQuotaInput) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error)
 	RequestServiceQuotaIncrease(*servicequotas.RequestServiceQuotaIncreaseInput) (*servicequotas.RequestServiceQuotaIncreaseOutput, error)
 
 	// Organizations
@@ -112,14 +162,33 @@ type Client interface {
 
 	// Resources
 	GetResources(input *resourcegroupstaggingapi.GetResourcesInput) (*resourcegroupstaggingapi.GetResourcesOutput, error)
+	TagResources(input *resourcegroupstaggingapi.TagResourcesInput) (*resourcegroupstaggingapi.TagResourcesOutput, error)
 
 	// Cost Explorer
 	GetCostAndUsage(input *costexplorer.GetCostAndUsageInput) (*costexplorer.GetCostAndUsageOutput, error)
 	CreateCostCategoryDefinition(input *costexplorer.CreateCostCategoryDefinitionInput) (*costexplorer.CreateCostCategoryDefinitionOutput, error)
 	ListCostCategoryDefinitions(input *costexplorer.ListCostCategoryDefinitionsInput) (*costexplorer.ListCostCategoryDefinitionsOutput, error)
 
+	// Pricing
+	GetProducts(input *pricing.GetProductsInput) (*pricing.GetProductsOutput, error)
+
 	// Cloudtrail
 	LookupEvents(input *cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error)
+
+	// Route53
+	ListHostedZonesByName(input *route53.ListHostedZonesByNameInput) (*route53.ListHostedZonesByNameOutput, error)
+	ListResourceRecordSets(input *route53.ListResourceRecordSetsInput) (*route53.ListResourceRecordSetsOutput, error)
+	ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error)
+
+	// Elastic Load Balancing
+	DescribeLoadBalancers(input *elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error)
+	DescribeInstanceHealth(input *elb.DescribeInstanceHealthInput) (*elb.DescribeInstanceHealthOutput, error)
+	DescribeV2LoadBalancers(input *elbv2.DescribeLoadBalancersInput) (*elbv2.DescribeLoadBalancersOutput, error)
+	DescribeTargetGroups(input *elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error)
+	DescribeTargetHealth(input *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error)
+
+	// Support
+	CreateCase(input *support.CreateCaseInput) (*support.CreateCaseOutput, error)
 }
 
 type AwsClient struct {
@@ -132,6 +201,45 @@ type AwsClient struct {
 	resClient           resourcegroupstaggingapiiface.ResourceGroupsTaggingAPIAPI
 	ceClient            costexploreriface.CostExplorerAPI
 	cloudTrailClient    cloudtrailiface.CloudTrailAPI
+	route53Client       route53iface.Route53API
+	elbClient           elbiface.ELBAPI
+	supportClient       supportiface.SupportAPI
+	elbv2Client         elbv2iface.ELBV2API
+	pricingClient       pricingiface.PricingAPI
+
+	// partition is the one of the region of the client, the global services are reached through its regions
+	partition string
+}
+
+// newAwsClientForSession creates the service clients of the session, the global ones in the regions of the partition
+// of the region
+func newAwsClientForSession(sess *session.Session, region string) *AwsClient {
+	partition := PartitionForRegion(region)
+	regions := globalServiceRegions[partition]
+	awsClient := &AwsClient{
+		iamClient:           iam.New(sess),
+		ec2Client:           ec2.New(sess),
+		stsClient:           sts.New(sess),
+		s3Client:            s3.New(sess),
+		servicequotasClient: servicequotas.New(sess),
+		orgClient:           organizations.New(sess),
+		resClient:           resourcegroupstaggingapi.New(sess),
+		cloudTrailClient:    cloudtrail.New(sess),
+		route53Client:       route53.New(sess),
+		elbClient:           elb.New(sess),
+		elbv2Client:         elbv2.New(sess),
+		partition:           partition,
+	}
+	if regions.support != "" {
+		awsClient.supportClient = support.New(sess, aws.NewConfig().WithRegion(regions.support))
+	}
+	if regions.pricing != "" {
+		awsClient.pricingClient = pricing.New(sess, aws.NewConfig().WithRegion(regions.pricing))
+	}
+	if regions.costExplorer != "" {
+		awsClient.ceClient = costexplorer.New(sess, aws.NewConfig().WithRegion(regions.costExplorer))
+	}
+	return awsClient
 }
 
 func NewAwsSession(profile, region, configFile string) (*session.Session, error) {
@@ -152,7 +260,19 @@ func NewAwsSession(profile, region, configFile string) (*session.Session, error)
 		opt.SharedConfigFiles = []string{absCfgPath}
 	}
 
+	// The replayed sessions use fake credentials, the profile may not exist
+	if recording.Replaying() {
+		opt.Profile, opt.SharedConfigFiles = "", nil
+		opt.Config.Credentials = recording.AWSCredentials()
+	}
+
 	sess := session.Must(session.NewSessionWithOptions(opt))
+	// The first attached is the innermost, the replayed requests still go through the others
+	recording.AttachToAWSSession(sess)
+	deadline.AttachToAWSSession(sess)
+	ratelimit.AttachToAWSSession(sess)
+	readonly.AttachToAWSSession(sess)
+	trace.AttachToAWSSession(sess)
 	if _, err := sess.Config.Credentials.Get(); err != nil {
 		if aerr, ok := err.(awserr.Error); ok {
 			switch aerr.Code() {
@@ -174,17 +294,7 @@ func NewAwsClient(profile, region, configFile string) (Client, error) {
 		return nil, err
 	}
 
-	awsClient := &AwsClient{
-		iamClient:           iam.New(sess),
-		ec2Client:           ec2.New(sess),
-		stsClient:           sts.New(sess),
-		s3Client:            s3.New(sess),
-		servicequotasClient: servicequotas.New(sess),
-		orgClient:           organizations.New(sess),
-		ceClient:            costexplorer.New(sess),
-		resClient:           resourcegroupstaggingapi.New(sess),
-		cloudTrailClient:    cloudtrail.New(sess),
-	}
+	awsClient := newAwsClientForSession(sess, region)
 
 	// Validate the creds
 	if _, err := awsClient.GetCallerIdentity(nil); err != nil {
@@ -213,18 +323,13 @@ func NewAwsClientWithInput(input *AwsClientInput) (Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	recording.AttachToAWSSession(s)
+	deadline.AttachToAWSSession(s)
+	ratelimit.AttachToAWSSession(s)
+	readonly.AttachToAWSSession(s)
+	trace.AttachToAWSSession(s)
 
-	return &AwsClient{
-		iamClient:           iam.New(s),
-		ec2Client:           ec2.New(s),
-		stsClient:           sts.New(s),
-		s3Client:            s3.New(s),
-		servicequotasClient: servicequotas.New(s),
-		orgClient:           organizations.New(s),
-		ceClient:            costexplorer.New(s),
-		resClient:           resourcegroupstaggingapi.New(s),
-		cloudTrailClient:    cloudtrail.New(s),
-	}, nil
+	return newAwsClientForSession(s, input.Region), nil
 }
 
 func (c *AwsClient) AssumeRole(input *sts.AssumeRoleInput) (*sts.AssumeRoleOutput, error) {
@@ -255,6 +360,38 @@ func (c *AwsClient) DeleteObjects(input *s3.DeleteObjectsInput) (*s3.DeleteObjec
 	return c.s3Client.DeleteObjects(input)
 }
 
+func (c *AwsClient) CreateBucket(input *s3.CreateBucketInput) (*s3.CreateBucketOutput, error) {
+	return c.s3Client.CreateBucket(input)
+}
+
+func (c *AwsClient) PutBucketLifecycleConfiguration(input *s3.PutBucketLifecycleConfigurationInput) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+	return c.s3Client.PutBucketLifecycleConfiguration(input)
+}
+
+func (c *AwsClient) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return c.s3Client.GetObject(input)
+}
+
+func (c *AwsClient) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	return c.s3Client.PutObject(input)
+}
+
+func (c *AwsClient) HeadBucket(input *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
+	return c.s3Client.HeadBucket(input)
+}
+
+func (c *AwsClient) GetBucketPolicy(input *s3.GetBucketPolicyInput) (*s3.GetBucketPolicyOutput, error) {
+	return c.s3Client.GetBucketPolicy(input)
+}
+
+func (c *AwsClient) GetBucketEncryption(input *s3.GetBucketEncryptionInput) (*s3.GetBucketEncryptionOutput, error) {
+	return c.s3Client.GetBucketEncryption(input)
+}
+
+func (c *AwsClient) GetPublicAccessBlock(input *s3.GetPublicAccessBlockInput) (*s3.GetPublicAccessBlockOutput, error) {
+	return c.s3Client.GetPublicAccessBlock(input)
+}
+
 func (c *AwsClient) CreateAccessKey(input *iam.CreateAccessKeyInput) (*iam.CreateAccessKeyOutput, error) {
 	return c.iamClient.CreateAccessKey(input)
 }
@@ -271,6 +408,10 @@ func (c *AwsClient) GetUser(input *iam.GetUserInput) (*iam.GetUserOutput, error)
 	return c.iamClient.GetUser(input)
 }
 
+func (c *AwsClient) SimulatePrincipalPolicy(input *iam.SimulatePrincipalPolicyInput) (*iam.SimulatePolicyResponse, error) {
+	return c.iamClient.SimulatePrincipalPolicy(input)
+}
+
 func (c *AwsClient) CreateUser(input *iam.CreateUserInput) (*iam.CreateUserOutput, error) {
 	return c.iamClient.CreateUser(input)
 }
@@ -347,6 +488,30 @@ func (c *AwsClient) ListRoles(input *iam.ListRolesInput) (*iam.ListRolesOutput,
 	return c.iamClient.ListRoles(input)
 }
 
+func (c *AwsClient) GetRole(input *iam.GetRoleInput) (*iam.GetRoleOutput, error) {
+	return c.iamClient.GetRole(input)
+}
+
+func (c *AwsClient) ListRolePolicies(input *iam.ListRolePoliciesInput) (*iam.ListRolePoliciesOutput, error) {
+	return c.iamClient.ListRolePolicies(input)
+}
+
+func (c *AwsClient) GetRolePolicy(input *iam.GetRolePolicyInput) (*iam.GetRolePolicyOutput, error) {
+	return c.iamClient.GetRolePolicy(input)
+}
+
+func (c *AwsClient) GetPolicy(input *iam.GetPolicyInput) (*iam.GetPolicyOutput, error) {
+	return c.iamClient.GetPolicy(input)
+}
+
+func (c *AwsClient) GetPolicyVersion(input *iam.GetPolicyVersionInput) (*iam.GetPolicyVersionOutput, error) {
+	return c.iamClient.GetPolicyVersion(input)
+}
+
+func (c *AwsClient) ListOpenIDConnectProviders(input *iam.ListOpenIDConnectProvidersInput) (*iam.ListOpenIDConnectProvidersOutput, error) {
+	return c.iamClient.ListOpenIDConnectProviders(input)
+}
+
 func (c *AwsClient) DeleteRole(input *iam.DeleteRoleInput) (*iam.DeleteRoleOutput, error) {
 	return c.iamClient.DeleteRole(input)
 }
@@ -377,6 +542,14 @@ func (c *AwsClient) ListServiceQuotas(input *servicequotas.ListServiceQuotasInpu
 	return c.servicequotasClient.ListServiceQuotas(input)
 }
 
+func (c *AwsClient) GetServiceQuota(input *servicequotas.GetServiceQuotaInput) (*servicequotas.GetServiceQuotaOutput, error) {
+	return c.servicequotasClient.GetServiceQuota(input)
+}
+
+func (c *AwsClient) GetAWSDefaultServiceQuota(input *servicequotas.GetAWSDefaultServiceQuotaInput) (*servicequotas.GetAWSDefaultServiceQuotaOutput, error) {
+	return c.servicequotasClient.GetAWSDefaultServiceQuota(input)
+}
+
 func (c *AwsClient) RequestServiceQuotaIncrease(input *servicequotas.RequestServiceQuotaIncreaseInput) (*servicequotas.RequestServiceQuotaIncreaseOutput, error) {
 	return c.servicequotasClient.RequestServiceQuotaIncrease(input)
 }
@@ -421,15 +594,28 @@ func (c *AwsClient) GetResources(input *resourcegroupstaggingapi.GetResourcesInp
 	return c.resClient.GetResources(input)
 }
 
+func (c *AwsClient) TagResources(input *resourcegroupstaggingapi.TagResourcesInput) (*resourcegroupstaggingapi.TagResourcesOutput, error) {
+	return c.resClient.TagResources(input)
+}
+
 func (c *AwsClient) GetCostAndUsage(input *costexplorer.GetCostAndUsageInput) (*costexplorer.GetCostAndUsageOutput, error) {
+	if c.ceClient == nil {
+		return nil, serviceUnavailableError("Cost Explorer", c.partition)
+	}
 	return c.ceClient.GetCostAndUsage(input)
 }
 
 func (c *AwsClient) CreateCostCategoryDefinition(input *costexplorer.CreateCostCategoryDefinitionInput) (*costexplorer.CreateCostCategoryDefinitionOutput, error) {
+	if c.ceClient == nil {
+		return nil, serviceUnavailableError("Cost Explorer", c.partition)
+	}
 	return c.ceClient.CreateCostCategoryDefinition(input)
 }
 
 func (c *AwsClient) ListCostCategoryDefinitions(input *costexplorer.ListCostCategoryDefinitionsInput) (*costexplorer.ListCostCategoryDefinitionsOutput, error) {
+	if c.ceClient == nil {
+		return nil, serviceUnavailableError("Cost Explorer", c.partition)
+	}
 	return c.ceClient.ListCostCategoryDefinitions(input)
 }
 
@@ -449,6 +635,26 @@ func (c *AwsClient) DescribeVpcs(input *ec2.DescribeVpcsInput) (*ec2.DescribeVpc
 	return c.ec2Client.DescribeVpcs(input)
 }
 
+func (c *AwsClient) DescribeAddresses(input *ec2.DescribeAddressesInput) (*ec2.DescribeAddressesOutput, error) {
+	return c.ec2Client.DescribeAddresses(input)
+}
+
+func (c *AwsClient) DescribeNatGateways(input *ec2.DescribeNatGatewaysInput) (*ec2.DescribeNatGatewaysOutput, error) {
+	return c.ec2Client.DescribeNatGateways(input)
+}
+
+func (c *AwsClient) DescribeVolumes(input *ec2.DescribeVolumesInput) (*ec2.DescribeVolumesOutput, error) {
+	return c.ec2Client.DescribeVolumes(input)
+}
+
+func (c *AwsClient) DescribeSecurityGroups(input *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return c.ec2Client.DescribeSecurityGroups(input)
+}
+
+func (c *AwsClient) DescribeNetworkAcls(input *ec2.DescribeNetworkAclsInput) (*ec2.DescribeNetworkAclsOutput, error) {
+	return c.ec2Client.DescribeNetworkAcls(input)
+}
+
 func (c *AwsClient) StopInstances(input *ec2.StopInstancesInput) (*ec2.StopInstancesOutput, error) {
 	return c.ec2Client.StopInstances(input)
 }
@@ -461,6 +667,22 @@ func (c *AwsClient) StartInstances(input *ec2.StartInstancesInput) (*ec2.StartIn
 	return c.ec2Client.StartInstances(input)
 }
 
+func (c *AwsClient) RebootInstances(input *ec2.RebootInstancesInput) (*ec2.RebootInstancesOutput, error) {
+	return c.ec2Client.RebootInstances(input)
+}
+
+func (c *AwsClient) CreateFlowLogs(input *ec2.CreateFlowLogsInput) (*ec2.CreateFlowLogsOutput, error) {
+	return c.ec2Client.CreateFlowLogs(input)
+}
+
+func (c *AwsClient) DescribeFlowLogs(input *ec2.DescribeFlowLogsInput) (*ec2.DescribeFlowLogsOutput, error) {
+	return c.ec2Client.DescribeFlowLogs(input)
+}
+
+func (c *AwsClient) DeleteFlowLogs(input *ec2.DeleteFlowLogsInput) (*ec2.DeleteFlowLogsOutput, error) {
+	return c.ec2Client.DeleteFlowLogs(input)
+}
+
 func (c *AwsClient) WaitUntilInstanceRunning(input *ec2.DescribeInstancesInput) error {
 	return c.ec2Client.WaitUntilInstanceRunning(input)
 }
@@ -472,3 +694,50 @@ func (c *AwsClient) WaitUntilInstanceStopped(input *ec2.DescribeInstancesInput)
 func (c *AwsClient) LookupEvents(input *cloudtrail.LookupEventsInput) (*cloudtrail.LookupEventsOutput, error) {
 	return c.cloudTrailClient.LookupEvents(input)
 }
+
+func (c *AwsClient) ListHostedZonesByName(input *route53.ListHostedZonesByNameInput) (*route53.ListHostedZonesByNameOutput, error) {
+	return c.route53Client.ListHostedZonesByName(input)
+}
+
+func (c *AwsClient) ListResourceRecordSets(input *route53.ListResourceRecordSetsInput) (*route53.ListResourceRecordSetsOutput, error) {
+	return c.route53Client.ListResourceRecordSets(input)
+}
+
+func (c *AwsClient) ChangeResourceRecordSets(input *route53.ChangeResourceRecordSetsInput) (*route53.ChangeResourceRecordSetsOutput, error) {
+	return c.route53Client.ChangeResourceRecordSets(input)
+}
+
+func (c *AwsClient) DescribeLoadBalancers(input *elb.DescribeLoadBalancersInput) (*elb.DescribeLoadBalancersOutput, error) {
+	return c.elbClient.DescribeLoadBalancers(input)
+}
+
+func (c *AwsClient) DescribeInstanceHealth(input *elb.DescribeInstanceHealthInput) (*elb.DescribeInstanceHealthOutput, error) {
+	return c.elbClient.DescribeInstanceHealth(input)
+}
+
+// DescribeV2LoadBalancers describes the application and network load balancers, DescribeLoadBalancers the classic ones
+func (c *AwsClient) DescribeV2LoadBalancers(input *elbv2.DescribeLoadBalancersInput) (*elbv2.DescribeLoadBalancersOutput, error) {
+	return c.elbv2Client.DescribeLoadBalancers(input)
+}
+
+func (c *AwsClient) DescribeTargetGroups(input *elbv2.DescribeTargetGroupsInput) (*elbv2.DescribeTargetGroupsOutput, error) {
+	return c.elbv2Client.DescribeTargetGroups(input)
+}
+
+func (c *AwsClient) DescribeTargetHealth(input *elbv2.DescribeTargetHealthInput) (*elbv2.DescribeTargetHealthOutput, error) {
+	return c.elbv2Client.DescribeTargetHealth(input)
+}
+
+func (c *AwsClient) GetProducts(input *pricing.GetProductsInput) (*pricing.GetProductsOutput, error) {
+	if c.pricingClient == nil {
+		return nil, serviceUnavailableError("The pricing API", c.partition)
+	}
+	return c.pricingClient.GetProducts(input)
+}
+
+func (c *AwsClient) CreateCase(input *support.CreateCaseInput) (*support.CreateCaseOutput, error) {
+	if c.supportClient == nil {
+		return nil, serviceUnavailableError("AWS Support", c.partition)
+	}
+	return c.supportClient.CreateCase(input)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/service/iam"
 	awsv1alpha1 "github.com/openshift/aws-account-operator/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -208,6 +209,7 @@ func RefreshIAMPolicy(awsClient Client, federatedRole *awsv1alpha1.AWSFederatedR
 	return nil
 }
 
+// GenerateRoleARN returns the ARN of the role in the account of the commercial partition
 func GenerateRoleARN(accountId, roleName string) string {
-	return fmt.Sprintf("arn:aws:iam::%s:role/%s", accountId, roleName)
+	return GenerateRoleARNInPartition(endpoints.AwsPartitionID, accountId, roleName)
 }
@@ -0,0 +1,86 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+)
+
+// partitionRegions are the regions the global services of a partition are reached through.
+// An empty region means the service isn't available in the partition.
+type partitionRegions struct {
+	// iam is the region the IAM and STS endpoints of the partition are reached through
+	iam          string
+	support      string
+	pricing      string
+	costExplorer string
+}
+
+var globalServiceRegions = map[string]partitionRegions{
+	endpoints.AwsPartitionID: {
+		iam:          "us-east-1",
+		support:      supportRegion,
+		pricing:      pricingRegion,
+		costExplorer: "us-east-1",
+	},
+	// GovCloud bills through the linked commercial account, it has no pricing nor Cost Explorer endpoint
+	endpoints.AwsUsGovPartitionID: {
+		iam:     "us-gov-west-1",
+		support: "us-gov-west-1",
+	},
+	endpoints.AwsCnPartitionID: {
+		iam:          "cn-north-1",
+		support:      "cn-north-1",
+		pricing:      "cn-northwest-1",
+		costExplorer: "cn-northwest-1",
+	},
+}
+
+// PartitionForRegion returns the partition of the region, e.g. aws-us-gov for us-gov-west-1, the commercial one when
+// the region is empty or unknown
+func PartitionForRegion(region string) string {
+	if partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok {
+		return partition.ID()
+	}
+	return endpoints.AwsPartitionID
+}
+
+// GlobalRegion returns the region of the IAM and STS endpoints of the partition, us-east-1 for an unknown partition
+func GlobalRegion(partition string) string {
+	if regions, ok := globalServiceRegions[partition]; ok {
+		return regions.iam
+	}
+	return globalServiceRegions[endpoints.AwsPartitionID].iam
+}
+
+// GenerateRoleARNInPartition returns the ARN of the role in the account of the partition
+func GenerateRoleARNInPartition(partition, accountId, roleName string) string {
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", partition, accountId, roleName)
+}
+
+// GeneratePolicyARNInPartition returns the ARN of the policy in the account of the partition, the account of the
+// AWS managed policies is "aws"
+func GeneratePolicyARNInPartition(partition, accountId, policyName string) string {
+	return fmt.Sprintf("arn:%s:iam::%s:policy/%s", partition, accountId, policyName)
+}
+
+// GenerateS3ARNInPartition returns the ARN of the bucket, or of a key of the bucket given as bucket/key, in the
+// partition
+func GenerateS3ARNInPartition(partition, path string) string {
+	return fmt.Sprintf("arn:%s:s3:::%s", partition, path)
+}
+
+// ServiceEndpointURL returns the URL of the service in the region, under the DNS suffix of the partition of the
+// region, e.g. https://logs.cn-north-1.amazonaws.com.cn
+func ServiceEndpointURL(service, region string) string {
+	dnsSuffix := "amazonaws.com"
+	if partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); ok {
+		dnsSuffix = partition.DNSSuffix()
+	}
+	return fmt.Sprintf("https://%s.%s.%s", service, region, dnsSuffix)
+}
+
+// serviceUnavailableError is returned by the clients of the global services the partition doesn't have
+func serviceUnavailableError(service, partition string) error {
+	return fmt.Errorf("%s isn't available in the %s partition", service, partition)
+}
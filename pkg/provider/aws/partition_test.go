@@ -0,0 +1,77 @@
+package aws
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/costexplorer"
+	"github.com/aws/aws-sdk-go/service/pricing"
+)
+
+func TestPartitionForRegion(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tests := []struct {
+		title    string
+		region   string
+		expected string
+	}{
+		{
+			title:    "Commercial region",
+			region:   "eu-west-1",
+			expected: endpoints.AwsPartitionID,
+		},
+		{
+			title:    "GovCloud region",
+			region:   "us-gov-west-1",
+			expected: endpoints.AwsUsGovPartitionID,
+		},
+		{
+			title:    "China region",
+			region:   "cn-northwest-1",
+			expected: endpoints.AwsCnPartitionID,
+		},
+		{
+			title:    "No region",
+			region:   "",
+			expected: endpoints.AwsPartitionID,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.title, func(t *testing.T) {
+			g.Expect(PartitionForRegion(tc.region)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestGenerateRoleARNInPartition(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(GenerateRoleARNInPartition(endpoints.AwsUsGovPartitionID, "123456789012", "RH-SRE-CCS-Access")).
+		To(Equal("arn:aws-us-gov:iam::123456789012:role/RH-SRE-CCS-Access"))
+	g.Expect(GenerateRoleARN("123456789012", "RH-SRE-CCS-Access")).
+		To(Equal("arn:aws:iam::123456789012:role/RH-SRE-CCS-Access"))
+}
+
+func TestPartitionARNsAndEndpoints(t *testing.T) {
+	g := NewGomegaWithT(t)
+	g.Expect(GeneratePolicyARNInPartition(endpoints.AwsCnPartitionID, "aws", "AdministratorAccess")).
+		To(Equal("arn:aws-cn:iam::aws:policy/AdministratorAccess"))
+	g.Expect(GenerateS3ARNInPartition(endpoints.AwsUsGovPartitionID, "flow-logs/abc123/")).
+		To(Equal("arn:aws-us-gov:s3:::flow-logs/abc123/"))
+	g.Expect(ServiceEndpointURL("logs", "cn-north-1")).To(Equal("https://logs.cn-north-1.amazonaws.com.cn"))
+	g.Expect(ServiceEndpointURL("logs", "eu-west-1")).To(Equal("https://logs.eu-west-1.amazonaws.com"))
+	g.Expect(GlobalRegion(endpoints.AwsUsGovPartitionID)).To(Equal("us-gov-west-1"))
+	g.Expect(GlobalRegion("unknown")).To(Equal("us-east-1"))
+}
+
+func TestGlobalServicesOfPartition(t *testing.T) {
+	g := NewGomegaWithT(t)
+	client := &AwsClient{partition: endpoints.AwsUsGovPartitionID}
+
+	_, err := client.GetCostAndUsage(&costexplorer.GetCostAndUsageInput{})
+	g.Expect(err).To(MatchError("Cost Explorer isn't available in the aws-us-gov partition"))
+	_, err = client.GetProducts(&pricing.GetProductsInput{})
+	g.Expect(err).To(MatchError("The pricing API isn't available in the aws-us-gov partition"))
+}
@@ -54,6 +54,9 @@ func GetFederationEndpointUrl(partition string) (string, error) {
 	case endpoints.AwsUsGovPartitionID:
 		// us-gov-west-1 endpoint
 		return "https://signin.amazonaws-us-gov.com/federation", nil
+	case endpoints.AwsCnPartitionID:
+		// cn-north-1 endpoint
+		return "https://signin.amazonaws.cn/federation", nil
 	default:
 		return "", fmt.Errorf("invalid partition %s", partition)
 	}
@@ -68,6 +71,9 @@ func GetConsoleUrl(partition string) (string, error) {
 	case endpoints.AwsUsGovPartitionID:
 		// us-gov-west-1 endpoint
 		return "https://console.amazonaws-us-gov.com/", nil
+	case endpoints.AwsCnPartitionID:
+		// cn-north-1 endpoint
+		return "https://console.amazonaws.cn/", nil
 	default:
 		return "", fmt.Errorf("invalid partition %s", partition)
 	}
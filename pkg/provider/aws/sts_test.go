@@ -102,6 +102,11 @@ func TestGetFederationEndpointUrl(t *testing.T) {
 			partition:   endpoints.AwsPartitionID,
 			errExpected: false,
 		},
+		{
+			title:       "AWS China partition",
+			partition:   endpoints.AwsCnPartitionID,
+			errExpected: false,
+		},
 		{
 			title:       "Invalid partition",
 			partition:   "hello",
@@ -131,6 +136,11 @@ func TestGetConsoleUrl(t *testing.T) {
 			partition:   endpoints.AwsUsGovPartitionID,
 			errExpected: false,
 		},
+		{
+			title:       "AWS China partition",
+			partition:   endpoints.AwsCnPartitionID,
+			errExpected: false,
+		},
 		{
 			title:       "Invalid partition",
 			partition:   "hello",
@@ -0,0 +1,62 @@
+package gcp
+
+// Generate client mocks for testing
+//go:generate mockgen -source=client.go -package=mock -destination=mock/client.go
+
+import (
+	"context"
+
+	compute "cloud.google.com/go/compute/apiv1"
+	"google.golang.org/api/iterator"
+	computepb "google.golang.org/genproto/googleapis/cloud/compute/v1"
+)
+
+// Client is a wrapper around the GCP APIs used by osdctl, so that commands can be tested with mocks
+type Client interface {
+	// Compute
+	ListInstances(projectID, zone string) ([]*computepb.Instance, error)
+
+	Close() error
+}
+
+type GcpClient struct {
+	instancesClient *compute.InstancesClient
+}
+
+// NewGcpClient creates a GCP client using the application default credentials
+func NewGcpClient() (Client, error) {
+	instancesClient, err := compute.NewInstancesRESTClient(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	return &GcpClient{
+		instancesClient: instancesClient,
+	}, nil
+}
+
+// ListInstances returns all instances of the project in the given zone
+func (c *GcpClient) ListInstances(projectID, zone string) ([]*computepb.Instance, error) {
+	request := &computepb.ListInstancesRequest{
+		Project: projectID,
+		Zone:    zone,
+	}
+
+	var instances []*computepb.Instance
+	it := c.instancesClient.List(context.Background(), request)
+	for {
+		instance, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+func (c *GcpClient) Close() error {
+	return c.instancesClient.Close()
+}
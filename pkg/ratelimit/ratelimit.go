@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// OCMRateConfigKey is the sustained number of OCM API requests per second. 0 disables limiting.
+	OCMRateConfigKey = "ocm_rate_limit"
+	// OCMBurstConfigKey is the number of OCM API requests allowed in a burst
+	OCMBurstConfigKey = "ocm_rate_burst"
+	// AWSRateConfigKey is the sustained number of AWS API requests per second. 0 disables limiting.
+	AWSRateConfigKey = "aws_rate_limit"
+	// AWSBurstConfigKey is the number of AWS API requests allowed in a burst
+	AWSBurstConfigKey = "aws_rate_burst"
+	// BatchShareConfigKey is the share of the OCM and AWS rates the batch commands use, 1 for all of it. The
+	// server-side limits are shared by the osdctl processes of a user, this leaves room for the interactive ones.
+	BatchShareConfigKey = "batch_rate_share"
+
+	OCMRateFlag = "ocm-rate-limit"
+	AWSRateFlag = "aws-rate-limit"
+
+	defaultOCMRate  = 10.0
+	defaultOCMBurst = 20
+	defaultAWSRate  = 10.0
+	defaultAWSBurst = 20
+
+	defaultBatchShare = 0.5
+)
+
+var (
+	ocmLimiter     *rate.Limiter
+	ocmLimiterOnce sync.Once
+	awsLimiter     *rate.Limiter
+	awsLimiterOnce sync.Once
+	batchOnce      sync.Once
+)
+
+func init() {
+	viper.SetDefault(OCMRateConfigKey, defaultOCMRate)
+	viper.SetDefault(OCMBurstConfigKey, defaultOCMBurst)
+	viper.SetDefault(AWSRateConfigKey, defaultAWSRate)
+	viper.SetDefault(AWSBurstConfigKey, defaultAWSBurst)
+	viper.SetDefault(BatchShareConfigKey, defaultBatchShare)
+}
+
+// AddFlags adds the rate limiting flags to the given command and binds them to the config
+// keys, so that flags take precedence over the config file
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Float64(OCMRateFlag, defaultOCMRate, "Maximum OCM API requests per second, 0 to disable (config key: "+OCMRateConfigKey+")")
+	cmd.PersistentFlags().Float64(AWSRateFlag, defaultAWSRate, "Maximum AWS API requests per second, 0 to disable (config key: "+AWSRateConfigKey+")")
+	_ = viper.BindPFlag(OCMRateConfigKey, cmd.PersistentFlags().Lookup(OCMRateFlag))
+	_ = viper.BindPFlag(AWSRateConfigKey, cmd.PersistentFlags().Lookup(AWSRateFlag))
+}
+
+// newLimiter returns nil when limiting is disabled
+func newLimiter(rateKey, burstKey string) *rate.Limiter {
+	limit := viper.GetFloat64(rateKey)
+	if limit <= 0 {
+		return nil
+	}
+	burst := viper.GetInt(burstKey)
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(limit), burst)
+}
+
+// OCM returns the limiter shared by all OCM connections of this process
+func OCM() *rate.Limiter {
+	ocmLimiterOnce.Do(func() {
+		ocmLimiter = newLimiter(OCMRateConfigKey, OCMBurstConfigKey)
+	})
+	return ocmLimiter
+}
+
+// AWS returns the limiter shared by all AWS sessions of this process
+func AWS() *rate.Limiter {
+	awsLimiterOnce.Do(func() {
+		awsLimiter = newLimiter(AWSRateConfigKey, AWSBurstConfigKey)
+	})
+	return awsLimiter
+}
+
+// Batch lowers the OCM and AWS limits of the process to their batch share, for the rest of the command. It is
+// called by the batch commands before they start.
+func Batch() {
+	batchOnce.Do(func() {
+		share := viper.GetFloat64(BatchShareConfigKey)
+		if share <= 0 || share >= 1 {
+			return
+		}
+		for _, limiter := range []*rate.Limiter{OCM(), AWS()} {
+			lowerLimiter(limiter, share)
+		}
+	})
+}
+
+func lowerLimiter(limiter *rate.Limiter, share float64) {
+	if limiter == nil {
+		return
+	}
+	limiter.SetLimit(limiter.Limit() * rate.Limit(share))
+	if burst := int(float64(limiter.Burst()) * share); burst >= 1 {
+		limiter.SetBurst(burst)
+	} else {
+		limiter.SetBurst(1)
+	}
+}
+
+type transport struct {
+	limiter *rate.Limiter
+	wrapped http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.wrapped.RoundTrip(req)
+}
+
+// OCMTransportWrapper returns a wrapper suitable for sdk.ConnectionBuilder.TransportWrapper
+// that delays requests according to the shared OCM limiter
+func OCMTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	limiter := OCM()
+	if limiter == nil {
+		return wrapped
+	}
+	return &transport{limiter: limiter, wrapped: wrapped}
+}
+
+// AttachToAWSSession makes every request sent through the session wait for the shared AWS limiter
+func AttachToAWSSession(sess *session.Session) {
+	limiter := AWS()
+	if limiter == nil {
+		return
+	}
+	sess.Handlers.Send.PushFrontNamed(request.NamedHandler{
+		Name: "osdctl.ratelimit",
+		Fn: func(r *request.Request) {
+			ctx := r.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if err := limiter.Wait(ctx); err != nil {
+				r.Error = err
+			}
+		},
+	})
+}
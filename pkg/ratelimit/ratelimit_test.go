@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+)
+
+func TestNewLimiterDisabled(t *testing.T) {
+	viper.Set(OCMRateConfigKey, 0)
+	defer viper.Set(OCMRateConfigKey, defaultOCMRate)
+
+	if limiter := newLimiter(OCMRateConfigKey, OCMBurstConfigKey); limiter != nil {
+		t.Fatalf("expected no limiter when the rate is 0, got %v", limiter)
+	}
+}
+
+func TestNewLimiterFromConfig(t *testing.T) {
+	viper.Set(AWSRateConfigKey, 2.5)
+	viper.Set(AWSBurstConfigKey, 0)
+	defer viper.Set(AWSRateConfigKey, defaultAWSRate)
+	defer viper.Set(AWSBurstConfigKey, defaultAWSBurst)
+
+	limiter := newLimiter(AWSRateConfigKey, AWSBurstConfigKey)
+	if limiter == nil {
+		t.Fatal("expected a limiter")
+	}
+	if limiter.Limit() != rate.Limit(2.5) {
+		t.Errorf("expected a limit of 2.5, got %v", limiter.Limit())
+	}
+	if limiter.Burst() != 1 {
+		t.Errorf("expected the burst to be raised to 1, got %d", limiter.Burst())
+	}
+}
+
+func TestTransportDelaysRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// One request immediately, then one every 50ms
+	client := &http.Client{Transport: &transport{
+		limiter: rate.NewLimiter(rate.Every(50*time.Millisecond), 1),
+		wrapped: http.DefaultTransport,
+	}}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected requests to be rate limited, 3 requests took %s", elapsed)
+	}
+}
+
+func TestLowerLimiter(t *testing.T) {
+	limiter := rate.NewLimiter(10, 20)
+	lowerLimiter(limiter, 0.5)
+	if limiter.Limit() != 5 || limiter.Burst() != 10 {
+		t.Errorf("expected a limit of 5 and a burst of 10, got %v and %d", limiter.Limit(), limiter.Burst())
+	}
+
+	limiter = rate.NewLimiter(1, 1)
+	lowerLimiter(limiter, 0.2)
+	if limiter.Burst() != 1 {
+		t.Errorf("expected the burst to stay at 1, got %d", limiter.Burst())
+	}
+	lowerLimiter(nil, 0.5)
+}
@@ -0,0 +1,187 @@
+package readonly
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigKey makes osdctl refuse every mutating OCM, AWS, Kubernetes and oc call when true
+	ConfigKey = "read_only"
+	Flag      = "read-only"
+
+	// Request bodies longer than this are truncated when printing what would have been sent
+	maxPrintedBody = 4096
+)
+
+// Output is where the refused calls are printed, it is a variable for tests
+var Output io.Writer = os.Stderr
+
+// readOnlyAWSPrefixes are the prefixes of the AWS operations that don't change anything. AssumeRole only
+// returns credentials, it is needed to read from the cluster accounts.
+var readOnlyAWSPrefixes = []string{"Describe", "Get", "List", "Lookup", "Search", "Simulate", "Head", "AssumeRole"}
+
+// readOnlyOCVerbs are the oc subcommands that don't change anything, exec is handled separately
+var readOnlyOCVerbs = map[string]bool{
+	"get":           true,
+	"describe":      true,
+	"logs":          true,
+	"explain":       true,
+	"api-resources": true,
+	"api-versions":  true,
+	"version":       true,
+	"whoami":        true,
+}
+
+// readOnlyExecWords are the subcommands making a command run with oc exec read-only, e.g.
+// 'etcdctl endpoint status', 'amtool silence query' or a query to the Prometheus API
+var readOnlyExecWords = []string{"status", "health", "list", "query"}
+
+// AddFlags adds the --read-only flag to the given command and binds it to the config key,
+// so that the flag takes precedence over the config file
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(Flag, false, "Refuse every call that would change something and print it instead (config key: "+ConfigKey+")")
+	_ = viper.BindPFlag(ConfigKey, cmd.PersistentFlags().Lookup(Flag))
+}
+
+// Enabled returns true when read-only mode is on
+func Enabled() bool {
+	return viper.GetBool(ConfigKey)
+}
+
+// refuse prints what would have been done and returns the error to abort with
+func refuse(action, details string) error {
+	fmt.Fprintf(Output, "Read-only mode, not running: %s\n", action)
+	if details != "" {
+		fmt.Fprintln(Output, details)
+	}
+	return osdctlErrors.New(osdctlErrors.ErrForbidden, "refused to %s in read-only mode", action)
+}
+
+// Check returns an error when read-only mode is on, for commands changing things through other means than
+// the OCM, AWS and Kubernetes clients
+func Check(action string) error {
+	if !Enabled() {
+		return nil
+	}
+	return refuse(action, "")
+}
+
+type transport struct {
+	wrapped http.RoundTripper
+	// allowed returns true for the requests that don't use a read-only method but don't change anything either
+	allowed func(req *http.Request) bool
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !Enabled() || req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions || t.allowed(req) {
+		return t.wrapped.RoundTrip(req)
+	}
+
+	var details string
+	if req.Body != nil {
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxPrintedBody))
+		req.Body.Close()
+		if err == nil {
+			details = strings.TrimSpace(string(body))
+		}
+	}
+	return nil, refuse(fmt.Sprintf("%s %s", req.Method, req.URL.Redacted()), details)
+}
+
+// OCMTransportWrapper returns a wrapper suitable for sdk.ConnectionBuilder.TransportWrapper
+// that refuses the OCM requests changing something in read-only mode
+func OCMTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	return &transport{wrapped: wrapped, allowed: func(req *http.Request) bool {
+		// Getting an SSO token or a registry access token doesn't change anything
+		return strings.HasSuffix(req.URL.Path, "/token") || strings.HasSuffix(req.URL.Path, "/access_token")
+	}}
+}
+
+// KubeTransportWrapper returns a wrapper suitable for rest.Config.WrapTransport that refuses the Kubernetes
+// requests changing something in read-only mode
+func KubeTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	return &transport{wrapped: wrapped, allowed: func(req *http.Request) bool {
+		// Reviews are created to check permissions, they aren't persisted
+		return strings.HasSuffix(req.URL.Path, "/selfsubjectaccessreviews") || strings.HasSuffix(req.URL.Path, "/selfsubjectrulesreviews")
+	}}
+}
+
+// AttachToAWSSession makes every request sent through the session fail in read-only mode,
+// unless its operation doesn't change anything
+func AttachToAWSSession(sess *session.Session) {
+	sess.Handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "osdctl.readonly",
+		Fn: func(r *request.Request) {
+			if !Enabled() || r.Operation == nil || readOnlyAWSOperation(r.Operation.Name) {
+				return
+			}
+			r.Error = refuse(fmt.Sprintf("call %s %s", r.ClientInfo.ServiceName, r.Operation.Name), awsutil.Prettify(r.Params))
+		},
+	})
+}
+
+func readOnlyAWSOperation(operation string) bool {
+	for _, prefix := range readOnlyAWSPrefixes {
+		if strings.HasPrefix(operation, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOC returns an error when read-only mode is on and the oc command changes something. Commands run with
+// 'oc exec' are only run when they are one of the read-only readOnlyExecWords.
+func CheckOC(args []string) error {
+	if !Enabled() || readOnlyOCCommand(args) {
+		return nil
+	}
+	return refuse("oc "+strings.Join(args, " "), "")
+}
+
+func readOnlyOCCommand(args []string) bool {
+	// Skip the global flags, e.g. --as, to find the subcommand
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		if !strings.Contains(args[i], "=") {
+			i++
+		}
+		i++
+	}
+	if i >= len(args) {
+		return false
+	}
+	if readOnlyOCVerbs[args[i]] {
+		return true
+	}
+	if args[i] == "adm" && i+1 < len(args) && args[i+1] == "top" {
+		return true
+	}
+	if args[i] != "exec" {
+		return false
+	}
+
+	for j, arg := range args {
+		if arg != "--" {
+			continue
+		}
+		for _, word := range args[j+1:] {
+			for _, readOnly := range readOnlyExecWords {
+				if word == readOnly || strings.HasSuffix(word, "/"+readOnly) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
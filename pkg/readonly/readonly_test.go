@@ -0,0 +1,136 @@
+package readonly
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/viper"
+)
+
+func enable(t *testing.T) *bytes.Buffer {
+	out := &bytes.Buffer{}
+	previous := Output
+	Output = out
+	viper.Set(ConfigKey, true)
+	t.Cleanup(func() {
+		viper.Set(ConfigKey, false)
+		Output = previous
+	})
+	return out
+}
+
+func TestTransportRefusesMutatingRequests(t *testing.T) {
+	out := enable(t)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}
+
+	resp, err := client.Get(server.URL + "/api/clusters_mgmt/v1/clusters")
+	if err != nil {
+		t.Fatalf("expected GET to be sent: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = client.Post(server.URL+"/auth/realms/redhat-external/protocol/openid-connect/token", "application/x-www-form-urlencoded", nil)
+	if err != nil {
+		t.Fatalf("expected the token request to be sent: %v", err)
+	}
+	resp.Body.Close()
+
+	_, err = client.Post(server.URL+"/api/service_logs/v1/cluster_logs", "application/json", strings.NewReader(`{"summary":"test"}`))
+	if !errors.Is(err, osdctlErrors.ErrForbidden) {
+		t.Fatalf("expected the POST to be refused, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests to reach the server, got %d", requests)
+	}
+	if !strings.Contains(out.String(), "POST "+server.URL+"/api/service_logs/v1/cluster_logs") || !strings.Contains(out.String(), `{"summary":"test"}`) {
+		t.Errorf("expected the refused request to be printed, got %q", out.String())
+	}
+}
+
+func TestTransportDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: KubeTransportWrapper(http.DefaultTransport)}
+	resp, err := client.Post(server.URL+"/api/v1/namespaces", "application/json", nil)
+	if err != nil {
+		t.Fatalf("expected the request to be sent when read-only mode is off: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestAttachToAWSSession(t *testing.T) {
+	enable(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	AttachToAWSSession(sess)
+
+	_, err := ec2.New(sess).RebootInstances(&ec2.RebootInstancesInput{InstanceIds: aws.StringSlice([]string{"i-1"})})
+	if !errors.Is(err, osdctlErrors.ErrForbidden) {
+		t.Fatalf("expected RebootInstances to be refused, got %v", err)
+	}
+	if _, err := ec2.New(sess).DescribeInstances(&ec2.DescribeInstancesInput{}); errors.Is(err, osdctlErrors.ErrForbidden) {
+		t.Fatalf("expected DescribeInstances to be sent, got %v", err)
+	}
+}
+
+func TestReadOnlyOCCommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		readOnly bool
+	}{
+		{args: []string{"get", "clusterversion", "version"}, readOnly: true},
+		{args: []string{"--as", "backplane-cluster-admin", "get", "nodes"}, readOnly: true},
+		{args: []string{"adm", "top", "nodes"}, readOnly: true},
+		{args: []string{"adm", "cordon", "node-1"}},
+		{args: []string{"delete", "pod", "a"}},
+		{args: []string{"exec", "-n", "openshift-etcd", "etcd-a", "--", "etcdctl", "endpoint", "status"}, readOnly: true},
+		{args: []string{"exec", "-n", "openshift-monitoring", "prometheus-k8s-0", "--", "curl", "http://localhost:9090/api/v1/query"}, readOnly: true},
+		{args: []string{"exec", "-n", "openshift-etcd", "etcd-a", "--", "etcdctl", "defrag"}},
+		{args: []string{"exec", "-n", "openshift-monitoring", "alertmanager-main-0", "--", "amtool", "silence", "expire", "a"}},
+	}
+
+	for _, test := range tests {
+		if readOnly := readOnlyOCCommand(test.args); readOnly != test.readOnly {
+			t.Errorf("readOnlyOCCommand(%v): expected %t, got %t", test.args, test.readOnly, readOnly)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check("resize a node"); err != nil {
+		t.Fatalf("expected no error when read-only mode is off, got %v", err)
+	}
+	out := enable(t)
+	if err := Check("resize a node"); !errors.Is(err, osdctlErrors.ErrForbidden) {
+		t.Fatalf("expected a forbidden error, got %v", err)
+	}
+	if !strings.Contains(out.String(), "resize a node") {
+		t.Errorf("expected the refused action to be printed, got %q", out.String())
+	}
+}
@@ -0,0 +1,522 @@
+// Package recording records the OCM, AWS, Kubernetes and oc interactions of a command to a session file with
+// --record, and replays them with --replay, so that trainers can run realistic incident simulations without
+// touching live clusters
+package recording
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	awsv2 "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	credentialsv2 "github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/trace"
+	"github.com/spf13/cobra"
+)
+
+const (
+	RecordFlag = "record"
+	ReplayFlag = "replay"
+
+	// ReplayHost is the Kubernetes API server of the replayed sessions when there is no kubeconfig
+	ReplayHost = "https://replay.invalid"
+
+	// replayCredential is the AWS access key and secret of the replayed sessions, the requests are never signed
+	// for real
+	replayCredential = "REPLAY"
+
+	version = 1
+)
+
+// replayToken is the OCM access token of the replayed sessions, it is not signed and expires in 2100
+var replayToken = strings.Join([]string{
+	base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)),
+	base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"Bearer","exp":4102444800,"preferred_username":"replay"}`)),
+	"replay",
+}, ".")
+
+// File is the content of a session file
+type File struct {
+	Version      int           `json:"version"`
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request and its response, or an oc command and its output. The bodies which aren't valid
+// UTF-8 are base64 encoded, so that the responses can be edited to build a scenario.
+type Interaction struct {
+	// Client is ocm, aws, kube or oc
+	Client       string      `json:"client"`
+	Method       string      `json:"method,omitempty"`
+	URL          string      `json:"url,omitempty"`
+	RequestBody  string      `json:"request_body,omitempty"`
+	Args         []string    `json:"args,omitempty"`
+	Status       int         `json:"status,omitempty"`
+	Header       http.Header `json:"header,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty"`
+	Error        string      `json:"error,omitempty"`
+}
+
+// recorder is the session being recorded or replayed
+type recorder struct {
+	mu     sync.Mutex
+	path   string
+	replay bool
+	file   File
+	// used are the replayed interactions already served
+	used map[int]bool
+}
+
+var (
+	recordPath string
+	replayPath string
+
+	current   *recorder
+	currentMu sync.Mutex
+)
+
+// AddFlags adds the --record and --replay flags to the given command
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&recordPath, RecordFlag, "", "Record the OCM, AWS, Kubernetes and oc interactions to the given session file, appending to it when it exists")
+	cmd.PersistentFlags().StringVar(&replayPath, ReplayFlag, "", "Replay the interactions of the given session file instead of calling OCM, AWS and the clusters")
+}
+
+// Open starts recording or replaying when --record or --replay was given
+func Open() error {
+	if recordPath != "" && replayPath != "" {
+		return fmt.Errorf("--%s and --%s can't be used together", RecordFlag, ReplayFlag)
+	}
+	if recordPath == "" && replayPath == "" {
+		return nil
+	}
+
+	r := &recorder{path: recordPath, used: map[int]bool{}}
+	if replayPath != "" {
+		r.path, r.replay = replayPath, true
+	}
+	file, err := load(r.path)
+	switch {
+	case err == nil:
+		r.file = file
+	case os.IsNotExist(err) && !r.replay:
+		// Fail before the command runs when the session can't be written
+		if err := save(r.path, File{Version: version}); err != nil {
+			return err
+		}
+		r.file = File{Version: version, RecordedAt: time.Now().UTC()}
+	default:
+		return err
+	}
+
+	currentMu.Lock()
+	current = r
+	currentMu.Unlock()
+	// The commands failing exit through osdctlErrors.CheckErr without returning to main
+	osdctlErrors.OnExit(func(error) {
+		if err := Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot save the recorded session: %v\n", err)
+		}
+	})
+	if r.replay {
+		fmt.Fprintf(os.Stderr, "Replaying the session %s, no request reaches OCM, AWS or the clusters\n", r.path)
+	}
+	return nil
+}
+
+// Close writes the recorded session, it does nothing when replaying or when nothing is recorded
+func Close() error {
+	currentMu.Lock()
+	r := current
+	current = nil
+	currentMu.Unlock()
+	if r == nil || r.replay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return save(r.path, r.file)
+}
+
+// Replaying returns true when --replay was given, the callers then skip what needs credentials or a live cluster
+func Replaying() bool {
+	r := currentRecorder()
+	return r != nil && r.replay
+}
+
+// Active returns true when --record or --replay was given, the responses must then come from the wrapped clients
+func Active() bool {
+	return currentRecorder() != nil
+}
+
+// ReplayToken returns the OCM access token of the replayed sessions, no real token is needed to replay
+func ReplayToken() string {
+	return replayToken
+}
+
+// AWSCredentials returns the AWS credentials of the replayed sessions
+func AWSCredentials() *credentials.Credentials {
+	return credentials.NewStaticCredentials(replayCredential, replayCredential, "")
+}
+
+func currentRecorder() *recorder {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	return current
+}
+
+func load(path string) (File, error) {
+	var file File
+	content, err := os.ReadFile(path) //#nosec G304 -- path is provided by the user
+	if err != nil {
+		return file, err
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return file, fmt.Errorf("cannot parse the session file '%s': %w", path, err)
+	}
+	if file.Version != version {
+		return file, fmt.Errorf("unsupported version %d of the session file '%s', expected %d", file.Version, path, version)
+	}
+	return file, nil
+}
+
+func save(path string, file File) error {
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The responses hold the data of the clusters, the file is only readable by the user
+	if err := os.WriteFile(path, append(content, '\n'), 0600); err != nil {
+		return fmt.Errorf("cannot write the session file '%s': %w", path, err)
+	}
+	return nil
+}
+
+func (r *recorder) add(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.file.Interactions = append(r.file.Interactions, interaction)
+}
+
+// find returns the first interaction which wasn't served yet matching exactly, then the first one matching
+// loosely, e.g. a search whose time window changed since the recording. Once the matching ones are used up,
+// the last one is served again, so that the commands polling a status get the last recorded one.
+func (r *recorder) find(exact, loose func(Interaction) bool) (Interaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, matches := range []func(Interaction) bool{exact, loose} {
+		last := -1
+		for i, interaction := range r.file.Interactions {
+			if !matches(interaction) {
+				continue
+			}
+			if !r.used[i] {
+				r.used[i] = true
+				return interaction, true
+			}
+			last = i
+		}
+		if last >= 0 {
+			return r.file.Interactions[last], true
+		}
+	}
+	return Interaction{}, false
+}
+
+func (r *recorder) notRecorded(what string) error {
+	return osdctlErrors.New(osdctlErrors.ErrNotFound, "%s wasn't recorded in the session '%s', record it again with the same command", what, r.path)
+}
+
+type transport struct {
+	client  string
+	wrapped http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := currentRecorder()
+	if r == nil || streaming(req) {
+		if r != nil && r.replay {
+			return nil, r.notRecorded(fmt.Sprintf("the streamed %s %s", req.Method, trace.SanitizeURL(req.URL)))
+		}
+		return t.wrapped.RoundTrip(req)
+	}
+
+	requestBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.replay {
+		return t.replay(r, req, requestBody)
+	}
+	// The transport must not change the request of the caller
+	req = req.Clone(req.Context())
+	if req.Body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+	resp, err := t.wrapped.RoundTrip(req)
+	if err != nil || tokenRequest(req.URL) {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Client:      t.client,
+		Method:      req.Method,
+		URL:         trace.SanitizeURL(req.URL),
+		RequestBody: string(redact(requestBody)),
+		Status:      resp.StatusCode,
+		Header:      recordedHeader(resp.Header),
+	}
+	interaction.setBody(redact(body))
+	r.add(interaction)
+	return resp, nil
+}
+
+func (t *transport) replay(r *recorder, req *http.Request, requestBody []byte) (*http.Response, error) {
+	target := requestTarget(t.client, req.URL)
+	path := requestPath(t.client, req.URL)
+	redactedBody := string(redact(requestBody))
+	interaction, ok := r.find(func(i Interaction) bool {
+		return i.Client == t.client && i.Method == req.Method && recordedTarget(i) == target && i.RequestBody == redactedBody
+	}, func(i Interaction) bool {
+		return i.Client == t.client && i.Method == req.Method && recordedPath(i) == path
+	})
+	if !ok {
+		return nil, r.notRecorded(fmt.Sprintf("%s %s", req.Method, trace.SanitizeURL(req.URL)))
+	}
+
+	body, err := interaction.body()
+	if err != nil {
+		return nil, err
+	}
+	header := interaction.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Transport returns a round tripper recording or replaying the requests sent through the wrapped one, client
+// names the API
+func Transport(client string, wrapped http.RoundTripper) http.RoundTripper {
+	if wrapped == nil {
+		wrapped = http.DefaultTransport
+	}
+	return &transport{client: client, wrapped: wrapped}
+}
+
+// OCMTransportWrapper returns a wrapper suitable for sdk.ConnectionBuilder.TransportWrapper that records or
+// replays the OCM requests
+func OCMTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	if currentRecorder() == nil {
+		return wrapped
+	}
+	return Transport("ocm", wrapped)
+}
+
+// KubeTransportWrapper returns a wrapper suitable for rest.Config.WrapTransport that records or replays the
+// Kubernetes requests
+func KubeTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	if currentRecorder() == nil {
+		return wrapped
+	}
+	return Transport("kube", wrapped)
+}
+
+// AttachToAWSSession makes the clients created from the session record or replay their requests, the replayed
+// sessions use fake credentials
+func AttachToAWSSession(sess *session.Session) {
+	r := currentRecorder()
+	if r == nil {
+		return
+	}
+	// Never change the shared http.DefaultClient, the session gets its own client
+	client := &http.Client{}
+	if sess.Config.HTTPClient != nil {
+		*client = *sess.Config.HTTPClient
+	}
+	client.Transport = Transport("aws", client.Transport)
+	sess.Config.HTTPClient = client
+	if r.replay {
+		sess.Config.Credentials = AWSCredentials()
+	}
+}
+
+// AWSV2Options returns the aws-sdk-go-v2 config options making the clients record or replay their requests, they
+// wrap the HTTP client of the previous options
+func AWSV2Options() []func(*config.LoadOptions) error {
+	r := currentRecorder()
+	if r == nil {
+		return nil
+	}
+	options := []func(*config.LoadOptions) error{func(o *config.LoadOptions) error {
+		var wrapped http.RoundTripper
+		if o.HTTPClient != nil {
+			wrapped = doerTransport{o.HTTPClient}
+		}
+		o.HTTPClient = &http.Client{Transport: Transport("aws", wrapped)}
+		return nil
+	}}
+	if r.replay {
+		options = append(options, config.WithCredentialsProvider(awsv2.NewCredentialsCache(
+			credentialsv2.NewStaticCredentialsProvider(replayCredential, replayCredential, ""))))
+	}
+	return options
+}
+
+// doerTransport sends the requests with the HTTP client of an aws-sdk-go-v2 config
+type doerTransport struct {
+	client config.HTTPClient
+}
+
+func (d doerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return d.client.Do(req)
+}
+
+// OC records or replays the oc command with the given arguments, run runs it
+func OC(args []string, run func() ([]byte, error)) ([]byte, error) {
+	r := currentRecorder()
+	if r == nil {
+		return run()
+	}
+	if r.replay {
+		interaction, ok := r.find(func(i Interaction) bool {
+			return i.Client == "oc" && strings.Join(i.Args, "\x00") == strings.Join(args, "\x00")
+		}, func(Interaction) bool { return false })
+		if !ok {
+			return nil, r.notRecorded("oc " + strings.Join(args, " "))
+		}
+		if interaction.Error != "" {
+			return nil, fmt.Errorf("%s", interaction.Error)
+		}
+		return interaction.body()
+	}
+
+	output, err := run()
+	interaction := Interaction{Client: "oc", Args: args}
+	interaction.setBody(redact(output))
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	r.add(interaction)
+	return output, err
+}
+
+func (i *Interaction) setBody(body []byte) {
+	if utf8.Valid(body) {
+		i.Body = string(body)
+		return
+	}
+	i.Body, i.BodyEncoding = base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func (i Interaction) body() ([]byte, error) {
+	if i.BodyEncoding == "base64" {
+		return base64.StdEncoding.DecodeString(i.Body)
+	}
+	return []byte(i.Body), nil
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+// streaming returns true for the requests whose response never ends, like Kubernetes watches and followed logs,
+// they can't be recorded
+func streaming(req *http.Request) bool {
+	query := req.URL.Query()
+	return query.Get("watch") == "true" || query.Get("follow") == "true"
+}
+
+// tokenRequest returns true for the requests to the SSO token endpoint, their responses are credentials and are
+// never recorded
+func tokenRequest(u *url.URL) bool {
+	return strings.HasSuffix(u.Path, "/token")
+}
+
+// recordedHeader returns the response headers worth replaying, without credentials and without the length of
+// a body which may be redacted
+func recordedHeader(header http.Header) http.Header {
+	recorded := http.Header{}
+	for name, values := range header {
+		if trace.SensitiveHeader(name) || http.CanonicalHeaderKey(name) == "Content-Length" {
+			continue
+		}
+		recorded[name] = values
+	}
+	return recorded
+}
+
+// requestTarget returns what identifies the target of a request: the path and query, and the host for AWS whose
+// endpoints are per region. The hosts of OCM and the clusters depend on the configuration of who replays.
+func requestTarget(client string, u *url.URL) string {
+	sanitized, err := url.Parse(trace.SanitizeURL(u))
+	if err != nil {
+		return ""
+	}
+	target := sanitized.EscapedPath()
+	if sanitized.RawQuery != "" {
+		// Encode sorts the parameters
+		target += "?" + sanitized.Query().Encode()
+	}
+	if client == "aws" {
+		target = sanitized.Host + target
+	}
+	return target
+}
+
+// requestPath is the target of a request without its query
+func requestPath(client string, u *url.URL) string {
+	target := requestTarget(client, u)
+	path, _, _ := strings.Cut(target, "?")
+	return path
+}
+
+func recordedTarget(i Interaction) string {
+	u, err := url.Parse(i.URL)
+	if err != nil {
+		return ""
+	}
+	return requestTarget(i.Client, u)
+}
+
+func recordedPath(i Interaction) string {
+	u, err := url.Parse(i.URL)
+	if err != nil {
+		return ""
+	}
+	return requestPath(i.Client, u)
+}
@@ -0,0 +1,165 @@
+package recording
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+func startSession(t *testing.T, record, replay string) {
+	t.Helper()
+	recordPath, replayPath = record, replay
+	t.Cleanup(func() {
+		recordPath, replayPath = "", ""
+		_ = Close()
+	})
+	if err := Open(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, client *http.Client, url string) (int, string, error) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Set-Cookie", "session=secret")
+		switch r.URL.Path {
+		case "/api/clusters_mgmt/v1/clusters/abc/status":
+			_, _ = io.WriteString(w, `{"state": "installing", "calls": `+strings.Repeat("1", calls)+`}`)
+		case "/api/accounts_mgmt/v1/token":
+			_, _ = io.WriteString(w, `{"access_token": "secret"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	startSession(t, path, "")
+	client := &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}
+	for i := 0; i < 2; i++ {
+		if _, _, err := get(t, client, server.URL+"/api/clusters_mgmt/v1/clusters/abc/status?search=name+%3D+%27abc%27"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, _, err := get(t, client, server.URL+"/api/accounts_mgmt/v1/token"); err != nil {
+		t.Fatal(err)
+	}
+	output, err := OC([]string{"get", "nodes"}, func() ([]byte, error) { return []byte("ip-10-0-1-1 Ready\n"), nil })
+	if err != nil || string(output) != "ip-10-0-1-1 Ready\n" {
+		t.Fatalf("unexpected oc output %q, %v", output, err)
+	}
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "secret") {
+		t.Errorf("expected no credentials in the session, got %s", content)
+	}
+	file, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Interactions) != 3 {
+		t.Fatalf("expected the token request not to be recorded, got %+v", file.Interactions)
+	}
+
+	// The replay doesn't reach the server, which may be anywhere else
+	server.Close()
+	startSession(t, "", path)
+	if !Replaying() {
+		t.Fatal("expected the session to be replayed")
+	}
+	client = &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}
+	var bodies []string
+	for _, query := range []string{"?search=name+%3D+%27abc%27", "?search=name+%3D+%27abc%27", "?search=other"} {
+		status, body, err := get(t, client, "https://api.example.com/api/clusters_mgmt/v1/clusters/abc/status"+query)
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("unexpected replayed response %d, %v", status, err)
+		}
+		bodies = append(bodies, body)
+	}
+	// In order, then the last one is served again, also to the requests matching loosely
+	if bodies[0] != `{"state": "installing", "calls": 1}` || bodies[1] != `{"state": "installing", "calls": 11}` || bodies[2] != bodies[1] {
+		t.Errorf("unexpected replayed bodies %q", bodies)
+	}
+
+	_, _, err = get(t, client, "https://api.example.com/api/clusters_mgmt/v1/clusters/other")
+	if !errors.Is(err, osdctlErrors.ErrNotFound) {
+		t.Errorf("expected the request which wasn't recorded to fail, got %v", err)
+	}
+	output, err = OC([]string{"get", "nodes"}, func() ([]byte, error) { t.Fatal("expected oc not to run"); return nil, nil })
+	if err != nil || string(output) != "ip-10-0-1-1 Ready\n" {
+		t.Errorf("unexpected replayed oc output %q, %v", output, err)
+	}
+	if _, err := OC([]string{"get", "pods"}, nil); err == nil {
+		t.Error("expected the oc command which wasn't recorded to fail")
+	}
+	// Replaying never writes the session
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	if replayed, _ := os.ReadFile(path); string(replayed) != string(content) {
+		t.Error("expected the replayed session to be left alone")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	recordPath, replayPath = "a.json", "b.json"
+	defer func() { recordPath, replayPath = "", "" }()
+	if err := Open(); err == nil {
+		t.Error("expected --record and --replay to be exclusive")
+	}
+
+	recordPath, replayPath = "", filepath.Join(t.TempDir(), "missing.json")
+	if err := Open(); !os.IsNotExist(err) {
+		t.Errorf("expected the missing session to be reported, got %v", err)
+	}
+
+	if wrapped := OCMTransportWrapper(http.DefaultTransport); wrapped != http.DefaultTransport {
+		t.Error("expected the transport to be left alone without --record and --replay")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	for _, tc := range []struct {
+		title, body, expected string
+	}{
+		{"json fields", `{"items": [{"name": "a", "client_secret": "s"}], "accessToken": "t"}`, `{"accessToken":"REDACTED","items":[{"client_secret":"REDACTED","name":"a"}]}`},
+		{"secret data", `{"kind": "Secret", "data": {"key": "dmFsdWU="}}`, `{"data":{"key":"REDACTED"},"kind":"Secret"}`},
+		{"secret list", `{"kind": "SecretList", "items": [{"data": {"key": "dmFsdWU="}}]}`, `{"items":[{"data":{"key":"REDACTED"}}],"kind":"SecretList"}`},
+		{"aws credentials", `<Credentials><AccessKeyId>ASIA</AccessKeyId><SecretAccessKey>s</SecretAccessKey></Credentials>`, `<Credentials><AccessKeyId>ASIA</AccessKeyId><SecretAccessKey>REDACTED</SecretAccessKey></Credentials>`},
+		{"nothing to redact", `{"b": 1,  "a": 2}`, `{"b": 1,  "a": 2}`},
+		{"plain text", "NAME STATUS\n", "NAME STATUS\n"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			if got := string(redact([]byte(tc.body))); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveFields are the JSON fields whose value is never recorded, compared in lower case without separators
+var sensitiveFields = map[string]bool{
+	"accesstoken":     true,
+	"refreshtoken":    true,
+	"idtoken":         true,
+	"token":           true,
+	"password":        true,
+	"clientsecret":    true,
+	"secretaccesskey": true,
+	"sessiontoken":    true,
+	"kubeconfig":      true,
+}
+
+// sensitiveXMLElements are the credentials of the AWS query APIs, e.g. of sts:AssumeRole
+var sensitiveXMLElements = regexp.MustCompile(`<(SecretAccessKey|SessionToken)>[^<]*</(SecretAccessKey|SessionToken)>`)
+
+// redact returns the body without the credentials it may hold: the sensitive JSON fields, the data of the
+// Kubernetes secrets and the AWS credentials. The other bodies are returned unchanged.
+func redact(body []byte) []byte {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return body
+	}
+	if trimmed[0] == '{' || trimmed[0] == '[' {
+		var value interface{}
+		if err := json.Unmarshal(trimmed, &value); err == nil {
+			if !redactValue(value, false) {
+				return body
+			}
+			if redactedBody, err := json.Marshal(value); err == nil {
+				return redactedBody
+			}
+		}
+		return body
+	}
+	if trimmed[0] == '<' {
+		return sensitiveXMLElements.ReplaceAll(body, []byte("<$1>"+redacted+"</$1>"))
+	}
+	return body
+}
+
+// redactValue redacts the JSON value in place, secret is true for the items of a SecretList, which don't have a
+// kind. It returns true when something was redacted.
+func redactValue(value interface{}, secret bool) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		secret = secret || v["kind"] == "Secret"
+		secretList := v["kind"] == "SecretList"
+		for key, field := range v {
+			normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+			if sensitiveFields[normalized] {
+				if _, ok := field.(string); ok {
+					v[key] = redacted
+					changed = true
+					continue
+				}
+			}
+			if secret && (key == "data" || key == "stringData") {
+				if data, ok := field.(map[string]interface{}); ok {
+					for name := range data {
+						data[name] = redacted
+					}
+					changed = true
+					continue
+				}
+			}
+			if redactValue(field, secretList && key == "items") {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if redactValue(item, secret) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,329 @@
+// Package responsecache keeps the OCM GET responses on disk for a short time, so that the commands run back to back
+// on the same cluster, e.g. context, health and describe, don't read the same resources again. It is opt-in with
+// --cache-responses. The Cache-Control and ETag/Last-Modified headers OCM sends are respected: the entries are fresh
+// for the shortest of their max-age and the configured TTL, and are then revalidated instead of read again.
+//
+// The cluster cache of --cached (pkg/utils/clustercache.go) answers the cluster and shard lookups before any request
+// is sent, so it wins over this cache for them; this cache serves every other GET. Both are dropped for a cluster
+// once a change to it is sent to OCM.
+package responsecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigKey makes the OCM GET responses cached across commands when true
+	ConfigKey = "response_cache"
+	Flag      = "cache-responses"
+	// TTLConfigKey is how long the responses without a shorter max-age are used without asking OCM again
+	TTLConfigKey = "response_cache_ttl"
+
+	defaultTTL = 5 * time.Minute
+	// maxBodySize bounds the cached responses, the larger ones are rare and aren't worth the disk
+	maxBodySize = 8 << 20
+)
+
+// sensitiveSuffixes are the paths whose responses hold credentials, they are never written to disk
+var sensitiveSuffixes = []string{"/credentials", "/access_token", "/token", "/kubeconfig"}
+
+// Swapped in tests
+var (
+	nowFunc = time.Now
+	dirFunc = defaultDir
+)
+
+func init() {
+	viper.SetDefault(TTLConfigKey, defaultTTL.String())
+}
+
+// AddFlags adds the --cache-responses flag to the given command and binds it to the config key
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(Flag, false, "Reuse the OCM responses of the previous commands for "+TTLConfigKey+" (default "+defaultTTL.String()+"), dropped when a command changes something (config key: "+ConfigKey+")")
+	_ = viper.BindPFlag(ConfigKey, cmd.PersistentFlags().Lookup(Flag))
+}
+
+// Enabled returns true when the responses are cached. They never are while a session is recorded or replayed, as
+// the cached responses would be missing from it.
+func Enabled() bool {
+	return viper.GetBool(ConfigKey) && !recording.Active()
+}
+
+func ttl() time.Duration {
+	value, err := time.ParseDuration(viper.GetString(TTLConfigKey))
+	if err != nil || value < 0 {
+		return defaultTTL
+	}
+	return value
+}
+
+func defaultDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "osdctl", "responses"), nil
+}
+
+// Clear drops every cached response
+func Clear() error {
+	dir, err := dirFunc()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("cannot clear the response cache %s: %w", dir, err)
+	}
+	return nil
+}
+
+// entry is a cached response
+type entry struct {
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Body     []byte      `json:"body"`
+	StoredAt time.Time   `json:"stored_at"`
+	// Expires is when the entry has to be revalidated
+	Expires time.Time `json:"expires"`
+}
+
+func (e *entry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// revalidatable returns true when OCM can tell whether the entry is still current without sending it again
+func (e *entry) revalidatable() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+type transport struct {
+	wrapped http.RoundTripper
+}
+
+// OCMTransportWrapper makes the OCM connection reuse the cached responses, it returns the transport unchanged when
+// the cache isn't enabled
+func OCMTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	if !Enabled() {
+		return wrapped
+	}
+	return &transport{wrapped: wrapped}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		resp, err := t.wrapped.RoundTrip(req)
+		// Any cached response may be out of date once something changed, the token requests of the connection
+		// aren't authenticated with a bearer token and don't change anything
+		if _, user := tokenSubject(req.Header.Get("Authorization")); user && err == nil && resp.StatusCode < 400 {
+			_ = Clear()
+		}
+		return resp, err
+	}
+	path, ok := cachePath(req)
+	if !ok || req.Method != http.MethodGet {
+		return t.wrapped.RoundTrip(req)
+	}
+
+	cached := load(path)
+	now := nowFunc()
+	if cached != nil && now.Before(cached.Expires) && !noCache(req.Header) {
+		return cached.response(req), nil
+	}
+
+	if cached != nil && cached.revalidatable() {
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+	resp, err := t.wrapped.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil && cached.revalidatable() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if expires, ok := freshUntil(resp.Header, now); ok {
+			cached.StoredAt, cached.Expires = now, expires
+			store(path, cached)
+		}
+		return cached.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+	expires, ok := freshUntil(resp.Header, now)
+	if !ok || resp.ContentLength > maxBodySize {
+		return resp, nil
+	}
+	// The responses to revalidate every time are only worth storing when they can be revalidated
+	if !expires.After(now) && resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "" {
+		return resp, nil
+	}
+
+	original := resp.Body
+	body, err := io.ReadAll(io.LimitReader(original, maxBodySize+1))
+	if err != nil {
+		original.Close()
+		return nil, err
+	}
+	if len(body) > maxBodySize {
+		// The part read ahead is followed by the rest of the body
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), original), original}
+		return resp, nil
+	}
+	original.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	store(path, &entry{URL: req.URL.Redacted(), Status: resp.StatusCode, Header: resp.Header.Clone(), Body: body, StoredAt: now, Expires: expires})
+	return resp, nil
+}
+
+// cachePath returns the file of the cached response of the request, false when the request can't be cached: it
+// isn't authenticated, asks for credentials or is already conditional. The responses are cached by user, as the
+// access token changes with every command.
+func cachePath(req *http.Request) (string, bool) {
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(req.URL.Path, suffix) {
+			return "", false
+		}
+	}
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" || req.Header.Get("Range") != "" {
+		return "", false
+	}
+	user, ok := tokenSubject(req.Header.Get("Authorization"))
+	if !ok {
+		return "", false
+	}
+	dir, err := dirFunc()
+	if err != nil {
+		return "", false
+	}
+	key := sha256.Sum256([]byte(strings.Join([]string{user, req.URL.String(), req.Header.Get("Accept")}, "\n")))
+	return filepath.Join(dir, hex.EncodeToString(key[:])+".json"), true
+}
+
+// tokenSubject returns the subject of the bearer token, without verifying it
+func tokenSubject(authorization string) (string, bool) {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == authorization {
+		return "", false
+	}
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", false
+	}
+	var claims struct {
+		Subject  string `json:"sub"`
+		Username string `json:"preferred_username"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", false
+	}
+	if claims.Subject != "" {
+		return claims.Subject, true
+	}
+	return claims.Username, claims.Username != ""
+}
+
+// freshUntil returns until when the response can be used without asking OCM, false when it can't be stored
+func freshUntil(header http.Header, now time.Time) (time.Time, bool) {
+	fresh := ttl()
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		switch name {
+		case "no-store":
+			return time.Time{}, false
+		case "no-cache":
+			fresh = 0
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && time.Duration(seconds)*time.Second < fresh {
+				fresh = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if header.Get("Vary") == "*" {
+		return time.Time{}, false
+	}
+	return now.Add(fresh), true
+}
+
+// noCache returns true when the request asks for a response from OCM
+func noCache(header http.Header) bool {
+	control := strings.ToLower(header.Get("Cache-Control"))
+	return strings.Contains(control, "no-cache") || strings.Contains(control, "max-age=0")
+}
+
+// load returns the cached entry, nil when there is none or it is unreadable
+func load(path string) *entry {
+	data, err := os.ReadFile(path) //#nosec G304 -- path is derived from the user cache dir
+	if err != nil {
+		return nil
+	}
+	var cached entry
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	// The stale entries OCM can't revalidate are of no use anymore
+	if !nowFunc().Before(cached.Expires) && !cached.revalidatable() {
+		_ = os.Remove(path)
+		return nil
+	}
+	return &cached
+}
+
+// store writes the entry, the cache is only an optimization and failing to write it doesn't fail the request
+func store(path string, cached *entry) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	// Written aside and renamed, so that the commands running at once never read a partial entry
+	temporary := path + ".tmp" + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(temporary, data, 0600); err != nil {
+		return
+	}
+	if err := os.Rename(temporary, path); err != nil {
+		_ = os.Remove(temporary)
+	}
+}
@@ -0,0 +1,142 @@
+package responsecache
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func testToken(subject string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none"}`)) + "." + encode([]byte(`{"sub":"`+subject+`"}`)) + ".signature"
+}
+
+// setup points the cache to a temporary directory and returns a client going through it
+func setup(t *testing.T) (*http.Client, *time.Time) {
+	t.Helper()
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	dirFunc = func() (string, error) { return dir, nil }
+	nowFunc = func() time.Time { return now }
+	viper.Set(ConfigKey, true)
+	t.Cleanup(func() {
+		dirFunc, nowFunc = defaultDir, time.Now
+		viper.Set(ConfigKey, false)
+		viper.Set(TTLConfigKey, defaultTTL.String())
+	})
+	return &http.Client{Transport: OCMTransportWrapper(http.DefaultTransport)}, &now
+}
+
+func send(t *testing.T, client *http.Client, method, url, subject string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, nil)
+	if subject != "" {
+		req.Header.Set("Authorization", "Bearer "+testToken(subject))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestCachedResponses(t *testing.T) {
+	client, now := setup(t)
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		switch r.URL.Path {
+		case "/api/clusters_mgmt/v1/clusters/abc":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = io.WriteString(w, `{"id": "abc", "calls": `+strconv.Itoa(calls[r.URL.Path])+`}`)
+		case "/api/clusters_mgmt/v1/clusters/abc/status":
+			w.Header().Set("Cache-Control", "max-age=10")
+			_, _ = io.WriteString(w, `{"state": "ready"}`)
+		case "/api/clusters_mgmt/v1/clusters/abc/credentials", "/api/clusters_mgmt/v1/clusters/abc/metrics":
+			if r.URL.Path == "/api/clusters_mgmt/v1/clusters/abc/metrics" {
+				w.Header().Set("Cache-Control", "no-store")
+			}
+			_, _ = io.WriteString(w, `{}`)
+		}
+	}))
+	defer server.Close()
+	cluster := server.URL + "/api/clusters_mgmt/v1/clusters/abc"
+
+	_, first := send(t, client, http.MethodGet, cluster, "alice")
+	_, second := send(t, client, http.MethodGet, cluster, "alice")
+	if calls["/api/clusters_mgmt/v1/clusters/abc"] != 1 || second != first {
+		t.Fatalf("expected the second read to come from the cache, got %d calls and %s", calls["/api/clusters_mgmt/v1/clusters/abc"], second)
+	}
+
+	// Another user doesn't get the responses of the first one
+	send(t, client, http.MethodGet, cluster, "bob")
+	if calls["/api/clusters_mgmt/v1/clusters/abc"] != 2 {
+		t.Errorf("expected the responses to be cached by user, got %d calls", calls["/api/clusters_mgmt/v1/clusters/abc"])
+	}
+
+	// Once stale, the entry is revalidated with its ETag and still served
+	*now = now.Add(defaultTTL + time.Second)
+	status, revalidated := send(t, client, http.MethodGet, cluster, "alice")
+	if calls["/api/clusters_mgmt/v1/clusters/abc"] != 3 || status != http.StatusOK || revalidated != first {
+		t.Errorf("expected the stale entry to be revalidated, got %d calls, %d and %s", calls["/api/clusters_mgmt/v1/clusters/abc"], status, revalidated)
+	}
+
+	// max-age shortens the TTL
+	send(t, client, http.MethodGet, cluster+"/status", "alice")
+	*now = now.Add(11 * time.Second)
+	send(t, client, http.MethodGet, cluster+"/status", "alice")
+	if calls["/api/clusters_mgmt/v1/clusters/abc/status"] != 2 {
+		t.Errorf("expected max-age to expire the entry, got %d calls", calls["/api/clusters_mgmt/v1/clusters/abc/status"])
+	}
+
+	// Neither the credentials nor the no-store responses are cached
+	for _, path := range []string{"/credentials", "/metrics"} {
+		send(t, client, http.MethodGet, cluster+path, "alice")
+		send(t, client, http.MethodGet, cluster+path, "alice")
+		if calls["/api/clusters_mgmt/v1/clusters/abc"+path] != 2 {
+			t.Errorf("expected %s not to be cached, got %d calls", path, calls["/api/clusters_mgmt/v1/clusters/abc"+path])
+		}
+	}
+
+	// A change drops every cached response
+	send(t, client, http.MethodPatch, cluster, "alice")
+	send(t, client, http.MethodGet, cluster+"/status", "alice")
+	if calls["/api/clusters_mgmt/v1/clusters/abc/status"] != 3 {
+		t.Errorf("expected the cache to be dropped after a change, got %d calls", calls["/api/clusters_mgmt/v1/clusters/abc/status"])
+	}
+}
+
+func TestNotAuthenticated(t *testing.T) {
+	client, _ := setup(t)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+	send(t, client, http.MethodGet, server.URL+"/api/clusters_mgmt/v1/clusters", "")
+	send(t, client, http.MethodGet, server.URL+"/api/clusters_mgmt/v1/clusters", "")
+	if calls != 2 {
+		t.Errorf("expected the requests without a bearer token not to be cached, got %d calls", calls)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	if wrapped := OCMTransportWrapper(http.DefaultTransport); wrapped != http.DefaultTransport {
+		t.Error("expected the transport to be left alone without --cache-responses")
+	}
+}
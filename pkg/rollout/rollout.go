@@ -0,0 +1,205 @@
+// Package rollout applies the changes of batch commands to a few canary items first, then to the others in batches,
+// and aborts the run when too many of them fail.
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/scheduler"
+	"github.com/openshift/osdctl/pkg/tui"
+	"github.com/openshift/osdctl/pkg/utils"
+	"github.com/spf13/cobra"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const defaultMaxErrorRate = 0.2
+
+// ErrAborted is returned when the error rate of the items done goes above --max-error-rate
+var ErrAborted = errors.New("rollout aborted")
+
+// Flags are the rollout flags shared by the batch commands
+type Flags struct {
+	Canary       int
+	BatchSize    int
+	PauseBetween time.Duration
+	MaxErrorRate float64
+}
+
+// Options configures a run
+type Options struct {
+	// Label describes what is done to the items in the progress bar, e.g. "clusters sent the service log"
+	Label string
+	// Success is printed next to the items that succeeded, without a terminal
+	Success string
+	// SkipPrompt goes on after the canary items without asking, as long as they didn't fail too often
+	SkipPrompt bool
+	// Scheduler applies the items of a batch concurrently as mutations, one at a time per cluster. The items are
+	// applied one by one when nil.
+	Scheduler *scheduler.Scheduler
+	// Cluster returns the ID of the cluster an item changes, the item is the cluster ID when nil
+	Cluster func(item string) string
+
+	In  io.Reader
+	Out io.Writer
+}
+
+// Result is what a run did
+type Result struct {
+	Done   int
+	Failed int
+	// Left is the number of items the run didn't get to
+	Left int
+}
+
+// AddFlags adds --canary, --batch-size, --pause-between and --max-error-rate to a batch command
+func (f *Flags) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&f.Canary, "canary", 0, "Apply to this many items first, and ask before going on with the others")
+	cmd.Flags().IntVar(&f.BatchSize, "batch-size", 0, "Apply to the items after the canary in batches of this size, 0 for a single batch")
+	cmd.Flags().DurationVar(&f.PauseBetween, "pause-between", 0, "Time to wait between two batches")
+	cmd.Flags().Float64Var(&f.MaxErrorRate, "max-error-rate", defaultMaxErrorRate, "Abort when more than this fraction of the items done so far failed, checked after the canary and every batch")
+}
+
+// Validate checks the values of the flags
+func (f *Flags) Validate(cmd *cobra.Command) error {
+	if f.Canary < 0 {
+		return cmdutil.UsageErrorf(cmd, "--canary can't be negative")
+	}
+	if f.BatchSize < 0 {
+		return cmdutil.UsageErrorf(cmd, "--batch-size can't be negative")
+	}
+	if f.PauseBetween < 0 {
+		return cmdutil.UsageErrorf(cmd, "--pause-between can't be negative")
+	}
+	if f.MaxErrorRate < 0 || f.MaxErrorRate > 1 {
+		return cmdutil.UsageErrorf(cmd, "--max-error-rate must be between 0 and 1")
+	}
+	return nil
+}
+
+// Batches splits the items into the canary batch, when --canary is set, and the batches of --batch-size
+func (f *Flags) Batches(items []string) [][]string {
+	var batches [][]string
+	if f.Canary > 0 && len(items) > 0 {
+		canary := f.Canary
+		if canary > len(items) {
+			canary = len(items)
+		}
+		batches = append(batches, items[:canary])
+		items = items[canary:]
+	}
+	size := f.BatchSize
+	if size == 0 {
+		size = len(items)
+	}
+	for len(items) > 0 {
+		if size > len(items) {
+			size = len(items)
+		}
+		batches = append(batches, items[:size])
+		items = items[size:]
+	}
+	return batches
+}
+
+// Run applies the change to every item, batch after batch. The run stops when the context is done, after an item
+// is applied, and then returns the error of the context.
+func (f *Flags) Run(ctx context.Context, items []string, opts Options, apply func(ctx context.Context, item string) error) (Result, error) {
+	if opts.Out == nil {
+		opts.Out = os.Stderr
+	}
+	result := Result{Left: len(items)}
+	batches := f.Batches(items)
+
+	bar := tui.NewProgress(opts.Out, len(items), opts.Label)
+	if opts.Success != "" {
+		bar.Success = opts.Success
+	}
+	for i, batch := range batches {
+		if err := runBatch(ctx, batch, opts, bar, &result, apply); err != nil {
+			bar.Done()
+			return result, err
+		}
+		if result.Left == 0 {
+			break
+		}
+		bar.Done()
+
+		if rate := float64(result.Failed) / float64(result.Done); rate > f.MaxErrorRate {
+			return result, fmt.Errorf("%w: %d of the %d items done failed (%.0f%%), above --max-error-rate %.0f%%, %d items left",
+				ErrAborted, result.Failed, result.Done, 100*rate, 100*f.MaxErrorRate, result.Left)
+		}
+		if i == 0 && f.Canary > 0 {
+			err := utils.Confirm(utils.ConfirmOptions{
+				Summary: &utils.ImpactSummary{
+					Action: fmt.Sprintf("Go on with the %d items left, the %d canary items had %d failures", result.Left, result.Done, result.Failed),
+				},
+				SkipPrompt: opts.SkipPrompt,
+				In:         opts.In,
+				Out:        opts.Out,
+			})
+			if err != nil {
+				return result, err
+			}
+		}
+		if f.PauseBetween > 0 {
+			fmt.Fprintf(opts.Out, "Waiting %s before the next batch\n", f.PauseBetween)
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(f.PauseBetween):
+			}
+		}
+	}
+	bar.Done()
+	return result, nil
+}
+
+// runBatch applies the items of the batch, the items applied once the context is done aren't counted
+func runBatch(ctx context.Context, batch []string, opts Options, bar *tui.Progress, result *Result, apply func(ctx context.Context, item string) error) error {
+	var mu sync.Mutex
+	record := func(item string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		bar.Increment(item, err)
+		result.Done++
+		result.Left--
+		if err != nil {
+			result.Failed++
+		}
+	}
+
+	if opts.Scheduler == nil {
+		for _, item := range batch {
+			err := apply(ctx, item)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			record(item, err)
+		}
+		return nil
+	}
+
+	tasks := make([]scheduler.Task, len(batch))
+	for i, item := range batch {
+		item := item
+		cluster := item
+		if opts.Cluster != nil {
+			cluster = opts.Cluster(item)
+		}
+		tasks[i] = scheduler.Task{Cluster: cluster, Kind: scheduler.Mutate, Run: func(ctx context.Context) error {
+			err := apply(ctx, item)
+			if ctx.Err() == nil {
+				record(item, err)
+			}
+			return err
+		}}
+	}
+	opts.Scheduler.Run(ctx, tasks)
+	return ctx.Err()
+}
@@ -0,0 +1,139 @@
+package rollout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/scheduler"
+)
+
+var testItems = []string{"a", "b", "c", "d", "e", "f", "g"}
+
+// recorder applies the items, failing the ones listed
+type recorder struct {
+	applied []string
+	fail    map[string]bool
+}
+
+func (r *recorder) apply(_ context.Context, item string) error {
+	r.applied = append(r.applied, item)
+	if r.fail[item] {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+func TestBatches(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect((&Flags{}).Batches(testItems)).To(Equal([][]string{testItems}))
+	g.Expect((&Flags{Canary: 2}).Batches(testItems)).To(Equal([][]string{{"a", "b"}, {"c", "d", "e", "f", "g"}}))
+	g.Expect((&Flags{Canary: 1, BatchSize: 3}).Batches(testItems)).To(Equal([][]string{{"a"}, {"b", "c", "d"}, {"e", "f", "g"}}))
+	g.Expect((&Flags{Canary: 10}).Batches(testItems)).To(Equal([][]string{testItems}))
+	g.Expect((&Flags{Canary: 2}).Batches(nil)).To(BeEmpty())
+}
+
+func TestRunCanary(t *testing.T) {
+	g := NewGomegaWithT(t)
+	flags := &Flags{Canary: 2, BatchSize: 3, MaxErrorRate: 0.2}
+
+	// Declining after the canary stops the run
+	var out bytes.Buffer
+	r := &recorder{}
+	result, err := flags.Run(context.Background(), testItems, Options{In: strings.NewReader("n\n"), Out: &out}, r.apply)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(r.applied).To(Equal([]string{"a", "b"}))
+	g.Expect(result).To(Equal(Result{Done: 2, Left: 5}))
+	g.Expect(out.String()).To(ContainSubstring("Go on with the 5 items left, the 2 canary items had 0 failures"))
+
+	r = &recorder{fail: map[string]bool{"e": true}}
+	result, err = flags.Run(context.Background(), testItems, Options{In: strings.NewReader("y\n"), Out: &out}, r.apply)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(r.applied).To(Equal(testItems))
+	g.Expect(result).To(Equal(Result{Done: 7, Failed: 1}))
+}
+
+func TestRunAbortsOnErrorRate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	flags := &Flags{Canary: 2, BatchSize: 3, MaxErrorRate: 0.2}
+
+	// A failed canary item is above the rate, the run stops without asking
+	r := &recorder{fail: map[string]bool{"b": true}}
+	result, err := flags.Run(context.Background(), testItems, Options{Out: &bytes.Buffer{}}, r.apply)
+	g.Expect(errors.Is(err, ErrAborted)).To(BeTrue())
+	g.Expect(err).To(MatchError(ContainSubstring("1 of the 2 items done failed (50%)")))
+	g.Expect(result).To(Equal(Result{Done: 2, Failed: 1, Left: 5}))
+
+	// The rate is over every item done so far
+	r = &recorder{fail: map[string]bool{"c": true, "d": true}}
+	result, err = flags.Run(context.Background(), testItems, Options{SkipPrompt: true, Out: &bytes.Buffer{}}, r.apply)
+	g.Expect(errors.Is(err, ErrAborted)).To(BeTrue())
+	g.Expect(result).To(Equal(Result{Done: 5, Failed: 2, Left: 2}))
+}
+
+func TestRunPauseAndInterrupt(t *testing.T) {
+	g := NewGomegaWithT(t)
+	flags := &Flags{BatchSize: 3, PauseBetween: time.Hour, MaxErrorRate: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out bytes.Buffer
+	r := &recorder{}
+	result, err := flags.Run(ctx, testItems, Options{Out: &out}, func(ctx context.Context, item string) error {
+		if item == "c" {
+			// Interrupted at the end of the first batch, the run stops during the pause
+			time.AfterFunc(10*time.Millisecond, cancel)
+		}
+		return r.apply(ctx, item)
+	})
+	g.Expect(err).To(MatchError(context.Canceled))
+	g.Expect(result).To(Equal(Result{Done: 3, Left: 4}))
+	g.Expect(out.String()).To(ContainSubstring("Waiting 1h0m0s before the next batch"))
+}
+
+func TestRunWithScheduler(t *testing.T) {
+	g := NewGomegaWithT(t)
+	flags := &Flags{Canary: 1, MaxErrorRate: 0.5}
+
+	var mu sync.Mutex
+	running := map[string]bool{}
+	var applied []string
+	opts := Options{
+		Out:        &bytes.Buffer{},
+		SkipPrompt: true,
+		Scheduler:  &scheduler.Scheduler{Reads: 1, Mutations: 3},
+		// Two reasons per cluster
+		Cluster: func(item string) string { return strings.Split(item, "/")[0] },
+	}
+	items := []string{"a/1", "a/2", "b/1", "b/2", "c/1", "c/2"}
+	result, err := flags.Run(context.Background(), items, opts, func(_ context.Context, item string) error {
+		cluster := opts.Cluster(item)
+		mu.Lock()
+		if running[cluster] {
+			t.Errorf("%s applied while another item of the cluster was", item)
+		}
+		running[cluster] = true
+		applied = append(applied, item)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		running[cluster] = false
+		if item == "c/2" {
+			return errors.New("not found")
+		}
+		return nil
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(result).To(Equal(Result{Done: 6, Failed: 1}))
+	g.Expect(applied).To(ConsistOf(items))
+	g.Expect(applied[0]).To(Equal("a/1"))
+}
@@ -0,0 +1,155 @@
+// Package scheduler runs the per-cluster operations of fleet commands. Read-only operations run many at a time, while
+// mutating ones run one at a time per cluster, in the order they were given, and only a few at a time overall. The
+// read-only operations go first when both are waiting, so that a batch of changes doesn't hold up the queries.
+package scheduler
+
+import (
+	"context"
+
+	"github.com/openshift/osdctl/pkg/ratelimit"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ReadConcurrencyConfigKey is the number of read-only operations run at the same time
+	ReadConcurrencyConfigKey = "fleet_read_concurrency"
+	// MutateConcurrencyConfigKey is the number of mutating operations run at the same time, on different clusters
+	MutateConcurrencyConfigKey = "fleet_mutate_concurrency"
+
+	defaultReadConcurrency   = 10
+	defaultMutateConcurrency = 2
+)
+
+func init() {
+	viper.SetDefault(ReadConcurrencyConfigKey, defaultReadConcurrency)
+	viper.SetDefault(MutateConcurrencyConfigKey, defaultMutateConcurrency)
+}
+
+// Kind tells whether an operation changes the cluster
+type Kind int
+
+const (
+	// Read operations only query the cluster, they run in parallel whatever their cluster
+	Read Kind = iota
+	// Mutate operations change the cluster, at most one of them runs on a cluster at a time
+	Mutate
+)
+
+// Task is an operation on a cluster
+type Task struct {
+	// Cluster is the ID of the cluster the operation is about, mutations on the same cluster are serialized
+	Cluster string
+	Kind    Kind
+	Run     func(ctx context.Context) error
+}
+
+// Scheduler runs tasks with at most Reads read-only and Mutations mutating operations at the same time
+type Scheduler struct {
+	Reads     int
+	Mutations int
+}
+
+// New returns a scheduler with the configured concurrency. It lowers the OCM and AWS rate limits of the process to
+// their batch share, see ratelimit.Batch, so that the interactive commands run next to the batch aren't throttled.
+func New() *Scheduler {
+	ratelimit.Batch()
+	return &Scheduler{
+		Reads:     atLeastOne(viper.GetInt(ReadConcurrencyConfigKey)),
+		Mutations: atLeastOne(viper.GetInt(MutateConcurrencyConfigKey)),
+	}
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+	return n
+}
+
+// queue is the state of a run, only used from the goroutine of Run
+type queue struct {
+	tasks     []Task
+	pending   []int
+	reads     int
+	mutations int
+	// mutating is the set of the clusters with a mutation running
+	mutating map[string]bool
+}
+
+// Run runs the tasks and returns their errors, in the order of the tasks. Once the context is done, the tasks not
+// started yet aren't started anymore and get the error of the context, Run waits for the running ones to return.
+func (s *Scheduler) Run(ctx context.Context, tasks []Task) []error {
+	errs := make([]error, len(tasks))
+	q := &queue{tasks: tasks, pending: make([]int, len(tasks)), mutating: map[string]bool{}}
+	for i := range tasks {
+		q.pending[i] = i
+	}
+
+	done := make(chan int)
+	for {
+		if ctx.Err() != nil {
+			for _, i := range q.pending {
+				errs[i] = ctx.Err()
+			}
+			q.pending = nil
+		}
+		for _, i := range q.next(atLeastOne(s.Reads), atLeastOne(s.Mutations)) {
+			go func(i int) {
+				errs[i] = tasks[i].Run(ctx)
+				done <- i
+			}(i)
+		}
+		if q.reads+q.mutations == 0 {
+			return errs
+		}
+
+		var i int
+		if ctx.Err() != nil {
+			i = <-done
+		} else {
+			select {
+			case i = <-done:
+			case <-ctx.Done():
+				continue
+			}
+		}
+		if tasks[i].Kind == Mutate {
+			q.mutations--
+			delete(q.mutating, tasks[i].Cluster)
+		} else {
+			q.reads--
+		}
+	}
+}
+
+// next takes the tasks that can start out of the pending ones: the read-only ones first, then the mutations of the
+// clusters without a running or an earlier pending one
+func (q *queue) next(reads, mutations int) []int {
+	var start, pending []int
+	for _, i := range q.pending {
+		if q.tasks[i].Kind != Mutate && q.reads < reads {
+			q.reads++
+			start = append(start, i)
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	q.pending = pending[:0:0]
+	// waiting is the set of the clusters with an earlier mutation that couldn't start
+	waiting := map[string]bool{}
+	for _, i := range pending {
+		task := q.tasks[i]
+		if task.Kind != Mutate || q.mutations >= mutations || q.mutating[task.Cluster] || waiting[task.Cluster] {
+			if task.Kind == Mutate {
+				waiting[task.Cluster] = true
+			}
+			q.pending = append(q.pending, i)
+			continue
+		}
+		q.mutations++
+		q.mutating[task.Cluster] = true
+		start = append(start, i)
+	}
+	return start
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tracker records how many tasks run at the same time, overall and per cluster
+type tracker struct {
+	mu          sync.Mutex
+	reads       int
+	mutations   int
+	maxReads    int
+	maxMutation int
+	perCluster  map[string]int
+	maxCluster  int
+	order       []string
+}
+
+func (tr *tracker) task(cluster string, kind Kind, name string) Task {
+	return Task{Cluster: cluster, Kind: kind, Run: func(ctx context.Context) error {
+		tr.mu.Lock()
+		tr.order = append(tr.order, name)
+		if kind == Mutate {
+			tr.mutations++
+			tr.perCluster[cluster]++
+			if tr.mutations > tr.maxMutation {
+				tr.maxMutation = tr.mutations
+			}
+			if tr.perCluster[cluster] > tr.maxCluster {
+				tr.maxCluster = tr.perCluster[cluster]
+			}
+		} else {
+			tr.reads++
+			if tr.reads > tr.maxReads {
+				tr.maxReads = tr.reads
+			}
+		}
+		tr.mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		tr.mu.Lock()
+		defer tr.mu.Unlock()
+		if kind == Mutate {
+			tr.mutations--
+			tr.perCluster[cluster]--
+		} else {
+			tr.reads--
+		}
+		if name == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	}}
+}
+
+func TestRunCapsConcurrency(t *testing.T) {
+	tr := &tracker{perCluster: map[string]int{}}
+	var tasks []Task
+	for i := 0; i < 4; i++ {
+		for _, cluster := range []string{"a", "b", "c"} {
+			tasks = append(tasks, tr.task(cluster, Mutate, fmt.Sprintf("%s-%d", cluster, i)))
+		}
+	}
+	for i := 0; i < 12; i++ {
+		tasks = append(tasks, tr.task("a", Read, "read"))
+	}
+	tasks = append(tasks, tr.task("b", Read, "fail"))
+
+	errs := (&Scheduler{Reads: 5, Mutations: 2}).Run(context.Background(), tasks)
+
+	if tr.maxReads != 5 {
+		t.Errorf("expected 5 reads at the same time, got %d", tr.maxReads)
+	}
+	if tr.maxMutation != 2 {
+		t.Errorf("expected 2 mutations at the same time, got %d", tr.maxMutation)
+	}
+	if tr.maxCluster != 1 {
+		t.Errorf("expected the mutations of a cluster to be serialized, got %d at the same time", tr.maxCluster)
+	}
+	for i, err := range errs {
+		if (err != nil) != (i == len(tasks)-1) {
+			t.Errorf("unexpected error of task %d: %v", i, err)
+		}
+	}
+
+	// The mutations of a cluster keep their order
+	next := map[string]int{}
+	for _, name := range tr.order {
+		var cluster string
+		var i int
+		if _, err := fmt.Sscanf(name, "%1s-%d", &cluster, &i); err != nil {
+			continue
+		}
+		if i != next[cluster] {
+			t.Errorf("expected %s-%d to run before %s", cluster, next[cluster], name)
+		}
+		next[cluster] = i + 1
+	}
+}
+
+func TestRunReadsFirst(t *testing.T) {
+	tr := &tracker{perCluster: map[string]int{}}
+	tasks := []Task{
+		tr.task("a", Mutate, "a-0"),
+		tr.task("a", Mutate, "a-1"),
+		tr.task("a", Read, "read"),
+	}
+
+	(&Scheduler{Reads: 1, Mutations: 1}).Run(context.Background(), tasks)
+
+	// The read doesn't wait for the mutations of its cluster
+	if tr.order[0] != "read" && tr.order[1] != "read" {
+		t.Errorf("expected the read to start along the first mutation, got %v", tr.order)
+	}
+	if tr.order[2] != "a-1" {
+		t.Errorf("expected the second mutation last, got %v", tr.order)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	started := 0
+	tasks := make([]Task, 5)
+	for i := range tasks {
+		tasks[i] = Task{Cluster: "a", Kind: Mutate, Run: func(ctx context.Context) error {
+			started++
+			cancel()
+			return nil
+		}}
+	}
+
+	errs := (&Scheduler{Reads: 1, Mutations: 1}).Run(ctx, tasks)
+
+	if started != 1 {
+		t.Errorf("expected no task to start once canceled, %d started", started)
+	}
+	if errs[0] != nil {
+		t.Errorf("expected the running task to finish, got %v", errs[0])
+	}
+	for _, err := range errs[1:] {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the tasks not started to be canceled, got %v", err)
+		}
+	}
+}
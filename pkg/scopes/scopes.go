@@ -0,0 +1,88 @@
+// Package scopes restricts osdctl to the commands granted to a profile written by 'osdctl auth mint-token', so that
+// automation running read-only reports can't be pointed at anything else.
+package scopes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// ConfigKey lists the scopes of the profile, every command is allowed when it is empty
+const ConfigKey = "scopes"
+
+// Commands are the command paths, and their subcommands, allowed by every scope. The calls changing something are
+// refused by read-only mode, which the scoped profiles turn on.
+var Commands = map[string][]string{
+	"read:clusters":    {"osdctl cluster", "osdctl get clusters", "osdctl describe cluster"},
+	"read:support":     {"osdctl cluster support status", "osdctl cluster support stats", "osdctl cluster support pending-review", "osdctl get reasons"},
+	"read:servicelogs": {"osdctl servicelog list"},
+	"read:orgs":        {"osdctl org"},
+	"read:fleet":       {"osdctl fleet"},
+	"read:cost":        {"osdctl cost"},
+}
+
+// alwaysAllowed don't reach OCM, AWS or the clusters
+var alwaysAllowed = []string{"osdctl version", "osdctl help", "osdctl completion", "osdctl options", "osdctl whoami"}
+
+// Names returns the known scopes, sorted
+func Names() []string {
+	names := make([]string, 0, len(Commands))
+	for name := range Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that the scopes are known
+func Validate(scopes []string) error {
+	if len(scopes) == 0 {
+		return osdctlErrors.New(osdctlErrors.ErrValidation, "no scope given, the scopes are: %s", strings.Join(Names(), ", "))
+	}
+	for _, scope := range scopes {
+		if _, ok := Commands[scope]; !ok {
+			return osdctlErrors.New(osdctlErrors.ErrValidation, "unknown scope '%s', the scopes are: %s", scope, strings.Join(Names(), ", "))
+		}
+	}
+	return nil
+}
+
+// Check refuses the command when the profile has scopes which don't allow it, or when read-only mode was turned off
+func Check(cmd *cobra.Command) error {
+	scopes := viper.GetStringSlice(ConfigKey)
+	if len(scopes) == 0 {
+		return nil
+	}
+	if err := Validate(scopes); err != nil {
+		return fmt.Errorf("invalid '%s' in the config file: %w", ConfigKey, err)
+	}
+	if !readonly.Enabled() {
+		return osdctlErrors.New(osdctlErrors.ErrForbidden, "the profile is scoped to %s, it can only be used in read-only mode", strings.Join(scopes, ", "))
+	}
+
+	path := cmd.CommandPath()
+	if Allowed(scopes, path) {
+		return nil
+	}
+	return osdctlErrors.New(osdctlErrors.ErrForbidden, "'%s' isn't allowed by the scopes of the profile: %s", path, strings.Join(scopes, ", "))
+}
+
+// Allowed returns true when one of the scopes allows the command path
+func Allowed(scopes []string, path string) bool {
+	allowed := append([]string{}, alwaysAllowed...)
+	for _, scope := range scopes {
+		allowed = append(allowed, Commands[scope]...)
+	}
+	for _, command := range allowed {
+		if path == command || strings.HasPrefix(path, command+" ") {
+			return true
+		}
+	}
+	return false
+}
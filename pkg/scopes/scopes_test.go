@@ -0,0 +1,73 @@
+package scopes
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newCommand returns the leaf command of the path, e.g. "osdctl cluster support status"
+func newCommand(names ...string) *cobra.Command {
+	var parent, cmd *cobra.Command
+	for _, name := range names {
+		cmd = &cobra.Command{Use: name}
+		if parent != nil {
+			parent.AddCommand(cmd)
+		}
+		parent = cmd
+	}
+	return cmd
+}
+
+func TestAllowed(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(Allowed([]string{"read:clusters"}, "osdctl cluster describe")).To(BeTrue())
+	g.Expect(Allowed([]string{"read:clusters"}, "osdctl clusterdeployment list")).To(BeFalse())
+	g.Expect(Allowed([]string{"read:support"}, "osdctl cluster support status")).To(BeTrue())
+	g.Expect(Allowed([]string{"read:support"}, "osdctl cluster support delete")).To(BeFalse())
+	// The verbs of the commands are allowed like the commands
+	g.Expect(Allowed([]string{"read:clusters"}, "osdctl get clusters")).To(BeTrue())
+	g.Expect(Allowed([]string{"read:clusters"}, "osdctl get accounts")).To(BeFalse())
+	g.Expect(Allowed([]string{"read:support"}, "osdctl get reasons")).To(BeTrue())
+	g.Expect(Allowed([]string{"read:servicelogs"}, "osdctl servicelog post")).To(BeFalse())
+	g.Expect(Allowed([]string{"read:orgs", "read:servicelogs"}, "osdctl servicelog list")).To(BeTrue())
+	g.Expect(Allowed([]string{"read:orgs"}, "osdctl version")).To(BeTrue())
+}
+
+func TestValidate(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(Validate([]string{"read:clusters", "read:fleet"})).To(Succeed())
+	g.Expect(errors.Is(Validate(nil), osdctlErrors.ErrValidation)).To(BeTrue())
+	g.Expect(Validate([]string{"write:clusters"})).To(MatchError(ContainSubstring("unknown scope 'write:clusters'")))
+}
+
+func TestCheck(t *testing.T) {
+	g := NewGomegaWithT(t)
+	defer viper.Set(ConfigKey, nil)
+	defer viper.Set(readonly.ConfigKey, false)
+
+	// Without scopes every command is allowed
+	g.Expect(Check(newCommand("osdctl", "cluster", "support", "delete"))).To(Succeed())
+
+	viper.Set(ConfigKey, []string{"read:support"})
+	viper.Set(readonly.ConfigKey, true)
+	g.Expect(Check(newCommand("osdctl", "cluster", "support", "status"))).To(Succeed())
+	err := Check(newCommand("osdctl", "cluster", "support", "delete"))
+	g.Expect(errors.Is(err, osdctlErrors.ErrForbidden)).To(BeTrue())
+	g.Expect(err).To(MatchError("'osdctl cluster support delete' isn't allowed by the scopes of the profile: read:support"))
+
+	// Turning read-only mode off, e.g. with --read-only=false, isn't allowed
+	viper.Set(readonly.ConfigKey, false)
+	err = Check(newCommand("osdctl", "cluster", "support", "status"))
+	g.Expect(errors.Is(err, osdctlErrors.ErrForbidden)).To(BeTrue())
+
+	viper.Set(ConfigKey, []string{"admin"})
+	g.Expect(Check(newCommand("osdctl", "version"))).To(MatchError(ContainSubstring("invalid 'scopes' in the config file")))
+}
@@ -0,0 +1,253 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/openshift/osdctl/pkg/fips"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	// PassphraseEnv provides the passphrase of the encrypted file non-interactively
+	PassphraseEnv = "OSDCTL_SECRETS_PASSPHRASE"
+	// FileConfigKey overrides the location of the encrypted file
+	FileConfigKey = "secrets_file"
+
+	defaultFileName = "osdctl-secrets.enc"
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+	// FIPS builds derive the key with PBKDF2, scrypt isn't FIPS-approved
+	kdfPBKDF2        = "pbkdf2-sha256"
+	pbkdf2Iterations = 600000
+	keyLength        = 32
+	saltLength       = 16
+	fileStoreTag     = "encrypted file"
+)
+
+// encryptedFile is the on-disk format of the file store
+type encryptedFile struct {
+	// KDF derives the key from the passphrase, scrypt when empty
+	KDF   string `json:"kdf,omitempty"`
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// FileStore keeps secrets in a file encrypted with AES-GCM, using a key derived from a passphrase
+type FileStore struct {
+	path string
+	// passphrase is read lazily, so that only commands that need a secret prompt for it
+	passphrase func() ([]byte, error)
+	// kdf derives the key of the file when it is saved
+	kdf string
+}
+
+// NewFileStore returns the encrypted file store at the configured location
+func NewFileStore() (*FileStore, error) {
+	path, err := filePath()
+	if err != nil {
+		return nil, err
+	}
+	store := &FileStore{path: path, passphrase: processPassphrase}
+	if fips.Enabled() {
+		store.kdf = kdfPBKDF2
+	}
+	return store, nil
+}
+
+func filePath() (string, error) {
+	if path := viper.GetString(FileConfigKey); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", defaultFileName), nil
+}
+
+// Swapped in tests
+var passphraseFunc = readPassphrase
+
+var (
+	cachedPassphrase []byte
+	passphraseMu     sync.Mutex
+)
+
+// processPassphrase prompts for the passphrase once per process, the commands reading several secrets, e.g. the OCM,
+// PagerDuty and Jira tokens, build a store for each of them
+func processPassphrase() ([]byte, error) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	if cachedPassphrase != nil {
+		return cachedPassphrase, nil
+	}
+	passphrase, err := passphraseFunc()
+	if err != nil {
+		return nil, err
+	}
+	cachedPassphrase = passphrase
+	return passphrase, nil
+}
+
+// readPassphrase reads the passphrase from the environment, or prompts for it
+func readPassphrase() ([]byte, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return []byte(passphrase), nil
+	}
+	fd := int(os.Stdin.Fd()) //#nosec G115 -- file descriptors fit in an int
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("no OS keyring is available, set %s to unlock the encrypted secrets file", PassphraseEnv)
+	}
+	fmt.Fprint(os.Stderr, "Passphrase of the osdctl secrets file: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("the passphrase can't be empty")
+	}
+	return passphrase, nil
+}
+
+func (f *FileStore) Name() string {
+	return fileStoreTag + " (" + f.path + ")"
+}
+
+func (f *FileStore) Get(key string) (string, error) {
+	secrets, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func (f *FileStore) Set(key, value string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	secrets[key] = value
+	return f.save(secrets)
+}
+
+func (f *FileStore) Delete(key string) error {
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[key]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, key)
+	return f.save(secrets)
+}
+
+// load decrypts the file. A missing file holds no secrets, and doesn't need the passphrase.
+func (f *FileStore) load() (map[string]string, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse '%s': %w", f.path, err)
+	}
+
+	gcm, err := f.cipher(file.KDF, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt '%s', is the passphrase correct?", f.path)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("cannot parse the secrets in '%s': %w", f.path, err)
+	}
+	return secrets, nil
+}
+
+// save encrypts the secrets with a fresh salt and nonce
+func (f *FileStore) save(secrets map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	file := encryptedFile{KDF: f.kdf, Salt: make([]byte, saltLength)}
+	if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+		return err
+	}
+	gcm, err := f.cipher(file.KDF, file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, data, 0600)
+}
+
+func (f *FileStore) cipher(kdf string, salt []byte) (cipher.AEAD, error) {
+	passphrase, err := f.passphrase()
+	if err != nil {
+		return nil, err
+	}
+	// Only ask for it once per store, NewFileStore's stores share a passphrase for the whole process
+	f.passphrase = func() ([]byte, error) { return passphrase, nil }
+
+	var key []byte
+	switch kdf {
+	case "":
+		if key, err = scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keyLength); err != nil {
+			return nil, err
+		}
+	case kdfPBKDF2:
+		key = pbkdf2.Key(passphrase, salt, pbkdf2Iterations, keyLength, sha256.New)
+	default:
+		return nil, fmt.Errorf("'%s' uses the unknown key derivation '%s', it was written by a newer osdctl", f.path, kdf)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,44 @@
+package secrets
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit code of security when the item doesn't exist
+const errSecItemNotFound = 44
+
+// newOSKeyring uses the security tool to reach the login keychain
+func newOSKeyring() Store {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil
+	}
+
+	return &execKeyring{
+		name: "keychain",
+		get: func(key string) []string {
+			return []string{"security", "find-generic-password", "-s", service, "-a", key, "-w"}
+		},
+		// The secret is read from stdin by the interactive mode so it doesn't show in the process list, -U updates the
+		// item if it already exists
+		set: func(key, value string) ([]string, string, error) {
+			if strings.ContainsAny(value, "\r\n") {
+				return nil, "", errors.New("the keychain can't store a secret spanning several lines, use secrets_backend: file")
+			}
+			command := strings.Join([]string{"add-generic-password", "-U", "-s", securityQuote(service), "-a", securityQuote(key), "-w", securityQuote(value)}, " ")
+			return []string{"security", "-i"}, command + "\n", nil
+		},
+		del: func(key string) []string {
+			return []string{"security", "delete-generic-password", "-s", service, "-a", key}
+		},
+		notFound: func(exitCode int, _ string) bool {
+			return exitCode == errSecItemNotFound
+		},
+	}
+}
+
+// securityQuote quotes an argument of a command of the interactive mode of security
+func securityQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,69 @@
+//go:build darwin || linux
+
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// execKeyring talks to the OS keyring through its command line tool
+type execKeyring struct {
+	name string
+	get  func(key string) []string
+	set  func(key, value string) (args []string, stdin string, err error)
+	del  func(key string) []string
+	// notFound tells whether a failed command means the secret isn't stored
+	notFound func(exitCode int, stderr string) bool
+}
+
+func (k *execKeyring) Name() string {
+	return "keyring (" + k.name + ")"
+}
+
+func (k *execKeyring) Get(key string) (string, error) {
+	out, err := k.run(nil, k.get(key))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (k *execKeyring) Set(key, value string) error {
+	args, stdin, err := k.set(key, value)
+	if err != nil {
+		return err
+	}
+	_, err = k.run(strings.NewReader(stdin), args)
+	return err
+}
+
+func (k *execKeyring) Delete(key string) error {
+	_, err := k.run(nil, k.del(key))
+	return err
+}
+
+func (k *execKeyring) run(stdin io.Reader, args []string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...) //#nosec G204 -- the command is one of the keyring tools
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if k.notFound(exitErr.ExitCode(), stderr.String()) {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("%s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return "", err
+	}
+	return stdout.String(), nil
+}
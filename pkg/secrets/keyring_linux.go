@@ -0,0 +1,36 @@
+package secrets
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// newOSKeyring uses secret-tool from libsecret to reach the Secret Service (GNOME Keyring, KWallet),
+// which is only available within a desktop session
+func newOSKeyring() Store {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil
+	}
+
+	return &execKeyring{
+		name: "secret-service",
+		get: func(key string) []string {
+			return []string{"secret-tool", "lookup", "service", service, "account", key}
+		},
+		// The secret is read from stdin so it doesn't show in the process list
+		set: func(key, value string) ([]string, string, error) {
+			return []string{"secret-tool", "store", "--label", service + " " + key, "service", service, "account", key}, value, nil
+		},
+		del: func(key string) []string {
+			return []string{"secret-tool", "clear", "service", service, "account", key}
+		},
+		// secret-tool exits with 1 and prints nothing when the secret doesn't exist
+		notFound: func(exitCode int, stderr string) bool {
+			return exitCode == 1 && strings.TrimSpace(stderr) == ""
+		},
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !darwin && !linux
+
+package secrets
+
+// newOSKeyring reports that no keyring is supported on this platform, the encrypted file is used instead
+func newOSKeyring() Store {
+	return nil
+}
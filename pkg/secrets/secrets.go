@@ -0,0 +1,118 @@
+// Package secrets stores long-lived tokens in the OS keyring, or in an encrypted file when no keyring is available
+package secrets
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// BackendConfigKey forces the secret storage backend, 'keyring' or 'file'. Auto-detected when unset.
+	BackendConfigKey = "secrets_backend"
+
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+
+	// service identifies osdctl's entries in the OS keyring
+	service = "osdctl"
+)
+
+// Keys of the secrets osdctl knows how to use
+const (
+	OCMRefreshTokenKey     = "ocm_refresh_token"
+	PagerDutyOauthTokenKey = "pd_oauth_token"
+	PagerDutyUserTokenKey  = "pd_user_token"
+	JiraTokenKey           = "jira_token"
+	SlackWebhookKey        = "slack_webhook_url"
+	SlackSigningSecretKey  = "slack_signing_secret"
+	OCMClientSecretKey     = "ocm_client_secret"
+	// OCMDeviceRefreshTokenKey is kept by the device code login, deleting it logs out
+	OCMDeviceRefreshTokenKey = "ocm_device_refresh_token"
+	// NotesKeyKey encrypts the cluster notes, the team shares it to read each other's notes
+	NotesKeyKey = "notes_key"
+)
+
+// Keys lists the secrets that can be stored
+var Keys = []string{OCMRefreshTokenKey, PagerDutyOauthTokenKey, PagerDutyUserTokenKey, JiraTokenKey, SlackWebhookKey, SlackSigningSecretKey,
+	OCMClientSecretKey, OCMDeviceRefreshTokenKey, NotesKeyKey}
+
+// ErrNotFound is returned when the secret isn't stored
+var ErrNotFound = errors.New("secret not found")
+
+// NotConfiguredError is returned when the token of an optional integration, e.g. PagerDuty or Jira, is neither
+// passed, stored nor in the config file, so that the commands skip what needs it instead of failing
+type NotConfiguredError struct {
+	Integration string
+	Key         string
+}
+
+func (e *NotConfiguredError) Error() string {
+	return fmt.Sprintf("%s isn't configured, store its token with 'osdctl secrets set %s'", e.Integration, e.Key)
+}
+
+// IsNotConfigured returns true when the error is about an optional integration which isn't configured
+func IsNotConfigured(err error) bool {
+	var notConfigured *NotConfiguredError
+	return errors.As(err, &notConfigured)
+}
+
+// Store is a secret storage backend
+type Store interface {
+	Get(key string) (string, error)
+	Set(key, value string) error
+	Delete(key string) error
+	// Name describes the backend, e.g. 'keyring (secret-service)'
+	Name() string
+}
+
+// Swapped in tests
+var newKeyring = newOSKeyring
+
+// New returns the configured backend, defaulting to the OS keyring when it's available
+func New() (Store, error) {
+	switch backend := viper.GetString(BackendConfigKey); backend {
+	case BackendKeyring:
+		keyring := newKeyring()
+		if keyring == nil {
+			return nil, fmt.Errorf("'%s' is set to '%s', but no OS keyring is available", BackendConfigKey, BackendKeyring)
+		}
+		return keyring, nil
+	case BackendFile:
+		return NewFileStore()
+	case "":
+		if keyring := newKeyring(); keyring != nil {
+			return keyring, nil
+		}
+		return NewFileStore()
+	default:
+		return nil, fmt.Errorf("invalid '%s' '%s', expected '%s' or '%s'", BackendConfigKey, backend, BackendKeyring, BackendFile)
+	}
+}
+
+// Lookup returns the stored secret, or an empty string if it isn't stored
+func Lookup(key string) (string, error) {
+	store, err := New()
+	if err != nil {
+		return "", err
+	}
+	value, err := store.Get(key)
+	if errors.Is(err, ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot read '%s' from the %s: %w", key, store.Name(), err)
+	}
+	return value, nil
+}
+
+// IsKnownKey checks that the key is one osdctl uses
+func IsKnownKey(key string) bool {
+	for _, k := range Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
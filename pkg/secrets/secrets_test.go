@@ -0,0 +1,197 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/spf13/viper"
+)
+
+func newTestFileStore(t *testing.T, passphrase string) *FileStore {
+	return &FileStore{
+		path:       filepath.Join(t.TempDir(), "secrets.enc"),
+		passphrase: func() ([]byte, error) { return []byte(passphrase), nil },
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	store := newTestFileStore(t, "correct horse")
+
+	_, err := store.Get(JiraTokenKey)
+	g.Expect(err).To(MatchError(ErrNotFound))
+
+	g.Expect(store.Set(JiraTokenKey, "jira-secret")).To(Succeed())
+	g.Expect(store.Set(PagerDutyOauthTokenKey, "pd-secret")).To(Succeed())
+
+	data, err := os.ReadFile(store.path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).NotTo(ContainSubstring("jira-secret"))
+	info, err := os.Stat(store.path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	// A new store reads what the first one wrote
+	reopened := &FileStore{path: store.path, passphrase: func() ([]byte, error) { return []byte("correct horse"), nil }}
+	value, err := reopened.Get(JiraTokenKey)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(value).To(Equal("jira-secret"))
+
+	g.Expect(reopened.Delete(JiraTokenKey)).To(Succeed())
+	g.Expect(reopened.Delete(JiraTokenKey)).To(MatchError(ErrNotFound))
+	value, err = reopened.Get(PagerDutyOauthTokenKey)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(value).To(Equal("pd-secret"))
+}
+
+func TestFileStoreWrongPassphrase(t *testing.T) {
+	g := NewGomegaWithT(t)
+	store := newTestFileStore(t, "correct horse")
+	g.Expect(store.Set(JiraTokenKey, "jira-secret")).To(Succeed())
+
+	wrong := &FileStore{path: store.path, passphrase: func() ([]byte, error) { return []byte("battery staple"), nil }}
+	_, err := wrong.Get(JiraTokenKey)
+	g.Expect(err).To(MatchError(ContainSubstring("is the passphrase correct?")))
+}
+
+func TestFileStorePBKDF2(t *testing.T) {
+	g := NewGomegaWithT(t)
+	store := newTestFileStore(t, "correct horse")
+	store.kdf = kdfPBKDF2
+	g.Expect(store.Set(JiraTokenKey, "jira-secret")).To(Succeed())
+
+	data, err := os.ReadFile(store.path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(data)).To(ContainSubstring(`"kdf":"pbkdf2-sha256"`))
+
+	// The key derivation is read from the file, not from the build
+	reopened := &FileStore{path: store.path, passphrase: func() ([]byte, error) { return []byte("correct horse"), nil }}
+	value, err := reopened.Get(JiraTokenKey)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(value).To(Equal("jira-secret"))
+}
+
+func TestFileStoreMissingFileDoesNotNeedPassphrase(t *testing.T) {
+	g := NewGomegaWithT(t)
+	store := &FileStore{
+		path:       filepath.Join(t.TempDir(), "secrets.enc"),
+		passphrase: func() ([]byte, error) { return nil, errors.New("prompted") },
+	}
+	_, err := store.Get(JiraTokenKey)
+	g.Expect(err).To(MatchError(ErrNotFound))
+}
+
+func TestFileStorePromptsOncePerProcess(t *testing.T) {
+	g := NewGomegaWithT(t)
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	viper.Set(FileConfigKey, path)
+	prompts := 0
+	passphraseFunc = func() ([]byte, error) { prompts++; return []byte("correct horse"), nil }
+	defer func() {
+		viper.Set(FileConfigKey, "")
+		passphraseFunc, cachedPassphrase = readPassphrase, nil
+	}()
+
+	store, err := NewFileStore()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(store.Set(JiraTokenKey, "jira-secret")).To(Succeed())
+	g.Expect(store.Set(PagerDutyOauthTokenKey, "pd-secret")).To(Succeed())
+
+	// Every lookup builds its own store
+	for _, key := range []string{JiraTokenKey, PagerDutyOauthTokenKey} {
+		reopened, err := NewFileStore()
+		g.Expect(err).NotTo(HaveOccurred())
+		_, err = reopened.Get(key)
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+	g.Expect(prompts).To(Equal(1))
+}
+
+type fakeKeyring struct{ values map[string]string }
+
+func (f *fakeKeyring) Name() string { return "keyring (fake)" }
+func (f *fakeKeyring) Get(key string) (string, error) {
+	if v, ok := f.values[key]; ok {
+		return v, nil
+	}
+	return "", ErrNotFound
+}
+func (f *fakeKeyring) Set(key, value string) error { f.values[key] = value; return nil }
+func (f *fakeKeyring) Delete(key string) error     { delete(f.values, key); return nil }
+
+func TestNew(t *testing.T) {
+	keyring := &fakeKeyring{values: map[string]string{JiraTokenKey: "from-keyring"}}
+	tests := []struct {
+		name        string
+		backend     string
+		keyring     Store
+		expectName  string
+		expectErr   string
+		expectToken string
+	}{
+		{
+			name:        "keyring is preferred",
+			keyring:     keyring,
+			expectName:  "keyring (fake)",
+			expectToken: "from-keyring",
+		},
+		{
+			name:       "file when no keyring is available",
+			expectName: fileStoreTag,
+		},
+		{
+			name:       "file is forced",
+			backend:    BackendFile,
+			keyring:    keyring,
+			expectName: fileStoreTag,
+		},
+		{
+			name:      "keyring is forced but unavailable",
+			backend:   BackendKeyring,
+			expectErr: "no OS keyring is available",
+		},
+		{
+			name:      "invalid backend",
+			backend:   "vault",
+			expectErr: "invalid 'secrets_backend' 'vault'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGomegaWithT(t)
+			viper.Set(BackendConfigKey, tt.backend)
+			viper.Set(FileConfigKey, filepath.Join(t.TempDir(), "secrets.enc"))
+			newKeyring = func() Store { return tt.keyring }
+			t.Cleanup(func() {
+				viper.Set(BackendConfigKey, "")
+				viper.Set(FileConfigKey, "")
+				newKeyring = newOSKeyring
+			})
+
+			store, err := New()
+			if tt.expectErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.expectErr)))
+				return
+			}
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(strings.HasPrefix(store.Name(), tt.expectName)).To(BeTrue(), store.Name())
+
+			token, err := Lookup(JiraTokenKey)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(token).To(Equal(tt.expectToken))
+		})
+	}
+}
+
+func TestIsNotConfigured(t *testing.T) {
+	g := NewGomegaWithT(t)
+	err := fmt.Errorf("cannot list the incidents: %w", &NotConfiguredError{Integration: "PagerDuty", Key: PagerDutyOauthTokenKey})
+	g.Expect(IsNotConfigured(err)).To(BeTrue())
+	g.Expect(err.Error()).To(Equal("cannot list the incidents: PagerDuty isn't configured, store its token with 'osdctl secrets set pd_oauth_token'"))
+	g.Expect(IsNotConfigured(errors.New("forbidden"))).To(BeFalse())
+}
@@ -0,0 +1,280 @@
+package telemetry
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	// EnabledConfigKey opts in to sending usage telemetry. Telemetry is never sent unless this is true.
+	EnabledConfigKey = "telemetry_enabled"
+	// EndpointConfigKey is the base URL of the pushgateway or OTLP/HTTP collector
+	EndpointConfigKey = "telemetry_endpoint"
+	// ProtocolConfigKey selects how metrics are shipped, either "pushgateway" (default) or "otlp"
+	ProtocolConfigKey = "telemetry_protocol"
+
+	ProtocolPushgateway = "pushgateway"
+	ProtocolOTLP        = "otlp"
+
+	jobName     = "osdctl"
+	sendTimeout = 5 * time.Second
+)
+
+// Record describes a single command invocation
+type Record struct {
+	Command  string
+	Duration time.Duration
+	Category string
+}
+
+var (
+	current        *invocation
+	sendFnc        = send
+	clockFunc      = time.Now
+	countsFileFunc = defaultCountsFile
+)
+
+type invocation struct {
+	command string
+	start   time.Time
+}
+
+// Enabled reports whether the user opted in to telemetry
+func Enabled() bool {
+	return viper.GetBool(EnabledConfigKey) && viper.GetString(EndpointConfigKey) != ""
+}
+
+// Start begins measuring the given command. It also hooks into osdctlErrors.CheckErr and the kubectl
+// fatal error handler, as the commands failing exit there without returning to main.
+func Start(cmd *cobra.Command) {
+	if !Enabled() {
+		return
+	}
+
+	current = &invocation{
+		command: cmd.CommandPath(),
+		start:   clockFunc(),
+	}
+
+	cmdutil.BehaviorOnFatal(func(msg string, code int) {
+		Finish(fmt.Errorf("%s", msg))
+		if len(msg) > 0 {
+			if !strings.HasSuffix(msg, "\n") {
+				msg += "\n"
+			}
+			fmt.Fprint(os.Stderr, msg)
+		}
+		os.Exit(code)
+	})
+	osdctlErrors.OnExit(Finish)
+}
+
+// Finish records the outcome of the command started with Start and ships it to the
+// configured endpoint. Failures to send are reported but never fail the command.
+func Finish(err error) {
+	if current == nil {
+		return
+	}
+
+	record := Record{
+		Command:  current.command,
+		Duration: clockFunc().Sub(current.start),
+		Category: Categorize(err),
+	}
+	current = nil
+
+	if sendErr := sendFnc(record); sendErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to send telemetry: %v\n", sendErr)
+	}
+}
+
+// Categorize buckets an error into a coarse category so that no customer data
+// (cluster IDs, account IDs, messages) ever leaves the machine
+func Categorize(err error) string {
+	if err == nil {
+		return "success"
+	}
+
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "unauthorized") || strings.Contains(msg, "forbidden") ||
+		strings.Contains(msg, "accessdenied") || strings.Contains(msg, "not logged in"):
+		return "auth"
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded"):
+		return "timeout"
+	case strings.Contains(msg, "throttl") || strings.Contains(msg, "rate exceeded") || strings.Contains(msg, "too many requests"):
+		return "throttled"
+	case strings.Contains(msg, "not found") || strings.Contains(msg, "there are no"):
+		return "not_found"
+	case strings.Contains(msg, "see '") || strings.Contains(msg, "flag") || strings.Contains(msg, "argument"):
+		return "usage"
+	default:
+		return "other"
+	}
+}
+
+func send(record Record) error {
+	endpoint := strings.TrimSuffix(viper.GetString(EndpointConfigKey), "/")
+	protocol := viper.GetString(ProtocolConfigKey)
+	if protocol == "" {
+		protocol = ProtocolPushgateway
+	}
+
+	switch protocol {
+	case ProtocolPushgateway:
+		return sendPushgateway(endpoint, record)
+	case ProtocolOTLP:
+		return sendOTLP(endpoint, record)
+	default:
+		return fmt.Errorf("unknown telemetry protocol '%s', expected '%s' or '%s'", protocol, ProtocolPushgateway, ProtocolOTLP)
+	}
+}
+
+// sendPushgateway pushes the number of invocations of the command by the user. The pushgateway keeps the last value
+// pushed to a group rather than adding them up, so every command, category and user is a group of its own, and the
+// counter pushed is the running total kept in the user cache dir. The total of a command is the sum of its groups.
+func sendPushgateway(endpoint string, record Record) error {
+	user := userID()
+	count, err := incrementCount(record.Command + "\n" + record.Category)
+	if err != nil {
+		return err
+	}
+
+	invocations := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "osdctl_command_invocations_total",
+		Help: "Number of osdctl command invocations",
+	})
+	invocations.Add(float64(count))
+
+	duration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "osdctl_command_duration_seconds",
+		Help: "Duration of the last osdctl command invocation",
+	})
+	duration.Set(record.Duration.Seconds())
+
+	return push.New(endpoint, jobName).
+		Client(&http.Client{Timeout: sendTimeout}).
+		Grouping("command", record.Command).
+		Grouping("category", record.Category).
+		Grouping("user", user).
+		Collector(invocations).
+		Collector(duration).
+		Add()
+}
+
+// userID identifies the user without naming them, so that the pushes of different users don't replace each other
+func userID() string {
+	name := "unknown"
+	if account, err := user.Current(); err == nil {
+		name = account.Username
+	}
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func defaultCountsFile() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "osdctl", "telemetry_counts.json"), nil
+}
+
+// incrementCount adds the invocation to the running total of the key and returns it
+func incrementCount(key string) (int, error) {
+	path, err := countsFileFunc()
+	if err != nil {
+		return 0, err
+	}
+	counts := map[string]int{}
+	if data, err := os.ReadFile(path); err == nil { //#nosec G304 -- path is derived from the user cache dir
+		// A corrupted file restarts the counts, which prometheus handles as a counter reset
+		_ = json.Unmarshal(data, &counts)
+	}
+	counts[key]++
+	data, err := json.Marshal(counts)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return 0, err
+	}
+	return counts[key], nil
+}
+
+// sendOTLP ships the record using the OTLP/HTTP JSON encoding
+func sendOTLP(endpoint string, record Record) error {
+	now := fmt.Sprintf("%d", clockFunc().UnixNano())
+	attributes := []map[string]interface{}{
+		{"key": "command", "value": map[string]string{"stringValue": record.Command}},
+		{"key": "category", "value": map[string]string{"stringValue": record.Category}},
+	}
+	payload := map[string]interface{}{
+		"resourceMetrics": []map[string]interface{}{{
+			"resource": map[string]interface{}{
+				"attributes": []map[string]interface{}{
+					{"key": "service.name", "value": map[string]string{"stringValue": jobName}},
+				},
+			},
+			"scopeMetrics": []map[string]interface{}{{
+				"metrics": []map[string]interface{}{
+					{
+						"name": "osdctl.command.invocations",
+						"sum": map[string]interface{}{
+							"aggregationTemporality": 1, // delta
+							"isMonotonic":            true,
+							"dataPoints": []map[string]interface{}{
+								{"attributes": attributes, "timeUnixNano": now, "asInt": "1"},
+							},
+						},
+					},
+					{
+						"name": "osdctl.command.duration",
+						"unit": "s",
+						"gauge": map[string]interface{}{
+							"dataPoints": []map[string]interface{}{
+								{"attributes": attributes, "timeUnixNano": now, "asDouble": record.Duration.Seconds()},
+							},
+						},
+					},
+				},
+			}},
+		}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: sendTimeout}
+	resp, err := client.Post(endpoint+"/v1/metrics", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
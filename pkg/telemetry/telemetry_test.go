@@ -0,0 +1,152 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestCategorize(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{nil, "success"},
+		{errors.New("status is 401, identifier is '401', code is 'CLUSTERS-MGMT-401': Unauthorized"), "auth"},
+		{errors.New("context deadline exceeded"), "timeout"},
+		{errors.New("Throttling: Rate exceeded"), "throttled"},
+		{errors.New("There are no subscriptions or clusters with identifier or name 'foo'"), "not_found"},
+		{errors.New("unknown flag: --foo"), "usage"},
+		{errors.New("something broke"), "other"},
+	}
+
+	for _, tc := range testCases {
+		if got := Categorize(tc.err); got != tc.expected {
+			t.Errorf("Categorize(%v) = %s, expected %s", tc.err, got, tc.expected)
+		}
+	}
+}
+
+func TestStartIsNoopWhenDisabled(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	Start(&cobra.Command{Use: "osdctl"})
+	if current != nil {
+		t.Fatal("expected telemetry to stay disabled when not opted in")
+	}
+}
+
+func TestSendOTLP(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("collector received invalid JSON: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set(EnabledConfigKey, true)
+	viper.Set(EndpointConfigKey, server.URL)
+	viper.Set(ProtocolConfigKey, ProtocolOTLP)
+
+	err := send(Record{Command: "osdctl cluster context", Duration: 2 * time.Second, Category: "success"})
+	if err != nil {
+		t.Fatalf("unexpected error sending telemetry: %v", err)
+	}
+	if _, ok := received["resourceMetrics"]; !ok {
+		t.Fatalf("expected resourceMetrics in payload, got %v", received)
+	}
+}
+
+func TestSendPushgateway(t *testing.T) {
+	// The total pushed to the group of each command, and the grouping labels
+	pushes := map[string]float64{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.TrimPrefix(r.URL.Path, "/metrics/job/osdctl/"), "/")
+		group := map[string]string{}
+		for i := 0; i+1 < len(segments); i += 2 {
+			group[segments[i]] = segments[i+1]
+		}
+		if group["category"] != "success" || group["user"] != userID() {
+			t.Errorf("expected the group to be keyed by category and user, got %s", r.URL.Path)
+		}
+		decoder := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+		var family dto.MetricFamily
+		for decoder.Decode(&family) == nil {
+			if family.GetName() == "osdctl_command_invocations_total" {
+				pushes[group["command"]] = family.GetMetric()[0].GetCounter().GetValue()
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	counts := filepath.Join(t.TempDir(), "counts.json")
+	countsFileFunc = func() (string, error) { return counts, nil }
+	defer func() { countsFileFunc = defaultCountsFile }()
+
+	for _, command := range []string{"context", "context", "health"} {
+		if err := sendPushgateway(server.URL, Record{Command: "osdctl cluster " + command, Category: "success"}); err != nil {
+			t.Fatalf("unexpected error sending telemetry: %v", err)
+		}
+	}
+
+	// Every command is pushed to a group of its own, with the running total of its invocations
+	expected := map[string]float64{"osdctl+cluster+context": 2, "osdctl+cluster+health": 1}
+	if len(pushes) != len(expected) || pushes["osdctl+cluster+context"] != 2 || pushes["osdctl+cluster+health"] != 1 {
+		t.Errorf("expected %v to be pushed, got %v", expected, pushes)
+	}
+}
+
+// TestCheckErrIsRecorded runs a command failing through osdctlErrors.CheckErr in a child process, as it exits
+func TestCheckErrIsRecorded(t *testing.T) {
+	if path := os.Getenv("TELEMETRY_RECORD_FILE"); path != "" {
+		viper.Set(EnabledConfigKey, true)
+		viper.Set(EndpointConfigKey, "http://127.0.0.1")
+		sendFnc = func(record Record) error {
+			data, _ := json.Marshal(record)
+			return os.WriteFile(path, data, 0600)
+		}
+		Start(&cobra.Command{Use: "osdctl"})
+		osdctlErrors.CheckErr(osdctlErrors.New(osdctlErrors.ErrNotFound, "There are no clusters with identifier 'abc'"))
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "record.json")
+	child := exec.Command(os.Args[0], "-test.run=^TestCheckErrIsRecorded$") //#nosec G204 -- runs the test binary
+	child.Env = append(os.Environ(), "TELEMETRY_RECORD_FILE="+path)
+	if err := child.Run(); err == nil {
+		t.Fatal("expected the child to exit with the code of the error")
+	}
+
+	data, err := os.ReadFile(path) //#nosec G304 -- path is in the test temp dir
+	if err != nil {
+		t.Fatalf("expected the failure to be recorded: %v", err)
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil || record.Command != "osdctl" || record.Category != "not_found" {
+		t.Errorf("expected a not_found record of osdctl, got %s and %v", data, err)
+	}
+}
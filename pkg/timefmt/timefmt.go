@@ -0,0 +1,63 @@
+package timefmt
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigKey prints the timestamps in UTC instead of the local timezone when true
+	ConfigKey = "utc"
+	Flag      = "utc"
+
+	// displayLayout is how timestamps are printed for humans, with the zone abbreviation so that they can't be
+	// mistaken for UTC
+	displayLayout = "2006-01-02 15:04:05 MST"
+)
+
+// machineReadable is set when the output is parsed rather than read, e.g. -o json
+var machineReadable bool
+
+// AddFlags adds the --utc flag to the given command and binds it to the config key
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(Flag, false, "Print the timestamps in UTC instead of the local timezone (config key: "+ConfigKey+")")
+	_ = viper.BindPFlag(ConfigKey, cmd.PersistentFlags().Lookup(Flag))
+}
+
+// SetOutputFormat sets the --output format, the timestamps of every format but the default one are RFC3339 in UTC
+func SetOutputFormat(format string) {
+	machineReadable = format != ""
+}
+
+// Location returns the timezone timestamps are printed in
+func Location() *time.Location {
+	if viper.GetBool(ConfigKey) {
+		return time.UTC
+	}
+	return time.Local
+}
+
+// Format returns the timestamp in the local timezone, or UTC with --utc, e.g. 2026-10-14 11:30:00 CEST.
+// With a machine-readable output it is RFC3339 in UTC instead, e.g. 2026-10-14T09:30:00Z.
+func Format(t time.Time) string {
+	if machineReadable {
+		return RFC3339(t)
+	}
+	return t.In(Location()).Format(displayLayout)
+}
+
+// RFC3339 returns the timestamp as RFC3339 in UTC, for what is parsed whatever the output, e.g. CSV columns
+func RFC3339(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// FormatString formats a timestamp returned by an API as text, which is returned as is when it isn't RFC3339
+func FormatString(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return Format(t)
+}
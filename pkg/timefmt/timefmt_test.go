@@ -0,0 +1,49 @@
+package timefmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestFormat(t *testing.T) {
+	defer SetOutputFormat("")
+	defer viper.Set(ConfigKey, false)
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.FixedZone("CEST", 2*60*60)
+	at := time.Date(2026, 10, 14, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		output string
+		utc    bool
+		want   string
+	}{
+		{name: "local timezone by default", want: "2026-10-14 11:30:00 CEST"},
+		{name: "utc", utc: true, want: "2026-10-14 09:30:00 UTC"},
+		{name: "json", output: "json", want: "2026-10-14T09:30:00Z"},
+		{name: "json ignores --utc", output: "json", utc: true, want: "2026-10-14T09:30:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetOutputFormat(tt.output)
+			viper.Set(ConfigKey, tt.utc)
+			if got := Format(at); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatString(t *testing.T) {
+	defer SetOutputFormat("")
+	SetOutputFormat("json")
+
+	if got := FormatString("2026-10-14T11:30:00.123+02:00"); got != "2026-10-14T09:30:00Z" {
+		t.Errorf("FormatString() = %q, want the timestamp in UTC", got)
+	}
+	if got := FormatString("yesterday"); got != "yesterday" {
+		t.Errorf("FormatString() = %q, want the value as is", got)
+	}
+}
@@ -0,0 +1,229 @@
+// Package trace logs the HTTP requests sent to the OCM and AWS APIs, with their status and latency, when --trace is given
+package trace
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/openshift/osdctl/pkg/logging"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	Flag = "trace"
+
+	// stderr is the destination of a bare --trace
+	stderr = "-"
+
+	redacted = "REDACTED"
+)
+
+// sensitiveHeaders are the headers whose value is never logged
+var sensitiveHeaders = map[string]bool{
+	"Authorization":        true,
+	"Cookie":               true,
+	"Set-Cookie":           true,
+	"Proxy-Authorization":  true,
+	"X-Amz-Security-Token": true,
+}
+
+// sensitiveQueryParameters are the query parameters whose value is never logged, e.g. the signatures of presigned URLs
+var sensitiveQueryParameters = map[string]bool{
+	"x-amz-signature":      true,
+	"x-amz-security-token": true,
+	"x-amz-credential":     true,
+	"access_token":         true,
+	"token":                true,
+}
+
+var (
+	destination string
+	logger      *log.Logger
+	loggerMu    sync.Mutex
+	traceFile   *os.File
+)
+
+// AddFlags adds the --trace flag to the given command
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&destination, Flag, "", "Log the OCM and AWS API requests to stderr, or to the given file with --trace=FILE")
+	cmd.PersistentFlags().Lookup(Flag).NoOptDefVal = stderr
+}
+
+// Open sets up the trace logger when --trace was given, in the log format of osdctl
+func Open() error {
+	if destination == "" {
+		return nil
+	}
+	formatter, err := logging.Formatter(viper.GetString(logging.LogFormatConfigKey))
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stderr
+	var file *os.File
+	if destination != stderr {
+		file, err = os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) //#nosec G304 -- path is provided by the user
+		if err != nil {
+			return fmt.Errorf("cannot open trace file '%s': %w", destination, err)
+		}
+		out = file
+	}
+
+	l := log.New()
+	l.SetOutput(out)
+	l.SetFormatter(formatter)
+	l.SetLevel(log.InfoLevel)
+
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger, traceFile = l, file
+	return nil
+}
+
+// Close closes the trace file opened by Open, if any
+func Close() error {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = nil
+	if traceFile == nil {
+		return nil
+	}
+	err := traceFile.Close()
+	traceFile = nil
+	return err
+}
+
+func currentLogger() *log.Logger {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	return logger
+}
+
+type transport struct {
+	client  string
+	wrapped http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := currentLogger()
+	if l == nil {
+		return t.wrapped.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.wrapped.RoundTrip(req)
+	fields := log.Fields{
+		"client":          t.client,
+		"method":          req.Method,
+		"url":             SanitizeURL(req.URL),
+		"latency_ms":      time.Since(start).Milliseconds(),
+		"request_headers": sanitizeHeaders(req.Header),
+	}
+	if err != nil {
+		l.WithFields(fields).WithError(err).Info("http request failed")
+		return resp, err
+	}
+	fields["status"] = resp.StatusCode
+	fields["response_headers"] = sanitizeHeaders(resp.Header)
+	l.WithFields(fields).Info("http request")
+	return resp, nil
+}
+
+// Transport returns a round tripper logging the requests sent through the wrapped one, client names the API
+func Transport(client string, wrapped http.RoundTripper) http.RoundTripper {
+	if wrapped == nil {
+		wrapped = http.DefaultTransport
+	}
+	return &transport{client: client, wrapped: wrapped}
+}
+
+// OCMTransportWrapper returns a wrapper suitable for sdk.ConnectionBuilder.TransportWrapper
+// that logs the OCM requests
+func OCMTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	if currentLogger() == nil {
+		return wrapped
+	}
+	return Transport("ocm", wrapped)
+}
+
+// AWSV2Options returns the aws-sdk-go-v2 config options making the clients log their requests
+func AWSV2Options() []func(*config.LoadOptions) error {
+	if currentLogger() == nil {
+		return nil
+	}
+	// Keep the defaults of the SDK's own client
+	defaults := awshttp.NewBuildableClient()
+	client := &http.Client{Transport: Transport("aws", defaults.GetTransport()), Timeout: defaults.GetTimeout()}
+	return []func(*config.LoadOptions) error{config.WithHTTPClient(client)}
+}
+
+// AttachToAWSSession makes the clients created from the session log their requests
+func AttachToAWSSession(sess *session.Session) {
+	if currentLogger() == nil {
+		return
+	}
+	// Never change the shared http.DefaultClient, the session gets its own client
+	client := &http.Client{}
+	if sess.Config.HTTPClient != nil {
+		*client = *sess.Config.HTTPClient
+	}
+	client.Transport = Transport("aws", client.Transport)
+	sess.Config.HTTPClient = client
+}
+
+// SensitiveHeader returns true for the headers holding credentials, whose value is never logged
+func SensitiveHeader(name string) bool {
+	return sensitiveHeaders[http.CanonicalHeaderKey(name)]
+}
+
+// sanitizeHeaders returns the headers as a single string, sorted, without the value of the sensitive ones
+func sanitizeHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		value := strings.Join(header[name], ",")
+		if SensitiveHeader(name) {
+			value = redacted
+		}
+		parts = append(parts, name+": "+value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SanitizeURL returns the URL without the value of the sensitive query parameters and without user info
+func SanitizeURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	sanitized := *u
+	sanitized.User = nil
+	query := sanitized.Query()
+	changed := false
+	for name := range query {
+		if sensitiveQueryParameters[strings.ToLower(name)] {
+			query.Set(name, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		sanitized.RawQuery = query.Encode()
+	}
+	return sanitized.String()
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const progressBarWidth = 30
+
+// Progress tracks a batch job over a known number of items
+type Progress struct {
+	// Success is printed next to the items that succeeded, without a terminal
+	Success string
+
+	w       io.Writer
+	label   string
+	total   int
+	current int
+	failed  int
+	tty     bool
+	mu      sync.Mutex
+}
+
+// NewProgress returns a progress bar for total items, labelled with what the job does
+func NewProgress(w io.Writer, total int, label string) *Progress {
+	return &Progress{Success: "done", w: w, label: label, total: total, tty: IsTerminal(w)}
+}
+
+// Increment records that the item is done, with the error it failed with if any. On a terminal failures are
+// printed above the bar, otherwise every item is printed on its own line.
+func (p *Progress) Increment(item string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.current++
+	if err != nil {
+		p.failed++
+	}
+
+	if !p.tty {
+		if err != nil {
+			fmt.Fprintf(p.w, "[%d/%d] %s: %v\n", p.current, p.total, item, err)
+		} else {
+			fmt.Fprintf(p.w, "[%d/%d] %s: %s\n", p.current, p.total, item, p.Success)
+		}
+		return
+	}
+
+	if err != nil {
+		fmt.Fprintf(p.w, "%s%s: %v\n", clearLine, item, err)
+	}
+	fmt.Fprint(p.w, clearLine+p.bar())
+}
+
+// Done ends the progress bar line
+func (p *Progress) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tty && p.current > 0 {
+		fmt.Fprintln(p.w)
+	}
+}
+
+func (p *Progress) bar() string {
+	filled := progressBarWidth
+	if p.total > 0 {
+		filled = p.current * progressBarWidth / p.total
+	}
+	bar := fmt.Sprintf("[%s%s] %d/%d %s", strings.Repeat("#", filled), strings.Repeat(".", progressBarWidth-filled), p.current, p.total, p.label)
+	if p.failed > 0 {
+		bar += fmt.Sprintf(" (%d failed)", p.failed)
+	}
+	return bar
+}
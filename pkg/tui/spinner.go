@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"-", "\\", "|", "/"}
+
+// Spinner shows that a step without measurable progress is running
+type Spinner struct {
+	w        io.Writer
+	message  string
+	tty      bool
+	interval time.Duration
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSpinner returns a spinner showing the message, it is drawn once started
+func NewSpinner(w io.Writer, message string) *Spinner {
+	return &Spinner{w: w, message: message, tty: IsTerminal(w), interval: 100 * time.Millisecond}
+}
+
+// Start draws the spinner until Stop is called. Without a terminal the message is printed once.
+func (s *Spinner) Start() {
+	if !s.tty {
+		fmt.Fprintf(s.w, "%s...\n", s.message)
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			s.mu.Lock()
+			fmt.Fprintf(s.w, "%s%s %s", clearLine, spinnerFrames[frame%len(spinnerFrames)], s.message)
+			s.mu.Unlock()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Update replaces the message shown next to the spinner
+func (s *Spinner) Update(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.message = message
+	if !s.tty {
+		fmt.Fprintf(s.w, "%s...\n", message)
+	}
+}
+
+// Stop removes the spinner and prints the result, when not empty
+func (s *Spinner) Stop(result string) {
+	if s.tty && s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+		fmt.Fprint(s.w, clearLine)
+	}
+	if result != "" {
+		fmt.Fprintln(s.w, result)
+	}
+}
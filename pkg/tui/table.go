@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	tablePadding = 3
+	// minColumnWidth is the narrowest a column gets when the table is shrunk to the terminal width
+	minColumnWidth = 10
+)
+
+// Table prints rows in aligned columns. On a terminal too narrow for the table the widest columns are truncated,
+// otherwise cells are printed whole.
+type Table struct {
+	w        io.Writer
+	rows     [][]string
+	maxWidth int
+}
+
+// NewTable returns a table fitting the width of w when it is a terminal
+func NewTable(w io.Writer) *Table {
+	return &Table{w: w, maxWidth: Width(w)}
+}
+
+// AddRow adds a row of cells
+func (t *Table) AddRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// Flush prints the rows followed by an empty line for readability
+func (t *Table) Flush() error {
+	widths := t.columnWidths()
+	for _, row := range t.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cell = truncate(cell, widths[i])
+			if i < len(row)-1 {
+				cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+tablePadding)
+			}
+			cells[i] = cell
+		}
+		if _, err := fmt.Fprintln(t.w, strings.TrimRight(strings.Join(cells, ""), " ")); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(t.w)
+	return err
+}
+
+// columnWidths returns the width of the widest cell of every column, narrowing the widest columns until the table
+// fits the terminal
+func (t *Table) columnWidths() []int {
+	var widths []int
+	for _, row := range t.rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	if t.maxWidth <= 0 {
+		return widths
+	}
+
+	for {
+		total := 0
+		widest := 0
+		for i, width := range widths {
+			total += width + tablePadding
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if total-tablePadding <= t.maxWidth || widths[widest] <= minColumnWidth {
+			return widths
+		}
+		widths[widest]--
+	}
+}
+
+// truncate shortens the value to width runes, ending with "..." when it was cut
+func truncate(value string, width int) string {
+	if utf8.RuneCountInString(value) <= width {
+		return value
+	}
+	runes := []rune(value)
+	if width <= 3 {
+		return string(runes[:width])
+	}
+	return string(runes[:width-3]) + "..."
+}
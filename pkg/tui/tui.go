@@ -0,0 +1,31 @@
+// Package tui provides spinners, progress bars and tables for long-running commands. They are drawn on terminals
+// only and fall back to plain lines of text otherwise, e.g. when the output is redirected to a file or piped.
+package tui
+
+import (
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// IsTerminal reports whether w is a terminal
+func IsTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(file.Fd()))
+}
+
+// Width returns the width of the terminal w, or 0 when it isn't one
+func Width(w io.Writer) int {
+	if !IsTerminal(w) {
+		return 0
+	}
+	width, _, err := term.GetSize(int(w.(*os.File).Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// clearLine moves back to the start of the current line and erases it
+const clearLine = "\r\033[K"
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestSpinnerWithoutTerminal(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	out := &bytes.Buffer{}
+	spinner := NewSpinner(out, "Running the egress verification")
+	spinner.Start()
+	spinner.Update("Collecting the results")
+	spinner.Stop("Done")
+
+	g.Expect(out.String()).To(Equal("Running the egress verification...\nCollecting the results...\nDone\n"))
+}
+
+func TestProgressWithoutTerminal(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	out := &bytes.Buffer{}
+	progress := NewProgress(out, 2, "service logs")
+	progress.Success = "sent"
+	progress.Increment("cluster-a", nil)
+	progress.Increment("cluster-b", errors.New("not found"))
+	progress.Done()
+
+	g.Expect(out.String()).To(Equal("[1/2] cluster-a: sent\n[2/2] cluster-b: not found\n"))
+}
+
+func TestProgressBar(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	progress := &Progress{total: 4, current: 2, failed: 1, label: "clusters"}
+	g.Expect(progress.bar()).To(Equal("[###############...............] 2/4 clusters (1 failed)"))
+}
+
+func TestTable(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	out := &bytes.Buffer{}
+	table := NewTable(out)
+	table.AddRow("ID", "STATUS", "DETAILS")
+	table.AddRow("abc", "revoked", "The security token included in the request is invalid")
+	g.Expect(table.Flush()).To(Succeed())
+	g.Expect(out.String()).To(Equal("" +
+		"ID    STATUS    DETAILS\n" +
+		"abc   revoked   The security token included in the request is invalid\n\n"))
+
+	// On a narrow terminal the widest column is truncated
+	out.Reset()
+	table.maxWidth = 40
+	g.Expect(table.Flush()).To(Succeed())
+	g.Expect(out.String()).To(Equal("" +
+		"ID    STATUS    DETAILS\n" +
+		"abc   revoked   The security token in...\n\n"))
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ActionLabelKey is the internal subscription label summarizing the last change osdctl made to the cluster, so
+	// that the SREs looking at the cluster in OCM know a CLI-driven change happened
+	ActionLabelKey = "osdctl.last-action"
+	// ActionLabelConfigKey turns the label off when set to false
+	ActionLabelConfigKey = "ocm_action_label"
+
+	// actionLabelMaxLength keeps the label value within what accounts_mgmt accepts
+	actionLabelMaxLength = 255
+)
+
+func init() {
+	viper.SetDefault(ActionLabelConfigKey, true)
+}
+
+// RecordClusterAction sets the internal subscription label of the cluster to a summary of the action, the OCM user
+// and the osdctl version. The action has already happened: failures are only warned about.
+func RecordClusterAction(connection *sdk.Connection, cluster *cmv1.Cluster, action string) {
+	if !viper.GetBool(ActionLabelConfigKey) {
+		return
+	}
+	subscriptionID := cluster.Subscription().ID()
+	if subscriptionID == "" {
+		log.Debugf("Not labelling cluster %s with the action, it has no subscription", cluster.ID())
+		return
+	}
+	if err := setActionLabel(connection, subscriptionID, actionLabelValue(action, currentOCMUser(connection), Version, time.Now())); err != nil {
+		log.Warnf("Unable to record the action on the subscription of cluster %s: %v", cluster.ID(), err)
+	}
+}
+
+// actionLabelValue summarizes the action, e.g. 'Restart nodes ip-10-0-1-2 by jdoe with osdctl 0.31.0 at 2026-10-14T09:30:00Z'
+func actionLabelValue(action, user, version string, now time.Time) string {
+	if version == "" {
+		version = "(development build)"
+	}
+	suffix := fmt.Sprintf(" by %s with osdctl %s at %s", user, version, now.UTC().Format(time.RFC3339))
+	// The action is cut rather than who did it and when
+	if len(action)+len(suffix) > actionLabelMaxLength {
+		action = action[:actionLabelMaxLength-len(suffix)-3] + "..."
+	}
+	return action + suffix
+}
+
+func currentOCMUser(connection *sdk.Connection) string {
+	response, err := connection.AccountsMgmt().V1().CurrentAccount().Get().Send()
+	if err != nil || response.Body().Username() == "" {
+		return "unknown user"
+	}
+	return response.Body().Username()
+}
+
+func setActionLabel(connection *sdk.Connection, subscriptionID, value string) error {
+	label, err := amv1.NewLabel().Key(ActionLabelKey).Value(value).Internal(true).Build()
+	if err != nil {
+		return err
+	}
+
+	labels := connection.AccountsMgmt().V1().Subscriptions().Subscription(subscriptionID).Labels()
+	response, err := labels.Label(ActionLabelKey).Get().Send()
+	if err != nil && (response == nil || response.Status() != http.StatusNotFound) {
+		return err
+	}
+	if err == nil {
+		_, err = labels.Label(ActionLabelKey).Update().Body(label).Send()
+	} else {
+		_, err = labels.Add().Body(label).Send()
+	}
+	return err
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActionLabelValue(t *testing.T) {
+	now := time.Date(2026, 10, 14, 11, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	value := actionLabelValue("Restart nodes ip-10-0-1-2", "jdoe", "0.31.0", now)
+	if expected := "Restart nodes ip-10-0-1-2 by jdoe with osdctl 0.31.0 at 2026-10-14T09:30:00Z"; value != expected {
+		t.Errorf("expected %q, got %q", expected, value)
+	}
+
+	value = actionLabelValue("Defragment 3 etcd members", "jdoe", "", now)
+	if !strings.Contains(value, "with osdctl (development build) at") {
+		t.Errorf("expected a development build, got %q", value)
+	}
+
+	// Long actions are cut, not who did them and when
+	value = actionLabelValue(strings.Repeat("x", 300), "jdoe", "0.31.0", now)
+	if len(value) != actionLabelMaxLength {
+		t.Errorf("expected %d characters, got %d", actionLabelMaxLength, len(value))
+	}
+	if !strings.HasSuffix(value, "... by jdoe with osdctl 0.31.0 at 2026-10-14T09:30:00Z") {
+		t.Errorf("expected the user and time to be kept, got %q", value)
+	}
+}
@@ -0,0 +1,371 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/timefmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// UseClusterCacheConfigKey makes lookups prefer the local cluster metadata cache
+	UseClusterCacheConfigKey = "cached"
+	// ClusterCacheTTLConfigKey is how long cached cluster metadata is considered fresh
+	ClusterCacheTTLConfigKey = "cluster_cache_ttl"
+
+	defaultClusterCacheTTL = time.Hour
+	clusterCacheFileName   = "clusters.json"
+)
+
+// ClusterMetadata is the subset of cluster information that is cached locally
+type ClusterMetadata struct {
+	ID            string    `json:"id"`
+	ExternalID    string    `json:"external_id"`
+	Name          string    `json:"name"`
+	CloudProvider string    `json:"cloud_provider"`
+	Region        string    `json:"region"`
+	Shard         string    `json:"shard,omitempty"`
+	OCMURL        string    `json:"ocm_url"`
+	FetchedAt     time.Time `json:"fetched_at"`
+	// Cluster is the cluster as returned by OCM, so that the cluster lookups can be served from the cache
+	Cluster json.RawMessage `json:"cluster,omitempty"`
+	// ProvisionShard is the provision shard of the cluster as returned by OCM
+	ProvisionShard json.RawMessage `json:"provision_shard,omitempty"`
+}
+
+// ClusterCache is a file backed cache of ClusterMetadata indexed by internal ID
+type ClusterCache struct {
+	Clusters map[string]*ClusterMetadata `json:"clusters"`
+
+	path string
+	mu   sync.Mutex
+}
+
+func init() {
+	viper.SetDefault(ClusterCacheTTLConfigKey, defaultClusterCacheTTL.String())
+}
+
+// AddClusterCacheFlags adds the --cached flag and binds it to the config
+func AddClusterCacheFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool("cached", false, "Look the clusters and their shard up in the local cluster cache (see 'osdctl cluster refresh-cache') before asking OCM")
+	_ = viper.BindPFlag(UseClusterCacheConfigKey, cmd.PersistentFlags().Lookup("cached"))
+}
+
+// ClusterCacheEnabled reports whether lookups should go through the cache
+func ClusterCacheEnabled() bool {
+	return viper.GetBool(UseClusterCacheConfigKey)
+}
+
+func clusterCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(viper.GetString(ClusterCacheTTLConfigKey))
+	if err != nil {
+		return defaultClusterCacheTTL
+	}
+	return ttl
+}
+
+// Swapped in tests
+var userCacheDir = os.UserCacheDir
+
+// ClusterCachePath returns the location of the cluster metadata cache file
+func ClusterCachePath() (string, error) {
+	cacheDir, err := userCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "osdctl", clusterCacheFileName), nil
+}
+
+// LoadClusterCache reads the cache from disk, returning an empty cache if it doesn't exist yet
+func LoadClusterCache() (*ClusterCache, error) {
+	path, err := ClusterCachePath()
+	if err != nil {
+		return nil, err
+	}
+	return loadClusterCacheFrom(path)
+}
+
+func loadClusterCacheFrom(path string) (*ClusterCache, error) {
+	cache := &ClusterCache{Clusters: map[string]*ClusterMetadata{}, path: path}
+
+	data, err := os.ReadFile(path) //#nosec G304 -- path is derived from the user cache dir
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read cluster cache '%s': %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		// A corrupt cache is not worth failing a command over, start from scratch
+		return &ClusterCache{Clusters: map[string]*ClusterMetadata{}, path: path}, nil
+	}
+	if cache.Clusters == nil {
+		cache.Clusters = map[string]*ClusterMetadata{}
+	}
+	return cache, nil
+}
+
+// Save writes the cache to disk
+func (c *ClusterCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0600)
+}
+
+// Find returns the cached entry matching the internal ID, external ID or name
+func (c *ClusterCache) Find(key, ocmURL string) *ClusterMetadata {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, metadata := range c.Clusters {
+		if metadata.OCMURL != ocmURL {
+			continue
+		}
+		if metadata.ID == key || metadata.ExternalID == key || metadata.Name == key {
+			return metadata
+		}
+	}
+	return nil
+}
+
+// Store adds or replaces an entry
+func (c *ClusterCache) Store(metadata *ClusterMetadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Clusters[metadata.ID] = metadata
+}
+
+// Remove drops the entries matching the internal ID, external ID or name, it reports whether there were any
+func (c *ClusterCache) Remove(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := false
+	for id, metadata := range c.Clusters {
+		if metadata.ID == key || metadata.ExternalID == key || metadata.Name == key {
+			delete(c.Clusters, id)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// Clear removes all entries
+func (c *ClusterCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Clusters = map[string]*ClusterMetadata{}
+}
+
+// IsFresh reports whether the entry is younger than the configured TTL
+func (m *ClusterMetadata) IsFresh(now time.Time) bool {
+	return now.Sub(m.FetchedAt) < clusterCacheTTL()
+}
+
+// FetchClusterMetadata retrieves the metadata of a cluster from OCM, bypassing the cache
+func FetchClusterMetadata(connection *sdk.Connection, key string) (*ClusterMetadata, error) {
+	cluster, err := getClusterAnyStatus(connection, key)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := newClusterMetadata(connection.URL(), cluster)
+	if err != nil {
+		return nil, err
+	}
+	if shard, err := fetchProvisionShard(connection, cluster.ID()); err == nil {
+		var body bytes.Buffer
+		if err := cmv1.MarshalProvisionShard(shard, &body); err == nil {
+			metadata.ProvisionShard = body.Bytes()
+		}
+		metadata.Shard = shard.HiveConfig().Server()
+	}
+
+	return metadata, nil
+}
+
+func newClusterMetadata(ocmURL string, cluster *cmv1.Cluster) (*ClusterMetadata, error) {
+	var body bytes.Buffer
+	if err := cmv1.MarshalCluster(cluster, &body); err != nil {
+		return nil, err
+	}
+	return &ClusterMetadata{
+		ID:            cluster.ID(),
+		ExternalID:    cluster.ExternalID(),
+		Name:          cluster.Name(),
+		CloudProvider: cluster.CloudProvider().ID(),
+		Region:        cluster.Region().ID(),
+		OCMURL:        ocmURL,
+		FetchedAt:     time.Now(),
+		Cluster:       body.Bytes(),
+	}, nil
+}
+
+// freshClusterMetadata returns the fresh cache entry of the cluster with --cached, nil otherwise. It only needs the
+// OCM URL, so that the lookups it serves don't connect to OCM.
+func freshClusterMetadata(ocmURL, key string) *ClusterMetadata {
+	if !ClusterCacheEnabled() {
+		return nil
+	}
+	cache, err := LoadClusterCache()
+	if err != nil {
+		return nil
+	}
+	cached := cache.Find(key, ocmURL)
+	if cached == nil || !cached.IsFresh(time.Now()) {
+		return nil
+	}
+	return cached
+}
+
+// cachedCluster returns the cluster from the cache with --cached, nil when it isn't cached or is stale
+func cachedCluster(connection *sdk.Connection, key string) *cmv1.Cluster {
+	cached := freshClusterMetadata(connection.URL(), key)
+	if cached == nil || len(cached.Cluster) == 0 {
+		return nil
+	}
+	cluster, err := cmv1.UnmarshalCluster([]byte(cached.Cluster))
+	if err != nil {
+		return nil
+	}
+	return cluster
+}
+
+// GetProvisionShard returns the provision shard of the cluster with the given internal ID. With --cached, the shard
+// of the cache entry of the cluster is returned instead.
+func GetProvisionShard(connection *sdk.Connection, clusterID string) (*cmv1.ProvisionShard, error) {
+	if ClusterCacheEnabled() {
+		metadata, err := GetClusterMetadata(connection, clusterID)
+		if err == nil && len(metadata.ProvisionShard) > 0 {
+			if shard, err := cmv1.UnmarshalProvisionShard([]byte(metadata.ProvisionShard)); err == nil {
+				return shard, nil
+			}
+		}
+	}
+	return fetchProvisionShard(connection, clusterID)
+}
+
+func fetchProvisionShard(connection *sdk.Connection, clusterID string) (*cmv1.ProvisionShard, error) {
+	response, err := connection.ClustersMgmt().V1().Clusters().Cluster(clusterID).ProvisionShard().Get().Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve the provision shard of cluster %s: %w", clusterID, err)
+	}
+	return response.Body(), nil
+}
+
+// storeCluster caches the cluster read from OCM with --cached, keeping the shard already cached
+func storeCluster(connection *sdk.Connection, cluster *cmv1.Cluster) {
+	if !ClusterCacheEnabled() {
+		return
+	}
+	cache, err := LoadClusterCache()
+	if err != nil {
+		return
+	}
+	metadata, err := newClusterMetadata(connection.URL(), cluster)
+	if err != nil {
+		return
+	}
+	if previous := cache.Find(cluster.ID(), connection.URL()); previous != nil {
+		metadata.Shard, metadata.ProvisionShard = previous.Shard, previous.ProvisionShard
+	}
+	cache.Store(metadata)
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to save cluster cache: %v\n", err)
+	}
+}
+
+// GetClusterMetadata returns cluster metadata, using the local cache when --cached is set.
+// When OCM cannot be reached, a stale cache entry is returned rather than failing.
+func GetClusterMetadata(connection *sdk.Connection, key string) (*ClusterMetadata, error) {
+	if !ClusterCacheEnabled() {
+		return FetchClusterMetadata(connection, key)
+	}
+
+	cache, err := LoadClusterCache()
+	if err != nil {
+		return nil, err
+	}
+
+	cached := cache.Find(key, connection.URL())
+	if cached != nil && cached.IsFresh(time.Now()) {
+		return cached, nil
+	}
+
+	metadata, err := FetchClusterMetadata(connection, key)
+	if err != nil {
+		if cached != nil && !strings.Contains(err.Error(), "there are 0 clusters") {
+			fmt.Fprintf(os.Stderr, "Warning: unable to refresh cluster metadata, using cached data from %s: %v\n", timefmt.Format(cached.FetchedAt), err)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	cache.Store(metadata)
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to save cluster cache: %v\n", err)
+	}
+	return metadata, nil
+}
+
+// InvalidateClusterMetadata is called by mutating commands once they changed a cluster. It drops the cached entry,
+// whether or not --cached is set, so that the next cached lookup fetches the cluster from OCM again.
+func InvalidateClusterMetadata(key string) {
+	cache, err := LoadClusterCache()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to invalidate the cluster cache: %v\n", err)
+		return
+	}
+	if !cache.Remove(key) {
+		return
+	}
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: unable to save cluster cache: %v\n", err)
+	}
+}
+
+// clusterPathPattern matches the paths of a cluster and of its sub-resources
+var clusterPathPattern = regexp.MustCompile(`^/api/clusters_mgmt/v1/clusters/([^/]+)`)
+
+type clusterCacheTransport struct {
+	wrapped http.RoundTripper
+}
+
+// ClusterCacheTransportWrapper drops the cache entry of a cluster once a change to it was sent to OCM, the same way
+// the response cache is dropped, so that neither cache serves the cluster as it was before the change
+func ClusterCacheTransportWrapper(wrapped http.RoundTripper) http.RoundTripper {
+	return &clusterCacheTransport{wrapped: wrapped}
+}
+
+func (t *clusterCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.wrapped.RoundTrip(req)
+	if err != nil || req.Method == http.MethodGet || req.Method == http.MethodHead || resp.StatusCode >= 400 {
+		return resp, err
+	}
+	if match := clusterPathPattern.FindStringSubmatch(req.URL.Path); match != nil {
+		InvalidateClusterMetadata(match[1])
+	}
+	return resp, err
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/spf13/viper"
+)
+
+func TestClusterCacheRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osdctl", clusterCacheFileName)
+
+	cache, err := loadClusterCacheFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading a missing cache: %v", err)
+	}
+	if len(cache.Clusters) != 0 {
+		t.Fatalf("expected an empty cache, got %d entries", len(cache.Clusters))
+	}
+
+	cache.Store(&ClusterMetadata{
+		ID:         "abc123",
+		ExternalID: "c0ffee00-0000-0000-0000-000000000000",
+		Name:       "my-cluster",
+		OCMURL:     "https://api.openshift.com",
+		FetchedAt:  time.Now(),
+	})
+	if err := cache.Save(); err != nil {
+		t.Fatalf("unexpected error saving the cache: %v", err)
+	}
+
+	reloaded, err := loadClusterCacheFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error reloading the cache: %v", err)
+	}
+	for _, key := range []string{"abc123", "c0ffee00-0000-0000-0000-000000000000", "my-cluster"} {
+		if reloaded.Find(key, "https://api.openshift.com") == nil {
+			t.Errorf("expected to find cluster by %s", key)
+		}
+	}
+	if reloaded.Find("my-cluster", "https://api.stage.openshift.com") != nil {
+		t.Error("expected entries from another OCM environment to be ignored")
+	}
+}
+
+func TestClusterCacheRemove(t *testing.T) {
+	cache, err := loadClusterCacheFrom(filepath.Join(t.TempDir(), clusterCacheFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Store(&ClusterMetadata{ID: "abc123", Name: "my-cluster", OCMURL: "https://api.openshift.com"})
+	cache.Store(&ClusterMetadata{ID: "def456", Name: "other-cluster", OCMURL: "https://api.openshift.com"})
+
+	if cache.Remove("unknown") {
+		t.Error("expected nothing to be removed for an unknown cluster")
+	}
+	if !cache.Remove("my-cluster") {
+		t.Error("expected the cluster to be removed by name")
+	}
+	if cache.Find("abc123", "https://api.openshift.com") != nil {
+		t.Error("expected the removed cluster to be gone")
+	}
+	if cache.Find("def456", "https://api.openshift.com") == nil {
+		t.Error("expected the other cluster to be kept")
+	}
+}
+
+func TestClusterCacheIgnoresCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), clusterCacheFileName)
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := loadClusterCacheFrom(path)
+	if err != nil {
+		t.Fatalf("expected a corrupt cache to be ignored, got %v", err)
+	}
+	if len(cache.Clusters) != 0 {
+		t.Fatalf("expected an empty cache, got %d entries", len(cache.Clusters))
+	}
+}
+
+func TestClusterMetadataIsFresh(t *testing.T) {
+	viper.Set(ClusterCacheTTLConfigKey, "10m")
+	defer viper.Set(ClusterCacheTTLConfigKey, defaultClusterCacheTTL.String())
+
+	now := time.Now()
+	if !(&ClusterMetadata{FetchedAt: now.Add(-5 * time.Minute)}).IsFresh(now) {
+		t.Error("expected an entry fetched 5 minutes ago to be fresh")
+	}
+	if (&ClusterMetadata{FetchedAt: now.Add(-15 * time.Minute)}).IsFresh(now) {
+		t.Error("expected an entry fetched 15 minutes ago to be stale")
+	}
+}
+
+// setupClusterCache points the cluster cache to a temporary directory with --cached
+func setupClusterCache(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	userCacheDir = func() (string, error) { return dir, nil }
+	viper.Set(UseClusterCacheConfigKey, true)
+	t.Cleanup(func() {
+		userCacheDir = os.UserCacheDir
+		viper.Set(UseClusterCacheConfigKey, false)
+	})
+}
+
+func TestGetClusterCached(t *testing.T) {
+	setupClusterCache(t)
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/accounts_mgmt/v1/subscriptions":
+			_, _ = io.WriteString(w, `{"kind": "SubscriptionList", "total": 1, "items": [{"kind": "Subscription", "cluster_id": "abc123"}]}`)
+		case "/api/clusters_mgmt/v1/clusters/abc123":
+			_, _ = io.WriteString(w, `{"kind": "Cluster", "id": "abc123", "name": "my-cluster", "external_id": "c0ffee00"}`)
+		}
+	}))
+	defer server.Close()
+	encode := base64.RawURLEncoding.EncodeToString
+	token := encode([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + encode([]byte(`{"typ":"Bearer","exp":4102444800}`)) + ".signature"
+	connection, err := sdk.NewConnectionBuilder().URL(server.URL).Tokens(token).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer connection.Close()
+
+	for _, key := range []string{"abc123", "my-cluster", "c0ffee00"} {
+		cluster, err := GetCluster(connection, key)
+		if err != nil || cluster.ID() != "abc123" {
+			t.Fatalf("expected to find abc123 by %s, got %v", key, err)
+		}
+	}
+	if len(requests) != 2 {
+		t.Errorf("expected the lookups after the first one to be served from the cache, got %v", requests)
+	}
+	cluster, err := GetClusterAnyStatus(connection, "my-cluster")
+	if err != nil || cluster.Name() != "my-cluster" || len(requests) != 2 {
+		t.Errorf("expected GetClusterAnyStatus to be served from the cache, got %v and %v", err, requests)
+	}
+}
+
+func TestGetHiveShardCachedDoesNotConnect(t *testing.T) {
+	setupClusterCache(t)
+	t.Setenv("OCM_URL", "production")
+	cache, err := LoadClusterCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Store(&ClusterMetadata{ID: "abc123", Shard: "https://api.hive-1.example.com:6443", OCMURL: "https://api.openshift.com", FetchedAt: time.Now()})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// There is no OCM configuration to connect with
+	t.Setenv("OCM_CONFIG", filepath.Join(t.TempDir(), "missing.json"))
+	shard, err := GetHiveShard("abc123")
+	if err != nil || shard != "https://api.hive-1.example.com:6443" {
+		t.Errorf("expected the cached shard, got %s and %v", shard, err)
+	}
+}
+
+func TestClusterCacheTransportWrapper(t *testing.T) {
+	setupClusterCache(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/clusters_mgmt/v1/clusters/denied" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	cache, err := LoadClusterCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"abc123", "def456", "denied"} {
+		cache.Store(&ClusterMetadata{ID: id, OCMURL: server.URL, FetchedAt: time.Now()})
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: ClusterCacheTransportWrapper(http.DefaultTransport)}
+	for _, request := range []struct{ method, path string }{
+		{http.MethodGet, "/api/clusters_mgmt/v1/clusters/def456"},
+		{http.MethodPost, "/api/clusters_mgmt/v1/clusters/abc123/limited_support_reasons"},
+		{http.MethodPatch, "/api/clusters_mgmt/v1/clusters/denied"},
+	} {
+		req, _ := http.NewRequest(request.method, server.URL+request.path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// Only the cluster which was changed is dropped
+	cache, err = LoadClusterCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Find("abc123", server.URL) != nil || cache.Find("def456", server.URL) == nil || cache.Find("denied", server.URL) == nil {
+		t.Errorf("expected only the changed cluster to be dropped, got %v", cache.Clusters)
+	}
+}
@@ -0,0 +1,286 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/printer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfirmTimeoutConfigKey is how long the prompts wait for an answer before aborting, so that a prompt
+	// forgotten in a terminal can't be confirmed hours later. 0 disables the timeout.
+	ConfirmTimeoutConfigKey = "confirm_timeout"
+	ConfirmTimeoutFlag      = "confirm-timeout"
+
+	defaultConfirmTimeout = 10 * time.Minute
+)
+
+// ImpactSummary describes the target and the effect of a mutating action, so the
+// user can double check what is about to happen before confirming
+type ImpactSummary struct {
+	Action      string
+	ClusterName string
+	ClusterID   string
+	ExternalID  string
+	// Organization is the ID of the organization owning the cluster, OrganizationName its name when known, both are
+	// printed so that the clusters of two customers with similar names aren't mixed up
+	Organization     string
+	OrganizationName string
+	Environment      string
+	// EnvironmentMismatch is set when the cluster doesn't seem to belong to the OCM environment, Confirm then
+	// refuses to go on, even when skipping the prompt
+	EnvironmentMismatch error
+	// OutOfScope is set when the tenant_scope of the config file doesn't allow changing the cluster, Confirm
+	// then refuses to go on, even when skipping the prompt
+	OutOfScope error
+}
+
+// ConfirmOptions configures a confirmation prompt
+type ConfirmOptions struct {
+	// Summary is printed before prompting, when set
+	Summary *ImpactSummary
+	// Diff shows the change to the resource after the summary, when set
+	Diff *printer.Diff
+	// TypedConfirmation, when set, requires the user to type this exact value instead of y/N.
+	// Use it for irreversible actions, e.g. with the cluster name.
+	TypedConfirmation string
+	// SkipPrompt bypasses the prompt entirely (e.g. --yes)
+	SkipPrompt bool
+
+	In  io.Reader
+	Out io.Writer
+}
+
+// AddConfirmFlags adds the --confirm-timeout flag and binds it to the config key, so that the flag takes
+// precedence over the config file
+func AddConfirmFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Duration(ConfirmTimeoutFlag, defaultConfirmTimeout, "Abort when a confirmation prompt isn't answered within this long, 0 to wait forever (config key: "+ConfirmTimeoutConfigKey+")")
+	_ = viper.BindPFlag(ConfirmTimeoutConfigKey, cmd.PersistentFlags().Lookup(ConfirmTimeoutFlag))
+}
+
+// ConfirmTimeout returns how long the prompts wait for an answer, the default when the config is invalid
+func ConfirmTimeout() time.Duration {
+	if !viper.IsSet(ConfirmTimeoutConfigKey) {
+		return defaultConfirmTimeout
+	}
+	timeout, err := time.ParseDuration(viper.GetString(ConfirmTimeoutConfigKey))
+	if err != nil || timeout < 0 {
+		return defaultConfirmTimeout
+	}
+	return timeout
+}
+
+// ReadAnswer reads a line answering a prompt. It gives up with an error once the confirm timeout passed, and when
+// the command is interrupted or times out, the callers then abort instead of going on.
+func ReadAnswer(in io.Reader) (string, error) {
+	ctx, cancel := deadline.Context(), context.CancelFunc(func() {})
+	timeout := ConfirmTimeout()
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	type answer struct {
+		line string
+		err  error
+	}
+	answers := make(chan answer, 1)
+	// bufio.NewReader returns the reader as is when it already is one, the callers reading several answers keep
+	// what was read ahead. The read is left behind on timeout, osdctl aborts anyway.
+	reader := bufio.NewReader(in)
+	go func() {
+		line, err := reader.ReadString('\n')
+		answers <- answer{line, err}
+	}()
+
+	select {
+	case a := <-answers:
+		return a.line, a.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && deadline.Context().Err() == nil {
+			return "", fmt.Errorf("no answer within %s (--%s), exiting without going on", timeout, ConfirmTimeoutFlag)
+		}
+		return "", ctx.Err()
+	}
+}
+
+// NewClusterImpactSummary builds an ImpactSummary for an action targeting the given cluster.
+// A missing organization is not fatal, as the summary is purely informational.
+func NewClusterImpactSummary(connection *sdk.Connection, cluster *cmv1.Cluster, action string) *ImpactSummary {
+	summary := &ImpactSummary{
+		Action:      action,
+		ClusterName: cluster.Name(),
+		ClusterID:   cluster.ID(),
+		ExternalID:  cluster.ExternalID(),
+		Environment: GetCurrentOCMEnv(connection),
+	}
+	summary.EnvironmentMismatch = checkClusterEnvironment(summary.Environment, cluster)
+
+	orgID, err := GetOrgfromClusterID(connection, *cluster)
+	if err != nil {
+		orgID = ""
+		summary.Organization = "unknown"
+	} else {
+		summary.Organization = orgID
+		summary.OrganizationName = getOrganizationName(connection, orgID)
+	}
+
+	scope, err := GetTenantScope()
+	if err != nil {
+		summary.OutOfScope = err
+	} else if scope != nil {
+		summary.OutOfScope = scope.Check(orgID, cluster)
+	}
+
+	return summary
+}
+
+// target names the cluster and its customer, e.g. my-cluster (external ID 0a1b...) of Acme, empty without a cluster
+func (s *ImpactSummary) target() string {
+	if s.ClusterName == "" && s.ClusterID == "" {
+		return ""
+	}
+	target := s.ClusterName
+	if target == "" {
+		target = s.ClusterID
+	}
+	if s.ExternalID != "" {
+		target += fmt.Sprintf(" (external ID %s)", s.ExternalID)
+	}
+	switch {
+	case s.OrganizationName != "":
+		target += " of " + s.OrganizationName
+	case s.Organization != "" && s.Organization != "unknown":
+		target += " of organization " + s.Organization
+	}
+	return target
+}
+
+// getOrganizationName returns the name of the organization, empty when it can't be read
+func getOrganizationName(connection *sdk.Connection, orgID string) string {
+	response, err := connection.AccountsMgmt().V1().Organizations().Organization(orgID).Get().Send()
+	if err != nil {
+		return ""
+	}
+	return response.Body().Name()
+}
+
+// Print writes the summary as a table to the given writer
+func (s *ImpactSummary) Print(out io.Writer) error {
+	table := printer.NewTablePrinter(out, 20, 1, 3, ' ')
+	if s.Action != "" {
+		table.AddRow([]string{"Action:", s.Action})
+	}
+	if s.ClusterName != "" || s.ClusterID != "" {
+		table.AddRow([]string{"Cluster:", fmt.Sprintf("%s (%s)", s.ClusterName, s.ClusterID)})
+	}
+	if s.ExternalID != "" {
+		table.AddRow([]string{"External ID:", s.ExternalID})
+	}
+	if s.OrganizationName != "" {
+		table.AddRow([]string{"Organization:", fmt.Sprintf("%s (%s)", s.OrganizationName, s.Organization)})
+	} else if s.Organization != "" {
+		table.AddRow([]string{"Organization:", s.Organization})
+	}
+	if s.Environment != "" {
+		table.AddRow([]string{"Environment:", s.Environment})
+	}
+	if s.EnvironmentMismatch != nil {
+		table.AddRow([]string{"Warning:", s.EnvironmentMismatch.Error()})
+	}
+	if s.OutOfScope != nil {
+		table.AddRow([]string{"Blocked:", s.OutOfScope.Error()})
+	}
+	// Add empty row for readability
+	table.AddRow([]string{})
+	return table.Flush()
+}
+
+// Confirm prints the impact summary if one is given and asks the user to confirm.
+// It returns an error if the user declined.
+func Confirm(opts ConfirmOptions) error {
+	if opts.In == nil {
+		opts.In = os.Stdin
+	}
+	if opts.Out == nil {
+		opts.Out = os.Stderr
+	}
+
+	if opts.Summary != nil {
+		if err := opts.Summary.Print(opts.Out); err != nil {
+			return err
+		}
+	}
+	if opts.Diff != nil {
+		if err := opts.Diff.Print(opts.Out); err != nil {
+			return err
+		}
+	}
+
+	if opts.Summary != nil && opts.Summary.EnvironmentMismatch != nil {
+		return opts.Summary.EnvironmentMismatch
+	}
+	if opts.Summary != nil && opts.Summary.OutOfScope != nil {
+		return opts.Summary.OutOfScope
+	}
+
+	if opts.SkipPrompt {
+		return nil
+	}
+
+	reader := bufio.NewReader(opts.In)
+	// The prompt repeats the cluster and its customer, the last thing read before confirming
+	target := ""
+	if opts.Summary != nil {
+		target = opts.Summary.target()
+	}
+
+	if opts.TypedConfirmation != "" {
+		if target != "" {
+			fmt.Fprintf(opts.Out, "This action cannot be undone on %s. Type '%s' to continue: ", target, opts.TypedConfirmation)
+		} else {
+			fmt.Fprintf(opts.Out, "This action cannot be undone. Type '%s' to continue: ", opts.TypedConfirmation)
+		}
+		response, err := ReadAnswer(reader)
+		if err != nil && response == "" {
+			return err
+		}
+		if strings.TrimSpace(response) != opts.TypedConfirmation {
+			return fmt.Errorf("confirmation did not match '%s', exiting", opts.TypedConfirmation)
+		}
+		return nil
+	}
+
+	for {
+		if target != "" {
+			fmt.Fprintf(opts.Out, "Continue with %s? (y/N): ", target)
+		} else {
+			fmt.Fprint(opts.Out, "Continue? (y/N): ")
+		}
+		response, err := ReadAnswer(reader)
+		if err != nil && response == "" {
+			return err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(response)) {
+		case "y", "yes":
+			return nil
+		case "n", "no", "":
+			return fmt.Errorf("Exiting...")
+		default:
+			fmt.Fprintln(opts.Out, "Invalid input. Expecting (y)es or (N)o")
+		}
+	}
+}
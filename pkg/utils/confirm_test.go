@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestConfirm(t *testing.T) {
+	testCases := []struct {
+		title       string
+		opts        ConfirmOptions
+		input       string
+		errExpected bool
+	}{
+		{
+			title: "accepts yes",
+			input: "y\n",
+		},
+		{
+			title:       "declines by default",
+			input:       "\n",
+			errExpected: true,
+		},
+		{
+			title: "reprompts on invalid input",
+			input: "maybe\nyes\n",
+		},
+		{
+			title: "skip prompt does not read input",
+			opts:  ConfirmOptions{SkipPrompt: true},
+		},
+		{
+			title: "typed confirmation matches",
+			opts:  ConfirmOptions{TypedConfirmation: "my-cluster"},
+			input: "my-cluster\n",
+		},
+		{
+			title:       "typed confirmation does not accept y",
+			opts:        ConfirmOptions{TypedConfirmation: "my-cluster"},
+			input:       "y\n",
+			errExpected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			tc.opts.In = strings.NewReader(tc.input)
+			tc.opts.Out = out
+
+			err := Confirm(tc.opts)
+			if tc.errExpected && err == nil {
+				t.Fatalf("expected an error, got none")
+			}
+			if !tc.errExpected && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfirmPrintsSummary(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := Confirm(ConfirmOptions{
+		Summary: &ImpactSummary{
+			Action:       "Delete limited support reason 'abc'",
+			ClusterName:  "my-cluster",
+			ClusterID:    "1234",
+			Organization: "org-id",
+			Environment:  "production",
+		},
+		SkipPrompt: true,
+		Out:        out,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{"Delete limited support reason 'abc'", "my-cluster (1234)", "org-id", "production"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected summary to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestConfirmNamesTheCustomer(t *testing.T) {
+	summary := &ImpactSummary{
+		Action:           "Delete limited support reason 'abc'",
+		ClusterName:      "my-cluster",
+		ClusterID:        "1234",
+		ExternalID:       "0a1b2c3d-4e5f",
+		Organization:     "org-id",
+		OrganizationName: "Acme Corp",
+	}
+
+	out := &bytes.Buffer{}
+	if err := Confirm(ConfirmOptions{Summary: summary, In: strings.NewReader("y\n"), Out: out}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"Acme Corp (org-id)", "External ID:", "Continue with my-cluster (external ID 0a1b2c3d-4e5f) of Acme Corp? (y/N): "} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected the output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	err := Confirm(ConfirmOptions{Summary: summary, TypedConfirmation: "my-cluster", In: strings.NewReader("my-cluster\n"), Out: out})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "cannot be undone on my-cluster (external ID 0a1b2c3d-4e5f) of Acme Corp."; !strings.Contains(out.String(), expected) {
+		t.Errorf("expected the output to contain %q, got:\n%s", expected, out.String())
+	}
+
+	// Without the name of the organization, its ID is given
+	summary.OrganizationName = ""
+	if target := summary.target(); target != "my-cluster (external ID 0a1b2c3d-4e5f) of organization org-id" {
+		t.Errorf("unexpected target %q", target)
+	}
+	if target := (&ImpactSummary{Action: "clean 3 stale account claims"}).target(); target != "" {
+		t.Errorf("expected no target without a cluster, got %q", target)
+	}
+}
+
+func TestConfirmTimesOut(t *testing.T) {
+	defer viper.Set(ConfirmTimeoutConfigKey, nil)
+	viper.Set(ConfirmTimeoutConfigKey, "10ms")
+
+	// Nobody answers the forgotten prompt, a late answer doesn't confirm it
+	in, answer := io.Pipe()
+	defer answer.Close()
+	err := Confirm(ConfirmOptions{In: in, Out: &bytes.Buffer{}})
+	if err == nil || !strings.Contains(err.Error(), "no answer within 10ms") {
+		t.Fatalf("expected the prompt to time out, got %v", err)
+	}
+}
+
+func TestConfirmTimeout(t *testing.T) {
+	defer viper.Set(ConfirmTimeoutConfigKey, nil)
+	for value, expected := range map[string]time.Duration{"": defaultConfirmTimeout, "30s": 30 * time.Second, "0": 0, "soon": defaultConfirmTimeout, "-1m": defaultConfirmTimeout} {
+		if value == "" {
+			viper.Set(ConfirmTimeoutConfigKey, nil)
+		} else {
+			viper.Set(ConfirmTimeoutConfigKey, value)
+		}
+		if got := ConfirmTimeout(); got != expected {
+			t.Errorf("expected %s for '%s', got %s", expected, value, got)
+		}
+	}
+}
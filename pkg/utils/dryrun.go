@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/pflag"
+)
+
+// DryRun is the value of a --dry-run flag: none, client or server
+type DryRun string
+
+const (
+	// DryRunNone sends the request
+	DryRunNone DryRun = "none"
+	// DryRunClient only prints what would be sent
+	DryRunClient DryRun = "client"
+	// DryRunServer sends the request with OCM's dry-run parameter, so that OCM validates it without applying it
+	DryRunServer DryRun = "server"
+)
+
+// serverDryRunEndpoint is an OCM endpoint taking a dry-run parameter, the parameter name isn't the same in every API
+type serverDryRunEndpoint struct {
+	method    string
+	path      *regexp.Regexp
+	parameter string
+}
+
+var serverDryRunEndpoints = []serverDryRunEndpoint{
+	{method: http.MethodDelete, path: regexp.MustCompile(`^/api/clusters_mgmt/v1/clusters/[^/]+$`), parameter: "dry_run"},
+	{method: http.MethodPatch, path: regexp.MustCompile(`^/api/addons_mgmt/v1/addons/[^/]+$`), parameter: "dryRun"},
+}
+
+// AddDryRunFlag adds --dry-run/-d, where a bare --dry-run means --dry-run=client
+func AddDryRunFlag(flags *pflag.FlagSet, dryRun *DryRun, usage string) {
+	*dryRun = DryRunNone
+	flags.VarP(dryRun, "dry-run", "d", usage)
+	flags.Lookup("dry-run").NoOptDefVal = string(DryRunClient)
+}
+
+func (d *DryRun) String() string {
+	return string(*d)
+}
+
+func (d *DryRun) Set(value string) error {
+	switch strings.ToLower(value) {
+	case "", "false", string(DryRunNone):
+		*d = DryRunNone
+	case "true", string(DryRunClient):
+		*d = DryRunClient
+	case string(DryRunServer):
+		*d = DryRunServer
+	default:
+		return fmt.Errorf("invalid dry-run value '%s', expected 'none', 'client' or 'server'", value)
+	}
+	return nil
+}
+
+func (d *DryRun) Type() string {
+	return "string"
+}
+
+// ServerDryRunParameter returns the query parameter making OCM validate the request without applying it. OCM ignores
+// unknown parameters, so requests to endpoints without a dry-run parameter would be applied: they return an error.
+func ServerDryRunParameter(method, path string) (string, error) {
+	path = strings.TrimSuffix(strings.SplitN(path, "?", 2)[0], "/")
+	for _, endpoint := range serverDryRunEndpoints {
+		if endpoint.method == method && endpoint.path.MatchString(path) {
+			return endpoint.parameter, nil
+		}
+	}
+	return "", osdctlErrors.New(osdctlErrors.ErrValidation,
+		"OCM doesn't support a server-side dry-run of %s %s, use --dry-run=client to print the request instead", method, path)
+}
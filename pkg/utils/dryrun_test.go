@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/pflag"
+)
+
+func TestDryRunFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected DryRun
+		err      bool
+	}{
+		{args: nil, expected: DryRunNone},
+		{args: []string{"--dry-run"}, expected: DryRunClient},
+		{args: []string{"-d"}, expected: DryRunClient},
+		{args: []string{"--dry-run=client"}, expected: DryRunClient},
+		{args: []string{"--dry-run=server"}, expected: DryRunServer},
+		{args: []string{"--dry-run=false"}, expected: DryRunNone},
+		{args: []string{"--dry-run=always"}, err: true},
+	}
+	for _, tt := range tests {
+		var dryRun DryRun
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		AddDryRunFlag(flags, &dryRun, "")
+		err := flags.Parse(tt.args)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%v: expected an error", tt.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", tt.args, err)
+		}
+		if dryRun != tt.expected {
+			t.Errorf("%v: expected %s, got %s", tt.args, tt.expected, dryRun)
+		}
+	}
+}
+
+func TestServerDryRunParameter(t *testing.T) {
+	parameter, err := ServerDryRunParameter("DELETE", "/api/clusters_mgmt/v1/clusters/abc")
+	if err != nil || parameter != "dry_run" {
+		t.Errorf("expected dry_run, got %q, %v", parameter, err)
+	}
+	parameter, err = ServerDryRunParameter("PATCH", "/api/addons_mgmt/v1/addons/my-addon/")
+	if err != nil || parameter != "dryRun" {
+		t.Errorf("expected dryRun, got %q, %v", parameter, err)
+	}
+
+	for _, request := range [][2]string{
+		{"PATCH", "/api/clusters_mgmt/v1/clusters/abc"},
+		{"DELETE", "/api/clusters_mgmt/v1/clusters/abc/identity_providers/def"},
+	} {
+		if _, err := ServerDryRunParameter(request[0], request[1]); !errors.Is(err, osdctlErrors.ErrValidation) {
+			t.Errorf("%s %s: expected a validation error, got %v", request[0], request[1], err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+// environmentDomains maps the base domains of the clusters installed by each OCM environment
+var environmentDomains = []struct {
+	suffix      string
+	environment string
+}{
+	{suffix: ".i1.devshift.org", environment: "integration"},
+	{suffix: ".s1.devshift.org", environment: "stage"},
+	{suffix: "openshiftapps.com", environment: "production"},
+}
+
+// ClusterDomain returns the DNS domain of the cluster, below which are its api and *.apps records,
+// e.g. mycluster.abcd.p1.openshiftapps.com
+func ClusterDomain(cluster *cmv1.Cluster) string {
+	return cluster.Name() + "." + cluster.DNS().BaseDomain()
+}
+
+// clusterEnvironment guesses the OCM environment that installed the cluster from its base domain or API URL.
+// It returns an empty string for domains it doesn't know.
+func clusterEnvironment(cluster *cmv1.Cluster) string {
+	domain := cluster.DNS().BaseDomain()
+	if domain == "" {
+		if apiURL, err := url.Parse(cluster.API().URL()); err == nil {
+			domain = apiURL.Hostname()
+		}
+	}
+	if domain == "" {
+		return ""
+	}
+
+	domain = "." + strings.TrimSuffix(domain, ".")
+	for _, d := range environmentDomains {
+		if strings.HasSuffix(domain, d.suffix) {
+			return d.environment
+		}
+	}
+	return ""
+}
+
+// CheckClusterEnvironment returns an error when the cluster doesn't seem to belong to the OCM environment of the
+// connection. Cluster names, and sometimes IDs, exist in several environments, so the cluster that has been found
+// may not be the one the user meant.
+func CheckClusterEnvironment(connection *sdk.Connection, cluster *cmv1.Cluster) error {
+	return checkClusterEnvironment(GetCurrentOCMEnv(connection), cluster)
+}
+
+func checkClusterEnvironment(ocmEnvironment string, cluster *cmv1.Cluster) error {
+	environment := clusterEnvironment(cluster)
+	if environment == "" || environment == ocmEnvironment {
+		return nil
+	}
+	return osdctlErrors.New(osdctlErrors.ErrValidation,
+		"cluster '%s' (%s) looks like a %s cluster, but you are logged in to %s OCM. Log in to the %s environment, "+
+			"or use the internal cluster ID if another cluster with the same name exists there",
+		cluster.Name(), cluster.ID(), environment, ocmEnvironment, environment)
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+func TestClusterDomain(t *testing.T) {
+	cluster, err := cmv1.NewCluster().Name("mycluster").DNS(cmv1.NewDNS().BaseDomain("abcd.p1.openshiftapps.com")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ClusterDomain(cluster); got != "mycluster.abcd.p1.openshiftapps.com" {
+		t.Errorf("expected mycluster.abcd.p1.openshiftapps.com, got %s", got)
+	}
+}
+
+func TestCheckClusterEnvironment(t *testing.T) {
+	testCases := []struct {
+		title       string
+		cluster     *cmv1.ClusterBuilder
+		environment string
+		errExpected bool
+	}{
+		{
+			title:       "production cluster in production",
+			cluster:     cmv1.NewCluster().DNS(cmv1.NewDNS().BaseDomain("abcd.p1.openshiftapps.com")),
+			environment: "production",
+		},
+		{
+			title:       "stage cluster in production",
+			cluster:     cmv1.NewCluster().DNS(cmv1.NewDNS().BaseDomain("abcd.s1.devshift.org")),
+			environment: "production",
+			errExpected: true,
+		},
+		{
+			title:       "production cluster in stage, from the API URL",
+			cluster:     cmv1.NewCluster().API(cmv1.NewClusterAPI().URL("https://api.my-cluster.abcd.p1.openshiftapps.com:6443")),
+			environment: "stage",
+			errExpected: true,
+		},
+		{
+			title:       "integration cluster in integration",
+			cluster:     cmv1.NewCluster().DNS(cmv1.NewDNS().BaseDomain("abcd.i1.devshift.org")),
+			environment: "integration",
+		},
+		{
+			title:       "unknown domain is not checked",
+			cluster:     cmv1.NewCluster().DNS(cmv1.NewDNS().BaseDomain("example.com")),
+			environment: "production",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			cluster, err := tc.cluster.Name("my-cluster").ID("1234").Build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkClusterEnvironment(tc.environment, cluster)
+			if tc.errExpected && !errors.Is(err, osdctlErrors.ErrValidation) {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+			if !tc.errExpected && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfirmRefusesEnvironmentMismatch(t *testing.T) {
+	mismatch := errors.New("wrong environment")
+	err := Confirm(ConfirmOptions{
+		Summary:    &ImpactSummary{ClusterName: "my-cluster", EnvironmentMismatch: mismatch},
+		SkipPrompt: true,
+		Out:        &bytes.Buffer{},
+	})
+	if !errors.Is(err, mismatch) {
+		t.Fatalf("expected the environment mismatch to abort, got %v", err)
+	}
+}
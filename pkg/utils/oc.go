@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/recording"
+)
+
+// backplaneClusterAdmin is impersonated by the oc commands changing the cluster
+const backplaneClusterAdmin = "backplane-cluster-admin"
+
+// OCRunner runs oc with the given arguments and returns its standard output
+type OCRunner func(args ...string) ([]byte, error)
+
+// RunOCAsClusterAdmin runs oc against the cluster of the current kubeconfig, impersonating backplane-cluster-admin
+func RunOCAsClusterAdmin(args ...string) ([]byte, error) {
+	return RunOC(append([]string{"--as", backplaneClusterAdmin}, args...)...)
+}
+
+// RunOC runs oc against the cluster of the current kubeconfig as the current user, e.g. for 'oc whoami'
+func RunOC(args ...string) ([]byte, error) {
+	if err := readonly.CheckOC(args); err != nil {
+		return nil, err
+	}
+	return recording.OC(args, func() ([]byte, error) {
+		cmd := exec.Command("oc", args...) //#nosec G204 -- arguments are built by the command
+		var stderr strings.Builder
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("oc %s failed: %v: %s", ocVerb(args), err, strings.TrimSpace(stderr.String()))
+		}
+		return output, nil
+	})
+}
+
+// ocVerb returns the verb of the oc arguments, after the impersonation flags
+func ocVerb(args []string) string {
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--as" {
+			i++
+			continue
+		}
+		return args[i]
+	}
+	return ""
+}
+
+// CheckOCCluster checks that the current kubeconfig is logged in to the cluster
+func CheckOCCluster(run OCRunner, cluster *cmv1.Cluster) error {
+	externalID, err := run("get", "clusterversion", "version", "-o", "jsonpath={.spec.clusterID}")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(externalID)) != cluster.ExternalID() {
+		return fmt.Errorf("the current kubeconfig isn't logged in to %s, run 'ocm backplane login %s' first", cluster.Name(), cluster.ID())
+	}
+	return nil
+}
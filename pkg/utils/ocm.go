@@ -1,20 +1,46 @@
 package utils
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws/arn"
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	v1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/deprecation"
+	"github.com/openshift/osdctl/pkg/justification"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/openshift/osdctl/pkg/ratelimit"
+	"github.com/openshift/osdctl/pkg/readonly"
+	"github.com/openshift/osdctl/pkg/recording"
+	"github.com/openshift/osdctl/pkg/responsecache"
+	"github.com/openshift/osdctl/pkg/trace"
+	"github.com/spf13/viper"
 )
 
 const ClusterServiceClusterSearch = "id = '%s' or name = '%s' or external_id = '%s'"
 
+// The OCM credentials of a profile written by 'osdctl auth mint-token', either an access token or the client
+// credentials of a service account
+const (
+	OCMURLConfigKey          = "ocm_url"
+	OCMTokenConfigKey        = "ocm_token"
+	OCMClientIDConfigKey     = "ocm_client_id"
+	OCMClientSecretConfigKey = "ocm_client_secret"
+)
+
+const (
+	ocmConfigError     = "Unable to load OCM config\nLogin with 'ocm login' or set OCM_TOKEN and OCM_URL environment variables"
+	ocmInvalidURLError = "Invalid OCM_URL found: %s\nValid URL aliases are: 'production', 'staging', 'integration'"
+)
+
 const (
 	productionURL  = "https://api.openshift.com"
 	stagingURL     = "https://api.stage.openshift.com"
@@ -52,8 +78,20 @@ type Config struct {
 }
 
 // GetClusterAnyStatus returns an OCM cluster object given an OCM connection and cluster id
-// (internal and external ids both supported).
+// (internal and external ids both supported). With --cached, a fresh cached cluster is returned instead.
 func GetClusterAnyStatus(conn *sdk.Connection, clusterId string) (*v1.Cluster, error) {
+	if cluster := cachedCluster(conn, clusterId); cluster != nil {
+		return cluster, nil
+	}
+	cluster, err := getClusterAnyStatus(conn, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	storeCluster(conn, cluster)
+	return cluster, nil
+}
+
+func getClusterAnyStatus(conn *sdk.Connection, clusterId string) (*v1.Cluster, error) {
 	// identifier in the accounts management service. To find those clusters we need to check
 	// directly in the clusters management service.
 	clustersSearch := fmt.Sprintf(ClusterServiceClusterSearch, clusterId, clusterId, clusterId)
@@ -78,7 +116,7 @@ func GetClusters(ocmClient *sdk.Connection, clusterIds []string) []*v1.Cluster {
 
 	clusters, err := ApplyFilters(ocmClient, []string{strings.Join(clusterIds, " or ")})
 	if err != nil {
-		log.Fatalf("error while retrieving cluster(s) from ocm: %[1]s", err)
+		osdctlErrors.CheckErr(fmt.Errorf("error while retrieving cluster(s) from ocm: %w", err))
 	}
 
 	return clusters
@@ -213,72 +251,125 @@ func loadOCMConfig() (*Config, error) {
 	return cfg, nil
 }
 
-func CreateConnection() *sdk.Connection {
-	token := os.Getenv("OCM_TOKEN")
+// GetOCMURL returns the OCM API gateway URL that CreateConnection will use, without connecting to it
+func GetOCMURL() (string, error) {
 	url := os.Getenv("OCM_URL")
-
-	// Unlikely to be set, but check anyway
-	refresh_token := os.Getenv("OCM_REFRESH_TOKEN")
-
-	ocmConfigError := "Unable to load OCM config\nLogin with 'ocm login' or set OCM_TOKEN and OCM_URL environment variables"
-	ocmInvalidURLError := "Invalid OCM_URL found: %s\nValid URL aliases are: 'production', 'staging', 'integration'"
-
-	connectionBuilder := sdk.NewConnectionBuilder()
-
-	config := &Config{}
-	err := error(nil)
-
-	if token == "" || url == "" {
-		// If either token or url are not set, try to load them from the config file
-		config, err = loadOCMConfig()
+	if url == "" {
+		url = viper.GetString(OCMURLConfigKey)
+	}
+	if url == "" {
+		config, err := loadOCMConfig()
 		if err != nil {
-			log.Fatal(ocmConfigError)
-			return nil
+			return "", err
+		}
+		if config != nil {
+			url = config.URL
 		}
 	}
 
-	if token == "" {
-		token = config.AccessToken
-		refresh_token = config.RefreshToken
-
-		// Can't both be nil
-		if token == "" && refresh_token == "" {
-			log.Fatal(ocmConfigError)
-			return nil
-		}
+	gatewayURL, ok := urlAliases[url]
+	if !ok {
+		return "", fmt.Errorf("invalid OCM_URL found: '%s'", url)
 	}
+	return gatewayURL, nil
+}
+
+// IsProductionOCMURL reports whether the gateway URL is the production OCM environment
+func IsProductionOCMURL(url string) bool {
+	return url == productionURL
+}
 
-	connectionBuilder.Tokens(token, refresh_token)
+// CreateConnection returns a connection to OCM, exiting with the exit code of the error when the user isn't logged
+// in
+func CreateConnection() *sdk.Connection {
+	connection, err := NewConnection()
+	osdctlErrors.CheckErr(err)
+	return connection
+}
 
+// NewConnection returns a connection to OCM, or an error when the user isn't logged in, for the callers that can do
+// without OCM
+func NewConnection() (*sdk.Connection, error) {
+	url := os.Getenv("OCM_URL")
+	// The URL of a profile written by 'osdctl auth mint-token' comes after the environment variable
 	if url == "" {
-		url = config.URL
-		if url == "" {
-			log.Fatal(ocmConfigError)
-			return nil
+		url = viper.GetString(OCMURLConfigKey)
+	}
+	// The replayed sessions don't need an OCM configuration
+	if url == "" && recording.Replaying() {
+		url = "production"
+	}
+	if url == "" {
+		// If the url isn't set, try to load it from the config file
+		config, err := loadOCMConfig()
+		if err != nil || config == nil || config.URL == "" {
+			return nil, osdctlErrors.Wrap(osdctlErrors.ErrForbidden, errors.New(ocmConfigError))
 		}
+		url = config.URL
 	}
 
 	// Parse the possible URLs
-	if url != "" {
-		gatewayURL, ok := urlAliases[url]
-		if !ok {
-			log.Fatalf(ocmInvalidURLError, url)
-		}
-		connectionBuilder.URL(gatewayURL)
-	} else {
-		log.Fatalf(ocmInvalidURLError, "\"\"")
+	gatewayURL, ok := urlAliases[url]
+	if !ok {
+		return nil, osdctlErrors.New(osdctlErrors.ErrValidation, ocmInvalidURLError, url)
+	}
+
+	connectionBuilder := sdk.NewConnectionBuilder()
+	connectionBuilder.URL(gatewayURL)
+	if recording.Replaying() {
+		connectionBuilder.Tokens(recording.ReplayToken())
+	} else if err := configureOCMAuth(connectionBuilder); err != nil {
+		return nil, err
 	}
 
+	// The first wrapper is the outermost, the shared context also bounds the wait for the rate limiter
+	connectionBuilder.TransportWrapper(deadline.OCMTransportWrapper)
+	// A change drops the cache entry of the cluster, whether or not the response cache is on
+	connectionBuilder.TransportWrapper(ClusterCacheTransportWrapper)
+	// The cached responses don't wait for the rate limiter
+	connectionBuilder.TransportWrapper(responsecache.OCMTransportWrapper)
+	// Share a single rate limiter between all connections so batch commands don't get throttled
+	connectionBuilder.TransportWrapper(ratelimit.OCMTransportWrapper)
+	connectionBuilder.TransportWrapper(readonly.OCMTransportWrapper)
+	connectionBuilder.TransportWrapper(justification.OCMTransportWrapper)
+	connectionBuilder.TransportWrapper(deprecation.OCMTransportWrapper)
+	connectionBuilder.TransportWrapper(trace.OCMTransportWrapper)
+	// The innermost, so that the replayed requests still go through the other wrappers
+	connectionBuilder.TransportWrapper(recording.OCMTransportWrapper)
+
 	connection, err := connectionBuilder.Build()
 
 	if err != nil {
 		if strings.Contains(err.Error(), "Not logged in, run the") {
-			log.Fatal(ocmConfigError)
+			return nil, osdctlErrors.Wrap(osdctlErrors.ErrForbidden, errors.New(ocmConfigError))
 		}
-		log.Fatalf("Failed to create OCM connection: %v", err)
+		return nil, fmt.Errorf("Failed to create OCM connection: %v", err)
 	}
 
-	return connection
+	return connection, nil
+}
+
+// TokenExpiry returns the expiry of a JWT, without verifying it. The zero time is returned for tokens
+// that don't expire, like offline refresh tokens.
+func TokenExpiry(token string) (time.Time, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("can't decode the JWT payload: %v", err)
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("can't parse the JWT claims: %v", err)
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(claims.Exp, 0).UTC(), nil
 }
 
 func GetSupportRoleArnForCluster(ocmClient *sdk.Connection, clusterID string) (string, error) {
@@ -338,30 +429,27 @@ func IsClusterCCS(ocmClient *sdk.Connection, clusterID string) (bool, error) {
 // Returns the hive shard corresponding to a cluster
 // e.g. https://api.<hive_cluster>.byo5.p1.openshiftapps.com:6443
 func GetHiveShard(clusterID string) (string, error) {
-	connection := CreateConnection()
-	defer connection.Close()
-
-	shardPath, err := connection.ClustersMgmt().V1().Clusters().
-		Cluster(clusterID).
-		ProvisionShard().
-		Get().
-		Send()
+	// The cached shard doesn't need a connection
+	if ocmURL, err := GetOCMURL(); err == nil {
+		if cached := freshClusterMetadata(ocmURL, clusterID); cached != nil && cached.Shard != "" {
+			return cached.Shard, nil
+		}
+	}
 
+	connection, err := NewConnection()
 	if err != nil {
 		return "", err
 	}
+	defer connection.Close()
 
-	var shard string
-
-	if shardPath != nil {
-		shard = shardPath.Body().HiveConfig().Server()
+	shard, err := GetProvisionShard(connection, clusterID)
+	if err != nil {
+		return "", err
 	}
-
-	if shard == "" {
+	if shard.HiveConfig().Server() == "" {
 		return "", fmt.Errorf("Unable to retrieve shard for cluster %s", clusterID)
 	}
-
-	return shard, nil
+	return shard.HiveConfig().Server(), nil
 }
 
 // Returns the backplane url corresponding to a cluster e.g.
@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/deadline"
+	"github.com/openshift/osdctl/pkg/secrets"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// OCMAuthConfigKey selects how osdctl authenticates to OCM, so that every config file or profile can use its own
+// flow. The first credentials found are used when it's unset, see ocmAuthAuto.
+const OCMAuthConfigKey = "ocm_auth"
+
+// The OCM authentication backends
+const (
+	// OCMAuthOfflineToken uses the tokens of the environment, the secrets store or 'ocm login'
+	OCMAuthOfflineToken = "offline-token"
+	// OCMAuthClientCredentials uses the client credentials of a service account, for headless automation
+	OCMAuthClientCredentials = "client-credentials"
+	// OCMAuthDeviceCode logs in through a browser on any device, for the shared jump hosts
+	OCMAuthDeviceCode = "device-code"
+)
+
+// OCMClientSecretEnv holds the client secret of the service account, instead of the config file
+const OCMClientSecretEnv = "OCM_CLIENT_SECRET"
+
+// ocmDeviceClientID is the SSO client of the device code flow, the one 'ocm login --use-device-code' uses
+const ocmDeviceClientID = "ocm-cli"
+
+// ocmAuthBackend sets the credentials of the OCM connections
+type ocmAuthBackend func(builder *sdk.ConnectionBuilder) error
+
+var ocmAuthBackends = map[string]ocmAuthBackend{
+	"":                       ocmAuthAuto,
+	OCMAuthOfflineToken:      ocmAuthOfflineToken,
+	OCMAuthClientCredentials: ocmAuthClientCredentials,
+	OCMAuthDeviceCode:        ocmAuthDeviceCode,
+}
+
+// Swapped in tests
+var (
+	ocmTokenURL      = sdk.DefaultTokenURL
+	ocmDeviceAuthURL = strings.TrimSuffix(sdk.DefaultTokenURL, "/token") + "/auth/device"
+	sleep            = time.Sleep
+)
+
+// OCMAuthBackends returns the values of ocm_auth, sorted
+func OCMAuthBackends() []string {
+	var names []string
+	for name := range ocmAuthBackends {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// configureOCMAuth sets the credentials of the backend selected by ocm_auth
+func configureOCMAuth(builder *sdk.ConnectionBuilder) error {
+	name := viper.GetString(OCMAuthConfigKey)
+	backend, ok := ocmAuthBackends[name]
+	if !ok {
+		return fmt.Errorf("invalid '%s' '%s', expected one of: %s", OCMAuthConfigKey, name, strings.Join(OCMAuthBackends(), ", "))
+	}
+	return backend(builder)
+}
+
+// ocmAuthAuto uses the tokens of the environment, then the credentials of a profile written by
+// 'osdctl auth mint-token', then the offline tokens of the secrets store and of 'ocm login'
+func ocmAuthAuto(builder *sdk.ConnectionBuilder) error {
+	if os.Getenv("OCM_TOKEN") == "" && os.Getenv("OCM_REFRESH_TOKEN") == "" {
+		if token := viper.GetString(OCMTokenConfigKey); token != "" {
+			builder.Tokens(token)
+			return nil
+		}
+		if viper.GetString(OCMClientIDConfigKey) != "" {
+			return ocmAuthClientCredentials(builder)
+		}
+	}
+	return ocmAuthOfflineToken(builder)
+}
+
+func ocmAuthOfflineToken(builder *sdk.ConnectionBuilder) error {
+	token := os.Getenv("OCM_TOKEN")
+	// Unlikely to be set, but check anyway
+	refreshToken := os.Getenv("OCM_REFRESH_TOKEN")
+
+	// Prefer a refresh token kept in the OS keyring over the plain text OCM config file
+	if token == "" && refreshToken == "" {
+		var err error
+		refreshToken, err = secrets.Lookup(secrets.OCMRefreshTokenKey)
+		if err != nil {
+			log.Warnf("Ignoring the stored OCM refresh token: %v", err)
+		}
+	}
+
+	if token == "" && refreshToken == "" {
+		config, err := loadOCMConfig()
+		if err != nil || config == nil {
+			return errors.New(ocmConfigError)
+		}
+		token = config.AccessToken
+		refreshToken = config.RefreshToken
+	}
+
+	// Can't both be empty
+	if token == "" && refreshToken == "" {
+		return errors.New(ocmConfigError)
+	}
+	for _, t := range []string{token, refreshToken} {
+		if t != "" {
+			builder.Tokens(t)
+		}
+	}
+	return nil
+}
+
+// ocmAuthClientCredentials reads the client secret from OCM_CLIENT_SECRET, the config file, then the secrets store
+func ocmAuthClientCredentials(builder *sdk.ConnectionBuilder) error {
+	clientID := viper.GetString(OCMClientIDConfigKey)
+	clientSecret := os.Getenv(OCMClientSecretEnv)
+	if clientSecret == "" {
+		clientSecret = viper.GetString(OCMClientSecretConfigKey)
+	}
+	if clientSecret == "" {
+		var err error
+		clientSecret, err = secrets.Lookup(secrets.OCMClientSecretKey)
+		if err != nil {
+			log.Warnf("Ignoring the stored OCM client secret: %v", err)
+		}
+	}
+
+	if clientID == "" || clientSecret == "" {
+		return fmt.Errorf("the '%s' OCM authentication needs '%s' in the config file and the client secret in %s, '%s' or 'osdctl secrets set %s'",
+			OCMAuthClientCredentials, OCMClientIDConfigKey, OCMClientSecretEnv, OCMClientSecretConfigKey, secrets.OCMClientSecretKey)
+	}
+	builder.Client(clientID, clientSecret)
+	return nil
+}
+
+// ocmAuthDeviceCode reuses the refresh token of the last device code login, kept in the secrets store, and logs in
+// again once it has expired
+func ocmAuthDeviceCode(builder *sdk.ConnectionBuilder) error {
+	// The tokens can only be refreshed by the client which they were issued to
+	builder.Client(ocmDeviceClientID, "")
+
+	refreshToken, err := secrets.Lookup(secrets.OCMDeviceRefreshTokenKey)
+	if err != nil {
+		log.Warnf("Ignoring the stored OCM device code refresh token: %v", err)
+	}
+	if refreshToken != "" {
+		expiry, err := TokenExpiry(refreshToken)
+		if err == nil && (expiry.IsZero() || time.Until(expiry) > time.Minute) {
+			builder.Tokens(refreshToken)
+			return nil
+		}
+	}
+
+	tokens, err := deviceCodeLogin(deadline.Context(), os.Stderr)
+	if err != nil {
+		return err
+	}
+	store, err := secrets.New()
+	if err == nil {
+		err = store.Set(secrets.OCMDeviceRefreshTokenKey, tokens.RefreshToken)
+	}
+	if err != nil {
+		log.Warnf("Cannot keep the OCM refresh token, the next command will log in again: %v", err)
+	}
+	builder.Tokens(tokens.AccessToken, tokens.RefreshToken)
+	return nil
+}
+
+// deviceTokens is the response of the token endpoint, either the tokens or an OAuth error
+type deviceTokens struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// deviceCodeLogin runs the OAuth device authorization grant: it prints the URL to approve the login at, from any
+// device, then polls the token endpoint until the login is approved
+func deviceCodeLogin(ctx context.Context, prompt io.Writer) (*deviceTokens, error) {
+	var authorization struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+	status, err := postForm(ctx, ocmDeviceAuthURL, url.Values{"client_id": {ocmDeviceClientID}, "scope": {"openid"}}, &authorization)
+	if err != nil {
+		return nil, fmt.Errorf("cannot start the OCM device code login: %w", err)
+	}
+	if status != http.StatusOK || authorization.DeviceCode == "" {
+		return nil, fmt.Errorf("cannot start the OCM device code login: %s answered %d", ocmDeviceAuthURL, status)
+	}
+
+	verification := authorization.VerificationURIComplete
+	if verification == "" {
+		verification = authorization.VerificationURI
+	}
+	fmt.Fprintf(prompt, "To log in to OCM, open %s on any device and check that the code is %s\n", verification, authorization.UserCode)
+
+	interval := time.Duration(authorization.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	expiry := time.Now().Add(time.Duration(authorization.ExpiresIn) * time.Second)
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {authorization.DeviceCode},
+		"client_id":   {ocmDeviceClientID},
+	}
+	for time.Now().Before(expiry) {
+		sleep(interval)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var tokens deviceTokens
+		status, err := postForm(ctx, ocmTokenURL, form, &tokens)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get the OCM tokens: %w", err)
+		}
+		switch {
+		case status == http.StatusOK && tokens.AccessToken != "":
+			return &tokens, nil
+		case tokens.Error == "authorization_pending":
+		case tokens.Error == "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, fmt.Errorf("the OCM device code login failed: %s %s", tokens.Error, tokens.ErrorDescription)
+		}
+	}
+	return nil, errors.New("the OCM device code login wasn't approved before the code expired")
+}
+
+// postForm posts the form and parses the JSON response, whatever its status
+func postForm(ctx context.Context, endpoint string, form url.Values, result interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return resp.StatusCode, fmt.Errorf("cannot parse the response of %s: %w", endpoint, err)
+	}
+	return resp.StatusCode, nil
+}
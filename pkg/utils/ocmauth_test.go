@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/viper"
+)
+
+// newTestSSO serves the device authorization and token endpoints, answering the polls with the given errors
+// before the tokens
+func newTestSSO(t *testing.T, pollErrors ...string) {
+	t.Helper()
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("client_id") != ocmDeviceClientID {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error": "invalid_client"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/auth/device":
+			_, _ = w.Write([]byte(`{"device_code": "device-123", "user_code": "ABCD-EFGH", "verification_uri": "https://sso.example.com/device",
+				"verification_uri_complete": "https://sso.example.com/device?user_code=ABCD-EFGH", "expires_in": 600, "interval": 5}`))
+		case "/token":
+			if r.Form.Get("device_code") != "device-123" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error": "invalid_grant"}`))
+				return
+			}
+			if polls < len(pollErrors) {
+				polls++
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error": "` + pollErrors[polls-1] + `", "error_description": "from the test"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token": "access", "refresh_token": "refresh"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	tokenURL, deviceAuthURL, realSleep := ocmTokenURL, ocmDeviceAuthURL, sleep
+	ocmTokenURL, ocmDeviceAuthURL = server.URL+"/token", server.URL+"/auth/device"
+	t.Cleanup(func() { ocmTokenURL, ocmDeviceAuthURL, sleep = tokenURL, deviceAuthURL, realSleep })
+}
+
+func TestDeviceCodeLogin(t *testing.T) {
+	newTestSSO(t, "authorization_pending", "slow_down")
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	var prompt bytes.Buffer
+	tokens, err := deviceCodeLogin(context.Background(), &prompt)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tokens.AccessToken != "access" || tokens.RefreshToken != "refresh" {
+		t.Errorf("unexpected tokens %+v", tokens)
+	}
+	if !strings.Contains(prompt.String(), "open https://sso.example.com/device?user_code=ABCD-EFGH on any device and check that the code is ABCD-EFGH") {
+		t.Errorf("unexpected prompt %q", prompt.String())
+	}
+	// slow_down adds 5 seconds to the interval
+	if len(slept) != 3 || slept[0] != 5*time.Second || slept[2] != 10*time.Second {
+		t.Errorf("unexpected polling intervals %v", slept)
+	}
+}
+
+func TestDeviceCodeLoginDenied(t *testing.T) {
+	newTestSSO(t, "access_denied")
+	sleep = func(time.Duration) {}
+
+	_, err := deviceCodeLogin(context.Background(), &bytes.Buffer{})
+	if err == nil || err.Error() != "the OCM device code login failed: access_denied from the test" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestConfigureOCMAuth(t *testing.T) {
+	defer viper.Set(OCMAuthConfigKey, "")
+	defer viper.Set(OCMClientIDConfigKey, "")
+	defer viper.Set(OCMClientSecretConfigKey, "")
+
+	viper.Set(OCMAuthConfigKey, "password")
+	err := configureOCMAuth(sdk.NewConnectionBuilder())
+	if err == nil || err.Error() != "invalid 'ocm_auth' 'password', expected one of: client-credentials, device-code, offline-token" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	viper.Set(OCMAuthConfigKey, OCMAuthClientCredentials)
+	viper.Set(OCMClientIDConfigKey, "service-account")
+	viper.Set(OCMClientSecretConfigKey, "secret")
+	if err := configureOCMAuth(sdk.NewConnectionBuilder()); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestNewConnectionNotLoggedIn(t *testing.T) {
+	t.Setenv("OCM_URL", "")
+	t.Setenv("OCM_CONFIG", t.TempDir()+"/ocm.json")
+	_, err := NewConnection()
+	if code := osdctlErrors.ExitCode(err); code != osdctlErrors.ExitForbidden {
+		t.Errorf("expected the exit code %d when not logged in, got %d for %v", osdctlErrors.ExitForbidden, code, err)
+	}
+
+	t.Setenv("OCM_URL", "prodution")
+	_, err = NewConnection()
+	if code := osdctlErrors.ExitCode(err); code != osdctlErrors.ExitValidation {
+		t.Errorf("expected the exit code %d for an invalid URL, got %d for %v", osdctlErrors.ExitValidation, code, err)
+	}
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+// OCMFeature is an OCM API that isn't deployed in every OCM environment
+type OCMFeature struct {
+	Name string
+	// Path is the metadata endpoint of the API, it returns 404 where the API isn't deployed
+	Path string
+}
+
+var (
+	AccessRequestsFeature = OCMFeature{Name: "Access requests", Path: "/api/access_transparency/v1"}
+	HCPFeature            = OCMFeature{Name: "HCP management clusters", Path: "/api/osd_fleet_mgmt/v1"}
+
+	ocmFeatures = []OCMFeature{AccessRequestsFeature, HCPFeature}
+)
+
+// ocmFeatureAvailability caches the probes by OCM URL and feature path, commands may check the same feature
+// several times
+var (
+	ocmFeatureAvailability   = map[string]bool{}
+	ocmFeatureAvailabilityMu sync.Mutex
+)
+
+// OCMFeatureForPath returns the feature an OCM API path belongs to, if it belongs to one that isn't
+// deployed everywhere
+func OCMFeatureForPath(path string) (OCMFeature, bool) {
+	for _, feature := range ocmFeatures {
+		if path == feature.Path || strings.HasPrefix(path, feature.Path+"/") {
+			return feature, true
+		}
+	}
+	return OCMFeature{}, false
+}
+
+// RequireOCMFeature returns an error when the OCM environment of the connection doesn't have the feature, so that
+// commands fail up front instead of with a 404 halfway through. Probe failures other than a 404 are ignored,
+// the command's own requests report them better.
+func RequireOCMFeature(connection *sdk.Connection, feature OCMFeature) error {
+	return requireOCMFeature(connection.URL(), GetCurrentOCMEnv(connection), feature, func(path string) (int, error) {
+		response, err := connection.Get().Path(path).Send()
+		if err != nil {
+			return 0, err
+		}
+		return response.Status(), nil
+	})
+}
+
+func requireOCMFeature(url, env string, feature OCMFeature, get func(path string) (int, error)) error {
+	key := url + feature.Path
+	ocmFeatureAvailabilityMu.Lock()
+	available, probed := ocmFeatureAvailability[key]
+	ocmFeatureAvailabilityMu.Unlock()
+
+	if !probed {
+		status, err := get(feature.Path)
+		if err != nil {
+			return nil
+		}
+		available = status != http.StatusNotFound
+		ocmFeatureAvailabilityMu.Lock()
+		ocmFeatureAvailability[key] = available
+		ocmFeatureAvailabilityMu.Unlock()
+	}
+
+	if !available {
+		return osdctlErrors.New(osdctlErrors.ErrNotFound, "%s not available in this OCM environment (%s, %s)", feature.Name, env, url)
+	}
+	return nil
+}
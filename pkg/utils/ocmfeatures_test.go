@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+)
+
+func TestOCMFeatureForPath(t *testing.T) {
+	if feature, ok := OCMFeatureForPath("/api/access_transparency/v1/access_requests"); !ok || feature != AccessRequestsFeature {
+		t.Errorf("expected the access requests feature, got %v", feature)
+	}
+	if _, ok := OCMFeatureForPath("/api/access_transparency_v2"); ok {
+		t.Error("expected no feature for a path only sharing a prefix")
+	}
+	if _, ok := OCMFeatureForPath("/api/clusters_mgmt/v1/clusters"); ok {
+		t.Error("expected no feature for clusters_mgmt")
+	}
+}
+
+func TestRequireOCMFeature(t *testing.T) {
+	testCases := []struct {
+		title       string
+		status      int
+		probeErr    error
+		errExpected bool
+	}{
+		{title: "deployed", status: http.StatusOK},
+		{title: "forbidden but deployed", status: http.StatusForbidden},
+		{title: "not deployed", status: http.StatusNotFound, errExpected: true},
+		{title: "probe failure is ignored", probeErr: errors.New("connection refused")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			url := "https://api." + tc.title
+			probes := 0
+			get := func(path string) (int, error) {
+				probes++
+				if path != HCPFeature.Path {
+					t.Errorf("unexpected probe of %s", path)
+				}
+				return tc.status, tc.probeErr
+			}
+
+			for i := 0; i < 2; i++ {
+				err := requireOCMFeature(url, "stage", HCPFeature, get)
+				if tc.errExpected != (err != nil) {
+					t.Fatalf("expected error %v, got %v", tc.errExpected, err)
+				}
+				if err != nil && !errors.Is(err, osdctlErrors.ErrNotFound) {
+					t.Errorf("expected a not found error, got %v", err)
+				}
+			}
+			if tc.probeErr == nil && probes != 1 {
+				t.Errorf("expected the probe to be cached, got %d probes", probes)
+			}
+		})
+	}
+}
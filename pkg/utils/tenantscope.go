@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/viper"
+)
+
+// TenantScopeConfigKey limits the clusters the mutating commands change, e.g. to the ones of the customer of an
+// engagement, so that the clusters of other tenants aren't changed by mistake
+const TenantScopeConfigKey = "tenant_scope"
+
+// TenantScope lists the organizations and clusters the mutating commands may change. The clusters are glob patterns
+// matching their name, ID or external ID, e.g. acme-*. The deny lists win over the allow lists, and when there is an
+// allow list, only the clusters of its organizations, or matching its patterns, may be changed.
+type TenantScope struct {
+	AllowOrganizations []string `mapstructure:"allow_organizations"`
+	DenyOrganizations  []string `mapstructure:"deny_organizations"`
+	AllowClusters      []string `mapstructure:"allow_clusters"`
+	DenyClusters       []string `mapstructure:"deny_clusters"`
+}
+
+// GetTenantScope returns the scope of the config file, nil when the mutating commands aren't scoped
+func GetTenantScope() (*TenantScope, error) {
+	if !viper.IsSet(TenantScopeConfigKey) {
+		return nil, nil
+	}
+	var scope TenantScope
+	if err := viper.UnmarshalKey(TenantScopeConfigKey, &scope); err != nil {
+		return nil, fmt.Errorf("cannot parse '%s' from the config file: %w", TenantScopeConfigKey, err)
+	}
+	for _, pattern := range append(scope.AllowClusters, scope.DenyClusters...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid cluster pattern '%s' in '%s': %w", pattern, TenantScopeConfigKey, err)
+		}
+	}
+	return &scope, nil
+}
+
+// CheckTenantScope returns an error when the scope of the config file doesn't allow changing the cluster
+func CheckTenantScope(connection *sdk.Connection, cluster *cmv1.Cluster) error {
+	scope, err := GetTenantScope()
+	if err != nil || scope == nil {
+		return err
+	}
+	// An organization that can't be read isn't allowed, but its clusters may still match a pattern
+	orgID, _ := GetOrgfromClusterID(connection, *cluster)
+	return scope.Check(orgID, cluster)
+}
+
+// Check returns an error when the cluster of the organization is out of the scope, orgID is empty when unknown
+func (s *TenantScope) Check(orgID string, cluster *cmv1.Cluster) error {
+	target := fmt.Sprintf("cluster '%s' (%s)", cluster.Name(), cluster.ID())
+	if orgID != "" && Contains(s.DenyOrganizations, orgID) {
+		return osdctlErrors.New(osdctlErrors.ErrForbidden, "%s belongs to organization %s, which '%s' denies", target, orgID, TenantScopeConfigKey)
+	}
+	if pattern := matchCluster(s.DenyClusters, cluster); pattern != "" {
+		return osdctlErrors.New(osdctlErrors.ErrForbidden, "%s matches '%s', which '%s' denies", target, pattern, TenantScopeConfigKey)
+	}
+	if len(s.AllowOrganizations) == 0 && len(s.AllowClusters) == 0 {
+		return nil
+	}
+	if orgID != "" && Contains(s.AllowOrganizations, orgID) {
+		return nil
+	}
+	if matchCluster(s.AllowClusters, cluster) != "" {
+		return nil
+	}
+	if orgID == "" {
+		orgID = "unknown"
+	}
+	return osdctlErrors.New(osdctlErrors.ErrForbidden, "%s of organization %s isn't in the '%s' of the config file, which limits the clusters osdctl changes", target, orgID, TenantScopeConfigKey)
+}
+
+// matchCluster returns the first pattern matching the name, ID or external ID of the cluster, empty when none does
+func matchCluster(patterns []string, cluster *cmv1.Cluster) string {
+	for _, pattern := range patterns {
+		for _, value := range []string{cluster.Name(), cluster.ID(), cluster.ExternalID()} {
+			if matched, _ := path.Match(pattern, value); matched && value != "" {
+				return pattern
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
+	"github.com/spf13/viper"
+)
+
+func TestTenantScopeCheck(t *testing.T) {
+	cluster, err := cmv1.NewCluster().Name("acme-prod").ID("1234").ExternalID("abcd-ef").Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		title       string
+		scope       TenantScope
+		orgID       string
+		errExpected bool
+	}{
+		{title: "no list", orgID: "org-b"},
+		{title: "allowed organization", scope: TenantScope{AllowOrganizations: []string{"org-a"}}, orgID: "org-a"},
+		{title: "other organization", scope: TenantScope{AllowOrganizations: []string{"org-a"}}, orgID: "org-b", errExpected: true},
+		{title: "unknown organization", scope: TenantScope{AllowOrganizations: []string{"org-a"}}, errExpected: true},
+		{title: "allowed cluster pattern", scope: TenantScope{AllowOrganizations: []string{"org-a"}, AllowClusters: []string{"acme-*"}}, orgID: "org-b"},
+		{title: "allowed external ID", scope: TenantScope{AllowClusters: []string{"abcd-*"}}},
+		{title: "denied organization", scope: TenantScope{DenyOrganizations: []string{"org-b"}}, orgID: "org-b", errExpected: true},
+		{title: "denied cluster wins", scope: TenantScope{AllowOrganizations: []string{"org-a"}, DenyClusters: []string{"*-prod"}}, orgID: "org-a", errExpected: true},
+		{title: "not denied", scope: TenantScope{DenyClusters: []string{"*-stage"}}, orgID: "org-a"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.scope.Check(tc.orgID, cluster)
+			if tc.errExpected && !errors.Is(err, osdctlErrors.ErrForbidden) {
+				t.Fatalf("expected a forbidden error, got %v", err)
+			}
+			if !tc.errExpected && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestGetTenantScope(t *testing.T) {
+	defer viper.Set(TenantScopeConfigKey, nil)
+
+	if scope, err := GetTenantScope(); scope != nil || err != nil {
+		t.Fatalf("expected no scope, got %v, %v", scope, err)
+	}
+
+	viper.Set(TenantScopeConfigKey, map[string]interface{}{"allow_organizations": []string{"org-a"}, "deny_clusters": []string{"*-prod"}})
+	scope, err := GetTenantScope()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scope.AllowOrganizations) != 1 || scope.DenyClusters[0] != "*-prod" {
+		t.Errorf("unexpected scope %+v", scope)
+	}
+
+	viper.Set(TenantScopeConfigKey, map[string]interface{}{"deny_clusters": []string{"["}})
+	if _, err := GetTenantScope(); err == nil {
+		t.Error("expected the invalid pattern to be rejected")
+	}
+}
+
+func TestConfirmRefusesOutOfScope(t *testing.T) {
+	outOfScope := errors.New("other tenant")
+	err := Confirm(ConfirmOptions{
+		Summary:    &ImpactSummary{ClusterName: "my-cluster", OutOfScope: outOfScope},
+		SkipPrompt: true,
+		Out:        &bytes.Buffer{},
+	})
+	if !errors.Is(err, outOfScope) {
+		t.Fatalf("expected the out of scope cluster to abort, got %v", err)
+	}
+}
@@ -4,18 +4,22 @@ import (
 	"bufio"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	sdk "github.com/openshift-online/ocm-sdk-go"
 	amv1 "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
 	cmv1 "github.com/openshift-online/ocm-sdk-go/clustersmgmt/v1"
+	"github.com/openshift/osdctl/pkg/osdctlErrors"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
 type LimitedSupportReasonItem struct {
-	ID      string
-	Summary string
-	Details string
+	ID        string
+	Summary   string
+	Details   string
+	CreatedAt time.Time
 }
 
 var clusterKeyRE = regexp.MustCompile(`^(\w|-)+$`)
@@ -26,7 +30,7 @@ func IsValidKey(clusterKey string) bool {
 
 func IsValidClusterKey(clusterKey string) (err error) {
 	if !IsValidKey(clusterKey) {
-		return fmt.Errorf(
+		return osdctlErrors.New(osdctlErrors.ErrValidation,
 			"Cluster name, identifier or external identifier '%s' isn't valid: it "+
 				"must contain only letters, digits, dashes and underscores",
 			clusterKey,
@@ -50,8 +54,21 @@ func GetCurrentOCMEnv(connection *sdk.Connection) string {
 	return currentEnv
 }
 
-// GetCluster Function allows to get a single cluster with any identifier (displayname, ID, or external ID)
-func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster, err error) {
+// GetCluster Function allows to get a single cluster with any identifier (displayname, ID, or external ID). With
+// --cached, a fresh cached cluster is returned instead.
+func GetCluster(connection *sdk.Connection, key string) (*cmv1.Cluster, error) {
+	if cluster := cachedCluster(connection, key); cluster != nil {
+		return cluster, nil
+	}
+	cluster, err := getCluster(connection, key)
+	if err != nil {
+		return nil, err
+	}
+	storeCluster(connection, cluster)
+	return cluster, nil
+}
+
+func getCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster, err error) {
 	// Prepare the resources that we will be using:
 	subsResource := connection.AccountsMgmt().V1().Subscriptions()
 	clustersResource := connection.ClustersMgmt().V1().Clusters()
@@ -67,7 +84,7 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 		Size(1).
 		Send()
 	if err != nil {
-		err = fmt.Errorf("Can't retrieve subscription for key '%s': %v", key, err)
+		err = fmt.Errorf("Can't retrieve subscription for key '%s': %w", key, err)
 		return
 	}
 
@@ -81,7 +98,7 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 				Send()
 			if err != nil {
 				err = fmt.Errorf(
-					"Can't retrieve cluster for key '%s': %v",
+					"Can't retrieve cluster for key '%s': %w",
 					key, err,
 				)
 				return
@@ -114,7 +131,7 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 		Size(1).
 		Send()
 	if err != nil {
-		err = fmt.Errorf("Can't retrieve clusters for key '%s': %v", key, err)
+		err = fmt.Errorf("Can't retrieve clusters for key '%s': %w", key, err)
 		return
 	}
 
@@ -135,13 +152,53 @@ func GetCluster(connection *sdk.Connection, key string) (cluster *cmv1.Cluster,
 	}
 
 	// If we are here then there are no subscriptions or clusters matching the passed key:
-	err = fmt.Errorf(
+	err = osdctlErrors.New(osdctlErrors.ErrNotFound,
 		"There are no subscriptions or clusters with identifier or name '%s'",
 		key,
 	)
 	return
 }
 
+// GetClusterFromSubscription looks up a cluster through its subscription regardless of the subscription
+// status, which allows targeting clusters that GetCluster can't find anymore (e.g. archived or deprovisioned).
+// If the cluster record itself can't be retrieved, a cluster containing only the IDs known to the
+// subscription is returned so that callers can still address the cluster's sub-resources.
+func GetClusterFromSubscription(connection *sdk.Connection, key string) (*cmv1.Cluster, error) {
+	subsSearch := fmt.Sprintf(
+		"external_cluster_id = '%s' or cluster_id = '%s' or display_name = '%s'",
+		key, key, key,
+	)
+	subsListResponse, err := connection.AccountsMgmt().V1().Subscriptions().List().
+		Search(subsSearch).
+		Size(2).
+		Send()
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve subscriptions for key '%s': %v", key, err)
+	}
+
+	if total := subsListResponse.Total(); total != 1 {
+		return nil, fmt.Errorf("there are %d subscriptions with cluster identifier or name '%s', expected 1", total, key)
+	}
+	subscription := subsListResponse.Items().Get(0)
+
+	clusterID, ok := subscription.GetClusterID()
+	if !ok || clusterID == "" {
+		return nil, fmt.Errorf("subscription '%s' has no cluster ID", subscription.ID())
+	}
+
+	clusterGetResponse, err := connection.ClustersMgmt().V1().Clusters().Cluster(clusterID).Get().Send()
+	if err == nil {
+		return clusterGetResponse.Body(), nil
+	}
+
+	return cmv1.NewCluster().
+		ID(clusterID).
+		ExternalID(subscription.ExternalClusterID()).
+		Name(subscription.DisplayName()).
+		Subscription(cmv1.NewSubscription().ID(subscription.ID())).
+		Build()
+}
+
 func GetClusterLimitedSupportReasons(connection *sdk.Connection, clusterID string) ([]*LimitedSupportReasonItem, error) {
 
 	limitedSupportReasons, err := connection.ClustersMgmt().V1().
@@ -160,16 +217,29 @@ func GetClusterLimitedSupportReasons(connection *sdk.Connection, clusterID strin
 
 	for _, reason := range lmtReason {
 		clusterLmtSprReason := LimitedSupportReasonItem{
-			ID:      reason.ID(),
-			Summary: reason.Summary(),
-			Details: reason.Details(),
+			ID:        reason.ID(),
+			Summary:   reason.Summary(),
+			Details:   reason.Details(),
+			CreatedAt: reason.CreationTimestamp(),
 		}
 		clusterLmtSprReasons = append(clusterLmtSprReasons, &clusterLmtSprReason)
 	}
+	SortLimitedSupportReasons(clusterLmtSprReasons)
 
 	return clusterLmtSprReasons, nil
 }
 
+// SortLimitedSupportReasons sorts the reasons oldest first, so that their 1-based index in the listings stays the
+// same until a reason is removed, and can be given to `cluster support delete --index`
+func SortLimitedSupportReasons(reasons []*LimitedSupportReasonItem) {
+	sort.SliceStable(reasons, func(i, j int) bool {
+		if !reasons[i].CreatedAt.Equal(reasons[j].CreatedAt) {
+			return reasons[i].CreatedAt.Before(reasons[j].CreatedAt)
+		}
+		return reasons[i].ID < reasons[j].ID
+	})
+}
+
 // GetSubscription Function allows to get a single subscription with any identifier (displayname, ID, internal or external ID)
 func GetSubscription(connection *sdk.Connection, key string) (subscription *amv1.Subscription, err error) {
 	// Prepare the resources that we will be using:
@@ -244,24 +314,9 @@ func GetAccount(connection *sdk.Connection, key string) (account *amv1.Account,
 	return
 }
 
+// ConfirmSend asks for a plain y/N confirmation without an impact summary
 func ConfirmSend() error {
-	fmt.Print("Continue? (y/N): ")
-
-	var response string
-	_, err := fmt.Scanln(&response)
-	if err != nil {
-		return err
-	}
-
-	switch strings.ToLower(response) {
-	case "y", "yes":
-		return nil
-	case "n", "no":
-		return fmt.Errorf("Exiting...")
-	default:
-		fmt.Println("Invalid input. Expecting (y)es or (N)o")
-		return ConfirmSend()
-	}
+	return Confirm(ConfirmOptions{})
 }
 
 // streamPrintln appends a newline then prints the given msg using the provided IOStreams
@@ -279,8 +334,12 @@ func StreamErrorln(stream genericclioptions.IOStreams, msg string) {
 	stream.ErrOut.Write([]byte(fmt.Sprintln(msg)))
 }
 
-// StreamRead retrieves input from the provided IOStreams up to (and including) the delimiter given
+// StreamRead retrieves input from the provided IOStreams up to (and including) the delimiter given. The lines
+// answering a prompt give up after the confirm timeout, see ReadAnswer.
 func StreamRead(stream genericclioptions.IOStreams, delim byte) (string, error) {
+	if delim == '\n' {
+		return ReadAnswer(stream.In)
+	}
 	reader := bufio.NewReader(stream.In)
 	return reader.ReadString(delim)
 }
@@ -1,15 +1,27 @@
 package utils
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/coreos/go-semver/semver"
 )
 
 const (
-	VersionAPIEndpoint     = "https://api.github.com/repos/openshift/osdctl/releases/latest"
-	VersionAddressTemplate = "https://github.com/openshift/osdctl/releases/download/v%s/osdctl_%s_%s_%s.tar.gz" // version, version, GOOS, GOARCH
+	VersionAPIEndpoint      = "https://api.github.com/repos/openshift/osdctl/releases/latest"
+	ReleasesAPIEndpoint     = "https://api.github.com/repos/openshift/osdctl/releases"
+	VersionAddressTemplate  = "https://github.com/openshift/osdctl/releases/download/v%s/osdctl_%s_%s_%s.tar.gz" // version, version, GOOS, GOARCH
+	ChecksumAddressTemplate = "https://github.com/openshift/osdctl/releases/download/v%s/sha256sum.txt"          // version
+
+	// StableChannel only has the releases GitHub marks as latest, CandidateChannel has the pre-releases as well
+	StableChannel    = "stable"
+	CandidateChannel = "candidate"
 )
 
 var (
@@ -27,7 +39,9 @@ var (
 // githubResponse is a necessary struct for the JSON unmarshalling that is happening
 // in the getLatestVersion().
 type gitHubResponse struct {
-	TagName string `json:"tag_name"`
+	TagName    string `json:"tag_name"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
 }
 
 // getLatestVersion connects to the GitHub API and returns the latest osdctl tag name
@@ -61,3 +75,72 @@ func GetLatestVersion() (latest string, err error) {
 
 	return githubResp.TagName, nil
 }
+
+// GetLatestVersionForChannel returns the latest osdctl tag name of the release channel
+func GetLatestVersionForChannel(channel string) (string, error) {
+	switch channel {
+	case StableChannel:
+		return GetLatestVersion()
+	case CandidateChannel:
+	default:
+		return "", fmt.Errorf("unknown release channel '%s', expected %s or %s", channel, StableChannel, CandidateChannel)
+	}
+
+	client := http.Client{
+		Timeout: time.Second * 10,
+	}
+
+	res, err := client.Get(ReleasesAPIEndpoint)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var releases []gitHubResponse
+	if err := json.Unmarshal(body, &releases); err != nil {
+		return "", err
+	}
+	return latestRelease(releases)
+}
+
+// latestRelease returns the tag name of the highest published release, pre-releases included
+func latestRelease(releases []gitHubResponse) (string, error) {
+	var latest string
+	var latestSemVer *semver.Version
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		version, err := semver.NewVersion(strings.TrimPrefix(release.TagName, "v"))
+		if err != nil {
+			continue
+		}
+		if latestSemVer == nil || latestSemVer.LessThan(*version) {
+			latest, latestSemVer = release.TagName, version
+		}
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no release found")
+	}
+	return latest, nil
+}
+
+// ChecksumForFile returns the sha256 of the file from the checksums of a release, in the sha256sum format
+func ChecksumForFile(checksums []byte, filename string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == filename {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("no checksum for %s in the release", filename)
+}
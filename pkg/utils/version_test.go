@@ -0,0 +1,41 @@
+package utils
+
+import "testing"
+
+func TestLatestRelease(t *testing.T) {
+	latest, err := latestRelease([]gitHubResponse{
+		{TagName: "v0.19.0"},
+		{TagName: "v0.21.0", Draft: true},
+		{TagName: "v0.20.0-rc.1", Prerelease: true},
+		{TagName: "v0.20.0-rc.2", Prerelease: true},
+		{TagName: "not-a-version"},
+		{TagName: "v0.19.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != "v0.20.0-rc.2" {
+		t.Errorf("expected v0.20.0-rc.2, got %s", latest)
+	}
+
+	if _, err := latestRelease([]gitHubResponse{{TagName: "v1.0.0", Draft: true}}); err == nil {
+		t.Error("expected an error without published releases")
+	}
+}
+
+func TestChecksumForFile(t *testing.T) {
+	checksums := []byte(`0123abcd  osdctl_0.20.0_Darwin_arm64.tar.gz
+4567ef01  osdctl_0.20.0_Linux_x86_64.tar.gz
+`)
+	sum, err := ChecksumForFile(checksums, "osdctl_0.20.0_Linux_x86_64.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum != "4567ef01" {
+		t.Errorf("expected 4567ef01, got %s", sum)
+	}
+
+	if _, err := ChecksumForFile(checksums, "osdctl_0.20.0_Linux_arm64.tar.gz"); err == nil {
+		t.Error("expected an error for a file without checksum")
+	}
+}
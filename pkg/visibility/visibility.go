@@ -0,0 +1,249 @@
+// Package visibility hides from the help and the shell completion the commands that the OCM roles and capabilities
+// of the user don't allow, so that new team members aren't presented with commands they cannot run
+package visibility
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	sdk "github.com/openshift-online/ocm-sdk-go"
+	"github.com/openshift/osdctl/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigKey lists the commands only some OCM roles or capabilities can run
+	ConfigKey = "command_visibility"
+	// ShowAllConfigKey shows every command, whatever the OCM roles and capabilities, when true
+	ShowAllConfigKey = "show_all_commands"
+	ShowAllFlag      = "show-all"
+
+	// The roles and capabilities are cached, the help and the completion would otherwise call OCM every time
+	identityCacheTTL      = time.Hour
+	identityCacheFileName = "identity.json"
+)
+
+var (
+	// Swapped in tests
+	currentIdentity           = cachedIdentity
+	hiddenOutput    io.Writer = os.Stderr
+)
+
+// Rule restricts a command, and all its subcommands, to the users with one of the roles or capabilities
+type Rule struct {
+	// Command is the full command path, e.g. 'osdctl cluster transfer-owner'
+	Command string   `mapstructure:"command"`
+	Roles   []string `mapstructure:"roles"`
+	// Capabilities are 'name=value', or just the name for the capabilities set to true
+	Capabilities []string `mapstructure:"capabilities"`
+}
+
+// Identity is what the user is allowed to run as, in the environment of the OCM URL
+type Identity struct {
+	OCMURL       string    `json:"ocm_url"`
+	Roles        []string  `json:"roles"`
+	Capabilities []string  `json:"capabilities"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// AddFlags adds the --show-all flag to the given command and binds it to the config key
+func AddFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(ShowAllFlag, false, "Show the commands that your OCM roles don't allow in the help and the completion (config key: "+ShowAllConfigKey+")")
+	_ = viper.BindPFlag(ShowAllConfigKey, cmd.PersistentFlags().Lookup(ShowAllFlag))
+}
+
+// Install hides the commands the user can't run before the help of any command is printed, and says how many
+// subcommands were hidden
+func Install(root *cobra.Command) {
+	defaultHelp := root.HelpFunc()
+	root.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		hidden := Apply(cmd.Root())
+		defaultHelp(cmd, args)
+		count := 0
+		for _, hiddenCmd := range hidden {
+			if hiddenCmd.Parent() == cmd {
+				count++
+			}
+		}
+		if count > 0 {
+			fmt.Fprintf(hiddenOutput, "%d command(s) your OCM roles don't allow are hidden, --%s lists them\n", count, ShowAllFlag)
+		}
+	})
+}
+
+// HideForCompletion hides the commands the user can't run when cmd is the shell completion request, before it
+// lists the commands. The flags of the completed command line aren't parsed, --show-all is looked up in the args.
+func HideForCompletion(cmd *cobra.Command, args []string) {
+	if cmd.Name() != cobra.ShellCompRequestCmd && cmd.Name() != cobra.ShellCompNoDescRequestCmd {
+		return
+	}
+	for _, arg := range args {
+		if arg == "--"+ShowAllFlag {
+			return
+		}
+	}
+	Apply(cmd.Root())
+}
+
+// Apply hides the commands below root that the user can't run and returns them. Every command is shown with
+// --show-all, when no rule is configured, or when the roles of the user can't be read from OCM.
+func Apply(root *cobra.Command) []*cobra.Command {
+	if viper.GetBool(ShowAllConfigKey) {
+		return nil
+	}
+	var rules []Rule
+	if err := viper.UnmarshalKey(ConfigKey, &rules); err != nil {
+		log.Warnf("Ignoring '%s' from the config file: %v", ConfigKey, err)
+		return nil
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	identity, err := currentIdentity()
+	if err != nil {
+		log.Debugf("Showing every command, the OCM roles can't be read: %v", err)
+		return nil
+	}
+	return hide(root, rules, identity)
+}
+
+func hide(root *cobra.Command, rules []Rule, identity *Identity) []*cobra.Command {
+	var hidden []*cobra.Command
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, child := range cmd.Commands() {
+			if rule := ruleFor(rules, child.CommandPath()); rule != nil && !child.Hidden && !identity.allows(*rule) {
+				// The subcommands aren't listed once their parent is hidden
+				child.Hidden = true
+				hidden = append(hidden, child)
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(root)
+	return hidden
+}
+
+func ruleFor(rules []Rule, commandPath string) *Rule {
+	for i := range rules {
+		if rules[i].Command == commandPath {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// allows returns whether the identity has one of the roles or capabilities of the rule. A rule without any
+// doesn't restrict its command.
+func (i *Identity) allows(rule Rule) bool {
+	if len(rule.Roles) == 0 && len(rule.Capabilities) == 0 {
+		return true
+	}
+	for _, role := range rule.Roles {
+		for _, userRole := range i.Roles {
+			if role == userRole {
+				return true
+			}
+		}
+	}
+	for _, capability := range rule.Capabilities {
+		for _, userCapability := range i.Capabilities {
+			if capability == userCapability || capability+"=true" == userCapability {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cachedIdentity returns the roles and capabilities of the current OCM account, from the cache when they were
+// fetched from the same OCM environment within the last hour
+func cachedIdentity() (*Identity, error) {
+	connection, err := utils.NewConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer connection.Close()
+
+	path, err := identityCachePath()
+	if err != nil {
+		return nil, err
+	}
+	if identity, err := loadIdentity(path); err == nil && identity.OCMURL == connection.URL() && time.Since(identity.FetchedAt) < identityCacheTTL {
+		return identity, nil
+	}
+
+	identity, err := fetchIdentity(connection)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveIdentity(path, identity); err != nil {
+		log.Debugf("Cannot cache the OCM roles: %v", err)
+	}
+	return identity, nil
+}
+
+// fetchIdentity reads the capabilities and the role bindings of the current OCM account
+func fetchIdentity(connection *sdk.Connection) (*Identity, error) {
+	response, err := connection.AccountsMgmt().V1().CurrentAccount().Get().Parameter("fetchCapabilities", true).Send()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the current account: %w", err)
+	}
+	account := response.Body()
+	identity := &Identity{OCMURL: connection.URL(), Roles: []string{}, Capabilities: []string{}, FetchedAt: time.Now()}
+	for _, capability := range account.Capabilities() {
+		identity.Capabilities = append(identity.Capabilities, fmt.Sprintf("%s=%s", capability.Name(), capability.Value()))
+	}
+
+	bindings, err := connection.AccountsMgmt().V1().RoleBindings().List().
+		Parameter("search", fmt.Sprintf("account_id = '%s'", account.ID())).Size(100).Send()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the role bindings: %w", err)
+	}
+	for _, binding := range bindings.Items().Slice() {
+		role := binding.RoleID()
+		if role == "" {
+			role = binding.Role().ID()
+		}
+		identity.Roles = append(identity.Roles, role)
+	}
+	return identity, nil
+}
+
+func identityCachePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "osdctl", identityCacheFileName), nil
+}
+
+func loadIdentity(path string) (*Identity, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- path is derived from the user cache dir
+	if err != nil {
+		return nil, err
+	}
+	identity := &Identity{}
+	if err := json.Unmarshal(data, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func saveIdentity(path string, identity *Identity) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(identity)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,179 @@
+package visibility
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// newTestRoot builds 'osdctl cluster transfer-owner', 'osdctl cluster list' and 'osdctl promote saas'
+func newTestRoot() *cobra.Command {
+	root := &cobra.Command{Use: "osdctl"}
+	AddFlags(root)
+	cluster := &cobra.Command{Use: "cluster", Run: func(*cobra.Command, []string) {}}
+	cluster.AddCommand(&cobra.Command{Use: "transfer-owner", Run: func(*cobra.Command, []string) {}})
+	cluster.AddCommand(&cobra.Command{Use: "list", Run: func(*cobra.Command, []string) {}})
+	promote := &cobra.Command{Use: "promote"}
+	promote.AddCommand(&cobra.Command{Use: "saas", Run: func(*cobra.Command, []string) {}})
+	root.AddCommand(cluster, promote)
+	return root
+}
+
+func setupVisibility(t *testing.T, identity *Identity, err error) {
+	viper.Set(ConfigKey, []map[string]interface{}{
+		{"command": "osdctl cluster transfer-owner", "roles": []string{"RegionLead"}},
+		{"command": "osdctl promote", "capabilities": []string{"capability.account.promote"}},
+	})
+	currentIdentity = func() (*Identity, error) { return identity, err }
+	t.Cleanup(func() {
+		viper.Set(ConfigKey, nil)
+		viper.Set(ShowAllConfigKey, false)
+		currentIdentity = cachedIdentity
+	})
+}
+
+func hiddenPaths(hidden []*cobra.Command) []string {
+	paths := []string{}
+	for _, cmd := range hidden {
+		paths = append(paths, cmd.CommandPath())
+	}
+	return paths
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *Identity
+		err      error
+		showAll  bool
+		expected []string
+	}{
+		{
+			name:     "hides the commands of the roles and capabilities the user doesn't have",
+			identity: &Identity{Roles: []string{"SREPLayeredProductAdmin"}, Capabilities: []string{"capability.account.promote=false"}},
+			expected: []string{"osdctl cluster transfer-owner", "osdctl promote"},
+		},
+		{
+			name:     "shows the commands of the roles and capabilities the user has",
+			identity: &Identity{Roles: []string{"RegionLead"}, Capabilities: []string{"capability.account.promote=true"}},
+			expected: []string{},
+		},
+		{
+			name:     "shows every command with --show-all",
+			identity: &Identity{},
+			showAll:  true,
+			expected: []string{},
+		},
+		{
+			name:     "shows every command when the roles can't be read",
+			err:      errors.New("not logged in"),
+			expected: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupVisibility(t, tt.identity, tt.err)
+			viper.Set(ShowAllConfigKey, tt.showAll)
+
+			root := newTestRoot()
+			paths := hiddenPaths(Apply(root))
+			if strings.Join(paths, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("expected %v to be hidden, got %v", tt.expected, paths)
+			}
+			for _, cmd := range root.Commands() {
+				for _, child := range cmd.Commands() {
+					if child.Name() == "list" && child.Hidden {
+						t.Error("expected 'osdctl cluster list' to be shown")
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestApplyWithoutRules(t *testing.T) {
+	currentIdentity = func() (*Identity, error) {
+		t.Fatal("OCM shouldn't be called without rules")
+		return nil, nil
+	}
+	defer func() { currentIdentity = cachedIdentity }()
+
+	if hidden := Apply(newTestRoot()); len(hidden) != 0 {
+		t.Errorf("expected no command to be hidden, got %v", hiddenPaths(hidden))
+	}
+}
+
+func TestInstallHelp(t *testing.T) {
+	setupVisibility(t, &Identity{}, nil)
+	var stderr bytes.Buffer
+	previousOutput := hiddenOutput
+	hiddenOutput = &stderr
+	defer func() { hiddenOutput = previousOutput }()
+
+	root := newTestRoot()
+	Install(root)
+	var stdout bytes.Buffer
+	root.SetOut(&stdout)
+	root.SetArgs([]string{"cluster", "--help"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stdout.String(), "transfer-owner") {
+		t.Errorf("expected transfer-owner to be hidden from the help, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "list") {
+		t.Errorf("expected list in the help, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "1 command(s) your OCM roles don't allow are hidden") {
+		t.Errorf("expected the hidden commands to be counted, got %q", stderr.String())
+	}
+}
+
+func TestHideForCompletion(t *testing.T) {
+	setupVisibility(t, &Identity{}, nil)
+
+	root := newTestRoot()
+	var stdout bytes.Buffer
+	root.SetOut(&stdout)
+	root.PersistentPreRun = HideForCompletion
+	root.SetArgs([]string{cobra.ShellCompNoDescRequestCmd, "cluster", ""})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(stdout.String(), "transfer-owner") || !strings.Contains(stdout.String(), "list") {
+		t.Errorf("expected only list to be completed, got:\n%s", stdout.String())
+	}
+
+	root = newTestRoot()
+	stdout.Reset()
+	root.SetOut(&stdout)
+	root.PersistentPreRun = HideForCompletion
+	root.SetArgs([]string{cobra.ShellCompNoDescRequestCmd, "--show-all", "cluster", ""})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "transfer-owner") {
+		t.Errorf("expected transfer-owner to be completed with --show-all, got:\n%s", stdout.String())
+	}
+}
+
+func TestIdentityCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "osdctl", identityCacheFileName)
+	identity := &Identity{OCMURL: "https://api.openshift.com", Roles: []string{"RegionLead"}, Capabilities: []string{}, FetchedAt: time.Now().UTC().Truncate(time.Second)}
+	if err := saveIdentity(path, identity); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadIdentity(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.OCMURL != identity.OCMURL || loaded.Roles[0] != "RegionLead" || !loaded.FetchedAt.Equal(identity.FetchedAt) {
+		t.Errorf("expected %+v, got %+v", identity, loaded)
+	}
+}
@@ -0,0 +1,274 @@
+// Package workflow parses the workflows encoding an SOP as a sequence of osdctl commands, e.g. verify the egress,
+// then remove the limited support, then post a service log, with the dependencies between the steps, their
+// confirmation policy, and the readiness checks gating them.
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openshift/osdctl/pkg/alias"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConfirmMutating asks before the step when its command changes something, the default
+	ConfirmMutating = "mutating"
+	// ConfirmAlways asks before the step, even when its command is read-only
+	ConfirmAlways = "always"
+	// ConfirmNever runs the step without asking, the command may still ask on its own
+	ConfirmNever = "never"
+
+	defaultCheckTimeout  = 10 * time.Minute
+	defaultCheckInterval = 30 * time.Second
+)
+
+var (
+	stepName    = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	placeholder = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+)
+
+// Workflow is a sequence of osdctl commands run from a single entry point
+type Workflow struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Params      []Param `json:"params,omitempty"`
+	Steps       []Step  `json:"steps"`
+}
+
+// Param is a parameter of the workflow, substituted for ${NAME} in the commands of the steps
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Step runs an osdctl command, without the leading osdctl, once the steps it needs succeeded and its check passed
+type Step struct {
+	Name  string   `json:"name"`
+	Run   string   `json:"run,omitempty"`
+	Needs []string `json:"needs,omitempty"`
+	// Confirm is the confirmation policy of the step, ConfirmMutating when empty
+	Confirm string `json:"confirm,omitempty"`
+	Check   *Check `json:"check,omitempty"`
+}
+
+// Check is a read-only osdctl command gating a step: it runs until it succeeds, and its output contains Contains
+// when set, or the timeout expires
+type Check struct {
+	Run      string `json:"run"`
+	Contains string `json:"contains,omitempty"`
+	// Timeout and Interval are durations like 10m, 10m and 30s when empty
+	Timeout  string `json:"timeout,omitempty"`
+	Interval string `json:"interval,omitempty"`
+}
+
+// Load reads and validates the workflow of a YAML file
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow %s: %w", path, err)
+	}
+	return w, nil
+}
+
+// Parse parses and validates a workflow
+func Parse(data []byte) (*Workflow, error) {
+	var w Workflow
+	if err := yaml.UnmarshalStrict(data, &w); err != nil {
+		return nil, err
+	}
+	if err := w.Validate(); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Validate checks the steps are named uniquely, only need steps of the workflow, without cycles, and that their
+// commands only use declared parameters
+func (w *Workflow) Validate() error {
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("the workflow has no step")
+	}
+	params := map[string]bool{}
+	for _, param := range w.Params {
+		if !stepName.MatchString(param.Name) {
+			return fmt.Errorf("invalid parameter name '%s'", param.Name)
+		}
+		if params[param.Name] {
+			return fmt.Errorf("parameter '%s' is declared twice", param.Name)
+		}
+		params[param.Name] = true
+	}
+
+	steps := map[string]bool{}
+	for _, step := range w.Steps {
+		if !stepName.MatchString(step.Name) {
+			return fmt.Errorf("invalid step name '%s', expected letters, digits, - and _", step.Name)
+		}
+		if steps[step.Name] {
+			return fmt.Errorf("step '%s' is declared twice", step.Name)
+		}
+		steps[step.Name] = true
+	}
+
+	for _, step := range w.Steps {
+		if step.Run == "" && step.Check == nil {
+			return fmt.Errorf("step '%s' has neither a command to run nor a check", step.Name)
+		}
+		switch step.Confirm {
+		case "", ConfirmMutating, ConfirmAlways, ConfirmNever:
+		default:
+			return fmt.Errorf("step '%s': invalid confirm '%s', expected %s, %s or %s", step.Name, step.Confirm, ConfirmMutating, ConfirmAlways, ConfirmNever)
+		}
+		for _, need := range step.Needs {
+			if !steps[need] {
+				return fmt.Errorf("step '%s' needs step '%s', which doesn't exist", step.Name, need)
+			}
+		}
+		commands := []string{step.Run}
+		if step.Check != nil {
+			if step.Check.Run == "" {
+				return fmt.Errorf("step '%s': the check has no command to run", step.Name)
+			}
+			if _, _, err := step.Check.durations(); err != nil {
+				return fmt.Errorf("step '%s': %w", step.Name, err)
+			}
+			commands = append(commands, step.Check.Run)
+		}
+		for _, command := range commands {
+			for _, match := range placeholder.FindAllStringSubmatch(command, -1) {
+				if !params[match[1]] {
+					return fmt.Errorf("step '%s' uses ${%s}, which isn't a parameter of the workflow", step.Name, match[1])
+				}
+			}
+		}
+	}
+
+	_, err := w.Order()
+	return err
+}
+
+// Order returns the steps in the order they run: after the steps they need, otherwise in the order of the file
+func (w *Workflow) Order() ([]Step, error) {
+	index := map[string]int{}
+	for i, step := range w.Steps {
+		index[step.Name] = i
+	}
+	pending := map[int]int{}
+	dependents := map[int][]int{}
+	for i, step := range w.Steps {
+		pending[i] = len(step.Needs)
+		for _, need := range step.Needs {
+			dependents[index[need]] = append(dependents[index[need]], i)
+		}
+	}
+
+	var ready []int
+	for i := range w.Steps {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	ordered := make([]Step, 0, len(w.Steps))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		next := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, w.Steps[next])
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	if len(ordered) != len(w.Steps) {
+		var cycle []string
+		for i, step := range w.Steps {
+			if pending[i] > 0 {
+				cycle = append(cycle, step.Name)
+			}
+		}
+		return nil, fmt.Errorf("the steps %s need each other", strings.Join(cycle, ", "))
+	}
+	return ordered, nil
+}
+
+// Values returns the value of every parameter, from the given ones or the defaults
+func (w *Workflow) Values(given map[string]string) (map[string]string, error) {
+	values := map[string]string{}
+	declared := map[string]bool{}
+	var missing []string
+	for _, param := range w.Params {
+		declared[param.Name] = true
+		value, ok := given[param.Name]
+		if !ok {
+			value = param.Default
+		}
+		if param.Required && value == "" {
+			missing = append(missing, param.Name)
+		}
+		values[param.Name] = value
+	}
+	for name := range given {
+		if !declared[name] {
+			return nil, fmt.Errorf("'%s' isn't a parameter of the workflow", name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing the required parameters %s, set them with --param NAME=VALUE", strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+// Expand splits a command of the workflow into the arguments of osdctl, and substitutes the parameters. The
+// parameters are substituted after splitting, so that a value with spaces stays a single argument.
+func Expand(command string, values map[string]string) ([]string, error) {
+	words, err := alias.Split(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) > 0 && words[0] == "osdctl" {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	for i, word := range words {
+		words[i] = placeholder.ReplaceAllStringFunc(word, func(match string) string {
+			return values[match[2:len(match)-1]]
+		})
+	}
+	return words, nil
+}
+
+// Durations returns the timeout and interval of the check, with the defaults
+func (c *Check) Durations() (timeout, interval time.Duration) {
+	timeout, interval, _ = c.durations()
+	return timeout, interval
+}
+
+func (c *Check) durations() (timeout, interval time.Duration, err error) {
+	timeout, interval = defaultCheckTimeout, defaultCheckInterval
+	if c.Timeout != "" {
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil || timeout <= 0 {
+			return 0, 0, fmt.Errorf("invalid check timeout '%s'", c.Timeout)
+		}
+	}
+	if c.Interval != "" {
+		if interval, err = time.ParseDuration(c.Interval); err != nil || interval <= 0 {
+			return 0, 0, fmt.Errorf("invalid check interval '%s'", c.Interval)
+		}
+	}
+	return timeout, interval, nil
+}
@@ -0,0 +1,112 @@
+package workflow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sop = `
+name: lift-egress-limited-support
+params:
+- name: CLUSTER_ID
+  required: true
+- name: TEMPLATE
+  default: https://example.com/egress-restored.json
+steps:
+- name: post-service-log
+  needs: [remove-limited-support]
+  run: servicelog post ${CLUSTER_ID} -t ${TEMPLATE}
+  confirm: always
+- name: verify-egress
+  run: network verify-egress --cluster-id ${CLUSTER_ID}
+- name: remove-limited-support
+  needs: [verify-egress]
+  run: cluster support delete ${CLUSTER_ID} --all
+  check:
+    run: cluster health ${CLUSTER_ID}
+    contains: healthy
+    timeout: 15m
+`
+
+func TestParseAndOrder(t *testing.T) {
+	w, err := Parse([]byte(sop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ordered, err := w.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, step := range ordered {
+		names = append(names, step.Name)
+	}
+	if expected := []string{"verify-egress", "remove-limited-support", "post-service-log"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the order %v, got %v", expected, names)
+	}
+
+	timeout, interval := ordered[1].Check.Durations()
+	if timeout != 15*time.Minute || interval != defaultCheckInterval {
+		t.Errorf("unexpected check durations %s, %s", timeout, interval)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		title    string
+		workflow string
+		expected string
+	}{
+		{"no step", "name: empty", "no step"},
+		{"unknown field", "steps:\n- name: a\n  run: cluster list\n  retries: 3", "unknown field"},
+		{"duplicate step", "steps:\n- name: a\n  run: cluster list\n- name: a\n  run: cluster list", "declared twice"},
+		{"unknown need", "steps:\n- name: a\n  run: cluster list\n  needs: [b]", "doesn't exist"},
+		{"cycle", "steps:\n- name: a\n  run: cluster list\n  needs: [b]\n- name: b\n  run: cluster list\n  needs: [a]\n- name: c\n  run: cluster list", "the steps a, b need each other"},
+		{"nothing to run", "steps:\n- name: a", "neither"},
+		{"invalid confirm", "steps:\n- name: a\n  run: cluster list\n  confirm: sometimes", "invalid confirm"},
+		{"undeclared parameter", "steps:\n- name: a\n  run: cluster describe ${CLUSTER_ID}", "isn't a parameter"},
+		{"invalid timeout", "steps:\n- name: a\n  check:\n    run: cluster list\n    timeout: soon", "invalid check timeout"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := Parse([]byte(tc.workflow))
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected an error containing '%s', got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestValues(t *testing.T) {
+	w, err := Parse([]byte(sop))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := w.Values(map[string]string{"CLUSTER_ID": "abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["TEMPLATE"] != "https://example.com/egress-restored.json" {
+		t.Errorf("expected the default template, got %v", values)
+	}
+	if _, err := w.Values(map[string]string{}); err == nil || !strings.Contains(err.Error(), "CLUSTER_ID") {
+		t.Errorf("expected the required parameter to be missing, got %v", err)
+	}
+	if _, err := w.Values(map[string]string{"CLUSTER_ID": "abc", "CLUSTER": "abc"}); err == nil {
+		t.Error("expected an unknown parameter to be rejected")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	args, err := Expand(`osdctl servicelog post ${CLUSTER_ID} -p 'REASON=${REASON}'`, map[string]string{"CLUSTER_ID": "abc", "REASON": "egress restored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"servicelog", "post", "abc", "-p", "REASON=egress restored"}; !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %q, got %q", expected, args)
+	}
+	if _, err := Expand("osdctl", nil); err == nil {
+		t.Error("expected an empty command to be rejected")
+	}
+}